@@ -53,6 +53,7 @@ const (
 	EnvHTTPProxy             = "VAULT_HTTP_PROXY"
 	EnvVaultProxyAddr        = "VAULT_PROXY_ADDR"
 	EnvVaultDisableRedirects = "VAULT_DISABLE_REDIRECTS"
+	EnvVaultReplayProtection = "VAULT_REPLAY_PROTECTION"
 	HeaderIndex              = "X-Vault-Index"
 	HeaderForward            = "X-Vault-Forward"
 	HeaderInconsistent       = "X-Vault-Inconsistent"
@@ -208,6 +209,12 @@ type Config struct {
 	// commands such as 'vault operator raft snapshot' as this redirects to the
 	// primary node.
 	DisableRedirects bool
+
+	// ReplayProtection when set to true, will make the client sign, timestamp
+	// and add a nonce to the requests submitting unseal, rekey and generate
+	// root key shares, as required by listeners with replay protection
+	// enabled.
+	ReplayProtection bool
 	clientTLSConfig  *tls.Config
 }
 
@@ -388,6 +395,7 @@ func (c *Config) ReadEnvironment() error {
 	var limit *rate.Limiter
 	var envVaultProxy string
 	var envVaultDisableRedirects bool
+	var envVaultReplayProtection bool
 
 	// Parse the environment variables
 	if v := os.Getenv(EnvVaultAddress); v != "" {
@@ -470,6 +478,16 @@ func (c *Config) ReadEnvironment() error {
 		c.DisableRedirects = envVaultDisableRedirects
 	}
 
+	if v := os.Getenv(EnvVaultReplayProtection); v != "" {
+		var err error
+		envVaultReplayProtection, err = strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("could not parse %s", EnvVaultReplayProtection)
+		}
+
+		c.ReplayProtection = envVaultReplayProtection
+	}
+
 	// Configure the HTTP clients TLS configuration.
 	t := &TLSConfig{
 		CACert:        envCACert,
@@ -687,6 +705,7 @@ func (c *Client) CloneConfig() *Config {
 	newConfig.CloneHeaders = c.config.CloneHeaders
 	newConfig.CloneToken = c.config.CloneToken
 	newConfig.ReadYourWrites = c.config.ReadYourWrites
+	newConfig.ReplayProtection = c.config.ReplayProtection
 	newConfig.clientTLSConfig = c.config.clientTLSConfig
 
 	// we specifically want a _copy_ of the client here, not a pointer to the original one
@@ -1245,21 +1264,22 @@ func (c *Client) clone(cloneHeaders bool) (*Client, error) {
 	config := c.config
 
 	newConfig := &Config{
-		Address:        config.Address,
-		HttpClient:     config.HttpClient,
-		MinRetryWait:   config.MinRetryWait,
-		MaxRetryWait:   config.MaxRetryWait,
-		MaxRetries:     config.MaxRetries,
-		Timeout:        config.Timeout,
-		Backoff:        config.Backoff,
-		CheckRetry:     config.CheckRetry,
-		Logger:         config.Logger,
-		Limiter:        config.Limiter,
-		AgentAddress:   config.AgentAddress,
-		SRVLookup:      config.SRVLookup,
-		CloneHeaders:   config.CloneHeaders,
-		CloneToken:     config.CloneToken,
-		ReadYourWrites: config.ReadYourWrites,
+		Address:          config.Address,
+		HttpClient:       config.HttpClient,
+		MinRetryWait:     config.MinRetryWait,
+		MaxRetryWait:     config.MaxRetryWait,
+		MaxRetries:       config.MaxRetries,
+		Timeout:          config.Timeout,
+		Backoff:          config.Backoff,
+		CheckRetry:       config.CheckRetry,
+		Logger:           config.Logger,
+		Limiter:          config.Limiter,
+		AgentAddress:     config.AgentAddress,
+		SRVLookup:        config.SRVLookup,
+		CloneHeaders:     config.CloneHeaders,
+		CloneToken:       config.CloneToken,
+		ReadYourWrites:   config.ReadYourWrites,
+		ReplayProtection: config.ReplayProtection,
 	}
 
 	if config.CloneTLSConfig {
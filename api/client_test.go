@@ -391,6 +391,7 @@ func TestClientEnvSettings(t *testing.T) {
 	oldSkipVerify := os.Getenv(EnvVaultSkipVerify)
 	oldMaxRetries := os.Getenv(EnvVaultMaxRetries)
 	oldDisableRedirects := os.Getenv(EnvVaultDisableRedirects)
+	oldReplayProtection := os.Getenv(EnvVaultReplayProtection)
 
 	os.Setenv(EnvVaultCACert, cwd+"/test-fixtures/keys/cert.pem")
 	os.Setenv(EnvVaultCACertBytes, string(caCertBytes))
@@ -400,6 +401,7 @@ func TestClientEnvSettings(t *testing.T) {
 	os.Setenv(EnvVaultSkipVerify, "true")
 	os.Setenv(EnvVaultMaxRetries, "5")
 	os.Setenv(EnvVaultDisableRedirects, "true")
+	os.Setenv(EnvVaultReplayProtection, "true")
 
 	defer func() {
 		os.Setenv(EnvVaultCACert, oldCACert)
@@ -410,6 +412,7 @@ func TestClientEnvSettings(t *testing.T) {
 		os.Setenv(EnvVaultSkipVerify, oldSkipVerify)
 		os.Setenv(EnvVaultMaxRetries, oldMaxRetries)
 		os.Setenv(EnvVaultDisableRedirects, oldDisableRedirects)
+		os.Setenv(EnvVaultReplayProtection, oldReplayProtection)
 	}()
 
 	config := DefaultConfig()
@@ -430,6 +433,9 @@ func TestClientEnvSettings(t *testing.T) {
 	if config.DisableRedirects != true {
 		t.Fatalf("bad: expected disable redirects to be true: %v", config.DisableRedirects)
 	}
+	if config.ReplayProtection != true {
+		t.Fatalf("bad: expected replay protection to be true: %v", config.ReplayProtection)
+	}
 }
 
 func TestClientDeprecatedEnvSettings(t *testing.T) {
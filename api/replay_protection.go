@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// ReplayProtectionTimestampHeader holds the Unix time, in seconds, at
+	// which a request submitting a key share was made.
+	ReplayProtectionTimestampHeader = "X-Vault-Request-Timestamp"
+
+	// ReplayProtectionNonceHeader holds a value unique to a request
+	// submitting a key share.
+	ReplayProtectionNonceHeader = "X-Vault-Request-Nonce"
+
+	// ReplayProtectionSignatureHeader holds the signature of a request
+	// submitting a key share, as returned by ReplayProtectionSignature.
+	ReplayProtectionSignatureHeader = "X-Vault-Request-Signature"
+)
+
+// ReplayProtectionSignature returns the hex-encoded HMAC-SHA256, keyed with
+// the submitted key share, of the timestamp, nonce and URL path of a request
+// submitting that share. Listeners with replay protection enabled require it
+// so that the headers of a request cannot be changed without knowing the
+// share. As the share is sent in the same request, the signature does not
+// authenticate the request: anyone may sign a request with a share of their
+// own, or sign a request they can read again. Replay protection only blocks
+// byte-for-byte replays.
+func ReplayProtectionSignature(key, timestamp, nonce, path string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + "\n" + nonce + "\n" + path))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// setReplayProtectionHeaders adds the replay protection headers to a request
+// submitting the key share key, if replay protection is enabled. No signature
+// is added if no key is submitted.
+func (c *Client) setReplayProtectionHeaders(r *Request, key string) error {
+	c.config.modifyLock.RLock()
+	enabled := c.config.ReplayProtection
+	c.config.modifyLock.RUnlock()
+	if !enabled {
+		return nil
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	nonce := hex.EncodeToString(buf)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// The headers of the request are shared with the client
+	headers := http.Header{}
+	if r.Headers != nil {
+		headers = r.Headers.Clone()
+	}
+	headers.Set(ReplayProtectionTimestampHeader, timestamp)
+	headers.Set(ReplayProtectionNonceHeader, nonce)
+	if key != "" {
+		headers.Set(ReplayProtectionSignatureHeader, ReplayProtectionSignature(key, timestamp, nonce, r.URL.Path))
+	}
+	r.Headers = headers
+
+	return nil
+}
@@ -62,24 +62,39 @@ func (c *Sys) GenerateRecoveryOperationTokenInit(otp, pgpKey string) (*GenerateR
 }
 
 func (c *Sys) GenerateRootInitWithContext(ctx context.Context, otp, pgpKey string) (*GenerateRootStatusResponse, error) {
-	return c.generateRootInitCommonWithContext(ctx, "/v1/sys/generate-root/attempt", otp, pgpKey)
+	return c.generateRootInitCommonWithContext(ctx, "/v1/sys/generate-root/attempt", &GenerateRootInitInput{OTP: otp, PGPKey: pgpKey})
+}
+
+func (c *Sys) GenerateRootInitWithInput(ctx context.Context, input *GenerateRootInitInput) (*GenerateRootStatusResponse, error) {
+	return c.generateRootInitCommonWithContext(ctx, "/v1/sys/generate-root/attempt", input)
 }
 
 func (c *Sys) GenerateDROperationTokenInitWithContext(ctx context.Context, otp, pgpKey string) (*GenerateRootStatusResponse, error) {
-	return c.generateRootInitCommonWithContext(ctx, "/v1/sys/replication/dr/secondary/generate-operation-token/attempt", otp, pgpKey)
+	return c.generateRootInitCommonWithContext(ctx, "/v1/sys/replication/dr/secondary/generate-operation-token/attempt", &GenerateRootInitInput{OTP: otp, PGPKey: pgpKey})
+}
+
+func (c *Sys) GenerateDROperationTokenInitWithInput(ctx context.Context, input *GenerateRootInitInput) (*GenerateRootStatusResponse, error) {
+	return c.generateRootInitCommonWithContext(ctx, "/v1/sys/replication/dr/secondary/generate-operation-token/attempt", input)
 }
 
 func (c *Sys) GenerateRecoveryOperationTokenInitWithContext(ctx context.Context, otp, pgpKey string) (*GenerateRootStatusResponse, error) {
-	return c.generateRootInitCommonWithContext(ctx, "/v1/sys/generate-recovery-token/attempt", otp, pgpKey)
+	return c.generateRootInitCommonWithContext(ctx, "/v1/sys/generate-recovery-token/attempt", &GenerateRootInitInput{OTP: otp, PGPKey: pgpKey})
 }
 
-func (c *Sys) generateRootInitCommonWithContext(ctx context.Context, path, otp, pgpKey string) (*GenerateRootStatusResponse, error) {
+func (c *Sys) GenerateRecoveryOperationTokenInitWithInput(ctx context.Context, input *GenerateRootInitInput) (*GenerateRootStatusResponse, error) {
+	return c.generateRootInitCommonWithContext(ctx, "/v1/sys/generate-recovery-token/attempt", input)
+}
+
+func (c *Sys) generateRootInitCommonWithContext(ctx context.Context, path string, input *GenerateRootInitInput) (*GenerateRootStatusResponse, error) {
 	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
 	defer cancelFunc()
 
 	body := map[string]interface{}{
-		"otp":     otp,
-		"pgp_key": pgpKey,
+		"otp":     input.OTP,
+		"pgp_key": input.PGPKey,
+	}
+	if input.Justification != "" {
+		body["justification"] = input.Justification
 	}
 
 	r := c.c.NewRequest(http.MethodPut, path)
@@ -172,6 +187,9 @@ func (c *Sys) generateRootUpdateCommonWithContext(ctx context.Context, path, sha
 	if err := r.SetJSONBody(body); err != nil {
 		return nil, err
 	}
+	if err := c.c.setReplayProtectionHeaders(r, shard); err != nil {
+		return nil, err
+	}
 
 	resp, err := c.c.rawRequestWithContext(ctx, r)
 	if err != nil {
@@ -195,4 +213,18 @@ type GenerateRootStatusResponse struct {
 	PGPFingerprint   string `json:"pgp_fingerprint"`
 	OTP              string `json:"otp"`
 	OTPLength        int    `json:"otp_length"`
+
+	Justification         string `json:"justification,omitempty"`
+	JustificationRequired bool   `json:"justification_required,omitempty"`
+}
+
+// GenerateRootInitInput is used to initialize a root, DR operation or
+// recovery token generation.
+type GenerateRootInitInput struct {
+	OTP    string
+	PGPKey string
+
+	// Justification records why the token is needed. It is required if the
+	// server is configured with generate_root require_justification.
+	Justification string
 }
@@ -52,4 +52,5 @@ type HealthResponse struct {
 	Enterprise                 bool   `json:"enterprise"`
 	EchoDurationMillis         int64  `json:"echo_duration_ms"`
 	ClockSkewMillis            int64  `json:"clock_skew_ms"`
+	CryptoPolicy               string `json:"crypto_policy,omitempty"`
 }
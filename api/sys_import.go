@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// ImportSecrets imports the secrets of an external secret manager into a KV
+// version 2 secrets engine, and returns the result of each secret.
+func (c *Sys) ImportSecrets(input *ImportSecretsInput) (*ImportSecretsOutput, error) {
+	return c.ImportSecretsWithContext(context.Background(), input)
+}
+
+func (c *Sys) ImportSecretsWithContext(ctx context.Context, input *ImportSecretsInput) (*ImportSecretsOutput, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPost, "/v1/sys/import")
+	if err := r.SetJSONBody(input); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result ImportSecretsOutput
+	if err := mapstructure.Decode(secret.Data, &result); err != nil {
+		return nil, err
+	}
+	result.Warnings = secret.Warnings
+	return &result, nil
+}
+
+type ImportSecretsInput struct {
+	// Source is the type of the secret manager: aws, azure or gcp.
+	Source string            `json:"source"`
+	Config map[string]string `json:"config,omitempty"`
+
+	// Mount is the path of the KV version 2 secrets engine to import into,
+	// and Prefix the path of the secrets within it.
+	Mount  string `json:"mount"`
+	Prefix string `json:"prefix,omitempty"`
+
+	// Secrets are the names of the secrets to import. All secrets of the
+	// source are imported if empty.
+	Secrets   []string `json:"secrets,omitempty"`
+	DryRun    bool     `json:"dry_run"`
+	Overwrite bool     `json:"overwrite"`
+}
+
+type ImportSecretsOutput struct {
+	DryRun   bool                  `json:"dry_run" mapstructure:"dry_run"`
+	Results  []*ImportSecretResult `json:"results" mapstructure:"results"`
+	Warnings []string              `json:"warnings,omitempty" mapstructure:"-"`
+}
+
+type ImportSecretResult struct {
+	Name   string `json:"name" mapstructure:"name"`
+	Path   string `json:"path" mapstructure:"path"`
+	Status string `json:"status" mapstructure:"status"`
+	Error  string `json:"error,omitempty" mapstructure:"error"`
+}
@@ -54,14 +54,16 @@ func (c *Sys) InitWithContext(ctx context.Context, opts *InitRequest) (*InitResp
 }
 
 type InitRequest struct {
-	SecretShares      int      `json:"secret_shares"`
-	SecretThreshold   int      `json:"secret_threshold"`
-	StoredShares      int      `json:"stored_shares"`
-	PGPKeys           []string `json:"pgp_keys"`
-	RecoveryShares    int      `json:"recovery_shares"`
-	RecoveryThreshold int      `json:"recovery_threshold"`
-	RecoveryPGPKeys   []string `json:"recovery_pgp_keys"`
-	RootTokenPGPKey   string   `json:"root_token_pgp_key"`
+	SecretShares             int      `json:"secret_shares"`
+	SecretThreshold          int      `json:"secret_threshold"`
+	StoredShares             int      `json:"stored_shares"`
+	PGPKeys                  []string `json:"pgp_keys"`
+	X509Certificates         []string `json:"x509_certificates,omitempty"`
+	RecoveryShares           int      `json:"recovery_shares"`
+	RecoveryThreshold        int      `json:"recovery_threshold"`
+	RecoveryPGPKeys          []string `json:"recovery_pgp_keys"`
+	RecoveryX509Certificates []string `json:"recovery_x509_certificates,omitempty"`
+	RootTokenPGPKey          string   `json:"root_token_pgp_key"`
 }
 
 type InitStatusResponse struct {
@@ -74,4 +76,5 @@ type InitResponse struct {
 	RecoveryKeys    []string `json:"recovery_keys"`
 	RecoveryKeysB64 []string `json:"recovery_keys_base64"`
 	RootToken       string   `json:"root_token"`
+	Warnings        []string `json:"warnings,omitempty"`
 }
@@ -273,6 +273,7 @@ type MountConfigInput struct {
 	UserLockoutConfig         *UserLockoutConfigInput `json:"user_lockout_config,omitempty"`
 	DelegatedAuthAccessors    []string                `json:"delegated_auth_accessors,omitempty" mapstructure:"delegated_auth_accessors"`
 	IdentityTokenKey          string                  `json:"identity_token_key,omitempty" mapstructure:"identity_token_key"`
+	PluginUpgradeWindow       *PluginUpgradeWindow    `json:"plugin_upgrade_window,omitempty" mapstructure:"plugin_upgrade_window"`
 
 	// Deprecated: This field will always be blank for newer server responses.
 	PluginName string `json:"plugin_name,omitempty" mapstructure:"plugin_name"`
@@ -292,6 +293,16 @@ type MountOutput struct {
 	RunningVersion        string            `json:"running_plugin_version" mapstructure:"running_plugin_version"`
 	RunningSha256         string            `json:"running_sha256" mapstructure:"running_sha256"`
 	DeprecationStatus     string            `json:"deprecation_status" mapstructure:"deprecation_status"`
+	PluginHealth          *PluginHealth     `json:"plugin_health,omitempty" mapstructure:"plugin_health"`
+}
+
+// PluginHealth describes the health of the process running the external
+// plugin of a mount.
+type PluginHealth struct {
+	Restarts             int    `json:"restarts" mapstructure:"restarts"`
+	LastRestart          string `json:"last_restart,omitempty" mapstructure:"last_restart"`
+	LastError            string `json:"last_error,omitempty" mapstructure:"last_error"`
+	RestartLimitExceeded bool   `json:"restart_limit_exceeded" mapstructure:"restart_limit_exceeded"`
 }
 
 type MountConfigOutput struct {
@@ -308,6 +319,7 @@ type MountConfigOutput struct {
 	UserLockoutConfig         *UserLockoutConfigOutput `json:"user_lockout_config,omitempty"`
 	DelegatedAuthAccessors    []string                 `json:"delegated_auth_accessors,omitempty" mapstructure:"delegated_auth_accessors"`
 	IdentityTokenKey          string                   `json:"identity_token_key,omitempty" mapstructure:"identity_token_key"`
+	PluginUpgradeWindow       *PluginUpgradeWindow     `json:"plugin_upgrade_window,omitempty" mapstructure:"plugin_upgrade_window"`
 
 	// Deprecated: This field will always be blank for newer server responses.
 	PluginName string `json:"plugin_name,omitempty" mapstructure:"plugin_name"`
@@ -327,6 +339,20 @@ type UserLockoutConfigOutput struct {
 	DisableLockout      *bool `json:"disable_lockout,omitempty" structs:"disable_lockout" mapstructure:"disable_lockout"`
 }
 
+// PluginUpgradeWindow is a daily window during which a mount is automatically
+// upgraded to the newest registered version of its plugin.
+type PluginUpgradeWindow struct {
+	// Start is the time of day the window opens, as HH:MM in UTC.
+	Start string `json:"start" mapstructure:"start"`
+
+	// Duration is how long the window stays open, e.g. "2h".
+	Duration string `json:"duration" mapstructure:"duration"`
+
+	// VersionConstraint optionally restricts the versions the mount can be
+	// upgraded to, e.g. "~> 1.2".
+	VersionConstraint string `json:"version_constraint,omitempty" mapstructure:"version_constraint"`
+}
+
 type MountMigrationOutput struct {
 	MigrationID string `mapstructure:"migration_id"`
 }
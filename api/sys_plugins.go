@@ -152,6 +152,10 @@ type GetPluginResponse struct {
 	Runtime           string   `json:"runtime,omitempty"`
 	DeprecationStatus string   `json:"deprecation_status,omitempty"`
 	Version           string   `json:"version,omitempty"`
+
+	// Cosign holds the public key, or the certificate identity and issuer,
+	// of the cosign signature the plugin is verified against.
+	Cosign map[string]string `json:"cosign,omitempty"`
 }
 
 // GetPlugin wraps GetPluginWithContext using context.Background.
@@ -215,6 +219,26 @@ type RegisterPluginInput struct {
 	// Env specifies a list of key=value pairs to add to the plugin's environment
 	// variables.
 	Env []string `json:"env,omitempty"`
+
+	// CosignPublicKey is the PEM encoded public key the plugin binary is
+	// signed with. Requires CosignSignature.
+	CosignPublicKey string `json:"cosign_public_key,omitempty"`
+
+	// CosignSignature is the base64 encoded signature of the plugin binary.
+	CosignSignature string `json:"cosign_signature,omitempty"`
+
+	// CosignBundle is the cosign bundle of a keyless signature of the plugin
+	// binary. Requires CosignCertificateIdentity and
+	// CosignCertificateOIDCIssuer.
+	CosignBundle string `json:"cosign_bundle,omitempty"`
+
+	// CosignCertificateIdentity is the email address or URI the signing
+	// certificate must be issued to.
+	CosignCertificateIdentity string `json:"cosign_certificate_identity,omitempty"`
+
+	// CosignCertificateOIDCIssuer is the OIDC issuer which must have
+	// authenticated the identity of the signing certificate.
+	CosignCertificateOIDCIssuer string `json:"cosign_certificate_oidc_issuer,omitempty"`
 }
 
 // RegisterPlugin wraps RegisterPluginWithContext using context.Background.
@@ -28,6 +28,10 @@ type GetPluginRuntimeResponse struct {
 	CgroupParent string `json:"cgroup_parent"`
 	CPU          int64  `json:"cpu_nanos"`
 	Memory       int64  `json:"memory_bytes"`
+	MaxRestarts  int64  `json:"max_restarts"`
+
+	// RestartWindow is in seconds.
+	RestartWindow int64 `json:"restart_window"`
 }
 
 // GetPluginRuntime retrieves information about the plugin.
@@ -67,6 +71,11 @@ type RegisterPluginRuntimeInput struct {
 	CPU          int64  `json:"cpu_nanos,omitempty"`
 	Memory       int64  `json:"memory_bytes,omitempty"`
 	Rootless     bool   `json:"rootless,omitempty"`
+
+	// MaxRestarts limits how often plugins are restarted within
+	// RestartWindow, e.g. "30s", after exiting unexpectedly.
+	MaxRestarts   int64  `json:"max_restarts,omitempty"`
+	RestartWindow string `json:"restart_window,omitempty"`
 }
 
 // RegisterPluginRuntime registers the plugin with the given information.
@@ -119,6 +128,10 @@ type PluginRuntimeDetails struct {
 	CgroupParent string `json:"cgroup_parent" mapstructure:"cgroup_parent"`
 	CPU          int64  `json:"cpu_nanos" mapstructure:"cpu_nanos"`
 	Memory       int64  `json:"memory_bytes" mapstructure:"memory_bytes"`
+	MaxRestarts  int64  `json:"max_restarts" mapstructure:"max_restarts"`
+
+	// RestartWindow is in seconds.
+	RestartWindow int64 `json:"restart_window" mapstructure:"restart_window"`
 }
 
 // ListPluginRuntimesInput is used as input to the ListPluginRuntimes function.
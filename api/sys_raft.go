@@ -12,6 +12,8 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -246,6 +248,31 @@ func (c *Sys) RaftSnapshotWithContext(ctx context.Context, snapWriter io.Writer)
 	return nil
 }
 
+// RaftSnapshotEncrypted wraps RaftSnapshotEncryptedWithContext using context.Background.
+func (c *Sys) RaftSnapshotEncrypted(snapWriter io.Writer, pgpKeys []string) error {
+	return c.RaftSnapshotEncryptedWithContext(context.Background(), snapWriter, pgpKeys)
+}
+
+// RaftSnapshotEncryptedWithContext invokes the API that takes the snapshot of
+// the raft cluster encrypted to the given base64-encoded PGP public keys, and
+// writes it to the supplied io.Writer. The snapshot is a binary PGP message
+// which any of the keys can decrypt, so unlike RaftSnapshotWithContext its
+// contents are not verified.
+func (c *Sys) RaftSnapshotEncryptedWithContext(ctx context.Context, snapWriter io.Writer, pgpKeys []string) error {
+	r := c.c.NewRequest(http.MethodGet, "/v1/sys/storage/raft/snapshot")
+	r.Params.Set("pgp_keys", strings.Join(pgpKeys, ","))
+	r.URL.RawQuery = r.Params.Encode()
+
+	resp, err := c.c.httpRequestWithContext(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(snapWriter, resp.Body)
+	return err
+}
+
 // RaftSnapshotRestore wraps RaftSnapshotRestoreWithContext using context.Background.
 func (c *Sys) RaftSnapshotRestore(snapReader io.Reader, force bool) error {
 	return c.RaftSnapshotRestoreWithContext(context.Background(), snapReader, force)
@@ -271,6 +298,31 @@ func (c *Sys) RaftSnapshotRestoreWithContext(ctx context.Context, snapReader io.
 	return nil
 }
 
+// RaftSnapshotRestorePath wraps RaftSnapshotRestorePathWithContext using context.Background.
+func (c *Sys) RaftSnapshotRestorePath(snapReader io.Reader, mount, path string) (*Secret, error) {
+	return c.RaftSnapshotRestorePathWithContext(context.Background(), snapReader, mount, path)
+}
+
+// RaftSnapshotRestorePathWithContext reads the snapshot from the io.Reader and
+// restores the secret at the given path of a KV mount from it, leaving the
+// rest of the cluster untouched.
+func (c *Sys) RaftSnapshotRestorePathWithContext(ctx context.Context, snapReader io.Reader, mount, path string) (*Secret, error) {
+	r := c.c.NewRequest(http.MethodPost, "/v1/sys/storage/raft/snapshot-restore-path")
+	r.URL.RawQuery = url.Values{
+		"mount": []string{mount},
+		"path":  []string{path},
+	}.Encode()
+	r.Body = snapReader
+
+	resp, err := c.c.httpRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
 // RaftAutopilotState wraps RaftAutopilotStateWithContext using context.Background.
 func (c *Sys) RaftAutopilotState() (*AutopilotState, error) {
 	return c.RaftAutopilotStateWithContext(context.Background())
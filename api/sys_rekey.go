@@ -228,6 +228,9 @@ func (c *Sys) RekeyUpdateWithContext(ctx context.Context, shard, nonce string) (
 	if err := r.SetJSONBody(body); err != nil {
 		return nil, err
 	}
+	if err := c.c.setReplayProtectionHeaders(r, shard); err != nil {
+		return nil, err
+	}
 
 	resp, err := c.c.rawRequestWithContext(ctx, r)
 	if err != nil {
@@ -257,6 +260,9 @@ func (c *Sys) RekeyRecoveryKeyUpdateWithContext(ctx context.Context, shard, nonc
 	if err := r.SetJSONBody(body); err != nil {
 		return nil, err
 	}
+	if err := c.c.setReplayProtectionHeaders(r, shard); err != nil {
+		return nil, err
+	}
 
 	resp, err := c.c.rawRequestWithContext(ctx, r)
 	if err != nil {
@@ -388,6 +394,9 @@ func (c *Sys) RekeyVerificationUpdateWithContext(ctx context.Context, shard, non
 	if err := r.SetJSONBody(body); err != nil {
 		return nil, err
 	}
+	if err := c.c.setReplayProtectionHeaders(r, shard); err != nil {
+		return nil, err
+	}
 
 	resp, err := c.c.rawRequestWithContext(ctx, r)
 	if err != nil {
@@ -417,6 +426,9 @@ func (c *Sys) RekeyRecoveryKeyVerificationUpdateWithContext(ctx context.Context,
 	if err := r.SetJSONBody(body); err != nil {
 		return nil, err
 	}
+	if err := c.c.setReplayProtectionHeaders(r, shard); err != nil {
+		return nil, err
+	}
 
 	resp, err := c.c.rawRequestWithContext(ctx, r)
 	if err != nil {
@@ -434,8 +446,10 @@ type RekeyInitRequest struct {
 	SecretThreshold     int      `json:"secret_threshold"`
 	StoredShares        int      `json:"stored_shares"`
 	PGPKeys             []string `json:"pgp_keys"`
+	X509Certificates    []string `json:"x509_certificates,omitempty"`
 	Backup              bool
 	RequireVerification bool `json:"require_verification"`
+	DelegatedUnseal     bool `json:"delegated_unseal,omitempty"`
 }
 
 type RekeyStatusResponse struct {
@@ -446,9 +460,11 @@ type RekeyStatusResponse struct {
 	Progress             int      `json:"progress"`
 	Required             int      `json:"required"`
 	PGPFingerprints      []string `json:"pgp_fingerprints"`
+	X509Fingerprints     []string `json:"x509_fingerprints,omitempty"`
 	Backup               bool     `json:"backup"`
 	VerificationRequired bool     `json:"verification_required"`
 	VerificationNonce    string   `json:"verification_nonce"`
+	DelegatedUnseal      bool     `json:"delegated_unseal,omitempty"`
 }
 
 type RekeyUpdateResponse struct {
@@ -457,9 +473,15 @@ type RekeyUpdateResponse struct {
 	Keys                 []string `json:"keys"`
 	KeysB64              []string `json:"keys_base64"`
 	PGPFingerprints      []string `json:"pgp_fingerprints"`
+	X509Fingerprints     []string `json:"x509_fingerprints,omitempty"`
 	Backup               bool     `json:"backup"`
 	VerificationRequired bool     `json:"verification_required"`
 	VerificationNonce    string   `json:"verification_nonce,omitempty"`
+	Warnings             []string `json:"warnings,omitempty"`
+
+	// Progress is the number of shares provided, if the rekey is not
+	// complete.
+	Progress int `json:"progress,omitempty"`
 }
 
 type RekeyRetrieveResponse struct {
@@ -479,4 +501,8 @@ type RekeyVerificationStatusResponse struct {
 type RekeyVerificationUpdateResponse struct {
 	Nonce    string `json:"nonce"`
 	Complete bool   `json:"complete"`
+
+	// Progress is the number of shares provided, if the verification is not
+	// complete.
+	Progress int `json:"progress,omitempty"`
 }
@@ -6,6 +6,7 @@ package api
 import (
 	"context"
 	"net/http"
+	"time"
 )
 
 func (c *Sys) SealStatus() (*SealStatusResponse, error) {
@@ -36,6 +37,36 @@ func (c *Sys) SealWithContext(ctx context.Context) error {
 	return nil
 }
 
+// DrainAndSeal seals Vault once the requests in flight complete, or once the
+// given grace period passes, whichever comes first. New requests are rejected
+// in the meantime. A zero grace period uses the server's default.
+func (c *Sys) DrainAndSeal(gracePeriod time.Duration) error {
+	return c.DrainAndSealWithContext(context.Background(), gracePeriod)
+}
+
+func (c *Sys) DrainAndSealWithContext(ctx context.Context, gracePeriod time.Duration) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	body := map[string]interface{}{"drain": true}
+	if gracePeriod > 0 {
+		body["grace_period"] = gracePeriod.String()
+	}
+
+	r := c.c.NewRequest(http.MethodPut, "/v1/sys/seal")
+	if err := r.SetJSONBody(body); err != nil {
+		return err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
 func (c *Sys) ResetUnsealProcess() (*SealStatusResponse, error) {
 	return c.ResetUnsealProcessWithContext(context.Background())
 }
@@ -47,6 +78,9 @@ func (c *Sys) ResetUnsealProcessWithContext(ctx context.Context) (*SealStatusRes
 	if err := r.SetJSONBody(body); err != nil {
 		return nil, err
 	}
+	if err := c.c.setReplayProtectionHeaders(r, ""); err != nil {
+		return nil, err
+	}
 
 	return sealStatusRequestWithContext(ctx, c, r)
 }
@@ -62,6 +96,9 @@ func (c *Sys) UnsealWithContext(ctx context.Context, shard string) (*SealStatusR
 	if err := r.SetJSONBody(body); err != nil {
 		return nil, err
 	}
+	if err := c.c.setReplayProtectionHeaders(r, shard); err != nil {
+		return nil, err
+	}
 
 	return sealStatusRequestWithContext(ctx, c, r)
 }
@@ -76,6 +113,9 @@ func (c *Sys) UnsealWithOptionsWithContext(ctx context.Context, opts *UnsealOpts
 	if err := r.SetJSONBody(opts); err != nil {
 		return nil, err
 	}
+	if err := c.c.setReplayProtectionHeaders(r, opts.Key); err != nil {
+		return nil, err
+	}
 
 	return sealStatusRequestWithContext(ctx, c, r)
 }
@@ -0,0 +1,278 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ShareOperation is an operation which key shares are submitted to.
+type ShareOperation string
+
+const (
+	ShareOperationUnseal              ShareOperation = "unseal"
+	ShareOperationRekey               ShareOperation = "rekey"
+	ShareOperationRekeyVerify         ShareOperation = "rekey-verify"
+	ShareOperationRekeyRecovery       ShareOperation = "rekey-recovery"
+	ShareOperationRekeyRecoveryVerify ShareOperation = "rekey-recovery-verify"
+	ShareOperationGenerateRoot        ShareOperation = "generate-root"
+)
+
+// ErrSharesComplete is the error of shares which were not submitted because
+// the operation completed with the shares submitted before them.
+var ErrSharesComplete = errors.New("share not needed, the operation is already complete")
+
+// Share is a key share to submit.
+type Share struct {
+	// Label identifies the share in its status, e.g. by its key holder.
+	Label string
+
+	// Key is the hex or base64 encoded key share.
+	Key string
+}
+
+// SubmitSharesInput is used as input to SubmitShares.
+type SubmitSharesInput struct {
+	Operation ShareOperation
+	Shares    []*Share
+
+	// Nonce is the nonce of the operation in progress. It is required for all
+	// operations but unseal, for which it is only verified if set.
+	Nonce string
+
+	// MaxParallel is the number of shares submitted concurrently. All shares
+	// are submitted concurrently if it is not set.
+	MaxParallel int
+}
+
+// ShareStatus is the outcome of submitting a share.
+type ShareStatus struct {
+	Label     string `json:"label"`
+	Submitted bool   `json:"submitted"`
+	Error     string `json:"error,omitempty"`
+
+	// Progress is the progress of the operation reported when the share was
+	// submitted.
+	Progress int `json:"progress,omitempty"`
+}
+
+// SubmitSharesResponse is the outcome of SubmitShares. Depending on the
+// operation, one of the responses holds the response of the share which
+// completed the operation, or of the last share submitted.
+type SubmitSharesResponse struct {
+	Shares   []*ShareStatus `json:"shares"`
+	Complete bool           `json:"complete"`
+
+	SealStatus        *SealStatusResponse              `json:"seal_status,omitempty"`
+	Rekey             *RekeyUpdateResponse             `json:"rekey,omitempty"`
+	RekeyVerification *RekeyVerificationUpdateResponse `json:"rekey_verification,omitempty"`
+	GenerateRoot      *GenerateRootStatusResponse      `json:"generate_root,omitempty"`
+}
+
+// ValidateShare returns the decoded share, or an error if the share is not a
+// hex or base64 encoded key share.
+func ValidateShare(key string) ([]byte, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, errors.New("share is empty")
+	}
+	if decoded, err := hex.DecodeString(key); err == nil {
+		return decoded, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, errors.New("share is neither hex nor base64 encoded; it may still be PGP encrypted")
+	}
+	return decoded, nil
+}
+
+// SubmitShares wraps SubmitSharesWithContext using context.Background.
+func (c *Sys) SubmitShares(i *SubmitSharesInput) (*SubmitSharesResponse, error) {
+	return c.SubmitSharesWithContext(context.Background(), i)
+}
+
+// SubmitSharesWithContext validates the shares, and submits the valid ones
+// concurrently to the operation. Invalid and duplicate shares, and shares of
+// a different length than the first valid share, are not submitted. Once the
+// operation is complete no further shares are submitted. The status of each share is returned in the order of the input, and an
+// error is only returned if no share could be submitted.
+func (c *Sys) SubmitSharesWithContext(ctx context.Context, i *SubmitSharesInput) (*SubmitSharesResponse, error) {
+	if len(i.Shares) == 0 {
+		return nil, errors.New("no shares to submit")
+	}
+
+	submit, err := c.newShareSubmitFunc(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &SubmitSharesResponse{
+		Shares: make([]*ShareStatus, len(i.Shares)),
+	}
+
+	// Validate all shares before submitting any of them
+	var decoded [][]byte
+	var pending []int
+	for idx, share := range i.Shares {
+		status := &ShareStatus{Label: share.Label}
+		if status.Label == "" {
+			status.Label = fmt.Sprintf("share %d", idx+1)
+		}
+		out.Shares[idx] = status
+
+		key, err := ValidateShare(share.Key)
+		if err != nil {
+			status.Error = err.Error()
+			continue
+		}
+		for j, other := range decoded {
+			if bytes.Equal(key, other) {
+				status.Error = fmt.Sprintf("duplicate of %s", out.Shares[pending[j]].Label)
+				break
+			}
+			if len(key) != len(other) {
+				status.Error = fmt.Sprintf("length does not match %s", out.Shares[pending[j]].Label)
+				break
+			}
+		}
+		if status.Error != "" {
+			continue
+		}
+		decoded = append(decoded, key)
+		pending = append(pending, idx)
+	}
+	if len(pending) == 0 {
+		return out, errors.New("no valid shares to submit")
+	}
+
+	maxParallel := i.MaxParallel
+	if maxParallel <= 0 || maxParallel > len(pending) {
+		maxParallel = len(pending)
+	}
+
+	var lock sync.Mutex
+	var progress int
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxParallel)
+	for _, idx := range pending {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(share *Share, status *ShareStatus) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			lock.Lock()
+			complete := out.Complete
+			lock.Unlock()
+			if complete {
+				status.Error = ErrSharesComplete.Error()
+				return
+			}
+
+			shareProgress, complete, record, err := submit(strings.TrimSpace(share.Key))
+
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				if out.Complete {
+					err = ErrSharesComplete
+				}
+				status.Error = err.Error()
+				return
+			}
+			status.Submitted = true
+			status.Progress = shareProgress
+			if !out.Complete && (complete || shareProgress >= progress) {
+				record(out)
+				out.Complete = complete
+				progress = shareProgress
+			}
+		}(i.Shares[idx], out.Shares[idx])
+	}
+	wg.Wait()
+
+	for _, status := range out.Shares {
+		if status.Submitted {
+			return out, nil
+		}
+	}
+	return out, errors.New("no share could be submitted")
+}
+
+// shareSubmitFunc submits a share, and returns the progress and completion of
+// the operation, and a function recording the response in the output.
+type shareSubmitFunc func(key string) (progress int, complete bool, record func(*SubmitSharesResponse), err error)
+
+// newShareSubmitFunc returns the shareSubmitFunc of the operation.
+func (c *Sys) newShareSubmitFunc(ctx context.Context, i *SubmitSharesInput) (shareSubmitFunc, error) {
+	if i.Operation != ShareOperationUnseal && i.Nonce == "" {
+		return nil, fmt.Errorf("a nonce is required to submit shares to %s", i.Operation)
+	}
+
+	type updateFunc func(context.Context, string, string) (*RekeyUpdateResponse, error)
+	rekey := func(update updateFunc) shareSubmitFunc {
+		return func(key string) (int, bool, func(*SubmitSharesResponse), error) {
+			resp, err := update(ctx, key, i.Nonce)
+			if err != nil {
+				return 0, false, nil, err
+			}
+			return resp.Progress, resp.Complete, func(out *SubmitSharesResponse) { out.Rekey = resp }, nil
+		}
+	}
+	type verifyFunc func(context.Context, string, string) (*RekeyVerificationUpdateResponse, error)
+	verify := func(update verifyFunc) shareSubmitFunc {
+		return func(key string) (int, bool, func(*SubmitSharesResponse), error) {
+			resp, err := update(ctx, key, i.Nonce)
+			if err != nil {
+				return 0, false, nil, err
+			}
+			return resp.Progress, resp.Complete, func(out *SubmitSharesResponse) { out.RekeyVerification = resp }, nil
+		}
+	}
+
+	switch i.Operation {
+	case ShareOperationUnseal:
+		if i.Nonce != "" {
+			status, err := c.SealStatusWithContext(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if status.Sealed && status.Nonce != i.Nonce {
+				return nil, fmt.Errorf("unseal nonce mismatch: expected %q but the server reports %q", i.Nonce, status.Nonce)
+			}
+		}
+		return func(key string) (int, bool, func(*SubmitSharesResponse), error) {
+			resp, err := c.UnsealWithContext(ctx, key)
+			if err != nil {
+				return 0, false, nil, err
+			}
+			return resp.Progress, !resp.Sealed, func(out *SubmitSharesResponse) { out.SealStatus = resp }, nil
+		}, nil
+	case ShareOperationRekey:
+		return rekey(c.RekeyUpdateWithContext), nil
+	case ShareOperationRekeyRecovery:
+		return rekey(c.RekeyRecoveryKeyUpdateWithContext), nil
+	case ShareOperationRekeyVerify:
+		return verify(c.RekeyVerificationUpdateWithContext), nil
+	case ShareOperationRekeyRecoveryVerify:
+		return verify(c.RekeyRecoveryKeyVerificationUpdateWithContext), nil
+	case ShareOperationGenerateRoot:
+		return func(key string) (int, bool, func(*SubmitSharesResponse), error) {
+			resp, err := c.GenerateRootUpdateWithContext(ctx, key, i.Nonce)
+			if err != nil {
+				return 0, false, nil, err
+			}
+			return resp.Progress, resp.Complete, func(out *SubmitSharesResponse) { out.GenerateRoot = resp }, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown share operation %q", i.Operation)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// DelegatedUnsealChallenge returns the delegated unseal attempt in progress,
+// starting one if there is none. Key holders sign its challenge with their
+// shares and submit the result with DelegatedUnseal.
+func (c *Sys) DelegatedUnsealChallenge() (*DelegatedUnsealResponse, error) {
+	return c.DelegatedUnsealChallengeWithContext(context.Background())
+}
+
+func (c *Sys) DelegatedUnsealChallengeWithContext(ctx context.Context) (*DelegatedUnsealResponse, error) {
+	r := c.c.NewRequest(http.MethodGet, "/v1/sys/unseal-delegated")
+	return delegatedUnsealRequestWithContext(ctx, c, r)
+}
+
+// DelegatedUnseal submits a key holder's partial signature of the challenge
+// of the delegated unseal attempt with the given nonce.
+func (c *Sys) DelegatedUnseal(nonce, signature string) (*DelegatedUnsealResponse, error) {
+	return c.DelegatedUnsealWithContext(context.Background(), nonce, signature)
+}
+
+func (c *Sys) DelegatedUnsealWithContext(ctx context.Context, nonce, signature string) (*DelegatedUnsealResponse, error) {
+	body := map[string]interface{}{
+		"nonce":     nonce,
+		"signature": signature,
+	}
+
+	r := c.c.NewRequest(http.MethodPut, "/v1/sys/unseal-delegated")
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	return delegatedUnsealRequestWithContext(ctx, c, r)
+}
+
+// ResetDelegatedUnseal discards the delegated unseal attempt in progress.
+func (c *Sys) ResetDelegatedUnseal() (*DelegatedUnsealResponse, error) {
+	return c.ResetDelegatedUnsealWithContext(context.Background())
+}
+
+func (c *Sys) ResetDelegatedUnsealWithContext(ctx context.Context) (*DelegatedUnsealResponse, error) {
+	body := map[string]interface{}{"reset": true}
+
+	r := c.c.NewRequest(http.MethodPut, "/v1/sys/unseal-delegated")
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	return delegatedUnsealRequestWithContext(ctx, c, r)
+}
+
+func delegatedUnsealRequestWithContext(ctx context.Context, c *Sys, r *Request) (*DelegatedUnsealResponse, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result DelegatedUnsealResponse
+	err = resp.DecodeJSON(&result)
+	return &result, err
+}
+
+type DelegatedUnsealResponse struct {
+	Sealed    bool   `json:"sealed"`
+	T         int    `json:"t"`
+	N         int    `json:"n"`
+	Progress  int    `json:"progress"`
+	Nonce     string `json:"nonce"`
+	Challenge string `json:"challenge"`
+}
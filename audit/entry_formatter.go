@@ -397,6 +397,13 @@ func (f *EntryFormatter) FormatResponse(ctx context.Context, in *logical.LogInpu
 		if !resp.Auth.IssueTime.IsZero() {
 			respAuth.TokenIssueTime = resp.Auth.IssueTime.Format(time.RFC3339)
 		}
+		for _, r := range resp.Auth.MFAEnforcementResults {
+			respAuth.MFAEnforcementResults = append(respAuth.MFAEnforcementResults, MFAEnforcementResult{
+				Name:    r.Name,
+				Applied: r.Applied,
+				Reason:  r.Reason,
+			})
+		}
 	}
 
 	var respSecret *Secret
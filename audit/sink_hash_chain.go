@@ -0,0 +1,465 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/vault/internal/observability/event"
+)
+
+// CheckpointType is the type of the entries written by a HashChainSink to
+// checkpoint the hash chain.
+const CheckpointType = "checkpoint"
+
+var (
+	_ eventlogger.Node = (*HashChainSink)(nil)
+	_ event.Flusher    = (*HashChainSink)(nil)
+)
+
+// CheckpointSigner signs the input with the named key of the transit secrets
+// engine mounted at the given path, and returns the signature.
+type CheckpointSigner func(ctx context.Context, mountPath, keyName string, input []byte) (string, error)
+
+// HashChain is added to each entry written by a HashChainSink to link it to
+// the entry written before it.
+type HashChain struct {
+	// Sequence is the position of the entry in the chain, starting at 1.
+	Sequence uint64 `json:"seq"`
+
+	// Previous is the hex encoded SHA-256 hash of the line of the previous
+	// entry, including its prefix and chain but not its line feed.
+	Previous string `json:"prev,omitempty"`
+}
+
+// Checkpoint is the signature of the hash of an entry of the chain, with the
+// key of a transit secrets engine.
+type Checkpoint struct {
+	Sequence  uint64 `json:"seq"`
+	Hash      string `json:"hash"`
+	Mount     string `json:"mount"`
+	Key       string `json:"key"`
+	Signature string `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SignedInput returns the input signed by the checkpoint.
+func (c *Checkpoint) SignedInput() []byte {
+	return []byte(fmt.Sprintf("%d:%s", c.Sequence, c.Hash))
+}
+
+// CheckpointEntry is the structure of a checkpoint audit log entry.
+type CheckpointEntry struct {
+	Time       string      `json:"time,omitempty"`
+	Type       string      `json:"type"`
+	Checkpoint *Checkpoint `json:"checkpoint"`
+}
+
+// HashChainConfig is used to configure a HashChainSink.
+type HashChainConfig struct {
+	// Format is the format of the events to chain. Only JSONFormat is supported.
+	Format string
+
+	// Prefix is the prefix configured for the entries of the audit device.
+	Prefix string
+
+	// ResumePath is the path of the file that the sink appends to, if any. The
+	// chain resumes from the last entry of the file.
+	ResumePath string
+
+	// CheckpointInterval is the number of entries between checkpoints.
+	CheckpointInterval uint64
+
+	// CheckpointMount and CheckpointKey are the mount path and key name of the
+	// transit key used to sign checkpoints. No checkpoints are written when
+	// CheckpointKey is empty.
+	CheckpointMount string
+	CheckpointKey   string
+	Signer          CheckpointSigner
+}
+
+// HashChainSink is a wrapper for an eventlogger.NodeTypeSink node which writes
+// audit entries as an append-only hash chain: each entry records the hash of
+// the entry written before it, so that modifying, removing or reordering
+// entries breaks the chain. Checkpoints signed with a transit key are written
+// periodically, so that the chain cannot be rewritten without the key.
+type HashChainSink struct {
+	Sink   eventlogger.Node
+	config HashChainConfig
+
+	l        sync.Mutex
+	sequence uint64
+	hash     string
+
+	// pending is the number of entries written since the last checkpoint.
+	pending uint64
+}
+
+// NewHashChainSink should be used to create the HashChainSink.
+// It expects that an eventlogger.NodeTypeSink should be supplied as the sink.
+func NewHashChainSink(sink eventlogger.Node, config HashChainConfig) (*HashChainSink, error) {
+	const op = "audit.NewHashChainSink"
+
+	if sink == nil || reflect.ValueOf(sink).IsNil() {
+		return nil, fmt.Errorf("%s: sink node is required: %w", op, event.ErrInvalidParameter)
+	}
+
+	if sink.Type() != eventlogger.NodeTypeSink {
+		return nil, fmt.Errorf("%s: sink node must be of type 'sink': %w", op, event.ErrInvalidParameter)
+	}
+
+	if config.Format != JSONFormat.String() {
+		return nil, fmt.Errorf("%s: hash chains require the %q format: %w", op, JSONFormat, event.ErrInvalidParameter)
+	}
+
+	if config.CheckpointKey != "" {
+		switch {
+		case config.CheckpointMount == "":
+			return nil, fmt.Errorf("%s: checkpoint mount is required: %w", op, event.ErrInvalidParameter)
+		case config.CheckpointInterval == 0:
+			return nil, fmt.Errorf("%s: checkpoint interval must be positive: %w", op, event.ErrInvalidParameter)
+		case config.Signer == nil:
+			return nil, fmt.Errorf("%s: checkpoint signer is required: %w", op, event.ErrInvalidParameter)
+		}
+	}
+
+	s := &HashChainSink{
+		Sink:   sink,
+		config: config,
+	}
+
+	if config.ResumePath != "" {
+		err := s.resume(config.ResumePath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: unable to resume hash chain from %q: %w", op, config.ResumePath, err)
+		}
+	}
+
+	return s, nil
+}
+
+// Process adds the link to the previous entry to the formatted event, and
+// passes it to the underlying sink. A checkpoint is written after the event
+// when the checkpoint interval is reached.
+func (s *HashChainSink) Process(ctx context.Context, e *eventlogger.Event) (*eventlogger.Event, error) {
+	const op = "audit.(HashChainSink).Process"
+
+	if e == nil {
+		return nil, fmt.Errorf("%s: event is nil: %w", op, event.ErrInvalidParameter)
+	}
+
+	formatted, found := e.Format(s.config.Format)
+	if !found {
+		return nil, fmt.Errorf("%s: unable to retrieve event formatted as %q", op, s.config.Format)
+	}
+
+	// The chain must be extended in the order that entries are written.
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	result, err := s.write(ctx, e, formatted)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.pending++
+	if s.config.CheckpointKey != "" && s.pending >= s.config.CheckpointInterval {
+		err = s.checkpoint(ctx, e)
+		if err != nil {
+			return nil, fmt.Errorf("%s: unable to write checkpoint: %w", op, err)
+		}
+		s.pending = 0
+	}
+
+	return result, nil
+}
+
+// write chains the formatted entry and writes it to the underlying sink. The
+// lock must be held.
+func (s *HashChainSink) write(ctx context.Context, e *eventlogger.Event, formatted []byte) (*eventlogger.Event, error) {
+	line := bytes.TrimRight(formatted, "\n")
+	if !bytes.HasSuffix(line, []byte("}")) {
+		return nil, errors.New("formatted entry is not a JSON object")
+	}
+
+	chain, err := json.Marshal(&HashChain{
+		Sequence: s.sequence + 1,
+		Previous: s.hash,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode hash chain: %w", err)
+	}
+
+	chained := make([]byte, 0, len(line)+len(chain)+11)
+	chained = append(chained, line[:len(line)-1]...)
+	chained = append(chained, `,"chain":`...)
+	chained = append(chained, chain...)
+	chained = append(chained, "}"...)
+	hash := sha256.Sum256(chained)
+
+	e2 := &eventlogger.Event{
+		Type:      e.Type,
+		CreatedAt: e.CreatedAt,
+		Formatted: make(map[string][]byte),
+		Payload:   e.Payload,
+	}
+	e2.FormattedAs(s.config.Format, append(chained, '\n'))
+
+	result, err := s.Sink.Process(ctx, e2)
+	if err != nil {
+		return nil, err
+	}
+
+	s.sequence++
+	s.hash = hex.EncodeToString(hash[:])
+
+	return result, nil
+}
+
+// checkpoint signs the hash of the last entry, and writes the signature in a
+// checkpoint entry. A failure to sign is recorded in the checkpoint rather
+// than failing the request being audited. The lock must be held.
+func (s *HashChainSink) checkpoint(ctx context.Context, e *eventlogger.Event) error {
+	checkpoint := &Checkpoint{
+		Sequence: s.sequence,
+		Hash:     s.hash,
+		Mount:    s.config.CheckpointMount,
+		Key:      s.config.CheckpointKey,
+	}
+
+	signature, err := s.config.Signer(ctx, checkpoint.Mount, checkpoint.Key, checkpoint.SignedInput())
+	if err != nil {
+		checkpoint.Error = err.Error()
+	}
+	checkpoint.Signature = signature
+
+	formatted, err := json.Marshal(&CheckpointEntry{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		Type:       CheckpointType,
+		Checkpoint: checkpoint,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to encode checkpoint: %w", err)
+	}
+
+	_, err = s.write(ctx, e, append([]byte(s.config.Prefix), formatted...))
+	return err
+}
+
+// resume continues the hash chain from the last entry of the file at the given
+// path, if it exists and is not empty.
+func (s *HashChainSink) resume(path string) error {
+	f, err := os.Open(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	}
+	defer f.Close()
+
+	line, err := lastLine(f)
+	if err != nil || line == nil {
+		return err
+	}
+
+	entry, err := parseChainedEntry(line, s.config.Prefix)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(line)
+	s.sequence = entry.Chain.Sequence
+	s.hash = hex.EncodeToString(hash[:])
+
+	return nil
+}
+
+// Reopen wraps the Reopen method of this underlying sink (eventlogger.Node).
+// The hash chain continues across reopened files.
+func (s *HashChainSink) Reopen() error {
+	return s.Sink.Reopen()
+}
+
+// Flush wraps the Flush method of this underlying sink (eventlogger.Node), if
+// it is an event.Flusher.
+func (s *HashChainSink) Flush() error {
+	if f, ok := s.Sink.(event.Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Type wraps the Type method of this underlying sink (eventlogger.Node).
+func (s *HashChainSink) Type() eventlogger.NodeType {
+	return s.Sink.Type()
+}
+
+// lastLine returns the last line of the file, without its line feed, or nil
+// if the file is empty. The file is read backwards from its end.
+func lastLine(f *os.File) ([]byte, error) {
+	const chunkSize = 64 * 1024
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	end := info.Size()
+	if end == 0 {
+		return nil, nil
+	}
+
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, end-1); err != nil {
+		return nil, err
+	}
+	if last[0] != '\n' {
+		return nil, errors.New("the last entry is incomplete")
+	}
+
+	var data []byte
+	for offset := end; offset > 0; {
+		n := int64(chunkSize)
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+
+		chunk := make([]byte, n)
+		if _, err := f.ReadAt(chunk, offset); err != nil {
+			return nil, err
+		}
+		data = append(chunk, data...)
+
+		if i := bytes.LastIndexByte(data[:len(data)-1], '\n'); i >= 0 {
+			return data[i+1 : len(data)-1], nil
+		}
+	}
+
+	return data[:len(data)-1], nil
+}
+
+// chainedEntry holds the fields of an audit log entry that relate to its hash
+// chain.
+type chainedEntry struct {
+	Type       string      `json:"type"`
+	Chain      *HashChain  `json:"chain"`
+	Checkpoint *Checkpoint `json:"checkpoint"`
+}
+
+// parseChainedEntry parses the line of an entry of a hash chain, after the
+// prefix.
+func parseChainedEntry(line []byte, prefix string) (*chainedEntry, error) {
+	if !bytes.HasPrefix(line, []byte(prefix)) {
+		return nil, fmt.Errorf("entry does not start with the prefix %q", prefix)
+	}
+
+	var entry chainedEntry
+	err := json.Unmarshal(line[len(prefix):], &entry)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse entry: %w", err)
+	}
+	if entry.Chain == nil {
+		return nil, errors.New("entry is not part of a hash chain")
+	}
+
+	return &entry, nil
+}
+
+// HashChainVerifier verifies the hash chain of audit logs written by a
+// HashChainSink. Files are verified in the order they were written, so that
+// the chain of a rotated file continues in the next file.
+type HashChainVerifier struct {
+	// Prefix is the prefix configured for the entries of the audit device.
+	Prefix string
+
+	// Entries is the number of entries verified, including checkpoints.
+	Entries int
+
+	// First is the hash chain of the first entry verified. The log is
+	// complete up to this entry if its sequence is 1.
+	First *HashChain
+
+	// Sequence and Hash are the sequence and hash of the last entry verified.
+	Sequence uint64
+	Hash     string
+
+	// Checkpoints are the checkpoints found in the chain, whose signatures
+	// remain to be verified with the transit key.
+	Checkpoints []*Checkpoint
+}
+
+// Verify reads the entries of the log, and returns an error if they do not
+// continue the hash chain of the entries verified before.
+func (v *HashChainVerifier) Verify(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		switch {
+		case errors.Is(err, io.EOF) && len(data) == 0:
+			return nil
+		case errors.Is(err, io.EOF):
+			return fmt.Errorf("line %d: the entry is incomplete", line)
+		case err != nil:
+			return err
+		}
+
+		err = v.verifyLine(data[:len(data)-1])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+// verifyLine verifies that the line of an entry continues the hash chain.
+func (v *HashChainVerifier) verifyLine(line []byte) error {
+	entry, err := parseChainedEntry(line, v.Prefix)
+	if err != nil {
+		return err
+	}
+
+	chain := entry.Chain
+	switch {
+	case v.First == nil && chain.Sequence == 1 && chain.Previous != "":
+		return errors.New("the first entry of the chain must not have a previous hash")
+	case v.First == nil:
+		v.First = chain
+	case chain.Sequence != v.Sequence+1:
+		return fmt.Errorf("expected entry %d of the chain, found entry %d", v.Sequence+1, chain.Sequence)
+	case chain.Previous != v.Hash:
+		return fmt.Errorf("entry %d does not match the hash of entry %d", chain.Sequence, v.Sequence)
+	}
+
+	if entry.Type == CheckpointType {
+		checkpoint := entry.Checkpoint
+		switch {
+		case checkpoint == nil:
+			return errors.New("checkpoint entry is missing its checkpoint")
+		case checkpoint.Sequence != chain.Sequence-1 || checkpoint.Hash != chain.Previous:
+			return fmt.Errorf("checkpoint does not match entry %d", chain.Sequence-1)
+		}
+		v.Checkpoints = append(v.Checkpoints, checkpoint)
+	}
+
+	hash := sha256.Sum256(line)
+	v.Entries++
+	v.Sequence = chain.Sequence
+	v.Hash = hex.EncodeToString(hash[:])
+
+	return nil
+}
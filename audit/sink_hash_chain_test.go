@@ -0,0 +1,184 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/vault/internal/observability/event"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewHashChainSink ensures that parameters are checked correctly and errors
+// reported as expected when attempting to create a HashChainSink.
+func TestNewHashChainSink(t *testing.T) {
+	t.Parallel()
+
+	signer := func(context.Context, string, string, []byte) (string, error) { return "", nil }
+
+	tests := map[string]struct {
+		node                 eventlogger.Node
+		config               HashChainConfig
+		expectedErrorMessage string
+	}{
+		"happy": {
+			node:   &event.FileSink{},
+			config: HashChainConfig{Format: "json"},
+		},
+		"happy-checkpoints": {
+			node:   &event.FileSink{},
+			config: HashChainConfig{Format: "json", CheckpointMount: "transit", CheckpointKey: "audit", CheckpointInterval: 10, Signer: signer},
+		},
+		"no-node": {
+			config:               HashChainConfig{Format: "json"},
+			expectedErrorMessage: "audit.NewHashChainSink: sink node is required: invalid parameter",
+		},
+		"bad-node": {
+			node:                 &EntryFormatter{},
+			config:               HashChainConfig{Format: "json"},
+			expectedErrorMessage: "audit.NewHashChainSink: sink node must be of type 'sink': invalid parameter",
+		},
+		"jsonx": {
+			node:                 &event.FileSink{},
+			config:               HashChainConfig{Format: "jsonx"},
+			expectedErrorMessage: `audit.NewHashChainSink: hash chains require the "json" format: invalid parameter`,
+		},
+		"no-interval": {
+			node:                 &event.FileSink{},
+			config:               HashChainConfig{Format: "json", CheckpointMount: "transit", CheckpointKey: "audit", Signer: signer},
+			expectedErrorMessage: "audit.NewHashChainSink: checkpoint interval must be positive: invalid parameter",
+		},
+		"no-signer": {
+			node:                 &event.FileSink{},
+			config:               HashChainConfig{Format: "json", CheckpointMount: "transit", CheckpointKey: "audit", CheckpointInterval: 10},
+			expectedErrorMessage: "audit.NewHashChainSink: checkpoint signer is required: invalid parameter",
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewHashChainSink(tc.node, tc.config)
+
+			switch {
+			case tc.expectedErrorMessage != "":
+				require.EqualError(t, err, tc.expectedErrorMessage)
+				require.Nil(t, s)
+			default:
+				require.NoError(t, err)
+				require.NotNil(t, s)
+			}
+		})
+	}
+}
+
+// TestHashChainSink_Process ensures that entries are chained and checkpointed
+// as they are written, that the chain resumes from an existing file, and that
+// modifications of the file are detected by the HashChainVerifier.
+func TestHashChainSink_Process(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	var signed []string
+	config := HashChainConfig{
+		Format:             "json",
+		Prefix:             "vault:",
+		ResumePath:         path,
+		CheckpointInterval: 2,
+		CheckpointMount:    "transit",
+		CheckpointKey:      "audit",
+		Signer: func(_ context.Context, mountPath, keyName string, input []byte) (string, error) {
+			require.Equal(t, "transit", mountPath)
+			require.Equal(t, "audit", keyName)
+			signed = append(signed, string(input))
+			if len(signed) > 1 {
+				return "", errors.New("transit is sealed")
+			}
+			return "vault:v1:signature", nil
+		},
+	}
+
+	process := func(n int) {
+		t.Helper()
+
+		fileSink, err := event.NewFileSink(path, "json")
+		require.NoError(t, err)
+		s, err := NewHashChainSink(fileSink, config)
+		require.NoError(t, err)
+
+		for i := 0; i < n; i++ {
+			e := &eventlogger.Event{
+				Type:      eventlogger.EventType(event.AuditType.String()),
+				CreatedAt: time.Now(),
+				Payload:   &AuditEvent{Subtype: RequestType},
+			}
+			e.FormattedAs("json", []byte(`vault:{"type":"request"}`+"\n"))
+			_, err = s.Process(context.Background(), e)
+			require.NoError(t, err)
+		}
+	}
+
+	verify := func(data []byte) (*HashChainVerifier, error) {
+		v := &HashChainVerifier{Prefix: "vault:"}
+		return v, v.Verify(bytes.NewReader(data))
+	}
+
+	// The sink is recreated after the third entry, and resumes the chain.
+	process(3)
+	process(2)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	require.Len(t, lines, 8) // the last one is empty
+	require.Contains(t, string(lines[2]), `"type":"checkpoint"`)
+	require.Contains(t, string(lines[2]), `"signature":"vault:v1:signature"`)
+	require.Contains(t, string(lines[4]), `"chain":{"seq":5,"prev":"`)
+	require.Contains(t, string(lines[6]), `"error":"transit is sealed"`)
+
+	v, err := verify(data)
+	require.NoError(t, err)
+	require.Equal(t, 7, v.Entries)
+	require.Equal(t, uint64(1), v.First.Sequence)
+	require.Equal(t, uint64(7), v.Sequence)
+	require.Len(t, v.Checkpoints, 2)
+	require.Equal(t, []string{"2:" + v.Checkpoints[0].Hash, "6:" + v.Checkpoints[1].Hash}, signed)
+
+	// A rotated file continues the chain
+	v, err = verify(bytes.Join(lines[3:], nil))
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), v.First.Sequence)
+	require.Equal(t, 4, v.Entries)
+
+	tampered := bytes.Join(lines, nil)
+	tampered = bytes.Replace(tampered, []byte(`"type":"request","chain":{"seq":2`), []byte(`"type":"response","chain":{"seq":2`), 1)
+	_, err = verify(tampered)
+	require.EqualError(t, err, "line 3: entry 3 does not match the hash of entry 2")
+
+	_, err = verify(bytes.Join(append(lines[:1:1], lines[2:]...), nil))
+	require.EqualError(t, err, "line 2: expected entry 2 of the chain, found entry 3")
+
+	_, err = verify(append([]byte(`vault:{"type":"request"}`+"\n"), data...))
+	require.EqualError(t, err, "line 1: entry is not part of a hash chain")
+
+	_, err = verify(data[:len(data)-1])
+	require.EqualError(t, err, "line 7: the entry is incomplete")
+
+	// The chain does not resume from an incomplete entry
+	require.NoError(t, os.WriteFile(path, data[:len(data)-1], 0o600))
+	fileSink, err := event.NewFileSink(path, "json")
+	require.NoError(t, err)
+	_, err = NewHashChainSink(fileSink, config)
+	require.ErrorContains(t, err, "the last entry is incomplete")
+}
@@ -14,7 +14,10 @@ import (
 	"github.com/hashicorp/vault/internal/observability/event"
 )
 
-var _ eventlogger.Node = (*SinkMetricTimer)(nil)
+var (
+	_ eventlogger.Node = (*SinkMetricTimer)(nil)
+	_ event.Flusher    = (*SinkMetricTimer)(nil)
+)
 
 // SinkMetricTimer is a wrapper for any kind of eventlogger.NodeTypeSink node that
 // processes events containing an AuditEvent payload.
@@ -73,6 +76,15 @@ func (s *SinkMetricTimer) Reopen() error {
 	return s.Sink.Reopen()
 }
 
+// Flush wraps the Flush method of this underlying sink (eventlogger.Node), if
+// it is an event.Flusher.
+func (s *SinkMetricTimer) Flush() error {
+	if f, ok := s.Sink.(event.Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
 // Type wraps the Type method of this underlying sink (eventlogger.Node).
 func (s *SinkMetricTimer) Type() eventlogger.NodeType {
 	return s.Sink.Type()
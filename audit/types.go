@@ -216,23 +216,32 @@ type Response struct {
 }
 
 type Auth struct {
-	ClientToken               string              `json:"client_token,omitempty"`
-	Accessor                  string              `json:"accessor,omitempty"`
-	DisplayName               string              `json:"display_name,omitempty"`
-	Policies                  []string            `json:"policies,omitempty"`
-	TokenPolicies             []string            `json:"token_policies,omitempty"`
-	IdentityPolicies          []string            `json:"identity_policies,omitempty"`
-	ExternalNamespacePolicies map[string][]string `json:"external_namespace_policies,omitempty"`
-	NoDefaultPolicy           bool                `json:"no_default_policy,omitempty"`
-	PolicyResults             *PolicyResults      `json:"policy_results,omitempty"`
-	Metadata                  map[string]string   `json:"metadata,omitempty"`
-	NumUses                   int                 `json:"num_uses,omitempty"`
-	RemainingUses             int                 `json:"remaining_uses,omitempty"`
-	EntityID                  string              `json:"entity_id,omitempty"`
-	EntityCreated             bool                `json:"entity_created,omitempty"`
-	TokenType                 string              `json:"token_type,omitempty"`
-	TokenTTL                  int64               `json:"token_ttl,omitempty"`
-	TokenIssueTime            string              `json:"token_issue_time,omitempty"`
+	ClientToken               string                 `json:"client_token,omitempty"`
+	Accessor                  string                 `json:"accessor,omitempty"`
+	DisplayName               string                 `json:"display_name,omitempty"`
+	Policies                  []string               `json:"policies,omitempty"`
+	TokenPolicies             []string               `json:"token_policies,omitempty"`
+	IdentityPolicies          []string               `json:"identity_policies,omitempty"`
+	ExternalNamespacePolicies map[string][]string    `json:"external_namespace_policies,omitempty"`
+	NoDefaultPolicy           bool                   `json:"no_default_policy,omitempty"`
+	PolicyResults             *PolicyResults         `json:"policy_results,omitempty"`
+	Metadata                  map[string]string      `json:"metadata,omitempty"`
+	NumUses                   int                    `json:"num_uses,omitempty"`
+	RemainingUses             int                    `json:"remaining_uses,omitempty"`
+	EntityID                  string                 `json:"entity_id,omitempty"`
+	EntityCreated             bool                   `json:"entity_created,omitempty"`
+	TokenType                 string                 `json:"token_type,omitempty"`
+	TokenTTL                  int64                  `json:"token_ttl,omitempty"`
+	TokenIssueTime            string                 `json:"token_issue_time,omitempty"`
+	MFAEnforcementResults     []MFAEnforcementResult `json:"mfa_enforcement_results,omitempty"`
+}
+
+// MFAEnforcementResult records whether a login MFA enforcement targeting a
+// login applied to it, given its network conditions.
+type MFAEnforcementResult struct {
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+	Reason  string `json:"reason,omitempty"`
 }
 
 type PolicyResults struct {
@@ -298,6 +307,12 @@ type Backend interface {
 	Invalidate(context.Context)
 }
 
+// Flusher is optionally implemented by audit backends which can flush the
+// entries they have written, e.g. to durable storage, before Vault seals.
+type Flusher interface {
+	Flush(context.Context) error
+}
+
 // BackendConfig contains configuration parameters used in the factory func to
 // instantiate audit backends
 type BackendConfig struct {
@@ -312,6 +327,9 @@ type BackendConfig struct {
 
 	// MountPath is the path where this Backend is mounted
 	MountPath string
+
+	// CheckpointSigner signs the checkpoints of hash chained audit logs
+	CheckpointSigner CheckpointSigner
 }
 
 // Factory is the factory function to create an audit backend.
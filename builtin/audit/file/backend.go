@@ -22,9 +22,15 @@ import (
 const (
 	stdout  = "stdout"
 	discard = "discard"
+
+	defaultCheckpointMount    = "transit"
+	defaultCheckpointInterval = 1000
 )
 
-var _ audit.Backend = (*Backend)(nil)
+var (
+	_ audit.Backend = (*Backend)(nil)
+	_ audit.Flusher = (*Backend)(nil)
+)
 
 // Backend is the audit backend for the file-based audit store.
 //
@@ -120,7 +126,12 @@ func Factory(_ context.Context, conf *audit.BackendConfig, headersConfig audit.H
 		return nil, fmt.Errorf("%s: error configuring formatter node: %w", op, err)
 	}
 
-	err = b.configureSinkNode(conf.MountPath, filePath, conf.Config["mode"], cfg.RequiredFormat.String())
+	chainConfig, err := hashChainConfig(conf, cfg.RequiredFormat.String())
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to create hash chain config: %w", op, err)
+	}
+
+	err = b.configureSinkNode(conf.MountPath, filePath, conf.Config["mode"], cfg.RequiredFormat.String(), chainConfig)
 	if err != nil {
 		return nil, fmt.Errorf("%s: error configuring sink node: %w", op, err)
 	}
@@ -170,6 +181,17 @@ func (b *Backend) Reload(_ context.Context) error {
 	return nil
 }
 
+// Flush commits the entries written to the audit file to durable storage.
+func (b *Backend) Flush(_ context.Context) error {
+	for _, n := range b.nodeMap {
+		if f, ok := n.(event.Flusher); ok && n.Type() == eventlogger.NodeTypeSink {
+			return f.Flush()
+		}
+	}
+
+	return nil
+}
+
 func (b *Backend) Invalidate(_ context.Context) {
 	b.saltMutex.Lock()
 	defer b.saltMutex.Unlock()
@@ -216,6 +238,45 @@ func formatterConfig(config map[string]string) (audit.FormatterConfig, error) {
 	return audit.NewFormatterConfig(opts...)
 }
 
+// hashChainConfig creates the configuration of the hash chain of the audit log
+// using the config supplied to the factory. It returns nil when hash chaining
+// is not enabled.
+func hashChainConfig(conf *audit.BackendConfig, format string) (*audit.HashChainConfig, error) {
+	const op = "file.hashChainConfig"
+
+	config := conf.Config
+	if raw, ok := config["hash_chain"]; !ok {
+		return nil, nil
+	} else if enabled, err := strconv.ParseBool(raw); err != nil {
+		return nil, fmt.Errorf("%s: unable to parse 'hash_chain': %w", op, err)
+	} else if !enabled {
+		return nil, nil
+	}
+
+	chainConfig := &audit.HashChainConfig{
+		Format:             format,
+		Prefix:             config["prefix"],
+		CheckpointInterval: defaultCheckpointInterval,
+		CheckpointMount:    defaultCheckpointMount,
+		CheckpointKey:      strings.TrimSpace(config["checkpoint_key"]),
+		Signer:             conf.CheckpointSigner,
+	}
+
+	if mount, ok := config["checkpoint_mount"]; ok {
+		chainConfig.CheckpointMount = strings.Trim(strings.TrimSpace(mount), "/")
+	}
+
+	if raw, ok := config["checkpoint_interval"]; ok {
+		interval, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: unable to parse 'checkpoint_interval': %w", op, err)
+		}
+		chainConfig.CheckpointInterval = interval
+	}
+
+	return chainConfig, nil
+}
+
 // configureFilterNode is used to configure a filter node and associated ID on the Backend.
 func (b *Backend) configureFilterNode(filter string) error {
 	const op = "file.(Backend).configureFilterNode"
@@ -262,7 +323,8 @@ func (b *Backend) configureFormatterNode(formatConfig audit.FormatterConfig, opt
 }
 
 // configureSinkNode is used to configure a sink node and associated ID on the Backend.
-func (b *Backend) configureSinkNode(name string, filePath string, mode string, format string) error {
+// When chainConfig is not nil, entries are written as a hash chain.
+func (b *Backend) configureSinkNode(name string, filePath string, mode string, format string, chainConfig *audit.HashChainConfig) error {
 	const op = "file.(Backend).configureSinkNode"
 
 	name = strings.TrimSpace(name)
@@ -312,6 +374,17 @@ func (b *Backend) configureSinkNode(name string, filePath string, mode string, f
 		return fmt.Errorf("%s: file sink creation failed for path %q: %w", op, filePath, err)
 	}
 
+	if chainConfig != nil && filePath != discard {
+		if filePath != stdout {
+			chainConfig.ResumePath = filePath
+		}
+
+		sinkNode, err = audit.NewHashChainSink(sinkNode, *chainConfig)
+		if err != nil {
+			return fmt.Errorf("%s: unable to chain the entries of sink for path %q: %w", op, filePath, err)
+		}
+	}
+
 	// Wrap the sink node with metrics middleware
 	sinkMetricTimer, err := audit.NewSinkMetricTimer(sinkName, sinkNode)
 	if err != nil {
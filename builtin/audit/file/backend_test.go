@@ -445,7 +445,7 @@ func TestBackend_configureSinkNode(t *testing.T) {
 				nodeMap:    map[eventlogger.NodeID]eventlogger.Node{},
 			}
 
-			err := b.configureSinkNode(tc.name, tc.filePath, tc.mode, tc.format)
+			err := b.configureSinkNode(tc.name, tc.filePath, tc.mode, tc.format, nil)
 
 			if tc.wantErr {
 				require.Error(t, err)
@@ -488,7 +488,7 @@ func TestBackend_configureFilterFormatterSink(t *testing.T) {
 	err = b.configureFormatterNode(formatConfig)
 	require.NoError(t, err)
 
-	err = b.configureSinkNode("foo", "/tmp/foo", "0777", "json")
+	err = b.configureSinkNode("foo", "/tmp/foo", "0777", "json", nil)
 	require.NoError(t, err)
 
 	require.Len(t, b.nodeIDList, 3)
@@ -560,6 +560,48 @@ func TestBackend_Factory_Conf(t *testing.T) {
 			},
 			isErrorExpected: false,
 		},
+		"hash-chain-invalid": {
+			backendConfig: &audit.BackendConfig{
+				MountPath:  "stdout",
+				SaltConfig: &salt.Config{},
+				SaltView:   &logical.InmemStorage{},
+				Config: map[string]string{
+					"file_path":  stdout,
+					"hash_chain": "maybe",
+				},
+			},
+			isErrorExpected:      true,
+			expectedErrorMessage: "file.Factory: failed to create hash chain config: file.hashChainConfig: unable to parse 'hash_chain': strconv.ParseBool: parsing \"maybe\": invalid syntax",
+		},
+		"hash-chain-jsonx": {
+			backendConfig: &audit.BackendConfig{
+				MountPath:  "stdout",
+				SaltConfig: &salt.Config{},
+				SaltView:   &logical.InmemStorage{},
+				Config: map[string]string{
+					"file_path":  stdout,
+					"format":     "jsonx",
+					"hash_chain": "true",
+				},
+			},
+			isErrorExpected:      true,
+			expectedErrorMessage: "file.Factory: error configuring sink node: file.(Backend).configureSinkNode: unable to chain the entries of sink for path \"stdout\": audit.NewHashChainSink: hash chains require the \"json\" format: invalid parameter",
+		},
+		"hash-chain-checkpoints": {
+			backendConfig: &audit.BackendConfig{
+				MountPath:        "stdout",
+				SaltConfig:       &salt.Config{},
+				SaltView:         &logical.InmemStorage{},
+				CheckpointSigner: func(context.Context, string, string, []byte) (string, error) { return "", nil },
+				Config: map[string]string{
+					"file_path":           stdout,
+					"hash_chain":          "true",
+					"checkpoint_key":      "audit",
+					"checkpoint_interval": "100",
+				},
+			},
+			isErrorExpected: false,
+		},
 	}
 
 	for name, tc := range tests {
@@ -227,6 +227,16 @@ can only be set during role creation and once set, it can't be reset later.`,
 								Required:    true,
 								Description: "If true, the secret identifiers generated using this role will be cluster local. This can only be set during role creation and once set, it can't be reset later",
 							},
+							"token_access_windows": {
+								Type:        framework.TypeCommaStringSlice,
+								Required:    true,
+								Description: "Comma separated string or JSON list of time windows, e.g. 'Mon-Fri 09:00-17:00'. If set, the generated token may only be used during these windows.",
+							},
+							"token_access_window_timezone": {
+								Type:        framework.TypeString,
+								Required:    true,
+								Description: "The IANA time zone of the access windows of the generated token. Defaults to UTC.",
+							},
 							"token_bound_cidrs": {
 								Type:        framework.TypeCommaStringSlice,
 								Required:    true,
@@ -629,6 +629,8 @@ func TestAwsEc2_RoleCrud(t *testing.T) {
 		"period":                         int64(60),
 		"token_period":                   int64(60),
 		"token_bound_cidrs":              []string{},
+		"token_access_windows":           []string{},
+		"token_access_window_timezone":   "",
 		"token_no_default_policy":        false,
 		"token_num_uses":                 0,
 		"token_type":                     "default",
@@ -111,6 +111,7 @@ func Backend(conf *logical.BackendConfig) *databaseBackend {
 				pathConfigurePluginConnection(&b),
 				pathResetConnection(&b),
 				pathReloadPlugin(&b),
+				pathHealth(&b),
 			},
 			pathListRoles(&b),
 			pathRoles(&b),
@@ -185,6 +186,11 @@ type databaseBackend struct {
 	gaugeCollectionProcessStop sync.Once
 
 	schedule schedule.Scheduler
+
+	// circuitBreakers holds the credential issuance circuit breakers by
+	// connection name
+	circuitBreakers     map[string]*circuitBreaker
+	circuitBreakersLock sync.Mutex
 }
 
 func (b *databaseBackend) DatabaseConfig(ctx context.Context, s logical.Storage, name string) (*DatabaseConfig, error) {
@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package database
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCircuitBreakerResetTimeout is the duration after which a tripped
+	// circuit breaker lets credential issuance be attempted again, unless the
+	// connection configures another one.
+	defaultCircuitBreakerResetTimeout = 5 * time.Minute
+
+	circuitBreakerClosed   = "closed"
+	circuitBreakerOpen     = "open"
+	circuitBreakerHalfOpen = "half-open"
+)
+
+// circuitBreaker tracks the consecutive failures to create credentials on a
+// database connection, or to connect to it. Once the threshold of the
+// connection is reached, the breaker trips and no credentials are issued
+// until its reset timeout elapses. The next attempt then closes the breaker
+// if it succeeds, or trips it again if it fails.
+type circuitBreaker struct {
+	sync.Mutex
+	failures  int
+	lastError string
+	trippedAt time.Time
+}
+
+// state returns the state of the breaker at now for the given connection
+// configuration.
+func (cb *circuitBreaker) state(config *DatabaseConfig, now time.Time) string {
+	cb.Lock()
+	defer cb.Unlock()
+	return cb.stateLocked(config, now)
+}
+
+func (cb *circuitBreaker) stateLocked(config *DatabaseConfig, now time.Time) string {
+	switch {
+	case config.CircuitBreakerThreshold <= 0 || cb.failures < config.CircuitBreakerThreshold:
+		return circuitBreakerClosed
+	case now.Before(cb.trippedAt.Add(config.circuitBreakerResetTimeout())):
+		return circuitBreakerOpen
+	default:
+		return circuitBreakerHalfOpen
+	}
+}
+
+// allow returns an error if credentials must not be issued at now.
+func (cb *circuitBreaker) allow(config *DatabaseConfig, now time.Time) error {
+	cb.Lock()
+	defer cb.Unlock()
+
+	if cb.stateLocked(config, now) != circuitBreakerOpen {
+		return nil
+	}
+	retry := cb.trippedAt.Add(config.circuitBreakerResetTimeout()).Sub(now).Round(time.Second)
+	return fmt.Errorf("credential issuance is suspended after %d consecutive failures, retrying in %s; last error: %s", cb.failures, retry, cb.lastError)
+}
+
+// success resets the breaker after credentials were issued.
+func (cb *circuitBreaker) success() {
+	cb.Lock()
+	defer cb.Unlock()
+
+	cb.failures = 0
+	cb.lastError = ""
+	cb.trippedAt = time.Time{}
+}
+
+// failure records a failure at now, and returns whether it tripped the
+// breaker.
+func (cb *circuitBreaker) failure(config *DatabaseConfig, err error, now time.Time) bool {
+	cb.Lock()
+	defer cb.Unlock()
+
+	cb.failures++
+	cb.lastError = err.Error()
+	if config.CircuitBreakerThreshold <= 0 || cb.failures < config.CircuitBreakerThreshold {
+		return false
+	}
+	cb.trippedAt = now
+	return true
+}
+
+// status returns the state of the breaker for the health of the mount.
+func (cb *circuitBreaker) status(config *DatabaseConfig, now time.Time) map[string]interface{} {
+	cb.Lock()
+	defer cb.Unlock()
+
+	status := map[string]interface{}{
+		"state":                cb.stateLocked(config, now),
+		"consecutive_failures": cb.failures,
+	}
+	if cb.lastError != "" {
+		status["last_error"] = cb.lastError
+	}
+	if !cb.trippedAt.IsZero() {
+		status["tripped_at"] = cb.trippedAt.Format(time.RFC3339)
+	}
+	return status
+}
+
+func (c *DatabaseConfig) circuitBreakerResetTimeout() time.Duration {
+	if c.CircuitBreakerResetTimeout > 0 {
+		return c.CircuitBreakerResetTimeout
+	}
+	return defaultCircuitBreakerResetTimeout
+}
+
+// circuitBreaker returns the circuit breaker of the named connection.
+func (b *databaseBackend) circuitBreaker(name string) *circuitBreaker {
+	b.circuitBreakersLock.Lock()
+	defer b.circuitBreakersLock.Unlock()
+
+	if b.circuitBreakers == nil {
+		b.circuitBreakers = make(map[string]*circuitBreaker)
+	}
+	cb, ok := b.circuitBreakers[name]
+	if !ok {
+		cb = new(circuitBreaker)
+		b.circuitBreakers[name] = cb
+	}
+	return cb
+}
+
+// resetCircuitBreaker forgets the failures of the named connection, e.g.
+// once it is reconfigured.
+func (b *databaseBackend) resetCircuitBreaker(name string) {
+	b.circuitBreakersLock.Lock()
+	defer b.circuitBreakersLock.Unlock()
+	delete(b.circuitBreakers, name)
+}
+
+// recordCircuitBreakerFailure records a failure of the named connection,
+// logging when it trips its circuit breaker.
+func (b *databaseBackend) recordCircuitBreakerFailure(name string, config *DatabaseConfig, err error) {
+	if b.circuitBreaker(name).failure(config, err, time.Now()) {
+		b.Logger().Warn("circuit breaker tripped, suspending credential issuance",
+			"connection", name, "reset_timeout", config.circuitBreakerResetTimeout(), "error", err)
+	}
+}
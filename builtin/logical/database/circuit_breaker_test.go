@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package database
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	v5 "github.com/hashicorp/vault/sdk/database/dbplugin/v5"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackend_CircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	b := Backend(config)
+	require.NoError(t, b.Setup(ctx, config))
+
+	dbConfig := &DatabaseConfig{
+		PluginName:                 "mock",
+		AllowedRoles:               []string{"*"},
+		CircuitBreakerThreshold:    2,
+		CircuitBreakerResetTimeout: time.Hour,
+	}
+	require.NoError(t, storeConfig(ctx, config.StorageView, "db", dbConfig))
+	entry, err := logical.StorageEntryJSON(databaseRolePath+"web", &roleEntry{
+		DBName:         "db",
+		CredentialType: v5.CredentialTypePassword,
+		DefaultTTL:     time.Hour,
+	})
+	require.NoError(t, err)
+	require.NoError(t, config.StorageView.Put(ctx, entry))
+
+	db := new(mockNewDatabase)
+	db.On("NewUser", mock.Anything, mock.Anything).
+		Return(v5.NewUserResponse{}, errors.New("connection refused")).
+		Times(2)
+	db.On("NewUser", mock.Anything, mock.Anything).
+		Return(v5.NewUserResponse{Username: "web-user"}, nil).
+		Once()
+	b.connections.Put("db", &dbPluginInstance{
+		database: databaseVersionWrapper{v5: db},
+		id:       "id",
+		name:     "db",
+	})
+
+	request := func(path string) (*logical.Response, error) {
+		return b.HandleRequest(ctx, &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      path,
+			Storage:   config.StorageView,
+		})
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err = request("creds/web")
+		require.ErrorContains(t, err, "connection refused")
+	}
+
+	// The breaker is tripped, so the database is not called again
+	_, err = request("creds/web")
+	require.ErrorContains(t, err, "credential issuance is suspended after 2 consecutive failures")
+	var coded logical.HTTPCodedError
+	require.ErrorAs(t, err, &coded)
+	require.Equal(t, http.StatusServiceUnavailable, coded.Code())
+	db.AssertNumberOfCalls(t, "NewUser", 2)
+
+	resp, err := request("health")
+	require.NoError(t, err)
+	require.Equal(t, false, resp.Data["healthy"])
+	status := resp.Data["connections"].(map[string]interface{})["db"].(map[string]interface{})
+	require.Equal(t, circuitBreakerOpen, status["state"])
+	require.Equal(t, 2, status["consecutive_failures"])
+	require.Equal(t, "connection refused", status["last_error"])
+
+	// Issuance is attempted again once the reset timeout elapses
+	cb := b.circuitBreaker("db")
+	cb.trippedAt = cb.trippedAt.Add(-2 * time.Hour)
+	require.Equal(t, circuitBreakerHalfOpen, cb.state(dbConfig, time.Now()))
+
+	resp, err = request("creds/web")
+	require.NoError(t, err)
+	require.Equal(t, "web-user", resp.Data["username"])
+	require.Equal(t, circuitBreakerClosed, cb.state(dbConfig, time.Now()))
+
+	resp, err = request("health")
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["healthy"])
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	config := &DatabaseConfig{}
+	cb := new(circuitBreaker)
+	for i := 0; i < 10; i++ {
+		require.False(t, cb.failure(config, errors.New("failure"), time.Now()))
+	}
+	require.NoError(t, cb.allow(config, time.Now()))
+	require.Equal(t, circuitBreakerClosed, cb.state(config, time.Now()))
+}
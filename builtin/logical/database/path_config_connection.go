@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/fatih/structs"
 	"github.com/hashicorp/go-uuid"
@@ -42,6 +43,14 @@ type DatabaseConfig struct {
 	RootCredentialsRotateStatements []string `json:"root_credentials_rotate_statements" structs:"root_credentials_rotate_statements" mapstructure:"root_credentials_rotate_statements"`
 
 	PasswordPolicy string `json:"password_policy" structs:"password_policy" mapstructure:"password_policy"`
+
+	// CircuitBreakerThreshold is the number of consecutive failures to issue
+	// credentials after which issuance is suspended. Zero disables the
+	// circuit breaker.
+	CircuitBreakerThreshold int `json:"circuit_breaker_threshold,omitempty" structs:"circuit_breaker_threshold,omitempty" mapstructure:"circuit_breaker_threshold,omitempty"`
+	// CircuitBreakerResetTimeout is the duration for which issuance is
+	// suspended once the circuit breaker trips.
+	CircuitBreakerResetTimeout time.Duration `json:"circuit_breaker_reset_timeout,omitempty" structs:"-" mapstructure:"circuit_breaker_reset_timeout,omitempty"`
 }
 
 func (c *DatabaseConfig) SupportsCredentialType(credentialType v5.CredentialType) bool {
@@ -99,6 +108,7 @@ func (b *databaseBackend) pathConnectionReset() framework.OperationFunc {
 		if err := b.reloadConnection(ctx, req.Storage, name); err != nil {
 			return nil, err
 		}
+		b.resetCircuitBreaker(name)
 
 		b.dbEvent(ctx, "reset", req.Path, name, false)
 		return nil, nil
@@ -255,6 +265,18 @@ func pathConfigurePluginConnection(b *databaseBackend) *framework.Path {
 				Type:        framework.TypeString,
 				Description: `Password policy to use when generating passwords.`,
 			},
+			"circuit_breaker_threshold": {
+				Type: framework.TypeInt,
+				Description: `Number of consecutive failures to create credentials
+				or to connect to the database after which credential issuance is
+				suspended. Defaults to 0, which disables the circuit breaker.`,
+			},
+			"circuit_breaker_reset_timeout": {
+				Type: framework.TypeDurationSecond,
+				Description: `Duration for which credential issuance is suspended
+				once the circuit breaker trips, after which issuance is attempted
+				again. Defaults to 5 minutes.`,
+			},
 		},
 
 		ExistenceCheck: b.connectionExistenceCheck(),
@@ -393,6 +415,10 @@ func (b *databaseBackend) connectionReadHandler() framework.OperationFunc {
 		}
 
 		resp.Data = structs.New(config).Map()
+		if config.CircuitBreakerThreshold > 0 {
+			resp.Data["circuit_breaker_reset_timeout"] = int64(config.circuitBreakerResetTimeout().Seconds())
+			resp.Data["circuit_breaker_state"] = b.circuitBreaker(name).state(&config, time.Now())
+		}
 		return resp, nil
 	}
 }
@@ -413,6 +439,7 @@ func (b *databaseBackend) connectionDeleteHandler() framework.OperationFunc {
 		if err := b.ClearConnection(name); err != nil {
 			return nil, err
 		}
+		b.resetCircuitBreaker(name)
 
 		b.dbEvent(ctx, "config-delete", req.Path, name, true)
 		return nil, nil
@@ -473,6 +500,20 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 			config.PasswordPolicy = passwordPolicyRaw.(string)
 		}
 
+		if thresholdRaw, ok := data.GetOk("circuit_breaker_threshold"); ok {
+			config.CircuitBreakerThreshold = thresholdRaw.(int)
+		}
+		if config.CircuitBreakerThreshold < 0 {
+			return logical.ErrorResponse("circuit_breaker_threshold must not be negative"), nil
+		}
+
+		if resetTimeoutRaw, ok := data.GetOk("circuit_breaker_reset_timeout"); ok {
+			config.CircuitBreakerResetTimeout = time.Duration(resetTimeoutRaw.(int)) * time.Second
+		}
+		if config.CircuitBreakerResetTimeout < 0 {
+			return logical.ErrorResponse("circuit_breaker_reset_timeout must not be negative"), nil
+		}
+
 		// Remove these entries from the data before we store it keyed under
 		// ConnectionDetails.
 		delete(data.Raw, "name")
@@ -482,6 +523,8 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 		delete(data.Raw, "verify_connection")
 		delete(data.Raw, "root_rotation_statements")
 		delete(data.Raw, "password_policy")
+		delete(data.Raw, "circuit_breaker_threshold")
+		delete(data.Raw, "circuit_breaker_reset_timeout")
 
 		id, err := uuid.GenerateUUID()
 		if err != nil {
@@ -541,6 +584,7 @@ func (b *databaseBackend) connectionWriteHandler() framework.OperationFunc {
 		if err != nil {
 			return nil, err
 		}
+		b.resetCircuitBreaker(name)
 
 		resp := &logical.Response{}
 
@@ -688,6 +732,11 @@ accepts:
 	* "verify_connection" (default: true) - A boolean value denoting if the plugin should verify
 	   it is able to connect to the database using the provided connection
        details.
+
+	* "circuit_breaker_threshold" (default: 0) - The number of consecutive
+	   failures to create credentials or to connect to the database after
+	   which credential issuance is suspended for
+	   "circuit_breaker_reset_timeout" (default: 5m).
 `
 
 const pathResetConnectionHelpSyn = `
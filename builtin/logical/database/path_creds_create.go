@@ -6,6 +6,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/hashicorp/go-secure-stdlib/strutil"
@@ -104,9 +105,15 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 				role.CredentialType.String()), nil
 		}
 
+		// Stop issuing credentials while the database is failing
+		if err := b.circuitBreaker(role.DBName).allow(dbConfig, time.Now()); err != nil {
+			return nil, logical.CodedError(http.StatusServiceUnavailable, err.Error())
+		}
+
 		// Get the Database object
-		dbi, err := b.GetConnection(ctx, req.Storage, role.DBName)
+		dbi, err := b.GetConnectionWithConfig(ctx, role.DBName, dbConfig)
 		if err != nil {
+			b.recordCircuitBreakerFailure(role.DBName, dbConfig, err)
 			return nil, err
 		}
 
@@ -208,8 +215,10 @@ func (b *databaseBackend) pathCredsCreateRead() framework.OperationFunc {
 		newUserResp, password, err := dbi.database.NewUser(ctx, newUserReq)
 		if err != nil {
 			b.CloseIfShutdown(dbi, err)
+			b.recordCircuitBreakerFailure(role.DBName, dbConfig, err)
 			return nil, err
 		}
+		b.circuitBreaker(role.DBName).success()
 		modified = true
 		respData["username"] = newUserResp.Username
 
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// pathHealth configures a path to read the health of the mount.
+func pathHealth(b *databaseBackend) *framework.Path {
+	return &framework.Path{
+		Pattern: "health/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixDatabase,
+			OperationVerb:   "read",
+			OperationSuffix: "health",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathHealthRead(),
+		},
+
+		HelpSynopsis:    pathHealthHelpSyn,
+		HelpDescription: pathHealthHelpDesc,
+	}
+}
+
+// pathHealthRead reports the state of the circuit breaker of every connection
+// which has one. The mount is unhealthy while any of them is open.
+func (b *databaseBackend) pathHealthRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+		connNames, err := req.Storage.List(ctx, databaseConfigPath)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		healthy := true
+		connections := make(map[string]interface{})
+		for _, connName := range connNames {
+			entry, err := req.Storage.Get(ctx, databaseConfigPath+connName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read connection configuration: %w", err)
+			}
+			if entry == nil {
+				continue
+			}
+
+			var config DatabaseConfig
+			if err := entry.DecodeJSON(&config); err != nil {
+				return nil, err
+			}
+			if config.CircuitBreakerThreshold <= 0 {
+				continue
+			}
+
+			status := b.circuitBreaker(connName).status(&config, now)
+			if status["state"] == circuitBreakerOpen {
+				healthy = false
+			}
+			connections[connName] = status
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"healthy":     healthy,
+				"connections": connections,
+			},
+		}, nil
+	}
+}
+
+const pathHealthHelpSyn = `
+Read the health of the database connections.
+`
+
+const pathHealthHelpDesc = `
+This path returns the state of the credential issuance circuit breaker of each
+database connection which configures one. The mount is reported as unhealthy
+while the circuit breaker of any connection is open.
+`
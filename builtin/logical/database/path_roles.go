@@ -209,6 +209,12 @@ func staticFields() map[string]*framework.FieldSchema {
 			Description: `The window of time in which rotations are allowed to
 	occur starting from a given "rotation_schedule". Requires "rotation_schedule"
 	to be specified`,
+		},
+		"rotation_calendar": {
+			Type: framework.TypeString,
+			Description: `The name of a rotation calendar managed at
+	"sys/rotation/calendars". Automatic rotations falling in one of its freeze
+	periods are postponed until the period ends.`,
 		},
 		"rotation_statements": {
 			Type: framework.TypeStringSlice,
@@ -321,6 +327,9 @@ func (b *databaseBackend) pathStaticRoleRead(ctx context.Context, req *logical.R
 				data["rotation_window"] = role.StaticAccount.RotationWindow.Seconds()
 			}
 		}
+		if role.StaticAccount.RotationCalendar != "" {
+			data["rotation_calendar"] = role.StaticAccount.RotationCalendar
+		}
 	}
 
 	if len(role.CredentialConfig) > 0 {
@@ -615,6 +624,16 @@ func (b *databaseBackend) pathStaticRoleCreateUpdate(ctx context.Context, req *l
 		role.StaticAccount.RotationPeriod = 0
 	}
 
+	if rotationCalendarRaw, ok := data.GetOk("rotation_calendar"); ok {
+		rotationCalendar := rotationCalendarRaw.(string)
+		if rotationCalendar != "" {
+			if _, err := b.System().RotationFreeze(ctx, rotationCalendar, time.Now()); err != nil {
+				return logical.ErrorResponse("rotation_calendar is invalid", "error", err), nil
+			}
+		}
+		role.StaticAccount.RotationCalendar = rotationCalendar
+	}
+
 	if rotationStmtsRaw, ok := data.GetOk("rotation_statements"); ok {
 		role.Statements.Rotation = rotationStmtsRaw.([]string)
 	} else if req.Operation == logical.CreateOperation {
@@ -819,6 +838,10 @@ type staticAccount struct {
 	// occur starting from a given rotation_schedule.
 	RotationWindow time.Duration `json:"rotation_window"`
 
+	// RotationCalendar is the name of the rotation calendar whose freeze
+	// periods automatic rotations are postponed past.
+	RotationCalendar string `json:"rotation_calendar,omitempty"`
+
 	// Schedule holds the parsed "chron style" string representing the allowed
 	// schedule for each rotation.
 	Schedule cron.SpecSchedule `json:"schedule"`
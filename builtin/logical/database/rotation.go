@@ -252,6 +252,29 @@ func (b *databaseBackend) rotateCredential(ctx context.Context, s logical.Storag
 		return false
 	}
 
+	// Postpone rotations falling in a freeze period of the role's rotation
+	// calendar. If the rotation window passes before the period ends, the
+	// rotation moves to the next scheduled time.
+	if role.StaticAccount.RotationCalendar != "" {
+		frozenUntil, err := b.System().RotationFreeze(ctx, role.StaticAccount.RotationCalendar, now)
+		if err != nil {
+			logger.Error("unable to check rotation calendar", "calendar", role.StaticAccount.RotationCalendar, "error", err)
+			item.Priority = now.Add(10 * time.Second).Unix()
+			if err := b.pushItem(item); err != nil {
+				logger.Error("unable to push item on to queue", "error", err)
+			}
+			return true
+		}
+		if !frozenUntil.IsZero() {
+			logger.Debug("postponing rotation during freeze period", "calendar", role.StaticAccount.RotationCalendar, "until", frozenUntil)
+			item.Priority = frozenUntil.Unix()
+			if err := b.pushItem(item); err != nil {
+				logger.Error("unable to push item on to queue", "error", err)
+			}
+			return true
+		}
+	}
+
 	// send an event indicating if the rotation was a success or failure
 	rotated := false
 	defer func() {
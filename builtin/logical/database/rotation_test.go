@@ -1805,3 +1805,99 @@ func (d *TestSchedule) ValidateRotationWindow(s int) error {
 	}
 	return nil
 }
+
+type rotationFreezeSystemView struct {
+	*logical.StaticSystemView
+	frozenUntil time.Time
+}
+
+func (s *rotationFreezeSystemView) RotationFreeze(_ context.Context, calendar string, _ time.Time) (time.Time, error) {
+	if calendar != "holidays" {
+		return time.Time{}, fmt.Errorf("rotation calendar %q not found", calendar)
+	}
+	return s.frozenUntil, nil
+}
+
+func TestBackend_StaticRole_Rotation_Calendar(t *testing.T) {
+	ctx := context.Background()
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	sys := &rotationFreezeSystemView{StaticSystemView: config.System.(*logical.StaticSystemView)}
+	config.System = sys
+
+	b := Backend(config)
+	if err := b.Setup(ctx, config); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Cleanup(ctx)
+	b.schedule = &TestSchedule{}
+	b.credRotationQueue = queue.New()
+	storage := config.StorageView
+	mockDB := setupMockDB(b)
+	configureDBMount(t, storage)
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.CreateOperation,
+		Path:      "static-roles/hashicorp",
+		Storage:   storage,
+		Data: map[string]interface{}{
+			"username":          "hashicorp",
+			"db_name":           "mockv5",
+			"rotation_period":   "86400s",
+			"rotation_calendar": "unknown",
+		},
+	})
+	if err != nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), "rotation_calendar is invalid") {
+		t.Fatalf("expected invalid rotation calendar, got resp:%#v err:%v", resp, err)
+	}
+
+	createRoleWithData(t, b, storage, mockDB, "hashicorp", map[string]interface{}{
+		"username":          "hashicorp",
+		"db_name":           "mockv5",
+		"rotation_period":   "86400s",
+		"rotation_calendar": "holidays",
+	})
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "static-roles/hashicorp",
+		Storage:   storage,
+	})
+	if err != nil || resp.IsError() {
+		t.Fatal(resp, err)
+	}
+	require.Equal(t, "holidays", resp.Data["rotation_calendar"])
+
+	// makeDue pushes the role's rotation to the front of the queue
+	makeDue := func() {
+		t.Helper()
+		if _, err := b.popFromRotationQueueByKey("hashicorp"); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.pushItem(&queue.Item{Key: "hashicorp", Priority: time.Now().Unix()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A rotation during a freeze period is postponed until the period ends
+	sys.frozenUntil = time.Now().Add(time.Hour).Truncate(time.Second)
+	makeDue()
+	b.rotateCredentials(ctx, storage)
+	mockDB.AssertNumberOfCalls(t, "UpdateUser", 1)
+	item, err := b.popFromRotationQueueByKey("hashicorp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	require.Equal(t, sys.frozenUntil.Unix(), item.Priority)
+	if err := b.pushItem(item); err != nil {
+		t.Fatal(err)
+	}
+
+	// and happens once it is over
+	sys.frozenUntil = time.Time{}
+	makeDue()
+	mockDB.On("UpdateUser", mock.Anything, mock.Anything).
+		Return(v5.UpdateUserResponse{}, nil).
+		Once()
+	b.rotateCredentials(ctx, storage)
+	mockDB.AssertNumberOfCalls(t, "UpdateUser", 2)
+}
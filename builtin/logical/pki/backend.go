@@ -116,6 +116,8 @@ func Backend(conf *logical.BackendConfig) *backend {
 				"unified-crl",
 				"unified-ocsp",   // Unified OCSP POST
 				"unified-ocsp/*", // Unified OCSP GET
+				"spiffe/bundle",
+				"timestamp",
 
 				// ACME paths are added below
 			},
@@ -157,6 +159,7 @@ func Backend(conf *logical.BackendConfig) *backend {
 				"ocsp/*",         // OCSP GET
 				"unified-ocsp",   // Unified OCSP POST
 				"unified-ocsp/*", // Unified OCSP GET
+				"timestamp",      // RFC 3161 timestamp request
 			},
 		},
 
@@ -173,6 +176,8 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathConfigCRL(&b),
 			pathConfigURLs(&b),
 			pathConfigCluster(&b),
+			pathConfigIssuanceQuotas(&b),
+			pathConfigWorkerPools(&b),
 			pathSignVerbatim(&b),
 			pathSign(&b),
 			pathIssue(&b),
@@ -220,11 +225,16 @@ func Backend(conf *logical.BackendConfig) *backend {
 			pathFetchValidRaw(&b),
 			pathFetchValid(&b),
 			pathFetchListCerts(&b),
+			pathSPIFFEBundle(&b),
 
 			// OCSP APIs
 			buildPathOcspGet(&b),
 			buildPathOcspPost(&b),
 
+			// Timestamp authority APIs
+			pathConfigTSA(&b),
+			pathTimestamp(&b),
+
 			// CRL Signing
 			pathResignCrls(&b),
 			pathSignRevocationList(&b),
@@ -297,6 +307,8 @@ func Backend(conf *logical.BackendConfig) *backend {
 	b.unifiedTransferStatus = newUnifiedTransferStatus()
 
 	b.acmeState = NewACMEState()
+	b.issuanceQuotas = newIssuanceQuotaTracker()
+	b.workerPools = newWorkerPools(b.backendUUID)
 	b.certificateCounter = NewCertificateCounter(b.backendUUID)
 
 	// It is important that we call SetupEnt at the very end as
@@ -332,6 +344,12 @@ type backend struct {
 	// Context around ACME operations
 	acmeState       *acmeState
 	acmeAccountLock sync.RWMutex // (Write) Locked on Tidy, (Read) Locked on Account Creation
+
+	// Certificates recently issued against issuance quotas
+	issuanceQuotas *issuanceQuotaTracker
+
+	// Pools running the CPU-heavy operations of the mount
+	workerPools *workerPools
 }
 
 // BackendOps a bridge/legacy interface until we can further
@@ -425,6 +443,10 @@ func (b *backend) initialize(ctx context.Context, ir *logical.InitializationRequ
 		return err
 	}
 
+	if err := b.reloadWorkerPoolsConfig(sc); err != nil {
+		return err
+	}
+
 	err := b.initializePKIIssuersStorage(ctx)
 	if err != nil {
 		return err
@@ -588,6 +610,10 @@ func (b *backend) invalidate(ctx context.Context, key string) {
 		b.CrlBuilder().markConfigDirty()
 	case key == storageAcmeConfig:
 		b.GetAcmeState().markConfigDirty()
+	case key == workerPoolsConfigPath:
+		if err := b.reloadWorkerPoolsConfig(b.makeStorageContext(ctx, b.storage)); err != nil {
+			b.Logger().Error("failed to reload worker pools configuration", "error", err)
+		}
 	case key == storageIssuerConfig:
 		b.CrlBuilder().invalidateCRLBuildTime()
 	case strings.HasPrefix(key, crossRevocationPrefix):
@@ -738,6 +764,9 @@ func (b *backend) periodicFunc(ctx context.Context, request *logical.Request) er
 	// First tidy any ACME nonces to free memory.
 	b.GetAcmeState().DoTidyNonces()
 
+	// Likewise forget the issuances no longer counted by issuance quotas.
+	b.issuanceQuotas.prune(time.Now())
+
 	// Then run unified transfer.
 	backgroundSc := b.makeStorageContext(context.Background(), b.storage)
 	go runUnifiedTransfer(backgroundSc)
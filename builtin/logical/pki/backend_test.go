@@ -55,6 +55,7 @@ import (
 	logicaltest "github.com/hashicorp/vault/helper/testhelpers/logical"
 	vaulthttp "github.com/hashicorp/vault/http"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/cryptopolicy"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault"
 	"github.com/mitchellh/mapstructure"
@@ -3608,6 +3609,304 @@ func TestBackend_AllowedDomainsTemplate(t *testing.T) {
 	}
 }
 
+func TestBackend_IssuancePolicy(t *testing.T) {
+	t.Parallel()
+	coreConfig := &vault.CoreConfig{
+		CredentialBackends: map[string]logical.Factory{
+			"userpass": userpass.Factory,
+		},
+		LogicalBackends: map[string]logical.Factory{
+			"pki": Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+	client := cluster.Cores[0].Client
+
+	err := client.Sys().PutPolicy("test", `
+   path "pki/*" {
+     capabilities = ["update"]
+   }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Sys().EnableAuth("userpass", "userpass", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("auth/userpass/users/web", map[string]interface{}{
+		"password": "test",
+		"policies": "test",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Sys().Mount("pki", &api.MountInput{
+		Type: "pki",
+		Config: api.MountConfigInput{
+			DefaultLeaseTTL: "16h",
+			MaxLeaseTTL:     "60h",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"ttl":         "40h",
+		"common_name": "myvault.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Policies referencing unknown fields are rejected
+	_, err = client.Logical().Write("pki/roles/bad", map[string]interface{}{
+		"allow_any_name":  true,
+		"issuance_policy": "requester.name == web",
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid issuance_policy") {
+		t.Fatalf("expected invalid issuance_policy error, got: %v", err)
+	}
+
+	_, err = client.Logical().Write("pki/roles/host", map[string]interface{}{
+		"allow_any_name":  true,
+		"issuance_policy": "{{identity.entity.metadata.hostname}} in dns_sans and ip_sans is empty",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Logical().Write("pki/roles/derived", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"derived_sans":     "{{identity.entity.metadata.hostname}}",
+		"issuance_policy":  `"web" in entity.groups or has_csr == false`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Log in and set the hostname of the entity
+	userpassAuth, err := auth.NewUserpassAuth("web", &auth.Password{FromString: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	userClient, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := userClient.Auth().Login(context.Background(), userpassAuth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Logical().Write("identity/entity/id/"+secret.Auth.EntityID, map[string]interface{}{
+		"metadata": "hostname=web01.example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The requested names must include the hostname of the entity
+	_, err = userClient.Logical().Write("pki/issue/host", map[string]interface{}{
+		"common_name": "web01.example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = userClient.Logical().Write("pki/issue/host", map[string]interface{}{
+		"common_name": "web02.example.com",
+	})
+	if err == nil || !strings.Contains(err.Error(), "issuance denied by the issuance policy") {
+		t.Fatalf("expected issuance to be denied, got: %v", err)
+	}
+
+	// Tokens without an entity cannot satisfy templated policies
+	_, err = client.Logical().Write("pki/issue/host", map[string]interface{}{
+		"common_name": "web01.example.com",
+	})
+	if err == nil || !strings.Contains(err.Error(), "issuance denied by the issuance policy") {
+		t.Fatalf("expected issuance to be denied, got: %v", err)
+	}
+
+	// The hostname of the entity is added to the SANs
+	resp, err := userClient.Logical().Write("pki/issue/derived", map[string]interface{}{
+		"common_name": "api.example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := parseCert(t, resp.Data["certificate"].(string))
+	if !reflect.DeepEqual(cert.DNSNames, []string{"api.example.com", "web01.example.com"}) {
+		t.Fatalf("unexpected DNS SANs: %v", cert.DNSNames)
+	}
+}
+
+func TestBackend_SPIFFE(t *testing.T) {
+	t.Parallel()
+	coreConfig := &vault.CoreConfig{
+		CredentialBackends: map[string]logical.Factory{
+			"userpass": userpass.Factory,
+		},
+		LogicalBackends: map[string]logical.Factory{
+			"pki": Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+	client := cluster.Cores[0].Client
+
+	err := client.Sys().PutPolicy("test", `
+   path "pki/*" {
+     capabilities = ["update"]
+   }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Sys().EnableAuth("userpass", "userpass", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Logical().Write("auth/userpass/users/web", map[string]interface{}{
+		"password": "test",
+		"policies": "test",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Sys().Mount("pki", &api.MountInput{
+		Type: "pki",
+		Config: api.MountConfigInput{
+			DefaultLeaseTTL: "16h",
+			MaxLeaseTTL:     "60h",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Logical().Write("pki/root/generate/internal", map[string]interface{}{
+		"ttl":         "40h",
+		"common_name": "myvault.com",
+		"key_type":    "ec",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := parseCert(t, resp.Data["certificate"].(string))
+
+	// SVIDs cannot be used to sign certificates
+	_, err = client.Logical().Write("pki/roles/bad", map[string]interface{}{
+		"spiffe_trust_domain": "example.org",
+		"key_usage":           "DigitalSignature,CertSign",
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid key_usage") {
+		t.Fatalf("expected invalid key_usage error, got: %v", err)
+	}
+
+	_, err = client.Logical().Write("pki/roles/svid", map[string]interface{}{
+		"spiffe_trust_domain": "example.org",
+		"spiffe_id_template":  "/ns/{{identity.entity.metadata.namespace}}/sa/{{identity.entity.name}}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Logical().Write("pki/roles/upstream", map[string]interface{}{
+		"spiffe_trust_domain": "example.org",
+		"allowed_uri_sans":    "spiffe://*",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userpassAuth, err := auth.NewUserpassAuth("web", &auth.Password{FromString: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	userClient, err := client.Clone()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := userClient.Auth().Login(context.Background(), userpassAuth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Logical().Write("identity/entity/id/"+secret.Auth.EntityID, map[string]interface{}{
+		"name":     "web",
+		"metadata": "namespace=prod",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The SPIFFE ID is rendered from the identity of the requester
+	resp, err = userClient.Logical().Write("pki/issue/svid", map[string]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := parseCert(t, resp.Data["certificate"].(string))
+	if len(cert.URIs) != 1 || cert.URIs[0].String() != "spiffe://example.org/ns/prod/sa/web" {
+		t.Fatalf("unexpected URI SANs: %v", cert.URIs)
+	}
+	if cert.IsCA || cert.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		t.Fatalf("unexpected SVID constraints: ca=%v key_usage=%v", cert.IsCA, cert.KeyUsage)
+	}
+
+	// Tokens without an entity have no SPIFFE ID
+	_, err = client.Logical().Write("pki/issue/svid", map[string]interface{}{})
+	if err == nil || !strings.Contains(err.Error(), "failed to render SPIFFE ID") {
+		t.Fatalf("expected SPIFFE ID error, got: %v", err)
+	}
+
+	// Requested SPIFFE IDs must be in the trust domain
+	resp, err = client.Logical().Write("pki/issue/upstream", map[string]interface{}{
+		"uri_sans": "spiffe://example.org/workload",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert = parseCert(t, resp.Data["certificate"].(string))
+	if len(cert.URIs) != 1 || cert.URIs[0].String() != "spiffe://example.org/workload" {
+		t.Fatalf("unexpected URI SANs: %v", cert.URIs)
+	}
+	for _, uriSANs := range []string{
+		"spiffe://other.org/workload",
+		"spiffe://example.org/",
+		"spiffe://example.org/a,spiffe://example.org/b",
+	} {
+		_, err = client.Logical().Write("pki/issue/upstream", map[string]interface{}{
+			"uri_sans": uriSANs,
+		})
+		if err == nil {
+			t.Fatalf("expected error issuing SVID for %q", uriSANs)
+		}
+	}
+
+	// The bundle holds the certificate of the issuer
+	bundleResp, err := client.Logical().ReadRawWithContext(context.Background(), "pki/spiffe/bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bundleResp.Body.Close()
+	var bundle struct {
+		Keys []struct {
+			Use string   `json:"use"`
+			Kty string   `json:"kty"`
+			X5C []string `json:"x5c"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(bundleResp.Body).Decode(&bundle); err != nil {
+		t.Fatal(err)
+	}
+	if len(bundle.Keys) != 1 || bundle.Keys[0].Use != "x509-svid" || bundle.Keys[0].Kty != "EC" {
+		t.Fatalf("unexpected bundle: %#v", bundle)
+	}
+	if bundle.Keys[0].X5C[0] != base64.StdEncoding.EncodeToString(root.Raw) {
+		t.Fatalf("bundle does not hold the issuer certificate")
+	}
+}
+
 func TestReadWriteDeleteRoles(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
@@ -3712,6 +4011,12 @@ func TestReadWriteDeleteRoles(t *testing.T) {
 		"issuer_ref":                         "default",
 		"cn_validations":                     []interface{}{"email", "hostname"},
 		"allowed_user_ids":                   []interface{}{},
+		"issuance_policy":                    "",
+		"derived_sans":                       []interface{}{},
+		"spiffe_trust_domain":                "",
+		"spiffe_id_template":                 "",
+		"max_issuances_per_hour":             json.Number("0"),
+		"max_entity_issuances_per_hour":      json.Number("0"),
 	}
 
 	if diff := deep.Equal(expectedData, resp.Data); len(diff) > 0 {
@@ -4821,6 +5126,69 @@ func TestBackend_Roles_KeySizeRegression(t *testing.T) {
 	t.Logf("Key size regression expanded matrix test scenarios: %d", tested)
 }
 
+func TestBackend_CryptoPolicy(t *testing.T) {
+	t.Parallel()
+
+	config := logical.TestBackendConfig()
+	config.StorageView = &logical.InmemStorage{}
+	config.System = &logical.StaticSystemView{
+		DefaultLeaseTTLVal: 24 * time.Hour,
+		MaxLeaseTTLVal:     32 * 24 * time.Hour,
+		PluginEnvironment: &logical.PluginEnvironment{
+			CryptoPolicy: cryptopolicy.FIPS1403,
+		},
+	}
+	b := Backend(config)
+	require.NoError(t, b.Setup(context.Background(), config))
+	b.pkiStorageVersion.Store(1)
+	s := config.StorageView
+
+	// P-224 is rejected for issuers
+	_, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root.example.com",
+		"key_type":    "ec",
+		"key_bits":    224,
+	})
+	require.ErrorContains(t, err, `crypto policy "fips-140-3" does not allow PKI key "ec-224"`)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root.example.com",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed generating root")
+
+	_, err = CBWrite(b, s, "roles/any", map[string]interface{}{
+		"allow_any_name": true,
+		"key_type":       "any",
+	})
+	require.NoError(t, err)
+
+	// and for signed CSRs
+	key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "leaf.example.com"},
+	}, key)
+	require.NoError(t, err)
+	_, err = CBWrite(b, s, "sign/any", map[string]interface{}{
+		"common_name": "leaf.example.com",
+		"csr":         string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})),
+	})
+	require.ErrorContains(t, err, `crypto policy "fips-140-3" does not allow PKI key "ec-224"`)
+
+	_, err = CBWrite(b, s, "roles/ec", map[string]interface{}{
+		"allow_any_name": true,
+		"key_type":       "ec",
+		"key_bits":       384,
+	})
+	require.NoError(t, err)
+	resp, err = CBWrite(b, s, "issue/ec", map[string]interface{}{
+		"common_name": "leaf.example.com",
+		"ttl":         "1h",
+	})
+	requireSuccessNonNilResponse(t, resp, err, "failed issuing certificate")
+}
+
 func TestRootWithExistingKey(t *testing.T) {
 	t.Parallel()
 	b, s := CreateBackendWithStorage(t)
@@ -6794,9 +7162,12 @@ func TestProperAuthing(t *testing.T) {
 		"config/ca":                              shouldBeAuthed,
 		"config/cluster":                         shouldBeAuthed,
 		"config/crl":                             shouldBeAuthed,
+		"config/issuance-quotas":                 shouldBeAuthed,
 		"config/issuers":                         shouldBeAuthed,
 		"config/keys":                            shouldBeAuthed,
+		"config/tsa":                             shouldBeAuthed,
 		"config/urls":                            shouldBeAuthed,
+		"config/worker-pools":                    shouldBeAuthed,
 		"crl":                                    shouldBeUnauthedReadList,
 		"crl/pem":                                shouldBeUnauthedReadList,
 		"crl/delta":                              shouldBeUnauthedReadList,
@@ -6873,9 +7244,11 @@ func TestProperAuthing(t *testing.T) {
 		"sign-verbatim":                          shouldBeAuthed,
 		"sign-verbatim/test":                     shouldBeAuthed,
 		"sign/test":                              shouldBeAuthed,
+		"spiffe/bundle":                          shouldBeUnauthedReadList,
 		"tidy":                                   shouldBeAuthed,
 		"tidy-cancel":                            shouldBeAuthed,
 		"tidy-status":                            shouldBeAuthed,
+		"timestamp":                              shouldBeUnauthedWriteOnly,
 		"unified-crl":                            shouldBeUnauthedReadList,
 		"unified-crl/pem":                        shouldBeUnauthedReadList,
 		"unified-crl/delta":                      shouldBeUnauthedReadList,
@@ -381,7 +381,9 @@ func (cb *CrlBuilder) _doRebuild(sc *storageContext, forceNew bool, ignoreForceF
 
 		// if forceRebuild was requested, that should force a complete rebuild even if requested not too by forceNew
 		myForceNew := forceBuildFlag || forceNew
-		return buildCRLs(sc, myForceNew)
+		return cb.buildOnPool(sc, func() ([]string, error) {
+			return buildCRLs(sc, myForceNew)
+		})
 	}
 
 	return nil, nil
@@ -516,7 +518,9 @@ func (cb *CrlBuilder) rebuildDeltaCRLsIfForced(sc *storageContext, override bool
 	}
 
 	// Finally, we must've needed to do the rebuild. Execute!
-	return cb.rebuildDeltaCRLsHoldingLock(sc, false)
+	return cb.buildOnPool(sc, func() ([]string, error) {
+		return cb.rebuildDeltaCRLsHoldingLock(sc, false)
+	})
 }
 
 func (cb *CrlBuilder) _shouldRebuildLocalCRLs(sc *storageContext, override bool) (bool, error) {
@@ -643,7 +647,21 @@ func (cb *CrlBuilder) rebuildDeltaCRLs(sc *storageContext, forceNew bool) ([]str
 	cb._builder.Lock()
 	defer cb._builder.Unlock()
 
-	return cb.rebuildDeltaCRLsHoldingLock(sc, forceNew)
+	return cb.buildOnPool(sc, func() ([]string, error) {
+		return cb.rebuildDeltaCRLsHoldingLock(sc, forceNew)
+	})
+}
+
+// buildOnPool runs a CRL build on the CRL building pool of the mount. Builds
+// must not be nested, as the pool may have a single worker.
+func (cb *CrlBuilder) buildOnPool(sc *storageContext, build func() ([]string, error)) ([]string, error) {
+	var warnings []string
+	err := runOnPool(sc.Context, sc.Backend.workerPools.crl, func() error {
+		var err error
+		warnings, err = build()
+		return err
+	})
+	return warnings, err
 }
 
 func (cb *CrlBuilder) rebuildDeltaCRLsHoldingLock(sc *storageContext, forceNew bool) ([]string, error) {
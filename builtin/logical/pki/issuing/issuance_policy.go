@@ -0,0 +1,245 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package issuing
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-bexpr"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/identitytpl"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// issuancePolicyTemplateRegex matches the identity templates of an issuance
+// policy, which are replaced by the quoted value they render to before the
+// policy is evaluated.
+var issuancePolicyTemplateRegex = regexp.MustCompile(`\{\{[^}]*\}\}`)
+
+// IssuancePolicyInput is what the issuance policy of a role is evaluated
+// against: the names of the certificate about to be issued, the CSR if one was
+// given, and the identity of the requester.
+type IssuancePolicyInput struct {
+	CommonName string               `bexpr:"common_name"`
+	DNSSANs    []string             `bexpr:"dns_sans"`
+	EmailSANs  []string             `bexpr:"email_sans"`
+	IPSANs     []string             `bexpr:"ip_sans"`
+	URISANs    []string             `bexpr:"uri_sans"`
+	HasCSR     bool                 `bexpr:"has_csr"`
+	CSR        IssuancePolicyCSR    `bexpr:"csr"`
+	Entity     IssuancePolicyEntity `bexpr:"entity"`
+}
+
+// IssuancePolicyCSR holds the requested values of a CSR, which may differ
+// from the issued ones depending on the use_csr_* options of the role.
+type IssuancePolicyCSR struct {
+	CommonName string   `bexpr:"common_name"`
+	DNSSANs    []string `bexpr:"dns_sans"`
+	EmailSANs  []string `bexpr:"email_sans"`
+	IPSANs     []string `bexpr:"ip_sans"`
+	URISANs    []string `bexpr:"uri_sans"`
+	KeyType    string   `bexpr:"key_type"`
+	KeyBits    int      `bexpr:"key_bits"`
+}
+
+// IssuancePolicyEntity is the identity entity of the requester, empty if the
+// request was made without one.
+type IssuancePolicyEntity struct {
+	ID       string            `bexpr:"id"`
+	Name     string            `bexpr:"name"`
+	Metadata map[string]string `bexpr:"metadata"`
+	Groups   []string          `bexpr:"groups"`
+}
+
+// ValidateIssuancePolicy checks that the given issuance policy parses and only
+// references fields of IssuancePolicyInput.
+func ValidateIssuancePolicy(policy string) error {
+	if _, _, err := identitytpl.PopulateString(identitytpl.PopulateStringInput{
+		Mode:              identitytpl.JSONTemplating,
+		ValidityCheckOnly: true,
+		String:            policy,
+	}); err != nil {
+		return fmt.Errorf("invalid identity template: %w", err)
+	}
+
+	eval, err := bexpr.CreateEvaluator(issuancePolicyTemplateRegex.ReplaceAllString(policy, `""`))
+	if err != nil {
+		return err
+	}
+
+	// Catch references to unknown fields now rather than failing every
+	// issuance against the role
+	if _, err := eval.Evaluate(&IssuancePolicyInput{}); err != nil {
+		return fmt.Errorf("policy references an unsupported field: %w", err)
+	}
+	return nil
+}
+
+// ValidateDerivedSANs checks that the given derived SANs are valid identity
+// templates.
+func ValidateDerivedSANs(sans []string) error {
+	for _, san := range sans {
+		if _, _, err := identitytpl.PopulateString(identitytpl.PopulateStringInput{
+			Mode:              identitytpl.ACLTemplating,
+			ValidityCheckOnly: true,
+			String:            san,
+		}); err != nil {
+			return fmt.Errorf("invalid identity template %q: %w", san, err)
+		}
+	}
+	return nil
+}
+
+// requesterIdentity returns the entity of the requester and its groups, or
+// nil if the request was made without an entity.
+func requesterIdentity(b logical.SystemView, entityInfo EntityInfo) (*logical.Entity, []*logical.Group, error) {
+	if entityInfo.EntityID == "" {
+		return nil, nil, nil
+	}
+
+	entity, err := b.EntityInfo(entityInfo.EntityID)
+	if err != nil {
+		return nil, nil, errutil.InternalError{Err: fmt.Sprintf("failed to look up entity: %v", err)}
+	}
+	if entity == nil {
+		return nil, nil, nil
+	}
+
+	groups, err := b.GroupsForEntity(entityInfo.EntityID)
+	if err != nil {
+		return nil, nil, errutil.InternalError{Err: fmt.Sprintf("failed to look up groups of entity: %v", err)}
+	}
+	return entity, groups, nil
+}
+
+// deriveSANs renders the derived SANs of the role for the requester, sorting
+// them into DNS names and email addresses like alt_names.
+func deriveSANs(role *RoleEntry, entity *logical.Entity, groups []*logical.Group) ([]string, []string, error) {
+	var dnsNames, emailAddresses []string
+	for _, tpl := range role.DerivedSANs {
+		_, san, err := identitytpl.PopulateString(identitytpl.PopulateStringInput{
+			Mode:   identitytpl.ACLTemplating,
+			String: tpl,
+			Entity: entity,
+			Groups: groups,
+		})
+		if err != nil {
+			return nil, nil, errutil.UserError{Err: fmt.Sprintf("failed to derive SAN from %q: %v", tpl, err)}
+		}
+		if san == "" {
+			return nil, nil, errutil.UserError{Err: fmt.Sprintf("SAN derived from %q is empty", tpl)}
+		}
+
+		if strings.Contains(san, "@") {
+			emailAddresses = append(emailAddresses, san)
+			continue
+		}
+		if !hostnameRegex.MatchString(san) {
+			return nil, nil, errutil.UserError{Err: fmt.Sprintf("SAN %q derived from %q is not a valid hostname", san, tpl)}
+		}
+		dnsNames = append(dnsNames, san)
+	}
+	return dnsNames, emailAddresses, nil
+}
+
+// evaluateIssuancePolicy returns a UserError if the issuance policy of the
+// role does not allow issuing a certificate for the given input. Identity
+// templates in the policy are rendered as quoted strings first, so that the
+// requested names can be compared with values of the requester's identity.
+func evaluateIssuancePolicy(role *RoleEntry, entity *logical.Entity, groups []*logical.Group, input *IssuancePolicyInput) error {
+	_, policy, err := identitytpl.PopulateString(identitytpl.PopulateStringInput{
+		Mode:   identitytpl.JSONTemplating,
+		String: role.IssuancePolicy,
+		Entity: entity,
+		Groups: groups,
+	})
+	if err != nil {
+		return errutil.UserError{Err: fmt.Sprintf("issuance denied by the issuance policy of this role: %v", err)}
+	}
+
+	eval, err := bexpr.CreateEvaluator(policy)
+	if err != nil {
+		return errutil.UserError{Err: fmt.Sprintf("failed to parse the issuance policy of this role: %v", err)}
+	}
+
+	if entity != nil {
+		input.Entity = IssuancePolicyEntity{
+			ID:       entity.ID,
+			Name:     entity.Name,
+			Metadata: entity.Metadata,
+		}
+		for _, group := range groups {
+			input.Entity.Groups = append(input.Entity.Groups, group.Name)
+		}
+	}
+
+	allowed, err := eval.Evaluate(input)
+	if err != nil {
+		return errutil.UserError{Err: fmt.Sprintf("failed to evaluate the issuance policy of this role: %v", err)}
+	}
+	if !allowed {
+		return errutil.UserError{Err: "issuance denied by the issuance policy of this role"}
+	}
+	return nil
+}
+
+// newIssuancePolicyInput builds the input of an issuance policy from the
+// names of the certificate about to be issued and the CSR, if any.
+func newIssuancePolicyInput(cn string, dnsNames, emailAddresses []string, ipAddresses []net.IP, uris []*url.URL, csr *x509.CertificateRequest) *IssuancePolicyInput {
+	input := &IssuancePolicyInput{
+		CommonName: cn,
+		DNSSANs:    dnsNames,
+		EmailSANs:  emailAddresses,
+		IPSANs:     ipStrings(ipAddresses),
+		URISANs:    uriStrings(uris),
+	}
+	if csr != nil {
+		input.HasCSR = true
+		input.CSR = IssuancePolicyCSR{
+			CommonName: csr.Subject.CommonName,
+			DNSSANs:    csr.DNSNames,
+			EmailSANs:  csr.EmailAddresses,
+			IPSANs:     ipStrings(csr.IPAddresses),
+			URISANs:    uriStrings(csr.URIs),
+			KeyType:    csrKeyType(csr),
+			KeyBits:    certutil.GetPublicKeySize(csr.PublicKey),
+		}
+	}
+	return input
+}
+
+func csrKeyType(csr *x509.CertificateRequest) string {
+	switch csr.PublicKeyAlgorithm {
+	case x509.RSA:
+		return "rsa"
+	case x509.ECDSA:
+		return "ec"
+	case x509.Ed25519:
+		return "ed25519"
+	default:
+		return ""
+	}
+}
+
+func ipStrings(ips []net.IP) []string {
+	out := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		out = append(out, ip.String())
+	}
+	return out
+}
+
+func uriStrings(uris []*url.URL) []string {
+	out := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		out = append(out, uri.String())
+	}
+	return out
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/cryptopolicy"
 	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/ryanuber/go-glob"
@@ -83,10 +84,38 @@ type CreationBundleInput interface {
 	GetUserIds() []string
 }
 
+// checkCryptoPolicy returns an error if the crypto policy of the server does
+// not allow certificates with keys of the given type and size.
+func checkCryptoPolicy(b logical.SystemView, keyType string, keyBits int) error {
+	env, err := b.PluginEnv(context.Background())
+	if err != nil {
+		return errutil.InternalError{Err: fmt.Sprintf("failed to read crypto policy: %v", err)}
+	}
+	if env == nil || env.CryptoPolicy == cryptopolicy.None {
+		return nil
+	}
+
+	keyBits, err = certutil.DefaultOrValueKeyBits(keyType, keyBits)
+	if err != nil {
+		return errutil.UserError{Err: err.Error()}
+	}
+	if err := cryptopolicy.CheckPKIKey(env.CryptoPolicy, keyType, keyBits); err != nil {
+		return errutil.UserError{Err: err.Error()}
+	}
+	return nil
+}
+
 // GenerateCreationBundle is a shared function that reads parameters supplied
 // from the various endpoints and generates a CreationParameters with the
 // parameters that can be used to issue or sign
 func GenerateCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo EntityInfo, cb CreationBundleInput, caSign *certutil.CAInfoBundle, csr *x509.CertificateRequest) (*certutil.CreationBundle, []string, error) {
+	// Keys of signed CSRs are checked by SignCert
+	if csr == nil {
+		if err := checkCryptoPolicy(b, role.KeyType, role.KeyBits); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Read in names -- CN, DNS and email addresses
 	var cn string
 	var ridSerialNumber string
@@ -99,7 +128,9 @@ func GenerateCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo En
 		}
 		if cn == "" {
 			cn = cb.GetCommonName()
-			if cn == "" && role.RequireCN {
+			// SVIDs are identified by their SPIFFE ID rather than their
+			// common name
+			if cn == "" && role.RequireCN && role.SPIFFETrustDomain == "" {
 				return nil, nil, errutil.UserError{Err: `the common_name field is required, or must be provided in a CSR with "use_csr_common_name" set to true, unless "require_cn" is set to false`}
 			}
 		}
@@ -199,6 +230,26 @@ func GenerateCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo En
 		}
 	}
 
+	// Add the SANs derived from the requester's identity, which are not
+	// subject to the name restrictions of the role
+	var entity *logical.Entity
+	var groups []*logical.Group
+	if len(role.DerivedSANs) > 0 || role.IssuancePolicy != "" || role.SPIFFEIDTemplate != "" {
+		var err error
+		entity, groups, err = requesterIdentity(b, entityInfo)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(role.DerivedSANs) > 0 {
+		derivedDNSNames, derivedEmailAddresses, err := deriveSANs(role, entity, groups)
+		if err != nil {
+			return nil, nil, err
+		}
+		dnsNames = append(dnsNames, derivedDNSNames...)
+		emailAddresses = append(emailAddresses, derivedEmailAddresses...)
+	}
+
 	// otherSANsInput has the same format as the other_sans HTTP param in the
 	// Vault PKI API: it is a list of strings of the form <oid>;<type>:<value>
 	// where <type> must be UTF8/UTF-8.
@@ -320,6 +371,23 @@ func GenerateCreationBundle(b logical.SystemView, role *RoleEntry, entityInfo En
 		}
 	}
 
+	// SVIDs have exactly one URI SAN, the SPIFFE ID of the workload
+	if role.SPIFFETrustDomain != "" {
+		id, err := spiffeID(role, entity, groups, URIs)
+		if err != nil {
+			return nil, nil, err
+		}
+		URIs = []*url.URL{id}
+	}
+
+	// Check the issuance policy of the role against the final names
+	if role.IssuancePolicy != "" {
+		input := newIssuancePolicyInput(cn, strutil.RemoveDuplicates(dnsNames, false), strutil.RemoveDuplicates(emailAddresses, false), ipAddresses, URIs, csr)
+		if err := evaluateIssuancePolicy(role, entity, groups, input); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Most of these could also be RemoveDuplicateStable, or even
 	// leave duplicates in, but OU is the one most likely to be duplicated.
 	subject := pkix.Name{
@@ -85,6 +85,12 @@ type RoleEntry struct {
 	NotBeforeDuration             time.Duration `json:"not_before_duration"`
 	NotAfter                      string        `json:"not_after"`
 	Issuer                        string        `json:"issuer"`
+	IssuancePolicy                string        `json:"issuance_policy"`
+	DerivedSANs                   []string      `json:"derived_sans"`
+	SPIFFETrustDomain             string        `json:"spiffe_trust_domain"`
+	SPIFFEIDTemplate              string        `json:"spiffe_id_template"`
+	MaxIssuancesPerHour           int           `json:"max_issuances_per_hour"`
+	MaxEntityIssuancesPerHour     int           `json:"max_entity_issuances_per_hour"`
 	// Name is only set when the role has been stored, on the fly roles have a blank name
 	Name string `json:"-"`
 	// WasModified indicates to callers if the returned entry is different than the persisted version
@@ -139,6 +145,12 @@ func (r *RoleEntry) ToResponseData() map[string]interface{} {
 		"not_before_duration":                int64(r.NotBeforeDuration.Seconds()),
 		"not_after":                          r.NotAfter,
 		"issuer_ref":                         r.Issuer,
+		"issuance_policy":                    r.IssuancePolicy,
+		"derived_sans":                       r.DerivedSANs,
+		"spiffe_trust_domain":                r.SPIFFETrustDomain,
+		"spiffe_id_template":                 r.SPIFFEIDTemplate,
+		"max_issuances_per_hour":             r.MaxIssuancesPerHour,
+		"max_entity_issuances_per_hour":      r.MaxEntityIssuancesPerHour,
 	}
 	if r.MaxPathLength != nil {
 		responseData["max_path_length"] = r.MaxPathLength
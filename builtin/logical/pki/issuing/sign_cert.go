@@ -203,6 +203,10 @@ func SignCert(b logical.SystemView, role *RoleEntry, entityInfo EntityInfo, caSi
 		return nil, nil, errutil.InternalError{Err: fmt.Sprintf("unsupported key type Value: %s", role.KeyType)}
 	}
 
+	if err := checkCryptoPolicy(b, actualKeyType, actualKeyBits); err != nil {
+		return nil, nil, err
+	}
+
 	// Before validating key lengths, update our KeyBits/SignatureBits based
 	// on the actual CSR key type.
 	if role.KeyType == "any" {
@@ -0,0 +1,136 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package issuing
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/identitytpl"
+	"github.com/hashicorp/vault/sdk/logical"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/parsing"
+)
+
+// SPIFFEScheme is the URI scheme of SPIFFE IDs.
+const SPIFFEScheme = "spiffe"
+
+var (
+	// spiffeTrustDomainRegex matches the characters allowed in the trust
+	// domain of a SPIFFE ID.
+	spiffeTrustDomainRegex = regexp.MustCompile(`^[a-z0-9._-]+$`)
+
+	// spiffePathSegmentRegex matches the characters allowed in the segments
+	// of the path of a SPIFFE ID.
+	spiffePathSegmentRegex = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+)
+
+// ValidateSPIFFETrustDomain checks that the given trust domain is valid in a
+// SPIFFE ID.
+func ValidateSPIFFETrustDomain(trustDomain string) error {
+	if !spiffeTrustDomainRegex.MatchString(trustDomain) {
+		return fmt.Errorf("trust domain %q may only contain lowercase letters, numbers, dots, dashes and underscores", trustDomain)
+	}
+	return nil
+}
+
+// ValidateSPIFFEIDTemplate checks that the given SPIFFE ID template is a valid
+// identity template of a path.
+func ValidateSPIFFEIDTemplate(tpl string) error {
+	if !strings.HasPrefix(tpl, "/") {
+		return fmt.Errorf("template %q must start with a /", tpl)
+	}
+	if _, _, err := identitytpl.PopulateString(identitytpl.PopulateStringInput{
+		Mode:              identitytpl.ACLTemplating,
+		ValidityCheckOnly: true,
+		String:            tpl,
+	}); err != nil {
+		return fmt.Errorf("invalid identity template %q: %w", tpl, err)
+	}
+	return nil
+}
+
+// ValidateSPIFFEKeyUsage checks that the given key usages are allowed for
+// X.509 SVIDs, which must be usable for digital signatures and cannot sign
+// certificates or CRLs.
+func ValidateSPIFFEKeyUsage(keyUsage []string) error {
+	usage := x509.KeyUsage(parsing.ParseKeyUsages(keyUsage))
+	if usage&(x509.KeyUsageCertSign|x509.KeyUsageCRLSign) != 0 {
+		return fmt.Errorf("CertSign and CRLSign are not allowed for SVIDs")
+	}
+	if usage&x509.KeyUsageDigitalSignature == 0 {
+		return fmt.Errorf("DigitalSignature is required for SVIDs")
+	}
+	return nil
+}
+
+// ParseSPIFFEID parses the given SPIFFE ID, checking that it is a workload ID
+// of the trust domain.
+func ParseSPIFFEID(id string, trustDomain string) (*url.URL, error) {
+	u, err := url.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("SPIFFE ID %q is not a valid URI: %w", id, err)
+	}
+	if u.Scheme != SPIFFEScheme {
+		return nil, fmt.Errorf("SPIFFE ID %q must use the %s scheme", id, SPIFFEScheme)
+	}
+	if u.Host != trustDomain {
+		return nil, fmt.Errorf("SPIFFE ID %q is not in trust domain %q", id, trustDomain)
+	}
+	if u.User != nil || u.Port() != "" || u.RawQuery != "" || u.Fragment != "" || u.Opaque != "" {
+		return nil, fmt.Errorf("SPIFFE ID %q may not contain a user, port, query or fragment", id)
+	}
+	if u.Path == "" || u.Path == "/" {
+		return nil, fmt.Errorf("SPIFFE ID %q must have a path", id)
+	}
+	for _, segment := range strings.Split(strings.TrimPrefix(u.Path, "/"), "/") {
+		if segment == "." || segment == ".." || !spiffePathSegmentRegex.MatchString(segment) {
+			return nil, fmt.Errorf("SPIFFE ID %q has an invalid path segment %q", id, segment)
+		}
+	}
+	return u, nil
+}
+
+// spiffeID returns the SPIFFE ID of the SVID issued by the role. It is
+// rendered from the SPIFFE ID template of the role for the requester, or
+// else taken from the single requested URI SAN. An SVID has exactly one URI
+// SAN, its SPIFFE ID.
+func spiffeID(role *RoleEntry, entity *logical.Entity, groups []*logical.Group, requested []*url.URL) (*url.URL, error) {
+	if role.SPIFFEIDTemplate == "" {
+		if len(requested) != 1 {
+			return nil, errutil.UserError{Err: "exactly one URI Subject Alternative Name, the SPIFFE ID, must be requested from this role"}
+		}
+		id, err := ParseSPIFFEID(requested[0].String(), role.SPIFFETrustDomain)
+		if err != nil {
+			return nil, errutil.UserError{Err: err.Error()}
+		}
+		return id, nil
+	}
+
+	_, path, err := identitytpl.PopulateString(identitytpl.PopulateStringInput{
+		Mode:   identitytpl.ACLTemplating,
+		String: role.SPIFFEIDTemplate,
+		Entity: entity,
+		Groups: groups,
+	})
+	if err != nil {
+		return nil, errutil.UserError{Err: fmt.Sprintf("failed to render SPIFFE ID from %q: %v", role.SPIFFEIDTemplate, err)}
+	}
+	id, err := ParseSPIFFEID(SPIFFEScheme+"://"+role.SPIFFETrustDomain+path, role.SPIFFETrustDomain)
+	if err != nil {
+		return nil, errutil.UserError{Err: err.Error()}
+	}
+
+	// Requested URI SANs may only repeat the SPIFFE ID
+	for _, uri := range requested {
+		if uri.String() != id.String() {
+			return nil, errutil.UserError{Err: fmt.Sprintf("URI Subject Alternative Name %q does not match the SPIFFE ID %q of the requester", uri, id)}
+		}
+	}
+	return id, nil
+}
@@ -0,0 +1,391 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/ryanuber/go-glob"
+)
+
+const (
+	issuanceQuotasConfigPath = "config/issuance-quotas"
+
+	// issuanceQuotaInterval is the sliding window over which issuance
+	// quotas are counted.
+	issuanceQuotaInterval = time.Hour
+
+	issuanceQuotaRole   = "role"
+	issuanceQuotaEntity = "entity"
+	issuanceQuotaSAN    = "san"
+)
+
+// issuanceQuotasConfigEntry holds the issuance quotas of the mount which are
+// not specific to a role.
+type issuanceQuotasConfigEntry struct {
+	// SANLimits maps glob patterns of certificate names to the maximum
+	// number of certificates with a matching name issued per hour.
+	SANLimits map[string]int `json:"san_limits"`
+}
+
+// issuanceQuota is a limit on the number of certificates issued per hour.
+type issuanceQuota struct {
+	kind  string
+	key   string
+	limit int
+	role  string
+}
+
+func (q *issuanceQuota) Error() string {
+	switch q.kind {
+	case issuanceQuotaRole:
+		return fmt.Sprintf("issuance quota exceeded: role %q allows %d certificates per hour", q.role, q.limit)
+	case issuanceQuotaEntity:
+		return fmt.Sprintf("issuance quota exceeded: role %q allows %d certificates per hour per entity", q.role, q.limit)
+	default:
+		return fmt.Sprintf("issuance quota exceeded: %d certificates per hour are allowed for names matching %q", q.limit, q.key)
+	}
+}
+
+func (q *issuanceQuota) id() string {
+	return q.kind + "/" + q.key
+}
+
+// issuanceQuotaTracker counts the certificates issued against each quota
+// over the last interval. Counts are kept in memory, and each node of the
+// cluster enforces its own.
+type issuanceQuotaTracker struct {
+	lock   sync.Mutex
+	issued map[string][]time.Time
+}
+
+func newIssuanceQuotaTracker() *issuanceQuotaTracker {
+	return &issuanceQuotaTracker{
+		issued: make(map[string][]time.Time),
+	}
+}
+
+// exceeded returns the first of the quotas which has no issuance left.
+func (t *issuanceQuotaTracker) exceeded(now time.Time, quotas []*issuanceQuota) *issuanceQuota {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.exceededLocked(now, quotas)
+}
+
+// allow records an issuance against all the quotas, unless one of them has no
+// issuance left, in which case it is returned and nothing is recorded.
+func (t *issuanceQuotaTracker) allow(now time.Time, quotas []*issuanceQuota) *issuanceQuota {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if quota := t.exceededLocked(now, quotas); quota != nil {
+		return quota
+	}
+	for _, quota := range quotas {
+		id := quota.id()
+		t.issued[id] = append(t.issued[id], now)
+	}
+	return nil
+}
+
+func (t *issuanceQuotaTracker) exceededLocked(now time.Time, quotas []*issuanceQuota) *issuanceQuota {
+	for _, quota := range quotas {
+		if len(t.pruneLocked(now, quota.id())) >= quota.limit {
+			return quota
+		}
+	}
+	return nil
+}
+
+// prune forgets the issuances which are older than the interval.
+func (t *issuanceQuotaTracker) prune(now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for id := range t.issued {
+		t.pruneLocked(now, id)
+	}
+}
+
+func (t *issuanceQuotaTracker) pruneLocked(now time.Time, id string) []time.Time {
+	issued := t.issued[id]
+	cutoff := now.Add(-issuanceQuotaInterval)
+	i := sort.Search(len(issued), func(i int) bool {
+		return issued[i].After(cutoff)
+	})
+	issued = issued[i:]
+	if len(issued) == 0 {
+		delete(t.issued, id)
+		return nil
+	}
+	t.issued[id] = issued
+	return issued
+}
+
+// roleIssuanceQuotas returns the quotas of the role which apply to a request,
+// which can be checked before the certificate is built.
+func roleIssuanceQuotas(req *logical.Request, role *issuing.RoleEntry) []*issuanceQuota {
+	// Roles built on the fly, such as those of sign-verbatim, have no name
+	// and no quotas.
+	if role == nil || role.Name == "" {
+		return nil
+	}
+
+	var quotas []*issuanceQuota
+	if role.MaxIssuancesPerHour > 0 {
+		quotas = append(quotas, &issuanceQuota{
+			kind:  issuanceQuotaRole,
+			key:   role.Name,
+			limit: role.MaxIssuancesPerHour,
+			role:  role.Name,
+		})
+	}
+	// Requests without an entity, such as those made with the root token,
+	// are only limited by the quota of the role.
+	if role.MaxEntityIssuancesPerHour > 0 && req.EntityID != "" {
+		quotas = append(quotas, &issuanceQuota{
+			kind:  issuanceQuotaEntity,
+			key:   role.Name + "/" + req.EntityID,
+			limit: role.MaxEntityIssuancesPerHour,
+			role:  role.Name,
+		})
+	}
+	return quotas
+}
+
+// sanIssuanceQuotas returns the quotas of the SAN patterns matching any name
+// of the certificate.
+func sanIssuanceQuotas(config *issuanceQuotasConfigEntry, cert *x509.Certificate, role *issuing.RoleEntry) []*issuanceQuota {
+	if config == nil || len(config.SANLimits) == 0 {
+		return nil
+	}
+
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+
+	patterns := make([]string, 0, len(config.SANLimits))
+	for pattern := range config.SANLimits {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	var quotas []*issuanceQuota
+	for _, pattern := range patterns {
+		for _, name := range names {
+			if name != "" && glob.Glob(pattern, name) {
+				quotas = append(quotas, &issuanceQuota{
+					kind:  issuanceQuotaSAN,
+					key:   pattern,
+					limit: config.SANLimits[pattern],
+					role:  role.Name,
+				})
+				break
+			}
+		}
+	}
+	return quotas
+}
+
+// checkIssuanceQuotas returns an error if a quota has no issuance left.
+func (b *backend) checkIssuanceQuotas(req *logical.Request, quotas []*issuanceQuota) error {
+	if quota := b.issuanceQuotas.exceeded(time.Now(), quotas); quota != nil {
+		return b.issuanceQuotaError(req, quota)
+	}
+	return nil
+}
+
+// recordIssuanceQuotas records an issuance against the quotas, or returns an
+// error if one of them has no issuance left.
+func (b *backend) recordIssuanceQuotas(req *logical.Request, quotas []*issuanceQuota) error {
+	if len(quotas) == 0 {
+		return nil
+	}
+	if quota := b.issuanceQuotas.allow(time.Now(), quotas); quota != nil {
+		return b.issuanceQuotaError(req, quota)
+	}
+	return nil
+}
+
+func (b *backend) issuanceQuotaError(req *logical.Request, quota *issuanceQuota) error {
+	labels := []metrics.Label{{Name: "quota", Value: quota.kind}}
+	if quota.role != "" {
+		labels = append(labels, metrics.Label{Name: "role", Value: quota.role})
+	}
+	metrics.IncrCounterWithLabels(metricsKey(req, "issuance_quota_exceeded"), 1, labels)
+
+	return logical.CodedError(http.StatusTooManyRequests, quota.Error())
+}
+
+func pathConfigIssuanceQuotas(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/issuance-quotas",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"san_limits": {
+				Type: framework.TypeKVPairs,
+				Description: `Map of glob patterns of certificate names to the
+maximum number of certificates with a matching common name or SAN issued per
+hour, across all roles.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "issuance-quotas-configuration",
+				},
+				Callback: b.pathIssuanceQuotasConfigRead,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"san_limits": {
+								Type:        framework.TypeMap,
+								Description: `Map of glob patterns of certificate names to the maximum number of certificates issued per hour.`,
+								Required:    true,
+							},
+						},
+					}},
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "issuance-quotas",
+				},
+				Callback: b.pathIssuanceQuotasConfigWrite,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"san_limits": {
+								Type:        framework.TypeMap,
+								Description: `Map of glob patterns of certificate names to the maximum number of certificates issued per hour.`,
+								Required:    true,
+							},
+						},
+					}},
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigIssuanceQuotasHelpSyn,
+		HelpDescription: pathConfigIssuanceQuotasHelpDesc,
+	}
+}
+
+func (sc *storageContext) getIssuanceQuotasConfig() (*issuanceQuotasConfigEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, issuanceQuotasConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var config issuanceQuotasConfigEntry
+	if entry == nil {
+		return &config, nil
+	}
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (sc *storageContext) writeIssuanceQuotasConfig(config *issuanceQuotasConfigEntry) error {
+	entry, err := logical.StorageEntryJSON(issuanceQuotasConfigPath, config)
+	if err != nil {
+		return err
+	}
+	return sc.Storage.Put(sc.Context, entry)
+}
+
+func (b *backend) pathIssuanceQuotasConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := sc.getIssuanceQuotasConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: issuanceQuotasConfigResponseData(config),
+	}, nil
+}
+
+func (b *backend) pathIssuanceQuotasConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := sc.getIssuanceQuotasConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if raw, ok := data.GetOk("san_limits"); ok {
+		config.SANLimits = make(map[string]int)
+		for pattern, value := range raw.(map[string]string) {
+			if pattern == "" {
+				return logical.ErrorResponse("san_limits patterns must not be empty"), nil
+			}
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit <= 0 {
+				return logical.ErrorResponse("invalid san_limits value %q for pattern %q: must be a positive integer", value, pattern), nil
+			}
+			config.SANLimits[pattern] = limit
+		}
+	}
+
+	if err := sc.writeIssuanceQuotasConfig(config); err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: issuanceQuotasConfigResponseData(config),
+	}, nil
+}
+
+func issuanceQuotasConfigResponseData(config *issuanceQuotasConfigEntry) map[string]interface{} {
+	sanLimits := make(map[string]interface{}, len(config.SANLimits))
+	for pattern, limit := range config.SANLimits {
+		sanLimits[pattern] = limit
+	}
+	return map[string]interface{}{
+		"san_limits": sanLimits,
+	}
+}
+
+const pathConfigIssuanceQuotasHelpSyn = `
+Configure the issuance quotas of certificate names.
+`
+
+const pathConfigIssuanceQuotasHelpDesc = `
+This endpoint configures the maximum number of certificates issued or signed
+per hour for names matching glob patterns, across all roles of the mount.
+Quotas of roles are configured on the roles, with the max_issuances_per_hour
+and max_entity_issuances_per_hour parameters.
+
+Quotas are counted over a sliding window of an hour, separately by each node
+of the cluster. Requests over a quota fail with a 429 status code.
+`
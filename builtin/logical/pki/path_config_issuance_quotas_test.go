@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/testhelpers/schema"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIssuanceQuotas verifies that issuance is refused once the quotas of a
+// role, an entity or a SAN pattern are reached.
+func TestIssuanceQuotas(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"key_type":    "ec",
+		"ttl":         "24h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	resp, err = CBWrite(b, s, "roles/web", map[string]interface{}{
+		"allowed_domains":               "example.com",
+		"allow_subdomains":              true,
+		"key_type":                      "ec",
+		"ttl":                           "1h",
+		"max_issuances_per_hour":        3,
+		"max_entity_issuances_per_hour": 1,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	require.Equal(t, 3, resp.Data["max_issuances_per_hour"])
+	require.Equal(t, 1, resp.Data["max_entity_issuances_per_hour"])
+
+	resp, err = CBWrite(b, s, "config/issuance-quotas", map[string]interface{}{
+		"san_limits": map[string]interface{}{"*.internal.example.com": 1},
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	schema.ValidateResponse(t, schema.GetResponseSchema(t, b.Route("config/issuance-quotas"), logical.UpdateOperation), resp, true)
+	require.Equal(t, map[string]interface{}{"*.internal.example.com": 1}, resp.Data["san_limits"])
+
+	issue := func(entityID, commonName string) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Operation:  logical.UpdateOperation,
+			Path:       "issue/web",
+			Storage:    s,
+			MountPoint: "pki/",
+			EntityID:   entityID,
+			Data:       map[string]interface{}{"common_name": commonName},
+		})
+	}
+	requireQuotaExceeded := func(err error, msg string) {
+		t.Helper()
+		require.ErrorContains(t, err, msg)
+		coded, ok := err.(logical.HTTPCodedError)
+		require.True(t, ok)
+		require.Equal(t, http.StatusTooManyRequests, coded.Code())
+	}
+
+	// Names matching a SAN pattern are limited across requesters
+	resp, err = issue("", "a.internal.example.com")
+	requireSuccessNonNilResponse(t, resp, err)
+	_, err = issue("", "b.internal.example.com")
+	requireQuotaExceeded(err, `issuance quota exceeded: 1 certificates per hour are allowed for names matching "*.internal.example.com"`)
+
+	// Each entity is limited separately
+	resp, err = issue("entity-1", "a.example.com")
+	requireSuccessNonNilResponse(t, resp, err)
+	_, err = issue("entity-1", "b.example.com")
+	requireQuotaExceeded(err, `issuance quota exceeded: role "web" allows 1 certificates per hour per entity`)
+
+	// The role is limited across entities. Refused requests are not counted.
+	resp, err = issue("entity-2", "c.example.com")
+	requireSuccessNonNilResponse(t, resp, err)
+	_, err = issue("entity-3", "d.example.com")
+	requireQuotaExceeded(err, `issuance quota exceeded: role "web" allows 3 certificates per hour`)
+
+	// Quotas no longer count issuances older than an hour
+	b.issuanceQuotas.prune(time.Now().Add(issuanceQuotaInterval + time.Second))
+	resp, err = issue("entity-1", "b.example.com")
+	requireSuccessNonNilResponse(t, resp, err)
+
+	// Removing the quotas of the role lifts them
+	resp, err = CBPatch(b, s, "roles/web", map[string]interface{}{
+		"max_issuances_per_hour":        0,
+		"max_entity_issuances_per_hour": 0,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	for i := 0; i < 5; i++ {
+		resp, err = issue("entity-1", "e.example.com")
+		requireSuccessNonNilResponse(t, resp, err)
+	}
+
+	resp, err = CBWrite(b, s, "config/issuance-quotas", map[string]interface{}{
+		"san_limits": map[string]interface{}{"*.example.com": "zero"},
+	})
+	require.ErrorContains(t, err, `invalid san_limits value "zero"`)
+
+	resp, err = CBRead(b, s, "config/issuance-quotas")
+	requireSuccessNonNilResponse(t, resp, err)
+	schema.ValidateResponse(t, schema.GetResponseSchema(t, b.Route("config/issuance-quotas"), logical.ReadOperation), resp, true)
+	require.Equal(t, map[string]interface{}{"*.internal.example.com": 1}, resp.Data["san_limits"])
+}
@@ -0,0 +1,265 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/workerpool"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	workerPoolsConfigPath = "config/worker-pools"
+
+	// defaultCRLWorkers is the default size of the CRL building pool, as the
+	// complete and delta CRLs of a mount are built one at a time.
+	defaultCRLWorkers = 1
+)
+
+// workerPoolsConfigEntry sizes the pools running the CPU-heavy operations of
+// the mount. Zero workers use one worker per CPU, and a zero max queue does
+// not bound the queue.
+type workerPoolsConfigEntry struct {
+	KeygenWorkers  int `json:"keygen_workers"`
+	KeygenMaxQueue int `json:"keygen_max_queue"`
+	CRLWorkers     int `json:"crl_workers"`
+	CRLMaxQueue    int `json:"crl_max_queue"`
+}
+
+var defaultWorkerPoolsConfig = workerPoolsConfigEntry{
+	CRLWorkers: defaultCRLWorkers,
+}
+
+// workerPools are the pools running the CPU-heavy operations of the mount:
+// generation of the RSA keys of issued certificates, and CRL building.
+type workerPools struct {
+	keygen *workerpool.Pool
+	crl    *workerpool.Pool
+}
+
+func newWorkerPools(backendUUID string) *workerPools {
+	key := []string{"secrets", "pki", "worker_pool"}
+	label := metrics.Label{Name: "backend_uuid", Value: backendUUID}
+	cfg := defaultWorkerPoolsConfig
+
+	return &workerPools{
+		keygen: workerpool.New("keygen", cfg.KeygenWorkers, cfg.KeygenMaxQueue, key, label),
+		crl:    workerpool.New("crl", cfg.CRLWorkers, cfg.CRLMaxQueue, key, label),
+	}
+}
+
+func (w *workerPools) apply(cfg *workerPoolsConfigEntry) {
+	w.keygen.Resize(cfg.KeygenWorkers, cfg.KeygenMaxQueue)
+	w.crl.Resize(cfg.CRLWorkers, cfg.CRLMaxQueue)
+}
+
+// runOnPool runs fn on the pool, and returns a 503 error if the queue of the
+// pool is full.
+func runOnPool(ctx context.Context, pool *workerpool.Pool, fn func() error) error {
+	err := pool.Run(ctx, fn)
+	if errors.Is(err, workerpool.ErrQueueFull) {
+		return logical.CodedError(http.StatusServiceUnavailable, fmt.Sprintf("too many pending %s operations, retry later", pool.Name()))
+	}
+	return err
+}
+
+func pathConfigWorkerPools(b *backend) *framework.Path {
+	fields := map[string]*framework.FieldSchema{
+		"keygen_workers": {
+			Type: framework.TypeInt,
+			Description: `Number of RSA keys of issued certificates generated at
+once. Defaults to the number of CPUs.`,
+		},
+		"keygen_max_queue": {
+			Type: framework.TypeInt,
+			Description: `Number of RSA key generations waiting for a worker from
+which further requests are rejected. Defaults to 0, for no limit.`,
+		},
+		"crl_workers": {
+			Type:        framework.TypeInt,
+			Description: `Number of CRL builds run at once. Defaults to 1.`,
+		},
+		"crl_max_queue": {
+			Type: framework.TypeInt,
+			Description: `Number of CRL builds waiting for a worker from which
+further requests are rejected. Defaults to 0, for no limit.`,
+		},
+	}
+
+	responseFields := map[string]*framework.FieldSchema{
+		"pools": {
+			Type:        framework.TypeMap,
+			Description: `State of the worker pools of this node.`,
+			Required:    true,
+		},
+	}
+	for name, field := range fields {
+		responseFields[name] = &framework.FieldSchema{
+			Type:        field.Type,
+			Description: field.Description,
+			Required:    true,
+		}
+	}
+
+	return &framework.Path{
+		Pattern: "config/worker-pools",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+		},
+
+		Fields: fields,
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "worker-pools-configuration",
+				},
+				Callback: b.pathWorkerPoolsConfigRead,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields:      responseFields,
+					}},
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "worker-pools",
+				},
+				Callback: b.pathWorkerPoolsConfigWrite,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields:      responseFields,
+					}},
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigWorkerPoolsHelpSyn,
+		HelpDescription: pathConfigWorkerPoolsHelpDesc,
+	}
+}
+
+func (sc *storageContext) getWorkerPoolsConfig() (*workerPoolsConfigEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, workerPoolsConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	config := defaultWorkerPoolsConfig
+	if entry == nil {
+		return &config, nil
+	}
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (sc *storageContext) writeWorkerPoolsConfig(config *workerPoolsConfigEntry) error {
+	entry, err := logical.StorageEntryJSON(workerPoolsConfigPath, config)
+	if err != nil {
+		return err
+	}
+	return sc.Storage.Put(sc.Context, entry)
+}
+
+// reloadWorkerPoolsConfig sizes the worker pools from the stored
+// configuration.
+func (b *backend) reloadWorkerPoolsConfig(sc *storageContext) error {
+	config, err := sc.getWorkerPoolsConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load worker pools configuration: %w", err)
+	}
+	b.workerPools.apply(config)
+	return nil
+}
+
+func (b *backend) pathWorkerPoolsConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := sc.getWorkerPoolsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: b.workerPoolsConfigResponseData(config),
+	}, nil
+}
+
+func (b *backend) pathWorkerPoolsConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := sc.getWorkerPoolsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	for name, field := range map[string]*int{
+		"keygen_workers":   &config.KeygenWorkers,
+		"keygen_max_queue": &config.KeygenMaxQueue,
+		"crl_workers":      &config.CRLWorkers,
+		"crl_max_queue":    &config.CRLMaxQueue,
+	} {
+		if raw, ok := data.GetOk(name); ok {
+			value := raw.(int)
+			if value < 0 {
+				return logical.ErrorResponse("%s must not be negative", name), nil
+			}
+			*field = value
+		}
+	}
+
+	if err := sc.writeWorkerPoolsConfig(config); err != nil {
+		return nil, err
+	}
+	b.workerPools.apply(config)
+
+	return &logical.Response{
+		Data: b.workerPoolsConfigResponseData(config),
+	}, nil
+}
+
+func (b *backend) workerPoolsConfigResponseData(config *workerPoolsConfigEntry) map[string]interface{} {
+	pools := make(map[string]interface{})
+	for _, pool := range []*workerpool.Pool{b.workerPools.keygen, b.workerPools.crl} {
+		stats := pool.Stats()
+		pools[pool.Name()] = map[string]interface{}{
+			"workers":   stats.Size,
+			"max_queue": stats.MaxQueue,
+			"running":   stats.Running,
+			"queued":    stats.Queued,
+		}
+	}
+
+	return map[string]interface{}{
+		"keygen_workers":   config.KeygenWorkers,
+		"keygen_max_queue": config.KeygenMaxQueue,
+		"crl_workers":      config.CRLWorkers,
+		"crl_max_queue":    config.CRLMaxQueue,
+		"pools":            pools,
+	}
+}
+
+const pathConfigWorkerPoolsHelpSyn = `
+Configure the worker pools of CPU-heavy operations.
+`
+
+const pathConfigWorkerPoolsHelpDesc = `
+This endpoint sizes the pools of workers which generate the RSA keys of issued
+certificates and build CRLs, separately from the handling of other requests.
+Requests wait for a worker of the pool, and are rejected with a 503 status code
+when the queue of the pool is full. Each node of the cluster has its own pools.
+`
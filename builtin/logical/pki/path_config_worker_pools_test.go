@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/testhelpers/schema"
+	"github.com/hashicorp/vault/sdk/helper/workerpool"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWorkerPools verifies that RSA issuance and CRL building run on the
+// worker pools of the mount, sized by its configuration.
+func TestWorkerPools(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBRead(b, s, "config/worker-pools")
+	requireSuccessNonNilResponse(t, resp, err)
+	schema.ValidateResponse(t, schema.GetResponseSchema(t, b.Route("config/worker-pools"), logical.ReadOperation), resp, true)
+	require.Equal(t, defaultCRLWorkers, resp.Data["crl_workers"])
+	pools := resp.Data["pools"].(map[string]interface{})
+	require.Equal(t, workerpool.DefaultSize(), pools["keygen"].(map[string]interface{})["workers"])
+
+	resp, err = CBWrite(b, s, "config/worker-pools", map[string]interface{}{
+		"keygen_workers":   1,
+		"keygen_max_queue": 1,
+		"crl_max_queue":    5,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	schema.ValidateResponse(t, schema.GetResponseSchema(t, b.Route("config/worker-pools"), logical.UpdateOperation), resp, true)
+	require.Equal(t, workerpool.Stats{Size: 1, MaxQueue: 1}, b.workerPools.keygen.Stats())
+	require.Equal(t, workerpool.Stats{Size: defaultCRLWorkers, MaxQueue: 5}, b.workerPools.crl.Stats())
+
+	_, err = CBWrite(b, s, "config/worker-pools", map[string]interface{}{
+		"crl_workers": -1,
+	})
+	require.ErrorContains(t, err, "crl_workers must not be negative")
+
+	resp, err = CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"key_type":    "ec",
+		"ttl":         "24h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	resp, err = CBWrite(b, s, "roles/rsa", map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"key_type":         "rsa",
+		"key_bits":         2048,
+		"ttl":              "1h",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	resp, err = CBWrite(b, s, "issue/rsa", map[string]interface{}{
+		"common_name": "a.example.com",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	serial := resp.Data["serial_number"].(string)
+
+	resp, err = CBWrite(b, s, "revoke", map[string]interface{}{
+		"serial_number": serial,
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	resp, err = CBRead(b, s, "crl/rotate")
+	requireSuccessNonNilResponse(t, resp, err)
+
+	// Issuance of RSA keys is rejected once the queue of the pool is full
+	release := make(chan struct{})
+	blocked := func() error {
+		<-release
+		return nil
+	}
+	done := make(chan error, 2)
+	go func() { done <- b.workerPools.keygen.Run(context.Background(), blocked) }()
+	require.Eventually(t, func() bool { return b.workerPools.keygen.Stats().Running == 1 }, 5*time.Second, time.Millisecond)
+	go func() { done <- b.workerPools.keygen.Run(context.Background(), blocked) }()
+	require.Eventually(t, func() bool { return b.workerPools.keygen.Stats().Queued == 1 }, 5*time.Second, time.Millisecond)
+
+	_, err = CBWrite(b, s, "issue/rsa", map[string]interface{}{
+		"common_name": "b.example.com",
+	})
+	coded, ok := err.(logical.HTTPCodedError)
+	require.True(t, ok, "expected a coded error, got %v", err)
+	require.Equal(t, http.StatusServiceUnavailable, coded.Code())
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, <-done)
+	}
+	resp, err = CBWrite(b, s, "issue/rsa", map[string]interface{}{
+		"common_name": "b.example.com",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+}
@@ -292,6 +292,18 @@ func (b *backend) pathIssue(ctx context.Context, req *logical.Request, data *fra
 		return logical.ErrorResponse("role key type \"any\" not allowed for issuing certificates, only signing"), nil
 	}
 
+	// RSA keys are generated on the key generation pool, so that bursts of
+	// issuance do not starve other requests of CPU.
+	if role.KeyType == "rsa" {
+		var resp *logical.Response
+		err := runOnPool(ctx, b.workerPools.keygen, func() error {
+			var err error
+			resp, err = b.pathIssueSignCert(ctx, req, data, role, false, false)
+			return err
+		})
+		return resp, err
+	}
+
 	return b.pathIssueSignCert(ctx, req, data, role, false, false)
 }
 
@@ -377,6 +389,14 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 			`the "format" path parameter must be "pem", "der", or "pem_bundle"`), nil
 	}
 
+	// Check the quotas of the role before building the certificate, so that
+	// requests over quota do not generate keys. The quotas of the names of
+	// the certificate are checked once it is built.
+	quotas := roleIssuanceQuotas(req, role)
+	if err := b.checkIssuanceQuotas(req, quotas); err != nil {
+		return nil, err
+	}
+
 	var caErr error
 	sc := b.makeStorageContext(ctx, req.Storage)
 	signingBundle, caErr := sc.fetchCAInfo(issuerName, issuing.IssuanceUsage)
@@ -415,6 +435,15 @@ func (b *backend) pathIssueSignCert(ctx context.Context, req *logical.Request, d
 		}
 	}
 
+	quotasConfig, err := sc.getIssuanceQuotasConfig()
+	if err != nil {
+		return nil, err
+	}
+	quotas = append(quotas, sanIssuanceQuotas(quotasConfig, parsedBundle.Certificate, role)...)
+	if err := b.recordIssuanceQuotas(req, quotas); err != nil {
+		return nil, err
+	}
+
 	generateLease := false
 	if role.GenerateLease != nil && *role.GenerateLease {
 		generateLease = true
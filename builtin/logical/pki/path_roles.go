@@ -386,6 +386,30 @@ The value format should be given in UTC format YYYY-MM-ddTHH:MM:SSZ.`,
 			Description: `Reference to the issuer used to sign requests
 serviced by this role.`,
 		},
+		"issuance_policy": {
+			Type:        framework.TypeString,
+			Description: `Boolean expression that must hold for certificates to be issued by this role.`,
+		},
+		"derived_sans": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: `Identity templates of SANs added to certificates issued by this role.`,
+		},
+		"spiffe_trust_domain": {
+			Type:        framework.TypeString,
+			Description: `Trust domain of the SPIFFE IDs of the SVIDs issued by this role.`,
+		},
+		"spiffe_id_template": {
+			Type:        framework.TypeString,
+			Description: `Identity template of the path of the SPIFFE IDs of the SVIDs issued by this role.`,
+		},
+		"max_issuances_per_hour": {
+			Type:        framework.TypeInt,
+			Description: `Maximum number of certificates issued by this role per hour.`,
+		},
+		"max_entity_issuances_per_hour": {
+			Type:        framework.TypeInt,
+			Description: `Maximum number of certificates issued by this role per hour to each entity.`,
+		},
 	}
 
 	return &framework.Path{
@@ -807,6 +831,51 @@ The value format should be given in UTC format YYYY-MM-ddTHH:MM:SSZ.`,
 serviced by this role.`,
 				Default: defaultRef,
 			},
+			"issuance_policy": {
+				Type: framework.TypeString,
+				Description: `If set, a boolean expression, using the go-bexpr syntax,
+that must hold for a certificate to be issued or signed by this role. The
+expression is evaluated against the names of the certificate, the CSR if
+any, and the identity entity of the requester. Identity templates are
+replaced by their quoted value before evaluation, e.g.
+{{identity.entity.metadata.hostname}} in dns_sans.`,
+			},
+			"derived_sans": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `If set, an array of identity templates, e.g.
+{{identity.entity.metadata.hostname}}, rendered for the requester and added
+to the subject alternative names of certificates issued or signed by this
+role. Values containing an @ are added as email addresses, others as DNS
+names. Derived SANs are not checked against the allowed domains of the role.`,
+			},
+			"spiffe_trust_domain": {
+				Type: framework.TypeString,
+				Description: `If set, the role issues SPIFFE X.509 SVIDs in this trust
+domain: certificates have exactly one URI SAN, the SPIFFE ID of the workload,
+which must be in the trust domain. The common name is optional, and the key
+usage must include DigitalSignature and exclude CertSign and CRLSign.`,
+			},
+			"spiffe_id_template": {
+				Type: framework.TypeString,
+				Description: `If set, an identity template of the path of the SPIFFE
+ID, e.g. /ns/{{identity.entity.metadata.namespace}}/sa/{{identity.entity.name}},
+rendered for the requester. Otherwise the SPIFFE ID is the single requested
+URI SAN, which must be allowed by allowed_uri_sans. Requires
+spiffe_trust_domain.`,
+			},
+			"max_issuances_per_hour": {
+				Type: framework.TypeInt,
+				Description: `If set, the maximum number of certificates issued or
+signed by this role per hour. Requests over the quota fail with a 429 status
+code. Defaults to 0, which is unlimited.`,
+			},
+			"max_entity_issuances_per_hour": {
+				Type: framework.TypeInt,
+				Description: `If set, the maximum number of certificates issued or
+signed by this role per hour to each identity entity. Requests without an
+entity, such as those made with the root token, are not counted. Defaults to
+0, which is unlimited.`,
+			},
 		},
 
 		Operations: map[logical.Operation]framework.OperationHandler{
@@ -984,6 +1053,12 @@ func (b *backend) pathRoleCreate(ctx context.Context, req *logical.Request, data
 		NotBeforeDuration:             time.Duration(data.Get("not_before_duration").(int)) * time.Second,
 		NotAfter:                      data.Get("not_after").(string),
 		Issuer:                        data.Get("issuer_ref").(string),
+		IssuancePolicy:                data.Get("issuance_policy").(string),
+		DerivedSANs:                   data.Get("derived_sans").([]string),
+		SPIFFETrustDomain:             data.Get("spiffe_trust_domain").(string),
+		SPIFFEIDTemplate:              data.Get("spiffe_id_template").(string),
+		MaxIssuancesPerHour:           data.Get("max_issuances_per_hour").(int),
+		MaxEntityIssuancesPerHour:     data.Get("max_entity_issuances_per_hour").(int),
 		Name:                          name,
 	}
 
@@ -1107,6 +1182,39 @@ func validateRole(b *backend, entry *issuing.RoleEntry, ctx context.Context, s l
 		return nil, errutil.UserError{Err: err.Error()}
 	}
 
+	if entry.IssuancePolicy != "" {
+		if err := issuing.ValidateIssuancePolicy(entry.IssuancePolicy); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid issuance_policy: %v", err)), nil
+		}
+	}
+	if err := issuing.ValidateDerivedSANs(entry.DerivedSANs); err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("invalid derived_sans: %v", err)), nil
+	}
+
+	if entry.SPIFFETrustDomain != "" {
+		if err := issuing.ValidateSPIFFETrustDomain(entry.SPIFFETrustDomain); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid spiffe_trust_domain: %v", err)), nil
+		}
+		if err := issuing.ValidateSPIFFEKeyUsage(entry.KeyUsage); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid key_usage: %v", err)), nil
+		}
+	}
+	if entry.SPIFFEIDTemplate != "" {
+		if entry.SPIFFETrustDomain == "" {
+			return logical.ErrorResponse("spiffe_id_template requires spiffe_trust_domain"), nil
+		}
+		if err := issuing.ValidateSPIFFEIDTemplate(entry.SPIFFEIDTemplate); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("invalid spiffe_id_template: %v", err)), nil
+		}
+	}
+
+	if entry.MaxIssuancesPerHour < 0 {
+		return logical.ErrorResponse("max_issuances_per_hour must not be negative"), nil
+	}
+	if entry.MaxEntityIssuancesPerHour < 0 {
+		return logical.ErrorResponse("max_entity_issuances_per_hour must not be negative"), nil
+	}
+
 	resp.Data = entry.ToResponseData()
 	return resp, nil
 }
@@ -1184,6 +1292,12 @@ func (b *backend) pathRolePatch(ctx context.Context, req *logical.Request, data
 		NotBeforeDuration:             getTimeWithExplicitDefault(data, "not_before_duration", oldEntry.NotBeforeDuration),
 		NotAfter:                      getWithExplicitDefault(data, "not_after", oldEntry.NotAfter).(string),
 		Issuer:                        getWithExplicitDefault(data, "issuer_ref", oldEntry.Issuer).(string),
+		IssuancePolicy:                getWithExplicitDefault(data, "issuance_policy", oldEntry.IssuancePolicy).(string),
+		DerivedSANs:                   getWithExplicitDefault(data, "derived_sans", oldEntry.DerivedSANs).([]string),
+		SPIFFETrustDomain:             getWithExplicitDefault(data, "spiffe_trust_domain", oldEntry.SPIFFETrustDomain).(string),
+		SPIFFEIDTemplate:              getWithExplicitDefault(data, "spiffe_id_template", oldEntry.SPIFFEIDTemplate).(string),
+		MaxIssuancesPerHour:           getWithExplicitDefault(data, "max_issuances_per_hour", oldEntry.MaxIssuancesPerHour).(int),
+		MaxEntityIssuancesPerHour:     getWithExplicitDefault(data, "max_entity_issuances_per_hour", oldEntry.MaxEntityIssuancesPerHour).(int),
 	}
 
 	allowedOtherSANsData, wasSet := data.GetOk("allowed_other_sans")
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// spiffeBundleKeyUse is the use of the keys of a SPIFFE bundle which are
+// X.509 SVID authorities.
+const spiffeBundleKeyUse = "x509-svid"
+
+// spiffeBundle is a SPIFFE trust bundle in its JWK set format.
+type spiffeBundle struct {
+	Keys []jose.JSONWebKey `json:"keys"`
+}
+
+func pathSPIFFEBundle(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "spiffe/bundle$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationVerb:   "read",
+			OperationSuffix: "spiffe-bundle",
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathSPIFFEBundleRead,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    pathSPIFFEBundleHelpSyn,
+		HelpDescription: pathSPIFFEBundleHelpDesc,
+	}
+}
+
+// pathSPIFFEBundleRead returns the SPIFFE trust bundle of the mount, made of
+// the certificates of its issuers which are not revoked.
+func (b *backend) pathSPIFFEBundleRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	if b.UseLegacyBundleCaStorage() {
+		return logical.ErrorResponse("Can not get the SPIFFE bundle until migration has completed"), nil
+	}
+
+	sc := b.makeStorageContext(ctx, req.Storage)
+	issuers, err := sc.listIssuers()
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &spiffeBundle{
+		Keys: []jose.JSONWebKey{},
+	}
+	for _, id := range issuers {
+		issuer, err := sc.fetchIssuerById(id)
+		if err != nil {
+			return nil, err
+		}
+		if issuer.Revoked {
+			continue
+		}
+
+		cert, err := issuer.GetCertificate()
+		if err != nil {
+			return nil, err
+		}
+		bundle.Keys = append(bundle.Keys, jose.JSONWebKey{
+			Key:          cert.PublicKey,
+			Certificates: []*x509.Certificate{cert},
+			Use:          spiffeBundleKeyUse,
+		})
+	}
+
+	body, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "application/json",
+			logical.HTTPRawBody:     body,
+			logical.HTTPStatusCode:  http.StatusOK,
+		},
+	}, nil
+}
+
+const pathSPIFFEBundleHelpSyn = `
+Fetch the SPIFFE trust bundle of the issuers of this mount.
+`
+
+const pathSPIFFEBundleHelpDesc = `
+This returns the certificates of the issuers of this mount which are not
+revoked, as a SPIFFE trust bundle in the JWK set format. Workloads use the
+bundle to verify the X.509 SVIDs issued by roles with a spiffe_trust_domain.
+`
@@ -0,0 +1,631 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/logical/pki/issuing"
+	"github.com/hashicorp/vault/helper/pkcs7"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/certutil"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	tsaConfigPath = "config/tsa"
+
+	tsaReplyContentType = "application/timestamp-reply"
+
+	tsaDefaultCommonName = "Vault Timestamp Authority"
+)
+
+var (
+	oidTSTInfo              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidExtKeyUsage          = asn1.ObjectIdentifier{2, 5, 29, 37}
+	oidExtKeyUsageTimestamp = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 8}
+
+	// tsaHashes are the hash algorithms accepted for message imprints.
+	tsaHashes = map[string]crypto.Hash{
+		pkcs7.OIDDigestAlgorithmSHA256.String(): crypto.SHA256,
+		pkcs7.OIDDigestAlgorithmSHA384.String(): crypto.SHA384,
+		pkcs7.OIDDigestAlgorithmSHA512.String(): crypto.SHA512,
+	}
+)
+
+// PKIStatus and PKIFailureInfo values of RFC 3161, section 2.4.2.
+const (
+	tsaStatusGranted   = 0
+	tsaStatusRejection = 2
+
+	tsaFailureBadAlg              = 0
+	tsaFailureBadRequest          = 2
+	tsaFailureBadDataFormat       = 5
+	tsaFailureUnacceptedPolicy    = 15
+	tsaFailureUnacceptedExtension = 16
+	tsaFailureSystemFailure       = 25
+)
+
+// tsaConfigEntry is the configuration of the timestamp authority of the
+// mount. The TSA certificate is issued for the key by the issuer when the
+// configuration is written.
+type tsaConfigEntry struct {
+	IssuerID    issuing.IssuerID `json:"issuer_id"`
+	KeyID       issuing.KeyID    `json:"key_id"`
+	Policy      string           `json:"policy"`
+	Accuracy    time.Duration    `json:"accuracy"`
+	Certificate string           `json:"certificate"`
+	CAChain     []string         `json:"ca_chain"`
+}
+
+type tsaMessageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type tsaRequest struct {
+	Version        int
+	MessageImprint tsaMessageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional"`
+	Extensions     []pkix.Extension      `asn1:"optional,tag:0"`
+}
+
+type tsaAccuracy struct {
+	Seconds int `asn1:"optional"`
+}
+
+type tsaTSTInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint tsaMessageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time   `asn1:"generalized"`
+	Accuracy       tsaAccuracy `asn1:"optional"`
+	Nonce          *big.Int    `asn1:"optional"`
+}
+
+type tsaStatusInfo struct {
+	Status       int
+	StatusString []asn1.RawValue `asn1:"optional"`
+	FailInfo     asn1.BitString  `asn1:"optional"`
+}
+
+type tsaResponse struct {
+	Status         tsaStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type tsaESSCertIDv2 struct {
+	CertHash []byte
+}
+
+type tsaSigningCertificateV2 struct {
+	Certs []tsaESSCertIDv2
+}
+
+func pathConfigTSA(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "config/tsa",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			issuerRefParam: {
+				Type: framework.TypeString,
+				Description: `Reference to the issuer of the TSA certificate;
+either "default" for the configured default issuer, an identifier or the name
+assigned to the issuer.`,
+				Default: defaultRef,
+			},
+			keyRefParam: {
+				Type: framework.TypeString,
+				Description: `Reference to the key signing the timestamps; an
+identifier or the name assigned to the key. It must be an RSA or EC key.`,
+			},
+			"common_name": {
+				Type:        framework.TypeString,
+				Description: `Common name of the TSA certificate.`,
+				Default:     tsaDefaultCommonName,
+			},
+			"ttl": {
+				Type: framework.TypeDurationSecond,
+				Description: `Validity period of the TSA certificate, capped to
+the validity of the issuer.`,
+				Default: 365 * 24 * 60 * 60,
+			},
+			"policy": {
+				Type: framework.TypeString,
+				Description: `OID of the TSA policy under which timestamps are
+issued.`,
+			},
+			"accuracy": {
+				Type: framework.TypeDurationSecond,
+				Description: `Accuracy of the time of the timestamps, or zero to
+leave it unspecified.`,
+				Default: 1,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "tsa-configuration",
+				},
+				Callback: b.pathTSAConfigRead,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+					}},
+				},
+			},
+			logical.UpdateOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "tsa",
+				},
+				Callback: b.pathTSAConfigWrite,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+					}},
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "tsa-configuration",
+				},
+				Callback: b.pathTSAConfigDelete,
+				Responses: map[int][]framework.Response{
+					http.StatusNoContent: {{
+						Description: "No Content",
+					}},
+				},
+				// Read more about why these flags are set in backend.go.
+				ForwardPerformanceStandby:   true,
+				ForwardPerformanceSecondary: true,
+			},
+		},
+
+		HelpSynopsis:    pathConfigTSAHelpSyn,
+		HelpDescription: pathConfigTSAHelpDesc,
+	}
+}
+
+func pathTimestamp(b *backend) *framework.Path {
+	return &framework.Path{
+		Pattern: "timestamp$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixPKI,
+			OperationVerb:   "request",
+			OperationSuffix: "timestamp",
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathTimestampWrite,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    pathTimestampHelpSyn,
+		HelpDescription: pathTimestampHelpDesc,
+	}
+}
+
+func (sc *storageContext) getTSAConfig() (*tsaConfigEntry, error) {
+	entry, err := sc.Storage.Get(sc.Context, tsaConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var config tsaConfigEntry
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (sc *storageContext) writeTSAConfig(config *tsaConfigEntry) error {
+	entry, err := logical.StorageEntryJSON(tsaConfigPath, config)
+	if err != nil {
+		return err
+	}
+	return sc.Storage.Put(sc.Context, entry)
+}
+
+func (b *backend) pathTSAConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := sc.getTSAConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	cert, err := parseCertificateFromBytes([]byte(config.Certificate))
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"issuer_id":     config.IssuerID,
+			"key_id":        config.KeyID,
+			"policy":        config.Policy,
+			"accuracy":      int64(config.Accuracy.Seconds()),
+			"certificate":   config.Certificate,
+			"ca_chain":      config.CAChain,
+			"serial_number": serialFromCert(cert),
+			"expiration":    cert.NotAfter.Unix(),
+		},
+	}, nil
+}
+
+// pathTSAConfigWrite configures the timestamp authority, issuing a new TSA
+// certificate for its key.
+func (b *backend) pathTSAConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if b.UseLegacyBundleCaStorage() {
+		return logical.ErrorResponse("Can not configure the timestamp authority until migration has completed"), nil
+	}
+
+	policy := data.Get("policy").(string)
+	if policy == "" {
+		return logical.ErrorResponse("policy is required"), nil
+	}
+	if _, err := certutil.StringToOid(policy); err != nil {
+		return logical.ErrorResponse("invalid policy %q: %v", policy, err), nil
+	}
+
+	keyRef := data.Get(keyRefParam).(string)
+	if keyRef == "" {
+		return logical.ErrorResponse("%s is required", keyRefParam), nil
+	}
+
+	sc := b.makeStorageContext(ctx, req.Storage)
+	caInfo, issuerID, err := sc.fetchCAInfoWithIssuer(data.Get(issuerRefParam).(string), issuing.IssuanceUsage)
+	if err != nil {
+		switch err.(type) {
+		case errutil.UserError:
+			return logical.ErrorResponse(err.Error()), nil
+		default:
+			return nil, err
+		}
+	}
+
+	keyID, err := sc.resolveKeyReference(keyRef)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+	key, err := sc.fetchKeyById(keyID)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := getTSASigner(key)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	serialNumber, err := certutil.GenerateSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC 3161 requires the TSA certificate to have the timeStamping extended
+	// key usage only, marked as critical.
+	eku, err := asn1.Marshal([]asn1.ObjectIdentifier{oidExtKeyUsageTimestamp})
+	if err != nil {
+		return nil, err
+	}
+
+	notBefore := time.Now().Add(-30 * time.Second)
+	notAfter := notBefore.Add(time.Duration(data.Get("ttl").(int)) * time.Second)
+	if notAfter.After(caInfo.Certificate.NotAfter) {
+		notAfter = caInfo.Certificate.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName: data.Get("common_name").(string),
+		},
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+		KeyUsage:  x509.KeyUsageDigitalSignature,
+		ExtraExtensions: []pkix.Extension{{
+			Id:       oidExtKeyUsage,
+			Critical: true,
+			Value:    eku,
+		}},
+		BasicConstraintsValid: true,
+	}
+	if caInfo.URLs != nil {
+		template.IssuingCertificateURL = caInfo.URLs.IssuingCertificates
+		template.CRLDistributionPoints = caInfo.URLs.CRLDistributionPoints
+		template.OCSPServer = caInfo.URLs.OCSPServers
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, caInfo.Certificate, signer.Public(), caInfo.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to issue the TSA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(certBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = issuing.StoreCertificate(ctx, req.Storage, b.GetCertificateCounter(), &certutil.ParsedCertBundle{
+		Certificate:      cert,
+		CertificateBytes: certBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tsaConfigEntry{
+		IssuerID:    issuerID,
+		KeyID:       keyID,
+		Policy:      policy,
+		Accuracy:    time.Duration(data.Get("accuracy").(int)) * time.Second,
+		Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})),
+	}
+	for _, block := range caInfo.GetFullChain() {
+		config.CAChain = append(config.CAChain, strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: block.Bytes}))))
+	}
+	if err := sc.writeTSAConfig(config); err != nil {
+		return nil, err
+	}
+
+	return b.pathTSAConfigRead(ctx, req, data)
+}
+
+func (b *backend) pathTSAConfigDelete(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, tsaConfigPath); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// getTSASigner returns the signer of a key backing the timestamp authority.
+func getTSASigner(key *issuing.KeyEntry) (crypto.Signer, error) {
+	if key.PrivateKeyType == certutil.ManagedPrivateKey {
+		return nil, fmt.Errorf("managed key %s can not back the timestamp authority", key.ID)
+	}
+
+	signer, _, _, err := getSignerFromKeyEntryBytes(key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch signer.Public().(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("key %s must be an RSA or EC key to back the timestamp authority", key.ID)
+	}
+}
+
+// pathTimestampWrite answers an RFC 3161 timestamp request. As for OCSP, the
+// request and its reply are DER encoded, and failures are reported within the
+// reply.
+func (b *backend) pathTimestampWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	derReq, err := fetchDerEncodedRequest(req, data)
+	if err != nil {
+		return tsaRejection(tsaFailureBadDataFormat, err.Error()), nil
+	}
+
+	var tsReq tsaRequest
+	if rest, err := asn1.Unmarshal(derReq, &tsReq); err != nil || len(rest) > 0 {
+		return tsaRejection(tsaFailureBadDataFormat, "malformed timestamp request"), nil
+	}
+	if tsReq.Version != 1 {
+		return tsaRejection(tsaFailureBadRequest, fmt.Sprintf("unsupported timestamp request version %d", tsReq.Version)), nil
+	}
+	hash, ok := tsaHashes[tsReq.MessageImprint.HashAlgorithm.Algorithm.String()]
+	if !ok {
+		return tsaRejection(tsaFailureBadAlg, "unsupported hash algorithm"), nil
+	}
+	if len(tsReq.MessageImprint.HashedMessage) != hash.Size() {
+		return tsaRejection(tsaFailureBadDataFormat, "hashed message does not match the hash algorithm"), nil
+	}
+	if len(tsReq.Extensions) > 0 {
+		return tsaRejection(tsaFailureUnacceptedExtension, "extensions are not supported"), nil
+	}
+
+	sc := b.makeStorageContext(ctx, req.Storage)
+	config, err := sc.getTSAConfig()
+	if err != nil {
+		return tsaSystemFailure(b, err), nil
+	}
+	if config == nil {
+		return tsaRejection(tsaFailureSystemFailure, "timestamp authority is not configured"), nil
+	}
+
+	policy, err := certutil.StringToOid(config.Policy)
+	if err != nil {
+		return tsaSystemFailure(b, err), nil
+	}
+	if len(tsReq.ReqPolicy) > 0 && !tsReq.ReqPolicy.Equal(policy) {
+		return tsaRejection(tsaFailureUnacceptedPolicy, "unsupported policy"), nil
+	}
+
+	token, err := b.signTimestamp(sc, config, policy, &tsReq)
+	if err != nil {
+		return tsaSystemFailure(b, err), nil
+	}
+
+	return tsaReply(tsaResponse{
+		Status:         tsaStatusInfo{Status: tsaStatusGranted},
+		TimeStampToken: asn1.RawValue{FullBytes: token},
+	})
+}
+
+// signTimestamp returns the timestamp token answering a request, which is a
+// SignedData whose content is a TSTInfo.
+func (b *backend) signTimestamp(sc *storageContext, config *tsaConfigEntry, policy asn1.ObjectIdentifier, tsReq *tsaRequest) ([]byte, error) {
+	key, err := sc.fetchKeyById(config.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := getTSASigner(key)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := parseCertificateFromBytes([]byte(config.Certificate))
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(cert.NotAfter) {
+		return nil, errors.New("the TSA certificate has expired")
+	}
+
+	serialNumber, err := certutil.GenerateSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := asn1.Marshal(tsaTSTInfo{
+		Version:        1,
+		Policy:         policy,
+		MessageImprint: tsReq.MessageImprint,
+		SerialNumber:   serialNumber,
+		GenTime:        time.Now().UTC().Truncate(time.Second),
+		Accuracy:       tsaAccuracy{Seconds: int(config.Accuracy.Seconds())},
+		Nonce:          tsReq.Nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	signedData, err := pkcs7.NewSignedData(info)
+	if err != nil {
+		return nil, err
+	}
+	signedData.SetContentType(oidTSTInfo)
+
+	certHash := sha256.Sum256(cert.Raw)
+	signerConfig := pkcs7.SignerInfoConfig{
+		ExtraSignedAttributes: []pkcs7.Attribute{{
+			Type: oidSigningCertificateV2,
+			Value: tsaSigningCertificateV2{
+				Certs: []tsaESSCertIDv2{{CertHash: certHash[:]}},
+			},
+		}},
+	}
+
+	// The TSA certificate is always included in the token, along with its
+	// chain when requested.
+	var parents []*x509.Certificate
+	if tsReq.CertReq {
+		for _, pemCert := range config.CAChain {
+			parent, err := parseCertificateFromBytes([]byte(pemCert))
+			if err != nil {
+				return nil, err
+			}
+			parents = append(parents, parent)
+		}
+	}
+	if err := signedData.AddSignerChain(cert, signer, parents, signerConfig); err != nil {
+		return nil, err
+	}
+
+	return signedData.Finish()
+}
+
+// tsaRejection returns a reply rejecting a timestamp request.
+func tsaRejection(failure int, reason string) *logical.Response {
+	failInfo := asn1.BitString{
+		Bytes:     make([]byte, failure/8+1),
+		BitLength: failure + 1,
+	}
+	failInfo.Bytes[failure/8] |= 0x80 >> (failure % 8)
+
+	resp, err := tsaReply(tsaResponse{
+		Status: tsaStatusInfo{
+			Status:       tsaStatusRejection,
+			StatusString: []asn1.RawValue{{Tag: asn1.TagUTF8String, Bytes: []byte(reason)}},
+			FailInfo:     failInfo,
+		},
+	})
+	if err != nil {
+		return logical.ErrorResponse(err.Error())
+	}
+	return resp
+}
+
+func tsaSystemFailure(b *backend, err error) *logical.Response {
+	// As for OCSP, internal errors are only logged at debug level, since
+	// this is an unauthenticated endpoint.
+	b.Logger().Debug("failed to answer timestamp request", "error", err)
+	return tsaRejection(tsaFailureSystemFailure, "internal error")
+}
+
+func tsaReply(reply tsaResponse) (*logical.Response, error) {
+	body, err := asn1.Marshal(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: tsaReplyContentType,
+			logical.HTTPStatusCode:  http.StatusOK,
+			logical.HTTPRawBody:     body,
+		},
+	}, nil
+}
+
+const pathConfigTSAHelpSyn = `
+Configure the RFC 3161 timestamp authority of this mount.
+`
+
+const pathConfigTSAHelpDesc = `
+This configures the key signing the timestamps of the timestamp authority, and
+the issuer of its certificate. Each write issues a new TSA certificate for the
+key, with the timeStamping extended key usage, which is returned along with its
+chain.
+`
+
+const pathTimestampHelpSyn = `
+Request an RFC 3161 timestamp.
+`
+
+const pathTimestampHelpDesc = `
+This endpoint accepts a DER encoded RFC 3161 timestamp request, and returns a
+DER encoded timestamp reply, signed by the timestamp authority configured with
+the config/tsa endpoint. The SHA-256, SHA-384 and SHA-512 hash algorithms are
+supported.
+`
@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pki
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/pkcs7"
+	"github.com/hashicorp/vault/sdk/helper/testhelpers/schema"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTSA_Timestamp verifies that timestamp tokens are signed by the
+// configured key, under a TSA certificate chaining to the issuer.
+func TestTSA_Timestamp(t *testing.T) {
+	t.Parallel()
+	b, s := CreateBackendWithStorage(t)
+
+	resp, err := CBWrite(b, s, "root/generate/internal", map[string]interface{}{
+		"common_name": "root example.com",
+		"key_type":    "ec",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	root := parseCert(t, resp.Data["certificate"].(string))
+
+	resp, err = CBWrite(b, s, "keys/generate/internal", map[string]interface{}{
+		"key_name": "tsa",
+		"key_type": "rsa",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+
+	// The authority is not usable until it is configured
+	tsResp := sendTimestampRequest(t, b, s, tsaRequest{
+		Version:        1,
+		MessageImprint: tsaTestImprint([]byte("artifact")),
+	})
+	require.Equal(t, tsaStatusRejection, tsResp.Status.Status)
+
+	resp, err = CBWrite(b, s, "config/tsa", map[string]interface{}{
+		"key_ref": "tsa",
+		"policy":  "1.3.6.1.4.1.13.2.1",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	schema.ValidateResponse(t, schema.GetResponseSchema(t, b.Route("config/tsa"), logical.UpdateOperation), resp, true)
+	tsaCert := parseCert(t, resp.Data["certificate"].(string))
+	require.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping}, tsaCert.ExtKeyUsage)
+
+	// The TSA certificate is stored along with the issued certificates
+	certResp, err := CBRead(b, s, "cert/"+resp.Data["serial_number"].(string))
+	requireSuccessNonNilResponse(t, certResp, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	_, err = tsaCert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	require.NoError(t, err)
+
+	imprint := tsaTestImprint([]byte("artifact"))
+	tsResp = sendTimestampRequest(t, b, s, tsaRequest{
+		Version:        1,
+		MessageImprint: imprint,
+		Nonce:          big.NewInt(42),
+		CertReq:        true,
+	})
+	require.Equal(t, tsaStatusGranted, tsResp.Status.Status)
+
+	token, err := pkcs7.Parse(tsResp.TimeStampToken.FullBytes)
+	require.NoError(t, err)
+	require.NoError(t, token.VerifyWithChain(roots))
+	require.Equal(t, tsaCert.Raw, token.GetOnlySigner().Raw)
+
+	var info tsaTSTInfo
+	_, err = asn1.Unmarshal(token.Content, &info)
+	require.NoError(t, err)
+	require.Equal(t, asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 13, 2, 1}, info.Policy)
+	require.Equal(t, imprint.HashedMessage, info.MessageImprint.HashedMessage)
+	require.Equal(t, int64(42), info.Nonce.Int64())
+	require.Equal(t, 1, info.Accuracy.Seconds)
+
+	// Requests with another policy or an unsupported hash are rejected
+	tsResp = sendTimestampRequest(t, b, s, tsaRequest{
+		Version:        1,
+		MessageImprint: imprint,
+		ReqPolicy:      asn1.ObjectIdentifier{1, 2, 3},
+	})
+	require.Equal(t, tsaStatusRejection, tsResp.Status.Status)
+	require.Equal(t, tsaFailureUnacceptedPolicy+1, tsResp.Status.FailInfo.BitLength)
+	require.Equal(t, 1, tsResp.Status.FailInfo.At(tsaFailureUnacceptedPolicy))
+
+	imprint.HashAlgorithm.Algorithm = pkcs7.OIDDigestAlgorithmSHA1
+	tsResp = sendTimestampRequest(t, b, s, tsaRequest{
+		Version:        1,
+		MessageImprint: imprint,
+	})
+	require.Equal(t, tsaStatusRejection, tsResp.Status.Status)
+	require.Equal(t, 1, tsResp.Status.FailInfo.At(tsaFailureBadAlg))
+
+	// Ed25519 keys can not back the authority
+	resp, err = CBWrite(b, s, "keys/generate/internal", map[string]interface{}{
+		"key_name": "ed",
+		"key_type": "ed25519",
+	})
+	requireSuccessNonNilResponse(t, resp, err)
+	_, err = CBWrite(b, s, "config/tsa", map[string]interface{}{
+		"key_ref": "ed",
+		"policy":  "1.3.6.1.4.1.13.2.1",
+	})
+	require.ErrorContains(t, err, "must be an RSA or EC key")
+}
+
+func tsaTestImprint(data []byte) tsaMessageImprint {
+	sum := sha256.Sum256(data)
+	imprint := tsaMessageImprint{
+		HashedMessage: sum[:],
+	}
+	imprint.HashAlgorithm.Algorithm = pkcs7.OIDDigestAlgorithmSHA256
+	return imprint
+}
+
+func sendTimestampRequest(t *testing.T, b *backend, s logical.Storage, tsReq tsaRequest) *tsaResponse {
+	t.Helper()
+
+	derReq, err := asn1.Marshal(tsReq)
+	require.NoError(t, err)
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation:  logical.UpdateOperation,
+		Path:       "timestamp",
+		Storage:    s,
+		MountPoint: "pki/",
+		HTTPRequest: &http.Request{
+			Body: io.NopCloser(bytes.NewReader(derReq)),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Data[logical.HTTPStatusCode])
+	require.Equal(t, tsaReplyContentType, resp.Data[logical.HTTPContentType])
+
+	var tsResp tsaResponse
+	_, err = asn1.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &tsResp)
+	require.NoError(t, err)
+	return &tsResp
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/cryptopolicy"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -41,9 +42,13 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 	var b backend
 	b.Backend = &framework.Backend{
 		PathsSpecial: &logical.Paths{
+			Unauthenticated: []string{
+				"jwks/*",
+			},
 			SealWrapStorage: []string{
 				"archive/",
 				"policy/",
+				"kmip/config",
 			},
 		},
 
@@ -55,14 +60,18 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 			b.pathWrappingKey(),
 			b.pathImport(),
 			b.pathImportVersion(),
+			b.pathKeysJWKS(),
 			b.pathKeys(),
 			b.pathListKeys(),
 			b.pathBYOKExportKeys(),
+			b.pathVerificationBundle(),
 			b.pathExportKeys(),
 			b.pathKeysConfig(),
 			b.pathEncrypt(),
 			b.pathDecrypt(),
+			b.pathDatakeyRewrap(),
 			b.pathDatakey(),
+			b.pathConvergentMigrate(),
 			b.pathRandom(),
 			b.pathHash(),
 			b.pathHMAC(),
@@ -73,17 +82,30 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 			b.pathTrim(),
 			b.pathCacheConfig(),
 			b.pathConfigKeys(),
+			b.pathConfigWorkerPools(),
 			b.pathCreateCsr(),
 			b.pathImportCertChain(),
+			b.pathKMIPConfig(),
+			b.pathListKMIPRoles(),
+			b.pathKMIPRoles(),
+			b.pathListJWKSSets(),
+			b.pathJWKSSets(),
+			b.pathJWKS(),
+			b.pathListDatakeyPolicies(),
+			b.pathDatakeyPolicies(),
 		},
 
-		Secrets:      []*framework.Secret{},
-		Invalidate:   b.invalidate,
-		BackendType:  logical.TypeLogical,
-		PeriodicFunc: b.periodicFunc,
+		Secrets:          []*framework.Secret{},
+		InitializeFunc:   b.initialize,
+		Clean:            b.cleanup,
+		Invalidate:       b.invalidate,
+		BackendType:      logical.TypeLogical,
+		PeriodicFunc:     b.periodicFunc,
+		RotateCredential: b.rotateScheduledKey,
 	}
 
 	b.backendUUID = conf.BackendUUID
+	b.storage = conf.StorageView
 
 	// determine cacheSize to use. Defaults to 0 which means unlimited
 	cacheSize := 0
@@ -107,6 +129,15 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (*backend, error)
 		return nil, err
 	}
 
+	poolsConfig := &defaultWorkerPoolsConfig
+	if conf.StorageView != nil {
+		poolsConfig, err = readConfigWorkerPools(ctx, conf.StorageView)
+		if err != nil {
+			return nil, err
+		}
+	}
+	b.workerPools = newWorkerPools(b.backendUUID, poolsConfig)
+
 	return &b, nil
 }
 
@@ -119,6 +150,15 @@ type backend struct {
 	checkAutoRotateAfter time.Time
 	autoRotateOnce       sync.Once
 	backendUUID          string
+	storage              logical.Storage
+
+	// kmipLock protects kmipServer, the KMIP server of the mount if one is
+	// configured.
+	kmipLock   sync.Mutex
+	kmipServer *kmipServer
+
+	// workerPools run the CPU-heavy operations of the mount.
+	workerPools *workerPools
 }
 
 func GetCacheSizeFromStorage(ctx context.Context, s logical.Storage) (int, error) {
@@ -171,6 +211,44 @@ func (b *backend) GetPolicy(ctx context.Context, polReq keysutil.PolicyRequest,
 	return p, true, nil
 }
 
+// checkCryptoPolicy returns an error response if the crypto policy of the
+// server does not allow keys of the given type.
+func (b *backend) checkCryptoPolicy(ctx context.Context, keyType keysutil.KeyType) (*logical.Response, error) {
+	env, err := b.System().PluginEnv(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crypto policy: %w", err)
+	}
+	if env == nil {
+		return nil, nil
+	}
+	if err := cryptopolicy.CheckTransitKeyType(env.CryptoPolicy, keyType.String()); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	return nil, nil
+}
+
+func (b *backend) initialize(ctx context.Context, _ *logical.InitializationRequest) error {
+	b.kmipLock.Lock()
+	defer b.kmipLock.Unlock()
+
+	// Failing to start the KMIP server should not prevent the mount from
+	// being used, so errors are only logged
+	if err := b.reloadKMIPServer(ctx); err != nil {
+		b.Logger().Error("failed to start KMIP server", "error", err)
+	}
+	return nil
+}
+
+func (b *backend) cleanup(_ context.Context) {
+	b.kmipLock.Lock()
+	defer b.kmipLock.Unlock()
+
+	if b.kmipServer != nil {
+		b.kmipServer.stop()
+		b.kmipServer = nil
+	}
+}
+
 func (b *backend) invalidate(ctx context.Context, key string) {
 	if b.Logger().IsDebug() {
 		b.Logger().Debug("invalidating key", "key", key)
@@ -184,6 +262,19 @@ func (b *backend) invalidate(ctx context.Context, key string) {
 		b.configMutex.Lock()
 		defer b.configMutex.Unlock()
 		b.cacheSizeChanged = true
+	case key == workerPoolsConfigPath:
+		cfg, err := readConfigWorkerPools(ctx, b.storage)
+		if err != nil {
+			b.Logger().Error("failed to reload worker pools configuration", "error", err)
+			return
+		}
+		b.workerPools.apply(cfg)
+	case key == kmipConfigPath:
+		b.kmipLock.Lock()
+		defer b.kmipLock.Unlock()
+		if err := b.reloadKMIPServer(ctx); err != nil {
+			b.Logger().Error("failed to restart KMIP server", "error", err)
+		}
 	}
 }
 
@@ -291,3 +382,33 @@ func (b *backend) rotateIfRequired(ctx context.Context, req *logical.Request, ke
 	}
 	return nil
 }
+
+// rotateScheduledKey rotates the key whose rotation schedule, registered with
+// Vault's rotation manager, is due.
+func (b *backend) rotateScheduledKey(ctx context.Context, req *logical.Request) error {
+	name := strings.TrimSuffix(strings.TrimPrefix(req.Path, "keys/"), "/rotate")
+
+	p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return fmt.Errorf("key %q not found", name)
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(true)
+	}
+	defer p.Unlock()
+
+	if p.RotationSchedule == "" {
+		return fmt.Errorf("key %q has no rotation schedule", name)
+	}
+
+	if b.Logger().IsDebug() {
+		b.Logger().Debug("rotating key on schedule", "key", name)
+	}
+	return p.Rotate(ctx, req.Storage, b.GetRandomReader())
+}
@@ -0,0 +1,592 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// KMIP tags of the items used by the KMIP server.
+const (
+	kmipTagAttribute               uint32 = 0x420008
+	kmipTagAttributeName           uint32 = 0x42000A
+	kmipTagAttributeValue          uint32 = 0x42000B
+	kmipTagBatchCount              uint32 = 0x42000D
+	kmipTagBatchItem               uint32 = 0x42000F
+	kmipTagCryptographicAlgorithm  uint32 = 0x420028
+	kmipTagCryptographicLength     uint32 = 0x42002A
+	kmipTagKeyBlock                uint32 = 0x420040
+	kmipTagKeyFormatType           uint32 = 0x420042
+	kmipTagKeyMaterial             uint32 = 0x420043
+	kmipTagKeyValue                uint32 = 0x420045
+	kmipTagMaximumItems            uint32 = 0x42004F
+	kmipTagNameValue               uint32 = 0x420055
+	kmipTagObjectType              uint32 = 0x420057
+	kmipTagOperation               uint32 = 0x42005C
+	kmipTagProtocolVersion         uint32 = 0x420069
+	kmipTagProtocolVersionMajor    uint32 = 0x42006A
+	kmipTagProtocolVersionMinor    uint32 = 0x42006B
+	kmipTagQueryFunction           uint32 = 0x420074
+	kmipTagRequestHeader           uint32 = 0x420077
+	kmipTagRequestMessage          uint32 = 0x420078
+	kmipTagRequestPayload          uint32 = 0x420079
+	kmipTagResponseHeader          uint32 = 0x42007A
+	kmipTagResponseMessage         uint32 = 0x42007B
+	kmipTagResponsePayload         uint32 = 0x42007C
+	kmipTagResultMessage           uint32 = 0x42007D
+	kmipTagResultReason            uint32 = 0x42007E
+	kmipTagResultStatus            uint32 = 0x42007F
+	kmipTagSymmetricKey            uint32 = 0x42008F
+	kmipTagTimeStamp               uint32 = 0x420092
+	kmipTagUniqueBatchItemID       uint32 = 0x420093
+	kmipTagUniqueIdentifier        uint32 = 0x420094
+	kmipTagData                    uint32 = 0x4200C2
+	kmipTagIVCounterNonce          uint32 = 0x4200C3
+	kmipTagCryptographicParameters uint32 = 0x42002B
+)
+
+// KMIP operations supported by the KMIP server.
+const (
+	kmipOperationLocate           uint32 = 0x08
+	kmipOperationGet              uint32 = 0x0A
+	kmipOperationQuery            uint32 = 0x18
+	kmipOperationDiscoverVersions uint32 = 0x1E
+	kmipOperationEncrypt          uint32 = 0x1F
+	kmipOperationDecrypt          uint32 = 0x20
+)
+
+// KMIP result reasons of failed operations.
+const (
+	kmipReasonItemNotFound          uint32 = 0x01
+	kmipReasonInvalidMessage        uint32 = 0x04
+	kmipReasonOperationNotSupported uint32 = 0x05
+	kmipReasonMissingData           uint32 = 0x06
+	kmipReasonInvalidField          uint32 = 0x07
+	kmipReasonCryptographicFailure  uint32 = 0x0A
+	kmipReasonIllegalOperation      uint32 = 0x0B
+	kmipReasonPermissionDenied      uint32 = 0x0C
+	kmipReasonGeneralFailure        uint32 = 0x100
+)
+
+const (
+	kmipResultStatusSuccess         uint32 = 0x00
+	kmipResultStatusOperationFailed uint32 = 0x01
+
+	kmipObjectTypeSymmetricKey    uint32 = 0x02
+	kmipKeyFormatTypeRaw          uint32 = 0x01
+	kmipCryptographicAlgorithmAES uint32 = 0x03
+
+	kmipQueryOperations uint32 = 0x01
+	kmipQueryObjects    uint32 = 0x02
+
+	// kmipIdleTimeout is how long a client connection may stay idle between
+	// two requests before being closed.
+	kmipIdleTimeout = 5 * time.Minute
+)
+
+// kmipOperationNames maps the supported KMIP operations to the names used in
+// the allowed_operations of KMIP roles. Discover Versions and Query are
+// allowed to any client with a role.
+var kmipOperationNames = map[uint32]string{
+	kmipOperationLocate:  "locate",
+	kmipOperationGet:     "get",
+	kmipOperationEncrypt: "encrypt",
+	kmipOperationDecrypt: "decrypt",
+}
+
+// kmipProtocolVersions are the KMIP protocol versions supported by the KMIP
+// server, from the most preferred. Encrypt and Decrypt require KMIP 1.2.
+var kmipProtocolVersions = [][2]int64{{1, 4}, {1, 3}, {1, 2}}
+
+// kmipError is the failure of a KMIP operation.
+type kmipError struct {
+	reason  uint32
+	message string
+}
+
+func (e *kmipError) Error() string {
+	return e.message
+}
+
+func newKMIPError(reason uint32, format string, args ...interface{}) *kmipError {
+	return &kmipError{reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+// kmipServer serves KMIP clients, authenticated by their TLS client
+// certificate, over the keys of the transit mount.
+type kmipServer struct {
+	b        *backend
+	storage  logical.Storage
+	listener net.Listener
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	connsLock sync.Mutex
+	conns     map[net.Conn]struct{}
+}
+
+// runsKMIPServer returns whether this node should serve KMIP clients: like
+// automatic key rotation, only primaries and performance secondaries with a
+// local mount do.
+func (b *backend) runsKMIPServer() bool {
+	return !b.System().ReplicationState().HasState(consts.ReplicationDRSecondary|consts.ReplicationPerformanceStandby) &&
+		(b.System().LocalMount() || !b.System().ReplicationState().HasState(consts.ReplicationPerformanceSecondary))
+}
+
+// newKMIPServer starts listening for KMIP clients with the given
+// configuration.
+func (b *backend) newKMIPServer(cfg *kmipConfig) (*kmipServer, error) {
+	cert, err := tls.X509KeyPair([]byte(cfg.TLSCertificate), []byte(cfg.TLSPrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS certificate or private key: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM([]byte(cfg.TLSClientCA)) {
+		return nil, errors.New("no certificate found in TLS client CA")
+	}
+
+	listener, err := tls.Listen("tcp", cfg.ListenAddress, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %q: %w", cfg.ListenAddress, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &kmipServer{
+		b:        b,
+		storage:  b.storage,
+		listener: listener,
+		ctx:      ctx,
+		cancel:   cancel,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// reloadKMIPServer stops the running KMIP server, if any, and starts a new one
+// from the stored configuration. The caller must hold kmipLock.
+func (b *backend) reloadKMIPServer(ctx context.Context) error {
+	if b.kmipServer != nil {
+		b.kmipServer.stop()
+		b.kmipServer = nil
+	}
+	if !b.runsKMIPServer() {
+		return nil
+	}
+
+	cfg, err := readKMIPConfig(ctx, b.storage)
+	if err != nil || cfg == nil {
+		return err
+	}
+	server, err := b.newKMIPServer(cfg)
+	if err != nil {
+		return err
+	}
+	b.kmipServer = server
+	return nil
+}
+
+// stop closes the listener and the client connections of the server, and
+// waits for in-flight requests to complete.
+func (s *kmipServer) stop() {
+	s.cancel()
+	s.listener.Close()
+
+	s.connsLock.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.connsLock.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *kmipServer) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if s.ctx.Err() == nil {
+				s.b.Logger().Error("failed to accept KMIP connection, stopping KMIP server", "error", err)
+			}
+			return
+		}
+
+		s.connsLock.Lock()
+		s.conns[conn] = struct{}{}
+		s.connsLock.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.connsLock.Lock()
+				delete(s.conns, conn)
+				s.connsLock.Unlock()
+				conn.Close()
+			}()
+			s.serveConn(conn.(*tls.Conn))
+		}()
+	}
+}
+
+func (s *kmipServer) serveConn(conn *tls.Conn) {
+	logger := s.b.Logger().With("remote_address", conn.RemoteAddr().String())
+
+	conn.SetDeadline(time.Now().Add(kmipIdleTimeout))
+	if err := conn.HandshakeContext(s.ctx); err != nil {
+		logger.Debug("KMIP TLS handshake failed", "error", err)
+		return
+	}
+	peerCerts := conn.ConnectionState().PeerCertificates
+	if len(peerCerts) == 0 {
+		return
+	}
+	commonName := peerCerts[0].Subject.CommonName
+
+	for {
+		conn.SetDeadline(time.Now().Add(kmipIdleTimeout))
+		req, err := readKMIPItem(conn)
+		if err != nil {
+			if s.ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				logger.Debug("failed to read KMIP request", "error", err)
+			}
+			return
+		}
+
+		resp := s.handleMessage(s.ctx, commonName, req)
+		if _, err := conn.Write(resp.marshal()); err != nil {
+			logger.Debug("failed to write KMIP response", "error", err)
+			return
+		}
+	}
+}
+
+// handleMessage processes the batch items of a KMIP request message sent by
+// the client with the given certificate common name.
+func (s *kmipServer) handleMessage(ctx context.Context, commonName string, req *kmipItem) *kmipItem {
+	version := kmipProtocolVersions[0]
+	header := req.child(kmipTagRequestHeader)
+	if req.Tag != kmipTagRequestMessage || req.Type != kmipTypeStructure || header == nil {
+		return kmipResponseMessage(version, kmipResponseBatchItem(nil, nil, nil,
+			newKMIPError(kmipReasonInvalidMessage, "invalid request message")))
+	}
+	if v := header.child(kmipTagProtocolVersion); v != nil {
+		major, minor := v.child(kmipTagProtocolVersionMajor), v.child(kmipTagProtocolVersionMinor)
+		if major != nil && minor != nil && major.Int == 1 && minor.Int < version[1] {
+			version = [2]int64{major.Int, minor.Int}
+		}
+	}
+
+	roles, err := s.b.kmipRolesForCommonName(ctx, s.storage, commonName)
+	if err != nil {
+		s.b.Logger().Error("failed to read KMIP roles", "error", err)
+	}
+
+	var batchItems []*kmipItem
+	for _, item := range req.childrenWithTag(kmipTagBatchItem) {
+		operation := item.child(kmipTagOperation)
+		batchItemID := item.child(kmipTagUniqueBatchItemID)
+
+		var payload *kmipItem
+		var opErr *kmipError
+		switch {
+		case err != nil:
+			opErr = newKMIPError(kmipReasonGeneralFailure, "failed to read KMIP roles")
+		case len(roles) == 0:
+			opErr = newKMIPError(kmipReasonPermissionDenied, "no KMIP role allows client %q", commonName)
+		case operation == nil:
+			opErr = newKMIPError(kmipReasonMissingData, "missing operation")
+		default:
+			payload, opErr = s.handleOperation(ctx, roles, uint32(operation.Int), item.child(kmipTagRequestPayload))
+		}
+		batchItems = append(batchItems, kmipResponseBatchItem(operation, batchItemID, payload, opErr))
+	}
+
+	return kmipResponseMessage(version, batchItems...)
+}
+
+func kmipResponseMessage(version [2]int64, batchItems ...*kmipItem) *kmipItem {
+	header := kmipStructure(kmipTagResponseHeader,
+		kmipStructure(kmipTagProtocolVersion,
+			kmipInteger(kmipTagProtocolVersionMajor, int32(version[0])),
+			kmipInteger(kmipTagProtocolVersionMinor, int32(version[1])),
+		),
+		kmipDateTime(kmipTagTimeStamp, time.Now()),
+		kmipInteger(kmipTagBatchCount, int32(len(batchItems))),
+	)
+	return kmipStructure(kmipTagResponseMessage, append([]*kmipItem{header}, batchItems...)...)
+}
+
+func kmipResponseBatchItem(operation, batchItemID, payload *kmipItem, opErr *kmipError) *kmipItem {
+	item := kmipStructure(kmipTagBatchItem)
+	if operation != nil {
+		item.Children = append(item.Children, operation)
+	}
+	if batchItemID != nil {
+		item.Children = append(item.Children, batchItemID)
+	}
+	if opErr != nil {
+		item.Children = append(item.Children,
+			kmipEnumeration(kmipTagResultStatus, kmipResultStatusOperationFailed),
+			kmipEnumeration(kmipTagResultReason, opErr.reason),
+			kmipTextString(kmipTagResultMessage, opErr.message),
+		)
+		return item
+	}
+
+	item.Children = append(item.Children, kmipEnumeration(kmipTagResultStatus, kmipResultStatusSuccess))
+	if payload != nil {
+		item.Children = append(item.Children, payload)
+	}
+	return item
+}
+
+func (s *kmipServer) handleOperation(ctx context.Context, roles []*kmipRole, operation uint32, payload *kmipItem) (*kmipItem, *kmipError) {
+	if payload == nil {
+		payload = kmipStructure(kmipTagRequestPayload)
+	}
+
+	switch operation {
+	case kmipOperationDiscoverVersions:
+		return s.discoverVersions(payload), nil
+	case kmipOperationQuery:
+		return s.query(payload), nil
+	case kmipOperationLocate:
+		return s.locate(ctx, roles, payload)
+	case kmipOperationGet:
+		return s.get(ctx, roles, payload)
+	case kmipOperationEncrypt, kmipOperationDecrypt:
+		return s.encryptOrDecrypt(ctx, roles, operation, payload)
+	default:
+		return nil, newKMIPError(kmipReasonOperationNotSupported, "operation %#x is not supported", operation)
+	}
+}
+
+// discoverVersions returns the supported protocol versions, restricted to
+// those of the client if any.
+func (s *kmipServer) discoverVersions(payload *kmipItem) *kmipItem {
+	resp := kmipStructure(kmipTagResponsePayload)
+	requested := payload.childrenWithTag(kmipTagProtocolVersion)
+	for _, v := range kmipProtocolVersions {
+		supported := len(requested) == 0
+		for _, r := range requested {
+			major, minor := r.child(kmipTagProtocolVersionMajor), r.child(kmipTagProtocolVersionMinor)
+			if major != nil && minor != nil && major.Int == v[0] && minor.Int == v[1] {
+				supported = true
+			}
+		}
+		if supported {
+			resp.Children = append(resp.Children, kmipStructure(kmipTagProtocolVersion,
+				kmipInteger(kmipTagProtocolVersionMajor, int32(v[0])),
+				kmipInteger(kmipTagProtocolVersionMinor, int32(v[1])),
+			))
+		}
+	}
+	return resp
+}
+
+// query returns the supported operations and object types.
+func (s *kmipServer) query(payload *kmipItem) *kmipItem {
+	resp := kmipStructure(kmipTagResponsePayload)
+	for _, function := range payload.childrenWithTag(kmipTagQueryFunction) {
+		switch uint32(function.Int) {
+		case kmipQueryOperations:
+			for _, op := range []uint32{
+				kmipOperationLocate, kmipOperationGet, kmipOperationQuery,
+				kmipOperationDiscoverVersions, kmipOperationEncrypt, kmipOperationDecrypt,
+			} {
+				resp.Children = append(resp.Children, kmipEnumeration(kmipTagOperation, op))
+			}
+		case kmipQueryObjects:
+			resp.Children = append(resp.Children, kmipEnumeration(kmipTagObjectType, kmipObjectTypeSymmetricKey))
+		}
+	}
+	return resp
+}
+
+// locate returns the names of the keys the client may locate, restricted to
+// the key named by the Name attribute if any.
+func (s *kmipServer) locate(ctx context.Context, roles []*kmipRole, payload *kmipItem) (*kmipItem, *kmipError) {
+	var name string
+	for _, attr := range payload.childrenWithTag(kmipTagAttribute) {
+		if attr.child(kmipTagAttributeName).text() != "Name" {
+			continue
+		}
+		value := attr.child(kmipTagAttributeValue)
+		if value == nil {
+			return nil, newKMIPError(kmipReasonInvalidField, "missing value of Name attribute")
+		}
+		name = value.child(kmipTagNameValue).text()
+	}
+
+	keys, err := s.storage.List(ctx, "policy/")
+	if err != nil {
+		s.b.Logger().Error("failed to list keys for KMIP locate", "error", err)
+		return nil, newKMIPError(kmipReasonGeneralFailure, "failed to list keys")
+	}
+
+	maxItems := -1
+	if m := payload.child(kmipTagMaximumItems); m != nil && m.Int > 0 {
+		maxItems = int(m.Int)
+	}
+
+	resp := kmipStructure(kmipTagResponsePayload)
+	for _, key := range keys {
+		if maxItems == 0 {
+			break
+		}
+		if (name != "" && key != name) || !kmipRolesAllow(roles, "locate", key) {
+			continue
+		}
+		resp.Children = append(resp.Children, kmipTextString(kmipTagUniqueIdentifier, key))
+		maxItems--
+	}
+	return resp, nil
+}
+
+// kmipKey returns the key identified by the unique identifier of the payload,
+// if the client may perform the operation with it. The returned policy is
+// read locked if caching is enabled.
+func (s *kmipServer) kmipKey(ctx context.Context, roles []*kmipRole, operation string, payload *kmipItem) (string, *keysutil.Policy, *kmipError) {
+	name := payload.child(kmipTagUniqueIdentifier).text()
+	if name == "" {
+		return "", nil, newKMIPError(kmipReasonMissingData, "missing unique identifier")
+	}
+	if !kmipRolesAllow(roles, operation, name) {
+		return "", nil, newKMIPError(kmipReasonPermissionDenied, "%s is not allowed on key %q", operation, name)
+	}
+
+	p, _, err := s.b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: s.storage,
+		Name:    name,
+	}, s.b.GetRandomReader())
+	if err != nil {
+		s.b.Logger().Error("failed to read key for KMIP request", "key", name, "error", err)
+		return "", nil, newKMIPError(kmipReasonGeneralFailure, "failed to read key %q", name)
+	}
+	if p == nil {
+		return "", nil, newKMIPError(kmipReasonItemNotFound, "key %q not found", name)
+	}
+	if !s.b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	return name, p, nil
+}
+
+// get returns the key material of the latest version of an exportable AES
+// key.
+func (s *kmipServer) get(ctx context.Context, roles []*kmipRole, payload *kmipItem) (*kmipItem, *kmipError) {
+	name, p, kerr := s.kmipKey(ctx, roles, "get", payload)
+	if kerr != nil {
+		return nil, kerr
+	}
+	defer p.Unlock()
+
+	if !p.Exportable {
+		return nil, newKMIPError(kmipReasonPermissionDenied, "key %q is not exportable", name)
+	}
+	if p.Type != keysutil.KeyType_AES128_GCM96 && p.Type != keysutil.KeyType_AES256_GCM96 {
+		return nil, newKMIPError(kmipReasonIllegalOperation, "key %q of type %s cannot be retrieved over KMIP", name, p.Type)
+	}
+	key, ok := p.Keys[strconv.Itoa(p.LatestVersion)]
+	if !ok {
+		return nil, newKMIPError(kmipReasonGeneralFailure, "latest version of key %q not found", name)
+	}
+
+	return kmipStructure(kmipTagResponsePayload,
+		kmipEnumeration(kmipTagObjectType, kmipObjectTypeSymmetricKey),
+		kmipTextString(kmipTagUniqueIdentifier, name),
+		kmipStructure(kmipTagSymmetricKey,
+			kmipStructure(kmipTagKeyBlock,
+				kmipEnumeration(kmipTagKeyFormatType, kmipKeyFormatTypeRaw),
+				kmipStructure(kmipTagKeyValue,
+					kmipByteString(kmipTagKeyMaterial, key.Key),
+				),
+				kmipEnumeration(kmipTagCryptographicAlgorithm, kmipCryptographicAlgorithmAES),
+				kmipInteger(kmipTagCryptographicLength, int32(len(key.Key)*8)),
+			),
+		),
+	), nil
+}
+
+// encryptOrDecrypt encrypts or decrypts the data of the payload with a key.
+// Ciphertexts are the transit ciphertexts of the data, which carry the key
+// version and nonce, so the client must treat them as opaque.
+func (s *kmipServer) encryptOrDecrypt(ctx context.Context, roles []*kmipRole, operation uint32, payload *kmipItem) (*kmipItem, *kmipError) {
+	opName := kmipOperationNames[operation]
+	name, p, kerr := s.kmipKey(ctx, roles, opName, payload)
+	if kerr != nil {
+		return nil, kerr
+	}
+	defer p.Unlock()
+
+	if p.Derived {
+		return nil, newKMIPError(kmipReasonIllegalOperation, "key %q is derived, which is not supported over KMIP", name)
+	}
+	if payload.child(kmipTagCryptographicParameters) != nil || payload.child(kmipTagIVCounterNonce) != nil {
+		return nil, newKMIPError(kmipReasonInvalidField, "cryptographic parameters and nonces are determined by the key")
+	}
+	data := payload.child(kmipTagData)
+	if data == nil {
+		return nil, newKMIPError(kmipReasonMissingData, "missing data")
+	}
+
+	var out []byte
+	if operation == kmipOperationEncrypt {
+		ciphertext, err := p.Encrypt(0, nil, nil, base64.StdEncoding.EncodeToString(data.Bytes))
+		if err != nil {
+			return nil, newKMIPError(kmipReasonCryptographicFailure, "failed to encrypt with key %q: %v", name, err)
+		}
+		out = []byte(ciphertext)
+	} else {
+		plaintext, err := p.Decrypt(nil, nil, string(data.Bytes))
+		if err != nil {
+			return nil, newKMIPError(kmipReasonCryptographicFailure, "failed to decrypt with key %q: %v", name, err)
+		}
+		if out, err = base64.StdEncoding.DecodeString(plaintext); err != nil {
+			return nil, newKMIPError(kmipReasonCryptographicFailure, "failed to decode plaintext: %v", err)
+		}
+	}
+
+	return kmipStructure(kmipTagResponsePayload,
+		kmipTextString(kmipTagUniqueIdentifier, name),
+		kmipByteString(kmipTagData, out),
+	), nil
+}
+
+// kmipRolesAllow returns whether any of the roles allows the operation on the
+// named key.
+func kmipRolesAllow(roles []*kmipRole, operation, key string) bool {
+	for _, role := range roles {
+		if strutil.StrListContains(role.AllowedOperations, operation) &&
+			strutil.StrListContainsGlob(role.AllowedKeys, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// KMIP item types of the TTLV (tag, type, length, value) encoding.
+const (
+	kmipTypeStructure   byte = 0x01
+	kmipTypeInteger     byte = 0x02
+	kmipTypeLongInteger byte = 0x03
+	kmipTypeBigInteger  byte = 0x04
+	kmipTypeEnumeration byte = 0x05
+	kmipTypeBoolean     byte = 0x06
+	kmipTypeTextString  byte = 0x07
+	kmipTypeByteString  byte = 0x08
+	kmipTypeDateTime    byte = 0x09
+	kmipTypeInterval    byte = 0x0A
+)
+
+// kmipMaxMessageSize bounds the size of the KMIP messages read from clients.
+const kmipMaxMessageSize = 1 << 20
+
+// kmipItem is a TTLV encoded KMIP item. Integer, long integer, enumeration,
+// boolean, date-time and interval values are held in Int, text, byte and big
+// integer values in Bytes, and the items of structures in Children.
+type kmipItem struct {
+	Tag      uint32
+	Type     byte
+	Int      int64
+	Bytes    []byte
+	Children []*kmipItem
+}
+
+func kmipStructure(tag uint32, children ...*kmipItem) *kmipItem {
+	return &kmipItem{Tag: tag, Type: kmipTypeStructure, Children: children}
+}
+
+func kmipInteger(tag uint32, v int32) *kmipItem {
+	return &kmipItem{Tag: tag, Type: kmipTypeInteger, Int: int64(v)}
+}
+
+func kmipEnumeration(tag uint32, v uint32) *kmipItem {
+	return &kmipItem{Tag: tag, Type: kmipTypeEnumeration, Int: int64(v)}
+}
+
+func kmipTextString(tag uint32, v string) *kmipItem {
+	return &kmipItem{Tag: tag, Type: kmipTypeTextString, Bytes: []byte(v)}
+}
+
+func kmipByteString(tag uint32, v []byte) *kmipItem {
+	return &kmipItem{Tag: tag, Type: kmipTypeByteString, Bytes: v}
+}
+
+func kmipDateTime(tag uint32, v time.Time) *kmipItem {
+	return &kmipItem{Tag: tag, Type: kmipTypeDateTime, Int: v.Unix()}
+}
+
+// child returns the first item of the structure with the given tag, or nil.
+func (i *kmipItem) child(tag uint32) *kmipItem {
+	for _, c := range i.Children {
+		if c.Tag == tag {
+			return c
+		}
+	}
+	return nil
+}
+
+// childrenWithTag returns the items of the structure with the given tag.
+func (i *kmipItem) childrenWithTag(tag uint32) []*kmipItem {
+	var children []*kmipItem
+	for _, c := range i.Children {
+		if c.Tag == tag {
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+// text returns the value of a text string item, or "" if the item is nil.
+func (i *kmipItem) text() string {
+	if i == nil || i.Type != kmipTypeTextString {
+		return ""
+	}
+	return string(i.Bytes)
+}
+
+// marshal encodes the item in TTLV.
+func (i *kmipItem) marshal() []byte {
+	var value []byte
+	switch i.Type {
+	case kmipTypeStructure:
+		for _, c := range i.Children {
+			value = append(value, c.marshal()...)
+		}
+	case kmipTypeInteger, kmipTypeEnumeration, kmipTypeInterval:
+		value = binary.BigEndian.AppendUint32(nil, uint32(i.Int))
+	case kmipTypeLongInteger, kmipTypeBoolean, kmipTypeDateTime:
+		value = binary.BigEndian.AppendUint64(nil, uint64(i.Int))
+	default:
+		value = i.Bytes
+	}
+
+	out := make([]byte, 8, 8+len(value)+7)
+	out[0], out[1], out[2] = byte(i.Tag>>16), byte(i.Tag>>8), byte(i.Tag)
+	out[3] = i.Type
+	binary.BigEndian.PutUint32(out[4:], uint32(len(value)))
+	out = append(out, value...)
+	if pad := len(value) % 8; pad != 0 {
+		out = append(out, make([]byte, 8-pad)...)
+	}
+	return out
+}
+
+// readKMIPItem reads a TTLV encoded item, such as a KMIP request message,
+// from r.
+func readKMIPItem(r io.Reader) (*kmipItem, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header[4:])
+	if length > kmipMaxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes exceeds the maximum of %d bytes", length, kmipMaxMessageSize)
+	}
+
+	buf := make([]byte, 8+int(length))
+	copy(buf, header[:])
+	if _, err := io.ReadFull(r, buf[8:]); err != nil {
+		return nil, err
+	}
+
+	item, rest, err := unmarshalKMIPItem(buf)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after item")
+	}
+	return item, nil
+}
+
+// unmarshalKMIPItem decodes the TTLV encoded item at the start of buf,
+// returning the remaining bytes.
+func unmarshalKMIPItem(buf []byte) (*kmipItem, []byte, error) {
+	if len(buf) < 8 {
+		return nil, nil, errors.New("truncated item header")
+	}
+	item := &kmipItem{
+		Tag:  uint32(buf[0])<<16 | uint32(buf[1])<<8 | uint32(buf[2]),
+		Type: buf[3],
+	}
+	length := int(binary.BigEndian.Uint32(buf[4:]))
+	buf = buf[8:]
+
+	padded := length
+	if item.Type != kmipTypeStructure && length%8 != 0 {
+		padded += 8 - length%8
+	}
+	if length < 0 || padded > len(buf) {
+		return nil, nil, fmt.Errorf("truncated value of item %06x", item.Tag)
+	}
+	value, rest := buf[:length], buf[padded:]
+
+	switch item.Type {
+	case kmipTypeStructure:
+		for len(value) > 0 {
+			child, remaining, err := unmarshalKMIPItem(value)
+			if err != nil {
+				return nil, nil, err
+			}
+			item.Children = append(item.Children, child)
+			value = remaining
+		}
+	case kmipTypeInteger, kmipTypeEnumeration, kmipTypeInterval:
+		if length != 4 {
+			return nil, nil, fmt.Errorf("invalid length %d of item %06x", length, item.Tag)
+		}
+		if item.Type == kmipTypeInteger {
+			item.Int = int64(int32(binary.BigEndian.Uint32(value)))
+		} else {
+			item.Int = int64(binary.BigEndian.Uint32(value))
+		}
+	case kmipTypeLongInteger, kmipTypeBoolean, kmipTypeDateTime:
+		if length != 8 {
+			return nil, nil, fmt.Errorf("invalid length %d of item %06x", length, item.Tag)
+		}
+		item.Int = int64(binary.BigEndian.Uint64(value))
+	case kmipTypeBigInteger, kmipTypeTextString, kmipTypeByteString:
+		item.Bytes = append([]byte(nil), value...)
+	default:
+		return nil, nil, fmt.Errorf("unknown type %#x of item %06x", item.Type, item.Tag)
+	}
+	return item, rest, nil
+}
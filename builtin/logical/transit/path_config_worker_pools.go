@@ -0,0 +1,242 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/helper/workerpool"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	workerPoolsConfigPath = "config/worker-pools"
+
+	// defaultBatchSignMinSize is the number of items from which batch signing
+	// requests are run on the batch signing pool.
+	defaultBatchSignMinSize = 64
+)
+
+// workerPoolsConfig sizes the pools running the CPU-heavy operations of the
+// mount. Zero workers use one worker per CPU, and a zero max queue does not
+// bound the queue.
+type workerPoolsConfig struct {
+	KeygenWorkers     int `json:"keygen_workers"`
+	KeygenMaxQueue    int `json:"keygen_max_queue"`
+	BatchSignWorkers  int `json:"batch_sign_workers"`
+	BatchSignMaxQueue int `json:"batch_sign_max_queue"`
+	BatchSignMinSize  int `json:"batch_sign_min_size"`
+}
+
+var defaultWorkerPoolsConfig = workerPoolsConfig{
+	BatchSignMinSize: defaultBatchSignMinSize,
+}
+
+// workerPools are the pools running the CPU-heavy operations of the mount:
+// RSA key generation, and signing of large batches.
+type workerPools struct {
+	keygen           *workerpool.Pool
+	batchSign        *workerpool.Pool
+	batchSignMinSize atomic.Int64
+}
+
+func newWorkerPools(backendUUID string, cfg *workerPoolsConfig) *workerPools {
+	key := []string{"secrets", "transit", "worker_pool"}
+	label := metrics.Label{Name: "backend_uuid", Value: backendUUID}
+
+	pools := &workerPools{
+		keygen:    workerpool.New("keygen", cfg.KeygenWorkers, cfg.KeygenMaxQueue, key, label),
+		batchSign: workerpool.New("batch_sign", cfg.BatchSignWorkers, cfg.BatchSignMaxQueue, key, label),
+	}
+	pools.batchSignMinSize.Store(int64(cfg.BatchSignMinSize))
+	return pools
+}
+
+func (w *workerPools) apply(cfg *workerPoolsConfig) {
+	w.keygen.Resize(cfg.KeygenWorkers, cfg.KeygenMaxQueue)
+	w.batchSign.Resize(cfg.BatchSignWorkers, cfg.BatchSignMaxQueue)
+	w.batchSignMinSize.Store(int64(cfg.BatchSignMinSize))
+}
+
+// runOnPool runs fn on the pool, and returns a 503 error if the queue of the
+// pool is full.
+func runOnPool(ctx context.Context, pool *workerpool.Pool, fn func() error) error {
+	err := pool.Run(ctx, fn)
+	if errors.Is(err, workerpool.ErrQueueFull) {
+		return logical.CodedError(http.StatusServiceUnavailable, fmt.Sprintf("too many pending %s operations, retry later", pool.Name()))
+	}
+	return err
+}
+
+// isRSAKeyType returns whether generating keys of the type is expensive
+// enough to be run on the key generation pool.
+func isRSAKeyType(keyType keysutil.KeyType) bool {
+	switch keyType {
+	case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
+		return true
+	}
+	return false
+}
+
+func (b *backend) pathConfigWorkerPools() *framework.Path {
+	return &framework.Path{
+		Pattern: "config/worker-pools",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"keygen_workers": {
+				Type: framework.TypeInt,
+				Description: `Number of RSA keys generated at once. Defaults to
+the number of CPUs.`,
+			},
+			"keygen_max_queue": {
+				Type: framework.TypeInt,
+				Description: `Number of RSA key generations waiting for a worker
+from which further requests are rejected. Defaults to 0, for no limit.`,
+			},
+			"batch_sign_workers": {
+				Type: framework.TypeInt,
+				Description: `Number of large signing batches processed at once.
+Defaults to the number of CPUs.`,
+			},
+			"batch_sign_max_queue": {
+				Type: framework.TypeInt,
+				Description: `Number of large signing batches waiting for a
+worker from which further requests are rejected. Defaults to 0, for no limit.`,
+			},
+			"batch_sign_min_size": {
+				Type: framework.TypeInt,
+				Description: `Number of items from which signing batches are
+processed by the batch signing workers. Defaults to 64.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathConfigWorkerPoolsWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "worker-pools",
+				},
+			},
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathConfigWorkerPoolsRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "worker-pools-configuration",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathConfigWorkerPoolsHelpSyn,
+		HelpDescription: pathConfigWorkerPoolsHelpDesc,
+	}
+}
+
+func readConfigWorkerPools(ctx context.Context, s logical.Storage) (*workerPoolsConfig, error) {
+	entry, err := s.Get(ctx, workerPoolsConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch worker pools configuration: %w", err)
+	}
+
+	cfg := defaultWorkerPoolsConfig
+	if entry == nil {
+		return &cfg, nil
+	}
+
+	if err := entry.DecodeJSON(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode worker pools configuration: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+func (b *backend) respondConfigWorkerPools(cfg *workerPoolsConfig) *logical.Response {
+	pools := make(map[string]interface{})
+	for _, pool := range []*workerpool.Pool{b.workerPools.keygen, b.workerPools.batchSign} {
+		stats := pool.Stats()
+		pools[pool.Name()] = map[string]interface{}{
+			"workers":   stats.Size,
+			"max_queue": stats.MaxQueue,
+			"running":   stats.Running,
+			"queued":    stats.Queued,
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"keygen_workers":       cfg.KeygenWorkers,
+			"keygen_max_queue":     cfg.KeygenMaxQueue,
+			"batch_sign_workers":   cfg.BatchSignWorkers,
+			"batch_sign_max_queue": cfg.BatchSignMaxQueue,
+			"batch_sign_min_size":  cfg.BatchSignMinSize,
+			"pools":                pools,
+		},
+	}
+}
+
+func (b *backend) pathConfigWorkerPoolsWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	cfg, err := readConfigWorkerPools(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, field := range map[string]*int{
+		"keygen_workers":       &cfg.KeygenWorkers,
+		"keygen_max_queue":     &cfg.KeygenMaxQueue,
+		"batch_sign_workers":   &cfg.BatchSignWorkers,
+		"batch_sign_max_queue": &cfg.BatchSignMaxQueue,
+		"batch_sign_min_size":  &cfg.BatchSignMinSize,
+	} {
+		if raw, ok := d.GetOk(name); ok {
+			value := raw.(int)
+			if value < 0 {
+				return logical.ErrorResponse("%s must not be negative", name), logical.ErrInvalidRequest
+			}
+			*field = value
+		}
+	}
+	if cfg.BatchSignMinSize < 1 {
+		return logical.ErrorResponse("batch_sign_min_size must be positive"), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(workerPoolsConfigPath, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal worker pools configuration: %w", err)
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	b.workerPools.apply(cfg)
+
+	return b.respondConfigWorkerPools(cfg), nil
+}
+
+func (b *backend) pathConfigWorkerPoolsRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	cfg, err := readConfigWorkerPools(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.respondConfigWorkerPools(cfg), nil
+}
+
+const pathConfigWorkerPoolsHelpSyn = `Configure the worker pools of CPU-heavy operations`
+
+const pathConfigWorkerPoolsHelpDesc = `
+This path is used to size the pools of workers which generate RSA keys and
+process large signing batches, separately from the handling of other requests.
+Requests wait for a worker of the pool, and are rejected with a 503 status code
+when the queue of the pool is full. Each node of the cluster has its own pools.
+`
@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/workerpool"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransit_ConfigWorkerPools(t *testing.T) {
+	b, s := createBackendWithSysView(t)
+
+	doReq := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("got err:\n%#v\nresp:\n%#v\n", err, resp)
+		}
+		return resp
+	}
+
+	resp := doReq(logical.ReadOperation, "config/worker-pools", nil)
+	require.Equal(t, 0, resp.Data["keygen_workers"])
+	require.Equal(t, defaultBatchSignMinSize, resp.Data["batch_sign_min_size"])
+	pools := resp.Data["pools"].(map[string]interface{})
+	require.Equal(t, workerpool.DefaultSize(), pools["keygen"].(map[string]interface{})["workers"])
+
+	resp, err := b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   s,
+		Operation: logical.UpdateOperation,
+		Path:      "config/worker-pools",
+		Data:      map[string]interface{}{"batch_sign_min_size": 0},
+	})
+	require.Error(t, err)
+	require.True(t, resp.IsError())
+
+	resp = doReq(logical.UpdateOperation, "config/worker-pools", map[string]interface{}{
+		"keygen_workers":      1,
+		"keygen_max_queue":    1,
+		"batch_sign_min_size": 2,
+	})
+	require.Equal(t, 1, resp.Data["keygen_workers"])
+	require.Equal(t, workerpool.Stats{Size: 1, MaxQueue: 1}, b.workerPools.keygen.Stats())
+
+	// RSA keys are generated and rotated on the key generation pool
+	doReq(logical.UpdateOperation, "keys/rsa", map[string]interface{}{"type": "rsa-2048"})
+	doReq(logical.UpdateOperation, "keys/rsa/rotate", nil)
+
+	// Large batches are signed on the batch signing pool
+	input := base64.StdEncoding.EncodeToString([]byte("hello"))
+	resp = doReq(logical.UpdateOperation, "sign/rsa", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"input": input},
+			map[string]interface{}{"input": input},
+		},
+	})
+	results := resp.Data["batch_results"].([]batchResponseSignItem)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		require.Empty(t, result.Error)
+		require.NotEmpty(t, result.Signature)
+	}
+
+	// Requests are rejected once the queue of the pool is full
+	release := make(chan struct{})
+	blocked := func() error {
+		<-release
+		return nil
+	}
+	done := make(chan error, 2)
+	go func() { done <- b.workerPools.keygen.Run(context.Background(), blocked) }()
+	require.Eventually(t, func() bool { return b.workerPools.keygen.Stats().Running == 1 }, 5*time.Second, time.Millisecond)
+	go func() { done <- b.workerPools.keygen.Run(context.Background(), blocked) }()
+	require.Eventually(t, func() bool { return b.workerPools.keygen.Stats().Queued == 1 }, 5*time.Second, time.Millisecond)
+
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   s,
+		Operation: logical.UpdateOperation,
+		Path:      "keys/rsa2",
+		Data:      map[string]interface{}{"type": "rsa-2048"},
+	})
+	var coded logical.HTTPCodedError
+	require.ErrorAs(t, err, &coded)
+	require.Equal(t, http.StatusServiceUnavailable, coded.Code())
+
+	// Keys which are not RSA are not generated on the pool
+	doReq(logical.UpdateOperation, "keys/ec", map[string]interface{}{"type": "ecdsa-p256"})
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, <-done)
+	}
+	doReq(logical.UpdateOperation, "keys/rsa2", map[string]interface{}{"type": "rsa-2048"})
+}
@@ -0,0 +1,322 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/errutil"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
+)
+
+// convergentMigrateChunkSize is the number of batch items migrated while
+// holding the locks of the keys, which are released between chunks so that
+// large migrations do not block key rotation and configuration.
+const convergentMigrateChunkSize = 256
+
+// ConvergentMigrateRequestItem represents a request item of the
+// convergent/migrate endpoint
+type ConvergentMigrateRequestItem struct {
+	// Ciphertext encrypted with the source key
+	Ciphertext string `json:"ciphertext" structs:"ciphertext" mapstructure:"ciphertext"`
+
+	// Context the ciphertext was derived with
+	Context string `json:"context" structs:"context" mapstructure:"context"`
+
+	// Nonce the ciphertext was encrypted with, for v1 convergent encryption
+	Nonce string `json:"nonce" structs:"nonce" mapstructure:"nonce"`
+
+	// DestinationContext to derive the new ciphertext with, defaulting to
+	// Context
+	DestinationContext string `json:"destination_context" structs:"destination_context" mapstructure:"destination_context"`
+
+	// Reference is an arbitrary caller supplied string value that will be placed on the
+	// batch response to ease correlation between inputs and outputs
+	Reference string `json:"reference" structs:"reference" mapstructure:"reference"`
+}
+
+// ConvergentMigrateResponseItem represents a response item of the
+// convergent/migrate endpoint
+type ConvergentMigrateResponseItem struct {
+	// Ciphertext encrypted with the destination key, unchanged if it was
+	// already encrypted with it
+	Ciphertext string `json:"ciphertext,omitempty" structs:"ciphertext" mapstructure:"ciphertext"`
+
+	// KeyVersion is the version of the destination key the ciphertext is
+	// encrypted with
+	KeyVersion int `json:"key_version,omitempty" structs:"key_version" mapstructure:"key_version"`
+
+	// Migrated is whether the ciphertext was re-encrypted
+	Migrated bool `json:"migrated" structs:"migrated" mapstructure:"migrated"`
+
+	// Error, if set represents a failure encountered while migrating a
+	// corresponding batch request item
+	Error string `json:"error,omitempty" structs:"error" mapstructure:"error"`
+
+	// Reference is an arbitrary caller supplied string value that will be placed on the
+	// batch response to ease correlation between inputs and outputs
+	Reference string `json:"reference" structs:"reference" mapstructure:"reference"`
+}
+
+func (b *backend) pathConvergentMigrate() *framework.Path {
+	return &framework.Path{
+		Pattern: "convergent/migrate/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationVerb:   "migrate",
+			OperationSuffix: "convergent-ciphertexts",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "The convergent key the ciphertexts are encrypted with",
+			},
+
+			"destination": {
+				Type: framework.TypeString,
+				Description: `The convergent key to re-encrypt the ciphertexts
+with. Defaults to the named key.`,
+			},
+
+			"key_version": {
+				Type: framework.TypeInt,
+				Description: `The version of the destination key to re-encrypt
+the ciphertexts with. Must be 0 (for latest) or a value greater than or
+equal to the min_encryption_version configured on the key.`,
+			},
+
+			"batch_input": {
+				Type: framework.TypeSlice,
+				Description: `
+Specifies a list of ciphertexts to migrate, each with a "ciphertext", the
+"context" it was derived with, the "nonce" it was encrypted with for v1
+convergent keys, an optional "destination_context" to derive the new
+ciphertext with and an optional "reference". Any batch output will preserve
+the order of the batch input.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathConvergentMigrateWrite,
+		},
+
+		HelpSynopsis:    pathConvergentMigrateHelpSyn,
+		HelpDescription: pathConvergentMigrateHelpDesc,
+	}
+}
+
+func (b *backend) pathConvergentMigrateWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	destination := d.Get("destination").(string)
+	if destination == "" {
+		destination = name
+	}
+
+	var batchInputItems []ConvergentMigrateRequestItem
+	if err := mapstructure.Decode(d.Raw["batch_input"], &batchInputItems); err != nil {
+		return nil, fmt.Errorf("failed to parse batch input: %w", err)
+	}
+	if len(batchInputItems) == 0 {
+		return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+	}
+
+	src, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if src == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+
+	dst := src
+	if destination != name {
+		dst, _, err = b.GetPolicy(ctx, keysutil.PolicyRequest{
+			Storage: req.Storage,
+			Name:    destination,
+		}, b.GetRandomReader())
+		if err != nil {
+			if b.System().CachingDisabled() {
+				src.Unlock()
+			}
+			return nil, err
+		}
+		if dst == nil {
+			if b.System().CachingDisabled() {
+				src.Unlock()
+			}
+			return logical.ErrorResponse("destination key not found"), logical.ErrInvalidRequest
+		}
+	}
+
+	// Without caching, policies are returned locked and are unlocked once
+	// the whole batch is migrated
+	lock, unlock := func() {}, func() {}
+	if b.System().CachingDisabled() {
+		defer src.Unlock()
+		if dst != src {
+			defer dst.Unlock()
+		}
+	} else {
+		lock = func() {
+			src.Lock(false)
+			if dst != src {
+				dst.Lock(false)
+			}
+		}
+		unlock = func() {
+			if dst != src {
+				dst.Unlock()
+			}
+			src.Unlock()
+		}
+	}
+
+	lock()
+	if !src.ConvergentEncryption {
+		unlock()
+		return logical.ErrorResponse("key %q does not use convergent encryption", name), logical.ErrInvalidRequest
+	}
+	if !dst.ConvergentEncryption {
+		unlock()
+		return logical.ErrorResponse("destination key %q does not use convergent encryption", destination), logical.ErrInvalidRequest
+	}
+	keyVersion := d.Get("key_version").(int)
+	if keyVersion == 0 {
+		keyVersion = dst.LatestVersion
+	}
+	if keyVersion < dst.MinEncryptionVersion || keyVersion > dst.LatestVersion {
+		unlock()
+		return logical.ErrorResponse("invalid key_version %d", keyVersion), logical.ErrInvalidRequest
+	}
+	unlock()
+
+	batchResponseItems := make([]ConvergentMigrateResponseItem, len(batchInputItems))
+	for start := 0; start < len(batchInputItems); start += convergentMigrateChunkSize {
+		end := start + convergentMigrateChunkSize
+		if end > len(batchInputItems) {
+			end = len(batchInputItems)
+		}
+
+		lock()
+		err := b.migrateConvergentChunk(src, dst, keyVersion, batchInputItems[start:end], batchResponseItems[start:end])
+		unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"batch_results": batchResponseItems,
+		},
+	}, nil
+}
+
+// migrateConvergentChunk re-encrypts the ciphertexts of the batch items from
+// the source key to the given version of the destination key. The locks of
+// both keys must be held.
+func (b *backend) migrateConvergentChunk(src, dst *keysutil.Policy, keyVersion int, items []ConvergentMigrateRequestItem, results []ConvergentMigrateResponseItem) error {
+	targetPrefix := datakeyVersionPrefix(dst, keyVersion)
+
+	for i, item := range items {
+		results[i].Reference = item.Reference
+
+		if item.Ciphertext == "" {
+			results[i].Error = "missing ciphertext to migrate"
+			continue
+		}
+
+		var err error
+		var context, nonce []byte
+		if len(item.Context) != 0 {
+			context, err = base64.StdEncoding.DecodeString(item.Context)
+			if err != nil {
+				results[i].Error = "failed to base64-decode context"
+				continue
+			}
+		}
+		if len(item.Nonce) != 0 {
+			if !nonceAllowed(src) {
+				results[i].Error = ErrNonceNotAllowed.Error()
+				continue
+			}
+			nonce, err = base64.StdEncoding.DecodeString(item.Nonce)
+			if err != nil {
+				results[i].Error = "failed to base64-decode nonce"
+				continue
+			}
+		}
+		destContext := context
+		if len(item.DestinationContext) != 0 {
+			destContext, err = base64.StdEncoding.DecodeString(item.DestinationContext)
+			if err != nil {
+				results[i].Error = "failed to base64-decode destination_context"
+				continue
+			}
+		}
+
+		// Decrypt even when no migration is needed, so that only valid
+		// ciphertexts are reported as up to date
+		plaintext, err := src.Decrypt(context, nonce, item.Ciphertext)
+		if err != nil {
+			if _, ok := err.(errutil.UserError); ok {
+				results[i].Error = err.Error()
+				continue
+			}
+			return err
+		}
+
+		results[i].KeyVersion = keyVersion
+		if dst == src && len(item.DestinationContext) == 0 && len(nonce) == 0 && strings.HasPrefix(item.Ciphertext, targetPrefix) {
+			results[i].Ciphertext = item.Ciphertext
+			continue
+		}
+
+		// Convergent encryption derives the nonce from the plaintext, except
+		// for v1 keys which require the caller to supply it
+		var destNonce []byte
+		if nonceAllowed(dst) {
+			destNonce = nonce
+		}
+		ciphertext, err := dst.Encrypt(keyVersion, destContext, destNonce, plaintext)
+		if err != nil {
+			if _, ok := err.(errutil.UserError); ok {
+				results[i].KeyVersion = 0
+				results[i].Error = err.Error()
+				continue
+			}
+			return err
+		}
+		results[i].Ciphertext = ciphertext
+		results[i].Migrated = true
+	}
+
+	return nil
+}
+
+const pathConvergentMigrateHelpSyn = `Migrate convergent ciphertexts to another key or key version`
+
+const pathConvergentMigrateHelpDesc = `
+This path re-encrypts a batch of ciphertexts of the named convergent key with
+the destination convergent key, defaulting to the named key, at its latest
+version or the given key_version. Ciphertexts can also be derived with a new
+destination_context. Since convergent encryption is deterministic, the
+migrated ciphertexts of equal plaintexts remain equal, so indexes built on
+them can be rebuilt from the results.
+
+Ciphertexts already encrypted with the destination key version are returned
+unchanged, with "migrated" set to false. Large batches are processed in
+chunks, releasing the locks of the keys between them.
+`
@@ -0,0 +1,129 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransit_ConvergentMigrate(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	request := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil && err != logical.ErrInvalidRequest {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	b64 := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	convergent := map[string]interface{}{
+		"derived":               true,
+		"convergent_encryption": true,
+	}
+
+	request("keys/old", convergent)
+	request("keys/new", convergent)
+	request("keys/plain", nil)
+
+	encrypt := func(key, context, plaintext string) string {
+		resp := request("encrypt/"+key, map[string]interface{}{
+			"context":   b64(context),
+			"plaintext": b64(plaintext),
+		})
+		require.False(t, resp.IsError())
+		return resp.Data["ciphertext"].(string)
+	}
+
+	// Enough ciphertexts to be migrated in several chunks
+	var inputs []interface{}
+	for i := 0; i < convergentMigrateChunkSize+2; i++ {
+		inputs = append(inputs, map[string]interface{}{
+			"ciphertext": encrypt("old", "tenant-a", "secret"),
+			"context":    b64("tenant-a"),
+		})
+	}
+	inputs = append(inputs,
+		map[string]interface{}{
+			"ciphertext":          encrypt("old", "tenant-a", "secret"),
+			"context":             b64("tenant-a"),
+			"destination_context": b64("tenant-b"),
+			"reference":           "recontext",
+		},
+		map[string]interface{}{
+			"ciphertext": encrypt("old", "tenant-a", "secret"),
+			"context":    b64("other"),
+		},
+	)
+
+	resp := request("convergent/migrate/old", map[string]interface{}{
+		"destination": "plain",
+		"batch_input": inputs,
+	})
+	require.True(t, resp.IsError())
+	require.Contains(t, resp.Error().Error(), "does not use convergent encryption")
+
+	resp = request("convergent/migrate/old", map[string]interface{}{
+		"destination": "new",
+		"batch_input": inputs,
+	})
+	require.False(t, resp.IsError())
+	results := resp.Data["batch_results"].([]ConvergentMigrateResponseItem)
+	require.Len(t, results, len(inputs))
+
+	// Migrated ciphertexts are those of the destination key
+	expected := encrypt("new", "tenant-a", "secret")
+	for _, result := range results[:convergentMigrateChunkSize+2] {
+		require.Empty(t, result.Error)
+		require.True(t, result.Migrated)
+		require.Equal(t, 1, result.KeyVersion)
+		require.Equal(t, expected, result.Ciphertext)
+	}
+
+	require.Equal(t, "recontext", results[len(results)-2].Reference)
+	require.Equal(t, encrypt("new", "tenant-b", "secret"), results[len(results)-2].Ciphertext)
+
+	// Wrong context for the ciphertext
+	require.NotEmpty(t, results[len(results)-1].Error)
+	require.Empty(t, results[len(results)-1].Ciphertext)
+
+	// Migrating to a new version of the same key
+	request("keys/new/rotate", nil)
+	current := encrypt("new", "tenant-a", "secret")
+	resp = request("convergent/migrate/new", map[string]interface{}{
+		"batch_input": []interface{}{
+			map[string]interface{}{"ciphertext": expected, "context": b64("tenant-a")},
+			map[string]interface{}{"ciphertext": current, "context": b64("tenant-a")},
+		},
+	})
+	require.False(t, resp.IsError())
+	results = resp.Data["batch_results"].([]ConvergentMigrateResponseItem)
+	require.True(t, results[0].Migrated)
+	require.True(t, strings.HasPrefix(results[0].Ciphertext, "vault:v2:"))
+	require.Equal(t, current, results[0].Ciphertext)
+	require.False(t, results[1].Migrated)
+	require.Equal(t, current, results[1].Ciphertext)
+
+	resp = request("convergent/migrate/new", map[string]interface{}{
+		"key_version": 3,
+		"batch_input": []interface{}{
+			map[string]interface{}{"ciphertext": current, "context": b64("tenant-a")},
+		},
+	})
+	require.True(t, resp.IsError())
+}
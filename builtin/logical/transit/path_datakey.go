@@ -9,14 +9,39 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/vault/helper/constants"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/mitchellh/mapstructure"
 )
 
+// DatakeyRewrapResponseItem represents a response item of the datakey/rewrap
+// endpoint
+type DatakeyRewrapResponseItem struct {
+	// Ciphertext of the data key, unchanged if it was already encrypted with
+	// the target key version
+	Ciphertext string `json:"ciphertext,omitempty" structs:"ciphertext" mapstructure:"ciphertext"`
+
+	// KeyVersion is the key version the data key is encrypted with
+	KeyVersion int `json:"key_version,omitempty" structs:"key_version" mapstructure:"key_version"`
+
+	// Rewrapped is whether the data key was re-encrypted
+	Rewrapped bool `json:"rewrapped" structs:"rewrapped" mapstructure:"rewrapped"`
+
+	// Error, if set represents a failure encountered while rewrapping a
+	// corresponding batch request item
+	Error string `json:"error,omitempty" structs:"error" mapstructure:"error"`
+
+	// Reference is an arbitrary caller supplied string value that will be placed on the
+	// batch response to ease correlation between inputs and outputs
+	Reference string `json:"reference" structs:"reference" mapstructure:"reference"`
+}
+
 func (b *backend) pathDatakey() *framework.Path {
 	return &framework.Path{
 		Pattern: "datakey/" + framework.GenericNameRegex("plaintext") + "/" + framework.GenericNameRegex("name"),
@@ -100,6 +125,14 @@ func (b *backend) pathDatakeyWrite(ctx context.Context, req *logical.Request, d
 		}
 	}
 
+	dp, err := readDatakeyPolicy(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := dp.allows(context); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
 	// Decode the nonce if any
 	nonceRaw := d.Get("nonce").(string)
 	var nonce []byte
@@ -202,6 +235,156 @@ func (b *backend) pathDatakeyWrite(ctx context.Context, req *logical.Request, d
 	return resp, nil
 }
 
+func (b *backend) pathDatakeyRewrap() *framework.Path {
+	return &framework.Path{
+		Pattern: "datakey/rewrap/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationVerb:   "rewrap",
+			OperationSuffix: "data-keys",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "The backend key the data keys are encrypted with",
+			},
+
+			"batch_input": {
+				Type: framework.TypeSlice,
+				Description: `
+Specifies a list of data keys to rewrap, each with a "ciphertext", the
+"context" it was generated with and an optional "reference". Any batch output
+will preserve the order of the batch input.`,
+			},
+
+			"key_version": {
+				Type: framework.TypeInt,
+				Description: `The version of the Vault key to rewrap the data
+keys to. Must be 0 (for latest) or a value greater than or
+equal to the min_encryption_version configured on the key.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathDatakeyRewrapWrite,
+		},
+
+		HelpSynopsis:    pathDatakeyRewrapHelpSyn,
+		HelpDescription: pathDatakeyRewrapHelpDesc,
+	}
+}
+
+func (b *backend) pathDatakeyRewrapWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	var batchInputItems []BatchRequestItem
+	if err := mapstructure.Decode(d.Raw["batch_input"], &batchInputItems); err != nil {
+		return nil, fmt.Errorf("failed to parse batch input: %w", err)
+	}
+	if len(batchInputItems) == 0 {
+		return logical.ErrorResponse("missing batch input to process"), logical.ErrInvalidRequest
+	}
+
+	dp, err := readDatakeyPolicy(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+
+	p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	keyVersion := d.Get("key_version").(int)
+	if keyVersion == 0 {
+		keyVersion = p.LatestVersion
+	}
+	if keyVersion < p.MinEncryptionVersion || keyVersion > p.LatestVersion {
+		return logical.ErrorResponse("invalid key_version %d", keyVersion), logical.ErrInvalidRequest
+	}
+	targetPrefix := datakeyVersionPrefix(p, keyVersion)
+
+	batchResponseItems := make([]DatakeyRewrapResponseItem, len(batchInputItems))
+	for i, item := range batchInputItems {
+		batchResponseItems[i].Reference = item.Reference
+
+		if item.Ciphertext == "" {
+			batchResponseItems[i].Error = "missing ciphertext to rewrap"
+			continue
+		}
+
+		var context []byte
+		if len(item.Context) != 0 {
+			context, err = base64.StdEncoding.DecodeString(item.Context)
+			if err != nil {
+				batchResponseItems[i].Error = "failed to base64-decode context"
+				continue
+			}
+		}
+		if err := dp.allows(context); err != nil {
+			batchResponseItems[i].Error = err.Error()
+			continue
+		}
+
+		// Decrypt even when no rewrap is needed, so that only valid data
+		// keys are reported as up to date
+		plaintext, err := p.Decrypt(context, nil, item.Ciphertext)
+		if err != nil {
+			if _, ok := err.(errutil.UserError); ok {
+				batchResponseItems[i].Error = err.Error()
+				continue
+			}
+			return nil, err
+		}
+
+		batchResponseItems[i].KeyVersion = keyVersion
+		if strings.HasPrefix(item.Ciphertext, targetPrefix) {
+			batchResponseItems[i].Ciphertext = item.Ciphertext
+			continue
+		}
+
+		ciphertext, err := p.Encrypt(keyVersion, context, nil, plaintext)
+		if err != nil {
+			if _, ok := err.(errutil.UserError); ok {
+				batchResponseItems[i].KeyVersion = 0
+				batchResponseItems[i].Error = err.Error()
+				continue
+			}
+			return nil, err
+		}
+		batchResponseItems[i].Ciphertext = ciphertext
+		batchResponseItems[i].Rewrapped = true
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"batch_results": batchResponseItems,
+		},
+	}, nil
+}
+
+// datakeyVersionPrefix returns the prefix of the ciphertexts encrypted with the
+// given version of the key.
+func datakeyVersionPrefix(p *keysutil.Policy, ver int) string {
+	template := p.VersionTemplate
+	if template == "" {
+		template = keysutil.DefaultVersionTemplate
+	}
+	return strings.ReplaceAll(template, "{{version}}", strconv.Itoa(ver))
+}
+
 const pathDatakeyHelpSyn = `Generate a data key`
 
 const pathDatakeyHelpDesc = `
@@ -212,4 +395,17 @@ or 512 bits can be specified; if not specified, the default
 is 256 bits. Call with the the "wrapped" path to prevent the
 (base64-encoded) plaintext key from being returned along with
 the encrypted key, the "plaintext" path returns both.
+
+If the key has a data key policy, the context must be
+allowed by it.
+`
+
+const pathDatakeyRewrapHelpSyn = `Rewrap data keys in bulk`
+
+const pathDatakeyRewrapHelpDesc = `
+After key rotation, this path can be used to rewrap a batch of data keys with
+the latest version of the named key, or the given key_version. Data keys
+already encrypted with that version are returned unchanged, with "rewrapped"
+set to false. The contexts of the data keys must be allowed by the data key
+policy of the key, if any.
 `
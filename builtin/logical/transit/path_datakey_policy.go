@@ -0,0 +1,194 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const datakeyPolicyStorageDir = "datakey-policy/"
+
+// datakeyPolicy restricts the contexts data keys of a derived key may be
+// generated and rewrapped for.
+type datakeyPolicy struct {
+	AllowedContexts []string `json:"allowed_contexts"`
+}
+
+// allows returns an error if the given decoded context is not allowed by the
+// policy.
+func (dp *datakeyPolicy) allows(context []byte) error {
+	if dp == nil {
+		return nil
+	}
+	if len(context) == 0 {
+		return fmt.Errorf("context is required by the data key policy")
+	}
+	if !strutil.StrListContainsGlob(dp.AllowedContexts, string(context)) {
+		return fmt.Errorf("context %q is not allowed by the data key policy", context)
+	}
+	return nil
+}
+
+func (b *backend) pathListDatakeyPolicies() *framework.Path {
+	return &framework.Path{
+		Pattern: "datakey-policies/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationSuffix: "data-key-policies",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathDatakeyPoliciesList,
+		},
+
+		HelpSynopsis:    pathDatakeyPolicyHelpSyn,
+		HelpDescription: pathDatakeyPolicyHelpDesc,
+	}
+}
+
+func (b *backend) pathDatakeyPolicies() *framework.Path {
+	return &framework.Path{
+		Pattern: "datakey-policies/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationSuffix: "data-key-policy",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the derived key the policy applies to",
+			},
+			"allowed_contexts": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Contexts, after base64 decoding, data keys may be
+generated and rewrapped for. Globs are supported.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathDatakeyPolicyWrite,
+			logical.ReadOperation:   b.pathDatakeyPolicyRead,
+			logical.DeleteOperation: b.pathDatakeyPolicyDelete,
+		},
+
+		HelpSynopsis:    pathDatakeyPolicyHelpSyn,
+		HelpDescription: pathDatakeyPolicyHelpDesc,
+	}
+}
+
+// readDatakeyPolicy returns the data key policy of the named key, or nil if it
+// has none.
+func readDatakeyPolicy(ctx context.Context, s logical.Storage, name string) (*datakeyPolicy, error) {
+	entry, err := s.Get(ctx, datakeyPolicyStorageDir+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch data key policy: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var dp datakeyPolicy
+	if err := entry.DecodeJSON(&dp); err != nil {
+		return nil, fmt.Errorf("failed to decode data key policy: %w", err)
+	}
+	return &dp, nil
+}
+
+func (b *backend) pathDatakeyPoliciesList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, datakeyPolicyStorageDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathDatakeyPolicyRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	dp, err := readDatakeyPolicy(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if dp == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"allowed_contexts": dp.AllowedContexts,
+		},
+	}, nil
+}
+
+func (b *backend) pathDatakeyPolicyWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	allowedContexts := d.Get("allowed_contexts").([]string)
+	if len(allowedContexts) == 0 {
+		return logical.ErrorResponse("missing allowed_contexts"), logical.ErrInvalidRequest
+	}
+	for _, allowed := range allowedContexts {
+		if _, err := path.Match(allowed, ""); err != nil {
+			return logical.ErrorResponse("invalid allowed_contexts glob %q", allowed), logical.ErrInvalidRequest
+		}
+	}
+
+	p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("encryption key not found"), logical.ErrInvalidRequest
+	}
+	derived := p.Derived
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	p.Unlock()
+
+	// The context of non-derived keys is ignored, so restricting it would
+	// give a false sense of security
+	if !derived {
+		return logical.ErrorResponse("data key policies can only be set on derived keys"), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(datakeyPolicyStorageDir+name, &datakeyPolicy{
+		AllowedContexts: allowedContexts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathDatakeyPolicyDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, datakeyPolicyStorageDir+d.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+const pathDatakeyPolicyHelpSyn = `Manage the context policies of data keys`
+
+const pathDatakeyPolicyHelpDesc = `
+A data key policy restricts the contexts for which data keys of a derived key
+can be generated with the datakey endpoints and rewrapped with the
+datakey/rewrap endpoint. Contexts are matched, after base64 decoding, against
+the allowed_contexts globs.
+`
@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransit_DatakeyPolicyAndRewrap(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	request := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil && err != logical.ErrInvalidRequest {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	b64 := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+
+	request("keys/envelope", map[string]interface{}{"derived": true})
+	request("keys/plain", nil)
+
+	resp := request("datakey-policies/plain", map[string]interface{}{"allowed_contexts": "tenant-*"})
+	require.True(t, resp.IsError())
+	resp = request("datakey-policies/envelope", map[string]interface{}{"allowed_contexts": "tenant-*"})
+	require.Nil(t, resp)
+
+	resp = request("datakey/wrapped/envelope", map[string]interface{}{"context": b64("other")})
+	require.True(t, resp.IsError())
+	require.Contains(t, resp.Error().Error(), "not allowed by the data key policy")
+
+	var inputs []interface{}
+	for _, tenant := range []string{"tenant-a", "tenant-b"} {
+		resp = request("datakey/wrapped/envelope", map[string]interface{}{"context": b64(tenant)})
+		require.False(t, resp.IsError())
+		inputs = append(inputs, map[string]interface{}{
+			"ciphertext": resp.Data["ciphertext"],
+			"context":    b64(tenant),
+			"reference":  tenant,
+		})
+	}
+
+	request("keys/envelope/rotate", nil)
+	resp = request("datakey/wrapped/envelope", map[string]interface{}{"context": b64("tenant-c")})
+	upToDate := resp.Data["ciphertext"].(string)
+	inputs = append(inputs,
+		map[string]interface{}{"ciphertext": upToDate, "context": b64("tenant-c")},
+		map[string]interface{}{"ciphertext": upToDate, "context": b64("tenant-d")},
+		map[string]interface{}{"ciphertext": upToDate, "context": b64("other")},
+	)
+
+	resp = request("datakey/rewrap/envelope", map[string]interface{}{"batch_input": inputs})
+	require.False(t, resp.IsError())
+	results := resp.Data["batch_results"].([]DatakeyRewrapResponseItem)
+	require.Len(t, results, 5)
+
+	for _, result := range results[:2] {
+		require.Empty(t, result.Error)
+		require.True(t, result.Rewrapped)
+		require.Equal(t, 2, result.KeyVersion)
+		require.True(t, strings.HasPrefix(result.Ciphertext, "vault:v2:"))
+	}
+	require.Equal(t, "tenant-b", results[1].Reference)
+
+	require.False(t, results[2].Rewrapped)
+	require.Equal(t, upToDate, results[2].Ciphertext)
+
+	// Wrong context for the ciphertext
+	require.NotEmpty(t, results[3].Error)
+	require.Empty(t, results[3].Ciphertext)
+
+	require.Contains(t, results[4].Error, "not allowed by the data key policy")
+
+	// Rewrapped data keys decrypt to the original ones
+	resp = request("decrypt/envelope", map[string]interface{}{
+		"ciphertext": inputs[0].(map[string]interface{})["ciphertext"],
+		"context":    b64("tenant-a"),
+	})
+	original := resp.Data["plaintext"]
+	resp = request("decrypt/envelope", map[string]interface{}{
+		"ciphertext": results[0].Ciphertext,
+		"context":    b64("tenant-a"),
+	})
+	require.Equal(t, original, resp.Data["plaintext"])
+}
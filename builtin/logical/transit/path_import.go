@@ -45,7 +45,7 @@ func (b *backend) pathImport() *framework.Path {
 				Default: "aes256-gcm96",
 				Description: `The type of key being imported. Currently, "aes128-gcm96" (symmetric), "aes256-gcm96" (symmetric), "ecdsa-p256"
 (asymmetric), "ecdsa-p384" (asymmetric), "ecdsa-p521" (asymmetric), "ed25519" (asymmetric), "rsa-2048" (asymmetric), "rsa-3072"
-(asymmetric), "rsa-4096" (asymmetric) are supported.  Defaults to "aes256-gcm96".
+(asymmetric), "rsa-4096" (asymmetric) and "hmac" (HMAC) are supported.  Defaults to "aes256-gcm96".
 `,
 			},
 			"hash_function": {
@@ -217,6 +217,9 @@ func (b *backend) pathImportWrite(ctx context.Context, req *logical.Request, d *
 	default:
 		return logical.ErrorResponse(fmt.Sprintf("unknown key type: %v", keyType)), logical.ErrInvalidRequest
 	}
+	if resp, err := b.checkCryptoPolicy(ctx, polReq.KeyType); resp != nil || err != nil {
+		return resp, err
+	}
 
 	p, _, err := b.GetPolicy(ctx, polReq, b.GetRandomReader())
 	if err != nil {
@@ -0,0 +1,377 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const jwksSetStorageDir = "jwks-set/"
+
+// jwksSet is a named set of keys whose public keys are published without
+// authentication at jwks/:name.
+type jwksSet struct {
+	Keys []string `json:"keys"`
+}
+
+func (b *backend) pathKeysJWKS() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/jwks",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationVerb:   "read",
+			OperationSuffix: "key-jwks",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathKeysJWKSRead,
+		},
+
+		HelpSynopsis:    pathJWKSHelpSyn,
+		HelpDescription: pathJWKSHelpDesc,
+	}
+}
+
+func (b *backend) pathListJWKSSets() *framework.Path {
+	return &framework.Path{
+		Pattern: "jwks-sets/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationSuffix: "jwks-sets",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathJWKSSetsList,
+		},
+
+		HelpSynopsis:    pathJWKSSetHelpSyn,
+		HelpDescription: pathJWKSSetHelpDesc,
+	}
+}
+
+func (b *backend) pathJWKSSets() *framework.Path {
+	return &framework.Path{
+		Pattern: "jwks-sets/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationSuffix: "jwks-set",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the JWKS set",
+			},
+			"keys": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Names of the signing keys whose public keys
+are published by the set.`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathJWKSSetWrite,
+			logical.ReadOperation:   b.pathJWKSSetRead,
+			logical.DeleteOperation: b.pathJWKSSetDelete,
+		},
+
+		HelpSynopsis:    pathJWKSSetHelpSyn,
+		HelpDescription: pathJWKSSetHelpDesc,
+	}
+}
+
+func (b *backend) pathJWKS() *framework.Path {
+	return &framework.Path{
+		Pattern: "jwks/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationVerb:   "read",
+			OperationSuffix: "jwks",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the JWKS set",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathJWKSRead,
+		},
+
+		HelpSynopsis:    pathJWKSHelpSyn,
+		HelpDescription: pathJWKSHelpDesc,
+	}
+}
+
+func (b *backend) pathKeysJWKSRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	keys, err := b.publicJWKs(ctx, req.Storage, name)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	if keys == nil {
+		return nil, nil
+	}
+
+	return jwksResponse(keys)
+}
+
+func (b *backend) pathJWKSRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	set, err := readJWKSSet(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, nil
+	}
+
+	keys := []jose.JSONWebKey{}
+	for _, name := range set.Keys {
+		// Keys deleted or changed since the set was written are skipped
+		// rather than failing the whole set
+		keyJWKs, err := b.publicJWKs(ctx, req.Storage, name)
+		if err != nil {
+			b.Logger().Warn("skipping key of JWKS set", "key", name, "error", err)
+			continue
+		}
+		keys = append(keys, keyJWKs...)
+	}
+
+	return jwksResponse(keys)
+}
+
+func jwksResponse(keys []jose.JSONWebKey) (*logical.Response, error) {
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPStatusCode:  200,
+			logical.HTTPRawBody:     data,
+			logical.HTTPContentType: "application/json",
+		},
+	}, nil
+}
+
+// publicJWKs returns the public keys of the versions of the named key that can
+// still verify signatures, from min_decryption_version to the latest one.
+// Key IDs are "<key name>:<version>". It returns nil if the key does not
+// exist.
+func (b *backend) publicJWKs(ctx context.Context, s logical.Storage, name string) ([]jose.JSONWebKey, error) {
+	p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: s,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, nil
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	alg, err := jwkAlgorithm(p)
+	if err != nil {
+		return nil, err
+	}
+
+	minVersion := p.MinDecryptionVersion
+	if minVersion < 1 {
+		minVersion = 1
+	}
+
+	keys := []jose.JSONWebKey{}
+	for ver := p.LatestVersion; ver >= minVersion; ver-- {
+		entry, ok := p.Keys[strconv.Itoa(ver)]
+		if !ok {
+			continue
+		}
+		pub, err := jwkPublicKey(p.Type, &entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read version %d of key %q: %w", ver, name, err)
+		}
+		keys = append(keys, jose.JSONWebKey{
+			Key:       pub,
+			KeyID:     name + ":" + strconv.Itoa(ver),
+			Algorithm: alg,
+			Use:       "sig",
+		})
+	}
+	return keys, nil
+}
+
+// jwkAlgorithm returns the JWS algorithm of the signatures made with the key,
+// or "" for RSA keys which may sign with either PKCS#1v15 or PSS.
+func jwkAlgorithm(p *keysutil.Policy) (string, error) {
+	switch p.Type {
+	case keysutil.KeyType_ECDSA_P256:
+		return string(jose.ES256), nil
+	case keysutil.KeyType_ECDSA_P384:
+		return string(jose.ES384), nil
+	case keysutil.KeyType_ECDSA_P521:
+		return string(jose.ES512), nil
+	case keysutil.KeyType_ED25519:
+		if p.Derived {
+			return "", fmt.Errorf("key %q is derived and has no single public key", p.Name)
+		}
+		return string(jose.EdDSA), nil
+	case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
+		return "", nil
+	default:
+		return "", fmt.Errorf("key %q of type %s is not a signing key", p.Name, p.Type)
+	}
+}
+
+func jwkPublicKey(keyType keysutil.KeyType, entry *keysutil.KeyEntry) (crypto.PublicKey, error) {
+	switch keyType {
+	case keysutil.KeyType_ED25519:
+		pub, err := base64.StdEncoding.DecodeString(entry.FormattedPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519.PublicKey(pub), nil
+	case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
+		if entry.RSAKey != nil {
+			return entry.RSAKey.Public(), nil
+		}
+		if entry.RSAPublicKey == nil {
+			return nil, errors.New("no RSA key present")
+		}
+		return entry.RSAPublicKey, nil
+	default:
+		block, _ := pem.Decode([]byte(entry.FormattedPublicKey))
+		if block == nil {
+			return nil, errors.New("no PEM encoded public key present")
+		}
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+}
+
+func readJWKSSet(ctx context.Context, s logical.Storage, name string) (*jwksSet, error) {
+	entry, err := s.Get(ctx, jwksSetStorageDir+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var set jwksSet
+	if err := entry.DecodeJSON(&set); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+func (b *backend) pathJWKSSetsList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, jwksSetStorageDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func (b *backend) pathJWKSSetRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	set, err := readJWKSSet(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if set == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"keys": set.Keys,
+		},
+	}, nil
+}
+
+func (b *backend) pathJWKSSetWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	keys := d.Get("keys").([]string)
+	if len(keys) == 0 {
+		return logical.ErrorResponse("missing keys"), logical.ErrInvalidRequest
+	}
+
+	for _, key := range keys {
+		keyJWKs, err := b.publicJWKs(ctx, req.Storage, key)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		if keyJWKs == nil {
+			return logical.ErrorResponse("key %q not found", key), logical.ErrInvalidRequest
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(jwksSetStorageDir+name, &jwksSet{Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathJWKSSetDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, jwksSetStorageDir+d.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+const pathJWKSHelpSyn = `Read the public keys of signing keys as a JSON Web Key Set`
+
+const pathJWKSHelpDesc = `
+keys/<name>/jwks returns the public keys of a signing key as a JSON Web Key
+Set, and jwks/<name> returns those of the keys of a JWKS set without
+authentication, so that relying parties can validate JWTs signed with transit.
+
+Every version of the keys from min_decryption_version up is published, with a
+key ID of "<key name>:<version>".
+`
+
+const pathJWKSSetHelpSyn = `Manage the JWKS sets published without authentication`
+
+const pathJWKSSetHelpDesc = `
+A JWKS set publishes the public keys of its keys without authentication at
+jwks/<name>. Only ECDSA, Ed25519 and RSA keys can be published; derived keys
+cannot.
+`
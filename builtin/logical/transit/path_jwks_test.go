@@ -0,0 +1,99 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransit_JWKS(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: op,
+			Path:      path,
+			Data:      data,
+		})
+		if err != nil && err != logical.ErrInvalidRequest {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	readJWKS := func(path string) jose.JSONWebKeySet {
+		t.Helper()
+		resp := request(logical.ReadOperation, path, nil)
+		require.NotNil(t, resp)
+		require.Equal(t, "application/json", resp.Data[logical.HTTPContentType])
+		var jwks jose.JSONWebKeySet
+		require.NoError(t, json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &jwks))
+		return jwks
+	}
+
+	request(logical.UpdateOperation, "keys/jwt", map[string]interface{}{"type": "ecdsa-p256"})
+	request(logical.UpdateOperation, "keys/jwt/rotate", nil)
+	request(logical.UpdateOperation, "keys/rsa", map[string]interface{}{"type": "rsa-2048"})
+	request(logical.UpdateOperation, "keys/aes", nil)
+
+	jwks := readJWKS("keys/jwt/jwks")
+	require.Len(t, jwks.Keys, 2)
+	require.Len(t, jwks.Key("jwt:1"), 1)
+	require.Equal(t, "ES256", jwks.Key("jwt:2")[0].Algorithm)
+	require.True(t, jwks.Key("jwt:2")[0].IsPublic())
+
+	// A JWS signed by transit verifies against the published key
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","kid":"jwt:2"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"test"}`))
+	resp := request(logical.UpdateOperation, "sign/jwt", map[string]interface{}{
+		"input":                base64.StdEncoding.EncodeToString([]byte(signingInput)),
+		"marshaling_algorithm": "jws",
+	})
+	require.False(t, resp.IsError())
+	signature := strings.TrimPrefix(resp.Data["signature"].(string), "vault:v2:")
+	jws, err := jose.ParseSigned(signingInput + "." + signature)
+	require.NoError(t, err)
+	payload, err := jws.Verify(jwks.Key("jwt:2")[0])
+	require.NoError(t, err)
+	require.Equal(t, `{"sub":"test"}`, string(payload))
+
+	// Versions that can no longer verify are not published
+	request(logical.UpdateOperation, "keys/jwt/config", map[string]interface{}{"min_decryption_version": 2})
+	jwks = readJWKS("keys/jwt/jwks")
+	require.Len(t, jwks.Keys, 1)
+	require.Len(t, jwks.Key("jwt:2"), 1)
+
+	resp = request(logical.ReadOperation, "keys/aes/jwks", nil)
+	require.True(t, resp.IsError())
+
+	// JWKS sets
+	resp = request(logical.UpdateOperation, "jwks-sets/bad", map[string]interface{}{"keys": "jwt,aes"})
+	require.True(t, resp.IsError())
+	resp = request(logical.UpdateOperation, "jwks-sets/bad", map[string]interface{}{"keys": "missing"})
+	require.True(t, resp.IsError())
+
+	resp = request(logical.UpdateOperation, "jwks-sets/public", map[string]interface{}{"keys": "jwt,rsa"})
+	require.Nil(t, resp)
+	resp = request(logical.ListOperation, "jwks-sets/", nil)
+	require.Equal(t, []string{"public"}, resp.Data["keys"])
+
+	jwks = readJWKS("jwks/public")
+	require.Len(t, jwks.Keys, 2)
+	require.Len(t, jwks.Key("rsa:1"), 1)
+	require.Empty(t, jwks.Key("rsa:1")[0].Algorithm)
+
+	require.Contains(t, b.PathsSpecial.Unauthenticated, "jwks/*")
+
+	request(logical.DeleteOperation, "jwks-sets/public", nil)
+	require.Nil(t, request(logical.ReadOperation, "jwks/public", nil))
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/rotation"
 )
 
 func (b *backend) pathListKeys() *framework.Path {
@@ -226,6 +227,9 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 	default:
 		return logical.ErrorResponse(fmt.Sprintf("unknown key type %v", keyType)), logical.ErrInvalidRequest
 	}
+	if resp, err := b.checkCryptoPolicy(ctx, polReq.KeyType); resp != nil || err != nil {
+		return resp, err
+	}
 	if keySize != 0 {
 		if polReq.KeyType != keysutil.KeyType_HMAC {
 			return logical.ErrorResponse(fmt.Sprintf("key_size is not valid for algorithm %v", polReq.KeyType)), logical.ErrInvalidRequest
@@ -245,7 +249,19 @@ func (b *backend) pathPolicyWrite(ctx context.Context, req *logical.Request, d *
 		polReq.ManagedKeyUUID = keyId
 	}
 
-	p, upserted, err := b.GetPolicy(ctx, polReq, b.GetRandomReader())
+	var p *keysutil.Policy
+	var upserted bool
+	getPolicy := func() error {
+		var err error
+		p, upserted, err = b.GetPolicy(ctx, polReq, b.GetRandomReader())
+		return err
+	}
+	var err error
+	if isRSAKeyType(polReq.KeyType) {
+		err = runOnPool(ctx, b.workerPools.keygen, getPolicy)
+	} else {
+		err = getPolicy()
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -326,6 +342,12 @@ func (b *backend) formatKeyPolicy(p *keysutil.Policy, context []byte) (*logical.
 			"imported_key":           p.Imported,
 		},
 	}
+	if p.RotationSchedule != "" {
+		resp.Data["rotation_schedule"] = p.RotationSchedule
+		resp.Data["rotation_window"] = int64(p.RotationWindow.Seconds())
+		resp.Data["rotation_jitter"] = int64(p.RotationJitter.Seconds())
+		resp.Data["rotation_id"] = p.RotationID
+	}
 	if p.KeySize != 0 {
 		resp.Data["key_size"] = p.KeySize
 	}
@@ -445,12 +467,27 @@ func (b *backend) formatKeyPolicy(p *keysutil.Policy, context []byte) (*logical.
 func (b *backend) pathPolicyDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	name := d.Get("name").(string)
 
+	p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+
 	// Delete does its own locking
-	err := b.lm.DeletePolicy(ctx, req.Storage, name)
+	err = b.lm.DeletePolicy(ctx, req.Storage, name)
 	if err != nil {
 		return logical.ErrorResponse(fmt.Sprintf("error deleting policy %s: %s", name, err)), err
 	}
 
+	if p != nil && p.RotationID != "" {
+		err = b.System().DeregisterRotationJob(ctx, &rotation.RotationJobDeregisterRequest{ReqPath: "keys/" + name + "/rotate"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to deregister rotation schedule of deleted key %s: %w", name, err)
+		}
+	}
+
 	return nil, nil
 }
 
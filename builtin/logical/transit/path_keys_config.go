@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/rotation"
 )
 
 func (b *backend) pathKeysConfig() *framework.Path {
@@ -65,6 +66,28 @@ the latest version of the key is allowed.`,
 being automatically rotated. A value of 0
 disables automatic rotation for the key.`,
 			},
+
+			"rotation_schedule": {
+				Type: framework.TypeString,
+				Description: `Cron-style schedule, e.g. "0 0 * * SAT", on
+which the key is rotated by Vault's rotation
+manager. Mutually exclusive with
+auto_rotate_period. An empty value disables
+scheduled rotation for the key.`,
+			},
+
+			"rotation_window": {
+				Type: framework.TypeDurationSecond,
+				Description: `Amount of time after its scheduled time a
+rotation may still be attempted. If 0, overdue
+rotations are always attempted.`,
+			},
+
+			"rotation_jitter": {
+				Type: framework.TypeDurationSecond,
+				Description: `Maximum random delay added to each scheduled
+rotation of the key.`,
+			},
 		},
 
 		Callbacks: map[logical.Operation]framework.OperationFunc{
@@ -104,6 +127,10 @@ func (b *backend) pathKeysConfigWrite(ctx context.Context, req *logical.Request,
 	originalDeletionAllowed := p.DeletionAllowed
 	originalExportable := p.Exportable
 	originalAllowPlaintextBackup := p.AllowPlaintextBackup
+	originalAutoRotatePeriod := p.AutoRotatePeriod
+	originalRotationSchedule := p.RotationSchedule
+	originalRotationWindow := p.RotationWindow
+	originalRotationJitter := p.RotationJitter
 
 	defer func() {
 		if retErr != nil || (resp != nil && resp.IsError()) {
@@ -112,6 +139,10 @@ func (b *backend) pathKeysConfigWrite(ctx context.Context, req *logical.Request,
 			p.DeletionAllowed = originalDeletionAllowed
 			p.Exportable = originalExportable
 			p.AllowPlaintextBackup = originalAllowPlaintextBackup
+			p.AutoRotatePeriod = originalAutoRotatePeriod
+			p.RotationSchedule = originalRotationSchedule
+			p.RotationWindow = originalRotationWindow
+			p.RotationJitter = originalRotationJitter
 		}
 	}()
 
@@ -224,6 +255,49 @@ func (b *backend) pathKeysConfigWrite(ctx context.Context, req *logical.Request,
 		}
 	}
 
+	rotationScheduleRaw, ok := d.GetOk("rotation_schedule")
+	if ok {
+		if rotationSchedule := rotationScheduleRaw.(string); rotationSchedule != p.RotationSchedule {
+			p.RotationSchedule = rotationSchedule
+			persistNeeded = true
+		}
+	}
+
+	rotationWindowRaw, ok, err := d.GetOkErr("rotation_window")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if rotationWindow := time.Second * time.Duration(rotationWindowRaw.(int)); rotationWindow != p.RotationWindow {
+			p.RotationWindow = rotationWindow
+			persistNeeded = true
+		}
+	}
+
+	rotationJitterRaw, ok, err := d.GetOkErr("rotation_jitter")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if rotationJitter := time.Second * time.Duration(rotationJitterRaw.(int)); rotationJitter != p.RotationJitter {
+			p.RotationJitter = rotationJitter
+			persistNeeded = true
+		}
+	}
+
+	switch {
+	case p.RotationWindow < 0 || p.RotationJitter < 0:
+		return logical.ErrorResponse("rotation window and rotation jitter cannot be negative"), nil
+	case p.RotationSchedule == "" && (p.RotationWindow != 0 || p.RotationJitter != 0):
+		return logical.ErrorResponse("rotation window and rotation jitter require a rotation schedule"), nil
+	case p.RotationSchedule != "" && p.AutoRotatePeriod != 0:
+		return logical.ErrorResponse("rotation schedule and auto rotate period are mutually exclusive"), nil
+	case p.RotationSchedule != "" && p.Type == keysutil.KeyType_MANAGED_KEY:
+		return logical.ErrorResponse("Scheduled rotation can not be set for managed keys"), nil
+	case p.RotationSchedule != "" && p.Imported && !p.AllowImportedKeyRotation:
+		return logical.ErrorResponse("Scheduled rotation can not be set for imported keys which do not allow rotation"), nil
+	}
+
 	if !persistNeeded {
 		resp, err := b.formatKeyPolicy(p, nil)
 		if err != nil {
@@ -242,6 +316,12 @@ func (b *backend) pathKeysConfigWrite(ctx context.Context, req *logical.Request,
 		return logical.ErrorResponse("min decryption version should not be less then min available version"), nil
 	}
 
+	if p.RotationSchedule != originalRotationSchedule || p.RotationWindow != originalRotationWindow || p.RotationJitter != originalRotationJitter {
+		if err := b.registerRotationSchedule(ctx, p); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("failed to configure scheduled rotation: %s", err)), nil
+		}
+	}
+
 	if err := p.Persist(ctx, req.Storage); err != nil {
 		return nil, err
 	}
@@ -256,6 +336,36 @@ func (b *backend) pathKeysConfigWrite(ctx context.Context, req *logical.Request,
 	return resp, nil
 }
 
+// registerRotationSchedule registers the key's rotation schedule with Vault's
+// rotation manager, or deregisters the key if it no longer has one.
+func (b *backend) registerRotationSchedule(ctx context.Context, p *keysutil.Policy) error {
+	reqPath := "keys/" + p.Name + "/rotate"
+
+	if p.RotationSchedule == "" {
+		if p.RotationID == "" {
+			return nil
+		}
+		if err := b.System().DeregisterRotationJob(ctx, &rotation.RotationJobDeregisterRequest{ReqPath: reqPath}); err != nil {
+			return err
+		}
+		p.RotationID = ""
+		return nil
+	}
+
+	rotationID, err := b.System().RegisterRotationJob(ctx, &rotation.RotationJobConfigureRequest{
+		Name:             p.Name,
+		ReqPath:          reqPath,
+		RotationSchedule: p.RotationSchedule,
+		RotationWindow:   p.RotationWindow,
+		RotationJitter:   p.RotationJitter,
+	})
+	if err != nil {
+		return err
+	}
+	p.RotationID = rotationID
+	return nil
+}
+
 const pathKeysConfigHelpSyn = `Configure a named encryption key`
 
 const pathKeysConfigHelpDesc = `
@@ -16,8 +16,11 @@ import (
 	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/api"
 	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/rotation"
 	"github.com/hashicorp/vault/vault"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTransit_ConfigSettings(t *testing.T) {
@@ -406,3 +409,159 @@ func TestTransit_UpdateKeyConfigWithAutorotation(t *testing.T) {
 		})
 	}
 }
+
+// rotationSystemView records the rotation jobs registered by the backend.
+type rotationSystemView struct {
+	logical.StaticSystemView
+	jobs map[string]*rotation.RotationJobConfigureRequest
+}
+
+func (s *rotationSystemView) RegisterRotationJob(_ context.Context, req *rotation.RotationJobConfigureRequest) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+	s.jobs[req.ReqPath] = req
+	return "rotation-" + req.Name, nil
+}
+
+func (s *rotationSystemView) DeregisterRotationJob(_ context.Context, req *rotation.RotationJobDeregisterRequest) error {
+	delete(s.jobs, req.ReqPath)
+	return nil
+}
+
+func TestTransit_UpdateKeyConfigWithRotationSchedule(t *testing.T) {
+	sysView := &rotationSystemView{
+		StaticSystemView: *logical.TestSystemView(),
+		jobs:             make(map[string]*rotation.RotationJobConfigureRequest),
+	}
+	storage := &logical.InmemStorage{}
+	conf := &logical.BackendConfig{
+		StorageView: storage,
+		System:      sysView,
+	}
+	b, err := Backend(context.Background(), conf)
+	require.NoError(t, err)
+	require.NoError(t, b.Backend.Setup(context.Background(), conf))
+
+	write := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   storage,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := write("keys/scheduled", nil)
+	require.False(t, resp.IsError(), resp)
+
+	for name, data := range map[string]map[string]interface{}{
+		"window without schedule": {"rotation_window": "1h"},
+		"with auto rotate period": {"rotation_schedule": "0 0 * * SAT", "auto_rotate_period": "24h"},
+		"negative jitter":         {"rotation_schedule": "0 0 * * SAT", "rotation_jitter": "-1h"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := write("keys/scheduled/config", data)
+			require.True(t, resp.IsError(), "expected error response")
+			require.Empty(t, sysView.jobs)
+		})
+	}
+
+	resp = write("keys/scheduled/config", map[string]interface{}{
+		"rotation_schedule": "0 0 * * SAT",
+		"rotation_window":   "1h",
+		"rotation_jitter":   "10m",
+	})
+	require.False(t, resp.IsError(), resp)
+	require.Equal(t, "0 0 * * SAT", resp.Data["rotation_schedule"])
+	require.Equal(t, int64(3600), resp.Data["rotation_window"])
+	require.Equal(t, int64(600), resp.Data["rotation_jitter"])
+	require.Equal(t, "rotation-scheduled", resp.Data["rotation_id"])
+	require.Equal(t, &rotation.RotationJobConfigureRequest{
+		Name:             "scheduled",
+		ReqPath:          "keys/scheduled/rotate",
+		RotationSchedule: "0 0 * * SAT",
+		RotationWindow:   time.Hour,
+		RotationJitter:   10 * time.Minute,
+	}, sysView.jobs["keys/scheduled/rotate"])
+
+	// The rotation manager's request rotates the key
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   storage,
+		Operation: logical.RotationOperation,
+		Path:      "keys/scheduled/rotate",
+	})
+	require.NoError(t, err)
+	p, _, err := b.GetPolicy(context.Background(), keysutil.PolicyRequest{
+		Storage: storage,
+		Name:    "scheduled",
+	}, b.GetRandomReader())
+	require.NoError(t, err)
+	require.Equal(t, 2, p.LatestVersion)
+
+	// Clearing the schedule deregisters the key
+	resp = write("keys/scheduled/config", map[string]interface{}{
+		"rotation_schedule": "",
+		"rotation_window":   0,
+		"rotation_jitter":   0,
+	})
+	require.False(t, resp.IsError(), resp)
+	require.Empty(t, sysView.jobs)
+	require.NotContains(t, resp.Data, "rotation_schedule")
+
+	// Deleting the key deregisters it
+	resp = write("keys/scheduled/config", map[string]interface{}{
+		"rotation_schedule": "0 0 * * SAT",
+		"deletion_allowed":  true,
+	})
+	require.False(t, resp.IsError(), resp)
+	require.Len(t, sysView.jobs, 1)
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   storage,
+		Operation: logical.DeleteOperation,
+		Path:      "keys/scheduled",
+	})
+	require.NoError(t, err)
+	require.Empty(t, sysView.jobs)
+}
+
+func TestTransit_RotationScheduleRegistersWithRotationManager(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"transit": Factory,
+		},
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+	vault.TestWaitActive(t, cluster.Cores[0].Core)
+	client := cluster.Cores[0].Client
+
+	err := client.Sys().Mount("transit", &api.MountInput{
+		Type: "transit",
+	})
+	require.NoError(t, err)
+
+	_, err = client.Logical().Write("transit/keys/scheduled", nil)
+	require.NoError(t, err)
+	resp, err := client.Logical().Write("transit/keys/scheduled/config", map[string]interface{}{
+		"rotation_schedule": "0 0 * * SAT",
+		"rotation_jitter":   "1h",
+	})
+	require.NoError(t, err)
+	rotationID, ok := resp.Data["rotation_id"].(string)
+	require.True(t, ok)
+	require.NotEmpty(t, rotationID)
+
+	resp, err = client.Logical().Read("sys/rotation/jobs/" + rotationID)
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	require.Equal(t, "transit/keys/scheduled/rotate", resp.Data["path"])
+	require.Equal(t, "0 0 * * SAT", resp.Data["rotation_schedule"])
+	require.Equal(t, json.Number("3600"), resp.Data["rotation_jitter"])
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/hashicorp/vault/builtin/audit/file"
 	"github.com/hashicorp/vault/builtin/logical/transit"
 	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/sdk/helper/cryptopolicy"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault"
 )
@@ -196,3 +198,50 @@ func TestTransit_CreateKeyWithAutorotation(t *testing.T) {
 		})
 	}
 }
+
+func TestTransit_CreateKeyWithCryptoPolicy(t *testing.T) {
+	coreConfig := &vault.CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"transit": transit.Factory,
+		},
+		CryptoPolicy: cryptopolicy.FIPS1403,
+	}
+	cluster := vault.NewTestCluster(t, coreConfig, &vault.TestClusterOptions{
+		HandlerFunc: vaulthttp.Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+	cores := cluster.Cores
+	vault.TestWaitActive(t, cores[0].Core)
+	client := cores[0].Client
+	err := client.Sys().Mount("transit", &api.MountInput{
+		Type: "transit",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.Logical().Write("transit/keys/aes", map[string]interface{}{
+		"type": "aes256-gcm96",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Logical().Write("transit/keys/chacha", map[string]interface{}{
+		"type": "chacha20-poly1305",
+	})
+	if err == nil {
+		t.Fatal("expected the crypto policy to reject the key type")
+	}
+	if !strings.Contains(err.Error(), `crypto policy "fips-140-3" does not allow transit key type "chacha20-poly1305"`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := client.Logical().Read("transit/keys/chacha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != nil {
+		t.Fatal("expected the key not to be created")
+	}
+}
@@ -0,0 +1,396 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	kmipConfigPath     = "kmip/config"
+	kmipRoleStorageDir = "kmip/role/"
+)
+
+type kmipConfig struct {
+	ListenAddress  string `json:"listen_address"`
+	TLSCertificate string `json:"tls_certificate"`
+	TLSPrivateKey  string `json:"tls_private_key"`
+	TLSClientCA    string `json:"tls_client_ca"`
+}
+
+type kmipRole struct {
+	AllowedCommonNames []string `json:"allowed_common_names"`
+	AllowedKeys        []string `json:"allowed_keys"`
+	AllowedOperations  []string `json:"allowed_operations"`
+}
+
+func (b *backend) pathKMIPConfig() *framework.Path {
+	return &framework.Path{
+		Pattern: "kmip/config",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"listen_address": {
+				Type:        framework.TypeString,
+				Description: `Address the KMIP server listens on, such as "0.0.0.0:5696".`,
+			},
+			"tls_certificate": {
+				Type:        framework.TypeString,
+				Description: `PEM encoded certificate chain presented by the KMIP server.`,
+			},
+			"tls_private_key": {
+				Type:        framework.TypeString,
+				Description: `PEM encoded private key of the KMIP server certificate.`,
+			},
+			"tls_client_ca": {
+				Type:        framework.TypeString,
+				Description: `PEM encoded CA certificates that KMIP client certificates must chain to.`,
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.pathKMIPConfigWrite,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb:   "configure",
+					OperationSuffix: "kmip",
+				},
+			},
+			logical.ReadOperation: &framework.PathOperation{
+				Callback: b.pathKMIPConfigRead,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "kmip-configuration",
+				},
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.pathKMIPConfigDelete,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationSuffix: "kmip-configuration",
+				},
+			},
+		},
+
+		HelpSynopsis:    pathKMIPConfigHelpSyn,
+		HelpDescription: pathKMIPConfigHelpDesc,
+	}
+}
+
+func (b *backend) pathListKMIPRoles() *framework.Path {
+	return &framework.Path{
+		Pattern: "kmip/roles/?$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationSuffix: "kmip-roles",
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ListOperation: b.pathKMIPRolesList,
+		},
+
+		HelpSynopsis:    pathKMIPRoleHelpSyn,
+		HelpDescription: pathKMIPRoleHelpDesc,
+	}
+}
+
+func (b *backend) pathKMIPRoles() *framework.Path {
+	return &framework.Path{
+		Pattern: "kmip/roles/" + framework.GenericNameRegex("name"),
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationSuffix: "kmip-role",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the KMIP role",
+			},
+			"allowed_common_names": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Common names of the KMIP client certificates
+the role applies to. Globs are supported.`,
+			},
+			"allowed_keys": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Names of the keys the clients may use.
+Globs are supported.`,
+			},
+			"allowed_operations": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `KMIP operations the clients may perform on
+the allowed keys: "locate", "get", "encrypt" and "decrypt".`,
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.UpdateOperation: b.pathKMIPRoleWrite,
+			logical.ReadOperation:   b.pathKMIPRoleRead,
+			logical.DeleteOperation: b.pathKMIPRoleDelete,
+		},
+
+		HelpSynopsis:    pathKMIPRoleHelpSyn,
+		HelpDescription: pathKMIPRoleHelpDesc,
+	}
+}
+
+func readKMIPConfig(ctx context.Context, s logical.Storage) (*kmipConfig, error) {
+	entry, err := s.Get(ctx, kmipConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch KMIP configuration: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var cfg kmipConfig
+	if err := entry.DecodeJSON(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode KMIP configuration: %w", err)
+	}
+	return &cfg, nil
+}
+
+func (b *backend) pathKMIPConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	cfg, err := readKMIPConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"listen_address":  cfg.ListenAddress,
+			"tls_certificate": cfg.TLSCertificate,
+			"tls_client_ca":   cfg.TLSClientCA,
+		},
+	}, nil
+}
+
+func (b *backend) pathKMIPConfigWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	cfg, err := readKMIPConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = &kmipConfig{}
+	}
+
+	if raw, ok := d.GetOk("listen_address"); ok {
+		cfg.ListenAddress = raw.(string)
+	}
+	if raw, ok := d.GetOk("tls_certificate"); ok {
+		cfg.TLSCertificate = raw.(string)
+	}
+	if raw, ok := d.GetOk("tls_private_key"); ok {
+		cfg.TLSPrivateKey = raw.(string)
+	}
+	if raw, ok := d.GetOk("tls_client_ca"); ok {
+		cfg.TLSClientCA = raw.(string)
+	}
+
+	switch {
+	case cfg.ListenAddress == "":
+		return logical.ErrorResponse("missing listen_address"), nil
+	case cfg.TLSCertificate == "" || cfg.TLSPrivateKey == "":
+		return logical.ErrorResponse("missing tls_certificate or tls_private_key"), nil
+	case cfg.TLSClientCA == "":
+		return logical.ErrorResponse("missing tls_client_ca"), nil
+	}
+
+	b.kmipLock.Lock()
+	defer b.kmipLock.Unlock()
+
+	// Stop the running server first, since the new one may listen on the
+	// same address
+	if b.kmipServer != nil {
+		b.kmipServer.stop()
+		b.kmipServer = nil
+	}
+
+	if b.runsKMIPServer() {
+		server, err := b.newKMIPServer(cfg)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		b.kmipServer = server
+	}
+
+	entry, err := logical.StorageEntryJSON(kmipConfigPath, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal KMIP configuration: %w", err)
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathKMIPConfigDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	b.kmipLock.Lock()
+	defer b.kmipLock.Unlock()
+
+	if err := req.Storage.Delete(ctx, kmipConfigPath); err != nil {
+		return nil, err
+	}
+	if b.kmipServer != nil {
+		b.kmipServer.stop()
+		b.kmipServer = nil
+	}
+	return nil, nil
+}
+
+func (b *backend) pathKMIPRolesList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	entries, err := req.Storage.List(ctx, kmipRoleStorageDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return logical.ListResponse(entries), nil
+}
+
+func readKMIPRole(ctx context.Context, s logical.Storage, name string) (*kmipRole, error) {
+	entry, err := s.Get(ctx, kmipRoleStorageDir+name)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var role kmipRole
+	if err := entry.DecodeJSON(&role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// kmipRolesForCommonName returns the KMIP roles applying to the client with
+// the given certificate common name.
+func (b *backend) kmipRolesForCommonName(ctx context.Context, s logical.Storage, commonName string) ([]*kmipRole, error) {
+	names, err := s.List(ctx, kmipRoleStorageDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var roles []*kmipRole
+	for _, name := range names {
+		role, err := readKMIPRole(ctx, s, name)
+		if err != nil {
+			return nil, err
+		}
+		if role != nil && strutil.StrListContainsGlob(role.AllowedCommonNames, commonName) {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+func (b *backend) pathKMIPRoleRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	role, err := readKMIPRole(ctx, req.Storage, d.Get("name").(string))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, nil
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"allowed_common_names": role.AllowedCommonNames,
+			"allowed_keys":         role.AllowedKeys,
+			"allowed_operations":   role.AllowedOperations,
+		},
+	}, nil
+}
+
+func (b *backend) pathKMIPRoleWrite(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+
+	role, err := readKMIPRole(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		role = &kmipRole{}
+	}
+
+	if raw, ok := d.GetOk("allowed_common_names"); ok {
+		role.AllowedCommonNames = raw.([]string)
+	}
+	if raw, ok := d.GetOk("allowed_keys"); ok {
+		role.AllowedKeys = raw.([]string)
+	}
+	if raw, ok := d.GetOk("allowed_operations"); ok {
+		role.AllowedOperations = raw.([]string)
+	}
+
+	if len(role.AllowedCommonNames) == 0 {
+		return logical.ErrorResponse("missing allowed_common_names"), nil
+	}
+	for i, op := range role.AllowedOperations {
+		op = strings.ToLower(strings.TrimSpace(op))
+		if !strutil.StrListContains([]string{"locate", "get", "encrypt", "decrypt"}, op) {
+			return logical.ErrorResponse("unsupported KMIP operation %q", op), nil
+		}
+		role.AllowedOperations[i] = op
+	}
+	for _, key := range role.AllowedKeys {
+		if _, err := path.Match(key, ""); err != nil {
+			return logical.ErrorResponse("invalid allowed_keys glob %q", key), nil
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(kmipRoleStorageDir+name, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Storage.Put(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (b *backend) pathKMIPRoleDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := req.Storage.Delete(ctx, kmipRoleStorageDir+d.Get("name").(string)); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+const pathKMIPConfigHelpSyn = `Configure the KMIP server of the transit mount`
+
+const pathKMIPConfigHelpDesc = `
+This path configures a KMIP server serving the keys of this mount to KMIP
+clients, such as databases using transparent data encryption and backup
+appliances. Clients authenticate with a TLS client certificate issued by the
+configured CA, and may only use the keys and operations allowed by the KMIP
+roles matching the common name of their certificate.
+
+Supported operations are Discover Versions, Query, Locate (by name), Get
+(of exportable AES keys), Encrypt and Decrypt. Ciphertexts are transit
+ciphertexts, which clients must store as opaque data.
+`
+
+const pathKMIPRoleHelpSyn = `Manage the KMIP roles of the transit mount`
+
+const pathKMIPRoleHelpDesc = `
+A KMIP role grants the KMIP clients whose certificate common name matches
+allowed_common_names the allowed_operations on the keys matching
+allowed_keys.
+`
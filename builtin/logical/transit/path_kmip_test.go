@@ -0,0 +1,303 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransit_KMIPItemRoundTrip(t *testing.T) {
+	item := kmipStructure(kmipTagRequestMessage,
+		kmipInteger(kmipTagBatchCount, -3),
+		kmipEnumeration(kmipTagOperation, kmipOperationEncrypt),
+		kmipTextString(kmipTagUniqueIdentifier, "backups"),
+		kmipByteString(kmipTagData, []byte("0123456789")),
+		kmipDateTime(kmipTagTimeStamp, time.Unix(1700000000, 0)),
+		kmipStructure(kmipTagRequestPayload),
+	)
+
+	encoded := item.marshal()
+	require.Zero(t, len(encoded)%8, "items must be padded to 8 bytes")
+
+	decoded, err := readKMIPItem(bytes.NewReader(encoded))
+	require.NoError(t, err)
+	require.Equal(t, item, decoded)
+
+	_, err = readKMIPItem(bytes.NewReader(encoded[:len(encoded)-8]))
+	require.Error(t, err)
+}
+
+type kmipTestPKI struct {
+	ca         *x509.Certificate
+	caKey      *ecdsa.PrivateKey
+	caPEM      string
+	serverCert string
+	serverKey  string
+}
+
+func newKMIPTestPKI(t *testing.T) *kmipTestPKI {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kmip-ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	p := &kmipTestPKI{
+		ca:    ca,
+		caKey: caKey,
+		caPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+	}
+	serverCert, serverKey := p.issue(t, "localhost", x509.ExtKeyUsageServerAuth)
+	p.serverCert, p.serverKey = string(serverCert), string(serverKey)
+	return p
+}
+
+func (p *kmipTestPKI) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, p.ca, key.Public(), p.caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func (p *kmipTestPKI) dial(t *testing.T, addr, cn string) *tls.Conn {
+	t.Helper()
+
+	certPEM, keyPEM := p.issue(t, cn, x509.ExtKeyUsageClientAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(p.ca)
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      roots,
+		ServerName:   "localhost",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// kmipRoundTrip sends a request with a single batch item and returns the
+// response batch item.
+func kmipRoundTrip(t *testing.T, conn *tls.Conn, operation uint32, payload ...*kmipItem) *kmipItem {
+	t.Helper()
+
+	req := kmipStructure(kmipTagRequestMessage,
+		kmipStructure(kmipTagRequestHeader,
+			kmipStructure(kmipTagProtocolVersion,
+				kmipInteger(kmipTagProtocolVersionMajor, 1),
+				kmipInteger(kmipTagProtocolVersionMinor, 2),
+			),
+			kmipInteger(kmipTagBatchCount, 1),
+		),
+		kmipStructure(kmipTagBatchItem,
+			kmipEnumeration(kmipTagOperation, operation),
+			kmipStructure(kmipTagRequestPayload, payload...),
+		),
+	)
+	_, err := conn.Write(req.marshal())
+	require.NoError(t, err)
+
+	resp, err := readKMIPItem(conn)
+	require.NoError(t, err)
+	require.Equal(t, kmipTagResponseMessage, resp.Tag)
+
+	version := resp.child(kmipTagResponseHeader).child(kmipTagProtocolVersion)
+	require.EqualValues(t, 2, version.child(kmipTagProtocolVersionMinor).Int)
+
+	items := resp.childrenWithTag(kmipTagBatchItem)
+	require.Len(t, items, 1)
+	return items[0]
+}
+
+func requireKMIPFailure(t *testing.T, item *kmipItem, reason uint32) {
+	t.Helper()
+	require.EqualValues(t, kmipResultStatusOperationFailed, item.child(kmipTagResultStatus).Int)
+	require.EqualValues(t, reason, item.child(kmipTagResultReason).Int, item.child(kmipTagResultMessage).text())
+}
+
+func requireKMIPSuccess(t *testing.T, item *kmipItem) *kmipItem {
+	t.Helper()
+	require.EqualValues(t, kmipResultStatusSuccess, item.child(kmipTagResultStatus).Int, item.child(kmipTagResultMessage).text())
+	return item.child(kmipTagResponsePayload)
+}
+
+func TestTransit_KMIP(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+	t.Cleanup(func() { b.Clean(context.Background()) })
+	p := newKMIPTestPKI(t)
+
+	write := func(path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Storage:   s,
+			Operation: logical.UpdateOperation,
+			Path:      path,
+			Data:      data,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	write("keys/tde", map[string]interface{}{"exportable": true})
+	write("keys/backups", nil)
+	write("keys/other", nil)
+
+	resp := write("kmip/roles/db", map[string]interface{}{
+		"allowed_common_names": "db-*",
+		"allowed_keys":         "tde,backups",
+		"allowed_operations":   "locate,get,encrypt,decrypt",
+	})
+	require.Nil(t, resp)
+	resp = write("kmip/roles/bad", map[string]interface{}{
+		"allowed_common_names": "db-*",
+		"allowed_operations":   "destroy",
+	})
+	require.True(t, resp.IsError())
+
+	// An invalid configuration is rejected without being stored
+	resp = write("kmip/config", map[string]interface{}{
+		"listen_address":  "127.0.0.1:0",
+		"tls_certificate": p.serverCert,
+		"tls_private_key": p.serverKey,
+		"tls_client_ca":   "not a certificate",
+	})
+	require.True(t, resp.IsError())
+	cfg, err := readKMIPConfig(context.Background(), s)
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+
+	resp = write("kmip/config", map[string]interface{}{
+		"listen_address":  "127.0.0.1:0",
+		"tls_certificate": p.serverCert,
+		"tls_private_key": p.serverKey,
+		"tls_client_ca":   p.caPEM,
+	})
+	require.Nil(t, resp)
+
+	resp, err = b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   s,
+		Operation: logical.ReadOperation,
+		Path:      "kmip/config",
+	})
+	require.NoError(t, err)
+	require.NotContains(t, resp.Data, "tls_private_key")
+
+	b.kmipLock.Lock()
+	addr := b.kmipServer.listener.Addr().String()
+	b.kmipLock.Unlock()
+
+	conn := p.dial(t, addr, "db-1")
+
+	// Locate only returns the allowed keys
+	payload := requireKMIPSuccess(t, kmipRoundTrip(t, conn, kmipOperationLocate))
+	var located []string
+	for _, id := range payload.childrenWithTag(kmipTagUniqueIdentifier) {
+		located = append(located, id.text())
+	}
+	require.ElementsMatch(t, []string{"tde", "backups"}, located)
+
+	payload = requireKMIPSuccess(t, kmipRoundTrip(t, conn, kmipOperationLocate,
+		kmipStructure(kmipTagAttribute,
+			kmipTextString(kmipTagAttributeName, "Name"),
+			kmipStructure(kmipTagAttributeValue, kmipTextString(kmipTagNameValue, "backups")),
+		),
+	))
+	require.Len(t, payload.Children, 1)
+	require.Equal(t, "backups", payload.child(kmipTagUniqueIdentifier).text())
+
+	// Encrypt and decrypt round trip
+	payload = requireKMIPSuccess(t, kmipRoundTrip(t, conn, kmipOperationEncrypt,
+		kmipTextString(kmipTagUniqueIdentifier, "backups"),
+		kmipByteString(kmipTagData, []byte("secret data")),
+	))
+	ciphertext := payload.child(kmipTagData).Bytes
+	require.True(t, bytes.HasPrefix(ciphertext, []byte("vault:v1:")))
+
+	payload = requireKMIPSuccess(t, kmipRoundTrip(t, conn, kmipOperationDecrypt,
+		kmipTextString(kmipTagUniqueIdentifier, "backups"),
+		kmipByteString(kmipTagData, ciphertext),
+	))
+	require.Equal(t, []byte("secret data"), payload.child(kmipTagData).Bytes)
+
+	// Get returns exportable keys only
+	payload = requireKMIPSuccess(t, kmipRoundTrip(t, conn, kmipOperationGet,
+		kmipTextString(kmipTagUniqueIdentifier, "tde"),
+	))
+	keyBlock := payload.child(kmipTagSymmetricKey).child(kmipTagKeyBlock)
+	require.Len(t, keyBlock.child(kmipTagKeyValue).child(kmipTagKeyMaterial).Bytes, 32)
+	require.EqualValues(t, 256, keyBlock.child(kmipTagCryptographicLength).Int)
+
+	requireKMIPFailure(t, kmipRoundTrip(t, conn, kmipOperationGet,
+		kmipTextString(kmipTagUniqueIdentifier, "backups"),
+	), kmipReasonPermissionDenied)
+
+	// Keys outside of the role are denied
+	requireKMIPFailure(t, kmipRoundTrip(t, conn, kmipOperationEncrypt,
+		kmipTextString(kmipTagUniqueIdentifier, "other"),
+		kmipByteString(kmipTagData, []byte("secret data")),
+	), kmipReasonPermissionDenied)
+
+	requireKMIPFailure(t, kmipRoundTrip(t, conn, 0x01), kmipReasonOperationNotSupported)
+
+	// Clients without a matching role are denied everything but the
+	// handshake
+	other := p.dial(t, addr, "web-1")
+	requireKMIPFailure(t, kmipRoundTrip(t, other, kmipOperationLocate), kmipReasonPermissionDenied)
+
+	// Deleting the configuration stops the server
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Storage:   s,
+		Operation: logical.DeleteOperation,
+		Path:      "kmip/config",
+	})
+	require.NoError(t, err)
+	_, err = net.DialTimeout("tcp", addr, time.Second)
+	require.Error(t, err)
+}
@@ -76,7 +76,14 @@ func (b *backend) pathRotateWrite(ctx context.Context, req *logical.Request, d *
 		err = p.RotateManagedKey(ctx, req.Storage, keyId)
 	} else {
 		// Rotate the policy
-		err = p.Rotate(ctx, req.Storage, b.GetRandomReader())
+		rotate := func() error {
+			return p.Rotate(ctx, req.Storage, b.GetRandomReader())
+		}
+		if isRSAKeyType(p.Type) {
+			err = runOnPool(ctx, b.workerPools.keygen, rotate)
+		} else {
+			err = rotate()
+		}
 	}
 
 	if err != nil {
@@ -404,79 +404,94 @@ func (b *backend) pathSignWrite(ctx context.Context, req *logical.Request, d *fr
 
 	response := make([]batchResponseSignItem, len(batchInputItems))
 
-	for i, item := range batchInputItems {
-
-		rawInput, ok := item["input"]
-		if !ok {
-			response[i].Error = "missing input"
-			response[i].err = logical.ErrInvalidRequest
-			continue
-		}
-
-		input, err := base64.StdEncoding.DecodeString(rawInput)
-		if err != nil {
-			response[i].Error = fmt.Sprintf("unable to decode input as base64: %s", err)
-			response[i].err = logical.ErrInvalidRequest
-			continue
-		}
+	signBatch := func() error {
+		for i, item := range batchInputItems {
 
-		if p.Type.HashSignatureInput() && !prehashed {
-			hf := keysutil.HashFuncMap[hashAlgorithm]()
-			if hf != nil {
-				hf.Write(input)
-				input = hf.Sum(nil)
+			rawInput, ok := item["input"]
+			if !ok {
+				response[i].Error = "missing input"
+				response[i].err = logical.ErrInvalidRequest
+				continue
 			}
-		}
 
-		contextRaw := item["context"]
-		var context []byte
-		if len(contextRaw) != 0 {
-			context, err = base64.StdEncoding.DecodeString(contextRaw)
+			input, err := base64.StdEncoding.DecodeString(rawInput)
 			if err != nil {
-				response[i].Error = "failed to base64-decode context"
+				response[i].Error = fmt.Sprintf("unable to decode input as base64: %s", err)
 				response[i].err = logical.ErrInvalidRequest
 				continue
 			}
-		}
 
-		var managedKeyParameters keysutil.ManagedKeyParameters
-		if p.Type == keysutil.KeyType_MANAGED_KEY {
-			managedKeySystemView, ok := b.System().(logical.ManagedKeySystemView)
-			if !ok {
-				return nil, errors.New("unsupported system view")
+			if p.Type.HashSignatureInput() && !prehashed {
+				hf := keysutil.HashFuncMap[hashAlgorithm]()
+				if hf != nil {
+					hf.Write(input)
+					input = hf.Sum(nil)
+				}
 			}
 
-			managedKeyParameters = keysutil.ManagedKeyParameters{
-				ManagedKeySystemView: managedKeySystemView,
-				BackendUUID:          b.backendUUID,
-				Context:              ctx,
+			contextRaw := item["context"]
+			var context []byte
+			if len(contextRaw) != 0 {
+				context, err = base64.StdEncoding.DecodeString(contextRaw)
+				if err != nil {
+					response[i].Error = "failed to base64-decode context"
+					response[i].err = logical.ErrInvalidRequest
+					continue
+				}
 			}
-		}
 
-		sig, err := p.SignWithOptions(ver, context, input, &keysutil.SigningOptions{
-			HashAlgorithm:    hashAlgorithm,
-			Marshaling:       marshaling,
-			SaltLength:       saltLength,
-			SigAlgorithm:     sigAlgorithm,
-			ManagedKeyParams: managedKeyParameters,
-		})
-		if err != nil {
-			if batchInputRaw != nil {
-				response[i].Error = err.Error()
-			}
-			response[i].err = err
-		} else if sig == nil {
-			response[i].err = fmt.Errorf("signature could not be computed")
-		} else {
-			keyVersion := ver
-			if keyVersion == 0 {
-				keyVersion = p.LatestVersion
+			var managedKeyParameters keysutil.ManagedKeyParameters
+			if p.Type == keysutil.KeyType_MANAGED_KEY {
+				managedKeySystemView, ok := b.System().(logical.ManagedKeySystemView)
+				if !ok {
+					return errors.New("unsupported system view")
+				}
+
+				managedKeyParameters = keysutil.ManagedKeyParameters{
+					ManagedKeySystemView: managedKeySystemView,
+					BackendUUID:          b.backendUUID,
+					Context:              ctx,
+				}
 			}
 
-			response[i].Signature = sig.Signature
-			response[i].PublicKey = sig.PublicKey
-			response[i].KeyVersion = keyVersion
+			sig, err := p.SignWithOptions(ver, context, input, &keysutil.SigningOptions{
+				HashAlgorithm:    hashAlgorithm,
+				Marshaling:       marshaling,
+				SaltLength:       saltLength,
+				SigAlgorithm:     sigAlgorithm,
+				ManagedKeyParams: managedKeyParameters,
+			})
+			if err != nil {
+				if batchInputRaw != nil {
+					response[i].Error = err.Error()
+				}
+				response[i].err = err
+			} else if sig == nil {
+				response[i].err = fmt.Errorf("signature could not be computed")
+			} else {
+				keyVersion := ver
+				if keyVersion == 0 {
+					keyVersion = p.LatestVersion
+				}
+
+				response[i].Signature = sig.Signature
+				response[i].PublicKey = sig.PublicKey
+				response[i].KeyVersion = keyVersion
+			}
 		}
+		return nil
+	}
+
+	// Large batches are signed on the batch signing pool, so that they do not
+	// starve other requests of CPU.
+	if batchInputRaw != nil && int64(len(batchInputItems)) >= b.workerPools.batchSignMinSize.Load() {
+		err = runOnPool(ctx, b.workerPools.batchSign, signBatch)
+	} else {
+		err = signBatch()
+	}
+	if err != nil {
+		p.Unlock()
+		return nil, err
 	}
 
 	// Generate the response
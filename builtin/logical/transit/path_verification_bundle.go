@@ -0,0 +1,219 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// keyCheckValueSize is the number of bytes of the HMAC of an empty message
+// returned as the check value of HMAC keys.
+const keyCheckValueSize = 8
+
+func (b *backend) pathVerificationBundle() *framework.Path {
+	return &framework.Path{
+		Pattern: "keys/" + framework.GenericNameRegex("name") + "/verification-bundle",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: operationPrefixTransit,
+			OperationVerb:   "read",
+			OperationSuffix: "verification-bundle",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"name": {
+				Type:        framework.TypeString,
+				Description: "Name of the key",
+			},
+			"consumers": {
+				Type: framework.TypeCommaStringSlice,
+				Description: `Names of the RSA keys of this mount, usually the imported public
+wrapping keys of the systems verifying HMACs, to wrap the HMAC keys for.`,
+				Required: true,
+			},
+			"version": {
+				Type: framework.TypeString,
+				Description: `Version of the key to include, else all the versions which can
+verify HMACs are included.`,
+			},
+			"hash": {
+				Type:        framework.TypeString,
+				Description: "Hash function to use for inner OAEP encryption. Defaults to SHA256.",
+				Default:     "SHA256",
+			},
+		},
+
+		Callbacks: map[logical.Operation]framework.OperationFunc{
+			logical.ReadOperation: b.pathVerificationBundleRead,
+		},
+
+		HelpSynopsis:    pathVerificationBundleHelpSyn,
+		HelpDescription: pathVerificationBundleHelpDesc,
+	}
+}
+
+func (b *backend) pathVerificationBundleRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("name").(string)
+	consumers := d.Get("consumers").([]string)
+	version := d.Get("version").(string)
+	hash := d.Get("hash").(string)
+
+	if len(consumers) == 0 {
+		return logical.ErrorResponse("at least one consumer is required"), logical.ErrInvalidRequest
+	}
+	if _, err := parseHashFn(hash); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	p, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+		Storage: req.Storage,
+		Name:    name,
+	}, b.GetRandomReader())
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return logical.ErrorResponse("no such key"), logical.ErrInvalidRequest
+	}
+	if !b.System().CachingDisabled() {
+		p.Lock(false)
+	}
+	defer p.Unlock()
+
+	if !p.Exportable {
+		return logical.ErrorResponse("key is not exportable"), nil
+	}
+
+	consumerPolicies := make(map[string]*keysutil.Policy, len(consumers))
+	for _, consumer := range consumers {
+		if consumer == name {
+			return logical.ErrorResponse("the key cannot be its own consumer"), logical.ErrInvalidRequest
+		}
+		if _, ok := consumerPolicies[consumer]; ok {
+			continue
+		}
+
+		consumerP, _, err := b.GetPolicy(ctx, keysutil.PolicyRequest{
+			Storage: req.Storage,
+			Name:    consumer,
+		}, b.GetRandomReader())
+		if err != nil {
+			return nil, err
+		}
+		if consumerP == nil {
+			return logical.ErrorResponse("no such consumer key %q", consumer), logical.ErrInvalidRequest
+		}
+		if !b.System().CachingDisabled() {
+			consumerP.Lock(false)
+		}
+		defer consumerP.Unlock()
+
+		switch consumerP.Type {
+		case keysutil.KeyType_RSA2048, keysutil.KeyType_RSA3072, keysutil.KeyType_RSA4096:
+		default:
+			return logical.ErrorResponse("consumer key %q must be an RSA key", consumer), logical.ErrInvalidRequest
+		}
+		consumerPolicies[consumer] = consumerP
+	}
+
+	// Versions below the minimum decryption version cannot verify HMACs
+	versions := make([]int, 0, p.LatestVersion)
+	switch version {
+	case "":
+		for v := p.MinDecryptionVersion; v <= p.LatestVersion; v++ {
+			if _, ok := p.Keys[strconv.Itoa(v)]; ok {
+				versions = append(versions, v)
+			}
+		}
+	default:
+		var versionValue int
+		if version == "latest" {
+			versionValue = p.LatestVersion
+		} else {
+			versionValue, err = strconv.Atoi(strings.TrimPrefix(version, "v"))
+			if err != nil {
+				return logical.ErrorResponse("invalid key version"), logical.ErrInvalidRequest
+			}
+		}
+		if versionValue < p.MinDecryptionVersion {
+			return logical.ErrorResponse("version for export is below minimum decryption version"), logical.ErrInvalidRequest
+		}
+		if _, ok := p.Keys[strconv.Itoa(versionValue)]; !ok {
+			return logical.ErrorResponse("version does not exist or cannot be found"), logical.ErrInvalidRequest
+		}
+		versions = append(versions, versionValue)
+	}
+
+	retVersions := make(map[string]interface{}, len(versions))
+	for _, v := range versions {
+		hmacKey, err := p.HMACKey(v)
+		if err != nil {
+			return nil, err
+		}
+
+		wrappedKeys := make(map[string]string, len(consumerPolicies))
+		for consumer, consumerP := range consumerPolicies {
+			hasher, err := parseHashFn(hash)
+			if err != nil {
+				return nil, err
+			}
+			wrapped, err := consumerP.WrapKey(0, hmacKey, keysutil.KeyType_HMAC, hasher)
+			if err != nil {
+				return nil, fmt.Errorf("failed to wrap the HMAC key for consumer %q: %w", consumer, err)
+			}
+			wrappedKeys[consumer] = wrapped
+		}
+
+		retVersions[strconv.Itoa(v)] = map[string]interface{}{
+			"creation_time":   p.Keys[strconv.Itoa(v)].CreationTime,
+			"key_size":        len(hmacKey),
+			"key_check_value": hmacKeyCheckValue(hmacKey),
+			"wrapped_keys":    wrappedKeys,
+		}
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":                   p.Name,
+			"type":                   p.Type.String(),
+			"latest_version":         p.LatestVersion,
+			"min_decryption_version": p.MinDecryptionVersion,
+			"versions":               retVersions,
+		},
+	}, nil
+}
+
+// hmacKeyCheckValue returns the hex encoded start of the HMAC-SHA256 of an
+// empty message with the key, which identifies the key without revealing it.
+func hmacKeyCheckValue(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	return hex.EncodeToString(mac.Sum(nil)[:keyCheckValueSize])
+}
+
+const pathVerificationBundleHelpSyn = `Export the HMAC keys of the named key for external verification`
+
+const pathVerificationBundleHelpDesc = `
+This path returns a verification bundle of the named key: the metadata of
+each of its versions able to verify HMACs, along with their HMAC key wrapped
+for each of the given consumer keys.
+
+Consumers are RSA keys of this mount, usually the public wrapping keys of the
+systems verifying HMACs imported with the /import path. The HMAC keys are
+wrapped with the same specification as the /import path, so they can be
+imported into another Vault instance with the "hmac" key type. The check value
+of each version is the start of the HMAC-SHA256 of an empty message, so that
+consumers can confirm they unwrapped the right key.
+
+The key must be exportable.
+`
@@ -0,0 +1,115 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package transit
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransit_VerificationBundle(t *testing.T) {
+	b, s := createBackendWithStorage(t)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return b.HandleRequest(context.Background(), &logical.Request{
+			Path:      path,
+			Operation: op,
+			Storage:   s,
+			Data:      data,
+		})
+	}
+	mustRequest := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := request(op, path, data)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("resp: %#v\nerr: %v", resp, err)
+		}
+		return resp
+	}
+
+	// Import a legacy HMAC key, and rotate it within Vault
+	legacyKey := []byte("0123456789abcdef0123456789abcdef")
+	resp := mustRequest(logical.ReadOperation, "wrapping_key", nil)
+	wrappingKey, err := b.getWrappingKey(context.Background(), s)
+	require.NoError(t, err)
+	mustRequest(logical.UpdateOperation, "keys/legacy/import", map[string]interface{}{
+		"ciphertext":     wrapTargetKeyForImport(t, &wrappingKey.Keys["1"].RSAKey.PublicKey, legacyKey, "hmac", "SHA256"),
+		"type":           "hmac",
+		"exportable":     true,
+		"allow_rotation": true,
+	})
+	mustRequest(logical.UpdateOperation, "keys/legacy/rotate", nil)
+
+	// The verifier is the system verifying HMACs, here this same mount
+	mustRequest(logical.UpdateOperation, "keys/verifier/import", map[string]interface{}{
+		"public_key": resp.Data["public_key"],
+		"type":       "rsa-4096",
+	})
+
+	resp = mustRequest(logical.ReadOperation, "keys/legacy/verification-bundle", map[string]interface{}{
+		"consumers": "verifier",
+	})
+	require.Equal(t, "legacy", resp.Data["name"])
+	require.Equal(t, "hmac", resp.Data["type"])
+	require.Equal(t, 2, resp.Data["latest_version"])
+	versions := resp.Data["versions"].(map[string]interface{})
+	require.Len(t, versions, 2)
+
+	v1 := versions["1"].(map[string]interface{})
+	require.Equal(t, hmacKeyCheckValue(legacyKey), v1["key_check_value"])
+	require.Equal(t, len(legacyKey), v1["key_size"])
+
+	// The wrapped HMAC keys can be imported by the verifier, which then
+	// verifies the HMACs of the key
+	for _, version := range []string{"1", "2"} {
+		wrapped := versions[version].(map[string]interface{})["wrapped_keys"].(map[string]string)["verifier"]
+		mustRequest(logical.UpdateOperation, "keys/verify-v"+version+"/import", map[string]interface{}{
+			"ciphertext": wrapped,
+			"type":       "hmac",
+		})
+
+		resp = mustRequest(logical.UpdateOperation, "hmac/legacy", map[string]interface{}{
+			"input":       base64.StdEncoding.EncodeToString([]byte("the quick brown fox")),
+			"key_version": version,
+		})
+		hmac := resp.Data["hmac"].(string)
+
+		resp = mustRequest(logical.UpdateOperation, "verify/verify-v"+version, map[string]interface{}{
+			"input": base64.StdEncoding.EncodeToString([]byte("the quick brown fox")),
+			"hmac":  "vault:v1:" + hmac[len("vault:v"+version+":"):],
+		})
+		require.Equal(t, true, resp.Data["valid"])
+	}
+
+	resp = mustRequest(logical.ReadOperation, "keys/legacy/verification-bundle", map[string]interface{}{
+		"consumers": "verifier",
+		"version":   "latest",
+	})
+	require.Len(t, resp.Data["versions"], 1)
+	require.Contains(t, resp.Data["versions"], "2")
+
+	// Consumers must be RSA keys of the mount, and the key exportable
+	mustRequest(logical.UpdateOperation, "keys/aes", map[string]interface{}{"type": "aes256-gcm96"})
+	for _, tc := range []struct {
+		name string
+		data map[string]interface{}
+		err  string
+	}{
+		{"legacy", map[string]interface{}{}, "at least one consumer is required"},
+		{"legacy", map[string]interface{}{"consumers": "missing"}, `no such consumer key "missing"`},
+		{"legacy", map[string]interface{}{"consumers": "aes"}, `consumer key "aes" must be an RSA key`},
+		{"legacy", map[string]interface{}{"consumers": "legacy"}, "the key cannot be its own consumer"},
+		{"legacy", map[string]interface{}{"consumers": "verifier", "version": "3"}, "version does not exist or cannot be found"},
+		{"aes", map[string]interface{}{"consumers": "verifier"}, "key is not exportable"},
+	} {
+		resp, _ = request(logical.ReadOperation, "keys/"+tc.name+"/verification-bundle", tc.data)
+		require.NotNil(t, resp)
+		require.True(t, resp.IsError())
+		require.Equal(t, tc.err, resp.Error().Error())
+	}
+}
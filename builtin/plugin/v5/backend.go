@@ -5,11 +5,15 @@ package plugin
 
 import (
 	"context"
+	"fmt"
 	"net/rpc"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/builtin/plugin/wasm"
 	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/pluginruntimeutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/plugin"
 	bplugin "github.com/hashicorp/vault/sdk/plugin"
@@ -26,18 +30,42 @@ func Backend(ctx context.Context, conf *logical.BackendConfig) (logical.Backend,
 	}
 	pluginVersion := conf.Config["plugin_version"]
 
-	sys := conf.System
-
-	raw, err := plugin.NewBackendV5(ctx, name, pluginType, pluginVersion, sys, conf)
+	raw, runtimeConfig, err := newBackend(ctx, name, pluginType, pluginVersion, conf)
 	if err != nil {
 		return nil, err
 	}
 	b.Backend = raw
 	b.config = conf
+	b.runtimeConfig = runtimeConfig
 
 	return &b, nil
 }
 
+// newBackend returns an instance of the plugin backend, running it with the
+// WebAssembly runtime if the plugin is a WebAssembly module, along with the
+// configuration of the plugin's runtime, if any.
+func newBackend(ctx context.Context, name string, pluginType consts.PluginType, pluginVersion string, conf *logical.BackendConfig) (logical.Backend, *pluginruntimeutil.PluginRuntimeConfig, error) {
+	sys := conf.System
+
+	runner, err := sys.LookupPluginVersion(ctx, name, pluginType, pluginVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	if runner.IsWASM() {
+		b, err := wasm.NewBackend(ctx, runner, conf)
+		if err != nil {
+			return nil, nil, err
+		}
+		return b, nil, nil
+	}
+
+	b, err := plugin.NewBackendV5(ctx, name, pluginType, pluginVersion, sys, conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, runner.RuntimeConfig, nil
+}
+
 // backend is a thin wrapper around a builtin plugin or a plugin.BackendPluginClientV5
 type backend struct {
 	logical.Backend
@@ -47,6 +75,14 @@ type backend struct {
 
 	// Used to detect if we already reloaded
 	canary string
+
+	// runtimeConfig limits how often the plugin is restarted
+	runtimeConfig *pluginruntimeutil.PluginRuntimeConfig
+
+	healthLock sync.Mutex
+	health     logical.PluginHealth
+	// restarts holds the times of the restarts within the restart window
+	restarts []time.Time
 }
 
 func (b *backend) reloadBackend(ctx context.Context, storage logical.Storage) error {
@@ -65,7 +101,7 @@ func (b *backend) reloadBackend(ctx context.Context, storage logical.Storage) er
 	reloadCtx := context.WithValue(ctx, plugin.ContextKeyPluginReload, "reload")
 	b.Backend.Cleanup(reloadCtx)
 
-	nb, err := plugin.NewBackendV5(ctx, pluginName, pluginType, pluginVersion, b.config.System, b.config)
+	nb, runtimeConfig, err := newBackend(ctx, pluginName, pluginType, pluginVersion, b.config)
 	if err != nil {
 		return err
 	}
@@ -74,6 +110,7 @@ func (b *backend) reloadBackend(ctx context.Context, storage logical.Storage) er
 		return err
 	}
 	b.Backend = nb
+	b.runtimeConfig = runtimeConfig
 
 	// Re-initialize the backend in case plugin was reloaded
 	// after it crashed
@@ -87,6 +124,45 @@ func (b *backend) reloadBackend(ctx context.Context, storage logical.Storage) er
 	return nil
 }
 
+// restartBackend reloads the backend after its plugin exited unexpectedly with
+// cause, unless that would exceed the restarts allowed per restart window by
+// the plugin's runtime.
+func (b *backend) restartBackend(ctx context.Context, storage logical.Storage, cause error) error {
+	now := time.Now()
+
+	b.healthLock.Lock()
+	b.health.LastError = cause.Error()
+	if maxRestarts, window := b.runtimeConfig.Restarts(); maxRestarts > 0 {
+		restarts := b.restarts[:0]
+		for _, restart := range b.restarts {
+			if now.Sub(restart) < window {
+				restarts = append(restarts, restart)
+			}
+		}
+		b.restarts = restarts
+
+		if int64(len(b.restarts)) >= maxRestarts {
+			b.health.RestartLimitExceeded = true
+			b.healthLock.Unlock()
+			return fmt.Errorf("plugin %q exceeded its limit of %d restarts per %s", b.config.Config["plugin_name"], maxRestarts, window)
+		}
+		b.restarts = append(b.restarts, now)
+	}
+	b.health.RestartLimitExceeded = false
+	b.health.Restarts++
+	b.health.LastRestart = now
+	b.healthLock.Unlock()
+
+	return b.reloadBackend(ctx, storage)
+}
+
+// PluginHealth returns the restarts of the plugin process.
+func (b *backend) PluginHealth() logical.PluginHealth {
+	b.healthLock.Lock()
+	defer b.healthLock.Unlock()
+	return b.health
+}
+
 // HandleRequest is a thin wrapper implementation of HandleRequest that includes automatic plugin reload.
 func (b *backend) HandleRequest(ctx context.Context, req *logical.Request) (*logical.Response, error) {
 	b.mu.RLock()
@@ -100,7 +176,7 @@ func (b *backend) HandleRequest(ctx context.Context, req *logical.Request) (*log
 		// Reload plugin if it's an rpc.ErrShutdown
 		b.mu.Lock()
 		if b.canary == canary {
-			err := b.reloadBackend(ctx, req.Storage)
+			err := b.restartBackend(ctx, req.Storage, err)
 			if err != nil {
 				b.mu.Unlock()
 				return nil, err
@@ -132,7 +208,7 @@ func (b *backend) HandleExistenceCheck(ctx context.Context, req *logical.Request
 		// Reload plugin if it's an rpc.ErrShutdown
 		b.mu.Lock()
 		if b.canary == canary {
-			err := b.reloadBackend(ctx, req.Storage)
+			err := b.restartBackend(ctx, req.Storage, err)
 			if err != nil {
 				b.mu.Unlock()
 				return false, false, err
@@ -162,7 +238,7 @@ func (b *backend) InvalidateKey(ctx context.Context, key string) {
 
 func (b *backend) IsExternal() bool {
 	switch b.Backend.(type) {
-	case *plugin.BackendPluginClientV5:
+	case *plugin.BackendPluginClientV5, *wasm.Backend:
 		return true
 	}
 	return false
@@ -0,0 +1,56 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/pluginruntimeutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestBackend_RestartLimit(t *testing.T) {
+	b := &backend{
+		Backend: &framework.Backend{},
+		config: &logical.BackendConfig{
+			System: logical.TestSystemView(),
+			Config: map[string]string{
+				"plugin_name": "foo",
+				"plugin_type": consts.PluginTypeSecrets.String(),
+			},
+		},
+		runtimeConfig: &pluginruntimeutil.PluginRuntimeConfig{
+			Type:          consts.PluginRuntimeTypeProcess,
+			MaxRestarts:   1,
+			RestartWindow: time.Minute,
+		},
+		// Restarts outside of the window don't count towards the limit
+		restarts: []time.Time{time.Now().Add(-2 * time.Minute)},
+	}
+
+	// The plugin is restarted, even though the test system view can't look it up
+	err := b.restartBackend(context.Background(), &logical.InmemStorage{}, errors.New("plugin exited"))
+	if err == nil || strings.Contains(err.Error(), "exceeded") {
+		t.Fatalf("expected plugin to be restarted, got: %v", err)
+	}
+	health := b.PluginHealth()
+	if health.Restarts != 1 || health.RestartLimitExceeded || health.LastError != "plugin exited" {
+		t.Fatalf("bad health: %#v", health)
+	}
+
+	err = b.restartBackend(context.Background(), &logical.InmemStorage{}, errors.New("plugin exited again"))
+	if err == nil || !strings.Contains(err.Error(), "exceeded its limit of 1 restarts per 1m0s") {
+		t.Fatalf("expected restart limit error, got: %v", err)
+	}
+	health = b.PluginHealth()
+	if health.Restarts != 1 || !health.RestartLimitExceeded || health.LastError != "plugin exited again" {
+		t.Fatalf("bad health: %#v", health)
+	}
+}
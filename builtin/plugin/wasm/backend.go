@@ -0,0 +1,478 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package wasm implements a runtime for secrets engines and auth methods
+// compiled to WebAssembly. Instead of running the plugin as a separate process
+// with go-plugin, the module is run in a sandbox within the Vault process and
+// only has access to the host API exposed by this package.
+//
+// A module must be a WASI reactor (i.e. export "_initialize" rather than
+// "_start") and export the following functions:
+//
+//	vault_alloc(size u32) u32
+//	vault_handle_request(ptr u32, len u32) u64
+//
+// and may optionally export:
+//
+//	vault_free(ptr u32, len u32)
+//	vault_initialize()
+//	vault_invalidate(ptr u32, len u32)
+//	vault_special_paths() u64
+//
+// Values are exchanged as JSON documents written to the module's memory. A
+// document returned by the module is referenced by a u64 holding its pointer
+// in the upper 32 bits and its length in the lower 32 bits. Zero means no
+// document was returned.
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/pluginutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+const (
+	// EnvHTTPAllowedHosts is the plugin environment variable holding the comma
+	// separated list of hosts the module is allowed to send HTTP requests to.
+	// A leading "*." matches any subdomain. If unset, HTTP egress is denied.
+	EnvHTTPAllowedHosts = "VAULT_WASM_HTTP_ALLOWED_HOSTS"
+
+	// EnvMemoryLimit is the plugin environment variable holding the maximum
+	// amount of memory, in MiB, the module may use.
+	EnvMemoryLimit = "VAULT_WASM_MEMORY_LIMIT_MB"
+
+	// DefaultMemoryLimit is the maximum amount of memory, in MiB, a module may
+	// use if no other limit is configured.
+	DefaultMemoryLimit = 256
+
+	// maxMemoryLimit is the largest memory limit, in MiB, that can be
+	// addressed by a 32-bit WebAssembly module.
+	maxMemoryLimit = 4096
+
+	// pagesPerMiB is the number of 64KiB WebAssembly pages in a MiB.
+	pagesPerMiB = 16
+)
+
+var (
+	// ErrModuleClosed is returned when a request is made to a backend that has
+	// been cleaned up.
+	ErrModuleClosed = errors.New("webassembly module is closed")
+
+	_ logical.Backend         = (*Backend)(nil)
+	_ logical.PluginVersioner = (*Backend)(nil)
+)
+
+// Backend is a logical.Backend implemented by a WebAssembly module. Requests
+// are handled one at a time by a single instance of the module. If the module
+// traps or exceeds the deadline of a request, it is instantiated again for the
+// next request.
+type Backend struct {
+	// lock serializes calls into the module and guards module.
+	lock sync.Mutex
+
+	runner      *pluginutil.PluginRunner
+	config      *logical.BackendConfig
+	logger      log.Logger
+	backendType logical.BackendType
+	paths       *logical.Paths
+	egress      *egressPolicy
+	env         map[string]string
+
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	module   api.Module
+	closed   bool
+}
+
+// NewBackend compiles the WebAssembly module referenced by the plugin runner
+// and returns a backend serving requests with it.
+func NewBackend(ctx context.Context, runner *pluginutil.PluginRunner, conf *logical.BackendConfig) (*Backend, error) {
+	var backendType logical.BackendType
+	switch runner.Type {
+	case consts.PluginTypeSecrets:
+		backendType = logical.TypeLogical
+	case consts.PluginTypeCredential:
+		backendType = logical.TypeCredential
+	default:
+		return nil, fmt.Errorf("unsupported webassembly plugin type: %v", runner.Type)
+	}
+
+	code, err := os.ReadFile(runner.Command)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webassembly module: %w", err)
+	}
+	if len(runner.Sha256) > 0 {
+		sum := sha256.Sum256(code)
+		if subtle.ConstantTimeCompare(sum[:], runner.Sha256) != 1 {
+			return nil, errors.New("webassembly module checksums did not match")
+		}
+	}
+	if runner.Cosign != nil {
+		if err := runner.Cosign.Verify(code, runner.CosignTrustedRoot); err != nil {
+			return nil, fmt.Errorf("failed to verify cosign signature of webassembly module: %w", err)
+		}
+	}
+
+	b := &Backend{
+		runner:      runner,
+		config:      conf,
+		logger:      conf.Logger.Named(runner.Name),
+		backendType: backendType,
+		env:         make(map[string]string),
+	}
+
+	memoryLimit := DefaultMemoryLimit
+	var allowedHosts string
+	for _, kv := range runner.Env {
+		k, v, _ := strings.Cut(kv, "=")
+		switch k {
+		case EnvHTTPAllowedHosts:
+			allowedHosts = v
+		case EnvMemoryLimit:
+			memoryLimit, err = strconv.Atoi(v)
+			if err != nil || memoryLimit <= 0 || memoryLimit > maxMemoryLimit {
+				return nil, fmt.Errorf("invalid %s: %q", EnvMemoryLimit, v)
+			}
+		default:
+			b.env[k] = v
+		}
+	}
+	b.egress, err = newEgressPolicy(allowedHosts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", EnvHTTPAllowedHosts, err)
+	}
+
+	b.runtime = wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(memoryLimit*pagesPerMiB)).
+		WithCloseOnContextDone(true))
+
+	if err := b.setupRuntime(ctx, code); err != nil {
+		b.runtime.Close(ctx)
+		return nil, err
+	}
+
+	if err := b.loadSpecialPaths(ctx); err != nil {
+		b.runtime.Close(ctx)
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// setupRuntime instantiates the host modules and compiles the plugin module.
+func (b *Backend) setupRuntime(ctx context.Context, code []byte) error {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, b.runtime); err != nil {
+		return fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+
+	_, err := b.runtime.NewHostModuleBuilder(hostModuleName).
+		NewFunctionBuilder().WithFunc(b.hostCall).Export("host_call").
+		NewFunctionBuilder().WithFunc(b.hostLog).Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to instantiate host module: %w", err)
+	}
+
+	b.compiled, err = b.runtime.CompileModule(ctx, code)
+	if err != nil {
+		return fmt.Errorf("failed to compile webassembly module: %w", err)
+	}
+
+	for _, name := range []string{"vault_alloc", "vault_handle_request"} {
+		if _, ok := b.compiled.ExportedFunctions()[name]; !ok {
+			return fmt.Errorf("webassembly module does not export %q", name)
+		}
+	}
+
+	return nil
+}
+
+// moduleLocked returns the running instance of the module, instantiating it
+// if it isn't running. The lock must be held.
+func (b *Backend) moduleLocked(ctx context.Context) (api.Module, error) {
+	if b.closed {
+		return nil, ErrModuleClosed
+	}
+	if b.module != nil && !b.module.IsClosed() {
+		return b.module, nil
+	}
+
+	// The module only gets a clock and a source of randomness. It has no
+	// access to the filesystem, and its output is sent to the logger.
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithStdout(&logWriter{logger: b.logger, level: log.Info}).
+		WithStderr(&logWriter{logger: b.logger, level: log.Error}).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader)
+	for k, v := range b.env {
+		config = config.WithEnv(k, v)
+	}
+
+	module, err := b.runtime.InstantiateModule(ctx, b.compiled, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate webassembly module: %w", err)
+	}
+	b.module = module
+	return module, nil
+}
+
+// call calls an exported function of the module, passing it in encoded as
+// JSON, and decodes the document it returns into out. Storage is made
+// available to the host API for the duration of the call.
+func (b *Backend) call(ctx context.Context, storage logical.Storage, name string, in, out interface{}) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	module, err := b.moduleLocked(ctx)
+	if err != nil {
+		return err
+	}
+
+	fn := module.ExportedFunction(name)
+	if fn == nil {
+		return fmt.Errorf("webassembly module does not export %q", name)
+	}
+
+	ctx = context.WithValue(ctx, callStateKey{}, &callState{storage: storage})
+
+	var params []uint64
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		ptr, err := writeGuest(ctx, module, buf)
+		if err != nil {
+			return err
+		}
+		defer freeGuest(ctx, module, ptr, uint32(len(buf)))
+		params = []uint64{uint64(ptr), uint64(len(buf))}
+	}
+
+	results, err := fn.Call(ctx, params...)
+	if err != nil {
+		return fmt.Errorf("webassembly module call %q failed: %w", name, err)
+	}
+	if out == nil || len(results) == 0 || results[0] == 0 {
+		return nil
+	}
+
+	ptr, size := unpack(results[0])
+	buf, ok := module.Memory().Read(ptr, size)
+	if !ok {
+		return fmt.Errorf("webassembly module call %q returned out of range memory", name)
+	}
+	err = json.Unmarshal(buf, out)
+	freeGuest(ctx, module, ptr, size)
+	if err != nil {
+		return fmt.Errorf("failed to decode result of webassembly module call %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// hasExport returns whether the module exports the named function.
+func (b *Backend) hasExport(name string) bool {
+	_, ok := b.compiled.ExportedFunctions()[name]
+	return ok
+}
+
+func (b *Backend) loadSpecialPaths(ctx context.Context) error {
+	b.paths = &logical.Paths{}
+	if !b.hasExport("vault_special_paths") {
+		return nil
+	}
+
+	var paths struct {
+		Root            []string `json:"root"`
+		Unauthenticated []string `json:"unauthenticated"`
+		SealWrapStorage []string `json:"seal_wrap_storage"`
+	}
+	if err := b.call(ctx, nil, "vault_special_paths", nil, &paths); err != nil {
+		return err
+	}
+	b.paths.Root = paths.Root
+	b.paths.Unauthenticated = paths.Unauthenticated
+	b.paths.SealWrapStorage = paths.SealWrapStorage
+
+	return nil
+}
+
+// request is the document passed to vault_handle_request.
+type request struct {
+	ID                  string                 `json:"id"`
+	Operation           logical.Operation      `json:"operation"`
+	Path                string                 `json:"path"`
+	Data                map[string]interface{} `json:"data,omitempty"`
+	MountPoint          string                 `json:"mount_point"`
+	ClientTokenAccessor string                 `json:"client_token_accessor,omitempty"`
+	EntityID            string                 `json:"entity_id,omitempty"`
+}
+
+// response is the document returned by vault_handle_request.
+type response struct {
+	Data     map[string]interface{} `json:"data"`
+	Warnings []string               `json:"warnings"`
+	Error    string                 `json:"error"`
+}
+
+func (b *Backend) HandleRequest(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+	in := &request{
+		ID:                  req.ID,
+		Operation:           req.Operation,
+		Path:                req.Path,
+		Data:                req.Data,
+		MountPoint:          req.MountPoint,
+		ClientTokenAccessor: req.ClientTokenAccessor,
+		EntityID:            req.EntityID,
+	}
+
+	var out *response
+	if err := b.call(ctx, req.Storage, "vault_handle_request", in, &out); err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, nil
+	}
+	if out.Error != "" {
+		return logical.ErrorResponse(out.Error), nil
+	}
+
+	resp := &logical.Response{
+		Data: out.Data,
+	}
+	for _, w := range out.Warnings {
+		resp.AddWarning(w)
+	}
+	return resp, nil
+}
+
+// HandleExistenceCheck always reports that no existence check is available,
+// so create and update requests are both handled as update operations.
+func (b *Backend) HandleExistenceCheck(context.Context, *logical.Request) (bool, bool, error) {
+	return false, false, nil
+}
+
+func (b *Backend) Initialize(ctx context.Context, req *logical.InitializationRequest) error {
+	if !b.hasExport("vault_initialize") {
+		return nil
+	}
+	return b.call(ctx, req.Storage, "vault_initialize", nil, nil)
+}
+
+func (b *Backend) InvalidateKey(ctx context.Context, key string) {
+	if !b.hasExport("vault_invalidate") {
+		return
+	}
+	if err := b.call(ctx, nil, "vault_invalidate", key, nil); err != nil {
+		b.logger.Error("failed to invalidate key", "key", key, "error", err)
+	}
+}
+
+func (b *Backend) Setup(_ context.Context, conf *logical.BackendConfig) error {
+	b.config = conf
+	return nil
+}
+
+// Cleanup closes the module and releases the resources of the runtime.
+func (b *Backend) Cleanup(ctx context.Context) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	b.module = nil
+	if err := b.runtime.Close(ctx); err != nil {
+		b.logger.Warn("failed to close webassembly runtime", "error", err)
+	}
+}
+
+func (b *Backend) SpecialPaths() *logical.Paths {
+	return b.paths
+}
+
+func (b *Backend) System() logical.SystemView {
+	return b.config.System
+}
+
+func (b *Backend) Logger() log.Logger {
+	return b.logger
+}
+
+func (b *Backend) Type() logical.BackendType {
+	return b.backendType
+}
+
+func (b *Backend) IsExternal() bool {
+	return true
+}
+
+// PluginVersion returns the version the plugin was registered with, since
+// modules do not report their own version.
+func (b *Backend) PluginVersion() logical.PluginVersion {
+	return logical.PluginVersion{Version: b.runner.Version}
+}
+
+// writeGuest copies buf into memory allocated by the module and returns its
+// pointer.
+func writeGuest(ctx context.Context, module api.Module, buf []byte) (uint32, error) {
+	results, err := module.ExportedFunction("vault_alloc").Call(ctx, uint64(len(buf)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate webassembly module memory: %w", err)
+	}
+	ptr := uint32(results[0])
+	if !module.Memory().Write(ptr, buf) {
+		return 0, errors.New("webassembly module allocated out of range memory")
+	}
+	return ptr, nil
+}
+
+// freeGuest releases memory of the module, if the module supports it.
+func freeGuest(ctx context.Context, module api.Module, ptr, size uint32) {
+	if fn := module.ExportedFunction("vault_free"); fn != nil && !module.IsClosed() {
+		fn.Call(ctx, uint64(ptr), uint64(size))
+	}
+}
+
+func pack(ptr, size uint32) uint64 {
+	return uint64(ptr)<<32 | uint64(size)
+}
+
+func unpack(v uint64) (uint32, uint32) {
+	return uint32(v >> 32), uint32(v)
+}
+
+// logWriter sends each line written to it to the logger.
+type logWriter struct {
+	logger log.Logger
+	level  log.Level
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		w.logger.Log(w.level, string(line))
+	}
+	return len(p), nil
+}
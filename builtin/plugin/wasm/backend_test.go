@@ -0,0 +1,282 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package wasm
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/pluginutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+var (
+	compileOnce sync.Once
+	compiled    []byte
+	compileErr  error
+)
+
+// testModule compiles the module in testdata/kv and writes it to a temporary
+// directory. The test is skipped if the toolchain can't build it.
+func testModule(t *testing.T) (string, []byte) {
+	t.Helper()
+
+	compileOnce.Do(func() {
+		out := filepath.Join(os.TempDir(), "vault-wasm-kv-test.wasm")
+		cmd := exec.Command("go", "build", "-buildmode=c-shared", "-o", out, ".")
+		cmd.Dir = filepath.Join("testdata", "kv")
+		cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm", "CGO_ENABLED=0")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			compileErr = fmt.Errorf("%w: %s", err, output)
+			return
+		}
+		compiled, compileErr = os.ReadFile(out)
+		os.Remove(out)
+	})
+	if compileErr != nil {
+		t.Skipf("failed to compile webassembly test module: %s", compileErr)
+	}
+
+	path := filepath.Join(t.TempDir(), "kv.wasm")
+	if err := os.WriteFile(path, compiled, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(compiled)
+	return path, sum[:]
+}
+
+func testBackend(t *testing.T, env ...string) *Backend {
+	t.Helper()
+
+	path, sum := testModule(t)
+	runner := &pluginutil.PluginRunner{
+		Name:    "kv-wasm",
+		Type:    consts.PluginTypeSecrets,
+		Version: "v1.0.0",
+		Command: path,
+		Env:     env,
+		Sha256:  sum,
+	}
+	if !runner.IsWASM() {
+		t.Fatal("expected runner to be a webassembly plugin")
+	}
+
+	b, err := NewBackend(context.Background(), runner, &logical.BackendConfig{
+		Logger: log.NewNullLogger(),
+		System: logical.TestSystemView(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Cleanup(context.Background()) })
+	return b
+}
+
+func TestBackend_Storage(t *testing.T) {
+	b := testBackend(t)
+	storage := &logical.InmemStorage{}
+	ctx := context.Background()
+
+	if b.Type() != logical.TypeLogical {
+		t.Fatalf("bad type: %v", b.Type())
+	}
+	if paths := b.SpecialPaths(); len(paths.Root) != 1 || paths.Root[0] != "root/*" {
+		t.Fatalf("bad special paths: %#v", paths)
+	}
+	if v := b.PluginVersion().Version; v != "v1.0.0" {
+		t.Fatalf("bad version: %q", v)
+	}
+
+	resp, err := b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "foo",
+		Data:      map[string]interface{}{"bar": "baz"},
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Warnings) != 1 || resp.Warnings[0] != "served by wasm" {
+		t.Fatalf("bad warnings: %#v", resp.Warnings)
+	}
+
+	entry, err := storage.Get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil || string(entry.Value) != `{"bar":"baz"}` {
+		t.Fatalf("bad entry: %#v", entry)
+	}
+
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ListOperation,
+		Path:      "",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys, ok := resp.Data["keys"].([]interface{}); !ok || len(keys) != 1 || keys[0] != "foo" {
+		t.Fatalf("bad list response: %#v", resp.Data)
+	}
+
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.DeleteOperation,
+		Path:      "foo",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "foo",
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != nil {
+		t.Fatalf("expected no response for deleted key, got: %#v", resp)
+	}
+}
+
+func TestBackend_RecoversFromTrap(t *testing.T) {
+	b := testBackend(t)
+	storage := &logical.InmemStorage{}
+
+	_, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "trap",
+		Storage:   storage,
+	})
+	if err == nil {
+		t.Fatal("expected error from trapped module")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = b.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "spin",
+		Storage:   storage,
+	})
+	if err == nil {
+		t.Fatal("expected error from module exceeding the request deadline")
+	}
+
+	// The module is instantiated again for the next request
+	_, err = b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "foo",
+		Data:      map[string]interface{}{"bar": "baz"},
+		Storage:   storage,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackend_HTTPEgress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(b *Backend) *logical.Response {
+		t.Helper()
+		resp, err := b.HandleRequest(context.Background(), &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "http",
+			Data:      map[string]interface{}{"url": srv.URL},
+			Storage:   &logical.InmemStorage{},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := request(testBackend(t))
+	if !resp.IsError() || !strings.Contains(resp.Error().Error(), "not allowed") {
+		t.Fatalf("expected egress to be denied by default, got: %#v", resp)
+	}
+
+	resp = request(testBackend(t, EnvHTTPAllowedHosts+"="+u.Hostname()))
+	if resp.IsError() {
+		t.Fatal(resp.Error())
+	}
+	if resp.Data["status_code"] != float64(http.StatusOK) || resp.Data["body"] != "aGVsbG8=" {
+		t.Fatalf("bad response: %#v", resp.Data)
+	}
+}
+
+func TestBackend_ChecksumMismatch(t *testing.T) {
+	path, _ := testModule(t)
+	_, err := NewBackend(context.Background(), &pluginutil.PluginRunner{
+		Name:    "kv-wasm",
+		Type:    consts.PluginTypeSecrets,
+		Command: path,
+		Sha256:  []byte("bad"),
+	}, &logical.BackendConfig{
+		Logger: log.NewNullLogger(),
+	})
+	if err == nil || !strings.Contains(err.Error(), "checksums did not match") {
+		t.Fatalf("expected checksum error, got: %v", err)
+	}
+}
+
+func TestEgressPolicy(t *testing.T) {
+	p, err := newEgressPolicy("example.com, *.hashicorp.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for raw, allowed := range map[string]bool{
+		"https://example.com/foo":         true,
+		"http://EXAMPLE.com:8080":         true,
+		"https://sub.example.com":         false,
+		"https://api.hashicorp.com":       true,
+		"https://hashicorp.com":           false,
+		"https://evilhashicorp.com":       false,
+		"ftp://example.com":               false,
+		"https://example.com.evil.com/x":  false,
+		"https://releases.hashicorp.com/": true,
+		"https://a.b.hashicorp.com":       true,
+	} {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.check(u); (err == nil) != allowed {
+			t.Fatalf("%s: expected allowed %t, got error: %v", raw, allowed, err)
+		}
+	}
+
+	// Wildcards must lead the host and be followed by a dot
+	for _, hosts := range []string{"*example.com", "api.*.example.com", "*", "*.*.example.com", "example.*"} {
+		if _, err := newEgressPolicy(hosts); err == nil {
+			t.Fatalf("%s: expected error", hosts)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/tetratelabs/wazero/api"
+)
+
+const (
+	// hostModuleName is the name of the module the host API is imported from.
+	hostModuleName = "vault"
+
+	// maxHTTPBodySize is the largest HTTP response body returned to a module.
+	maxHTTPBodySize = 10 << 20
+
+	// httpTimeout bounds the duration of HTTP requests made by a module.
+	httpTimeout = 30 * time.Second
+)
+
+// Host operations available through host_call.
+const (
+	opStorageGet    = "storage.get"
+	opStoragePut    = "storage.put"
+	opStorageDelete = "storage.delete"
+	opStorageList   = "storage.list"
+	opHTTPRequest   = "http.request"
+)
+
+var errStorageUnavailable = errors.New("storage is not available outside of requests")
+
+type callStateKey struct{}
+
+// callState holds the request scoped state the host API operates on.
+type callState struct {
+	storage logical.Storage
+}
+
+// hostRequest is the document passed to host_call.
+type hostRequest struct {
+	Op string `json:"op"`
+
+	// Storage operations
+	Key    string `json:"key,omitempty"`
+	Value  []byte `json:"value,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+
+	// HTTP requests
+	Method  string            `json:"method,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    []byte            `json:"body,omitempty"`
+}
+
+// hostResponse is the document host_call returns to the module.
+type hostResponse struct {
+	Error string `json:"error,omitempty"`
+
+	// Storage operations
+	Value []byte   `json:"value,omitempty"`
+	Found bool     `json:"found,omitempty"`
+	Keys  []string `json:"keys,omitempty"`
+
+	// HTTP requests
+	StatusCode int               `json:"status_code,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       []byte            `json:"body,omitempty"`
+}
+
+// hostCall is imported by modules as vault.host_call(ptr u32, len u32) u64.
+// It performs the operation described by the JSON document at ptr and returns
+// a reference to the JSON document holding the result.
+func (b *Backend) hostCall(ctx context.Context, module api.Module, ptr, size uint32) uint64 {
+	resp := &hostResponse{}
+
+	buf, ok := module.Memory().Read(ptr, size)
+	if !ok {
+		resp.Error = "request out of range of memory"
+		return b.hostRespond(ctx, module, resp)
+	}
+
+	var req hostRequest
+	if err := json.Unmarshal(buf, &req); err != nil {
+		resp.Error = fmt.Sprintf("failed to decode request: %s", err)
+		return b.hostRespond(ctx, module, resp)
+	}
+
+	var err error
+	switch req.Op {
+	case opStorageGet, opStoragePut, opStorageDelete, opStorageList:
+		err = b.hostStorage(ctx, &req, resp)
+	case opHTTPRequest:
+		err = b.hostHTTP(ctx, &req, resp)
+	default:
+		err = fmt.Errorf("unknown operation %q", req.Op)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	return b.hostRespond(ctx, module, resp)
+}
+
+// hostRespond writes resp to the memory of the module.
+func (b *Backend) hostRespond(ctx context.Context, module api.Module, resp *hostResponse) uint64 {
+	buf, err := json.Marshal(resp)
+	if err != nil {
+		b.logger.Error("failed to encode host call response", "error", err)
+		return 0
+	}
+	ptr, err := writeGuest(ctx, module, buf)
+	if err != nil {
+		b.logger.Error("failed to write host call response", "error", err)
+		return 0
+	}
+	return pack(ptr, uint32(len(buf)))
+}
+
+func (b *Backend) hostStorage(ctx context.Context, req *hostRequest, resp *hostResponse) error {
+	state, ok := ctx.Value(callStateKey{}).(*callState)
+	if !ok || state.storage == nil {
+		return errStorageUnavailable
+	}
+
+	switch req.Op {
+	case opStorageGet:
+		entry, err := state.storage.Get(ctx, req.Key)
+		if err != nil {
+			return err
+		}
+		if entry != nil {
+			resp.Found = true
+			resp.Value = entry.Value
+		}
+	case opStoragePut:
+		return state.storage.Put(ctx, &logical.StorageEntry{
+			Key:   req.Key,
+			Value: req.Value,
+		})
+	case opStorageDelete:
+		return state.storage.Delete(ctx, req.Key)
+	case opStorageList:
+		keys, err := state.storage.List(ctx, req.Prefix)
+		if err != nil {
+			return err
+		}
+		resp.Keys = keys
+	}
+
+	return nil
+}
+
+func (b *Backend) hostHTTP(ctx context.Context, req *hostRequest, resp *hostResponse) error {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return err
+	}
+	if err := b.egress.check(u); err != nil {
+		return err
+	}
+
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, httpTimeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(req.Body))
+	if err != nil {
+		return err
+	}
+	for k, v := range req.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	httpResp, err := b.egress.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, maxHTTPBodySize+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > maxHTTPBodySize {
+		return fmt.Errorf("response body exceeds %d bytes", maxHTTPBodySize)
+	}
+
+	resp.StatusCode = httpResp.StatusCode
+	resp.Body = body
+	resp.Headers = make(map[string]string, len(httpResp.Header))
+	for k := range httpResp.Header {
+		resp.Headers[k] = httpResp.Header.Get(k)
+	}
+
+	return nil
+}
+
+// hostLog is imported by modules as vault.log(level u32, ptr u32, len u32).
+// Levels follow hclog, from 1 (trace) to 5 (error).
+func (b *Backend) hostLog(_ context.Context, module api.Module, level, ptr, size uint32) {
+	buf, ok := module.Memory().Read(ptr, size)
+	if !ok {
+		return
+	}
+	lvl := log.Level(level)
+	if lvl < log.Trace || lvl > log.Error {
+		lvl = log.Info
+	}
+	b.logger.Log(lvl, string(buf))
+}
+
+// egressPolicy decides which hosts a module may send HTTP requests to.
+type egressPolicy struct {
+	hosts  []string
+	client *http.Client
+}
+
+// newEgressPolicy returns a policy allowing the comma separated hosts. A
+// wildcard is only allowed as the leading label, as in "*.example.com", so
+// that it cannot match hosts such as "evilexample.com".
+func newEgressPolicy(allowedHosts string) (*egressPolicy, error) {
+	p := &egressPolicy{}
+	for _, h := range strings.Split(allowedHosts, ",") {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h == "" {
+			continue
+		}
+		if strings.Contains(strings.TrimPrefix(h, "*."), "*") {
+			return nil, fmt.Errorf("invalid allowed host %q: wildcards are only allowed as the leading label, as in %q", h, "*.example.com")
+		}
+		p.hosts = append(p.hosts, h)
+	}
+
+	p.client = cleanhttp.DefaultPooledClient()
+	p.client.Timeout = httpTimeout
+	p.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return p.check(req.URL)
+	}
+
+	return p, nil
+}
+
+// check returns an error if requests to u are not allowed.
+func (p *egressPolicy) check(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported url scheme %q", u.Scheme)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range p.hosts {
+		// Only subdomains match, not hosts merely ending with the domain
+		if domain, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+domain) {
+				return nil
+			}
+			continue
+		}
+		if host == allowed {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("requests to host %q are not allowed", host)
+}
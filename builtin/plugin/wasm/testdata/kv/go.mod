@@ -0,0 +1,3 @@
+module github.com/hashicorp/vault/builtin/plugin/wasm/testdata/kv
+
+go 1.24
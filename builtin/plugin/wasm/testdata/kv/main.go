@@ -0,0 +1,123 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Command kv is a minimal key/value secrets engine compiled to WebAssembly,
+// used to test the WebAssembly plugin runtime. Build it with:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o kv.wasm .
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"unsafe"
+)
+
+//go:wasmimport vault host_call
+func hostCall(ptr, size uint32) uint64
+
+//go:wasmimport vault log
+func hostLog(level, ptr, size uint32)
+
+// allocations keeps memory handed to the host alive until it is freed.
+var allocations = map[uint32][]byte{}
+
+//go:wasmexport vault_alloc
+func alloc(size uint32) uint32 {
+	buf := make([]byte, size+1)
+	ptr := uint32(uintptr(unsafe.Pointer(&buf[0])))
+	allocations[ptr] = buf
+	return ptr
+}
+
+//go:wasmexport vault_free
+func free(ptr, _ uint32) {
+	delete(allocations, ptr)
+}
+
+func read(ptr, size uint32) []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(uintptr(ptr))), size)
+}
+
+func write(v interface{}) uint64 {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	ptr := alloc(uint32(len(buf)))
+	copy(read(ptr, uint32(len(buf))), buf)
+	return uint64(ptr)<<32 | uint64(len(buf))
+}
+
+func call(req map[string]interface{}) map[string]interface{} {
+	buf, _ := json.Marshal(req)
+	ret := hostCall(uint32(uintptr(unsafe.Pointer(&buf[0]))), uint32(len(buf)))
+	ptr, size := uint32(ret>>32), uint32(ret)
+	var resp map[string]interface{}
+	if err := json.Unmarshal(read(ptr, size), &resp); err != nil {
+		panic(err)
+	}
+	free(ptr, size)
+	return resp
+}
+
+func logInfo(msg string) {
+	buf := []byte(msg)
+	hostLog(3, uint32(uintptr(unsafe.Pointer(&buf[0]))), uint32(len(buf)))
+}
+
+//go:wasmexport vault_special_paths
+func specialPaths() uint64 {
+	return write(map[string]interface{}{
+		"root": []string{"root/*"},
+	})
+}
+
+//go:wasmexport vault_handle_request
+func handleRequest(ptr, size uint32) uint64 {
+	var req struct {
+		Operation string                 `json:"operation"`
+		Path      string                 `json:"path"`
+		Data      map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(read(ptr, size), &req); err != nil {
+		return write(map[string]interface{}{"error": err.Error()})
+	}
+	logInfo("handling " + req.Operation + " " + req.Path)
+
+	var resp map[string]interface{}
+	switch {
+	case req.Path == "http":
+		resp = call(map[string]interface{}{"op": "http.request", "url": req.Data["url"]})
+	case req.Path == "trap":
+		panic("trap")
+	case req.Path == "spin":
+		for {
+		}
+	case req.Operation == "list":
+		resp = call(map[string]interface{}{"op": "storage.list", "prefix": ""})
+	case req.Operation == "read":
+		resp = call(map[string]interface{}{"op": "storage.get", "key": req.Path})
+		if resp["found"] != true && resp["error"] == nil {
+			return 0
+		}
+	case req.Operation == "update" || req.Operation == "create":
+		value, _ := json.Marshal(req.Data)
+		resp = call(map[string]interface{}{"op": "storage.put", "key": req.Path, "value": value})
+	case req.Operation == "delete":
+		resp = call(map[string]interface{}{"op": "storage.delete", "key": req.Path})
+	default:
+		return write(map[string]interface{}{"error": "unsupported operation " + strings.ToLower(req.Operation)})
+	}
+
+	if msg, ok := resp["error"].(string); ok {
+		return write(map[string]interface{}{"error": msg})
+	}
+	delete(resp, "headers")
+	return write(map[string]interface{}{
+		"data":     resp,
+		"warnings": []string{"served by wasm"},
+	})
+}
+
+func main() {}
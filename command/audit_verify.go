@@ -0,0 +1,185 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/audit"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*AuditVerifyCommand)(nil)
+	_ cli.CommandAutocomplete = (*AuditVerifyCommand)(nil)
+)
+
+type AuditVerifyCommand struct {
+	*BaseCommand
+
+	flagPrefix         string
+	flagSkipSignatures bool
+}
+
+func (c *AuditVerifyCommand) Synopsis() string {
+	return "Verifies the hash chain of audit log files"
+}
+
+func (c *AuditVerifyCommand) Help() string {
+	helpText := `
+Usage: vault audit verify [options] FILE...
+
+  Verifies the hash chain of audit log files written by a file audit device
+  with "hash_chain" enabled. Each entry must record the hash of the entry
+  written before it, so modified, removed or reordered entries are detected.
+  Rotated files must be given in the order they were written.
+
+  The signatures of the checkpoints in the chain are verified with the transit
+  key which signed them, unless -skip-signatures is given. This requires a
+  token allowed to update the "verify" path of the key.
+
+  Verify the current audit log and the file it was rotated from:
+
+      $ vault audit verify /var/log/vault_audit.log.1 /var/log/vault_audit.log
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AuditVerifyCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "prefix",
+		Target:     &c.flagPrefix,
+		Completion: complete.PredictAnything,
+		Usage:      "Prefix configured for the entries of the audit device.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "skip-signatures",
+		Target:  &c.flagSkipSignatures,
+		Default: false,
+		Usage: "Verify the hash chain without verifying the signatures of its " +
+			"checkpoints with Vault.",
+	})
+
+	return set
+}
+
+func (c *AuditVerifyCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *AuditVerifyCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *AuditVerifyCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) < 1 {
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected at least 1, got %d)", len(args)))
+		return 1
+	}
+
+	verifier := &audit.HashChainVerifier{Prefix: c.flagPrefix}
+	for _, path := range args {
+		if err := c.verifyFile(verifier, path); err != nil {
+			c.UI.Error(fmt.Sprintf("Error verifying %s: %s", path, err))
+			return 2
+		}
+	}
+
+	if verifier.First == nil {
+		c.UI.Error("Error verifying audit logs: no entries found")
+		return 2
+	}
+
+	var unsigned int
+	for _, checkpoint := range verifier.Checkpoints {
+		if checkpoint.Signature == "" {
+			unsigned++
+			c.UI.Warn(fmt.Sprintf("Checkpoint of entry %d is not signed: %s", checkpoint.Sequence, checkpoint.Error))
+			continue
+		}
+		if c.flagSkipSignatures {
+			continue
+		}
+
+		if err := c.verifySignature(checkpoint); err != nil {
+			c.UI.Error(fmt.Sprintf("Error verifying checkpoint of entry %d: %s", checkpoint.Sequence, err))
+			return 2
+		}
+	}
+
+	if verifier.First.Sequence != 1 {
+		c.UI.Warn(fmt.Sprintf("The logs start at entry %d of the chain, after the entry with hash %s. "+
+			"Verify them with the files written before them to ensure no entries were removed.",
+			verifier.First.Sequence, verifier.First.Previous))
+	}
+	if len(verifier.Checkpoints) == 0 {
+		c.UI.Warn("The logs have no checkpoints, so the chain could have been rewritten from its start.")
+	}
+
+	return OutputData(c.UI, map[string]interface{}{
+		"entries":              verifier.Entries,
+		"first_entry":          verifier.First.Sequence,
+		"last_entry":           verifier.Sequence,
+		"last_hash":            verifier.Hash,
+		"checkpoints":          len(verifier.Checkpoints),
+		"unsigned_checkpoints": unsigned,
+		"signatures_verified":  !c.flagSkipSignatures,
+	})
+}
+
+// verifyFile verifies that the entries of the file continue the hash chain.
+func (c *AuditVerifyCommand) verifyFile(verifier *audit.HashChainVerifier, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return verifier.Verify(f)
+}
+
+// verifySignature verifies the signature of the checkpoint with the transit key
+// which signed it.
+func (c *AuditVerifyCommand) verifySignature(checkpoint *audit.Checkpoint) error {
+	client, err := c.Client()
+	if err != nil {
+		return err
+	}
+
+	secret, err := client.Logical().Write(checkpoint.Mount+"/verify/"+checkpoint.Key, map[string]interface{}{
+		"input":     base64.StdEncoding.EncodeToString(checkpoint.SignedInput()),
+		"signature": checkpoint.Signature,
+	})
+	switch {
+	case err != nil:
+		return err
+	case secret == nil || secret.Data == nil:
+		return fmt.Errorf("no response verifying the signature with key %q of %s/", checkpoint.Key, checkpoint.Mount)
+	}
+
+	if valid, _ := secret.Data["valid"].(bool); !valid {
+		return fmt.Errorf("the signature is not valid for key %q of %s/", checkpoint.Key, checkpoint.Mount)
+	}
+
+	return nil
+}
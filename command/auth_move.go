@@ -30,15 +30,16 @@ func (c *AuthMoveCommand) Help() string {
 Usage: vault auth move [options] SOURCE DESTINATION
 
   Moves an existing auth method to a new path. Any leases from the old
-  auth method are revoked, but all configuration associated with the method
-  is preserved. It initiates the migration and intermittently polls its status,
+  auth method are moved with it when staying in the same namespace, and
+  revoked otherwise. All configuration associated with the method is
+  preserved. It initiates the migration and intermittently polls its status,
   exiting if a final state is reached.
 
   This command works within or across namespaces, both source and destination paths
   can be prefixed with a namespace heirarchy relative to the current namespace.
 
-  WARNING! Moving an auth method will revoke any leases from the
-  old method.
+  WARNING! Moving an auth method to another namespace will revoke any
+  leases from the old method.
 
   Move the auth method at approle/ to generic/:
 
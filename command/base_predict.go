@@ -170,10 +170,12 @@ func (b *BaseCommand) PredictVaultPolicies() complete.Predictor {
 func (b *BaseCommand) PredictVaultDebugTargets() complete.Predictor {
 	return complete.PredictSet(
 		"config",
+		"expiration",
 		"host",
 		"metrics",
 		"pprof",
 		"replication-status",
+		"rotation",
 		"server-status",
 	)
 }
@@ -305,6 +305,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"audit verify": func() (cli.Command, error) {
+			return &AuditVerifyCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"auth tune": func() (cli.Command, error) {
 			return &AuthTuneCommand{
 				BaseCommand: getBaseCommand(),
@@ -485,6 +490,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator raft inspect": func() (cli.Command, error) {
+			return &OperatorRaftInspectCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator raft join": func() (cli.Command, error) {
 			return &OperatorRaftJoinCommand{
 				BaseCommand: getBaseCommand(),
@@ -545,11 +555,21 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator submit-shares": func() (cli.Command, error) {
+			return &OperatorSubmitSharesCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator unseal": func() (cli.Command, error) {
 			return &OperatorUnsealCommand{
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator unseal-delegated": func() (cli.Command, error) {
+			return &OperatorUnsealDelegatedCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator members": func() (cli.Command, error) {
 			return &OperatorMembersCommand{
 				BaseCommand: getBaseCommand(),
@@ -729,6 +749,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"secrets import": func() (cli.Command, error) {
+			return &SecretsImportCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"secrets move": func() (cli.Command, error) {
 			return &SecretsMoveCommand{
 				BaseCommand: getBaseCommand(),
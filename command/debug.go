@@ -117,6 +117,8 @@ type DebugCommand struct {
 	replicationStatusCollection []map[string]interface{}
 	serverStatusCollection      []map[string]interface{}
 	inFlightReqStatusCollection []map[string]interface{}
+	expirationCollection        []map[string]interface{}
+	rotationCollection          []map[string]interface{}
 
 	// cachedClient holds the client retrieved during preflight
 	cachedClient *api.Client
@@ -182,8 +184,8 @@ func (c *DebugCommand) Flags() *FlagSets {
 		Target: &c.flagTargets,
 		Usage: "Target to capture, defaulting to all if none specified. " +
 			"This can be specified multiple times to capture multiple targets. " +
-			"Available targets are: config, host, metrics, pprof, " +
-			"replication-status, server-status, log.",
+			"Available targets are: config, expiration, host, metrics, pprof, " +
+			"replication-status, requests, rotation, server-status, log.",
 	})
 
 	f.StringVar(&StringVar{
@@ -512,7 +514,7 @@ func (c *DebugCommand) preflight(rawArgs []string) (string, error) {
 }
 
 func (c *DebugCommand) defaultTargets() []string {
-	return []string{"config", "host", "requests", "metrics", "pprof", "replication-status", "server-status", "log"}
+	return []string{"config", "expiration", "host", "requests", "metrics", "pprof", "replication-status", "rotation", "server-status", "log"}
 }
 
 func (c *DebugCommand) validDRSecondaryTargets() []string {
@@ -627,6 +629,26 @@ func (c *DebugCommand) capturePollingTargets() error {
 		})
 	}
 
+	// Collect expiration queue state if target is specified
+	if strutil.StrListContains(c.flagTargets, "expiration") {
+		g.Add(func() error {
+			c.collectExpirationQueue(ctx)
+			return nil
+		}, func(error) {
+			cancelFunc()
+		})
+	}
+
+	// Collect rotation jobs if target is specified
+	if strutil.StrListContains(c.flagTargets, "rotation") {
+		g.Add(func() error {
+			c.collectRotationJobs(ctx)
+			return nil
+		}, func(error) {
+			cancelFunc()
+		})
+	}
+
 	if strutil.StrListContains(c.flagTargets, "log") {
 		g.Add(func() error {
 			c.writeLogs(ctx)
@@ -661,6 +683,12 @@ func (c *DebugCommand) capturePollingTargets() error {
 	if err := c.persistCollection(c.inFlightReqStatusCollection, "requests.json"); err != nil {
 		c.UI.Error(fmt.Sprintf("Error writing data to %s: %v", "requests.json", err))
 	}
+	if err := c.persistCollection(c.expirationCollection, "expiration.json"); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing data to %s: %v", "expiration.json", err))
+	}
+	if err := c.persistCollection(c.rotationCollection, "rotation.json"); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing data to %s: %v", "rotation.json", err))
+	}
 	return nil
 }
 
@@ -959,6 +987,80 @@ func (c *DebugCommand) collectInFlightRequestStatus(ctx context.Context) {
 	}
 }
 
+// collectExpirationQueue polls the expiration manager's queue state, which
+// includes a histogram of pending leases by time until expiration and the
+// length of the revocation queue.
+func (c *DebugCommand) collectExpirationQueue(ctx context.Context) {
+	idxCount := 0
+	intervalTicker := time.Tick(c.flagInterval)
+
+	for {
+		if idxCount > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-intervalTicker:
+			}
+		}
+
+		c.logger.Info("capturing expiration queue state", "count", idxCount)
+		idxCount++
+
+		secret, err := c.cachedClient.Logical().ReadWithDataWithContext(ctx, "sys/leases/queue", map[string][]string{
+			"include_child_namespaces": {"true"},
+		})
+		if err != nil {
+			c.captureError("expiration", err)
+			return
+		}
+
+		if secret != nil && secret.Data != nil {
+			statusEntry := map[string]interface{}{
+				"timestamp":  time.Now().UTC(),
+				"expiration": secret.Data,
+			}
+			c.expirationCollection = append(c.expirationCollection, statusEntry)
+		}
+	}
+}
+
+// collectRotationJobs polls the root credentials registered for scheduled
+// rotation, which includes the next rotation time of each of them.
+func (c *DebugCommand) collectRotationJobs(ctx context.Context) {
+	idxCount := 0
+	intervalTicker := time.Tick(c.flagInterval)
+
+	for {
+		if idxCount > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-intervalTicker:
+			}
+		}
+
+		c.logger.Info("capturing rotation jobs", "count", idxCount)
+		idxCount++
+
+		secret, err := c.cachedClient.Logical().ListWithContext(ctx, "sys/rotation/jobs")
+		if err != nil {
+			c.captureError("rotation", err)
+			return
+		}
+
+		// No jobs are returned if no credential is registered for rotation
+		data := map[string]interface{}{}
+		if secret != nil && secret.Data != nil {
+			data = secret.Data
+		}
+		statusEntry := map[string]interface{}{
+			"timestamp": time.Now().UTC(),
+			"rotation":  data,
+		}
+		c.rotationCollection = append(c.rotationCollection, statusEntry)
+	}
+}
+
 // persistCollection writes the collected data for a particular target onto the
 // specified file. If the collection is empty, it returns immediately.
 func (c *DebugCommand) persistCollection(collection []map[string]interface{}, outFile string) error {
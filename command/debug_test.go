@@ -245,6 +245,16 @@ func TestDebugCommand_CaptureTargets(t *testing.T) {
 			[]string{"requests"},
 			[]string{"requests.json"},
 		},
+		{
+			"expiration",
+			[]string{"expiration"},
+			[]string{"expiration.json"},
+		},
+		{
+			"rotation",
+			[]string{"rotation"},
+			[]string{"rotation.json"},
+		},
 		{
 			"all-minus-pprof",
 			[]string{"config", "host", "metrics", "replication-status", "server-status"},
@@ -27,6 +27,10 @@ type EventsSubscribeCommands struct {
 
 	namespaces  []string
 	bexprFilter string
+	path        string
+	mountType   string
+	metadata    map[string]string
+	cursor      string
 }
 
 func (c *EventsSubscribeCommands) Synopsis() string {
@@ -35,7 +39,7 @@ func (c *EventsSubscribeCommands) Synopsis() string {
 
 func (c *EventsSubscribeCommands) Help() string {
 	helpText := `
-Usage: vault events subscribe [-namespaces=ns1] [-timeout=XYZs] [-filter=filterExpression] eventType
+Usage: vault events subscribe [-namespaces=ns1] [-timeout=XYZs] [-filter=filterExpression] [-path=pathGlob] [-mount-type=type] [-metadata=key=value] [-cursor=eventID] eventType
 
   Subscribe to events of the given event type (topic), which may be a glob
   pattern (with "*" treated as a wildcard). The events will be sent to
@@ -70,6 +74,36 @@ func (c *EventsSubscribeCommands) Flags() *FlagSets {
 		Default: []string{},
 		Target:  &c.namespaces,
 	})
+	f.StringVar(&StringVar{
+		Name: "path",
+		Usage: `A pattern of the data paths of the events to subscribe to, e.g.
+                'secret/data/app/*'. Patterns can include "*" characters to
+                indicate wildcards.`,
+		Default: "",
+		Target:  &c.path,
+	})
+	f.StringVar(&StringVar{
+		Name: "mount-type",
+		Usage: `The type of the plugin, e.g. 'kv', of the mounts whose events
+                to subscribe to.`,
+		Default: "",
+		Target:  &c.mountType,
+	})
+	f.StringMapVar(&StringMapVar{
+		Name:       "metadata",
+		Target:     &c.metadata,
+		Completion: complete.PredictAnything,
+		Usage: `Key-value pair provided as key=value that the metadata of the
+                events to subscribe to must contain. This can be specified
+                multiple times.`,
+	})
+	f.StringVar(&StringVar{
+		Name: "cursor",
+		Usage: `The ID of a recent event. The events which followed it are
+                replayed before new events are received.`,
+		Default: "",
+		Target:  &c.cursor,
+	})
 	return set
 }
 
@@ -146,6 +180,18 @@ func (c *EventsSubscribeCommands) subscribeRequest(client *api.Client, path stri
 	if bexprFilter != "" {
 		q.Set("filter", bexprFilter)
 	}
+	if c.path != "" {
+		q.Set("path", c.path)
+	}
+	if c.mountType != "" {
+		q.Set("mount_type", c.mountType)
+	}
+	for key, value := range c.metadata {
+		q.Add("metadata", key+"="+value)
+	}
+	if c.cursor != "" {
+		q.Set("cursor", c.cursor)
+	}
 	u.RawQuery = q.Encode()
 	client.AddHeader("X-Vault-Token", client.Token())
 	client.AddHeader("X-Vault-Namespace", client.Namespace())
@@ -5,6 +5,7 @@ package command
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -41,6 +42,7 @@ type OperatorGenerateRootCommand struct {
 	flagOTP           string
 	flagPGPKey        string
 	flagNonce         string
+	flagJustification string
 	flagGenerateOTP   bool
 	flagDRToken       bool
 	flagRecoveryToken bool
@@ -99,6 +101,12 @@ Usage: vault operator generate-root [options] -init [-otp=...] [-pgp-key=...]
 
             $ vault operator generate-root -init -pgp-key="..."
 
+    If the server requires a justification for root generation, provide one
+    with "-justification". It is recorded in the audit log and in the
+    metadata of the generated token:
+
+            $ vault operator generate-root -init -justification="INC-1234"
+
   Form 2 (no option) - Enter an unseal key to progress root token generation:
 
     In the sub-form intended for interactive use, the command will
@@ -254,6 +262,17 @@ func (c *OperatorGenerateRootCommand) Flags() *FlagSets {
 			"when providing an unseal or recovery key.",
 	})
 
+	f.StringVar(&StringVar{
+		Name:       "justification",
+		Target:     &c.flagJustification,
+		Default:    "",
+		EnvVar:     "",
+		Completion: complete.PredictAnything,
+		Usage: "Reason for the token generation, recorded in the audit log " +
+			"and the metadata of the generated token. Required if the server " +
+			"requires a justification. Must be used with \"-init\".",
+	})
+
 	return set
 }
 
@@ -319,7 +338,7 @@ func (c *OperatorGenerateRootCommand) Run(args []string) int {
 	case c.flagCancel:
 		return c.cancel(client, kind)
 	case c.flagInit:
-		return c.init(client, c.flagOTP, c.flagPGPKey, kind)
+		return c.init(client, c.flagOTP, c.flagPGPKey, c.flagJustification, kind)
 	case c.flagStatus:
 		return c.status(client, kind)
 	default:
@@ -423,7 +442,7 @@ func (c *OperatorGenerateRootCommand) decode(client *api.Client, encoded, otp st
 }
 
 // init is used to start the generation process
-func (c *OperatorGenerateRootCommand) init(client *api.Client, otp, pgpKey string, kind generateRootKind) int {
+func (c *OperatorGenerateRootCommand) init(client *api.Client, otp, pgpKey, justification string, kind generateRootKind) int {
 	// Validate incoming fields. Either OTP OR PGP keys must be supplied.
 	if otp != "" && pgpKey != "" {
 		c.UI.Error("Error initializing: cannot specify both -otp and -pgp-key")
@@ -431,14 +450,18 @@ func (c *OperatorGenerateRootCommand) init(client *api.Client, otp, pgpKey strin
 	}
 
 	// Start the root generation
-	f := client.Sys().GenerateRootInit
+	f := client.Sys().GenerateRootInitWithInput
 	switch kind {
 	case generateRootDR:
-		f = client.Sys().GenerateDROperationTokenInit
+		f = client.Sys().GenerateDROperationTokenInitWithInput
 	case generateRootRecovery:
-		f = client.Sys().GenerateRecoveryOperationTokenInit
+		f = client.Sys().GenerateRecoveryOperationTokenInitWithInput
 	}
-	status, err := f(otp, pgpKey)
+	status, err := f(context.Background(), &api.GenerateRootInitInput{
+		OTP:           otp,
+		PGPKey:        pgpKey,
+		Justification: justification,
+	})
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error initializing root generation: %s", err))
 		return 2
@@ -608,6 +631,11 @@ func (c *OperatorGenerateRootCommand) printStatus(status *api.GenerateRootStatus
 	if status.PGPFingerprint != "" {
 		out = append(out, fmt.Sprintf("PGP Fingerprint | %s", status.PGPFingerprint))
 	}
+	if status.Justification != "" {
+		out = append(out, fmt.Sprintf("Justification | %s", status.Justification))
+	} else if status.JustificationRequired {
+		out = append(out, "Justification Required | true")
+	}
 	switch {
 	case status.EncodedToken != "":
 		out = append(out, fmt.Sprintf("Encoded Token | %s", status.EncodedToken))
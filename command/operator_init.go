@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/cli"
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/helper/x509keys"
 	"github.com/posener/complete"
 
 	consulapi "github.com/hashicorp/consul/api"
@@ -29,12 +30,14 @@ type OperatorInitCommand struct {
 	flagKeyShares       int
 	flagKeyThreshold    int
 	flagPGPKeys         []string
+	flagX509Certs       []string
 	flagRootTokenPGPKey string
 
 	// Auto Unseal
 	flagRecoveryShares    int
 	flagRecoveryThreshold int
 	flagRecoveryPGPKeys   []string
+	flagRecoveryX509Certs []string
 	flagStoredShares      int
 
 	// Consul
@@ -81,6 +84,14 @@ Usage: vault operator init [options]
           -key-threshold=2 \
           -pgp-keys="keybase:hashicorp,keybase:jefferai,keybase:sethvargo"
 
+  Initialize, but encrypt the unseal keys to the public keys of X.509
+  certificates, e.g. of PIV smartcards:
+
+      $ vault operator init \
+          -key-shares=3 \
+          -key-threshold=2 \
+          -x509-certificates="alice.pem,bob.pem,carol.pem"
+
   Encrypt the initial root token using a pgp key:
 
       $ vault operator init -root-token-pgp-key="keybase:hashicorp"
@@ -134,6 +145,19 @@ func (c *OperatorInitCommand) Flags() *FlagSets {
 			"unless -stored-shares are used.",
 	})
 
+	f.VarFlag(&VarFlag{
+		Name:       "x509-certificates",
+		Value:      (*x509keys.CertFilesFlag)(&c.flagX509Certs),
+		Completion: complete.PredictFiles("*.pem"),
+		Usage: "Comma-separated list of paths to files on disk containing " +
+			"PEM-encoded X.509 certificates with RSA or EC public keys, such as " +
+			"those of PIV smartcards. When supplied, the generated unseal keys " +
+			"will be encrypted to the certificates' public keys and " +
+			"base64-encoded in the order specified in this list. The number of " +
+			"entries must match -key-shares. This is mutually exclusive with " +
+			"-pgp-keys.",
+	})
+
 	f.VarFlag(&VarFlag{
 		Name:       "root-token-pgp-key",
 		Value:      (*pgpkeys.PubKeyFileFlag)(&c.flagRootTokenPGPKey),
@@ -206,6 +230,14 @@ func (c *OperatorInitCommand) Flags() *FlagSets {
 			"is only used in Auto Unseal mode.",
 	})
 
+	f.VarFlag(&VarFlag{
+		Name:       "recovery-x509-certificates",
+		Value:      (*x509keys.CertFilesFlag)(&c.flagRecoveryX509Certs),
+		Completion: complete.PredictFiles("*.pem"),
+		Usage: "Behaves like -x509-certificates, but for the recovery key " +
+			"shares. This is only used in Auto Unseal mode.",
+	})
+
 	return set
 }
 
@@ -278,14 +310,16 @@ func (c *OperatorInitCommand) Run(args []string) int {
 
 	// Build the initial init request
 	initReq := &api.InitRequest{
-		SecretShares:    c.flagKeyShares,
-		SecretThreshold: c.flagKeyThreshold,
-		PGPKeys:         c.flagPGPKeys,
-		RootTokenPGPKey: c.flagRootTokenPGPKey,
-
-		RecoveryShares:    c.flagRecoveryShares,
-		RecoveryThreshold: c.flagRecoveryThreshold,
-		RecoveryPGPKeys:   c.flagRecoveryPGPKeys,
+		SecretShares:     c.flagKeyShares,
+		SecretThreshold:  c.flagKeyThreshold,
+		PGPKeys:          c.flagPGPKeys,
+		X509Certificates: c.flagX509Certs,
+		RootTokenPGPKey:  c.flagRootTokenPGPKey,
+
+		RecoveryShares:           c.flagRecoveryShares,
+		RecoveryThreshold:        c.flagRecoveryThreshold,
+		RecoveryPGPKeys:          c.flagRecoveryPGPKeys,
+		RecoveryX509Certificates: c.flagRecoveryX509Certs,
 	}
 
 	// Check auto mode
@@ -507,6 +541,11 @@ func (c *OperatorInitCommand) init(client *api.Client, req *api.InitRequest) int
 			req.RecoveryThreshold)))
 	}
 
+	for _, warning := range resp.Warnings {
+		c.UI.Output("")
+		c.UI.Warn(wrapAtLength("WARNING! " + warning))
+	}
+
 	return 0
 }
 
@@ -43,6 +43,10 @@ Usage: vault operator raft <subcommand> [options] [args]
 
       $ vault operator raft snapshot save out.snap
 
+  Inspects a snapshot or the data directory of a stopped server offline:
+
+      $ vault operator raft inspect out.snap
+
   Please see the individual subcommand help for detailed usage information.
 `
 
@@ -0,0 +1,216 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/physical/raft/inspect"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*OperatorRaftInspectCommand)(nil)
+	_ cli.CommandAutocomplete = (*OperatorRaftInspectCommand)(nil)
+)
+
+type OperatorRaftInspectCommand struct {
+	*BaseCommand
+
+	flagDepth  int
+	flagFilter string
+	flagTop    int
+}
+
+func (c *OperatorRaftInspectCommand) Synopsis() string {
+	return "Inspects a raft snapshot or data directory offline"
+}
+
+func (c *OperatorRaftInspectCommand) Help() string {
+	helpText := `
+Usage: vault operator raft inspect [options] <snapshot_file | data_dir>
+
+  Inspects a raft snapshot file or the data directory of a stopped server,
+  without starting a server. It reports the raft index, term and
+  configuration, the number and size of the entries of the storage, its largest
+  prefixes and entries, and the keyring terms the entries are encrypted with.
+
+  The data directory is the path of the raft storage configuration. Its
+  databases are opened read-only and cannot be inspected while a server is
+  using them; inspect a copy of the directory or a snapshot instead.
+
+  Inspect a snapshot file:
+
+      $ vault operator raft inspect raft.snap
+
+  Inspect the data directory of a stopped server, breaking down the entries of
+  the logical mounts by mount:
+
+      $ vault operator raft inspect -depth=3 -filter=logical/ /opt/vault/data
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorRaftInspectCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.IntVar(&IntVar{
+		Name:    "depth",
+		Target:  &c.flagDepth,
+		Default: 2,
+		Usage:   "The key prefix depth used to break down the entries. If set to 0, entries are broken down by full key.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:    "filter",
+		Target:  &c.flagFilter,
+		Default: "",
+		Usage:   "Limits the breakdown of the entries to the keys with this prefix.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:    "top",
+		Target:  &c.flagTop,
+		Default: 10,
+		Usage:   "The number of largest prefixes and entries to report. If set to 0, all of them are reported.",
+	})
+
+	return set
+}
+
+func (c *OperatorRaftInspectCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *OperatorRaftInspectCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorRaftInspectCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if c.flagDepth < 0 {
+		c.UI.Error("Depth must be equal to or greater than 0")
+		return 1
+	}
+	if c.flagTop < 0 {
+		c.UI.Error("Top must be equal to or greater than 0")
+		return 1
+	}
+
+	var path string
+	args = f.Args()
+	switch len(args) {
+	case 0:
+		c.UI.Error("Missing snapshot file or data directory argument")
+		return 1
+	case 1:
+		path = strings.TrimSpace(args[0])
+	default:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+
+	report, err := inspect.Inspect(path, inspect.Options{
+		Depth:  c.flagDepth,
+		Filter: c.flagFilter,
+		Top:    c.flagTop,
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error inspecting %s: %s", path, err))
+		return 2
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, report)
+	}
+
+	c.outputReport(report)
+	return 0
+}
+
+func (c *OperatorRaftInspectCommand) outputReport(report *inspect.Report) {
+	summary := []string{
+		"Key | Value",
+		fmt.Sprintf("Source | %s", report.Source),
+		fmt.Sprintf("Path | %s", report.Path),
+	}
+	if report.Raft.SnapshotID != "" {
+		summary = append(summary, fmt.Sprintf("Snapshot ID | %s", report.Raft.SnapshotID))
+	}
+	summary = append(summary,
+		fmt.Sprintf("Index | %d", report.Raft.Index),
+		fmt.Sprintf("Term | %d", report.Raft.Term),
+	)
+	if report.Raft.LogStore != "" {
+		summary = append(summary, fmt.Sprintf("Log Store | %s", report.Raft.LogStore))
+	}
+	if report.Raft.LastLogIndex != 0 {
+		summary = append(summary,
+			fmt.Sprintf("First Log Index | %d", report.Raft.FirstLogIndex),
+			fmt.Sprintf("Last Log Index | %d", report.Raft.LastLogIndex),
+		)
+	}
+	summary = append(summary,
+		fmt.Sprintf("Entries | %d", report.FSM.Entries),
+		fmt.Sprintf("Key Size | %s", ByteSize(uint64(report.FSM.KeyBytes))),
+		fmt.Sprintf("Value Size | %s", ByteSize(uint64(report.FSM.ValueBytes))),
+	)
+	if report.FSM.DatabaseSize != 0 {
+		summary = append(summary, fmt.Sprintf("Database Size | %s", ByteSize(uint64(report.FSM.DatabaseSize))))
+	}
+	summary = append(summary,
+		fmt.Sprintf("Keyring Present | %t", report.Keyring.Present),
+		fmt.Sprintf("Keyring Latest Term | %d", report.Keyring.LatestTerm),
+		fmt.Sprintf("Keyring Upgrade Terms | %v", report.Keyring.UpgradeTerms),
+		fmt.Sprintf("Unencrypted Entries | %d", report.Keyring.UnencryptedEntries),
+	)
+	c.UI.Output(tableOutput(summary, nil))
+
+	if len(report.Raft.Servers) > 0 {
+		out := []string{"Node | Address | Suffrage"}
+		for _, server := range report.Raft.Servers {
+			out = append(out, fmt.Sprintf("%s | %s | %s", server.ID, server.Address, server.Suffrage))
+		}
+		c.UI.Output("")
+		c.UI.Output(tableOutput(out, nil))
+	}
+
+	if len(report.Keyring.Terms) > 0 {
+		out := []string{"Keyring Term | Entries"}
+		for _, term := range report.Keyring.Terms {
+			out = append(out, fmt.Sprintf("%d | %d", term.Term, term.Entries))
+		}
+		c.UI.Output("")
+		c.UI.Output(tableOutput(out, nil))
+	}
+
+	if len(report.LargestPrefixes) > 0 {
+		out := []string{"Prefix | Entries | Size"}
+		for _, prefix := range report.LargestPrefixes {
+			out = append(out, fmt.Sprintf("%s | %d | %s", prefix.Prefix, prefix.Entries, ByteSize(uint64(prefix.Bytes))))
+		}
+		c.UI.Output("")
+		c.UI.Output(tableOutput(out, nil))
+	}
+
+	if len(report.LargestEntries) > 0 {
+		out := []string{"Key | Size"}
+		for _, entry := range report.LargestEntries {
+			out = append(out, fmt.Sprintf("%s | %s", entry.Key, ByteSize(uint64(entry.Bytes))))
+		}
+		c.UI.Output("")
+		c.UI.Output(tableOutput(out, nil))
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+)
+
+func testOperatorRaftInspectCommand(tb testing.TB) (*cli.MockUi, *OperatorRaftInspectCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorRaftInspectCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestOperatorRaftInspectCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	snap, cleanup, err := createSnapshot(t)
+	if err != nil {
+		t.Fatalf("Error creating snapshot %s", err)
+	}
+	t.Cleanup(cleanup)
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"Missing snapshot file or data directory argument",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"test.snap", "test"},
+			"Too many arguments",
+			1,
+		},
+		{
+			"invalid_depth",
+			[]string{"-depth", "-1", snap.Name()},
+			"Depth must be equal to or greater than 0",
+			1,
+		},
+		{
+			"missing_file",
+			[]string{"missing.snap"},
+			"Error inspecting missing.snap",
+			2,
+		},
+		{
+			"snapshot",
+			[]string{snap.Name()},
+			"bolt-snapshot",
+			0,
+		},
+		{
+			"all_flags",
+			[]string{"-depth", "0", "-filter", "key-1", "-top", "3", snap.Name()},
+			"key-12",
+			0,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ui, cmd := testOperatorRaftInspectCommand(t)
+
+			code := cmd.Run(tc.args)
+			if code != tc.code {
+				t.Errorf("expected %d to be %d", code, tc.code)
+			}
+
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if !strings.Contains(combined, tc.out) {
+				t.Errorf("expected %q to contain %q", combined, tc.out)
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ type OperatorRaftRemovePeerCommand struct {
 	*BaseCommand
 
 	flagDRToken string
+	flagForce   bool
 }
 
 func (c *OperatorRaftRemovePeerCommand) Synopsis() string {
@@ -30,7 +31,8 @@ func (c *OperatorRaftRemovePeerCommand) Help() string {
 	helpText := `
 Usage: vault operator raft remove-peer <server_id>
 
-  Removes a node from the Raft cluster.
+  Removes a node from the Raft cluster. Removing a voter is refused if the
+  remaining healthy voters would not form a quorum, unless -force is set.
 
 	  $ vault operator raft remove-peer node1
 
@@ -52,6 +54,13 @@ func (c *OperatorRaftRemovePeerCommand) Flags() *FlagSets {
 		Usage:      "DR operation token used to authorize this request (if a DR secondary node).",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:    "force",
+		Target:  &c.flagForce,
+		Default: false,
+		Usage:   "Remove the node even if the remaining healthy voters would not form a quorum.",
+	})
+
 	return set
 }
 
@@ -93,15 +102,22 @@ func (c *OperatorRaftRemovePeerCommand) Run(args []string) int {
 		return 2
 	}
 
-	_, err = client.Logical().Write("sys/storage/raft/remove-peer", map[string]interface{}{
+	secret, err := client.Logical().Write("sys/storage/raft/remove-peer", map[string]interface{}{
 		"server_id":          serverID,
 		"dr_operation_token": c.flagDRToken,
+		"force":              c.flagForce,
 	})
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error removing the peer from raft cluster: %s", err))
 		return 2
 	}
 
+	if secret != nil {
+		for _, warning := range secret.Warnings {
+			c.UI.Warn(warning)
+		}
+	}
+
 	c.UI.Output("Peer removed successfully!")
 
 	return 0
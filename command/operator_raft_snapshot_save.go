@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/helper/pgpkeys"
 	"github.com/posener/complete"
 )
 
@@ -20,6 +21,8 @@ var (
 
 type OperatorRaftSnapshotSaveCommand struct {
 	*BaseCommand
+
+	flagPGPKeys []string
 }
 
 func (c *OperatorRaftSnapshotSaveCommand) Synopsis() string {
@@ -34,6 +37,15 @@ Usage: vault operator raft snapshot save <snapshot_file>
 
 	  $ vault operator raft snapshot save raft.snap
 
+  Save a snapshot encrypted to the PGP keys of recovery officers, which only
+  their private keys can decrypt, independently of the seal of the cluster:
+
+	  $ vault operator raft snapshot save -pgp-keys="officer1.asc,officer2.asc" raft.snap.gpg
+
+  Decrypt the snapshot with any of the private keys before restoring it:
+
+	  $ gpg --output raft.snap --decrypt raft.snap.gpg
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -42,6 +54,19 @@ Usage: vault operator raft snapshot save <snapshot_file>
 func (c *OperatorRaftSnapshotSaveCommand) Flags() *FlagSets {
 	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
 
+	f := set.NewFlagSet("Command Options")
+
+	f.VarFlag(&VarFlag{
+		Name:       "pgp-keys",
+		Value:      (*pgpkeys.PubKeyFilesFlag)(&c.flagPGPKeys),
+		Completion: complete.PredictAnything,
+		Usage: "Comma-separated list of paths to files on disk containing " +
+			"public PGP keys OR a comma-separated list of Keybase usernames using " +
+			"the format \"keybase:<username>\". When supplied, Vault encrypts the " +
+			"snapshot to all of the keys, so that it can only be decrypted with " +
+			"one of their private keys.",
+	})
+
 	return set
 }
 
@@ -90,7 +115,11 @@ func (c *OperatorRaftSnapshotSaveCommand) Run(args []string) int {
 		return 2
 	}
 
-	err = client.Sys().RaftSnapshot(w)
+	if len(c.flagPGPKeys) > 0 {
+		err = client.Sys().RaftSnapshotEncrypted(w, c.flagPGPKeys)
+	} else {
+		err = client.Sys().RaftSnapshot(w)
+	}
 	if err != nil {
 		w.Close()
 		c.UI.Error(fmt.Sprintf("Error taking the snapshot: %s", err))
@@ -15,6 +15,7 @@ import (
 	"github.com/hashicorp/go-secure-stdlib/password"
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/helper/x509keys"
 	"github.com/posener/complete"
 )
 
@@ -37,9 +38,11 @@ type OperatorRekeyCommand struct {
 	flagKeyThreshold int
 	flagNonce        string
 	flagPGPKeys      []string
+	flagX509Certs    []string
 	flagStatus       bool
 	flagTarget       string
 	flagVerify       bool
+	flagDelegated    bool
 
 	// Backup options
 	flagBackup         bool
@@ -91,6 +94,15 @@ Usage: vault operator rekey [options] [KEY]
           -pgp-keys="..." \
           -backup
 
+  Rekey for delegated unseal, where key holders unseal with
+  "vault operator unseal-delegated" rather than by submitting their keys:
+
+      $ vault operator rekey \
+          -init \
+          -key-shares=5 \
+          -key-threshold=3 \
+          -delegated-unseal
+
   Retrieve backed-up unseal keys:
 
       $ vault operator rekey -backup-retrieve
@@ -194,6 +206,29 @@ func (c *OperatorRekeyCommand) Flags() *FlagSets {
 			"specified in this list.",
 	})
 
+	f.VarFlag(&VarFlag{
+		Name:       "x509-certificates",
+		Value:      (*x509keys.CertFilesFlag)(&c.flagX509Certs),
+		Completion: complete.PredictFiles("*.pem"),
+		Usage: "Comma-separated list of paths to files on disk containing " +
+			"PEM-encoded X.509 certificates with RSA or EC public keys, such as " +
+			"those of PIV smartcards. When supplied, the generated unseal or " +
+			"recovery keys will be encrypted to the certificates' public keys and " +
+			"base64-encoded in the order specified in this list. This is mutually " +
+			"exclusive with -pgp-keys and cannot be combined with -backup.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:       "delegated-unseal",
+		Target:     &c.flagDelegated,
+		Default:    false,
+		Completion: complete.PredictNothing,
+		Usage: "Generate unseal keys for the experimental delegated unseal, " +
+			"where key holders sign an unseal challenge with \"vault operator " +
+			"unseal-delegated\" instead of submitting their keys. Requires the " +
+			"\"core.unseal.delegated.alpha1\" experiment and a Shamir seal.",
+	})
+
 	f = set.NewFlagSet("Backup Options")
 
 	f.BoolVar(&BoolVar{
@@ -296,8 +331,10 @@ func (c *OperatorRekeyCommand) init(client *api.Client) int {
 		SecretShares:        c.flagKeyShares,
 		SecretThreshold:     c.flagKeyThreshold,
 		PGPKeys:             c.flagPGPKeys,
+		X509Certificates:    c.flagX509Certs,
 		Backup:              c.flagBackup,
 		RequireVerification: c.flagVerify,
+		DelegatedUnseal:     c.flagDelegated,
 	})
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error initializing rekey: %s", err))
@@ -305,7 +342,7 @@ func (c *OperatorRekeyCommand) init(client *api.Client) int {
 	}
 
 	// Print warnings about recovery, etc.
-	if len(c.flagPGPKeys) == 0 {
+	if len(c.flagPGPKeys) == 0 && len(c.flagX509Certs) == 0 {
 		if Format(c.UI) == "table" {
 			c.UI.Warn(wrapAtLength(
 				fmt.Sprintf("WARNING! If you lose the keys after they are returned, there is no "+
@@ -644,6 +681,12 @@ func (c *OperatorRekeyCommand) printStatus(in interface{}) int {
 			out = append(out, fmt.Sprintf("PGP Fingerprints | %s", status.PGPFingerprints))
 			out = append(out, fmt.Sprintf("Backup | %t", status.Backup))
 		}
+		if len(status.X509Fingerprints) > 0 {
+			out = append(out, fmt.Sprintf("X.509 Fingerprints | %s", status.X509Fingerprints))
+		}
+		if status.DelegatedUnseal {
+			out = append(out, fmt.Sprintf("Delegated Unseal | %t", status.DelegatedUnseal))
+		}
 	case *api.RekeyVerificationStatusResponse:
 		status := in
 		out = append(out, fmt.Sprintf("Started | %t", status.Started))
@@ -687,6 +730,8 @@ func (c *OperatorRekeyCommand) printUnsealKeys(client *api.Client, status *api.R
 			} else {
 				c.UI.Output(fmt.Sprintf("Key %d fingerprint: %s; value: %s", i+1, resp.PGPFingerprints[i], key))
 			}
+		} else if len(resp.X509Fingerprints) > 0 {
+			c.UI.Output(fmt.Sprintf("Key %d certificate fingerprint: %s; value: %s", i+1, resp.X509Fingerprints[i], resp.KeysB64[i]))
 		} else {
 			if haveB64 {
 				c.UI.Output(fmt.Sprintf("Key %d: %s", i+1, resp.KeysB64[i]))
@@ -719,6 +764,11 @@ func (c *OperatorRekeyCommand) printUnsealKeys(client *api.Client, status *api.R
 		}
 	}
 
+	for _, warning := range resp.Warnings {
+		c.UI.Output("")
+		c.UI.Warn(wrapAtLength("WARNING! " + warning))
+	}
+
 	switch status.VerificationRequired {
 	case false:
 		c.UI.Output("")
@@ -6,6 +6,7 @@ package command
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/cli"
 	"github.com/posener/complete"
@@ -18,6 +19,9 @@ var (
 
 type OperatorSealCommand struct {
 	*BaseCommand
+
+	flagDrain       bool
+	flagGracePeriod time.Duration
 }
 
 func (c *OperatorSealCommand) Synopsis() string {
@@ -42,13 +46,41 @@ Usage: vault operator seal [options]
 
       $ vault operator seal
 
+  Stop accepting new requests, and give the requests in flight up to a minute
+  to complete before sealing the Vault server:
+
+      $ vault operator seal -drain -grace-period=1m
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
 }
 
 func (c *OperatorSealCommand) Flags() *FlagSets {
-	return c.flagSet(FlagSetHTTP)
+	set := c.flagSet(FlagSetHTTP)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:       "drain",
+		Target:     &c.flagDrain,
+		Default:    false,
+		Completion: complete.PredictNothing,
+		Usage: "Stop accepting new requests, and let the requests in flight " +
+			"complete before sealing. Requests still in flight after the grace " +
+			"period are aborted.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "grace-period",
+		Target:     &c.flagGracePeriod,
+		Completion: complete.PredictAnything,
+		Usage: "How long the requests in flight are given to complete when " +
+			"draining. If unspecified, this defaults to the Vault server's " +
+			"default of 30 seconds.",
+	})
+
+	return set
 }
 
 func (c *OperatorSealCommand) AutocompleteArgs() complete.Predictor {
@@ -79,7 +111,17 @@ func (c *OperatorSealCommand) Run(args []string) int {
 		return 2
 	}
 
-	if err := client.Sys().Seal(); err != nil {
+	if c.flagGracePeriod != 0 && !c.flagDrain {
+		c.UI.Error("The -grace-period flag requires -drain")
+		return 1
+	}
+
+	if c.flagDrain {
+		err = client.Sys().DrainAndSeal(c.flagGracePeriod)
+	} else {
+		err = client.Sys().Seal()
+	}
+	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error sealing: %s", err))
 		return 2
 	}
@@ -0,0 +1,341 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*OperatorSubmitSharesCommand)(nil)
+	_ cli.CommandAutocomplete = (*OperatorSubmitSharesCommand)(nil)
+)
+
+type OperatorSubmitSharesCommand struct {
+	*BaseCommand
+
+	flagOperation      string
+	flagNonce          string
+	flagPGPPrivateKeys []string
+	flagParallel       int
+}
+
+func (c *OperatorSubmitSharesCommand) Synopsis() string {
+	return "Submits several key shares concurrently"
+}
+
+func (c *OperatorSubmitSharesCommand) Help() string {
+	helpText := `
+Usage: vault operator submit-shares [options] [LABEL=]FILE...
+
+  Submits the key shares in the given files to an unseal, rekey, rekey
+  verification, or root generation operation in progress. Each file contains
+  one share, labeled by the file name unless a label is given.
+
+  PGP-encrypted shares, as returned by init, rekey, or generate-root when PGP
+  keys are supplied, are decrypted locally with the private keys given by
+  -pgp-private-key. Only the plaintext shares are sent to the server.
+
+  All shares are decrypted and validated before any of them is submitted. The
+  valid shares are then submitted concurrently, and the status of each share
+  is reported once the operation is complete or all shares are submitted.
+
+  Unseal Vault with the shares of three key holders:
+
+      $ vault operator submit-shares \
+          -pgp-private-key=alice.asc \
+          -pgp-private-key=bob.asc \
+          -pgp-private-key=carol.asc \
+          alice=alice.gpg bob=bob.gpg carol=carol.gpg
+
+  Provide the new shares to verify a rekey:
+
+      $ vault operator submit-shares -operation=rekey-verify -nonce=abcd1234... \
+          share-1.txt share-2.txt share-3.txt
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSubmitSharesCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:    "operation",
+		Target:  &c.flagOperation,
+		Default: string(api.ShareOperationUnseal),
+		Completion: complete.PredictSet(
+			string(api.ShareOperationUnseal),
+			string(api.ShareOperationRekey),
+			string(api.ShareOperationRekeyVerify),
+			string(api.ShareOperationRekeyRecovery),
+			string(api.ShareOperationRekeyRecoveryVerify),
+			string(api.ShareOperationGenerateRoot),
+		),
+		Usage: "Operation to submit the shares to. This is one of \"unseal\", " +
+			"\"rekey\", \"rekey-verify\", \"rekey-recovery\", " +
+			"\"rekey-recovery-verify\", or \"generate-root\".",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "nonce",
+		Target:     &c.flagNonce,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "Nonce of the operation in progress. This is required for all " +
+			"operations but unseal, for which no share is submitted if the " +
+			"server reports a different nonce.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "pgp-private-key",
+		Target:     &c.flagPGPPrivateKeys,
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to a PGP private key (armored, base64, or binary) used to " +
+			"decrypt PGP-encrypted shares locally. Each share is decrypted with " +
+			"the key it was encrypted for. This can be specified multiple times. " +
+			"If a key is protected by a passphrase, Vault prompts for it.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:       "parallel",
+		Target:     &c.flagParallel,
+		Default:    0,
+		Completion: complete.PredictAnything,
+		Usage: "Number of shares submitted concurrently. By default all shares " +
+			"are submitted concurrently.",
+	})
+
+	return set
+}
+
+func (c *OperatorSubmitSharesCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *OperatorSubmitSharesCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorSubmitSharesCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) == 0 {
+		c.UI.Error("Not enough arguments (expected at least 1, got 0)")
+		return 1
+	}
+
+	operation := api.ShareOperation(strings.ToLower(strings.TrimSpace(c.flagOperation)))
+	if operation != api.ShareOperationUnseal && c.flagNonce == "" {
+		c.UI.Error("Missing nonce value: specify it via the -nonce flag")
+		return 1
+	}
+
+	keyring, err := c.readKeyring()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	shares, err := c.readShares(args, keyring)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	resp, err := client.Sys().SubmitShares(&api.SubmitSharesInput{
+		Operation:   operation,
+		Shares:      shares,
+		Nonce:       c.flagNonce,
+		MaxParallel: c.flagParallel,
+	})
+	if resp == nil {
+		c.UI.Error(fmt.Sprintf("Error submitting shares: %s", err))
+		return 2
+	}
+
+	// Shares skipped because the operation was already complete are not
+	// failures
+	failed := err != nil
+	for _, share := range resp.Shares {
+		if share.Error != "" && share.Error != api.ErrSharesComplete.Error() {
+			failed = true
+		}
+	}
+
+	if Format(c.UI) != "table" {
+		if code := OutputData(c.UI, resp); code != 0 {
+			return code
+		}
+	} else {
+		c.printShareStatus(resp)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error submitting shares: %s", err))
+			return 2
+		}
+		if code := c.printResult(client, operation, resp); code != 0 {
+			return code
+		}
+	}
+
+	if failed {
+		return 2
+	}
+	return 0
+}
+
+// readKeyring reads the PGP private keys, prompting for the passphrase of
+// each key which is protected by one.
+func (c *OperatorSubmitSharesCommand) readKeyring() (openpgp.EntityList, error) {
+	var keyring openpgp.EntityList
+	for _, path := range c.flagPGPPrivateKeys {
+		keyBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading PGP private key: %w", err)
+		}
+		entity, err := pgpkeys.ReadPrivateKey(keyBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error reading PGP private key %s: %w", path, err)
+		}
+		if pgpkeys.PrivateKeyEncrypted(entity) {
+			passphrase, err := c.UI.AskSecret(fmt.Sprintf("Passphrase of PGP private key %s (will be hidden):", path))
+			if err != nil {
+				return nil, fmt.Errorf("error reading PGP private key passphrase: %w", err)
+			}
+			if err := pgpkeys.DecryptPrivateKey(entity, []byte(passphrase)); err != nil {
+				return nil, fmt.Errorf("error reading PGP private key %s: %w", path, err)
+			}
+		}
+		keyring = append(keyring, entity)
+	}
+	return keyring, nil
+}
+
+// readShares reads and, if needed, decrypts the shares in the given files
+// concurrently. A share is labeled by its file name unless the argument is of
+// the form LABEL=FILE.
+func (c *OperatorSubmitSharesCommand) readShares(args []string, keyring openpgp.EntityList) ([]*api.Share, error) {
+	shares := make([]*api.Share, len(args))
+	errs := make([]error, len(args))
+
+	var wg sync.WaitGroup
+	for i, arg := range args {
+		label, path := filepath.Base(arg), arg
+		if l, p, ok := strings.Cut(arg, "="); ok && l != "" && !strings.ContainsRune(l, filepath.Separator) {
+			label, path = l, p
+		}
+		shares[i] = &api.Share{Label: label}
+
+		wg.Add(1)
+		go func(share *api.Share, path string, err *error) {
+			defer wg.Done()
+			contents, readErr := os.ReadFile(path)
+			if readErr != nil {
+				*err = fmt.Errorf("error reading share of %s: %w", share.Label, readErr)
+				return
+			}
+			if !pgpkeys.ShareEncrypted(contents) {
+				share.Key = strings.TrimSpace(string(contents))
+				return
+			}
+			if len(keyring) == 0 {
+				*err = fmt.Errorf("share of %s is PGP encrypted, specify the private key to decrypt it with -pgp-private-key", share.Label)
+				return
+			}
+			share.Key, readErr = pgpkeys.DecryptShareWithKeyRing(contents, keyring)
+			if readErr != nil {
+				*err = fmt.Errorf("error decrypting share of %s: %w", share.Label, readErr)
+			}
+		}(shares[i], path, &errs[i])
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return shares, nil
+}
+
+// printShareStatus prints the outcome of submitting each share.
+func (c *OperatorSubmitSharesCommand) printShareStatus(resp *api.SubmitSharesResponse) {
+	out := []string{"Share | Status | Progress"}
+	for _, share := range resp.Shares {
+		switch {
+		case share.Submitted:
+			out = append(out, fmt.Sprintf("%s | submitted | %d", share.Label, share.Progress))
+		default:
+			out = append(out, fmt.Sprintf("%s | %s | n/a", share.Label, share.Error))
+		}
+	}
+	c.UI.Output(tableOutput(out, nil))
+	c.UI.Output("")
+}
+
+// printResult prints the state of the operation after the shares were
+// submitted, including the new keys if a rekey completed.
+func (c *OperatorSubmitSharesCommand) printResult(client *api.Client, operation api.ShareOperation, resp *api.SubmitSharesResponse) int {
+	switch operation {
+	case api.ShareOperationUnseal:
+		return OutputSealStatus(c.UI, client, resp.SealStatus)
+	case api.ShareOperationRekey, api.ShareOperationRekeyRecovery:
+		rekey := &OperatorRekeyCommand{BaseCommand: c.BaseCommand, flagTarget: "barrier"}
+		statusFn := client.Sys().RekeyStatus
+		if operation == api.ShareOperationRekeyRecovery {
+			rekey.flagTarget = "recovery"
+			statusFn = client.Sys().RekeyRecoveryKeyStatus
+		}
+		if !resp.Complete {
+			return rekey.status(client)
+		}
+		// The status of a completed rekey still holds its configuration until
+		// verification, if required, is complete.
+		status, err := statusFn()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error getting rekey status: %s", err))
+			return 2
+		}
+		if !status.VerificationRequired {
+			status.VerificationRequired = resp.Rekey.VerificationRequired
+		}
+		return rekey.printUnsealKeys(client, status, resp.Rekey)
+	case api.ShareOperationRekeyVerify, api.ShareOperationRekeyRecoveryVerify:
+		if !resp.Complete {
+			c.UI.Output(fmt.Sprintf("Rekey verification in progress: %d shares provided.", resp.RekeyVerification.Progress))
+			return 0
+		}
+		c.UI.Output(wrapAtLength("Rekey verification successful. The rekey operation is complete and the new keys are now active."))
+		return 0
+	case api.ShareOperationGenerateRoot:
+		generateRoot := &OperatorGenerateRootCommand{BaseCommand: c.BaseCommand}
+		return generateRoot.printStatus(resp.GenerateRoot)
+	}
+	return 0
+}
@@ -0,0 +1,199 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/helper/pgpkeys"
+)
+
+func testOperatorSubmitSharesCommand(tb testing.TB) (*cli.MockUi, *OperatorSubmitSharesCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorSubmitSharesCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestOperatorSubmitSharesCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not_enough_args", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testOperatorSubmitSharesCommand(t)
+
+		code := cmd.Run(nil)
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Not enough arguments"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("missing_nonce", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testOperatorSubmitSharesCommand(t)
+
+		code := cmd.Run([]string{"-operation=rekey", "share.txt"})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Missing nonce value"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("pgp_shares", func(t *testing.T) {
+		t.Parallel()
+
+		client, keys, closer := testVaultServerUnseal(t)
+		defer closer()
+
+		// Seal so we can unseal
+		if err := client.Sys().Seal(); err != nil {
+			t.Fatal(err)
+		}
+
+		dir := t.TempDir()
+		privKeyPath := filepath.Join(dir, "private.key")
+		if err := os.WriteFile(privKeyPath, []byte(pgpkeys.TestPrivKey1), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		var args []string
+		for i, key := range keys {
+			_, encrypted, err := pgpkeys.EncryptShares([][]byte{[]byte(key)}, []string{pgpkeys.TestPubKey1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sharePath := filepath.Join(dir, "share-"+string(rune('a'+i)))
+			if err := os.WriteFile(sharePath, []byte(base64.StdEncoding.EncodeToString(encrypted[0])), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			args = append(args, sharePath)
+		}
+
+		ui, cmd := testOperatorSubmitSharesCommand(t)
+		cmd.client = client
+
+		code := cmd.Run(append([]string{"-pgp-private-key=" + privKeyPath}, args...))
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		status, err := client.Sys().SealStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Sealed {
+			t.Error("expected vault to be unsealed")
+		}
+
+		output := ui.OutputWriter.String()
+		if !strings.Contains(output, "share-a") || !strings.Contains(output, "submitted") {
+			t.Errorf("expected share status in output, got: %s", output)
+		}
+	})
+
+	t.Run("pgp_share_without_key", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		_, encrypted, err := pgpkeys.EncryptShares([][]byte{[]byte("abcd")}, []string{pgpkeys.TestPubKey1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sharePath := filepath.Join(dir, "share.txt")
+		if err := os.WriteFile(sharePath, []byte(base64.StdEncoding.EncodeToString(encrypted[0])), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testOperatorSubmitSharesCommand(t)
+
+		code := cmd.Run([]string{"alice=" + sharePath})
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "share of alice is PGP encrypted"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("duplicate_and_invalid_shares", func(t *testing.T) {
+		t.Parallel()
+
+		client, keys, closer := testVaultServerUnseal(t)
+		defer closer()
+
+		// Seal so we can unseal
+		if err := client.Sys().Seal(); err != nil {
+			t.Fatal(err)
+		}
+
+		dir := t.TempDir()
+		var args []string
+		for label, key := range map[string]string{
+			"alice": keys[0],
+			"bob":   keys[0],
+			"carol": "not a share",
+		} {
+			sharePath := filepath.Join(dir, label)
+			if err := os.WriteFile(sharePath, []byte(key), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			args = append(args, sharePath)
+		}
+
+		ui, cmd := testOperatorSubmitSharesCommand(t)
+		cmd.client = client
+
+		code := cmd.Run(args)
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		output := ui.OutputWriter.String()
+		for _, expected := range []string{"duplicate of", "neither hex nor base64"} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("expected %q to contain %q", output, expected)
+			}
+		}
+
+		status, err := client.Sys().SealStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Progress != 1 {
+			t.Errorf("expected one share to be submitted, progress is %d", status.Progress)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testOperatorSubmitSharesCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -4,14 +4,17 @@
 package command
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/hashicorp/cli"
 	"github.com/hashicorp/go-secure-stdlib/password"
 	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/pgpkeys"
 	"github.com/posener/complete"
 )
 
@@ -23,8 +26,16 @@ var (
 type OperatorUnsealCommand struct {
 	*BaseCommand
 
-	flagReset   bool
-	flagMigrate bool
+	flagReset         bool
+	flagMigrate       bool
+	flagInteractive   bool
+	flagNonce         string
+	flagShareFile     string
+	flagPGPPrivateKey string
+
+	// pgpEntity is the parsed (and decrypted) private key used to decrypt
+	// shares, loaded at most once per invocation.
+	pgpEntity *openpgp.Entity
 
 	testOutput io.Writer // for tests
 }
@@ -51,6 +62,19 @@ Usage: vault operator unseal [options] [KEY]
       $ vault operator unseal
       Key (will be hidden): IXyR0OJnSFobekZMMCKCoVEpT7wI6l+USMzE3IcyDyo=
 
+  For an unseal ceremony where several key holders share a terminal, run in
+  interactive mode. Vault prompts for each share in turn, shows the progress
+  with the label each key holder entered, and verifies that the unseal nonce
+  does not change between shares:
+
+      $ vault operator unseal -interactive -nonce=2dbd10f1-8528-6246-09e7-82b25b8aba63
+
+  If the share was encrypted with a PGP key during init or rekey, it can be
+  decrypted locally before submission. In interactive mode, a share can also
+  be read from a file by entering "@" followed by the file path:
+
+      $ vault operator unseal -share-file=share.gpg -pgp-private-key=private.asc
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -81,6 +105,44 @@ func (c *OperatorUnsealCommand) Flags() *FlagSets {
 		Usage:      "Indicate that this share is provided with the intent that it is part of a seal migration process.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:       "interactive",
+		Target:     &c.flagInteractive,
+		Default:    false,
+		Completion: complete.PredictNothing,
+		Usage: "Run an interactive unseal ceremony, prompting for labeled shares " +
+			"until the threshold is reached and the Vault server is unsealed.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "nonce",
+		Target:     &c.flagNonce,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "Expected nonce of the in-progress unseal attempt. If the server " +
+			"reports a different nonce, no share is submitted.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "share-file",
+		Target:     &c.flagShareFile,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to a file containing the unseal key share. If " +
+			"-pgp-private-key is also given, the share is decrypted locally " +
+			"before submission.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "pgp-private-key",
+		Target:     &c.flagPGPPrivateKey,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to a PGP private key (armored, base64, or binary) used to " +
+			"decrypt PGP-encrypted shares locally. If the key is protected by " +
+			"a passphrase, Vault prompts for it.",
+	})
+
 	return set
 }
 
@@ -113,12 +175,25 @@ func (c *OperatorUnsealCommand) Run(args []string) int {
 		return 1
 	}
 
+	if c.flagShareFile != "" && unsealKey != "" {
+		c.UI.Error("Cannot specify both an unseal key argument and -share-file")
+		return 1
+	}
+
 	client, err := c.Client()
 	if err != nil {
 		c.UI.Error(err.Error())
 		return 2
 	}
 
+	if c.flagInteractive {
+		if c.flagReset || unsealKey != "" || c.flagShareFile != "" {
+			c.UI.Error("Cannot specify -reset, -share-file, or an unseal key argument with -interactive")
+			return 1
+		}
+		return c.runInteractive(client)
+	}
+
 	if c.flagReset {
 		status, err := client.Sys().ResetUnsealProcess()
 		if err != nil {
@@ -128,6 +203,15 @@ func (c *OperatorUnsealCommand) Run(args []string) int {
 		return OutputSealStatus(c.UI, client, status)
 	}
 
+	if c.flagShareFile != "" {
+		share, err := c.readShareFile(c.flagShareFile)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		unsealKey = share
+	}
+
 	if unsealKey == "" {
 		// Override the output
 		writer := (io.Writer)(os.Stdout)
@@ -152,6 +236,18 @@ func (c *OperatorUnsealCommand) Run(args []string) int {
 		unsealKey = strings.TrimSpace(value)
 	}
 
+	if c.flagNonce != "" {
+		status, err := client.Sys().SealStatus()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error checking seal status: %s", err))
+			return 2
+		}
+		if err := verifyUnsealNonce(status, c.flagNonce); err != nil {
+			c.UI.Error(err.Error())
+			return 2
+		}
+	}
+
 	status, err := client.Sys().UnsealWithOptions(&api.UnsealOpts{
 		Key:     unsealKey,
 		Migrate: c.flagMigrate,
@@ -163,3 +259,165 @@ func (c *OperatorUnsealCommand) Run(args []string) int {
 
 	return OutputSealStatus(c.UI, client, status)
 }
+
+// runInteractive runs an unseal ceremony, prompting for shares until the server
+// is unsealed. The nonce of the unseal attempt is pinned after the first
+// submission (or up front via -nonce) so that a reset by another operator is
+// detected rather than silently starting a new attempt.
+func (c *OperatorUnsealCommand) runInteractive(client *api.Client) int {
+	status, err := client.Sys().SealStatus()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error checking seal status: %s", err))
+		return 2
+	}
+	if !status.Sealed {
+		c.UI.Output("Vault is already unsealed.")
+		return OutputSealStatus(c.UI, client, status)
+	}
+
+	nonce := c.flagNonce
+	if err := verifyUnsealNonce(status, nonce); err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+	if nonce == "" && status.Progress > 0 {
+		nonce = status.Nonce
+		c.UI.Warn(wrapAtLength(fmt.Sprintf("An unseal attempt is already in "+
+			"progress (%d of %d shares) with nonce %s. Continuing that attempt; "+
+			"use -reset first to start over.", status.Progress, status.T, nonce)))
+	}
+
+	c.UI.Output(fmt.Sprintf("Unseal ceremony: %d of %d shares are required.", status.T, status.N))
+
+	var labels []string
+	for status.Sealed {
+		shareNum := status.Progress + 1
+		label, err := c.UI.Ask(fmt.Sprintf("Label for share %d of %d (optional):", shareNum, status.T))
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading share label: %s", err))
+			return 1
+		}
+		label = strings.TrimSpace(label)
+		if label == "" {
+			label = fmt.Sprintf("share %d", shareNum)
+		}
+
+		value, err := c.UI.AskSecret(fmt.Sprintf("Unseal Key for %s (will be hidden, or @path to read a file):", label))
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading unseal key: %s", err))
+			return 1
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			c.UI.Warn("No unseal key entered, try again.")
+			continue
+		}
+
+		unsealKey := value
+		switch {
+		case strings.HasPrefix(value, "@"):
+			unsealKey, err = c.readShareFile(strings.TrimPrefix(value, "@"))
+		case c.flagPGPPrivateKey != "":
+			unsealKey, err = c.decryptShare([]byte(value))
+		}
+		if err != nil {
+			c.UI.Error(err.Error())
+			continue
+		}
+
+		// Check the nonce immediately before submitting, so that a share is
+		// never contributed to an attempt that someone else started.
+		current, err := client.Sys().SealStatus()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error checking seal status: %s", err))
+			return 2
+		}
+		if err := verifyUnsealNonce(current, nonce); err != nil {
+			c.UI.Error(err.Error())
+			return 2
+		}
+
+		status, err = client.Sys().UnsealWithOptions(&api.UnsealOpts{
+			Key:     unsealKey,
+			Migrate: c.flagMigrate,
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error unsealing with %s: %s", label, err))
+			continue
+		}
+		labels = append(labels, label)
+
+		if !status.Sealed {
+			break
+		}
+		if nonce == "" {
+			nonce = status.Nonce
+			c.UI.Output(fmt.Sprintf("Unseal nonce: %s", nonce))
+		}
+		c.UI.Output(fmt.Sprintf("Accepted %s. Progress: %d of %d [%s]", label, status.Progress, status.T, strings.Join(labels, ", ")))
+	}
+
+	c.UI.Output(fmt.Sprintf("Vault is unsealed. Shares submitted in this session: %s", strings.Join(labels, ", ")))
+	return OutputSealStatus(c.UI, client, status)
+}
+
+// verifyUnsealNonce returns an error unless an unseal attempt with the
+// expected nonce is in progress. An empty expected nonce always passes.
+func verifyUnsealNonce(status *api.SealStatusResponse, expected string) error {
+	if expected == "" || !status.Sealed {
+		return nil
+	}
+	if status.Progress == 0 {
+		return fmt.Errorf("no unseal attempt with nonce %q is in progress; "+
+			"it may have been reset by another operator", expected)
+	}
+	if status.Nonce != expected {
+		return fmt.Errorf("unseal nonce mismatch: expected %q but the server reports %q; "+
+			"the unseal attempt may have been reset or started by another operator", expected, status.Nonce)
+	}
+	return nil
+}
+
+// readShareFile reads an unseal key share from the given file, decrypting it
+// with the configured PGP private key if one was given.
+func (c *OperatorUnsealCommand) readShareFile(path string) (string, error) {
+	share, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading share file: %w", err)
+	}
+	if c.flagPGPPrivateKey != "" {
+		return c.decryptShare(share)
+	}
+	return strings.TrimSpace(string(share)), nil
+}
+
+// decryptShare decrypts a PGP-encrypted share locally, so that only the
+// plaintext share is ever sent to the server.
+func (c *OperatorUnsealCommand) decryptShare(share []byte) (string, error) {
+	if c.flagPGPPrivateKey == "" {
+		return "", errors.New("no PGP private key given to decrypt the share with")
+	}
+
+	if c.pgpEntity == nil {
+		keyBytes, err := os.ReadFile(c.flagPGPPrivateKey)
+		if err != nil {
+			return "", fmt.Errorf("error reading PGP private key: %w", err)
+		}
+		entity, err := pgpkeys.ReadPrivateKey(keyBytes)
+		if err != nil {
+			return "", err
+		}
+		if pgpkeys.PrivateKeyEncrypted(entity) {
+			passphrase, err := c.UI.AskSecret("PGP private key passphrase (will be hidden):")
+			if err != nil {
+				return "", fmt.Errorf("error reading PGP private key passphrase: %w", err)
+			}
+			if err := pgpkeys.DecryptPrivateKey(entity, []byte(passphrase)); err != nil {
+				return "", err
+			}
+		}
+		c.pgpEntity = entity
+	}
+
+	return pgpkeys.DecryptShare(share, c.pgpEntity)
+}
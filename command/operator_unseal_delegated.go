@@ -0,0 +1,240 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/go-secure-stdlib/password"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/thresholdunseal"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*OperatorUnsealDelegatedCommand)(nil)
+	_ cli.CommandAutocomplete = (*OperatorUnsealDelegatedCommand)(nil)
+)
+
+type OperatorUnsealDelegatedCommand struct {
+	*BaseCommand
+
+	flagReset     bool
+	flagNonce     string
+	flagShareFile string
+
+	testOutput io.Writer // for tests
+}
+
+func (c *OperatorUnsealDelegatedCommand) Synopsis() string {
+	return "Unseals the Vault server by signing an unseal challenge"
+}
+
+func (c *OperatorUnsealDelegatedCommand) Help() string {
+	helpText := `
+Usage: vault operator unseal-delegated [options]
+
+  Take part in a delegated unseal of a Vault server. This is an experimental
+  unseal mode, enabled by rekeying with -delegated-unseal on a server started
+  with the "core.unseal.delegated.alpha1" experiment.
+
+  Rather than submitting the key share, this command fetches the current
+  unseal challenge from the server, signs it locally with the key share and
+  submits only the signature. The key share never leaves this machine, and a
+  signature is of no use for any other unseal attempt.
+
+  Run the command with no arguments and it will prompt for the key share:
+
+      $ vault operator unseal-delegated
+      Key (will be hidden): ASi2dWGu9Ca9o7KqPUGG9wtSzuWB+S7ExGs8gZ+y7WHd
+
+  The key share can also be read from a file:
+
+      $ vault operator unseal-delegated -share-file=share.txt
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorUnsealDelegatedCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:       "reset",
+		Target:     &c.flagReset,
+		Default:    false,
+		Completion: complete.PredictNothing,
+		Usage:      "Discard the current unseal challenge and any signatures submitted for it.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "nonce",
+		Target:     &c.flagNonce,
+		Default:    "",
+		Completion: complete.PredictAnything,
+		Usage: "Expected nonce of the in-progress delegated unseal. If the " +
+			"server reports a different nonce, the challenge is not signed.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "share-file",
+		Target:     &c.flagShareFile,
+		Default:    "",
+		Completion: complete.PredictFiles("*"),
+		Usage:      "Path to a file containing the key share.",
+	})
+
+	return set
+}
+
+func (c *OperatorUnsealDelegatedCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorUnsealDelegatedCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorUnsealDelegatedCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	if args = f.Args(); len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	if c.flagReset {
+		status, err := client.Sys().ResetDelegatedUnseal()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error resetting delegated unseal: %s", err))
+			return 2
+		}
+		return c.output(client, status)
+	}
+
+	var encoded string
+	if c.flagShareFile != "" {
+		share, err := os.ReadFile(c.flagShareFile)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading share file: %s", err))
+			return 1
+		}
+		encoded = string(share)
+	} else {
+		// Override the output
+		writer := (io.Writer)(os.Stdout)
+		if c.testOutput != nil {
+			writer = c.testOutput
+		}
+
+		fmt.Fprintf(writer, "Key (will be hidden): ")
+		encoded, err = password.Read(os.Stdin)
+		fmt.Fprintf(writer, "\n")
+		if err != nil {
+			c.UI.Error(wrapAtLength(fmt.Sprintf("An error occurred attempting to "+
+				"ask for the key share. The raw error message is shown below, but "+
+				"usually this is because you attempted to pipe a value into the "+
+				"command or you are executing outside of a terminal (tty). If this "+
+				"is not an option, use -share-file. The raw error was:\n\n%s", err)))
+			return 1
+		}
+	}
+
+	share, err := decodeDelegatedUnsealShare(strings.TrimSpace(encoded))
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	status, err := client.Sys().DelegatedUnsealChallenge()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error fetching unseal challenge: %s", err))
+		return 2
+	}
+	if !status.Sealed {
+		return c.output(client, status)
+	}
+	if c.flagNonce != "" && c.flagNonce != status.Nonce {
+		c.UI.Error(fmt.Sprintf("Unseal nonce mismatch: expected %q but the server reports %q; "+
+			"the delegated unseal may have been reset by another operator", c.flagNonce, status.Nonce))
+		return 2
+	}
+
+	challenge, err := base64.StdEncoding.DecodeString(status.Challenge)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error decoding unseal challenge: %s", err))
+		return 2
+	}
+	signature, err := thresholdunseal.Sign(share, challenge)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error signing unseal challenge: %s", err))
+		return 1
+	}
+
+	status, err = client.Sys().DelegatedUnseal(status.Nonce, base64.StdEncoding.EncodeToString(signature))
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error unsealing: %s", err))
+		return 2
+	}
+
+	return c.output(client, status)
+}
+
+func (c *OperatorUnsealDelegatedCommand) output(client *api.Client, status *api.DelegatedUnsealResponse) int {
+	if !status.Sealed {
+		sealStatus, err := client.Sys().SealStatus()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error checking seal status: %s", err))
+			return 2
+		}
+		return OutputSealStatus(c.UI, client, sealStatus)
+	}
+
+	switch Format(c.UI) {
+	case "table":
+		c.UI.Output(tableOutput([]string{
+			"Key | Value",
+			fmt.Sprintf("Sealed | %t", status.Sealed),
+			fmt.Sprintf("Total Shares | %d", status.N),
+			fmt.Sprintf("Threshold | %d", status.T),
+			fmt.Sprintf("Unseal Progress | %d/%d", status.Progress, status.T),
+			fmt.Sprintf("Unseal Nonce | %s", status.Nonce),
+		}, nil))
+		return 0
+	default:
+		return OutputData(c.UI, status)
+	}
+}
+
+// decodeDelegatedUnsealShare decodes a hex or base64 encoded key share.
+func decodeDelegatedUnsealShare(encoded string) ([]byte, error) {
+	if share, err := hex.DecodeString(encoded); err == nil && len(share) == thresholdunseal.ShareLength {
+		return share, nil
+	}
+	share, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(share) != thresholdunseal.ShareLength {
+		return nil, fmt.Errorf("key share must be a valid hex or base64 encoded delegated unseal share")
+	}
+	return share, nil
+}
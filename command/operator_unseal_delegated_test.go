@@ -0,0 +1,118 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/experiments"
+	"github.com/hashicorp/vault/vault"
+)
+
+func testOperatorUnsealDelegatedCommand(tb testing.TB) (*cli.MockUi, *OperatorUnsealDelegatedCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorUnsealDelegatedCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestOperatorUnsealDelegatedCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	client, keys, closer := testVaultServerCoreConfig(t, &vault.CoreConfig{
+		Experiments: []string{experiments.VaultExperimentCoreUnsealDelegated},
+	})
+	defer closer()
+
+	status, err := client.Sys().RekeyInit(&api.RekeyInitRequest{
+		SecretShares:    3,
+		SecretThreshold: 2,
+		DelegatedUnseal: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp *api.RekeyUpdateResponse
+	for _, key := range keys {
+		resp, err = client.Sys().RekeyUpdate(key, status.Nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if resp == nil || len(resp.KeysB64) != 3 {
+		t.Fatalf("expected 3 keys, got %#v", resp)
+	}
+
+	if err := client.Sys().Seal(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for i, share := range resp.KeysB64[:2] {
+		path := filepath.Join(dir, "share")
+		if err := os.WriteFile(path, []byte(share), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testOperatorUnsealDelegatedCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-share-file", path})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := "Unseal Progress    1/2"
+		if i == 1 {
+			expected = "Sealed          false"
+		}
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	}
+
+	sealStatus, err := client.Sys().SealStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealStatus.Sealed {
+		t.Error("expected unsealed")
+	}
+}
+
+func TestOperatorUnsealDelegatedCommand_InvalidShare(t *testing.T) {
+	t.Parallel()
+
+	client, closer := testVaultServer(t)
+	defer closer()
+
+	path := filepath.Join(t.TempDir(), "share")
+	if err := os.WriteFile(path, []byte("not-a-share"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ui, cmd := testOperatorUnsealDelegatedCommand(t)
+	cmd.client = client
+
+	code := cmd.Run([]string{"-share-file", path})
+	if exp := 1; code != exp {
+		t.Errorf("expected %d to be %d", code, exp)
+	}
+
+	expected := "delegated unseal share"
+	combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+	if !strings.Contains(combined, expected) {
+		t.Errorf("expected %q to contain %q", combined, expected)
+	}
+}
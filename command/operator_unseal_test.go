@@ -5,13 +5,18 @@ package command
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/helper/pgpkeys"
 )
 
 func testOperatorUnsealCommand(tb testing.TB) (*cli.MockUi, *OperatorUnsealCommand) {
@@ -118,6 +123,128 @@ func TestOperatorUnsealCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("interactive", func(t *testing.T) {
+		t.Parallel()
+
+		client, keys, closer := testVaultServerUnseal(t)
+		defer closer()
+
+		// Seal so we can unseal
+		if err := client.Sys().Seal(); err != nil {
+			t.Fatal(err)
+		}
+
+		var input strings.Builder
+		for i, key := range keys {
+			fmt.Fprintf(&input, "holder-%d\n%s\n", i, key)
+		}
+
+		ui, cmd := testOperatorUnsealCommand(t)
+		cmd.client = client
+		// MockUi creates a new buffered reader for every prompt, so feed it
+		// one byte at a time to avoid losing the input for later prompts.
+		ui.InputReader = iotest.OneByteReader(strings.NewReader(input.String()))
+
+		code := cmd.Run([]string{"-interactive"})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		for _, expected := range []string{"Unseal nonce: ", "Progress: 2 of 3 [holder-0, holder-1]", "holder-0, holder-1, holder-2"} {
+			if !strings.Contains(combined, expected) {
+				t.Errorf("expected %q to contain %q", combined, expected)
+			}
+		}
+
+		status, err := client.Sys().SealStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Sealed {
+			t.Error("expected unsealed")
+		}
+	})
+
+	t.Run("nonce_mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		client, keys, closer := testVaultServerUnseal(t)
+		defer closer()
+
+		// Seal so we can unseal
+		if err := client.Sys().Seal(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Sys().Unseal(keys[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testOperatorUnsealCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-nonce=not-the-nonce", keys[1]})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+		expected := "unseal nonce mismatch"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+
+		status, err := client.Sys().SealStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Progress != 1 {
+			t.Errorf("expected no share to be submitted, progress is %d", status.Progress)
+		}
+	})
+
+	t.Run("pgp_share_file", func(t *testing.T) {
+		t.Parallel()
+
+		client, keys, closer := testVaultServerUnseal(t)
+		defer closer()
+
+		// Seal so we can unseal
+		if err := client.Sys().Seal(); err != nil {
+			t.Fatal(err)
+		}
+
+		dir := t.TempDir()
+		privKeyPath := filepath.Join(dir, "private.key")
+		if err := os.WriteFile(privKeyPath, []byte(pgpkeys.TestPrivKey1), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, encrypted, err := pgpkeys.EncryptShares([][]byte{[]byte(keys[0])}, []string{pgpkeys.TestPubKey1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sharePath := filepath.Join(dir, "share.txt")
+		if err := os.WriteFile(sharePath, []byte(base64.StdEncoding.EncodeToString(encrypted[0])), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testOperatorUnsealCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{"-share-file=" + sharePath, "-pgp-private-key=" + privKeyPath})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		status, err := client.Sys().SealStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Progress != 1 {
+			t.Errorf("expected progress of 1, got %d", status.Progress)
+		}
+	})
+
 	t.Run("communication_failure", func(t *testing.T) {
 		t.Parallel()
 
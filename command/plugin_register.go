@@ -5,6 +5,7 @@ package command
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/hashicorp/cli"
@@ -27,6 +28,12 @@ type PluginRegisterCommand struct {
 	flagOCIImage string
 	flagRuntime  string
 	flagEnv      []string
+
+	flagCosignKey                   string
+	flagCosignSignature             string
+	flagCosignBundle                string
+	flagCosignCertificateIdentity   string
+	flagCosignCertificateOIDCIssuer string
 }
 
 func (c *PluginRegisterCommand) Synopsis() string {
@@ -53,6 +60,16 @@ Usage: vault plugin register [options] TYPE NAME
           -args=--with-glibc,--with-cgo \
           auth my-custom-plugin
 
+  Register a plugin which must carry a keyless cosign signature by a release
+  workflow:
+
+      $ vault plugin register \
+          -sha256=d3f0a8b... \
+          -cosign-bundle=my-custom-plugin.bundle \
+          -cosign-certificate-identity=https://github.com/example/my-custom-plugin/.github/workflows/release.yml@refs/heads/main \
+          -cosign-certificate-oidc-issuer=https://token.actions.githubusercontent.com \
+          secret my-custom-plugin
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -105,7 +122,7 @@ func (c *PluginRegisterCommand) Flags() *FlagSets {
 		Name:       "runtime",
 		Target:     &c.flagRuntime,
 		Completion: complete.PredictAnything,
-		Usage:      "Vault plugin runtime to use if oci_image is specified.",
+		Usage:      "Vault plugin runtime to use. Must be a container runtime if oci_image is specified, and a process runtime otherwise.",
 	})
 
 	f.StringSliceVar(&StringSliceVar{
@@ -116,6 +133,43 @@ func (c *PluginRegisterCommand) Flags() *FlagSets {
 			"flag can be specified multiple times to specify multiple environment variables.",
 	})
 
+	f.StringVar(&StringVar{
+		Name:       "cosign-key",
+		Target:     &c.flagCosignKey,
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to the PEM encoded public key the plugin binary is signed with. " +
+			"Requires -cosign-signature.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "cosign-signature",
+		Target:     &c.flagCosignSignature,
+		Completion: complete.PredictFiles("*"),
+		Usage:      "Path to the base64 encoded cosign signature of the plugin binary.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "cosign-bundle",
+		Target:     &c.flagCosignBundle,
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to the cosign bundle of a keyless signature of the plugin binary. " +
+			"Requires -cosign-certificate-identity and -cosign-certificate-oidc-issuer.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "cosign-certificate-identity",
+		Target:     &c.flagCosignCertificateIdentity,
+		Completion: complete.PredictAnything,
+		Usage:      "Email address or URI the signing certificate of -cosign-bundle must be issued to.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "cosign-certificate-oidc-issuer",
+		Target:     &c.flagCosignCertificateOIDCIssuer,
+		Completion: complete.PredictAnything,
+		Usage:      "OIDC issuer which must have authenticated the identity of the signing certificate of -cosign-bundle.",
+	})
+
 	return set
 }
 
@@ -175,7 +229,7 @@ func (c *PluginRegisterCommand) Run(args []string) int {
 		command = pluginName
 	}
 
-	if err := client.Sys().RegisterPlugin(&api.RegisterPluginInput{
+	input := &api.RegisterPluginInput{
 		Name:     pluginName,
 		Type:     pluginType,
 		Args:     c.flagArgs,
@@ -185,7 +239,30 @@ func (c *PluginRegisterCommand) Run(args []string) int {
 		OCIImage: c.flagOCIImage,
 		Runtime:  c.flagRuntime,
 		Env:      c.flagEnv,
-	}); err != nil {
+
+		CosignCertificateIdentity:   c.flagCosignCertificateIdentity,
+		CosignCertificateOIDCIssuer: c.flagCosignCertificateOIDCIssuer,
+	}
+	for _, file := range []struct {
+		path   string
+		target *string
+	}{
+		{c.flagCosignKey, &input.CosignPublicKey},
+		{c.flagCosignSignature, &input.CosignSignature},
+		{c.flagCosignBundle, &input.CosignBundle},
+	} {
+		if file.path == "" {
+			continue
+		}
+		contents, err := os.ReadFile(file.path)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error reading %s: %s", file.path, err))
+			return 1
+		}
+		*file.target = strings.TrimSpace(string(contents))
+	}
+
+	if err := client.Sys().RegisterPlugin(input); err != nil {
 		c.UI.Error(fmt.Sprintf("Error registering plugin %s: %s", pluginName, err))
 		return 2
 	}
@@ -125,12 +125,14 @@ func (c *PluginRuntimeInfoCommand) Run(args []string) int {
 	}
 
 	data := map[string]interface{}{
-		"name":          resp.Name,
-		"type":          resp.Type,
-		"oci_runtime":   resp.OCIRuntime,
-		"cgroup_parent": resp.CgroupParent,
-		"cpu_nanos":     resp.CPU,
-		"memory_bytes":  resp.Memory,
+		"name":           resp.Name,
+		"type":           resp.Type,
+		"oci_runtime":    resp.OCIRuntime,
+		"cgroup_parent":  resp.CgroupParent,
+		"cpu_nanos":      resp.CPU,
+		"memory_bytes":   resp.Memory,
+		"max_restarts":   resp.MaxRestarts,
+		"restart_window": resp.RestartWindow,
 	}
 
 	if c.flagField != "" {
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/cli"
 	"github.com/hashicorp/vault/api"
@@ -21,12 +22,14 @@ var (
 type PluginRuntimeRegisterCommand struct {
 	*BaseCommand
 
-	flagType         string
-	flagOCIRuntime   string
-	flagCgroupParent string
-	flagCPUNanos     int64
-	flagMemoryBytes  int64
-	flagRootless     bool
+	flagType          string
+	flagOCIRuntime    string
+	flagCgroupParent  string
+	flagCPUNanos      int64
+	flagMemoryBytes   int64
+	flagRootless      bool
+	flagMaxRestarts   int64
+	flagRestartWindow time.Duration
 }
 
 func (c *PluginRuntimeRegisterCommand) Synopsis() string {
@@ -37,13 +40,18 @@ func (c *PluginRuntimeRegisterCommand) Help() string {
 	helpText := `
 Usage: vault plugin runtime register [options] NAME
 
-  Registers a new plugin runtime in the catalog. Currently, Vault only supports registering runtimes of type "container".
+  Registers a new plugin runtime in the catalog. Vault supports registering runtimes of type "container" and "process".
 The OCI runtime must be available on Vault's host. If no OCI runtime is specified, Vault will use "runsc", gVisor's OCI runtime.
+Runtimes of type "process" limit the resources of plugins run as processes on Vault's host.
 
   Register the plugin runtime named my-custom-plugin-runtime:
 
       $ vault plugin runtime register -type=container -oci_runtime=my-oci-runtime my-custom-plugin-runtime
 
+  Register a process runtime limiting plugins to 256MiB of memory and 5 restarts per minute:
+
+      $ vault plugin runtime register -type=process -memory_bytes=268435456 -max_restarts=5 my-process-runtime
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -58,7 +66,7 @@ func (c *PluginRuntimeRegisterCommand) Flags() *FlagSets {
 		Name:       "type",
 		Target:     &c.flagType,
 		Completion: complete.PredictAnything,
-		Usage:      "Plugin runtime type. Vault currently only supports \"container\" runtime type.",
+		Usage:      "Plugin runtime type. Vault supports \"container\" and \"process\" runtime types.",
 	})
 
 	f.StringVar(&StringVar{
@@ -72,21 +80,21 @@ func (c *PluginRuntimeRegisterCommand) Flags() *FlagSets {
 		Name:       "cgroup_parent",
 		Target:     &c.flagCgroupParent,
 		Completion: complete.PredictAnything,
-		Usage:      "Parent cgroup to set for each container. This can be used to control the total resource usage for a group of plugins.",
+		Usage:      "Parent cgroup to set for each container or plugin process. This can be used to control the total resource usage for a group of plugins.",
 	})
 
 	f.Int64Var(&Int64Var{
 		Name:       "cpu_nanos",
 		Target:     &c.flagCPUNanos,
 		Completion: complete.PredictAnything,
-		Usage:      "CPU limit to set per container in nanos. Defaults to no limit.",
+		Usage:      "CPU limit to set per container or plugin process in nanos. Defaults to no limit.",
 	})
 
 	f.Int64Var(&Int64Var{
 		Name:       "memory_bytes",
 		Target:     &c.flagMemoryBytes,
 		Completion: complete.PredictAnything,
-		Usage:      "Memory limit to set per container in bytes. Defaults to no limit.",
+		Usage:      "Memory limit to set per container or plugin process in bytes. Defaults to no limit.",
 	})
 
 	f.BoolVar(&BoolVar{
@@ -98,6 +106,21 @@ func (c *PluginRuntimeRegisterCommand) Flags() *FlagSets {
 			"image is also configured to run as a non-root user.",
 	})
 
+	f.Int64Var(&Int64Var{
+		Name:       "max_restarts",
+		Target:     &c.flagMaxRestarts,
+		Completion: complete.PredictAnything,
+		Usage: "Maximum number of times a plugin is restarted after exiting " +
+			"unexpectedly within the restart window. Defaults to no limit.",
+	})
+
+	f.DurationVar(&DurationVar{
+		Name:       "restart_window",
+		Target:     &c.flagRestartWindow,
+		Completion: complete.PredictAnything,
+		Usage:      "Window the maximum number of restarts applies to. Defaults to 1m.",
+	})
+
 	return set
 }
 
@@ -154,7 +177,7 @@ func (c *PluginRuntimeRegisterCommand) Run(args []string) int {
 	ociRuntime := strings.TrimSpace(c.flagOCIRuntime)
 	cgroupParent := strings.TrimSpace(c.flagCgroupParent)
 
-	if err := client.Sys().RegisterPluginRuntime(context.Background(), &api.RegisterPluginRuntimeInput{
+	input := &api.RegisterPluginRuntimeInput{
 		Name:         runtimeName,
 		Type:         runtimeType,
 		OCIRuntime:   ociRuntime,
@@ -162,7 +185,12 @@ func (c *PluginRuntimeRegisterCommand) Run(args []string) int {
 		CPU:          c.flagCPUNanos,
 		Memory:       c.flagMemoryBytes,
 		Rootless:     c.flagRootless,
-	}); err != nil {
+		MaxRestarts:  c.flagMaxRestarts,
+	}
+	if c.flagRestartWindow > 0 {
+		input.RestartWindow = c.flagRestartWindow.String()
+	}
+	if err := client.Sys().RegisterPluginRuntime(context.Background(), input); err != nil {
 		c.UI.Error(fmt.Sprintf("Error registering plugin runtime %s: %s", runtimeName, err))
 		return 2
 	}
@@ -0,0 +1,178 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/api"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*SecretsImportCommand)(nil)
+	_ cli.CommandAutocomplete = (*SecretsImportCommand)(nil)
+)
+
+type SecretsImportCommand struct {
+	*BaseCommand
+
+	flagConfig    map[string]string
+	flagPrefix    string
+	flagSecrets   []string
+	flagDryRun    bool
+	flagOverwrite bool
+}
+
+func (c *SecretsImportCommand) Synopsis() string {
+	return "Import secrets from an external secret manager"
+}
+
+func (c *SecretsImportCommand) Help() string {
+	helpText := `
+Usage: vault secrets import [options] SOURCE MOUNT
+
+  Imports the secrets of an external secret manager into the KV version 2
+  secrets engine at MOUNT. SOURCE is the type of the secret manager: "aws" for
+  AWS Secrets Manager, "azure" for Azure Key Vault or "gcp" for GCP Secret
+  Manager. The names of the secrets are kept, and their tags or labels are
+  written as the custom metadata of the imported secrets.
+
+  Existing secrets are skipped unless -overwrite is given. Use -dry-run to list
+  the secrets which would be imported without writing them.
+
+  List the secrets of AWS Secrets Manager which would be imported into kv/:
+
+      $ vault secrets import -dry-run -config=region=us-east-1 aws kv
+
+  Import two secrets of a GCP project under kv/gcp/:
+
+      $ vault secrets import -config=project=my-project -prefix=gcp \
+          -secret=db-password -secret=api-key gcp kv
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SecretsImportCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringMapVar(&StringMapVar{
+		Name:       "config",
+		Target:     &c.flagConfig,
+		Completion: complete.PredictAnything,
+		Usage: "Key-value pair provided as key=value for the configuration of the " +
+			"source, such as its region and credentials. This can be specified " +
+			"multiple times.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "prefix",
+		Target:     &c.flagPrefix,
+		Completion: complete.PredictAnything,
+		Usage:      "Path within the secrets engine under which secrets are imported.",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "secret",
+		Target:     &c.flagSecrets,
+		Completion: complete.PredictAnything,
+		Usage: "Name of a secret of the source to import. To import multiple " +
+			"secrets, specify this flag multiple times. If unspecified, all " +
+			"secrets of the source are imported.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "dry-run",
+		Target:  &c.flagDryRun,
+		Default: false,
+		Usage:   "List the secrets which would be imported without writing them.",
+	})
+
+	f.BoolVar(&BoolVar{
+		Name:    "overwrite",
+		Target:  &c.flagOverwrite,
+		Default: false,
+		Usage:   "Overwrite secrets which already exist in the secrets engine.",
+	})
+
+	return set
+}
+
+func (c *SecretsImportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictSet("aws", "azure", "gcp")
+}
+
+func (c *SecretsImportCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *SecretsImportCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	switch {
+	case len(args) < 2:
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected 2, got %d)", len(args)))
+		return 1
+	case len(args) > 2:
+		c.UI.Error(fmt.Sprintf("Too many arguments (expected 2, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	mount := ensureTrailingSlash(sanitizePath(args[1]))
+	out, err := client.Sys().ImportSecrets(&api.ImportSecretsInput{
+		Source:    args[0],
+		Config:    c.flagConfig,
+		Mount:     mount,
+		Prefix:    c.flagPrefix,
+		Secrets:   c.flagSecrets,
+		DryRun:    c.flagDryRun,
+		Overwrite: c.flagOverwrite,
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error importing secrets into %s: %s", mount, err))
+		return 2
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, out)
+	}
+
+	for _, warning := range out.Warnings {
+		c.UI.Warn(fmt.Sprintf("WARNING! %s", warning))
+	}
+	if len(out.Results) == 0 {
+		c.UI.Output(fmt.Sprintf("No secrets found in the %s source", args[0]))
+		return 0
+	}
+
+	rows := []string{"Name | Path | Status | Error"}
+	for _, result := range out.Results {
+		rows = append(rows, fmt.Sprintf("%s | %s | %s | %s", result.Name, result.Path, result.Status, result.Error))
+	}
+	c.UI.Output(tableOutput(rows, nil))
+
+	// Secrets which failed to import are reported as warnings
+	if len(out.Warnings) > 0 {
+		return 2
+	}
+	return 0
+}
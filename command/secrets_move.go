@@ -35,15 +35,16 @@ func (c *SecretsMoveCommand) Help() string {
 Usage: vault secrets move [options] SOURCE DESTINATION
 
   Moves an existing secrets engine to a new path. Any leases from the old
-  secrets engine are revoked, but all configuration associated with the engine
-  is preserved. It initiates the migration and intermittently polls its status,
+  secrets engine are moved with it when staying in the same namespace, and
+  revoked otherwise. All configuration associated with the engine is
+  preserved. It initiates the migration and intermittently polls its status,
   exiting if a final state is reached.
 
   This command works within or across namespaces, both source and destination paths
   can be prefixed with a namespace heirarchy relative to the current namespace.
 
-  WARNING! Moving a secrets engine will revoke any leases from the
-  old engine.
+  WARNING! Moving a secrets engine to another namespace will revoke any
+  leases from the old engine.
 
   Move the secrets engine at secret/ to generic/:
 
@@ -52,6 +52,7 @@ import (
 	vaulthttp "github.com/hashicorp/vault/http"
 	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/hashicorp/vault/internalshared/listenerutil"
+	"github.com/hashicorp/vault/limits"
 	"github.com/hashicorp/vault/plugins/event"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
@@ -446,6 +447,12 @@ func (c *ServerCommand) parseConfig() (*server.Config, []configutil.ConfigError,
 		}
 	}
 
+	if config != nil {
+		if err := config.CheckCryptoPolicy(); err != nil {
+			return nil, nil, fmt.Errorf("error validating crypto policy: %w", err)
+		}
+	}
+
 	if config != nil && config.Entropy != nil && config.Entropy.Mode == configutil.EntropyAugmentation && constants.IsFIPS() {
 		c.UI.Warn("WARNING: Entropy Augmentation is not supported in FIPS 140-2 Inside mode; disabling from server configuration!\n")
 		config.Entropy = nil
@@ -1446,6 +1453,12 @@ func (c *ServerCommand) Run(args []string) int {
 		info["request limiter"] = "disabled"
 	}
 
+	infoKeys = append(infoKeys, "load shedding")
+	info["load shedding"] = "disabled"
+	if config.LoadShedding != nil && !config.LoadShedding.Disable {
+		info["load shedding"] = "enabled"
+	}
+
 	sort.Strings(infoKeys)
 	c.UI.Output("==> Vault server configuration:\n")
 
@@ -1603,6 +1616,10 @@ func (c *ServerCommand) Run(args []string) int {
 		coreShutdownDoneCh = core.ShutdownDone()
 	}
 
+	// The configuration the listeners and telemetry were set up with, which a
+	// reload does not change
+	startupConfig := config
+
 	// Wait for shutdown
 	shutdownTriggered := false
 	retCode := 0
@@ -1625,6 +1642,7 @@ func (c *ServerCommand) Run(args []string) int {
 			// Check for new log level
 			var config *server.Config
 			var configErrors []configutil.ConfigError
+			var configuredSeals []*configutil.KMS
 			for _, path := range c.flagConfigs {
 				current, err := server.LoadConfig(path)
 				if err != nil {
@@ -1652,6 +1670,10 @@ func (c *ServerCommand) Run(args []string) int {
 				c.logger.Warn(cErr.String())
 			}
 
+			// Keep the seals of the configuration files, which are replaced
+			// when they fail to reload
+			configuredSeals = config.Seals
+
 			if !cmp.Equal(core.GetCoreConfigInternal().Seals, config.Seals) {
 				setSealResponse, err = c.reloadSeals(ctx, core, config)
 				if err != nil {
@@ -1664,6 +1686,11 @@ func (c *ServerCommand) Run(args []string) int {
 				}
 			}
 
+			core.SetConfigDrift(server.NewConfigDriftReport(startupConfig, config, configuredSeals))
+			if core.ConfigDrift().RestartRequired() {
+				c.logger.Warn("configuration changes require a restart to take effect, see sys/config/state/drift")
+			}
+
 			core.SetConfig(config)
 
 			// reloading custom response headers to make sure we have
@@ -3095,6 +3122,9 @@ func createCoreConfig(c *ServerCommand, config *server.Config, backend physical.
 		CacheSize:                      config.CacheSize,
 		PluginDirectory:                config.PluginDirectory,
 		PluginTmpdir:                   config.PluginTmpdir,
+		CryptoPolicy:                   config.CryptoPolicy,
+		PluginCosignFulcioCAFile:       config.PluginCosignFulcioCAFile,
+		PluginCosignRekorKeyFile:       config.PluginCosignRekorKeyFile,
 		PluginFileUid:                  config.PluginFileUid,
 		PluginFilePermissions:          config.PluginFilePermissions,
 		EnableUI:                       config.EnableUI,
@@ -3122,6 +3152,43 @@ func createCoreConfig(c *ServerCommand, config *server.Config, backend physical.
 		coreConfig.DisableRequestLimiter = config.RequestLimiter.Disable
 	}
 
+	if config.LoadShedding != nil && !config.LoadShedding.Disable {
+		coreConfig.LoadShedding = &limits.OverloadConfig{
+			MaxInFlight:  int(config.LoadShedding.MaxInFlight),
+			MaxLatency:   config.LoadShedding.MaxLatency,
+			QueueTimeout: config.LoadShedding.QueueTimeout,
+			RetryAfter:   config.LoadShedding.RetryAfter,
+		}
+	}
+
+	if config.GenerateRoot != nil {
+		coreConfig.GenerateRoot = &vault.GenerateRootTokenConfig{
+			TokenType:            logical.TokenTypeService,
+			TTL:                  config.GenerateRoot.TTL,
+			BoundCIDRs:           config.GenerateRoot.BoundCIDRs,
+			RequireJustification: config.GenerateRoot.RequireJustification,
+		}
+		if config.GenerateRoot.TokenType == "batch" {
+			coreConfig.GenerateRoot.TokenType = logical.TokenTypeBatch
+		}
+	}
+
+	for _, l := range config.StorageLimits {
+		coreConfig.StorageLimits = append(coreConfig.StorageLimits, &vault.StorageLimit{
+			Prefix:       l.Prefix,
+			MaxEntrySize: l.MaxEntrySize,
+			MaxEntries:   l.MaxEntries,
+		})
+	}
+
+	for _, l := range config.RequestLimits {
+		coreConfig.RequestLimits = append(coreConfig.RequestLimits, &vault.RequestLimit{
+			PathPrefix:           l.PathPrefix,
+			MaxRequestSize:       l.MaxRequestSize,
+			MaxRequestParameters: l.MaxRequestParameters,
+		})
+	}
+
 	if c.flagDev {
 		coreConfig.EnableRaw = true
 		coreConfig.EnableIntrospection = true
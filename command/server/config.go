@@ -17,12 +17,14 @@ import (
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	sockaddr "github.com/hashicorp/go-sockaddr"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
 	"github.com/hashicorp/vault/helper/experiments"
 	"github.com/hashicorp/vault/helper/osutil"
 	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/cryptopolicy"
 	"github.com/hashicorp/vault/sdk/helper/strutil"
 	"github.com/hashicorp/vault/sdk/helper/testcluster"
 	"github.com/mitchellh/mapstructure"
@@ -50,6 +52,12 @@ type Config struct {
 
 	ServiceRegistration *ServiceRegistration `hcl:"-"`
 
+	GenerateRoot *GenerateRoot `hcl:"-"`
+
+	StorageLimits []*StorageLimit `hcl:"-"`
+
+	RequestLimits []*RequestLimit `hcl:"-"`
+
 	Experiments []string `hcl:"experiments"`
 
 	CacheSize                int         `hcl:"cache_size"`
@@ -68,9 +76,14 @@ type Config struct {
 
 	ClusterCipherSuites string `hcl:"cluster_cipher_suites"`
 
+	CryptoPolicy string `hcl:"crypto_policy"`
+
 	PluginDirectory string `hcl:"plugin_directory"`
 	PluginTmpdir    string `hcl:"plugin_tmpdir"`
 
+	PluginCosignFulcioCAFile string `hcl:"plugin_cosign_fulcio_ca_file"`
+	PluginCosignRekorKeyFile string `hcl:"plugin_cosign_rekor_key_file"`
+
 	PluginFileUid int `hcl:"plugin_file_uid"`
 
 	PluginFilePermissions    int         `hcl:"-"`
@@ -129,6 +142,15 @@ func (c *Config) Validate(sourceFilePath string) []configutil.ConfigError {
 	if c.ServiceRegistration != nil {
 		results = append(results, c.ServiceRegistration.Validate(sourceFilePath)...)
 	}
+	if c.GenerateRoot != nil {
+		results = append(results, c.GenerateRoot.Validate(sourceFilePath)...)
+	}
+	for _, l := range c.StorageLimits {
+		results = append(results, l.Validate(sourceFilePath)...)
+	}
+	for _, l := range c.RequestLimits {
+		results = append(results, l.Validate(sourceFilePath)...)
+	}
 	for _, l := range c.Listeners {
 		results = append(results, l.Validate(sourceFilePath)...)
 	}
@@ -255,6 +277,72 @@ func (b *ServiceRegistration) GoString() string {
 	return fmt.Sprintf("*%#v", *b)
 }
 
+// GenerateRoot restricts the root tokens produced by root generation.
+type GenerateRoot struct {
+	UnusedKeys configutil.UnusedKeyMap `hcl:",unusedKeyPositions"`
+
+	TokenType string `hcl:"token_type"`
+
+	TTL    time.Duration `hcl:"-"`
+	TTLRaw interface{}   `hcl:"ttl"`
+
+	BoundCIDRs    []*sockaddr.SockAddrMarshaler `hcl:"-"`
+	BoundCIDRsRaw interface{}                   `hcl:"bound_cidrs"`
+
+	RequireJustification    bool        `hcl:"-"`
+	RequireJustificationRaw interface{} `hcl:"require_justification"`
+}
+
+func (g *GenerateRoot) Validate(source string) []configutil.ConfigError {
+	return configutil.ValidateUnusedFields(g.UnusedKeys, source)
+}
+
+func (g *GenerateRoot) GoString() string {
+	return fmt.Sprintf("*%#v", *g)
+}
+
+// StorageLimit restricts the size of individual entries and the number of
+// entries written under a storage path prefix.
+type StorageLimit struct {
+	UnusedKeys configutil.UnusedKeyMap `hcl:",unusedKeyPositions"`
+
+	Prefix string `hcl:"-"`
+
+	MaxEntrySize    int         `hcl:"-"`
+	MaxEntrySizeRaw interface{} `hcl:"max_entry_size"`
+
+	MaxEntries int `hcl:"max_entries"`
+}
+
+func (l *StorageLimit) Validate(source string) []configutil.ConfigError {
+	return configutil.ValidateUnusedFields(l.UnusedKeys, source)
+}
+
+func (l *StorageLimit) GoString() string {
+	return fmt.Sprintf("*%#v", *l)
+}
+
+// RequestLimit overrides the maximum request size of the listeners, and
+// limits the number of request parameters, under an API path prefix.
+type RequestLimit struct {
+	UnusedKeys configutil.UnusedKeyMap `hcl:",unusedKeyPositions"`
+
+	PathPrefix string `hcl:"-"`
+
+	MaxRequestSize    int64       `hcl:"-"`
+	MaxRequestSizeRaw interface{} `hcl:"max_request_size"`
+
+	MaxRequestParameters int `hcl:"max_request_parameters"`
+}
+
+func (l *RequestLimit) Validate(source string) []configutil.ConfigError {
+	return configutil.ValidateUnusedFields(l.UnusedKeys, source)
+}
+
+func (l *RequestLimit) GoString() string {
+	return fmt.Sprintf("*%#v", *l)
+}
+
 func NewConfig() *Config {
 	return &Config{
 		SharedConfig: new(configutil.SharedConfig),
@@ -289,6 +377,21 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.ServiceRegistration = c2.ServiceRegistration
 	}
 
+	result.GenerateRoot = c.GenerateRoot
+	if c2.GenerateRoot != nil {
+		result.GenerateRoot = c2.GenerateRoot
+	}
+
+	result.StorageLimits = c.StorageLimits
+	if len(c2.StorageLimits) > 0 {
+		result.StorageLimits = c2.StorageLimits
+	}
+
+	result.RequestLimits = c.RequestLimits
+	if len(c2.RequestLimits) > 0 {
+		result.RequestLimits = c2.RequestLimits
+	}
+
 	result.CacheSize = c.CacheSize
 	if c2.CacheSize != 0 {
 		result.CacheSize = c2.CacheSize
@@ -326,6 +429,11 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.ClusterCipherSuites = c2.ClusterCipherSuites
 	}
 
+	result.CryptoPolicy = c.CryptoPolicy
+	if c2.CryptoPolicy != "" {
+		result.CryptoPolicy = c2.CryptoPolicy
+	}
+
 	result.EnableUI = c.EnableUI
 	if c2.EnableUI {
 		result.EnableUI = c2.EnableUI
@@ -369,6 +477,16 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.PluginTmpdir = c2.PluginTmpdir
 	}
 
+	result.PluginCosignFulcioCAFile = c.PluginCosignFulcioCAFile
+	if c2.PluginCosignFulcioCAFile != "" {
+		result.PluginCosignFulcioCAFile = c2.PluginCosignFulcioCAFile
+	}
+
+	result.PluginCosignRekorKeyFile = c.PluginCosignRekorKeyFile
+	if c2.PluginCosignRekorKeyFile != "" {
+		result.PluginCosignRekorKeyFile = c2.PluginCosignRekorKeyFile
+	}
+
 	result.PluginFileUid = c.PluginFileUid
 	if c2.PluginFileUid != 0 {
 		result.PluginFileUid = c2.PluginFileUid
@@ -522,6 +640,51 @@ func CheckConfig(c *Config, e error) (*Config, error) {
 	return c, nil
 }
 
+// CheckCryptoPolicy validates the crypto policy, and the seals and listeners
+// against it. Listeners without TLS cipher suites are restricted to those the
+// policy allows. It must be called on the merged configuration.
+func (c *Config) CheckCryptoPolicy() error {
+	if err := cryptopolicy.Validate(c.CryptoPolicy); err != nil {
+		return err
+	}
+	if c.CryptoPolicy == cryptopolicy.None {
+		return nil
+	}
+
+	for _, seal := range c.Seals {
+		if seal.Disabled {
+			continue
+		}
+		if err := cryptopolicy.CheckSealType(c.CryptoPolicy, seal.Type); err != nil {
+			return fmt.Errorf("seals: %w", err)
+		}
+	}
+
+	for _, l := range c.Listeners {
+		if l.TLSDisable {
+			continue
+		}
+		for _, version := range []string{l.TLSMinVersion, l.TLSMaxVersion} {
+			if version == "" {
+				continue
+			}
+			if err := cryptopolicy.CheckTLSVersion(c.CryptoPolicy, version); err != nil {
+				return fmt.Errorf("listener %q: %w", l.Address, err)
+			}
+		}
+		if len(l.TLSCipherSuites) == 0 {
+			l.TLSCipherSuites = cryptopolicy.TLSCipherSuites(c.CryptoPolicy)
+			continue
+		}
+		for _, suite := range l.TLSCipherSuites {
+			if err := cryptopolicy.CheckTLSCipherSuite(c.CryptoPolicy, suite); err != nil {
+				return fmt.Errorf("listener %q: %w", l.Address, err)
+			}
+		}
+	}
+	return nil
+}
+
 // LoadConfigFile loads the configuration from the given file.
 func LoadConfigFile(path string) (*Config, error) {
 	// Open the file
@@ -744,6 +907,27 @@ func ParseConfig(d, source string) (*Config, error) {
 		}
 	}
 
+	if o := list.Filter("generate_root"); len(o.Items) > 0 {
+		delete(result.UnusedKeys, "generate_root")
+		if err := parseGenerateRoot(result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'generate_root': %w", err)
+		}
+	}
+
+	if o := list.Filter("storage_limit"); len(o.Items) > 0 {
+		delete(result.UnusedKeys, "storage_limit")
+		if err := parseStorageLimits(result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'storage_limit': %w", err)
+		}
+	}
+
+	if o := list.Filter("request_limit"); len(o.Items) > 0 {
+		delete(result.UnusedKeys, "request_limit")
+		if err := parseRequestLimits(result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'request_limit': %w", err)
+		}
+	}
+
 	if err := validateExperiments(result.Experiments); err != nil {
 		return nil, fmt.Errorf("error validating experiment(s) from config: %w", err)
 	}
@@ -1090,6 +1274,143 @@ func parseServiceRegistration(result *Config, list *ast.ObjectList, name string)
 	return nil
 }
 
+func parseStorageLimits(result *Config, list *ast.ObjectList) error {
+	prefixes := make(map[string]struct{}, len(list.Items))
+	for _, item := range list.Items {
+		if len(item.Keys) == 0 {
+			return errors.New("storage path prefix is required")
+		}
+		prefix := item.Keys[0].Token.Value().(string)
+
+		var l StorageLimit
+		if err := hcl.DecodeObject(&l, item.Val); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("storage_limit.%s:", prefix))
+		}
+
+		// Entries are counted by listing the prefix, so it must be a directory
+		if !strings.HasSuffix(prefix, "/") {
+			return fmt.Errorf("storage path prefix %q must end with '/'", prefix)
+		}
+		if _, ok := prefixes[prefix]; ok {
+			return fmt.Errorf("storage path prefix %q is limited more than once", prefix)
+		}
+		prefixes[prefix] = struct{}{}
+		l.Prefix = prefix
+
+		if l.MaxEntrySizeRaw != nil {
+			size, err := parseutil.ParseCapacityString(l.MaxEntrySizeRaw)
+			if err != nil {
+				return fmt.Errorf("invalid max_entry_size for %q: %w", prefix, err)
+			}
+			l.MaxEntrySize = int(size)
+			l.MaxEntrySizeRaw = nil
+		}
+		if l.MaxEntries < 0 {
+			return fmt.Errorf("invalid max_entries for %q: must not be negative", prefix)
+		}
+		if l.MaxEntrySize == 0 && l.MaxEntries == 0 {
+			return fmt.Errorf("storage limit for %q must set max_entry_size or max_entries", prefix)
+		}
+
+		result.StorageLimits = append(result.StorageLimits, &l)
+	}
+
+	return nil
+}
+
+func parseRequestLimits(result *Config, list *ast.ObjectList) error {
+	prefixes := make(map[string]struct{}, len(list.Items))
+	for _, item := range list.Items {
+		if len(item.Keys) == 0 {
+			return errors.New("request path prefix is required")
+		}
+		prefix := item.Keys[0].Token.Value().(string)
+
+		var l RequestLimit
+		if err := hcl.DecodeObject(&l, item.Val); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("request_limit.%s:", prefix))
+		}
+
+		if _, ok := prefixes[prefix]; ok {
+			return fmt.Errorf("request path prefix %q is limited more than once", prefix)
+		}
+		prefixes[prefix] = struct{}{}
+		l.PathPrefix = prefix
+
+		if l.MaxRequestSizeRaw != nil {
+			size, err := parseutil.ParseCapacityString(l.MaxRequestSizeRaw)
+			if err != nil {
+				return fmt.Errorf("invalid max_request_size for %q: %w", prefix, err)
+			}
+			l.MaxRequestSize = int64(size)
+			l.MaxRequestSizeRaw = nil
+		}
+		if l.MaxRequestParameters < 0 {
+			return fmt.Errorf("invalid max_request_parameters for %q: must not be negative", prefix)
+		}
+		if l.MaxRequestSize == 0 && l.MaxRequestParameters == 0 {
+			return fmt.Errorf("request limit for %q must set max_request_size or max_request_parameters", prefix)
+		}
+
+		result.RequestLimits = append(result.RequestLimits, &l)
+	}
+
+	return nil
+}
+
+func parseGenerateRoot(result *Config, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'generate_root' block is permitted")
+	}
+
+	var g GenerateRoot
+	if err := hcl.DecodeObject(&g, list.Items[0].Val); err != nil {
+		return multierror.Prefix(err, "generate_root:")
+	}
+
+	switch g.TokenType {
+	case "", "service", "batch":
+	default:
+		return fmt.Errorf("invalid token_type %q, must be \"service\" or \"batch\"", g.TokenType)
+	}
+
+	var err error
+	if g.TTLRaw != nil {
+		if g.TTL, err = parseutil.ParseDurationSecond(g.TTLRaw); err != nil {
+			return fmt.Errorf("invalid ttl: %w", err)
+		}
+		g.TTLRaw = nil
+	}
+
+	if g.BoundCIDRsRaw != nil {
+		if g.BoundCIDRs, err = parseutil.ParseAddrs(g.BoundCIDRsRaw); err != nil {
+			return fmt.Errorf("invalid bound_cidrs: %w", err)
+		}
+		g.BoundCIDRsRaw = nil
+	}
+
+	if g.RequireJustificationRaw != nil {
+		if g.RequireJustification, err = parseutil.ParseBool(g.RequireJustificationRaw); err != nil {
+			return fmt.Errorf("invalid require_justification: %w", err)
+		}
+		g.RequireJustificationRaw = nil
+	}
+
+	// Non-expiring root tokens are not bound to CIDRs, and batch tokens cannot
+	// be revoked, so both need a TTL
+	if g.TTL == 0 {
+		if g.TokenType == "batch" {
+			return errors.New("ttl is required when token_type is \"batch\"")
+		}
+		if len(g.BoundCIDRs) > 0 {
+			return errors.New("ttl is required when bound_cidrs is set")
+		}
+	}
+
+	result.GenerateRoot = &g
+	return nil
+}
+
 // Sanitized returns a copy of the config with all values that are considered
 // sensitive stripped. It also strips all `*Raw` values that are mainly
 // used for parsing.
@@ -1119,9 +1440,14 @@ func (c *Config) Sanitized() map[string]interface{} {
 
 		"cluster_cipher_suites": c.ClusterCipherSuites,
 
+		"crypto_policy": c.CryptoPolicy,
+
 		"plugin_directory": c.PluginDirectory,
 		"plugin_tmpdir":    c.PluginTmpdir,
 
+		"plugin_cosign_fulcio_ca_file": c.PluginCosignFulcioCAFile,
+		"plugin_cosign_rekor_key_file": c.PluginCosignRekorKeyFile,
+
 		"plugin_file_uid": c.PluginFileUid,
 
 		"plugin_file_permissions": c.PluginFilePermissions,
@@ -1201,6 +1527,46 @@ func (c *Config) Sanitized() map[string]interface{} {
 		result["service_registration"] = sanitizedServiceRegistration
 	}
 
+	// Sanitize generate_root stanza
+	if c.GenerateRoot != nil {
+		var boundCIDRs []string
+		for _, cidr := range c.GenerateRoot.BoundCIDRs {
+			boundCIDRs = append(boundCIDRs, cidr.String())
+		}
+		result["generate_root"] = map[string]interface{}{
+			"token_type":            c.GenerateRoot.TokenType,
+			"ttl":                   c.GenerateRoot.TTL / time.Second,
+			"bound_cidrs":           boundCIDRs,
+			"require_justification": c.GenerateRoot.RequireJustification,
+		}
+	}
+
+	// Sanitize storage_limit stanzas
+	if len(c.StorageLimits) > 0 {
+		sanitizedStorageLimits := make([]interface{}, 0, len(c.StorageLimits))
+		for _, l := range c.StorageLimits {
+			sanitizedStorageLimits = append(sanitizedStorageLimits, map[string]interface{}{
+				"prefix":         l.Prefix,
+				"max_entry_size": l.MaxEntrySize,
+				"max_entries":    l.MaxEntries,
+			})
+		}
+		result["storage_limits"] = sanitizedStorageLimits
+	}
+
+	// Sanitize request_limit stanzas
+	if len(c.RequestLimits) > 0 {
+		sanitizedRequestLimits := make([]interface{}, 0, len(c.RequestLimits))
+		for _, l := range c.RequestLimits {
+			sanitizedRequestLimits = append(sanitizedRequestLimits, map[string]interface{}{
+				"path_prefix":            l.PathPrefix,
+				"max_request_size":       l.MaxRequestSize,
+				"max_request_parameters": l.MaxRequestParameters,
+			})
+		}
+		result["request_limits"] = sanitizedRequestLimits
+	}
+
 	entConfigResult := c.entConfig.Sanitized()
 	for k, v := range entConfigResult {
 		result[k] = v
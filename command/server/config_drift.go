@@ -0,0 +1,236 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package server
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/vault/internalshared/configutil"
+)
+
+// Statuses of the changes of a ConfigDriftReport.
+const (
+	// ConfigChangeApplied is the status of changes applied by a reload.
+	ConfigChangeApplied = "applied"
+
+	// ConfigChangeRequiresRestart is the status of changes which only take
+	// effect when the server is restarted.
+	ConfigChangeRequiresRestart = "requires_restart"
+)
+
+// reloadableListenerFields are the listener fields applied by a reload.
+var reloadableListenerFields = map[string]struct{}{
+	"custom_response_headers": {},
+}
+
+// ConfigChange is a difference between the configuration the server was started
+// with and its configuration files.
+type ConfigChange struct {
+	// Section is the stanza of the change: "listener", "seal" or "telemetry".
+	Section string `json:"section"`
+
+	// Name identifies the listener or seal changed.
+	Name string `json:"name,omitempty"`
+
+	// Field is the field changed. It is empty when a listener or seal was
+	// added or removed.
+	Field string `json:"field,omitempty"`
+
+	// Previous and Configured are the values of the field when the server was
+	// started and in the configuration files. Seal configurations are omitted
+	// since they contain credentials.
+	Previous   interface{} `json:"previous"`
+	Configured interface{} `json:"configured"`
+
+	// Status is ConfigChangeApplied or ConfigChangeRequiresRestart.
+	Status string `json:"status"`
+}
+
+// ConfigDriftReport lists the changes of the listeners, seals and telemetry
+// between the configuration the server was started with and its configuration
+// files, as of the last reload.
+type ConfigDriftReport struct {
+	Time    time.Time       `json:"time"`
+	Changes []*ConfigChange `json:"changes"`
+}
+
+// RestartRequired returns whether some changes only take effect when the server
+// is restarted.
+func (r *ConfigDriftReport) RestartRequired() bool {
+	for _, change := range r.Changes {
+		if change.Status == ConfigChangeRequiresRestart {
+			return true
+		}
+	}
+	return false
+}
+
+// NewConfigDriftReport compares the configuration the server was started with
+// to the configuration loaded from its files by a reload. configuredSeals are
+// the seals of the configuration files, and the seals of configured are the
+// seals in effect after the reload, which differ when the seals failed to
+// reload.
+func NewConfigDriftReport(started, configured *Config, configuredSeals []*configutil.KMS) *ConfigDriftReport {
+	report := &ConfigDriftReport{
+		Time:    time.Now(),
+		Changes: []*ConfigChange{},
+	}
+
+	report.Changes = append(report.Changes, listenerChanges(started.Listeners, configured.Listeners)...)
+
+	sealStatus := ConfigChangeApplied
+	if !reflect.DeepEqual(configured.Seals, configuredSeals) {
+		sealStatus = ConfigChangeRequiresRestart
+	}
+	report.Changes = append(report.Changes, sealChanges(started.Seals, configuredSeals, sealStatus)...)
+
+	report.Changes = append(report.Changes, telemetryChanges(started.SharedConfig, configured.SharedConfig)...)
+
+	return report
+}
+
+// listenerChanges compares listeners by type and address.
+func listenerChanges(started, configured []*configutil.Listener) []*ConfigChange {
+	listenerName := func(l *configutil.Listener) string {
+		return fmt.Sprintf("%s:%s", l.Type, l.Address)
+	}
+
+	startedByName := make(map[string]*configutil.Listener, len(started))
+	for _, l := range started {
+		startedByName[listenerName(l)] = l
+	}
+	configuredByName := make(map[string]*configutil.Listener, len(configured))
+	for _, l := range configured {
+		configuredByName[listenerName(l)] = l
+	}
+
+	var changes []*ConfigChange
+	for _, name := range sortedKeys(startedByName, configuredByName) {
+		previous, configured := startedByName[name], configuredByName[name]
+		switch {
+		case previous == nil:
+			changes = append(changes, &ConfigChange{Section: "listener", Name: name, Configured: configured.RawConfig, Status: ConfigChangeRequiresRestart})
+		case configured == nil:
+			changes = append(changes, &ConfigChange{Section: "listener", Name: name, Previous: previous.RawConfig, Status: ConfigChangeRequiresRestart})
+		default:
+			for _, field := range sortedKeys(previous.RawConfig, configured.RawConfig) {
+				if reflect.DeepEqual(previous.RawConfig[field], configured.RawConfig[field]) {
+					continue
+				}
+
+				status := ConfigChangeRequiresRestart
+				if _, ok := reloadableListenerFields[field]; ok {
+					status = ConfigChangeApplied
+				}
+				changes = append(changes, &ConfigChange{
+					Section:    "listener",
+					Name:       name,
+					Field:      field,
+					Previous:   previous.RawConfig[field],
+					Configured: configured.RawConfig[field],
+					Status:     status,
+				})
+			}
+		}
+	}
+
+	return changes
+}
+
+// sealChanges compares seals by type and name.
+func sealChanges(started, configured []*configutil.KMS, status string) []*ConfigChange {
+	sealName := func(s *configutil.KMS) string {
+		if s.Name == "" || s.Name == s.Type {
+			return s.Type
+		}
+		return fmt.Sprintf("%s:%s", s.Type, s.Name)
+	}
+
+	startedByName := make(map[string]*configutil.KMS, len(started))
+	for _, s := range started {
+		startedByName[sealName(s)] = s
+	}
+	configuredByName := make(map[string]*configutil.KMS, len(configured))
+	for _, s := range configured {
+		configuredByName[sealName(s)] = s
+	}
+
+	var changes []*ConfigChange
+	for _, name := range sortedKeys(startedByName, configuredByName) {
+		previous, configured := startedByName[name], configuredByName[name]
+		switch {
+		case previous == nil:
+			changes = append(changes, &ConfigChange{Section: "seal", Name: name, Configured: sanitizedSeal(configured), Status: status})
+			continue
+		case configured == nil:
+			changes = append(changes, &ConfigChange{Section: "seal", Name: name, Previous: sanitizedSeal(previous), Status: status})
+			continue
+		case previous.Disabled != configured.Disabled:
+			changes = append(changes, &ConfigChange{Section: "seal", Name: name, Field: "disabled", Previous: previous.Disabled, Configured: configured.Disabled, Status: status})
+		}
+		if previous.Priority != configured.Priority {
+			changes = append(changes, &ConfigChange{Section: "seal", Name: name, Field: "priority", Previous: previous.Priority, Configured: configured.Priority, Status: status})
+		}
+		if !reflect.DeepEqual(previous.Config, configured.Config) {
+			changes = append(changes, &ConfigChange{Section: "seal", Name: name, Field: "config", Status: status})
+		}
+	}
+
+	return changes
+}
+
+// sanitizedSeal returns the fields of the seal which do not contain secrets.
+func sanitizedSeal(s *configutil.KMS) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     s.Type,
+		"name":     s.Name,
+		"disabled": s.Disabled,
+		"priority": s.Priority,
+	}
+}
+
+// telemetryChanges compares the sanitized telemetry stanzas, so that secrets
+// are not reported.
+func telemetryChanges(started, configured *configutil.SharedConfig) []*ConfigChange {
+	telemetry := func(c *configutil.SharedConfig) map[string]interface{} {
+		sanitized, _ := c.Sanitized()["telemetry"].(map[string]interface{})
+		return sanitized
+	}
+	previous, current := telemetry(started), telemetry(configured)
+
+	var changes []*ConfigChange
+	for _, field := range sortedKeys(previous, current) {
+		if reflect.DeepEqual(previous[field], current[field]) {
+			continue
+		}
+		changes = append(changes, &ConfigChange{
+			Section:    "telemetry",
+			Field:      field,
+			Previous:   previous[field],
+			Configured: current[field],
+			Status:     ConfigChangeRequiresRestart,
+		})
+	}
+
+	return changes
+}
+
+// sortedKeys returns the sorted union of the keys of the maps.
+func sortedKeys[V any](maps ...map[string]V) []string {
+	seen := make(map[string]struct{})
+	var keys []string
+	for _, m := range maps {
+		for k := range m {
+			if _, ok := seen[k]; !ok {
+				seen[k] = struct{}{}
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
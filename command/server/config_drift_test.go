@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewConfigDriftReport ensures that changes of the listeners, seals and
+// telemetry are reported with whether a reload applied them.
+func TestNewConfigDriftReport(t *testing.T) {
+	t.Parallel()
+
+	started, err := ParseConfig(`
+listener "tcp" {
+  address     = "127.0.0.1:8200"
+  tls_disable = true
+}
+
+listener "tcp" {
+  address = "127.0.0.1:8300"
+}
+
+seal "transit" {
+  address = "https://vault:8200"
+  token   = "secret"
+}
+
+telemetry {
+  statsd_address = "statsd:8125"
+}
+`, "")
+	require.NoError(t, err)
+
+	configured, err := ParseConfig(`
+listener "tcp" {
+  address     = "127.0.0.1:8200"
+  tls_disable = false
+  custom_response_headers {
+    "default" = {
+      "X-Custom" = ["value"]
+    }
+  }
+}
+
+listener "tcp" {
+  address = "127.0.0.1:8400"
+}
+
+seal "transit" {
+  address = "https://vault:8200"
+  token   = "rotated"
+}
+
+telemetry {
+  statsd_address = "statsd:8126"
+}
+`, "")
+	require.NoError(t, err)
+
+	t.Run("no-changes", func(t *testing.T) {
+		t.Parallel()
+
+		report := NewConfigDriftReport(started, started, started.Seals)
+		require.Empty(t, report.Changes)
+		require.False(t, report.RestartRequired())
+	})
+
+	t.Run("seals-applied", func(t *testing.T) {
+		t.Parallel()
+
+		report := NewConfigDriftReport(started, configured, configured.Seals)
+		require.True(t, report.RestartRequired())
+
+		byName := make(map[string]*ConfigChange)
+		for _, change := range report.Changes {
+			byName[change.Section+"/"+change.Name+"/"+change.Field] = change
+		}
+		require.Len(t, byName, len(report.Changes))
+
+		change := byName["listener/tcp:127.0.0.1:8200/tls_disable"]
+		require.NotNil(t, change)
+		require.Equal(t, true, change.Previous)
+		require.Equal(t, false, change.Configured)
+		require.Equal(t, ConfigChangeRequiresRestart, change.Status)
+
+		change = byName["listener/tcp:127.0.0.1:8200/custom_response_headers"]
+		require.NotNil(t, change)
+		require.Equal(t, ConfigChangeApplied, change.Status)
+
+		change = byName["listener/tcp:127.0.0.1:8300/"]
+		require.NotNil(t, change)
+		require.NotNil(t, change.Previous)
+		require.Nil(t, change.Configured)
+		require.Equal(t, ConfigChangeRequiresRestart, change.Status)
+
+		change = byName["listener/tcp:127.0.0.1:8400/"]
+		require.NotNil(t, change)
+		require.Nil(t, change.Previous)
+		require.NotNil(t, change.Configured)
+
+		change = byName["seal/transit/config"]
+		require.NotNil(t, change)
+		require.Nil(t, change.Previous)
+		require.Nil(t, change.Configured)
+		require.Equal(t, ConfigChangeApplied, change.Status)
+
+		change = byName["telemetry//statsd_address"]
+		require.NotNil(t, change)
+		require.Equal(t, "statsd:8125", change.Previous)
+		require.Equal(t, "statsd:8126", change.Configured)
+		require.Equal(t, ConfigChangeRequiresRestart, change.Status)
+
+		require.Len(t, report.Changes, 6)
+	})
+
+	t.Run("seals-not-applied", func(t *testing.T) {
+		t.Parallel()
+
+		// The seals of the configuration files failed to reload, so the
+		// seals the server was started with are still in effect.
+		shared := *configured.SharedConfig
+		shared.Seals = started.Seals
+		effective := &Config{SharedConfig: &shared}
+
+		report := NewConfigDriftReport(started, effective, configured.Seals)
+		var found bool
+		for _, change := range report.Changes {
+			if change.Section == "seal" {
+				found = true
+				require.Equal(t, ConfigChangeRequiresRestart, change.Status)
+			}
+		}
+		require.True(t, found)
+	})
+}
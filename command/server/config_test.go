@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/stretchr/testify/require"
@@ -294,3 +295,298 @@ func TestCheckConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckCryptoPolicy(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      string
+		expectError string
+	}{
+		{
+			name: "no-policy",
+			config: `
+seal "aead" {}
+listener "tcp" {
+	tls_min_version = "tls10"
+}`,
+		},
+		{
+			name:        "unknown-policy",
+			config:      `crypto_policy = "fips-140-1"`,
+			expectError: `unknown crypto policy "fips-140-1"`,
+		},
+		{
+			name: "compliant",
+			config: `
+crypto_policy = "fips-140-3"
+seal "awskms" {}
+listener "tcp" {
+	tls_cipher_suites = "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"
+}`,
+		},
+		{
+			name: "seal",
+			config: `
+crypto_policy = "fips-140-3"
+seal "aead" {}`,
+			expectError: `seal type "aead"`,
+		},
+		{
+			name: "tls-version",
+			config: `
+crypto_policy = "fips-140-3"
+listener "tcp" {
+	tls_min_version = "tls11"
+}`,
+			expectError: `TLS version "tls11"`,
+		},
+		{
+			name: "tls-cipher-suite",
+			config: `
+crypto_policy = "fips-140-3"
+listener "tcp" {
+	tls_cipher_suites = "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"
+}`,
+			expectError: `TLS cipher suite "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"`,
+		},
+		{
+			name: "tls-disabled",
+			config: `
+crypto_policy = "fips-140-3"
+listener "tcp" {
+	tls_disable = true
+	tls_min_version = "tls10"
+}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseConfig(tt.config, "")
+			require.NoError(t, err)
+
+			err = config.CheckCryptoPolicy()
+			if tt.expectError != "" {
+				require.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			for _, l := range config.Listeners {
+				if config.CryptoPolicy != "" && !l.TLSDisable {
+					require.NotEmpty(t, l.TLSCipherSuites)
+				}
+			}
+		})
+	}
+}
+
+func TestParseGenerateRoot(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      string
+		expectError string
+	}{
+		{
+			name: "valid",
+			config: `
+generate_root {
+	token_type = "batch"
+	ttl = "1h"
+	bound_cidrs = ["10.0.0.0/8", "127.0.0.1/32"]
+	require_justification = true
+}`,
+		},
+		{
+			name: "invalid-token-type",
+			config: `
+generate_root {
+	token_type = "default"
+}`,
+			expectError: `invalid token_type "default"`,
+		},
+		{
+			name: "batch-without-ttl",
+			config: `
+generate_root {
+	token_type = "batch"
+}`,
+			expectError: "ttl is required",
+		},
+		{
+			name: "bound-cidrs-without-ttl",
+			config: `
+generate_root {
+	bound_cidrs = "10.0.0.0/8"
+}`,
+			expectError: "ttl is required",
+		},
+		{
+			name: "invalid-bound-cidrs",
+			config: `
+generate_root {
+	ttl = "1h"
+	bound_cidrs = "not-a-cidr"
+}`,
+			expectError: "invalid bound_cidrs",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseConfig(tt.config, "")
+			if tt.expectError != "" {
+				require.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, config.GenerateRoot)
+			require.Equal(t, "batch", config.GenerateRoot.TokenType)
+			require.Equal(t, time.Hour, config.GenerateRoot.TTL)
+			require.Len(t, config.GenerateRoot.BoundCIDRs, 2)
+			require.True(t, config.GenerateRoot.RequireJustification)
+			require.Empty(t, config.Validate(""))
+		})
+	}
+}
+
+func TestParseStorageLimits(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      string
+		expectError string
+	}{
+		{
+			name: "valid",
+			config: `
+storage_limit "logical/" {
+	max_entry_size = "512KiB"
+}
+storage_limit "sys/expire/" {
+	max_entries = 1000
+}`,
+		},
+		{
+			name: "prefix-not-directory",
+			config: `
+storage_limit "logical" {
+	max_entries = 1000
+}`,
+			expectError: "must end with '/'",
+		},
+		{
+			name: "duplicate-prefix",
+			config: `
+storage_limit "logical/" {
+	max_entries = 1000
+}
+storage_limit "logical/" {
+	max_entry_size = 1024
+}`,
+			expectError: "limited more than once",
+		},
+		{
+			name: "no-limit",
+			config: `
+storage_limit "logical/" {
+}`,
+			expectError: "must set max_entry_size or max_entries",
+		},
+		{
+			name: "invalid-size",
+			config: `
+storage_limit "logical/" {
+	max_entry_size = "large"
+}`,
+			expectError: "invalid max_entry_size",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseConfig(tt.config, "")
+			if tt.expectError != "" {
+				require.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, config.StorageLimits, 2)
+			require.Equal(t, "logical/", config.StorageLimits[0].Prefix)
+			require.Equal(t, 512*1024, config.StorageLimits[0].MaxEntrySize)
+			require.Equal(t, "sys/expire/", config.StorageLimits[1].Prefix)
+			require.Equal(t, 1000, config.StorageLimits[1].MaxEntries)
+			require.Empty(t, config.Validate(""))
+		})
+	}
+}
+
+// TestParseRequestLimits verifies the parsing and validation of request_limit
+// stanzas.
+func TestParseRequestLimits(t *testing.T) {
+	testCases := []struct {
+		name        string
+		config      string
+		expectError string
+	}{
+		{
+			name: "valid",
+			config: `
+request_limit "pki/" {
+	max_request_size = "256MiB"
+}
+request_limit "secret/data/" {
+	max_request_parameters = 50
+}`,
+		},
+		{
+			name: "duplicate-prefix",
+			config: `
+request_limit "pki/" {
+	max_request_size = "256MiB"
+}
+request_limit "pki/" {
+	max_request_parameters = 50
+}`,
+			expectError: "limited more than once",
+		},
+		{
+			name: "no-limit",
+			config: `
+request_limit "pki/" {
+}`,
+			expectError: "must set max_request_size or max_request_parameters",
+		},
+		{
+			name: "invalid-size",
+			config: `
+request_limit "pki/" {
+	max_request_size = "large"
+}`,
+			expectError: "invalid max_request_size",
+		},
+		{
+			name: "negative-parameters",
+			config: `
+request_limit "pki/" {
+	max_request_parameters = -1
+}`,
+			expectError: "invalid max_request_parameters",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseConfig(tt.config, "")
+			if tt.expectError != "" {
+				require.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, config.RequestLimits, 2)
+			require.Equal(t, "pki/", config.RequestLimits[0].PathPrefix)
+			require.Equal(t, int64(256*1024*1024), config.RequestLimits[0].MaxRequestSize)
+			require.Equal(t, "secret/data/", config.RequestLimits[1].PathPrefix)
+			require.Equal(t, 50, config.RequestLimits[1].MaxRequestParameters)
+			require.Empty(t, config.Validate(""))
+		})
+	}
+}
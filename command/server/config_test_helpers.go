@@ -764,6 +764,7 @@ func testConfig_Sanitized(t *testing.T) {
 		"cluster_addr":                        "top_level_cluster_addr",
 		"cluster_cipher_suites":               "",
 		"cluster_name":                        "testcluster",
+		"crypto_policy":                       "",
 		"default_lease_ttl":                   (365 * 24 * time.Hour) / time.Second,
 		"default_max_request_duration":        0 * time.Second,
 		"disable_cache":                       true,
@@ -806,6 +807,9 @@ func testConfig_Sanitized(t *testing.T) {
 		"pid_file":         "./pidfile",
 		"plugin_directory": "",
 		"plugin_tmpdir":    "",
+
+		"plugin_cosign_fulcio_ca_file": "",
+		"plugin_cosign_rekor_key_file": "",
 		"seals": []interface{}{
 			map[string]interface{}{
 				"disabled": false,
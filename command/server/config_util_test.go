@@ -8,6 +8,7 @@ package server
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/stretchr/testify/require"
@@ -139,3 +140,80 @@ listener "tcp" {
 		})
 	}
 }
+
+// TestLoadSheddingConfig verifies that the load shedding config is correctly
+// instantiated from HCL
+func TestLoadSheddingConfig(t *testing.T) {
+	testCases := []struct {
+		name            string
+		inConfig        string
+		outErr          bool
+		outLoadShedding *configutil.LoadShedding
+	}{
+		{
+			name:            "empty",
+			outLoadShedding: nil,
+		},
+		{
+			name: "thresholds",
+			inConfig: `
+load_shedding {
+	max_in_flight = 500
+	max_latency = "250ms"
+	queue_timeout = "2s"
+	retry_after = 5
+}`,
+			outLoadShedding: &configutil.LoadShedding{
+				MaxInFlight:  500,
+				MaxLatency:   250 * time.Millisecond,
+				QueueTimeout: 2 * time.Second,
+				RetryAfter:   5 * time.Second,
+			},
+		},
+		{
+			name: "disabled",
+			inConfig: `
+load_shedding {
+	disable = true
+}`,
+			outLoadShedding: &configutil.LoadShedding{Disable: true},
+		},
+		{
+			name: "no thresholds",
+			inConfig: `
+load_shedding {
+	queue_timeout = "2s"
+}`,
+			outErr: true,
+		},
+		{
+			name: "negative threshold",
+			inConfig: `
+load_shedding {
+	max_in_flight = -1
+}`,
+			outErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := fmt.Sprintf(`
+ui = false
+storage "file" {
+	path = "/tmp/test"
+}
+
+listener "tcp" {
+	address = "0.0.0.0:8200"
+}
+%s`, tc.inConfig)
+			gotConfig, err := ParseConfig(config, "")
+			if tc.outErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.outLoadShedding, gotConfig.LoadShedding)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import "slices"
 const (
 	VaultExperimentCoreAuditEventsAlpha1 = "core.audit.events.alpha1"
 	VaultExperimentSecretsImport         = "secrets.import.alpha1"
+	VaultExperimentCoreUnsealDelegated   = "core.unseal.delegated.alpha1"
 
 	// Unused experiments. We keep them so that we don't break users who include them in their
 	// flags or configs, but they no longer have any effect.
@@ -18,6 +19,7 @@ var validExperiments = []string{
 	VaultExperimentEventsAlpha1,
 	VaultExperimentCoreAuditEventsAlpha1,
 	VaultExperimentSecretsImport,
+	VaultExperimentCoreUnsealDelegated,
 }
 
 var unusedExperiments = []string{
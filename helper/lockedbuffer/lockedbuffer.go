@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package lockedbuffer holds key material in memory outside of the Go heap.
+// The memory of a Buffer is locked so that it is not swapped to disk, is
+// excluded from core dumps where supported, and is surrounded by inaccessible
+// guard pages so that overflows fault instead of silently reading or writing
+// neighboring memory. As the memory is not managed by the garbage collector
+// it is never copied by it, and it must be released with Destroy.
+package lockedbuffer
+
+import (
+	"crypto/subtle"
+	"sync"
+)
+
+// Buffer is a fixed size buffer of key material. It is safe for concurrent
+// use, but slices returned by Bytes must not be used after Destroy.
+type Buffer struct {
+	l         sync.RWMutex
+	data      []byte
+	region    []byte
+	locked    bool
+	destroyed bool
+}
+
+// New returns a Buffer holding a copy of data. The caller remains
+// responsible for wiping data. If locked memory cannot be allocated, e.g.
+// because the limit of locked memory of the process is exceeded, New falls
+// back to unlocked memory; see Locked.
+func New(data []byte) *Buffer {
+	b := &Buffer{}
+	if len(data) == 0 {
+		return b
+	}
+	b.region, b.data, b.locked = alloc(len(data))
+	copy(b.data, data)
+	return b
+}
+
+// Bytes returns the contents of the buffer, or nil if the buffer is nil,
+// empty or destroyed. The returned slice refers to the locked memory itself,
+// so it must not be retained, and must not be used after Destroy.
+func (b *Buffer) Bytes() []byte {
+	if b == nil {
+		return nil
+	}
+	b.l.RLock()
+	defer b.l.RUnlock()
+	return b.data
+}
+
+// Len returns the length of the buffer, which is 0 once it is destroyed.
+func (b *Buffer) Len() int {
+	if b == nil {
+		return 0
+	}
+	b.l.RLock()
+	defer b.l.RUnlock()
+	return len(b.data)
+}
+
+// Equal reports in constant time whether the buffer holds other.
+func (b *Buffer) Equal(other []byte) bool {
+	if b == nil {
+		return len(other) == 0
+	}
+	b.l.RLock()
+	defer b.l.RUnlock()
+	return subtle.ConstantTimeCompare(b.data, other) == 1
+}
+
+// Locked reports whether the buffer is held in locked memory.
+func (b *Buffer) Locked() bool {
+	if b == nil {
+		return false
+	}
+	b.l.RLock()
+	defer b.l.RUnlock()
+	return b.locked
+}
+
+// Destroy wipes the buffer and releases its memory. It is safe to call
+// Destroy more than once, and on a nil Buffer.
+func (b *Buffer) Destroy() {
+	if b == nil {
+		return
+	}
+	b.l.Lock()
+	defer b.l.Unlock()
+	if b.destroyed {
+		return
+	}
+	b.destroyed = true
+	for i := range b.data {
+		b.data[i] = 0
+	}
+	if b.region != nil {
+		free(b.region, b.locked)
+	}
+	b.data = nil
+	b.region = nil
+	b.locked = false
+}
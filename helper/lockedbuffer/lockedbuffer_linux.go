@@ -0,0 +1,11 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package lockedbuffer
+
+import "golang.org/x/sys/unix"
+
+// dontDump excludes the memory from core dumps.
+func dontDump(b []byte) {
+	unix.Madvise(b, unix.MADV_DONTDUMP)
+}
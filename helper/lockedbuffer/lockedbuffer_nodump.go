@@ -0,0 +1,9 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build unix && !linux
+
+package lockedbuffer
+
+// dontDump is a no-op where memory cannot be excluded from core dumps.
+func dontDump([]byte) {}
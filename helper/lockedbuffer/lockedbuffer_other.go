@@ -0,0 +1,14 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !unix
+
+package lockedbuffer
+
+// alloc returns heap allocated memory, as locked memory is not supported.
+func alloc(size int) (region, data []byte, locked bool) {
+	return nil, make([]byte, size), false
+}
+
+// free is never called, as alloc does not return a region.
+func free([]byte, bool) {}
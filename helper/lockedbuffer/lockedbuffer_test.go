@@ -0,0 +1,63 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package lockedbuffer
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	data := []byte("root key material")
+	b := New(data)
+	if !bytes.Equal(b.Bytes(), data) {
+		t.Fatalf("expected %q, got %q", data, b.Bytes())
+	}
+	if b.Len() != len(data) {
+		t.Fatalf("expected length %d, got %d", len(data), b.Len())
+	}
+	if !b.Equal(data) || b.Equal([]byte("other key material")) {
+		t.Fatal("unexpected result of Equal")
+	}
+
+	// The buffer holds a copy of the data
+	data[0] = 'x'
+	if b.Bytes()[0] != 'r' {
+		t.Fatal("expected buffer to be independent of the input")
+	}
+
+	b.Destroy()
+	if b.Bytes() != nil || b.Len() != 0 || b.Equal([]byte("root key material")) {
+		t.Fatal("expected destroyed buffer to be empty")
+	}
+	b.Destroy()
+}
+
+func TestBuffer_Empty(t *testing.T) {
+	var nilBuffer *Buffer
+	for _, b := range []*Buffer{nilBuffer, New(nil)} {
+		if b.Bytes() != nil || b.Len() != 0 || !b.Equal(nil) || b.Locked() {
+			t.Fatal("expected empty buffer")
+		}
+		b.Destroy()
+	}
+}
+
+func TestBuffer_Layout(t *testing.T) {
+	pageSize := os.Getpagesize()
+	for _, size := range []int{1, 32, pageSize, pageSize + 1} {
+		b := New(bytes.Repeat([]byte{0xff}, size))
+		if b.region == nil {
+			b.Destroy()
+			t.Skip("memory could not be mapped")
+		}
+		// The data ends at the trailing guard page
+		end := len(b.region) - pageSize
+		if &b.data[size-1] != &b.region[end-1] {
+			t.Fatalf("expected data of size %d to end at the trailing guard page", size)
+		}
+		b.Destroy()
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build unix
+
+package lockedbuffer
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// alloc maps a region of memory holding size bytes between two guard pages,
+// and returns the region, and the data at the end of the region so that a
+// read or write past it hits the trailing guard page. If the region cannot
+// be mapped, a heap allocated slice is returned instead.
+func alloc(size int) (region, data []byte, locked bool) {
+	pageSize := os.Getpagesize()
+	dataPages := (size + pageSize - 1) / pageSize
+	region, err := unix.Mmap(-1, 0, (dataPages+2)*pageSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANON)
+	if err != nil {
+		return nil, make([]byte, size), false
+	}
+
+	inner := region[pageSize : (dataPages+1)*pageSize]
+	if unix.Mprotect(region[:pageSize], unix.PROT_NONE) != nil ||
+		unix.Mprotect(region[(dataPages+1)*pageSize:], unix.PROT_NONE) != nil {
+		unix.Munmap(region)
+		return nil, make([]byte, size), false
+	}
+	locked = unix.Mlock(inner) == nil
+	dontDump(inner)
+
+	return region, inner[len(inner)-size:], locked
+}
+
+// free unlocks and unmaps a region returned by alloc.
+func free(region []byte, locked bool) {
+	pageSize := os.Getpagesize()
+	if locked {
+		unix.Munlock(region[pageSize : len(region)-pageSize])
+	}
+	unix.Munmap(region)
+}
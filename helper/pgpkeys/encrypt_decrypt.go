@@ -6,9 +6,14 @@ package pgpkeys
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
 	"github.com/ProtonMail/go-crypto/openpgp/packet"
 )
 
@@ -49,6 +54,39 @@ func EncryptShares(input [][]byte, pgpKeys []string) ([]string, [][]byte, error)
 	return fingerprints, encryptedShares, nil
 }
 
+// EncryptStream returns a writer encrypting what is written to it to all the
+// given base64-encoded public keys as a single binary PGP message written to
+// out, so that any of the keys can decrypt it, along with the fingerprints of
+// the keys. Expired or revoked keys are rejected. The writer must be closed to
+// complete the message, which does not close out.
+func EncryptStream(out io.Writer, pgpKeys []string) (io.WriteCloser, []string, error) {
+	if len(pgpKeys) == 0 {
+		return nil, nil, fmt.Errorf("no PGP keys provided")
+	}
+	entities, err := GetEntities(pgpKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	for _, entity := range entities {
+		if _, err := KeyExpiration(entity, now); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	fingerprints, err := GetFingerprints(nil, entities)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pt, err := openpgp.Encrypt(out, entities, nil, &openpgp.FileHints{IsBinary: true}, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error setting up encryption for PGP message: %w", err)
+	}
+
+	return pt, fingerprints, nil
+}
+
 // GetFingerprints takes in a list of openpgp Entities and returns the
 // fingerprints. If entities is nil, it will instead parse both entities and
 // fingerprints from the pgpKeys string slice.
@@ -117,3 +155,133 @@ func DecryptBytes(encodedCrypt, privKey string) (*bytes.Buffer, error) {
 
 	return ptBuf, nil
 }
+
+// ReadPrivateKey parses a single private key entity from the given bytes,
+// which may be ASCII-armored, base64-encoded, or raw binary. The returned
+// entity's private keys may still be encrypted with a passphrase; see
+// DecryptPrivateKey.
+func ReadPrivateKey(keyBytes []byte) (*openpgp.Entity, error) {
+	trimmed := bytes.TrimSpace(keyBytes)
+	if bytes.HasPrefix(trimmed, []byte("-----BEGIN")) {
+		entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(trimmed))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing armored private key: %w", err)
+		}
+		if len(entities) != 1 {
+			return nil, fmt.Errorf("expected exactly one private key, found %d", len(entities))
+		}
+		return entities[0], nil
+	}
+
+	if decoded, err := base64.StdEncoding.DecodeString(string(trimmed)); err == nil {
+		keyBytes = decoded
+	}
+	entity, err := openpgp.ReadEntity(packet.NewReader(bytes.NewReader(keyBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+	return entity, nil
+}
+
+// DecryptPrivateKey decrypts the entity's primary private key and any
+// encrypted subkeys with the given passphrase.
+func DecryptPrivateKey(entity *openpgp.Entity, passphrase []byte) error {
+	if entity.PrivateKey != nil && entity.PrivateKey.Encrypted {
+		if err := entity.PrivateKey.Decrypt(passphrase); err != nil {
+			return fmt.Errorf("error decrypting private key: %w", err)
+		}
+	}
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+			if err := subkey.PrivateKey.Decrypt(passphrase); err != nil {
+				return fmt.Errorf("error decrypting private subkey: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// PrivateKeyEncrypted returns true if any of the entity's private keys
+// require a passphrase before they can be used.
+func PrivateKeyEncrypted(entity *openpgp.Entity) bool {
+	if entity.PrivateKey != nil && entity.PrivateKey.Encrypted {
+		return true
+	}
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey != nil && subkey.PrivateKey.Encrypted {
+			return true
+		}
+	}
+	return false
+}
+
+// DecryptShare decrypts a PGP-encrypted key share, as returned by init, rekey,
+// or generate-root when PGP keys are supplied, using the given private key
+// entity. The share may be hex-encoded (the "keys" field), base64-encoded (the
+// "keys_base64" field), ASCII-armored, or raw binary.
+func DecryptShare(share []byte, entity *openpgp.Entity) (string, error) {
+	return DecryptShareWithKeyRing(share, openpgp.EntityList{entity})
+}
+
+// DecryptShareWithKeyRing decrypts a PGP-encrypted key share like
+// DecryptShare, using whichever private key of the keyring the share was
+// encrypted for.
+func DecryptShareWithKeyRing(share []byte, keyring openpgp.EntityList) (string, error) {
+	cryptBytes, err := decodeShare(share)
+	if err != nil {
+		return "", err
+	}
+
+	md, err := openpgp.ReadMessage(bytes.NewReader(cryptBytes), keyring, nil, nil)
+	if err != nil {
+		return "", fmt.Errorf("error decrypting the share: %w", err)
+	}
+
+	ptBuf := bytes.NewBuffer(nil)
+	if _, err := ptBuf.ReadFrom(md.UnverifiedBody); err != nil {
+		return "", fmt.Errorf("error reading the decrypted share: %w", err)
+	}
+
+	return strings.TrimSpace(ptBuf.String()), nil
+}
+
+// ShareEncrypted returns true if the key share, in any of the encodings
+// accepted by DecryptShare, is a PGP-encrypted message.
+func ShareEncrypted(share []byte) bool {
+	cryptBytes, err := decodeShare(share)
+	if err != nil {
+		return false
+	}
+	p, err := packet.Read(bytes.NewReader(cryptBytes))
+	if err != nil {
+		return false
+	}
+	_, ok := p.(*packet.EncryptedKey)
+	return ok
+}
+
+// decodeShare returns the raw bytes of a hex-encoded, base64-encoded,
+// ASCII-armored, or raw binary share.
+func decodeShare(share []byte) ([]byte, error) {
+	trimmed := strings.TrimSpace(string(share))
+
+	if strings.HasPrefix(trimmed, "-----BEGIN") {
+		block, err := armor.Decode(strings.NewReader(trimmed))
+		if err != nil {
+			return nil, fmt.Errorf("error decoding armored share: %w", err)
+		}
+		buf := bytes.NewBuffer(nil)
+		if _, err := buf.ReadFrom(block.Body); err != nil {
+			return nil, fmt.Errorf("error reading armored share: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	if decoded, err := hex.DecodeString(trimmed); err == nil {
+		return decoded, nil
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(trimmed); err == nil {
+		return decoded, nil
+	}
+	return share, nil
+}
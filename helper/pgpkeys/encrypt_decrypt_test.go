@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pgpkeys
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptStream(t *testing.T) {
+	var out bytes.Buffer
+	w, fingerprints, err := EncryptStream(&out, []string{TestPubKey1, TestPubKey2})
+	require.NoError(t, err)
+
+	expected, err := GetFingerprints([]string{TestPubKey1, TestPubKey2}, nil)
+	require.NoError(t, err)
+	require.Equal(t, expected, fingerprints)
+
+	plaintext := bytes.Repeat([]byte("snapshot"), 4096)
+	_, err = w.Write(plaintext)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	// Any of the recipients can decrypt the message
+	encoded := base64.StdEncoding.EncodeToString(out.Bytes())
+	for _, privKey := range []string{TestPrivKey1, TestPrivKey2} {
+		decrypted, err := DecryptBytes(encoded, privKey)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, decrypted.Bytes())
+	}
+
+	_, err = DecryptBytes(encoded, TestPrivKey3)
+	require.Error(t, err)
+
+	_, _, err = EncryptStream(&out, nil)
+	require.EqualError(t, err, "no PGP keys provided")
+
+	_, _, err = EncryptStream(&out, []string{"not a key"})
+	require.ErrorContains(t, err, "error decoding given PGP key")
+}
@@ -0,0 +1,58 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pgpkeys
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// KeyExpiration returns when the given entity stops being usable for
+// encryption, or the zero time if it does not expire. It returns an error if
+// the entity has already expired or been revoked at now, since anything
+// encrypted to it could then not be decrypted in practice.
+func KeyExpiration(entity *openpgp.Entity, now time.Time) (time.Time, error) {
+	fingerprint := fmt.Sprintf("%x", entity.PrimaryKey.Fingerprint)
+
+	if entity.Revoked(now) {
+		return time.Time{}, fmt.Errorf("PGP key %s has been revoked", fingerprint)
+	}
+	identity := entity.PrimaryIdentity()
+	if identity == nil || identity.SelfSignature == nil {
+		return time.Time{}, fmt.Errorf("PGP key %s has no self-signed identity", fingerprint)
+	}
+	if identity.Revoked(now) {
+		return time.Time{}, fmt.Errorf("PGP key %s identity %q has been revoked", fingerprint, identity.Name)
+	}
+
+	expiration := keyExpiration(entity.PrimaryKey, identity.SelfSignature)
+	if !expiration.IsZero() && !now.Before(expiration) {
+		return time.Time{}, fmt.Errorf("PGP key %s expired at %s", fingerprint, expiration.Format(time.RFC3339))
+	}
+
+	key, ok := entity.EncryptionKey(now)
+	if !ok {
+		return time.Time{}, fmt.Errorf("PGP key %s has no valid encryption key; its encryption subkeys may have expired or been revoked", fingerprint)
+	}
+	if key.PublicKey != entity.PrimaryKey {
+		subkeyExpiration := keyExpiration(key.PublicKey, key.SelfSignature)
+		if expiration.IsZero() || (!subkeyExpiration.IsZero() && subkeyExpiration.Before(expiration)) {
+			expiration = subkeyExpiration
+		}
+	}
+
+	return expiration, nil
+}
+
+// keyExpiration returns when the key expires according to its binding
+// signature, or the zero time if it does not expire.
+func keyExpiration(key *packet.PublicKey, sig *packet.Signature) time.Time {
+	if sig == nil || sig.KeyLifetimeSecs == nil || *sig.KeyLifetimeSecs == 0 {
+		return time.Time{}
+	}
+	return key.CreationTime.Add(time.Duration(*sig.KeyLifetimeSecs) * time.Second)
+}
@@ -0,0 +1,62 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package pgpkeys
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyExpiration(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	config := func(lifetime time.Duration) *packet.Config {
+		return &packet.Config{
+			Algorithm:       packet.PubKeyAlgoEdDSA,
+			KeyLifetimeSecs: uint32(lifetime / time.Second),
+			Time:            func() time.Time { return now },
+		}
+	}
+
+	t.Run("no-expiration", func(t *testing.T) {
+		entity, err := openpgp.NewEntity("test", "", "test@example.com", config(0))
+		require.NoError(t, err)
+
+		expiration, err := KeyExpiration(entity, now)
+		require.NoError(t, err)
+		require.True(t, expiration.IsZero())
+	})
+
+	t.Run("expiring", func(t *testing.T) {
+		entity, err := openpgp.NewEntity("test", "", "test@example.com", config(24*time.Hour))
+		require.NoError(t, err)
+
+		expiration, err := KeyExpiration(entity, now)
+		require.NoError(t, err)
+		require.Equal(t, now.Add(24*time.Hour), expiration)
+
+		_, err = KeyExpiration(entity, now.Add(25*time.Hour))
+		require.ErrorContains(t, err, "expired")
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		entity, err := openpgp.NewEntity("test", "", "test@example.com", config(0))
+		require.NoError(t, err)
+		require.NoError(t, entity.RevokeKey(packet.KeyCompromised, "", config(0)))
+
+		_, err = KeyExpiration(entity, now)
+		require.ErrorContains(t, err, "revoked")
+	})
+
+	t.Run("test-key", func(t *testing.T) {
+		entities, err := GetEntities([]string{TestPubKey1})
+		require.NoError(t, err)
+
+		_, err = KeyExpiration(entities[0], now)
+		require.NoError(t, err)
+	})
+}
@@ -95,6 +95,14 @@ type issuerAndSerial struct {
 	SerialNumber *big.Int
 }
 
+// SetContentType sets the content type of the SignedData. For example to specify the
+// content type of a time-stamp token according to RFC 3161 section 2.4.2.
+//
+// This should be called before adding signers
+func (sd *SignedData) SetContentType(contentType asn1.ObjectIdentifier) {
+	sd.sd.ContentInfo.ContentType = contentType
+}
+
 // SetDigestAlgorithm sets the digest algorithm to be used in the signing process.
 //
 // This should be called before adding signers
@@ -70,8 +70,13 @@ func (p PolicyParser) ParsePolicy(raw string) (sg StringGenerator, err error) {
 	}
 
 	gen = StringGenerator{
-		Length: gen.Length,
-		Rules:  rules,
+		Length:     gen.Length,
+		Mode:       gen.Mode,
+		Words:      gen.Words,
+		Separator:  gen.Separator,
+		Capitalize: gen.Capitalize,
+		Digits:     gen.Digits,
+		Rules:      rules,
 	}
 
 	err = gen.validateConfig()
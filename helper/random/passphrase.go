@@ -0,0 +1,226 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package random
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// ModeCharset generates strings by selecting runes from the charset built from the rules. This is the default.
+	ModeCharset = "charset"
+
+	// ModePassphrase generates diceware-style passphrases from the built-in word list.
+	ModePassphrase = "passphrase"
+
+	// ModePronounceable generates strings of alternating consonants and vowels.
+	ModePronounceable = "pronounceable"
+
+	defaultPassphraseWords    = 6
+	defaultPassphraseSep      = "-"
+	maxPassphraseWords        = 64
+	pronounceableConsonantSet = "bcdfghjklmnprstvwz"
+	pronounceableVowelSet     = "aeiou"
+)
+
+//go:embed wordlist.txt
+var rawWordList string
+
+// WordList is the list of words passphrases are built from. It contains lowercase words of three to eight letters.
+var WordList = strings.Fields(rawWordList)
+
+var (
+	pronounceableConsonants = []rune(pronounceableConsonantSet)
+	pronounceableVowels     = []rune(pronounceableVowelSet)
+)
+
+// validateWordConfig validates the configuration of the passphrase & pronounceable modes
+func (g *StringGenerator) validateWordConfig() error {
+	merr := &multierror.Error{}
+
+	if g.Digits < 0 {
+		merr = multierror.Append(merr, fmt.Errorf("digits must be >= 0"))
+	}
+
+	minLen := getMinLength(g.Rules)
+	switch g.Mode {
+	case ModePassphrase:
+		if g.Words < 0 || g.Words > maxPassphraseWords {
+			merr = multierror.Append(merr, fmt.Errorf("words must be between 1 and %d", maxPassphraseWords))
+		}
+		if g.Length < 0 {
+			merr = multierror.Append(merr, fmt.Errorf("length must be >= 0"))
+		} else if g.Length > 0 && g.Length < minLen {
+			merr = multierror.Append(merr, fmt.Errorf("specified rules require at least %d characters but %d is specified", minLen, g.Length))
+		} else if shortest := g.minPassphraseLength(); g.Length > 0 && g.Length < shortest {
+			merr = multierror.Append(merr, fmt.Errorf("passphrases are at least %d characters but %d is specified", shortest, g.Length))
+		}
+	case ModePronounceable:
+		if g.Words != 0 || g.Separator != "" {
+			merr = multierror.Append(merr, fmt.Errorf("words and separator are only supported in %s mode", ModePassphrase))
+		}
+		if g.Length <= 0 {
+			merr = multierror.Append(merr, fmt.Errorf("length must be > 0"))
+		} else if g.Length < minLen {
+			merr = multierror.Append(merr, fmt.Errorf("specified rules require at least %d characters but %d is specified", minLen, g.Length))
+		} else if g.Digits >= g.Length {
+			merr = multierror.Append(merr, fmt.Errorf("digits must be less than the length"))
+		}
+	}
+
+	for _, r := range g.Separator {
+		if !unicode.IsPrint(r) {
+			merr = multierror.Append(merr, fmt.Errorf("non-printable character in separator"))
+			break
+		}
+	}
+	return merr.ErrorOrNil()
+}
+
+func (g *StringGenerator) wordCount() int {
+	if g.Words == 0 {
+		return defaultPassphraseWords
+	}
+	return g.Words
+}
+
+func (g *StringGenerator) separator() string {
+	if g.Separator == "" {
+		return defaultPassphraseSep
+	}
+	return g.Separator
+}
+
+// minPassphraseLength is the length of a passphrase made up of the shortest words in the word list
+func (g *StringGenerator) minPassphraseLength() int {
+	shortest := len(WordList[0])
+	for _, word := range WordList {
+		shortest = min(shortest, len(word))
+	}
+
+	length := g.wordCount()*shortest + (g.wordCount()-1)*utf8.RuneCountInString(g.separator())
+	if g.Digits > 0 {
+		length += utf8.RuneCountInString(g.separator()) + g.Digits
+	}
+	return length
+}
+
+// generatePassphrase joins randomly selected words from the word list, followed by the configured number of digits.
+func (g *StringGenerator) generatePassphrase(rng io.Reader) ([]rune, error) {
+	parts := make([]string, 0, g.wordCount()+1)
+	for i := 0; i < g.wordCount(); i++ {
+		idx, err := randomIndex(rng, len(WordList))
+		if err != nil {
+			return nil, err
+		}
+		word := WordList[idx]
+		if g.Capitalize {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		parts = append(parts, word)
+	}
+
+	if g.Digits > 0 {
+		digits, err := randomRunes(rng, NumericRuneset, g.Digits)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, string(digits))
+	}
+
+	return []rune(strings.Join(parts, g.separator())), nil
+}
+
+// generatePronounceable alternates consonants and vowels up to the length, with the configured number of digits
+// making up the end of the string.
+func (g *StringGenerator) generatePronounceable(rng io.Reader) ([]rune, error) {
+	letters := g.Length - g.Digits
+	candidate := make([]rune, 0, g.Length)
+	for i := 0; i < letters; i++ {
+		set := pronounceableConsonants
+		if i%2 == 1 {
+			set = pronounceableVowels
+		}
+		idx, err := randomIndex(rng, len(set))
+		if err != nil {
+			return nil, err
+		}
+		candidate = append(candidate, set[idx])
+	}
+	if g.Capitalize && len(candidate) > 0 {
+		candidate[0] = unicode.ToUpper(candidate[0])
+	}
+
+	if g.Digits > 0 {
+		digits, err := randomRunes(rng, NumericRuneset, g.Digits)
+		if err != nil {
+			return nil, err
+		}
+		candidate = append(candidate, digits...)
+	}
+	return candidate, nil
+}
+
+// Entropy estimates the number of bits of entropy in the strings produced by the generator. Rules reject some
+// candidates, so this is an upper bound.
+func (g *StringGenerator) Entropy() float64 {
+	digits := float64(g.Digits) * math.Log2(float64(len(NumericRuneset)))
+
+	switch g.Mode {
+	case ModePassphrase:
+		return float64(g.wordCount())*math.Log2(float64(len(WordList))) + digits
+	case ModePronounceable:
+		letters := g.Length - g.Digits
+		consonants := (letters + 1) / 2
+		vowels := letters / 2
+		return float64(consonants)*math.Log2(float64(len(pronounceableConsonants))) +
+			float64(vowels)*math.Log2(float64(len(pronounceableVowels))) + digits
+	default:
+		g.charsetLock.RLock()
+		charset := g.charset
+		g.charsetLock.RUnlock()
+		if len(charset) == 0 {
+			charset = getChars(g.Rules)
+		}
+		if len(charset) == 0 {
+			return 0
+		}
+		return float64(g.Length) * math.Log2(float64(len(charset)))
+	}
+}
+
+// randomIndex returns a uniformly distributed index in [0, n). Like randomRunes, it discards RNG values that
+// would bias the selection towards the front of the range.
+func randomIndex(rng io.Reader, n int) (int, error) {
+	if n <= 0 || n > math.MaxUint16+1 {
+		return 0, fmt.Errorf("unable to select an index from a range of %d", n)
+	}
+
+	// Default to the standard crypto reader if one isn't provided
+	if rng == nil {
+		rng = rand.Reader
+	}
+
+	maxAllowed := ((math.MaxUint16 + 1) / n) * n
+	buf := make([]byte, 2)
+	for {
+		if _, err := io.ReadFull(rng, buf); err != nil {
+			return 0, err
+		}
+		v := int(binary.BigEndian.Uint16(buf))
+		if v < maxAllowed {
+			return v % n, nil
+		}
+	}
+}
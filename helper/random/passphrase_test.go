@@ -0,0 +1,362 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package random
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+)
+
+func TestWordList(t *testing.T) {
+	if len(WordList) < 1024 {
+		t.Fatalf("word list has %d words, expected at least 1024", len(WordList))
+	}
+
+	seen := map[string]bool{}
+	for _, word := range WordList {
+		if seen[word] {
+			t.Fatalf("duplicate word %q", word)
+		}
+		seen[word] = true
+
+		if len(word) < 3 || len(word) > 8 {
+			t.Fatalf("word %q is not between 3 and 8 letters", word)
+		}
+		for _, r := range word {
+			if r < 'a' || r > 'z' {
+				t.Fatalf("word %q is not lowercase ASCII", word)
+			}
+		}
+	}
+}
+
+func TestStringGenerator_Generate_passphrase(t *testing.T) {
+	type testCase struct {
+		generator *StringGenerator
+		sep       string
+		words     int
+		digits    int
+	}
+
+	tests := map[string]testCase{
+		"defaults": {
+			generator: &StringGenerator{
+				Mode: ModePassphrase,
+			},
+			sep:   "-",
+			words: 6,
+		},
+		"custom separator and words": {
+			generator: &StringGenerator{
+				Mode:      ModePassphrase,
+				Words:     4,
+				Separator: " ",
+			},
+			sep:   " ",
+			words: 4,
+		},
+		"capitalized with digits and rules": {
+			generator: &StringGenerator{
+				Mode:       ModePassphrase,
+				Words:      3,
+				Capitalize: true,
+				Digits:     3,
+				Rules: []Rule{
+					CharsetRule{
+						Charset:  UppercaseRuneset,
+						MinChars: 3,
+					},
+					CharsetRule{
+						Charset:  NumericRuneset,
+						MinChars: 3,
+					},
+				},
+			},
+			sep:    "-",
+			words:  3,
+			digits: 3,
+		},
+		"maximum length": {
+			generator: &StringGenerator{
+				Mode:   ModePassphrase,
+				Length: 20,
+				Words:  3,
+			},
+			sep:   "-",
+			words: 3,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			for i := 0; i < 100; i++ {
+				actual, err := test.generator.Generate(ctx, nil)
+				if err != nil {
+					t.Fatalf("no error expected, got: %s", err)
+				}
+				if test.generator.Length > 0 && len(actual) > test.generator.Length {
+					t.Fatalf("passphrase %q is longer than %d characters", actual, test.generator.Length)
+				}
+
+				parts := strings.Split(actual, test.sep)
+				expectedParts := test.words
+				if test.digits > 0 {
+					expectedParts++
+				}
+				if len(parts) != expectedParts {
+					t.Fatalf("passphrase %q has %d parts, expected %d", actual, len(parts), expectedParts)
+				}
+
+				for _, word := range parts[:test.words] {
+					if test.generator.Capitalize != unicode.IsUpper(rune(word[0])) {
+						t.Fatalf("word %q in %q has unexpected capitalization", word, actual)
+					}
+				}
+				if test.digits > 0 {
+					last := parts[len(parts)-1]
+					if len(last) != test.digits || strings.Trim(last, NumericCharset) != "" {
+						t.Fatalf("passphrase %q does not end with %d digits", actual, test.digits)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestStringGenerator_Generate_pronounceable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sg := &StringGenerator{
+		Mode:       ModePronounceable,
+		Length:     12,
+		Digits:     2,
+		Capitalize: true,
+	}
+
+	for i := 0; i < 100; i++ {
+		actual, err := sg.Generate(ctx, nil)
+		if err != nil {
+			t.Fatalf("no error expected, got: %s", err)
+		}
+		if len(actual) != 12 {
+			t.Fatalf("expected 12 characters, got %q", actual)
+		}
+
+		letters := strings.ToLower(actual[:10])
+		for j, r := range letters {
+			set := pronounceableConsonantSet
+			if j%2 == 1 {
+				set = pronounceableVowelSet
+			}
+			if !strings.ContainsRune(set, r) {
+				t.Fatalf("character %d of %q is not from %q", j, actual, set)
+			}
+		}
+		if !unicode.IsUpper(rune(actual[0])) {
+			t.Fatalf("%q is not capitalized", actual)
+		}
+		if strings.Trim(actual[10:], NumericCharset) != "" {
+			t.Fatalf("%q does not end with 2 digits", actual)
+		}
+	}
+}
+
+func TestValidate_wordModes(t *testing.T) {
+	type testCase struct {
+		generator *StringGenerator
+		expectErr bool
+	}
+
+	tests := map[string]testCase{
+		"unknown mode": {
+			generator: &StringGenerator{
+				Mode:   "diceware",
+				Length: 10,
+			},
+			expectErr: true,
+		},
+		"passphrase without length": {
+			generator: &StringGenerator{
+				Mode: ModePassphrase,
+			},
+			expectErr: false,
+		},
+		"passphrase with too many words": {
+			generator: &StringGenerator{
+				Mode:  ModePassphrase,
+				Words: maxPassphraseWords + 1,
+			},
+			expectErr: true,
+		},
+		"passphrase with negative digits": {
+			generator: &StringGenerator{
+				Mode:   ModePassphrase,
+				Digits: -1,
+			},
+			expectErr: true,
+		},
+		"passphrase length shorter than rules": {
+			generator: &StringGenerator{
+				Mode:   ModePassphrase,
+				Length: 2,
+				Rules: []Rule{
+					CharsetRule{
+						Charset:  LowercaseRuneset,
+						MinChars: 3,
+					},
+				},
+			},
+			expectErr: true,
+		},
+		"pronounceable without length": {
+			generator: &StringGenerator{
+				Mode: ModePronounceable,
+			},
+			expectErr: true,
+		},
+		"pronounceable with words": {
+			generator: &StringGenerator{
+				Mode:   ModePronounceable,
+				Length: 10,
+				Words:  3,
+			},
+			expectErr: true,
+		},
+		"pronounceable with only digits": {
+			generator: &StringGenerator{
+				Mode:   ModePronounceable,
+				Length: 4,
+				Digits: 4,
+			},
+			expectErr: true,
+		},
+		"separator has non-printable characters": {
+			generator: &StringGenerator{
+				Mode:      ModePassphrase,
+				Separator: "\x00",
+			},
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.generator.validateConfig()
+			if test.expectErr && err == nil {
+				t.Fatalf("err expected, got nil")
+			}
+			if !test.expectErr && err != nil {
+				t.Fatalf("no error expected, got: %s", err)
+			}
+		})
+	}
+}
+
+func TestParsePolicy_wordModes(t *testing.T) {
+	raw := `mode = "passphrase"
+			words = 5
+			separator = "."
+			capitalize = true
+			digits = 2
+			rule "charset" {
+				charset = "0123456789"
+				min-chars = 2
+			}`
+
+	actual, err := ParsePolicy(raw)
+	if err != nil {
+		t.Fatalf("no error expected, got: %s", err)
+	}
+
+	expected := &StringGenerator{
+		Mode:       ModePassphrase,
+		Words:      5,
+		Separator:  ".",
+		Capitalize: true,
+		Digits:     2,
+		Rules: []Rule{
+			CharsetRule{
+				Charset:  []rune("0123456789"),
+				MinChars: 2,
+			},
+		},
+	}
+	if !reflect.DeepEqual(&actual, expected) {
+		t.Fatalf("Actual: %#v\nExpected:%#v", &actual, expected)
+	}
+}
+
+func TestStringGenerator_Entropy(t *testing.T) {
+	type testCase struct {
+		generator *StringGenerator
+		expected  float64
+	}
+
+	tests := map[string]testCase{
+		"charset": {
+			generator: &StringGenerator{
+				Length: 10,
+				Rules: []Rule{
+					CharsetRule{
+						Charset: []rune("abcdefghijklmnop"),
+					},
+				},
+			},
+			expected: 40,
+		},
+		"passphrase": {
+			generator: &StringGenerator{
+				Mode:   ModePassphrase,
+				Words:  4,
+				Digits: 1,
+			},
+			expected: 4*math.Log2(float64(len(WordList))) + math.Log2(10),
+		},
+		"pronounceable": {
+			generator: &StringGenerator{
+				Mode:   ModePronounceable,
+				Length: 5,
+			},
+			expected: 3*math.Log2(18) + 2*math.Log2(5),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := test.generator.Entropy()
+			if math.Abs(actual-test.expected) > 0.0001 {
+				t.Fatalf("Actual: %f Expected: %f", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestRandomIndex_Bias(t *testing.T) {
+	n := 7
+	counts := make([]int, n)
+	iterations := 70000
+	for i := 0; i < iterations; i++ {
+		idx, err := randomIndex(nil, n)
+		if err != nil {
+			t.Fatalf("no error expected, got: %s", err)
+		}
+		counts[idx]++
+	}
+
+	expected := float64(iterations) / float64(n)
+	for idx, count := range counts {
+		if math.Abs(float64(count)-expected)/expected > 0.05 {
+			t.Fatalf("index %d selected %d times, expected about %f", idx, count, expected)
+		}
+	}
+}
@@ -73,9 +73,25 @@ func sortCharset(chars string) string {
 // StringGenerator generates random strings from the provided charset & adhering to a set of rules. The set of rules
 // are things like CharsetRule which requires a certain number of characters from a sub-charset.
 type StringGenerator struct {
-	// Length of the string to generate.
+	// Length of the string to generate. In passphrase mode this is an optional maximum length.
 	Length int `mapstructure:"length" json:"length"`
 
+	// Mode selects how candidate strings are built: from the charset (the default), from words in the
+	// built-in word list, or from alternating consonants and vowels. See ModeCharset and friends.
+	Mode string `mapstructure:"mode" json:"mode,omitempty"`
+
+	// Words is the number of words in a passphrase. Only used in passphrase mode.
+	Words int `mapstructure:"words" json:"words,omitempty"`
+
+	// Separator joins the words of a passphrase. Only used in passphrase mode.
+	Separator string `mapstructure:"separator" json:"separator,omitempty"`
+
+	// Capitalize the first letter of each word (passphrase mode) or of the string (pronounceable mode).
+	Capitalize bool `mapstructure:"capitalize" json:"capitalize,omitempty"`
+
+	// Digits is the number of random digits appended to passphrases and pronounceable strings.
+	Digits int `mapstructure:"digits" json:"digits,omitempty"`
+
 	// Rules the generated strings must adhere to.
 	Rules serializableRules `mapstructure:"-" json:"rule"` // This is "rule" in JSON so it matches the HCL property type
 
@@ -121,12 +137,29 @@ func (g *StringGenerator) generate(rng io.Reader) (str string, err error) {
 	// If performance improvements need to be made, this can be changed to read a batch of
 	// potential strings at once rather than one at a time. This will significantly
 	// improve performance, but at the cost of added complexity.
-	g.charsetLock.RLock()
-	charset := g.charset
-	g.charsetLock.RUnlock()
-	candidate, err := randomRunes(rng, charset, g.Length)
-	if err != nil {
-		return "", fmt.Errorf("unable to generate random characters: %w", err)
+	var candidate []rune
+	switch g.Mode {
+	case ModePassphrase:
+		candidate, err = g.generatePassphrase(rng)
+		if err != nil {
+			return "", fmt.Errorf("unable to generate passphrase: %w", err)
+		}
+		if g.Length > 0 && len(candidate) > g.Length {
+			return "", nil
+		}
+	case ModePronounceable:
+		candidate, err = g.generatePronounceable(rng)
+		if err != nil {
+			return "", fmt.Errorf("unable to generate pronounceable string: %w", err)
+		}
+	default:
+		g.charsetLock.RLock()
+		charset := g.charset
+		g.charsetLock.RUnlock()
+		candidate, err = randomRunes(rng, charset, g.Length)
+		if err != nil {
+			return "", fmt.Errorf("unable to generate random characters: %w", err)
+		}
 	}
 
 	for _, rule := range g.Rules {
@@ -227,6 +260,14 @@ func randomRunes(rng io.Reader, charset []rune, length int) (candidate []rune, e
 
 // validateConfig of the generator to ensure that we can successfully generate a string.
 func (g *StringGenerator) validateConfig() (err error) {
+	switch g.Mode {
+	case "", ModeCharset:
+	case ModePassphrase, ModePronounceable:
+		return g.validateWordConfig()
+	default:
+		return fmt.Errorf("unrecognized mode %q", g.Mode)
+	}
+
 	merr := &multierror.Error{}
 
 	// Ensure the sum of minimum lengths in the rules doesn't exceed the length specified
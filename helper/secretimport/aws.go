@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package secretimport
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-secure-stdlib/awsutil"
+)
+
+// awsSource reads secrets from AWS Secrets Manager. Tags of the secrets are
+// their labels.
+type awsSource struct {
+	client *secretsmanager.SecretsManager
+}
+
+func newAWSSource(config map[string]string) (*awsSource, error) {
+	if err := requireConfig(config, "region"); err != nil {
+		return nil, err
+	}
+
+	// Without static credentials, the credentials of the environment of
+	// the server are used.
+	credsConfig := &awsutil.CredentialsConfig{
+		AccessKey:    config["access_key"],
+		SecretKey:    config["secret_key"],
+		SessionToken: config["session_token"],
+		Region:       config["region"],
+	}
+	creds, err := credsConfig.GenerateCredentialChain()
+	if err != nil {
+		return nil, err
+	}
+
+	awsConfig := &aws.Config{
+		Credentials: creds,
+		Region:      aws.String(config["region"]),
+		HTTPClient:  cleanhttp.DefaultClient(),
+	}
+	if endpoint := config["endpoint"]; endpoint != "" {
+		awsConfig.Endpoint = aws.String(endpoint)
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &awsSource{
+		client: secretsmanager.New(sess),
+	}, nil
+}
+
+func (s *awsSource) List(ctx context.Context) ([]*Secret, error) {
+	var secrets []*Secret
+	err := s.client.ListSecretsPagesWithContext(ctx, &secretsmanager.ListSecretsInput{}, func(page *secretsmanager.ListSecretsOutput, _ bool) bool {
+		for _, entry := range page.SecretList {
+			secret := &Secret{
+				Name:   aws.StringValue(entry.Name),
+				Labels: make(map[string]string),
+			}
+			for _, tag := range entry.Tags {
+				secret.Labels[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			secrets = append(secrets, secret)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sortSecrets(secrets), nil
+}
+
+func (s *awsSource) Value(ctx context.Context, name string) ([]byte, error) {
+	out, err := s.client.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == secretsmanager.ErrCodeResourceNotFoundException {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	if out.SecretString != nil {
+		return []byte(*out.SecretString), nil
+	}
+	return out.SecretBinary, nil
+}
@@ -0,0 +1,147 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package secretimport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/hashicorp/go-cleanhttp"
+)
+
+const (
+	azureKeyVaultAPIVersion = "7.4"
+	azureKeyVaultScope      = "https://vault.azure.net/.default"
+)
+
+// azureSource reads secrets from an Azure Key Vault through its REST API.
+// Tags of the secrets are their labels, and disabled secrets are skipped.
+type azureSource struct {
+	vaultURL string
+	client   *http.Client
+	token    func(ctx context.Context) (string, error)
+}
+
+type azureSecretItem struct {
+	ID         string            `json:"id"`
+	Value      string            `json:"value"`
+	Tags       map[string]string `json:"tags"`
+	Attributes struct {
+		Enabled bool `json:"enabled"`
+	} `json:"attributes"`
+}
+
+type azureSecretList struct {
+	Value    []*azureSecretItem `json:"value"`
+	NextLink string             `json:"nextLink"`
+}
+
+type azureError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newAzureSource(config map[string]string) (*azureSource, error) {
+	if err := requireConfig(config, "vault_url", "tenant_id", "client_id", "client_secret"); err != nil {
+		return nil, err
+	}
+	if _, err := url.Parse(config["vault_url"]); err != nil {
+		return nil, fmt.Errorf("invalid vault_url: %w", err)
+	}
+
+	cred, err := azidentity.NewClientSecretCredential(config["tenant_id"], config["client_id"], config["client_secret"], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &azureSource{
+		vaultURL: strings.TrimSuffix(config["vault_url"], "/"),
+		client:   cleanhttp.DefaultClient(),
+		token: func(ctx context.Context) (string, error) {
+			token, err := cred.GetToken(ctx, policy.TokenRequestOptions{
+				Scopes: []string{azureKeyVaultScope},
+			})
+			if err != nil {
+				return "", err
+			}
+			return token.Token, nil
+		},
+	}, nil
+}
+
+func (s *azureSource) List(ctx context.Context) ([]*Secret, error) {
+	var secrets []*Secret
+	next := s.vaultURL + "/secrets?api-version=" + azureKeyVaultAPIVersion
+	for next != "" {
+		var list azureSecretList
+		if err := s.get(ctx, next, &list); err != nil {
+			return nil, err
+		}
+		for _, item := range list.Value {
+			if !item.Attributes.Enabled {
+				continue
+			}
+			secrets = append(secrets, &Secret{
+				Name:   path.Base(item.ID),
+				Labels: item.Tags,
+			})
+		}
+		next = list.NextLink
+	}
+	return sortSecrets(secrets), nil
+}
+
+func (s *azureSource) Value(ctx context.Context, name string) ([]byte, error) {
+	var item azureSecretItem
+	err := s.get(ctx, s.vaultURL+"/secrets/"+url.PathEscape(name)+"?api-version="+azureKeyVaultAPIVersion, &item)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(item.Value), nil
+}
+
+func (s *azureSource) get(ctx context.Context, u string, out interface{}) error {
+	token, err := s.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		var azErr azureError
+		if err := json.Unmarshal(body, &azErr); err == nil && azErr.Error.Message != "" {
+			return fmt.Errorf("azure key vault returned %s: %s", azErr.Error.Code, azErr.Error.Message)
+		}
+		return fmt.Errorf("azure key vault returned status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
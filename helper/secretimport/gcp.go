@@ -0,0 +1,151 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package secretimport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/hashicorp/go-cleanhttp"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	gcpSecretManagerEndpoint = "https://secretmanager.googleapis.com"
+	gcpCloudPlatformScope    = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// gcpSource reads secrets from GCP Secret Manager through its REST API.
+// Labels of the secrets are their labels, and their latest version is read.
+type gcpSource struct {
+	endpoint string
+	project  string
+	client   *http.Client
+}
+
+type gcpSecretList struct {
+	Secrets []struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+	} `json:"secrets"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+type gcpSecretVersion struct {
+	Payload struct {
+		Data []byte `json:"data"`
+	} `json:"payload"`
+}
+
+type gcpError struct {
+	Error struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newGCPSource(ctx context.Context, config map[string]string) (*gcpSource, error) {
+	if err := requireConfig(config, "project"); err != nil {
+		return nil, err
+	}
+
+	// Without credentials, the application default credentials of the
+	// server are used.
+	var creds *google.Credentials
+	var err error
+	if config["credentials"] != "" {
+		creds, err = google.CredentialsFromJSON(ctx, []byte(config["credentials"]), gcpCloudPlatformScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, gcpCloudPlatformScope)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load GCP credentials: %w", err)
+	}
+
+	endpoint := gcpSecretManagerEndpoint
+	if config["endpoint"] != "" {
+		endpoint = strings.TrimSuffix(config["endpoint"], "/")
+	}
+
+	// The token source is not bound to ctx, which may be done by the time
+	// tokens are refreshed.
+	client := oauth2.NewClient(context.WithValue(context.Background(), oauth2.HTTPClient, cleanhttp.DefaultClient()), creds.TokenSource)
+	return &gcpSource{
+		endpoint: endpoint,
+		project:  config["project"],
+		client:   client,
+	}, nil
+}
+
+func (s *gcpSource) List(ctx context.Context) ([]*Secret, error) {
+	var secrets []*Secret
+	pageToken := ""
+	for {
+		u := fmt.Sprintf("%s/v1/projects/%s/secrets?pageSize=250", s.endpoint, url.PathEscape(s.project))
+		if pageToken != "" {
+			u += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+
+		var list gcpSecretList
+		if err := s.get(ctx, u, &list); err != nil {
+			return nil, err
+		}
+		for _, secret := range list.Secrets {
+			secrets = append(secrets, &Secret{
+				Name:   path.Base(secret.Name),
+				Labels: secret.Labels,
+			})
+		}
+
+		pageToken = list.NextPageToken
+		if pageToken == "" {
+			return sortSecrets(secrets), nil
+		}
+	}
+}
+
+func (s *gcpSource) Value(ctx context.Context, name string) ([]byte, error) {
+	var version gcpSecretVersion
+	u := fmt.Sprintf("%s/v1/projects/%s/secrets/%s/versions/latest:access", s.endpoint, url.PathEscape(s.project), url.PathEscape(name))
+	if err := s.get(ctx, u, &version); err != nil {
+		return nil, err
+	}
+	return version.Payload.Data, nil
+}
+
+func (s *gcpSource) get(ctx context.Context, u string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		var gErr gcpError
+		if err := json.Unmarshal(body, &gErr); err == nil && gErr.Error.Message != "" {
+			return fmt.Errorf("gcp secret manager returned %s: %s", gErr.Error.Status, gErr.Error.Message)
+		}
+		return fmt.Errorf("gcp secret manager returned status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
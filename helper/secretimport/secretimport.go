@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package secretimport reads secrets from external secret managers, so that
+// they can be imported into Vault.
+package secretimport
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+)
+
+const (
+	SourceAWS   = "aws"
+	SourceAzure = "azure"
+	SourceGCP   = "gcp"
+)
+
+// configKeys are the configuration keys supported by each type of source.
+var configKeys = map[string][]string{
+	SourceAWS:   {"region", "access_key", "secret_key", "session_token", "endpoint"},
+	SourceAzure: {"vault_url", "tenant_id", "client_id", "client_secret"},
+	SourceGCP:   {"project", "credentials", "endpoint"},
+}
+
+// ErrNotFound is returned when a secret does not exist in a source.
+var ErrNotFound = errors.New("secret not found")
+
+// Secret is a secret of an external secret manager.
+type Secret struct {
+	// Name is the name of the secret in its source.
+	Name string
+
+	// Labels are the tags or labels of the secret in its source.
+	Labels map[string]string
+}
+
+// Source is an external secret manager.
+type Source interface {
+	// List returns the secrets of the source, ordered by name.
+	List(ctx context.Context) ([]*Secret, error)
+
+	// Value returns the current value of the secret with the given name.
+	Value(ctx context.Context, name string) ([]byte, error)
+}
+
+// New returns the source of the given type, which connects to the secret
+// manager with the given configuration.
+func New(ctx context.Context, sourceType string, config map[string]string) (Source, error) {
+	keys, ok := configKeys[sourceType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported source %q", sourceType)
+	}
+	for key := range config {
+		if !strutil.StrListContains(keys, key) {
+			return nil, fmt.Errorf("unsupported %s configuration key %q", sourceType, key)
+		}
+	}
+
+	switch sourceType {
+	case SourceAWS:
+		return newAWSSource(config)
+	case SourceAzure:
+		return newAzureSource(config)
+	default:
+		return newGCPSource(ctx, config)
+	}
+}
+
+// Data returns the KV data of a secret value: the fields of JSON object
+// values, and a "value" field otherwise. Values which are not valid UTF-8 are
+// base64 encoded in a "value_base64" field.
+func Data(value []byte) map[string]interface{} {
+	var data map[string]interface{}
+	if err := json.Unmarshal(value, &data); err == nil && len(data) > 0 {
+		return data
+	}
+	if !utf8.Valid(value) {
+		return map[string]interface{}{
+			"value_base64": base64.StdEncoding.EncodeToString(value),
+		}
+	}
+	return map[string]interface{}{
+		"value": string(value),
+	}
+}
+
+func requireConfig(config map[string]string, keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		if config[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing configuration: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func sortSecrets(secrets []*Secret) []*Secret {
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+	return secrets
+}
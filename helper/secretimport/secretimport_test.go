@@ -0,0 +1,224 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package secretimport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestData(t *testing.T) {
+	require.Equal(t, map[string]interface{}{"user": "app", "port": float64(5432)}, Data([]byte(`{"user":"app","port":5432}`)))
+	require.Equal(t, map[string]interface{}{"value": "s3cr3t"}, Data([]byte("s3cr3t")))
+	require.Equal(t, map[string]interface{}{"value": `["a"]`}, Data([]byte(`["a"]`)))
+	require.Equal(t, map[string]interface{}{"value_base64": "/wA="}, Data([]byte{0xff, 0x00}))
+}
+
+func TestNew_Config(t *testing.T) {
+	_, err := New(context.Background(), "vault", nil)
+	require.ErrorContains(t, err, `unsupported source "vault"`)
+
+	_, err = New(context.Background(), SourceAWS, map[string]string{"region": "us-east-1", "bucket": "b"})
+	require.ErrorContains(t, err, `unsupported aws configuration key "bucket"`)
+
+	_, err = New(context.Background(), SourceAzure, map[string]string{"vault_url": "https://example.vault.azure.net"})
+	require.ErrorContains(t, err, "missing configuration: tenant_id, client_id, client_secret")
+
+	_, err = New(context.Background(), SourceGCP, map[string]string{"credentials": "{}"})
+	require.ErrorContains(t, err, "missing configuration: project")
+}
+
+// TestAWSSource verifies that secrets are read from the Secrets Manager API.
+func TestAWSSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.ListSecrets":
+			if input["NextToken"] == nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"SecretList": []interface{}{
+						map[string]interface{}{
+							"Name": "prod/db",
+							"Tags": []interface{}{map[string]interface{}{"Key": "team", "Value": "payments"}},
+						},
+					},
+					"NextToken": "page2",
+				})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"SecretList": []interface{}{map[string]interface{}{"Name": "api-key"}},
+			})
+		case "secretsmanager.GetSecretValue":
+			if input["SecretId"] != "prod/db" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"__type":  "ResourceNotFoundException",
+					"message": "Secrets Manager can't find the specified secret.",
+				})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"Name":         "prod/db",
+				"SecretString": `{"password":"hunter2"}`,
+			})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	source, err := New(context.Background(), SourceAWS, map[string]string{
+		"region":     "us-east-1",
+		"access_key": "AKIAEXAMPLE",
+		"secret_key": "secret",
+		"endpoint":   server.URL,
+	})
+	require.NoError(t, err)
+
+	secrets, err := source.List(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []*Secret{
+		{Name: "api-key", Labels: map[string]string{}},
+		{Name: "prod/db", Labels: map[string]string{"team": "payments"}},
+	}, secrets)
+
+	value, err := source.Value(context.Background(), "prod/db")
+	require.NoError(t, err)
+	require.Equal(t, `{"password":"hunter2"}`, string(value))
+
+	_, err = source.Value(context.Background(), "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestAzureSource verifies that enabled secrets are read from the Key Vault
+// API with the token of the source.
+func TestAzureSource(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.Equal(t, azureKeyVaultAPIVersion, r.URL.Query().Get("api-version"))
+
+		switch r.URL.Path {
+		case "/secrets":
+			if r.URL.Query().Get("page") == "" {
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"value": []interface{}{
+						map[string]interface{}{
+							"id":         server.URL + "/secrets/db-password",
+							"tags":       map[string]string{"env": "prod"},
+							"attributes": map[string]interface{}{"enabled": true},
+						},
+						map[string]interface{}{
+							"id":         server.URL + "/secrets/old",
+							"attributes": map[string]interface{}{"enabled": false},
+						},
+					},
+					"nextLink": server.URL + "/secrets?api-version=" + azureKeyVaultAPIVersion + "&page=2",
+				})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": []interface{}{
+					map[string]interface{}{
+						"id":         server.URL + "/secrets/api-key",
+						"attributes": map[string]interface{}{"enabled": true},
+					},
+				},
+			})
+		case "/secrets/db-password":
+			json.NewEncoder(w).Encode(map[string]interface{}{"value": "hunter2"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source := &azureSource{
+		vaultURL: server.URL,
+		client:   server.Client(),
+		token: func(context.Context) (string, error) {
+			return "token", nil
+		},
+	}
+
+	secrets, err := source.List(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []*Secret{
+		{Name: "api-key"},
+		{Name: "db-password", Labels: map[string]string{"env": "prod"}},
+	}, secrets)
+
+	value, err := source.Value(context.Background(), "db-password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", string(value))
+
+	_, err = source.Value(context.Background(), "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestGCPSource verifies that the latest versions of secrets are read from
+// the Secret Manager API.
+func TestGCPSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/projects/acme/secrets":
+			if r.URL.Query().Get("pageToken") == "" {
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"secrets": []interface{}{
+						map[string]interface{}{
+							"name":   "projects/acme/secrets/db-password",
+							"labels": map[string]string{"env": "prod"},
+						},
+					},
+					"nextPageToken": "page2",
+				})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"secrets": []interface{}{
+					map[string]interface{}{"name": "projects/acme/secrets/api-key"},
+				},
+			})
+		case "/v1/projects/acme/secrets/db-password/versions/latest:access":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"payload": map[string]interface{}{"data": "aHVudGVyMg=="},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error": map[string]interface{}{"status": "NOT_FOUND", "message": "not found"},
+			})
+		}
+	}))
+	defer server.Close()
+
+	source := &gcpSource{
+		endpoint: server.URL,
+		project:  "acme",
+		client:   server.Client(),
+	}
+
+	secrets, err := source.List(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []*Secret{
+		{Name: "api-key"},
+		{Name: "db-password", Labels: map[string]string{"env": "prod"}},
+	}, secrets)
+
+	value, err := source.Value(context.Background(), "db-password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", string(value))
+
+	_, err = source.Value(context.Background(), "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,157 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package plugintest runs a minimal in-memory Vault server for plugin
+// integration tests. Requests to the server go through the same HTTP handler,
+// token store, router, barrier and expiration manager as in a real Vault
+// server, so plugins can be tested against realistic request lifecycles
+// without a Docker based cluster.
+package plugintest
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	vaulthttp "github.com/hashicorp/vault/http"
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault"
+	"github.com/mitchellh/go-testing-interface"
+)
+
+// ServerOptions configures the plugins available to a Server.
+type ServerOptions struct {
+	// SecretsEngines are the factories of secrets engine plugins, by the
+	// type they are mounted with.
+	SecretsEngines map[string]logical.Factory
+
+	// AuthMethods are the factories of auth method plugins, by the type they
+	// are enabled with.
+	AuthMethods map[string]logical.Factory
+}
+
+// Server is an initialized and unsealed in-memory Vault server, listening on
+// a local address.
+type Server struct {
+	core      *vault.Core
+	rootToken string
+	address   string
+	client    *api.Client
+}
+
+// NewServer starts a Server with the plugins of opts, which is stopped when
+// the test completes. It should work fine with a nil opts argument.
+func NewServer(t testing.T, opts *ServerOptions) *Server {
+	t.Helper()
+	if opts == nil {
+		opts = &ServerOptions{}
+	}
+
+	core, _, rootToken := vault.TestCoreUnsealedWithConfig(t, &vault.CoreConfig{
+		LogicalBackends:    opts.SecretsEngines,
+		CredentialBackends: opts.AuthMethods,
+	})
+	t.Cleanup(func() {
+		if err := core.Shutdown(); err != nil {
+			t.Logf("failed to shut down core: %s", err)
+		}
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		Handler: vaulthttp.Handler.Handler(&vault.HandlerProperties{
+			Core:           core,
+			ListenerConfig: &configutil.Listener{Address: ln.Addr().String()},
+		}),
+		ErrorLog: core.Logger().StandardLogger(nil),
+	}
+	go server.Serve(ln)
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	s := &Server{
+		core:      core,
+		rootToken: rootToken,
+		address:   "http://" + ln.Addr().String(),
+	}
+	s.client = s.NewClient(t, rootToken)
+	return s
+}
+
+// Core returns the core of the server.
+func (s *Server) Core() *vault.Core {
+	return s.core
+}
+
+// Address returns the address the server is listening on.
+func (s *Server) Address() string {
+	return s.address
+}
+
+// RootToken returns the root token of the server.
+func (s *Server) RootToken() string {
+	return s.rootToken
+}
+
+// Client returns a client of the server authenticated with the root token.
+func (s *Server) Client() *api.Client {
+	return s.client
+}
+
+// NewClient returns a client of the server authenticated with token, e.g. one
+// issued by an auth method under test.
+func (s *Server) NewClient(t testing.T, token string) *api.Client {
+	t.Helper()
+	config := api.DefaultConfig()
+	config.Address = s.address
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+	return client
+}
+
+// MountSecretsEngine mounts the secrets engine of type pluginType at path.
+func (s *Server) MountSecretsEngine(t testing.T, path, pluginType string) {
+	t.Helper()
+	if err := s.client.Sys().Mount(path, &api.MountInput{Type: pluginType}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// EnableAuthMethod enables the auth method of type pluginType at path.
+func (s *Server) EnableAuthMethod(t testing.T, path, pluginType string) {
+	t.Helper()
+	if err := s.client.Sys().EnableAuthWithOptions(path, &api.EnableAuthOptions{Type: pluginType}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// RevokeLease revokes the lease, and waits for the plugin to revoke its
+// secret.
+func (s *Server) RevokeLease(t testing.T, leaseID string) {
+	t.Helper()
+	if _, err := s.client.Logical().Write("sys/leases/revoke", map[string]interface{}{
+		"lease_id": leaseID,
+		"sync":     true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Rollback runs the periodic function of the plugin mounted at path, without
+// waiting for the periodic rollback of mounts.
+func (s *Server) Rollback(t testing.T, path string) {
+	t.Helper()
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	vault.TestCoreRollback(t, s.core, path)
+}
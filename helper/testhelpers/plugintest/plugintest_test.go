@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package plugintest
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func testLeasingBackend(revoked, periodic *int32) logical.Factory {
+	return func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+		b := &framework.Backend{
+			BackendType: logical.TypeLogical,
+			Paths: []*framework.Path{
+				{
+					Pattern: "creds",
+					Operations: map[logical.Operation]framework.OperationHandler{
+						logical.ReadOperation: &framework.PathOperation{
+							Callback: func(context.Context, *logical.Request, *framework.FieldData) (*logical.Response, error) {
+								resp := &logical.Response{
+									Secret: &logical.Secret{InternalData: map[string]interface{}{"secret_type": "creds"}},
+									Data:   map[string]interface{}{"password": "hunter2"},
+								}
+								resp.Secret.TTL = time.Hour
+								return resp, nil
+							},
+						},
+					},
+				},
+			},
+			Secrets: []*framework.Secret{
+				{
+					Type: "creds",
+					Revoke: func(context.Context, *logical.Request, *framework.FieldData) (*logical.Response, error) {
+						atomic.AddInt32(revoked, 1)
+						return nil, nil
+					},
+				},
+			},
+			PeriodicFunc: func(context.Context, *logical.Request) error {
+				atomic.AddInt32(periodic, 1)
+				return nil
+			},
+		}
+		return b, b.Setup(ctx, conf)
+	}
+}
+
+// TestServer ensures secrets of plugins are leased and revoked by the server,
+// and the periodic function of plugins can be run on demand.
+func TestServer(t *testing.T) {
+	var revoked, periodic int32
+	s := NewServer(t, &ServerOptions{
+		SecretsEngines: map[string]logical.Factory{"leasing": testLeasingBackend(&revoked, &periodic)},
+	})
+	s.MountSecretsEngine(t, "leasing", "leasing")
+
+	secret, err := s.Client().Logical().Read("leasing/creds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.LeaseID == "" || secret.LeaseDuration != 3600 || secret.Data["password"] != "hunter2" {
+		t.Fatalf("bad secret: %#v", secret)
+	}
+
+	s.RevokeLease(t, secret.LeaseID)
+	if atomic.LoadInt32(&revoked) != 1 {
+		t.Fatalf("expected secret to be revoked once, got %d", revoked)
+	}
+
+	before := atomic.LoadInt32(&periodic)
+	s.Rollback(t, "leasing")
+	if atomic.LoadInt32(&periodic) <= before {
+		t.Fatal("expected periodic function to run")
+	}
+}
@@ -0,0 +1,445 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package thresholdunseal implements delegated unseal, where key holders
+// prove possession of their share by signing a challenge issued by the server
+// instead of submitting the share itself.
+//
+// Setup splits a random scalar s over the P-256 group with Shamir's scheme
+// and picks a random base point P. The unseal key is encrypted with
+// AES-256-GCM under SHA-256(s·P), and only P, the ciphertext and the share
+// commitments s_i·G are kept by the server.
+//
+// To unseal, the server issues the challenge C = r·P for a random blinding
+// scalar r that never leaves its memory. Each key holder returns the partial
+// signature s_i·C together with a Chaum-Pedersen proof that it used the same
+// scalar as its commitment. Once the threshold is met, the server
+// interpolates the partial signatures to s·C = r·s·P, unblinds it with r⁻¹ and
+// decrypts the unseal key. The shares themselves never leave their holders,
+// and as every challenge is blinded with a fresh r, a partial signature is of
+// no use for any other unseal attempt.
+package thresholdunseal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/cloudflare/circl/group"
+)
+
+var (
+	suite = group.P256
+
+	// proofDST and keyDST are the domain separation tags of the proof
+	// challenge and the key derivation.
+	proofDST = []byte("vault-delegated-unseal-v1-proof")
+	keyDST   = []byte("vault-delegated-unseal-v1-key")
+)
+
+const (
+	// ShareLength is the length of a marshaled share: the one byte index
+	// followed by the scalar.
+	ShareLength = 1 + 32
+
+	elementLength = 33
+	scalarLength  = 32
+
+	// PartialSignatureLength is the length of a marshaled partial signature:
+	// the one byte share index, the partial signature and the proof
+	// challenge and response.
+	PartialSignatureLength = 1 + elementLength + 2*scalarLength
+)
+
+// Config holds the public parameters of a delegated unseal setup. It does
+// not contain anything that allows recovering the unseal key without the
+// cooperation of a threshold of share holders.
+type Config struct {
+	Threshold int `json:"threshold"`
+
+	// Base is the compressed base point P.
+	Base []byte `json:"base"`
+
+	// Commitments holds the compressed commitment s_i·G of each share, with
+	// the commitment of the share with index i at position i-1.
+	Commitments [][]byte `json:"commitments"`
+
+	// Ciphertext is the GCM nonce followed by the unseal key encrypted under
+	// the key derived from s·P.
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Generate sets up delegated unseal of secret for the given number of shares
+// and threshold. It returns the public configuration and the marshaled
+// shares.
+func Generate(secret []byte, shares, threshold int) (*Config, [][]byte, error) {
+	if shares < threshold {
+		return nil, nil, errors.New("shares cannot be less than threshold")
+	}
+	if shares > 255 {
+		return nil, nil, errors.New("shares cannot exceed 255")
+	}
+	if threshold < 1 {
+		return nil, nil, errors.New("threshold must be at least 1")
+	}
+	if len(secret) == 0 {
+		return nil, nil, errors.New("cannot split an empty secret")
+	}
+
+	// The coefficients of a random polynomial of degree threshold-1, whose
+	// constant term is s.
+	coefficients := make([]group.Scalar, threshold)
+	for i := range coefficients {
+		coefficients[i] = suite.RandomNonZeroScalar(rand.Reader)
+	}
+
+	base := suite.RandomElement(rand.Reader)
+	baseBytes, err := base.MarshalBinaryCompress()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := &Config{
+		Threshold: threshold,
+		Base:      baseBytes,
+	}
+
+	out := make([][]byte, 0, shares)
+	for i := 1; i <= shares; i++ {
+		x := suite.NewScalar().SetUint64(uint64(i))
+		y := suite.NewScalar()
+		for j := len(coefficients) - 1; j >= 0; j-- {
+			y.Mul(y, x)
+			y.Add(y, coefficients[j])
+		}
+
+		commitment, err := suite.NewElement().MulGen(y).MarshalBinaryCompress()
+		if err != nil {
+			return nil, nil, err
+		}
+		config.Commitments = append(config.Commitments, commitment)
+
+		scalar, err := y.MarshalBinary()
+		if err != nil {
+			return nil, nil, err
+		}
+		out = append(out, append([]byte{byte(i)}, scalar...))
+	}
+
+	aead, err := newAEAD(suite.NewElement().Mul(base, coefficients[0]))
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	config.Ciphertext = aead.Seal(nonce, nonce, secret, nil)
+
+	return config, out, nil
+}
+
+// Validate returns an error if the configuration is malformed.
+func (c *Config) Validate() error {
+	if c.Threshold < 1 || c.Threshold > len(c.Commitments) {
+		return fmt.Errorf("invalid threshold %d for %d shares", c.Threshold, len(c.Commitments))
+	}
+	if _, err := unmarshalElement(c.Base); err != nil {
+		return fmt.Errorf("invalid base: %w", err)
+	}
+	for i, commitment := range c.Commitments {
+		if _, err := unmarshalElement(commitment); err != nil {
+			return fmt.Errorf("invalid commitment for share %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the configuration.
+func (c *Config) Clone() *Config {
+	if c == nil {
+		return nil
+	}
+	ret := &Config{
+		Threshold:  c.Threshold,
+		Base:       append([]byte(nil), c.Base...),
+		Ciphertext: append([]byte(nil), c.Ciphertext...),
+	}
+	for _, commitment := range c.Commitments {
+		ret.Commitments = append(ret.Commitments, append([]byte(nil), commitment...))
+	}
+	return ret
+}
+
+// Sign computes the partial signature of the challenge with the marshaled
+// share. It is run by the key holder, so that the share never has to be
+// sent to the server.
+func Sign(share, challenge []byte) ([]byte, error) {
+	if len(share) != ShareLength || share[0] == 0 {
+		return nil, errors.New("invalid share")
+	}
+	s := suite.NewScalar()
+	if err := s.UnmarshalBinary(share[1:]); err != nil {
+		return nil, fmt.Errorf("invalid share: %w", err)
+	}
+	c, err := unmarshalElement(challenge)
+	if err != nil {
+		return nil, fmt.Errorf("invalid challenge: %w", err)
+	}
+
+	commitment := suite.NewElement().MulGen(s)
+	partial := suite.NewElement().Mul(c, s)
+
+	// Prove that log_G(commitment) == log_C(partial).
+	k := suite.RandomNonZeroScalar(rand.Reader)
+	e, err := proofChallenge(commitment, c, partial, suite.NewElement().MulGen(k), suite.NewElement().Mul(c, k))
+	if err != nil {
+		return nil, err
+	}
+	z := suite.NewScalar().Sub(k, suite.NewScalar().Mul(e, s))
+
+	partialBytes, err := partial.MarshalBinaryCompress()
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte{share[0]}, partialBytes...)
+	for _, scalar := range []group.Scalar{e, z} {
+		b, err := scalar.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+	return out, nil
+}
+
+// Session is a single delegated unseal attempt. It is not safe for
+// concurrent use.
+type Session struct {
+	config    *Config
+	blind     group.Scalar
+	challenge group.Element
+	partials  map[uint8]group.Element
+}
+
+// NewSession starts a delegated unseal attempt with a freshly blinded
+// challenge.
+func (c *Config) NewSession() (*Session, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	base, err := unmarshalElement(c.Base)
+	if err != nil {
+		return nil, err
+	}
+	blind := suite.RandomNonZeroScalar(rand.Reader)
+	return &Session{
+		config:    c,
+		blind:     blind,
+		challenge: suite.NewElement().Mul(base, blind),
+		partials:  make(map[uint8]group.Element),
+	}, nil
+}
+
+// Challenge returns the compressed challenge that key holders sign.
+func (s *Session) Challenge() []byte {
+	b, _ := s.challenge.MarshalBinaryCompress()
+	return b
+}
+
+// Progress returns the number of valid partial signatures received so far.
+func (s *Session) Progress() int {
+	return len(s.partials)
+}
+
+// Add verifies the marshaled partial signature and records it. It returns
+// false if a partial signature for the same share was already recorded.
+func (s *Session) Add(signature []byte) (bool, error) {
+	if len(signature) != PartialSignatureLength {
+		return false, errors.New("invalid partial signature length")
+	}
+	index := signature[0]
+	if index == 0 || int(index) > len(s.config.Commitments) {
+		return false, fmt.Errorf("invalid share index %d", index)
+	}
+	if _, ok := s.partials[index]; ok {
+		return false, nil
+	}
+
+	commitment, err := unmarshalElement(s.config.Commitments[index-1])
+	if err != nil {
+		return false, err
+	}
+	partial, err := unmarshalElement(signature[1 : 1+elementLength])
+	if err != nil {
+		return false, fmt.Errorf("invalid partial signature: %w", err)
+	}
+	e := suite.NewScalar()
+	z := suite.NewScalar()
+	if err := e.UnmarshalBinary(signature[1+elementLength : 1+elementLength+scalarLength]); err != nil {
+		return false, fmt.Errorf("invalid proof: %w", err)
+	}
+	if err := z.UnmarshalBinary(signature[1+elementLength+scalarLength:]); err != nil {
+		return false, fmt.Errorf("invalid proof: %w", err)
+	}
+
+	// Recompute the proof commitments as z·G + e·S_i and z·C + e·D_i.
+	a1 := suite.NewElement().MulGen(z)
+	a1.Add(a1, suite.NewElement().Mul(commitment, e))
+	a2 := suite.NewElement().Mul(s.challenge, z)
+	a2.Add(a2, suite.NewElement().Mul(partial, e))
+	expected, err := proofChallenge(commitment, s.challenge, partial, a1, a2)
+	if err != nil {
+		return false, err
+	}
+	if !expected.IsEqual(e) {
+		return false, fmt.Errorf("partial signature for share %d does not match its commitment", index)
+	}
+
+	s.partials[index] = partial
+	return true, nil
+}
+
+// Secret combines the partial signatures and decrypts the secret. It returns
+// an error if fewer than the threshold of partial signatures were recorded.
+func (s *Session) Secret() ([]byte, error) {
+	if len(s.partials) < s.config.Threshold {
+		return nil, fmt.Errorf("need %d partial signatures, have %d", s.config.Threshold, len(s.partials))
+	}
+
+	indexes := make([]uint8, 0, s.config.Threshold)
+	for index := range s.partials {
+		indexes = append(indexes, index)
+		if len(indexes) == s.config.Threshold {
+			break
+		}
+	}
+
+	// Interpolate s·C at zero.
+	combined := suite.Identity()
+	for _, i := range indexes {
+		combined.Add(combined, suite.NewElement().Mul(s.partials[i], lagrange(indexes, i)))
+	}
+
+	// Remove the blinding to get s·P.
+	return s.config.decrypt(suite.NewElement().Mul(combined, suite.NewScalar().Inv(s.blind)))
+}
+
+// Combine recovers the secret directly from a threshold of marshaled shares.
+// It allows operations that authorize with key shares, such as rekeying and
+// root token generation, to keep working once delegated unseal is set up.
+func Combine(c *Config, shares [][]byte) ([]byte, error) {
+	if len(shares) < c.Threshold {
+		return nil, fmt.Errorf("need %d shares, have %d", c.Threshold, len(shares))
+	}
+
+	scalars := make(map[uint8]group.Scalar, len(shares))
+	indexes := make([]uint8, 0, len(shares))
+	for _, share := range shares {
+		if len(share) != ShareLength || share[0] == 0 || int(share[0]) > len(c.Commitments) {
+			return nil, errors.New("invalid share")
+		}
+		if _, ok := scalars[share[0]]; ok {
+			return nil, fmt.Errorf("duplicate share index %d", share[0])
+		}
+		scalar := suite.NewScalar()
+		if err := scalar.UnmarshalBinary(share[1:]); err != nil {
+			return nil, fmt.Errorf("invalid share: %w", err)
+		}
+		scalars[share[0]] = scalar
+		indexes = append(indexes, share[0])
+	}
+
+	secret := suite.NewScalar()
+	for _, i := range indexes {
+		secret.Add(secret, suite.NewScalar().Mul(scalars[i], lagrange(indexes, i)))
+	}
+
+	base, err := unmarshalElement(c.Base)
+	if err != nil {
+		return nil, err
+	}
+	return c.decrypt(suite.NewElement().Mul(base, secret))
+}
+
+// lagrange returns the Lagrange coefficient λ_i = Π x_j / (x_j - x_i) for
+// interpolating at zero from the shares with the given indexes.
+func lagrange(indexes []uint8, i uint8) group.Scalar {
+	xi := suite.NewScalar().SetUint64(uint64(i))
+	lambda := suite.NewScalar().SetUint64(1)
+	for _, j := range indexes {
+		if i == j {
+			continue
+		}
+		xj := suite.NewScalar().SetUint64(uint64(j))
+		lambda.Mul(lambda, xj)
+		lambda.Mul(lambda, suite.NewScalar().Inv(suite.NewScalar().Sub(xj, xi)))
+	}
+	return lambda
+}
+
+// decrypt decrypts the ciphertext with the key derived from s·P.
+func (c *Config) decrypt(element group.Element) ([]byte, error) {
+	aead, err := newAEAD(element)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Ciphertext) < aead.NonceSize() {
+		return nil, errors.New("invalid ciphertext")
+	}
+	nonce := c.Ciphertext[:aead.NonceSize()]
+	secret, err := aead.Open(nil, nonce, c.Ciphertext[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return secret, nil
+}
+
+// proofChallenge hashes the statement and commitments of a Chaum-Pedersen
+// proof to its challenge scalar.
+func proofChallenge(elements ...group.Element) (group.Scalar, error) {
+	var msg []byte
+	for _, element := range elements {
+		b, err := element.MarshalBinaryCompress()
+		if err != nil {
+			return nil, err
+		}
+		msg = append(msg, b...)
+	}
+	return suite.HashToScalar(msg, proofDST), nil
+}
+
+// newAEAD returns the AES-256-GCM cipher keyed by the SHA-256 hash of the
+// element.
+func newAEAD(element group.Element) (cipher.AEAD, error) {
+	b, err := element.MarshalBinaryCompress()
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(keyDST)
+	h.Write(b)
+
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func unmarshalElement(b []byte) (group.Element, error) {
+	if len(b) != elementLength {
+		return nil, errors.New("invalid element length")
+	}
+	element := suite.NewElement()
+	if err := element.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	if element.IsIdentity() {
+		return nil, errors.New("element is the identity")
+	}
+	return element, nil
+}
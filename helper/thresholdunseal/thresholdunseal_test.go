@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package thresholdunseal
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThresholdUnseal(t *testing.T) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	require.NoError(t, err)
+
+	config, shares, err := Generate(secret, 5, 3)
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+	require.Len(t, shares, 5)
+	require.Len(t, config.Commitments, 5)
+	for _, share := range shares {
+		require.Len(t, share, ShareLength)
+	}
+
+	t.Run("threshold", func(t *testing.T) {
+		session, err := config.NewSession()
+		require.NoError(t, err)
+
+		for i, share := range []int{4, 1, 2} {
+			_, err := session.Secret()
+			require.Error(t, err)
+
+			signature, err := Sign(shares[share], session.Challenge())
+			require.NoError(t, err)
+			require.Len(t, signature, PartialSignatureLength)
+
+			added, err := session.Add(signature)
+			require.NoError(t, err)
+			require.True(t, added)
+			require.Equal(t, i+1, session.Progress())
+
+			added, err = session.Add(signature)
+			require.NoError(t, err)
+			require.False(t, added)
+		}
+
+		recovered, err := session.Secret()
+		require.NoError(t, err)
+		require.Equal(t, secret, recovered)
+	})
+
+	t.Run("combine", func(t *testing.T) {
+		recovered, err := Combine(config, [][]byte{shares[3], shares[0], shares[2]})
+		require.NoError(t, err)
+		require.Equal(t, secret, recovered)
+
+		_, err = Combine(config, shares[:2])
+		require.Error(t, err)
+		_, err = Combine(config, [][]byte{shares[0], shares[0], shares[1]})
+		require.ErrorContains(t, err, "duplicate")
+	})
+
+	t.Run("stale-challenge", func(t *testing.T) {
+		first, err := config.NewSession()
+		require.NoError(t, err)
+		second, err := config.NewSession()
+		require.NoError(t, err)
+		require.NotEqual(t, first.Challenge(), second.Challenge())
+
+		signature, err := Sign(shares[0], first.Challenge())
+		require.NoError(t, err)
+		_, err = second.Add(signature)
+		require.ErrorContains(t, err, "does not match")
+	})
+
+	t.Run("wrong-share", func(t *testing.T) {
+		session, err := config.NewSession()
+		require.NoError(t, err)
+
+		_, other, err := Generate(secret, 5, 3)
+		require.NoError(t, err)
+		signature, err := Sign(other[0], session.Challenge())
+		require.NoError(t, err)
+		_, err = session.Add(signature)
+		require.ErrorContains(t, err, "does not match")
+		require.Zero(t, session.Progress())
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		session, err := config.NewSession()
+		require.NoError(t, err)
+
+		_, err = Sign(shares[0][1:], session.Challenge())
+		require.Error(t, err)
+		_, err = Sign(shares[0], []byte("challenge"))
+		require.Error(t, err)
+
+		signature, err := Sign(shares[0], session.Challenge())
+		require.NoError(t, err)
+		signature[0] = 6
+		_, err = session.Add(signature)
+		require.ErrorContains(t, err, "invalid share index")
+		_, err = session.Add(signature[1:])
+		require.ErrorContains(t, err, "length")
+	})
+}
+
+func TestGenerate_Validation(t *testing.T) {
+	_, _, err := Generate([]byte("secret"), 2, 3)
+	require.Error(t, err)
+	_, _, err = Generate([]byte("secret"), 256, 3)
+	require.Error(t, err)
+	_, _, err = Generate([]byte("secret"), 3, 0)
+	require.Error(t, err)
+	_, _, err = Generate(nil, 3, 2)
+	require.Error(t, err)
+
+	config, shares, err := Generate([]byte("secret"), 1, 1)
+	require.NoError(t, err)
+	session, err := config.NewSession()
+	require.NoError(t, err)
+	signature, err := Sign(shares[0], session.Challenge())
+	require.NoError(t, err)
+	_, err = session.Add(signature)
+	require.NoError(t, err)
+	recovered, err := session.Secret()
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), recovered)
+}
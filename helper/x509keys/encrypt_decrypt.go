@@ -0,0 +1,206 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package x509keys encrypts key shares to the public keys of X.509
+// certificates, such as those of PIV smartcards, so that they can be
+// decrypted with standard PKCS#11 tooling.
+//
+// Shares encrypted to RSA keys use RSA-OAEP with SHA-256 and an empty label.
+//
+// Shares encrypted to EC keys use ECDH with an ephemeral key on the curve of
+// the certificate. The shared secret is passed through the ANSI X9.63 KDF with
+// SHA-256 and no shared info to derive an AES-256-GCM key. The encrypted
+// share is the uncompressed ephemeral public point, followed by the 12 byte
+// GCM nonce, followed by the GCM ciphertext and tag.
+package x509keys
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ParseCertificate parses a single PEM-encoded certificate.
+func ParseCertificate(certPEM string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM-encoded certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// Fingerprint returns the hex-encoded SHA-256 fingerprint of the certificate.
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// GetFingerprints returns the fingerprints of the given PEM-encoded
+// certificates, in the same order.
+func GetFingerprints(certs []string) ([]string, error) {
+	fingerprints := make([]string, 0, len(certs))
+	for _, certPEM := range certs {
+		cert, err := ParseCertificate(certPEM)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing given certificate: %w", err)
+		}
+		fingerprints = append(fingerprints, Fingerprint(cert))
+	}
+	return fingerprints, nil
+}
+
+// ValidateCertificate returns an error if shares cannot be encrypted to the
+// certificate at now, because it is not valid at now, its public key is not an
+// RSA or supported EC key, or its key usage does not allow it.
+func ValidateCertificate(cert *x509.Certificate, now time.Time) error {
+	fingerprint := Fingerprint(cert)
+
+	if now.Before(cert.NotBefore) {
+		return fmt.Errorf("certificate %s is not valid before %s", fingerprint, cert.NotBefore.Format(time.RFC3339))
+	}
+	if !now.Before(cert.NotAfter) {
+		return fmt.Errorf("certificate %s expired at %s", fingerprint, cert.NotAfter.Format(time.RFC3339))
+	}
+
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageKeyEncipherment == 0 {
+			return fmt.Errorf("certificate %s does not allow key encipherment", fingerprint)
+		}
+	case *ecdsa.PublicKey:
+		if _, err := pub.ECDH(); err != nil {
+			return fmt.Errorf("certificate %s has an unsupported EC key: %w", fingerprint, err)
+		}
+		if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageKeyAgreement == 0 {
+			return fmt.Errorf("certificate %s does not allow key agreement", fingerprint)
+		}
+	default:
+		return fmt.Errorf("certificate %s has an unsupported public key type %T; only RSA and EC keys are supported", fingerprint, cert.PublicKey)
+	}
+	return nil
+}
+
+// EncryptShares encrypts each of the inputs to the public key of the
+// PEM-encoded certificate at the same index, and returns the fingerprints of
+// the certificates and the encrypted shares.
+func EncryptShares(input [][]byte, certs []string) ([]string, [][]byte, error) {
+	if len(certs) != len(input) {
+		return nil, nil, fmt.Errorf("mismatch between number items to encrypt and number of certificates")
+	}
+
+	fingerprints := make([]string, 0, len(certs))
+	encryptedShares := make([][]byte, 0, len(certs))
+	for i, certPEM := range certs {
+		cert, err := ParseCertificate(certPEM)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing given certificate: %w", err)
+		}
+		encrypted, err := encrypt(cert.PublicKey, input[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("error encrypting given value to certificate %s: %w", Fingerprint(cert), err)
+		}
+		fingerprints = append(fingerprints, Fingerprint(cert))
+		encryptedShares = append(encryptedShares, encrypted)
+	}
+	return fingerprints, encryptedShares, nil
+}
+
+func encrypt(pub crypto.PublicKey, plaintext []byte) ([]byte, error) {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, plaintext, nil)
+	case *ecdsa.PublicKey:
+		recipient, err := pub.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		ephemeral, err := recipient.Curve().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		secret, err := ephemeral.ECDH(recipient)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := newAEAD(secret)
+		if err != nil {
+			return nil, err
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		out := append(ephemeral.PublicKey().Bytes(), nonce...)
+		return aead.Seal(out, nonce, plaintext, nil), nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// DecryptShare decrypts a share encrypted by EncryptShares with the private
+// key of the certificate. It is intended for testing; operators holding keys
+// on smartcards decrypt shares with their PKCS#11 tooling.
+func DecryptShare(priv crypto.PrivateKey, encrypted []byte) ([]byte, error) {
+	switch priv := priv.(type) {
+	case *rsa.PrivateKey:
+		return rsa.DecryptOAEP(sha256.New(), nil, priv, encrypted, nil)
+	case *ecdsa.PrivateKey:
+		recipient, err := priv.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		pointLen := len(recipient.PublicKey().Bytes())
+		if len(encrypted) < pointLen {
+			return nil, errors.New("encrypted share is too short")
+		}
+		ephemeral, err := recipient.Curve().NewPublicKey(encrypted[:pointLen])
+		if err != nil {
+			return nil, err
+		}
+		secret, err := recipient.ECDH(ephemeral)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := newAEAD(secret)
+		if err != nil {
+			return nil, err
+		}
+
+		encrypted = encrypted[pointLen:]
+		if len(encrypted) < aead.NonceSize() {
+			return nil, errors.New("encrypted share is too short")
+		}
+		return aead.Open(nil, encrypted[:aead.NonceSize()], encrypted[aead.NonceSize():], nil)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", priv)
+	}
+}
+
+// newAEAD returns the AES-256-GCM cipher keyed by the ANSI X9.63 KDF with
+// SHA-256 of the ECDH shared secret.
+func newAEAD(secret []byte) (cipher.AEAD, error) {
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, 1)
+
+	h := sha256.New()
+	h.Write(secret)
+	h.Write(counter)
+
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
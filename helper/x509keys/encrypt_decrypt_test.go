@@ -0,0 +1,136 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package x509keys
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCertificate returns a PEM-encoded self-signed certificate for the key,
+// valid from notBefore to notAfter.
+func testCertificate(t *testing.T, key crypto.Signer, usage x509.KeyUsage, notBefore, notAfter time.Time) string {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     usage,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestEncryptShares(t *testing.T) {
+	now := time.Now()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	keys := []crypto.Signer{rsaKey, p256Key, p384Key}
+	certs := []string{
+		testCertificate(t, rsaKey, x509.KeyUsageKeyEncipherment, now.Add(-time.Hour), now.Add(time.Hour)),
+		testCertificate(t, p256Key, x509.KeyUsageKeyAgreement, now.Add(-time.Hour), now.Add(time.Hour)),
+		testCertificate(t, p384Key, 0, now.Add(-time.Hour), now.Add(time.Hour)),
+	}
+	shares := [][]byte{[]byte("share-1"), []byte("share-2"), []byte("share-3")}
+
+	fingerprints, encrypted, err := EncryptShares(shares, certs)
+	require.NoError(t, err)
+
+	expectedFingerprints, err := GetFingerprints(certs)
+	require.NoError(t, err)
+	require.Equal(t, expectedFingerprints, fingerprints)
+
+	for i, key := range keys {
+		require.NotEqual(t, shares[i], encrypted[i])
+		decrypted, err := DecryptShare(key, encrypted[i])
+		require.NoError(t, err)
+		require.Equal(t, shares[i], decrypted)
+	}
+
+	_, err = DecryptShare(p256Key, encrypted[2])
+	require.Error(t, err)
+
+	_, _, err = EncryptShares(shares[:1], certs)
+	require.ErrorContains(t, err, "mismatch")
+}
+
+func TestValidateCertificate(t *testing.T) {
+	now := time.Now()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		cert       string
+		wantErrStr string
+	}{
+		"rsa":              {cert: testCertificate(t, rsaKey, x509.KeyUsageKeyEncipherment, now.Add(-time.Hour), now.Add(time.Hour))},
+		"ec":               {cert: testCertificate(t, ecKey, x509.KeyUsageKeyAgreement, now.Add(-time.Hour), now.Add(time.Hour))},
+		"expired":          {cert: testCertificate(t, rsaKey, 0, now.Add(-2*time.Hour), now.Add(-time.Hour)), wantErrStr: "expired"},
+		"not yet valid":    {cert: testCertificate(t, rsaKey, 0, now.Add(time.Hour), now.Add(2*time.Hour)), wantErrStr: "not valid before"},
+		"rsa signing only": {cert: testCertificate(t, rsaKey, x509.KeyUsageDigitalSignature, now.Add(-time.Hour), now.Add(time.Hour)), wantErrStr: "key encipherment"},
+		"ec signing only":  {cert: testCertificate(t, ecKey, x509.KeyUsageDigitalSignature, now.Add(-time.Hour), now.Add(time.Hour)), wantErrStr: "key agreement"},
+		"ed25519":          {cert: testCertificate(t, edKey, 0, now.Add(-time.Hour), now.Add(time.Hour)), wantErrStr: "unsupported public key type"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cert, err := ParseCertificate(tc.cert)
+			require.NoError(t, err)
+
+			err = ValidateCertificate(cert, now)
+			if tc.wantErrStr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.wantErrStr)
+		})
+	}
+}
+
+func TestCertFilesFlag(t *testing.T) {
+	now := time.Now()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	cert := testCertificate(t, key, 0, now.Add(-time.Hour), now.Add(time.Hour))
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	require.NoError(t, os.WriteFile(certFile, []byte(cert), 0o600))
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidFile, []byte("not a certificate"), 0o600))
+
+	var certs CertFilesFlag
+	require.NoError(t, certs.Set(certFile+", @"+certFile))
+	require.Equal(t, CertFilesFlag{cert, cert}, certs)
+	require.ErrorContains(t, certs.Set(certFile), "only be specified once")
+
+	var invalid CertFilesFlag
+	require.ErrorContains(t, invalid.Set(invalidFile), "error parsing certificate file")
+}
@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package x509keys
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CertFilesFlag implements the flag.Value interface and allows parsing and
+// reading a list of PEM-encoded certificate files.
+type CertFilesFlag []string
+
+func (c *CertFilesFlag) String() string {
+	return fmt.Sprint(*c)
+}
+
+func (c *CertFilesFlag) Set(val string) error {
+	if len(*c) > 0 {
+		return errors.New("can only be specified once")
+	}
+
+	certs, err := ReadCertFiles(strings.Split(val, ","))
+	if err != nil {
+		return err
+	}
+
+	*c = CertFilesFlag(certs)
+	return nil
+}
+
+func (c *CertFilesFlag) Example() string { return "cert1.pem, cert2.pem, ..." }
+
+// ReadCertFiles reads the given PEM-encoded certificate files from disk and
+// returns their contents in the same order.
+func ReadCertFiles(paths []string) ([]string, error) {
+	certs := make([]string, len(paths))
+	for i, path := range paths {
+		path = strings.TrimPrefix(strings.TrimSpace(path), "@")
+		if path == "" {
+			return nil, errors.New("empty path")
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := ParseCertificate(string(data)); err != nil {
+			return nil, fmt.Errorf("error parsing certificate file %q: %w", path, err)
+		}
+		certs[i] = string(data)
+	}
+	return certs, nil
+}
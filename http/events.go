@@ -4,6 +4,7 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hashicorp/eventlogger"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -29,6 +31,10 @@ import (
 // events that the websocket requested access to.
 var webSocketRevalidationTime = 5 * time.Minute
 
+// sseKeepAliveTime is how often we send a comment line on server-sent event
+// streams to keep the HTTP connection (and any intermediate proxies) alive.
+var sseKeepAliveTime = 30 * time.Second
+
 type eventSubscriber struct {
 	ctx               context.Context
 	cancelCtx         context.CancelFunc
@@ -38,6 +44,12 @@ type eventSubscriber struct {
 	namespacePatterns []string
 	pattern           string
 	bexprFilter       string
+	accessor          string
+	pathPrefix        string
+	pathPattern       string
+	mountType         string
+	metadata          map[string]string
+	cursor            string
 	json              bool
 	checkCache        *cache.Cache
 	isRootToken       bool
@@ -63,6 +75,13 @@ func (sub *eventSubscriber) handleEventsSubscribeWebsocket() {
 	defer cancel()
 	logger.Debug("WebSocket is subscribed to messages", "namespaces", sub.namespacePatterns, "event_types", sub.pattern, "bexpr_filter", sub.bexprFilter)
 
+	replayed, replayedIDs, err := sub.replay()
+	if err != nil {
+		logger.Info("Error replaying events", "error", err)
+		respondReplayError(sub.w, err)
+		return
+	}
+
 	conn, err := websocket.Accept(sub.w, sub.r, nil)
 	if err != nil {
 		logger.Info("Could not accept as websocket", "error", err)
@@ -81,12 +100,12 @@ func (sub *eventSubscriber) handleEventsSubscribeWebsocket() {
 
 	defer func() {
 		if closeErr != nil {
-			closeStatus = websocket.CloseStatus(err)
+			closeStatus = websocket.CloseStatus(closeErr)
 			if closeStatus == -1 {
 				closeStatus = websocket.StatusInternalError
 			}
-			closeReason = fmt.Sprintf("Internal error: %v", err)
-			logger.Debug("Error from websocket handler", "error", err)
+			closeReason = fmt.Sprintf("Internal error: %v", closeErr)
+			logger.Debug("Error from websocket handler", "error", closeErr)
 		}
 		// Close() will panic if the reason is greater than this length
 		if len(closeReason) > 123 {
@@ -112,6 +131,36 @@ func (sub *eventSubscriber) handleEventsSubscribeWebsocket() {
 		}
 	}()
 
+	send := func(message *eventlogger.Event) error {
+		logger.Debug("Sending message to websocket", "message", message.Payload)
+		var messageBytes []byte
+		var messageType websocket.MessageType
+		if sub.json {
+			var ok bool
+			messageBytes, ok = message.Format("cloudevents-json")
+			if !ok {
+				logger.Warn("Could not get cloudevents JSON format")
+				return errors.New("could not get cloudevents JSON format")
+			}
+			messageType = websocket.MessageText
+		} else {
+			var err error
+			messageBytes, err = proto.Marshal(message.Payload.(*logical.EventReceived))
+			if err != nil {
+				logger.Warn("Could not serialize websocket event", "error", err)
+				return err
+			}
+			messageType = websocket.MessageBinary
+		}
+		return conn.Write(ctx, messageType, messageBytes)
+	}
+
+	for _, message := range replayed {
+		if closeErr = send(message); closeErr != nil {
+			return
+		}
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -122,40 +171,198 @@ func (sub *eventSubscriber) handleEventsSubscribeWebsocket() {
 			// For example, if a new namespace was created that matches the namespace patterns,
 			// but the token doesn't have access to it, we don't want to accidentally send it to
 			// the websocket.
-			if !sub.allowMessageCached(message.Payload.(*logical.EventReceived)) {
+			eventReceived := message.Payload.(*logical.EventReceived)
+			if replayedIDs.pop(eventReceived.ID()) || !sub.matchesMetadataFilters(eventReceived) || !sub.allowMessageCached(eventReceived) {
 				continue
 			}
 
-			logger.Debug("Sending message to websocket", "message", message.Payload)
-			var messageBytes []byte
-			var messageType websocket.MessageType
-			if sub.json {
-				var ok bool
-				messageBytes, ok = message.Format("cloudevents-json")
-				if !ok {
-					logger.Warn("Could not get cloudevents JSON format")
-					closeErr = errors.New("could not get cloudevents JSON format")
-					return
-				}
-				messageType = websocket.MessageText
-			} else {
-				messageBytes, err = proto.Marshal(message.Payload.(*logical.EventReceived))
-				messageType = websocket.MessageBinary
+			if closeErr = send(message); closeErr != nil {
+				return
 			}
-			if err != nil {
-				logger.Warn("Could not serialize websocket event", "error", err)
-				closeErr = err
+		}
+	}
+}
+
+// handleEventsSubscribeSSE subscribes to the events and then runs forever, serving events to the
+// client as a text/event-stream response. Server-sent events are always formatted as CloudEvents JSON.
+func (sub *eventSubscriber) handleEventsSubscribeSSE() {
+	ctx := sub.ctx
+	logger := sub.logger
+
+	// http.ResponseWriter may be wrapped, e.g. in wrapGenericHandler, so
+	// unwrap it until the underlying functionality is found
+	w := sub.w
+	flusher, ok := w.(http.Flusher)
+	for !ok {
+		nw, isWrapper := w.(logical.WrappingResponseWriter)
+		if !isWrapper {
+			respondError(sub.w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+			return
+		}
+		w = nw.Wrapped()
+		flusher, ok = w.(http.Flusher)
+	}
+
+	ch, cancel, err := sub.events.SubscribeMultipleNamespaces(ctx, sub.namespacePatterns, sub.pattern, sub.bexprFilter)
+	if err != nil {
+		logger.Info("Error subscribing", "error", err)
+		respondError(sub.w, http.StatusBadRequest, fmt.Errorf("error subscribing"))
+		return
+	}
+	defer cancel()
+	logger.Debug("Event stream is subscribed to messages", "namespaces", sub.namespacePatterns, "event_types", sub.pattern, "bexpr_filter", sub.bexprFilter)
+
+	replayed, replayedIDs, err := sub.replay()
+	if err != nil {
+		logger.Info("Error replaying events", "error", err)
+		respondReplayError(sub.w, err)
+		return
+	}
+
+	sub.w.Header().Set("Content-Type", "text/event-stream")
+	sub.w.Header().Set("Cache-Control", "no-cache")
+	sub.w.Header().Set("Connection", "keep-alive")
+	sub.w.WriteHeader(http.StatusOK)
+
+	// 0 byte write is needed before the Flush call so that if we are using
+	// a gzip stream it will go ahead and write out the HTTP response header
+	if _, err := sub.w.Write([]byte("")); err != nil {
+		logger.Debug("Error seeding flusher", "error", err)
+		return
+	}
+	flusher.Flush()
+
+	// continually validate subscribe access while the stream is running
+	go sub.validateSubscribeAccessLoop()
+
+	send := func(message *eventlogger.Event) error {
+		eventReceived := message.Payload.(*logical.EventReceived)
+		logger.Debug("Sending message to event stream", "message", message.Payload)
+		messageBytes, ok := message.Format("cloudevents-json")
+		if !ok {
+			logger.Warn("Could not get cloudevents JSON format")
+			return errors.New("could not get cloudevents JSON format")
+		}
+		_, err := fmt.Fprintf(sub.w, "id: %s\nevent: %s\ndata: %s\n\n", eventReceived.ID(), eventReceived.EventType, bytes.TrimSpace(messageBytes))
+		if err != nil {
+			logger.Debug("Error writing to event stream", "error", err)
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	for _, message := range replayed {
+		if err := send(message); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(sseKeepAliveTime)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Event stream context is done, closing the connection")
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(sub.w, ": keep-alive\n\n"); err != nil {
+				logger.Debug("Error writing to event stream", "error", err)
 				return
 			}
-			err = conn.Write(ctx, messageType, messageBytes)
-			if err != nil {
-				closeErr = err
+			flusher.Flush()
+		case message := <-ch:
+			eventReceived := message.Payload.(*logical.EventReceived)
+			if replayedIDs.pop(eventReceived.ID()) || !sub.matchesMetadataFilters(eventReceived) || !sub.allowMessageCached(eventReceived) {
+				continue
+			}
+
+			if err := send(message); err != nil {
 				return
 			}
 		}
 	}
 }
 
+// replayedEvents is the set of IDs of the events replayed to a subscriber,
+// which are skipped if they are received on the subscription as well.
+type replayedEvents map[string]struct{}
+
+// pop reports whether the event was replayed, removing it from the set.
+func (r replayedEvents) pop(id string) bool {
+	if _, ok := r[id]; !ok {
+		return false
+	}
+	delete(r, id)
+	return true
+}
+
+// replay returns the events sent after the requested cursor which match the
+// subscription's filters. As the subscription is made before replaying, the
+// replayed events may also be received on the subscription.
+func (sub *eventSubscriber) replay() ([]*eventlogger.Event, replayedEvents, error) {
+	replayedIDs := make(replayedEvents)
+	if sub.cursor == "" {
+		return nil, replayedIDs, nil
+	}
+
+	events, err := sub.events.Replay(sub.ctx, sub.namespacePatterns, sub.pattern, sub.bexprFilter, sub.cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var replayed []*eventlogger.Event
+	for _, message := range events {
+		eventReceived := message.Payload.(*logical.EventReceived)
+		if !sub.matchesMetadataFilters(eventReceived) || !sub.allowMessageCached(eventReceived) {
+			continue
+		}
+		replayed = append(replayed, message)
+		replayedIDs[eventReceived.ID()] = struct{}{}
+	}
+	return replayed, replayedIDs, nil
+}
+
+// respondReplayError responds to a subscription whose events could not be
+// replayed, with a 410 if the cursor is no longer in the event history.
+func respondReplayError(w http.ResponseWriter, err error) {
+	if errors.Is(err, eventbus.ErrCursorExpired) {
+		respondError(w, http.StatusGone, err)
+		return
+	}
+	respondError(w, http.StatusBadRequest, fmt.Errorf("error replaying events"))
+}
+
+// matchesMetadataFilters checks the message against the optional accessor,
+// path prefix, path glob, mount type and metadata filters that were requested
+// with the subscription.
+func (sub *eventSubscriber) matchesMetadataFilters(message *logical.EventReceived) bool {
+	if sub.accessor == "" && sub.pathPrefix == "" && sub.pathPattern == "" && sub.mountType == "" && len(sub.metadata) == 0 {
+		return true
+	}
+	fields := message.GetEvent().GetMetadata().GetFields()
+	if sub.accessor != "" && fields["accessor"].GetStringValue() != sub.accessor {
+		return false
+	}
+	dataPath := fields[logical.EventMetadataDataPath].GetStringValue()
+	if sub.pathPrefix != "" && !strings.HasPrefix(dataPath, sub.pathPrefix) {
+		return false
+	}
+	if sub.pathPattern != "" && !glob.Glob(sub.pathPattern, dataPath) {
+		return false
+	}
+	if sub.mountType != "" && message.GetPluginInfo().GetPlugin() != sub.mountType {
+		return false
+	}
+	for key, value := range sub.metadata {
+		if fields[key].GetStringValue() != value {
+			return false
+		}
+	}
+	return true
+}
+
 // allowMessageCached checks that the message is allowed to received by the websocket.
 // It caches results for specific namespaces, data paths, and event types.
 func (sub *eventSubscriber) allowMessageCached(message *logical.EventReceived) bool {
@@ -278,6 +485,22 @@ func handleEventsSubscribe(core *vault.Core, req *logical.Request) http.Handler
 		}
 
 		bexprFilter := strings.TrimSpace(r.URL.Query().Get("filter"))
+		accessor := strings.TrimSpace(r.URL.Query().Get("accessor"))
+		pathPrefix := strings.TrimLeft(strings.TrimSpace(r.URL.Query().Get("path_prefix")), "/")
+		pathPattern := strings.TrimLeft(strings.TrimSpace(r.URL.Query().Get("path")), "/")
+		mountType := strings.TrimSpace(r.URL.Query().Get("mount_type"))
+		metadata, err := parseMetadataFilters(r.URL.Query()["metadata"])
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// Server-sent event clients resume from the last event they received
+		// when reconnecting
+		cursor := strings.TrimSpace(r.URL.Query().Get("cursor"))
+		if cursor == "" {
+			cursor = strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+		}
 		namespacePatterns := r.URL.Query()["namespaces"]
 		namespacePatterns = prependNamespacePatterns(namespacePatterns, ns)
 		isRoot := entry.IsRoot()
@@ -292,6 +515,12 @@ func handleEventsSubscribe(core *vault.Core, req *logical.Request) http.Handler
 			namespacePatterns: namespacePatterns,
 			pattern:           pattern,
 			bexprFilter:       bexprFilter,
+			accessor:          accessor,
+			pathPrefix:        pathPrefix,
+			pathPattern:       pathPattern,
+			mountType:         mountType,
+			metadata:          metadata,
+			cursor:            cursor,
 			json:              json,
 			checkCache:        cache.New(webSocketRevalidationTime, webSocketRevalidationTime),
 			clientToken:       auth.ClientToken,
@@ -301,10 +530,29 @@ func handleEventsSubscribe(core *vault.Core, req *logical.Request) http.Handler
 			r:                 r,
 			req:               req,
 		}
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			sub.handleEventsSubscribeSSE()
+			return
+		}
 		sub.handleEventsSubscribeWebsocket()
 	})
 }
 
+// parseMetadataFilters parses the metadata filters of a subscription, each of
+// the form key=value.
+func parseMetadataFilters(filters []string) (map[string]string, error) {
+	metadata := make(map[string]string, len(filters))
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid metadata filter %q, expected key=value", filter)
+		}
+		metadata[key] = value
+	}
+	return metadata, nil
+}
+
 // prependNamespacePatterns prepends the request namespace to the namespace patterns,
 // and also adds the request namespace to the list.
 func prependNamespacePatterns(patterns []string, requestNamespace *namespace.Namespace) []string {
@@ -4,6 +4,7 @@
 package http
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -26,6 +27,7 @@ import (
 	"github.com/hashicorp/vault/vault"
 	"github.com/hashicorp/vault/vault/cluster"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
 	"nhooyr.io/websocket"
 )
 
@@ -128,6 +130,103 @@ func TestEventsSubscribe(t *testing.T) {
 	}
 }
 
+// TestEventsSubscribeSSE tests that lease lifecycle events can be consumed as
+// server-sent events, and that the accessor filter is applied.
+func TestEventsSubscribeSSE(t *testing.T) {
+	core := vault.TestCoreWithConfig(t, &vault.CoreConfig{})
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	// unseal the core
+	keys, token := vault.TestCoreInit(t, core)
+	for _, key := range keys {
+		_, err := core.Unseal(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := api.DefaultConfig()
+	config.Address = addr
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+
+	// create a token up front so that we know which accessor to filter on
+	secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{Policies: []string{"default"}, TTL: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessor := secret.Auth.Accessor
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	location := fmt.Sprintf("%s/v1/sys/events/subscribe/token/*?accessor=%s", addr, url.QueryEscape(accessor))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream content type, got %q", ct)
+	}
+
+	// revoke an unrelated token first, which should be filtered out
+	other, err := client.Auth().Token().Create(&api.TokenCreateRequest{Policies: []string{"default"}, TTL: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Auth().Token().RevokeAccessor(other.Auth.Accessor); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Auth().Token().RevokeAccessor(accessor); err != nil {
+		t.Fatal(err)
+	}
+
+	var eventType, data string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && data == "" {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if eventType != "token/revoke" {
+		t.Fatalf("expected token/revoke event, got %q", eventType)
+	}
+
+	event := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatal(err)
+	}
+	checkRequiredCloudEventsFields(t, event)
+	metadata := event["data"].(map[string]interface{})["event"].(map[string]interface{})["metadata"].(map[string]interface{})
+	if metadata["accessor"] != accessor {
+		t.Fatalf("expected accessor %s, got %v", accessor, metadata["accessor"])
+	}
+	if metadata["data_path"] != "auth/token/create" {
+		t.Fatalf("expected data_path auth/token/create, got %v", metadata["data_path"])
+	}
+}
+
 // TestBexprFilters tests that go-bexpr filters are used to filter events.
 func TestBexprFilters(t *testing.T) {
 	core := vault.TestCoreWithConfig(t, &vault.CoreConfig{})
@@ -408,3 +507,101 @@ func TestCanForwardEventConnections(t *testing.T) {
 		t.Fatalf("Expected 1 core to handle the request and 2 to forward")
 	}
 }
+
+// TestEventsSubscribeFiltersAndReplay tests that the path, mount type and
+// metadata filters are applied server-side, and that events after a cursor are
+// replayed before new events.
+func TestEventsSubscribeFiltersAndReplay(t *testing.T) {
+	core := vault.TestCoreWithConfig(t, &vault.CoreConfig{})
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	// unseal the core
+	keys, token := vault.TestCoreInit(t, core)
+	for _, key := range keys {
+		_, err := core.Unseal(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sendEvent := func(plugin, dataPath, operation string) string {
+		t.Helper()
+		event, err := logical.NewEvent()
+		if err != nil {
+			t.Fatal(err)
+		}
+		event.Metadata, err = structpb.NewStruct(map[string]interface{}{
+			logical.EventMetadataDataPath:  dataPath,
+			logical.EventMetadataOperation: operation,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pluginInfo := &logical.EventPluginInfo{
+			MountPath: "secret/",
+			Plugin:    plugin,
+		}
+		err = core.Events().SendEventInternal(namespace.RootContext(ctx), namespace.RootNamespace, pluginInfo, "kv-v2/data-write", event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return event.Id
+	}
+
+	subscribe := func(cursor string) *http.Response {
+		t.Helper()
+		query := url.Values{}
+		query.Set("path", "secret/data/app/*")
+		query.Set("mount_type", "kv")
+		query.Add("metadata", "operation=data-write")
+		query.Set("cursor", cursor)
+		location := fmt.Sprintf("%s/v1/sys/events/subscribe/kv-v2/*?%s", addr, query.Encode())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Vault-Token", token)
+		req.Header.Set("Accept", "text/event-stream")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	cursor := sendEvent("kv", "data/app/a", "data-write")
+	sendEvent("kv", "data/other", "data-write")
+	sendEvent("kv", "data/app/b", "data-delete")
+	replayed := sendEvent("kv", "data/app/c", "data-write")
+
+	resp := subscribe(cursor)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	sendEvent("database", "data/app/d", "data-write")
+	received := sendEvent("kv", "data/app/e", "data-write")
+
+	var ids []string
+	scanner := bufio.NewScanner(resp.Body)
+	for len(ids) < 2 && scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{replayed, received}, ids)
+
+	// The cursor is no longer in the event history once it is full
+	core.Events().SetHistorySize(1)
+	sendEvent("kv", "data/app/f", "data-write")
+	resp = subscribe(cursor)
+	assert.Equal(t, http.StatusGone, resp.StatusCode)
+}
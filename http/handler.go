@@ -20,12 +20,14 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/go-sockaddr"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/namespace"
@@ -48,6 +50,16 @@ const (
 	// wrap in; has no effect if the wrap TTL is not set
 	WrapFormatHeaderName = "X-Vault-Wrap-Format"
 
+	// WrapBoundCIDRsHeaderName is the name of the header containing a comma
+	// separated list of CIDRs the wrapping token can be unwrapped from; has no
+	// effect if the wrap TTL is not set
+	WrapBoundCIDRsHeaderName = "X-Vault-Wrap-Bound-CIDRs"
+
+	// WrapNotBeforeHeaderName is the name of the header containing the time,
+	// either RFC3339 or a duration from now, before which the wrapping token
+	// cannot be unwrapped; has no effect if the wrap TTL is not set
+	WrapNotBeforeHeaderName = "X-Vault-Wrap-Not-Before"
+
 	// NoRequestForwardingHeaderName is the name of the header telling Vault
 	// not to use request forwarding
 	NoRequestForwardingHeaderName = "X-Vault-No-Request-Forwarding"
@@ -114,6 +126,15 @@ var (
 	websocketRawPaths = []string{
 		"/v1/sys/events/subscribe",
 	}
+	// drainExemptPaths are still served while the requests in flight are
+	// drained ahead of sealing, so that the node can be monitored, or sealed
+	// right away
+	drainExemptPaths = []string{
+		"/v1/sys/health",
+		"/v1/sys/leader",
+		"/v1/sys/seal",
+		"/v1/sys/seal-status",
+	}
 	oidcProtectedPathRegex = regexp.MustCompile(`^identity/oidc/provider/\w(([\w-.]+)?\w)?/userinfo$`)
 )
 
@@ -121,6 +142,7 @@ func init() {
 	alwaysRedirectPaths.AddPaths([]string{
 		"sys/storage/raft/snapshot",
 		"sys/storage/raft/snapshot-force",
+		"sys/storage/raft/snapshot-restore-path",
 		"!sys/storage/raft/snapshot-auto/config",
 	})
 	websocketPaths.AddPaths(websocketRawPaths)
@@ -157,13 +179,20 @@ func handler(props *vault.HandlerProperties) http.Handler {
 		chrootNamespace = props.ListenerConfig.ChrootNamespace
 	}
 
+	// Requests submitting key shares are checked for replays if the listener
+	// requires it
+	replayProtected := func(h http.Handler) http.Handler { return h }
+	if props.ListenerConfig != nil && props.ListenerConfig.ReplayProtection {
+		replayProtected = newReplayProtector(props.ListenerConfig.ReplayProtectionWindow).wrap
+	}
+
 	switch {
 	case props.RecoveryMode:
 		raw := vault.NewRawBackend(core)
 		strategy := vault.GenerateRecoveryTokenStrategy(props.RecoveryToken)
 		mux.Handle("/v1/sys/raw/", handleLogicalRecovery(raw, props.RecoveryToken))
 		mux.Handle("/v1/sys/generate-recovery-token/attempt", handleSysGenerateRootAttempt(core, strategy))
-		mux.Handle("/v1/sys/generate-recovery-token/update", handleSysGenerateRootUpdate(core, strategy))
+		mux.Handle("/v1/sys/generate-recovery-token/update", replayProtected(handleSysGenerateRootUpdate(core, strategy)))
 	default:
 		// Handle non-forwarded paths
 		mux.Handle("/v1/sys/config/state/", handleLogicalNoForward(core, chrootNamespace))
@@ -176,23 +205,25 @@ func handler(props *vault.HandlerProperties) http.Handler {
 		mux.Handle("/v1/sys/seal-backend-status", handleSysSealBackendStatus(core))
 		mux.Handle("/v1/sys/seal", handleSysSeal(core))
 		mux.Handle("/v1/sys/step-down", handleRequestForwarding(core, handleSysStepDown(core)))
-		mux.Handle("/v1/sys/unseal", handleSysUnseal(core))
+		mux.Handle("/v1/sys/unseal", replayProtected(handleSysUnseal(core)))
+		mux.Handle("/v1/sys/unseal-delegated", handleSysDelegatedUnseal(core))
 		mux.Handle("/v1/sys/leader", handleSysLeader(core,
 			WithRedactAddresses(props.ListenerConfig.RedactAddresses)))
 		mux.Handle("/v1/sys/health", handleSysHealth(core,
 			WithRedactClusterName(props.ListenerConfig.RedactClusterName),
-			WithRedactVersion(props.ListenerConfig.RedactVersion)))
+			WithRedactVersion(props.ListenerConfig.RedactVersion),
+			WithHealthProfiles(props.ListenerConfig.HealthProfiles)))
 		mux.Handle("/v1/sys/monitor", handleLogicalNoForward(core, chrootNamespace))
 		mux.Handle("/v1/sys/generate-root/attempt", handleRequestForwarding(core,
 			handleAuditNonLogical(core, handleSysGenerateRootAttempt(core, vault.GenerateStandardRootTokenStrategy))))
-		mux.Handle("/v1/sys/generate-root/update", handleRequestForwarding(core,
-			handleAuditNonLogical(core, handleSysGenerateRootUpdate(core, vault.GenerateStandardRootTokenStrategy))))
+		mux.Handle("/v1/sys/generate-root/update", replayProtected(handleRequestForwarding(core,
+			handleAuditNonLogical(core, handleSysGenerateRootUpdate(core, vault.GenerateStandardRootTokenStrategy)))))
 		mux.Handle("/v1/sys/rekey/init", handleRequestForwarding(core, handleSysRekeyInit(core, false)))
-		mux.Handle("/v1/sys/rekey/update", handleRequestForwarding(core, handleSysRekeyUpdate(core, false)))
-		mux.Handle("/v1/sys/rekey/verify", handleRequestForwarding(core, handleSysRekeyVerify(core, false)))
+		mux.Handle("/v1/sys/rekey/update", replayProtected(handleRequestForwarding(core, handleSysRekeyUpdate(core, false))))
+		mux.Handle("/v1/sys/rekey/verify", replayProtected(handleRequestForwarding(core, handleSysRekeyVerify(core, false))))
 		mux.Handle("/v1/sys/rekey-recovery-key/init", handleRequestForwarding(core, handleSysRekeyInit(core, true)))
-		mux.Handle("/v1/sys/rekey-recovery-key/update", handleRequestForwarding(core, handleSysRekeyUpdate(core, true)))
-		mux.Handle("/v1/sys/rekey-recovery-key/verify", handleRequestForwarding(core, handleSysRekeyVerify(core, true)))
+		mux.Handle("/v1/sys/rekey-recovery-key/update", replayProtected(handleRequestForwarding(core, handleSysRekeyUpdate(core, true))))
+		mux.Handle("/v1/sys/rekey-recovery-key/verify", replayProtected(handleRequestForwarding(core, handleSysRekeyVerify(core, true))))
 		mux.Handle("/v1/sys/storage/raft/bootstrap", handleSysRaftBootstrap(core))
 		mux.Handle("/v1/sys/storage/raft/join", handleSysRaftJoin(core))
 		mux.Handle("/v1/sys/internal/ui/feature-flags", handleSysInternalFeatureFlags(core))
@@ -239,6 +270,14 @@ func handler(props *vault.HandlerProperties) http.Handler {
 		} else {
 			mux.Handle("/v1/sys/in-flight-req", handleLogicalNoForward(core, chrootNamespace))
 		}
+
+		if props.ListenerConfig != nil && props.ListenerConfig.UnauthenticatedDiscoveryAccess {
+			discoveryHandler := handleUnAuthenticatedSysDiscovery(core,
+				WithRedactClusterName(props.ListenerConfig.RedactClusterName),
+				WithRedactVersion(props.ListenerConfig.RedactVersion))
+			mux.Handle("/v1/sys/discovery", discoveryHandler)
+			mux.Handle(discoveryWellKnownPath, discoveryHandler)
+		}
 		entAdditionalRoutes(mux, core)
 	}
 
@@ -246,8 +285,9 @@ func handler(props *vault.HandlerProperties) http.Handler {
 	wrappedHandler := wrapHelpHandler(mux, core)
 	wrappedHandler = wrapCORSHandler(wrappedHandler, core)
 	wrappedHandler = rateLimitQuotaWrapping(wrappedHandler, core)
+	wrappedHandler = wrapLoadSheddingHandler(wrappedHandler, core)
+	wrappedHandler = wrapMaxRequestSizeHandler(wrappedHandler, core, props)
 	wrappedHandler = entWrapGenericHandler(core, wrappedHandler, props)
-	wrappedHandler = wrapMaxRequestSizeHandler(wrappedHandler, props)
 
 	// Add an extra wrapping handler if the DisablePrintableCheck listener
 	// setting isn't true that checks for non-printable characters in the
@@ -384,6 +424,11 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 		}
 
 		ctx = logical.CreateContextOriginalRequestPath(ctx, r.URL.Path)
+		// The cluster handler has no listener address, and keeps the one of
+		// the listener the forwarded request was received on
+		if props.ListenerConfig != nil && props.ListenerConfig.Address != "" {
+			ctx = logical.CreateContextListenerAddress(ctx, props.ListenerConfig.Address)
+		}
 		r = r.WithContext(ctx)
 		r = r.WithContext(namespace.ContextWithNamespace(r.Context(), namespace.RootNamespace))
 
@@ -412,6 +457,9 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 			}
 			r = newR
 
+		case r.URL.Path == discoveryWellKnownPath && props.ListenerConfig != nil && props.ListenerConfig.UnauthenticatedDiscoveryAccess:
+			// Served by the discovery handler rather than a mount redirect
+
 		case strings.HasPrefix(r.URL.Path, "/ui"), r.URL.Path == "/robots.txt", r.URL.Path == "/":
 			// RFC 5785
 		case strings.HasPrefix(r.URL.Path, "/.well-known/"):
@@ -446,6 +494,14 @@ func wrapGenericHandler(core *vault.Core, h http.Handler, props *vault.HandlerPr
 			return
 		}
 
+		// Reject new requests while the requests in flight are drained ahead
+		// of sealing
+		if core.Draining() && !slices.Contains(drainExemptPaths, r.URL.Path) {
+			respondError(nw, http.StatusServiceUnavailable, vault.ErrDraining)
+			cancelFunc()
+			return
+		}
+
 		// The uuid for the request is going to be generated when a logical
 		// request is generated. But, here we generate one to be able to track
 		// in-flight requests, and use that to update the req data with clientID
@@ -975,6 +1031,13 @@ func request(core *vault.Core, w http.ResponseWriter, rawReq *http.Request, r *l
 		}
 		resp.AddWarning("Timeout hit while waiting for local replicated cluster to apply primary's write; this client may encounter stale reads of values written during this operation.")
 	}
+	if state := r.ResponseState(); state != nil {
+		// Return the index state of the writes of the request, which clients
+		// can require on subsequent requests for read-after-write consistency
+		if header := core.IndexStateHeader(state); header != "" {
+			w.Header().Set(VaultIndexHeaderName, header)
+		}
+	}
 	if errwrap.Contains(err, consts.ErrStandby.Error()) {
 		respondStandby(core, w, rawReq.URL)
 		return resp, false, false
@@ -1161,6 +1224,28 @@ func requestWrapInfo(r *http.Request, req *logical.Request) (*logical.Request, e
 		req.WrapInfo.Format = "jwt"
 	}
 
+	if boundCIDRs := r.Header.Get(WrapBoundCIDRsHeaderName); boundCIDRs != "" {
+		cidrs := strutil.ParseDedupAndSortStrings(boundCIDRs, ",")
+		for _, cidr := range cidrs {
+			if _, err := sockaddr.NewSockAddr(cidr); err != nil {
+				return req, fmt.Errorf("invalid %s value %q: %w", WrapBoundCIDRsHeaderName, cidr, err)
+			}
+		}
+		req.WrapInfo.BoundCIDRs = cidrs
+	}
+
+	if notBefore := r.Header.Get(WrapNotBeforeHeaderName); notBefore != "" {
+		t, err := time.Parse(time.RFC3339, notBefore)
+		if err != nil {
+			delay, durErr := parseutil.ParseDurationSecond(notBefore)
+			if durErr != nil || delay < 0 {
+				return req, fmt.Errorf("invalid %s value %q: must be an RFC3339 time or a non-negative duration", WrapNotBeforeHeaderName, notBefore)
+			}
+			t = time.Now().Add(delay)
+		}
+		req.WrapInfo.NotBefore = t.UTC()
+	}
+
 	return req, nil
 }
 
@@ -1245,6 +1330,11 @@ func isForm(head []byte, contentType string) bool {
 }
 
 func respondError(w http.ResponseWriter, status int, err error) {
+	var limitErr *requestLimitError
+	if errors.As(err, &limitErr) {
+		respondErrorAndData(w, status, limitErr.data(), err)
+		return
+	}
 	logical.RespondError(w, status, err)
 }
 
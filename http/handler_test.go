@@ -18,6 +18,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/stretchr/testify/require"
@@ -26,6 +27,7 @@ import (
 	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/versions"
+	"github.com/hashicorp/vault/limits"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault"
@@ -973,3 +975,114 @@ func TestHandler_MaxRequestSize_Memory(t *testing.T) {
 	runtime.ReadMemStats(&end)
 	require.Less(t, end.TotalAlloc-start.TotalAlloc, uint64(1024*1024))
 }
+
+// TestHandler_RequestLimits verifies that request limits override the maximum
+// request size of the listener under their path prefix, limit the number of
+// request parameters, and are reported as structured 413 errors.
+func TestHandler_RequestLimits(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealedWithConfig(t, &vault.CoreConfig{
+		RequestLimits: []*vault.RequestLimit{
+			{PathPrefix: "secret/large", MaxRequestSize: 4096},
+			{PathPrefix: "secret/small", MaxRequestSize: 512, MaxRequestParameters: 2},
+		},
+	})
+	ln, addr := TestListener(t)
+	TestServerWithListenerAndProperties(t, ln, addr, core, &vault.HandlerProperties{
+		Core: core,
+		ListenerConfig: &configutil.Listener{
+			Address:        addr,
+			MaxRequestSize: 1024,
+		},
+	})
+	defer ln.Close()
+
+	write := func(path string, data map[string]interface{}) (int, map[string]interface{}) {
+		t.Helper()
+		body, err := json.Marshal(data)
+		require.NoError(t, err)
+		req, err := http.NewRequest("POST", addr+"/v1/"+path, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(consts.AuthHeaderName, token)
+		resp, err := cleanhttp.DefaultClient().Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var out map[string]interface{}
+		if resp.StatusCode != http.StatusNoContent {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+		}
+		return resp.StatusCode, out
+	}
+
+	payload := map[string]interface{}{"value": strings.Repeat("a", 2048)}
+
+	// The listener limit applies outside of the request limits
+	status, _ := write("secret/foo", payload)
+	require.Equal(t, http.StatusRequestEntityTooLarge, status)
+
+	status, _ = write("secret/large/foo", payload)
+	require.Equal(t, http.StatusNoContent, status)
+
+	status, out := write("secret/small/foo", map[string]interface{}{"value": strings.Repeat("a", 600)})
+	require.Equal(t, http.StatusRequestEntityTooLarge, status)
+	require.Equal(t, map[string]interface{}{
+		"limit":       "max_request_size",
+		"maximum":     float64(512),
+		"actual":      float64(len(`{"value":""}`) + 600),
+		"path_prefix": "secret/small",
+	}, out["data"])
+
+	status, out = write("secret/small/foo", map[string]interface{}{"a": 1, "b": 2, "c": 3})
+	require.Equal(t, http.StatusRequestEntityTooLarge, status)
+	require.Equal(t, "max_request_parameters", out["data"].(map[string]interface{})["limit"])
+	require.Equal(t, float64(3), out["data"].(map[string]interface{})["actual"])
+	require.Contains(t, out["errors"].([]interface{})[0], "exceeding the maximum of 2")
+
+	status, _ = write("secret/small/foo", map[string]interface{}{"a": 1, "b": 2})
+	require.Equal(t, http.StatusNoContent, status)
+}
+
+// TestHandler_LoadShedding verifies that data plane requests are shed with a
+// Retry-After header while Vault is overloaded, whereas sys/ requests are
+// still served.
+func TestHandler_LoadShedding(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealedWithConfig(t, &vault.CoreConfig{
+		LoadShedding: &limits.OverloadConfig{
+			MaxInFlight: 1,
+			RetryAfter:  3 * time.Second,
+		},
+	})
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	// Hold the only request slot
+	release, err := core.OverloadController().Admit(context.Background(), limits.RequestClassData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := cleanhttp.DefaultClient()
+	doRequest := func(path string) *http.Response {
+		req, err := http.NewRequest("GET", addr+path, nil)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		req.Header.Set(consts.AuthHeaderName, token)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return resp
+	}
+
+	resp := doRequest("/v1/secret/foo")
+	testResponseStatus(t, resp, http.StatusTooManyRequests)
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "3" {
+		t.Fatalf("expected Retry-After of 3, got %q", retryAfter)
+	}
+
+	testResponseStatus(t, doRequest("/v1/sys/mounts"), http.StatusOK)
+
+	release()
+	testResponseStatus(t, doRequest("/v1/secret/foo"), http.StatusNotFound)
+}
@@ -46,6 +46,11 @@ func (b *bufferedReader) Close() error {
 
 const MergePatchContentTypeHeader = "application/merge-patch+json"
 
+// SCIMContentTypeHeader is the content type of SCIM requests. The SCIM
+// endpoint of the identity store accepts PATCH requests of this content type,
+// or of plain JSON, as SCIM clients do not send merge patches.
+const SCIMContentTypeHeader = "application/scim+json"
+
 func buildLogicalRequestNoAuth(perfStandby bool, ra *vault.RouterAccess, w http.ResponseWriter, r *http.Request) (*logical.Request, io.ReadCloser, int, error) {
 	ns, err := namespace.FromContext(r.Context())
 	if err != nil {
@@ -116,6 +121,12 @@ func buildLogicalRequestNoAuth(perfStandby bool, ra *vault.RouterAccess, w http.
 			path == "sys/storage/raft/snapshot" || path == "sys/storage/raft/snapshot-force" {
 			passHTTPReq = true
 			origBody = r.Body
+		} else if path == "sys/storage/raft/snapshot-restore-path" {
+			// The snapshot is the body, so the secret to restore is
+			// passed in the query string
+			passHTTPReq = true
+			origBody = r.Body
+			data = parseQuery(r.URL.Query())
 		} else {
 			// Sample the first bytes to determine whether this should be parsed as
 			// a form or as JSON. The amount to look ahead (512 bytes) is arbitrary
@@ -162,7 +173,8 @@ func buildLogicalRequestNoAuth(perfStandby bool, ra *vault.RouterAccess, w http.
 			return nil, nil, status, err
 		}
 
-		if contentType != MergePatchContentTypeHeader {
+		isSCIM := (contentType == SCIMContentTypeHeader || contentType == "application/json") && strings.HasPrefix(path, "identity/scim/")
+		if contentType != MergePatchContentTypeHeader && !isSCIM {
 			return nil, nil, http.StatusUnsupportedMediaType, fmt.Errorf("PATCH requires Content-Type of %s, provided %s", MergePatchContentTypeHeader, contentType)
 		}
 
@@ -194,6 +206,10 @@ func buildLogicalRequestNoAuth(perfStandby bool, ra *vault.RouterAccess, w http.
 		return nil, nil, http.StatusMethodNotAllowed, nil
 	}
 
+	if err := checkRequestParameters(r.Context(), data); err != nil {
+		return nil, nil, http.StatusRequestEntityTooLarge, err
+	}
+
 	// RFC 5785 Redirect, keep the request for auditing purposes
 	if r.URL.Path != r.RequestURI {
 		passHTTPReq = true
@@ -278,7 +294,7 @@ func buildLogicalRequest(core *vault.Core, w http.ResponseWriter, r *http.Reques
 
 	req, err = requestWrapInfo(r, req)
 	if err != nil {
-		return nil, nil, http.StatusBadRequest, fmt.Errorf("error parsing X-Vault-Wrap-TTL header: %w", err)
+		return nil, nil, http.StatusBadRequest, fmt.Errorf("error parsing response wrapping headers: %w", err)
 	}
 
 	err = parseMFAHeader(req)
@@ -178,6 +178,7 @@ func TestLogical_StandbyRedirect(t *testing.T) {
 			"ttl":              json.Number("0"),
 			"creation_ttl":     json.Number("0"),
 			"explicit_max_ttl": json.Number("0"),
+			"request_count":    json.Number("1"),
 			"expire_time":      nil,
 			"entity_id":        "",
 			"type":             "service",
@@ -193,6 +194,7 @@ func TestLogical_StandbyRedirect(t *testing.T) {
 	actualDataMap := actual["data"].(map[string]interface{})
 	delete(actualDataMap, "creation_time")
 	delete(actualDataMap, "accessor")
+	delete(actualDataMap, "last_used_time")
 	actual["data"] = actualDataMap
 	expected["request_id"] = actual["request_id"]
 	delete(actual, "lease_id")
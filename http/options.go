@@ -3,6 +3,8 @@
 
 package http
 
+import "github.com/hashicorp/vault/internalshared/configutil"
+
 // ListenerConfigOption is how listenerConfigOptions are passed as arguments.
 type ListenerConfigOption func(*listenerConfigOptions) error
 
@@ -12,6 +14,7 @@ type listenerConfigOptions struct {
 	withRedactAddresses   bool
 	withRedactClusterName bool
 	withRedactVersion     bool
+	withHealthProfiles    []*configutil.ListenerHealthProfile
 }
 
 // getDefaultOptions returns listenerConfigOptions with their default values.
@@ -69,3 +72,12 @@ func WithRedactVersion(r bool) ListenerConfigOption {
 		return nil
 	}
 }
+
+// WithHealthProfiles provides an ListenerConfigOption to represent the health profiles which can be
+// selected on the health endpoint.
+func WithHealthProfiles(p []*configutil.ListenerHealthProfile) ListenerConfigOption {
+	return func(o *listenerConfigOptions) error {
+		o.withHealthProfiles = p
+		return nil
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"bytes"
+	"container/heap"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+)
+
+// maxReplayProtectionNonces bounds the number of nonces a replayProtector
+// tracks, as they are submitted by unauthenticated requests. Once reached, the
+// nonces closest to expiring are forgotten first.
+const maxReplayProtectionNonces = 100000
+
+// replayProtector rejects the replayed requests submitting key shares to a
+// listener with replay protection enabled. Requests must be made within the
+// window of their timestamp, carry a nonce which was not used within that
+// window and, if they submit a key share, be signed with it as done by
+// api.ReplayProtectionSignature.
+//
+// The signature is keyed with the key share sent in the same request, so it
+// does not authenticate the request: anyone may sign a request with a share
+// of their own, and anyone who can read a request may sign it again with a new
+// nonce and timestamp. Only byte-for-byte replays are blocked. The nonces are
+// only tracked by the listener, so a captured request may also be replayed
+// within its window against another node of the cluster.
+//
+// Since requests are not authenticated, they are never refused because of
+// the number of nonces tracked: beyond maxReplayProtectionNonces, the nonces
+// closest to expiring are forgotten instead.
+type replayProtector struct {
+	window    time.Duration
+	maxNonces int
+
+	l sync.Mutex
+	// nonces maps the nonces seen to the time after which their requests
+	// are rejected for their timestamp
+	nonces map[string]time.Time
+	// expiries orders the nonces seen by the time they expire
+	expiries nonceExpiries
+}
+
+func newReplayProtector(window time.Duration) *replayProtector {
+	return &replayProtector{
+		window:    window,
+		maxNonces: maxReplayProtectionNonces,
+		nonces:    make(map[string]time.Time),
+	}
+}
+
+// nonceExpiry is a nonce seen and the time it expires.
+type nonceExpiry struct {
+	nonce  string
+	expiry time.Time
+}
+
+// nonceExpiries is a min-heap of nonces by expiry, implementing
+// heap.Interface.
+type nonceExpiries []nonceExpiry
+
+func (n nonceExpiries) Len() int           { return len(n) }
+func (n nonceExpiries) Less(i, j int) bool { return n[i].expiry.Before(n[j].expiry) }
+func (n nonceExpiries) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
+
+func (n *nonceExpiries) Push(x interface{}) {
+	*n = append(*n, x.(nonceExpiry))
+}
+
+func (n *nonceExpiries) Pop() interface{} {
+	old := *n
+	last := old[len(old)-1]
+	*n = old[:len(old)-1]
+	return last
+}
+
+// wrap returns a handler which rejects the replayed requests before passing
+// them on to h.
+func (p *replayProtector) wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := p.check(r); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (p *replayProtector) check(r *http.Request) error {
+	rawTimestamp := r.Header.Get(api.ReplayProtectionTimestampHeader)
+	if rawTimestamp == "" {
+		return fmt.Errorf("missing %s header", api.ReplayProtectionTimestampHeader)
+	}
+	timestamp, err := strconv.ParseInt(rawTimestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s header", api.ReplayProtectionTimestampHeader)
+	}
+	nonce := r.Header.Get(api.ReplayProtectionNonceHeader)
+	if nonce == "" {
+		return fmt.Errorf("missing %s header", api.ReplayProtectionNonceHeader)
+	}
+
+	now := time.Now()
+	requestTime := time.Unix(timestamp, 0)
+	if requestTime.Before(now.Add(-p.window)) || requestTime.After(now.Add(p.window)) {
+		return errors.New("request timestamp is outside of the replay protection window")
+	}
+
+	// The body is read to verify the signature of the submitted key share,
+	// and restored for the wrapped handler
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req struct {
+		Key string `json:"key"`
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := jsonutil.DecodeJSON(body, &req); err != nil {
+			return fmt.Errorf("failed to parse JSON input: %w", err)
+		}
+	}
+	if req.Key != "" {
+		signature := r.Header.Get(api.ReplayProtectionSignatureHeader)
+		if signature == "" {
+			return fmt.Errorf("missing %s header", api.ReplayProtectionSignatureHeader)
+		}
+		expected := api.ReplayProtectionSignature(req.Key, rawTimestamp, nonce, r.URL.Path)
+		if subtle.ConstantTimeCompare([]byte(signature), []byte(expected)) != 1 {
+			return errors.New("invalid request signature")
+		}
+	}
+
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	for len(p.expiries) > 0 && now.After(p.expiries[0].expiry) {
+		delete(p.nonces, heap.Pop(&p.expiries).(nonceExpiry).nonce)
+	}
+	if _, ok := p.nonces[nonce]; ok {
+		return errors.New("request nonce has already been used")
+	}
+	for len(p.expiries) >= p.maxNonces {
+		delete(p.nonces, heap.Pop(&p.expiries).(nonceExpiry).nonce)
+	}
+	expiry := requestTime.Add(p.window)
+	p.nonces[nonce] = expiry
+	heap.Push(&p.expiries, nonceExpiry{nonce: nonce, expiry: expiry})
+
+	return nil
+}
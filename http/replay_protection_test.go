@@ -0,0 +1,171 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysUnseal_ReplayProtection(t *testing.T) {
+	core, keys, token := vault.TestCoreUnsealed(t)
+	if err := core.Seal(token); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ln, addr := TestListener(t)
+	props := &vault.HandlerProperties{
+		Core: core,
+		ListenerConfig: &configutil.Listener{
+			ReplayProtection:       true,
+			ReplayProtectionWindow: configutil.DefaultReplayProtectionWindow,
+		},
+	}
+	TestServerWithListenerAndProperties(t, ln, addr, core, props)
+	defer ln.Close()
+
+	key := hex.EncodeToString(keys[0])
+	unseal := func(timestamp int64, nonce, signature string) *http.Response {
+		t.Helper()
+
+		body, err := json.Marshal(map[string]interface{}{"key": key})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		req, err := http.NewRequest(http.MethodPut, addr+"/v1/sys/unseal", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if timestamp != 0 {
+			req.Header.Set(api.ReplayProtectionTimestampHeader, strconv.FormatInt(timestamp, 10))
+		}
+		if nonce != "" {
+			req.Header.Set(api.ReplayProtectionNonceHeader, nonce)
+		}
+		if signature != "" {
+			req.Header.Set(api.ReplayProtectionSignatureHeader, signature)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return resp
+	}
+	sign := func(timestamp int64, nonce string) string {
+		return api.ReplayProtectionSignature(key, strconv.FormatInt(timestamp, 10), nonce, "/v1/sys/unseal")
+	}
+
+	now := time.Now().Unix()
+	stale := now - 2*int64(configutil.DefaultReplayProtectionWindow/time.Second)
+
+	// Requests missing the headers, outside of the window or with a bad
+	// signature are rejected
+	testResponseStatus(t, unseal(0, "", ""), 400)
+	testResponseStatus(t, unseal(now, "", ""), 400)
+	testResponseStatus(t, unseal(now, "a", ""), 400)
+	testResponseStatus(t, unseal(now, "a", sign(now, "b")), 400)
+	testResponseStatus(t, unseal(stale, "a", sign(stale, "a")), 400)
+
+	// A signed request is accepted once
+	testResponseStatus(t, unseal(now, "a", sign(now, "a")), 200)
+	testResponseStatus(t, unseal(now, "a", sign(now, "a")), 400)
+
+	// The api client signs the requests when replay protection is enabled
+	config := api.DefaultConfig()
+	config.Address = addr
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := client.Sys().Unseal(hex.EncodeToString(keys[1])); err == nil {
+		t.Fatal("expected unsigned request to be rejected")
+	}
+
+	config.ReplayProtection = true
+	client, err = api.NewClient(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, key := range keys[1:] {
+		if _, err := client.Sys().Unseal(hex.EncodeToString(key)); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	if core.Sealed() {
+		t.Fatal("should not be sealed")
+	}
+}
+
+func TestReplayProtector_MaxNonces(t *testing.T) {
+	p := newReplayProtector(time.Minute)
+	p.maxNonces = 2
+
+	check := func(timestamp time.Time, nonce string) error {
+		req := httptest.NewRequest(http.MethodPut, "/v1/sys/unseal", nil)
+		req.Header.Set(api.ReplayProtectionTimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+		req.Header.Set(api.ReplayProtectionNonceHeader, nonce)
+		return p.check(req)
+	}
+
+	now := time.Now()
+	if err := check(now.Add(-30*time.Second), "a"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := check(now, "b"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Requests are not refused once the limit is reached, the nonce closest
+	// to expiring is forgotten instead
+	if err := check(now, "c"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(p.nonces) != 2 || len(p.expiries) != 2 {
+		t.Fatalf("bad: %v", p.nonces)
+	}
+	if _, ok := p.nonces["a"]; ok {
+		t.Fatalf("expected nonce a to be forgotten: %v", p.nonces)
+	}
+	if err := check(now, "b"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestReplayProtector_ExpireNonces(t *testing.T) {
+	p := newReplayProtector(time.Second)
+
+	check := func(timestamp time.Time, nonce string) error {
+		req := httptest.NewRequest(http.MethodPut, "/v1/sys/unseal", nil)
+		req.Header.Set(api.ReplayProtectionTimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+		req.Header.Set(api.ReplayProtectionNonceHeader, nonce)
+		return p.check(req)
+	}
+
+	if err := check(time.Now(), "a"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := check(time.Now(), "a"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Expired nonces are removed as new requests come in
+	time.Sleep(2500 * time.Millisecond)
+	if err := check(time.Now(), "b"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(p.nonces) != 1 || len(p.expiries) != 1 {
+		t.Fatalf("bad: %v", p.nonces)
+	}
+}
@@ -203,3 +203,72 @@ func TestSysConfigState_Sanitized(t *testing.T) {
 		})
 	}
 }
+
+func TestSysConfigState_Drift(t *testing.T) {
+	t.Parallel()
+
+	started := &server.Config{
+		SharedConfig: &configutil.SharedConfig{
+			Listeners: []*configutil.Listener{
+				{
+					Type:      "tcp",
+					Address:   "127.0.0.1",
+					RawConfig: map[string]interface{}{"address": "127.0.0.1", "tls_disable": true},
+				},
+			},
+		},
+	}
+	core, _, token := vault.TestCoreUnsealedWithConfig(t, &vault.CoreConfig{RawConfig: started})
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpGet(t, token, addr+"/v1/sys/config/state/drift")
+	testResponseStatus(t, resp, 200)
+
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	data := actual["data"].(map[string]interface{})
+	if changes := data["changes"].([]interface{}); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+	if data["restart_required"] != false {
+		t.Fatalf("expected no restart to be required, got %v", data["restart_required"])
+	}
+
+	configured := &server.Config{
+		SharedConfig: &configutil.SharedConfig{
+			Listeners: []*configutil.Listener{
+				{
+					Type:      "tcp",
+					Address:   "127.0.0.1",
+					RawConfig: map[string]interface{}{"address": "127.0.0.1", "tls_disable": false},
+				},
+			},
+		},
+	}
+	core.SetConfigDrift(server.NewConfigDriftReport(started, configured, nil))
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/config/state/drift")
+	testResponseStatus(t, resp, 200)
+
+	actual = nil
+	testResponseBody(t, resp, &actual)
+	data = actual["data"].(map[string]interface{})
+	expected := []interface{}{
+		map[string]interface{}{
+			"section":    "listener",
+			"name":       "tcp:127.0.0.1",
+			"field":      "tls_disable",
+			"previous":   true,
+			"configured": false,
+			"status":     server.ConfigChangeRequiresRestart,
+		},
+	}
+	if diff := deep.Equal(data["changes"], expected); len(diff) > 0 {
+		t.Fatalf("bad mismatch response body: diff: %v", diff)
+	}
+	if data["restart_required"] != true {
+		t.Fatalf("expected a restart to be required, got %v", data["restart_required"])
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+// discoveryWellKnownPath is the RFC 5785 path the discovery document is also
+// served at by listeners allowing unauthenticated access to it
+const discoveryWellKnownPath = "/.well-known/vault"
+
+func handleUnAuthenticatedSysDiscovery(core *vault.Core, opt ...ListenerConfigOption) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		opts, err := getOpts(opt...)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		doc, err := core.DiscoveryDocument(r.Context())
+		if err != nil {
+			core.Logger().Error("error building discovery document", "error", err)
+			respondError(w, http.StatusInternalServerError, nil)
+			return
+		}
+
+		if opts.withRedactVersion {
+			doc.Version = opts.withRedactionValue
+		}
+
+		if opts.withRedactClusterName {
+			doc.ClusterName = opts.withRedactionValue
+		}
+
+		respondOk(w, doc)
+	})
+}
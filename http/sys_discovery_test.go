@@ -0,0 +1,71 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/internalshared/configutil"
+	"github.com/hashicorp/vault/vault"
+)
+
+func TestSysDiscovery(t *testing.T) {
+	conf := &vault.CoreConfig{}
+	core, _, token := vault.TestCoreUnsealedWithConfig(t, conf)
+	ln, addr := TestServer(t, core)
+	TestServerAuth(t, addr, token)
+
+	// Default: Only authenticated access
+	resp := testHttpGet(t, "", addr+"/v1/sys/discovery")
+	testResponseStatus(t, resp, 403)
+	resp = testHttpGet(t, "", addr+discoveryWellKnownPath)
+	testResponseStatus(t, resp, 404)
+	resp = testHttpGet(t, token, addr+"/v1/sys/discovery")
+	testResponseStatus(t, resp, 200)
+
+	var actual map[string]interface{}
+	testResponseBody(t, resp, &actual)
+	if actual["cluster_name"] == "" || actual["version"] == "" {
+		t.Fatalf("expected cluster name and version, got: %#v", actual)
+	}
+	if apiVersions := actual["api_versions"].([]interface{}); len(apiVersions) != 1 || apiVersions[0] != "v1" {
+		t.Fatalf("bad api_versions: %#v", actual["api_versions"])
+	}
+	if features := actual["features"].(map[string]interface{}); features["events"] != true {
+		t.Fatalf("expected events to be supported, got: %#v", features)
+	}
+
+	// Close listener
+	ln.Close()
+
+	// Setup new custom listener with unauthenticated discovery access
+	ln, addr = TestListener(t)
+	props := &vault.HandlerProperties{
+		Core: core,
+		ListenerConfig: &configutil.Listener{
+			UnauthenticatedDiscoveryAccess: true,
+			RedactClusterName:              true,
+		},
+	}
+	TestServerWithListenerAndProperties(t, ln, addr, core, props)
+	defer ln.Close()
+
+	// Test without token, at both paths
+	for _, path := range []string{"/v1/sys/discovery", discoveryWellKnownPath} {
+		resp = testHttpGet(t, "", addr+path)
+		testResponseStatus(t, resp, 200)
+
+		actual = map[string]interface{}{}
+		testResponseBody(t, resp, &actual)
+		if _, ok := actual["cluster_name"]; ok {
+			t.Fatalf("expected the cluster name to be redacted, got: %#v", actual)
+		}
+		if actual["version"] == "" {
+			t.Fatalf("expected version, got: %#v", actual)
+		}
+	}
+
+	resp = testHttpPost(t, "", addr+"/v1/sys/discovery", nil)
+	testResponseStatus(t, resp, 405)
+}
@@ -82,11 +82,14 @@ func handleSysGenerateRootAttemptGet(core *vault.Core, w http.ResponseWriter, r
 		Complete:  false,
 		OTPLength: otpLength,
 		OTP:       otp,
+
+		JustificationRequired: core.GenerateRootJustificationRequired(),
 	}
 	if generationConfig != nil {
 		status.Nonce = generationConfig.Nonce
 		status.Started = true
 		status.PGPFingerprint = generationConfig.PGPFingerprint
+		status.Justification = generationConfig.Justification
 	}
 
 	respondOk(w, status)
@@ -119,7 +122,7 @@ func handleSysGenerateRootAttemptPut(core *vault.Core, w http.ResponseWriter, r
 	}
 
 	// Attemptialize the generation
-	if err := core.GenerateRootInit(req.OTP, req.PGPKey, generateStrategy); err != nil {
+	if err := core.GenerateRootInitWithJustification(req.OTP, req.PGPKey, req.Justification, generateStrategy); err != nil {
 		respondError(w, http.StatusBadRequest, err)
 		return
 	}
@@ -201,8 +204,9 @@ func handleSysGenerateRootUpdate(core *vault.Core, generateStrategy vault.Genera
 }
 
 type GenerateRootInitRequest struct {
-	OTP    string `json:"otp"`
-	PGPKey string `json:"pgp_key"`
+	OTP           string `json:"otp"`
+	PGPKey        string `json:"pgp_key"`
+	Justification string `json:"justification"`
 }
 
 type GenerateRootStatusResponse struct {
@@ -216,6 +220,9 @@ type GenerateRootStatusResponse struct {
 	PGPFingerprint   string `json:"pgp_fingerprint"`
 	OTP              string `json:"otp"`
 	OTPLength        int    `json:"otp_length"`
+
+	Justification         string `json:"justification,omitempty"`
+	JustificationRequired bool   `json:"justification_required,omitempty"`
 }
 
 type GenerateRootUpdateRequest struct {
@@ -367,6 +367,8 @@ func TestSysGenerateRoot_Update_OTP(t *testing.T) {
 
 	expected["creation_time"] = actual["data"].(map[string]interface{})["creation_time"]
 	expected["accessor"] = actual["data"].(map[string]interface{})["accessor"]
+	expected["last_used_time"] = actual["data"].(map[string]interface{})["last_used_time"]
+	expected["request_count"] = json.Number("1")
 
 	if !reflect.DeepEqual(actual["data"], expected) {
 		t.Fatalf("\nexpected: %#v\nactual: %#v", expected, actual["data"])
@@ -470,6 +472,8 @@ func TestSysGenerateRoot_Update_PGP(t *testing.T) {
 
 	expected["creation_time"] = actual["data"].(map[string]interface{})["creation_time"]
 	expected["accessor"] = actual["data"].(map[string]interface{})["accessor"]
+	expected["last_used_time"] = actual["data"].(map[string]interface{})["last_used_time"]
+	expected["request_count"] = json.Number("1")
 
 	if diff := deep.Equal(actual["data"], expected); diff != nil {
 		t.Fatal(diff)
@@ -13,6 +13,7 @@ import (
 
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/hashicorp/vault/helper/constants"
+	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/vault"
 	"github.com/hashicorp/vault/version"
@@ -24,7 +25,7 @@ func handleSysHealth(core *vault.Core, opt ...ListenerConfigOption) http.Handler
 		case "GET":
 			handleSysHealthGet(core, w, r, opt...)
 		case "HEAD":
-			handleSysHealthHead(core, w, r)
+			handleSysHealthHead(core, w, r, opt...)
 		default:
 			respondError(w, http.StatusMethodNotAllowed, nil)
 		}
@@ -45,7 +46,13 @@ func fetchStatusCode(r *http.Request, field string) (int, bool, bool) {
 }
 
 func handleSysHealthGet(core *vault.Core, w http.ResponseWriter, r *http.Request, opt ...ListenerConfigOption) {
-	code, body, err := getSysHealth(core, r)
+	opts, err := getOpts(opt...)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	code, body, err := getSysHealth(core, r, opts.withHealthProfiles)
 	if err != nil {
 		core.Logger().Error("error checking health", "error", err)
 		respondError(w, code, nil)
@@ -57,8 +64,6 @@ func handleSysHealthGet(core *vault.Core, w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	opts, err := getOpts(opt...)
-
 	if opts.withRedactVersion {
 		body.Version = opts.withRedactionValue
 	}
@@ -75,8 +80,14 @@ func handleSysHealthGet(core *vault.Core, w http.ResponseWriter, r *http.Request
 	enc.Encode(body)
 }
 
-func handleSysHealthHead(core *vault.Core, w http.ResponseWriter, r *http.Request) {
-	code, body, _ := getSysHealth(core, r)
+func handleSysHealthHead(core *vault.Core, w http.ResponseWriter, r *http.Request, opt ...ListenerConfigOption) {
+	opts, err := getOpts(opt...)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	code, body, _ := getSysHealth(core, r, opts.withHealthProfiles)
 
 	if body != nil {
 		w.Header().Set("Content-Type", "application/json")
@@ -84,23 +95,44 @@ func handleSysHealthHead(core *vault.Core, w http.ResponseWriter, r *http.Reques
 	w.WriteHeader(code)
 }
 
-func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, error) {
+func getSysHealth(core *vault.Core, r *http.Request, profiles []*configutil.ListenerHealthProfile) (int, *HealthResponse, error) {
 	var err error
 
+	// The health profile of the listener provides the defaults of the
+	// parameters, and additional readiness checks
+	var profile *configutil.ListenerHealthProfile
+	if name := r.URL.Query().Get("profile"); name != "" {
+		for _, p := range profiles {
+			if p.Name == name {
+				profile = p
+				break
+			}
+		}
+		if profile == nil {
+			return http.StatusBadRequest, nil, fmt.Errorf("unknown health profile %q", name)
+		}
+	}
+
 	// Check if being a standby is allowed for the purpose of a 200 OK
 	standbyOKStr, standbyOK := r.URL.Query()["standbyok"]
-	if standbyOK {
+	switch {
+	case standbyOK:
 		standbyOK, err = parseutil.ParseBool(standbyOKStr[0])
 		if err != nil {
 			return http.StatusBadRequest, nil, fmt.Errorf("bad value for standbyok parameter: %w", err)
 		}
+	case profile != nil:
+		standbyOK = profile.StandbyOK
 	}
 	perfStandbyOKStr, perfStandbyOK := r.URL.Query()["perfstandbyok"]
-	if perfStandbyOK {
+	switch {
+	case perfStandbyOK:
 		perfStandbyOK, err = parseutil.ParseBool(perfStandbyOKStr[0])
 		if err != nil {
 			return http.StatusBadRequest, nil, fmt.Errorf("bad value for perfstandbyok parameter: %w", err)
 		}
+	case profile != nil:
+		perfStandbyOK = profile.PerfStandbyOK
 	}
 
 	uninitCode := http.StatusNotImplemented
@@ -145,6 +177,16 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		perfStandbyCode = code
 	}
 
+	unreadyCode := http.StatusServiceUnavailable
+	if profile != nil {
+		unreadyCode = profile.UnreadyCode
+	}
+	if code, found, ok := fetchStatusCode(r, "unreadycode"); !ok {
+		return http.StatusBadRequest, nil, nil
+	} else if found {
+		unreadyCode = code
+	}
+
 	ctx := context.Background()
 
 	// Check system status
@@ -210,6 +252,7 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 		ClusterID:                  clusterID,
 		ClockSkewMillis:            core.ActiveNodeClockSkewMillis(),
 		EchoDurationMillis:         core.EchoDuration().Milliseconds(),
+		CryptoPolicy:               core.CryptoPolicy(),
 	}
 
 	licenseState, err := core.EntGetLicenseState()
@@ -229,31 +272,116 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 
 	if init && !sealed && !standby {
 		body.LastWAL = core.EntLastWAL()
+		body.SelfCheck = selfCheckHealth(core.SelfCheckResult())
+	}
+
+	if profile != nil {
+		body.Profile = checkHealthProfile(core, profile, init && !sealed && !standby)
+		if !body.Profile.Ready && code == activeCode {
+			code = unreadyCode
+		}
 	}
 
 	return code, body, nil
 }
 
+func selfCheckHealth(result *vault.SelfCheckResult) *HealthResponseSelfCheck {
+	if result == nil {
+		return nil
+	}
+
+	resp := &HealthResponseSelfCheck{
+		Status:    result.Status,
+		StartTime: result.StartTime.Format(time.RFC3339),
+	}
+	if !result.EndTime.IsZero() {
+		resp.EndTime = result.EndTime.Format(time.RFC3339)
+	}
+	for _, finding := range result.Findings {
+		resp.Findings = append(resp.Findings, &HealthResponseSelfCheckFinding{
+			Check:   finding.Check,
+			Message: finding.Message,
+		})
+	}
+	return resp
+}
+
+// checkHealthProfile runs the readiness checks of the health profile. Mounts
+// and rotations are only checked on the active node, which runs them.
+func checkHealthProfile(core *vault.Core, profile *configutil.ListenerHealthProfile, active bool) *HealthResponseProfile {
+	resp := &HealthResponseProfile{
+		Name:  profile.Name,
+		Ready: true,
+	}
+	if !active {
+		return resp
+	}
+
+	if profile.RequireMountsReady {
+		unavailable := core.UnavailableMounts()
+		resp.UnavailableMounts = &unavailable
+		if unavailable > 0 {
+			resp.Ready = false
+		}
+	}
+
+	if profile.MaxRotationBacklog >= 0 {
+		backlog := core.RotationBacklog()
+		resp.RotationBacklog = &backlog
+		if backlog > profile.MaxRotationBacklog {
+			resp.Ready = false
+		}
+	}
+
+	return resp
+}
+
 type HealthResponseLicense struct {
 	State      string `json:"state"`
 	ExpiryTime string `json:"expiry_time"`
 	Terminated bool   `json:"terminated"`
 }
 
+// HealthResponseProfile is the result of the readiness checks of the health
+// profile selected by the request.
+type HealthResponseProfile struct {
+	Name              string `json:"name"`
+	Ready             bool   `json:"ready"`
+	UnavailableMounts *int   `json:"unavailable_mounts,omitempty"`
+	RotationBacklog   *int64 `json:"rotation_backlog,omitempty"`
+}
+
+// HealthResponseSelfCheck is the result of the integrity checks run once the
+// node became active.
+type HealthResponseSelfCheck struct {
+	Status    string                            `json:"status"`
+	StartTime string                            `json:"start_time"`
+	EndTime   string                            `json:"end_time,omitempty"`
+	Findings  []*HealthResponseSelfCheckFinding `json:"findings,omitempty"`
+}
+
+type HealthResponseSelfCheckFinding struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
 type HealthResponse struct {
-	Initialized                bool                   `json:"initialized"`
-	Sealed                     bool                   `json:"sealed"`
-	Standby                    bool                   `json:"standby"`
-	PerformanceStandby         bool                   `json:"performance_standby"`
-	ReplicationPerformanceMode string                 `json:"replication_performance_mode"`
-	ReplicationDRMode          string                 `json:"replication_dr_mode"`
-	ServerTimeUTC              int64                  `json:"server_time_utc"`
-	Version                    string                 `json:"version"`
-	Enterprise                 bool                   `json:"enterprise"`
-	ClusterName                string                 `json:"cluster_name,omitempty"`
-	ClusterID                  string                 `json:"cluster_id,omitempty"`
-	LastWAL                    uint64                 `json:"last_wal,omitempty"`
-	License                    *HealthResponseLicense `json:"license,omitempty"`
-	EchoDurationMillis         int64                  `json:"echo_duration_ms"`
-	ClockSkewMillis            int64                  `json:"clock_skew_ms"`
+	Initialized                bool                     `json:"initialized"`
+	Sealed                     bool                     `json:"sealed"`
+	Standby                    bool                     `json:"standby"`
+	PerformanceStandby         bool                     `json:"performance_standby"`
+	ReplicationPerformanceMode string                   `json:"replication_performance_mode"`
+	ReplicationDRMode          string                   `json:"replication_dr_mode"`
+	ServerTimeUTC              int64                    `json:"server_time_utc"`
+	Version                    string                   `json:"version"`
+	Enterprise                 bool                     `json:"enterprise"`
+	ClusterName                string                   `json:"cluster_name,omitempty"`
+	ClusterID                  string                   `json:"cluster_id,omitempty"`
+	LastWAL                    uint64                   `json:"last_wal,omitempty"`
+	License                    *HealthResponseLicense   `json:"license,omitempty"`
+	EchoDurationMillis         int64                    `json:"echo_duration_ms"`
+	ClockSkewMillis            int64                    `json:"clock_skew_ms"`
+	CryptoPolicy               string                   `json:"crypto_policy,omitempty"`
+	Profile                    *HealthResponseProfile   `json:"profile,omitempty"`
+	SelfCheck                  *HealthResponseSelfCheck `json:"self_check,omitempty"`
 }
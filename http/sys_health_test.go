@@ -4,6 +4,7 @@
 package http
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -13,7 +14,9 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/helper/constants"
+	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/cryptopolicy"
 	"github.com/hashicorp/vault/vault"
 )
 
@@ -215,3 +218,69 @@ func TestSysHealth_head(t *testing.T) {
 		}
 	}
 }
+
+func TestSysHealth_cryptoPolicy(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealedWithConfig(t, &vault.CoreConfig{
+		CryptoPolicy: cryptopolicy.FIPS1403,
+	})
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+
+	config := api.DefaultConfig()
+	config.Address = addr
+	client, err := api.NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Sys().Health()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if resp.CryptoPolicy != cryptopolicy.FIPS1403 {
+		t.Fatalf("expected crypto policy %q, got %q", cryptopolicy.FIPS1403, resp.CryptoPolicy)
+	}
+}
+
+func TestSysHealth_profile(t *testing.T) {
+	core, _, _ := vault.TestCoreUnsealed(t)
+	ln, addr := TestListener(t)
+	defer ln.Close()
+	TestServerWithListenerAndProperties(t, ln, addr, core, &vault.HandlerProperties{
+		Core: core,
+		ListenerConfig: &configutil.Listener{
+			HealthProfiles: []*configutil.ListenerHealthProfile{
+				{
+					Name:               "lb",
+					RequireMountsReady: true,
+					MaxRotationBacklog: 0,
+					UnreadyCode:        429,
+				},
+			},
+		},
+	})
+
+	raw, err := http.Get(addr + "/v1/sys/health?profile=missing")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testResponseStatus(t, raw, 400)
+
+	raw, err = http.Get(addr + "/v1/sys/health?profile=lb")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testResponseStatus(t, raw, 200)
+
+	var actual map[string]interface{}
+	testResponseBody(t, raw, &actual)
+	expected := map[string]interface{}{
+		"name":               "lb",
+		"ready":              true,
+		"unavailable_mounts": json.Number("0"),
+		"rotation_backlog":   json.Number("0"),
+	}
+	if diff := cmp.Diff(expected, actual["profile"]); len(diff) > 0 {
+		t.Fatal(diff)
+	}
+}
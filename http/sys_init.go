@@ -57,16 +57,18 @@ func handleSysInitPut(core *vault.Core, w http.ResponseWriter, r *http.Request)
 
 	// Initialize
 	barrierConfig := &vault.SealConfig{
-		SecretShares:    req.SecretShares,
-		SecretThreshold: req.SecretThreshold,
-		StoredShares:    req.StoredShares,
-		PGPKeys:         req.PGPKeys,
+		SecretShares:     req.SecretShares,
+		SecretThreshold:  req.SecretThreshold,
+		StoredShares:     req.StoredShares,
+		PGPKeys:          req.PGPKeys,
+		X509Certificates: req.X509Certificates,
 	}
 
 	recoveryConfig := &vault.SealConfig{
-		SecretShares:    req.RecoveryShares,
-		SecretThreshold: req.RecoveryThreshold,
-		PGPKeys:         req.RecoveryPGPKeys,
+		SecretShares:     req.RecoveryShares,
+		SecretThreshold:  req.RecoveryThreshold,
+		PGPKeys:          req.RecoveryPGPKeys,
+		X509Certificates: req.RecoveryX509Certificates,
 	}
 
 	initParams := &vault.InitParams{
@@ -98,6 +100,7 @@ func handleSysInitPut(core *vault.Core, w http.ResponseWriter, r *http.Request)
 		Keys:      keys,
 		KeysB64:   keysB64,
 		RootToken: result.RootToken,
+		Warnings:  result.Warnings,
 	}
 
 	if len(result.RecoveryShares) > 0 {
@@ -118,14 +121,16 @@ func handleSysInitPut(core *vault.Core, w http.ResponseWriter, r *http.Request)
 }
 
 type InitRequest struct {
-	SecretShares      int      `json:"secret_shares"`
-	SecretThreshold   int      `json:"secret_threshold"`
-	StoredShares      int      `json:"stored_shares"`
-	PGPKeys           []string `json:"pgp_keys"`
-	RecoveryShares    int      `json:"recovery_shares"`
-	RecoveryThreshold int      `json:"recovery_threshold"`
-	RecoveryPGPKeys   []string `json:"recovery_pgp_keys"`
-	RootTokenPGPKey   string   `json:"root_token_pgp_key"`
+	SecretShares             int      `json:"secret_shares"`
+	SecretThreshold          int      `json:"secret_threshold"`
+	StoredShares             int      `json:"stored_shares"`
+	PGPKeys                  []string `json:"pgp_keys"`
+	X509Certificates         []string `json:"x509_certificates"`
+	RecoveryShares           int      `json:"recovery_shares"`
+	RecoveryThreshold        int      `json:"recovery_threshold"`
+	RecoveryPGPKeys          []string `json:"recovery_pgp_keys"`
+	RecoveryX509Certificates []string `json:"recovery_x509_certificates"`
+	RootTokenPGPKey          string   `json:"root_token_pgp_key"`
 }
 
 type InitResponse struct {
@@ -134,6 +139,7 @@ type InitResponse struct {
 	RecoveryKeys    []string `json:"recovery_keys,omitempty"`
 	RecoveryKeysB64 []string `json:"recovery_keys_base64,omitempty"`
 	RootToken       string   `json:"root_token"`
+	Warnings        []string `json:"warnings,omitempty"`
 }
 
 type InitStatusResponse struct {
@@ -154,6 +160,9 @@ func validateInitParameters(core *vault.Core, req InitRequest) error {
 	if len(req.PGPKeys) != 0 {
 		barrierFlags = append(barrierFlags, "pgp_keys")
 	}
+	if len(req.X509Certificates) != 0 {
+		barrierFlags = append(barrierFlags, "x509_certificates")
+	}
 	if req.RecoveryShares != 0 {
 		recoveryFlags = append(recoveryFlags, "recovery_shares")
 	}
@@ -163,6 +172,9 @@ func validateInitParameters(core *vault.Core, req InitRequest) error {
 	if len(req.RecoveryPGPKeys) != 0 {
 		recoveryFlags = append(recoveryFlags, "recovery_pgp_keys")
 	}
+	if len(req.RecoveryX509Certificates) != 0 {
+		recoveryFlags = append(recoveryFlags, "recovery_x509_certificates")
+	}
 
 	switch core.SealAccess().RecoveryKeySupported() {
 	case true:
@@ -12,6 +12,7 @@ import (
 	"net/http"
 
 	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/helper/x509keys"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/vault"
 )
@@ -95,6 +96,7 @@ func handleSysRekeyInitGet(ctx context.Context, core *vault.Core, recovery bool,
 		status.Progress = progress
 		status.VerificationRequired = rekeyConf.VerificationRequired
 		status.VerificationNonce = rekeyConf.VerificationNonce
+		status.DelegatedUnseal = rekeyConf.DelegatedUnseal
 		if rekeyConf.PGPKeys != nil && len(rekeyConf.PGPKeys) != 0 {
 			pgpFingerprints, err := pgpkeys.GetFingerprints(rekeyConf.PGPKeys, nil)
 			if err != nil {
@@ -104,6 +106,14 @@ func handleSysRekeyInitGet(ctx context.Context, core *vault.Core, recovery bool,
 			status.PGPFingerprints = pgpFingerprints
 			status.Backup = rekeyConf.Backup
 		}
+		if len(rekeyConf.X509Certificates) != 0 {
+			x509Fingerprints, err := x509keys.GetFingerprints(rekeyConf.X509Certificates)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			}
+			status.X509Fingerprints = x509Fingerprints
+		}
 	}
 	respondOk(w, status)
 }
@@ -126,14 +136,21 @@ func handleSysRekeyInitPut(ctx context.Context, core *vault.Core, recovery bool,
 		return
 	}
 
+	if len(req.X509Certificates) > 0 && len(req.X509Certificates) != req.SecretShares {
+		respondError(w, http.StatusBadRequest, fmt.Errorf("incorrect number of X.509 certificates for rekey"))
+		return
+	}
+
 	// Initialize the rekey
 	err := core.RekeyInit(&vault.SealConfig{
 		SecretShares:         req.SecretShares,
 		SecretThreshold:      req.SecretThreshold,
 		StoredShares:         req.StoredShares,
 		PGPKeys:              req.PGPKeys,
+		X509Certificates:     req.X509Certificates,
 		Backup:               req.Backup,
 		VerificationRequired: req.RequireVerification,
+		DelegatedUnseal:      req.DelegatedUnseal,
 	}, recovery)
 	if err != nil {
 		respondError(w, err.Code(), err)
@@ -205,8 +222,10 @@ func handleSysRekeyUpdate(core *vault.Core, recovery bool) http.Handler {
 			resp.Nonce = req.Nonce
 			resp.Backup = result.Backup
 			resp.PGPFingerprints = result.PGPFingerprints
+			resp.X509Fingerprints = result.X509Fingerprints
 			resp.VerificationRequired = result.VerificationRequired
 			resp.VerificationNonce = result.VerificationNonce
+			resp.Warnings = result.Warnings
 
 			// Encode the keys
 			keys := make([]string, 0, len(result.SecretShares))
@@ -362,8 +381,10 @@ type RekeyRequest struct {
 	SecretThreshold     int      `json:"secret_threshold"`
 	StoredShares        int      `json:"stored_shares"`
 	PGPKeys             []string `json:"pgp_keys"`
+	X509Certificates    []string `json:"x509_certificates"`
 	Backup              bool     `json:"backup"`
 	RequireVerification bool     `json:"require_verification"`
+	DelegatedUnseal     bool     `json:"delegated_unseal"`
 }
 
 type RekeyStatusResponse struct {
@@ -374,9 +395,11 @@ type RekeyStatusResponse struct {
 	Progress             int      `json:"progress"`
 	Required             int      `json:"required"`
 	PGPFingerprints      []string `json:"pgp_fingerprints"`
+	X509Fingerprints     []string `json:"x509_fingerprints,omitempty"`
 	Backup               bool     `json:"backup"`
 	VerificationRequired bool     `json:"verification_required"`
 	VerificationNonce    string   `json:"verification_nonce,omitempty"`
+	DelegatedUnseal      bool     `json:"delegated_unseal,omitempty"`
 }
 
 type RekeyUpdateRequest struct {
@@ -390,9 +413,11 @@ type RekeyUpdateResponse struct {
 	Keys                 []string `json:"keys"`
 	KeysB64              []string `json:"keys_base64"`
 	PGPFingerprints      []string `json:"pgp_fingerprints"`
+	X509Fingerprints     []string `json:"x509_fingerprints,omitempty"`
 	Backup               bool     `json:"backup"`
 	VerificationRequired bool     `json:"verification_required"`
 	VerificationNonce    string   `json:"verification_nonce,omitempty"`
+	Warnings             []string `json:"warnings,omitempty"`
 }
 
 type RekeyVerificationUpdateRequest struct {
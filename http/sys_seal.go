@@ -8,9 +8,12 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault"
@@ -31,9 +34,20 @@ func handleSysSeal(core *vault.Core) http.Handler {
 			return
 		}
 
+		gracePeriod, err := parseSealDrain(req.Data)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
 		// Seal with the token above
 		// We use context.Background since there won't be a request context if the node isn't active
-		if err := core.SealWithRequest(r.Context(), req); err != nil {
+		if gracePeriod > 0 {
+			err = core.DrainAndSealWithRequest(r.Context(), req, gracePeriod)
+		} else {
+			err = core.SealWithRequest(r.Context(), req)
+		}
+		if err != nil {
 			if errwrap.Contains(err, logical.ErrPermissionDenied.Error()) {
 				respondError(w, http.StatusForbidden, err)
 				return
@@ -46,6 +60,31 @@ func handleSysSeal(core *vault.Core) http.Handler {
 	})
 }
 
+// parseSealDrain returns the grace period for the requests in flight to be
+// drained before sealing, if draining was requested, or zero otherwise.
+func parseSealDrain(data map[string]interface{}) (time.Duration, error) {
+	drain, err := parseutil.ParseBool(data["drain"])
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for drain: %w", err)
+	}
+	if !drain {
+		return 0, nil
+	}
+
+	gracePeriod := vault.DefaultSealDrainGracePeriod
+	if raw, ok := data["grace_period"]; ok {
+		gracePeriod, err = parseutil.ParseDurationSecond(raw)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value for grace_period: %w", err)
+		}
+		if gracePeriod <= 0 {
+			return 0, errors.New("grace_period must be positive")
+		}
+	}
+
+	return gracePeriod, nil
+}
+
 func handleSysStepDown(core *vault.Core) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		req, _, statusCode, err := buildLogicalRequest(core, w, r, "")
@@ -8,14 +8,19 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-test/deep"
+	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/testhelpers/corehelpers"
+	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault"
 	"github.com/hashicorp/vault/vault/seal"
@@ -93,6 +98,56 @@ func TestSysSeal(t *testing.T) {
 	}
 }
 
+// TestSysSeal_drain verifies that while draining the requests in flight ahead
+// of sealing new requests are rejected, except for those used to monitor the
+// node.
+func TestSysSeal_drain(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	core.StoreInFlightReqData("in-flight", vault.InFlightReqData{ReqPath: "/v1/secret/foo"})
+
+	statusCh := make(chan int)
+	go func() {
+		req, err := http.NewRequest(http.MethodPut, addr+"/v1/sys/seal", strings.NewReader(`{"drain": true, "grace_period": "1m"}`))
+		if err != nil {
+			statusCh <- 0
+			return
+		}
+		req.Header.Set(consts.AuthHeaderName, token)
+		resp, err := cleanhttp.DefaultClient().Do(req)
+		if err != nil {
+			statusCh <- 0
+			return
+		}
+		resp.Body.Close()
+		statusCh <- resp.StatusCode
+	}()
+
+	corehelpers.RetryUntil(t, 5*time.Second, func() error {
+		if !core.Draining() {
+			return errors.New("not draining")
+		}
+		return nil
+	})
+
+	resp := testHttpGet(t, token, addr+"/v1/secret/foo")
+	testResponseStatus(t, resp, http.StatusServiceUnavailable)
+
+	resp = testHttpGet(t, "", addr+"/v1/sys/seal-status")
+	testResponseStatus(t, resp, http.StatusOK)
+
+	core.FinalizeInFlightReqData("in-flight", http.StatusOK)
+	if status := <-statusCh; status != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, status)
+	}
+	if !core.Sealed() {
+		t.Fatal("should be sealed")
+	}
+}
+
 func TestSysSeal_unsealed(t *testing.T) {
 	core, _, token := vault.TestCoreUnsealed(t)
 	ln, addr := TestServer(t, core)
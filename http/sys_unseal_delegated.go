@@ -0,0 +1,106 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package http
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/hashicorp/vault/vault"
+)
+
+func handleSysDelegatedUnseal(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			handleSysDelegatedUnsealGet(core, w, r)
+		case "PUT", "POST":
+			handleSysDelegatedUnsealPut(core, w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+		}
+	})
+}
+
+func handleSysDelegatedUnsealGet(core *vault.Core, w http.ResponseWriter, r *http.Request) {
+	if !core.Sealed() {
+		respondOk(w, &DelegatedUnsealResponse{Sealed: false})
+		return
+	}
+
+	status, err := core.DelegatedUnsealChallenge(r.Context())
+	if err != nil {
+		respondError(w, err.Code(), err)
+		return
+	}
+	respondOk(w, delegatedUnsealResponse(status))
+}
+
+func handleSysDelegatedUnsealPut(core *vault.Core, w http.ResponseWriter, r *http.Request) {
+	var req DelegatedUnsealRequest
+	if _, err := parseJSONRequest(core.PerfStandby(), r, w, &req); err != nil {
+		respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Reset {
+		if !core.Sealed() {
+			respondError(w, http.StatusBadRequest, errors.New("vault is unsealed"))
+			return
+		}
+		core.ResetUnsealProcess()
+		handleSysDelegatedUnsealGet(core, w, r)
+		return
+	}
+
+	if req.Signature == "" {
+		respondError(
+			w, http.StatusBadRequest,
+			errors.New("'signature' must be specified in request body as JSON, or 'reset' set to true"))
+		return
+	}
+	signature, err := base64.StdEncoding.DecodeString(req.Signature)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, errors.New("'signature' must be a valid base64 string"))
+		return
+	}
+
+	unsealed, codedErr := core.DelegatedUnseal(r.Context(), req.Nonce, signature)
+	if codedErr != nil {
+		respondError(w, codedErr.Code(), codedErr)
+		return
+	}
+	if unsealed {
+		respondOk(w, &DelegatedUnsealResponse{Sealed: false})
+		return
+	}
+	handleSysDelegatedUnsealGet(core, w, r)
+}
+
+func delegatedUnsealResponse(status *vault.DelegatedUnsealStatus) *DelegatedUnsealResponse {
+	return &DelegatedUnsealResponse{
+		Sealed:    true,
+		T:         status.Threshold,
+		N:         status.Shares,
+		Progress:  status.Progress,
+		Nonce:     status.Nonce,
+		Challenge: base64.StdEncoding.EncodeToString(status.Challenge),
+	}
+}
+
+type DelegatedUnsealRequest struct {
+	Nonce     string `json:"nonce"`
+	Signature string `json:"signature"`
+	Reset     bool   `json:"reset"`
+}
+
+type DelegatedUnsealResponse struct {
+	Sealed    bool   `json:"sealed"`
+	T         int    `json:"t,omitempty"`
+	N         int    `json:"n,omitempty"`
+	Progress  int    `json:"progress"`
+	Nonce     string `json:"nonce,omitempty"`
+	Challenge string `json:"challenge,omitempty"`
+}
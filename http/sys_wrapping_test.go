@@ -387,3 +387,108 @@ func TestHTTP_Wrapping(t *testing.T) {
 		t.Fatalf("expected 403 response, actual: %d", respError.StatusCode)
 	}
 }
+
+// TestHTTP_WrappingConstraints verifies that the bound CIDRs and not-before
+// time of a wrapping token are enforced when unwrapping it, without consuming
+// the token.
+func TestHTTP_WrappingConstraints(t *testing.T) {
+	cluster := vault.NewTestCluster(t, &vault.CoreConfig{}, &vault.TestClusterOptions{
+		HandlerFunc: Handler,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	client.SetToken(cluster.RootToken)
+
+	_, err := client.Logical().Write("secret/foo", map[string]interface{}{
+		"zip": "zap",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wrap := func(headers map[string]string) (*api.Secret, error) {
+		wrapClient, err := client.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		wrapClient.SetToken(cluster.RootToken)
+		wrapClient.SetWrappingLookupFunc(func(operation, path string) string {
+			return "5m"
+		})
+		for name, value := range headers {
+			wrapClient.AddHeader(name, value)
+		}
+		return wrapClient.Logical().Read("secret/foo")
+	}
+
+	// Invalid constraints are rejected
+	if _, err := wrap(map[string]string{WrapBoundCIDRsHeaderName: "not-a-cidr"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := wrap(map[string]string{WrapNotBeforeHeaderName: "1h"}); err == nil {
+		t.Fatal("expected error for not-before after the token expires")
+	}
+
+	readResponse := func(token string) (*api.Secret, error) {
+		readClient, err := client.Clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		readClient.SetToken(token)
+		return readClient.Logical().Read("cubbyhole/response")
+	}
+
+	// A token bound to other CIDRs cannot be unwrapped, rewrapped or read
+	// from directly, but can be looked up
+	secret, err := wrap(map[string]string{WrapBoundCIDRsHeaderName: "10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := secret.WrapInfo.Token
+	if _, err := client.Logical().Unwrap(token); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := client.Logical().Write("sys/wrapping/rewrap", map[string]interface{}{"token": token}); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := readResponse(token); err == nil {
+		t.Fatal("expected error")
+	}
+	secret, err = client.Logical().Write("sys/wrapping/lookup", map[string]interface{}{"token": token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secret.Data["bound_cidrs"], []interface{}{"10.0.0.0/8"}) {
+		t.Fatalf("bad: %#v", secret.Data)
+	}
+
+	// A token cannot be unwrapped or read from directly before its not-before
+	// time
+	notBefore := time.Now().Add(2 * time.Second)
+	secret, err = wrap(map[string]string{
+		WrapBoundCIDRsHeaderName: "127.0.0.1/32",
+		WrapNotBeforeHeaderName:  notBefore.Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token = secret.WrapInfo.Token
+	if _, err := client.Logical().Unwrap(token); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := readResponse(token); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// The rejected attempts did not consume the token
+	time.Sleep(time.Until(notBefore))
+	secret, err = client.Logical().Unwrap(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.Data["zip"] != "zap" {
+		t.Fatalf("bad: %#v", secret.Data)
+	}
+}
@@ -8,21 +8,57 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/vault/sdk/logical"
 
 	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/limits"
 	"github.com/hashicorp/vault/vault"
 	"github.com/hashicorp/vault/vault/quotas"
 )
 
 var nonVotersAllowed = false
 
-func wrapMaxRequestSizeHandler(handler http.Handler, props *vault.HandlerProperties) http.Handler {
+// requestLimitContextKey is the context key of the request limit matching the
+// path of a request.
+type requestLimitContextKey struct{}
+
+// requestLimitError is returned when a request exceeds a request limit. It is
+// rendered as a structured 413 response by respondError.
+type requestLimitError struct {
+	limit      string
+	maximum    int64
+	actual     int64
+	pathPrefix string
+}
+
+func (e *requestLimitError) Error() string {
+	if e.limit == "max_request_parameters" {
+		return fmt.Sprintf("request has %d parameters, exceeding the maximum of %d", e.actual, e.maximum)
+	}
+	return fmt.Sprintf("request size of %d bytes exceeds the maximum of %d bytes", e.actual, e.maximum)
+}
+
+func (e *requestLimitError) data() map[string]interface{} {
+	return map[string]interface{}{
+		"limit":       e.limit,
+		"maximum":     e.maximum,
+		"actual":      e.actual,
+		"path_prefix": e.pathPrefix,
+	}
+}
+
+// wrapMaxRequestSizeHandler limits the size of request bodies to the maximum
+// request size of the listener, or of the request limit matching the request
+// path. It must wrap handlers after the namespace of the request is resolved.
+func wrapMaxRequestSizeHandler(handler http.Handler, core *vault.Core, props *vault.HandlerProperties) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var maxRequestSize int64
 		if props.ListenerConfig != nil {
@@ -32,9 +68,42 @@ func wrapMaxRequestSizeHandler(handler http.Handler, props *vault.HandlerPropert
 			maxRequestSize = DefaultMaxRequestSize
 		}
 		ctx := r.Context()
+
+		var pathPrefix string
+		if strings.HasPrefix(r.URL.Path, "/v1/") {
+			if ns, err := namespace.FromContext(ctx); err == nil {
+				limit := core.RequestLimit(ns.Path + ns.TrimmedPath(r.URL.Path[len("/v1/"):]))
+				if limit != nil {
+					ctx = context.WithValue(ctx, requestLimitContextKey{}, limit)
+					if limit.MaxRequestSize > 0 {
+						maxRequestSize = limit.MaxRequestSize
+						pathPrefix = limit.PathPrefix
+					}
+				}
+			}
+		}
+
+		// Reject requests announcing a body over the limit of their path
+		// before reading it
+		if pathPrefix != "" && r.ContentLength > maxRequestSize {
+			respondError(w, http.StatusRequestEntityTooLarge, &requestLimitError{
+				limit:      "max_request_size",
+				maximum:    maxRequestSize,
+				actual:     r.ContentLength,
+				pathPrefix: pathPrefix,
+			})
+			return
+		}
+
 		originalBody := r.Body
 		if maxRequestSize > 0 {
-			r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+			// Limit the body through the response writer of the server, so
+			// that it closes the connection once the limit is hit
+			bodyWriter := w
+			if nw, ok := w.(*logical.StatusHeaderResponseWriter); ok {
+				bodyWriter = nw.Wrapped()
+			}
+			r.Body = http.MaxBytesReader(bodyWriter, r.Body, maxRequestSize)
 		}
 		ctx = logical.CreateContextOriginalBody(ctx, originalBody)
 		r = r.WithContext(ctx)
@@ -43,6 +112,22 @@ func wrapMaxRequestSizeHandler(handler http.Handler, props *vault.HandlerPropert
 	})
 }
 
+// checkRequestParameters enforces the maximum number of request parameters of
+// the request limit matching the path of a request, if any.
+func checkRequestParameters(ctx context.Context, data map[string]interface{}) error {
+	limit, ok := ctx.Value(requestLimitContextKey{}).(*vault.RequestLimit)
+	if !ok || limit.MaxRequestParameters == 0 || len(data) <= limit.MaxRequestParameters {
+		return nil
+	}
+
+	return &requestLimitError{
+		limit:      "max_request_parameters",
+		maximum:    int64(limit.MaxRequestParameters),
+		actual:     int64(len(data)),
+		pathPrefix: limit.PathPrefix,
+	}
+}
+
 func wrapRequestLimiterHandler(handler http.Handler, props *vault.HandlerProperties) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		request := r.WithContext(
@@ -56,6 +141,51 @@ func wrapRequestLimiterHandler(handler http.Handler, props *vault.HandlerPropert
 	})
 }
 
+// wrapLoadSheddingHandler sheds or queues requests while the node is
+// overloaded, depending on their class. Requests outside of the API, and sys/
+// requests, are needed to administer the node and are always admitted.
+func wrapLoadSheddingHandler(handler http.Handler, core *vault.Core) http.Handler {
+	controller := core.OverloadController()
+	if controller == nil {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release, err := controller.Admit(r.Context(), requestClass(core, r))
+		if err != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(controller.RetryAfter().Seconds()))))
+			respondError(w, http.StatusTooManyRequests, err)
+			return
+		}
+		defer release()
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// requestClass determines the priority of a request while the node is
+// overloaded.
+func requestClass(core *vault.Core, r *http.Request) limits.RequestClass {
+	if !strings.HasPrefix(r.URL.Path, "/v1/") {
+		return limits.RequestClassSystem
+	}
+
+	ns, err := namespace.FromContext(r.Context())
+	if err != nil {
+		return limits.RequestClassData
+	}
+	path := ns.TrimmedPath(strings.TrimPrefix(r.URL.Path, "/v1/"))
+
+	switch {
+	case strings.HasPrefix(path, "sys/"):
+		return limits.RequestClassSystem
+	case core.RouterAccess().IsLoginPath(r.Context(), path):
+		return limits.RequestClassLogin
+	default:
+		return limits.RequestClassData
+	}
+}
+
 func rateLimitQuotaWrapping(handler http.Handler, core *vault.Core) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ns, err := namespace.FromContext(r.Context())
@@ -110,6 +240,16 @@ func rateLimitQuotaWrapping(handler http.Handler, core *vault.Core) http.Handler
 			quotaReq.Role = role
 		}
 
+		// If the applicable quota groups clients by entity or has token based
+		// exemptions, resolve the client token.
+		if token, _ := getTokenFromReq(r); token != "" {
+			if err := core.ResolveTokenForQuotas(r.Context(), quotaReq, token); err != nil {
+				core.Logger().Error("failed to lookup quotas", "path", path, "error", err)
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
 		quotaResp, err := core.ApplyRateLimitQuota(r.Context(), quotaReq)
 		if err != nil {
 			core.Logger().Error("failed to apply quota", "path", path, "error", err)
@@ -150,6 +290,38 @@ func rateLimitQuotaWrapping(handler http.Handler, core *vault.Core) http.Handler
 			return
 		}
 
+		concurrencyResp, err := core.ApplyConcurrencyQuota(r.Context(), quotaReq)
+		if err != nil {
+			core.Logger().Error("failed to apply quota", "path", path, "error", err)
+			respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !concurrencyResp.Allowed {
+			for h, v := range concurrencyResp.Headers {
+				w.Header().Set(h, v)
+			}
+			quotaErr := fmt.Errorf("request path %q: %w", path, quotas.ErrConcurrencyQuotaExceeded)
+			respondError(w, http.StatusServiceUnavailable, quotaErr)
+
+			if core.Logger().IsTrace() {
+				core.Logger().Trace("request rejected due to concurrency quota violation", "request_path", path)
+			}
+			return
+		}
+		defer core.ReleaseConcurrencyQuota(concurrencyResp.Access)
+
+		// Adaptive rate limit quotas need the latency and outcome of the
+		// requests they allowed.
+		if quotaResp.Access != nil {
+			sw := logical.NewStatusHeaderResponseWriter(w, nil)
+			start := time.Now()
+			defer func() {
+				core.ObserveRateLimitQuota(quotaResp.Access, time.Since(start), sw.StatusCode)
+			}()
+			w = sw
+		}
+
 		handler.ServeHTTP(w, r)
 		return
 	})
@@ -13,7 +13,10 @@ import (
 	"github.com/hashicorp/eventlogger"
 )
 
-var _ eventlogger.Node = (*MetricsCounter)(nil)
+var (
+	_ eventlogger.Node = (*MetricsCounter)(nil)
+	_ Flusher          = (*MetricsCounter)(nil)
+)
 
 // MetricsCounter offers a way for nodes to emit metrics which increment a label by 1.
 type MetricsCounter struct {
@@ -73,6 +76,14 @@ func (m MetricsCounter) Reopen() error {
 	return m.Node.Reopen()
 }
 
+// Flush flushes the underlying eventlogger.Node, if it is a Flusher.
+func (m MetricsCounter) Flush() error {
+	if f, ok := m.Node.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
 // Type returns the type for the underlying eventlogger.Node.
 func (m MetricsCounter) Type() eventlogger.NodeType {
 	return m.Node.Type()
@@ -22,7 +22,16 @@ const (
 	devnull         = "/dev/null"
 )
 
-var _ eventlogger.Node = (*FileSink)(nil)
+var (
+	_ eventlogger.Node = (*FileSink)(nil)
+	_ Flusher          = (*FileSink)(nil)
+)
+
+// Flusher is optionally implemented by sink nodes which can flush the events
+// they have written.
+type Flusher interface {
+	Flush() error
+}
 
 // FileSink is a sink node which handles writing events to file.
 type FileSink struct {
@@ -142,6 +151,29 @@ func (s *FileSink) Reopen() error {
 	return s.open()
 }
 
+// Flush commits the events written so far to durable storage.
+func (s *FileSink) Flush() error {
+	const op = "event.(FileSink).Flush"
+
+	// '/dev/null' path means we just do nothing and pretend we're done.
+	if s.path == devnull {
+		return nil
+	}
+
+	s.fileLock.Lock()
+	defer s.fileLock.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("%s: unable to sync file for sink: %w", op, err)
+	}
+
+	return nil
+}
+
 // Type describes the type of this node (sink).
 func (s *FileSink) Type() eventlogger.NodeType {
 	return eventlogger.NodeTypeSink
@@ -201,6 +201,43 @@ func TestFileSink_Reopen(t *testing.T) {
 	}
 }
 
+// TestFileSink_Flush tests that the sink flushes files, and that the devnull
+// path is ignored.
+func TestFileSink_Flush(t *testing.T) {
+	tests := map[string]struct {
+		Path                  string
+		ShouldUseAbsolutePath bool
+	}{
+		"devnull": {
+			Path:                  "/dev/null",
+			ShouldUseAbsolutePath: true,
+		},
+		"happy": {
+			Path: "vault.log",
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tempPath := tc.Path
+			if !tc.ShouldUseAbsolutePath {
+				tempPath = filepath.Join(t.TempDir(), tc.Path)
+			}
+
+			sink, err := NewFileSink(tempPath, "json")
+			require.NoError(t, err)
+			require.NotNil(t, sink)
+
+			require.NoError(t, sink.log([]byte("{}\n")))
+			require.NoError(t, sink.Flush())
+		})
+	}
+}
+
 // TestFileSink_Process ensures that Process behaves as expected.
 func TestFileSink_Process(t *testing.T) {
 	tests := map[string]struct {
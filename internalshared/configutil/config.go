@@ -57,6 +57,8 @@ type SharedConfig struct {
 	AdministrativeNamespacePath string `hcl:"administrative_namespace_path"`
 
 	RequestLimiter *RequestLimiter `hcl:"request_limiter"`
+
+	LoadShedding *LoadShedding `hcl:"load_shedding"`
 }
 
 func ParseConfig(d string) (*SharedConfig, error) {
@@ -165,6 +167,13 @@ func ParseConfig(d string) (*SharedConfig, error) {
 		}
 	}
 
+	if o := list.Filter("load_shedding"); len(o.Items) > 0 {
+		result.found("load_shedding", "LoadShedding")
+		if err := parseLoadShedding(&result, o); err != nil {
+			return nil, fmt.Errorf("error parsing 'load_shedding': %w", err)
+		}
+	}
+
 	entConfig := &(result.EntSharedConfig)
 	if err := entConfig.ParseConfig(list); err != nil {
 		return nil, fmt.Errorf("error parsing enterprise config: %w", err)
@@ -300,6 +309,17 @@ func (c *SharedConfig) Sanitized() map[string]interface{} {
 		result["request_limiter"] = sanitizedRequestLimiter
 	}
 
+	if c.LoadShedding != nil {
+		sanitizedLoadShedding := map[string]interface{}{
+			"disable":       c.LoadShedding.Disable,
+			"max_in_flight": c.LoadShedding.MaxInFlight,
+			"max_latency":   c.LoadShedding.MaxLatency,
+			"queue_timeout": c.LoadShedding.QueueTimeout,
+			"retry_after":   c.LoadShedding.RetryAfter,
+		}
+		result["load_shedding"] = sanitizedLoadShedding
+	}
+
 	return result
 }
 
@@ -6,6 +6,7 @@ package configutil
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"net/textproto"
 	"regexp"
 	"strings"
@@ -25,6 +26,10 @@ import (
 const (
 	TCP  ListenerType = "tcp"
 	Unix ListenerType = "unix"
+
+	// DefaultReplayProtectionWindow is the window used by listeners with
+	// replay protection enabled if none is configured
+	DefaultReplayProtectionWindow = 30 * time.Second
 )
 
 // ListenerType represents the supported types of listener.
@@ -48,6 +53,32 @@ type ListenerInFlightRequestLogging struct {
 	UnauthenticatedInFlightAccessRaw interface{}  `hcl:"unauthenticated_in_flight_requests_access,alias:unauthenticatedInFlightAccessRaw"`
 }
 
+// ListenerHealthProfile is a named set of readiness checks selected with the
+// profile parameter of the listener's sys/health endpoint.
+type ListenerHealthProfile struct {
+	UnusedKeys UnusedKeyMap `hcl:",unusedKeyPositions"`
+	Name       string       `hcl:",key"`
+
+	StandbyOK        bool        `hcl:"-"`
+	StandbyOKRaw     interface{} `hcl:"standby_ok"`
+	PerfStandbyOK    bool        `hcl:"-"`
+	PerfStandbyOKRaw interface{} `hcl:"perf_standby_ok"`
+
+	// RequireMountsReady fails the profile while any mount has no backend,
+	// e.g. because its plugin could not be started
+	RequireMountsReady    bool        `hcl:"-"`
+	RequireMountsReadyRaw interface{} `hcl:"require_mounts_ready"`
+
+	// MaxRotationBacklog fails the profile while more root credentials than
+	// this are overdue for rotation. It is -1 if not set.
+	MaxRotationBacklog    int64       `hcl:"-"`
+	MaxRotationBacklogRaw interface{} `hcl:"max_rotation_backlog"`
+
+	// UnreadyCode is the status code returned when a check of the profile
+	// fails
+	UnreadyCode int `hcl:"unready_code"`
+}
+
 // Listener is the listener configuration for the server.
 type Listener struct {
 	UnusedKeys UnusedKeyMap `hcl:",unusedKeyPositions"`
@@ -114,6 +145,7 @@ type Listener struct {
 	Telemetry              ListenerTelemetry              `hcl:"telemetry"`
 	Profiling              ListenerProfiling              `hcl:"profiling"`
 	InFlightRequestLogging ListenerInFlightRequestLogging `hcl:"inflight_requests_logging"`
+	HealthProfiles         []*ListenerHealthProfile       `hcl:"health_profile"`
 
 	// RandomPort is used only for some testing purposes
 	RandomPort bool `hcl:"-"`
@@ -147,6 +179,19 @@ type Listener struct {
 	// DisableRequestLimiter allows per-listener disabling of the Request Limiter.
 	DisableRequestLimiterRaw any  `hcl:"disable_request_limiter"`
 	DisableRequestLimiter    bool `hcl:"-"`
+
+	// UnauthenticatedDiscoveryAccess serves the cluster discovery document
+	// without requiring a token
+	UnauthenticatedDiscoveryAccessRaw any  `hcl:"unauthenticated_discovery_access"`
+	UnauthenticatedDiscoveryAccess    bool `hcl:"-"`
+
+	// ReplayProtection requires the requests submitting unseal, rekey and
+	// generate root key shares to be signed, timestamped and carry a nonce
+	// which has not been seen within ReplayProtectionWindow
+	ReplayProtectionRaw       any           `hcl:"replay_protection"`
+	ReplayProtection          bool          `hcl:"-"`
+	ReplayProtectionWindowRaw any           `hcl:"replay_protection_window"`
+	ReplayProtectionWindow    time.Duration `hcl:"-"`
 }
 
 // AgentAPI allows users to select which parts of the Agent API they want enabled.
@@ -165,9 +210,23 @@ func (l *Listener) GoString() string {
 
 func (l *Listener) Validate(path string) []ConfigError {
 	results := append(ValidateUnusedFields(l.UnusedKeys, path), ValidateUnusedFields(l.Telemetry.UnusedKeys, path)...)
+	for _, profile := range l.HealthProfiles {
+		results = append(results, ValidateUnusedFields(profile.UnusedKeys, path)...)
+	}
 	return append(results, ValidateUnusedFields(l.Profiling.UnusedKeys, path)...)
 }
 
+// HealthProfile returns the health profile of the listener with the given
+// name, or nil if there is none.
+func (l *Listener) HealthProfile(name string) *ListenerHealthProfile {
+	for _, profile := range l.HealthProfiles {
+		if profile.Name == name {
+			return profile
+		}
+	}
+	return nil
+}
+
 // ParseSingleIPTemplate is used as a helper function to parse out a single IP
 // address from a config parameter.
 // If the input doesn't appear to contain the 'template' format,
@@ -256,12 +315,15 @@ func parseListener(item *ast.ObjectItem) (*Listener, error) {
 		l.parseTelemetrySettings,
 		l.parseProfilingSettings,
 		l.parseInFlightRequestSettings,
+		l.parseHealthProfileSettings,
 		l.parseCORSSettings,
 		l.parseHTTPHeaderSettings,
 		l.parseChrootNamespaceSettings,
 		l.parseRedactionSettings,
 		l.parseDisableReplicationStatusEndpointSettings,
 		l.parseDisableRequestLimiter,
+		l.parseDiscoverySettings,
+		l.parseReplayProtectionSettings,
 	} {
 		err := parser()
 		if err != nil {
@@ -386,6 +448,47 @@ func (l *Listener) parseDisableRequestLimiter() error {
 	return nil
 }
 
+// parseDiscoverySettings attempts to parse the raw
+// unauthenticated_discovery_access setting. The receiving Listener's
+// UnauthenticatedDiscoveryAccess field will be set with the successfully
+// parsed value.
+func (l *Listener) parseDiscoverySettings() error {
+	if err := parseAndClearBool(&l.UnauthenticatedDiscoveryAccessRaw, &l.UnauthenticatedDiscoveryAccess); err != nil {
+		return fmt.Errorf("invalid value for unauthenticated_discovery_access: %w", err)
+	}
+
+	return nil
+}
+
+// parseReplayProtectionSettings attempts to parse the raw replay_protection
+// and replay_protection_window settings. The state of the listener will be
+// modified, raw data will be cleared upon successful parsing.
+func (l *Listener) parseReplayProtectionSettings() error {
+	if err := parseAndClearBool(&l.ReplayProtectionRaw, &l.ReplayProtection); err != nil {
+		return fmt.Errorf("invalid value for replay_protection: %w", err)
+	}
+
+	if l.ReplayProtectionWindowRaw != nil {
+		window, err := parseutil.ParseDurationSecond(l.ReplayProtectionWindowRaw)
+		if err != nil {
+			return fmt.Errorf("error parsing replay_protection_window: %w", err)
+		}
+
+		if window <= 0 {
+			return errors.New("replay_protection_window must be positive")
+		}
+
+		l.ReplayProtectionWindow = window
+		l.ReplayProtectionWindowRaw = nil
+	}
+
+	if l.ReplayProtection && l.ReplayProtectionWindow == 0 {
+		l.ReplayProtectionWindow = DefaultReplayProtectionWindow
+	}
+
+	return nil
+}
+
 // parseChrootNamespace attempts to parse the raw listener chroot namespace settings.
 // The state of the listener will be modified, raw data will be cleared upon
 // successful parsing.
@@ -647,6 +750,49 @@ func (l *Listener) parseInFlightRequestSettings() error {
 	return nil
 }
 
+// parseHealthProfileSettings attempts to parse the raw listener health profile
+// settings. The state of the listener will be modified, raw data will be
+// cleared upon successful parsing.
+func (l *Listener) parseHealthProfileSettings() error {
+	names := make(map[string]bool, len(l.HealthProfiles))
+	for _, profile := range l.HealthProfiles {
+		if profile.Name == "" {
+			return errors.New("health_profile must have a name")
+		}
+		if names[profile.Name] {
+			return fmt.Errorf("duplicate health_profile %q", profile.Name)
+		}
+		names[profile.Name] = true
+
+		if err := parseAndClearBool(&profile.StandbyOKRaw, &profile.StandbyOK); err != nil {
+			return fmt.Errorf("invalid value for health_profile.%s.standby_ok: %w", profile.Name, err)
+		}
+		if err := parseAndClearBool(&profile.PerfStandbyOKRaw, &profile.PerfStandbyOK); err != nil {
+			return fmt.Errorf("invalid value for health_profile.%s.perf_standby_ok: %w", profile.Name, err)
+		}
+		if err := parseAndClearBool(&profile.RequireMountsReadyRaw, &profile.RequireMountsReady); err != nil {
+			return fmt.Errorf("invalid value for health_profile.%s.require_mounts_ready: %w", profile.Name, err)
+		}
+
+		if profile.MaxRotationBacklogRaw == nil {
+			profile.MaxRotationBacklog = -1
+		} else {
+			if err := parseAndClearInt(&profile.MaxRotationBacklogRaw, &profile.MaxRotationBacklog); err != nil {
+				return fmt.Errorf("invalid value for health_profile.%s.max_rotation_backlog: %w", profile.Name, err)
+			}
+			if profile.MaxRotationBacklog < 0 {
+				return fmt.Errorf("health_profile.%s.max_rotation_backlog cannot be negative", profile.Name)
+			}
+		}
+
+		if profile.UnreadyCode == 0 {
+			profile.UnreadyCode = http.StatusServiceUnavailable
+		}
+	}
+
+	return nil
+}
+
 // parseCORSSettings attempts to parse the raw listener CORS settings.
 // The state of the listener will be modified, raw data will be cleared upon
 // successful parsing.
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/hcl"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -797,6 +798,225 @@ func TestListener_parseInFlightRequestSettings(t *testing.T) {
 	}
 }
 
+// TestListener_parseDiscoverySettings exercises the listener receiver
+// parseDiscoverySettings.
+func TestListener_parseDiscoverySettings(t *testing.T) {
+	tests := map[string]struct {
+		rawUnauthenticatedDiscoveryAccess      any
+		expectedUnauthenticatedDiscoveryAccess bool
+		isErrorExpected                        bool
+		errorMessage                           string
+	}{
+		"nil": {
+			isErrorExpected: false,
+		},
+		"bad": {
+			rawUnauthenticatedDiscoveryAccess: "juan",
+			isErrorExpected:                   true,
+			errorMessage:                      "invalid value for unauthenticated_discovery_access",
+		},
+		"good": {
+			rawUnauthenticatedDiscoveryAccess:      "true",
+			expectedUnauthenticatedDiscoveryAccess: true,
+			isErrorExpected:                        false,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// Configure listener with raw values
+			l := &Listener{
+				UnauthenticatedDiscoveryAccessRaw: tc.rawUnauthenticatedDiscoveryAccess,
+			}
+
+			err := l.parseDiscoverySettings()
+
+			switch {
+			case tc.isErrorExpected:
+				require.Error(t, err)
+				require.ErrorContains(t, err, tc.errorMessage)
+			default:
+				// Assert we got the relevant values.
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedUnauthenticatedDiscoveryAccess, l.UnauthenticatedDiscoveryAccess)
+
+				// Ensure the state was modified for the raw values.
+				require.Nil(t, l.UnauthenticatedDiscoveryAccessRaw)
+			}
+		})
+	}
+}
+
+// TestListener_parseReplayProtectionSettings exercises the listener receiver
+// parseReplayProtectionSettings.
+func TestListener_parseReplayProtectionSettings(t *testing.T) {
+	tests := map[string]struct {
+		rawReplayProtection any
+		rawWindow           any
+		expectedEnabled     bool
+		expectedWindow      time.Duration
+		isErrorExpected     bool
+		errorMessage        string
+	}{
+		"nil": {
+			isErrorExpected: false,
+		},
+		"bad-enabled": {
+			rawReplayProtection: "juan",
+			isErrorExpected:     true,
+			errorMessage:        "invalid value for replay_protection",
+		},
+		"bad-window": {
+			rawReplayProtection: true,
+			rawWindow:           "juan",
+			isErrorExpected:     true,
+			errorMessage:        "error parsing replay_protection_window",
+		},
+		"negative-window": {
+			rawReplayProtection: true,
+			rawWindow:           "-10s",
+			isErrorExpected:     true,
+			errorMessage:        "replay_protection_window must be positive",
+		},
+		"default-window": {
+			rawReplayProtection: "true",
+			expectedEnabled:     true,
+			expectedWindow:      DefaultReplayProtectionWindow,
+		},
+		"window": {
+			rawReplayProtection: true,
+			rawWindow:           "2m",
+			expectedEnabled:     true,
+			expectedWindow:      2 * time.Minute,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// Configure listener with raw values
+			l := &Listener{
+				ReplayProtectionRaw:       tc.rawReplayProtection,
+				ReplayProtectionWindowRaw: tc.rawWindow,
+			}
+
+			err := l.parseReplayProtectionSettings()
+
+			switch {
+			case tc.isErrorExpected:
+				require.Error(t, err)
+				require.ErrorContains(t, err, tc.errorMessage)
+			default:
+				// Assert we got the relevant values.
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedEnabled, l.ReplayProtection)
+				require.Equal(t, tc.expectedWindow, l.ReplayProtectionWindow)
+
+				// Ensure the state was modified for the raw values.
+				require.Nil(t, l.ReplayProtectionRaw)
+				require.Nil(t, l.ReplayProtectionWindowRaw)
+			}
+		})
+	}
+}
+
+// TestListener_parseHealthProfileSettings exercises the listener receiver
+// parseHealthProfileSettings, and the decoding of health_profile blocks.
+func TestListener_parseHealthProfileSettings(t *testing.T) {
+	tests := map[string]struct {
+		config          string
+		expected        []*ListenerHealthProfile
+		isErrorExpected bool
+		errorMessage    string
+	}{
+		"defaults": {
+			config: `health_profile "lb" {}`,
+			expected: []*ListenerHealthProfile{{
+				Name:               "lb",
+				MaxRotationBacklog: -1,
+				UnreadyCode:        503,
+			}},
+		},
+		"good": {
+			config: `
+health_profile "lb" {
+	standby_ok           = true
+	perf_standby_ok      = "true"
+	require_mounts_ready = true
+	max_rotation_backlog = 0
+	unready_code         = 429
+}
+health_profile "strict" {
+	max_rotation_backlog = "5"
+}`,
+			expected: []*ListenerHealthProfile{
+				{
+					Name:               "lb",
+					StandbyOK:          true,
+					PerfStandbyOK:      true,
+					RequireMountsReady: true,
+					MaxRotationBacklog: 0,
+					UnreadyCode:        429,
+				},
+				{
+					Name:               "strict",
+					MaxRotationBacklog: 5,
+					UnreadyCode:        503,
+				},
+			},
+		},
+		"bad-bool": {
+			config:          `health_profile "lb" { require_mounts_ready = "juan" }`,
+			isErrorExpected: true,
+			errorMessage:    "invalid value for health_profile.lb.require_mounts_ready",
+		},
+		"negative-backlog": {
+			config:          `health_profile "lb" { max_rotation_backlog = -1 }`,
+			isErrorExpected: true,
+			errorMessage:    "max_rotation_backlog cannot be negative",
+		},
+		"duplicate": {
+			config:          `health_profile "lb" {} ` + "\n" + `health_profile "lb" {}`,
+			isErrorExpected: true,
+			errorMessage:    `duplicate health_profile "lb"`,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var l *Listener
+			require.NoError(t, hcl.Decode(&l, tc.config))
+
+			err := l.parseHealthProfileSettings()
+
+			switch {
+			case tc.isErrorExpected:
+				require.Error(t, err)
+				require.ErrorContains(t, err, tc.errorMessage)
+			default:
+				require.NoError(t, err)
+				for _, profile := range l.HealthProfiles {
+					profile.UnusedKeys = nil
+				}
+				require.Equal(t, tc.expected, l.HealthProfiles)
+				require.Equal(t, tc.expected[0], l.HealthProfile(tc.expected[0].Name))
+				require.Nil(t, l.HealthProfile("missing"))
+			}
+		})
+	}
+}
+
 // TestListener_parseCORSSettings exercises the listener receiver parseCORSSettings.
 // We check various inputs to ensure we can parse the values as expected and
 // assign the relevant value on the SharedConfig struct.
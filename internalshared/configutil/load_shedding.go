@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package configutil
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+// LoadShedding configures the shedding of low priority requests while the
+// server is overloaded.
+type LoadShedding struct {
+	UnusedKeys UnusedKeyMap `hcl:",unusedKeyPositions"`
+
+	Disable    bool        `hcl:"-"`
+	DisableRaw interface{} `hcl:"disable"`
+
+	MaxInFlight    int64       `hcl:"-"`
+	MaxInFlightRaw interface{} `hcl:"max_in_flight"`
+
+	MaxLatency    time.Duration `hcl:"-"`
+	MaxLatencyRaw interface{}   `hcl:"max_latency"`
+
+	QueueTimeout    time.Duration `hcl:"-"`
+	QueueTimeoutRaw interface{}   `hcl:"queue_timeout"`
+
+	RetryAfter    time.Duration `hcl:"-"`
+	RetryAfterRaw interface{}   `hcl:"retry_after"`
+}
+
+func (l *LoadShedding) Validate(source string) []ConfigError {
+	return ValidateUnusedFields(l.UnusedKeys, source)
+}
+
+func (l *LoadShedding) GoString() string {
+	return fmt.Sprintf("*%#v", *l)
+}
+
+func parseLoadShedding(result *SharedConfig, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'load_shedding' block is permitted")
+	}
+
+	result.LoadShedding = &LoadShedding{}
+
+	// Get our one item
+	item := list.Items[0]
+
+	if err := hcl.DecodeObject(&result.LoadShedding, item.Val); err != nil {
+		return multierror.Prefix(err, "load_shedding:")
+	}
+
+	l := result.LoadShedding
+	if err := parseAndClearBool(&l.DisableRaw, &l.Disable); err != nil {
+		return fmt.Errorf("invalid value for disable: %w", err)
+	}
+	if err := parseAndClearInt(&l.MaxInFlightRaw, &l.MaxInFlight); err != nil {
+		return fmt.Errorf("invalid value for max_in_flight: %w", err)
+	}
+	if err := parseAndClearDurationSecond(&l.MaxLatencyRaw, &l.MaxLatency); err != nil {
+		return fmt.Errorf("invalid value for max_latency: %w", err)
+	}
+	if err := parseAndClearDurationSecond(&l.QueueTimeoutRaw, &l.QueueTimeout); err != nil {
+		return fmt.Errorf("invalid value for queue_timeout: %w", err)
+	}
+	if err := parseAndClearDurationSecond(&l.RetryAfterRaw, &l.RetryAfter); err != nil {
+		return fmt.Errorf("invalid value for retry_after: %w", err)
+	}
+
+	switch {
+	case l.MaxInFlight < 0, l.MaxLatency < 0, l.QueueTimeout < 0, l.RetryAfter < 0:
+		return errors.New("load_shedding thresholds and durations cannot be negative")
+	case !l.Disable && l.MaxInFlight == 0 && l.MaxLatency == 0:
+		return errors.New("load_shedding requires max_in_flight or max_latency to be set")
+	}
+
+	return nil
+}
@@ -103,5 +103,10 @@ func (c *SharedConfig) Merge(c2 *SharedConfig) *SharedConfig {
 		result.RequestLimiter = c2.RequestLimiter
 	}
 
+	result.LoadShedding = c.LoadShedding
+	if c2.LoadShedding != nil {
+		result.LoadShedding = c2.LoadShedding
+	}
+
 	return result
 }
@@ -0,0 +1,225 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+package limits
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-hclog"
+)
+
+// ErrOverloaded is returned when a request is shed because Vault is
+// overloaded. This should be handled by callers in request paths to return
+// http.StatusTooManyRequests to the client, along with a Retry-After header.
+var ErrOverloaded = errors.New("Vault server overloaded, request shed")
+
+const (
+	// DefaultOverloadRetryAfter is the default time clients are asked to wait
+	// before retrying a shed request.
+	DefaultOverloadRetryAfter = time.Second
+
+	// overloadLatencySmoothing is the weight of each new sample in the moving
+	// average of request latency.
+	overloadLatencySmoothing = .1
+)
+
+// RequestClass is the priority of a request during overload.
+type RequestClass int
+
+const (
+	// RequestClassSystem requests, such as health checks and the sys/
+	// endpoints used to administer or fail over a node, are never shed.
+	RequestClassSystem RequestClass = iota
+
+	// RequestClassLogin requests are queued while Vault is overloaded, and
+	// shed only if the overload does not pass within the queue timeout.
+	RequestClassLogin
+
+	// RequestClassData requests, i.e. all other traffic, are shed while Vault
+	// is overloaded.
+	RequestClassData
+)
+
+func (c RequestClass) String() string {
+	switch c {
+	case RequestClassSystem:
+		return "system"
+	case RequestClassLogin:
+		return "login"
+	default:
+		return "data"
+	}
+}
+
+// OverloadConfig holds the thresholds beyond which an OverloadController
+// considers Vault overloaded. A zero threshold is not checked.
+type OverloadConfig struct {
+	// MaxInFlight is the number of requests in flight beyond which Vault is
+	// overloaded.
+	MaxInFlight int
+
+	// MaxLatency is the moving average of request latency beyond which Vault
+	// is overloaded.
+	MaxLatency time.Duration
+
+	// QueueTimeout is how long login requests are queued while Vault is
+	// overloaded before they are shed. Login requests are shed right away if
+	// it is zero.
+	QueueTimeout time.Duration
+
+	// RetryAfter is the time clients are asked to wait before retrying a shed
+	// request. It is also how long a latency measurement is considered
+	// current, so that requests are admitted again to probe whether an
+	// overload has passed once shedding stops all new measurements.
+	RetryAfter time.Duration
+}
+
+// OverloadController sheds or queues requests by their class while Vault is
+// overloaded, so that the requests required to administer a node keep being
+// served.
+type OverloadController struct {
+	config OverloadConfig
+	logger hclog.Logger
+
+	inFlight atomic.Int64
+
+	// latency is the moving average of request latency, measured at
+	// latencyTime, both in nanoseconds
+	latency     atomic.Int64
+	latencyTime atomic.Int64
+
+	// releasedCh is closed, and replaced, when a request completes while
+	// waiters requests are queued
+	waiters    atomic.Int64
+	releasedL  sync.Mutex
+	releasedCh chan struct{}
+}
+
+// NewOverloadController is a basic constructor for the OverloadController.
+func NewOverloadController(logger hclog.Logger, config OverloadConfig) *OverloadController {
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = DefaultOverloadRetryAfter
+	}
+
+	logger.Info("setting up load shedding",
+		"maxInFlight", config.MaxInFlight,
+		"maxLatency", config.MaxLatency,
+		"queueTimeout", config.QueueTimeout,
+		"retryAfter", config.RetryAfter,
+	)
+
+	return &OverloadController{
+		config:     config,
+		logger:     logger,
+		releasedCh: make(chan struct{}),
+	}
+}
+
+// RetryAfter returns the time clients are asked to wait before retrying a
+// shed request.
+func (o *OverloadController) RetryAfter() time.Duration {
+	return o.config.RetryAfter
+}
+
+// Overloaded reports whether either the number of requests in flight or the
+// current moving average of request latency exceed their thresholds.
+func (o *OverloadController) Overloaded() bool {
+	if o.config.MaxInFlight > 0 && o.inFlight.Load() >= int64(o.config.MaxInFlight) {
+		return true
+	}
+	if o.config.MaxLatency > 0 && time.Duration(o.latency.Load()) > o.config.MaxLatency {
+		measured := time.Unix(0, o.latencyTime.Load())
+		return time.Since(measured) < o.config.RetryAfter
+	}
+	return false
+}
+
+// Admit decides whether a request of the given class is served. If it is,
+// the caller must call the returned function once the request completes, to
+// release it and measure its latency. Otherwise ErrOverloaded is returned.
+//
+// A nil OverloadController admits all requests.
+func (o *OverloadController) Admit(ctx context.Context, class RequestClass) (func(), error) {
+	if o == nil {
+		return func() {}, nil
+	}
+
+	if class != RequestClassSystem && o.Overloaded() {
+		if class != RequestClassLogin || !o.wait(ctx) {
+			metrics.IncrCounterWithLabels([]string{"limits", "load_shedding", "shed"}, 1, []metrics.Label{
+				{Name: "class", Value: class.String()},
+			})
+			return nil, ErrOverloaded
+		}
+	}
+
+	o.inFlight.Add(1)
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			o.release(time.Since(start))
+		})
+	}, nil
+}
+
+// wait queues a request until Vault is no longer overloaded, and reports
+// whether it is to be admitted.
+func (o *OverloadController) wait(ctx context.Context) bool {
+	if o.config.QueueTimeout <= 0 {
+		return false
+	}
+
+	metrics.IncrCounter([]string{"limits", "load_shedding", "queued"}, 1)
+	o.waiters.Add(1)
+	defer o.waiters.Add(-1)
+
+	timer := time.NewTimer(o.config.QueueTimeout)
+	defer timer.Stop()
+
+	for {
+		o.releasedL.Lock()
+		released := o.releasedCh
+		o.releasedL.Unlock()
+
+		// Check again now that a release would be noticed
+		if !o.Overloaded() {
+			return true
+		}
+
+		select {
+		case <-released:
+		case <-timer.C:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// release releases a completed request, measuring its latency, and wakes up
+// the queued requests, if any.
+func (o *OverloadController) release(latency time.Duration) {
+	o.inFlight.Add(-1)
+
+	for {
+		prev := o.latency.Load()
+		next := prev + int64(overloadLatencySmoothing*float64(int64(latency)-prev))
+		if o.latency.CompareAndSwap(prev, next) {
+			break
+		}
+	}
+	o.latencyTime.Store(time.Now().UnixNano())
+
+	if o.waiters.Load() > 0 {
+		o.releasedL.Lock()
+		close(o.releasedCh)
+		o.releasedCh = make(chan struct{})
+		o.releasedL.Unlock()
+	}
+}
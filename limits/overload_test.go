@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package limits
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOverloadController_Admit verifies that while the controller is
+// overloaded, system requests are admitted, data requests are shed and login
+// requests are queued until a request is released.
+func TestOverloadController_Admit(t *testing.T) {
+	o := NewOverloadController(hclog.NewNullLogger(), OverloadConfig{
+		MaxInFlight:  1,
+		QueueTimeout: 5 * time.Second,
+	})
+	ctx := context.Background()
+
+	release, err := o.Admit(ctx, RequestClassData)
+	require.NoError(t, err)
+	require.True(t, o.Overloaded())
+
+	_, err = o.Admit(ctx, RequestClassData)
+	require.ErrorIs(t, err, ErrOverloaded)
+
+	releaseSystem, err := o.Admit(ctx, RequestClassSystem)
+	require.NoError(t, err)
+	releaseSystem()
+
+	admitted := make(chan error)
+	go func() {
+		releaseLogin, err := o.Admit(ctx, RequestClassLogin)
+		if err == nil {
+			releaseLogin()
+		}
+		admitted <- err
+	}()
+
+	select {
+	case err := <-admitted:
+		t.Fatalf("login request was not queued: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	release()
+	require.NoError(t, <-admitted)
+}
+
+// TestOverloadController_QueueTimeout verifies that a queued login request is
+// shed once the queue timeout passes.
+func TestOverloadController_QueueTimeout(t *testing.T) {
+	o := NewOverloadController(hclog.NewNullLogger(), OverloadConfig{
+		MaxInFlight:  1,
+		QueueTimeout: 50 * time.Millisecond,
+	})
+	ctx := context.Background()
+
+	release, err := o.Admit(ctx, RequestClassData)
+	require.NoError(t, err)
+	defer release()
+
+	_, err = o.Admit(ctx, RequestClassLogin)
+	require.ErrorIs(t, err, ErrOverloaded)
+}
+
+// TestOverloadController_Nil verifies that a nil controller admits all
+// requests.
+func TestOverloadController_Nil(t *testing.T) {
+	var o *OverloadController
+	release, err := o.Admit(context.Background(), RequestClassData)
+	require.NoError(t, err)
+	release()
+}
@@ -31,9 +31,10 @@ import (
 
 // Verify MySQLBackend satisfies the correct interfaces
 var (
-	_ physical.Backend   = (*MySQLBackend)(nil)
-	_ physical.HABackend = (*MySQLBackend)(nil)
-	_ physical.Lock      = (*MySQLHALock)(nil)
+	_ physical.Backend          = (*MySQLBackend)(nil)
+	_ physical.PaginatedBackend = (*MySQLBackend)(nil)
+	_ physical.HABackend        = (*MySQLBackend)(nil)
+	_ physical.Lock             = (*MySQLHALock)(nil)
 )
 
 // Unreserved tls key
@@ -186,6 +187,8 @@ func NewMySQLBackend(conf map[string]string, logger log.Logger) (physical.Backen
 		"get":    "SELECT vault_value FROM " + dbTable + " WHERE vault_key = ?",
 		"delete": "DELETE FROM " + dbTable + " WHERE vault_key = ?",
 		"list":   "SELECT vault_key FROM " + dbTable + " WHERE vault_key LIKE ?",
+		"list_page": "SELECT vault_key FROM " + dbTable +
+			" WHERE vault_key LIKE ? AND vault_key >= ? ORDER BY vault_key LIMIT ?",
 	}
 
 	// Only prepare ha-related statements if we need them
@@ -450,6 +453,82 @@ func (m *MySQLBackend) List(ctx context.Context, prefix string) ([]string, error
 	return keys, nil
 }
 
+// ListPage is used to list a page of the keys under a given prefix, up to the
+// next prefix, which sort after the given key.
+func (m *MySQLBackend) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	if limit <= 0 {
+		keys, err := m.List(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		return physical.PaginateKeys(keys, after, limit), nil
+	}
+
+	defer metrics.MeasureSince([]string{"mysql", "list_page"}, time.Now())
+
+	m.permitPool.Acquire()
+	defer m.permitPool.Release()
+
+	// Seek past the given key, skipping all the keys within it if it is a
+	// 'folder'
+	likePrefix := prefix + "%"
+	seek := seekPastKey(prefix, after)
+
+	var keys []string
+	for {
+		rows, err := m.statements["list_page"].QueryContext(ctx, likePrefix, seek, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute statement: %w", err)
+		}
+
+		var n int
+		var last, lastKey string
+		for rows.Next() {
+			if err := rows.Scan(&last); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan rows: %w", err)
+			}
+			n++
+
+			lastKey = strings.TrimPrefix(last, prefix)
+			if i := strings.Index(lastKey, "/"); i != -1 {
+				lastKey = lastKey[:i+1]
+			}
+			if lastKey <= after || (len(keys) > 0 && keys[len(keys)-1] == lastKey) {
+				continue
+			}
+			if len(keys) < limit {
+				keys = append(keys, lastKey)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read rows: %w", err)
+		}
+		rows.Close()
+
+		// Stop once the page is full or all the keys have been read,
+		// otherwise carry on past the last key read
+		if len(keys) >= limit || n < limit {
+			return keys, nil
+		}
+		if strings.HasSuffix(lastKey, "/") {
+			seek = seekPastKey(prefix, lastKey)
+		} else {
+			seek = last + "\x00"
+		}
+	}
+}
+
+// seekPastKey returns the first storage key under the prefix which sorts
+// after the given key and, if it is a 'folder', all the keys within it.
+func seekPastKey(prefix, key string) string {
+	if strings.HasSuffix(key, "/") {
+		return prefix + key[:len(key)-1] + string('/'+1)
+	}
+	return prefix + key + "\x00"
+}
+
 // LockWith is used for mutual exclusion based on the given key.
 func (m *MySQLBackend) LockWith(key, value string) (physical.Lock, error) {
 	l := &MySQLHALock{
@@ -108,6 +108,7 @@ func TestMySQLBackend(t *testing.T) {
 
 	physical.ExerciseBackend(t, b)
 	physical.ExerciseBackend_ListPrefix(t, b)
+	physical.ExerciseBackend_ListPage(t, b)
 }
 
 func TestMySQLHABackend(t *testing.T) {
@@ -37,7 +37,10 @@ const (
 )
 
 // Verify PostgreSQLBackend satisfies the correct interfaces
-var _ physical.Backend = (*PostgreSQLBackend)(nil)
+var (
+	_ physical.Backend          = (*PostgreSQLBackend)(nil)
+	_ physical.PaginatedBackend = (*PostgreSQLBackend)(nil)
+)
 
 // HA backend was implemented based on the DynamoDB backend pattern
 // With distinction using central postgres clock, hereby avoiding
@@ -50,12 +53,13 @@ var (
 // PostgreSQL Backend is a physical backend that stores data
 // within a PostgreSQL database.
 type PostgreSQLBackend struct {
-	table        string
-	client       *sql.DB
-	put_query    string
-	get_query    string
-	delete_query string
-	list_query   string
+	table           string
+	client          *sql.DB
+	put_query       string
+	get_query       string
+	delete_query    string
+	list_query      string
+	list_page_query string
 
 	ha_table                 string
 	haGetLockValueQuery      string
@@ -167,6 +171,10 @@ func NewPostgreSQLBackend(conf map[string]string, logger log.Logger) (physical.B
 	}
 	quoted_ha_table := dbutil.QuoteIdentifier(unquoted_ha_table)
 
+	list_query := "SELECT key FROM " + quoted_table + " WHERE path = $1" +
+		" UNION ALL SELECT DISTINCT substring(substr(path, length($1)+1) from '^.*?/') FROM " + quoted_table +
+		" WHERE parent_path LIKE $1 || '%'"
+
 	// Setup the backend.
 	m := &PostgreSQLBackend{
 		table:        quoted_table,
@@ -174,9 +182,11 @@ func NewPostgreSQLBackend(conf map[string]string, logger log.Logger) (physical.B
 		put_query:    put_query,
 		get_query:    "SELECT value FROM " + quoted_table + " WHERE path = $1 AND key = $2",
 		delete_query: "DELETE FROM " + quoted_table + " WHERE path = $1 AND key = $2",
-		list_query: "SELECT key FROM " + quoted_table + " WHERE path = $1" +
-			" UNION ALL SELECT DISTINCT substring(substr(path, length($1)+1) from '^.*?/') FROM " + quoted_table +
-			" WHERE parent_path LIKE $1 || '%'",
+		list_query:   list_query,
+		// Keys are compared bytewise, which is the order the keys of other
+		// backends are listed in. A NULL limit returns all the keys.
+		list_page_query: "SELECT key FROM (" + list_query + ") AS keys" +
+			" WHERE key COLLATE \"C\" > $2 ORDER BY key COLLATE \"C\" LIMIT $3",
 		haGetLockValueQuery:
 		// only read non expired data
 		" SELECT ha_value FROM " + quoted_ha_table + " WHERE NOW() <= valid_until AND ha_key = $1 ",
@@ -321,6 +331,39 @@ func (m *PostgreSQLBackend) List(ctx context.Context, prefix string) ([]string,
 	return keys, nil
 }
 
+// ListPage is used to list a page of the keys under a given prefix, up to
+// the next prefix, which sort after the given key.
+func (m *PostgreSQLBackend) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	defer metrics.MeasureSince([]string{"postgres", "list_page"}, time.Now())
+
+	m.permitPool.Acquire()
+	defer m.permitPool.Release()
+
+	var pageLimit sql.NullInt64
+	if limit > 0 {
+		pageLimit = sql.NullInt64{Int64: int64(limit), Valid: true}
+	}
+
+	rows, err := m.client.QueryContext(ctx, m.list_page_query, "/"+prefix, after, pageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		err = rows.Scan(&key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rows: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
 // LockWith is used for mutual exclusion based on the given key.
 func (p *PostgreSQLBackend) LockWith(key, value string) (physical.Lock, error) {
 	identity, err := uuid.GenerateUUID()
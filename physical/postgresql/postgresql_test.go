@@ -80,6 +80,7 @@ func TestPostgreSQLBackend(t *testing.T) {
 	physical.ExerciseBackend(t, b1)
 	logger.Info("Running list prefix backend tests")
 	physical.ExerciseBackend_ListPrefix(t, b1)
+	physical.ExerciseBackend_ListPage(t, b1)
 
 	ha1, ok := b1.(physical.HABackend)
 	if !ok {
@@ -55,10 +55,11 @@ var (
 
 // Verify FSM satisfies the correct interfaces
 var (
-	_ physical.Backend       = (*FSM)(nil)
-	_ physical.Transactional = (*FSM)(nil)
-	_ raft.FSM               = (*FSM)(nil)
-	_ raft.BatchingFSM       = (*FSM)(nil)
+	_ physical.Backend          = (*FSM)(nil)
+	_ physical.PaginatedBackend = (*FSM)(nil)
+	_ physical.Transactional    = (*FSM)(nil)
+	_ raft.FSM                  = (*FSM)(nil)
+	_ raft.BatchingFSM          = (*FSM)(nil)
 )
 
 var logVerifierMagicBytes [8]byte
@@ -633,6 +634,48 @@ func (f *FSM) List(ctx context.Context, prefix string) ([]string, error) {
 	return keys, err
 }
 
+// ListPage is used to list a page of the keys under a given prefix, up to the
+// next prefix, which sort after the given key.
+func (f *FSM) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	defer metrics.MeasureSince([]string{"raft_storage", "fsm", "list_page"}, time.Now())
+
+	f.l.RLock()
+	defer f.l.RUnlock()
+
+	var keys []string
+
+	err := f.db.View(func(tx *bolt.Tx) error {
+		// Assume bucket exists and has keys
+		c := tx.Bucket(dataBucketName).Cursor()
+
+		// Seek past the given key. If it is a 'folder', skip all the keys
+		// within it by seeking to the first key which sorts after them.
+		seek := prefix + after
+		if strings.HasSuffix(after, "/") {
+			seek = seek[:len(seek)-1] + string('/'+1)
+		}
+
+		prefixBytes := []byte(prefix)
+		for k, _ := c.Seek([]byte(seek)); k != nil && bytes.HasPrefix(k, prefixBytes); k, _ = c.Next() {
+			key := strings.TrimPrefix(string(k), prefix)
+			if i := strings.Index(key, "/"); i != -1 {
+				key = key[:i+1]
+			}
+			if key <= after || (len(keys) > 0 && keys[len(keys)-1] == key) {
+				continue
+			}
+			if limit > 0 && len(keys) >= limit {
+				break
+			}
+			keys = append(keys, key)
+		}
+
+		return nil
+	})
+
+	return keys, err
+}
+
 // Transaction writes all the operations in the provided transaction to the bolt
 // file.
 func (f *FSM) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
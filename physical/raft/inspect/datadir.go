@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package inspect
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	bolt "github.com/hashicorp-forge/bbolt"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+	vaultraft "github.com/hashicorp/vault/physical/raft"
+	etcdbolt "go.etcd.io/bbolt"
+)
+
+const (
+	// The file and bucket names below match those of the raft storage
+	// backend.
+	databaseFilename = "vault.db"
+	raftDir          = "raft"
+	raftLogFilename  = "raft.db"
+	raftWalDir       = "wal"
+
+	// openTimeout is how long to wait for the lock of the databases, which
+	// is held while a server uses them.
+	openTimeout = time.Second
+)
+
+var (
+	dataBucketName   = []byte("data")
+	configBucketName = []byte("config")
+	latestIndexKey   = []byte("latest_indexes")
+	latestConfigKey  = []byte("latest_config")
+)
+
+// InspectDataDir reports on the raft data directory at the given path, the
+// path of the raft storage configuration. The databases are opened read-only,
+// and cannot be inspected while a server uses them.
+func InspectDataDir(path string, opts Options) (*Report, error) {
+	dbPath := filepath.Join(path, databaseFilename)
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the raft database: %w", err)
+	}
+
+	db, err := bolt.Open(dbPath, 0o600, &bolt.Options{
+		ReadOnly: true,
+		Timeout:  openTimeout,
+	})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("failed to open %q, it is likely in use by a Vault server", dbPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", dbPath, err)
+	}
+	defer db.Close()
+
+	i := newInspector(SourceDataDir, path, opts)
+	i.report.FSM.DatabaseSize = info.Size()
+
+	err = db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(configBucketName); b != nil {
+			if err := readConfig(b, &i.report.Raft); err != nil {
+				return err
+			}
+		}
+
+		b := tx.Bucket(dataBucketName)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			i.add(string(k), v)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", dbPath, err)
+	}
+
+	if err := readLogStore(filepath.Join(path, raftDir), &i.report.Raft); err != nil {
+		return nil, err
+	}
+
+	return i.finish(), nil
+}
+
+// readConfig reads the latest index and configuration applied to the FSM.
+func readConfig(b *bolt.Bucket, info *RaftInfo) error {
+	if val := b.Get(latestIndexKey); val != nil {
+		var latest vaultraft.IndexValue
+		if err := proto.Unmarshal(val, &latest); err != nil {
+			return fmt.Errorf("failed to decode the latest index: %w", err)
+		}
+		info.Term = latest.Term
+		info.Index = latest.Index
+	}
+
+	if val := b.Get(latestConfigKey); val != nil {
+		var latest vaultraft.ConfigurationValue
+		if err := proto.Unmarshal(val, &latest); err != nil {
+			return fmt.Errorf("failed to decode the latest configuration: %w", err)
+		}
+		for _, server := range latest.Servers {
+			info.Servers = append(info.Servers, ServerInfo{
+				ID:       server.Id,
+				Address:  server.Address,
+				Suffrage: raft.ServerSuffrage(server.Suffrage).String(),
+			})
+		}
+	}
+
+	return nil
+}
+
+// readLogStore reads the range of the raft log, when it is stored in BoltDB.
+func readLogStore(path string, info *RaftInfo) error {
+	if _, err := os.Stat(filepath.Join(path, raftWalDir)); err == nil {
+		info.LogStore = "raft-wal"
+	}
+
+	logPath := filepath.Join(path, raftLogFilename)
+	if _, err := os.Stat(logPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	info.LogStore = "boltdb"
+
+	store, err := raftboltdb.New(raftboltdb.Options{
+		Path: logPath,
+		BoltOptions: &etcdbolt.Options{
+			ReadOnly: true,
+			Timeout:  openTimeout,
+		},
+	})
+	if errors.Is(err, etcdbolt.ErrTimeout) {
+		return fmt.Errorf("failed to open %q, it is likely in use by a Vault server", logPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %w", logPath, err)
+	}
+	defer store.Close()
+
+	if info.FirstLogIndex, err = store.FirstIndex(); err != nil {
+		return fmt.Errorf("failed to read the first log index: %w", err)
+	}
+	if info.LastLogIndex, err = store.LastIndex(); err != nil {
+		return fmt.Errorf("failed to read the last log index: %w", err)
+	}
+	return nil
+}
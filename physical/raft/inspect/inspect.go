@@ -0,0 +1,296 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+// Package inspect reads raft snapshots and data directories offline, without
+// starting a server, to report on the data they hold for support and capacity
+// analysis.
+package inspect
+
+import (
+	"encoding/binary"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SourceSnapshot is the source of the reports of snapshot files.
+	SourceSnapshot = "snapshot"
+
+	// SourceDataDir is the source of the reports of data directories.
+	SourceDataDir = "data_dir"
+)
+
+const (
+	// The paths below match those of the vault package, they are not
+	// encrypted with the keyring.
+	keyringPath          = "core/keyring"
+	keyringUpgradePrefix = "core/upgrade/"
+
+	// barrierTermSize is the number of bytes of the key term prefixing the
+	// values encrypted by the barrier, followed by the version byte.
+	barrierTermSize = 4
+
+	// barrierMinSize is the size of the values encrypted by the barrier with
+	// an empty plaintext: the term, version, nonce and GCM tag.
+	barrierMinSize = barrierTermSize + 1 + 12 + 16
+)
+
+// unencryptedPaths are the paths of the entries written by Vault without the
+// barrier, such as the seal configuration.
+var unencryptedPaths = map[string]struct{}{
+	"core/hsm/barrier-unseal-keys": {},
+	"core/hsm/iv":                  {},
+	"core/lock":                    {},
+	"core/recovery-config":         {},
+	"core/recovery-key":            {},
+	"core/seal-config":             {},
+	"core/seal-gen-info":           {},
+}
+
+// Options configures the breakdown of the entries of the reports.
+type Options struct {
+	// Depth is the number of key segments the entries are grouped by into
+	// prefixes, 0 to group them by full key.
+	Depth int
+
+	// Filter limits the prefixes and entries reported to the keys with the
+	// given prefix. Statistics and keyring information cover all entries.
+	Filter string
+
+	// Top is the number of largest prefixes and entries reported, 0 to report
+	// all of them.
+	Top int
+}
+
+// Report is the result of the inspection of a snapshot or data directory.
+type Report struct {
+	Source          string        `json:"source"`
+	Path            string        `json:"path"`
+	Raft            RaftInfo      `json:"raft"`
+	FSM             FSMStats      `json:"fsm"`
+	LargestPrefixes []PrefixStats `json:"largest_prefixes"`
+	LargestEntries  []EntryStats  `json:"largest_entries"`
+	Keyring         KeyringInfo   `json:"keyring"`
+}
+
+// RaftInfo is the raft state of a snapshot or data directory.
+type RaftInfo struct {
+	SnapshotID    string       `json:"snapshot_id,omitempty"`
+	Index         uint64       `json:"index"`
+	Term          uint64       `json:"term"`
+	Servers       []ServerInfo `json:"servers"`
+	LogStore      string       `json:"log_store,omitempty"`
+	FirstLogIndex uint64       `json:"first_log_index,omitempty"`
+	LastLogIndex  uint64       `json:"last_log_index,omitempty"`
+}
+
+// ServerInfo is a server of the raft configuration.
+type ServerInfo struct {
+	ID       string `json:"id"`
+	Address  string `json:"address"`
+	Suffrage string `json:"suffrage"`
+}
+
+// FSMStats are the statistics of the entries of the FSM.
+type FSMStats struct {
+	Entries      int   `json:"entries"`
+	KeyBytes     int64 `json:"key_bytes"`
+	ValueBytes   int64 `json:"value_bytes"`
+	DatabaseSize int64 `json:"database_size,omitempty"`
+}
+
+// PrefixStats are the statistics of the entries of a prefix.
+type PrefixStats struct {
+	Prefix  string `json:"prefix"`
+	Entries int    `json:"entries"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// EntryStats is the size of an entry, key and value included.
+type EntryStats struct {
+	Key   string `json:"key"`
+	Bytes int    `json:"bytes"`
+}
+
+// KeyringInfo describes the barrier keyring generations the entries are
+// encrypted with. The keyring itself cannot be decrypted offline, so the
+// generations are those found in the encrypted entries.
+type KeyringInfo struct {
+	Present            bool        `json:"present"`
+	Size               int         `json:"size"`
+	LatestTerm         uint32      `json:"latest_term"`
+	Terms              []TermStats `json:"terms"`
+	UpgradeTerms       []uint32    `json:"upgrade_terms"`
+	UnencryptedEntries int         `json:"unencrypted_entries"`
+}
+
+// TermStats is the number of entries encrypted with a keyring term.
+type TermStats struct {
+	Term    uint32 `json:"term"`
+	Entries int    `json:"entries"`
+}
+
+// Inspect reports on the snapshot file or data directory at the given path,
+// depending on whether it is a directory.
+func Inspect(path string, opts Options) (*Report, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return InspectDataDir(path, opts)
+	}
+	return InspectSnapshotFile(path, opts)
+}
+
+// inspector accumulates the statistics of the entries of a report.
+type inspector struct {
+	opts     Options
+	report   *Report
+	prefixes map[string]*PrefixStats
+	terms    map[uint32]int
+}
+
+func newInspector(source, path string, opts Options) *inspector {
+	return &inspector{
+		opts: opts,
+		report: &Report{
+			Source:          source,
+			Path:            path,
+			Raft:            RaftInfo{Servers: []ServerInfo{}},
+			LargestPrefixes: []PrefixStats{},
+			LargestEntries:  []EntryStats{},
+			Keyring: KeyringInfo{
+				Terms:        []TermStats{},
+				UpgradeTerms: []uint32{},
+			},
+		},
+		prefixes: make(map[string]*PrefixStats),
+		terms:    make(map[uint32]int),
+	}
+}
+
+func (i *inspector) add(key string, value []byte) {
+	fsm := &i.report.FSM
+	fsm.Entries++
+	fsm.KeyBytes += int64(len(key))
+	fsm.ValueBytes += int64(len(value))
+
+	i.addKeyring(key, value)
+
+	if key == "" || !strings.HasPrefix(key, i.opts.Filter) {
+		return
+	}
+
+	split := strings.Split(key, "/")
+	depth := i.opts.Depth
+	if depth == 0 || depth > len(split) {
+		depth = len(split)
+	}
+	prefix := strings.Join(split[:depth], "/")
+	stats, ok := i.prefixes[prefix]
+	if !ok {
+		stats = &PrefixStats{Prefix: prefix}
+		i.prefixes[prefix] = stats
+	}
+	stats.Entries++
+	stats.Bytes += int64(len(key) + len(value))
+
+	// Only keep the largest entries to bound the memory used by large
+	// data sets, trimming them when they grow to twice the limit.
+	entries := append(i.report.LargestEntries, EntryStats{Key: key, Bytes: len(key) + len(value)})
+	if i.opts.Top > 0 && len(entries) >= 2*i.opts.Top {
+		entries = topEntries(entries, i.opts.Top)
+	}
+	i.report.LargestEntries = entries
+}
+
+func (i *inspector) addKeyring(key string, value []byte) {
+	keyring := &i.report.Keyring
+	switch {
+	case key == keyringPath:
+		// The keyring is encrypted with the root key, not a keyring term
+		keyring.Present = true
+		keyring.Size = len(value)
+		return
+	case strings.HasPrefix(key, keyringUpgradePrefix):
+		if term, err := strconv.ParseUint(strings.TrimPrefix(key, keyringUpgradePrefix), 10, 32); err == nil {
+			keyring.UpgradeTerms = append(keyring.UpgradeTerms, uint32(term))
+		}
+	}
+
+	if _, ok := unencryptedPaths[key]; ok {
+		keyring.UnencryptedEntries++
+		return
+	}
+	term, ok := barrierTerm(value)
+	if !ok {
+		keyring.UnencryptedEntries++
+		return
+	}
+	i.terms[term]++
+	if term > keyring.LatestTerm {
+		keyring.LatestTerm = term
+	}
+}
+
+// finish sorts and trims the statistics, and returns the report.
+func (i *inspector) finish() *Report {
+	prefixes := make([]PrefixStats, 0, len(i.prefixes))
+	for _, stats := range i.prefixes {
+		prefixes = append(prefixes, *stats)
+	}
+	sort.Slice(prefixes, func(a, b int) bool {
+		if prefixes[a].Bytes == prefixes[b].Bytes {
+			return prefixes[a].Prefix < prefixes[b].Prefix
+		}
+		return prefixes[a].Bytes > prefixes[b].Bytes
+	})
+	if i.opts.Top > 0 && len(prefixes) > i.opts.Top {
+		prefixes = prefixes[:i.opts.Top]
+	}
+	i.report.LargestPrefixes = prefixes
+	i.report.LargestEntries = topEntries(i.report.LargestEntries, i.opts.Top)
+
+	keyring := &i.report.Keyring
+	for term, entries := range i.terms {
+		keyring.Terms = append(keyring.Terms, TermStats{Term: term, Entries: entries})
+	}
+	sort.Slice(keyring.Terms, func(a, b int) bool { return keyring.Terms[a].Term < keyring.Terms[b].Term })
+	sort.Slice(keyring.UpgradeTerms, func(a, b int) bool { return keyring.UpgradeTerms[a] < keyring.UpgradeTerms[b] })
+
+	return i.report
+}
+
+// topEntries returns the top largest entries, sorted by size.
+func topEntries(entries []EntryStats, top int) []EntryStats {
+	sort.Slice(entries, func(a, b int) bool {
+		if entries[a].Bytes == entries[b].Bytes {
+			return entries[a].Key < entries[b].Key
+		}
+		return entries[a].Bytes > entries[b].Bytes
+	})
+	if top > 0 && len(entries) > top {
+		entries = entries[:top]
+	}
+	return entries
+}
+
+// barrierTerm returns the keyring term of a value encrypted by the barrier,
+// and false if the value is not in the format of the barrier.
+func barrierTerm(value []byte) (uint32, bool) {
+	if len(value) < barrierMinSize {
+		return 0, false
+	}
+	// The versions of the AES-GCM barrier encryption
+	if version := value[barrierTermSize]; version != 0x1 && version != 0x2 {
+		return 0, false
+	}
+	term := binary.BigEndian.Uint32(value[:barrierTermSize])
+	if term == 0 {
+		return 0, false
+	}
+	return term, true
+}
@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/stretchr/testify/require"
+)
+
+// barrierValue returns a value in the format of the barrier, encrypted with the
+// given keyring term.
+func barrierValue(term uint32, size int) []byte {
+	value := make([]byte, barrierMinSize+size)
+	binary.BigEndian.PutUint32(value, term)
+	value[barrierTermSize] = 0x2
+	return value
+}
+
+func writeEntries(t *testing.T, b *raft.RaftBackend) {
+	t.Helper()
+
+	entries := []*physical.Entry{
+		{Key: "core/keyring", Value: barrierValue(1, 100)},
+		{Key: "core/upgrade/1", Value: barrierValue(1, 10)},
+		{Key: "core/seal-config", Value: []byte(`{"type":"shamir"}`)},
+		{Key: "logical/large/big", Value: barrierValue(2, 4096)},
+	}
+	for i := 0; i < 10; i++ {
+		entries = append(entries,
+			&physical.Entry{Key: fmt.Sprintf("logical/small/%d", i), Value: barrierValue(1, 10)},
+			&physical.Entry{Key: fmt.Sprintf("sys/token/id/%d", i), Value: barrierValue(2, 10)},
+		)
+	}
+	for _, entry := range entries {
+		require.NoError(t, b.Put(context.Background(), entry))
+	}
+}
+
+func checkReport(t *testing.T, report *Report) {
+	t.Helper()
+
+	require.Equal(t, 24, report.FSM.Entries)
+	require.Len(t, report.Raft.Servers, 1)
+	require.Equal(t, "Voter", report.Raft.Servers[0].Suffrage)
+	require.NotZero(t, report.Raft.Index)
+	require.NotZero(t, report.Raft.Term)
+
+	require.Len(t, report.LargestPrefixes, 2)
+	require.Equal(t, "logical/large", report.LargestPrefixes[0].Prefix)
+	require.Equal(t, 1, report.LargestPrefixes[0].Entries)
+	require.Equal(t, "logical/small", report.LargestPrefixes[1].Prefix)
+	require.Equal(t, 10, report.LargestPrefixes[1].Entries)
+
+	require.Len(t, report.LargestEntries, 2)
+	require.Equal(t, "logical/large/big", report.LargestEntries[0].Key)
+	require.Equal(t, len("logical/large/big")+barrierMinSize+4096, report.LargestEntries[0].Bytes)
+
+	require.Equal(t, KeyringInfo{
+		Present:            true,
+		Size:               barrierMinSize + 100,
+		LatestTerm:         2,
+		Terms:              []TermStats{{Term: 1, Entries: 11}, {Term: 2, Entries: 11}},
+		UpgradeTerms:       []uint32{1},
+		UnencryptedEntries: 1,
+	}, report.Keyring)
+}
+
+func TestInspect(t *testing.T) {
+	b, dir := raft.GetRaft(t, true, false)
+	writeEntries(t, b)
+
+	opts := Options{Depth: 2, Top: 2}
+
+	t.Run("snapshot", func(t *testing.T) {
+		var snap bytes.Buffer
+		require.NoError(t, b.Snapshot(&snap, nil))
+
+		report, err := InspectSnapshot(&snap, "raft.snap", opts)
+		require.NoError(t, err)
+		require.Equal(t, SourceSnapshot, report.Source)
+		require.Equal(t, "bolt-snapshot", report.Raft.SnapshotID)
+		checkReport(t, report)
+	})
+
+	t.Run("in use", func(t *testing.T) {
+		_, err := Inspect(dir, opts)
+		require.ErrorContains(t, err, "it is likely in use by a Vault server")
+	})
+
+	require.NoError(t, b.TeardownCluster(nil))
+	require.NoError(t, b.Close())
+
+	t.Run("data dir", func(t *testing.T) {
+		report, err := Inspect(dir, opts)
+		require.NoError(t, err)
+		require.Equal(t, SourceDataDir, report.Source)
+		require.Equal(t, "boltdb", report.Raft.LogStore)
+		require.NotZero(t, report.Raft.LastLogIndex)
+		require.NotZero(t, report.FSM.DatabaseSize)
+		checkReport(t, report)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		report, err := Inspect(dir, Options{Depth: 0, Filter: "logical/small/"})
+		require.NoError(t, err)
+		require.Len(t, report.LargestPrefixes, 10)
+		require.Len(t, report.LargestEntries, 10)
+		require.Equal(t, 24, report.FSM.Entries)
+	})
+
+	t.Run("invalid snapshot", func(t *testing.T) {
+		_, err := InspectSnapshot(bytes.NewReader([]byte("not a snapshot")), "raft.snap", opts)
+		require.ErrorContains(t, err, "failed to decompress snapshot")
+	})
+}
+
+func TestBarrierTerm(t *testing.T) {
+	term, ok := barrierTerm(barrierValue(42, 0))
+	require.True(t, ok)
+	require.Equal(t, uint32(42), term)
+
+	_, ok = barrierTerm(barrierValue(0, 10))
+	require.False(t, ok)
+
+	_, ok = barrierTerm(barrierValue(1, 0)[:barrierMinSize-1])
+	require.False(t, ok)
+
+	_, ok = barrierTerm([]byte(`{"type":"shamir","secret_shares":5}`))
+	require.False(t, ok)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package inspect
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/raft"
+	protoio "github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/sdk/plugin/pb"
+)
+
+// InspectSnapshotFile reports on the snapshot file at the given path, as saved
+// with the sys/storage/raft/snapshot endpoint.
+func InspectSnapshotFile(path string, opts Options) (*Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	return InspectSnapshot(f, path, opts)
+}
+
+// InspectSnapshot reports on the snapshot read from in, checking its
+// integrity. The path is only used to label the report.
+func InspectSnapshot(in io.Reader, path string, opts Options) (*Report, error) {
+	decomp, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+	}
+	defer decomp.Close()
+
+	i := newInspector(SourceSnapshot, path, opts)
+
+	sums := make(map[string][]byte)
+	var meta raft.SnapshotMeta
+	var sumsFile bytes.Buffer
+	archive := tar.NewReader(decomp)
+	for {
+		hdr, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot: %w", err)
+		}
+
+		h := sha256.New()
+		switch hdr.Name {
+		case "meta.json":
+			buf, err := io.ReadAll(io.TeeReader(archive, h))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read snapshot metadata: %w", err)
+			}
+			if err := json.Unmarshal(buf, &meta); err != nil {
+				return nil, fmt.Errorf("failed to decode snapshot metadata: %w", err)
+			}
+		case "state.bin":
+			if err := readState(io.TeeReader(archive, h), i); err != nil {
+				return nil, fmt.Errorf("failed to read snapshot state: %w", err)
+			}
+		case "SHA256SUMS":
+			if _, err := io.CopyN(&sumsFile, archive, 10000); err != nil && err != io.EOF {
+				return nil, fmt.Errorf("failed to read snapshot hashes: %w", err)
+			}
+			continue
+		case "SHA256SUMS.sealed":
+			continue
+		default:
+			return nil, fmt.Errorf("unexpected file %q in snapshot", hdr.Name)
+		}
+		sums[hdr.Name] = h.Sum(nil)
+	}
+
+	// The gzip stream is only verified once it is fully read
+	if _, err := io.Copy(io.Discard, decomp); err != nil {
+		return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+	}
+	if err := verifySums(&sumsFile, sums); err != nil {
+		return nil, fmt.Errorf("failed checking integrity of snapshot: %w", err)
+	}
+
+	i.report.Raft.SnapshotID = meta.ID
+	i.report.Raft.Index = meta.Index
+	i.report.Raft.Term = meta.Term
+	for _, server := range meta.Configuration.Servers {
+		i.report.Raft.Servers = append(i.report.Raft.Servers, ServerInfo{
+			ID:       string(server.ID),
+			Address:  string(server.Address),
+			Suffrage: server.Suffrage.String(),
+		})
+	}
+
+	return i.finish(), nil
+}
+
+// readState reads the entries of the FSM from the state of a snapshot.
+func readState(r io.Reader, i *inspector) error {
+	protoReader := protoio.NewDelimitedReader(r, math.MaxInt32)
+	for {
+		entry := new(pb.StorageEntry)
+		if err := protoReader.ReadMsg(entry); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		i.add(entry.Key, entry.Value)
+	}
+}
+
+// verifySums checks the hashes of the files of a snapshot against its
+// SHA256SUMS file.
+func verifySums(r io.Reader, sums map[string][]byte) error {
+	if _, ok := sums["meta.json"]; !ok {
+		return errors.New("snapshot is missing meta.json")
+	}
+	if _, ok := sums["state.bin"]; !ok {
+		return errors.New("snapshot is missing state.bin")
+	}
+
+	seen := make(map[string]struct{}, len(sums))
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		sum, file, ok := strings.Cut(s.Text(), "  ")
+		if !ok {
+			return fmt.Errorf("invalid hash line %q", s.Text())
+		}
+		expected, err := hex.DecodeString(sum)
+		if err != nil {
+			return fmt.Errorf("invalid hash for %q: %w", file, err)
+		}
+		actual, ok := sums[file]
+		if !ok {
+			return fmt.Errorf("file missing for %q", file)
+		}
+		if !bytes.Equal(expected, actual) {
+			return fmt.Errorf("hash check failed for %q", file)
+		}
+		seen[file] = struct{}{}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	for file := range sums {
+		if _, ok := seen[file]; !ok {
+			return fmt.Errorf("list missing hash for %q", file)
+		}
+	}
+	return nil
+}
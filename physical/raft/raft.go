@@ -81,6 +81,7 @@ var getMmapFlags = func(string) int { return 0 }
 // Verify RaftBackend satisfies the correct interfaces
 var (
 	_ physical.Backend             = (*RaftBackend)(nil)
+	_ physical.PaginatedBackend    = (*RaftBackend)(nil)
 	_ physical.Transactional       = (*RaftBackend)(nil)
 	_ physical.TransactionalLimits = (*RaftBackend)(nil)
 	_ physical.HABackend           = (*RaftBackend)(nil)
@@ -1454,6 +1455,79 @@ func (b *RaftBackend) RemovePeer(ctx context.Context, peerID string) error {
 	return b.autopilot.RemoveServer(raft.ServerID(peerID))
 }
 
+// QuorumProjection describes the voters and quorum of the raft cluster after a
+// membership change.
+type QuorumProjection struct {
+	Voters           int  `json:"voters" mapstructure:"voters"`
+	HealthyVoters    int  `json:"healthy_voters" mapstructure:"healthy_voters"`
+	Quorum           int  `json:"quorum" mapstructure:"quorum"`
+	FailureTolerance int  `json:"failure_tolerance" mapstructure:"failure_tolerance"`
+	Safe             bool `json:"safe" mapstructure:"safe"`
+}
+
+// ProjectQuorum returns the quorum of the raft cluster after the given peer is
+// added as a voter, or removed if add is false. The health of voters is taken
+// from autopilot; if autopilot is disabled all voters are considered healthy.
+func (b *RaftBackend) ProjectQuorum(ctx context.Context, peerID string, add bool) (*QuorumProjection, error) {
+	config, err := b.GetConfiguration(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := b.GetAutopilotServerState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return projectQuorum(config, state, peerID, add), nil
+}
+
+// projectQuorum computes the quorum projection of a membership change. The
+// change is safe if it does not affect the voters, or if enough healthy voters
+// remain to form a quorum afterwards. A peer being added is considered
+// healthy since it is reachable.
+func projectQuorum(config *RaftConfigurationResponse, state *AutopilotState, peerID string, add bool) *QuorumProjection {
+	healthy := func(id string) bool {
+		if state == nil {
+			return true
+		}
+		server, ok := state.Servers[id]
+		return ok && server.Healthy
+	}
+
+	var p QuorumProjection
+	var isVoter bool
+	for _, server := range config.Servers {
+		if !server.Voter {
+			continue
+		}
+		if server.NodeID == peerID {
+			isVoter = true
+			if !add {
+				continue
+			}
+		}
+		p.Voters++
+		if healthy(server.NodeID) {
+			p.HealthyVoters++
+		}
+	}
+	if add && !isVoter {
+		p.Voters++
+		p.HealthyVoters++
+	}
+
+	p.Quorum = p.Voters/2 + 1
+	if p.HealthyVoters > p.Quorum {
+		p.FailureTolerance = p.HealthyVoters - p.Quorum
+	}
+
+	changed := add != isVoter
+	p.Safe = !changed || p.HealthyVoters >= p.Quorum
+
+	return &p
+}
+
 // GetConfigurationOffline is used to read the stale, last known raft
 // configuration to this node. It accesses the last state written into the
 // FSM. When a server is online use GetConfiguration instead.
@@ -1779,6 +1853,28 @@ func (b *RaftBackend) List(ctx context.Context, prefix string) ([]string, error)
 	return b.fsm.List(ctx, prefix)
 }
 
+// ListPage is used to list a page of the keys under a given prefix, up to the
+// next prefix, which sort after the given key.
+func (b *RaftBackend) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	defer metrics.MeasureSince([]string{"raft-storage", "list_page"}, time.Now())
+	if b.fsm == nil {
+		return nil, errors.New("raft: fsm not configured")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	b.permitPool.Acquire()
+	defer b.permitPool.Release()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return b.fsm.ListPage(ctx, prefix, after, limit)
+}
+
 // Transaction applies all the given operations into a single log and
 // applies it.
 func (b *RaftBackend) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
@@ -1913,6 +2009,18 @@ func (b *RaftBackend) applyLog(ctx context.Context, command *LogData) error {
 		return errors.New("could not apply data")
 	}
 
+	// Record the index of the write in the index state of the request, so
+	// clients can require it on subsequent reads.
+	if state := logical.IndexStateFromContext(ctx); state != nil {
+		index := applyFuture.Index()
+		for {
+			current := atomic.LoadUint64(&state.LocalIndex)
+			if index <= current || atomic.CompareAndSwapUint64(&state.LocalIndex, current, index) {
+				break
+			}
+		}
+	}
+
 	// populate command with our results
 	if fsmar.EntrySlice == nil {
 		return errors.New("entries on FSM response were empty")
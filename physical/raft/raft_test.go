@@ -488,6 +488,47 @@ func TestRaft_ParseNonVoter(t *testing.T) {
 	}
 }
 
+func TestRaft_ProjectQuorum(t *testing.T) {
+	config := &RaftConfigurationResponse{
+		Servers: []*RaftServer{
+			{NodeID: "node1", Voter: true, Leader: true},
+			{NodeID: "node2", Voter: true},
+			{NodeID: "node3", Voter: true},
+			{NodeID: "node4", Voter: true},
+			{NodeID: "node5", Voter: true},
+			{NodeID: "node6"},
+		},
+	}
+	state := &AutopilotState{
+		Servers: map[string]*AutopilotServer{
+			"node1": {Healthy: true},
+			"node2": {Healthy: true},
+			"node3": {Healthy: true},
+			"node4": {Healthy: false},
+			"node6": {Healthy: true},
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		state  *AutopilotState
+		peerID string
+		add    bool
+		want   QuorumProjection
+	}{
+		"remove unhealthy voter": {state, "node4", false, QuorumProjection{Voters: 4, HealthyVoters: 3, Quorum: 3, Safe: true}},
+		"remove healthy voter":   {state, "node2", false, QuorumProjection{Voters: 4, HealthyVoters: 2, Quorum: 3, Safe: false}},
+		"remove non-voter":       {state, "node6", false, QuorumProjection{Voters: 5, HealthyVoters: 3, Quorum: 3, Safe: true}},
+		"remove unknown peer":    {state, "node7", false, QuorumProjection{Voters: 5, HealthyVoters: 3, Quorum: 3, Safe: true}},
+		"add voter":              {state, "node7", true, QuorumProjection{Voters: 6, HealthyVoters: 4, Quorum: 4, Safe: true}},
+		"promote non-voter":      {state, "node6", true, QuorumProjection{Voters: 6, HealthyVoters: 4, Quorum: 4, Safe: true}},
+		"autopilot disabled":     {nil, "node2", false, QuorumProjection{Voters: 4, HealthyVoters: 4, Quorum: 3, FailureTolerance: 1, Safe: true}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, &tc.want, projectQuorum(config, tc.state, tc.peerID, tc.add))
+		})
+	}
+}
+
 func TestRaft_Backend_LargeKey(t *testing.T) {
 	t.Parallel()
 
@@ -720,6 +761,19 @@ func TestRaft_Backend_ListPrefix(t *testing.T) {
 	})
 }
 
+func TestRaft_Backend_ListPage(t *testing.T) {
+	t.Parallel()
+	testBothRaftBackends(t, func(useRaftWal string) {
+		conf := map[string]string{
+			"trailing_logs": "100",
+			"raft_wal":      useRaftWal,
+		}
+
+		b, _ := GetRaftWithConfig(t, true, true, conf)
+		physical.ExerciseBackend_ListPage(t, b)
+	})
+}
+
 func TestRaft_TransactionalBackend(t *testing.T) {
 	t.Parallel()
 	testBothRaftBackends(t, func(useRaftWal string) {
@@ -91,6 +91,12 @@ type Backend struct {
 	WALRollback       WALRollbackFunc
 	WALRollbackMinAge time.Duration
 
+	// RotateCredential is called when a root credential the backend
+	// registered with the rotation manager, using the system view's
+	// RegisterRotationJob, is due for rotation. The request's path is the
+	// path the credential was registered with.
+	RotateCredential RotateCredentialFunc
+
 	// Clean is called on unload to clean up e.g any existing connections
 	// to the backend, if required.
 	Clean CleanupFunc
@@ -129,6 +135,9 @@ type ExistenceFunc func(context.Context, *logical.Request, *FieldData) (bool, er
 // WALRollbackFunc is the callback for rollbacks.
 type WALRollbackFunc func(context.Context, *logical.Request, string, interface{}) error
 
+// RotateCredentialFunc is the callback for scheduled root credential rotations.
+type RotateCredentialFunc func(context.Context, *logical.Request) error
+
 // CleanupFunc is the callback for backend unload.
 type CleanupFunc func(context.Context)
 
@@ -217,6 +226,8 @@ func (b *Backend) HandleRequest(ctx context.Context, req *logical.Request) (*log
 		return b.handleRevokeRenew(ctx, req)
 	case logical.RollbackOperation:
 		return b.handleRollback(ctx, req)
+	case logical.RotationOperation:
+		return b.handleRotateCredential(ctx, req)
 	}
 
 	// If the path is empty and it is a help operation, handle that.
@@ -662,6 +673,14 @@ func (b *Backend) handleRollback(ctx context.Context, req *logical.Request) (*lo
 	return resp, merr.ErrorOrNil()
 }
 
+func (b *Backend) handleRotateCredential(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+	if b.RotateCredential == nil {
+		return nil, logical.ErrUnsupportedOperation
+	}
+
+	return nil, b.RotateCredential(ctx, req)
+}
+
 func (b *Backend) handleAuthRenew(ctx context.Context, req *logical.Request) (*logical.Response, error) {
 	if b.AuthRenew == nil {
 		return logical.ErrorResponse("this auth type doesn't support renew"), nil
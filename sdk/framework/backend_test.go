@@ -530,6 +530,36 @@ func TestBackendHandleRequest_rollbackMinAge(t *testing.T) {
 	}
 }
 
+func TestBackendHandleRequest_rotateCredential(t *testing.T) {
+	var rotated string
+	b := &Backend{
+		RotateCredential: func(_ context.Context, req *logical.Request) error {
+			rotated = req.Path
+			return nil
+		},
+	}
+
+	_, err := b.HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RotationOperation,
+		Path:      "config/root",
+		Storage:   new(logical.InmemStorage),
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if rotated != "config/root" {
+		t.Fatalf("bad: %q", rotated)
+	}
+
+	_, err = (&Backend{}).HandleRequest(context.Background(), &logical.Request{
+		Operation: logical.RotationOperation,
+		Path:      "config/root",
+	})
+	if err != logical.ErrUnsupportedOperation {
+		t.Fatalf("expected unsupported operation, got: %v", err)
+	}
+}
+
 func TestBackendHandleRequest_unsupportedOperation(t *testing.T) {
 	callback := func(ctx context.Context, req *logical.Request, data *FieldData) (*logical.Response, error) {
 		return &logical.Response{
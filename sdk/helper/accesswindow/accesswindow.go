@@ -0,0 +1,169 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package accesswindow parses and evaluates the time-of-day and day-of-week
+// windows during which a token may be used.
+//
+// A window is an optional set of days followed by a time range in 24-hour
+// format, e.g. "09:00-17:00", "Mon-Fri 09:00-17:00" or "Sat,Sun 10:00-14:00".
+// Windows without days apply every day. A time range whose end is before its
+// start, e.g. "Mon-Fri 22:00-06:00", spans midnight and belongs to the day it
+// starts on.
+package accesswindow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Window is a time range on a set of days of the week.
+type Window struct {
+	days [7]bool
+
+	// start and end are minutes since midnight
+	start int
+	end   int
+}
+
+// Parse parses a single window.
+func Parse(raw string) (*Window, error) {
+	fields := strings.Fields(raw)
+
+	w := new(Window)
+	var timeRange string
+	switch len(fields) {
+	case 1:
+		for i := range w.days {
+			w.days[i] = true
+		}
+		timeRange = fields[0]
+	case 2:
+		if err := w.parseDays(fields[0]); err != nil {
+			return nil, fmt.Errorf("invalid access window %q: %w", raw, err)
+		}
+		timeRange = fields[1]
+	default:
+		return nil, fmt.Errorf("invalid access window %q: expected optional days and a time range, e.g. \"Mon-Fri 09:00-17:00\"", raw)
+	}
+
+	start, end, ok := strings.Cut(timeRange, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid access window %q: invalid time range %q", raw, timeRange)
+	}
+	var err error
+	if w.start, err = parseTime(start); err != nil {
+		return nil, fmt.Errorf("invalid access window %q: %w", raw, err)
+	}
+	if w.end, err = parseTime(end); err != nil {
+		return nil, fmt.Errorf("invalid access window %q: %w", raw, err)
+	}
+	if w.start == w.end {
+		return nil, fmt.Errorf("invalid access window %q: time range is empty", raw)
+	}
+	return w, nil
+}
+
+func (w *Window) parseDays(raw string) error {
+	for _, part := range strings.Split(raw, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		from, ok := weekdays[strings.ToLower(first)]
+		if !ok {
+			return fmt.Errorf("invalid day %q", first)
+		}
+		to := from
+		if isRange {
+			if to, ok = weekdays[strings.ToLower(last)]; !ok {
+				return fmt.Errorf("invalid day %q", last)
+			}
+		}
+		for day := from; ; day = (day + 1) % 7 {
+			w.days[day] = true
+			if day == to {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func parseTime(raw string) (int, error) {
+	if raw == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", raw)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains returns whether t, in the location the window applies to, falls
+// within the window.
+func (w *Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return w.days[t.Weekday()] && minute >= w.start && minute < w.end
+	}
+
+	// The window spans midnight
+	if minute >= w.start {
+		return w.days[t.Weekday()]
+	}
+	return minute < w.end && w.days[(t.Weekday()+6)%7]
+}
+
+// Set is a set of windows in a time zone.
+type Set struct {
+	windows  []*Window
+	location *time.Location
+}
+
+// ParseSet parses the given windows in the given IANA time zone, which
+// defaults to UTC. It returns nil if there are no windows.
+func ParseSet(windows []string, timezone string) (*Set, error) {
+	if len(windows) == 0 {
+		return nil, nil
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access window time zone %q: %w", timezone, err)
+	}
+
+	s := &Set{location: location}
+	for _, raw := range windows {
+		w, err := Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		s.windows = append(s.windows, w)
+	}
+	return s, nil
+}
+
+// Contains returns whether t falls within any of the windows of the set. A nil
+// set contains all times.
+func (s *Set) Contains(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+
+	t = t.In(s.location)
+	for _, w := range s.windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package accesswindow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Invalid(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"09:00",
+		"9am-5pm",
+		"09:00-09:00",
+		"Mon-Fri",
+		"Funday 09:00-17:00",
+		"Mon-Fri 09:00-17:00 extra",
+		"25:00-26:00",
+	} {
+		if _, err := Parse(raw); err == nil {
+			t.Errorf("expected an error parsing %q", raw)
+		}
+	}
+}
+
+func TestWindow_Contains(t *testing.T) {
+	// 2024-01-01 is a Monday
+	at := func(day int, clock string) time.Time {
+		c, err := time.Parse("15:04", clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return time.Date(2024, 1, day, c.Hour(), c.Minute(), 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		window   string
+		time     time.Time
+		expected bool
+	}{
+		{"09:00-17:00", at(7, "12:00"), true},
+		{"09:00-17:00", at(1, "17:00"), false},
+		{"Mon-Fri 09:00-17:00", at(1, "09:00"), true},
+		{"Mon-Fri 09:00-17:00", at(6, "12:00"), false},
+		{"Sat,Sun 10:00-14:00", at(7, "13:59"), true},
+		{"Sat,Sun 10:00-14:00", at(5, "12:00"), false},
+		{"Fri-Mon 00:00-24:00", at(7, "23:59"), true},
+		{"Fri-Mon 00:00-24:00", at(3, "12:00"), false},
+		// Spans midnight, so Friday night into Saturday morning is allowed
+		// but Sunday morning is not
+		{"Mon-Fri 22:00-06:00", at(5, "23:00"), true},
+		{"Mon-Fri 22:00-06:00", at(6, "05:59"), true},
+		{"Mon-Fri 22:00-06:00", at(7, "05:00"), false},
+		{"mon 22:00-06:00", at(1, "21:59"), false},
+	}
+	for _, tc := range cases {
+		w, err := Parse(tc.window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := w.Contains(tc.time); actual != tc.expected {
+			t.Errorf("window %q, time %s: expected %t, got %t", tc.window, tc.time.Format(time.RFC1123), tc.expected, actual)
+		}
+	}
+}
+
+func TestSet_Contains(t *testing.T) {
+	s, err := ParseSet([]string{"Mon-Fri 09:00-12:00", "Mon-Fri 13:00-17:00"}, "America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 15:00 UTC on a Monday during standard time is 10:00 in New York
+	if !s.Contains(time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC)) {
+		t.Fatal("expected the time to be within the windows")
+	}
+	// 17:30 UTC is 12:30 in New York, between the windows
+	if s.Contains(time.Date(2024, 1, 1, 17, 30, 0, 0, time.UTC)) {
+		t.Fatal("expected the time to be outside the windows")
+	}
+
+	var empty *Set
+	if !empty.Contains(time.Now()) {
+		t.Fatal("expected a nil set to contain all times")
+	}
+
+	if _, err := ParseSet([]string{"09:00-17:00"}, "Mars/Olympus_Mons"); err == nil {
+		t.Fatal("expected an error for an invalid time zone")
+	}
+}
@@ -12,6 +12,7 @@ import "fmt"
 var PluginRuntimeTypes = []PluginRuntimeType{
 	PluginRuntimeTypeUnsupported,
 	PluginRuntimeTypeContainer,
+	PluginRuntimeTypeProcess,
 }
 
 type PluginRuntimeType uint32
@@ -22,12 +23,15 @@ const (
 
 	PluginRuntimeTypeUnsupported PluginRuntimeType = iota
 	PluginRuntimeTypeContainer
+	PluginRuntimeTypeProcess
 )
 
 func (r PluginRuntimeType) String() string {
 	switch r {
 	case PluginRuntimeTypeContainer:
 		return "container"
+	case PluginRuntimeTypeProcess:
+		return "process"
 	default:
 		return "unsupported"
 	}
@@ -37,6 +41,8 @@ func ParsePluginRuntimeType(PluginRuntimeType string) (PluginRuntimeType, error)
 	switch PluginRuntimeType {
 	case "container":
 		return PluginRuntimeTypeContainer, nil
+	case "process":
+		return PluginRuntimeTypeProcess, nil
 	default:
 		return PluginRuntimeTypeUnsupported, fmt.Errorf("%q is not a supported plugin runtime type", PluginRuntimeType)
 	}
@@ -0,0 +1,184 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package cryptopolicy defines the server-wide crypto policies which restrict
+// the algorithms Vault and its plugins may use.
+package cryptopolicy
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// None places no restrictions on algorithms.
+	None = ""
+
+	// FIPS1403 restricts algorithms to those approved for use in FIPS 140-3
+	// mode.
+	FIPS1403 = "fips-140-3"
+)
+
+const (
+	ComponentTransit        = "transit key type"
+	ComponentPKI            = "PKI key"
+	ComponentTLSVersion     = "TLS version"
+	ComponentTLSCipherSuite = "TLS cipher suite"
+	ComponentSeal           = "seal type"
+)
+
+// ErrNotAllowed is wrapped by every *Error.
+var ErrNotAllowed = errors.New("not allowed by crypto policy")
+
+// Error is returned when a crypto policy rejects an algorithm.
+type Error struct {
+	// Policy is the name of the crypto policy.
+	Policy string
+
+	// Component is the kind of algorithm that was rejected, e.g.
+	// ComponentTransit.
+	Component string
+
+	// Algorithm is the rejected algorithm.
+	Algorithm string
+
+	// Allowed are the algorithms of the component the policy allows.
+	Allowed []string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("crypto policy %q does not allow %s %q, allowed values are: %s", e.Policy, e.Component, e.Algorithm, strings.Join(e.Allowed, ", "))
+}
+
+func (e *Error) Unwrap() error {
+	return ErrNotAllowed
+}
+
+type policy struct {
+	transitKeyTypes []string
+
+	// pkiKeys are key types, suffixed with the key size for RSA and ECDSA
+	// keys
+	pkiKeys []string
+
+	tlsVersions     []string
+	tlsCipherSuites []uint16
+	sealTypes       []string
+}
+
+var policies = map[string]*policy{
+	FIPS1403: {
+		transitKeyTypes: []string{
+			"aes128-gcm96", "aes256-gcm96",
+			"ecdsa-p256", "ecdsa-p384", "ecdsa-p521",
+			"ed25519",
+			"rsa-2048", "rsa-3072", "rsa-4096",
+			"hmac",
+		},
+		pkiKeys: []string{
+			"rsa-2048", "rsa-3072", "rsa-4096", "rsa-8192",
+			"ec-256", "ec-384", "ec-521",
+			"ed25519",
+		},
+		tlsVersions: []string{"tls12", "tls13"},
+		tlsCipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_AES_128_GCM_SHA256,
+			tls.TLS_AES_256_GCM_SHA384,
+		},
+		sealTypes: []string{
+			"shamir", "awskms", "azurekeyvault", "gcpckms", "kmip", "ocikms", "pkcs11", "transit",
+		},
+	},
+}
+
+// Validate returns an error if name is not a known crypto policy.
+func Validate(name string) error {
+	if name == None {
+		return nil
+	}
+	if _, ok := policies[name]; !ok {
+		names := make([]string, 0, len(policies))
+		for name := range policies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown crypto policy %q, valid policies are: %s", name, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func check(name, component, algorithm string, allowed func(*policy) []string) error {
+	p, ok := policies[name]
+	if !ok {
+		return nil
+	}
+	values := allowed(p)
+	for _, value := range values {
+		if value == algorithm {
+			return nil
+		}
+	}
+	return &Error{
+		Policy:    name,
+		Component: component,
+		Algorithm: algorithm,
+		Allowed:   values,
+	}
+}
+
+// CheckTransitKeyType returns an *Error if the policy does not allow transit
+// keys of the given type, e.g. "aes256-gcm96".
+func CheckTransitKeyType(name, keyType string) error {
+	return check(name, ComponentTransit, keyType, func(p *policy) []string { return p.transitKeyTypes })
+}
+
+// CheckPKIKey returns an *Error if the policy does not allow PKI keys of the
+// given type ("rsa", "ec" or "ed25519") and size in bits.
+func CheckPKIKey(name, keyType string, keyBits int) error {
+	key := keyType
+	if keyType == "rsa" || keyType == "ec" {
+		key = fmt.Sprintf("%s-%d", keyType, keyBits)
+	}
+	return check(name, ComponentPKI, key, func(p *policy) []string { return p.pkiKeys })
+}
+
+// CheckTLSVersion returns an *Error if the policy does not allow the given TLS
+// version, e.g. "tls12".
+func CheckTLSVersion(name, version string) error {
+	return check(name, ComponentTLSVersion, version, func(p *policy) []string { return p.tlsVersions })
+}
+
+// CheckTLSCipherSuite returns an *Error if the policy does not allow the given
+// TLS cipher suite.
+func CheckTLSCipherSuite(name string, suite uint16) error {
+	return check(name, ComponentTLSCipherSuite, tls.CipherSuiteName(suite), func(p *policy) []string {
+		names := make([]string, 0, len(p.tlsCipherSuites))
+		for _, suite := range p.tlsCipherSuites {
+			names = append(names, tls.CipherSuiteName(suite))
+		}
+		return names
+	})
+}
+
+// TLSCipherSuites returns the TLS cipher suites the policy allows, or nil if
+// the policy does not restrict them.
+func TLSCipherSuites(name string) []uint16 {
+	p, ok := policies[name]
+	if !ok {
+		return nil
+	}
+	return append([]uint16(nil), p.tlsCipherSuites...)
+}
+
+// CheckSealType returns an *Error if the policy does not allow seals of the
+// given type, e.g. "awskms".
+func CheckSealType(name, sealType string) error {
+	return check(name, ComponentSeal, sealType, func(p *policy) []string { return p.sealTypes })
+}
@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package cryptopolicy
+
+import (
+	"crypto/tls"
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, name := range []string{None, FIPS1403} {
+		if err := Validate(name); err != nil {
+			t.Fatalf("expected %q to be valid: %v", name, err)
+		}
+	}
+	if err := Validate("fips-140-1"); err == nil {
+		t.Fatal("expected an error for an unknown policy")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name    string
+		err     error
+		allowed bool
+	}{
+		{"transit aes", CheckTransitKeyType(FIPS1403, "aes256-gcm96"), true},
+		{"transit chacha", CheckTransitKeyType(FIPS1403, "chacha20-poly1305"), false},
+		{"transit managed", CheckTransitKeyType(FIPS1403, "managed_key"), false},
+		{"pki rsa", CheckPKIKey(FIPS1403, "rsa", 3072), true},
+		{"pki ec p-224", CheckPKIKey(FIPS1403, "ec", 224), false},
+		{"pki ed25519", CheckPKIKey(FIPS1403, "ed25519", 0), true},
+		{"tls12", CheckTLSVersion(FIPS1403, "tls12"), true},
+		{"tls11", CheckTLSVersion(FIPS1403, "tls11"), false},
+		{"cipher gcm", CheckTLSCipherSuite(FIPS1403, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256), true},
+		{"cipher chacha", CheckTLSCipherSuite(FIPS1403, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256), false},
+		{"seal awskms", CheckSealType(FIPS1403, "awskms"), true},
+		{"seal aead", CheckSealType(FIPS1403, "aead"), false},
+		{"no policy", CheckTransitKeyType(None, "chacha20-poly1305"), true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.allowed {
+				if tc.err != nil {
+					t.Fatalf("expected no error, got: %v", tc.err)
+				}
+				return
+			}
+			if !errors.Is(tc.err, ErrNotAllowed) {
+				t.Fatalf("expected ErrNotAllowed, got: %v", tc.err)
+			}
+			var policyErr *Error
+			if !errors.As(tc.err, &policyErr) || policyErr.Policy != FIPS1403 || len(policyErr.Allowed) == 0 {
+				t.Fatalf("expected a structured error, got: %#v", tc.err)
+			}
+		})
+	}
+}
@@ -458,6 +458,17 @@ type Policy struct {
 	// rotate. Setting this to zero disables automatic rotation for the key.
 	AutoRotatePeriod time.Duration `json:"auto_rotate_period"`
 
+	// RotationSchedule is a cron-style schedule on which the key is rotated
+	// by Vault's rotation manager, as an alternative to AutoRotatePeriod.
+	// RotationWindow and RotationJitter are the window and maximum jitter of
+	// the schedule.
+	RotationSchedule string        `json:"rotation_schedule,omitempty"`
+	RotationWindow   time.Duration `json:"rotation_window,omitempty"`
+	RotationJitter   time.Duration `json:"rotation_jitter,omitempty"`
+
+	// RotationID is the ID of the key's job with the rotation manager.
+	RotationID string `json:"rotation_id,omitempty"`
+
 	// versionPrefixCache stores caches of version prefix strings and the split
 	// version template.
 	versionPrefixCache sync.Map
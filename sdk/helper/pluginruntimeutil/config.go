@@ -3,7 +3,15 @@
 
 package pluginruntimeutil
 
-import "github.com/hashicorp/vault/sdk/helper/consts"
+import (
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/consts"
+)
+
+// DefaultRestartWindow is the window MaxRestarts applies to if no
+// RestartWindow is configured.
+const DefaultRestartWindow = time.Minute
 
 // PluginRuntimeConfig defines the metadata needed to run a plugin runtime
 type PluginRuntimeConfig struct {
@@ -14,4 +22,20 @@ type PluginRuntimeConfig struct {
 	CPU          int64                    `json:"cpu" structs:"cpu"`
 	Memory       int64                    `json:"memory" structs:"memory"`
 	Rootless     bool                     `json:"rootless" structs:"rootlesss"`
+
+	// MaxRestarts is the number of times a plugin may be restarted after
+	// exiting unexpectedly within RestartWindow. Unlimited if zero.
+	MaxRestarts   int64         `json:"max_restarts" structs:"max_restarts"`
+	RestartWindow time.Duration `json:"restart_window" structs:"restart_window"`
+}
+
+// Restarts returns the number of restarts allowed per window, and the window.
+func (c *PluginRuntimeConfig) Restarts() (int64, time.Duration) {
+	if c == nil || c.MaxRestarts <= 0 {
+		return 0, 0
+	}
+	if c.RestartWindow <= 0 {
+		return c.MaxRestarts, DefaultRestartWindow
+	}
+	return c.MaxRestarts, c.RestartWindow
 }
@@ -0,0 +1,360 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package pluginutil
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	// OIDs of the OIDC issuer extension of Fulcio certificates. The first
+	// version holds the raw issuer, the second a DER encoded UTF8String.
+	oidFulcioIssuerV1 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidFulcioIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// CosignVerification requires a plugin binary to carry a valid cosign
+// signature, as created by "cosign sign-blob". Signatures are either verified
+// with a public key, or keyless with the Fulcio certificate in a cosign bundle,
+// whose identity must match CertificateIdentity and CertificateOIDCIssuer.
+type CosignVerification struct {
+	// PublicKey is the PEM encoded public key of keyed signatures.
+	PublicKey string `json:"public_key,omitempty"`
+	// Signature is the base64 encoded signature of keyed signatures.
+	Signature string `json:"signature,omitempty"`
+
+	// Bundle is the cosign bundle of keyless signatures, as written by
+	// "cosign sign-blob --bundle".
+	Bundle string `json:"bundle,omitempty"`
+	// CertificateIdentity is the email address or URI the signing certificate
+	// of keyless signatures must have been issued to.
+	CertificateIdentity string `json:"certificate_identity,omitempty"`
+	// CertificateOIDCIssuer is the OIDC issuer the identity of keyless
+	// signatures must have been verified by.
+	CertificateOIDCIssuer string `json:"certificate_oidc_issuer,omitempty"`
+}
+
+// CosignTrustedRoot holds the Fulcio certificate authorities and Rekor
+// transparency log keys keyless signatures are verified against.
+type CosignTrustedRoot struct {
+	FulcioRoots         *x509.CertPool
+	FulcioIntermediates *x509.CertPool
+	RekorPublicKeys     []crypto.PublicKey
+}
+
+// ParseCosignTrustedRoot parses the PEM encoded Fulcio certificate chain and
+// Rekor public keys of a trusted root.
+func ParseCosignTrustedRoot(fulcioPEM, rekorPEM []byte) (*CosignTrustedRoot, error) {
+	root := &CosignTrustedRoot{
+		FulcioRoots:         x509.NewCertPool(),
+		FulcioIntermediates: x509.NewCertPool(),
+	}
+
+	var roots int
+	for block, rest := pem.Decode(fulcioPEM); block != nil; block, rest = pem.Decode(rest) {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fulcio certificate: %w", err)
+		}
+		if bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+			root.FulcioRoots.AddCert(cert)
+			roots++
+		} else {
+			root.FulcioIntermediates.AddCert(cert)
+		}
+	}
+	if roots == 0 {
+		return nil, errors.New("no fulcio root certificate found")
+	}
+
+	for block, rest := pem.Decode(rekorPEM); block != nil; block, rest = pem.Decode(rest) {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rekor public key: %w", err)
+		}
+		root.RekorPublicKeys = append(root.RekorPublicKeys, key)
+	}
+	if len(root.RekorPublicKeys) == 0 {
+		return nil, errors.New("no rekor public key found")
+	}
+
+	return root, nil
+}
+
+// Validate checks that exactly one of a keyed or a keyless signature is
+// configured.
+func (c *CosignVerification) Validate() error {
+	switch {
+	case c.PublicKey != "" && c.Bundle != "":
+		return errors.New("cosign public key and bundle are mutually exclusive")
+	case c.PublicKey != "":
+		if c.Signature == "" {
+			return errors.New("cosign signature is required with a public key")
+		}
+		if c.CertificateIdentity != "" || c.CertificateOIDCIssuer != "" {
+			return errors.New("cosign certificate identity and issuer are only supported with a bundle")
+		}
+		_, err := parseCosignPublicKey([]byte(c.PublicKey))
+		return err
+	case c.Bundle != "":
+		if c.Signature != "" {
+			return errors.New("cosign signature is only supported with a public key")
+		}
+		if c.CertificateIdentity == "" || c.CertificateOIDCIssuer == "" {
+			return errors.New("cosign certificate identity and issuer are required with a bundle")
+		}
+		_, err := parseCosignBundle(c.Bundle)
+		return err
+	default:
+		return errors.New("either a cosign public key or bundle is required")
+	}
+}
+
+// Verify verifies the cosign signature of content. The trusted root is only
+// required for keyless signatures.
+func (c *CosignVerification) Verify(content []byte, root *CosignTrustedRoot) error {
+	if c.PublicKey != "" {
+		key, err := parseCosignPublicKey([]byte(c.PublicKey))
+		if err != nil {
+			return err
+		}
+		sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(c.Signature))
+		if err != nil {
+			return fmt.Errorf("failed to decode cosign signature: %w", err)
+		}
+		return verifyCosignSignature(key, content, sig)
+	}
+
+	if root == nil {
+		return errors.New("no trusted root is configured for keyless cosign signatures")
+	}
+	bundle, err := parseCosignBundle(c.Bundle)
+	if err != nil {
+		return err
+	}
+	return c.verifyBundle(bundle, content, root)
+}
+
+type cosignBundle struct {
+	Base64Signature string `json:"base64Signature"`
+	Cert            string `json:"cert"`
+	RekorBundle     *struct {
+		SignedEntryTimestamp []byte             `json:"SignedEntryTimestamp"`
+		Payload              cosignRekorPayload `json:"Payload"`
+	} `json:"rekorBundle"`
+}
+
+// cosignRekorPayload is the transparency log entry signed by Rekor. Its fields
+// are sorted, so that it marshals to its canonical JSON form.
+type cosignRekorPayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// cosignHashedRekord is the body of the transparency log entry of a blob
+// signature.
+type cosignHashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content string `json:"content"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+func parseCosignBundle(raw string) (*cosignBundle, error) {
+	bundle := new(cosignBundle)
+	if err := json.Unmarshal([]byte(raw), bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode cosign bundle: %w", err)
+	}
+	if bundle.Base64Signature == "" || bundle.Cert == "" {
+		return nil, errors.New("cosign bundle is missing the signature or certificate")
+	}
+	if bundle.RekorBundle == nil {
+		return nil, errors.New("cosign bundle is missing the transparency log entry")
+	}
+	return bundle, nil
+}
+
+// verifyBundle verifies that the signature of the bundle was created by a
+// certificate issued by Fulcio to the configured identity, while the
+// certificate was valid according to the bundle's Rekor entry.
+func (c *CosignVerification) verifyBundle(bundle *cosignBundle, content []byte, root *CosignTrustedRoot) error {
+	sig, err := base64.StdEncoding.DecodeString(bundle.Base64Signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode cosign signature: %w", err)
+	}
+
+	// Newer versions of cosign base64 encode the PEM certificate
+	certPEM := []byte(bundle.Cert)
+	if decoded, err := base64.StdEncoding.DecodeString(bundle.Cert); err == nil {
+		certPEM = decoded
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return errors.New("failed to decode cosign certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse cosign certificate: %w", err)
+	}
+
+	// Verify the transparency log entry first, as its integrated time is the
+	// time the short-lived certificate must have been valid at.
+	payload := bundle.RekorBundle.Payload
+	canonical, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var setErr error
+	for _, key := range root.RekorPublicKeys {
+		if setErr = verifyCosignSignature(key, canonical, bundle.RekorBundle.SignedEntryTimestamp); setErr == nil {
+			break
+		}
+	}
+	if setErr != nil {
+		return fmt.Errorf("invalid transparency log entry: %w", setErr)
+	}
+
+	digest := sha256.Sum256(content)
+	body, err := base64.StdEncoding.DecodeString(payload.Body)
+	if err != nil {
+		return fmt.Errorf("failed to decode transparency log entry: %w", err)
+	}
+	var rekord cosignHashedRekord
+	if err := json.Unmarshal(body, &rekord); err != nil {
+		return fmt.Errorf("failed to decode transparency log entry: %w", err)
+	}
+	if rekord.Kind != "hashedrekord" || rekord.Spec.Data.Hash.Algorithm != "sha256" ||
+		rekord.Spec.Data.Hash.Value != hex.EncodeToString(digest[:]) || rekord.Spec.Signature.Content != bundle.Base64Signature {
+		return errors.New("transparency log entry does not match the plugin signature")
+	}
+
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         root.FulcioRoots,
+		Intermediates: root.FulcioIntermediates,
+		CurrentTime:   time.Unix(payload.IntegratedTime, 0),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}); err != nil {
+		return fmt.Errorf("invalid cosign certificate: %w", err)
+	}
+	if err := c.verifyIdentity(cert); err != nil {
+		return err
+	}
+
+	return verifyCosignSignature(cert.PublicKey, content, sig)
+}
+
+// verifyIdentity checks that the certificate was issued to the configured
+// identity by the configured OIDC issuer.
+func (c *CosignVerification) verifyIdentity(cert *x509.Certificate) error {
+	identities := cert.EmailAddresses
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	var found bool
+	for _, identity := range identities {
+		if identity == c.CertificateIdentity {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("cosign certificate identities %q do not match %q", identities, c.CertificateIdentity)
+	}
+
+	var issuer string
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(oidFulcioIssuerV2):
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err != nil {
+				return fmt.Errorf("failed to decode cosign certificate issuer: %w", err)
+			}
+		case ext.Id.Equal(oidFulcioIssuerV1) && issuer == "":
+			issuer = string(ext.Value)
+		}
+	}
+	if issuer != c.CertificateOIDCIssuer {
+		return fmt.Errorf("cosign certificate issuer %q does not match %q", issuer, c.CertificateOIDCIssuer)
+	}
+	return nil
+}
+
+func parseCosignPublicKey(raw []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("failed to decode cosign public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cosign public key: %w", err)
+	}
+	return key, nil
+}
+
+// verifyCosignSignature verifies sig over content the way cosign signs blobs,
+// i.e. over its SHA256 digest for ECDSA and RSA keys.
+func verifyCosignSignature(key crypto.PublicKey, content, sig []byte) error {
+	digest := sha256.Sum256(content)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, digest[:], sig) {
+			return errors.New("invalid cosign signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig); err != nil {
+			return errors.New("invalid cosign signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, content, sig) {
+			return errors.New("invalid cosign signature")
+		}
+	default:
+		return fmt.Errorf("unsupported cosign key type %T", key)
+	}
+	return nil
+}
+
+// VerifyCosignFile verifies the cosign signature of the plugin binary at path.
+// The binary must also match the pinned SHA256, so that the plugin that is
+// verified by its checksum when it is run is the one that was signed.
+func VerifyCosignFile(path string, sum []byte, v *CosignVerification, root *CosignTrustedRoot) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read plugin binary: %w", err)
+	}
+	actual := sha256.Sum256(content)
+	if subtle.ConstantTimeCompare(actual[:], sum) != 1 {
+		return errors.New("plugin binary does not match its SHA256")
+	}
+	if err := v.Verify(content, root); err != nil {
+		return fmt.Errorf("failed to verify cosign signature of plugin: %w", err)
+	}
+	return nil
+}
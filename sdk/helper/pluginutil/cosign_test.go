@@ -0,0 +1,303 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package pluginutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func cosignSign(t *testing.T, key *ecdsa.PrivateKey, content []byte) string {
+	t.Helper()
+	digest := sha256.Sum256(content)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func cosignPublicKeyPEM(t *testing.T, key crypto.PublicKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestCosignVerification_Keyed(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("plugin binary")
+	v := &CosignVerification{
+		PublicKey: string(cosignPublicKeyPEM(t, key.Public())),
+		Signature: cosignSign(t, key, content),
+	}
+	if err := v.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.Verify(content, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify([]byte("tampered binary"), nil); err == nil {
+		t.Fatal("expected signature of tampered binary to be invalid")
+	}
+}
+
+func TestCosignVerification_Validate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		v          *CosignVerification
+		wantErrStr string
+	}{
+		"empty":                 {&CosignVerification{}, "either a cosign public key or bundle is required"},
+		"key without signature": {&CosignVerification{PublicKey: "key"}, "signature is required"},
+		"key and bundle":        {&CosignVerification{PublicKey: "key", Bundle: "{}"}, "mutually exclusive"},
+		"key with identity":     {&CosignVerification{PublicKey: "key", Signature: "sig", CertificateIdentity: "me"}, "only supported with a bundle"},
+		"invalid key":           {&CosignVerification{PublicKey: "key", Signature: "sig"}, "failed to decode cosign public key"},
+		"bundle with signature": {&CosignVerification{Bundle: "{}", Signature: "sig"}, "only supported with a public key"},
+		"bundle without issuer": {&CosignVerification{Bundle: "{}", CertificateIdentity: "me"}, "identity and issuer are required"},
+		"invalid bundle":        {&CosignVerification{Bundle: "{}", CertificateIdentity: "me", CertificateOIDCIssuer: "ci"}, "missing the signature"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.v.Validate()
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrStr) {
+				t.Fatalf("expected error containing %q, got: %v", tc.wantErrStr, err)
+			}
+		})
+	}
+}
+
+type testFulcio struct {
+	ca       *x509.Certificate
+	caKey    *ecdsa.PrivateKey
+	rekorKey *ecdsa.PrivateKey
+	root     *CosignTrustedRoot
+}
+
+func newTestFulcio(t *testing.T) *testFulcio {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sigstore"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := ParseCosignTrustedRoot(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		cosignPublicKeyPEM(t, rekorKey.Public()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testFulcio{ca: ca, caKey: caKey, rekorKey: rekorKey, root: root}
+}
+
+// bundle signs content with a short-lived certificate issued to the identity,
+// and returns the cosign bundle of the signature logged at integratedTime.
+func (f *testFulcio) bundle(t *testing.T, content []byte, identity, issuer string, integratedTime time.Time) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuerExt, err := asn1.Marshal(issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       now.Add(-time.Minute),
+		NotAfter:        now.Add(10 * time.Minute),
+		EmailAddresses:  []string{identity},
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		ExtraExtensions: []pkix.Extension{{Id: oidFulcioIssuerV2, Value: issuerExt}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, f.ca, key.Public(), f.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	sig := cosignSign(t, key, content)
+	digest := sha256.Sum256(content)
+	var rekord cosignHashedRekord
+	rekord.Kind = "hashedrekord"
+	rekord.Spec.Data.Hash.Algorithm = "sha256"
+	rekord.Spec.Data.Hash.Value = hex.EncodeToString(digest[:])
+	rekord.Spec.Signature.Content = sig
+	body, err := json.Marshal(rekord)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := cosignRekorPayload{
+		Body:           base64.StdEncoding.EncodeToString(body),
+		IntegratedTime: integratedTime.Unix(),
+		LogID:          "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+		LogIndex:       42,
+	}
+	canonical, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonicalDigest := sha256.Sum256(canonical)
+	set, err := ecdsa.SignASN1(rand.Reader, f.rekorKey, canonicalDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := json.Marshal(map[string]interface{}{
+		"base64Signature": sig,
+		"cert":            base64.StdEncoding.EncodeToString(certPEM),
+		"rekorBundle": map[string]interface{}{
+			"SignedEntryTimestamp": set,
+			"Payload":              payload,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bundle)
+}
+
+func TestCosignVerification_Keyless(t *testing.T) {
+	f := newTestFulcio(t)
+	content := []byte("plugin binary")
+	identity, issuer := "release@example.com", "https://accounts.example.com"
+
+	for name, tc := range map[string]struct {
+		bundle     string
+		content    []byte
+		root       *CosignTrustedRoot
+		wantErrStr string
+	}{
+		"valid": {
+			bundle: f.bundle(t, content, identity, issuer, time.Now()),
+		},
+		"tampered binary": {
+			bundle:     f.bundle(t, content, identity, issuer, time.Now()),
+			content:    []byte("tampered binary"),
+			wantErrStr: "does not match the plugin signature",
+		},
+		"wrong identity": {
+			bundle:     f.bundle(t, content, "attacker@example.com", issuer, time.Now()),
+			wantErrStr: "do not match",
+		},
+		"wrong issuer": {
+			bundle:     f.bundle(t, content, identity, "https://attacker.example.com", time.Now()),
+			wantErrStr: "issuer",
+		},
+		"logged after certificate expired": {
+			bundle:     f.bundle(t, content, identity, issuer, time.Now().Add(time.Hour)),
+			wantErrStr: "invalid cosign certificate",
+		},
+		"untrusted fulcio": {
+			bundle:     f.bundle(t, content, identity, issuer, time.Now()),
+			root:       &CosignTrustedRoot{FulcioRoots: newTestFulcio(t).root.FulcioRoots, RekorPublicKeys: f.root.RekorPublicKeys},
+			wantErrStr: "invalid cosign certificate",
+		},
+		"untrusted rekor": {
+			bundle:     f.bundle(t, content, identity, issuer, time.Now()),
+			root:       &CosignTrustedRoot{FulcioRoots: f.root.FulcioRoots, RekorPublicKeys: newTestFulcio(t).root.RekorPublicKeys},
+			wantErrStr: "invalid transparency log entry",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			v := &CosignVerification{
+				Bundle:                tc.bundle,
+				CertificateIdentity:   identity,
+				CertificateOIDCIssuer: issuer,
+			}
+			if err := v.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			verifyContent, root := content, f.root
+			if tc.content != nil {
+				verifyContent = tc.content
+			}
+			if tc.root != nil {
+				root = tc.root
+			}
+			err := v.Verify(verifyContent, root)
+			if tc.wantErrStr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrStr) {
+				t.Fatalf("expected error containing %q, got: %v", tc.wantErrStr, err)
+			}
+		})
+	}
+}
+
+func TestVerifyCosignFile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("plugin binary")
+	path := filepath.Join(t.TempDir(), "plugin")
+	if err := os.WriteFile(path, content, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	v := &CosignVerification{
+		PublicKey: string(cosignPublicKeyPEM(t, key.Public())),
+		Signature: cosignSign(t, key, content),
+	}
+
+	if err := VerifyCosignFile(path, sum[:], v, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The binary must match the pinned checksum, even if it is signed
+	other := sha256.Sum256([]byte("other binary"))
+	if err := VerifyCosignFile(path, other[:], v, nil); err == nil || !strings.Contains(err.Error(), "SHA256") {
+		t.Fatalf("expected checksum error, got: %v", err)
+	}
+}
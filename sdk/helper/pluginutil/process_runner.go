@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package pluginutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin/runner"
+	"github.com/hashicorp/vault/sdk/helper/pluginruntimeutil"
+)
+
+var _ runner.Runner = (*processRunner)(nil)
+
+// processRunner runs a plugin process with the resource limits of its process
+// runtime. It mirrors go-plugin's runner for plain commands, but starts the
+// plugin from a shell that applies the limits to itself before executing the
+// plugin, so that they apply from the plugin's first instruction.
+type processRunner struct {
+	logger log.Logger
+	cmd    *exec.Cmd
+	config *pluginruntimeutil.PluginRuntimeConfig
+
+	stdout io.ReadCloser
+	stderr io.ReadCloser
+
+	// cgroup is the cgroup the plugin runs in, if the runtime has a cgroup
+	// parent
+	cgroup string
+
+	// Cmd info is persisted early, since the process information will be removed
+	// after Kill is called.
+	path string
+	pid  int
+}
+
+func newProcessRunner(logger log.Logger, cmd *exec.Cmd, config *pluginruntimeutil.PluginRuntimeConfig) (*processRunner, error) {
+	r := &processRunner{
+		logger: logger,
+		config: config,
+		path:   cmd.Path,
+	}
+
+	var err error
+	if config.CgroupParent != "" {
+		r.cgroup, err = createCgroup(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cgroup for plugin: %w", err)
+		}
+	} else if config.CPU > 0 {
+		return nil, errors.New("cpu limits of plugin processes require a cgroup parent")
+	} else if runtime.GOOS == "windows" {
+		return nil, errors.New("resource limits of plugin processes are not supported on windows")
+	}
+
+	r.cmd = r.limitedCmd(cmd)
+	if r.stdout, err = r.cmd.StdoutPipe(); err != nil {
+		r.removeCgroup()
+		return nil, err
+	}
+	if r.stderr, err = r.cmd.StderrPipe(); err != nil {
+		r.removeCgroup()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// limitedCmd returns a command which runs cmd from a shell which moves itself
+// into the cgroup of the plugin, or limits its data segment if the plugin has
+// no cgroup.
+func (r *processRunner) limitedCmd(cmd *exec.Cmd) *exec.Cmd {
+	var setup []string
+	args := []string{"-c", "", "vault-plugin"}
+	if r.cgroup != "" {
+		setup = append(setup, `echo $$ > "$1"`, "shift")
+		args = append(args, filepath.Join(r.cgroup, "cgroup.procs"))
+	} else if r.config.Memory > 0 {
+		// ulimit takes the limit in KiB
+		setup = append(setup, fmt.Sprintf("ulimit -d %d", (r.config.Memory+1023)/1024))
+	}
+	args[1] = strings.Join(append(setup, `exec "$@"`), " && ")
+	args = append(args, cmd.Path)
+	args = append(args, cmd.Args[1:]...)
+
+	limited := exec.Command("/bin/sh", args...)
+	limited.Env = cmd.Env
+	limited.Stdin = cmd.Stdin
+	limited.Dir = cmd.Dir
+	return limited
+}
+
+func (r *processRunner) Start(_ context.Context) error {
+	r.logger.Debug("starting plugin", "path", r.path, "args", r.cmd.Args, "cgroup", r.cgroup)
+	if err := r.cmd.Start(); err != nil {
+		r.removeCgroup()
+		return err
+	}
+
+	r.pid = r.cmd.Process.Pid
+	r.logger.Debug("plugin started", "path", r.path, "pid", r.pid)
+	return nil
+}
+
+func (r *processRunner) Wait(_ context.Context) error {
+	err := r.cmd.Wait()
+	if r.cgroup != "" {
+		if kills, _ := cgroupOOMKills(r.cgroup); kills > 0 {
+			r.logger.Warn("plugin exceeded its memory limit and was killed", "path", r.path, "pid", r.pid, "memory_bytes", r.config.Memory)
+		}
+		r.removeCgroup()
+	}
+	return err
+}
+
+func (r *processRunner) Kill(_ context.Context) error {
+	if r.cmd.Process == nil {
+		return nil
+	}
+
+	err := r.cmd.Process.Kill()
+	if r.cgroup != "" {
+		// Also kill any processes the plugin spawned
+		killCgroup(r.cgroup)
+	}
+	// Swallow ErrProcessDone, we support calling Kill multiple times.
+	if !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
+
+func (r *processRunner) removeCgroup() {
+	if r.cgroup == "" {
+		return
+	}
+	if err := os.Remove(r.cgroup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		r.logger.Warn("failed to remove plugin cgroup", "cgroup", r.cgroup, "error", err)
+	}
+}
+
+func (r *processRunner) Stdout() io.ReadCloser {
+	return r.stdout
+}
+
+func (r *processRunner) Stderr() io.ReadCloser {
+	return r.stderr
+}
+
+func (r *processRunner) Name() string {
+	return r.path
+}
+
+func (r *processRunner) ID() string {
+	return strconv.Itoa(r.pid)
+}
+
+func (r *processRunner) Diagnose(_ context.Context) string {
+	return fmt.Sprintf("the plugin %s may have failed to start within the resource limits of runtime %q (cpu_nanos=%d, memory_bytes=%d)",
+		r.path, r.config.Name, r.config.CPU, r.config.Memory)
+}
+
+func (r *processRunner) PluginToHost(pluginNet, pluginAddr string) (string, string, error) {
+	return pluginNet, pluginAddr, nil
+}
+
+func (r *processRunner) HostToPlugin(hostNet, hostAddr string) (string, string, error) {
+	return hostNet, hostAddr, nil
+}
@@ -0,0 +1,100 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux
+
+package pluginutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/sdk/helper/pluginruntimeutil"
+)
+
+const (
+	cgroupRoot = "/sys/fs/cgroup"
+
+	// cgroupCPUPeriod is the period, in microseconds, of the CPU quota of
+	// plugin cgroups.
+	cgroupCPUPeriod = 100000
+)
+
+// createCgroup creates a cgroup v2 cgroup below the cgroup parent of the
+// runtime, limited to the CPU and memory of the runtime.
+func createCgroup(config *pluginruntimeutil.PluginRuntimeConfig) (string, error) {
+	parent := filepath.Join(cgroupRoot, filepath.Clean("/"+config.CgroupParent))
+	if _, err := os.Stat(filepath.Join(parent, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup parent %q is not a cgroup v2 cgroup: %w", config.CgroupParent, err)
+	}
+
+	// The controllers may already be enabled, or not be delegated to Vault,
+	// in which case writing the limits below fails.
+	for _, controller := range []string{"+cpu", "+memory"} {
+		_ = os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte(controller), 0o644)
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+	cgroup := filepath.Join(parent, "vault-plugin-"+id)
+	if err := os.Mkdir(cgroup, 0o755); err != nil {
+		return "", err
+	}
+
+	limits := map[string]string{}
+	if config.Memory > 0 {
+		limits["memory.max"] = strconv.FormatInt(config.Memory, 10)
+		limits["memory.swap.max"] = "0"
+	}
+	if config.CPU > 0 {
+		// CPU is in units of 10^-9 CPUs
+		quota := config.CPU * cgroupCPUPeriod / 1e9
+		if quota < 1000 {
+			quota = 1000
+		}
+		limits["cpu.max"] = fmt.Sprintf("%d %d", quota, cgroupCPUPeriod)
+	}
+	for file, value := range limits {
+		err := os.WriteFile(filepath.Join(cgroup, file), []byte(value), 0o644)
+		// Swap accounting may be disabled
+		if os.IsNotExist(err) && file == "memory.swap.max" {
+			continue
+		}
+		if err != nil {
+			os.Remove(cgroup)
+			return "", fmt.Errorf("failed to set %s: %w", file, err)
+		}
+	}
+
+	return cgroup, nil
+}
+
+// cgroupOOMKills returns the number of processes of the cgroup that were
+// killed for exceeding its memory limit.
+func cgroupOOMKills(cgroup string) (int, error) {
+	events, err := os.ReadFile(filepath.Join(cgroup, "memory.events"))
+	if err != nil {
+		return 0, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(events))
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "oom_kill ") {
+			return strconv.Atoi(strings.TrimPrefix(line, "oom_kill "))
+		}
+	}
+	return 0, scanner.Err()
+}
+
+// killCgroup kills all processes of the cgroup.
+func killCgroup(cgroup string) {
+	_ = os.WriteFile(filepath.Join(cgroup, "cgroup.kill"), []byte("1"), 0o644)
+}
@@ -0,0 +1,22 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !linux
+
+package pluginutil
+
+import (
+	"errors"
+
+	"github.com/hashicorp/vault/sdk/helper/pluginruntimeutil"
+)
+
+func createCgroup(_ *pluginruntimeutil.PluginRuntimeConfig) (string, error) {
+	return "", errors.New("cgroups are only supported on Linux")
+}
+
+func cgroupOOMKills(_ string) (int, error) {
+	return 0, nil
+}
+
+func killCgroup(_ string) {}
@@ -0,0 +1,80 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package pluginutil
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/pluginruntimeutil"
+)
+
+func TestProcessLimited(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config   *pluginruntimeutil.PluginRuntimeConfig
+		expected bool
+	}{
+		"no runtime":        {nil, false},
+		"container runtime": {&pluginruntimeutil.PluginRuntimeConfig{Type: consts.PluginRuntimeTypeContainer, Memory: 1}, false},
+		"restarts only":     {&pluginruntimeutil.PluginRuntimeConfig{Type: consts.PluginRuntimeTypeProcess, MaxRestarts: 1}, false},
+		"memory":            {&pluginruntimeutil.PluginRuntimeConfig{Type: consts.PluginRuntimeTypeProcess, Memory: 1}, true},
+		"cpu":               {&pluginruntimeutil.PluginRuntimeConfig{Type: consts.PluginRuntimeTypeProcess, CPU: 1}, true},
+		"cgroup parent":     {&pluginruntimeutil.PluginRuntimeConfig{Type: consts.PluginRuntimeTypeProcess, CgroupParent: "vault"}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rc := runConfig{runtimeConfig: tc.config}
+			if actual := rc.processLimited(); actual != tc.expected {
+				t.Fatalf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// TestProcessRunner_MemoryLimit tests that plugins without a cgroup are run
+// with their data segment limited to the memory of the runtime.
+func TestProcessRunner_MemoryLimit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("resource limits are not supported on windows")
+	}
+
+	r, err := newProcessRunner(hclog.NewNullLogger(), exec.Command("/bin/sh", "-c", "ulimit -d"), &pluginruntimeutil.PluginRuntimeConfig{
+		Name:   "limited",
+		Type:   consts.PluginRuntimeTypeProcess,
+		Memory: 64 * 1024 * 1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := r.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(r.Stdout())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if actual := strings.TrimSpace(string(out)); actual != "65536" {
+		t.Fatalf("expected data segment limit of 65536 KiB, got %q", actual)
+	}
+}
+
+func TestProcessRunner_CPURequiresCgroup(t *testing.T) {
+	_, err := newProcessRunner(hclog.NewNullLogger(), exec.Command("/bin/true"), &pluginruntimeutil.PluginRuntimeConfig{
+		Type: consts.PluginRuntimeTypeProcess,
+		CPU:  1e9,
+	})
+	if err == nil || !strings.Contains(err.Error(), "require a cgroup parent") {
+		t.Fatalf("expected error, got: %v", err)
+	}
+}
@@ -15,6 +15,7 @@ import (
 
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/go-plugin/runner"
 	"github.com/hashicorp/go-secure-stdlib/plugincontainer"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/pluginruntimeutil"
@@ -55,6 +56,9 @@ type runConfig struct {
 
 	runtimeConfig *pluginruntimeutil.PluginRuntimeConfig
 
+	cosign            *CosignVerification
+	cosignTrustedRoot *CosignTrustedRoot
+
 	PluginClientConfig
 	tmpdir string
 }
@@ -63,6 +67,15 @@ func (rc runConfig) mlockEnabled() bool {
 	return rc.MLock || (rc.Wrapper != nil && rc.Wrapper.MlockEnabled())
 }
 
+// processLimited returns whether the plugin process is run with the resource
+// limits of a process runtime.
+func (rc runConfig) processLimited() bool {
+	if rc.runtimeConfig == nil || rc.runtimeConfig.Type != consts.PluginRuntimeTypeProcess {
+		return false
+	}
+	return rc.runtimeConfig.CgroupParent != "" || rc.runtimeConfig.CPU > 0 || rc.runtimeConfig.Memory > 0
+}
+
 func (rc runConfig) generateCmd(ctx context.Context) (cmd *exec.Cmd, clientTLSConfig *tls.Config, err error) {
 	cmd = exec.Command(rc.command, rc.args...)
 	env := rc.env
@@ -159,13 +172,37 @@ func (rc runConfig) makeConfig(ctx context.Context) (*plugin.ClientConfig, error
 		AutoMTLS:    rc.AutoMTLS,
 		SkipHostEnv: true,
 	}
-	if rc.image == "" {
+	if rc.image == "" && rc.cosign != nil {
+		if err := VerifyCosignFile(cmd.Path, rc.sha256, rc.cosign, rc.cosignTrustedRoot); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case rc.image == "" && rc.processLimited():
+		clientConfig.RunnerFunc = func(logger log.Logger, pluginCmd *exec.Cmd, _ string) (runner.Runner, error) {
+			// go-plugin only verifies the checksum of Cmd, which must not be
+			// set along with RunnerFunc.
+			secureConfig := &plugin.SecureConfig{
+				Checksum: rc.sha256,
+				Hash:     sha256.New(),
+			}
+			if ok, err := secureConfig.Check(cmd.Path); err != nil {
+				return nil, fmt.Errorf("error verifying checksum: %s", err)
+			} else if !ok {
+				return nil, plugin.ErrChecksumsDoNotMatch
+			}
+
+			cmd.Env = append(cmd.Env, pluginCmd.Env...)
+			cmd.Stdin = pluginCmd.Stdin
+			return newProcessRunner(logger, cmd, rc.runtimeConfig)
+		}
+	case rc.image == "":
 		clientConfig.Cmd = cmd
 		clientConfig.SecureConfig = &plugin.SecureConfig{
 			Checksum: rc.sha256,
 			Hash:     sha256.New(),
 		}
-	} else {
+	default:
 		containerCfg, err := rc.containerConfig(ctx, cmd.Env)
 		if err != nil {
 			return nil, err
@@ -301,6 +338,9 @@ func (r *PluginRunner) RunConfig(ctx context.Context, opts ...RunOpt) (*plugin.C
 		env:           r.Env,
 		runtimeConfig: r.RuntimeConfig,
 		tmpdir:        r.Tmpdir,
+
+		cosign:            r.Cosign,
+		cosignTrustedRoot: r.CosignTrustedRoot,
 		PluginClientConfig: PluginClientConfig{
 			Name:       r.Name,
 			PluginType: r.Type,
@@ -70,6 +70,11 @@ type PluginRunner struct {
 	BuiltinFactory func() (interface{}, error) `json:"-" structs:"-"`
 	RuntimeConfig  *prutil.PluginRuntimeConfig `json:"-" structs:"-"`
 	Tmpdir         string                      `json:"-" structs:"-"`
+
+	// Cosign, if set, requires the plugin binary to carry a valid cosign
+	// signature each time it is run, in addition to matching Sha256.
+	Cosign            *CosignVerification `json:"cosign,omitempty" structs:"cosign"`
+	CosignTrustedRoot *CosignTrustedRoot  `json:"-" structs:"-"`
 }
 
 // BinaryReference returns either the OCI image reference if it's a container
@@ -90,6 +95,12 @@ func (p *PluginRunner) BinaryReference() string {
 	return imageRef
 }
 
+// IsWASM returns whether the plugin is a WebAssembly module run by Vault's
+// WebAssembly runtime rather than a process managed by go-plugin.
+func (p *PluginRunner) IsWASM() bool {
+	return !p.Builtin && p.OCIImage == "" && strings.HasSuffix(p.Command, ".wasm")
+}
+
 // SetPluginInput is only used as input for the plugin catalog's set methods.
 // We don't use the very similar PluginRunner struct to avoid confusion about
 // what's settable, which does not include the builtin fields.
@@ -103,6 +114,7 @@ type SetPluginInput struct {
 	Args     []string
 	Env      []string
 	Sha256   []byte
+	Cosign   *CosignVerification
 }
 
 // Run takes a wrapper RunnerUtil instance along with the go-plugin parameters and
@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	sockaddr "github.com/hashicorp/go-sockaddr"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/accesswindow"
 	"github.com/hashicorp/vault/sdk/helper/policyutil"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -22,6 +23,11 @@ type TokenParams struct {
 	// The set of CIDRs that tokens generated using this role will be bound to
 	TokenBoundCIDRs []*sockaddr.SockAddrMarshaler `json:"token_bound_cidrs"`
 
+	// The time-of-day and day-of-week windows during which tokens generated
+	// using this role may be used, in TokenAccessWindowTimezone
+	TokenAccessWindows        []string `json:"token_access_windows" mapstructure:"token_access_windows"`
+	TokenAccessWindowTimezone string   `json:"token_access_window_timezone" mapstructure:"token_access_window_timezone"`
+
 	// If set, the token entry will have an explicit maximum TTL set, rather
 	// than deferring to role/mount values
 	TokenExplicitMaxTTL time.Duration `json:"token_explicit_max_ttl" mapstructure:"token_explicit_max_ttl"`
@@ -74,6 +80,25 @@ func AddTokenFieldsWithAllowList(m map[string]*framework.FieldSchema, allowed []
 // TokenFields provides a set of field schemas for the parameters
 func TokenFields() map[string]*framework.FieldSchema {
 	return map[string]*framework.FieldSchema{
+		"token_access_windows": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: tokenAccessWindowsHelp,
+			DisplayAttrs: &framework.DisplayAttributes{
+				Name:        "Generated Token's Access Windows",
+				Group:       "Tokens",
+				Description: "A list of time windows, e.g. 'Mon-Fri 09:00-17:00'. If set, the generated token may only be used during these windows.",
+			},
+		},
+
+		"token_access_window_timezone": {
+			Type:        framework.TypeString,
+			Description: "The IANA time zone, e.g. 'Europe/Paris', of the access windows of the generated token. Defaults to UTC.",
+			DisplayAttrs: &framework.DisplayAttributes{
+				Name:  "Generated Token's Access Window Time Zone",
+				Group: "Tokens",
+			},
+		},
+
 		"token_bound_cidrs": {
 			Type:        framework.TypeCommaStringSlice,
 			Description: `Comma separated string or JSON list of CIDR blocks. If set, specifies the blocks of IP addresses which are allowed to use the generated token.`,
@@ -170,6 +195,16 @@ func (t *TokenParams) ParseTokenFields(req *logical.Request, d *framework.FieldD
 		t.TokenBoundCIDRs = boundCIDRs
 	}
 
+	if accessWindowsRaw, ok := d.GetOk("token_access_windows"); ok {
+		t.TokenAccessWindows = accessWindowsRaw.([]string)
+	}
+	if timezoneRaw, ok := d.GetOk("token_access_window_timezone"); ok {
+		t.TokenAccessWindowTimezone = timezoneRaw.(string)
+	}
+	if _, err := accesswindow.ParseSet(t.TokenAccessWindows, t.TokenAccessWindowTimezone); err != nil {
+		return err
+	}
+
 	if explicitMaxTTLRaw, ok := d.GetOk("token_explicit_max_ttl"); ok {
 		t.TokenExplicitMaxTTL = time.Duration(explicitMaxTTLRaw.(int)) * time.Second
 	}
@@ -243,6 +278,8 @@ func (t *TokenParams) ParseTokenFields(req *logical.Request, d *framework.FieldD
 
 // PopulateTokenData adds information from TokenParams into the map
 func (t *TokenParams) PopulateTokenData(m map[string]interface{}) {
+	m["token_access_windows"] = t.TokenAccessWindows
+	m["token_access_window_timezone"] = t.TokenAccessWindowTimezone
 	m["token_bound_cidrs"] = t.TokenBoundCIDRs
 	m["token_explicit_max_ttl"] = int64(t.TokenExplicitMaxTTL.Seconds())
 	m["token_max_ttl"] = int64(t.TokenMaxTTL.Seconds())
@@ -257,6 +294,10 @@ func (t *TokenParams) PopulateTokenData(m map[string]interface{}) {
 		m["token_policies"] = []string{}
 	}
 
+	if len(t.TokenAccessWindows) == 0 {
+		m["token_access_windows"] = []string{}
+	}
+
 	if len(t.TokenBoundCIDRs) == 0 {
 		m["token_bound_cidrs"] = []string{}
 	}
@@ -264,6 +305,8 @@ func (t *TokenParams) PopulateTokenData(m map[string]interface{}) {
 
 // PopulateTokenAuth populates Auth with parameters
 func (t *TokenParams) PopulateTokenAuth(auth *logical.Auth) {
+	auth.AccessWindows = t.TokenAccessWindows
+	auth.AccessWindowTimezone = t.TokenAccessWindowTimezone
 	auth.BoundCIDRs = t.TokenBoundCIDRs
 	auth.ExplicitMaxTTL = t.TokenExplicitMaxTTL
 	auth.MaxTTL = t.TokenMaxTTL
@@ -408,6 +451,10 @@ func UpgradeValue(d *framework.FieldData, oldKey, newKey string, oldVal, newVal
 }
 
 const (
+	tokenAccessWindowsHelp = `Comma separated string or JSON list of
+time windows, e.g. "Mon-Fri 09:00-17:00". If set,
+the generated token may only be used during these
+windows, in 'token_access_window_timezone'.`
 	tokenPeriodHelp = `If set, tokens created via this role
 will have no max lifetime; instead, their
 renewal period will be fixed to this value.
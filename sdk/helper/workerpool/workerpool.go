@@ -0,0 +1,204 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package workerpool runs CPU-heavy operations, such as key generation, on
+// bounded pools of workers separate from the goroutines handling requests, so
+// that bursts of expensive operations queue up instead of starving unrelated
+// requests of CPU.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+)
+
+// ErrQueueFull is returned by Run when the queue of the pool is full.
+var ErrQueueFull = errors.New("worker pool queue is full")
+
+// DefaultSize returns the number of workers of pools whose size is not
+// configured.
+func DefaultSize() int {
+	return runtime.GOMAXPROCS(0)
+}
+
+// Pool is a bounded pool of workers. Workers are started as operations are
+// queued, up to the size of the pool, and stop once the queue is empty.
+type Pool struct {
+	name       string
+	metricsKey []string
+	labels     []metrics.Label
+
+	lock     sync.Mutex
+	size     int
+	maxQueue int
+	workers  int
+	running  int
+	queue    []*job
+}
+
+type job struct {
+	fn      func() error
+	queued  time.Time
+	started bool
+	done    chan error
+}
+
+// Stats is the state of a pool.
+type Stats struct {
+	// Size is the maximum number of operations run at once.
+	Size int
+
+	// MaxQueue is the maximum number of operations waiting for a worker, or
+	// zero if unbounded.
+	MaxQueue int
+
+	// Running is the number of operations being run.
+	Running int
+
+	// Queued is the number of operations waiting for a worker.
+	Queued int
+}
+
+// New returns a pool of the given size, with a queue of at most maxQueue
+// operations, or unbounded if maxQueue is zero. A size of zero or less uses
+// DefaultSize. The depth of the queue and the time operations wait in it are
+// emitted as metrics under metricsKey, labeled with the name of the pool.
+func New(name string, size, maxQueue int, metricsKey []string, labels ...metrics.Label) *Pool {
+	p := &Pool{
+		name:       name,
+		metricsKey: metricsKey,
+		labels:     append([]metrics.Label{{Name: "pool", Value: name}}, labels...),
+	}
+	p.Resize(size, maxQueue)
+	return p
+}
+
+// Name returns the name of the pool.
+func (p *Pool) Name() string {
+	return p.name
+}
+
+// Resize changes the size and the maximum queue of the pool. Operations
+// already queued are kept even if the queue is now larger than maxQueue.
+func (p *Pool) Resize(size, maxQueue int) {
+	if size <= 0 {
+		size = DefaultSize()
+	}
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.size = size
+	p.maxQueue = maxQueue
+	p.startWorkersLocked()
+}
+
+// Stats returns the state of the pool.
+func (p *Pool) Stats() Stats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return Stats{
+		Size:     p.size,
+		MaxQueue: p.maxQueue,
+		Running:  p.running,
+		Queued:   len(p.queue),
+	}
+}
+
+// Run runs fn on a worker of the pool, and returns its error. It waits for a
+// worker to be available, unless the queue is full in which case ErrQueueFull
+// is returned. If ctx is done while fn is still queued, fn is dropped and the
+// error of ctx is returned; once fn started, Run waits for it to complete so
+// that callers may hold locks for fn.
+func (p *Pool) Run(ctx context.Context, fn func() error) error {
+	j := &job{
+		fn:     fn,
+		queued: time.Now(),
+		done:   make(chan error, 1),
+	}
+
+	p.lock.Lock()
+	if p.maxQueue > 0 && len(p.queue) >= p.maxQueue {
+		p.lock.Unlock()
+		metrics.IncrCounterWithLabels(p.key("rejected"), 1, p.labels)
+		return ErrQueueFull
+	}
+	p.queue = append(p.queue, j)
+	p.startWorkersLocked()
+	p.emitQueueDepthLocked()
+	p.lock.Unlock()
+
+	select {
+	case err := <-j.done:
+		return err
+	case <-ctx.Done():
+	}
+
+	p.lock.Lock()
+	if !j.started {
+		for i, queued := range p.queue {
+			if queued == j {
+				p.queue = append(p.queue[:i], p.queue[i+1:]...)
+				break
+			}
+		}
+		p.emitQueueDepthLocked()
+		p.lock.Unlock()
+		return ctx.Err()
+	}
+	p.lock.Unlock()
+
+	return <-j.done
+}
+
+// startWorkersLocked starts workers for the queued operations, up to the
+// size of the pool.
+func (p *Pool) startWorkersLocked() {
+	for p.workers < p.size && p.workers < len(p.queue)+p.running {
+		p.workers++
+		go p.work()
+	}
+}
+
+func (p *Pool) work() {
+	for {
+		p.lock.Lock()
+		if len(p.queue) == 0 || p.running >= p.size {
+			p.workers--
+			p.lock.Unlock()
+			return
+		}
+		j := p.queue[0]
+		p.queue = p.queue[1:]
+		j.started = true
+		p.running++
+		p.emitQueueDepthLocked()
+		p.lock.Unlock()
+
+		metrics.MeasureSinceWithLabels(p.key("wait_time"), j.queued, p.labels)
+		j.done <- j.fn()
+
+		p.lock.Lock()
+		p.running--
+		p.lock.Unlock()
+	}
+}
+
+func (p *Pool) emitQueueDepthLocked() {
+	metrics.SetGaugeWithLabels(p.key("queue_depth"), float32(len(p.queue)), p.labels)
+}
+
+func (p *Pool) key(name string) []string {
+	key := make([]string, 0, len(p.metricsKey)+1)
+	key = append(key, p.metricsKey...)
+	return append(key, name)
+}
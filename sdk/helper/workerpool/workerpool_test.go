@@ -0,0 +1,174 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPool_BoundsConcurrency(t *testing.T) {
+	p := New("test", 2, 0, nil)
+
+	var current, peak int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.Run(context.Background(), func() error {
+				n := atomic.AddInt32(&current, 1)
+				for {
+					old := atomic.LoadInt32(&peak)
+					if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+						break
+					}
+				}
+				<-release
+				atomic.AddInt32(&current, -1)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	waitFor(t, func() bool {
+		stats := p.Stats()
+		return stats.Running == 2 && stats.Queued == 4
+	})
+	close(release)
+	wg.Wait()
+
+	if peak != 2 {
+		t.Fatalf("expected at most 2 operations at once, got %d", peak)
+	}
+	if stats := p.Stats(); stats.Running != 0 || stats.Queued != 0 {
+		t.Fatalf("expected an idle pool, got %#v", stats)
+	}
+}
+
+func TestPool_ReturnsError(t *testing.T) {
+	p := New("test", 1, 0, nil)
+	expected := errors.New("failed")
+	if err := p.Run(context.Background(), func() error { return expected }); err != expected {
+		t.Fatalf("expected %v, got %v", expected, err)
+	}
+}
+
+func TestPool_QueueFull(t *testing.T) {
+	p := New("test", 1, 1, nil)
+
+	release := make(chan struct{})
+	blocked := func() error {
+		<-release
+		return nil
+	}
+	errs := make(chan error, 2)
+	go func() { errs <- p.Run(context.Background(), blocked) }()
+	waitFor(t, func() bool { return p.Stats().Running == 1 })
+	go func() { errs <- p.Run(context.Background(), blocked) }()
+	waitFor(t, func() bool { return p.Stats().Queued == 1 })
+
+	if err := p.Run(context.Background(), blocked); err != ErrQueueFull {
+		t.Fatalf("expected a full queue, got %v", err)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestPool_CanceledWhileQueued(t *testing.T) {
+	p := New("test", 1, 0, nil)
+
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Run(context.Background(), func() error {
+			<-release
+			return nil
+		})
+	}()
+	waitFor(t, func() bool { return p.Stats().Running == 1 })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ran := false
+	errs := make(chan error, 1)
+	go func() {
+		errs <- p.Run(ctx, func() error {
+			ran = true
+			return nil
+		})
+	}()
+	waitFor(t, func() bool { return p.Stats().Queued == 1 })
+	cancel()
+
+	if err := <-errs; err != context.Canceled {
+		t.Fatalf("expected the operation to be canceled, got %v", err)
+	}
+	if stats := p.Stats(); stats.Queued != 0 {
+		t.Fatalf("expected the canceled operation to be dropped, got %#v", stats)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ran {
+		t.Fatal("expected the canceled operation not to run")
+	}
+}
+
+func TestPool_Resize(t *testing.T) {
+	p := New("test", 1, 0, nil)
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Run(context.Background(), func() error {
+				<-release
+				return nil
+			})
+		}()
+	}
+	waitFor(t, func() bool {
+		stats := p.Stats()
+		return stats.Running == 1 && stats.Queued == 2
+	})
+
+	p.Resize(3, 0)
+	waitFor(t, func() bool { return p.Stats().Running == 3 })
+
+	close(release)
+	wg.Wait()
+
+	p.Resize(0, -1)
+	if stats := p.Stats(); stats.Size != DefaultSize() || stats.MaxQueue != 0 {
+		t.Fatalf("expected the default size and an unbounded queue, got %#v", stats)
+	}
+}
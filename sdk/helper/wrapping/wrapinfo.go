@@ -37,4 +37,12 @@ type ResponseWrapInfo struct {
 
 	// Controls seal wrapping behavior downstream for specific use cases
 	SealWrap bool `json:"seal_wrap" structs:"seal_wrap" mapstructure:"seal_wrap" sentinel:""`
+
+	// BoundCIDRs restricts unwrapping to requests from the given CIDRs. This
+	// doesn't get returned, it's only internal.
+	BoundCIDRs []string `json:"bound_cidrs,omitempty" structs:"bound_cidrs" mapstructure:"bound_cidrs" sentinel:""`
+
+	// NotBefore is the time before which the wrapping token cannot be
+	// unwrapped. This doesn't get returned, it's only internal.
+	NotBefore time.Time `json:"not_before" structs:"not_before" mapstructure:"not_before" sentinel:""`
 }
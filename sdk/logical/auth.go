@@ -93,6 +93,11 @@ type Auth struct {
 	// The set of CIDRs that this token can be used with
 	BoundCIDRs []*sockaddr.SockAddrMarshaler `json:"bound_cidrs"`
 
+	// AccessWindows are the time-of-day and day-of-week windows during which
+	// the token can be used, in AccessWindowTimezone
+	AccessWindows        []string `json:"access_windows"`
+	AccessWindowTimezone string   `json:"access_window_timezone"`
+
 	// CreationPath is a path that the backend can return to use in the lease.
 	// This is currently only supported for the token store where roles may
 	// change the perceived path of the lease, even though they don't change
@@ -112,6 +117,11 @@ type Auth struct {
 	// MFARequirement
 	MFARequirement *MFARequirement `json:"mfa_requirement"`
 
+	// MFAEnforcementResults records, for auditing, whether the login MFA
+	// enforcements targeting a login applied to it given their network
+	// conditions.
+	MFAEnforcementResults []*MFAEnforcementResult `json:"mfa_enforcement_results,omitempty"`
+
 	// EntityCreated is set to true if an entity is created as part of a login request
 	EntityCreated bool `json:"entity_created"`
 }
@@ -120,6 +130,14 @@ func (a *Auth) GoString() string {
 	return fmt.Sprintf("*%#v", *a)
 }
 
+// MFAEnforcementResult is the outcome of evaluating a login MFA enforcement
+// against a login request.
+type MFAEnforcementResult struct {
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+	Reason  string `json:"reason,omitempty"`
+}
+
 type PolicyResults struct {
 	Allowed          bool         `json:"allowed"`
 	GrantingPolicies []PolicyInfo `json:"granting_policies"`
@@ -98,7 +98,7 @@ func (x *EventReceived) BexprDatum() any {
 	return &EventReceivedBexpr{
 		EventType:         x.EventType,
 		Operation:         operation,
-		SourcePluginMount: x.PluginInfo.MountPath,
+		SourcePluginMount: x.PluginInfo.GetMountPath(),
 		DataPath:          dataPath,
 		Namespace:         x.Namespace,
 	}
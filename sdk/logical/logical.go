@@ -5,6 +5,7 @@ package logical
 
 import (
 	"context"
+	"time"
 
 	log "github.com/hashicorp/go-hclog"
 )
@@ -174,6 +175,16 @@ type Paths struct {
 	//
 	// For more details, consult limits/registry.go.
 	Limited []string
+
+	// Renamed maps API paths that were renamed to their new paths, so that
+	// requests to the old paths keep working while clients migrate. The
+	// router handles requests to an old path with the new path, and warns
+	// that the old path is deprecated. A path ending in '*' renames every
+	// path under the prefix, and its new path must then also end in '*'.
+	//
+	// Policies are evaluated against the path of the request, so the old
+	// paths must be kept in Root or Unauthenticated if the new paths are.
+	Renamed map[string]string
 }
 
 type Auditor interface {
@@ -192,3 +203,27 @@ type PluginVersioner interface {
 }
 
 var EmptyPluginVersion = PluginVersion{""}
+
+// PluginHealth describes the health of the process running an external plugin.
+type PluginHealth struct {
+	// Restarts is the number of times the plugin was restarted after exiting
+	// unexpectedly.
+	Restarts int
+
+	// LastRestart is when the plugin was last restarted.
+	LastRestart time.Time
+
+	// LastError is the error the plugin last exited with.
+	LastError string
+
+	// RestartLimitExceeded is set while the plugin is not restarted because it
+	// exceeded the restarts allowed by its plugin runtime.
+	RestartLimitExceeded bool
+}
+
+// PluginHealthReporter is an optional interface to return the health of the
+// process running an external plugin.
+type PluginHealthReporter interface {
+	// PluginHealth returns the health of the plugin process
+	PluginHealth() PluginHealth
+}
@@ -34,6 +34,9 @@ type PluginEnvironment struct {
 	VaultVersionPrerelease string `protobuf:"bytes,2,opt,name=vault_version_prerelease,json=vaultVersionPrerelease,proto3" json:"vault_version_prerelease,omitempty"`
 	// VaultVersionMetadata is the version metadata of the Vault server
 	VaultVersionMetadata string `protobuf:"bytes,3,opt,name=vault_version_metadata,json=vaultVersionMetadata,proto3" json:"vault_version_metadata,omitempty"`
+	// CryptoPolicy is the crypto policy of the Vault server, restricting the
+	// algorithms plugins may use
+	CryptoPolicy string `protobuf:"bytes,4,opt,name=crypto_policy,json=cryptoPolicy,proto3" json:"crypto_policy,omitempty"`
 }
 
 func (x *PluginEnvironment) Reset() {
@@ -89,12 +92,19 @@ func (x *PluginEnvironment) GetVaultVersionMetadata() string {
 	return ""
 }
 
+func (x *PluginEnvironment) GetCryptoPolicy() string {
+	if x != nil {
+		return x.CryptoPolicy
+	}
+	return ""
+}
+
 var File_sdk_logical_plugin_proto protoreflect.FileDescriptor
 
 var file_sdk_logical_plugin_proto_rawDesc = []byte{
 	0x0a, 0x18, 0x73, 0x64, 0x6b, 0x2f, 0x6c, 0x6f, 0x67, 0x69, 0x63, 0x61, 0x6c, 0x2f, 0x70, 0x6c,
 	0x75, 0x67, 0x69, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x6c, 0x6f, 0x67, 0x69,
-	0x63, 0x61, 0x6c, 0x22, 0xa8, 0x01, 0x0a, 0x11, 0x50, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x45, 0x6e,
+	0x63, 0x61, 0x6c, 0x22, 0xcd, 0x01, 0x0a, 0x11, 0x50, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x45, 0x6e,
 	0x76, 0x69, 0x72, 0x6f, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x76, 0x61, 0x75,
 	0x6c, 0x74, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
 	0x52, 0x0c, 0x76, 0x61, 0x75, 0x6c, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x38,
@@ -104,10 +114,13 @@ var file_sdk_logical_plugin_proto_rawDesc = []byte{
 	0x65, 0x72, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x12, 0x34, 0x0a, 0x16, 0x76, 0x61, 0x75, 0x6c,
 	0x74, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61,
 	0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x14, 0x76, 0x61, 0x75, 0x6c, 0x74, 0x56,
-	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x42, 0x28,
-	0x5a, 0x26, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x68, 0x61, 0x73,
-	0x68, 0x69, 0x63, 0x6f, 0x72, 0x70, 0x2f, 0x76, 0x61, 0x75, 0x6c, 0x74, 0x2f, 0x73, 0x64, 0x6b,
-	0x2f, 0x6c, 0x6f, 0x67, 0x69, 0x63, 0x61, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x23,
+	0x0a, 0x0d, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x5f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x63, 0x72, 0x79, 0x70, 0x74, 0x6f, 0x50, 0x6f, 0x6c,
+	0x69, 0x63, 0x79, 0x42, 0x28, 0x5a, 0x26, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x68, 0x61, 0x73, 0x68, 0x69, 0x63, 0x6f, 0x72, 0x70, 0x2f, 0x76, 0x61, 0x75, 0x6c,
+	0x74, 0x2f, 0x73, 0x64, 0x6b, 0x2f, 0x6c, 0x6f, 0x67, 0x69, 0x63, 0x61, 0x6c, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -126,7 +139,7 @@ var file_sdk_logical_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
 var file_sdk_logical_plugin_proto_goTypes = []interface{}{
 	(*PluginEnvironment)(nil), // 0: logical.PluginEnvironment
 }
-var file_sdk_logical_plugin_proto_depIdxs = []int32{
+var file_sdk_logical_plugin_proto_depIDxs = []int32{
 	0, // [0:0] is the sub-list for method output_type
 	0, // [0:0] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
@@ -164,11 +177,11 @@ func file_sdk_logical_plugin_proto_init() {
 			NumServices:   0,
 		},
 		GoTypes:           file_sdk_logical_plugin_proto_goTypes,
-		DependencyIndexes: file_sdk_logical_plugin_proto_depIdxs,
+		DependencyIndexes: file_sdk_logical_plugin_proto_depIDxs,
 		MessageInfos:      file_sdk_logical_plugin_proto_msgTypes,
 	}.Build()
 	File_sdk_logical_plugin_proto = out.File
 	file_sdk_logical_plugin_proto_rawDesc = nil
 	file_sdk_logical_plugin_proto_goTypes = nil
-	file_sdk_logical_plugin_proto_depIdxs = nil
+	file_sdk_logical_plugin_proto_depIDxs = nil
 }
@@ -28,6 +28,14 @@ type RequestWrapInfo struct {
 	// A flag to conforming backends that data for a given request should be
 	// seal wrapped
 	SealWrap bool `json:"seal_wrap" structs:"seal_wrap" mapstructure:"seal_wrap" sentinel:""`
+
+	// BoundCIDRs restricts the unwrapping of the wrapping token to requests
+	// from the given CIDRs
+	BoundCIDRs []string `json:"bound_cidrs,omitempty" structs:"bound_cidrs" mapstructure:"bound_cidrs" sentinel:""`
+
+	// NotBefore prevents the wrapping token from being unwrapped before the
+	// given time
+	NotBefore time.Time `json:"not_before" structs:"not_before" mapstructure:"not_before" sentinel:""`
 }
 
 func (r *RequestWrapInfo) SentinelGet(key string) (interface{}, error) {
@@ -455,6 +463,10 @@ const (
 	RevokeOperation   Operation = "revoke"
 	RenewOperation              = "renew"
 	RollbackOperation           = "rollback"
+
+	// RotationOperation is sent to the path of a root credential registered
+	// with the rotation manager when it is due for rotation.
+	RotationOperation = "rotate"
 )
 
 type MFACreds map[string][]string
@@ -536,6 +548,32 @@ func CreateContextOriginalRequestPath(parent context.Context, value string) cont
 	return context.WithValue(parent, ctxKeyOriginalRequestPath{}, value)
 }
 
+// ctxKeyListenerAddress is a custom type used as a key in context.Context to
+// store the configured address of the listener through which a request was
+// received.
+type ctxKeyListenerAddress struct{}
+
+// String returns a string representation of the receiver type.
+func (c ctxKeyListenerAddress) String() string {
+	return "listener_address"
+}
+
+// ContextListenerAddressValue examines the provided context.Context for the
+// listener address value and returns it as a string value if it's found along
+// with the ok value set to true; otherwise the ok return value is false.
+func ContextListenerAddressValue(ctx context.Context) (value string, ok bool) {
+	value, ok = ctx.Value(ctxKeyListenerAddress{}).(string)
+
+	return
+}
+
+// CreateContextListenerAddress creates a new context.Context based on the
+// provided parent that also includes the provided listener address value for
+// the ctxKeyListenerAddress key.
+func CreateContextListenerAddress(parent context.Context, value string) context.Context {
+	return context.WithValue(parent, ctxKeyListenerAddress{}, value)
+}
+
 type ctxKeyOriginalBody struct{}
 
 func ContextOriginalBodyValue(ctx context.Context) (io.ReadCloser, bool) {
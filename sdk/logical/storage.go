@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/physical"
 )
 
 // ErrReadOnly is returned when a backend does not support
@@ -36,6 +37,16 @@ type Storage interface {
 	Delete(context.Context, string) error
 }
 
+// PaginatedStorage is an optional interface for storage which is able to list
+// the keys under a prefix one page at a time. Use the ListPage helper to list
+// a page from any storage.
+type PaginatedStorage interface {
+	// ListPage lists the keys under the given prefix which sort after the
+	// given key, in lexicographic order, returning at most limit of them. A
+	// limit of zero or less returns all the keys after the given one.
+	ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error)
+}
+
 // StorageEntry is the entry for an item in a Storage implementation.
 type StorageEntry struct {
 	Key      string
@@ -97,6 +108,64 @@ func ScanView(ctx context.Context, view ClearableView, cb func(path string)) err
 	return nil
 }
 
+// ListPage lists a page of the keys under the given prefix of a view. If the
+// view is a PaginatedStorage the page is listed by the view itself, otherwise
+// all the keys are listed and then paginated.
+func ListPage(ctx context.Context, view ClearableView, prefix string, after string, limit int) ([]string, error) {
+	if pv, ok := view.(PaginatedStorage); ok {
+		return pv.ListPage(ctx, prefix, after, limit)
+	}
+
+	keys, err := view.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return physical.PaginateKeys(keys, after, limit), nil
+}
+
+// ScanViewPaginated is used to scan all the keys in a view iteratively like
+// AbortableScanView, but lists at most pageSize keys at a time rather than
+// all the keys of a prefix at once. The scan is aborted if cb returns false.
+func ScanViewPaginated(ctx context.Context, view ClearableView, pageSize int, cb func(path string) (cont bool)) error {
+	frontier := []string{""}
+	for len(frontier) > 0 {
+		n := len(frontier)
+		current := frontier[n-1]
+		frontier = frontier[:n-1]
+
+		after := ""
+		for {
+			// List the next page of the contents
+			contents, err := ListPage(ctx, view, current, after, pageSize)
+			if err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("list failed at path %q: {{err}}", current), err)
+			}
+
+			// Handle the contents in the directory
+			for _, c := range contents {
+				// Exit if the context has been canceled
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				fullPath := current + c
+				if strings.HasSuffix(c, "/") {
+					frontier = append(frontier, fullPath)
+				} else {
+					if !cb(fullPath) {
+						return nil
+					}
+				}
+			}
+
+			if pageSize <= 0 || len(contents) < pageSize {
+				break
+			}
+			after = contents[len(contents)-1]
+		}
+	}
+	return nil
+}
+
 // AbortableScanView is used to scan all the keys in a view iteratively,
 // but will abort the scan if cb returns false
 func AbortableScanView(ctx context.Context, view ClearableView, cb func(path string) (cont bool)) error {
@@ -0,0 +1,148 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logical
+
+import (
+	"context"
+	"errors"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+)
+
+// DefaultStorageCacheSize is used if no size is specified for NewStorageCache
+const DefaultStorageCacheSize = 1024
+
+// StorageDecodeFunc decodes the value cached for a storage entry. It is not
+// called for missing entries.
+type StorageDecodeFunc func(entry *StorageEntry) (interface{}, error)
+
+// StorageCache is a size-bounded cache of the values decoded from the entries
+// of a backend's storage, keyed by storage path. Missing entries are cached
+// too.
+//
+// The writes made through the Storage returned by View invalidate the cached
+// value of the path they write. Writes made on other nodes are not seen, so
+// backends must also call Invalidate from their invalidate function.
+//
+// Cached values are shared between callers and must not be modified.
+type StorageCache struct {
+	lru    *lru.TwoQueueCache
+	locks  []*locksutil.LockEntry
+	decode StorageDecodeFunc
+}
+
+// storageCacheMissing is the value cached for missing entries
+type storageCacheMissing struct{}
+
+// NewStorageCache returns a storage cache holding up to size values decoded
+// with decode. If no size is provided, the default size is used.
+func NewStorageCache(size int, decode StorageDecodeFunc) (*StorageCache, error) {
+	if decode == nil {
+		return nil, errors.New("decode function is required")
+	}
+	if size <= 0 {
+		size = DefaultStorageCacheSize
+	}
+
+	cache, err := lru.New2Q(size)
+	if err != nil {
+		return nil, err
+	}
+	return &StorageCache{
+		lru:    cache,
+		locks:  locksutil.CreateLocks(),
+		decode: decode,
+	}, nil
+}
+
+// Get returns the decoded value of the entry at key, reading it from the
+// storage if it is not cached. It returns nil if the entry does not exist.
+func (c *StorageCache) Get(ctx context.Context, s Storage, key string) (interface{}, error) {
+	lock := locksutil.LockForKey(c.locks, key)
+	lock.RLock()
+	defer lock.RUnlock()
+
+	if raw, ok := c.lru.Get(key); ok {
+		if _, ok := raw.(storageCacheMissing); ok {
+			return nil, nil
+		}
+		return raw, nil
+	}
+
+	entry, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		c.lru.Add(key, storageCacheMissing{})
+		return nil, nil
+	}
+
+	value, err := c.decode(entry)
+	if err != nil {
+		return nil, err
+	}
+	c.lru.Add(key, value)
+	return value, nil
+}
+
+// Invalidate removes the cached value of key. Its signature matches the
+// invalidate function of backends, so that it can be called from it.
+func (c *StorageCache) Invalidate(_ context.Context, key string) {
+	lock := locksutil.LockForKey(c.locks, key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	c.lru.Remove(key)
+}
+
+// Purge removes all the cached values
+func (c *StorageCache) Purge() {
+	// Lock the world
+	for _, lock := range c.locks {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	c.lru.Purge()
+}
+
+// View returns the storage s, with the writes made through it invalidating
+// the cached values of the paths they write.
+func (c *StorageCache) View(s Storage) Storage {
+	return &storageCacheView{
+		Storage: s,
+		cache:   c,
+	}
+}
+
+type storageCacheView struct {
+	Storage
+	cache *StorageCache
+}
+
+func (v *storageCacheView) Put(ctx context.Context, entry *StorageEntry) error {
+	if entry == nil {
+		return errors.New("cannot write nil entry")
+	}
+
+	lock := locksutil.LockForKey(v.cache.locks, entry.Key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// The cached value is removed even if the write fails, since the write
+	// may have been applied nonetheless
+	defer v.cache.lru.Remove(entry.Key)
+	return v.Storage.Put(ctx, entry)
+}
+
+func (v *storageCacheView) Delete(ctx context.Context, key string) error {
+	lock := locksutil.LockForKey(v.cache.locks, key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	defer v.cache.lru.Remove(key)
+	return v.Storage.Delete(ctx, key)
+}
@@ -0,0 +1,121 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package logical
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCachedConfig struct {
+	Value string `json:"value"`
+}
+
+// countingStorage counts the reads made from the storage it wraps
+type countingStorage struct {
+	Storage
+	gets int
+}
+
+func (s *countingStorage) Get(ctx context.Context, key string) (*StorageEntry, error) {
+	s.gets++
+	return s.Storage.Get(ctx, key)
+}
+
+func testStorageCache(t *testing.T, size int) (*StorageCache, *countingStorage) {
+	t.Helper()
+
+	cache, err := NewStorageCache(size, func(entry *StorageEntry) (interface{}, error) {
+		config := new(testCachedConfig)
+		if err := entry.DecodeJSON(config); err != nil {
+			return nil, err
+		}
+		return config, nil
+	})
+	require.NoError(t, err)
+	return cache, &countingStorage{Storage: &InmemStorage{}}
+}
+
+func TestStorageCache(t *testing.T) {
+	ctx := context.Background()
+	cache, s := testStorageCache(t, 0)
+	view := cache.View(s)
+
+	// Missing entries are cached
+	value, err := cache.Get(ctx, view, "config")
+	require.NoError(t, err)
+	require.Nil(t, value)
+	_, err = cache.Get(ctx, view, "config")
+	require.NoError(t, err)
+	require.Equal(t, 1, s.gets)
+
+	// Writes through the view invalidate the cached value
+	entry, err := StorageEntryJSON("config", &testCachedConfig{Value: "a"})
+	require.NoError(t, err)
+	require.NoError(t, view.Put(ctx, entry))
+
+	for i := 0; i < 2; i++ {
+		value, err = cache.Get(ctx, view, "config")
+		require.NoError(t, err)
+		require.Equal(t, &testCachedConfig{Value: "a"}, value)
+	}
+	require.Equal(t, 2, s.gets)
+
+	require.NoError(t, view.Delete(ctx, "config"))
+	value, err = cache.Get(ctx, view, "config")
+	require.NoError(t, err)
+	require.Nil(t, value)
+	require.Equal(t, 3, s.gets)
+
+	// Writes which do not go through the view are only seen once the
+	// key is invalidated
+	entry, err = StorageEntryJSON("config", &testCachedConfig{Value: "b"})
+	require.NoError(t, err)
+	require.NoError(t, s.Put(ctx, entry))
+	value, err = cache.Get(ctx, view, "config")
+	require.NoError(t, err)
+	require.Nil(t, value)
+
+	cache.Invalidate(ctx, "config")
+	value, err = cache.Get(ctx, view, "config")
+	require.NoError(t, err)
+	require.Equal(t, &testCachedConfig{Value: "b"}, value)
+
+	cache.Purge()
+	_, err = cache.Get(ctx, view, "config")
+	require.NoError(t, err)
+	require.Equal(t, 5, s.gets)
+}
+
+func TestStorageCache_Size(t *testing.T) {
+	ctx := context.Background()
+	cache, s := testStorageCache(t, 2)
+
+	for _, key := range []string{"a", "b", "c", "a"} {
+		_, err := cache.Get(ctx, s, key)
+		require.NoError(t, err)
+	}
+
+	// "a" was evicted to make room for "c"
+	require.Equal(t, 4, s.gets)
+}
+
+func TestStorageCache_DecodeError(t *testing.T) {
+	ctx := context.Background()
+	cache, s := testStorageCache(t, 0)
+
+	require.NoError(t, s.Put(ctx, &StorageEntry{Key: "config", Value: []byte("{")}))
+	for i := 0; i < 2; i++ {
+		_, err := cache.Get(ctx, s, "config")
+		require.Error(t, err)
+	}
+
+	// Decoding errors are not cached
+	require.Equal(t, 2, s.gets)
+
+	_, err := NewStorageCache(0, nil)
+	require.Error(t, err)
+}
@@ -54,6 +54,58 @@ func TestScanView_CancelContext(t *testing.T) {
 	}
 }
 
+func TestScanViewPaginated(t *testing.T) {
+	s := prepKeyStorage(t)
+
+	for _, pageSize := range []int{0, 1, 2, 100} {
+		keys := make([]string, 0)
+		err := ScanViewPaginated(context.Background(), s, pageSize, func(path string) bool {
+			keys = append(keys, path)
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := deep.Equal(keys, keyList); diff != nil {
+			t.Fatalf("page size %d: %v", pageSize, diff)
+		}
+	}
+
+	// The scan stops once the callback returns false
+	var i int
+	err := ScanViewPaginated(context.Background(), s, 1, func(path string) bool {
+		i++
+		return i < 3
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != 3 {
+		t.Errorf("Want i==3, got %d", i)
+	}
+}
+
+func TestListPage(t *testing.T) {
+	s := prepKeyStorage(t)
+
+	// A view without ListPage of its own is paginated from its listing
+	views := map[string]ClearableView{
+		"paginated": s,
+		"listed":    struct{ ClearableView }{s},
+	}
+	for name, view := range views {
+		keys, err := ListPage(context.Background(), view, "", "c/", 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := deep.Equal(keys, []string{"d", "foo", "foo/"}); diff != nil {
+			t.Fatalf("%s: %v", name, diff)
+		}
+	}
+}
+
 func TestCollectKeys(t *testing.T) {
 	s := prepKeyStorage(t)
 
@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/license"
 	"github.com/hashicorp/vault/sdk/helper/pluginutil"
 	"github.com/hashicorp/vault/sdk/helper/wrapping"
+	"github.com/hashicorp/vault/sdk/rotation"
 )
 
 // SystemView exposes system configuration information in a safe way
@@ -100,6 +101,20 @@ type SystemView interface {
 
 	// GenerateIdentityToken returns an identity token for the requesting plugin.
 	GenerateIdentityToken(ctx context.Context, req *pluginutil.IdentityTokenRequest) (*pluginutil.IdentityTokenResponse, error)
+
+	// RegisterRotationJob registers a root credential of the plugin's mount
+	// with the rotation manager, which sends a RotationOperation to the
+	// credential's path whenever it is due. Returns the ID of the job.
+	RegisterRotationJob(ctx context.Context, req *rotation.RotationJobConfigureRequest) (string, error)
+
+	// DeregisterRotationJob removes a root credential of the plugin's mount
+	// from the rotation manager.
+	DeregisterRotationJob(ctx context.Context, req *rotation.RotationJobDeregisterRequest) error
+
+	// RotationFreeze returns when the freeze period of the named rotation
+	// calendar containing t ends, or the zero time if t is not in a freeze
+	// period. Scheduled rotations should be postponed until then.
+	RotationFreeze(ctx context.Context, calendar string, t time.Time) (time.Time, error)
 }
 
 type PasswordPolicy interface {
@@ -282,6 +297,18 @@ func (d StaticSystemView) GenerateIdentityToken(_ context.Context, _ *pluginutil
 	return nil, errors.New("GenerateIdentityToken is not implemented in StaticSystemView")
 }
 
+func (d StaticSystemView) RegisterRotationJob(_ context.Context, _ *rotation.RotationJobConfigureRequest) (string, error) {
+	return "", errors.New("RegisterRotationJob is not implemented in StaticSystemView")
+}
+
+func (d StaticSystemView) DeregisterRotationJob(_ context.Context, _ *rotation.RotationJobDeregisterRequest) error {
+	return errors.New("DeregisterRotationJob is not implemented in StaticSystemView")
+}
+
+func (d StaticSystemView) RotationFreeze(_ context.Context, _ string, _ time.Time) (time.Time, error) {
+	return time.Time{}, errors.New("RotationFreeze is not implemented in StaticSystemView")
+}
+
 func (d StaticSystemView) APILockShouldBlockRequest() (bool, error) {
 	return d.APILockShouldBlockRequestVal, nil
 }
@@ -166,6 +166,11 @@ type TokenEntry struct {
 	// The set of CIDRs that this token can be used with
 	BoundCIDRs []*sockaddr.SockAddrMarshaler `json:"bound_cidrs" sentinel:""`
 
+	// AccessWindows are the time-of-day and day-of-week windows during which
+	// this token can be used, in AccessWindowTimezone
+	AccessWindows        []string `json:"access_windows" sentinel:""`
+	AccessWindowTimezone string   `json:"access_window_timezone" sentinel:""`
+
 	// NamespaceID is the identifier of the namespace to which this token is
 	// confined to. Do not return this value over the API when the token is
 	// being looked up.
@@ -85,6 +85,7 @@ var (
 	_ ToggleablePurgemonster = (*Cache)(nil)
 	_ ToggleablePurgemonster = (*TransactionalCache)(nil)
 	_ Backend                = (*Cache)(nil)
+	_ PaginatedBackend       = (*Cache)(nil)
 	_ Transactional          = (*TransactionalCache)(nil)
 	_ TransactionalLimits    = (*TransactionalCache)(nil)
 )
@@ -226,6 +227,11 @@ func (c *Cache) List(ctx context.Context, prefix string) ([]string, error) {
 	return c.backend.List(ctx, prefix)
 }
 
+func (c *Cache) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	// Always pass-through, as with List
+	return ListPage(ctx, c.backend, prefix, after, limit)
+}
+
 func (c *TransactionalCache) Locks() []*locksutil.LockEntry {
 	return c.locks
 }
@@ -30,8 +30,9 @@ type TransactionalStorageEncoding struct {
 
 // Verify StorageEncoding satisfies the correct interfaces
 var (
-	_ Backend       = (*StorageEncoding)(nil)
-	_ Transactional = (*TransactionalStorageEncoding)(nil)
+	_ Backend          = (*StorageEncoding)(nil)
+	_ PaginatedBackend = (*StorageEncoding)(nil)
+	_ Transactional    = (*TransactionalStorageEncoding)(nil)
 )
 
 // NewStorageEncoding returns a wrapped physical backend and verifies the key
@@ -83,6 +84,10 @@ func (e *StorageEncoding) Delete(ctx context.Context, key string) error {
 	return e.Backend.Delete(ctx, key)
 }
 
+func (e *StorageEncoding) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	return ListPage(ctx, e.Backend, prefix, after, limit)
+}
+
 func (e *TransactionalStorageEncoding) Transaction(ctx context.Context, txns []*TxnEntry) error {
 	for _, txn := range txns {
 		if !utf8.ValidString(txn.Entry.Key) {
@@ -35,8 +35,9 @@ type TransactionalErrorInjector struct {
 
 // Verify ErrorInjector satisfies the correct interfaces
 var (
-	_ Backend       = (*ErrorInjector)(nil)
-	_ Transactional = (*TransactionalErrorInjector)(nil)
+	_ Backend          = (*ErrorInjector)(nil)
+	_ PaginatedBackend = (*ErrorInjector)(nil)
+	_ Transactional    = (*TransactionalErrorInjector)(nil)
 )
 
 // NewErrorInjector returns a wrapped physical backend to inject error
@@ -105,6 +106,13 @@ func (e *ErrorInjector) List(ctx context.Context, prefix string) ([]string, erro
 	return e.backend.List(ctx, prefix)
 }
 
+func (e *ErrorInjector) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	if err := e.addError(); err != nil {
+		return nil, err
+	}
+	return ListPage(ctx, e.backend, prefix, after, limit)
+}
+
 func (e *TransactionalErrorInjector) Transaction(ctx context.Context, txns []*TxnEntry) error {
 	if err := e.addError(); err != nil {
 		return err
@@ -25,6 +25,7 @@ func TestCache(t *testing.T) {
 	cache.SetEnabled(true)
 	physical.ExerciseBackend(t, cache)
 	physical.ExerciseBackend_ListPrefix(t, cache)
+	physical.ExerciseBackend_ListPage(t, cache)
 }
 
 func TestCache_Purge(t *testing.T) {
@@ -23,6 +23,7 @@ import (
 // Verify interfaces are satisfied
 var (
 	_ physical.Backend             = (*InmemBackend)(nil)
+	_ physical.PaginatedBackend    = (*InmemBackend)(nil)
 	_ physical.HABackend           = (*InmemHABackend)(nil)
 	_ physical.HABackend           = (*TransactionalInmemHABackend)(nil)
 	_ physical.Lock                = (*InmemLock)(nil)
@@ -322,6 +323,51 @@ func (i *InmemBackend) ListInternal(ctx context.Context, prefix string) ([]strin
 	return out, nil
 }
 
+// ListPage is used to list a page of the keys under a given prefix, up to
+// the next prefix, which sort after the given key.
+func (i *InmemBackend) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	i.permitPool.Acquire()
+	defer i.permitPool.Release()
+
+	i.RLock()
+	defer i.RUnlock()
+
+	return i.ListPageInternal(ctx, prefix, after, limit)
+}
+
+func (i *InmemBackend) ListPageInternal(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	if i.logOps {
+		i.logger.Trace("list page", "prefix", prefix, "after", after, "limit", limit)
+	}
+	if atomic.LoadUint32(i.failList) != 0 {
+		return nil, ListDisabledError
+	}
+
+	// The tree is walked in lexicographic order, so the keys of a 'folder'
+	// are adjacent to each other
+	var out []string
+	walkFn := func(s string, v interface{}) bool {
+		trimmed := strings.TrimPrefix(s, prefix)
+		if sep := strings.Index(trimmed, "/"); sep != -1 {
+			trimmed = trimmed[:sep+1]
+		}
+		if trimmed <= after || (len(out) > 0 && out[len(out)-1] == trimmed) {
+			return false
+		}
+		out = append(out, trimmed)
+		return limit > 0 && len(out) >= limit
+	}
+	i.root.WalkPrefix(prefix, walkFn)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return out, nil
+}
+
 func (i *InmemBackend) FailList(fail bool) {
 	var val uint32
 	if fail {
@@ -4,6 +4,7 @@
 package inmem
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -61,6 +62,12 @@ func NewTransactionalInmemHA(_ map[string]string, logger log.Logger) (physical.B
 	return in, nil
 }
 
+// ListPage is used to list a page of the keys under a given prefix, which
+// sort after the given key.
+func (i *InmemHABackend) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	return physical.ListPage(ctx, i.Backend, prefix, after, limit)
+}
+
 // LockWith is used for mutual exclusion based on the given key.
 func (i *InmemHABackend) LockWith(key, value string) (physical.Lock, error) {
 	l := &InmemLock{
@@ -20,4 +20,5 @@ func TestInmem(t *testing.T) {
 	}
 	physical.ExerciseBackend(t, inm)
 	physical.ExerciseBackend_ListPrefix(t, inm)
+	physical.ExerciseBackend_ListPage(t, inm)
 }
@@ -37,8 +37,9 @@ type TransactionalLatencyInjector struct {
 
 // Verify LatencyInjector satisfies the correct interfaces
 var (
-	_ Backend       = (*LatencyInjector)(nil)
-	_ Transactional = (*TransactionalLatencyInjector)(nil)
+	_ Backend          = (*LatencyInjector)(nil)
+	_ PaginatedBackend = (*LatencyInjector)(nil)
+	_ Transactional    = (*TransactionalLatencyInjector)(nil)
 )
 
 // NewLatencyInjector returns a wrapped physical backend to simulate latency
@@ -112,6 +113,12 @@ func (l *LatencyInjector) List(ctx context.Context, prefix string) ([]string, er
 	return l.backend.List(ctx, prefix)
 }
 
+// ListPage is a latent list page request
+func (l *LatencyInjector) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	l.addLatency()
+	return ListPage(ctx, l.backend, prefix, after, limit)
+}
+
 // Transaction is a latent transaction request
 func (l *TransactionalLatencyInjector) Transaction(ctx context.Context, txns []*TxnEntry) error {
 	l.addLatency()
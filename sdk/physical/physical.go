@@ -5,6 +5,7 @@ package physical
 
 import (
 	"context"
+	"sort"
 	"strings"
 
 	log "github.com/hashicorp/go-hclog"
@@ -48,6 +49,18 @@ type Backend interface {
 	List(ctx context.Context, prefix string) ([]string, error)
 }
 
+// PaginatedBackend is an optional interface for backends which are able to
+// list the keys under a prefix one page at a time, rather than materializing
+// all of them at once. Use the ListPage helper to list a page from any
+// backend.
+type PaginatedBackend interface {
+	// ListPage is used to list the keys under a given prefix, up to the next
+	// prefix, which sort after the given key. Keys are returned in
+	// lexicographic order, and at most limit of them are returned. A limit
+	// of zero or less returns all the keys after the given one.
+	ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error)
+}
+
 // HABackend is an extensions to the standard physical
 // backend to support high-availability. Vault only expects to
 // use mutual exclusion to allow multiple instances to act as a
@@ -187,6 +200,39 @@ func (c *PermitPool) CurrentPermits() int {
 	return len(c.sem)
 }
 
+// ListPage lists a page of the keys under the given prefix of a backend. If
+// the backend is a PaginatedBackend the page is listed by the backend itself,
+// otherwise all the keys are listed and then paginated.
+func ListPage(ctx context.Context, b Backend, prefix string, after string, limit int) ([]string, error) {
+	if pb, ok := b.(PaginatedBackend); ok {
+		return pb.ListPage(ctx, prefix, after, limit)
+	}
+
+	keys, err := b.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return PaginateKeys(keys, after, limit), nil
+}
+
+// PaginateKeys is a shared helper function which returns the page of at most
+// limit keys sorting after the given key, for backends which are unable to
+// paginate natively. The keys are sorted in place.
+func PaginateKeys(keys []string, after string, limit int) []string {
+	sort.Strings(keys)
+	if after != "" {
+		idx := sort.SearchStrings(keys, after)
+		if idx < len(keys) && keys[idx] == after {
+			idx++
+		}
+		keys = keys[idx:]
+	}
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
 // Prefixes is a shared helper function returns all parent 'folders' for a
 // given vault key.
 // e.g. for 'foo/bar/baz', it returns ['foo', 'foo/bar']
@@ -0,0 +1,31 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package physical
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPaginateKeys(t *testing.T) {
+	cases := []struct {
+		after    string
+		limit    int
+		expected []string
+	}{
+		{"", 0, []string{"a", "b", "b/", "c"}},
+		{"", 2, []string{"a", "b"}},
+		{"a", 2, []string{"b", "b/"}},
+		{"b/", 0, []string{"c"}},
+		{"ab", 1, []string{"b"}},
+		{"c", 0, []string{}},
+	}
+	for _, tc := range cases {
+		keys := []string{"c", "b/", "a", "b"}
+		out := PaginateKeys(keys, tc.after, tc.limit)
+		if !reflect.DeepEqual(out, tc.expected) {
+			t.Errorf("page after %q with limit %d expected %v: %v", tc.after, tc.limit, tc.expected, out)
+		}
+	}
+}
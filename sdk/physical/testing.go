@@ -304,6 +304,70 @@ func ExerciseBackend_ListPrefix(t testing.TB, b Backend) {
 	}
 }
 
+// ExerciseBackend_ListPage exercises the ListPage helper against the backend,
+// which uses the backend's own pagination if it is a PaginatedBackend.
+func ExerciseBackend_ListPage(t testing.TB, b Backend) {
+	t.Helper()
+	ctx := context.Background()
+
+	keys := []string{"a", "b", "b/c", "b/d/e", "b0", "c", "d/e", "f"}
+	for _, key := range keys {
+		if err := b.Put(ctx, &Entry{Key: key, Value: []byte("test")}); err != nil {
+			t.Fatalf("failed to put %q: %v", key, err)
+		}
+	}
+	defer func() {
+		for _, key := range keys {
+			_ = b.Delete(ctx, key)
+		}
+	}()
+
+	cases := []struct {
+		prefix   string
+		after    string
+		limit    int
+		expected []string
+	}{
+		{"", "", 0, []string{"a", "b", "b/", "b0", "c", "d/", "f"}},
+		{"", "", 3, []string{"a", "b", "b/"}},
+		{"", "b", 2, []string{"b/", "b0"}},
+		{"", "b/", 2, []string{"b0", "c"}},
+		{"", "c", 0, []string{"d/", "f"}},
+		{"", "f", 3, nil},
+		{"b/", "", 0, []string{"c", "d/"}},
+		{"b/", "c", 1, []string{"d/"}},
+		{"b/", "d/", 1, nil},
+	}
+	for _, tc := range cases {
+		out, err := ListPage(ctx, b, tc.prefix, tc.after, tc.limit)
+		if err != nil {
+			t.Fatalf("list page of %q after %q: %v", tc.prefix, tc.after, err)
+		}
+		if len(out) != len(tc.expected) || (len(out) > 0 && !reflect.DeepEqual(out, tc.expected)) {
+			t.Errorf("page of %q after %q with limit %d expected %v: %v", tc.prefix, tc.after, tc.limit, tc.expected, out)
+		}
+	}
+
+	// Walking the pages should list all the keys
+	var all []string
+	after := ""
+	for {
+		page, err := ListPage(ctx, b, "", after, 2)
+		if err != nil {
+			t.Fatalf("list page after %q: %v", after, err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		all = append(all, page...)
+		after = page[len(page)-1]
+	}
+	expected := []string{"a", "b", "b/", "b0", "c", "d/", "f"}
+	if !reflect.DeepEqual(all, expected) {
+		t.Errorf("pages expected %v: %v", expected, all)
+	}
+}
+
 func ExerciseHABackend(t testing.TB, b HABackend, b2 HABackend) {
 	t.Helper()
 
@@ -16,6 +16,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/wrapping"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/plugin/pb"
+	"github.com/hashicorp/vault/sdk/rotation"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -226,6 +227,45 @@ func (s *gRPCSystemViewClient) GenerateIdentityToken(ctx context.Context, req *p
 	}, nil
 }
 
+func (s *gRPCSystemViewClient) RegisterRotationJob(ctx context.Context, req *rotation.RotationJobConfigureRequest) (string, error) {
+	resp, err := s.client.RegisterRotationJob(ctx, &pb.RegisterRotationJobRequest{
+		Name:             req.Name,
+		ReqPath:          req.ReqPath,
+		RotationSchedule: req.RotationSchedule,
+		RotationWindow:   int64(req.RotationWindow.Seconds()),
+		RotationPeriod:   int64(req.RotationPeriod.Seconds()),
+		RotationJitter:   int64(req.RotationJitter.Seconds()),
+		RotationCalendar: req.RotationCalendar,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.RotationID, nil
+}
+
+func (s *gRPCSystemViewClient) DeregisterRotationJob(ctx context.Context, req *rotation.RotationJobDeregisterRequest) error {
+	_, err := s.client.DeregisterRotationJob(ctx, &pb.DeregisterRotationJobRequest{
+		ReqPath: req.ReqPath,
+	})
+	return err
+}
+
+func (s *gRPCSystemViewClient) RotationFreeze(ctx context.Context, calendar string, t time.Time) (time.Time, error) {
+	resp, err := s.client.RotationFreeze(ctx, &pb.RotationFreezeRequest{
+		Calendar: calendar,
+		Time:     t.Unix(),
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if resp.FrozenUntil == 0 {
+		return time.Time{}, nil
+	}
+
+	return time.Unix(resp.FrozenUntil, 0), nil
+}
+
 type gRPCSystemViewServer struct {
 	pb.UnimplementedSystemViewServer
 
@@ -429,3 +469,62 @@ func (s *gRPCSystemViewServer) GenerateIdentityToken(ctx context.Context, req *p
 		TTL:   int64(res.TTL.Seconds()),
 	}, nil
 }
+
+func (s *gRPCSystemViewServer) RegisterRotationJob(ctx context.Context, req *pb.RegisterRotationJobRequest) (*pb.RegisterRotationJobResponse, error) {
+	if s.impl == nil {
+		return nil, errMissingSystemView
+	}
+
+	rotationID, err := s.impl.RegisterRotationJob(ctx, &rotation.RotationJobConfigureRequest{
+		Name:             req.GetName(),
+		ReqPath:          req.GetReqPath(),
+		RotationSchedule: req.GetRotationSchedule(),
+		RotationWindow:   time.Duration(req.GetRotationWindow()) * time.Second,
+		RotationPeriod:   time.Duration(req.GetRotationPeriod()) * time.Second,
+		RotationJitter:   time.Duration(req.GetRotationJitter()) * time.Second,
+		RotationCalendar: req.GetRotationCalendar(),
+	})
+	if err != nil {
+		return &pb.RegisterRotationJobResponse{}, status.Errorf(codes.Internal,
+			"failed to register rotation job: %s", err)
+	}
+
+	return &pb.RegisterRotationJobResponse{
+		RotationID: rotationID,
+	}, nil
+}
+
+func (s *gRPCSystemViewServer) DeregisterRotationJob(ctx context.Context, req *pb.DeregisterRotationJobRequest) (*pb.Empty, error) {
+	if s.impl == nil {
+		return nil, errMissingSystemView
+	}
+
+	err := s.impl.DeregisterRotationJob(ctx, &rotation.RotationJobDeregisterRequest{
+		ReqPath: req.GetReqPath(),
+	})
+	if err != nil {
+		return &pb.Empty{}, status.Errorf(codes.Internal,
+			"failed to deregister rotation job: %s", err)
+	}
+
+	return &pb.Empty{}, nil
+}
+
+func (s *gRPCSystemViewServer) RotationFreeze(ctx context.Context, req *pb.RotationFreezeRequest) (*pb.RotationFreezeReply, error) {
+	if s.impl == nil {
+		return nil, errMissingSystemView
+	}
+
+	frozenUntil, err := s.impl.RotationFreeze(ctx, req.GetCalendar(), time.Unix(req.GetTime(), 0))
+	if err != nil {
+		return &pb.RotationFreezeReply{}, status.Errorf(codes.Internal,
+			"failed to read rotation calendar: %s", err)
+	}
+	if frozenUntil.IsZero() {
+		return &pb.RotationFreezeReply{}, nil
+	}
+
+	return &pb.RotationFreezeReply{
+		FrozenUntil: frozenUntil.Unix(),
+	}, nil
+}
@@ -5,6 +5,7 @@ package plugin
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/plugin/pb"
+	"github.com/hashicorp/vault/sdk/rotation"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/proto"
 )
@@ -281,3 +283,90 @@ func TestSystem_GRPC_GeneratePasswordFromPolicy(t *testing.T) {
 		t.Fatalf("Actual password: %s\nExpected password: %s", password, expectedPassword)
 	}
 }
+
+type rotationSystemView struct {
+	logical.StaticSystemView
+	registered   *rotation.RotationJobConfigureRequest
+	deregistered *rotation.RotationJobDeregisterRequest
+}
+
+func (r *rotationSystemView) RotationFreeze(_ context.Context, calendar string, t time.Time) (time.Time, error) {
+	if calendar != "holidays" {
+		return time.Time{}, fmt.Errorf("rotation calendar %q not found", calendar)
+	}
+	if t.Month() == time.December && t.Day() == 25 {
+		return time.Date(t.Year(), time.December, 26, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, nil
+}
+
+func (r *rotationSystemView) RegisterRotationJob(_ context.Context, req *rotation.RotationJobConfigureRequest) (string, error) {
+	r.registered = req
+	return "rotation-id", nil
+}
+
+func (r *rotationSystemView) DeregisterRotationJob(_ context.Context, req *rotation.RotationJobDeregisterRequest) error {
+	r.deregistered = req
+	return nil
+}
+
+func TestSystem_GRPC_RotationJobs(t *testing.T) {
+	sys := &rotationSystemView{}
+	client, server := plugin.TestGRPCConn(t, func(s *grpc.Server) {
+		pb.RegisterSystemViewServer(s, &gRPCSystemViewServer{
+			impl: sys,
+		})
+	})
+	defer server.Stop()
+	defer client.Close()
+
+	testSystemView := newGRPCSystemView(client)
+
+	expected := &rotation.RotationJobConfigureRequest{
+		Name:             "root",
+		ReqPath:          "config/root",
+		RotationSchedule: "0 * * * SAT",
+		RotationWindow:   time.Hour,
+		RotationJitter:   time.Minute,
+		RotationCalendar: "holidays",
+	}
+	id, err := testSystemView.RegisterRotationJob(context.Background(), expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "rotation-id" {
+		t.Fatalf("expected rotation id %q, got %q", "rotation-id", id)
+	}
+	if !reflect.DeepEqual(expected, sys.registered) {
+		t.Fatalf("expected: %#v, got: %#v", expected, sys.registered)
+	}
+
+	err = testSystemView.DeregisterRotationJob(context.Background(), &rotation.RotationJobDeregisterRequest{ReqPath: "config/root"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sys.deregistered == nil || sys.deregistered.ReqPath != "config/root" {
+		t.Fatalf("bad deregistration: %#v", sys.deregistered)
+	}
+
+	christmas := time.Date(2026, time.December, 25, 12, 0, 0, 0, time.UTC)
+	frozenUntil, err := testSystemView.RotationFreeze(context.Background(), "holidays", christmas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := christmas.Add(12 * time.Hour); !frozenUntil.Equal(expected) {
+		t.Fatalf("expected freeze until %s, got %s", expected, frozenUntil)
+	}
+
+	frozenUntil, err = testSystemView.RotationFreeze(context.Background(), "holidays", christmas.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !frozenUntil.IsZero() {
+		t.Fatalf("expected no freeze, got %s", frozenUntil)
+	}
+
+	if _, err := testSystemView.RotationFreeze(context.Background(), "unknown", christmas); err == nil {
+		t.Fatal("expected error for unknown calendar")
+	}
+}
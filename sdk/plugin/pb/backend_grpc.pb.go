@@ -640,6 +640,9 @@ const (
 	SystemView_GeneratePasswordFromPolicy_FullMethodName = "/pb.SystemView/GeneratePasswordFromPolicy"
 	SystemView_ClusterInfo_FullMethodName                = "/pb.SystemView/ClusterInfo"
 	SystemView_GenerateIdentityToken_FullMethodName      = "/pb.SystemView/GenerateIdentityToken"
+	SystemView_RegisterRotationJob_FullMethodName        = "/pb.SystemView/RegisterRotationJob"
+	SystemView_DeregisterRotationJob_FullMethodName      = "/pb.SystemView/DeregisterRotationJob"
+	SystemView_RotationFreeze_FullMethodName             = "/pb.SystemView/RotationFreeze"
 )
 
 // SystemViewClient is the client API for SystemView service.
@@ -688,6 +691,15 @@ type SystemViewClient interface {
 	ClusterInfo(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ClusterInfoReply, error)
 	// GenerateIdentityToken returns an identity token for the requesting plugin.
 	GenerateIdentityToken(ctx context.Context, in *GenerateIdentityTokenRequest, opts ...grpc.CallOption) (*GenerateIdentityTokenResponse, error)
+	// RegisterRotationJob registers a root credential of the plugin's mount
+	// for scheduled rotation.
+	RegisterRotationJob(ctx context.Context, in *RegisterRotationJobRequest, opts ...grpc.CallOption) (*RegisterRotationJobResponse, error)
+	// DeregisterRotationJob removes a root credential of the plugin's mount
+	// from scheduled rotation.
+	DeregisterRotationJob(ctx context.Context, in *DeregisterRotationJobRequest, opts ...grpc.CallOption) (*Empty, error)
+	// RotationFreeze returns the end of the freeze period of a rotation
+	// calendar containing the given time, if any.
+	RotationFreeze(ctx context.Context, in *RotationFreezeRequest, opts ...grpc.CallOption) (*RotationFreezeReply, error)
 }
 
 type systemViewClient struct {
@@ -824,6 +836,33 @@ func (c *systemViewClient) GenerateIdentityToken(ctx context.Context, in *Genera
 	return out, nil
 }
 
+func (c *systemViewClient) RegisterRotationJob(ctx context.Context, in *RegisterRotationJobRequest, opts ...grpc.CallOption) (*RegisterRotationJobResponse, error) {
+	out := new(RegisterRotationJobResponse)
+	err := c.cc.Invoke(ctx, SystemView_RegisterRotationJob_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *systemViewClient) DeregisterRotationJob(ctx context.Context, in *DeregisterRotationJobRequest, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, SystemView_DeregisterRotationJob_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *systemViewClient) RotationFreeze(ctx context.Context, in *RotationFreezeRequest, opts ...grpc.CallOption) (*RotationFreezeReply, error) {
+	out := new(RotationFreezeReply)
+	err := c.cc.Invoke(ctx, SystemView_RotationFreeze_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SystemViewServer is the server API for SystemView service.
 // All implementations must embed UnimplementedSystemViewServer
 // for forward compatibility
@@ -870,6 +909,15 @@ type SystemViewServer interface {
 	ClusterInfo(context.Context, *Empty) (*ClusterInfoReply, error)
 	// GenerateIdentityToken returns an identity token for the requesting plugin.
 	GenerateIdentityToken(context.Context, *GenerateIdentityTokenRequest) (*GenerateIdentityTokenResponse, error)
+	// RegisterRotationJob registers a root credential of the plugin's mount
+	// for scheduled rotation.
+	RegisterRotationJob(context.Context, *RegisterRotationJobRequest) (*RegisterRotationJobResponse, error)
+	// DeregisterRotationJob removes a root credential of the plugin's mount
+	// from scheduled rotation.
+	DeregisterRotationJob(context.Context, *DeregisterRotationJobRequest) (*Empty, error)
+	// RotationFreeze returns the end of the freeze period of a rotation
+	// calendar containing the given time, if any.
+	RotationFreeze(context.Context, *RotationFreezeRequest) (*RotationFreezeReply, error)
 	mustEmbedUnimplementedSystemViewServer()
 }
 
@@ -919,6 +967,15 @@ func (UnimplementedSystemViewServer) ClusterInfo(context.Context, *Empty) (*Clus
 func (UnimplementedSystemViewServer) GenerateIdentityToken(context.Context, *GenerateIdentityTokenRequest) (*GenerateIdentityTokenResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GenerateIdentityToken not implemented")
 }
+func (UnimplementedSystemViewServer) RegisterRotationJob(context.Context, *RegisterRotationJobRequest) (*RegisterRotationJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterRotationJob not implemented")
+}
+func (UnimplementedSystemViewServer) DeregisterRotationJob(context.Context, *DeregisterRotationJobRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeregisterRotationJob not implemented")
+}
+func (UnimplementedSystemViewServer) RotationFreeze(context.Context, *RotationFreezeRequest) (*RotationFreezeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotationFreeze not implemented")
+}
 func (UnimplementedSystemViewServer) mustEmbedUnimplementedSystemViewServer() {}
 
 // UnsafeSystemViewServer may be embedded to opt out of forward compatibility for this service.
@@ -1184,6 +1241,60 @@ func _SystemView_GenerateIdentityToken_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SystemView_RegisterRotationJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRotationJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemViewServer).RegisterRotationJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SystemView_RegisterRotationJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemViewServer).RegisterRotationJob(ctx, req.(*RegisterRotationJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SystemView_DeregisterRotationJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeregisterRotationJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemViewServer).DeregisterRotationJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SystemView_DeregisterRotationJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemViewServer).DeregisterRotationJob(ctx, req.(*DeregisterRotationJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SystemView_RotationFreeze_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotationFreezeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SystemViewServer).RotationFreeze(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SystemView_RotationFreeze_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SystemViewServer).RotationFreeze(ctx, req.(*RotationFreezeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // SystemView_ServiceDesc is the grpc.ServiceDesc for SystemView service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -1247,6 +1358,18 @@ var SystemView_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GenerateIdentityToken",
 			Handler:    _SystemView_GenerateIdentityToken_Handler,
 		},
+		{
+			MethodName: "RegisterRotationJob",
+			Handler:    _SystemView_RegisterRotationJob_Handler,
+		},
+		{
+			MethodName: "DeregisterRotationJob",
+			Handler:    _SystemView_DeregisterRotationJob_Handler,
+		},
+		{
+			MethodName: "RotationFreeze",
+			Handler:    _SystemView_RotationFreeze_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "sdk/plugin/pb/backend.proto",
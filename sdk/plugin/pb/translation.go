@@ -524,24 +524,26 @@ func LogicalAuthToProtoAuth(a *logical.Auth) (*Auth, error) {
 	}
 
 	return &Auth{
-		LeaseOptions:     lo,
-		TokenType:        uint32(a.TokenType),
-		InternalData:     string(buf[:]),
-		DisplayName:      a.DisplayName,
-		Policies:         a.Policies,
-		TokenPolicies:    a.TokenPolicies,
-		IdentityPolicies: a.IdentityPolicies,
-		NoDefaultPolicy:  a.NoDefaultPolicy,
-		Metadata:         a.Metadata,
-		ClientToken:      a.ClientToken,
-		Accessor:         a.Accessor,
-		Period:           int64(a.Period),
-		NumUses:          int64(a.NumUses),
-		EntityID:         a.EntityID,
-		Alias:            a.Alias,
-		GroupAliases:     a.GroupAliases,
-		BoundCIDRs:       boundCIDRs,
-		ExplicitMaxTTL:   int64(a.ExplicitMaxTTL),
+		LeaseOptions:         lo,
+		TokenType:            uint32(a.TokenType),
+		InternalData:         string(buf[:]),
+		DisplayName:          a.DisplayName,
+		Policies:             a.Policies,
+		TokenPolicies:        a.TokenPolicies,
+		IdentityPolicies:     a.IdentityPolicies,
+		NoDefaultPolicy:      a.NoDefaultPolicy,
+		Metadata:             a.Metadata,
+		ClientToken:          a.ClientToken,
+		Accessor:             a.Accessor,
+		Period:               int64(a.Period),
+		NumUses:              int64(a.NumUses),
+		EntityID:             a.EntityID,
+		Alias:                a.Alias,
+		GroupAliases:         a.GroupAliases,
+		BoundCIDRs:           boundCIDRs,
+		AccessWindows:        a.AccessWindows,
+		AccessWindowTimezone: a.AccessWindowTimezone,
+		ExplicitMaxTTL:       int64(a.ExplicitMaxTTL),
 	}, nil
 }
 
@@ -572,24 +574,26 @@ func ProtoAuthToLogicalAuth(a *Auth) (*logical.Auth, error) {
 	}
 
 	return &logical.Auth{
-		LeaseOptions:     lo,
-		TokenType:        logical.TokenType(a.TokenType),
-		InternalData:     data,
-		DisplayName:      a.DisplayName,
-		Policies:         a.Policies,
-		TokenPolicies:    a.TokenPolicies,
-		IdentityPolicies: a.IdentityPolicies,
-		NoDefaultPolicy:  a.NoDefaultPolicy,
-		Metadata:         a.Metadata,
-		ClientToken:      a.ClientToken,
-		Accessor:         a.Accessor,
-		Period:           time.Duration(a.Period),
-		NumUses:          int(a.NumUses),
-		EntityID:         a.EntityID,
-		Alias:            a.Alias,
-		GroupAliases:     a.GroupAliases,
-		BoundCIDRs:       boundCIDRs,
-		ExplicitMaxTTL:   time.Duration(a.ExplicitMaxTTL),
+		LeaseOptions:         lo,
+		TokenType:            logical.TokenType(a.TokenType),
+		InternalData:         data,
+		DisplayName:          a.DisplayName,
+		Policies:             a.Policies,
+		TokenPolicies:        a.TokenPolicies,
+		IdentityPolicies:     a.IdentityPolicies,
+		NoDefaultPolicy:      a.NoDefaultPolicy,
+		Metadata:             a.Metadata,
+		ClientToken:          a.ClientToken,
+		Accessor:             a.Accessor,
+		Period:               time.Duration(a.Period),
+		NumUses:              int(a.NumUses),
+		EntityID:             a.EntityID,
+		Alias:                a.Alias,
+		GroupAliases:         a.GroupAliases,
+		BoundCIDRs:           boundCIDRs,
+		AccessWindows:        a.AccessWindows,
+		AccessWindowTimezone: a.AccessWindowTimezone,
+		ExplicitMaxTTL:       time.Duration(a.ExplicitMaxTTL),
 	}, nil
 }
 
@@ -604,28 +608,30 @@ func LogicalTokenEntryToProtoTokenEntry(t *logical.TokenEntry) *TokenEntry {
 	}
 
 	return &TokenEntry{
-		ID:                 t.ID,
-		Accessor:           t.Accessor,
-		Parent:             t.Parent,
-		Policies:           t.Policies,
-		InlinePolicy:       t.InlinePolicy,
-		Path:               t.Path,
-		Meta:               t.Meta,
-		InternalMeta:       t.InternalMeta,
-		DisplayName:        t.DisplayName,
-		NumUses:            int64(t.NumUses),
-		CreationTime:       t.CreationTime,
-		TTL:                int64(t.TTL),
-		ExplicitMaxTTL:     int64(t.ExplicitMaxTTL),
-		Role:               t.Role,
-		Period:             int64(t.Period),
-		EntityID:           t.EntityID,
-		NoIdentityPolicies: t.NoIdentityPolicies,
-		BoundCIDRs:         boundCIDRs,
-		NamespaceID:        t.NamespaceID,
-		CubbyholeID:        t.CubbyholeID,
-		Type:               uint32(t.Type),
-		ExternalID:         t.ExternalID,
+		ID:                   t.ID,
+		Accessor:             t.Accessor,
+		Parent:               t.Parent,
+		Policies:             t.Policies,
+		InlinePolicy:         t.InlinePolicy,
+		Path:                 t.Path,
+		Meta:                 t.Meta,
+		InternalMeta:         t.InternalMeta,
+		DisplayName:          t.DisplayName,
+		NumUses:              int64(t.NumUses),
+		CreationTime:         t.CreationTime,
+		TTL:                  int64(t.TTL),
+		ExplicitMaxTTL:       int64(t.ExplicitMaxTTL),
+		Role:                 t.Role,
+		Period:               int64(t.Period),
+		EntityID:             t.EntityID,
+		NoIdentityPolicies:   t.NoIdentityPolicies,
+		BoundCIDRs:           boundCIDRs,
+		AccessWindows:        t.AccessWindows,
+		AccessWindowTimezone: t.AccessWindowTimezone,
+		NamespaceID:          t.NamespaceID,
+		CubbyholeID:          t.CubbyholeID,
+		Type:                 uint32(t.Type),
+		ExternalID:           t.ExternalID,
 	}
 }
 
@@ -645,28 +651,30 @@ func ProtoTokenEntryToLogicalTokenEntry(t *TokenEntry) (*logical.TokenEntry, err
 	}
 
 	return &logical.TokenEntry{
-		ID:                 t.ID,
-		Accessor:           t.Accessor,
-		Parent:             t.Parent,
-		Policies:           t.Policies,
-		InlinePolicy:       t.InlinePolicy,
-		Path:               t.Path,
-		Meta:               t.Meta,
-		InternalMeta:       t.InternalMeta,
-		DisplayName:        t.DisplayName,
-		NumUses:            int(t.NumUses),
-		CreationTime:       t.CreationTime,
-		TTL:                time.Duration(t.TTL),
-		ExplicitMaxTTL:     time.Duration(t.ExplicitMaxTTL),
-		Role:               t.Role,
-		Period:             time.Duration(t.Period),
-		EntityID:           t.EntityID,
-		NoIdentityPolicies: t.NoIdentityPolicies,
-		BoundCIDRs:         boundCIDRs,
-		NamespaceID:        t.NamespaceID,
-		CubbyholeID:        t.CubbyholeID,
-		Type:               logical.TokenType(t.Type),
-		ExternalID:         t.ExternalID,
+		ID:                   t.ID,
+		Accessor:             t.Accessor,
+		Parent:               t.Parent,
+		Policies:             t.Policies,
+		InlinePolicy:         t.InlinePolicy,
+		Path:                 t.Path,
+		Meta:                 t.Meta,
+		InternalMeta:         t.InternalMeta,
+		DisplayName:          t.DisplayName,
+		NumUses:              int(t.NumUses),
+		CreationTime:         t.CreationTime,
+		TTL:                  time.Duration(t.TTL),
+		ExplicitMaxTTL:       time.Duration(t.ExplicitMaxTTL),
+		Role:                 t.Role,
+		Period:               time.Duration(t.Period),
+		EntityID:             t.EntityID,
+		NoIdentityPolicies:   t.NoIdentityPolicies,
+		BoundCIDRs:           boundCIDRs,
+		AccessWindows:        t.AccessWindows,
+		AccessWindowTimezone: t.AccessWindowTimezone,
+		NamespaceID:          t.NamespaceID,
+		CubbyholeID:          t.CubbyholeID,
+		Type:                 logical.TokenType(t.Type),
+		ExternalID:           t.ExternalID,
 	}, nil
 }
 
@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+// Package rotation defines the types plugins use to register root credentials
+// with Vault's rotation manager. Once registered, Vault invokes a
+// logical.RotationOperation on the credential's path whenever it is due for
+// rotation, and keeps a history of the attempts.
+package rotation
+
+import (
+	"errors"
+	"time"
+)
+
+// RotationJobConfigureRequest registers a root credential of the requesting
+// mount for scheduled rotation.
+type RotationJobConfigureRequest struct {
+	// Name is a human readable name of the credential, e.g. "root".
+	Name string
+
+	// ReqPath is the path, relative to the mount, the RotationOperation is
+	// sent to. It identifies the job, registering the same path again
+	// replaces its schedule.
+	ReqPath string
+
+	// RotationSchedule is a cron-style schedule, e.g. "0 0 * * SAT", on which
+	// the credential is rotated. Mutually exclusive with RotationPeriod.
+	RotationSchedule string
+
+	// RotationWindow is how long after its scheduled time a rotation may
+	// still be attempted, e.g. after Vault was sealed at the scheduled time.
+	// Rotations are attempted whenever they are overdue if zero.
+	RotationWindow time.Duration
+
+	// RotationPeriod is the interval on which the credential is rotated.
+	// Mutually exclusive with RotationSchedule.
+	RotationPeriod time.Duration
+
+	// RotationJitter is the maximum random delay added to each scheduled
+	// rotation, spreading the rotations of credentials sharing a schedule.
+	RotationJitter time.Duration
+
+	// RotationCalendar is the name of a rotation calendar managed by the
+	// operator. Rotations falling in one of its freeze periods are postponed
+	// until the freeze period ends.
+	RotationCalendar string
+}
+
+// Validate checks that the request describes exactly one schedule. Cron
+// schedules are parsed by Vault on registration.
+func (r *RotationJobConfigureRequest) Validate() error {
+	switch {
+	case r.RotationSchedule != "" && r.RotationPeriod != 0:
+		return errors.New("rotation schedule and rotation period are mutually exclusive")
+	case r.RotationSchedule == "" && r.RotationPeriod <= 0:
+		return errors.New("either a rotation schedule or a positive rotation period is required")
+	case r.RotationWindow < 0:
+		return errors.New("rotation window must not be negative")
+	case r.RotationWindow != 0 && r.RotationSchedule == "":
+		return errors.New("rotation window requires a rotation schedule")
+	case r.RotationJitter < 0:
+		return errors.New("rotation jitter must not be negative")
+	}
+	return nil
+}
+
+// RotationJobDeregisterRequest deregisters a root credential of the requesting
+// mount from scheduled rotation.
+type RotationJobDeregisterRequest struct {
+	// ReqPath is the path the job was registered with.
+	ReqPath string
+}
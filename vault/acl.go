@@ -757,6 +757,12 @@ func (c *Core) performPolicyChecks(ctx context.Context, acl *ACL, te *logical.To
 		if !ret.RootPrivs && opts.RootPrivsRequired && req.Operation != logical.HelpOperation {
 			return ret
 		}
+		// Requests controlled by a control group are only allowed once the
+		// control group authorized them
+		if err := checkControlGroup(req, ret.ACLResults); err != nil {
+			ret.Error = multierror.Append(ret.Error, err)
+			return ret
+		}
 	}
 
 	c.performEntPolicyChecks(ctx, acl, te, req, inEntity, opts, ret)
@@ -0,0 +1,216 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/namespace"
+)
+
+const (
+	asyncOperationStatusRunning   = "running"
+	asyncOperationStatusSucceeded = "succeeded"
+	asyncOperationStatusFailed    = "failed"
+	asyncOperationStatusCanceled  = "canceled"
+
+	asyncOperationTypeLeaseTidy         = "lease-tidy"
+	asyncOperationTypeLeaseRevokePrefix = "lease-revoke-prefix"
+	asyncOperationTypeTokenTidy         = "token-tidy"
+)
+
+// asyncOperationRetention is how long finished operations are kept, so that
+// their outcome can be read.
+var asyncOperationRetention = time.Hour
+
+// maxFinishedAsyncOperations bounds the number of finished operations kept,
+// the oldest ones being pruned first.
+const maxFinishedAsyncOperations = 1000
+
+// AsyncOperation is a long-running operation started by a request, which
+// runs in the background on the active node. Operations are only tracked in
+// memory, so they are lost when the node seals or steps down, which cancels
+// the running ones.
+type AsyncOperation struct {
+	ID          string
+	Type        string
+	NamespaceID string
+	Status      string
+	StartTime   time.Time
+	EndTime     time.Time
+	Error       string
+
+	// Completed and Total are the progress of the operation, in units of
+	// work depending on its type. Total is zero if it is unknown.
+	Completed int64
+	Total     int64
+
+	cancel context.CancelFunc
+}
+
+// asyncOperationFunc runs an operation. It reports its progress through the
+// given operation, and must return once ctx is canceled.
+type asyncOperationFunc func(ctx context.Context, op *AsyncOperation) error
+
+// asyncOperations tracks the operations running in the background.
+type asyncOperations struct {
+	l          sync.RWMutex
+	operations map[string]*AsyncOperation
+}
+
+func newAsyncOperations() *asyncOperations {
+	return &asyncOperations{
+		operations: make(map[string]*AsyncOperation),
+	}
+}
+
+// start runs f in the background as an operation of the given type in the
+// given namespace. The operation is canceled along with parent.
+func (a *asyncOperations) start(parent context.Context, ns *namespace.Namespace, opType string, f asyncOperationFunc) (*AsyncOperation, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(namespace.ContextWithNamespace(parent, ns))
+	op := &AsyncOperation{
+		ID:          id,
+		Type:        opType,
+		NamespaceID: ns.ID,
+		Status:      asyncOperationStatusRunning,
+		StartTime:   time.Now(),
+		cancel:      cancel,
+	}
+
+	a.l.Lock()
+	a.pruneLocked()
+	a.operations[id] = op
+	a.l.Unlock()
+
+	go func() {
+		defer cancel()
+		err := f(ctx, op)
+
+		a.l.Lock()
+		defer a.l.Unlock()
+		op.EndTime = time.Now()
+		switch {
+		case err == nil:
+			op.Status = asyncOperationStatusSucceeded
+		case ctx.Err() != nil && errors.Is(err, context.Canceled):
+			op.Status = asyncOperationStatusCanceled
+		default:
+			op.Status = asyncOperationStatusFailed
+			op.Error = err.Error()
+		}
+	}()
+
+	return op, nil
+}
+
+// setProgress records the progress of an operation.
+func (a *asyncOperations) setProgress(op *AsyncOperation, completed, total int64) {
+	a.l.Lock()
+	defer a.l.Unlock()
+	op.Completed = completed
+	op.Total = total
+}
+
+// get returns a copy of the operation with the given ID in the given
+// namespace, or nil if there is none.
+func (a *asyncOperations) get(nsID, id string) *AsyncOperation {
+	a.l.RLock()
+	defer a.l.RUnlock()
+
+	op, ok := a.operations[id]
+	if !ok || op.NamespaceID != nsID {
+		return nil
+	}
+	opCopy := *op
+	return &opCopy
+}
+
+// list returns copies of the operations of the given namespace, ordered by
+// start time.
+func (a *asyncOperations) list(nsID string) []*AsyncOperation {
+	a.l.Lock()
+	defer a.l.Unlock()
+	a.pruneLocked()
+
+	var ops []*AsyncOperation
+	for _, op := range a.operations {
+		if op.NamespaceID != nsID {
+			continue
+		}
+		opCopy := *op
+		ops = append(ops, &opCopy)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].StartTime.Before(ops[j].StartTime)
+	})
+	return ops
+}
+
+// cancel cancels the operation with the given ID in the given namespace. It
+// returns false if there is no such operation.
+func (a *asyncOperations) cancel(nsID, id string) bool {
+	a.l.RLock()
+	defer a.l.RUnlock()
+
+	op, ok := a.operations[id]
+	if !ok || op.NamespaceID != nsID {
+		return false
+	}
+	op.cancel()
+	return true
+}
+
+// pruneLocked removes the finished operations past their retention, and the
+// oldest ones beyond the maximum number of finished operations.
+func (a *asyncOperations) pruneLocked() {
+	var finished []*AsyncOperation
+	for id, op := range a.operations {
+		switch {
+		case op.EndTime.IsZero():
+		case time.Since(op.EndTime) > asyncOperationRetention:
+			delete(a.operations, id)
+		default:
+			finished = append(finished, op)
+		}
+	}
+
+	if len(finished) <= maxFinishedAsyncOperations {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].EndTime.Before(finished[j].EndTime)
+	})
+	for _, op := range finished[:len(finished)-maxFinishedAsyncOperations] {
+		delete(a.operations, op.ID)
+	}
+}
+
+// responseData returns the API representation of an operation.
+func (op *AsyncOperation) responseData() map[string]interface{} {
+	data := map[string]interface{}{
+		"id":         op.ID,
+		"type":       op.Type,
+		"status":     op.Status,
+		"start_time": op.StartTime.Format(time.RFC3339),
+		"completed":  op.Completed,
+		"total":      op.Total,
+	}
+	if !op.EndTime.IsZero() {
+		data["end_time"] = op.EndTime.Format(time.RFC3339)
+	}
+	if op.Error != "" {
+		data["error"] = op.Error
+	}
+	return data
+}
@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAsyncOperations_Lifecycle verifies that operations started by requests
+// can be listed, read and canceled through sys/operations.
+func TestAsyncOperations_Lifecycle(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	require.Eventually(t, func() bool {
+		return !c.expiration.inRestoreMode()
+	}, 10*time.Second, 10*time.Millisecond)
+
+	request := func(op logical.Operation, path string) *logical.Response {
+		t.Helper()
+		resp, err := c.HandleRequest(ctx, &logical.Request{
+			Operation:   op,
+			Path:        path,
+			ClientToken: root,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+	waitForStatus := func(id, status string) *logical.Response {
+		t.Helper()
+		var resp *logical.Response
+		require.Eventually(t, func() bool {
+			resp = request(logical.ReadOperation, "sys/operations/"+id)
+			return resp != nil && resp.Data["status"] == status
+		}, 10*time.Second, 10*time.Millisecond)
+		return resp
+	}
+
+	resp := request(logical.UpdateOperation, "sys/leases/tidy")
+	require.Equal(t, http.StatusAccepted, resp.Data[logical.HTTPStatusCode])
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(resp.Data[logical.HTTPRawBody].(string)), &body))
+	tidyID := body.Data["operation_id"].(string)
+
+	resp = waitForStatus(tidyID, asyncOperationStatusSucceeded)
+	require.Equal(t, asyncOperationTypeLeaseTidy, resp.Data["type"])
+	require.Contains(t, resp.Data, "end_time")
+
+	started := make(chan struct{})
+	op, err := c.asyncOperations.start(c.activeContext, namespace.RootNamespace, "test", func(ctx context.Context, op *AsyncOperation) error {
+		c.asyncOperations.setProgress(op, 1, 2)
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+	<-started
+
+	resp = request(logical.ListOperation, "sys/operations")
+	require.ElementsMatch(t, []string{tidyID, op.ID}, resp.Data["keys"])
+	require.Equal(t, asyncOperationStatusRunning, resp.Data["key_info"].(map[string]interface{})[op.ID].(map[string]interface{})["status"])
+
+	resp = request(logical.ReadOperation, "sys/operations/"+op.ID)
+	require.Equal(t, int64(1), resp.Data["completed"])
+	require.Equal(t, int64(2), resp.Data["total"])
+
+	require.Nil(t, request(logical.DeleteOperation, "sys/operations/"+op.ID))
+	waitForStatus(op.ID, asyncOperationStatusCanceled)
+
+	// Operations are not visible from other namespaces
+	require.Nil(t, c.asyncOperations.get("other", op.ID))
+	require.False(t, c.asyncOperations.cancel("other", op.ID))
+}
+
+// TestAsyncOperations_Prune verifies that finished operations are pruned past
+// their retention.
+func TestAsyncOperations_Prune(t *testing.T) {
+	a := newAsyncOperations()
+	done := make(chan struct{})
+	op, err := a.start(context.Background(), namespace.RootNamespace, "test", func(context.Context, *AsyncOperation) error {
+		defer close(done)
+		return nil
+	})
+	require.NoError(t, err)
+	<-done
+
+	require.Eventually(t, func() bool {
+		finished := a.get(namespace.RootNamespaceID, op.ID)
+		return finished.Status == asyncOperationStatusSucceeded
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, a.list(namespace.RootNamespaceID), 1)
+
+	a.l.Lock()
+	a.operations[op.ID].EndTime = time.Now().Add(-2 * asyncOperationRetention)
+	a.l.Unlock()
+	require.Empty(t, a.list(namespace.RootNamespaceID))
+}
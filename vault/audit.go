@@ -6,6 +6,7 @@ package vault
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"strconv"
@@ -23,6 +24,10 @@ import (
 )
 
 const (
+	// auditHashKeyMinLength is the minimum length of the keys imported to
+	// hash sensitive values in the audit log.
+	auditHashKeyMinLength = 32
+
 	// coreAuditConfigPath is used to store the audit configuration.
 	// Audit configuration is protected within the Vault itself, which means it
 	// can only be viewed or modified after an unseal.
@@ -244,6 +249,50 @@ func (c *Core) disableAudit(ctx context.Context, path string, updateStorage bool
 	return existed, nil
 }
 
+// setAuditHashKey sets the key with which the audit backend at path hashes
+// sensitive values in place of its random salt, or reverts to a new random
+// salt if key is nil. The key is stored where the salt was, so it is
+// encrypted by the barrier and replicated along with the backend.
+func (c *Core) setAuditHashKey(ctx context.Context, path string, key []byte) error {
+	// Ensure we end the path in a slash
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	c.auditLock.RLock()
+	defer c.auditLock.RUnlock()
+
+	entry, err := c.audit.find(ctx, path)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("no matching backend")
+	}
+	if !entry.Local && c.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		return logical.ErrReadOnly
+	}
+
+	view := NewBarrierView(c.barrier, entry.ViewPath())
+	if key == nil {
+		err = view.Delete(ctx, salt.DefaultLocation)
+	} else {
+		err = view.Put(ctx, &logical.StorageEntry{
+			Key:   salt.DefaultLocation,
+			Value: key,
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update audit hash key: %w", err)
+	}
+
+	// The backends reload their salts from storage
+	c.auditBroker.Invalidate(ctx, path)
+
+	c.logger.Info("updated audit hash key", "path", path, "operator_supplied", key != nil)
+	return nil
+}
+
 // loadAudits is invoked as part of postUnseal to load the audit table
 func (c *Core) loadAudits(ctx context.Context) error {
 	auditTable := &MountTable{}
@@ -519,10 +568,11 @@ func (c *Core) newAuditBackend(ctx context.Context, entry *MountEntry, view logi
 
 	be, err := f(
 		ctx, &audit.BackendConfig{
-			SaltView:   view,
-			SaltConfig: saltConfig,
-			Config:     conf,
-			MountPath:  entry.Path,
+			SaltView:         view,
+			SaltConfig:       saltConfig,
+			Config:           conf,
+			MountPath:        entry.Path,
+			CheckpointSigner: c.signAuditCheckpoint,
 		},
 		c.auditedHeaders)
 	if err != nil {
@@ -567,6 +617,37 @@ func (c *Core) newAuditBackend(ctx context.Context, entry *MountEntry, view logi
 	return be, err
 }
 
+// signAuditCheckpoint signs the checkpoint of a hash chained audit log with a
+// key of the transit secrets engine mounted at the given path of the root
+// namespace. The request is routed directly to the engine, so it is neither
+// authorized against a token nor audited itself.
+func (c *Core) signAuditCheckpoint(ctx context.Context, mountPath, keyName string, input []byte) (string, error) {
+	req := &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      mountPath + "/sign/" + keyName,
+		Data: map[string]interface{}{
+			"input": base64.StdEncoding.EncodeToString(input),
+		},
+	}
+
+	resp, err := c.router.Route(namespace.ContextWithNamespace(ctx, namespace.RootNamespace), req)
+	switch {
+	case err != nil:
+		return "", err
+	case resp == nil:
+		return "", errors.New("empty response signing audit checkpoint")
+	case resp.IsError():
+		return "", resp.Error()
+	}
+
+	signature, ok := resp.Data["signature"].(string)
+	if !ok {
+		return "", errors.New("missing signature in response signing audit checkpoint")
+	}
+
+	return signature, nil
+}
+
 // defaultAuditTable creates a default audit table
 func defaultAuditTable() *MountTable {
 	table := &MountTable{
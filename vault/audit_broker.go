@@ -409,6 +409,26 @@ func (a *AuditBroker) Invalidate(ctx context.Context, _ string) {
 	}
 }
 
+// Flush waits for the entries being logged to be written, and then flushes the
+// audit backends which support it, so that no entries are lost when sealing.
+func (a *AuditBroker) Flush(ctx context.Context) error {
+	a.Lock()
+	defer a.Unlock()
+
+	var retErr *multierror.Error
+	for name, be := range a.backends {
+		flusher, ok := be.backend.(audit.Flusher)
+		if !ok {
+			continue
+		}
+		if err := flusher.Flush(ctx); err != nil {
+			retErr = multierror.Append(retErr, fmt.Errorf("failed to flush audit backend %q: %w", name, err))
+		}
+	}
+
+	return retErr.ErrorOrNil()
+}
+
 // requiredSuccessThresholdSinks examines backends that have already been registered,
 // and returns the value that should be used for configuring success threshold sinks
 // on the eventlogger broker.
@@ -460,9 +460,7 @@ func (c *Core) remountCredential(ctx context.Context, src, dst namespace.MountPa
 	}
 
 	if c.expiration != nil {
-		revokeCtx := namespace.ContextWithNamespace(ctx, src.Namespace)
-		// Revoke all the dynamic keys
-		if err := c.expiration.RevokePrefix(revokeCtx, src.MountPath, true); err != nil {
+		if err := c.migrateMountLeases(ctx, src, dst); err != nil {
 			return err
 		}
 	}
@@ -484,6 +482,9 @@ func (c *Core) remountCredential(ctx context.Context, src, dst namespace.MountPa
 		srcMatch.Path = srcPath
 		srcMatch.Tainted = true
 		c.authLock.Unlock()
+		if c.expiration != nil {
+			c.restoreMountLeases(ctx, src, dst)
+		}
 		if err == logical.ErrReadOnly && c.perfStandby {
 			return err
 		}
@@ -765,9 +766,9 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 	c.authLock.Lock()
 	defer c.authLock.Unlock()
 
-	for _, entry := range c.auth.sortEntriesByPathDepth().Entries {
-		var backend logical.Backend
-
+	entries := c.auth.sortEntriesByPathDepth().Entries
+	setups := make([]*mountSetup, 0, len(entries))
+	for _, entry := range entries {
 		// Create a barrier view using the UUID
 		viewPath := entry.ViewPath()
 
@@ -794,10 +795,23 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 			defer view.setReadOnlyErr(origViewReadOnlyErr)
 		}
 
-		// Initialize the backend
-		sysView := c.mountEntrySysView(entry)
+		setups = append(setups, &mountSetup{
+			entry:           entry,
+			view:            view,
+			nilMount:        nilMount,
+			origReadOnlyErr: origViewReadOnlyErr,
+		})
+	}
+
+	// Initialize the backends
+	c.createMountBackends(ctx, setups, c.newCredentialBackend)
+	defer cleanupUnmountedBackends(ctx, setups)
+
+	for _, setup := range setups {
+		entry, view, nilMount, origViewReadOnlyErr := setup.entry, setup.view, setup.nilMount, setup.origReadOnlyErr
+		viewPath := entry.ViewPath()
 
-		backend, err = c.newCredentialBackend(ctx, entry, sysView, view)
+		backend, err := setup.backend, setup.err
 		if err != nil {
 			c.logger.Error("failed to create credential entry", "path", entry.Path, "error", err)
 
@@ -857,9 +871,10 @@ func (c *Core) setupCredentials(ctx context.Context) error {
 			c.logger.Error("failed to mount auth entry", "path", entry.Path, "namespace", entry.Namespace(), "error", err)
 			return errLoadAuthFailed
 		}
+		setup.mounted = true
 
 		if c.logger.IsInfo() {
-			c.logger.Info("successfully mounted", "type", entry.Type, "version", entry.RunningVersion, "path", entry.Path, "namespace", entry.Namespace())
+			c.logger.Info("successfully mounted", "type", entry.Type, "version", entry.RunningVersion, "path", entry.Path, "namespace", entry.Namespace(), "duration", setup.duration)
 		}
 
 		// Ensure the path is tainted if set in the mount table
@@ -790,14 +790,38 @@ func TestCore_RemountCredential_Cleanup(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	// Token should be revoked
+	// Token should have moved with the mount
 	te, err := c.tokenStore.Lookup(namespace.RootContext(nil), resp.Auth.ClientToken)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if te != nil {
+	if te == nil || te.Path != "auth/bar/login" {
 		t.Fatalf("bad: %#v", te)
 	}
+	saltedID, err := c.tokenStore.SaltID(namespace.RootContext(nil), te.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	leaseID := "auth/bar/login/" + saltedID
+	le, err := c.expiration.loadEntry(namespace.RootContext(nil), leaseID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if le == nil || le.Path != "auth/bar/login" {
+		t.Fatalf("bad: %#v", le)
+	}
+
+	// Revoking the token should remove its lease
+	if err := c.tokenStore.revokeOrphan(namespace.RootContext(nil), te.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	le, err = c.expiration.loadEntry(namespace.RootContext(nil), leaseID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if le != nil {
+		t.Fatalf("bad: %#v", le)
+	}
 
 	// View should be empty
 	out, err := logical.CollectKeys(context.Background(), view)
@@ -60,6 +60,9 @@ var (
 	barrierRotationsMetric                 = []string{"barrier", "auto_rotation"}
 )
 
+// AESGCMBarrier lists pages of keys from the physical backend
+var _ logical.PaginatedStorage = &AESGCMBarrier{}
+
 // AESGCMBarrier is a SecurityBarrier implementation that uses the AES
 // cipher core and the Galois Counter Mode block mode. It defaults to
 // the golang NONCE default value of 12 and a key size of 256
@@ -175,10 +178,11 @@ func (b *AESGCMBarrier) Initialize(ctx context.Context, key []byte, sealKey []by
 		return fmt.Errorf("failed to generate encryption key: %w", err)
 	}
 
-	// Create a new keyring, install the keys
-	keyring := NewKeyring()
-	keyring = keyring.SetRootKey(key)
-	keyring, err = keyring.AddKey(&Key{
+	// Create a new keyring, install the keys. The keyring is only used to
+	// persist the keys, so its root key is destroyed once done.
+	rootKeyring := NewKeyring().SetRootKey(key)
+	defer rootKeyring.Zeroize(false)
+	keyring, err := rootKeyring.AddKey(&Key{
 		Term:    1,
 		Version: 1,
 		Value:   encryptionKey,
@@ -186,6 +190,7 @@ func (b *AESGCMBarrier) Initialize(ctx context.Context, key []byte, sealKey []by
 	if err != nil {
 		return fmt.Errorf("failed to create keyring: %w", err)
 	}
+	defer keyring.Zeroize(false)
 
 	err = b.persistKeyring(ctx, keyring)
 	if err != nil {
@@ -393,8 +398,10 @@ func (b *AESGCMBarrier) recoverKeyring(plaintext []byte) error {
 		return fmt.Errorf("keyring deserialization failed: %w", err)
 	}
 
-	// Setup the keyring and finish
+	// Setup the keyring and finish, destroying the root key of the keyring
+	// being replaced, if any
 	b.cache = make(map[uint32]cipher.AEAD)
+	b.keyring.Zeroize(false)
 	b.keyring = keyring
 	return nil
 }
@@ -530,13 +537,10 @@ func (b *AESGCMBarrier) Unseal(ctx context.Context, key []byte) error {
 	}
 
 	// Setup a new keyring, this is for backwards compatibility
-	keyringNew := NewKeyring()
-	keyring := keyringNew.SetRootKey(key)
-
-	// AddKey reuses the root, so we are only zeroizing after this call
-	defer keyringNew.Zeroize(false)
+	rootKeyring := NewKeyring().SetRootKey(key)
+	defer rootKeyring.Zeroize(false)
 
-	keyring, err = keyring.AddKey(&Key{
+	keyring, err := rootKeyring.AddKey(&Key{
 		Term:    1,
 		Version: 1,
 		Value:   init.Key,
@@ -545,11 +549,13 @@ func (b *AESGCMBarrier) Unseal(ctx context.Context, key []byte) error {
 		return fmt.Errorf("failed to create keyring: %w", err)
 	}
 	if err := b.persistKeyring(ctx, keyring); err != nil {
+		keyring.Zeroize(false)
 		return err
 	}
 
 	// Delete the old barrier entry
 	if err := b.backend.Delete(ctx, barrierInitPath); err != nil {
+		keyring.Zeroize(false)
 		return fmt.Errorf("failed to delete barrier init file: %w", err)
 	}
 
@@ -605,6 +611,7 @@ func (b *AESGCMBarrier) Rotate(ctx context.Context, randomSource io.Reader) (uin
 
 	// Persist the new keyring
 	if err := b.persistKeyring(ctx, newKeyring); err != nil {
+		newKeyring.Zeroize(false)
 		return 0, err
 	}
 
@@ -614,7 +621,9 @@ func (b *AESGCMBarrier) Rotate(ctx context.Context, randomSource io.Reader) (uin
 	b.UnaccountedEncryptions.Store(0)
 
 	// Swap the keyrings
+	oldKeyring := b.keyring
 	b.keyring = newKeyring
+	oldKeyring.Zeroize(false)
 
 	return newTerm, nil
 }
@@ -724,7 +733,11 @@ func (b *AESGCMBarrier) CheckUpgrade(ctx context.Context) (bool, uint32, error)
 	if err != nil {
 		return false, 0, fmt.Errorf("failed to add new encryption key: %w", err)
 	}
-	b.keyring = newKeyring
+	if newKeyring != b.keyring {
+		oldKeyring := b.keyring
+		b.keyring = newKeyring
+		oldKeyring.Zeroize(false)
+	}
 
 	// Done!
 	return true, key.Term, nil
@@ -763,6 +776,7 @@ func (b *AESGCMBarrier) Rekey(ctx context.Context, key []byte) error {
 
 	// Persist the new keyring
 	if err := b.persistKeyring(ctx, newKeyring); err != nil {
+		newKeyring.Zeroize(false)
 		return err
 	}
 
@@ -936,6 +950,20 @@ func (b *AESGCMBarrier) List(ctx context.Context, prefix string) ([]string, erro
 	return b.backend.List(ctx, prefix)
 }
 
+// ListPage is used to list a page of the keys under a given prefix, up to
+// the next prefix, which sort after the given key.
+func (b *AESGCMBarrier) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	defer metrics.MeasureSince([]string{"barrier", "list_page"}, time.Now())
+	b.l.RLock()
+	sealed := b.sealed
+	b.l.RUnlock()
+	if sealed {
+		return nil, ErrBarrierSealed
+	}
+
+	return physical.ListPage(ctx, b.backend, prefix, after, limit)
+}
+
 // aeadForTerm returns the AES-GCM AEAD for the given term
 func (b *AESGCMBarrier) aeadForTerm(term uint32) (cipher.AEAD, error) {
 	// Check for the keyring
@@ -1245,8 +1273,10 @@ func (b *AESGCMBarrier) persistEncryptions(ctx context.Context) error {
 			// autoRotateCheckInterval later.
 			newEncs := upe + 1
 			activeKey.Encryptions += uint64(newEncs)
-			newKeyring := b.keyring.Clone()
-			err := b.persistKeyringBestEffort(ctx, newKeyring)
+			// The active key is updated in place, so the keyring is persisted
+			// as is rather than cloned, which would copy the root key into
+			// new locked memory
+			err := b.persistKeyringBestEffort(ctx, b.keyring)
 			if err != nil {
 				return err
 			}
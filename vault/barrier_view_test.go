@@ -217,6 +217,49 @@ func TestBarrierView_Scan(t *testing.T) {
 	}
 }
 
+func TestBarrierView_ListPage(t *testing.T) {
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "view/")
+
+	// Write a key outside of view/
+	entry := &logical.StorageEntry{Key: "test", Value: []byte("test")}
+	if err := barrier.Put(context.Background(), entry); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for _, key := range []string{"foo", "zip", "foo/bar", "bar", "zap/zoo"} {
+		if err := view.Put(context.Background(), &logical.StorageEntry{Key: key, Value: []byte("test")}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	var out []string
+	after := ""
+	for {
+		page, err := view.ListPage(context.Background(), "", after, 2)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(page) > 2 {
+			t.Fatalf("page too large: %v", page)
+		}
+		if len(page) == 0 {
+			break
+		}
+		out = append(out, page...)
+		after = page[len(page)-1]
+	}
+
+	expect := []string{"bar", "foo", "foo/", "zap/", "zip"}
+	if !reflect.DeepEqual(out, expect) {
+		t.Fatalf("out: %v expect: %v", out, expect)
+	}
+
+	if _, err := view.ListPage(context.Background(), "../", "", 2); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
 func TestBarrierView_CollectKeys(t *testing.T) {
 	_, barrier, _ := mockBarrier(t)
 	view := NewBarrierView(barrier, "view/")
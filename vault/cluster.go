@@ -35,6 +35,11 @@ const (
 
 	// Internal so as not to log a trace message
 	IntNoForwardingHeaderName = "X-Vault-Internal-No-Request-Forwarding"
+
+	// IntForwardedListenerHeaderName carries the address of the listener
+	// through which a forwarded request was originally received. It is only
+	// trusted on requests received over request forwarding.
+	IntForwardedListenerHeaderName = "X-Vault-Internal-Forwarded-Listener"
 )
 
 var (
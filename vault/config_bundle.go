@@ -0,0 +1,388 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/rotation"
+)
+
+const (
+	// coreConfigBundleKeyPath is the storage path of the key signing the
+	// configuration bundles exported by this cluster.
+	coreConfigBundleKeyPath = "core/config-bundle/signing-key"
+
+	// configBundleVersion is the version of the format of configuration
+	// bundles.
+	configBundleVersion = 1
+)
+
+// configBundlePolicyFields are the fields of the resources of a bundle which
+// name policies, whose policies are added to the bundle.
+var configBundlePolicyFields = []string{
+	"policies",
+	"token_policies",
+	"allowed_policies",
+}
+
+// ConfigBundle is the declarative configuration of a set of mounts, which is
+// exported from a cluster and applied to the same or another cluster. It
+// holds no secret data.
+type ConfigBundle struct {
+	Version   int                   `json:"version"`
+	CreatedAt time.Time             `json:"created_at"`
+	Namespace string                `json:"namespace"`
+	Mounts    []*ConfigBundleMount  `json:"mounts"`
+	Policies  []*ConfigBundlePolicy `json:"policies,omitempty"`
+}
+
+// ConfigBundleMount is the configuration of a secrets engine or auth method.
+// Its path is relative to the namespace, and starts with "auth/" for auth
+// methods. Its config holds the tunable settings of the mount, in the form
+// accepted by the tune endpoints.
+type ConfigBundleMount struct {
+	Path                  string                     `json:"path"`
+	Type                  string                     `json:"type"`
+	Local                 bool                       `json:"local,omitempty"`
+	SealWrap              bool                       `json:"seal_wrap,omitempty"`
+	ExternalEntropyAccess bool                       `json:"external_entropy_access,omitempty"`
+	Config                map[string]interface{}     `json:"config"`
+	Resources             []*ConfigBundleResource    `json:"resources,omitempty"`
+	RotationJobs          []*ConfigBundleRotationJob `json:"rotation_jobs,omitempty"`
+}
+
+// ConfigBundleResource is an object of the mount, such as its configuration
+// or a role, as read from its path relative to the mount.
+type ConfigBundleResource struct {
+	Path string                 `json:"path"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// ConfigBundleRotationJob is the rotation schedule of a root credential of
+// the mount. Durations are in seconds.
+type ConfigBundleRotationJob struct {
+	Name     string `json:"name"`
+	ReqPath  string `json:"req_path"`
+	Schedule string `json:"rotation_schedule,omitempty"`
+	Window   int64  `json:"rotation_window,omitempty"`
+	Period   int64  `json:"rotation_period,omitempty"`
+	Jitter   int64  `json:"rotation_jitter,omitempty"`
+	Calendar string `json:"rotation_calendar,omitempty"`
+}
+
+// ConfigBundlePolicy is an ACL policy used by the mounts of the bundle.
+type ConfigBundlePolicy struct {
+	Name   string `json:"name"`
+	Policy string `json:"policy"`
+}
+
+// configBundleKey is the stored key signing configuration bundles.
+type configBundleKey struct {
+	PrivateKey []byte `json:"private_key"`
+}
+
+// configBundleSigningKey returns the key signing the configuration bundles
+// exported by this cluster, which is created on first use.
+func (c *Core) configBundleSigningKey(ctx context.Context) (ed25519.PrivateKey, error) {
+	c.configBundleKeyLock.Lock()
+	defer c.configBundleKeyLock.Unlock()
+
+	raw, err := c.barrier.Get(ctx, coreConfigBundleKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration bundle signing key: %w", err)
+	}
+	if raw != nil {
+		var key configBundleKey
+		if err := jsonutil.DecodeJSON(raw.Value, &key); err != nil {
+			return nil, fmt.Errorf("failed to decode configuration bundle signing key: %w", err)
+		}
+		if len(key.PrivateKey) != ed25519.SeedSize {
+			return nil, errors.New("invalid configuration bundle signing key")
+		}
+		return ed25519.NewKeyFromSeed(key.PrivateKey), nil
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate configuration bundle signing key: %w", err)
+	}
+	encoded, err := jsonutil.EncodeJSON(&configBundleKey{PrivateKey: priv.Seed()})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.barrier.Put(ctx, &logical.StorageEntry{
+		Key:   coreConfigBundleKeyPath,
+		Value: encoded,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to persist configuration bundle signing key: %w", err)
+	}
+	return priv, nil
+}
+
+// signConfigBundle encodes the bundle and signs its encoding.
+func (c *Core) signConfigBundle(ctx context.Context, bundle *ConfigBundle) ([]byte, []byte, error) {
+	key, err := c.configBundleSigningKey(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encoded, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode configuration bundle: %w", err)
+	}
+	return encoded, ed25519.Sign(key, encoded), nil
+}
+
+// verifyConfigBundle checks the signature of an encoded bundle with the given
+// public key, or the key of this cluster if none is given, and decodes it.
+func (c *Core) verifyConfigBundle(ctx context.Context, encoded, signature []byte, publicKey ed25519.PublicKey) (*ConfigBundle, error) {
+	if publicKey == nil {
+		key, err := c.configBundleSigningKey(ctx)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = key.Public().(ed25519.PublicKey)
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid public key")
+	}
+	if !ed25519.Verify(publicKey, encoded, signature) {
+		return nil, errors.New("invalid bundle signature")
+	}
+
+	bundle := new(ConfigBundle)
+	if err := jsonutil.DecodeJSON(encoded, bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode configuration bundle: %w", err)
+	}
+	if bundle.Version != configBundleVersion {
+		return nil, fmt.Errorf("unsupported configuration bundle version %d", bundle.Version)
+	}
+	return bundle, nil
+}
+
+// configBundleMountConfig returns the tunable settings of the mount, keyed
+// by the fields of the tune endpoints.
+func configBundleMountConfig(entry *MountEntry) map[string]interface{} {
+	config := map[string]interface{}{
+		"description":        entry.Description,
+		"default_lease_ttl":  configBundleTTL(entry.Config.DefaultLeaseTTL),
+		"max_lease_ttl":      configBundleTTL(entry.Config.MaxLeaseTTL),
+		"listing_visibility": string(entry.Config.ListingVisibility),
+	}
+	if len(entry.Options) > 0 {
+		config["options"] = entry.Options
+	}
+	if entry.Version != "" {
+		config["plugin_version"] = entry.Version
+	}
+	for _, key := range []string{
+		"audit_non_hmac_request_keys",
+		"audit_non_hmac_response_keys",
+		"passthrough_request_headers",
+		"allowed_response_headers",
+		"allowed_managed_keys",
+	} {
+		if rawVal, ok := entry.synthesizedConfigCache.Load(key); ok {
+			config[key] = rawVal.([]string)
+		}
+	}
+	if rawVal, ok := entry.synthesizedConfigCache.Load("identity_token_key"); ok {
+		config["identity_token_key"] = rawVal.(string)
+	}
+	if entry.Table == credentialTableType {
+		config["token_type"] = entry.Config.TokenType.String()
+	}
+	if lockout := entry.Config.UserLockoutConfig; lockout != nil {
+		config["user_lockout_config"] = map[string]interface{}{
+			"lockout_threshold":              strconv.FormatUint(lockout.LockoutThreshold, 10),
+			"lockout_duration":               configBundleTTL(lockout.LockoutDuration),
+			"lockout_counter_reset_duration": configBundleTTL(lockout.LockoutCounterReset),
+			"lockout_disable":                lockout.DisableLockout,
+		}
+	}
+	if entry.Config.PluginUpgradeWindow != nil {
+		config["plugin_upgrade_window"] = pluginUpgradeWindowResponse(entry.Config.PluginUpgradeWindow)
+	}
+	return config
+}
+
+// configBundleTTL formats a duration of the mount config, where zero stands
+// for the system default.
+func configBundleTTL(d time.Duration) string {
+	if d == 0 {
+		return "system"
+	}
+	return strconv.FormatInt(int64(d.Seconds()), 10) + "s"
+}
+
+// configBundleRotationJobs returns the rotation schedules of the root
+// credentials of the mount.
+func (c *Core) configBundleRotationJobs(ctx context.Context, entry *MountEntry) ([]*ConfigBundleRotationJob, error) {
+	ids, err := c.barrier.List(ctx, coreRotationJobsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*ConfigBundleRotationJob
+	for _, id := range ids {
+		if !strings.HasPrefix(id, entry.Accessor+"-") {
+			continue
+		}
+		job, err := c.rotationJob(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if job == nil || job.MountAccessor != entry.Accessor {
+			continue
+		}
+		jobs = append(jobs, &ConfigBundleRotationJob{
+			Name:     job.Name,
+			ReqPath:  job.ReqPath,
+			Schedule: job.Schedule,
+			Window:   int64(job.Window.Seconds()),
+			Period:   int64(job.Period.Seconds()),
+			Jitter:   int64(job.Jitter.Seconds()),
+			Calendar: job.Calendar,
+		})
+	}
+	return jobs, nil
+}
+
+// applyConfigBundleRotationJob registers the rotation schedule of a root
+// credential of the mount, and returns whether it changed.
+func (c *Core) applyConfigBundleRotationJob(ctx context.Context, entry *MountEntry, job *ConfigBundleRotationJob, dryRun bool) (bool, error) {
+	req := &rotation.RotationJobConfigureRequest{
+		Name:             job.Name,
+		ReqPath:          job.ReqPath,
+		RotationSchedule: job.Schedule,
+		RotationWindow:   time.Duration(job.Window) * time.Second,
+		RotationPeriod:   time.Duration(job.Period) * time.Second,
+		RotationJitter:   time.Duration(job.Jitter) * time.Second,
+		RotationCalendar: job.Calendar,
+	}
+
+	var existing *RotationJob
+	if entry != nil {
+		var err error
+		existing, err = c.rotationJob(ctx, rotationJobID(entry.Accessor, job.ReqPath))
+		if err != nil {
+			return false, err
+		}
+	}
+	if existing != nil && existing.Name == req.Name && existing.Schedule == req.RotationSchedule &&
+		existing.Window == req.RotationWindow && existing.Period == req.RotationPeriod &&
+		existing.Jitter == req.RotationJitter && existing.Calendar == req.RotationCalendar {
+		return false, nil
+	}
+
+	if dryRun {
+		return true, req.Validate()
+	}
+	if _, err := c.registerRotationJob(ctx, entry, req); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// configBundlePolicyNames returns the names of the policies the data of a
+// resource refers to.
+func configBundlePolicyNames(data map[string]interface{}) []string {
+	var names []string
+	for _, field := range configBundlePolicyFields {
+		switch v := data[field].(type) {
+		case []string:
+			names = append(names, v...)
+		case []interface{}:
+			for _, name := range v {
+				if s, ok := name.(string); ok {
+					names = append(names, s)
+				}
+			}
+		case string:
+			for _, name := range strings.Split(v, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// configBundleEqual returns whether two values have the same JSON encoding,
+// which compares the data of a bundle with data read from the cluster. Empty
+// lists and maps are equal to null, as backends return either for unset
+// fields.
+func configBundleEqual(a, b interface{}) bool {
+	normalize := func(v interface{}) (interface{}, error) {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var out interface{}
+		if err := json.Unmarshal(encoded, &out); err != nil {
+			return nil, err
+		}
+		return configBundlePruneEmpty(out), nil
+	}
+
+	na, err := normalize(a)
+	if err != nil {
+		return false
+	}
+	nb, err := normalize(b)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(na, nb)
+}
+
+func configBundlePruneEmpty(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		for i := range v {
+			v[i] = configBundlePruneEmpty(v[i])
+		}
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		for key := range v {
+			if v[key] = configBundlePruneEmpty(v[key]); v[key] == nil {
+				delete(v, key)
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	}
+	return v
+}
+
+// configBundleDiff returns the fields of the bundled data which differ from
+// the existing data, which are the fields written to update a resource.
+func configBundleDiff(existing, bundled map[string]interface{}) map[string]interface{} {
+	diff := make(map[string]interface{})
+	for key, value := range bundled {
+		if !configBundleEqual(existing[key], value) {
+			diff[key] = value
+		}
+	}
+	return diff
+}
@@ -0,0 +1,523 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/wrapping"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// coreControlGroupConfigPath is the storage path of the control group
+	// configuration.
+	coreControlGroupConfigPath = "core/control-group/config"
+
+	// coreControlGroupRequestsPath is the storage path of the parked control
+	// group requests, indexed by the accessor of their control group token.
+	// They are not stored in the cubbyhole of the token, which its holder
+	// could write to.
+	coreControlGroupRequestsPath = "core/control-group/requests/"
+
+	// defaultControlGroupTTL is the TTL of control group tokens if neither
+	// the policy nor the control group configuration sets one.
+	defaultControlGroupTTL = 24 * time.Hour
+)
+
+var (
+	errControlGroupNotApproved     = errors.New("control group request has not been approved")
+	errControlGroupNotAuthorizer   = errors.New("entity is not an authorizer of the control group request")
+	errControlGroupSelfAuthorize   = errors.New("control group requests cannot be authorized by their requester")
+	errControlGroupInvalidAccessor = errors.New("accessor is not the accessor of a control group token")
+	errControlGroupNotFound        = errors.New("no control group request found")
+)
+
+// ErrControlGroupRequired is returned by policy checks when a request is
+// allowed, but only once the control group of the policy authorizes it.
+type ErrControlGroupRequired struct {
+	ControlGroup *ControlGroup
+}
+
+func (e *ErrControlGroupRequired) Error() string {
+	return "request requires control group authorization"
+}
+
+// ControlGroupConfig is the control group configuration.
+type ControlGroupConfig struct {
+	// MaxTTL caps the TTL of control group tokens
+	MaxTTL time.Duration `json:"max_ttl"`
+}
+
+// controlGroupRequest is a request parked until the factors of its control
+// group authorize it. It is stored by core, indexed by the accessor of the
+// control group token returned to the requester.
+type controlGroupRequest struct {
+	Path        string                   `json:"path"`
+	Operation   logical.Operation        `json:"operation"`
+	Data        map[string]interface{}   `json:"data,omitempty"`
+	WrapInfo    *logical.RequestWrapInfo `json:"wrap_info,omitempty"`
+	NamespaceID string                   `json:"namespace_id"`
+	RequestTime time.Time                `json:"request_time"`
+
+	// ClientTokenAccessor is the accessor of the requester's token, which
+	// the request is run with once authorized.
+	ClientTokenAccessor string `json:"client_token_accessor"`
+	EntityID            string `json:"entity_id,omitempty"`
+
+	Factors        []*ControlGroupFactor        `json:"factors"`
+	Authorizations []*controlGroupAuthorization `json:"authorizations,omitempty"`
+}
+
+// controlGroupAuthorization is the approval of a control group request by an
+// entity, counting towards the factors the entity was an authorizer of.
+type controlGroupAuthorization struct {
+	EntityID   string    `json:"entity_id"`
+	EntityName string    `json:"entity_name"`
+	Time       time.Time `json:"time"`
+	Factors    []string  `json:"factors"`
+}
+
+// approved returns whether each factor of the request has its required number
+// of distinct approvals.
+func (r *controlGroupRequest) approved() bool {
+	for _, factor := range r.Factors {
+		var approvals int
+		for _, authz := range r.Authorizations {
+			if strutil.StrListContains(authz.Factors, factor.Name) {
+				approvals++
+			}
+		}
+		if approvals < factor.Identity.ApprovalsRequired {
+			return false
+		}
+	}
+	return true
+}
+
+// controlGroupFactors returns the factors of the control group which apply to
+// the operation, i.e. those controlling one of its capabilities.
+func controlGroupFactors(cg *ControlGroup, op logical.Operation) []*ControlGroupFactor {
+	if cg == nil {
+		return nil
+	}
+
+	var factors []*ControlGroupFactor
+	for _, factor := range cg.Factors {
+		if len(factor.ControlledCapabilities) == 0 ||
+			strutil.StrListContains(factor.ControlledCapabilities, operationCapability(op)) {
+			factors = append(factors, factor)
+		}
+	}
+	return factors
+}
+
+// operationCapability returns the name of the capability an operation
+// requires.
+func operationCapability(op logical.Operation) string {
+	switch op {
+	case logical.ReadOperation:
+		return ReadCapability
+	case logical.ListOperation:
+		return ListCapability
+	case logical.UpdateOperation:
+		return UpdateCapability
+	case logical.CreateOperation:
+		return CreateCapability
+	case logical.DeleteOperation:
+		return DeleteCapability
+	case logical.PatchOperation:
+		return PatchCapability
+	default:
+		return string(op)
+	}
+}
+
+// checkControlGroup returns ErrControlGroupRequired if a control group
+// applies to the request and the request is not the run of an authorized
+// control group request.
+func checkControlGroup(req *logical.Request, results *ACLResults) error {
+	if results == nil || (req.ControlGroup != nil && req.ControlGroup.Approved) {
+		return nil
+	}
+	if len(controlGroupFactors(results.ControlGroup, req.Operation)) == 0 {
+		return nil
+	}
+	return &ErrControlGroupRequired{ControlGroup: results.ControlGroup}
+}
+
+// controlGroupConfig returns the control group configuration, which is empty
+// if it was never configured.
+func (c *Core) controlGroupConfig(ctx context.Context) (*ControlGroupConfig, error) {
+	config := new(ControlGroupConfig)
+
+	entry, err := c.barrier.Get(ctx, coreControlGroupConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read control group config: %w", err)
+	}
+	if entry == nil {
+		return config, nil
+	}
+
+	if err := jsonutil.DecodeJSON(entry.Value, config); err != nil {
+		return nil, fmt.Errorf("failed to decode control group config: %w", err)
+	}
+	return config, nil
+}
+
+// controlGroupTTL returns the TTL of the token of a request parked by the
+// control group.
+func (c *Core) controlGroupTTL(ctx context.Context, cg *ControlGroup) (time.Duration, error) {
+	config, err := c.controlGroupConfig(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ttl := cg.TTL
+	if ttl == 0 {
+		ttl = defaultControlGroupTTL
+	}
+	if config.MaxTTL > 0 && ttl > config.MaxTTL {
+		ttl = config.MaxTTL
+	}
+	return ttl, nil
+}
+
+// parkControlGroupRequest parks a request requiring control group
+// authorization under the accessor of a new control group token, and returns a
+// response wrapping that token. The requester checks the status of the request
+// with the token's accessor, and unwraps the token to run the request once it
+// is authorized.
+func (c *Core) parkControlGroupRequest(ctx context.Context, req *logical.Request, auth *logical.Auth, cg *ControlGroup) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	requesterTE := req.TokenEntry()
+	if requesterTE == nil || requesterTE.Accessor == "" {
+		return logical.ErrorResponse("control groups require a service token"), logical.ErrInvalidRequest
+	}
+
+	ttl, err := c.controlGroupTTL(ctx, cg)
+	if err != nil {
+		return nil, err
+	}
+
+	creationTime := time.Now()
+	cgRequest := &controlGroupRequest{
+		Path:                req.Path,
+		Operation:           req.Operation,
+		Data:                req.Data,
+		WrapInfo:            req.WrapInfo,
+		NamespaceID:         ns.ID,
+		RequestTime:         creationTime,
+		ClientTokenAccessor: requesterTE.Accessor,
+		EntityID:            auth.EntityID,
+		Factors:             controlGroupFactors(cg, req.Operation),
+	}
+
+	te := &logical.TokenEntry{
+		Path:           req.Path,
+		Policies:       []string{controlGroupPolicyName},
+		CreationTime:   creationTime.Unix(),
+		TTL:            ttl,
+		ExplicitMaxTTL: ttl,
+		NamespaceID:    ns.ID,
+	}
+	if err := c.CreateToken(ctx, te); err != nil {
+		c.logger.Error("failed to create control group token", "error", err)
+		return nil, ErrInternalError
+	}
+
+	if err := c.storeControlGroupRequest(ctx, te, cgRequest); err != nil {
+		c.tokenStore.revokeOrphan(ctx, te.ID)
+		c.logger.Error("failed to store control group request", "request_path", req.Path, "error", err)
+		return nil, ErrInternalError
+	}
+
+	cgAuth := &logical.Auth{
+		ClientToken: te.ID,
+		Policies:    []string{controlGroupPolicyName},
+		LeaseOptions: logical.LeaseOptions{
+			TTL:       te.TTL,
+			Renewable: false,
+		},
+	}
+	if err := c.expiration.RegisterAuth(ctx, te, cgAuth, ""); err != nil {
+		c.tokenStore.revokeOrphan(ctx, te.ID)
+		c.logger.Error("failed to register control group token lease", "request_path", req.Path, "error", err)
+		return nil, ErrInternalError
+	}
+
+	return &logical.Response{
+		WrapInfo: &wrapping.ResponseWrapInfo{
+			TTL:             ttl,
+			Token:           te.ExternalID,
+			Accessor:        te.Accessor,
+			CreationTime:    creationTime,
+			CreationPath:    req.Path,
+			WrappedEntityID: auth.EntityID,
+		},
+	}, nil
+}
+
+// controlGroupToken returns the control group token of the accessor.
+func (c *Core) controlGroupToken(ctx context.Context, accessor string) (*logical.TokenEntry, error) {
+	aEntry, err := c.tokenStore.lookupByAccessor(ctx, accessor, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if aEntry == nil || aEntry.TokenID == "" {
+		return nil, errControlGroupInvalidAccessor
+	}
+
+	te, err := c.tokenStore.Lookup(ctx, aEntry.TokenID)
+	if err != nil {
+		return nil, err
+	}
+	if te == nil || len(te.Policies) != 1 || te.Policies[0] != controlGroupPolicyName {
+		return nil, errControlGroupInvalidAccessor
+	}
+	return te, nil
+}
+
+// controlGroupRequest returns the parked request of the control group token.
+func (c *Core) controlGroupRequest(ctx context.Context, te *logical.TokenEntry) (*controlGroupRequest, error) {
+	entry, err := c.barrier.Get(ctx, coreControlGroupRequestsPath+te.Accessor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read control group request: %w", err)
+	}
+	if entry == nil {
+		return nil, errControlGroupNotFound
+	}
+
+	cgRequest := new(controlGroupRequest)
+	if err := jsonutil.DecodeJSON(entry.Value, cgRequest); err != nil {
+		return nil, fmt.Errorf("failed to decode control group request: %w", err)
+	}
+	return cgRequest, nil
+}
+
+func (c *Core) storeControlGroupRequest(ctx context.Context, te *logical.TokenEntry, cgRequest *controlGroupRequest) error {
+	encoded, err := json.Marshal(cgRequest)
+	if err != nil {
+		return err
+	}
+
+	return c.barrier.Put(ctx, &logical.StorageEntry{
+		Key:   coreControlGroupRequestsPath + te.Accessor,
+		Value: encoded,
+	})
+}
+
+// deleteControlGroupRequest deletes the parked request of the control group
+// token with the given accessor, if any.
+func (c *Core) deleteControlGroupRequest(ctx context.Context, accessor string) error {
+	if err := c.barrier.Delete(ctx, coreControlGroupRequestsPath+accessor); err != nil {
+		return fmt.Errorf("failed to delete control group request: %w", err)
+	}
+	return nil
+}
+
+// authorizeControlGroupRequest records the approval of the control group
+// request of the token by the entity, for the factors whose groups the entity
+// is a member of, and returns whether the request is now authorized.
+func (c *Core) authorizeControlGroupRequest(ctx context.Context, te *logical.TokenEntry, entityID string) (bool, error) {
+	c.controlGroupLock.Lock()
+	defer c.controlGroupLock.Unlock()
+
+	cgRequest, err := c.controlGroupRequest(ctx, te)
+	if err != nil {
+		return false, err
+	}
+	if entityID == cgRequest.EntityID {
+		return false, errControlGroupSelfAuthorize
+	}
+	for _, authz := range cgRequest.Authorizations {
+		if authz.EntityID == entityID {
+			return cgRequest.approved(), nil
+		}
+	}
+
+	entity, err := c.identityStore.MemDBEntityByID(entityID, false)
+	if err != nil {
+		return false, err
+	}
+	if entity == nil {
+		return false, errControlGroupNotAuthorizer
+	}
+
+	directGroups, inheritedGroups, err := c.identityStore.groupsByEntityID(entityID)
+	if err != nil {
+		return false, err
+	}
+	groupIDs := make(map[string]bool)
+	groupNames := make(map[string]bool)
+	for _, group := range append(directGroups, inheritedGroups...) {
+		if group.NamespaceID != cgRequest.NamespaceID {
+			continue
+		}
+		groupIDs[group.ID] = true
+		groupNames[group.Name] = true
+	}
+
+	var factors []string
+	for _, factor := range cgRequest.Factors {
+		for _, id := range factor.Identity.GroupIDs {
+			if groupIDs[id] {
+				factors = append(factors, factor.Name)
+				break
+			}
+		}
+		if strutil.StrListContains(factors, factor.Name) {
+			continue
+		}
+		for _, name := range factor.Identity.GroupNames {
+			if groupNames[name] {
+				factors = append(factors, factor.Name)
+				break
+			}
+		}
+	}
+	if len(factors) == 0 {
+		return false, errControlGroupNotAuthorizer
+	}
+
+	cgRequest.Authorizations = append(cgRequest.Authorizations, &controlGroupAuthorization{
+		EntityID:   entity.ID,
+		EntityName: entity.Name,
+		Time:       time.Now(),
+		Factors:    factors,
+	})
+	if err := c.storeControlGroupRequest(ctx, te, cgRequest); err != nil {
+		return false, err
+	}
+
+	return cgRequest.approved(), nil
+}
+
+// consumeControlGroupRequest returns the parked request of the control group
+// token if it is authorized, and deletes it so that concurrent unwraps of the
+// token do not run it more than once.
+func (c *Core) consumeControlGroupRequest(ctx context.Context, te *logical.TokenEntry) (*controlGroupRequest, error) {
+	c.controlGroupLock.Lock()
+	defer c.controlGroupLock.Unlock()
+
+	cgRequest, err := c.controlGroupRequest(ctx, te)
+	if err != nil {
+		return nil, err
+	}
+	if !cgRequest.approved() {
+		return nil, errControlGroupNotApproved
+	}
+	if err := c.deleteControlGroupRequest(ctx, te.Accessor); err != nil {
+		return nil, err
+	}
+	return cgRequest, nil
+}
+
+// runControlGroupRequest runs the authorized control group request of the
+// token with the requester's token, returning the HTTP response, and revokes
+// the control group token.
+func (c *Core) runControlGroupRequest(ctx context.Context, token string) (string, error) {
+	te, err := c.tokenStore.lookupTainted(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if te == nil {
+		return "", errors.New("invalid control group token")
+	}
+
+	cgRequest, err := c.consumeControlGroupRequest(ctx, te)
+	if err != nil {
+		if errors.Is(err, errControlGroupNotApproved) {
+			return err.Error(), logical.ErrInvalidRequest
+		}
+		return "", err
+	}
+	// The request can be run again if it failed to run
+	restore := func() {
+		c.controlGroupLock.Lock()
+		defer c.controlGroupLock.Unlock()
+		if err := c.storeControlGroupRequest(ctx, te, cgRequest); err != nil {
+			c.logger.Error("failed to restore control group request", "error", err)
+		}
+	}
+
+	aEntry, err := c.tokenStore.lookupByAccessor(ctx, cgRequest.ClientTokenAccessor, false, false)
+	if err != nil {
+		return "", err
+	}
+	if aEntry == nil || aEntry.TokenID == "" {
+		restore()
+		return "the token of the control group request is no longer valid", logical.ErrPermissionDenied
+	}
+
+	ns, err := NamespaceByID(ctx, cgRequest.NamespaceID, c)
+	if err != nil {
+		restore()
+		return "", err
+	}
+	if ns == nil {
+		restore()
+		return "", errors.New("control group request is not from a valid namespace")
+	}
+
+	authorizations := make([]*logical.Authz, 0, len(cgRequest.Authorizations))
+	for _, authz := range cgRequest.Authorizations {
+		authorizations = append(authorizations, &logical.Authz{
+			Token:             authz.EntityID,
+			AuthorizationTime: authz.Time,
+		})
+	}
+	req := &logical.Request{
+		ID:          te.Accessor,
+		Path:        cgRequest.Path,
+		Operation:   cgRequest.Operation,
+		Data:        cgRequest.Data,
+		WrapInfo:    cgRequest.WrapInfo,
+		ClientToken: aEntry.TokenID,
+		ControlGroup: &logical.ControlGroup{
+			Authorizations: authorizations,
+			RequestTime:    cgRequest.RequestTime,
+			Approved:       true,
+			NamespaceID:    cgRequest.NamespaceID,
+		},
+	}
+
+	resp, err := c.handleCancelableRequest(namespace.ContextWithNamespace(ctx, ns), req)
+	if err != nil {
+		restore()
+		if resp != nil && resp.IsError() {
+			return resp.Error().Error(), err
+		}
+		return "", err
+	}
+
+	// The token is not used on every request, so that the requester can keep
+	// checking the request's status with it, and is revoked once the request
+	// has run
+	if err := c.tokenStore.revokeOrphan(ctx, te.ID); err != nil {
+		c.logger.Error("failed to revoke control group token", "error", err)
+	}
+
+	if resp == nil {
+		return "", nil
+	}
+	httpResp := logical.LogicalResponseToHTTPResponse(resp)
+	httpResp.RequestID = req.ID
+	encoded, err := json.Marshal(httpResp)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode control group response: %w", err)
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,272 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// testControlGroupEntityToken creates an entity and a service token for it
+// with the given policies, returning the entity ID and the token.
+func testControlGroupEntityToken(t *testing.T, c *Core, name string, policies ...string) (string, string) {
+	t.Helper()
+	ctx := namespace.RootContext(nil)
+
+	resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity",
+		Operation: logical.UpdateOperation,
+		Data:      map[string]interface{}{"name": name},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	entityID := resp.Data["id"].(string)
+
+	te := &logical.TokenEntry{
+		Path:     "auth/token/create",
+		Policies: policies,
+		EntityID: entityID,
+		TTL:      time.Hour,
+	}
+	testMakeTokenDirectly(t, c.tokenStore, te)
+	return entityID, te.ID
+}
+
+func TestControlGroup_Authorization(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	resp, err := c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "secret/foo",
+		ClientToken: root,
+		Data:        map[string]interface{}{"foo": "bar"},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	for name, rules := range map[string]string{
+		"requester": `
+path "secret/foo" {
+	capabilities = ["read", "update"]
+	control_group {
+		factor "approvers" {
+			controlled_capabilities = ["read"]
+			identity {
+				group_names = ["approvers"]
+				approvals = 2
+			}
+		}
+	}
+}`,
+		"authorizer": `
+path "sys/control-group/authorize" {
+	capabilities = ["update"]
+}
+path "sys/control-group/request" {
+	capabilities = ["update"]
+}`,
+	} {
+		policy, err := ParseACLPolicy(namespace.RootNamespace, rules)
+		require.NoError(t, err)
+		policy.Name = name
+		require.NoError(t, c.policyStore.SetPolicy(ctx, policy))
+	}
+
+	requesterID, requester := testControlGroupEntityToken(t, c, "requester", "requester")
+	approver1ID, approver1 := testControlGroupEntityToken(t, c, "approver1", "authorizer")
+	approver2ID, approver2 := testControlGroupEntityToken(t, c, "approver2", "authorizer")
+	_, outsider := testControlGroupEntityToken(t, c, "outsider", "authorizer")
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "group",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"name":              "approvers",
+			"member_entity_ids": []string{requesterID, approver1ID, approver2ID},
+		},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	// Capabilities which are not controlled are not parked
+	resp, err = c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "secret/foo",
+		ClientToken: requester,
+		Data:        map[string]interface{}{"foo": "baz"},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	resp, err = c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "secret/foo",
+		ClientToken: requester,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp.WrapInfo)
+	require.Nil(t, resp.Data)
+	require.Equal(t, "secret/foo", resp.WrapInfo.CreationPath)
+	require.Equal(t, requesterID, resp.WrapInfo.WrappedEntityID)
+	require.Equal(t, defaultControlGroupTTL, resp.WrapInfo.TTL)
+	cgToken, cgAccessor := resp.WrapInfo.Token, resp.WrapInfo.Accessor
+
+	unwrap := func() (*logical.Response, error) {
+		return c.HandleRequest(ctx, &logical.Request{
+			Operation:   logical.UpdateOperation,
+			Path:        "sys/wrapping/unwrap",
+			ClientToken: cgToken,
+		})
+	}
+	authorize := func(token string) (*logical.Response, error) {
+		return c.HandleRequest(ctx, &logical.Request{
+			Operation:   logical.UpdateOperation,
+			Path:        "sys/control-group/authorize",
+			ClientToken: token,
+			Data:        map[string]interface{}{"accessor": cgAccessor},
+		})
+	}
+
+	// The control group token cannot rewrite its parked request
+	_, err = c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "cubbyhole/control-group",
+		ClientToken: cgToken,
+		Data:        map[string]interface{}{"request": `{"path":"secret/foo","operation":"read"}`},
+	})
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+
+	// The request cannot run before it is authorized
+	resp, err = unwrap()
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+	require.Contains(t, resp.Error().Error(), "not been approved")
+
+	// Neither the requester nor non-members of the group can authorize it
+	_, err = authorize(requester)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+	_, err = authorize(outsider)
+	require.ErrorIs(t, err, logical.ErrPermissionDenied)
+
+	resp, err = authorize(approver1)
+	require.NoError(t, err)
+	require.Equal(t, false, resp.Data["approved"])
+
+	// Repeated approvals by the same entity are not counted twice
+	resp, err = authorize(approver1)
+	require.NoError(t, err)
+	require.Equal(t, false, resp.Data["approved"])
+
+	resp, err = authorize(approver2)
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["approved"])
+
+	resp, err = c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/control-group/request",
+		ClientToken: approver1,
+		Data:        map[string]interface{}{"accessor": cgAccessor},
+	})
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["approved"])
+	require.Equal(t, "secret/foo", resp.Data["request_path"])
+	require.Equal(t, map[string]interface{}{"id": requesterID, "name": "requester"}, resp.Data["request_entity"])
+	require.Equal(t, []map[string]interface{}{
+		{"entity_id": approver1ID, "entity_name": "approver1"},
+		{"entity_id": approver2ID, "entity_name": "approver2"},
+	}, resp.Data["authorizations"])
+
+	resp, err = unwrap()
+	require.NoError(t, err)
+	require.Contains(t, string(resp.Data[logical.HTTPRawBody].([]byte)), `"data":{"foo":"baz"}`)
+
+	// The control group token and its request are deleted once the request
+	// has run
+	te, err := c.tokenStore.Lookup(ctx, cgToken)
+	require.NoError(t, err)
+	require.Nil(t, te)
+	entry, err := c.barrier.Get(ctx, coreControlGroupRequestsPath+cgAccessor)
+	require.NoError(t, err)
+	require.Nil(t, entry)
+}
+
+// TestControlGroup_ConsumeOnce verifies that an authorized control group
+// request is only handed out once to concurrent unwraps.
+func TestControlGroup_ConsumeOnce(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	te := &logical.TokenEntry{Accessor: "cg-accessor"}
+	require.NoError(t, c.storeControlGroupRequest(ctx, te, &controlGroupRequest{Path: "secret/foo"}))
+
+	results := make(chan error, 10)
+	for i := 0; i < cap(results); i++ {
+		go func() {
+			_, err := c.consumeControlGroupRequest(ctx, te)
+			results <- err
+		}()
+	}
+
+	var consumed int
+	for i := 0; i < cap(results); i++ {
+		err := <-results
+		if err == nil {
+			consumed++
+			continue
+		}
+		require.ErrorIs(t, err, errControlGroupNotFound)
+	}
+	require.Equal(t, 1, consumed)
+}
+
+func TestControlGroup_Config(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	resp, err := c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/config/control-group",
+		ClientToken: root,
+		Data:        map[string]interface{}{"max_ttl": "1h"},
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	resp, err = c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "sys/config/control-group",
+		ClientToken: root,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3600), resp.Data["max_ttl"])
+
+	// The TTL of control groups is capped by the max TTL
+	ttl, err := c.controlGroupTTL(ctx, &ControlGroup{})
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, ttl)
+	ttl, err = c.controlGroupTTL(ctx, &ControlGroup{TTL: time.Minute})
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, ttl)
+
+	resp, err = c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.DeleteOperation,
+		Path:        "sys/config/control-group",
+		ClientToken: root,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	ttl, err = c.controlGroupTTL(ctx, &ControlGroup{})
+	require.NoError(t, err)
+	require.Equal(t, defaultControlGroupTTL, ttl)
+}
@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/armon/go-radix"
 	"github.com/hashicorp/errwrap"
 	log "github.com/hashicorp/go-hclog"
 	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
@@ -54,9 +55,11 @@ import (
 	"github.com/hashicorp/vault/plugins/event"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
 	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/cryptopolicy"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 	"github.com/hashicorp/vault/sdk/helper/logging"
 	"github.com/hashicorp/vault/sdk/helper/pathmanager"
+	"github.com/hashicorp/vault/sdk/helper/pluginutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/physical"
 	sr "github.com/hashicorp/vault/serviceregistration"
@@ -164,6 +167,15 @@ var (
 	// enabled in the configuration file
 	ErrIntrospectionNotEnabled = errors.New("The Vault configuration must set \"introspection_endpoint\" to true to enable this endpoint")
 
+	// DefaultSealDrainGracePeriod is how long the requests in flight are
+	// given to complete when draining them ahead of sealing, unless
+	// overridden on the request
+	DefaultSealDrainGracePeriod = 30 * time.Second
+
+	// ErrDraining is returned for new requests while the requests in flight
+	// are drained ahead of sealing
+	ErrDraining = errors.New("Vault is draining requests before sealing")
+
 	// manualStepDownSleepPeriod is how long to sleep after a user-initiated
 	// step down of the active node, to prevent instantly regrabbing the lock.
 	// It's var not const so that tests can manipulate it.
@@ -344,12 +356,20 @@ type Core struct {
 	// unlockInfo has the keys provided to Unseal until the threshold number of parts is available, as well as the operation nonce
 	unlockInfo *unlockInformation
 
+	// delegatedUnsealInfo has the challenge and partial signatures of the
+	// delegated unseal attempt in progress, if any
+	delegatedUnsealInfo *delegatedUnsealInformation
+
 	// generateRootProgress holds the shares until we reach enough
 	// to verify the master key
 	generateRootConfig   *GenerateRootConfig
 	generateRootProgress [][]byte
 	generateRootLock     sync.Mutex
 
+	// generateRootTokenConfig restricts the root tokens produced by root
+	// generation
+	generateRootTokenConfig *GenerateRootTokenConfig
+
 	// These variables holds the config and shares we have until we reach
 	// enough to verify the appropriate master key. Note that the same lock is
 	// used; this isn't time-critical so this shouldn't be a problem.
@@ -434,6 +454,10 @@ type Core struct {
 	// inFlightReqMap is used to store info about in-flight requests
 	inFlightReqData *InFlightRequests
 
+	// draining is set while the requests in flight are drained ahead of
+	// sealing
+	draining atomic.Bool
+
 	// mfaResponseAuthQueue is used to cache the auth response per request ID
 	mfaResponseAuthQueue     *LoginMFAPriorityQueue
 	mfaResponseAuthQueueLock sync.Mutex
@@ -462,6 +486,9 @@ type Core struct {
 	// disabled
 	physicalCache physical.ToggleablePurgemonster
 
+	// storageLimits enforces the configured storage limits, if any
+	storageLimits *LimitedStorage
+
 	// logRequestsLevel indicates at which level requests should be logged
 	logRequestsLevel *uberAtomic.Int32
 
@@ -484,6 +511,9 @@ type Core struct {
 	clusterID uberAtomic.String
 	// Specific cipher suites to use for clustering, if any
 	clusterCipherSuites []uint16
+	// The crypto policy restricting the algorithms used by Vault and its
+	// plugins, if any
+	cryptoPolicy string
 	// Used to modify cluster parameters
 	clusterParamsLock sync.RWMutex
 	// The private key stored in the barrier used for establishing
@@ -543,6 +573,10 @@ type Core struct {
 	// temporary files
 	pluginTmpdir string
 
+	// pluginCosignTrustedRoot is used to verify keyless cosign signatures of
+	// plugins
+	pluginCosignTrustedRoot *pluginutil.CosignTrustedRoot
+
 	// pluginFileUid is the uid of the plugin files and directory
 	pluginFileUid int
 
@@ -621,6 +655,10 @@ type Core struct {
 	// rawConfig stores the config as-is from the provided server configuration.
 	rawConfig *atomic.Value
 
+	// configDrift stores the drift between the configuration the server was
+	// started with and its configuration files, as of the last reload.
+	configDrift *atomic.Value
+
 	coreNumber int
 
 	// secureRandomReader is the reader used for CSP operations
@@ -656,6 +694,72 @@ type Core struct {
 
 	updateLockedUserEntriesCancel context.CancelFunc
 
+	pgpKeyExpirationChecksCancel context.CancelFunc
+
+	// pluginUpgrades tracks failed upgrades of mounts onto newer plugin
+	// versions within their upgrade window
+	pluginUpgrades       *pluginUpgrades
+	pluginUpgradesCancel context.CancelFunc
+
+	// rotationJobsLock serializes updates of root credentials registered for
+	// scheduled rotation
+	rotationJobsLock      sync.Mutex
+	rotationManagerCancel context.CancelFunc
+
+	// rotationBacklog is the number of root credentials in the rotation
+	// backlog as of the last run of the rotation manager
+	rotationBacklog atomic.Int64
+
+	// groupSyncCancel stops the scheduled refresh of external group
+	// memberships
+	groupSyncCancel context.CancelFunc
+
+	// metadataSourceSyncCancel stops the scheduled sync of entity metadata
+	// from external sources
+	metadataSourceSyncCancel context.CancelFunc
+
+	// tokenTidyCancel stops the scheduled tidy of the token store
+	tokenTidyCancel context.CancelFunc
+
+	// tokenUsageFlushCancel stops the periodic writes of token usage
+	tokenUsageFlushCancel context.CancelFunc
+
+	// secretCounters accumulates the changes of the counters of the secrets
+	// engines until they are written to storage
+	secretCounters *secretCounterTracker
+
+	// secretCountersFlushCancel stops the periodic writes of the counters of
+	// the secrets engines
+	secretCountersFlushCancel context.CancelFunc
+
+	// controlGroupLock serializes updates of parked control group requests
+	controlGroupLock sync.Mutex
+
+	// mountTrashLock serializes updates of the mounts retained in the trash
+	mountTrashLock sync.Mutex
+
+	// mountTrashCancel stops the purge of trashed mounts past their retention
+	// period
+	mountTrashCancel context.CancelFunc
+
+	// selfCheckResult is the result of the integrity checks run once the
+	// node becomes active
+	selfCheckResult atomic.Pointer[SelfCheckResult]
+
+	// selfCheckCancel stops the integrity checks
+	selfCheckCancel context.CancelFunc
+
+	// configBundleKeyLock serializes the creation of the key signing
+	// configuration bundles
+	configBundleKeyLock sync.Mutex
+
+	// sealHistoryLock serializes updates of the seal history
+	sealHistoryLock sync.Mutex
+
+	// pendingUnsealEvent is the unseal of the node, recorded in the seal
+	// history once the node is active
+	pendingUnsealEvent *SealHistoryEvent
+
 	// number of workers to use for lease revocation in the expiration manager
 	numExpirationWorkers int
 
@@ -718,6 +822,17 @@ type Core struct {
 
 	limiterRegistry     *limits.LimiterRegistry
 	limiterRegistryLock sync.Mutex
+
+	// overloadController sheds low priority requests while the node is
+	// overloaded. It is nil if load shedding is not configured.
+	overloadController *limits.OverloadController
+
+	// requestLimits indexes the configured request limits by path prefix. It
+	// is nil if no request limits are configured.
+	requestLimits *radix.Tree
+
+	// asyncOperations tracks the long-running operations started by requests
+	asyncOperations *asyncOperations
 }
 
 func (c *Core) ActiveNodeClockSkewMillis() int64 {
@@ -734,6 +849,12 @@ func (c *Core) GetRequestLimiter(key string) *limits.RequestLimiter {
 	return c.limiterRegistry.GetLimiter(key)
 }
 
+// OverloadController returns the controller used to shed requests while the
+// node is overloaded, or nil if load shedding is not configured.
+func (c *Core) OverloadController() *limits.OverloadController {
+	return c.overloadController
+}
+
 // c.stateLock needs to be held in read mode before calling this function.
 func (c *Core) HAState() consts.HAState {
 	switch {
@@ -824,6 +945,21 @@ type CoreConfig struct {
 
 	ClusterCipherSuites string
 
+	// CryptoPolicy is the name of the crypto policy restricting the algorithms
+	// used by Vault and its plugins, e.g. "fips-140-3".
+	CryptoPolicy string
+
+	// GenerateRoot restricts the root tokens produced by root generation
+	GenerateRoot *GenerateRootTokenConfig
+
+	// StorageLimits restrict the size and number of entries written under
+	// storage path prefixes
+	StorageLimits []*StorageLimit
+
+	// RequestLimits override the maximum request size of the listeners, and
+	// limit the number of request parameters, under API path prefixes
+	RequestLimits []*RequestLimit
+
 	EnableUI bool
 
 	// Enable the raw endpoint
@@ -835,6 +971,12 @@ type CoreConfig struct {
 	PluginDirectory string
 	PluginTmpdir    string
 
+	// PluginCosignFulcioCAFile and PluginCosignRekorKeyFile are the PEM
+	// files of the Fulcio CA certificates and Rekor public keys used to
+	// verify keyless cosign signatures of plugins.
+	PluginCosignFulcioCAFile string
+	PluginCosignRekorKeyFile string
+
 	PluginFileUid int
 
 	PluginFilePermissions int
@@ -905,6 +1047,10 @@ type CoreConfig struct {
 
 	DisableRequestLimiter bool
 	LimiterRegistry       *limits.LimiterRegistry
+
+	// LoadShedding enables the shedding of low priority requests while the
+	// node is overloaded, using the given thresholds, if set.
+	LoadShedding *limits.OverloadConfig
 }
 
 // GetServiceRegistration returns the config's ServiceRegistration, or nil if it does
@@ -1070,6 +1216,7 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		metricSink:                     conf.MetricSink,
 		secureRandomReader:             conf.SecureRandomReader,
 		rawConfig:                      new(atomic.Value),
+		configDrift:                    new(atomic.Value),
 		recoveryMode:                   conf.RecoveryMode,
 		postUnsealStarted:              new(uint32),
 		raftInfo:                       new(atomic.Value),
@@ -1097,6 +1244,9 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		echoDuration:                   uberAtomic.NewDuration(0),
 		activeNodeClockSkewMillis:      uberAtomic.NewInt64(0),
 		periodicLeaderRefreshInterval:  conf.PeriodicLeaderRefreshInterval,
+		requestLimits:                  newRequestLimitTree(conf.RequestLimits),
+		asyncOperations:                newAsyncOperations(),
+		secretCounters:                 newSecretCounterTracker(),
 	}
 
 	c.standbyStopCh.Store(make(chan struct{}))
@@ -1118,6 +1268,10 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 	}
 
 	c.SetConfig(conf.RawConfig)
+	c.SetConfigDrift(&server.ConfigDriftReport{
+		Time:    time.Now(),
+		Changes: []*server.ConfigChange{},
+	})
 
 	atomic.StoreUint32(c.replicationState, uint32(consts.ReplicationDRDisabled|consts.ReplicationPerformanceDisabled))
 	c.localClusterCert.Store(([]byte)(nil))
@@ -1161,6 +1315,26 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		c.clusterCipherSuites = suites
 	}
 
+	c.generateRootTokenConfig = conf.GenerateRoot
+
+	if conf.CryptoPolicy != cryptopolicy.None {
+		if err := cryptopolicy.Validate(conf.CryptoPolicy); err != nil {
+			return nil, fmt.Errorf("core setup failed: %w", err)
+		}
+		c.cryptoPolicy = conf.CryptoPolicy
+
+		switch conf.ClusterCipherSuites {
+		case "", "tls13", "tls12":
+			c.clusterCipherSuites = cryptopolicy.TLSCipherSuites(c.cryptoPolicy)
+		default:
+			for _, suite := range c.clusterCipherSuites {
+				if err := cryptopolicy.CheckTLSCipherSuite(c.cryptoPolicy, suite); err != nil {
+					return nil, fmt.Errorf("error parsing cluster cipher suites: %w", err)
+				}
+			}
+		}
+	}
+
 	// Load CORS config and provide a value for the core field.
 	c.corsConfig = &CORSConfig{
 		core:    c,
@@ -1258,6 +1432,23 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 			return nil, fmt.Errorf("core setup failed, could not verify plugin tmpdir: %w", err)
 		}
 	}
+	if conf.PluginCosignFulcioCAFile != "" || conf.PluginCosignRekorKeyFile != "" {
+		if conf.PluginCosignFulcioCAFile == "" || conf.PluginCosignRekorKeyFile == "" {
+			return nil, errors.New("core setup failed, both a plugin cosign Fulcio CA file and Rekor key file are required")
+		}
+		fulcioPEM, err := os.ReadFile(conf.PluginCosignFulcioCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("core setup failed, could not read plugin cosign Fulcio CA file: %w", err)
+		}
+		rekorPEM, err := os.ReadFile(conf.PluginCosignRekorKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("core setup failed, could not read plugin cosign Rekor key file: %w", err)
+		}
+		c.pluginCosignTrustedRoot, err = pluginutil.ParseCosignTrustedRoot(fulcioPEM, rekorPEM)
+		if err != nil {
+			return nil, fmt.Errorf("core setup failed: %w", err)
+		}
+	}
 
 	if conf.PluginFileUid != 0 {
 		c.pluginFileUid = conf.PluginFileUid
@@ -1324,6 +1515,10 @@ func NewCore(conf *CoreConfig) (*Core, error) {
 	}
 	c.limiterRegistryLock.Unlock()
 
+	if conf.LoadShedding != nil {
+		c.overloadController = limits.NewOverloadController(c.logger.Named("load-shedding"), *conf.LoadShedding)
+	}
+
 	err = c.adjustForSealMigration(conf.UnwrapSeal)
 	if err != nil {
 		return nil, err
@@ -1513,6 +1708,13 @@ func (c *Core) RaftNodeIDHeaderEnabled() bool {
 	return c.enableResponseHeaderRaftNodeID
 }
 
+// CryptoPolicy returns the name of the crypto policy restricting the
+// algorithms used by Vault and its plugins, or an empty string if there is
+// none.
+func (c *Core) CryptoPolicy() string {
+	return c.cryptoPolicy
+}
+
 // DisableSSCTokens determines whether to use server side consistent tokens or not.
 func (c *Core) DisableSSCTokens() bool {
 	return c.disableSSCTokens
@@ -1532,6 +1734,11 @@ func (c *Core) ShutdownCoreError(err error) {
 // happens as quickly as possible.
 func (c *Core) Shutdown() error {
 	c.logger.Debug("shutdown called")
+
+	c.stateLock.RLock()
+	c.recordSeal(namespace.RootContext(nil), sealReasonShutdown, "")
+	c.stateLock.RUnlock()
+
 	err := c.sealInternal()
 
 	c.stateLock.Lock()
@@ -1599,6 +1806,7 @@ func (c *Core) ResetUnsealProcess() {
 	c.stateLock.Lock()
 	defer c.stateLock.Unlock()
 	c.unlockInfo = nil
+	c.delegatedUnsealInfo = nil
 }
 
 func (c *Core) UnsealMigrate(key []byte) (bool, error) {
@@ -1691,6 +1899,16 @@ func (c *Core) unsealFragment(key []byte, migrate bool) error {
 		sealToUse = c.migrationInfo.seal
 	}
 
+	if !sealToUse.RecoveryKeySupported() && !c.isRaftUnseal() {
+		config, err := sealToUse.BarrierConfig(ctx)
+		if err != nil {
+			return err
+		}
+		if config != nil && config.DelegatedUnsealConfig != nil {
+			return &ErrInvalidKey{"delegated unseal is configured; key holders must sign the unseal challenge instead of submitting their key shares"}
+		}
+	}
+
 	newKey, err := c.recordUnsealPart(key)
 	if !newKey || err != nil {
 		return err
@@ -1713,7 +1931,7 @@ func (c *Core) unsealFragment(key []byte, migrate bool) error {
 	if err != nil {
 		return err
 	}
-	return c.unsealInternal(ctx, masterKey)
+	return c.unsealInternal(ctx, masterKey, sealReasonUnsealKeys)
 }
 
 func (c *Core) unsealWithRaft(combinedKey []byte) error {
@@ -1777,7 +1995,7 @@ func (c *Core) unsealWithRaft(combinedKey []byte) error {
 				}
 			}
 			if keyringFound && len(masterKey) > 0 {
-				err := c.unsealInternal(ctx, masterKey)
+				err := c.unsealInternal(ctx, masterKey, sealReasonUnsealKeys)
 				if err != nil {
 					c.logger.Error("failed to unseal", "error", err)
 				}
@@ -2031,12 +2249,14 @@ func (c *Core) migrateSeal(ctx context.Context) error {
 }
 
 // unsealInternal takes in the master key and attempts to unseal the barrier.
+// The reason is recorded in the seal history.
 // N.B.: This must be called with the state write lock held.
-func (c *Core) unsealInternal(ctx context.Context, masterKey []byte) error {
+func (c *Core) unsealInternal(ctx context.Context, masterKey []byte, reason string) error {
 	// Attempt to unlock
 	if err := c.barrier.Unseal(ctx, masterKey); err != nil {
 		return err
 	}
+	c.pendingUnsealEvent = c.newSealHistoryEvent(sealHistoryEventUnseal, reason, "")
 
 	if err := preUnsealInternal(ctx, c); err != nil {
 		return err
@@ -2122,6 +2342,24 @@ func (c *Core) unsealInternal(ctx context.Context, masterKey []byte) error {
 func (c *Core) SealWithRequest(httpCtx context.Context, req *logical.Request) error {
 	defer metrics.MeasureSince([]string{"core", "seal-with-request"}, time.Now())
 
+	return c.sealWithRequest(httpCtx, req, 0)
+}
+
+// DrainAndSealWithRequest is like SealWithRequest, but once the request is
+// authorized it stops accepting new requests, and gives the requests in flight
+// up to gracePeriod to complete before sealing. Requests still in flight after
+// the grace period are aborted.
+func (c *Core) DrainAndSealWithRequest(httpCtx context.Context, req *logical.Request, gracePeriod time.Duration) error {
+	defer metrics.MeasureSince([]string{"core", "drain-and-seal-with-request"}, time.Now())
+
+	if gracePeriod <= 0 {
+		return errors.New("grace period must be positive to drain requests")
+	}
+
+	return c.sealWithRequest(httpCtx, req, gracePeriod)
+}
+
+func (c *Core) sealWithRequest(httpCtx context.Context, req *logical.Request, gracePeriod time.Duration) error {
 	if c.Sealed() {
 		return nil
 	}
@@ -2133,6 +2371,12 @@ func (c *Core) SealWithRequest(httpCtx context.Context, req *logical.Request) er
 	ctx, cancel := context.WithCancel(namespace.RootContext(nil))
 	defer cancel()
 
+	// Carry over the in-flight request ID, so that draining does not wait on
+	// this request itself
+	if inFlightReqID, ok := httpCtx.Value(logical.CtxKeyInFlightRequestID{}).(string); ok {
+		ctx = context.WithValue(ctx, logical.CtxKeyInFlightRequestID{}, inFlightReqID)
+	}
+
 	go func() {
 		select {
 		case <-ctx.Done():
@@ -2142,7 +2386,7 @@ func (c *Core) SealWithRequest(httpCtx context.Context, req *logical.Request) er
 	}()
 
 	// This will unlock the read lock
-	return c.sealInitCommon(ctx, req)
+	return c.sealInitCommon(ctx, req, gracePeriod)
 }
 
 // Seal takes in a token and creates a logical.Request, acquires the lock, and
@@ -2164,13 +2408,15 @@ func (c *Core) Seal(token string) error {
 
 	// This will unlock the read lock
 	// We use background context since we may not be active
-	return c.sealInitCommon(namespace.RootContext(nil), req)
+	return c.sealInitCommon(namespace.RootContext(nil), req, 0)
 }
 
 // sealInitCommon is common logic for Seal and SealWithRequest and is used to
 // re-seal the Vault. This requires the Vault to be unsealed again to perform
-// any further operations. Note: this function will read-unlock the state lock.
-func (c *Core) sealInitCommon(ctx context.Context, req *logical.Request) (retErr error) {
+// any further operations. If gracePeriod is positive, the requests in flight
+// are drained before sealing. Note: this function will read-unlock the state
+// lock.
+func (c *Core) sealInitCommon(ctx context.Context, req *logical.Request, gracePeriod time.Duration) (retErr error) {
 	defer metrics.MeasureSince([]string{"core", "seal-internal"}, time.Now())
 
 	var unlocked bool
@@ -2280,10 +2526,24 @@ func (c *Core) sealInitCommon(ctx context.Context, req *logical.Request) (retErr
 		}
 	}
 
+	reason := sealReasonRequest
+	if gracePeriod > 0 {
+		reason = sealReasonDrainRequest
+	}
+	var initiator string
+	if te != nil {
+		initiator = te.DisplayName
+	}
+	c.recordSeal(ctx, reason, initiator)
+
 	// Unlock; sealing will grab the lock when needed
 	unlocked = true
 	c.stateLock.RUnlock()
 
+	if gracePeriod > 0 {
+		c.drainRequests(ctx, gracePeriod)
+	}
+
 	sealErr := c.sealInternal()
 
 	if sealErr != nil {
@@ -2293,6 +2553,70 @@ func (c *Core) sealInitCommon(ctx context.Context, req *logical.Request) (retErr
 	return
 }
 
+// drainRequests stops new requests from being accepted, and waits up to
+// gracePeriod for the requests in flight to complete. Requests still in flight
+// after that are aborted by canceling the active context. The audit devices
+// are flushed once done.
+func (c *Core) drainRequests(ctx context.Context, gracePeriod time.Duration) {
+	defer metrics.MeasureSince([]string{"core", "seal", "drain"}, time.Now())
+
+	c.draining.Store(true)
+	defer c.draining.Store(false)
+
+	ownReqID, _ := ctx.Value(logical.CtxKeyInFlightRequestID{}).(string)
+	inFlight := func() int {
+		var count int
+		c.inFlightReqData.InFlightReqMap.Range(func(key, _ interface{}) bool {
+			if key.(string) != ownReqID {
+				count++
+			}
+			return true
+		})
+		return count
+	}
+
+	c.logger.Info("draining requests before sealing", "in_flight", inFlight(), "grace_period", gracePeriod)
+
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	remaining := inFlight()
+DRAIN:
+	for remaining > 0 {
+		select {
+		case <-ticker.C:
+			remaining = inFlight()
+		case <-timer.C:
+			break DRAIN
+		case <-ctx.Done():
+			break DRAIN
+		}
+	}
+
+	if remaining > 0 {
+		c.logger.Warn("grace period ended with requests in flight, aborting them", "in_flight", remaining)
+		if activeCtxCancel := c.activeContextCancelFunc.Load().(context.CancelFunc); activeCtxCancel != nil {
+			activeCtxCancel()
+		}
+	} else {
+		c.logger.Info("drained requests before sealing")
+	}
+
+	if c.auditBroker != nil {
+		if err := c.auditBroker.Flush(ctx); err != nil {
+			c.logger.Error("failed to flush audit devices before sealing", "error", err)
+		}
+	}
+}
+
+// Draining returns whether the node is draining the requests in flight ahead
+// of sealing, in which case no new requests should be accepted.
+func (c *Core) Draining() bool {
+	return c.draining.Load()
+}
+
 // UIEnabled returns if the UI is enabled
 func (c *Core) UIEnabled() bool {
 	return c.uiConfig.Enabled()
@@ -2559,6 +2883,7 @@ func (c *Core) setupPluginCatalog(ctx context.Context) error {
 		Tmpdir:               c.pluginTmpdir,
 		EnableMlock:          c.enableMlock,
 		PluginRuntimeCatalog: c.pluginRuntimeCatalog,
+		CosignTrustedRoot:    c.pluginCosignTrustedRoot,
 	})
 	if err != nil {
 		return err
@@ -2616,9 +2941,21 @@ func buildUnsealSetupFunctionSlice(c *Core) []func(context.Context) error {
 			c.updateLockedUserEntries()
 			return nil
 		})
+		setupFunctions = append(setupFunctions, func(_ context.Context) error {
+			c.startPGPKeyExpirationChecks()
+			return nil
+		})
 		setupFunctions = append(setupFunctions, func(_ context.Context) error {
 			return c.startRollback()
 		})
+		setupFunctions = append(setupFunctions, func(_ context.Context) error {
+			c.startPluginUpgrades()
+			return nil
+		})
+		setupFunctions = append(setupFunctions, func(_ context.Context) error {
+			c.startRotationManager()
+			return nil
+		})
 		setupFunctions = append(setupFunctions, func(_ context.Context) error {
 			return c.setupExpiration(expireLeaseStrategyFairsharing)
 		})
@@ -2626,6 +2963,35 @@ func buildUnsealSetupFunctionSlice(c *Core) []func(context.Context) error {
 		setupFunctions = append(setupFunctions, c.setupAuditedHeadersConfig)
 		setupFunctions = append(setupFunctions, c.setupAudits)
 		setupFunctions = append(setupFunctions, c.loadIdentityStoreArtifacts)
+		setupFunctions = append(setupFunctions, func(_ context.Context) error {
+			c.startGroupSync()
+			return nil
+		})
+		setupFunctions = append(setupFunctions, func(_ context.Context) error {
+			c.startMetadataSourceSync()
+			return nil
+		})
+		setupFunctions = append(setupFunctions, func(_ context.Context) error {
+			c.startTokenTidy()
+			return nil
+		})
+		setupFunctions = append(setupFunctions, func(_ context.Context) error {
+			c.startTokenUsageFlush()
+			return nil
+		})
+		setupFunctions = append(setupFunctions, func(_ context.Context) error {
+			c.startSecretCountersFlush()
+			return nil
+		})
+		setupFunctions = append(setupFunctions, func(_ context.Context) error {
+			c.startMountTrashPurge()
+			return nil
+		})
+		setupFunctions = append(setupFunctions, c.recordPendingUnseal)
+		setupFunctions = append(setupFunctions, func(_ context.Context) error {
+			c.startSelfCheck()
+			return nil
+		})
 		setupFunctions = append(setupFunctions, func(ctx context.Context) error {
 			return loadPolicyMFAConfigs(ctx, c)
 		})
@@ -2734,6 +3100,12 @@ func (c *Core) postUnseal(ctx context.Context, ctxCancelFunc context.CancelFunc,
 		c.physicalCache.SetEnabled(true)
 	}
 
+	// Count the entries under the storage limits again, as other nodes may
+	// have written them while this node was standby
+	if c.storageLimits != nil {
+		c.storageLimits.resetEntries()
+	}
+
 	// Purge these for safety in case of a rekey
 	_ = c.seal.ClearBarrierConfig(ctx)
 	if c.seal.RecoveryKeySupported() {
@@ -2834,7 +3206,9 @@ func (c *Core) preSeal() error {
 	c.postUnsealFuncs = nil
 
 	// Clear any rekey progress
+	c.barrierRekeyConfig.destroyVerificationKey()
 	c.barrierRekeyConfig = nil
+	c.recoveryRekeyConfig.destroyVerificationKey()
 	c.recoveryRekeyConfig = nil
 
 	if c.metricsCh != nil {
@@ -2856,6 +3230,9 @@ func (c *Core) preSeal() error {
 	if err := c.teardownAudits(); err != nil {
 		result = multierror.Append(result, fmt.Errorf("error tearing down audits: %w", err))
 	}
+	// The token usage is flushed before the token store is torn down
+	c.stopTokenUsageFlush()
+	c.stopSecretCountersFlush()
 	if err := c.stopExpiration(); err != nil {
 		result = multierror.Append(result, fmt.Errorf("error stopping expiration: %w", err))
 	}
@@ -2892,6 +3269,48 @@ func (c *Core) preSeal() error {
 		c.updateLockedUserEntriesCancel = nil
 	}
 
+	if c.pgpKeyExpirationChecksCancel != nil {
+		c.pgpKeyExpirationChecksCancel()
+		c.pgpKeyExpirationChecksCancel = nil
+	}
+
+	if c.pluginUpgradesCancel != nil {
+		c.pluginUpgradesCancel()
+		c.pluginUpgradesCancel = nil
+	}
+
+	if c.rotationManagerCancel != nil {
+		c.rotationManagerCancel()
+		c.rotationManagerCancel = nil
+	}
+	c.rotationBacklog.Store(0)
+
+	if c.groupSyncCancel != nil {
+		c.groupSyncCancel()
+		c.groupSyncCancel = nil
+	}
+
+	if c.metadataSourceSyncCancel != nil {
+		c.metadataSourceSyncCancel()
+		c.metadataSourceSyncCancel = nil
+	}
+
+	if c.tokenTidyCancel != nil {
+		c.tokenTidyCancel()
+		c.tokenTidyCancel = nil
+	}
+
+	if c.mountTrashCancel != nil {
+		c.mountTrashCancel()
+		c.mountTrashCancel = nil
+	}
+
+	if c.selfCheckCancel != nil {
+		c.selfCheckCancel()
+		c.selfCheckCancel = nil
+	}
+	c.selfCheckResult.Store(nil)
+
 	if seal, ok := c.seal.(*autoSeal); ok {
 		seal.StopHealthCheck()
 	}
@@ -3463,6 +3882,18 @@ func (c *Core) SetLogLevelByName(name string, level log.Level) bool {
 	return found
 }
 
+// SetConfigDrift sets the drift between the configuration the server was
+// started with and its configuration files.
+func (c *Core) SetConfigDrift(report *server.ConfigDriftReport) {
+	c.configDrift.Store(report)
+}
+
+// ConfigDrift returns the drift between the configuration the server was
+// started with and its configuration files, as of the last reload.
+func (c *Core) ConfigDrift() *server.ConfigDriftReport {
+	return c.configDrift.Load().(*server.ConfigDriftReport)
+}
+
 // SetConfig sets core's config object to the newly provided config.
 func (c *Core) SetConfig(conf *server.Config) {
 	c.rawConfig.Store(conf)
@@ -3690,6 +4121,41 @@ func (c *Core) ApplyRateLimitQuota(ctx context.Context, req *quotas.Request) (qu
 	return resp, nil
 }
 
+// ObserveRateLimitQuota reports the latency and status code of a completed
+// request that was allowed by ApplyRateLimitQuota with the given access. Server
+// errors count as failures towards adaptive rate limit quotas.
+func (c *Core) ObserveRateLimitQuota(access quotas.Access, latency time.Duration, statusCode int) {
+	if c.quotaManager != nil && access != nil {
+		c.quotaManager.ObserveRequest(access, latency, statusCode >= http.StatusInternalServerError)
+	}
+}
+
+// ApplyConcurrencyQuota checks the request against the applicable concurrency
+// quota rule. If the request is allowed and the response has an Access set,
+// ReleaseConcurrencyQuota must be called with it once the request completes.
+func (c *Core) ApplyConcurrencyQuota(ctx context.Context, req *quotas.Request) (quotas.Response, error) {
+	req.Type = quotas.TypeConcurrency
+
+	resp := quotas.Response{
+		Allowed: true,
+		Headers: make(map[string]string),
+	}
+
+	if c.quotaManager != nil {
+		return c.quotaManager.ApplyQuota(ctx, req)
+	}
+
+	return resp, nil
+}
+
+// ReleaseConcurrencyQuota frees the in-flight request slot held by the access
+// of a request that was allowed by ApplyConcurrencyQuota.
+func (c *Core) ReleaseConcurrencyQuota(access quotas.Access) {
+	if c.quotaManager != nil && access != nil {
+		c.quotaManager.ReleaseQuota(access)
+	}
+}
+
 // RateLimitAuditLoggingEnabled returns if the quota configuration allows audit
 // logging of request rejections due to rate limiting quota rule violations.
 func (c *Core) RateLimitAuditLoggingEnabled() bool {
@@ -3798,13 +4264,14 @@ func (c *Core) loadLoginMFAConfigs(ctx context.Context) error {
 }
 
 type MFACachedAuthResponse struct {
-	CachedAuth            *logical.Auth
-	RequestPath           string
-	RequestNSID           string
-	RequestNSPath         string
-	RequestConnRemoteAddr string
-	TimeOfStorage         time.Time
-	RequestID             string
+	CachedAuth             *logical.Auth
+	RequestPath            string
+	RequestNSID            string
+	RequestNSPath          string
+	RequestConnRemoteAddr  string
+	RequestListenerAddress string
+	TimeOfStorage          time.Time
+	RequestID              string
 }
 
 func (c *Core) setupCachedMFAResponseAuth() {
@@ -4272,6 +4739,56 @@ func (c *Core) ResolveRoleForQuotas(ctx context.Context, req *quotas.Request) (b
 	return c.quotaManager.QueryResolveRoleQuotas(req)
 }
 
+// ResolveTokenForQuotas populates the entity ID, policies and entity metadata
+// of the given client token in the quota request if the applicable rate limit
+// quota groups clients by entity or has token based exemptions. The token is
+// only looked up when such a quota exists. Tokens that cannot be resolved are
+// not an error here, since the request will be rejected later on if the token
+// is invalid; the quota then applies as if no token was given.
+func (c *Core) ResolveTokenForQuotas(ctx context.Context, req *quotas.Request, token string) error {
+	if c.quotaManager == nil || token == "" {
+		return nil
+	}
+
+	required, err := c.quotaManager.QueryResolveTokenQuotas(req)
+	if err != nil || !required {
+		return err
+	}
+
+	c.stateLock.RLock()
+	defer c.stateLock.RUnlock()
+
+	te, err := c.LookupToken(ctx, token)
+	if err != nil {
+		c.logger.Trace("unable to resolve token for quotas", "error", err)
+		return nil
+	}
+	if te == nil {
+		return nil
+	}
+
+	req.EntityID = te.EntityID
+	req.Policies = append(req.Policies, te.Policies...)
+
+	tokenNS, err := NamespaceByID(ctx, te.NamespaceID, c)
+	if err != nil || tokenNS == nil {
+		return nil
+	}
+	entity, identityPolicies, err := c.fetchEntityAndDerivedPolicies(ctx, tokenNS, te.EntityID, te.NoIdentityPolicies)
+	if err != nil {
+		c.logger.Trace("unable to resolve token entity for quotas", "error", err)
+		return nil
+	}
+	if entity != nil {
+		req.EntityMetadata = entity.Metadata
+	}
+	for _, nsPolicies := range identityPolicies {
+		req.Policies = append(req.Policies, nsPolicies...)
+	}
+
+	return nil
+}
+
 // aliasNameFromLoginRequest will determine the aliasName from the login Request
 func (c *Core) aliasNameFromLoginRequest(ctx context.Context, req *logical.Request) (string, error) {
 	c.authLock.RLock()
@@ -854,6 +854,63 @@ func TestCore_Seal_BadToken(t *testing.T) {
 	}
 }
 
+// TestCore_DrainAndSeal verifies that draining rejects new requests, and
+// seals once the requests in flight complete.
+func TestCore_DrainAndSeal(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	c.StoreInFlightReqData("in-flight", InFlightReqData{ReqPath: "/v1/secret/foo"})
+
+	req := &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/seal",
+		ClientToken: root,
+	}
+	errCh := make(chan error)
+	go func() {
+		errCh <- c.DrainAndSealWithRequest(context.Background(), req, time.Minute)
+	}()
+
+	corehelpers.RetryUntil(t, 5*time.Second, func() error {
+		if !c.Draining() {
+			return errors.New("not draining")
+		}
+		return nil
+	})
+	if c.Sealed() {
+		t.Fatal("sealed with a request in flight")
+	}
+
+	c.FinalizeInFlightReqData("in-flight", 200)
+	if err := <-errCh; err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !c.Sealed() {
+		t.Fatal("should be sealed")
+	}
+	if c.Draining() {
+		t.Fatal("should no longer be draining")
+	}
+}
+
+// TestCore_DrainAndSeal_GracePeriod verifies that draining seals once the grace
+// period passes, even with requests still in flight.
+func TestCore_DrainAndSeal_GracePeriod(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	c.StoreInFlightReqData("in-flight", InFlightReqData{ReqPath: "/v1/secret/foo"})
+
+	req := &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/seal",
+		ClientToken: root,
+	}
+	if err := c.DrainAndSealWithRequest(context.Background(), req, 200*time.Millisecond); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !c.Sealed() {
+		t.Fatal("should be sealed")
+	}
+}
+
 func TestCore_PreOneTen_BatchTokens(t *testing.T) {
 	c, _, _ := TestCoreUnsealed(t)
 
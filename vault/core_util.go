@@ -58,6 +58,19 @@ func coreInit(c *Core, conf *CoreConfig) error {
 		c.physical = physical.NewStorageEncoding(c.physical)
 	}
 
+	// Wrap in storage limits if configured
+	if len(conf.StorageLimits) > 0 {
+		storageLimitsLogger := c.baseLogger.Named("storage.limits")
+		c.allLoggers = append(c.allLoggers, storageLimitsLogger)
+		c.physical = NewLimitedStorage(c.physical, conf.StorageLimits, storageLimitsLogger, c.MetricSink())
+		switch limited := c.physical.(type) {
+		case *LimitedStorage:
+			c.storageLimits = limited
+		case *TransactionalLimitedStorage:
+			c.storageLimits = limited.LimitedStorage
+		}
+	}
+
 	return nil
 }
 
@@ -140,8 +153,19 @@ func (c *Core) collectNamespaces() []*namespace.Namespace {
 	}
 }
 
+// HasWALState returns whether the required index state of this cluster is
+// applied to the raft storage of this node. Index states of other clusters,
+// and those of nodes not using raft storage, are always considered present.
 func (c *Core) HasWALState(required *logical.WALState, perfStandby bool) bool {
-	return true
+	if required == nil || required.ClusterID != c.ClusterID() {
+		return true
+	}
+
+	raftBackend := c.getRaftBackend()
+	if raftBackend == nil {
+		return true
+	}
+	return raftBackend.AppliedIndex() >= required.LocalIndex
 }
 
 func (c *Core) setupReplicatedClusterPrimary(*replication.Cluster) error { return nil }
@@ -198,7 +222,20 @@ func (c *Core) ForwardToActive() string {
 	return ""
 }
 
+// MissingRequiredState returns whether any of the index states required with
+// the X-Vault-Index header of a request is not present on this node. Invalid
+// index states are ignored.
 func (c *Core) MissingRequiredState(raw []string, perfStandby bool) bool {
+	for _, r := range raw {
+		state, err := c.parseIndexStateHeader(r)
+		if err != nil {
+			c.logger.Debug("ignoring invalid required index state", "error", err)
+			continue
+		}
+		if !c.HasWALState(state, perfStandby) {
+			return true
+		}
+	}
 	return false
 }
 
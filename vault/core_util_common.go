@@ -5,8 +5,14 @@ package vault
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
 
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/command/server"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -69,3 +75,37 @@ func (c *Core) setupHeaderHMACKey(ctx context.Context, isPerfStandby bool) error
 	c.IndexHeaderHMACKey.Store([]byte(key))
 	return nil
 }
+
+// IndexStateHeader returns the value of the X-Vault-Index header for the given
+// index state, signed with the index header HMAC key so that the nodes of the
+// cluster can trust it. It returns an empty string if the key is not set up.
+func (c *Core) IndexStateHeader(state *logical.WALState) string {
+	key := c.headerHMACKey()
+	if len(key) == 0 || state == nil {
+		return ""
+	}
+
+	raw := fmt.Sprintf("v1:%s:%d:%d", state.ClusterID, state.LocalIndex, state.ReplicatedIndex)
+	hm := hmac.New(sha256.New, key)
+	hm.Write([]byte(raw))
+	return base64.StdEncoding.EncodeToString([]byte(raw + ":" + hex.EncodeToString(hm.Sum(nil))))
+}
+
+// parseIndexStateHeader parses and verifies the value of a X-Vault-Index
+// header returned by IndexStateHeader.
+func (c *Core) parseIndexStateHeader(raw string) (*logical.WALState, error) {
+	key := c.headerHMACKey()
+	if len(key) == 0 {
+		return nil, fmt.Errorf("index header HMAC key is not set up")
+	}
+
+	state, err := api.ParseReplicationState(raw, key)
+	if err != nil {
+		return nil, err
+	}
+	return &logical.WALState{
+		ClusterID:       state.ClusterID,
+		LocalIndex:      state.LocalIndex,
+		ReplicatedIndex: state.ReplicatedIndex,
+	}, nil
+}
@@ -28,6 +28,8 @@ var StdAllowedHeaders = []string{
 	"X-Vault-No-Request-Forwarding",
 	"X-Vault-Wrap-Format",
 	"X-Vault-Wrap-TTL",
+	"X-Vault-Wrap-Bound-CIDRs",
+	"X-Vault-Wrap-Not-Before",
 	"X-Vault-Policy-Override",
 	"Authorization",
 	consts.AuthHeaderName,
@@ -0,0 +1,444 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// secretCountersSubPath is the storage path of the counters of the secrets
+// engines, indexed by the accessor of their mount.
+const secretCountersSubPath = countersSubPath + "secrets/"
+
+// secretCountersFlushInterval is how often the changes of the counters of
+// the secrets engines recorded in memory are written to storage.
+var secretCountersFlushInterval = 30 * time.Second
+
+// SecretCounter counts the secrets, versions of secrets and roles of secrets
+// engines.
+type SecretCounter struct {
+	// Secrets is the number of secrets created and not deleted.
+	Secrets int64 `json:"secrets"`
+
+	// Versions is the number of versions of secrets written.
+	Versions int64 `json:"versions"`
+
+	// Roles is the number of roles created and not deleted.
+	Roles int64 `json:"roles"`
+}
+
+func (s *SecretCounter) add(other SecretCounter) {
+	s.Secrets += other.Secrets
+	s.Versions += other.Versions
+	s.Roles += other.Roles
+}
+
+// clamp resets the counters which went below zero, such as when deleting a
+// secret which was created before counting started.
+func (s *SecretCounter) clamp() {
+	s.Secrets = max(s.Secrets, 0)
+	s.Versions = max(s.Versions, 0)
+	s.Roles = max(s.Roles, 0)
+}
+
+// secretCounters are the counters of a secrets engine. They are counted from
+// the requests creating and deleting secrets and roles since TrackingSince,
+// instead of by listing the storage of the mount, so they do not include
+// what existed before and are approximate.
+type secretCounters struct {
+	SecretCounter
+	TrackingSince time.Time `json:"tracking_since"`
+}
+
+func (s *secretCounters) merge(delta *secretCounters) {
+	s.add(delta.SecretCounter)
+	s.clamp()
+	if s.TrackingSince.IsZero() || (!delta.TrackingSince.IsZero() && delta.TrackingSince.Before(s.TrackingSince)) {
+		s.TrackingSince = delta.TrackingSince
+	}
+}
+
+// secretCounterTracker accumulates the changes of the counters of the secrets
+// engines in memory, indexed by mount accessor, so that requests do not write
+// to storage.
+type secretCounterTracker struct {
+	lock    sync.Mutex
+	pending map[string]*secretCounters
+}
+
+func newSecretCounterTracker() *secretCounterTracker {
+	return &secretCounterTracker{
+		pending: make(map[string]*secretCounters),
+	}
+}
+
+func (t *secretCounterTracker) record(accessor string, delta SecretCounter, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	counters, ok := t.pending[accessor]
+	if !ok {
+		counters = &secretCounters{TrackingSince: now}
+		t.pending[accessor] = counters
+	}
+	counters.add(delta)
+}
+
+// get returns a copy of the changes recorded since the last flush, if any.
+func (t *secretCounterTracker) get(accessor string) *secretCounters {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	counters, ok := t.pending[accessor]
+	if !ok {
+		return nil
+	}
+	copied := *counters
+	return &copied
+}
+
+func (t *secretCounterTracker) forget(accessor string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.pending, accessor)
+}
+
+// drain returns the changes recorded since the last flush, and resets them.
+func (t *secretCounterTracker) drain() map[string]*secretCounters {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	pending := t.pending
+	t.pending = make(map[string]*secretCounters)
+	return pending
+}
+
+// isKVMount returns whether the mount is a KV secrets engine, and if so the
+// version of the engine.
+func isKVMount(entry *MountEntry) (bool, string) {
+	switch {
+	case entry.Type == mountTypeKV, entry.Type == "generic":
+	case entry.Type == mountTypePlugin && entry.Config.PluginName == mountTypeKV:
+	default:
+		return false, ""
+	}
+
+	version := entry.Options["version"]
+	if version == "" {
+		version = "1"
+	}
+	return true, version
+}
+
+// isRolePath returns whether the path, relative to its mount, is that of a
+// role, such as roles/readonly or static-roles/app.
+func isRolePath(path string) bool {
+	prefix, name, ok := strings.Cut(strings.Trim(path, "/"), "/")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return false
+	}
+	switch prefix {
+	case "role", "roles", "static-role", "static-roles":
+		return true
+	}
+	return false
+}
+
+// secretCountersDelta returns how a successful request with the given
+// operation on the path, relative to the mount, changes the counters of the
+// mount. Creations are told apart from updates by the existence checks of
+// the backends, so secrets and roles of backends without one are not counted.
+func secretCountersDelta(entry *MountEntry, op logical.Operation, path string) (SecretCounter, bool) {
+	var delta SecretCounter
+
+	if kv, version := isKVMount(entry); kv {
+		if version == "2" {
+			prefix, key, _ := strings.Cut(path, "/")
+			if key == "" {
+				return delta, false
+			}
+			switch {
+			case prefix == "data" && op == logical.CreateOperation:
+				delta.Secrets, delta.Versions = 1, 1
+			case prefix == "data" && (op == logical.UpdateOperation || op == logical.PatchOperation):
+				delta.Versions = 1
+			case prefix == "metadata" && op == logical.CreateOperation:
+				delta.Secrets = 1
+			case prefix == "metadata" && op == logical.DeleteOperation:
+				delta.Secrets = -1
+			default:
+				return delta, false
+			}
+			return delta, true
+		}
+
+		switch op {
+		case logical.CreateOperation:
+			delta.Secrets, delta.Versions = 1, 1
+		case logical.UpdateOperation:
+			delta.Versions = 1
+		case logical.DeleteOperation:
+			delta.Secrets = -1
+		default:
+			return delta, false
+		}
+		return delta, true
+	}
+
+	if !isRolePath(path) {
+		return delta, false
+	}
+	switch op {
+	case logical.CreateOperation:
+		delta.Roles = 1
+	case logical.DeleteOperation:
+		delta.Roles = -1
+	default:
+		return delta, false
+	}
+	return delta, true
+}
+
+// countsSecrets returns whether the secrets and roles of the mount are
+// counted. Only secrets engines are counted, except for the built-in ones.
+func countsSecrets(entry *MountEntry) bool {
+	if entry == nil || entry.Table != mountTableType {
+		return false
+	}
+	switch entry.Type {
+	case mountTypeSystem, mountTypeNSSystem, mountTypeIdentity, mountTypeCubbyhole, mountTypeNSCubbyhole:
+		return false
+	}
+	return true
+}
+
+// recordSecretWrite records the changes of the counters of the mount made by
+// a request which succeeded.
+func (c *Core) recordSecretWrite(entry *MountEntry, req *logical.Request) {
+	if !countsSecrets(entry) {
+		return
+	}
+
+	delta, ok := secretCountersDelta(entry, req.Operation, strings.TrimPrefix(req.Path, entry.Path))
+	if !ok {
+		return
+	}
+	c.secretCounters.record(entry.Accessor, delta, time.Now())
+}
+
+func (c *Core) secretCountersView() *BarrierView {
+	return c.systemBarrierView.SubView(secretCountersSubPath)
+}
+
+func (c *Core) storedSecretCounters(ctx context.Context, accessor string) (*secretCounters, error) {
+	entry, err := c.secretCountersView().Get(ctx, accessor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret counters: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	counters := new(secretCounters)
+	if err := jsonutil.DecodeJSON(entry.Value, counters); err != nil {
+		return nil, fmt.Errorf("failed to decode secret counters: %w", err)
+	}
+	return counters, nil
+}
+
+// mountSecretCounters returns the counters of the mount, combining the
+// counters in storage with the changes recorded since the last flush. It
+// returns nil if nothing was counted.
+func (c *Core) mountSecretCounters(ctx context.Context, accessor string) (*secretCounters, error) {
+	stored, err := c.storedSecretCounters(ctx, accessor)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := c.secretCounters.get(accessor)
+	switch {
+	case pending == nil:
+		return stored, nil
+	case stored == nil:
+		stored = new(secretCounters)
+	}
+	stored.merge(pending)
+	return stored, nil
+}
+
+// flushSecretCounters writes the changes of the counters recorded since the
+// last flush to storage. The changes of mounts disabled since they were
+// recorded are dropped.
+func (c *Core) flushSecretCounters(ctx context.Context) error {
+	var result *multierror.Error
+	for accessor, pending := range c.secretCounters.drain() {
+		if c.router.MatchingMountByAccessor(accessor) == nil {
+			continue
+		}
+
+		counters, err := c.storedSecretCounters(ctx, accessor)
+		if err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+		if counters == nil {
+			counters = new(secretCounters)
+		}
+		counters.merge(pending)
+
+		entry, err := logical.StorageEntryJSON(accessor, counters)
+		if err != nil {
+			result = multierror.Append(result, fmt.Errorf("failed to encode secret counters: %w", err))
+			continue
+		}
+		if err := c.secretCountersView().Put(ctx, entry); err != nil {
+			result = multierror.Append(result, fmt.Errorf("failed to persist secret counters: %w", err))
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+// deleteSecretCounters deletes the counters of a mount whose storage was
+// cleared.
+func (c *Core) deleteSecretCounters(ctx context.Context, accessor string) error {
+	c.secretCounters.forget(accessor)
+	if err := c.secretCountersView().Delete(ctx, accessor); err != nil {
+		return fmt.Errorf("failed to delete secret counters: %w", err)
+	}
+	return nil
+}
+
+// startSecretCountersFlush runs a process which, every
+// secretCountersFlushInterval, writes the changes of the counters of the
+// secrets engines recorded in memory to storage, until
+// stopSecretCountersFlush is called.
+func (c *Core) startSecretCountersFlush() {
+	if c.secretCountersFlushCancel != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, c.secretCountersFlushCancel = context.WithCancel(namespace.RootContext(c.activeContext))
+
+	go func() {
+		ticker := time.NewTicker(secretCountersFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.flushSecretCounters(ctx); err != nil {
+					c.logger.Error("failed to write secret counters", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// stopSecretCountersFlush stops the periodic writes of the counters of the
+// secrets engines, and writes the changes recorded since the last one.
+func (c *Core) stopSecretCountersFlush() {
+	if c.secretCountersFlushCancel == nil {
+		return
+	}
+	c.secretCountersFlushCancel()
+	c.secretCountersFlushCancel = nil
+
+	if err := c.flushSecretCounters(namespace.RootContext(context.Background())); err != nil {
+		c.logger.Error("failed to write secret counters", "error", err)
+	}
+}
+
+// MountSecretCounts contains the counters of a secrets engine.
+type MountSecretCounts struct {
+	SecretCounter
+	Path          string     `json:"mount"`
+	Accessor      string     `json:"mount_accessor"`
+	Type          string     `json:"type"`
+	TrackingSince *time.Time `json:"tracking_since,omitempty"`
+}
+
+// SecretCounts contains the counters of the secrets engines.
+type SecretCounts struct {
+	// Mounts contains the counters of each secrets engine.
+	Mounts []*MountSecretCounts `json:"mounts"`
+
+	// Total is the sum of the counters of the secrets engines.
+	Total SecretCounter `json:"total"`
+}
+
+// countedMounts returns the secrets engines of the namespace of the context
+// and its children whose secrets are counted, sorted by path.
+func (c *Core) countedMounts(ctx context.Context) ([]*MountEntry, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mountsLock.RLock()
+	defer c.mountsLock.RUnlock()
+
+	var entries []*MountEntry
+	if c.mounts == nil {
+		return entries, nil
+	}
+	for _, entry := range c.mounts.Entries {
+		if !countsSecrets(entry) || entry.namespace == nil {
+			continue
+		}
+		if entry.namespace.ID != ns.ID && !entry.namespace.HasParent(ns) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].APIPath() < entries[j].APIPath()
+	})
+	return entries, nil
+}
+
+// countSecrets returns the counters of the secrets engines of the namespace
+// of the context and its children.
+func (c *Core) countSecrets(ctx context.Context) (*SecretCounts, error) {
+	entries, err := c.countedMounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := &SecretCounts{
+		Mounts: make([]*MountSecretCounts, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		mount := &MountSecretCounts{
+			Path:     entry.APIPath(),
+			Accessor: entry.Accessor,
+			Type:     entry.Type,
+		}
+
+		counters, err := c.mountSecretCounters(ctx, entry.Accessor)
+		if err != nil {
+			return nil, err
+		}
+		if counters != nil {
+			mount.SecretCounter = counters.SecretCounter
+			mount.TrackingSince = &counters.TrackingSince
+		}
+
+		counts.Mounts = append(counts.Mounts, mount)
+		counts.Total.add(mount.SecretCounter)
+	}
+	return counts, nil
+}
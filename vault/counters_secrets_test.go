@@ -0,0 +1,149 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/testhelpers/schema"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretCountersDelta(t *testing.T) {
+	kv1 := &MountEntry{Type: mountTypeKV}
+	kv2 := &MountEntry{Type: mountTypeKV, Options: map[string]string{"version": "2"}}
+	database := &MountEntry{Type: "database"}
+
+	cases := []struct {
+		name     string
+		entry    *MountEntry
+		op       logical.Operation
+		path     string
+		expected SecretCounter
+		counted  bool
+	}{
+		{"kv1 create", kv1, logical.CreateOperation, "app/db", SecretCounter{Secrets: 1, Versions: 1}, true},
+		{"kv1 update", kv1, logical.UpdateOperation, "app/db", SecretCounter{Versions: 1}, true},
+		{"kv1 delete", kv1, logical.DeleteOperation, "app/db", SecretCounter{Secrets: -1}, true},
+		{"kv1 read", kv1, logical.ReadOperation, "app/db", SecretCounter{}, false},
+		{"kv1 roles are secrets", kv1, logical.CreateOperation, "roles/app", SecretCounter{Secrets: 1, Versions: 1}, true},
+		{"kv2 create", kv2, logical.CreateOperation, "data/app/db", SecretCounter{Secrets: 1, Versions: 1}, true},
+		{"kv2 update", kv2, logical.UpdateOperation, "data/app/db", SecretCounter{Versions: 1}, true},
+		{"kv2 patch", kv2, logical.PatchOperation, "data/app/db", SecretCounter{Versions: 1}, true},
+		{"kv2 soft delete", kv2, logical.DeleteOperation, "data/app/db", SecretCounter{}, false},
+		{"kv2 metadata create", kv2, logical.CreateOperation, "metadata/app/db", SecretCounter{Secrets: 1}, true},
+		{"kv2 metadata delete", kv2, logical.DeleteOperation, "metadata/app/db", SecretCounter{Secrets: -1}, true},
+		{"kv2 config", kv2, logical.UpdateOperation, "config", SecretCounter{}, false},
+		{"role create", database, logical.CreateOperation, "roles/app", SecretCounter{Roles: 1}, true},
+		{"static role delete", database, logical.DeleteOperation, "static-roles/app", SecretCounter{Roles: -1}, true},
+		{"role update", database, logical.UpdateOperation, "roles/app", SecretCounter{}, false},
+		{"role list", database, logical.CreateOperation, "roles/", SecretCounter{}, false},
+		{"role subpath", database, logical.CreateOperation, "roles/app/rotate", SecretCounter{}, false},
+		{"other path", database, logical.CreateOperation, "config/postgres", SecretCounter{}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			delta, counted := secretCountersDelta(tc.entry, tc.op, tc.path)
+			require.Equal(t, tc.counted, counted)
+			require.Equal(t, tc.expected, delta)
+		})
+	}
+}
+
+// TestCore_CountSecrets verifies that secrets written to a secrets engine are
+// counted, written to storage in batches, and deleted with the mount.
+func TestCore_CountSecrets(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	do := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := c.HandleRequest(ctx, &logical.Request{
+			Operation:   op,
+			Path:        path,
+			ClientToken: root,
+			Data:        data,
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: resp: %#v\n err: %v", resp, err)
+		}
+		return resp
+	}
+	count := func() *MountSecretCounts {
+		t.Helper()
+		req := &logical.Request{
+			ClientToken: root,
+			Operation:   logical.ReadOperation,
+			Path:        "sys/internal/counters/secrets",
+		}
+		resp, err := c.HandleRequest(ctx, req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: resp: %#v\n err: %v", resp, err)
+		}
+		schema.ValidateResponse(
+			t,
+			schema.GetResponseSchema(t, c.systemBackend.Route("internal/counters/secrets"), req.Operation),
+			resp,
+			true,
+		)
+
+		counts := resp.Data["counters"].(*SecretCounts)
+		for _, mount := range counts.Mounts {
+			if mount.Path == "secret/" {
+				require.Equal(t, mount.SecretCounter, counts.Total)
+				return mount
+			}
+		}
+		t.Fatalf("secret/ not found in %#v", counts.Mounts)
+		return nil
+	}
+
+	// Nothing is counted before secrets are written
+	mount := count()
+	require.Equal(t, SecretCounter{}, mount.SecretCounter)
+	require.Nil(t, mount.TrackingSince)
+
+	data := map[string]interface{}{"foo": "bar"}
+	do(logical.UpdateOperation, "secret/foo", data)
+	do(logical.UpdateOperation, "secret/foo", data)
+	do(logical.UpdateOperation, "secret/bar", data)
+	do(logical.ReadOperation, "secret/bar", nil)
+	mount = count()
+	require.Equal(t, SecretCounter{Secrets: 2, Versions: 3}, mount.SecretCounter)
+	require.NotNil(t, mount.TrackingSince)
+
+	// The changes recorded since the last flush are combined with the stored
+	// counters
+	require.NoError(t, c.flushSecretCounters(ctx))
+	require.Empty(t, c.secretCounters.drain())
+	do(logical.DeleteOperation, "secret/bar", nil)
+	require.Equal(t, SecretCounter{Secrets: 1, Versions: 3}, count().SecretCounter)
+	require.NoError(t, c.flushSecretCounters(ctx))
+
+	entry := c.router.MatchingMountEntry(ctx, "secret/")
+	stored, err := c.storedSecretCounters(ctx, entry.Accessor)
+	require.NoError(t, err)
+	require.Equal(t, SecretCounter{Secrets: 1, Versions: 3}, stored.SecretCounter)
+
+	// Deleting a secret twice does not make the counters negative
+	do(logical.DeleteOperation, "secret/foo", nil)
+	do(logical.DeleteOperation, "secret/foo", nil)
+	require.Equal(t, SecretCounter{Versions: 3}, count().SecretCounter)
+
+	// Disabling the mount deletes its counters, and changes recorded
+	// afterwards are not written
+	do(logical.DeleteOperation, "sys/mounts/secret", nil)
+	stored, err = c.storedSecretCounters(ctx, entry.Accessor)
+	require.NoError(t, err)
+	require.Nil(t, stored)
+
+	c.secretCounters.record(entry.Accessor, SecretCounter{Secrets: 1}, time.Now())
+	require.NoError(t, c.flushSecretCounters(ctx))
+	stored, err = c.storedSecretCounters(ctx, entry.Accessor)
+	require.NoError(t, err)
+	require.Nil(t, stored)
+}
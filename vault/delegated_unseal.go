@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/experiments"
+	"github.com/hashicorp/vault/helper/thresholdunseal"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// delegatedUnsealInformation holds the state of a delegated unseal attempt.
+// The session holds the blinding of the challenge, so it must never leave
+// memory.
+type delegatedUnsealInformation struct {
+	Nonce   string
+	session *thresholdunseal.Session
+}
+
+// DelegatedUnsealStatus describes the delegated unseal attempt in progress.
+type DelegatedUnsealStatus struct {
+	Nonce     string
+	Challenge []byte
+	Threshold int
+	Shares    int
+	Progress  int
+}
+
+// DelegatedUnsealChallenge returns the delegated unseal attempt in progress,
+// starting one with a new challenge if there is none.
+func (c *Core) DelegatedUnsealChallenge(ctx context.Context) (*DelegatedUnsealStatus, logical.HTTPCodedError) {
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	if !c.Sealed() {
+		return nil, logical.CodedError(http.StatusBadRequest, "vault is unsealed")
+	}
+
+	config, err := c.delegatedUnsealConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.delegatedUnsealInfo == nil {
+		session, err := config.DelegatedUnsealConfig.NewSession()
+		if err != nil {
+			return nil, logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to create unseal challenge: %w", err).Error())
+		}
+		nonce, err := uuid.GenerateUUID()
+		if err != nil {
+			return nil, logical.CodedError(http.StatusInternalServerError, fmt.Errorf("error generating nonce for procedure: %w", err).Error())
+		}
+		c.delegatedUnsealInfo = &delegatedUnsealInformation{
+			Nonce:   nonce,
+			session: session,
+		}
+	}
+
+	return c.delegatedUnsealStatus(config), nil
+}
+
+// DelegatedUnseal records the partial signature of the unseal challenge
+// created by one of the key holders with their share. Once the threshold of
+// partial signatures is met, the unseal key is recovered from them and used
+// to unseal Vault. It returns whether Vault is unsealed.
+func (c *Core) DelegatedUnseal(ctx context.Context, nonce string, signature []byte) (bool, logical.HTTPCodedError) {
+	defer metrics.MeasureSince([]string{"core", "unseal"}, time.Now())
+
+	c.stateLock.Lock()
+	defer c.stateLock.Unlock()
+
+	if !c.Sealed() {
+		return true, nil
+	}
+
+	config, err := c.delegatedUnsealConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if c.delegatedUnsealInfo == nil {
+		return false, logical.CodedError(http.StatusBadRequest, "no delegated unseal in progress; the unseal challenge must be fetched first")
+	}
+	if nonce != c.delegatedUnsealInfo.Nonce {
+		return false, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("incorrect nonce supplied; nonce for this delegated unseal is %q", c.delegatedUnsealInfo.Nonce))
+	}
+
+	session := c.delegatedUnsealInfo.session
+	if _, err := session.Add(signature); err != nil {
+		return false, logical.CodedError(http.StatusBadRequest, fmt.Errorf("invalid partial signature: %w", err).Error())
+	}
+	if session.Progress() < config.SecretThreshold {
+		if c.logger.IsDebug() {
+			c.logger.Debug("cannot unseal, not enough partial signatures", "signatures", session.Progress(), "threshold", config.SecretThreshold, "nonce", c.delegatedUnsealInfo.Nonce)
+		}
+		return false, nil
+	}
+
+	defer func() {
+		c.delegatedUnsealInfo = nil
+	}()
+
+	unsealKey, uErr := session.Secret()
+	if uErr != nil {
+		return false, logical.CodedError(http.StatusBadRequest, fmt.Errorf("failed to recover unseal key: %w", uErr).Error())
+	}
+	masterKey, uErr := c.unsealKeyToMasterKeyPreUnseal(ctx, c.seal, unsealKey)
+	if uErr != nil {
+		return false, logical.CodedError(http.StatusInternalServerError, uErr.Error())
+	}
+	if uErr := c.unsealInternal(ctx, masterKey, sealReasonDelegatedUnseal); uErr != nil {
+		return false, logical.CodedError(http.StatusInternalServerError, uErr.Error())
+	}
+	return !c.Sealed(), nil
+}
+
+// delegatedUnsealConfig returns the barrier seal configuration, or an error
+// if delegated unseal is not possible. It must be called with the stateLock
+// held.
+func (c *Core) delegatedUnsealConfig(ctx context.Context) (*SealConfig, logical.HTTPCodedError) {
+	if !c.IsExperimentEnabled(experiments.VaultExperimentCoreUnsealDelegated) {
+		return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("delegated unseal requires the %q experiment", experiments.VaultExperimentCoreUnsealDelegated))
+	}
+	if c.migrationInfo != nil {
+		return nil, logical.CodedError(http.StatusBadRequest, "delegated unseal is not supported during seal migration")
+	}
+	if c.isRaftUnseal() {
+		return nil, logical.CodedError(http.StatusBadRequest, "delegated unseal is not supported while joining a raft cluster")
+	}
+	if c.seal.RecoveryKeySupported() {
+		return nil, logical.CodedError(http.StatusBadRequest, "delegated unseal is only supported with Shamir seals")
+	}
+
+	config, err := c.seal.BarrierConfig(ctx)
+	if err != nil {
+		return nil, logical.CodedError(http.StatusInternalServerError, err.Error())
+	}
+	if config == nil {
+		return nil, logical.CodedError(http.StatusBadRequest, ErrNotInit.Error())
+	}
+	if config.DelegatedUnsealConfig == nil {
+		return nil, logical.CodedError(http.StatusBadRequest, "delegated unseal is not configured")
+	}
+	return config, nil
+}
+
+// delegatedUnsealStatus must be called with the stateLock held.
+func (c *Core) delegatedUnsealStatus(config *SealConfig) *DelegatedUnsealStatus {
+	return &DelegatedUnsealStatus{
+		Nonce:     c.delegatedUnsealInfo.Nonce,
+		Challenge: c.delegatedUnsealInfo.session.Challenge(),
+		Threshold: config.SecretThreshold,
+		Shares:    config.SecretShares,
+		Progress:  c.delegatedUnsealInfo.session.Progress(),
+	}
+}
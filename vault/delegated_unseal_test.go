@@ -0,0 +1,142 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/experiments"
+	"github.com/hashicorp/vault/helper/thresholdunseal"
+	"github.com/stretchr/testify/require"
+)
+
+// testRekeyDelegated rekeys the barrier of c for delegated unseal with the
+// given unseal keys and returns the new shares.
+func testRekeyDelegated(t *testing.T, c *Core, keys [][]byte, shares, threshold int) [][]byte {
+	t.Helper()
+
+	hErr := c.RekeyInit(&SealConfig{
+		Type:            c.seal.BarrierSealConfigType().String(),
+		SecretShares:    shares,
+		SecretThreshold: threshold,
+		DelegatedUnseal: true,
+	}, false)
+	require.Nil(t, hErr)
+
+	rkconf, hErr := c.RekeyConfig(false)
+	require.Nil(t, hErr)
+
+	var result *RekeyResult
+	for _, key := range keys {
+		result, hErr = c.RekeyUpdate(context.Background(), key, rkconf.Nonce, false)
+		require.Nil(t, hErr)
+	}
+	require.NotNil(t, result)
+	require.Len(t, result.SecretShares, shares)
+	return result.SecretShares
+}
+
+func TestCore_DelegatedUnseal(t *testing.T) {
+	c, keys, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		Experiments: []string{experiments.VaultExperimentCoreUnsealDelegated},
+	})
+	ctx := context.Background()
+
+	shares := testRekeyDelegated(t, c, keys, 5, 3)
+
+	config, err := c.seal.BarrierConfig(ctx)
+	require.NoError(t, err)
+	require.True(t, config.DelegatedUnseal)
+	require.NotNil(t, config.DelegatedUnsealConfig)
+
+	_, hErr := c.DelegatedUnsealChallenge(ctx)
+	require.ErrorContains(t, hErr, "vault is unsealed")
+
+	require.NoError(t, c.Seal(root))
+
+	// Submitting a share directly is refused.
+	_, err = c.Unseal(shares[0])
+	require.ErrorContains(t, err, "delegated unseal is configured")
+
+	status, hErr := c.DelegatedUnsealChallenge(ctx)
+	require.Nil(t, hErr)
+	require.Equal(t, 3, status.Threshold)
+	require.Equal(t, 5, status.Shares)
+	require.Zero(t, status.Progress)
+
+	// A signature over another challenge is rejected.
+	other, err := config.DelegatedUnsealConfig.NewSession()
+	require.NoError(t, err)
+	signature, err := thresholdunseal.Sign(shares[0], other.Challenge())
+	require.NoError(t, err)
+	_, hErr = c.DelegatedUnseal(ctx, status.Nonce, signature)
+	require.ErrorContains(t, hErr, "invalid partial signature")
+
+	// So is a signature for another attempt.
+	signature, err = thresholdunseal.Sign(shares[0], status.Challenge)
+	require.NoError(t, err)
+	_, hErr = c.DelegatedUnseal(ctx, "bogus", signature)
+	require.ErrorContains(t, hErr, "incorrect nonce")
+
+	for i, share := range [][]byte{shares[4], shares[1], shares[2]} {
+		signature, err := thresholdunseal.Sign(share, status.Challenge)
+		require.NoError(t, err)
+
+		unsealed, hErr := c.DelegatedUnseal(ctx, status.Nonce, signature)
+		require.Nil(t, hErr)
+		require.Equal(t, i == 2, unsealed)
+	}
+	require.False(t, c.Sealed())
+}
+
+func TestCore_DelegatedUnseal_Rekey(t *testing.T) {
+	c, keys, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		Experiments: []string{experiments.VaultExperimentCoreUnsealDelegated},
+	})
+	ctx := context.Background()
+
+	shares := testRekeyDelegated(t, c, keys, 3, 2)
+
+	// The delegated unseal shares authorize rekeying back to regular shares.
+	hErr := c.RekeyInit(&SealConfig{
+		Type:            c.seal.BarrierSealConfigType().String(),
+		SecretShares:    3,
+		SecretThreshold: 2,
+	}, false)
+	require.Nil(t, hErr)
+	rkconf, hErr := c.RekeyConfig(false)
+	require.Nil(t, hErr)
+
+	var result *RekeyResult
+	for _, share := range shares[:2] {
+		result, hErr = c.RekeyUpdate(ctx, share, rkconf.Nonce, false)
+		require.Nil(t, hErr)
+	}
+	require.NotNil(t, result)
+
+	config, err := c.seal.BarrierConfig(ctx)
+	require.NoError(t, err)
+	require.False(t, config.DelegatedUnseal)
+	require.Nil(t, config.DelegatedUnsealConfig)
+
+	require.NoError(t, c.Seal(root))
+	for _, key := range result.SecretShares[:2] {
+		_, err := TestCoreUnseal(c, key)
+		require.NoError(t, err)
+	}
+	require.False(t, c.Sealed())
+}
+
+func TestCore_DelegatedUnseal_RequiresExperiment(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	hErr := c.RekeyInit(&SealConfig{
+		Type:            c.seal.BarrierSealConfigType().String(),
+		SecretShares:    3,
+		SecretThreshold: 2,
+		DelegatedUnseal: true,
+	}, false)
+	require.ErrorContains(t, hErr, experiments.VaultExperimentCoreUnsealDelegated)
+}
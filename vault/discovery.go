@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/helper/constants"
+	"github.com/hashicorp/vault/version"
+)
+
+// DiscoveryDocument describes the cluster and the API capabilities it
+// supports, so that clients can negotiate features without probing endpoints.
+type DiscoveryDocument struct {
+	ClusterName string          `json:"cluster_name,omitempty"`
+	Version     string          `json:"version"`
+	Enterprise  bool            `json:"enterprise"`
+	APIVersions []string        `json:"api_versions"`
+	Features    map[string]bool `json:"features"`
+}
+
+// DiscoveryDocument builds the discovery document of the cluster. The cluster
+// name is only read from storage when Vault is unsealed, otherwise the name
+// from the configuration is used if there is one.
+func (c *Core) DiscoveryDocument(ctx context.Context) (*DiscoveryDocument, error) {
+	clusterName := c.clusterName
+	if !c.Sealed() {
+		cluster, err := c.Cluster(ctx)
+		if err != nil {
+			return nil, err
+		}
+		clusterName = cluster.Name
+	}
+
+	raftStorage := c.getRaftBackend() != nil
+
+	return &DiscoveryDocument{
+		ClusterName: clusterName,
+		Version:     version.GetVersion().VersionNumber(),
+		Enterprise:  constants.IsEnterprise,
+		APIVersions: []string{"v1"},
+		Features: map[string]bool{
+			"consistency_headers": raftStorage || constants.IsEnterprise,
+			"events":              c.events != nil,
+			"grpc":                false,
+			"integrated_storage":  raftStorage,
+			"namespaces":          constants.IsEnterprise,
+			"performance_standby": constants.IsEnterprise,
+			"replication":         constants.IsEnterprise,
+			"ui":                  c.UIEnabled(),
+		},
+	}, nil
+}
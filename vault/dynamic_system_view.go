@@ -16,6 +16,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/pluginutil"
 	"github.com/hashicorp/vault/sdk/helper/wrapping"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/rotation"
 	"github.com/hashicorp/vault/vault/plugincatalog"
 	"github.com/hashicorp/vault/version"
 )
@@ -411,6 +412,7 @@ func (d dynamicSystemView) PluginEnv(_ context.Context) (*logical.PluginEnvironm
 		VaultVersion:           v.Version,
 		VaultVersionPrerelease: v.VersionPrerelease,
 		VaultVersionMetadata:   v.VersionMetadata,
+		CryptoPolicy:           d.core.cryptoPolicy,
 	}, nil
 }
 
@@ -474,3 +476,15 @@ func (d dynamicSystemView) GenerateIdentityToken(ctx context.Context, req *plugi
 		TTL:   ttl,
 	}, nil
 }
+
+func (d dynamicSystemView) RegisterRotationJob(ctx context.Context, req *rotation.RotationJobConfigureRequest) (string, error) {
+	return d.core.registerRotationJob(ctx, d.mountEntry, req)
+}
+
+func (d dynamicSystemView) DeregisterRotationJob(ctx context.Context, req *rotation.RotationJobDeregisterRequest) error {
+	return d.core.deregisterRotationJob(ctx, d.mountEntry, req)
+}
+
+func (d dynamicSystemView) RotationFreeze(ctx context.Context, calendar string, t time.Time) (time.Time, error) {
+	return d.core.rotationFreeze(ctx, calendar, t)
+}
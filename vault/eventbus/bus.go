@@ -55,6 +55,7 @@ type EventBus struct {
 	timeout                    time.Duration
 	filters                    *Filters
 	cloudEventsFormatterFilter *cloudevents.FormatterFilter
+	history                    atomic.Pointer[eventHistory]
 }
 
 type pluginEventBus struct {
@@ -131,6 +132,7 @@ func (bus *EventBus) SendEventInternal(_ context.Context, ns *namespace.Namespac
 		EventType:  string(eventType),
 		PluginInfo: pluginInfo,
 	}
+	bus.history.Load().add(eventReceived)
 
 	// We can't easily know when the SendEvent is complete, so we can't call the cancel function.
 	// But, it is called automatically after bus.timeout, so there won't be any leak as long as bus.timeout is not too long.
@@ -192,14 +194,16 @@ func NewEventBus(localClusterID string, logger hclog.Logger) (*EventBus, error)
 		},
 	}
 
-	return &EventBus{
+	bus := &EventBus{
 		logger:                     logger,
 		broker:                     broker,
 		formatterNodeID:            formatterNodeID,
 		timeout:                    defaultTimeout,
 		cloudEventsFormatterFilter: cloudEventsFormatterFilter,
 		filters:                    NewFilters(localClusterID),
-	}, nil
+	}
+	bus.SetHistorySize(defaultHistorySize)
+	return bus, nil
 }
 
 // Subscribe subscribes to events in the given namespace matching the event type pattern and after
@@ -1027,3 +1027,58 @@ func TestNotifyOnLocalFilterChanges(t *testing.T) {
 		t.Fatal("We expected to get a global filter notification")
 	}
 }
+
+// TestReplay tests that events sent after a cursor are replayed, filtered as
+// they would be for a subscription, and that expired cursors are reported.
+func TestReplay(t *testing.T) {
+	bus, err := NewEventBus("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	bus.Start()
+
+	send := func(eventType logical.EventType) string {
+		t.Helper()
+		event, err := logical.NewEvent()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := bus.SendEventInternal(ctx, namespace.RootNamespace, nil, eventType, event); err != nil {
+			t.Fatal(err)
+		}
+		return event.Id
+	}
+
+	cursor := send("someType")
+	send("otherType")
+	want := send("someType")
+
+	events, err := bus.Replay(ctx, []string{""}, "someType", "", cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Payload.(*logical.EventReceived).ID() != want {
+		t.Fatalf("Got unexpected replayed events: %+v", events)
+	}
+	if _, ok := events[0].Format("cloudevents-json"); !ok {
+		t.Fatal("Replayed event is not formatted")
+	}
+
+	events, err = bus.Replay(ctx, []string{""}, "*", "", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("Expected no events after the latest one, got: %+v", events)
+	}
+
+	bus.SetHistorySize(2)
+	send("someType")
+	send("someType")
+	send("someType")
+	_, err = bus.Replay(ctx, []string{""}, "*", "", want)
+	if !errors.Is(err, ErrCursorExpired) {
+		t.Fatalf("Expected cursor expired error but got: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// defaultHistorySize is the number of recent events kept for subscribers to
+// replay from.
+const defaultHistorySize = 1024
+
+// ErrCursorExpired is returned when replaying from a cursor which is no longer
+// in the event history, so events after it may have been lost.
+var ErrCursorExpired = errors.New("event cursor is no longer available")
+
+// eventHistory is a ring buffer of the most recent events sent on the bus.
+type eventHistory struct {
+	lock   sync.RWMutex
+	events []*eventlogger.Event
+	next   int
+	full   bool
+}
+
+func newEventHistory(size int) *eventHistory {
+	return &eventHistory{
+		events: make([]*eventlogger.Event, size),
+	}
+}
+
+func (h *eventHistory) add(event *logical.EventReceived) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if len(h.events) == 0 {
+		return
+	}
+	h.events[h.next] = &eventlogger.Event{
+		Type:      eventTypeAll,
+		CreatedAt: time.Now(),
+		Payload:   event,
+	}
+	h.next = (h.next + 1) % len(h.events)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// since returns the events sent after the event with the given ID, oldest
+// first.
+func (h *eventHistory) since(cursor string) ([]*eventlogger.Event, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	var events []*eventlogger.Event
+	if h.full {
+		events = append(events, h.events[h.next:]...)
+	}
+	events = append(events, h.events[:h.next]...)
+
+	for i, event := range events {
+		if event.Payload.(*logical.EventReceived).ID() == cursor {
+			return events[i+1:], nil
+		}
+	}
+	return nil, ErrCursorExpired
+}
+
+// SetHistorySize sets the number of recent events kept for subscribers to
+// replay from, discarding the current history.
+func (bus *EventBus) SetHistorySize(size int) {
+	bus.history.Store(newEventHistory(size))
+}
+
+// Replay returns the events sent after the event with the given ID which match
+// the namespace patterns, event type pattern and optional go-bexpr filter,
+// oldest first, formatted as they would be for a subscription. It returns
+// ErrCursorExpired if the event is no longer in the history.
+func (bus *EventBus) Replay(ctx context.Context, namespacePathPatterns []string, pattern string, bexprFilter string, cursor string) ([]*eventlogger.Event, error) {
+	filterNode, err := newFilterNode(namespacePathPatterns, pattern, bexprFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := bus.history.Load().since(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var replayed []*eventlogger.Event
+	for _, event := range events {
+		matched, err := filterNode.Predicate(event)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+
+		formatted, err := bus.cloudEventsFormatterFilter.Process(ctx, event)
+		if err != nil {
+			return nil, err
+		}
+		replayed = append(replayed, formatted)
+	}
+	return replayed, nil
+}
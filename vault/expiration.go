@@ -48,6 +48,10 @@ const (
 	// tokenViewPrefix is the prefix used for the token based lookup of leases.
 	tokenViewPrefix = "token/"
 
+	// leaseListPageSize is the number of lease IDs listed from storage at a
+	// time while restoring leases
+	leaseListPageSize = 1000
+
 	// maxRevokeAttempts limits how many revoke attempts are made
 	maxRevokeAttempts = 6
 
@@ -157,6 +161,12 @@ type ExpirationManager struct {
 
 	jobManager      *fairshare.JobManager
 	revokeRetryBase time.Duration
+
+	// leaseUsage tracks the lifetimes of leases to recommend TTLs
+	leaseUsage *leaseUsageTracker
+
+	// credentialAges counts the leases of secrets by mount and age
+	credentialAges *credentialAgeTracker
 }
 
 type ExpireLeaseStrategy func(context.Context, *ExpirationManager, string, *namespace.Namespace)
@@ -231,7 +241,7 @@ func (r *revocationJob) Execute() error {
 	}
 
 	r.m.coreStateLock.RLock()
-	err := r.m.Revoke(revokeCtx, r.leaseID)
+	err := r.m.Revoke(contextWithLeaseExpiry(revokeCtx), r.leaseID)
 	r.m.coreStateLock.RUnlock()
 
 	return err
@@ -250,6 +260,11 @@ func (r *revocationJob) OnFailure(err error) {
 
 	pending := pendingRaw.(pendingInfo)
 	pending.revokesAttempted++
+	if failures := r.m.recordRevokeFailure(r.nsCtx, r.leaseID, err); failures != nil && pending.cachedLeaseInfo != nil {
+		cached := *pending.cachedLeaseInfo
+		cached.RevokeFailures = failures
+		pending.cachedLeaseInfo = &cached
+	}
 	newTimer := r.revokeExponentialBackoff(pending.revokesAttempted)
 
 	if pending.revokesAttempted >= maxRevokeAttempts || errIsUnrecoverable(err) {
@@ -367,6 +382,8 @@ func NewExpirationManager(c *Core, view *BarrierView, e ExpireLeaseStrategy, log
 
 		jobManager:      jobManager,
 		revokeRetryBase: c.expirationRevokeRetryBase,
+		leaseUsage:      newLeaseUsageTracker(),
+		credentialAges:  newCredentialAgeTracker(),
 	}
 	exp.expireFunc.Store(&e)
 	if exp.revokeRetryBase == 0 {
@@ -498,6 +515,7 @@ func (m *ExpirationManager) invalidate(key string) {
 				pending.timer.Stop()
 				m.pending.Delete(leaseID)
 				m.leaseCount--
+				m.credentialAges.remove(leaseID)
 
 				// Avoid nil pointer dereference. Without cachedLeaseInfo we do not have enough information to
 				// accurately update quota lease information.
@@ -526,6 +544,7 @@ func (m *ExpirationManager) invalidate(key string) {
 					m.irrevocableLeaseCount--
 
 					m.leaseCount--
+					m.credentialAges.remove(leaseID)
 					// Note that the leaseEntry should never be nil under normal operation.
 					if ile != nil {
 						leaseInfo := &quotas.QuotaLeaseInformation{LeaseId: leaseID, Role: ile.LoginRole}
@@ -550,6 +569,12 @@ func (m *ExpirationManager) invalidate(key string) {
 // not required to use the API that invokes this. This is only intended to
 // clean up the corrupt storage due to bugs.
 func (m *ExpirationManager) Tidy(ctx context.Context) error {
+	return m.tidy(ctx, nil)
+}
+
+// tidy runs Tidy, calling progress, if not nil, with the number of leases
+// scanned. The tidy stops once ctx is canceled.
+func (m *ExpirationManager) tidy(ctx context.Context, progress func(scanned int64)) error {
 	if m.inRestoreMode() {
 		return errors.New("cannot run tidy while restoring leases")
 	}
@@ -574,10 +599,17 @@ func (m *ExpirationManager) Tidy(ctx context.Context) error {
 	var countLease, revokedCount, deletedCountInvalidToken, deletedCountEmptyToken int64
 
 	tidyFunc := func(leaseID string) {
+		if ctx.Err() != nil {
+			return
+		}
+
 		countLease++
 		if countLease%500 == 0 {
 			logger.Info("tidying leases", "progress", countLease)
 		}
+		if progress != nil {
+			progress(countLease)
+		}
 
 		le, err := m.loadEntry(ctx, leaseID)
 		if err != nil {
@@ -657,6 +689,9 @@ func (m *ExpirationManager) Tidy(ctx context.Context) error {
 	if err := logical.ScanView(m.quitContext, leaseView, tidyFunc); err != nil {
 		return err
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	logger.Info("number of leases scanned", "count", countLease)
 	logger.Info("number of leases which had empty tokens", "count", deletedCountEmptyToken)
@@ -694,24 +729,23 @@ func (m *ExpirationManager) Restore(errorFunc func()) (retErr error) {
 		}
 	}()
 
-	// Accumulate existing leases
-	m.logger.Debug("collecting leases")
-	existing, leaseCount, err := m.collectLeases()
-	if err != nil {
-		return err
-	}
-	m.logger.Debug("leases collected", "num_existing", leaseCount)
-
 	// Make the channels used for the worker pool
 	type lease struct {
 		namespace *namespace.Namespace
 		id        string
 	}
 	broker := make(chan *lease)
-	quit := make(chan bool)
-	// Buffer these channels to prevent deadlocks
-	errs := make(chan error, leaseCount)
-	result := make(chan struct{}, leaseCount)
+	quit := make(chan struct{})
+
+	// The first error restoring a lease stops the restore
+	var errOnce sync.Once
+	var restoreErr error
+	fail := func(err error) {
+		errOnce.Do(func() {
+			restoreErr = err
+			close(quit)
+		})
+	}
 
 	// Use a wait group
 	wg := &sync.WaitGroup{}
@@ -731,15 +765,10 @@ func (m *ExpirationManager) Restore(errorFunc func()) (retErr error) {
 					}
 
 					ctx := namespace.ContextWithNamespace(m.quitContext, lease.namespace)
-					err := m.processRestore(ctx, lease.id)
-					if err != nil {
-						errs <- err
-						continue
+					if err := m.processRestore(ctx, lease.id); err != nil {
+						fail(err)
 					}
 
-					// Send message that lease is done
-					result <- struct{}{}
-
 				// quit early
 				case <-quit:
 					return
@@ -751,60 +780,44 @@ func (m *ExpirationManager) Restore(errorFunc func()) (retErr error) {
 		}()
 	}
 
-	// Distribute the collected keys to the workers in a go routine
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		i := 0
-		for ns := range existing {
-			for _, leaseID := range existing[ns] {
-				i++
-				if i%500 == 0 {
-					m.logger.Debug("leases loading", "progress", i)
-				}
-
-				select {
-				case <-quit:
-					return
-
-				case <-m.quitCh:
-					return
-
-				default:
-					broker <- &lease{
-						namespace: ns,
-						id:        leaseID,
-					}
-				}
-			}
+	// Distribute the existing leases to the workers as they are listed from
+	// storage, a page at a time, rather than collecting all of them first
+	m.logger.Debug("collecting leases")
+	leaseCount := 0
+	err := m.scanLeases(func(ns *namespace.Namespace, leaseID string) bool {
+		leaseCount++
+		if leaseCount%500 == 0 {
+			m.logger.Debug("leases loading", "progress", leaseCount)
 		}
 
-		// Close the broker, causing worker routines to exit
-		close(broker)
-	}()
-
-	// Ensure all keys on the chan are processed
-LOOP:
-	for i := 0; i < leaseCount; i++ {
 		select {
-		case err = <-errs:
-			// Close all go routines
-			close(quit)
-			break LOOP
+		case <-quit:
+			return false
 
 		case <-m.quitCh:
-			close(quit)
-			break LOOP
+			return false
 
-		case <-result:
+		case broker <- &lease{
+			namespace: ns,
+			id:        leaseID,
+		}:
+			return true
 		}
-	}
+	})
+
+	// Close the broker, causing worker routines to exit once all the leases
+	// have been processed
+	close(broker)
 
 	// Let all go routines finish
 	wg.Wait()
+	if restoreErr != nil {
+		return restoreErr
+	}
 	if err != nil {
 		return err
 	}
+	m.logger.Debug("leases collected", "num_existing", leaseCount)
 
 	m.restoreModeLock.Lock()
 	atomic.StoreInt32(m.restoreMode, 0)
@@ -882,6 +895,7 @@ func (m *ExpirationManager) Stop() error {
 	oldPending := &m.pending
 	m.pending, m.nonexpiring, m.irrevocable = sync.Map{}, sync.Map{}, sync.Map{}
 	m.leaseCount = 0
+	m.credentialAges.reset()
 	m.uniquePolicies = make(map[string][]string)
 	m.irrevocableLeaseCount = 0
 	m.pendingLock.Unlock()
@@ -1063,6 +1077,7 @@ func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, fo
 	if _, ok := m.irrevocable.Load(le.LeaseID); ok {
 		m.irrevocable.Delete(leaseID)
 		m.irrevocableLeaseCount--
+		m.credentialAges.remove(leaseID)
 	}
 	m.pendingLock.Unlock()
 
@@ -1076,6 +1091,14 @@ func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, fo
 		}
 		m.logger.Warn("finished revoking incorrectly non-expiring lease", "leaseID", le.LeaseID, "accessor", accessor)
 	}
+
+	m.recordLeaseUsage(ctx, le, isLeaseExpiryContext(ctx))
+
+	if isLeaseExpiryContext(ctx) {
+		m.sendLeaseEvent(ctx, leaseEventTypeExpire, "expire", le, "")
+	} else {
+		m.sendLeaseEvent(ctx, leaseEventTypeRevoke, "revoke", le, "")
+	}
 	return nil
 }
 
@@ -1084,7 +1107,7 @@ func (m *ExpirationManager) revokeCommon(ctx context.Context, leaseID string, fo
 func (m *ExpirationManager) RevokeForce(ctx context.Context, prefix string) error {
 	defer metrics.MeasureSince([]string{"expire", "revoke-force"}, time.Now())
 
-	return m.revokePrefixCommon(ctx, prefix, true, true)
+	return m.revokePrefixCommon(ctx, prefix, true, true, nil)
 }
 
 // RevokePrefix is used to revoke all secrets with a given prefix.
@@ -1093,7 +1116,7 @@ func (m *ExpirationManager) RevokeForce(ctx context.Context, prefix string) erro
 func (m *ExpirationManager) RevokePrefix(ctx context.Context, prefix string, sync bool) error {
 	defer metrics.MeasureSince([]string{"expire", "revoke-prefix"}, time.Now())
 
-	return m.revokePrefixCommon(ctx, prefix, false, sync)
+	return m.revokePrefixCommon(ctx, prefix, false, sync, nil)
 }
 
 // RevokeByToken is used to revoke all the secrets issued with a given token.
@@ -1156,7 +1179,9 @@ func (m *ExpirationManager) RevokeByToken(ctx context.Context, te *logical.Token
 // if sync == true, revoke immediately (using a single worker).
 // otherwise, mark the lease as expiring  `now` and let the expiration manager
 // queue it for revocation.
-func (m *ExpirationManager) revokePrefixCommon(ctx context.Context, prefix string, force, sync bool) error {
+// if progress is not nil, it is called with the number of leases revoked or
+// queued for revocation.
+func (m *ExpirationManager) revokePrefixCommon(ctx context.Context, prefix string, force, sync bool, progress func(revoked, total int64)) error {
 	if m.inRestoreMode() {
 		m.restoreRequestLock.Lock()
 		defer m.restoreRequestLock.Unlock()
@@ -1192,6 +1217,13 @@ func (m *ExpirationManager) revokePrefixCommon(ctx context.Context, prefix strin
 
 	// Revoke all the keys
 	for idx, suffix := range existing {
+		if progress != nil {
+			progress(int64(idx), int64(len(existing)))
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		leaseID := prefix + suffix
 		// No need to acquire per-lease lock here, one of these two will do it.
 		switch {
@@ -1205,6 +1237,9 @@ func (m *ExpirationManager) revokePrefixCommon(ctx context.Context, prefix strin
 			}
 		}
 	}
+	if progress != nil {
+		progress(int64(len(existing)), int64(len(existing)))
+	}
 
 	return nil
 }
@@ -1317,6 +1352,7 @@ func (m *ExpirationManager) Renew(ctx context.Context, leaseID string, increment
 
 	// Update the expiration time
 	m.updatePending(le)
+	m.sendLeaseEvent(ctx, leaseEventTypeRenew, "renew", le, "")
 
 	// Return the response
 	return resp, nil
@@ -1435,11 +1471,143 @@ func (m *ExpirationManager) RenewToken(ctx context.Context, req *logical.Request
 		return nil, err
 	}
 	m.updatePending(le)
+	m.sendLeaseEvent(ctx, leaseEventTypeRenew, "renew", le, te.Accessor)
 
 	retResp.Auth = resp.Auth
 	return retResp, nil
 }
 
+// refreshGroupMemberships refreshes the external group memberships of the
+// entities holding a renewable token of one of the given auth mounts. For each
+// entity and mount, one of its tokens is renewed with the auth method, without
+// extending its lease, and the entity's memberships are updated from the
+// group aliases the auth method returns. It returns the number of memberships
+// refreshed.
+func (m *ExpirationManager) refreshGroupMemberships(ctx context.Context, mountAccessors map[string]struct{}) (int, error) {
+	if m.inRestoreMode() || len(mountAccessors) == 0 {
+		return 0, nil
+	}
+
+	var leaseIDs []string
+	m.pending.Range(func(k, v interface{}) bool {
+		le := v.(pendingInfo).cachedLeaseInfo
+		if le != nil && le.Auth != nil && le.Auth.Renewable && !strings.HasPrefix(le.Path, "auth/token/") {
+			leaseIDs = append(leaseIDs, k.(string))
+		}
+		return true
+	})
+
+	var retErr error
+	refreshed := make(map[string]struct{})
+	for _, leaseID := range leaseIDs {
+		if err := ctx.Err(); err != nil {
+			return len(refreshed), err
+		}
+
+		if err := m.refreshGroupMembershipsByLease(ctx, leaseID, mountAccessors, refreshed); err != nil {
+			retErr = multierror.Append(retErr, err)
+		}
+	}
+	return len(refreshed), retErr
+}
+
+// refreshGroupMembershipsByLease refreshes the external group memberships of
+// the entity of the token lease, unless they were already refreshed for the
+// lease's mount.
+func (m *ExpirationManager) refreshGroupMembershipsByLease(ctx context.Context, leaseID string, mountAccessors, refreshed map[string]struct{}) error {
+	leaseLock := m.lockForLeaseID(leaseID)
+	leaseLock.Lock()
+	le, err := m.loadEntry(ctx, leaseID)
+	leaseLock.Unlock()
+	if err != nil {
+		return err
+	}
+	if le == nil || le.Auth == nil || le.Auth.EntityID == "" || le.Auth.Alias == nil {
+		return nil
+	}
+	if _, err := le.renewable(); err != nil {
+		return nil
+	}
+
+	mountAccessor := le.Auth.Alias.MountAccessor
+	if _, ok := mountAccessors[mountAccessor]; !ok {
+		return nil
+	}
+	key := le.Auth.EntityID + "/" + mountAccessor
+	if _, ok := refreshed[key]; ok {
+		return nil
+	}
+
+	resp, err := m.renewAuthEntry(ctx, &logical.Request{}, le, 0)
+	if err == nil && resp.IsError() {
+		err = resp.Error()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to refresh group memberships of entity %q: %w", le.Auth.EntityID, err)
+	}
+	if resp == nil || resp.Auth == nil {
+		return nil
+	}
+
+	nsCtx := namespace.ContextWithNamespace(ctx, le.namespace)
+	if _, err := m.core.identityStore.refreshExternalGroupMembershipsByEntityID(nsCtx, le.Auth.EntityID, resp.Auth.GroupAliases, mountAccessor); err != nil {
+		return fmt.Errorf("failed to refresh group memberships of entity %q: %w", le.Auth.EntityID, err)
+	}
+	refreshed[key] = struct{}{}
+	return nil
+}
+
+// revokeByEntityID revokes the tokens issued to the given entity, along with
+// their child tokens and the leases created with them. Batch tokens cannot be
+// revoked and are skipped. It returns the number of tokens revoked.
+func (m *ExpirationManager) revokeByEntityID(ctx context.Context, entityID string) (int, error) {
+	if m.inRestoreMode() {
+		return 0, ErrInRestoreMode
+	}
+
+	var leaseIDs []string
+	collect := func(k, v interface{}) bool {
+		le := v.(pendingInfo).cachedLeaseInfo
+		if le != nil && le.Auth != nil {
+			leaseIDs = append(leaseIDs, k.(string))
+		}
+		return true
+	}
+	m.pendingLock.RLock()
+	toWalk := []*sync.Map{&m.pending, &m.nonexpiring}
+	m.pendingLock.RUnlock()
+	for _, pending := range toWalk {
+		pending.Range(collect)
+	}
+
+	var revoked int
+	var retErr error
+	for _, leaseID := range leaseIDs {
+		if err := ctx.Err(); err != nil {
+			return revoked, err
+		}
+
+		leaseLock := m.lockForLeaseID(leaseID)
+		leaseLock.Lock()
+		le, err := m.loadEntry(ctx, leaseID)
+		leaseLock.Unlock()
+		if err != nil {
+			retErr = multierror.Append(retErr, err)
+			continue
+		}
+		if le == nil || le.Auth == nil || le.Auth.EntityID != entityID || le.ClientTokenType == logical.TokenTypeBatch {
+			continue
+		}
+
+		if err := m.revokeCommon(ctx, leaseID, false, false); err != nil {
+			retErr = multierror.Append(retErr, fmt.Errorf("failed to revoke token of entity %q: %w", entityID, err))
+			continue
+		}
+		revoked++
+	}
+	return revoked, retErr
+}
+
 // Register is used to take a request and response with an associated
 // lease. The secret gets assigned a LeaseID and the management of
 // the lease is assumed by the expiration manager.
@@ -1565,6 +1733,7 @@ func (m *ExpirationManager) Register(ctx context.Context, req *logical.Request,
 
 	// Setup revocation timer if there is a lease
 	m.updatePending(le)
+	m.sendLeaseEvent(ctx, leaseEventTypeCreate, "create", le, req.ClientTokenAccessor)
 
 	// We round here because the clock will have already started
 	// ticking, so we'll end up always returning 299 instead of 300 or
@@ -1649,6 +1818,7 @@ func (m *ExpirationManager) RegisterAuth(ctx context.Context, te *logical.TokenE
 
 	// Setup revocation timer
 	m.updatePending(&le)
+	m.sendLeaseEvent(ctx, leaseEventTypeCreate, "create", &le, te.Accessor)
 	if strings.HasPrefix(auth.ClientToken, consts.ServiceTokenPrefix) {
 		generatedTokenEntry := logical.TokenEntry{Policies: auth.Policies}
 		tok := m.tokenStore.GenerateSSCTokenID(auth.ClientToken, logical.IndexStateFromContext(ctx), &generatedTokenEntry)
@@ -1777,6 +1947,7 @@ func (m *ExpirationManager) inMemoryLeaseInfo(le *leaseEntry) *leaseEntry {
 	if le.isIrrevocable() {
 		ret.RevokeErr = le.RevokeErr
 	}
+	ret.RevokeFailures = le.RevokeFailures
 	ret.LoginRole = le.LoginRole
 	return ret
 }
@@ -1913,6 +2084,7 @@ func (m *ExpirationManager) updatePendingInternal(le *leaseEntry) {
 	}
 	if leaseCreated {
 		m.leaseCount++
+		m.trackCredentialAge(le)
 
 		// If we're in restore mode, Vault is still starting. While we may get leases created, it is likely
 		// 'catching up' on old creates. There will be a core.quotasHandleLeases call to register these leases in
@@ -2537,6 +2709,7 @@ func (m *ExpirationManager) removeFromPending(ctx context.Context, leaseID strin
 		m.pending.Delete(leaseID)
 		if decrementCounters {
 			m.leaseCount--
+			m.credentialAges.remove(leaseID)
 			// Avoid nil pointer dereference. Without cachedLeaseInfo we do not have enough information to
 			// accurately update quota lease information.
 			// Note that cachedLeaseInfo should never be nil under normal operation.
@@ -2668,6 +2841,136 @@ func (m *ExpirationManager) getIrrevocableLeaseCounts(ctx context.Context, inclu
 	return resp, nil
 }
 
+// pendingLeaseHistogramBuckets are the upper bounds used to bucket pending
+// leases by their time until expiration in getPendingLeaseHistogram.
+var pendingLeaseHistogramBuckets = []struct {
+	name  string
+	bound time.Duration
+}{
+	{"1m", time.Minute},
+	{"10m", 10 * time.Minute},
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// getPendingLeaseHistogram returns a snapshot of the expiration queue: pending
+// leases bucketed by how soon they expire, leases whose expiration has passed
+// but which are still pending revocation, and the state of the revocation job
+// manager.
+func (m *ExpirationManager) getPendingLeaseHistogram(ctx context.Context, includeChildNamespaces bool) (map[string]interface{}, error) {
+	requestNS, err := namespace.FromContext(ctx)
+	if err != nil {
+		m.logger.Error("could not get namespace from context", "error", err)
+		return nil, err
+	}
+
+	histogram := make(map[string]int, len(pendingLeaseHistogramBuckets)+2)
+	histogram["overdue"] = 0
+	for _, bucket := range pendingLeaseHistogramBuckets {
+		histogram[bucket.name] = 0
+	}
+	histogram["later"] = 0
+
+	type pendingSnapshot struct {
+		leaseID    string
+		expireTime time.Time
+		retrying   bool
+	}
+
+	// Take a cheap snapshot of the queue so that namespace lookups don't
+	// happen while holding the pending lock.
+	m.pendingLock.RLock()
+	snapshot := make([]pendingSnapshot, 0, m.leaseCount)
+	m.pending.Range(func(k, v interface{}) bool {
+		pending := v.(pendingInfo)
+		entry := pendingSnapshot{
+			leaseID:  k.(string),
+			retrying: pending.revokesAttempted > 0,
+		}
+		if pending.cachedLeaseInfo != nil {
+			entry.expireTime = pending.cachedLeaseInfo.ExpireTime
+		}
+		snapshot = append(snapshot, entry)
+		return true
+	})
+	var nonExpiring []string
+	m.nonexpiring.Range(func(k, _ interface{}) bool {
+		nonExpiring = append(nonExpiring, k.(string))
+		return true
+	})
+	m.pendingLock.RUnlock()
+
+	leaseMatches := func(leaseID string) bool {
+		leaseNS, err := m.getNamespaceFromLeaseID(ctx, leaseID)
+		if err != nil {
+			m.logger.Warn("could not get lease namespace from ID", "error", err)
+			return false
+		}
+		return leaseNS == requestNS || (includeChildNamespaces && leaseNS.HasParent(requestNS))
+	}
+
+	now := time.Now()
+	pendingCount := 0
+	retryingCount := 0
+	nonExpiringCount := 0
+	for _, entry := range snapshot {
+		if !leaseMatches(entry.leaseID) {
+			continue
+		}
+		pendingCount++
+		if entry.retrying {
+			retryingCount++
+		}
+		if entry.expireTime.IsZero() {
+			continue
+		}
+
+		bucket := "later"
+		untilExpiry := entry.expireTime.Sub(now)
+		if untilExpiry <= 0 {
+			bucket = "overdue"
+		} else {
+			for _, b := range pendingLeaseHistogramBuckets {
+				if untilExpiry <= b.bound {
+					bucket = b.name
+					break
+				}
+			}
+		}
+		histogram[bucket]++
+	}
+	for _, leaseID := range nonExpiring {
+		if leaseMatches(leaseID) {
+			nonExpiringCount++
+		}
+	}
+
+	irrevocable, err := m.getIrrevocableLeaseCounts(ctx, includeChildNamespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := map[string]interface{}{
+		"pending_count":      pendingCount,
+		"retrying_count":     retryingCount,
+		"non_expiring_count": nonExpiringCount,
+		"irrevocable_count":  irrevocable["lease_count"],
+		"expiring_within":    histogram,
+	}
+
+	// The revocation job manager is shared across all namespaces, so only
+	// report on it from the root namespace.
+	if requestNS.ID == namespace.RootNamespaceID && m.jobManager != nil {
+		resp["revocation_queue_length"] = m.jobManager.GetPendingJobCount()
+		resp["revocation_queue_lengths"] = m.jobManager.GetWorkQueueLengths()
+		resp["revocation_worker_counts"] = m.jobManager.GetWorkerCounts()
+	}
+
+	return resp, nil
+}
+
 type leaseResponse struct {
 	LeaseID    string `json:"lease_id"`
 	MountID    string `json:"mount_id"`
@@ -2773,6 +3076,10 @@ type leaseEntry struct {
 	// RevokeErr will be set, thus marking this leaseEntry as irrevocable. From
 	// there, it must be manually removed (force revoked).
 	RevokeErr string `json:"revokeErr"`
+
+	// RevokeFailures tracks the causes of the failed revocation attempts of
+	// the lease, so that they can be reported per mount.
+	RevokeFailures *leaseRevokeFailures `json:"revoke_failures,omitempty"`
 }
 
 // encode is used to JSON encode the lease entry
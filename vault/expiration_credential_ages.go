@@ -0,0 +1,194 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+)
+
+// CredentialAgeCounter counts the credentials of secrets engines, that is
+// the leases of their secrets, by age.
+type CredentialAgeCounter struct {
+	Total              int `json:"total"`
+	UnderOneDay        int `json:"under_1d"`
+	OneToSevenDays     int `json:"1d_to_7d"`
+	SevenToThirtyDays  int `json:"7d_to_30d"`
+	ThirtyToNinetyDays int `json:"30d_to_90d"`
+	OverNinetyDays     int `json:"over_90d"`
+}
+
+func (c *CredentialAgeCounter) add(age time.Duration, count int) {
+	c.Total += count
+	switch {
+	case age < 24*time.Hour:
+		c.UnderOneDay += count
+	case age < 7*24*time.Hour:
+		c.OneToSevenDays += count
+	case age < 30*24*time.Hour:
+		c.SevenToThirtyDays += count
+	case age < 90*24*time.Hour:
+		c.ThirtyToNinetyDays += count
+	default:
+		c.OverNinetyDays += count
+	}
+}
+
+func (c *CredentialAgeCounter) merge(other *CredentialAgeCounter) {
+	c.Total += other.Total
+	c.UnderOneDay += other.UnderOneDay
+	c.OneToSevenDays += other.OneToSevenDays
+	c.SevenToThirtyDays += other.SevenToThirtyDays
+	c.ThirtyToNinetyDays += other.ThirtyToNinetyDays
+	c.OverNinetyDays += other.OverNinetyDays
+}
+
+// credentialAgeRef is where a lease is counted.
+type credentialAgeRef struct {
+	mountAccessor string
+	issueHour     int64
+}
+
+// credentialAgeTracker counts the leases of the secrets of each mount by the
+// hour they were issued, as they are added to and removed from the
+// expiration manager, so that their ages are computed without iterating over
+// the leases.
+type credentialAgeTracker struct {
+	lock   sync.Mutex
+	leases map[string]credentialAgeRef
+	mounts map[string]map[int64]int
+}
+
+func newCredentialAgeTracker() *credentialAgeTracker {
+	return &credentialAgeTracker{
+		leases: make(map[string]credentialAgeRef),
+		mounts: make(map[string]map[int64]int),
+	}
+}
+
+// add counts the lease, unless it is already counted.
+func (t *credentialAgeTracker) add(leaseID, mountAccessor string, issueTime time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.leases[leaseID]; ok {
+		return
+	}
+	ref := credentialAgeRef{
+		mountAccessor: mountAccessor,
+		issueHour:     issueTime.Unix() / 3600,
+	}
+	t.leases[leaseID] = ref
+
+	hours, ok := t.mounts[mountAccessor]
+	if !ok {
+		hours = make(map[int64]int)
+		t.mounts[mountAccessor] = hours
+	}
+	hours[ref.issueHour]++
+}
+
+// remove stops counting the lease, if it is counted.
+func (t *credentialAgeTracker) remove(leaseID string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	ref, ok := t.leases[leaseID]
+	if !ok {
+		return
+	}
+	delete(t.leases, leaseID)
+
+	hours := t.mounts[ref.mountAccessor]
+	hours[ref.issueHour]--
+	if hours[ref.issueHour] <= 0 {
+		delete(hours, ref.issueHour)
+	}
+	if len(hours) == 0 {
+		delete(t.mounts, ref.mountAccessor)
+	}
+}
+
+func (t *credentialAgeTracker) reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.leases = make(map[string]credentialAgeRef)
+	t.mounts = make(map[string]map[int64]int)
+}
+
+// ages returns the credentials of the mount by age, as of now.
+func (t *credentialAgeTracker) ages(mountAccessor string, now time.Time) *CredentialAgeCounter {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	counter := new(CredentialAgeCounter)
+	nowHour := now.Unix() / 3600
+	for issueHour, count := range t.mounts[mountAccessor] {
+		counter.add(time.Duration(nowHour-issueHour)*time.Hour, count)
+	}
+	return counter
+}
+
+// trackCredentialAge counts the lease by age if it is the lease of a secret.
+// It must be called with m.pendingLock held.
+func (m *ExpirationManager) trackCredentialAge(le *leaseEntry) {
+	if le.Secret == nil || le.namespace == nil || le.IssueTime.IsZero() {
+		return
+	}
+
+	mount := m.router.MatchingMountEntry(namespace.ContextWithNamespace(m.quitContext, le.namespace), le.Path)
+	if mount == nil {
+		return
+	}
+	m.credentialAges.add(le.LeaseID, mount.Accessor, le.IssueTime)
+}
+
+// MountCredentialCounts contains the credentials of a secrets engine by age.
+type MountCredentialCounts struct {
+	CredentialAgeCounter
+	Path     string `json:"mount"`
+	Accessor string `json:"mount_accessor"`
+	Type     string `json:"type"`
+}
+
+// CredentialCounts contains the credentials of the secrets engines by age.
+type CredentialCounts struct {
+	// Mounts contains the credentials of each secrets engine.
+	Mounts []*MountCredentialCounts `json:"mounts"`
+
+	// Total is the sum of the credentials of the secrets engines.
+	Total CredentialAgeCounter `json:"total"`
+}
+
+// countCredentials returns the credentials by age of the secrets engines of
+// the namespace of the context and its children.
+func (c *Core) countCredentials(ctx context.Context) (*CredentialCounts, error) {
+	entries, err := c.countedMounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := &CredentialCounts{
+		Mounts: make([]*MountCredentialCounts, 0, len(entries)),
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		mount := &MountCredentialCounts{
+			Path:     entry.APIPath(),
+			Accessor: entry.Accessor,
+			Type:     entry.Type,
+		}
+		if c.expiration != nil {
+			mount.CredentialAgeCounter = *c.expiration.credentialAges.ages(entry.Accessor, now)
+		}
+
+		counts.Mounts = append(counts.Mounts, mount)
+		counts.Total.merge(&mount.CredentialAgeCounter)
+	}
+	return counts, nil
+}
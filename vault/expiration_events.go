@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Lease and token lifecycle event types. These are emitted by the core onto
+// the common event bus without any plugin info, so subscribers are authorized
+// against the data_path of the event, which is the path the lease or token
+// was created on (e.g., database/creds/readonly or auth/userpass/login/alice).
+const (
+	leaseEventTypeCreate logical.EventType = "lease/create"
+	leaseEventTypeRenew  logical.EventType = "lease/renew"
+	leaseEventTypeRevoke logical.EventType = "lease/revoke"
+	leaseEventTypeExpire logical.EventType = "lease/expire"
+	tokenEventTypeRevoke logical.EventType = "token/revoke"
+)
+
+// Metadata keys specific to lifecycle events, in addition to the common
+// data_path and operation keys.
+const (
+	lifecycleEventMetadataLeaseID    = "lease_id"
+	lifecycleEventMetadataAccessor   = "accessor"
+	lifecycleEventMetadataExpireTime = "expire_time"
+)
+
+type leaseExpiryContextKey struct{}
+
+// contextWithLeaseExpiry marks the context as belonging to a revocation that
+// was triggered by the lease reaching its expiration time, as opposed to an
+// explicit revocation request.
+func contextWithLeaseExpiry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, leaseExpiryContextKey{}, true)
+}
+
+func isLeaseExpiryContext(ctx context.Context) bool {
+	expiry, _ := ctx.Value(leaseExpiryContextKey{}).(bool)
+	return expiry
+}
+
+// sendLifecycleEvent sends a core-generated lease or token event to the event
+// bus. Failures are logged rather than returned since events are best-effort
+// and must never cause the underlying lease operation to fail.
+func (c *Core) sendLifecycleEvent(ns *namespace.Namespace, eventType logical.EventType, dataPath, operation string, metadataPairs ...string) {
+	if c.events == nil || ns == nil {
+		return
+	}
+
+	ev, err := logical.NewEvent()
+	if err != nil {
+		c.logger.Debug("failed to create lifecycle event", "event_type", eventType, "error", err)
+		return
+	}
+	ev.Metadata = &structpb.Struct{Fields: map[string]*structpb.Value{
+		logical.EventMetadataDataPath:  structpb.NewStringValue(dataPath),
+		logical.EventMetadataOperation: structpb.NewStringValue(operation),
+	}}
+	for i := 0; i+1 < len(metadataPairs); i += 2 {
+		if metadataPairs[i+1] == "" {
+			continue
+		}
+		ev.Metadata.Fields[metadataPairs[i]] = structpb.NewStringValue(metadataPairs[i+1])
+	}
+
+	// The request context may be short-lived, and SendEventInternal ignores
+	// the context anyway, so always use the background context here.
+	if err := c.events.SendEventInternal(context.Background(), ns, nil, eventType, ev); err != nil {
+		c.logger.Debug("failed to send lifecycle event", "event_type", eventType, "error", err)
+	}
+}
+
+// sendLeaseEvent sends a lifecycle event describing the given lease entry.
+// Lease IDs of tokens are derived from the salted token ID, so for auth leases
+// only the accessor is included.
+func (m *ExpirationManager) sendLeaseEvent(ctx context.Context, eventType logical.EventType, operation string, le *leaseEntry, accessor string) {
+	ns := le.namespace
+	if ns == nil {
+		var err error
+		ns, err = namespace.FromContext(ctx)
+		if err != nil {
+			return
+		}
+	}
+
+	leaseID := le.LeaseID
+	if le.Auth != nil {
+		leaseID = ""
+		if accessor == "" {
+			accessor = le.Auth.Accessor
+		}
+	}
+
+	expireTime := ""
+	if !le.ExpireTime.IsZero() {
+		expireTime = le.ExpireTime.UTC().Format(time.RFC3339)
+	}
+
+	m.core.sendLifecycleEvent(ns, eventType, le.Path, operation,
+		lifecycleEventMetadataLeaseID, leaseID,
+		lifecycleEventMetadataAccessor, accessor,
+		lifecycleEventMetadataExpireTime, expireTime,
+	)
+}
@@ -0,0 +1,158 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// migratePrefix moves the leases under the src prefix of the namespace in the
+// context to the dst prefix, as done when their mount is moved within its
+// namespace. The leases keep their expiry, and are rewritten along with their
+// token indexes and, for the leases of tokens, the paths of their tokens.
+//
+// If any lease cannot be moved, the leases already moved are moved back, so
+// that either all or none of the leases are found under dst.
+func (m *ExpirationManager) migratePrefix(ctx context.Context, src, dst string) error {
+	defer metrics.MeasureSince([]string{"expire", "migrate-prefix"}, time.Now())
+
+	if m.inRestoreMode() {
+		m.restoreRequestLock.Lock()
+		defer m.restoreRequestLock.Unlock()
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	existing, err := logical.CollectKeys(ctx, m.leaseView(ns).SubView(src))
+	if err != nil {
+		return fmt.Errorf("failed to scan for leases: %w", err)
+	}
+
+	for idx, suffix := range existing {
+		err := ctx.Err()
+		if err == nil {
+			err = m.migrateLease(ctx, src+suffix, dst+suffix, src, dst)
+		}
+		if err == nil {
+			continue
+		}
+
+		// The active context may be done, so the leases are moved back
+		// regardless
+		undoCtx := namespace.ContextWithNamespace(context.Background(), ns)
+		for _, moved := range existing[:idx] {
+			if err := m.migrateLease(undoCtx, dst+moved, src+moved, dst, src); err != nil {
+				m.logger.Error("failed to move back lease", "lease_id", dst+moved, "error", err)
+			}
+		}
+		return fmt.Errorf("failed to migrate %q (%d / %d): %w", src+suffix, idx+1, len(existing), err)
+	}
+
+	if len(existing) > 0 {
+		m.logger.Info("migrated leases", "from", ns.Path+src, "to", ns.Path+dst, "count", len(existing))
+	}
+	return nil
+}
+
+// migrateLease moves the lease oldID to newID, replacing the src prefix of its
+// path with dst. The new lease is written before the old one is deleted, so
+// that the lease is not lost if this is interrupted.
+func (m *ExpirationManager) migrateLease(ctx context.Context, oldID, newID, src, dst string) error {
+	// The locks are taken in order so that concurrent migrations do not
+	// deadlock
+	ids := []string{oldID, newID}
+	sort.Strings(ids)
+	for _, id := range ids {
+		lock := m.lockForLeaseID(id)
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	le, err := m.loadEntry(ctx, oldID)
+	if err != nil {
+		return err
+	}
+	if le == nil {
+		// The lease was revoked in the interim
+		return nil
+	}
+
+	migrated := *le
+	migrated.LeaseID = newID
+	migrated.Path = dst + strings.TrimPrefix(le.Path, src)
+	if err := m.persistEntry(ctx, &migrated); err != nil {
+		return err
+	}
+
+	switch {
+	case le.Secret != nil:
+		indexToken, err := m.indexToken(ctx, le)
+		if err != nil {
+			return err
+		}
+		if indexToken != "" {
+			if err := m.createIndexByToken(ctx, &migrated, indexToken); err != nil {
+				return err
+			}
+			if err := m.removeIndexByToken(ctx, le, indexToken); err != nil {
+				return err
+			}
+		}
+	case le.Auth != nil:
+		// The lease of a token is found from the path of the token
+		nsCtx := namespace.ContextWithNamespace(ctx, le.namespace)
+		if err := m.tokenStore.setPath(nsCtx, le.ClientToken, migrated.Path); err != nil {
+			return fmt.Errorf("failed to update token path: %w", err)
+		}
+	}
+
+	// Move the expiration handler
+	m.updatePending(&migrated)
+	m.pendingLock.Lock()
+	m.removeFromPending(ctx, oldID, true)
+	m.nonexpiring.Delete(oldID)
+	if _, ok := m.irrevocable.Load(oldID); ok {
+		m.irrevocable.Delete(oldID)
+		m.irrevocableLeaseCount--
+		m.credentialAges.remove(oldID)
+	}
+	m.pendingLock.Unlock()
+
+	if err := m.deleteEntry(ctx, le); err != nil {
+		return err
+	}
+	m.deleteLockForLease(oldID)
+
+	return nil
+}
+
+// indexToken returns the token whose secondary index holds the leased secret,
+// which is the parent of non-orphan batch tokens, or an empty string for
+// orphan batch tokens.
+func (m *ExpirationManager) indexToken(ctx context.Context, le *leaseEntry) (string, error) {
+	if le.ClientTokenType != logical.TokenTypeBatch {
+		return le.ClientToken, nil
+	}
+
+	te, err := m.tokenStore.lookupBatchTokenInternal(ctx, le.ClientToken)
+	if err != nil {
+		return "", err
+	}
+	// lookupBatchTokenInternal can return nil, nil in the case of a token
+	// decrypt error
+	if te == nil {
+		return "", nil
+	}
+	return te.Parent, nil
+}
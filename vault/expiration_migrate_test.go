@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExpiration_MigratePrefix verifies that the leases of a moved mount keep
+// being found from the tokens they were issued to, and are revoked with them.
+func TestExpiration_MigratePrefix(t *testing.T) {
+	noop := &NoopBackend{}
+	c, _, root := TestCoreUnsealed(t)
+	c.logicalBackends["noop"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return noop, nil
+	}
+	ctx := namespace.RootContext(nil)
+
+	require.NoError(t, c.mount(ctx, &MountEntry{
+		Table: mountTableType,
+		Path:  "test/",
+		Type:  "noop",
+	}))
+	testMakeServiceTokenViaBackend(t, c.tokenStore, root, "client", "1h", []string{"root"})
+
+	noop.Response = &logical.Response{
+		Secret: &logical.Secret{
+			LeaseOptions: logical.LeaseOptions{
+				TTL: time.Hour,
+			},
+		},
+	}
+	var leaseIDs []string
+	for i := 0; i < 3; i++ {
+		resp, err := c.HandleRequest(ctx, &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        "test/foo",
+			ClientToken: "client",
+		})
+		require.NoError(t, err)
+		leaseIDs = append(leaseIDs, resp.Secret.LeaseID)
+	}
+	leaseCount := c.expiration.leaseCount
+
+	require.NoError(t, c.remountSecretsEngineCurrentNamespace(ctx, "test/", "moved/", true))
+
+	te, err := c.tokenStore.Lookup(ctx, "client")
+	require.NoError(t, err)
+	indexed, err := c.expiration.lookupLeasesByToken(ctx, te)
+	require.NoError(t, err)
+	require.Len(t, indexed, len(leaseIDs))
+	for _, leaseID := range indexed {
+		require.True(t, strings.HasPrefix(leaseID, "moved/foo/"), leaseID)
+		_, pending := c.expiration.pending.Load(leaseID)
+		require.True(t, pending, leaseID)
+	}
+	for _, leaseID := range leaseIDs {
+		_, pending := c.expiration.pending.Load(leaseID)
+		require.False(t, pending, leaseID)
+	}
+	require.Equal(t, leaseCount, c.expiration.leaseCount)
+
+	// Revoking the token revokes the moved leases through the moved mount
+	requests := len(noop.Requests)
+	require.NoError(t, c.tokenStore.revokeOrphan(ctx, te.ID))
+	require.Eventually(t, func() bool {
+		leases, err := logical.CollectKeys(ctx, c.expiration.leaseView(namespace.RootNamespace).SubView("moved/"))
+		return err == nil && len(leases) == 0
+	}, 10*time.Second, 50*time.Millisecond)
+
+	revoked := 0
+	for _, req := range noop.Requests[requests:] {
+		if req.Operation == logical.RevokeOperation {
+			require.Equal(t, "foo", req.Path)
+			revoked++
+		}
+	}
+	require.Equal(t, len(leaseIDs), revoked)
+}
@@ -0,0 +1,286 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// Categories of the causes of failed revocation attempts.
+const (
+	revokeFailureNetwork    = "network"
+	revokeFailurePermission = "permission"
+	revokeFailureNotFound   = "not_found"
+	revokeFailureOther      = "other"
+)
+
+var (
+	revokeFailureNetworkMessages = []string{
+		"connection refused",
+		"connection reset",
+		"no such host",
+		"i/o timeout",
+		"network is unreachable",
+		"no route to host",
+		"broken pipe",
+		"tls handshake timeout",
+		"context deadline exceeded",
+	}
+	revokeFailurePermissionMessages = []string{
+		"permission denied",
+		"access denied",
+		"accessdenied",
+		"unauthorized",
+		"forbidden",
+		"not authorized",
+		"insufficient privilege",
+	}
+	revokeFailureNotFoundMessages = []string{
+		"not found",
+		"does not exist",
+		"no such",
+		"unknown role",
+		"no handler for route",
+	}
+)
+
+// leaseRevokeFailures records the failed revocation attempts of a lease, by
+// category of their cause.
+type leaseRevokeFailures struct {
+	Categories map[string]*leaseRevokeFailure `json:"categories"`
+}
+
+// leaseRevokeFailure records the failed revocation attempts of a lease with
+// causes of a single category.
+type leaseRevokeFailure struct {
+	Count           int       `json:"count"`
+	LastError       string    `json:"last_error"`
+	LastFailureTime time.Time `json:"last_failure_time"`
+}
+
+// revokeFailureCategory returns the category of the cause of a failed
+// revocation attempt. Errors from plugins may only carry their message, so
+// known messages are matched when the error type does not tell.
+func revokeFailureCategory(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, logical.ErrPermissionDenied),
+		errors.Is(err, os.ErrPermission):
+		return revokeFailurePermission
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.As(err, &netErr):
+		return revokeFailureNetwork
+	case errors.Is(err, logical.ErrUnsupportedPath),
+		errors.Is(err, os.ErrNotExist):
+		return revokeFailureNotFound
+	}
+
+	msg := strings.ToLower(err.Error())
+	contains := func(messages []string) bool {
+		for _, m := range messages {
+			if strings.Contains(msg, m) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case contains(revokeFailureNetworkMessages):
+		return revokeFailureNetwork
+	case contains(revokeFailurePermissionMessages):
+		return revokeFailurePermission
+	case contains(revokeFailureNotFoundMessages):
+		return revokeFailureNotFound
+	}
+	return revokeFailureOther
+}
+
+// withFailure returns a copy of the failures with the given failed attempt
+// recorded. Copies are made so that the failures cached with pending leases
+// are never modified.
+func (f *leaseRevokeFailures) withFailure(err error, now time.Time) *leaseRevokeFailures {
+	ret := &leaseRevokeFailures{
+		Categories: make(map[string]*leaseRevokeFailure),
+	}
+	if f != nil {
+		for category, failure := range f.Categories {
+			ret.Categories[category] = failure
+		}
+	}
+
+	category := revokeFailureCategory(err)
+	errStr := err.Error()
+	if len(errStr) > maxIrrevocableErrorLength {
+		errStr = errStr[:maxIrrevocableErrorLength]
+	}
+
+	failure := &leaseRevokeFailure{
+		LastError:       errStr,
+		LastFailureTime: now,
+	}
+	if prev, ok := ret.Categories[category]; ok {
+		failure.Count = prev.Count
+	}
+	failure.Count++
+	ret.Categories[category] = failure
+
+	return ret
+}
+
+// recordRevokeFailure persists the cause of a failed revocation attempt of a
+// lease, and returns the failures of the lease, or nil if it no longer exists.
+func (m *ExpirationManager) recordRevokeFailure(ctx context.Context, leaseID string, revokeErr error) *leaseRevokeFailures {
+	if revokeErr == nil {
+		return nil
+	}
+
+	le, err := m.loadEntry(ctx, leaseID)
+	if err != nil {
+		m.logger.Warn("failed to record revocation failure - failed to load", "lease_id", leaseID, "err", err)
+		return nil
+	}
+	if le == nil {
+		return nil
+	}
+
+	le.RevokeFailures = le.RevokeFailures.withFailure(revokeErr, time.Now())
+	if err := m.persistEntry(ctx, le); err != nil {
+		m.logger.Warn("failed to record revocation failure", "lease_id", leaseID, "err", err)
+	}
+
+	return le.RevokeFailures
+}
+
+// revokeFailureSummary is the failed revocation attempts of the leases of a
+// mount with causes of a single category.
+type revokeFailureSummary struct {
+	LeaseCount      int       `json:"lease_count"`
+	FailureCount    int       `json:"failure_count"`
+	LastError       string    `json:"last_error"`
+	LastFailureTime time.Time `json:"last_failure_time"`
+}
+
+// getRevokeFailures returns the failed revocation attempts of the leases of the
+// request namespace, and its children if includeChildNamespaces is true, by
+// mount and category of their cause. If mountAccessor is set, only the leases
+// of that mount are returned.
+func (m *ExpirationManager) getRevokeFailures(ctx context.Context, includeChildNamespaces bool, mountAccessor string) (map[string]interface{}, error) {
+	requestNS, err := namespace.FromContext(ctx)
+	if err != nil {
+		m.logger.Error("could not get namespace from context", "error", err)
+		return nil, err
+	}
+
+	type mountFailures struct {
+		path       string
+		accessor   string
+		leaseCount int
+		categories map[string]*revokeFailureSummary
+	}
+	mounts := make(map[string]*mountFailures)
+	numMatchingLeases := 0
+
+	callback := func(k, v interface{}) bool {
+		leaseID := k.(string)
+
+		var le *leaseEntry
+		switch info := v.(type) {
+		case pendingInfo:
+			le = info.cachedLeaseInfo
+		case *leaseEntry:
+			le = info
+		}
+		if le == nil || le.RevokeFailures == nil {
+			return true
+		}
+
+		leaseNS, err := m.getNamespaceFromLeaseID(ctx, leaseID)
+		if err != nil {
+			m.logger.Warn("could not get lease namespace from ID", "error", err)
+			return true
+		}
+		if leaseNS.ID != requestNS.ID && !(includeChildNamespaces && leaseNS.HasParent(requestNS)) {
+			return true
+		}
+
+		path := "mount-path-not-found"
+		accessor := "mount-accessor-not-found"
+		if mount := m.router.MatchingMountEntry(namespace.ContextWithNamespace(ctx, leaseNS), leaseID); mount != nil {
+			path = mount.APIPath()
+			accessor = mount.Accessor
+		}
+		if mountAccessor != "" && accessor != mountAccessor {
+			return true
+		}
+
+		mf, ok := mounts[accessor]
+		if !ok {
+			mf = &mountFailures{
+				path:       path,
+				accessor:   accessor,
+				categories: make(map[string]*revokeFailureSummary),
+			}
+			mounts[accessor] = mf
+		}
+		numMatchingLeases++
+		mf.leaseCount++
+
+		for category, failure := range le.RevokeFailures.Categories {
+			summary, ok := mf.categories[category]
+			if !ok {
+				summary = &revokeFailureSummary{}
+				mf.categories[category] = summary
+			}
+			summary.LeaseCount++
+			summary.FailureCount += failure.Count
+			if failure.LastFailureTime.After(summary.LastFailureTime) {
+				summary.LastError = failure.LastError
+				summary.LastFailureTime = failure.LastFailureTime
+			}
+		}
+
+		return true
+	}
+
+	m.pending.Range(callback)
+	m.irrevocable.Range(callback)
+
+	sorted := make([]*mountFailures, 0, len(mounts))
+	for _, mf := range mounts {
+		sorted = append(sorted, mf)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].path != sorted[j].path {
+			return sorted[i].path < sorted[j].path
+		}
+		return sorted[i].accessor < sorted[j].accessor
+	})
+
+	result := make([]map[string]interface{}, 0, len(sorted))
+	for _, mf := range sorted {
+		result = append(result, map[string]interface{}{
+			"mount":          mf.path,
+			"mount_accessor": mf.accessor,
+			"lease_count":    mf.leaseCount,
+			"categories":     mf.categories,
+		})
+	}
+
+	return map[string]interface{}{
+		"lease_count": numMatchingLeases,
+		"mounts":      result,
+	}, nil
+}
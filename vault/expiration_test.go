@@ -29,6 +29,7 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/physical"
 	"github.com/hashicorp/vault/sdk/physical/inmem"
+	"github.com/stretchr/testify/require"
 )
 
 var testImagePull sync.Once
@@ -1292,6 +1293,108 @@ func TestExpiration_Revoke(t *testing.T) {
 	}
 }
 
+func TestExpiration_LeaseRecommendations(t *testing.T) {
+	exp := mockExpiration(t)
+	noop := &NoopBackend{}
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = exp.router.Mount(noop, "prod/aws/", &MountEntry{Path: "prod/aws/", Type: "noop", UUID: meUUID, Accessor: "noop-accessor", namespace: namespace.RootNamespace}, view)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	register := func(path string, ttl time.Duration) string {
+		t.Helper()
+
+		req := &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        path,
+			ClientToken: "foobar",
+		}
+		req.SetTokenEntry(&logical.TokenEntry{ID: "foobar", NamespaceID: "root"})
+		resp := &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: ttl,
+				},
+			},
+		}
+
+		id, err := exp.Register(namespace.RootContext(nil), req, resp, "")
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return id
+	}
+
+	// Leases of creds/short are used far less than their TTL, while those of
+	// creds/long are too few to make recommendations
+	for i := 0; i < 5; i++ {
+		if err := exp.Revoke(namespace.RootContext(nil), register("prod/aws/creds/short", 24*time.Hour)); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if err := exp.Revoke(namespace.RootContext(nil), register("prod/aws/creds/long", time.Hour)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp, err := exp.getLeaseRecommendations(namespace.RootContext(nil), false, 95, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recommendations := resp["recommendations"].([]map[string]interface{})
+	if len(recommendations) != 1 {
+		t.Fatalf("expected 1 recommendation, got %v", recommendations)
+	}
+
+	expected := map[string]interface{}{
+		"mount":               "prod/aws/",
+		"mount_accessor":      "noop-accessor",
+		"role":                "creds/short",
+		"leases":              5,
+		"revoked":             5,
+		"expired":             0,
+		"renewed":             0,
+		"lifetime_percentile": int64(0),
+		"max_lifetime":        int64(0),
+		"ttl":                 int64(86400),
+		"max_ttl":             int64(2764800),
+		"recommended_ttl":     int64(60),
+		"recommended_max_ttl": int64(60),
+		"recommendation":      recommendations[0]["recommendation"],
+	}
+	if !reflect.DeepEqual(recommendations[0], expected) {
+		t.Fatalf("bad: expected:\n%#v\nactual:\n%#v", expected, recommendations[0])
+	}
+	if message := recommendations[0]["recommendation"].(string); !strings.HasPrefix(message, "95% of leases on this role ended within ") || !strings.HasSuffix(message, " but the TTL is 24h0m0s") {
+		t.Fatalf("unexpected recommendation %q", message)
+	}
+
+	resp, err = exp.getLeaseRecommendations(namespace.RootContext(nil), false, 95, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recommendations := resp["recommendations"].([]map[string]interface{}); len(recommendations) != 2 || recommendations[0]["role"] != "creds/long" {
+		t.Fatalf("expected 2 recommendations, got %v", recommendations)
+	}
+
+	// Recommendations are no longer made for mounts which were disabled
+	if err := exp.router.Unmount(namespace.RootContext(nil), "prod/aws/"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err = exp.getLeaseRecommendations(namespace.RootContext(nil), false, 95, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recommendations := resp["recommendations"].([]map[string]interface{}); len(recommendations) != 0 {
+		t.Fatalf("expected no recommendations, got %v", recommendations)
+	}
+}
+
 func TestExpiration_RevokeOnExpire(t *testing.T) {
 	exp := mockExpiration(t)
 	noop := &NoopBackend{}
@@ -3282,6 +3385,203 @@ func TestExpiration_unrecoverableErrorMakesIrrevocable(t *testing.T) {
 	}
 }
 
+func TestExpiration_revokeFailureCategory(t *testing.T) {
+	testCases := []struct {
+		err      error
+		category string
+	}{
+		{
+			err:      logical.ErrPermissionDenied,
+			category: revokeFailurePermission,
+		},
+		{
+			err:      fmt.Errorf("failed to revoke user: pq: permission denied for table users"),
+			category: revokeFailurePermission,
+		},
+		{
+			err:      context.DeadlineExceeded,
+			category: revokeFailureNetwork,
+		},
+		{
+			err:      fmt.Errorf("dial tcp 10.0.0.1:5432: connect: connection refused"),
+			category: revokeFailureNetwork,
+		},
+		{
+			err:      fmt.Errorf("dial tcp: lookup db.example.com: no such host"),
+			category: revokeFailureNetwork,
+		},
+		{
+			err:      logical.ErrUnsupportedPath,
+			category: revokeFailureNotFound,
+		},
+		{
+			err:      fmt.Errorf(`role "app" not found`),
+			category: revokeFailureNotFound,
+		},
+		{
+			err:      fmt.Errorf("some other error"),
+			category: revokeFailureOther,
+		},
+	}
+
+	for _, tc := range testCases {
+		if category := revokeFailureCategory(tc.err); category != tc.category {
+			t.Errorf("bad category for %q: expected %q, got %q", tc.err, tc.category, category)
+		}
+	}
+}
+
+func TestExpiration_getRevokeFailures(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	exp := c.expiration
+	ctx := namespace.RootContext(nil)
+
+	backends := []*backend{
+		{
+			path: "database/",
+			ns:   namespace.RootNamespace,
+		},
+		{
+			path: "aws/",
+			ns:   namespace.RootNamespace,
+		},
+	}
+	pathToMount, err := mountNoopBackends(c, backends)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registerLease := func(path string) string {
+		req := &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        path,
+			ClientToken: "sometoken",
+		}
+		req.SetTokenEntry(&logical.TokenEntry{ID: "sometoken", NamespaceID: "root"})
+		resp := &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: 10 * time.Hour,
+				},
+			},
+		}
+		leaseID, err := exp.Register(ctx, req, resp, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return leaseID
+	}
+	fail := func(leaseID string, errs ...error) {
+		job, err := newRevocationJob(ctx, leaseID, namespace.RootNamespace, exp)
+		if err != nil {
+			t.Fatalf("err making revocation job: %v", err)
+		}
+		for _, err := range errs {
+			job.OnFailure(err)
+		}
+	}
+
+	unreachable := fmt.Errorf("dial tcp 10.0.0.1:5432: connect: connection refused")
+	deleted := fmt.Errorf(`role "app" not found: %w`, logical.ErrUnrecoverable)
+
+	dbLease1 := registerLease("database/creds/app")
+	fail(dbLease1, unreachable, unreachable)
+	dbLease2 := registerLease("database/creds/app")
+	fail(dbLease2, unreachable)
+	awsLease := registerLease("aws/creds/app")
+	fail(awsLease, deleted)
+	registerLease("aws/creds/other")
+
+	// The causes are persisted with the lease, including once it is irrevocable
+	le, err := exp.loadEntry(ctx, awsLease)
+	require.NoError(t, err)
+	require.True(t, le.isIrrevocable())
+	require.NotNil(t, le.RevokeFailures)
+	require.Equal(t, 1, le.RevokeFailures.Categories[revokeFailureNotFound].Count)
+	require.Equal(t, deleted.Error(), le.RevokeFailures.Categories[revokeFailureNotFound].LastError)
+
+	le, err = exp.loadEntry(ctx, dbLease1)
+	require.NoError(t, err)
+	require.False(t, le.isIrrevocable())
+	require.Equal(t, 2, le.RevokeFailures.Categories[revokeFailureNetwork].Count)
+
+	out, err := exp.getRevokeFailures(ctx, false, "")
+	require.NoError(t, err)
+	require.Equal(t, 3, out["lease_count"])
+
+	mounts := out["mounts"].([]map[string]interface{})
+	require.Len(t, mounts, 2)
+	require.Equal(t, "aws/", mounts[0]["mount"])
+	require.Equal(t, pathToMount["aws/"], mounts[0]["mount_accessor"])
+	require.Equal(t, 1, mounts[0]["lease_count"])
+	categories := mounts[0]["categories"].(map[string]*revokeFailureSummary)
+	require.Len(t, categories, 1)
+	require.Equal(t, 1, categories[revokeFailureNotFound].LeaseCount)
+	require.Equal(t, 1, categories[revokeFailureNotFound].FailureCount)
+
+	require.Equal(t, "database/", mounts[1]["mount"])
+	require.Equal(t, 2, mounts[1]["lease_count"])
+	categories = mounts[1]["categories"].(map[string]*revokeFailureSummary)
+	require.Len(t, categories, 1)
+	require.Equal(t, 2, categories[revokeFailureNetwork].LeaseCount)
+	require.Equal(t, 3, categories[revokeFailureNetwork].FailureCount)
+	require.Equal(t, unreachable.Error(), categories[revokeFailureNetwork].LastError)
+
+	out, err = exp.getRevokeFailures(ctx, false, pathToMount["database/"])
+	require.NoError(t, err)
+	require.Equal(t, 2, out["lease_count"])
+	require.Len(t, out["mounts"], 1)
+}
+
+func TestExpiration_getPendingLeaseHistogram(t *testing.T) {
+	exp := mockExpiration(t)
+	req := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "prod/aws/foo",
+		ClientToken: "foobar",
+	}
+	req.SetTokenEntry(&logical.TokenEntry{ID: "foobar", NamespaceID: "root"})
+
+	ttls := []time.Duration{30 * time.Minute, 30 * time.Minute, 12 * time.Hour, 90 * 24 * time.Hour}
+	for _, ttl := range ttls {
+		resp := &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: ttl,
+				},
+			},
+		}
+		if _, err := exp.Register(namespace.RootContext(nil), req, resp, ""); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	out, err := exp.getPendingLeaseHistogram(namespace.RootContext(nil), false)
+	if err != nil {
+		t.Fatalf("error getting pending lease histogram: %v", err)
+	}
+
+	if count := out["pending_count"].(int); count != len(ttls) {
+		t.Fatalf("bad pending count. expected %d, got %d", len(ttls), count)
+	}
+	expected := map[string]int{
+		"overdue": 0,
+		"1m":      0,
+		"10m":     0,
+		"1h":      2,
+		"24h":     1,
+		"7d":      0,
+		"30d":     0,
+		"later":   1,
+	}
+	if actual := out["expiring_within"].(map[string]int); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("bad histogram. expected %v, got %v", expected, actual)
+	}
+	if _, ok := out["revocation_queue_length"]; !ok {
+		t.Fatal("expected revocation queue length in root namespace")
+	}
+}
+
 func TestExpiration_getIrrevocableLeaseCounts(t *testing.T) {
 	c, _, _ := TestCoreUnsealed(t)
 
@@ -3500,3 +3800,91 @@ func TestExpiration_listIrrevocableLeases_includeAll(t *testing.T) {
 		t.Errorf("bad lease count. expected %d, got %d", expectedNumLeases, numLeases)
 	}
 }
+
+// TestExpiration_countCredentials verifies that the leases of secrets are
+// counted by mount and age as they are registered and revoked.
+func TestExpiration_countCredentials(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	exp := c.expiration
+	ctx := namespace.RootContext(nil)
+
+	backends := []*backend{
+		{
+			path: "database/",
+			ns:   namespace.RootNamespace,
+		},
+		{
+			path: "aws/",
+			ns:   namespace.RootNamespace,
+		},
+	}
+	if _, err := mountNoopBackends(c, backends); err != nil {
+		t.Fatal(err)
+	}
+
+	registerLease := func(path string) string {
+		req := &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        path,
+			ClientToken: "sometoken",
+		}
+		req.SetTokenEntry(&logical.TokenEntry{ID: "sometoken", NamespaceID: "root"})
+		resp := &logical.Response{
+			Secret: &logical.Secret{
+				LeaseOptions: logical.LeaseOptions{
+					TTL: 10 * time.Hour,
+				},
+			},
+		}
+		leaseID, err := exp.Register(ctx, req, resp, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return leaseID
+	}
+
+	dbLease := registerLease("database/creds/app")
+	registerLease("database/creds/app")
+	registerLease("aws/creds/app")
+
+	// Leases issued before the tracker saw them, such as when restored, are
+	// counted by the time they were issued
+	dbAccessor := c.router.MatchingMountEntry(ctx, "database/").Accessor
+	exp.credentialAges.add("database/creds/app/old", dbAccessor, time.Now().Add(-10*24*time.Hour))
+
+	findMount := func(counts *CredentialCounts, path string) *MountCredentialCounts {
+		t.Helper()
+		for _, mount := range counts.Mounts {
+			if mount.Path == path {
+				return mount
+			}
+		}
+		t.Fatalf("mount %q not found in %#v", path, counts.Mounts)
+		return nil
+	}
+
+	counts, err := c.countCredentials(ctx)
+	require.NoError(t, err)
+	require.Equal(t, CredentialAgeCounter{Total: 3, UnderOneDay: 2, SevenToThirtyDays: 1}, findMount(counts, "database/").CredentialAgeCounter)
+	require.Equal(t, CredentialAgeCounter{Total: 1, UnderOneDay: 1}, findMount(counts, "aws/").CredentialAgeCounter)
+	require.Equal(t, CredentialAgeCounter{Total: 4, UnderOneDay: 3, SevenToThirtyDays: 1}, counts.Total)
+
+	// Revoked leases are no longer counted, and counting a lease twice has
+	// no effect
+	require.NoError(t, exp.Revoke(ctx, dbLease))
+	exp.credentialAges.add("database/creds/app/old", dbAccessor, time.Now())
+	counts, err = c.countCredentials(ctx)
+	require.NoError(t, err)
+	require.Equal(t, CredentialAgeCounter{Total: 2, UnderOneDay: 1, SevenToThirtyDays: 1}, findMount(counts, "database/").CredentialAgeCounter)
+
+	// Credentials are counted again from the leases restored after a restart
+	exp.credentialAges.remove("database/creds/app/old")
+	require.NoError(t, c.stopExpiration())
+	require.NoError(t, c.setupExpiration(expireLeaseStrategyFairsharing))
+	require.Eventually(t, func() bool {
+		return !c.expiration.inRestoreMode()
+	}, 10*time.Second, 10*time.Millisecond)
+	counts, err = c.countCredentials(ctx)
+	require.NoError(t, err)
+	require.Equal(t, CredentialAgeCounter{Total: 2, UnderOneDay: 2}, counts.Total)
+}
@@ -0,0 +1,314 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+)
+
+const (
+	// leaseUsageSamples is the number of lease lifetimes kept per mount and
+	// role to compute recommendations from.
+	leaseUsageSamples = 1000
+
+	// maxLeaseUsageGroups bounds the number of mount and role pairs tracked,
+	// leases of new pairs are not tracked once it is reached.
+	maxLeaseUsageGroups = 10000
+
+	// leaseUsageHeadroom is the factor applied to observed lifetimes to
+	// compute recommended TTLs.
+	leaseUsageHeadroom = 1.25
+)
+
+// leaseUsageTracker tracks how long the leases of each mount and role are used
+// before they are revoked or expire, to recommend tighter TTLs. Usage is only
+// tracked in memory, from when the expiration manager was set up.
+type leaseUsageTracker struct {
+	lock   sync.Mutex
+	groups map[string]*leaseUsageGroup
+}
+
+// leaseUsageGroup is the usage of the leases of a mount and role.
+type leaseUsageGroup struct {
+	namespace     *namespace.Namespace
+	mountPath     string
+	mountAccessor string
+	role          string
+
+	ended   int
+	revoked int
+	renewed int
+
+	// lifetimes is a ring buffer of the lifetimes of the last leases which
+	// ended, next is the index of the oldest lifetime once it is full.
+	lifetimes []time.Duration
+	next      int
+
+	// ttl and maxTTL are those of the last lease which ended.
+	ttl    time.Duration
+	maxTTL time.Duration
+}
+
+// leaseUsageRecommendation is the usage of the leases of a mount and role, with
+// the TTLs recommended for them.
+type leaseUsageRecommendation struct {
+	namespace         *namespace.Namespace
+	mountPath         string
+	mountAccessor     string
+	role              string
+	ended             int
+	revoked           int
+	renewed           int
+	lifetime          time.Duration
+	maxLifetime       time.Duration
+	ttl               time.Duration
+	maxTTL            time.Duration
+	recommendedTTL    time.Duration
+	recommendedMaxTTL time.Duration
+	message           string
+}
+
+func newLeaseUsageTracker() *leaseUsageTracker {
+	return &leaseUsageTracker{
+		groups: make(map[string]*leaseUsageGroup),
+	}
+}
+
+// recordLeaseUsage records the lifetime of a lease which was revoked, or
+// expired if expired is true.
+func (m *ExpirationManager) recordLeaseUsage(ctx context.Context, le *leaseEntry, expired bool) {
+	if le.namespace == nil || le.IssueTime.IsZero() || le.ExpireTime.IsZero() {
+		return
+	}
+
+	nsCtx := namespace.ContextWithNamespace(ctx, le.namespace)
+	mount := m.router.MatchingMountEntry(nsCtx, le.Path)
+	if mount == nil {
+		return
+	}
+
+	// Secrets are grouped by the path they were created on within the mount,
+	// such as creds/readonly, and tokens by the role they logged in with.
+	mountPath := mount.APIPathNoNamespace()
+	role := strings.TrimPrefix(le.Path, mountPath)
+	var maxTTL time.Duration
+	switch {
+	case le.Auth != nil:
+		role = le.LoginRole
+		maxTTL = le.Auth.MaxTTL
+	case le.Secret != nil:
+		maxTTL = le.Secret.MaxTTL
+	}
+	if sysView := m.router.MatchingSystemView(nsCtx, le.Path); sysView != nil {
+		if mountMax := sysView.MaxLeaseTTL(); maxTTL == 0 || mountMax < maxTTL {
+			maxTTL = mountMax
+		}
+	}
+
+	// The TTL is the duration the lease was last issued or renewed for.
+	granted := le.IssueTime
+	if !le.LastRenewalTime.IsZero() {
+		granted = le.LastRenewalTime
+	}
+	ttl := le.ExpireTime.Sub(granted).Round(time.Second)
+
+	lifetime := time.Since(le.IssueTime)
+	if expired || lifetime > le.ExpireTime.Sub(le.IssueTime) {
+		lifetime = le.ExpireTime.Sub(le.IssueTime)
+	}
+
+	m.leaseUsage.record(le.namespace, mount.Accessor, mountPath, role, lifetime, ttl, maxTTL, expired, !le.LastRenewalTime.IsZero())
+}
+
+func (t *leaseUsageTracker) record(ns *namespace.Namespace, mountAccessor, mountPath, role string, lifetime, ttl, maxTTL time.Duration, expired, renewed bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	key := mountAccessor + "/" + role
+	group, ok := t.groups[key]
+	if !ok {
+		if len(t.groups) >= maxLeaseUsageGroups {
+			return
+		}
+		group = &leaseUsageGroup{
+			namespace:     ns,
+			mountAccessor: mountAccessor,
+			role:          role,
+		}
+		t.groups[key] = group
+	}
+
+	// The mount could have been moved since the group was created
+	group.mountPath = mountPath
+	group.ttl = ttl
+	group.maxTTL = maxTTL
+
+	group.ended++
+	if !expired {
+		group.revoked++
+	}
+	if renewed {
+		group.renewed++
+	}
+
+	if len(group.lifetimes) < leaseUsageSamples {
+		group.lifetimes = append(group.lifetimes, lifetime)
+		return
+	}
+	group.lifetimes[group.next] = lifetime
+	group.next = (group.next + 1) % leaseUsageSamples
+}
+
+// removeMount stops tracking the usage of the leases of a mount which was
+// disabled.
+func (t *leaseUsageTracker) removeMount(mountAccessor string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for key, group := range t.groups {
+		if group.mountAccessor == mountAccessor {
+			delete(t.groups, key)
+		}
+	}
+}
+
+// recommendations returns the usage of the mounts and roles of the namespaces
+// with at least minLeases leases which ended, sorted by mount and role. The TTL
+// recommended for them covers the lifetime of the given percentile of their
+// leases, and the max TTL the lifetime of all of them.
+func (t *leaseUsageTracker) recommendations(matches func(*namespace.Namespace) bool, percentile float64, minLeases int) []*leaseUsageRecommendation {
+	t.lock.Lock()
+	var groups []leaseUsageGroup
+	for _, group := range t.groups {
+		if group.ended < minLeases || !matches(group.namespace) {
+			continue
+		}
+		g := *group
+		g.lifetimes = append([]time.Duration(nil), group.lifetimes...)
+		groups = append(groups, g)
+	}
+	t.lock.Unlock()
+
+	recommendations := make([]*leaseUsageRecommendation, 0, len(groups))
+	for _, group := range groups {
+		sort.Slice(group.lifetimes, func(i, j int) bool { return group.lifetimes[i] < group.lifetimes[j] })
+
+		rank := int(math.Ceil(percentile/100*float64(len(group.lifetimes)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+
+		r := &leaseUsageRecommendation{
+			namespace:     group.namespace,
+			mountPath:     group.mountPath,
+			mountAccessor: group.mountAccessor,
+			role:          group.role,
+			ended:         group.ended,
+			revoked:       group.revoked,
+			renewed:       group.renewed,
+			lifetime:      group.lifetimes[rank],
+			maxLifetime:   group.lifetimes[len(group.lifetimes)-1],
+			ttl:           group.ttl,
+			maxTTL:        group.maxTTL,
+		}
+
+		if ttl := recommendedLeaseTTL(r.lifetime); ttl < r.ttl {
+			r.recommendedTTL = ttl
+			r.message = fmt.Sprintf("%s%% of leases on this role ended within %s but the TTL is %s",
+				formatPercentile(percentile), r.lifetime.Round(time.Second), r.ttl)
+		}
+		if maxTTL := recommendedLeaseTTL(r.maxLifetime); r.maxTTL > 0 && maxTTL < r.maxTTL {
+			r.recommendedMaxTTL = maxTTL
+			if r.message == "" {
+				r.message = fmt.Sprintf("all leases on this role ended within %s but the max TTL is %s",
+					r.maxLifetime.Round(time.Second), r.maxTTL)
+			}
+		}
+
+		recommendations = append(recommendations, r)
+	}
+
+	sort.Slice(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.namespace.Path+a.mountPath != b.namespace.Path+b.mountPath {
+			return a.namespace.Path+a.mountPath < b.namespace.Path+b.mountPath
+		}
+		return a.role < b.role
+	})
+
+	return recommendations
+}
+
+// recommendedLeaseTTL returns the TTL recommended for leases used for the given
+// lifetime, with some headroom and rounded up to the minute.
+func recommendedLeaseTTL(lifetime time.Duration) time.Duration {
+	ttl := time.Duration(float64(lifetime) * leaseUsageHeadroom)
+	if rounded := ttl.Truncate(time.Minute); rounded != ttl {
+		ttl = rounded + time.Minute
+	}
+	if ttl < time.Minute {
+		ttl = time.Minute
+	}
+	return ttl
+}
+
+func formatPercentile(percentile float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", percentile), "0"), ".")
+}
+
+// getLeaseRecommendations returns the usage and recommended TTLs of the leases
+// of the mounts and roles of the request namespace, and its children if
+// includeChildNamespaces is true.
+func (m *ExpirationManager) getLeaseRecommendations(ctx context.Context, includeChildNamespaces bool, percentile float64, minLeases int) (map[string]interface{}, error) {
+	requestNS, err := namespace.FromContext(ctx)
+	if err != nil {
+		m.logger.Error("could not get namespace from context", "error", err)
+		return nil, err
+	}
+
+	matches := func(ns *namespace.Namespace) bool {
+		return ns.ID == requestNS.ID || (includeChildNamespaces && ns.HasParent(requestNS))
+	}
+
+	recommendations := m.leaseUsage.recommendations(matches, percentile, minLeases)
+	result := make([]map[string]interface{}, 0, len(recommendations))
+	for _, r := range recommendations {
+		// The accessors of disabled mounts are not reused
+		if m.router.MatchingMountByAccessor(r.mountAccessor) == nil {
+			m.leaseUsage.removeMount(r.mountAccessor)
+			continue
+		}
+
+		entry := map[string]interface{}{
+			"mount":               r.namespace.Path + r.mountPath,
+			"mount_accessor":      r.mountAccessor,
+			"role":                r.role,
+			"leases":              r.ended,
+			"revoked":             r.revoked,
+			"expired":             r.ended - r.revoked,
+			"renewed":             r.renewed,
+			"lifetime_percentile": int64(r.lifetime.Seconds()),
+			"max_lifetime":        int64(r.maxLifetime.Seconds()),
+			"ttl":                 int64(r.ttl.Seconds()),
+			"max_ttl":             int64(r.maxTTL.Seconds()),
+			"recommended_ttl":     int64(r.recommendedTTL.Seconds()),
+			"recommended_max_ttl": int64(r.recommendedMaxTTL.Seconds()),
+			"recommendation":      r.message,
+		}
+		result = append(result, entry)
+	}
+
+	return map[string]interface{}{
+		"percentile":      percentile,
+		"recommendations": result,
+	}, nil
+}
@@ -20,14 +20,14 @@ func (m *ExpirationManager) tokenIndexView(*namespace.Namespace) *BarrierView {
 	return m.tokenView
 }
 
-func (m *ExpirationManager) collectLeases() (map[*namespace.Namespace][]string, int, error) {
-	leaseCount := 0
-	existing := make(map[*namespace.Namespace][]string)
-	keys, err := logical.CollectKeys(m.quitContext, m.leaseView(namespace.RootNamespace))
+// scanLeases calls cb with each lease in storage, until it returns false. The
+// lease IDs are listed a page at a time.
+func (m *ExpirationManager) scanLeases(cb func(ns *namespace.Namespace, leaseID string) bool) error {
+	err := logical.ScanViewPaginated(m.quitContext, m.leaseView(namespace.RootNamespace), leaseListPageSize, func(leaseID string) bool {
+		return cb(namespace.RootNamespace, leaseID)
+	})
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to scan for leases: %w", err)
+		return fmt.Errorf("failed to scan for leases: %w", err)
 	}
-	existing[namespace.RootNamespace] = keys
-	leaseCount += len(keys)
-	return existing, leaseCount, nil
+	return nil
 }
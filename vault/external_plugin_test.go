@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -846,6 +847,53 @@ func TestBackend_PluginMain_Multiplexed_Credential_v123(t *testing.T) {
 	}
 }
 
+// TestCore_EnableExternalPlugin_ProcessRuntime tests that plugins registered
+// with a process runtime run within its resource limits and report their
+// health.
+func TestCore_EnableExternalPlugin_ProcessRuntime(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("resource limits are not supported on windows")
+	}
+	c, plugins := testCoreWithPlugins(t, consts.PluginTypeSecrets, "")
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "plugins/runtimes/catalog/process/limited")
+	req.Data = map[string]interface{}{
+		"memory_bytes": 4 << 30,
+		"max_restarts": 3,
+	}
+	resp, err := c.systemBackend.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, fmt.Sprintf("plugins/catalog/secret/%s", plugins[0].Name))
+	req.Data = map[string]interface{}{
+		"command": plugins[0].FileName,
+		"sha256":  plugins[0].Sha256,
+		"runtime": "limited",
+	}
+	resp, err = c.systemBackend.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	mountPlugin(t, c.systemBackend, plugins[0].Name, consts.PluginTypeSecrets, "", "")
+
+	req = logical.TestRequest(t, logical.ListOperation, "foo/roles/")
+	if _, err := c.router.Route(namespace.RootContext(nil), req); err != nil {
+		t.Fatal(err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "mounts/foo")
+	resp, err = c.systemBackend.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	health, ok := resp.Data["plugin_health"].(map[string]interface{})
+	if !ok || health["restarts"] != 0 || health["restart_limit_exceeded"] != false {
+		t.Fatalf("bad plugin_health: %#v", resp.Data["plugin_health"])
+	}
+}
+
 func registerPlugin(t *testing.T, sys *SystemBackend, pluginName, pluginType, version, sha, command string) {
 	t.Helper()
 	req := logical.TestRequest(t, logical.UpdateOperation, fmt.Sprintf("plugins/catalog/%s/%s", pluginType, pluginName))
@@ -4,6 +4,7 @@
 package quotas
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -542,3 +543,175 @@ func TestQuotas_RateLimitQuota(t *testing.T) {
 		t.Fatalf("unexpected number of failed requests: %d", numFail)
 	}
 }
+
+// TestQuotas_RateLimitQuota_GroupByEntity verifies that a rate limit quota
+// grouping by entity throttles a single identity without affecting other
+// identities making requests from the same address.
+func TestQuotas_RateLimitQuota_GroupByEntity(t *testing.T) {
+	conf, opts := teststorage.ClusterSetup(coreConfig, nil, nil)
+	opts.NoDefaultQuotas = true
+	cluster := vault.NewTestCluster(t, conf, opts)
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0].Core
+	client := cluster.Cores[0].Client
+	vault.TestWaitActive(t, core)
+
+	err := client.Sys().EnableAuthWithOptions("userpass", &api.EnableAuthOptions{
+		Type: "userpass",
+	})
+	require.NoError(t, err)
+
+	var clients []*api.Client
+	for _, user := range []string{"foo", "bar"} {
+		_, err = client.Logical().Write("auth/userpass/users/"+user, map[string]interface{}{
+			"password": "baz",
+		})
+		require.NoError(t, err)
+
+		secret, err := client.Logical().Write("auth/userpass/login/"+user, map[string]interface{}{
+			"password": "baz",
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, secret.Auth.EntityID)
+
+		userClient, err := client.Clone()
+		require.NoError(t, err)
+		userClient.SetToken(secret.Auth.ClientToken)
+		clients = append(clients, userClient)
+	}
+
+	_, err = client.Logical().Write("sys/quotas/rate-limit/rlq", map[string]interface{}{
+		"rate":     1,
+		"interval": "1m",
+		"path":     "auth/token/",
+		"group_by": "entity_then_ip",
+	})
+	require.NoError(t, err)
+
+	secret, err := client.Logical().Read("sys/quotas/rate-limit/rlq")
+	require.NoError(t, err)
+	require.Equal(t, "entity_then_ip", secret.Data["group_by"])
+
+	// The first entity exhausts its own rate limit.
+	_, err = clients[0].Auth().Token().LookupSelf()
+	require.NoError(t, err)
+	_, err = clients[0].Auth().Token().LookupSelf()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "429")
+
+	// The second entity is unaffected, even though it shares the address.
+	_, err = clients[1].Auth().Token().LookupSelf()
+	require.NoError(t, err)
+
+	_, err = client.Logical().Write("sys/quotas/rate-limit/rlq", map[string]interface{}{
+		"rate":     1,
+		"path":     "auth/token/",
+		"group_by": "entity",
+	})
+	require.Error(t, err)
+}
+
+func TestQuotas_Concurrency_CRUD(t *testing.T) {
+	conf, opts := teststorage.ClusterSetup(coreConfig, nil, nil)
+	opts.NoDefaultQuotas = true
+	cluster := vault.NewTestCluster(t, conf, opts)
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0].Core
+	client := cluster.Cores[0].Client
+	vault.TestWaitActive(t, core)
+
+	err := client.Sys().Mount("pki", &api.MountInput{
+		Type: "pki",
+	})
+	require.NoError(t, err)
+
+	// Concurrency quotas must target a mount
+	_, err = client.Logical().Write("sys/quotas/concurrency/cq", map[string]interface{}{
+		"max_in_flight": 4,
+	})
+	require.Error(t, err)
+
+	_, err = client.Logical().Write("sys/quotas/concurrency/cq", map[string]interface{}{
+		"path":          "pki/issue/*",
+		"max_in_flight": 4,
+		"queue_timeout": "5s",
+	})
+	require.NoError(t, err)
+
+	secret, err := client.Logical().Read("sys/quotas/concurrency/cq")
+	require.NoError(t, err)
+	require.Equal(t, "pki/issue/*", secret.Data["path"])
+	require.Equal(t, "concurrency", secret.Data["type"])
+	require.EqualValues(t, "4", secret.Data["max_in_flight"].(json.Number).String())
+	require.EqualValues(t, "5", secret.Data["queue_timeout"].(json.Number).String())
+
+	secret, err = client.Logical().List("sys/quotas/concurrency")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"cq"}, secret.Data["keys"])
+
+	// Requests within the limit are unaffected
+	_, err = client.Logical().List("pki/issue")
+	require.NoError(t, err)
+
+	_, err = client.Logical().Delete("sys/quotas/concurrency/cq")
+	require.NoError(t, err)
+
+	secret, err = client.Logical().Read("sys/quotas/concurrency/cq")
+	require.NoError(t, err)
+	require.Nil(t, secret)
+}
+
+// TestQuotas_RateLimitQuota_ExemptPolicies verifies that requests made with a
+// token carrying an exempt policy are not subject to the rate limit quota.
+func TestQuotas_RateLimitQuota_ExemptPolicies(t *testing.T) {
+	conf, opts := teststorage.ClusterSetup(coreConfig, nil, nil)
+	opts.NoDefaultQuotas = true
+	cluster := vault.NewTestCluster(t, conf, opts)
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0].Core
+	client := cluster.Cores[0].Client
+	vault.TestWaitActive(t, core)
+
+	newClient := func(policies ...string) *api.Client {
+		secret, err := client.Auth().Token().Create(&api.TokenCreateRequest{
+			Policies: policies,
+		})
+		require.NoError(t, err)
+
+		c, err := client.Clone()
+		require.NoError(t, err)
+		c.SetToken(secret.Auth.ClientToken)
+		return c
+	}
+	automation := newClient("automation")
+	tenant := newClient("tenant")
+
+	_, err := client.Logical().Write("sys/quotas/rate-limit/rlq", map[string]interface{}{
+		"rate":            1,
+		"interval":        "1m",
+		"path":            "auth/token/",
+		"exempt_policies": "automation",
+	})
+	require.NoError(t, err)
+
+	secret, err := client.Logical().Read("sys/quotas/rate-limit/rlq")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"automation"}, secret.Data["exempt_policies"])
+
+	_, err = tenant.Auth().Token().LookupSelf()
+	require.NoError(t, err)
+	_, err = tenant.Auth().Token().LookupSelf()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "429")
+
+	for i := 0; i < 5; i++ {
+		_, err = automation.Auth().Token().LookupSelf()
+		require.NoError(t, err)
+	}
+}
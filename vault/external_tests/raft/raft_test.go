@@ -7,6 +7,8 @@ import (
 	"bytes"
 	"context"
 	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -24,6 +26,7 @@ import (
 	"github.com/hashicorp/vault/helper/benchhelpers"
 	"github.com/hashicorp/vault/helper/constants"
 	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/pgpkeys"
 	"github.com/hashicorp/vault/helper/testhelpers"
 	"github.com/hashicorp/vault/helper/testhelpers/corehelpers"
 	"github.com/hashicorp/vault/helper/testhelpers/teststorage"
@@ -409,6 +412,75 @@ func TestRaft_NodeIDHeader(t *testing.T) {
 	}
 }
 
+// TestRaft_IndexStateHeader verifies that writes return the raft index they
+// were applied at in the X-Vault-Index header, and that requests requiring an
+// index state not yet applied fail with a 412.
+func TestRaft_IndexStateHeader(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, nil)
+	defer cluster.Cleanup()
+
+	leaderCore := cluster.Cores[0]
+	client := leaderCore.Client
+	client.SetMaxRetries(0)
+
+	req := client.NewRequest("PUT", "/v1/sys/policies/acl/test")
+	require.NoError(t, req.SetJSONBody(map[string]interface{}{
+		"policy": `path "secret/*" { capabilities = ["read"] }`,
+	}))
+	resp, err := client.RawRequest(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	header := resp.Header.Get(api.HeaderIndex)
+	require.NotEmpty(t, header)
+	state, err := api.ParseReplicationState(header, leaderCore.IndexHeaderHMACKey.Load().([]byte))
+	require.NoError(t, err)
+	require.Equal(t, leaderCore.ClusterID(), state.ClusterID)
+	require.NotZero(t, state.LocalIndex)
+	require.LessOrEqual(t, state.LocalIndex, leaderCore.UnderlyingRawStorage.(*raft.RaftBackend).AppliedIndex())
+
+	// Reads do not return an index state
+	resp, err = client.RawRequest(client.NewRequest("GET", "/v1/sys/policies/acl/test"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Empty(t, resp.Header.Get(api.HeaderIndex))
+
+	read := func(c *api.Client, states ...string) (int, error) {
+		req := c.NewRequest("GET", "/v1/sys/policies/acl/test")
+		for _, s := range states {
+			req.Headers.Add(api.HeaderIndex, s)
+		}
+		resp, err := c.RawRequest(req)
+		if resp != nil {
+			resp.Body.Close()
+			return resp.StatusCode, err
+		}
+		return 0, err
+	}
+
+	// The state of the write is present on the leader, and standbys forward
+	// the requests to it
+	for _, core := range cluster.Cores {
+		core.Client.SetMaxRetries(0)
+		code, err := read(core.Client, header)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+	}
+
+	// States of other clusters and invalid states are ignored
+	otherCluster := leaderCore.IndexStateHeader(&logical.WALState{ClusterID: "other", LocalIndex: state.LocalIndex + 1000})
+	code, err := read(client, otherCluster, "invalid")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, code)
+
+	// States not yet applied are missing
+	future := leaderCore.IndexStateHeader(&logical.WALState{ClusterID: state.ClusterID, LocalIndex: state.LocalIndex + 1000})
+	code, err = read(client, header, future)
+	require.Error(t, err)
+	require.Equal(t, http.StatusPreconditionFailed, code)
+}
+
 func TestRaft_Configuration(t *testing.T) {
 	t.Parallel()
 	cluster, _ := raftCluster(t, nil)
@@ -485,6 +557,114 @@ func TestRaft_SnapshotAPI(t *testing.T) {
 	}
 }
 
+// TestRaft_SnapshotAPI_PGPKeys verifies that a snapshot encrypted to PGP keys
+// can be decrypted with any of their private keys and restored.
+func TestRaft_SnapshotAPI_PGPKeys(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, &RaftClusterOpts{
+		NumCores:     1,
+		InmemCluster: true,
+	})
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+
+	_, err := client.Logical().Write("secret/foo", map[string]interface{}{"test": "data"})
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	err = client.Sys().RaftSnapshotEncrypted(buf, []string{pgpkeys.TestPubKey1, pgpkeys.TestPubKey2})
+	require.NoError(t, err)
+
+	// The snapshot is not a gzipped archive until it is decrypted
+	err = client.Sys().RaftSnapshotRestore(bytes.NewReader(buf.Bytes()), false)
+	require.Error(t, err)
+
+	snap, err := pgpkeys.DecryptBytes(base64.StdEncoding.EncodeToString(buf.Bytes()), pgpkeys.TestPrivKey2)
+	require.NoError(t, err)
+
+	_, err = client.Logical().Delete("secret/foo")
+	require.NoError(t, err)
+
+	err = client.Sys().RaftSnapshotRestore(snap, false)
+	require.NoError(t, err)
+
+	secret, err := client.Logical().Read("secret/foo")
+	require.NoError(t, err)
+	require.NotNil(t, secret)
+	require.Equal(t, "data", secret.Data["test"])
+
+	err = client.Sys().RaftSnapshotEncrypted(new(bytes.Buffer), []string{"not a key"})
+	require.ErrorContains(t, err, "invalid pgp_keys")
+}
+
+// TestRaft_SnapshotAPI_RestorePath verifies that a single KV secret can be
+// restored from a snapshot without rolling back any other data.
+func TestRaft_SnapshotAPI_RestorePath(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, &RaftClusterOpts{
+		NumCores:     1,
+		InmemCluster: true,
+	})
+	defer cluster.Cleanup()
+
+	ctx := context.Background()
+	client := cluster.Cores[0].Client
+
+	err := client.Sys().Mount("kv", &api.MountInput{
+		Type:    "kv",
+		Options: map[string]string{"version": "2"},
+	})
+	require.NoError(t, err)
+	kv := client.KVv2("kv")
+
+	// Mounting a KV v2 engine upgrades it in the background
+	corehelpers.RetryUntil(t, 10*time.Second, func() error {
+		_, err := kv.Put(ctx, "app/db", map[string]interface{}{"password": "one"})
+		return err
+	})
+	_, err = kv.Put(ctx, "app/db", map[string]interface{}{"password": "two"})
+	require.NoError(t, err)
+	_, err = kv.Put(ctx, "other", map[string]interface{}{"foo": "bar"})
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, client.Sys().RaftSnapshot(buf))
+	snap := buf.Bytes()
+
+	require.NoError(t, kv.Destroy(ctx, "app/db", []int{1, 2}))
+	_, err = kv.Put(ctx, "app/db", map[string]interface{}{"password": "three"})
+	require.NoError(t, err)
+	_, err = kv.Put(ctx, "other", map[string]interface{}{"foo": "baz"})
+	require.NoError(t, err)
+
+	secret, err := client.Sys().RaftSnapshotRestorePath(bytes.NewReader(snap), "kv", "app/db")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{json.Number("1"), json.Number("2")}, secret.Data["restored_versions"])
+
+	for version, password := range map[int]string{1: "one", 2: "two", 3: "three"} {
+		s, err := kv.GetVersion(ctx, "app/db", version)
+		require.NoError(t, err)
+		require.Equal(t, password, s.Data["password"])
+	}
+	s, err := kv.Get(ctx, "app/db")
+	require.NoError(t, err)
+	require.Equal(t, 3, s.VersionMetadata.Version)
+
+	// Nothing else was rolled back
+	s, err = kv.Get(ctx, "other")
+	require.NoError(t, err)
+	require.Equal(t, "baz", s.Data["foo"])
+
+	// Restoring again is a no-op
+	secret, err = client.Sys().RaftSnapshotRestorePath(bytes.NewReader(snap), "kv", "app/db")
+	require.NoError(t, err)
+	require.Empty(t, secret.Data["restored_versions"])
+
+	_, err = client.Sys().RaftSnapshotRestorePath(bytes.NewReader(snap), "kv", "missing")
+	require.Error(t, err)
+}
+
 func TestRaft_SnapshotAPI_MidstreamFailure(t *testing.T) {
 	// defer goleak.VerifyNone(t)
 	t.Parallel()
@@ -9,11 +9,16 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	sockaddr "github.com/hashicorp/go-sockaddr"
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/helper/thresholdunseal"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/roottoken"
+	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/shamir"
 )
 
@@ -53,7 +58,7 @@ func (g generateStandardRootToken) authenticate(ctx context.Context, c *Core, co
 }
 
 func (g generateStandardRootToken) generate(ctx context.Context, c *Core) (string, func(), error) {
-	te, err := c.tokenStore.rootToken(ctx)
+	te, err := c.tokenStore.generatedRootToken(ctx, c.generateRootTokenConfig, c.generateRootConfig.Justification)
 	if err != nil {
 		c.logger.Error("root token generation failed", "error", err)
 		return "", nil, err
@@ -64,12 +69,85 @@ func (g generateStandardRootToken) generate(ctx context.Context, c *Core) (strin
 	}
 
 	cleanupFunc := func() {
-		c.tokenStore.revokeOrphan(ctx, te.ID)
+		// Batch tokens cannot be revoked, but expire
+		if te.Type != logical.TokenTypeBatch {
+			c.tokenStore.revokeOrphan(ctx, te.ID)
+		}
+	}
+
+	if err := c.auditGeneratedRoot(ctx, te); err != nil {
+		cleanupFunc()
+		return "", nil, err
 	}
 
+	// Batch tokens are not persisted, so have no separate external ID
+	if te.Type == logical.TokenTypeBatch {
+		return te.ID, cleanupFunc, nil
+	}
 	return te.ExternalID, cleanupFunc, nil
 }
 
+// auditGeneratedRoot records the completion of a root generation, including
+// its justification, in the audit log.
+func (c *Core) auditGeneratedRoot(ctx context.Context, te *logical.TokenEntry) error {
+	if c.auditBroker == nil {
+		return nil
+	}
+
+	requestID, err := uuid.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	req := &logical.Request{
+		ID:        requestID,
+		Operation: logical.UpdateOperation,
+		Path:      "sys/generate-root/update",
+		Data: map[string]interface{}{
+			"nonce": c.generateRootConfig.Nonce,
+		},
+	}
+	auth := &logical.Auth{
+		ClientToken:   te.ID,
+		Accessor:      te.Accessor,
+		DisplayName:   te.DisplayName,
+		Policies:      te.Policies,
+		TokenPolicies: te.Policies,
+		Metadata:      te.Meta,
+		TokenType:     te.Type,
+		BoundCIDRs:    te.BoundCIDRs,
+		LeaseOptions: logical.LeaseOptions{
+			TTL: te.TTL,
+		},
+	}
+
+	if err := c.auditBroker.LogRequest(ctx, &logical.LogInput{Auth: auth, Request: req}); err != nil {
+		c.logger.Error("failed to audit root generation", "error", err)
+		return errors.New("failed to audit root generation, cannot continue")
+	}
+	return nil
+}
+
+// GenerateRootTokenConfig restricts the root tokens produced by root
+// generation, hardening break-glass root access.
+type GenerateRootTokenConfig struct {
+	// TokenType is the type of the generated tokens. Batch tokens cannot be
+	// revoked, so require a TTL.
+	TokenType logical.TokenType
+
+	// TTL is the non-renewable TTL of the generated tokens. Zero means they do
+	// not expire.
+	TTL time.Duration
+
+	// BoundCIDRs restricts the use of the generated tokens to clients in the
+	// given CIDRs. Requires a TTL, as CIDRs are not enforced on non-expiring
+	// root tokens.
+	BoundCIDRs []*sockaddr.SockAddrMarshaler
+
+	// RequireJustification requires a justification when initializing a root
+	// generation.
+	RequireJustification bool
+}
+
 // GenerateRootConfig holds the configuration for a root generation
 // command.
 type GenerateRootConfig struct {
@@ -77,6 +155,7 @@ type GenerateRootConfig struct {
 	PGPKey         string
 	PGPFingerprint string
 	OTP            string
+	Justification  string
 	Strategy       GenerateRootStrategy
 }
 
@@ -132,8 +211,26 @@ func (c *Core) GenerateRootConfiguration() (*GenerateRootConfig, error) {
 	return conf, nil
 }
 
+// GenerateRootJustificationRequired returns whether a justification must be
+// provided when initializing a root generation.
+func (c *Core) GenerateRootJustificationRequired() bool {
+	return c.generateRootTokenConfig != nil && c.generateRootTokenConfig.RequireJustification
+}
+
 // GenerateRootInit is used to initialize the root generation settings
 func (c *Core) GenerateRootInit(otp, pgpKey string, strategy GenerateRootStrategy) error {
+	return c.GenerateRootInitWithJustification(otp, pgpKey, "", strategy)
+}
+
+// GenerateRootInitWithJustification is used to initialize the root generation
+// settings, recording why the root generation is needed. The justification is
+// stored in the metadata of the generated token.
+func (c *Core) GenerateRootInitWithJustification(otp, pgpKey, justification string, strategy GenerateRootStrategy) error {
+	justification = strings.TrimSpace(justification)
+	if justification == "" && c.GenerateRootJustificationRequired() {
+		return fmt.Errorf("justification parameter must be provided")
+	}
+
 	var fingerprint string
 	switch {
 	case len(otp) > 0:
@@ -141,6 +238,11 @@ func (c *Core) GenerateRootInit(otp, pgpKey string, strategy GenerateRootStrateg
 			(len(otp) != TokenLength+OldTokenPrefixLength && c.DisableSSCTokens()) {
 			return fmt.Errorf("OTP string is wrong length")
 		}
+		// Batch tokens are longer than any OTP
+		if _, ok := strategy.(generateStandardRootToken); ok && c.generateRootTokenConfig != nil &&
+			c.generateRootTokenConfig.TokenType == logical.TokenTypeBatch {
+			return fmt.Errorf("pgp_key parameter must be provided to generate batch root tokens")
+		}
 
 	case len(pgpKey) > 0:
 		fingerprints, err := pgpkeys.GetFingerprints([]string{pgpKey}, nil)
@@ -191,17 +293,18 @@ func (c *Core) GenerateRootInit(otp, pgpKey string, strategy GenerateRootStrateg
 		OTP:            otp,
 		PGPKey:         pgpKey,
 		PGPFingerprint: fingerprint,
+		Justification:  justification,
 		Strategy:       strategy,
 	}
 
 	if c.logger.IsInfo() {
 		switch strategy.(type) {
 		case generateStandardRootToken:
-			c.logger.Info("root generation initialized", "nonce", c.generateRootConfig.Nonce)
+			c.logger.Info("root generation initialized", "nonce", c.generateRootConfig.Nonce, "justification", justification)
 		case *generateRecoveryToken:
-			c.logger.Info("recovery token generation initialized", "nonce", c.generateRootConfig.Nonce)
+			c.logger.Info("recovery token generation initialized", "nonce", c.generateRootConfig.Nonce, "justification", justification)
 		default:
-			c.logger.Info("dr operation token generation initialized", "nonce", c.generateRootConfig.Nonce)
+			c.logger.Info("dr operation token generation initialized", "nonce", c.generateRootConfig.Nonce, "justification", justification)
 		}
 	}
 
@@ -300,10 +403,19 @@ func (c *Core) GenerateRootUpdate(ctx context.Context, key []byte, nonce string,
 
 	// Combine the key parts
 	var combinedKey []byte
-	if config.SecretThreshold == 1 {
+	switch {
+	case config.DelegatedUnsealConfig != nil:
+		// The shares of a delegated unseal setup are not Shamir shares of the
+		// unseal key, so recover it through the delegated unseal parameters.
+		combinedKey, err = thresholdunseal.Combine(config.DelegatedUnsealConfig, c.generateRootProgress)
+		c.generateRootProgress = nil
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute unseal key: %w", err)
+		}
+	case config.SecretThreshold == 1:
 		combinedKey = c.generateRootProgress[0]
 		c.generateRootProgress = nil
-	} else {
+	default:
 		combinedKey, err = shamir.Combine(c.generateRootProgress)
 		c.generateRootProgress = nil
 		if err != nil {
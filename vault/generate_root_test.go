@@ -6,11 +6,13 @@ package vault
 import (
 	"encoding/base64"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-secure-stdlib/base62"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/pgpkeys"
 	"github.com/hashicorp/vault/sdk/helper/xor"
+	"github.com/hashicorp/vault/sdk/logical"
 )
 
 func TestCore_GenerateRoot_Lifecycle(t *testing.T) {
@@ -317,3 +319,69 @@ func testCore_GenerateRoot_Update_PGP_Common(t *testing.T, c *Core, keys [][]byt
 		t.Fatalf("bad: %#v", *te)
 	}
 }
+
+func TestCore_GenerateRoot_TokenConfig(t *testing.T) {
+	c, keys, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		GenerateRoot: &GenerateRootTokenConfig{
+			TokenType:            logical.TokenTypeBatch,
+			TTL:                  time.Hour,
+			RequireJustification: true,
+		},
+	})
+
+	// A justification is required
+	if err := c.GenerateRootInit("", pgpkeys.TestPubKey1, GenerateStandardRootTokenStrategy); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Batch tokens cannot be encoded with an OTP
+	otp, err := base62.Random(TokenPrefixLength + TokenLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GenerateRootInitWithJustification(otp, "", "INC-1234", GenerateStandardRootTokenStrategy); err == nil {
+		t.Fatal("expected error")
+	}
+
+	err = c.GenerateRootInitWithJustification("", pgpkeys.TestPubKey1, "INC-1234", GenerateStandardRootTokenStrategy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rkconf, err := c.GenerateRootConfiguration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rkconf.Justification != "INC-1234" {
+		t.Fatalf("bad: justification %q", rkconf.Justification)
+	}
+
+	var result *GenerateRootResult
+	for _, key := range keys {
+		result, err = c.GenerateRootUpdate(namespace.RootContext(nil), key, rkconf.Nonce, GenerateStandardRootTokenStrategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.EncodedToken != "" {
+			break
+		}
+	}
+
+	ptBuf, err := pgpkeys.DecryptBytes(result.EncodedToken, pgpkeys.TestPrivKey1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	te, err := c.tokenStore.Lookup(namespace.RootContext(nil), ptBuf.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if te == nil {
+		t.Fatal("token was nil")
+	}
+	if te.Type != logical.TokenTypeBatch || te.TTL != time.Hour || te.NumUses != 0 ||
+		len(te.Policies) != 1 || te.Policies[0] != "root" ||
+		te.Meta["justification"] != "INC-1234" {
+		t.Fatalf("bad: %#v", *te)
+	}
+}
@@ -1014,7 +1014,11 @@ func (c *Core) reloadShamirKey(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("failed to update seal access: %w", err)
 		}
-		shamirKey = keyring.rootKey
+		// The root key of the keyring is destroyed with it, so the seal is
+		// given its own copy
+		shamirKey = make([]byte, len(keyring.RootKey()))
+		copy(shamirKey, keyring.RootKey())
+		keyring.Zeroize(false)
 	}
 	return c.seal.GetAccess().SetShamirSealKey(shamirKey)
 }
@@ -65,6 +65,7 @@ func NewIdentityStore(ctx context.Context, core *Core, config *logical.BackendCo
 		tokenStorer:   core,
 		entityCreator: core,
 		mountLister:   core,
+		eventSender:   core,
 		mfaBackend:    core.loginMFABackend,
 	}
 
@@ -139,9 +140,14 @@ func (i *IdentityStore) paths() []*framework.Path {
 		groupAliasPaths(i),
 		groupPaths(i),
 		lookupPaths(i),
+		duplicatesPaths(i),
+		groupSyncPaths(i),
+		metadataSourcePaths(i),
+		scimPaths(i),
 		upgradePaths(i),
 		oidcPaths(i),
 		oidcProviderPaths(i),
+		oidcRevocationPaths(i),
 		mfaCommonPaths(i),
 		mfaTOTPPaths(i),
 		mfaTOTPExtraPaths(i),
@@ -536,6 +542,14 @@ func mfaLoginEnforcementPaths(i *IdentityStore) []*framework.Path {
 					Type:        framework.TypeStringSlice,
 					Description: "Array of identity entity IDs",
 				},
+				"source_cidrs": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Array of CIDRs. If set, the enforcement only applies to logins from these CIDRs",
+				},
+				"listener_addresses": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Array of listener addresses. If set, the enforcement only applies to logins received on these listeners",
+				},
 			},
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
@@ -0,0 +1,428 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func duplicatesPaths(i *IdentityStore) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "duplicates$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationVerb:   "read",
+				OperationSuffix: "duplicates",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathDuplicatesRead(),
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(duplicatesHelp["duplicates"][0]),
+			HelpDescription: strings.TrimSpace(duplicatesHelp["duplicates"][1]),
+		},
+		{
+			Pattern: "duplicates/merge-plan$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationVerb:   "read",
+				OperationSuffix: "duplicates-merge-plan",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathDuplicatesMergePlanRead(),
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(duplicatesHelp["duplicates-merge-plan"][0]),
+			HelpDescription: strings.TrimSpace(duplicatesHelp["duplicates-merge-plan"][1]),
+		},
+		{
+			Pattern: "duplicates/merge$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationVerb:   "merge",
+				OperationSuffix: "duplicates",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"to_entity_ids": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "IDs of the entities into which duplicates are merged, restricting the merge plan to the merges into those entities. If not set, the whole merge plan is executed.",
+				},
+				"force": {
+					Type:        framework.TypeBool,
+					Description: "Setting this will follow the 'mine' strategy for merging MFA secrets, as for the entity merge API. If not set, merges of entities with conflicting MFA secrets fail.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback:                  i.pathDuplicatesMerge(),
+					ForwardPerformanceStandby: true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(duplicatesHelp["duplicates-merge"][0]),
+			HelpDescription: strings.TrimSpace(duplicatesHelp["duplicates-merge"][1]),
+		},
+	}
+}
+
+// duplicateEntities is a set of entities of a namespace whose names differ by
+// case only.
+type duplicateEntities struct {
+	Name        string   `json:"name"`
+	EntityIDs   []string `json:"entity_ids"`
+	EntityNames []string `json:"entity_names"`
+}
+
+// duplicateAliases is a set of aliases of a mount whose names differ by case
+// only.
+type duplicateAliases struct {
+	Name          string   `json:"name"`
+	MountAccessor string   `json:"mount_accessor"`
+	MountPath     string   `json:"mount_path"`
+	AliasIDs      []string `json:"alias_ids"`
+	AliasNames    []string `json:"alias_names"`
+	EntityIDs     []string `json:"entity_ids"`
+}
+
+// duplicatesMerge is a step of the merge plan for duplicates, merging entities
+// into the oldest one of them. Error is set if executing it failed.
+type duplicatesMerge struct {
+	ToEntityID      string   `json:"to_entity_id"`
+	ToEntityName    string   `json:"to_entity_name"`
+	FromEntityIDs   []string `json:"from_entity_ids"`
+	FromEntityNames []string `json:"from_entity_names"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// pathDuplicatesRead reports the duplicate entities and aliases of the
+// request's namespace
+func (i *IdentityStore) pathDuplicatesRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		entities, aliases, err := i.findDuplicates(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"entities": entities,
+				"aliases":  aliases,
+			},
+		}, nil
+	}
+}
+
+// pathDuplicatesMergePlanRead previews the merges that would resolve the
+// duplicates of the request's namespace
+func (i *IdentityStore) pathDuplicatesMergePlanRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		plan, err := i.duplicatesMergePlan(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"merges": plan,
+			},
+		}, nil
+	}
+}
+
+// pathDuplicatesMerge executes the merge plan for the duplicates of the
+// request's namespace. The merges done are part of the response, and so of
+// its audit record.
+func (i *IdentityStore) pathDuplicatesMerge() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		toEntityIDs := d.Get("to_entity_ids").([]string)
+		force := d.Get("force").(bool)
+
+		i.lock.Lock()
+		defer i.lock.Unlock()
+
+		plan, err := i.duplicatesMergePlan(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		merges := make([]*duplicatesMerge, 0, len(plan))
+		for _, merge := range plan {
+			if len(toEntityIDs) > 0 && !strutil.StrListContains(toEntityIDs, merge.ToEntityID) {
+				continue
+			}
+
+			if err := i.mergeDuplicates(ctx, merge, force); err != nil {
+				i.logger.Error("failed to merge duplicate entities", "to_entity_id", merge.ToEntityID, "from_entity_ids", merge.FromEntityIDs, "error", err)
+				merge.Error = err.Error()
+			} else {
+				i.logger.Info("merged duplicate entities", "to_entity_id", merge.ToEntityID, "from_entity_ids", merge.FromEntityIDs)
+			}
+			merges = append(merges, merge)
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"merges": merges,
+			},
+		}, nil
+	}
+}
+
+// findDuplicates returns the entities and the aliases of the context's
+// namespace whose names differ by case only, ordered by name. These can only
+// exist once the identity store operates on case sensitive names.
+func (i *IdentityStore) findDuplicates(ctx context.Context) ([]*duplicateEntities, []*duplicateAliases, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	txn := i.db.Txn(false)
+
+	entitiesIter, err := txn.Get(entitiesTable, "namespace_id", ns.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch entities: %w", err)
+	}
+
+	entitiesByName := make(map[string]*duplicateEntities)
+	for raw := entitiesIter.Next(); raw != nil; raw = entitiesIter.Next() {
+		entity := raw.(*identity.Entity)
+		name := strings.ToLower(entity.Name)
+		dup, ok := entitiesByName[name]
+		if !ok {
+			dup = &duplicateEntities{Name: name}
+			entitiesByName[name] = dup
+		}
+		dup.EntityIDs = append(dup.EntityIDs, entity.ID)
+		dup.EntityNames = append(dup.EntityNames, entity.Name)
+	}
+
+	aliasesIter, err := txn.Get(entityAliasesTable, "namespace_id", ns.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch aliases: %w", err)
+	}
+
+	aliasesByFactors := make(map[string]*duplicateAliases)
+	for raw := aliasesIter.Next(); raw != nil; raw = aliasesIter.Next() {
+		alias := raw.(*identity.Alias)
+		name := strings.ToLower(alias.Name)
+		factors := alias.MountAccessor + "/" + name
+		dup, ok := aliasesByFactors[factors]
+		if !ok {
+			dup = &duplicateAliases{
+				Name:          name,
+				MountAccessor: alias.MountAccessor,
+			}
+			aliasesByFactors[factors] = dup
+		}
+		dup.AliasIDs = append(dup.AliasIDs, alias.ID)
+		dup.AliasNames = append(dup.AliasNames, alias.Name)
+		dup.EntityIDs = append(dup.EntityIDs, alias.CanonicalID)
+	}
+
+	entities := make([]*duplicateEntities, 0)
+	for _, dup := range entitiesByName {
+		if len(dup.EntityIDs) > 1 {
+			entities = append(entities, dup)
+		}
+	}
+	sort.Slice(entities, func(a, b int) bool {
+		return entities[a].Name < entities[b].Name
+	})
+
+	aliases := make([]*duplicateAliases, 0)
+	for _, dup := range aliasesByFactors {
+		if len(dup.AliasIDs) < 2 {
+			continue
+		}
+		if mountValidationResp := i.router.ValidateMountByAccessor(dup.MountAccessor); mountValidationResp != nil {
+			dup.MountPath = mountValidationResp.MountPath
+		}
+		aliases = append(aliases, dup)
+	}
+	sort.Slice(aliases, func(a, b int) bool {
+		if aliases[a].MountAccessor != aliases[b].MountAccessor {
+			return aliases[a].MountAccessor < aliases[b].MountAccessor
+		}
+		return aliases[a].Name < aliases[b].Name
+	})
+
+	return entities, aliases, nil
+}
+
+// duplicatesMergePlan returns the merges which resolve the duplicates of the
+// context's namespace. Entities which are duplicates of each other, or which
+// hold duplicate aliases, are merged into the oldest one of them. Where the
+// entities merged hold aliases of the same mount, the alias of the entity
+// merged into is kept.
+func (i *IdentityStore) duplicatesMergePlan(ctx context.Context) ([]*duplicatesMerge, error) {
+	entities, aliases, err := i.findDuplicates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group the entities to merge together, as both their names and the
+	// names of their aliases may be duplicates
+	parents := make(map[string]string)
+	var root func(id string) string
+	root = func(id string) string {
+		parent, ok := parents[id]
+		if !ok || parent == id {
+			parents[id] = id
+			return id
+		}
+		parents[id] = root(parent)
+		return parents[id]
+	}
+	union := func(ids []string) {
+		for _, id := range ids[1:] {
+			parents[root(id)] = root(ids[0])
+		}
+	}
+	for _, dup := range entities {
+		union(dup.EntityIDs)
+	}
+	for _, dup := range aliases {
+		union(dup.EntityIDs)
+	}
+
+	groups := make(map[string][]*identity.Entity)
+	for id := range parents {
+		entity, err := i.MemDBEntityByID(id, false)
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			continue
+		}
+		groups[root(id)] = append(groups[root(id)], entity)
+	}
+
+	plan := make([]*duplicatesMerge, 0, len(groups))
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+
+		sort.Slice(group, func(a, b int) bool {
+			createdA, createdB := group[a].CreationTime.AsTime(), group[b].CreationTime.AsTime()
+			if !createdA.Equal(createdB) {
+				return createdA.Before(createdB)
+			}
+			return group[a].ID < group[b].ID
+		})
+
+		merge := &duplicatesMerge{
+			ToEntityID:   group[0].ID,
+			ToEntityName: group[0].Name,
+		}
+		for _, entity := range group[1:] {
+			merge.FromEntityIDs = append(merge.FromEntityIDs, entity.ID)
+			merge.FromEntityNames = append(merge.FromEntityNames, entity.Name)
+		}
+		plan = append(plan, merge)
+	}
+	sort.Slice(plan, func(a, b int) bool {
+		return plan[a].ToEntityID < plan[b].ToEntityID
+	})
+
+	return plan, nil
+}
+
+// mergeDuplicates executes a step of the merge plan for duplicates, merging
+// one entity at a time so that conflicting aliases can be resolved. The
+// identity store lock must be held.
+func (i *IdentityStore) mergeDuplicates(ctx context.Context, merge *duplicatesMerge, force bool) error {
+	for _, fromEntityID := range merge.FromEntityIDs {
+		if err := i.mergeDuplicate(ctx, merge.ToEntityID, fromEntityID, force); err != nil {
+			return fmt.Errorf("failed to merge entity %q: %w", fromEntityID, err)
+		}
+	}
+	return nil
+}
+
+func (i *IdentityStore) mergeDuplicate(ctx context.Context, toEntityID, fromEntityID string, force bool) error {
+	txn := i.db.Txn(true)
+	defer txn.Abort()
+
+	toEntity, err := i.MemDBEntityByIDInTxn(txn, toEntityID, true)
+	if err != nil {
+		return err
+	}
+	if toEntity == nil {
+		return errors.New("entity to merge into no longer exists")
+	}
+	fromEntity, err := i.MemDBEntityByIDInTxn(txn, fromEntityID, false)
+	if err != nil {
+		return err
+	}
+	if fromEntity == nil {
+		return errors.New("entity no longer exists")
+	}
+
+	var conflictingAliasIDsToKeep []string
+	for _, toAlias := range toEntity.Aliases {
+		for _, fromAlias := range fromEntity.Aliases {
+			if toAlias.MountAccessor == fromAlias.MountAccessor {
+				conflictingAliasIDsToKeep = append(conflictingAliasIDsToKeep, toAlias.ID)
+			}
+		}
+	}
+	conflictingAliasIDsToKeep = strutil.RemoveDuplicates(conflictingAliasIDsToKeep, false)
+
+	userErr, intErr, _ := i.mergeEntity(ctx, txn, toEntity, []string{fromEntityID}, conflictingAliasIDsToKeep, force, false, false, true, false)
+	if userErr != nil {
+		return userErr
+	}
+	if intErr != nil {
+		return intErr
+	}
+
+	txn.Commit()
+	return nil
+}
+
+var duplicatesHelp = map[string][2]string{
+	"duplicates": {
+		"Report the duplicate entities and entity aliases of the namespace.",
+		`Entities are duplicates when their names differ by case only, and so are
+		entity aliases of the same mount. These can only exist once identity names
+		are case sensitive. Duplicate identities inflate client counts, and spread
+		policy assignments across entities.`,
+	},
+	"duplicates-merge-plan": {
+		"Preview the merges which resolve the duplicate entities and entity aliases of the namespace.",
+		`Entities which are duplicates, or which hold duplicate aliases, are merged
+		into the oldest one of them. Where the entities merged hold aliases of the
+		same mount, the alias of the entity merged into is kept.`,
+	},
+	"duplicates-merge": {
+		"Execute the merges which resolve the duplicate entities and entity aliases of the namespace.",
+		`The merge plan is executed as previewed by the 'duplicates/merge-plan'
+		endpoint, or only the merges into the entities given in 'to_entity_ids'.
+		The merges done, and their errors if any, are returned.`,
+	},
+}
@@ -0,0 +1,173 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIdentityStore_Duplicates verifies that entities and aliases whose names
+// differ by case only are reported, and merged according to the merge plan.
+func TestIdentityStore_Duplicates(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	is := c.identityStore
+
+	// Duplicates can only exist once names are case sensitive
+	is.disableLowerCasedNames = true
+	require.NoError(t, is.resetDB(ctx))
+
+	resp, err := c.systemBackend.HandleRequest(ctx, &logical.Request{
+		Path:      "auth",
+		Operation: logical.ReadOperation,
+	})
+	require.NoError(t, err)
+	tokenMountAccessor := resp.Data["token/"].(map[string]interface{})["accessor"].(string)
+
+	createEntity := func(name string) string {
+		t.Helper()
+		resp, err := is.HandleRequest(ctx, &logical.Request{
+			Path:      "entity",
+			Operation: logical.UpdateOperation,
+			Data:      map[string]interface{}{"name": name},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+		}
+		return resp.Data["id"].(string)
+	}
+	createAlias := func(name, entityID string) string {
+		t.Helper()
+		resp, err := is.HandleRequest(ctx, &logical.Request{
+			Path:      "entity-alias",
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"name":           name,
+				"mount_accessor": tokenMountAccessor,
+				"canonical_id":   entityID,
+			},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+		}
+		return resp.Data["id"].(string)
+	}
+
+	bobID := createEntity("bob")
+	bobVariantID := createEntity("Bob")
+	carolID := createEntity("carol")
+	daveID := createEntity("dave")
+	carolAliasID := createAlias("alice", carolID)
+	daveAliasID := createAlias("Alice", daveID)
+	createEntity("erin")
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:      "duplicates",
+		Operation: logical.ReadOperation,
+	})
+	require.NoError(t, err)
+	entities := resp.Data["entities"].([]*duplicateEntities)
+	require.Len(t, entities, 1)
+	require.Equal(t, "bob", entities[0].Name)
+	require.ElementsMatch(t, []string{bobID, bobVariantID}, entities[0].EntityIDs)
+	require.ElementsMatch(t, []string{"bob", "Bob"}, entities[0].EntityNames)
+
+	aliases := resp.Data["aliases"].([]*duplicateAliases)
+	require.Len(t, aliases, 1)
+	require.Equal(t, "alice", aliases[0].Name)
+	require.Equal(t, "auth/token/", aliases[0].MountPath)
+	require.ElementsMatch(t, []string{carolAliasID, daveAliasID}, aliases[0].AliasIDs)
+	require.ElementsMatch(t, []string{carolID, daveID}, aliases[0].EntityIDs)
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:      "duplicates/merge-plan",
+		Operation: logical.ReadOperation,
+	})
+	require.NoError(t, err)
+	plan := resp.Data["merges"].([]*duplicatesMerge)
+	require.Len(t, plan, 2)
+	expected := map[string][]string{
+		bobID:   {bobVariantID},
+		carolID: {daveID},
+	}
+	for _, merge := range plan {
+		require.Equal(t, expected[merge.ToEntityID], merge.FromEntityIDs)
+	}
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:      "duplicates/merge",
+		Operation: logical.UpdateOperation,
+	})
+	require.NoError(t, err)
+	merges := resp.Data["merges"].([]*duplicatesMerge)
+	require.Len(t, merges, 2)
+	for _, merge := range merges {
+		require.Empty(t, merge.Error)
+	}
+
+	for _, id := range []string{bobVariantID, daveID} {
+		entity, err := is.MemDBEntityByID(id, false)
+		require.NoError(t, err)
+		require.Nil(t, entity)
+	}
+
+	// The alias of the entity merged into is kept
+	carol, err := is.MemDBEntityByID(carolID, false)
+	require.NoError(t, err)
+	require.Len(t, carol.Aliases, 1)
+	require.Equal(t, carolAliasID, carol.Aliases[0].ID)
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:      "duplicates",
+		Operation: logical.ReadOperation,
+	})
+	require.NoError(t, err)
+	require.Empty(t, resp.Data["entities"])
+	require.Empty(t, resp.Data["aliases"])
+}
+
+// TestIdentityStore_Duplicates_MergeSubset verifies that the merge plan can be
+// executed for some of the entities merged into only.
+func TestIdentityStore_Duplicates_MergeSubset(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	is := c.identityStore
+
+	is.disableLowerCasedNames = true
+	require.NoError(t, is.resetDB(ctx))
+
+	ids := make(map[string]string)
+	for _, name := range []string{"bob", "Bob", "carol", "Carol"} {
+		resp, err := is.HandleRequest(ctx, &logical.Request{
+			Path:      "entity",
+			Operation: logical.UpdateOperation,
+			Data:      map[string]interface{}{"name": name},
+		})
+		require.NoError(t, err)
+		ids[name] = resp.Data["id"].(string)
+	}
+
+	resp, err := is.HandleRequest(ctx, &logical.Request{
+		Path:      "duplicates/merge",
+		Operation: logical.UpdateOperation,
+		Data:      map[string]interface{}{"to_entity_ids": ids["carol"]},
+	})
+	require.NoError(t, err)
+	merges := resp.Data["merges"].([]*duplicatesMerge)
+	require.Len(t, merges, 1)
+	require.Equal(t, ids["carol"], merges[0].ToEntityID)
+
+	resp, err = is.HandleRequest(ctx, &logical.Request{
+		Path:      "duplicates",
+		Operation: logical.ReadOperation,
+	})
+	require.NoError(t, err)
+	entities := resp.Data["entities"].([]*duplicateEntities)
+	require.Len(t, entities, 1)
+	require.Equal(t, "bob", entities[0].Name)
+}
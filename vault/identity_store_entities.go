@@ -362,6 +362,7 @@ func (i *IdentityStore) handleEntityUpdateCommon() framework.OperationFunc {
 			return logical.ErrorResponse("policies cannot contain root"), nil
 		}
 
+		wasDisabled := entity.Disabled
 		disabledRaw, ok := d.GetOk("disabled")
 		if ok {
 			entity.Disabled = disabledRaw.(bool)
@@ -390,6 +391,15 @@ func (i *IdentityStore) handleEntityUpdateCommon() framework.OperationFunc {
 			return nil, err
 		}
 
+		// Disabling an entity revokes its identity tokens
+		if !newEntity && entity.Disabled && !wasDisabled {
+			ns, err := namespace.FromContext(ctx)
+			if err != nil {
+				return nil, err
+			}
+			i.sendEntityRevokeEvent(ns, entity.ID, entityRevocationReasonDisabled)
+		}
+
 		// If this operation was an update to an existing entity, return 204
 		if !newEntity {
 			return nil, nil
@@ -731,6 +741,8 @@ func (i *IdentityStore) handleEntityDeleteCommon(ctx context.Context, txn *memdb
 		if err != nil {
 			return err
 		}
+
+		i.recordEntityDeletion(ctx, entity.ID)
 	}
 
 	return nil
@@ -1031,6 +1043,7 @@ func (i *IdentityStore) mergeEntity(ctx context.Context, txn *memdb.Txn, toEntit
 			return errors.New("entity id to merge from does not belong to this namespace"), nil, nil
 		}
 
+	FROM_ALIASES:
 		for _, fromAlias := range fromEntity.Aliases {
 			// If true, we need to handle conflicts (conflict = both aliases share the same mount accessor)
 			if toAliasIds, ok := toEntityAccessors[fromAlias.MountAccessor]; ok {
@@ -1053,7 +1066,7 @@ func (i *IdentityStore) mergeEntity(ctx context.Context, txn *memdb.Txn, toEntit
 						}
 
 						// Continue to next alias, as there's no alias to merge left in the from_entity
-						continue
+						continue FROM_ALIASES
 					} else if strutil.StrListContains(conflictingAliasIDsToKeep, fromAlias.ID) {
 						i.logger.Info("Deleting to_entity alias during entity merge", "to_entity", toEntity.ID, "deleted_alias", toAliasId)
 						err := i.MemDBDeleteAliasByIDInTxn(txn, toAliasId, false)
@@ -1122,6 +1135,8 @@ func (i *IdentityStore) mergeEntity(ctx context.Context, txn *memdb.Txn, toEntit
 			if err != nil {
 				return nil, err, nil
 			}
+
+			i.recordEntityDeletion(ctx, fromEntity.ID)
 		}
 	}
 
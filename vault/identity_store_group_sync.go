@@ -0,0 +1,328 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// groupSyncConfigKey is the storage key of the schedule on which external
+// group memberships are refreshed.
+const groupSyncConfigKey = "group-sync/config"
+
+// groupSyncCheckInterval is how often the group sync schedule is checked for
+// whether a sync is due.
+var groupSyncCheckInterval = 10 * time.Second
+
+var errGroupSyncNamespace = errors.New("group sync can only be configured in the root namespace")
+
+// groupSyncConfig is the schedule on which the external group memberships of
+// active entities are refreshed from their auth methods. Syncs are disabled if
+// neither a schedule nor a period is set.
+type groupSyncConfig struct {
+	Schedule string        `json:"sync_schedule,omitempty"`
+	Period   time.Duration `json:"sync_period,omitempty"`
+
+	NextSync time.Time `json:"next_sync,omitempty"`
+	LastSync time.Time `json:"last_sync,omitempty"`
+}
+
+func (g *groupSyncConfig) enabled() bool {
+	return g.Schedule != "" || g.Period > 0
+}
+
+func groupSyncPaths(i *IdentityStore) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "group-sync/config$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationSuffix: "group-sync-configuration",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"sync_schedule": {
+					Type:        framework.TypeString,
+					Description: "Cron-style schedule, e.g. '0 * * * *', on which external group memberships are refreshed. Mutually exclusive with 'sync_period'.",
+				},
+				"sync_period": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Interval on which external group memberships are refreshed. Mutually exclusive with 'sync_schedule'.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathGroupSyncConfigRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.pathGroupSyncConfigWrite(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: i.pathGroupSyncConfigDelete(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(groupSyncHelp["group-sync-config"][0]),
+			HelpDescription: strings.TrimSpace(groupSyncHelp["group-sync-config"][1]),
+		},
+	}
+}
+
+func (i *IdentityStore) pathGroupSyncConfigRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if err := groupSyncCheckNamespace(ctx); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		config, err := i.groupSyncConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		data := map[string]interface{}{
+			"sync_schedule": config.Schedule,
+			"sync_period":   int64(config.Period.Seconds()),
+		}
+		if config.enabled() {
+			data["next_sync"] = config.NextSync.Format(time.RFC3339)
+		}
+		if !config.LastSync.IsZero() {
+			data["last_sync"] = config.LastSync.Format(time.RFC3339)
+		}
+		return &logical.Response{Data: data}, nil
+	}
+}
+
+func (i *IdentityStore) pathGroupSyncConfigWrite() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if err := groupSyncCheckNamespace(ctx); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		i.groupSyncLock.Lock()
+		defer i.groupSyncLock.Unlock()
+
+		config, err := i.groupSyncConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if raw, ok := d.GetOk("sync_schedule"); ok {
+			config.Schedule = raw.(string)
+			if _, ok := d.GetOk("sync_period"); !ok {
+				config.Period = 0
+			}
+		}
+		if raw, ok := d.GetOk("sync_period"); ok {
+			config.Period = time.Duration(raw.(int)) * time.Second
+			if _, ok := d.GetOk("sync_schedule"); !ok {
+				config.Schedule = ""
+			}
+		}
+
+		switch {
+		case config.Schedule != "" && config.Period != 0:
+			return logical.ErrorResponse("sync_schedule and sync_period are mutually exclusive"), nil
+		case config.Period < 0:
+			return logical.ErrorResponse("sync_period must not be negative"), nil
+		}
+
+		if config.enabled() {
+			config.NextSync, err = nextScheduled(config.Schedule, config.Period, time.Now())
+			if err != nil {
+				return logical.ErrorResponse("invalid sync_schedule: %s", err), nil
+			}
+		} else {
+			config.NextSync = time.Time{}
+		}
+
+		return nil, i.persistGroupSyncConfig(ctx, config)
+	}
+}
+
+func (i *IdentityStore) pathGroupSyncConfigDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if err := groupSyncCheckNamespace(ctx); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		i.groupSyncLock.Lock()
+		defer i.groupSyncLock.Unlock()
+
+		return nil, i.view.Delete(ctx, groupSyncConfigKey)
+	}
+}
+
+func groupSyncCheckNamespace(ctx context.Context) error {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if ns.ID != namespace.RootNamespaceID {
+		return errGroupSyncNamespace
+	}
+	return nil
+}
+
+// groupSyncConfig returns the group sync schedule, which is empty if it was
+// never configured.
+func (i *IdentityStore) groupSyncConfig(ctx context.Context) (*groupSyncConfig, error) {
+	config := new(groupSyncConfig)
+
+	entry, err := i.view.Get(ctx, groupSyncConfigKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read group sync config: %w", err)
+	}
+	if entry == nil {
+		return config, nil
+	}
+
+	if err := jsonutil.DecodeJSON(entry.Value, config); err != nil {
+		return nil, fmt.Errorf("failed to decode group sync config: %w", err)
+	}
+	return config, nil
+}
+
+func (i *IdentityStore) persistGroupSyncConfig(ctx context.Context, config *groupSyncConfig) error {
+	entry, err := logical.StorageEntryJSON(groupSyncConfigKey, config)
+	if err != nil {
+		return fmt.Errorf("failed to encode group sync config: %w", err)
+	}
+	return i.view.Put(ctx, entry)
+}
+
+// groupAliasMountAccessors returns the accessors of the auth mounts external
+// groups have aliases on.
+func (i *IdentityStore) groupAliasMountAccessors() (map[string]struct{}, error) {
+	iter, err := i.MemDBAliases(nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch group aliases: %w", err)
+	}
+
+	accessors := make(map[string]struct{})
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		accessors[raw.(*identity.Alias).MountAccessor] = struct{}{}
+	}
+	return accessors, nil
+}
+
+// startGroupSync runs a process which, every groupSyncCheckInterval, refreshes
+// the external group memberships of active entities if a sync is due, until
+// the active context is done.
+func (c *Core) startGroupSync() {
+	if c.groupSyncCancel != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, c.groupSyncCancel = context.WithCancel(namespace.RootContext(c.activeContext))
+
+	go func() {
+		ticker := time.NewTicker(groupSyncCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.runGroupSync(ctx, time.Now()); err != nil {
+					c.logger.Error("failed to refresh external group memberships", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// runGroupSync refreshes the external group memberships of active entities if
+// a sync is due at now, and schedules the next sync.
+func (c *Core) runGroupSync(ctx context.Context, now time.Time) error {
+	i := c.identityStore
+	if i == nil || c.expiration == nil {
+		return nil
+	}
+
+	i.groupSyncLock.Lock()
+	config, err := i.groupSyncConfig(ctx)
+	i.groupSyncLock.Unlock()
+	if err != nil || !config.enabled() || now.Before(config.NextSync) {
+		return err
+	}
+
+	accessors, err := i.groupAliasMountAccessors()
+	if err != nil {
+		return err
+	}
+
+	refreshed, syncErr := c.expiration.refreshGroupMemberships(ctx, accessors)
+	metrics.MeasureSince([]string{"identity", "group_sync"}, now)
+	metrics.IncrCounter([]string{"identity", "group_sync", "refreshed"}, float32(refreshed))
+	c.logger.Debug("refreshed external group memberships", "refreshed", refreshed)
+
+	if err := c.scheduleGroupSync(ctx, now); err != nil {
+		return err
+	}
+	return syncErr
+}
+
+// scheduleGroupSync records the sync started at now and schedules the next
+// one, unless the schedule was changed while syncing.
+func (c *Core) scheduleGroupSync(ctx context.Context, now time.Time) error {
+	i := c.identityStore
+	i.groupSyncLock.Lock()
+	defer i.groupSyncLock.Unlock()
+
+	config, err := i.groupSyncConfig(ctx)
+	if err != nil || !config.enabled() {
+		return err
+	}
+
+	config.LastSync = now
+	if !config.NextSync.After(now) {
+		if config.NextSync, err = nextScheduled(config.Schedule, config.Period, now); err != nil {
+			return err
+		}
+	}
+	return i.persistGroupSyncConfig(ctx, config)
+}
+
+var groupSyncHelp = map[string][2]string{
+	"group-sync-config": {
+		"Configure the schedule on which external group memberships are refreshed.",
+		`
+External group memberships are refreshed whenever an entity logs in or renews
+a token. On the configured schedule, the active node additionally refreshes the
+memberships of the entities holding a renewable token issued by an auth method
+external groups have aliases on. One token of each entity is renewed with its
+auth method, without extending its lease, and the entity's memberships are
+updated from the group aliases the auth method returns, e.g. from LDAP groups,
+so that removing a user from a directory group takes effect before the user
+next logs in. Entities whose token the auth method fails to renew keep their
+memberships.
+
+Syncs are disabled if neither 'sync_schedule' nor 'sync_period' is set.
+`,
+	},
+}
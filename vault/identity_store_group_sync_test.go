@@ -0,0 +1,194 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIdentityStore_GroupSync verifies that a scheduled group sync removes an
+// entity from an external group once its auth method no longer reports the
+// group, without the entity logging in again.
+func TestIdentityStore_GroupSync(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	var groupsLock sync.Mutex
+	groups := []string{"admins"}
+	groupAliases := func() []*logical.Alias {
+		groupsLock.Lock()
+		defer groupsLock.Unlock()
+		var aliases []*logical.Alias
+		for _, group := range groups {
+			aliases = append(aliases, &logical.Alias{Name: group})
+		}
+		return aliases
+	}
+
+	renewed := make(chan struct{}, 10)
+	c.credentialBackends["groups"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return &NoopBackend{
+			BackendType: logical.TypeCredential,
+			Login:       []string{"login"},
+			RequestHandler: func(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+				switch req.Operation {
+				case logical.RenewOperation:
+					renewed <- struct{}{}
+					auth := req.Auth
+					auth.GroupAliases = groupAliases()
+					return &logical.Response{Auth: auth}, nil
+				default:
+					return &logical.Response{
+						Auth: &logical.Auth{
+							Alias:        &logical.Alias{Name: "alice"},
+							GroupAliases: groupAliases(),
+							Policies:     []string{"default"},
+							LeaseOptions: logical.LeaseOptions{
+								TTL:       time.Hour,
+								Renewable: true,
+							},
+						},
+					}, nil
+				}
+			},
+		}, nil
+	}
+
+	resp, err := c.HandleRequest(ctx, &logical.Request{
+		Path:        "sys/auth/groups",
+		Operation:   logical.UpdateOperation,
+		ClientToken: root,
+		Data:        map[string]interface{}{"type": "groups"},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+	accessor := c.router.MatchingMountEntry(ctx, "auth/groups/").Accessor
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "group",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"name": "admins",
+			"type": "external",
+		},
+	})
+	require.NoError(t, err)
+	groupID := resp.Data["id"].(string)
+
+	_, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "group-alias",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"name":           "admins",
+			"mount_accessor": accessor,
+			"canonical_id":   groupID,
+		},
+	})
+	require.NoError(t, err)
+
+	resp, err = c.HandleRequest(ctx, &logical.Request{
+		Path:       "auth/groups/login",
+		Operation:  logical.UpdateOperation,
+		Connection: &logical.Connection{},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Auth)
+	entityID := resp.Auth.EntityID
+
+	group, err := c.identityStore.MemDBGroupByID(groupID, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{entityID}, group.MemberEntityIDs)
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "group-sync/config",
+		Operation: logical.UpdateOperation,
+		Data:      map[string]interface{}{"sync_period": "1h"},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	groupsLock.Lock()
+	groups = nil
+	groupsLock.Unlock()
+
+	// The sync is not due yet
+	require.NoError(t, c.runGroupSync(ctx, time.Now()))
+	require.Len(t, renewed, 0)
+
+	now := time.Now().Add(time.Hour)
+	require.NoError(t, c.runGroupSync(ctx, now))
+	require.Len(t, renewed, 1)
+
+	group, err = c.identityStore.MemDBGroupByID(groupID, false)
+	require.NoError(t, err)
+	require.Empty(t, group.MemberEntityIDs)
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "group-sync/config",
+		Operation: logical.ReadOperation,
+	})
+	require.NoError(t, err)
+	require.Equal(t, now.Format(time.RFC3339), resp.Data["last_sync"])
+	require.Equal(t, now.Add(time.Hour).Format(time.RFC3339), resp.Data["next_sync"])
+}
+
+// TestIdentityStore_GroupSyncConfig verifies the validation of the group sync
+// schedule.
+func TestIdentityStore_GroupSyncConfig(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	for name, data := range map[string]map[string]interface{}{
+		"both":     {"sync_schedule": "0 * * * *", "sync_period": "1h"},
+		"bad cron": {"sync_schedule": "hourly"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+				Path:      "group-sync/config",
+				Operation: logical.UpdateOperation,
+				Data:      data,
+			})
+			require.NoError(t, err)
+			require.True(t, resp.IsError())
+		})
+	}
+
+	resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "group-sync/config",
+		Operation: logical.UpdateOperation,
+		Data:      map[string]interface{}{"sync_schedule": "0 * * * *"},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "group-sync/config",
+		Operation: logical.ReadOperation,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "0 * * * *", resp.Data["sync_schedule"])
+	next, err := time.Parse(time.RFC3339, resp.Data["next_sync"].(string))
+	require.NoError(t, err)
+	require.Zero(t, next.Minute())
+
+	_, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "group-sync/config",
+		Operation: logical.DeleteOperation,
+	})
+	require.NoError(t, err)
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "group-sync/config",
+		Operation: logical.ReadOperation,
+	})
+	require.NoError(t, err)
+	require.NotContains(t, resp.Data, "next_sync")
+}
@@ -0,0 +1,848 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/jmespath/go-jmespath"
+)
+
+const (
+	// metadataSourcesPrefix is the storage prefix of the external sources
+	// entity metadata is pulled from, keyed by name.
+	metadataSourcesPrefix = "metadata-sources/"
+
+	metadataSourceTypeHTTP = "http"
+	metadataSourceTypeSCIM = "scim"
+
+	// metadataSourceMaxResponseSize is the maximum size of a response of a
+	// metadata source.
+	metadataSourceMaxResponseSize = 32 << 20
+
+	// metadataSourceSCIMPageSize is the number of users requested per page
+	// from SCIM sources.
+	metadataSourceSCIMPageSize = 100
+)
+
+// metadataSourceCheckInterval is how often the metadata sources are checked
+// for whether a sync is due.
+var metadataSourceCheckInterval = 10 * time.Second
+
+var errMetadataSourceNamespace = errors.New("metadata sources can only be configured in the root namespace")
+
+// metadataSource is an external inventory, e.g. an HR system or a CMDB, from
+// which attributes are pulled into the metadata of entities on a schedule.
+// Each record of the source is matched to an entity by name, or by the name
+// of its alias on MountAccessor, and the metadata keys mapped by the source
+// are set on the entity from the record. Other metadata keys are left as is.
+type metadataSource struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	URL         string `json:"url"`
+	BearerToken string `json:"bearer_token,omitempty"`
+
+	// Records selects the list of records from the response of HTTP
+	// sources. SCIM sources list their users.
+	Records string `json:"records,omitempty"`
+	// MatchKey selects the name of the entity, or of its alias on
+	// MountAccessor, of a record.
+	MatchKey      string `json:"match_key"`
+	MountAccessor string `json:"mount_accessor,omitempty"`
+	// Metadata maps metadata keys to the expressions selecting their value
+	// from a record.
+	Metadata map[string]string `json:"metadata"`
+
+	Schedule string        `json:"sync_schedule,omitempty"`
+	Period   time.Duration `json:"sync_period,omitempty"`
+
+	NextSync        time.Time `json:"next_sync,omitempty"`
+	LastSync        time.Time `json:"last_sync,omitempty"`
+	LastError       string    `json:"last_error,omitempty"`
+	LastUpdated     int       `json:"last_updated,omitempty"`
+	LastUnmatched   int       `json:"last_unmatched,omitempty"`
+	LastRecordCount int       `json:"last_record_count,omitempty"`
+}
+
+func (s *metadataSource) enabled() bool {
+	return s.Schedule != "" || s.Period > 0
+}
+
+func metadataSourcePaths(i *IdentityStore) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "metadata-source/" + framework.GenericNameRegex("name") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationSuffix: "metadata-source",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the metadata source.",
+				},
+				"type": {
+					Type:          framework.TypeString,
+					Default:       metadataSourceTypeHTTP,
+					AllowedValues: []interface{}{metadataSourceTypeHTTP, metadataSourceTypeSCIM},
+					Description:   "Type of the metadata source, 'http' for a JSON document or 'scim' for the users of a SCIM 2.0 service.",
+				},
+				"url": {
+					Type:        framework.TypeString,
+					Description: "URL of the JSON document of 'http' sources, or base URL of the SCIM service of 'scim' sources.",
+				},
+				"bearer_token": {
+					Type:        framework.TypeString,
+					Description: "Bearer token to authenticate to the metadata source with.",
+					DisplayAttrs: &framework.DisplayAttributes{
+						Sensitive: true,
+					},
+				},
+				"records": {
+					Type:        framework.TypeString,
+					Description: "JMESPath expression selecting the list of records from the document of 'http' sources. Defaults to the whole document.",
+				},
+				"match_key": {
+					Type:        framework.TypeString,
+					Description: "JMESPath expression selecting the name of the entity, or of its alias on 'mount_accessor', of a record. Defaults to 'userName' for 'scim' sources.",
+				},
+				"mount_accessor": {
+					Type:        framework.TypeString,
+					Description: "Accessor of the auth method records are matched to the aliases of. Records are matched to entity names if unset.",
+				},
+				"metadata": {
+					Type:        framework.TypeKVPairs,
+					Description: "Map of entity metadata keys to the JMESPath expressions selecting their value from a record.",
+				},
+				"sync_schedule": {
+					Type:        framework.TypeString,
+					Description: "Cron-style schedule, e.g. '0 * * * *', on which entity metadata is pulled from the source. Mutually exclusive with 'sync_period'.",
+				},
+				"sync_period": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Interval on which entity metadata is pulled from the source. Mutually exclusive with 'sync_schedule'.",
+				},
+			},
+
+			ExistenceCheck: i.pathMetadataSourceExistenceCheck(),
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.CreateOperation: &framework.PathOperation{
+					Callback: i.pathMetadataSourceWrite(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "create",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.pathMetadataSourceWrite(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "update",
+					},
+				},
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathMetadataSourceRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: i.pathMetadataSourceDelete(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(metadataSourceHelp["metadata-source"][0]),
+			HelpDescription: strings.TrimSpace(metadataSourceHelp["metadata-source"][1]),
+		},
+		{
+			Pattern: "metadata-source/" + framework.GenericNameRegex("name") + "/sync$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationVerb:   "sync",
+				OperationSuffix: "metadata-source",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the metadata source.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.pathMetadataSourceSync(),
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(metadataSourceHelp["metadata-source-sync"][0]),
+			HelpDescription: strings.TrimSpace(metadataSourceHelp["metadata-source-sync"][1]),
+		},
+		{
+			Pattern: "metadata-source/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationSuffix: "metadata-sources",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: i.pathMetadataSourceList(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "list",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(metadataSourceHelp["metadata-source-list"][0]),
+			HelpDescription: strings.TrimSpace(metadataSourceHelp["metadata-source-list"][1]),
+		},
+	}
+}
+
+func (i *IdentityStore) pathMetadataSourceExistenceCheck() framework.ExistenceFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (bool, error) {
+		source, err := i.metadataSource(ctx, d.Get("name").(string))
+		if err != nil {
+			return false, err
+		}
+		return source != nil, nil
+	}
+}
+
+func (i *IdentityStore) pathMetadataSourceRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if err := metadataSourceCheckNamespace(ctx); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		source, err := i.metadataSource(ctx, d.Get("name").(string))
+		if err != nil || source == nil {
+			return nil, err
+		}
+
+		data := map[string]interface{}{
+			"name":           source.Name,
+			"type":           source.Type,
+			"url":            source.URL,
+			"records":        source.Records,
+			"match_key":      source.MatchKey,
+			"mount_accessor": source.MountAccessor,
+			"metadata":       source.Metadata,
+			"sync_schedule":  source.Schedule,
+			"sync_period":    int64(source.Period.Seconds()),
+		}
+		if source.enabled() {
+			data["next_sync"] = source.NextSync.Format(time.RFC3339)
+		}
+		if !source.LastSync.IsZero() {
+			data["last_sync"] = source.LastSync.Format(time.RFC3339)
+			data["last_error"] = source.LastError
+			data["last_record_count"] = source.LastRecordCount
+			data["last_updated"] = source.LastUpdated
+			data["last_unmatched"] = source.LastUnmatched
+		}
+		return &logical.Response{Data: data}, nil
+	}
+}
+
+func (i *IdentityStore) pathMetadataSourceWrite() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if err := metadataSourceCheckNamespace(ctx); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		i.metadataSourcesLock.Lock()
+		defer i.metadataSourcesLock.Unlock()
+
+		name := d.Get("name").(string)
+		source, err := i.metadataSource(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if source == nil {
+			source = &metadataSource{
+				Name: name,
+				Type: d.Get("type").(string),
+			}
+		}
+
+		if raw, ok := d.GetOk("type"); ok {
+			source.Type = raw.(string)
+		}
+		if raw, ok := d.GetOk("url"); ok {
+			source.URL = raw.(string)
+		}
+		if raw, ok := d.GetOk("bearer_token"); ok {
+			source.BearerToken = raw.(string)
+		}
+		if raw, ok := d.GetOk("records"); ok {
+			source.Records = raw.(string)
+		}
+		if raw, ok := d.GetOk("match_key"); ok {
+			source.MatchKey = raw.(string)
+		}
+		if raw, ok := d.GetOk("mount_accessor"); ok {
+			source.MountAccessor = raw.(string)
+		}
+		if raw, ok := d.GetOk("metadata"); ok {
+			source.Metadata = raw.(map[string]string)
+		}
+		if raw, ok := d.GetOk("sync_schedule"); ok {
+			source.Schedule = raw.(string)
+			if _, ok := d.GetOk("sync_period"); !ok {
+				source.Period = 0
+			}
+		}
+		if raw, ok := d.GetOk("sync_period"); ok {
+			source.Period = time.Duration(raw.(int)) * time.Second
+			if _, ok := d.GetOk("sync_schedule"); !ok {
+				source.Schedule = ""
+			}
+		}
+		if source.Type == metadataSourceTypeSCIM && source.MatchKey == "" {
+			source.MatchKey = "userName"
+		}
+
+		if err := i.validateMetadataSource(source); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+
+		if source.enabled() {
+			source.NextSync, err = nextScheduled(source.Schedule, source.Period, time.Now())
+			if err != nil {
+				return logical.ErrorResponse("invalid sync_schedule: %s", err), nil
+			}
+		} else {
+			source.NextSync = time.Time{}
+		}
+
+		return nil, i.persistMetadataSource(ctx, source)
+	}
+}
+
+func (i *IdentityStore) validateMetadataSource(source *metadataSource) error {
+	switch {
+	case source.Type != metadataSourceTypeHTTP && source.Type != metadataSourceTypeSCIM:
+		return fmt.Errorf("invalid type %q", source.Type)
+	case source.URL == "":
+		return errors.New("missing url")
+	case source.MatchKey == "":
+		return errors.New("missing match_key")
+	case len(source.Metadata) == 0:
+		return errors.New("missing metadata")
+	case source.Records != "" && source.Type != metadataSourceTypeHTTP:
+		return errors.New("records can only be set on http sources")
+	case source.Schedule != "" && source.Period != 0:
+		return errors.New("sync_schedule and sync_period are mutually exclusive")
+	case source.Period < 0:
+		return errors.New("sync_period must not be negative")
+	}
+
+	if u, err := url.Parse(source.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid url %q", source.URL)
+	}
+	if source.MountAccessor != "" && i.router.MatchingMountByAccessor(source.MountAccessor) == nil {
+		return fmt.Errorf("invalid mount_accessor %q", source.MountAccessor)
+	}
+
+	expressions := map[string]string{"match_key": source.MatchKey}
+	if source.Records != "" {
+		expressions["records"] = source.Records
+	}
+	for key, expr := range source.Metadata {
+		if err := validateMetaPair(key, ""); err != nil {
+			return fmt.Errorf("invalid metadata key %q: %w", key, err)
+		}
+		expressions["metadata."+key] = expr
+	}
+	if len(source.Metadata) > metaMaxKeyPairs {
+		return fmt.Errorf("metadata cannot map more than %d keys", metaMaxKeyPairs)
+	}
+	for field, expr := range expressions {
+		if _, err := jmespath.Compile(expr); err != nil {
+			return fmt.Errorf("invalid expression in %s: %w", field, err)
+		}
+	}
+	return nil
+}
+
+func (i *IdentityStore) pathMetadataSourceDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if err := metadataSourceCheckNamespace(ctx); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		i.metadataSourcesLock.Lock()
+		defer i.metadataSourcesLock.Unlock()
+
+		return nil, i.view.Delete(ctx, metadataSourcesPrefix+d.Get("name").(string))
+	}
+}
+
+func (i *IdentityStore) pathMetadataSourceList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if err := metadataSourceCheckNamespace(ctx); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		names, err := i.view.List(ctx, metadataSourcesPrefix)
+		if err != nil {
+			return nil, err
+		}
+		return logical.ListResponse(names), nil
+	}
+}
+
+// pathMetadataSourceSync pulls entity metadata from the source immediately,
+// without changing its schedule.
+func (i *IdentityStore) pathMetadataSourceSync() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if err := metadataSourceCheckNamespace(ctx); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		name := d.Get("name").(string)
+		source, err := i.metadataSource(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if source == nil {
+			return logical.ErrorResponse("unknown metadata source %q", name), logical.ErrInvalidRequest
+		}
+
+		result, syncErr := i.syncMetadataSource(ctx, source)
+		if err := i.recordMetadataSourceSync(ctx, name, time.Now(), result, syncErr, false); err != nil {
+			return nil, err
+		}
+		if syncErr != nil {
+			return logical.ErrorResponse("failed to sync metadata source: %s", syncErr), nil
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"record_count": result.records,
+				"updated":      result.updated,
+				"unmatched":    result.unmatched,
+			},
+		}, nil
+	}
+}
+
+func metadataSourceCheckNamespace(ctx context.Context) error {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if ns.ID != namespace.RootNamespaceID {
+		return errMetadataSourceNamespace
+	}
+	return nil
+}
+
+// metadataSource returns the named metadata source, or nil if there is none.
+func (i *IdentityStore) metadataSource(ctx context.Context, name string) (*metadataSource, error) {
+	entry, err := i.view.Get(ctx, metadataSourcesPrefix+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata source: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	source := new(metadataSource)
+	if err := jsonutil.DecodeJSON(entry.Value, source); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata source: %w", err)
+	}
+	return source, nil
+}
+
+func (i *IdentityStore) persistMetadataSource(ctx context.Context, source *metadataSource) error {
+	entry, err := logical.StorageEntryJSON(metadataSourcesPrefix+source.Name, source)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata source: %w", err)
+	}
+	return i.view.Put(ctx, entry)
+}
+
+// metadataSourceSyncResult counts the outcome of a sync of a metadata source.
+type metadataSourceSyncResult struct {
+	records   int
+	updated   int
+	unmatched int
+}
+
+// syncMetadataSource pulls the records of the source, and merges their
+// mapped attributes into the metadata of the matching entities.
+func (i *IdentityStore) syncMetadataSource(ctx context.Context, source *metadataSource) (*metadataSourceSyncResult, error) {
+	result := new(metadataSourceSyncResult)
+
+	var records []interface{}
+	var err error
+	switch source.Type {
+	case metadataSourceTypeSCIM:
+		records, err = fetchSCIMUsers(ctx, source)
+	default:
+		records, err = fetchMetadataSourceRecords(ctx, source)
+	}
+	if err != nil {
+		return result, err
+	}
+	result.records = len(records)
+
+	matchKey, err := jmespath.Compile(source.MatchKey)
+	if err != nil {
+		return result, err
+	}
+	keys := make([]string, 0, len(source.Metadata))
+	expressions := make(map[string]*jmespath.JMESPath, len(source.Metadata))
+	for key, expr := range source.Metadata {
+		if expressions[key], err = jmespath.Compile(expr); err != nil {
+			return result, err
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	var errs error
+	for _, record := range records {
+		raw, err := matchKey.Search(record)
+		name, ok := raw.(string)
+		if err != nil || !ok || name == "" {
+			result.unmatched++
+			continue
+		}
+
+		entity, err := i.metadataSourceEntity(ctx, source, name)
+		if err != nil {
+			return result, err
+		}
+		if entity == nil {
+			result.unmatched++
+			continue
+		}
+
+		metadata := make(map[string]string, len(entity.Metadata)+len(keys))
+		for k, v := range entity.Metadata {
+			metadata[k] = v
+		}
+		for _, key := range keys {
+			value, err := expressions[key].Search(record)
+			if err != nil || value == nil {
+				delete(metadata, key)
+				continue
+			}
+			metadata[key] = metadataSourceValue(value)
+		}
+		if metadataEqual(entity.Metadata, metadata) {
+			continue
+		}
+
+		entity.Metadata = metadata
+		if err := validateMetadata(entity.Metadata); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("invalid metadata for entity %q: %w", entity.Name, err))
+			continue
+		}
+		if err := i.upsertEntity(ctx, entity, nil, true); err != nil {
+			return result, err
+		}
+		result.updated++
+	}
+	return result, errs
+}
+
+// metadataSourceEntity returns a clone of the entity of the root namespace
+// matching the record name, or nil if there is none.
+func (i *IdentityStore) metadataSourceEntity(ctx context.Context, source *metadataSource, name string) (*identity.Entity, error) {
+	if source.MountAccessor == "" {
+		return i.MemDBEntityByName(ctx, name, true)
+	}
+
+	alias, err := i.MemDBAliasByFactors(source.MountAccessor, name, false, false)
+	if err != nil || alias == nil {
+		return nil, err
+	}
+	entity, err := i.MemDBEntityByAliasID(alias.ID, true)
+	if err != nil || entity == nil || entity.NamespaceID != namespace.RootNamespaceID {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// metadataSourceValue returns the metadata value of a value selected from a
+// record. Values which are not strings are JSON encoded.
+func metadataSourceValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
+
+func metadataEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchMetadataSourceRecords returns the records of the document of an HTTP
+// metadata source.
+func fetchMetadataSourceRecords(ctx context.Context, source *metadataSource) ([]interface{}, error) {
+	document, err := fetchMetadataSourceDocument(ctx, source, source.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := document
+	if source.Records != "" {
+		if selected, err = jmespath.Search(source.Records, document); err != nil {
+			return nil, fmt.Errorf("failed to select records: %w", err)
+		}
+	}
+	records, ok := selected.([]interface{})
+	if !ok {
+		return nil, errors.New("records of the metadata source are not a list")
+	}
+	return records, nil
+}
+
+// fetchSCIMUsers returns all users of a SCIM metadata source, following the
+// pagination of the service.
+func fetchSCIMUsers(ctx context.Context, source *metadataSource) ([]interface{}, error) {
+	var users []interface{}
+	for startIndex := 1; ; {
+		u, err := url.Parse(strings.TrimSuffix(source.URL, "/") + "/Users")
+		if err != nil {
+			return nil, err
+		}
+		query := u.Query()
+		query.Set("startIndex", strconv.Itoa(startIndex))
+		query.Set("count", strconv.Itoa(metadataSourceSCIMPageSize))
+		u.RawQuery = query.Encode()
+
+		document, err := fetchMetadataSourceDocument(ctx, source, u.String())
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			TotalResults int           `json:"totalResults"`
+			Resources    []interface{} `json:"Resources"`
+		}
+		encoded, err := json.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(encoded, &page); err != nil {
+			return nil, fmt.Errorf("invalid SCIM list response: %w", err)
+		}
+
+		users = append(users, page.Resources...)
+		startIndex += len(page.Resources)
+		if len(page.Resources) == 0 || len(users) >= page.TotalResults {
+			return users, nil
+		}
+	}
+}
+
+// fetchMetadataSourceDocument returns the decoded JSON document at the URL of
+// a metadata source.
+func fetchMetadataSourceDocument(ctx context.Context, source *metadataSource, u string) (interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, application/scim+json")
+	if source.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+source.BearerToken)
+	}
+
+	client := cleanhttp.DefaultClient()
+	client.Timeout = time.Minute
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metadata source: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata source returned status %d", resp.StatusCode)
+	}
+
+	var document interface{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, metadataSourceMaxResponseSize)).Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode response of metadata source: %w", err)
+	}
+	return document, nil
+}
+
+// startMetadataSourceSync runs a process which, every
+// metadataSourceCheckInterval, pulls entity metadata from the sources whose
+// sync is due, until the active context is done.
+func (c *Core) startMetadataSourceSync() {
+	if c.metadataSourceSyncCancel != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, c.metadataSourceSyncCancel = context.WithCancel(namespace.RootContext(c.activeContext))
+
+	go func() {
+		ticker := time.NewTicker(metadataSourceCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.runMetadataSourceSync(ctx, time.Now()); err != nil {
+					c.logger.Error("failed to sync entity metadata sources", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// runMetadataSourceSync pulls entity metadata from the sources whose sync is
+// due at now, and schedules their next sync.
+func (c *Core) runMetadataSourceSync(ctx context.Context, now time.Time) error {
+	i := c.identityStore
+	if i == nil {
+		return nil
+	}
+
+	names, err := i.view.List(ctx, metadataSourcesPrefix)
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, name := range names {
+		source, err := i.metadataSource(ctx, name)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		if source == nil || !source.enabled() || now.Before(source.NextSync) {
+			continue
+		}
+
+		result, syncErr := i.syncMetadataSource(ctx, source)
+		metrics.MeasureSinceWithLabels([]string{"identity", "metadata_source", "sync"}, now, []metrics.Label{{Name: "source", Value: name}})
+		metrics.IncrCounterWithLabels([]string{"identity", "metadata_source", "updated"}, float32(result.updated), []metrics.Label{{Name: "source", Value: name}})
+		if syncErr != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to sync metadata source %q: %w", name, syncErr))
+		} else {
+			c.logger.Debug("synced entity metadata source", "source", name, "records", result.records, "updated", result.updated, "unmatched", result.unmatched)
+		}
+
+		if err := i.recordMetadataSourceSync(ctx, name, now, result, syncErr, true); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
+
+// recordMetadataSourceSync records the outcome of the sync of the source
+// started at now and, if scheduled, schedules the next one, unless the
+// schedule was changed while syncing.
+func (i *IdentityStore) recordMetadataSourceSync(ctx context.Context, name string, now time.Time, result *metadataSourceSyncResult, syncErr error, scheduled bool) error {
+	i.metadataSourcesLock.Lock()
+	defer i.metadataSourcesLock.Unlock()
+
+	source, err := i.metadataSource(ctx, name)
+	if err != nil || source == nil {
+		return err
+	}
+
+	source.LastSync = now
+	source.LastError = ""
+	if syncErr != nil {
+		source.LastError = syncErr.Error()
+	}
+	source.LastRecordCount = result.records
+	source.LastUpdated = result.updated
+	source.LastUnmatched = result.unmatched
+
+	if scheduled && source.enabled() && !source.NextSync.After(now) {
+		if source.NextSync, err = nextScheduled(source.Schedule, source.Period, now); err != nil {
+			return err
+		}
+	}
+	return i.persistMetadataSource(ctx, source)
+}
+
+var metadataSourceHelp = map[string][2]string{
+	"metadata-source": {
+		"Configure an external source entity metadata is pulled from.",
+		`
+Metadata sources pull attributes from an external inventory, e.g. an HR system
+or a CMDB, into the metadata of entities of the root namespace, so that
+policies can be templated on them. 'http' sources fetch a JSON document from
+'url' and select its list of records with the 'records' JMESPath expression.
+'scim' sources list the users of the SCIM 2.0 service at 'url'.
+
+Each record is matched, with the 'match_key' expression, to the name of an
+entity, or to the name of its alias on 'mount_accessor'. The metadata keys of
+'metadata' are then set on the entity to the values their expression selects
+from the record, and removed when the expression selects nothing. Other
+metadata keys of the entity are left as is.
+
+Syncs run on 'sync_schedule' or every 'sync_period', and are disabled if
+neither is set.
+`,
+	},
+	"metadata-source-sync": {
+		"Pull entity metadata from a source immediately.",
+		`
+This pulls entity metadata from the source immediately, without changing the
+schedule of its next sync.
+`,
+	},
+	"metadata-source-list": {
+		"List the sources entity metadata is pulled from.",
+		"",
+	},
+}
@@ -0,0 +1,182 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIdentityStore_MetadataSource_HTTP verifies that a scheduled sync of an
+// HTTP metadata source merges the mapped attributes of its records into the
+// metadata of the entities they match by name.
+func TestIdentityStore_MetadataSource_HTTP(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	department := "engineering"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"employees": []interface{}{
+				map[string]interface{}{"login": "alice", "org": map[string]interface{}{"department": department}, "level": 3},
+				map[string]interface{}{"login": "bob", "org": map[string]interface{}{}},
+				map[string]interface{}{"login": "carol"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+			Path:      path,
+			Operation: op,
+			Data:      data,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	for _, name := range []string{"alice", "bob"} {
+		request(logical.UpdateOperation, "entity", map[string]interface{}{
+			"name":     name,
+			"metadata": map[string]string{"team": "core", "department": "sales"},
+		})
+	}
+
+	resp := request(logical.CreateOperation, "metadata-source/hr", map[string]interface{}{
+		"records":   "employees",
+		"match_key": "login",
+		"metadata":  map[string]string{"department": "org.department"},
+	})
+	require.True(t, resp.IsError())
+	require.Contains(t, resp.Error().Error(), "missing url")
+
+	resp = request(logical.CreateOperation, "metadata-source/hr", map[string]interface{}{
+		"url":          server.URL,
+		"bearer_token": "secret",
+		"records":      "employees",
+		"match_key":    "login[",
+		"metadata":     map[string]string{"department": "org.department"},
+	})
+	require.True(t, resp.IsError())
+	require.Contains(t, resp.Error().Error(), "invalid expression in match_key")
+
+	resp = request(logical.CreateOperation, "metadata-source/hr", map[string]interface{}{
+		"url":          server.URL,
+		"bearer_token": "secret",
+		"records":      "employees",
+		"match_key":    "login",
+		"metadata":     map[string]string{"department": "org.department", "level": "level"},
+		"sync_period":  "1h",
+	})
+	require.Nil(t, resp)
+
+	resp = request(logical.ReadOperation, "metadata-source/hr", nil)
+	require.NotContains(t, resp.Data, "bearer_token")
+	require.NotContains(t, resp.Data, "last_sync")
+
+	// Nothing is synced before the source is due
+	require.NoError(t, c.runMetadataSourceSync(ctx, time.Now()))
+	entity, err := c.identityStore.MemDBEntityByName(ctx, "alice", false)
+	require.NoError(t, err)
+	require.Equal(t, "sales", entity.Metadata["department"])
+
+	require.NoError(t, c.runMetadataSourceSync(ctx, time.Now().Add(2*time.Hour)))
+
+	entity, err = c.identityStore.MemDBEntityByName(ctx, "alice", false)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"team": "core", "department": "engineering", "level": "3"}, entity.Metadata)
+
+	// Mapped keys missing from the record are removed
+	entity, err = c.identityStore.MemDBEntityByName(ctx, "bob", false)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"team": "core"}, entity.Metadata)
+
+	resp = request(logical.ReadOperation, "metadata-source/hr", nil)
+	require.Equal(t, 3, resp.Data["last_record_count"])
+	require.Equal(t, 2, resp.Data["last_updated"])
+	require.Equal(t, 1, resp.Data["last_unmatched"])
+	require.Equal(t, "", resp.Data["last_error"])
+
+	// Syncs can be run on demand
+	department = "research"
+	resp = request(logical.UpdateOperation, "metadata-source/hr/sync", nil)
+	require.Equal(t, 1, resp.Data["updated"])
+	entity, err = c.identityStore.MemDBEntityByName(ctx, "alice", false)
+	require.NoError(t, err)
+	require.Equal(t, "research", entity.Metadata["department"])
+
+	resp = request(logical.ListOperation, "metadata-source/", nil)
+	require.Equal(t, []string{"hr"}, resp.Data["keys"])
+}
+
+// TestIdentityStore_MetadataSource_SCIM verifies that the users of a SCIM
+// source are listed across pages and matched to entities by alias.
+func TestIdentityStore_MetadataSource_SCIM(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/scim/v2/Users", r.URL.Path)
+		startIndex, err := strconv.Atoi(r.URL.Query().Get("startIndex"))
+		require.NoError(t, err)
+
+		users := []interface{}{
+			map[string]interface{}{"userName": "alice", "title": "SRE"},
+			map[string]interface{}{"userName": "dave", "title": "Manager"},
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"totalResults": len(users),
+			"Resources":    users[startIndex-1 : startIndex],
+		})
+	}))
+	defer server.Close()
+
+	accessor := c.router.MatchingMountEntry(ctx, "auth/token/").Accessor
+	resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity",
+		Operation: logical.UpdateOperation,
+		Data:      map[string]interface{}{"name": "entity-1"},
+	})
+	require.NoError(t, err)
+	entityID := resp.Data["id"].(string)
+	_, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity-alias",
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"name":           "alice",
+			"canonical_id":   entityID,
+			"mount_accessor": accessor,
+		},
+	})
+	require.NoError(t, err)
+
+	source := &metadataSource{
+		Name:          "directory",
+		Type:          metadataSourceTypeSCIM,
+		URL:           server.URL + "/scim/v2/",
+		MatchKey:      "userName",
+		MountAccessor: accessor,
+		Metadata:      map[string]string{"title": "title"},
+	}
+	require.NoError(t, c.identityStore.validateMetadataSource(source))
+
+	result, err := c.identityStore.syncMetadataSource(ctx, source)
+	require.NoError(t, err)
+	require.Equal(t, &metadataSourceSyncResult{records: 2, updated: 1, unmatched: 1}, result)
+
+	entity, err := c.identityStore.MemDBEntityByID(entityID, false)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"title": "SRE"}, entity.Metadata)
+}
@@ -5,8 +5,10 @@ package vault
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -36,9 +38,12 @@ const (
 	clientIDLength           = 32
 	clientSecretLength       = 64
 	clientSecretPrefix       = "hvo_secret_"
+	refreshTokenLength       = 64
+	refreshTokenPrefix       = "hvo_refresh_"
 	codeChallengeMethodPlain = "plain"
 	codeChallengeMethodS256  = "S256"
 	defaultProviderName      = "default"
+	dynamicClientNamePrefix  = "dynamic-"
 	defaultKeyName           = "default"
 	allowAllAssignmentName   = "allow_all"
 
@@ -48,6 +53,7 @@ const (
 	scopePath          = oidcProviderPrefix + "scope/"
 	clientPath         = oidcProviderPrefix + "client/"
 	providerPath       = oidcProviderPrefix + "provider/"
+	refreshTokenPath   = oidcProviderPrefix + "refresh_token/"
 
 	// Error constants used in the Authorization Endpoint. See details at
 	// https://openid.net/specs/openid-connect-core-1_0.html#AuthError.
@@ -74,6 +80,11 @@ const (
 	ErrUserInfoInvalidToken   = "invalid_token"
 	ErrUserInfoAccessDenied   = "access_denied"
 
+	// Error constants used in the Client Registration Endpoint. See details at
+	// https://datatracker.ietf.org/doc/html/rfc7591#section-3.2.2
+	ErrRegistrationInvalidRedirectURI    = "invalid_redirect_uri"
+	ErrRegistrationInvalidClientMetadata = "invalid_client_metadata"
+
 	// The following errors are used by the UI for specific behavior of
 	// the OIDC specification. Any changes to their values must come with
 	// a corresponding change in the UI code.
@@ -98,12 +109,13 @@ type client struct {
 	NamespaceID string `json:"namespace_id"`
 
 	// User-supplied parameters
-	RedirectURIs   []string      `json:"redirect_uris"`
-	Assignments    []string      `json:"assignments"`
-	Key            string        `json:"key"`
-	IDTokenTTL     time.Duration `json:"id_token_ttl"`
-	AccessTokenTTL time.Duration `json:"access_token_ttl"`
-	Type           clientType    `json:"type"`
+	RedirectURIs    []string      `json:"redirect_uris"`
+	Assignments     []string      `json:"assignments"`
+	Key             string        `json:"key"`
+	IDTokenTTL      time.Duration `json:"id_token_ttl"`
+	AccessTokenTTL  time.Duration `json:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `json:"refresh_token_ttl"`
+	Type            clientType    `json:"type"`
 
 	// Generated values that are used in OIDC endpoints
 	ClientID     string `json:"client_id"`
@@ -157,6 +169,7 @@ type providerDiscovery struct {
 	AuthorizationEndpoint string   `json:"authorization_endpoint"`
 	TokenEndpoint         string   `json:"token_endpoint"`
 	UserinfoEndpoint      string   `json:"userinfo_endpoint"`
+	RegistrationEndpoint  string   `json:"registration_endpoint"`
 	RequestParameter      bool     `json:"request_parameter_supported"`
 	RequestURIParameter   bool     `json:"request_uri_parameter_supported"`
 	IDTokenAlgs           []string `json:"id_token_signing_alg_values_supported"`
@@ -181,6 +194,17 @@ type authCodeCacheEntry struct {
 	codeChallengeMethod string
 }
 
+// refreshTokenEntry is the stored grant of an OIDC refresh token. It is keyed
+// by the hash of the refresh token, which is single use.
+type refreshTokenEntry struct {
+	Provider   string    `json:"provider"`
+	ClientID   string    `json:"client_id"`
+	EntityID   string    `json:"entity_id"`
+	Scopes     []string  `json:"scopes"`
+	AuthTime   time.Time `json:"auth_time"`
+	ExpireTime time.Time `json:"expire_time"`
+}
+
 func oidcProviderPaths(i *IdentityStore) []*framework.Path {
 	return []*framework.Path{
 		{
@@ -293,40 +317,7 @@ func oidcProviderPaths(i *IdentityStore) []*framework.Path {
 				OperationPrefix: "oidc",
 				OperationSuffix: "client",
 			},
-			Fields: map[string]*framework.FieldSchema{
-				"name": {
-					Type:        framework.TypeString,
-					Description: "Name of the client.",
-				},
-				"redirect_uris": {
-					Type:        framework.TypeCommaStringSlice,
-					Description: "Comma separated string or array of redirect URIs used by the client. One of these values must exactly match the redirect_uri parameter value used in each authentication request.",
-				},
-				"assignments": {
-					Type:        framework.TypeCommaStringSlice,
-					Description: "Comma separated string or array of assignment resources.",
-				},
-				"key": {
-					Type:        framework.TypeString,
-					Description: "A reference to a named key resource. Cannot be modified after creation. Defaults to the 'default' key.",
-					Default:     "default",
-				},
-				"id_token_ttl": {
-					Type:        framework.TypeDurationSecond,
-					Description: "The time-to-live for ID tokens obtained by the client.",
-					Default:     "24h",
-				},
-				"access_token_ttl": {
-					Type:        framework.TypeDurationSecond,
-					Description: "The time-to-live for access tokens obtained by the client.",
-					Default:     "24h",
-				},
-				"client_type": {
-					Type:        framework.TypeString,
-					Description: "The client type based on its ability to maintain confidentiality of credentials. The following client types are supported: 'confidential', 'public'. Defaults to 'confidential'.",
-					Default:     "confidential",
-				},
-			},
+			Fields: oidcClientFields(),
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.UpdateOperation: &framework.PathOperation{
 					Callback: i.pathOIDCCreateUpdateClient,
@@ -560,18 +551,20 @@ func oidcProviderPaths(i *IdentityStore) []*framework.Path {
 				},
 				"code": {
 					Type:        framework.TypeString,
-					Description: "The authorization code received from the provider's authorization endpoint.",
-					Required:    true,
+					Description: "The authorization code received from the provider's authorization endpoint. Required for the 'authorization_code' grant type.",
 				},
 				"grant_type": {
 					Type:        framework.TypeString,
-					Description: "The authorization grant type. The following grant types are supported: 'authorization_code'.",
+					Description: "The authorization grant type. The following grant types are supported: 'authorization_code', 'refresh_token'.",
 					Required:    true,
 				},
 				"redirect_uri": {
 					Type:        framework.TypeString,
-					Description: "The callback location where the authentication response was sent.",
-					Required:    true,
+					Description: "The callback location where the authentication response was sent. Required for the 'authorization_code' grant type.",
+				},
+				"refresh_token": {
+					Type:        framework.TypeString,
+					Description: "The refresh token issued to the client. Required for the 'refresh_token' grant type.",
 				},
 				"code_verifier": {
 					Type:        framework.TypeString,
@@ -603,7 +596,7 @@ func oidcProviderPaths(i *IdentityStore) []*framework.Path {
 				},
 			},
 			HelpSynopsis:    "Provides the OIDC Token Endpoint.",
-			HelpDescription: "The OIDC Token Endpoint allows a client to exchange its Authorization Grant for an Access Token and ID Token.",
+			HelpDescription: "The OIDC Token Endpoint allows a client to exchange its Authorization Grant or Refresh Token for an Access Token and ID Token.",
 		},
 		{
 			Pattern: "oidc/provider/" + framework.GenericNameRegex("name") + "/userinfo",
@@ -633,9 +626,106 @@ func oidcProviderPaths(i *IdentityStore) []*framework.Path {
 			HelpSynopsis:    "Provides the OIDC UserInfo Endpoint.",
 			HelpDescription: "The OIDC UserInfo Endpoint returns claims about the authenticated end-user.",
 		},
+		{
+			Pattern: "oidc/provider/" + framework.GenericNameRegex("name") + "/register",
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "oidc-provider",
+				OperationVerb:   "register",
+				OperationSuffix: "client",
+			},
+			Fields: oidcClientRegistrationFields(),
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.pathOIDCRegisterClient,
+				},
+			},
+			HelpSynopsis:    "Provides the OAuth 2.0 Dynamic Client Registration Endpoint.",
+			HelpDescription: "The Client Registration Endpoint registers a new client of the provider from the client metadata defined by RFC 7591. Registration requires a token allowed to update this path.",
+		},
 	}
 }
 
+// oidcClientFields returns the fields of the OIDC client configuration.
+func oidcClientFields() map[string]*framework.FieldSchema {
+	return map[string]*framework.FieldSchema{
+		"name": {
+			Type:        framework.TypeString,
+			Description: "Name of the client.",
+		},
+		"redirect_uris": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: "Comma separated string or array of redirect URIs used by the client. One of these values must exactly match the redirect_uri parameter value used in each authentication request.",
+		},
+		"assignments": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: "Comma separated string or array of assignment resources.",
+		},
+		"key": {
+			Type:        framework.TypeString,
+			Description: "A reference to a named key resource. Cannot be modified after creation. Defaults to the 'default' key.",
+			Default:     "default",
+		},
+		"id_token_ttl": {
+			Type:        framework.TypeDurationSecond,
+			Description: "The time-to-live for ID tokens obtained by the client.",
+			Default:     "24h",
+		},
+		"access_token_ttl": {
+			Type:        framework.TypeDurationSecond,
+			Description: "The time-to-live for access tokens obtained by the client.",
+			Default:     "24h",
+		},
+		"refresh_token_ttl": {
+			Type:        framework.TypeDurationSecond,
+			Description: "The time-to-live for refresh tokens obtained by the client. Refresh tokens are not issued to the client if zero.",
+			Default:     0,
+		},
+		"client_type": {
+			Type:        framework.TypeString,
+			Description: "The client type based on its ability to maintain confidentiality of credentials. The following client types are supported: 'confidential', 'public'. Defaults to 'confidential'.",
+			Default:     "confidential",
+		},
+	}
+}
+
+// oidcClientRegistrationFields returns the fields of the client registration
+// endpoint: the client metadata defined by RFC 7591, as well as the OIDC
+// client configuration that the metadata does not cover.
+func oidcClientRegistrationFields() map[string]*framework.FieldSchema {
+	fields := map[string]*framework.FieldSchema{
+		"name": {
+			Type:        framework.TypeString,
+			Description: "Name of the provider",
+		},
+		"client_name": {
+			Type:        framework.TypeString,
+			Description: "Human-readable name of the client.",
+		},
+		"token_endpoint_auth_method": {
+			Type:        framework.TypeString,
+			Description: "The authentication method of the client at the token endpoint. The following methods are supported: 'client_secret_basic', 'client_secret_post', 'none'. Clients using 'none' are public clients.",
+			Default:     "client_secret_basic",
+		},
+		"grant_types": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: "The grant types the client may use. The following grant types are supported: 'authorization_code', 'refresh_token'. Defaults to 'authorization_code'.",
+		},
+		"response_types": {
+			Type:        framework.TypeCommaStringSlice,
+			Description: "The response types the client may use. The following response types are supported: 'code'. Defaults to 'code'.",
+		},
+	}
+	for name, field := range oidcClientFields() {
+		switch name {
+		case "name", "client_type":
+		default:
+			fields[name] = field
+		}
+	}
+
+	return fields
+}
+
 // clientsReferencingTargetAssignmentName returns a map of client names to
 // clients referencing targetAssignmentName.
 func (i *IdentityStore) clientsReferencingTargetAssignmentName(ctx context.Context, req *logical.Request, targetAssignmentName string) (map[string]client, error) {
@@ -1152,6 +1242,12 @@ func (i *IdentityStore) pathOIDCCreateUpdateClient(ctx context.Context, req *log
 		client.AccessTokenTTL = time.Duration(d.Get("access_token_ttl").(int)) * time.Second
 	}
 
+	if refreshTokenTTLRaw, ok := d.GetOk("refresh_token_ttl"); ok {
+		client.RefreshTokenTTL = time.Duration(refreshTokenTTLRaw.(int)) * time.Second
+	} else if req.Operation == logical.CreateOperation {
+		client.RefreshTokenTTL = time.Duration(d.Get("refresh_token_ttl").(int)) * time.Second
+	}
+
 	if clientTypeRaw, ok := d.GetOk("client_type"); ok {
 		clientType := clientTypeRaw.(string)
 		if req.Operation == logical.UpdateOperation && client.Type.String() != clientType {
@@ -1216,13 +1312,14 @@ func (i *IdentityStore) pathOIDCListClient(ctx context.Context, req *logical.Req
 	for _, client := range clients {
 		keys = append(keys, client.Name)
 		keyInfo[client.Name] = map[string]interface{}{
-			"redirect_uris":    client.RedirectURIs,
-			"assignments":      client.Assignments,
-			"key":              client.Key,
-			"id_token_ttl":     int64(client.IDTokenTTL.Seconds()),
-			"access_token_ttl": int64(client.AccessTokenTTL.Seconds()),
-			"client_type":      client.Type.String(),
-			"client_id":        client.ClientID,
+			"redirect_uris":     client.RedirectURIs,
+			"assignments":       client.Assignments,
+			"key":               client.Key,
+			"id_token_ttl":      int64(client.IDTokenTTL.Seconds()),
+			"access_token_ttl":  int64(client.AccessTokenTTL.Seconds()),
+			"refresh_token_ttl": int64(client.RefreshTokenTTL.Seconds()),
+			"client_type":       client.Type.String(),
+			"client_id":         client.ClientID,
 			// client_secret is intentionally omitted
 		}
 	}
@@ -1244,13 +1341,14 @@ func (i *IdentityStore) pathOIDCReadClient(ctx context.Context, req *logical.Req
 
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"redirect_uris":    client.RedirectURIs,
-			"assignments":      client.Assignments,
-			"key":              client.Key,
-			"id_token_ttl":     int64(client.IDTokenTTL.Seconds()),
-			"access_token_ttl": int64(client.AccessTokenTTL.Seconds()),
-			"client_id":        client.ClientID,
-			"client_type":      client.Type.String(),
+			"redirect_uris":     client.RedirectURIs,
+			"assignments":       client.Assignments,
+			"key":               client.Key,
+			"id_token_ttl":      int64(client.IDTokenTTL.Seconds()),
+			"access_token_ttl":  int64(client.AccessTokenTTL.Seconds()),
+			"refresh_token_ttl": int64(client.RefreshTokenTTL.Seconds()),
+			"client_id":         client.ClientID,
+			"client_type":       client.Type.String(),
 		},
 	}
 
@@ -1559,6 +1657,7 @@ func (i *IdentityStore) pathOIDCProviderDiscovery(ctx context.Context, req *logi
 		AuthorizationEndpoint: strings.Replace(p.effectiveIssuer, "/v1/", "/ui/vault/", 1) + "/authorize",
 		TokenEndpoint:         p.effectiveIssuer + "/token",
 		UserinfoEndpoint:      p.effectiveIssuer + "/userinfo",
+		RegistrationEndpoint:  p.effectiveIssuer + "/register",
 		IDTokenAlgs:           supportedAlgs,
 		Scopes:                scopes,
 		Claims:                []string{},
@@ -1566,7 +1665,7 @@ func (i *IdentityStore) pathOIDCProviderDiscovery(ctx context.Context, req *logi
 		RequestURIParameter:   false,
 		ResponseTypes:         []string{"code"},
 		Subjects:              []string{"public"},
-		GrantTypes:            []string{"authorization_code"},
+		GrantTypes:            []string{"authorization_code", "refresh_token"},
 		AuthMethods: []string{
 			// PKCE is required for auth method "none"
 			"none",
@@ -1596,6 +1695,163 @@ func (i *IdentityStore) pathOIDCProviderDiscovery(ctx context.Context, req *logi
 	return resp, nil
 }
 
+// pathOIDCRegisterClient implements the OAuth 2.0 Dynamic Client Registration
+// Protocol (RFC 7591). The registered client authenticates no entity unless
+// given assignments, and may only use the provider if the provider's allowed
+// client IDs include it, which registration does not change.
+func (i *IdentityStore) pathOIDCRegisterClient(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	providerName := d.Get("name").(string)
+	p, err := i.getOIDCProvider(ctx, req.Storage, providerName)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return registrationResponse(nil, ErrRegistrationInvalidClientMetadata, "provider not found")
+	}
+
+	redirectURIs := strutil.RemoveDuplicates(d.Get("redirect_uris").([]string), false)
+	if len(redirectURIs) == 0 {
+		return registrationResponse(nil, ErrRegistrationInvalidRedirectURI, "redirect_uris is required")
+	}
+	for _, redirectURI := range redirectURIs {
+		u, err := url.Parse(redirectURI)
+		if err != nil || !u.IsAbs() || u.Host == "" || u.Fragment != "" {
+			return registrationResponse(nil, ErrRegistrationInvalidRedirectURI, fmt.Sprintf("invalid redirect URI %q", redirectURI))
+		}
+	}
+
+	authMethod := d.Get("token_endpoint_auth_method").(string)
+	var clientType clientType
+	switch authMethod {
+	case "client_secret_basic", "client_secret_post":
+		clientType = confidential
+	case "none":
+		clientType = public
+	default:
+		return registrationResponse(nil, ErrRegistrationInvalidClientMetadata, fmt.Sprintf("unsupported token_endpoint_auth_method %q", authMethod))
+	}
+
+	grantTypes := strutil.RemoveDuplicates(d.Get("grant_types").([]string), false)
+	if len(grantTypes) == 0 {
+		grantTypes = []string{"authorization_code"}
+	}
+	for _, grantType := range grantTypes {
+		if grantType != "authorization_code" && grantType != "refresh_token" {
+			return registrationResponse(nil, ErrRegistrationInvalidClientMetadata, fmt.Sprintf("unsupported grant type %q", grantType))
+		}
+	}
+	if !strutil.StrListContains(grantTypes, "authorization_code") {
+		return registrationResponse(nil, ErrRegistrationInvalidClientMetadata, "grant_types must contain \"authorization_code\"")
+	}
+
+	responseTypes := strutil.RemoveDuplicates(d.Get("response_types").([]string), false)
+	if len(responseTypes) == 0 {
+		responseTypes = []string{"code"}
+	}
+	for _, responseType := range responseTypes {
+		if responseType != "code" {
+			return registrationResponse(nil, ErrRegistrationInvalidClientMetadata, fmt.Sprintf("unsupported response type %q", responseType))
+		}
+	}
+
+	random, err := base62.Random(16)
+	if err != nil {
+		return nil, err
+	}
+	clientName := dynamicClientNamePrefix + strings.ToLower(random)
+
+	// Create the client as if through its configuration endpoint
+	raw := map[string]interface{}{
+		"name":          clientName,
+		"client_type":   clientType.String(),
+		"redirect_uris": redirectURIs,
+	}
+	for _, field := range []string{"assignments", "key", "id_token_ttl", "access_token_ttl", "refresh_token_ttl"} {
+		if value, ok := d.GetOk(field); ok {
+			raw[field] = value
+		}
+	}
+	switch _, ok := raw["refresh_token_ttl"]; {
+	case !strutil.StrListContains(grantTypes, "refresh_token") && ok:
+		return registrationResponse(nil, ErrRegistrationInvalidClientMetadata, "refresh_token_ttl requires the \"refresh_token\" grant type")
+	case strutil.StrListContains(grantTypes, "refresh_token") && !ok:
+		raw["refresh_token_ttl"] = "24h"
+	}
+
+	clientReq := *req
+	clientReq.Operation = logical.CreateOperation
+	clientReq.Path = "oidc/client/" + clientName
+	resp, err := i.pathOIDCCreateUpdateClient(ctx, &clientReq, &framework.FieldData{
+		Raw:    raw,
+		Schema: oidcClientFields(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return registrationResponse(nil, ErrRegistrationInvalidClientMetadata, resp.Error().Error())
+	}
+
+	client, err := i.clientByName(ctx, req.Storage, clientName)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, fmt.Errorf("registered client %q not found", clientName)
+	}
+
+	response := map[string]interface{}{
+		"client_id":                  client.ClientID,
+		"client_id_issued_at":        time.Now().Unix(),
+		"client_name":                d.Get("client_name").(string),
+		"redirect_uris":              client.RedirectURIs,
+		"token_endpoint_auth_method": authMethod,
+		"grant_types":                grantTypes,
+		"response_types":             responseTypes,
+		"vault_client_name":          clientName,
+	}
+	if client.Type == confidential {
+		response["client_secret"] = client.ClientSecret
+		// Client secrets do not expire
+		response["client_secret_expires_at"] = 0
+	}
+
+	return registrationResponse(response, "", "")
+}
+
+// registrationResponse returns the OAuth 2.0 Client Information Response. An
+// error response is returned if the given error code is non-empty. For
+// details, see spec at
+//   - https://datatracker.ietf.org/doc/html/rfc7591#section-3.2.1
+//   - https://datatracker.ietf.org/doc/html/rfc7591#section-3.2.2
+func registrationResponse(response map[string]interface{}, errorCode, errorDescription string) (*logical.Response, error) {
+	statusCode := http.StatusCreated
+	if errorCode != "" {
+		statusCode = http.StatusBadRequest
+		response = map[string]interface{}{
+			"error":             errorCode,
+			"error_description": errorDescription,
+		}
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPStatusCode:  statusCode,
+			logical.HTTPRawBody:     body,
+			logical.HTTPContentType: "application/json",
+
+			// Responses containing client secrets must not be cached
+			logical.HTTPCacheControlHeader: "no-store",
+			logical.HTTPPragmaHeader:       "no-cache",
+		},
+	}, nil
+}
+
 // pathOIDCReadProviderPublicKeys is used to retrieve all public keys for a
 // named provider so that clients can verify the validity of a signed OIDC token.
 func (i *IdentityStore) pathOIDCReadProviderPublicKeys(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
@@ -2000,7 +2256,11 @@ func (i *IdentityStore) pathOIDCToken(ctx context.Context, req *logical.Request,
 	if grantType == "" {
 		return tokenResponse(nil, ErrTokenInvalidRequest, "grant_type parameter is required")
 	}
-	if grantType != "authorization_code" {
+	switch grantType {
+	case "authorization_code":
+	case "refresh_token":
+		return i.pathOIDCRefreshToken(ctx, req, d, ns, provider, client, key)
+	default:
 		return tokenResponse(nil, ErrTokenUnsupportedGrantType, "unsupported grant_type value")
 	}
 
@@ -2084,6 +2344,13 @@ func (i *IdentityStore) pathOIDCToken(ctx context.Context, req *logical.Request,
 		}
 	}
 
+	return i.issueOIDCTokens(ctx, req, ns, provider, client, key, entity, authCodeEntry, code)
+}
+
+// issueOIDCTokens issues an access token, ID token and, if the client obtains
+// them, refresh token for the given grant. The code is the authorization code
+// of the grant, or empty for refresh token grants.
+func (i *IdentityStore) issueOIDCTokens(ctx context.Context, req *logical.Request, ns *namespace.Namespace, provider *provider, client *client, key *namedKey, entity *identity.Entity, grant *authCodeCacheEntry, code string) (*logical.Response, error) {
 	// The access token is a Vault batch token with a policy that only
 	// provides access to the issuing provider's userinfo endpoint.
 	accessTokenIssuedAt := time.Now()
@@ -2101,15 +2368,15 @@ func (i *IdentityStore) pathOIDCToken(ctx context.Context, req *logical.Request,
 		},
 		InternalMeta: map[string]string{
 			accessTokenClientIDMeta: client.ClientID,
-			accessTokenScopesMeta:   strings.Join(authCodeEntry.scopes, scopesDelimiter),
+			accessTokenScopesMeta:   strings.Join(grant.scopes, scopesDelimiter),
 		},
 		InlinePolicy: fmt.Sprintf(`
 			path "identity/oidc/provider/%s/userinfo" {
 				capabilities = ["read", "update"]
 			}
-		`, name),
+		`, grant.provider),
 	}
-	err = i.tokenStorer.CreateToken(ctx, accessToken)
+	err := i.tokenStorer.CreateToken(ctx, accessToken)
 	if err != nil {
 		return tokenResponse(nil, ErrTokenServerError, err.Error())
 	}
@@ -2120,10 +2387,14 @@ func (i *IdentityStore) pathOIDCToken(ctx context.Context, req *logical.Request,
 		return tokenResponse(nil, ErrTokenServerError, err.Error())
 	}
 
-	// Compute the authorization code hash claim (c_hash)
-	cHash, err := computeHashClaim(key.Algorithm, code)
-	if err != nil {
-		return tokenResponse(nil, ErrTokenServerError, err.Error())
+	// Compute the authorization code hash claim (c_hash). It is omitted
+	// for refresh token grants.
+	var cHash string
+	if code != "" {
+		cHash, err = computeHashClaim(key.Algorithm, code)
+		if err != nil {
+			return tokenResponse(nil, ErrTokenServerError, err.Error())
+		}
 	}
 
 	// Set the ID token claims
@@ -2132,9 +2403,9 @@ func (i *IdentityStore) pathOIDCToken(ctx context.Context, req *logical.Request,
 	idToken := idToken{
 		Namespace:       ns.ID,
 		Issuer:          provider.effectiveIssuer,
-		Subject:         authCodeEntry.entityID,
-		Audience:        authCodeEntry.clientID,
-		Nonce:           authCodeEntry.nonce,
+		Subject:         grant.entityID,
+		Audience:        grant.clientID,
+		Nonce:           grant.nonce,
 		Expiry:          idTokenExpiry.Unix(),
 		IssuedAt:        idTokenIssuedAt.Unix(),
 		AccessTokenHash: atHash,
@@ -2142,12 +2413,12 @@ func (i *IdentityStore) pathOIDCToken(ctx context.Context, req *logical.Request,
 	}
 
 	// Add the auth_time claim if it's not the zero time instant
-	if !authCodeEntry.authTime.IsZero() {
-		idToken.AuthTime = authCodeEntry.authTime.Unix()
+	if !grant.authTime.IsZero() {
+		idToken.AuthTime = grant.authTime.Unix()
 	}
 
 	// Populate each of the requested scope templates
-	templates, conflict, err := i.populateScopeTemplates(ctx, req.Storage, ns, entity, authCodeEntry.scopes...)
+	templates, conflict, err := i.populateScopeTemplates(ctx, req.Storage, ns, entity, grant.scopes...)
 	if !conflict && err != nil {
 		return tokenResponse(nil, ErrTokenServerError, err.Error())
 	}
@@ -2167,12 +2438,170 @@ func (i *IdentityStore) pathOIDCToken(ctx context.Context, req *logical.Request,
 		return tokenResponse(nil, ErrTokenServerError, err.Error())
 	}
 
-	return tokenResponse(map[string]interface{}{
+	response := map[string]interface{}{
 		"token_type":   "Bearer",
 		"access_token": accessToken.ID,
 		"id_token":     signedIDToken,
 		"expires_in":   int64(accessTokenExpiry.Sub(accessTokenIssuedAt).Seconds()),
-	}, "", "")
+	}
+
+	// Issue a refresh token if the client is configured to obtain them
+	if client.RefreshTokenTTL > 0 {
+		refreshToken, err := i.createOIDCRefreshToken(ctx, req.Storage, client, grant)
+		if err != nil {
+			return tokenResponse(nil, ErrTokenServerError, err.Error())
+		}
+		response["refresh_token"] = refreshToken
+	}
+
+	return tokenResponse(response, "", "")
+}
+
+// pathOIDCRefreshToken handles the refresh_token grant type of the token
+// endpoint. Refresh tokens are rotated: the given refresh token is revoked and
+// a new one is issued along with the new access and ID tokens.
+func (i *IdentityStore) pathOIDCRefreshToken(ctx context.Context, req *logical.Request, d *framework.FieldData, ns *namespace.Namespace, provider *provider, client *client, key *namedKey) (*logical.Response, error) {
+	refreshToken := d.Get("refresh_token").(string)
+	if refreshToken == "" {
+		return tokenResponse(nil, ErrTokenInvalidRequest, "refresh_token parameter is required")
+	}
+
+	entry, err := i.redeemOIDCRefreshToken(ctx, req.Storage, refreshToken, client.ClientID, d.Get("name").(string))
+	if err != nil {
+		return tokenResponse(nil, ErrTokenServerError, err.Error())
+	}
+	if entry == nil {
+		return tokenResponse(nil, ErrTokenInvalidGrant, "refresh token is invalid or expired")
+	}
+	if client.RefreshTokenTTL <= 0 {
+		return tokenResponse(nil, ErrTokenInvalidGrant, "refresh tokens are not enabled for the client")
+	}
+
+	// Get the entity associated with the initial authorization request
+	entity, err := i.MemDBEntityByID(entry.EntityID, true)
+	if err != nil {
+		return tokenResponse(nil, ErrTokenServerError, err.Error())
+	}
+	if entity == nil || entity.Disabled {
+		return tokenResponse(nil, ErrTokenInvalidGrant, "identity entity associated with the refresh token not found")
+	}
+
+	// Validate that the entity is still a member of the client's assignments
+	isMember, err := i.entityHasAssignment(ctx, req.Storage, entity, client.Assignments)
+	if err != nil {
+		return tokenResponse(nil, ErrTokenServerError, err.Error())
+	}
+	if !isMember {
+		return tokenResponse(nil, ErrTokenInvalidGrant, "identity entity not authorized by client assignment")
+	}
+
+	return i.issueOIDCTokens(ctx, req, ns, provider, client, key, entity, &authCodeCacheEntry{
+		provider: entry.Provider,
+		clientID: entry.ClientID,
+		entityID: entry.EntityID,
+		scopes:   entry.Scopes,
+		authTime: entry.AuthTime,
+	}, "")
+}
+
+// createOIDCRefreshToken stores a new refresh token for the given grant and
+// returns it.
+func (i *IdentityStore) createOIDCRefreshToken(ctx context.Context, s logical.Storage, client *client, grant *authCodeCacheEntry) (string, error) {
+	random, err := base62.Random(refreshTokenLength)
+	if err != nil {
+		return "", err
+	}
+	refreshToken := refreshTokenPrefix + random
+
+	entry, err := logical.StorageEntryJSON(refreshTokenPath+hashRefreshToken(refreshToken), &refreshTokenEntry{
+		Provider:   grant.provider,
+		ClientID:   client.ClientID,
+		EntityID:   grant.entityID,
+		Scopes:     grant.scopes,
+		AuthTime:   grant.authTime,
+		ExpireTime: time.Now().Add(client.RefreshTokenTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := s.Put(ctx, entry); err != nil {
+		return "", err
+	}
+
+	return refreshToken, nil
+}
+
+// redeemOIDCRefreshToken returns the grant of the refresh token if it was
+// issued to the given client by the given provider and has not expired,
+// deleting it so that it can only be used once. It returns nil otherwise.
+func (i *IdentityStore) redeemOIDCRefreshToken(ctx context.Context, s logical.Storage, refreshToken, clientID, providerName string) (*refreshTokenEntry, error) {
+	i.oidcRefreshTokenLock.Lock()
+	defer i.oidcRefreshTokenLock.Unlock()
+
+	path := refreshTokenPath + hashRefreshToken(refreshToken)
+	raw, err := s.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	var entry refreshTokenEntry
+	if err := raw.DecodeJSON(&entry); err != nil {
+		return nil, err
+	}
+	if entry.ClientID != clientID || entry.Provider != providerName {
+		return nil, nil
+	}
+
+	if err := s.Delete(ctx, path); err != nil {
+		return nil, err
+	}
+	if time.Now().After(entry.ExpireTime) {
+		return nil, nil
+	}
+
+	return &entry, nil
+}
+
+// expireOIDCRefreshTokens deletes the expired refresh tokens in the given
+// storage.
+func (i *IdentityStore) expireOIDCRefreshTokens(ctx context.Context, s logical.Storage) error {
+	hashes, err := s.List(ctx, refreshTokenPath)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, hash := range hashes {
+		raw, err := s.Get(ctx, refreshTokenPath+hash)
+		if err != nil {
+			return err
+		}
+		if raw == nil {
+			continue
+		}
+
+		var entry refreshTokenEntry
+		if err := raw.DecodeJSON(&entry); err != nil {
+			return err
+		}
+		if now.After(entry.ExpireTime) {
+			if err := s.Delete(ctx, refreshTokenPath+hash); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// hashRefreshToken returns the storage key of a refresh token, so that
+// refresh tokens are not persisted in plaintext.
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
 }
 
 // tokenResponse returns the OIDC Token Response. An error response is
@@ -1898,14 +1898,15 @@ func TestOIDC_Path_OIDC_ProviderClient(t *testing.T) {
 	})
 	expectSuccess(t, resp, err)
 	expected := map[string]interface{}{
-		"redirect_uris":    []string{},
-		"assignments":      []string{},
-		"key":              "test-key",
-		"id_token_ttl":     int64(60),
-		"access_token_ttl": int64(86400),
-		"client_id":        resp.Data["client_id"],
-		"client_secret":    resp.Data["client_secret"],
-		"client_type":      confidential.String(),
+		"redirect_uris":     []string{},
+		"assignments":       []string{},
+		"key":               "test-key",
+		"id_token_ttl":      int64(60),
+		"access_token_ttl":  int64(86400),
+		"refresh_token_ttl": int64(0),
+		"client_id":         resp.Data["client_id"],
+		"client_secret":     resp.Data["client_secret"],
+		"client_type":       confidential.String(),
 	}
 	if diff := deep.Equal(expected, resp.Data); diff != nil {
 		t.Fatal(diff)
@@ -1953,14 +1954,15 @@ func TestOIDC_Path_OIDC_ProviderClient(t *testing.T) {
 	})
 	expectSuccess(t, resp, err)
 	expected = map[string]interface{}{
-		"redirect_uris":    []string{"http://localhost:3456/callback"},
-		"assignments":      []string{"my-assignment"},
-		"key":              "test-key",
-		"id_token_ttl":     int64(90),
-		"access_token_ttl": int64(60),
-		"client_id":        resp.Data["client_id"],
-		"client_secret":    resp.Data["client_secret"],
-		"client_type":      confidential.String(),
+		"redirect_uris":     []string{"http://localhost:3456/callback"},
+		"assignments":       []string{"my-assignment"},
+		"key":               "test-key",
+		"id_token_ttl":      int64(90),
+		"access_token_ttl":  int64(60),
+		"refresh_token_ttl": int64(0),
+		"client_id":         resp.Data["client_id"],
+		"client_secret":     resp.Data["client_secret"],
+		"client_type":       confidential.String(),
 	}
 	if diff := deep.Equal(expected, resp.Data); diff != nil {
 		t.Fatal(diff)
@@ -2033,13 +2035,14 @@ func TestOIDC_Path_OIDC_ProviderClient_Deduplication(t *testing.T) {
 	})
 	expectSuccess(t, resp, err)
 	expected := map[string]interface{}{
-		"redirect_uris":    []string{"http://example.com", "http://notduplicate.com"},
-		"assignments":      []string{"test-assignment1"},
-		"key":              "test-key",
-		"id_token_ttl":     int64(60),
-		"access_token_ttl": int64(86400),
-		"client_id":        resp.Data["client_id"],
-		"client_type":      public.String(),
+		"redirect_uris":     []string{"http://example.com", "http://notduplicate.com"},
+		"assignments":       []string{"test-assignment1"},
+		"key":               "test-key",
+		"id_token_ttl":      int64(60),
+		"access_token_ttl":  int64(86400),
+		"refresh_token_ttl": int64(0),
+		"client_id":         resp.Data["client_id"],
+		"client_type":       public.String(),
 	}
 	if diff := deep.Equal(expected, resp.Data); diff != nil {
 		t.Fatal(diff)
@@ -2094,14 +2097,15 @@ func TestOIDC_Path_OIDC_ProviderClient_Update(t *testing.T) {
 	})
 	expectSuccess(t, resp, err)
 	expected := map[string]interface{}{
-		"redirect_uris":    []string{"http://localhost:3456/callback"},
-		"assignments":      []string{"my-assignment"},
-		"key":              "test-key",
-		"id_token_ttl":     int64(120),
-		"access_token_ttl": int64(3600),
-		"client_id":        resp.Data["client_id"],
-		"client_secret":    resp.Data["client_secret"],
-		"client_type":      confidential.String(),
+		"redirect_uris":     []string{"http://localhost:3456/callback"},
+		"assignments":       []string{"my-assignment"},
+		"key":               "test-key",
+		"id_token_ttl":      int64(120),
+		"access_token_ttl":  int64(3600),
+		"refresh_token_ttl": int64(0),
+		"client_id":         resp.Data["client_id"],
+		"client_secret":     resp.Data["client_secret"],
+		"client_type":       confidential.String(),
 	}
 	if diff := deep.Equal(expected, resp.Data); diff != nil {
 		t.Fatal(diff)
@@ -2128,14 +2132,15 @@ func TestOIDC_Path_OIDC_ProviderClient_Update(t *testing.T) {
 	})
 	expectSuccess(t, resp, err)
 	expected = map[string]interface{}{
-		"redirect_uris":    []string{"http://localhost:3456/callback2"},
-		"assignments":      []string{"my-assignment"},
-		"key":              "test-key",
-		"id_token_ttl":     int64(30),
-		"access_token_ttl": int64(60),
-		"client_id":        resp.Data["client_id"],
-		"client_secret":    resp.Data["client_secret"],
-		"client_type":      confidential.String(),
+		"redirect_uris":     []string{"http://localhost:3456/callback2"},
+		"assignments":       []string{"my-assignment"},
+		"key":               "test-key",
+		"id_token_ttl":      int64(30),
+		"access_token_ttl":  int64(60),
+		"refresh_token_ttl": int64(0),
+		"client_id":         resp.Data["client_id"],
+		"client_secret":     resp.Data["client_secret"],
+		"client_type":       confidential.String(),
 	}
 	if diff := deep.Equal(expected, resp.Data); diff != nil {
 		t.Fatal(diff)
@@ -3634,7 +3639,8 @@ func TestOIDC_Path_OpenIDProviderConfig(t *testing.T) {
 		AuthorizationEndpoint: "/ui/vault/identity/oidc/provider/test-provider/authorize",
 		TokenEndpoint:         basePath + "/token",
 		UserinfoEndpoint:      basePath + "/userinfo",
-		GrantTypes:            []string{"authorization_code"},
+		RegistrationEndpoint:  basePath + "/register",
+		GrantTypes:            []string{"authorization_code", "refresh_token"},
 		AuthMethods:           []string{"none", "client_secret_basic", "client_secret_post"},
 		RequestParameter:      false,
 		RequestURIParameter:   false,
@@ -3691,7 +3697,8 @@ func TestOIDC_Path_OpenIDProviderConfig(t *testing.T) {
 		AuthorizationEndpoint: testIssuer + "/ui/vault/identity/oidc/provider/test-provider/authorize",
 		TokenEndpoint:         basePath + "/token",
 		UserinfoEndpoint:      basePath + "/userinfo",
-		GrantTypes:            []string{"authorization_code"},
+		RegistrationEndpoint:  basePath + "/register",
+		GrantTypes:            []string{"authorization_code", "refresh_token"},
 		AuthMethods:           []string{"none", "client_secret_basic", "client_secret_post"},
 		RequestParameter:      false,
 		RequestURIParameter:   false,
@@ -3724,3 +3731,206 @@ func TestOIDC_Path_OpenIDProviderConfig_ProviderDoesNotExist(t *testing.T) {
 		t.Fatalf("expected empty response but got success; error:\n%v\nresp: %#v", err, resp)
 	}
 }
+
+func TestOIDC_Path_OIDC_Token_RefreshToken(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	s := new(logical.InmemStorage)
+
+	entityID, _, _, clientID, clientSecret := setupOIDCCommon(t, c, s)
+
+	// Enable refresh tokens for the client
+	req := testClientReq(s)
+	req.Operation = logical.UpdateOperation
+	req.Data["refresh_token_ttl"] = "1h"
+	resp, err := c.identityStore.HandleRequest(ctx, req)
+	expectSuccess(t, resp, err)
+
+	var tokenRes struct {
+		AccessToken      string `json:"access_token"`
+		IDToken          string `json:"id_token"`
+		RefreshToken     string `json:"refresh_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	refresh := func(refreshToken string) {
+		t.Helper()
+		req := testTokenReq(s, "", clientID, clientSecret)
+		req.Data = map[string]interface{}{
+			"grant_type":    "refresh_token",
+			"refresh_token": refreshToken,
+		}
+		resp, err := c.identityStore.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		tokenRes.RefreshToken, tokenRes.Error = "", ""
+		require.NoError(t, json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &tokenRes))
+	}
+
+	// Obtain an authorization code and exchange it
+	var authRes struct {
+		Code string `json:"code"`
+	}
+	req = testAuthorizeReq(s, clientID)
+	req.EntityID = entityID
+	resp, err = c.identityStore.HandleRequest(ctx, req)
+	expectSuccess(t, resp, err)
+	require.NoError(t, json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &authRes))
+
+	resp, err = c.identityStore.HandleRequest(ctx, testTokenReq(s, authRes.Code, clientID, clientSecret))
+	expectSuccess(t, resp, err)
+	require.NoError(t, json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &tokenRes))
+	require.Empty(t, tokenRes.Error)
+	require.True(t, strings.HasPrefix(tokenRes.RefreshToken, refreshTokenPrefix))
+	firstRefreshToken := tokenRes.RefreshToken
+
+	// Refresh tokens are rotated on use
+	refresh(firstRefreshToken)
+	require.Empty(t, tokenRes.Error)
+	require.NotEmpty(t, tokenRes.AccessToken)
+	require.NotEmpty(t, tokenRes.IDToken)
+	require.NotEmpty(t, tokenRes.RefreshToken)
+	require.NotEqual(t, firstRefreshToken, tokenRes.RefreshToken)
+
+	refresh(firstRefreshToken)
+	require.Equal(t, ErrTokenInvalidGrant, tokenRes.Error)
+
+	refresh("hvo_refresh_unknown")
+	require.Equal(t, ErrTokenInvalidGrant, tokenRes.Error)
+
+	// The discovery document advertises refresh tokens and registration
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Storage:   s,
+		Path:      "oidc/provider/test-provider/.well-known/openid-configuration",
+		Operation: logical.ReadOperation,
+	})
+	expectSuccess(t, resp, err)
+	var discovery providerDiscovery
+	require.NoError(t, json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &discovery))
+	require.Contains(t, discovery.GrantTypes, "refresh_token")
+	require.True(t, strings.HasSuffix(discovery.RegistrationEndpoint, "/identity/oidc/provider/test-provider/register"))
+}
+
+func TestOIDC_Path_OIDC_RegisterClient(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	s := new(logical.InmemStorage)
+
+	setupOIDCCommon(t, c, s)
+	require.NoError(t, c.identityStore.storeOIDCDefaultResources(ctx, s))
+
+	register := func(data map[string]interface{}) (int, map[string]interface{}) {
+		t.Helper()
+		resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+			Storage:   s,
+			Path:      "oidc/provider/test-provider/register",
+			Operation: logical.UpdateOperation,
+			Data:      data,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "no-store", resp.Data[logical.HTTPCacheControlHeader])
+		body := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(resp.Data[logical.HTTPRawBody].([]byte), &body))
+		return resp.Data[logical.HTTPStatusCode].(int), body
+	}
+
+	tests := []struct {
+		name      string
+		data      map[string]interface{}
+		wantError string
+	}{
+		{
+			name:      "missing redirect_uris",
+			data:      map[string]interface{}{},
+			wantError: ErrRegistrationInvalidRedirectURI,
+		},
+		{
+			name:      "redirect URI with fragment",
+			data:      map[string]interface{}{"redirect_uris": []string{"https://localhost/callback#frag"}},
+			wantError: ErrRegistrationInvalidRedirectURI,
+		},
+		{
+			name: "unsupported grant type",
+			data: map[string]interface{}{
+				"redirect_uris": []string{"https://localhost/callback"},
+				"grant_types":   []string{"authorization_code", "implicit"},
+			},
+			wantError: ErrRegistrationInvalidClientMetadata,
+		},
+		{
+			name: "unsupported response type",
+			data: map[string]interface{}{
+				"redirect_uris":  []string{"https://localhost/callback"},
+				"response_types": []string{"token"},
+			},
+			wantError: ErrRegistrationInvalidClientMetadata,
+		},
+		{
+			name: "refresh_token_ttl without refresh_token grant",
+			data: map[string]interface{}{
+				"redirect_uris":     []string{"https://localhost/callback"},
+				"refresh_token_ttl": "1h",
+			},
+			wantError: ErrRegistrationInvalidClientMetadata,
+		},
+		{
+			name: "assignment does not exist",
+			data: map[string]interface{}{
+				"redirect_uris": []string{"https://localhost/callback"},
+				"assignments":   []string{"non-existent"},
+			},
+			wantError: ErrRegistrationInvalidClientMetadata,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statusCode, body := register(tt.data)
+			require.Equal(t, http.StatusBadRequest, statusCode)
+			require.Equal(t, tt.wantError, body["error"])
+			require.NotEmpty(t, body["error_description"])
+		})
+	}
+
+	// Register a confidential client using refresh tokens
+	statusCode, body := register(map[string]interface{}{
+		"client_name":   "my app",
+		"redirect_uris": []string{"https://localhost/callback"},
+		"grant_types":   []string{"authorization_code", "refresh_token"},
+	})
+	require.Equal(t, http.StatusCreated, statusCode)
+	require.NotEmpty(t, body["client_id"])
+	require.NotEmpty(t, body["client_secret"])
+	require.Equal(t, "my app", body["client_name"])
+	require.Equal(t, "client_secret_basic", body["token_endpoint_auth_method"])
+	require.Equal(t, []interface{}{"code"}, body["response_types"])
+	clientID := body["client_id"].(string)
+	clientName := body["vault_client_name"].(string)
+	require.True(t, strings.HasPrefix(clientName, dynamicClientNamePrefix))
+
+	resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+		Storage:   s,
+		Path:      "oidc/client/" + clientName,
+		Operation: logical.ReadOperation,
+	})
+	expectSuccess(t, resp, err)
+	require.Equal(t, clientID, resp.Data["client_id"])
+	require.Empty(t, resp.Data["assignments"])
+	require.Equal(t, int64(86400), resp.Data["refresh_token_ttl"])
+
+	// Registration does not change the clients allowed by the provider
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Storage:   s,
+		Path:      "oidc/provider/test-provider",
+		Operation: logical.ReadOperation,
+	})
+	expectSuccess(t, resp, err)
+	require.NotContains(t, resp.Data["allowed_client_ids"], clientID)
+
+	// Register a public client
+	statusCode, body = register(map[string]interface{}{
+		"redirect_uris":              []string{"http://127.0.0.1:8250/callback"},
+		"token_endpoint_auth_method": "none",
+	})
+	require.Equal(t, http.StatusCreated, statusCode)
+	require.NotEmpty(t, body["client_id"])
+	require.NotContains(t, body, "client_secret")
+}
@@ -0,0 +1,274 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// deletedEntitiesPath is the storage path of the entities deleted while
+	// identity tokens issued to them may still be valid.
+	deletedEntitiesPath = oidcTokensPrefix + "deleted_entities"
+
+	// defaultRevocationListTTL is how long a revocation list is valid for if
+	// no TTL is requested.
+	defaultRevocationListTTL = 5 * time.Minute
+
+	// entityEventTypeRevoke is sent when the identity tokens of an entity are
+	// revoked, as they are by deleting or disabling the entity.
+	entityEventTypeRevoke logical.EventType = "identity/entity/revoke"
+
+	entityRevocationReasonDeleted  = "deleted"
+	entityRevocationReasonDisabled = "disabled"
+)
+
+// revokedEntity is an entity whose identity tokens are no longer valid.
+type revokedEntity struct {
+	EntityID  string `json:"sub"`
+	RevokedAt int64  `json:"revoked_at"`
+	Reason    string `json:"reason"`
+}
+
+// revocationList is the signed list of the entities whose identity tokens
+// are no longer valid, for verifiers which do not introspect tokens.
+type revocationList struct {
+	Issuer    string           `json:"iss"`
+	Namespace string           `json:"namespace"`
+	IssuedAt  int64            `json:"iat"`
+	Expiry    int64            `json:"exp"`
+	Revoked   []*revokedEntity `json:"revoked"`
+}
+
+func oidcRevocationPaths(i *IdentityStore) []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "oidc/key/" + framework.GenericNameRegex("name") + "/revocations/?$",
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "oidc",
+				OperationVerb:   "read",
+				OperationSuffix: "revocation-list",
+			},
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the key",
+				},
+				"ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "How long the revocation list is valid for.",
+					Default:     int(defaultRevocationListTTL.Seconds()),
+					Query:       true,
+				},
+			},
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.ReadOperation: i.pathOIDCReadRevocationList,
+			},
+			HelpSynopsis:    "Retrieve a signed list of revoked entities",
+			HelpDescription: "Query this path to retrieve the list of the entities whose OIDC tokens signed by the key are no longer valid, as a token signed by the key. Verifiers which do not introspect tokens can poll this list to reject the tokens of deleted and disabled entities.",
+		},
+	}
+}
+
+// pathOIDCReadRevocationList returns the revocation list of the tokens signed
+// by a named key, signed by that key.
+func (i *IdentityStore) pathOIDCReadRevocationList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name := d.Get("name").(string)
+	key, err := i.getNamedKey(ctx, req.Storage, name)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return logical.ErrorResponse("key %q not found", name), nil
+	}
+
+	ttl := time.Duration(d.Get("ttl").(int)) * time.Second
+	if ttl <= 0 {
+		return logical.ErrorResponse("ttl must be positive"), nil
+	}
+
+	config, err := i.getOIDCConfig(ctx, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := config.fullIssuer(baseIdentityTokenIssuer)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	// Tokens signed by the key do not outlive its verification TTL, so older
+	// deletions do not need to be listed
+	now := time.Now()
+	revoked, err := i.revokedEntities(ctx, req.Storage, ns, now.Add(-key.VerificationTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(&revocationList{
+		Issuer:    issuer,
+		Namespace: ns.ID,
+		IssuedAt:  now.Unix(),
+		Expiry:    now.Add(ttl).Unix(),
+		Revoked:   revoked,
+	})
+	if err != nil {
+		return nil, err
+	}
+	signed, err := key.signPayload(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error signing revocation list: %w", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"revocation_list": signed,
+			"ttl":             int64(ttl.Seconds()),
+		},
+	}, nil
+}
+
+// revokedEntities returns the disabled entities of the namespace, and the
+// entities deleted since the given time, ordered by entity ID.
+func (i *IdentityStore) revokedEntities(ctx context.Context, s logical.Storage, ns *namespace.Namespace, since time.Time) ([]*revokedEntity, error) {
+	deleted, err := i.deletedEntities(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := make([]*revokedEntity, 0, len(deleted))
+	for _, entity := range deleted {
+		if entity.RevokedAt >= since.Unix() {
+			revoked = append(revoked, entity)
+		}
+	}
+
+	txn := i.db.Txn(false)
+	iter, err := txn.Get(entitiesTable, "namespace_id", ns.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch iterator for entities in memdb: %w", err)
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		entity := raw.(*identity.Entity)
+		if !entity.Disabled {
+			continue
+		}
+		var revokedAt int64
+		if entity.LastUpdateTime != nil {
+			revokedAt = entity.LastUpdateTime.AsTime().Unix()
+		}
+		revoked = append(revoked, &revokedEntity{
+			EntityID:  entity.ID,
+			RevokedAt: revokedAt,
+			Reason:    entityRevocationReasonDisabled,
+		})
+	}
+
+	sort.Slice(revoked, func(a, b int) bool {
+		return revoked[a].EntityID < revoked[b].EntityID
+	})
+	return revoked, nil
+}
+
+func (i *IdentityStore) deletedEntities(ctx context.Context, s logical.Storage) ([]*revokedEntity, error) {
+	entry, err := s.Get(ctx, deletedEntitiesPath)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var deleted []*revokedEntity
+	if err := entry.DecodeJSON(&deleted); err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// recordEntityDeletion adds a deleted entity to the revocation lists, and
+// drops the deletions older than the verification TTL of every key. Failing
+// to record the deletion does not prevent it.
+func (i *IdentityStore) recordEntityDeletion(ctx context.Context, entityID string) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		i.logger.Error("failed to record entity deletion", "entity_id", entityID, "error", err)
+		return
+	}
+
+	if err := i.recordEntityDeletionInternal(ctx, ns, entityID); err != nil {
+		i.logger.Error("failed to record entity deletion", "entity_id", entityID, "error", err)
+	}
+	i.sendEntityRevokeEvent(ns, entityID, entityRevocationReasonDeleted)
+}
+
+func (i *IdentityStore) recordEntityDeletionInternal(ctx context.Context, ns *namespace.Namespace, entityID string) error {
+	s := i.router.MatchingStorageByAPIPath(ctx, ns.Path+"identity/oidc")
+	if s == nil {
+		return fmt.Errorf("could not find storage for namespace %q", ns.Path)
+	}
+
+	i.deletedEntitiesLock.Lock()
+	defer i.deletedEntitiesLock.Unlock()
+
+	deleted, err := i.deletedEntities(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	var maxTTL time.Duration
+	keyNames, err := s.List(ctx, namedKeyConfigPath)
+	if err != nil {
+		return err
+	}
+	for _, name := range keyNames {
+		key, err := i.getNamedKey(ctx, s, name)
+		if err != nil {
+			return err
+		}
+		if key != nil && key.VerificationTTL > maxTTL {
+			maxTTL = key.VerificationTTL
+		}
+	}
+
+	now := time.Now()
+	kept := []*revokedEntity{{
+		EntityID:  entityID,
+		RevokedAt: now.Unix(),
+		Reason:    entityRevocationReasonDeleted,
+	}}
+	for _, entity := range deleted {
+		if entity.EntityID != entityID && entity.RevokedAt >= now.Add(-maxTTL).Unix() {
+			kept = append(kept, entity)
+		}
+	}
+
+	entry, err := logical.StorageEntryJSON(deletedEntitiesPath, kept)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, entry)
+}
+
+// sendEntityRevokeEvent notifies the subscribers of the event bus that the
+// identity tokens of an entity have been revoked, so that they can refresh
+// their revocation lists.
+func (i *IdentityStore) sendEntityRevokeEvent(ns *namespace.Namespace, entityID, reason string) {
+	i.eventSender.sendLifecycleEvent(ns, entityEventTypeRevoke, "identity/entity/id/"+entityID, reason,
+		"entity_id", entityID,
+	)
+}
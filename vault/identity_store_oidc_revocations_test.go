@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOIDC_Path_RevocationList tests that the revocation list of a key lists
+// the deleted and disabled entities, and is signed by the key
+func TestOIDC_Path_RevocationList(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	storage := c.router.MatchingStorageByAPIPath(ctx, "identity/oidc")
+
+	resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "oidc/key/test-key",
+		Operation: logical.CreateOperation,
+		Storage:   storage,
+	})
+	expectSuccess(t, resp, err)
+
+	// Keys are only published once used by a role
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "oidc/role/test-role",
+		Operation: logical.CreateOperation,
+		Data: map[string]interface{}{
+			"key": "test-key",
+		},
+		Storage: storage,
+	})
+	expectSuccess(t, resp, err)
+
+	entityIDs := make(map[string]string)
+	for _, name := range []string{"deleted", "disabled", "enabled"} {
+		resp, err := c.identityStore.HandleRequest(ctx, &logical.Request{
+			Path:      "entity",
+			Operation: logical.UpdateOperation,
+			Data: map[string]interface{}{
+				"name": name,
+			},
+		})
+		expectSuccess(t, resp, err)
+		entityIDs[name] = resp.Data["id"].(string)
+	}
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity/id/" + entityIDs["deleted"],
+		Operation: logical.DeleteOperation,
+	})
+	expectSuccess(t, resp, err)
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "entity/id/" + entityIDs["disabled"],
+		Operation: logical.UpdateOperation,
+		Data: map[string]interface{}{
+			"disabled": true,
+		},
+	})
+	expectSuccess(t, resp, err)
+
+	// Unknown keys have no revocation list
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "oidc/key/unknown/revocations",
+		Operation: logical.ReadOperation,
+		Storage:   storage,
+	})
+	expectError(t, resp, err)
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "oidc/key/test-key/revocations",
+		Operation: logical.ReadOperation,
+		Data: map[string]interface{}{
+			"ttl": "1m",
+		},
+		Storage: storage,
+	})
+	expectSuccess(t, resp, err)
+	require.Equal(t, int64(60), resp.Data["ttl"])
+
+	signed, err := jose.ParseSigned(resp.Data["revocation_list"].(string))
+	require.NoError(t, err)
+
+	// The list is verified with the published keys
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "oidc/.well-known/keys",
+		Operation: logical.ReadOperation,
+		Storage:   storage,
+	})
+	require.NoError(t, err)
+	jwks := &jose.JSONWebKeySet{}
+	require.NoError(t, json.Unmarshal(resp.Data["http_raw_body"].([]byte), jwks))
+	keys := jwks.Key(signed.Signatures[0].Header.KeyID)
+	require.Len(t, keys, 1)
+	payload, err := signed.Verify(keys[0])
+	require.NoError(t, err)
+
+	list := &revocationList{}
+	require.NoError(t, json.Unmarshal(payload, list))
+	require.Equal(t, namespace.RootNamespaceID, list.Namespace)
+	require.Equal(t, int64(60), list.Expiry-list.IssuedAt)
+	require.WithinDuration(t, time.Now(), time.Unix(list.IssuedAt, 0), time.Minute)
+
+	reasons := make(map[string]string)
+	for _, entity := range list.Revoked {
+		reasons[entity.EntityID] = entity.Reason
+	}
+	require.Equal(t, map[string]string{
+		entityIDs["deleted"]:  entityRevocationReasonDeleted,
+		entityIDs["disabled"]: entityRevocationReasonDisabled,
+	}, reasons)
+}
@@ -0,0 +1,1299 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// scimConfigKey is the storage key of the configuration of the SCIM
+	// provisioning endpoint.
+	scimConfigKey = "scim/config"
+
+	scimContentType = "application/scim+json"
+
+	scimSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	scimSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	// scimMaxResults is the maximum number of resources returned per page,
+	// and the number returned when the client does not ask for a count.
+	scimMaxResults = 100
+
+	// scimTimeFormat is the format of the timestamps of SCIM resources.
+	scimTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+var (
+	errSCIMNamespace = errors.New("SCIM provisioning is only available in the root namespace")
+
+	// scimFilterRegex matches the only filters supported, equality on a
+	// single attribute, e.g. 'userName eq "alice"'.
+	scimFilterRegex = regexp.MustCompile(`(?i)^\s*(\w+)\s+eq\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+	// scimMemberPathRegex matches the path of a single group member in a
+	// patch operation, e.g. 'members[value eq "<id>"]'.
+	scimMemberPathRegex = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+("(?:[^"\\]|\\.)*")\s*\]$`)
+)
+
+// scimConfig configures how the users provisioned through SCIM are linked to
+// the logins of an auth method.
+type scimConfig struct {
+	// MountAccessor is the auth method on which each provisioned user is
+	// given an alias named after their userName, so that their logins are
+	// attributed to the provisioned entity.
+	MountAccessor string `json:"mount_accessor,omitempty"`
+}
+
+// scimError is an error returned to SCIM clients in the error format of RFC
+// 7644.
+type scimError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+func (e *scimError) Error() string {
+	return e.detail
+}
+
+func newSCIMError(status int, scimType, format string, args ...interface{}) *scimError {
+	return &scimError{
+		status:   status,
+		scimType: scimType,
+		detail:   fmt.Sprintf(format, args...),
+	}
+}
+
+// scimOperationFunc handles a SCIM request, returning the HTTP status and the
+// resource of the response.
+type scimOperationFunc func(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error)
+
+func scimPaths(i *IdentityStore) []*framework.Path {
+	userFields := map[string]*framework.FieldSchema{
+		"userName": {
+			Type:        framework.TypeString,
+			Description: "Name of the entity of the user.",
+		},
+		"active": {
+			Type:        framework.TypeBool,
+			Description: "Whether the entity of the user is enabled. Deactivating a user revokes the tokens of its entity.",
+		},
+		"schemas": {
+			Type:        framework.TypeStringSlice,
+			Description: "SCIM schemas of the request.",
+		},
+	}
+	groupFields := map[string]*framework.FieldSchema{
+		"displayName": {
+			Type:        framework.TypeString,
+			Description: "Name of the internal group.",
+		},
+		"members": {
+			Type:        framework.TypeSlice,
+			Description: "Members of the group, whose 'value' is the ID of a user.",
+		},
+		"schemas": {
+			Type:        framework.TypeStringSlice,
+			Description: "SCIM schemas of the request.",
+		},
+	}
+	listFields := map[string]*framework.FieldSchema{
+		"filter": {
+			Type:        framework.TypeString,
+			Description: "Filter on the resources to list. Only equality on the name of the resource is supported.",
+		},
+		"startIndex": {
+			Type:        framework.TypeInt,
+			Description: "1-based index of the first resource to list.",
+			Default:     1,
+		},
+		"count": {
+			Type:        framework.TypeInt,
+			Description: "Maximum number of resources to list.",
+			Default:     scimMaxResults,
+		},
+	}
+	idField := map[string]*framework.FieldSchema{
+		"id": {
+			Type:        framework.TypeString,
+			Description: "ID of the resource.",
+		},
+		"Operations": {
+			Type:        framework.TypeSlice,
+			Description: "Operations of a patch request.",
+		},
+	}
+	fields := func(sets ...map[string]*framework.FieldSchema) map[string]*framework.FieldSchema {
+		merged := make(map[string]*framework.FieldSchema)
+		for _, set := range sets {
+			for name, schema := range set {
+				merged[name] = schema
+			}
+		}
+		return merged
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "scim/config$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationSuffix: "scim-configuration",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"mount_accessor": {
+					Type:        framework.TypeString,
+					Description: "Accessor of the auth method on which provisioned users are given an alias named after their userName.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.pathSCIMConfigRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.pathSCIMConfigWrite(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(scimHelp["scim-config"][0]),
+			HelpDescription: strings.TrimSpace(scimHelp["scim-config"][1]),
+		},
+		{
+			Pattern: "scim/v2/ServiceProviderConfig$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationVerb:   "read",
+				OperationSuffix: "scim-service-provider-configuration",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMServiceProviderConfigRead),
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(scimHelp["scim-service-provider-config"][0]),
+			HelpDescription: strings.TrimSpace(scimHelp["scim-service-provider-config"][1]),
+		},
+		{
+			Pattern: "scim/v2/Users$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationSuffix: "scim-users",
+			},
+
+			Fields: fields(userFields, listFields),
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMUsersList),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "list",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMUserCreate),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "create",
+						OperationSuffix: "scim-user",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(scimHelp["scim-users"][0]),
+			HelpDescription: strings.TrimSpace(scimHelp["scim-users"][1]),
+		},
+		{
+			Pattern: "scim/v2/Users/" + framework.GenericNameRegex("id") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationSuffix: "scim-user",
+			},
+
+			Fields: fields(userFields, idField),
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMUserRead),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMUserReplace),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "replace",
+					},
+				},
+				logical.PatchOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMUserPatch),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "patch",
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMUserDelete),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(scimHelp["scim-user"][0]),
+			HelpDescription: strings.TrimSpace(scimHelp["scim-user"][1]),
+		},
+		{
+			Pattern: "scim/v2/Groups$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationSuffix: "scim-groups",
+			},
+
+			Fields: fields(groupFields, listFields),
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMGroupsList),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "list",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMGroupCreate),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "create",
+						OperationSuffix: "scim-group",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(scimHelp["scim-groups"][0]),
+			HelpDescription: strings.TrimSpace(scimHelp["scim-groups"][1]),
+		},
+		{
+			Pattern: "scim/v2/Groups/" + framework.GenericNameRegex("id") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "identity",
+				OperationSuffix: "scim-group",
+			},
+
+			Fields: fields(groupFields, idField),
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMGroupRead),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMGroupReplace),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "replace",
+					},
+				},
+				logical.PatchOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMGroupPatch),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "patch",
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: i.scimHandler(i.pathSCIMGroupDelete),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(scimHelp["scim-group"][0]),
+			HelpDescription: strings.TrimSpace(scimHelp["scim-group"][1]),
+		},
+	}
+}
+
+func (i *IdentityStore) pathSCIMConfigRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if err := scimCheckNamespace(ctx); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		config, err := i.scimConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"mount_accessor": config.MountAccessor,
+			},
+		}, nil
+	}
+}
+
+func (i *IdentityStore) pathSCIMConfigWrite() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if err := scimCheckNamespace(ctx); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		config, err := i.scimConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if raw, ok := d.GetOk("mount_accessor"); ok {
+			config.MountAccessor = raw.(string)
+		}
+		if config.MountAccessor != "" {
+			mountEntry := i.router.MatchingMountByAccessor(config.MountAccessor)
+			switch {
+			case mountEntry == nil || mountEntry.Table != credentialTableType:
+				return logical.ErrorResponse("invalid auth method accessor %q", config.MountAccessor), logical.ErrInvalidRequest
+			case mountEntry.NamespaceID != namespace.RootNamespaceID:
+				return logical.ErrorResponse("auth method %q is not in the root namespace", config.MountAccessor), logical.ErrInvalidRequest
+			case mountEntry.Local:
+				return logical.ErrorResponse("auth method %q is local", config.MountAccessor), logical.ErrInvalidRequest
+			}
+		}
+
+		entry, err := logical.StorageEntryJSON(scimConfigKey, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode SCIM config: %w", err)
+		}
+		return nil, i.view.Put(ctx, entry)
+	}
+}
+
+func scimCheckNamespace(ctx context.Context) error {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if ns.ID != namespace.RootNamespaceID {
+		return errSCIMNamespace
+	}
+	return nil
+}
+
+// scimConfig returns the configuration of the SCIM endpoint, which is empty if
+// it was never configured.
+func (i *IdentityStore) scimConfig(ctx context.Context) (*scimConfig, error) {
+	config := new(scimConfig)
+
+	entry, err := i.view.Get(ctx, scimConfigKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SCIM config: %w", err)
+	}
+	if entry == nil {
+		return config, nil
+	}
+
+	if err := jsonutil.DecodeJSON(entry.Value, config); err != nil {
+		return nil, fmt.Errorf("failed to decode SCIM config: %w", err)
+	}
+	return config, nil
+}
+
+// scimHandler adapts a SCIM operation to a framework operation, encoding its
+// resource, or its SCIM error, as the raw body of the response.
+func (i *IdentityStore) scimHandler(op scimOperationFunc) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		var status int
+		var resource interface{}
+		err := scimCheckNamespace(ctx)
+		if err == nil {
+			status, resource, err = op(ctx, req, d)
+		}
+
+		var scimErr *scimError
+		switch {
+		case errors.Is(err, errSCIMNamespace):
+			scimErr = newSCIMError(http.StatusForbidden, "", err.Error())
+		case err != nil && !errors.As(err, &scimErr):
+			return nil, err
+		}
+		if scimErr != nil {
+			status = scimErr.status
+			resource = map[string]interface{}{
+				"schemas":  []string{scimSchemaError},
+				"status":   strconv.Itoa(scimErr.status),
+				"scimType": scimErr.scimType,
+				"detail":   scimErr.detail,
+			}
+		}
+
+		data := map[string]interface{}{
+			logical.HTTPStatusCode: status,
+		}
+		if resource != nil {
+			body, err := json.Marshal(resource)
+			if err != nil {
+				return nil, err
+			}
+			data[logical.HTTPContentType] = scimContentType
+			data[logical.HTTPRawBody] = body
+		}
+		return &logical.Response{Data: data}, nil
+	}
+}
+
+func (i *IdentityStore) pathSCIMServiceProviderConfigRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	unsupported := map[string]interface{}{"supported": false}
+	return http.StatusOK, map[string]interface{}{
+		"schemas": []string{scimSchemaServiceProviderConfig},
+		"patch":   map[string]interface{}{"supported": true},
+		"bulk": map[string]interface{}{
+			"supported":      false,
+			"maxOperations":  0,
+			"maxPayloadSize": 0,
+		},
+		"filter": map[string]interface{}{
+			"supported":  true,
+			"maxResults": scimMaxResults,
+		},
+		"changePassword": unsupported,
+		"sort":           unsupported,
+		"etag":           unsupported,
+		"authenticationSchemes": []interface{}{
+			map[string]interface{}{
+				"type":        "oauthbearertoken",
+				"name":        "Vault token",
+				"description": "A Vault token sent as a bearer token in the Authorization header.",
+				"primary":     true,
+			},
+		},
+	}, nil
+}
+
+func (i *IdentityStore) pathSCIMUsersList(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	userName, filtered, err := parseSCIMFilter(d.Get("filter").(string), "userName")
+	if err != nil {
+		return 0, nil, err
+	}
+
+	txn := i.db.Txn(false)
+	iter, err := txn.Get(entitiesTable, "namespace_id", namespace.RootNamespaceID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to fetch iterator for entities in memdb: %w", err)
+	}
+
+	var entities []*identity.Entity
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		entity := raw.(*identity.Entity)
+		if !filtered || strings.EqualFold(entity.Name, userName) {
+			entities = append(entities, entity)
+		}
+	}
+	sort.Slice(entities, func(a, b int) bool {
+		return entities[a].Name < entities[b].Name
+	})
+
+	startIndex, page := scimPage(d, len(entities))
+	resources := make([]interface{}, 0, len(page))
+	for _, index := range page {
+		user, err := i.scimUser(entities[index])
+		if err != nil {
+			return 0, nil, err
+		}
+		resources = append(resources, user)
+	}
+	return http.StatusOK, scimListResponse(resources, len(entities), startIndex), nil
+}
+
+func (i *IdentityStore) pathSCIMUserRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	entity, err := i.scimEntity(d.Get("id").(string))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	user, err := i.scimUser(entity)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, user, nil
+}
+
+func (i *IdentityStore) pathSCIMUserCreate(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	userName := d.Get("userName").(string)
+	if userName == "" {
+		return 0, nil, newSCIMError(http.StatusBadRequest, "invalidValue", "missing userName")
+	}
+	active := true
+	if raw, ok := d.GetOk("active"); ok {
+		active = raw.(bool)
+	}
+
+	config, err := i.scimConfig(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	existing, err := i.MemDBEntityByName(ctx, userName, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if existing != nil {
+		return 0, nil, newSCIMError(http.StatusConflict, "uniqueness", "user %q already exists", userName)
+	}
+
+	entity := &identity.Entity{
+		Name:     userName,
+		Disabled: !active,
+	}
+	if err := i.sanitizeEntity(ctx, entity); err != nil {
+		return 0, nil, err
+	}
+	if err := i.scimUpsertAlias(ctx, config, entity); err != nil {
+		return 0, nil, err
+	}
+	if err := i.upsertEntity(ctx, entity, nil, true); err != nil {
+		return 0, nil, err
+	}
+
+	user, err := i.scimUser(entity)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, user, nil
+}
+
+func (i *IdentityStore) pathSCIMUserReplace(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	userName := d.Get("userName").(string)
+	if userName == "" {
+		return 0, nil, newSCIMError(http.StatusBadRequest, "invalidValue", "missing userName")
+	}
+	var active *bool
+	if raw, ok := d.GetOk("active"); ok {
+		value := raw.(bool)
+		active = &value
+	}
+
+	return i.scimUpdateUser(ctx, d.Get("id").(string), userName, active)
+}
+
+// pathSCIMUserPatch applies the patch operations on the userName and active
+// attributes of a user. Operations on other attributes are ignored, as they
+// have no counterpart on entities.
+func (i *IdentityStore) pathSCIMUserPatch(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	ops, err := parseSCIMPatchOperations(d)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var userName string
+	var active *bool
+	for _, op := range ops {
+		values := op.values()
+		for attribute, value := range values {
+			switch strings.ToLower(attribute) {
+			case "username":
+				if op.op == "remove" {
+					return 0, nil, newSCIMError(http.StatusBadRequest, "mutability", "userName cannot be removed")
+				}
+				name, ok := value.(string)
+				if !ok || name == "" {
+					return 0, nil, newSCIMError(http.StatusBadRequest, "invalidValue", "invalid userName")
+				}
+				userName = name
+			case "active":
+				if op.op == "remove" {
+					return 0, nil, newSCIMError(http.StatusBadRequest, "mutability", "active cannot be removed")
+				}
+				value, err := parseutil.ParseBool(value)
+				if err != nil {
+					return 0, nil, newSCIMError(http.StatusBadRequest, "invalidValue", "invalid active: %s", err)
+				}
+				active = &value
+			}
+		}
+	}
+
+	return i.scimUpdateUser(ctx, d.Get("id").(string), userName, active)
+}
+
+// scimUpdateUser renames the entity of the user if userName is set, and
+// enables or disables it if active is set. Deactivating a user revokes the
+// tokens of its entity, and the leases created with them.
+func (i *IdentityStore) scimUpdateUser(ctx context.Context, id, userName string, active *bool) (int, interface{}, error) {
+	config, err := i.scimConfig(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	entity, err := func() (*identity.Entity, error) {
+		i.lock.Lock()
+		defer i.lock.Unlock()
+
+		entity, err := i.scimEntity(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if userName != "" && userName != entity.Name {
+			existing, err := i.MemDBEntityByName(ctx, userName, false)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil && existing.ID != entity.ID {
+				return nil, newSCIMError(http.StatusConflict, "uniqueness", "user %q already exists", userName)
+			}
+			entity.Name = userName
+		}
+		if active != nil {
+			entity.Disabled = !*active
+		}
+
+		if err := i.sanitizeEntity(ctx, entity); err != nil {
+			return nil, err
+		}
+		if err := i.scimUpsertAlias(ctx, config, entity); err != nil {
+			return nil, err
+		}
+		if err := i.upsertEntity(ctx, entity, nil, true); err != nil {
+			return nil, err
+		}
+		return entity, nil
+	}()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Tokens are revoked whenever a user is deactivated, rather than only
+	// when it was active, so that a failed revocation is retried along with
+	// the request.
+	if entity.Disabled {
+		if err := i.scimRevokeEntityTokens(ctx, entity.ID); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	user, err := i.scimUser(entity)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, user, nil
+}
+
+// pathSCIMUserDelete deprovisions a user, revoking the tokens of its entity
+// and the leases created with them before deleting the entity.
+func (i *IdentityStore) pathSCIMUserDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	entity, err := i.scimEntity(d.Get("id").(string))
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := i.scimRevokeEntityTokens(ctx, entity.ID); err != nil {
+		return 0, nil, err
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	txn := i.db.Txn(true)
+	defer txn.Abort()
+
+	entity, err = i.MemDBEntityByIDInTxn(txn, entity.ID, true)
+	if err != nil {
+		return 0, nil, err
+	}
+	if entity != nil {
+		if err := i.handleEntityDeleteCommon(ctx, txn, entity, true); err != nil {
+			return 0, nil, err
+		}
+	}
+	txn.Commit()
+
+	return http.StatusNoContent, nil, nil
+}
+
+// scimEntity returns a clone of the entity of the user with the given ID.
+func (i *IdentityStore) scimEntity(id string) (*identity.Entity, error) {
+	entity, err := i.MemDBEntityByID(id, true)
+	if err != nil {
+		return nil, err
+	}
+	if entity == nil || entity.NamespaceID != namespace.RootNamespaceID {
+		return nil, newSCIMError(http.StatusNotFound, "", "user %q not found", id)
+	}
+	return entity, nil
+}
+
+// scimUpsertAlias gives the entity an alias named after its name on the
+// configured auth method, renaming its existing alias there if needed.
+func (i *IdentityStore) scimUpsertAlias(ctx context.Context, config *scimConfig, entity *identity.Entity) error {
+	if config.MountAccessor == "" {
+		return nil
+	}
+
+	existing, err := i.MemDBAliasByFactors(config.MountAccessor, entity.Name, false, false)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.CanonicalID != entity.ID {
+		return newSCIMError(http.StatusConflict, "uniqueness", "alias %q is already in use by another entity", entity.Name)
+	}
+
+	for _, alias := range entity.Aliases {
+		if alias.MountAccessor == config.MountAccessor {
+			if alias.Name != entity.Name {
+				alias.Name = entity.Name
+				alias.LastUpdateTime = ptypes.TimestampNow()
+			}
+			return nil
+		}
+	}
+
+	alias := &identity.Alias{
+		CanonicalID:   entity.ID,
+		MountAccessor: config.MountAccessor,
+		Name:          entity.Name,
+	}
+	if err := i.sanitizeAlias(ctx, alias); err != nil {
+		return err
+	}
+	entity.UpsertAlias(alias)
+	return nil
+}
+
+// scimRevokeEntityTokens revokes the tokens of the entity, along with the
+// leases created with them.
+func (i *IdentityStore) scimRevokeEntityTokens(ctx context.Context, entityID string) error {
+	revoked, err := i.tokenStorer.RevokeEntityTokens(ctx, entityID)
+	if revoked > 0 {
+		i.logger.Info("revoked tokens of deprovisioned entity", "entity_id", entityID, "tokens", revoked)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke tokens of entity %q: %w", entityID, err)
+	}
+	return nil
+}
+
+// scimUser returns the SCIM resource of the user of the entity.
+func (i *IdentityStore) scimUser(entity *identity.Entity) (map[string]interface{}, error) {
+	groups, err := i.MemDBGroupsByMemberEntityID(entity.ID, false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := make([]interface{}, 0, len(groups))
+	for _, group := range groups {
+		memberships = append(memberships, map[string]interface{}{
+			"value":   group.ID,
+			"display": group.Name,
+			"$ref":    "/v1/identity/scim/v2/Groups/" + group.ID,
+		})
+	}
+
+	return map[string]interface{}{
+		"schemas":  []string{scimSchemaUser},
+		"id":       entity.ID,
+		"userName": entity.Name,
+		"active":   !entity.Disabled,
+		"groups":   memberships,
+		"meta":     scimMeta("User", entity.ID, entity.CreationTime, entity.LastUpdateTime),
+	}, nil
+}
+
+func (i *IdentityStore) pathSCIMGroupsList(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	displayName, filtered, err := parseSCIMFilter(d.Get("filter").(string), "displayName")
+	if err != nil {
+		return 0, nil, err
+	}
+
+	txn := i.db.Txn(false)
+	iter, err := txn.Get(groupsTable, "namespace_id", namespace.RootNamespaceID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to lookup groups using namespace ID: %w", err)
+	}
+
+	var groups []*identity.Group
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		group := raw.(*identity.Group)
+		if group.Type == groupTypeInternal && (!filtered || strings.EqualFold(group.Name, displayName)) {
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(a, b int) bool {
+		return groups[a].Name < groups[b].Name
+	})
+
+	startIndex, page := scimPage(d, len(groups))
+	resources := make([]interface{}, 0, len(page))
+	for _, index := range page {
+		group, err := i.scimGroup(groups[index])
+		if err != nil {
+			return 0, nil, err
+		}
+		resources = append(resources, group)
+	}
+	return http.StatusOK, scimListResponse(resources, len(groups), startIndex), nil
+}
+
+func (i *IdentityStore) pathSCIMGroupRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	group, err := i.scimInternalGroup(d.Get("id").(string))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resource, err := i.scimGroup(group)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, resource, nil
+}
+
+func (i *IdentityStore) pathSCIMGroupCreate(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	displayName := d.Get("displayName").(string)
+	if displayName == "" {
+		return 0, nil, newSCIMError(http.StatusBadRequest, "invalidValue", "missing displayName")
+	}
+	memberIDs, err := i.scimMemberIDs(d.Get("members").([]interface{}))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	i.groupLock.Lock()
+	defer i.groupLock.Unlock()
+
+	existing, err := i.MemDBGroupByName(ctx, displayName, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if existing != nil {
+		return 0, nil, newSCIMError(http.StatusConflict, "uniqueness", "group %q already exists", displayName)
+	}
+
+	group := &identity.Group{
+		Name:            displayName,
+		Type:            groupTypeInternal,
+		MemberEntityIDs: memberIDs,
+	}
+	if err := i.sanitizeAndUpsertGroup(ctx, group, nil, nil); err != nil {
+		return 0, nil, err
+	}
+
+	resource, err := i.scimGroup(group)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, resource, nil
+}
+
+func (i *IdentityStore) pathSCIMGroupReplace(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	displayName := d.Get("displayName").(string)
+	if displayName == "" {
+		return 0, nil, newSCIMError(http.StatusBadRequest, "invalidValue", "missing displayName")
+	}
+	memberIDs, err := i.scimMemberIDs(d.Get("members").([]interface{}))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return i.scimUpdateGroup(ctx, d.Get("id").(string), func(group *identity.Group) error {
+		group.Name = displayName
+		group.MemberEntityIDs = memberIDs
+		return nil
+	})
+}
+
+// pathSCIMGroupPatch applies the patch operations on the displayName and
+// members attributes of a group. Operations on other attributes are ignored.
+func (i *IdentityStore) pathSCIMGroupPatch(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	ops, err := parseSCIMPatchOperations(d)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// The members are resolved before the group is locked, as applying the
+	// operations requires no further lookups
+	type memberChange struct {
+		op      string
+		members []string
+	}
+	var displayName string
+	var changes []memberChange
+	for _, op := range ops {
+		if matches := scimMemberPathRegex.FindStringSubmatch(op.path); matches != nil {
+			if op.op != "remove" {
+				return 0, nil, newSCIMError(http.StatusBadRequest, "invalidPath", "unsupported path %q for operation %q", op.path, op.op)
+			}
+			memberID, err := strconv.Unquote(matches[1])
+			if err != nil {
+				return 0, nil, newSCIMError(http.StatusBadRequest, "invalidPath", "invalid path %q", op.path)
+			}
+			changes = append(changes, memberChange{op: op.op, members: []string{memberID}})
+			continue
+		}
+
+		for attribute, value := range op.values() {
+			switch strings.ToLower(attribute) {
+			case "displayname":
+				name, ok := value.(string)
+				if op.op == "remove" || !ok || name == "" {
+					return 0, nil, newSCIMError(http.StatusBadRequest, "invalidValue", "invalid displayName")
+				}
+				displayName = name
+			case "members":
+				var members []string
+				if value != nil {
+					rawMembers, ok := value.([]interface{})
+					if !ok {
+						return 0, nil, newSCIMError(http.StatusBadRequest, "invalidValue", "invalid members")
+					}
+					resolve := i.scimMemberIDs
+					if op.op == "remove" {
+						// Members which no longer exist may still be removed
+						resolve = scimMemberValues
+					}
+					if members, err = resolve(rawMembers); err != nil {
+						return 0, nil, err
+					}
+				}
+				changes = append(changes, memberChange{op: op.op, members: members})
+			}
+		}
+	}
+
+	return i.scimUpdateGroup(ctx, d.Get("id").(string), func(group *identity.Group) error {
+		if displayName != "" {
+			group.Name = displayName
+		}
+		for _, change := range changes {
+			switch {
+			case change.op == "add":
+				group.MemberEntityIDs = append(group.MemberEntityIDs, change.members...)
+			case change.op == "replace":
+				group.MemberEntityIDs = change.members
+			case change.members == nil:
+				group.MemberEntityIDs = nil
+			default:
+				for _, member := range change.members {
+					group.MemberEntityIDs = strutil.StrListDelete(group.MemberEntityIDs, member)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// scimUpdateGroup applies update to the internal group with the given ID,
+// checking that its new name is not in use.
+func (i *IdentityStore) scimUpdateGroup(ctx context.Context, id string, update func(*identity.Group) error) (int, interface{}, error) {
+	group, err := func() (*identity.Group, error) {
+		i.groupLock.Lock()
+		defer i.groupLock.Unlock()
+
+		group, err := i.scimInternalGroup(id)
+		if err != nil {
+			return nil, err
+		}
+		if err := update(group); err != nil {
+			return nil, err
+		}
+
+		existing, err := i.MemDBGroupByName(ctx, group.Name, false)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil && existing.ID != group.ID {
+			return nil, newSCIMError(http.StatusConflict, "uniqueness", "group %q already exists", group.Name)
+		}
+
+		if err := i.sanitizeAndUpsertGroup(ctx, group, nil, nil); err != nil {
+			return nil, err
+		}
+		return group, nil
+	}()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resource, err := i.scimGroup(group)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, resource, nil
+}
+
+func (i *IdentityStore) pathSCIMGroupDelete(ctx context.Context, req *logical.Request, d *framework.FieldData) (int, interface{}, error) {
+	group, err := i.scimInternalGroup(d.Get("id").(string))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp, err := i.handleGroupDeleteCommon(ctx, group.ID, true)
+	if err != nil {
+		return 0, nil, err
+	}
+	if resp.IsError() {
+		return 0, nil, resp.Error()
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+// scimInternalGroup returns a clone of the internal group with the given ID.
+// External groups cannot be managed through SCIM, as their memberships are
+// set by their auth method.
+func (i *IdentityStore) scimInternalGroup(id string) (*identity.Group, error) {
+	group, err := i.MemDBGroupByID(id, true)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil || group.NamespaceID != namespace.RootNamespaceID || group.Type != groupTypeInternal {
+		return nil, newSCIMError(http.StatusNotFound, "", "group %q not found", id)
+	}
+	return group, nil
+}
+
+// scimMemberIDs returns the entity IDs of the members of a group, checking
+// that they exist.
+func (i *IdentityStore) scimMemberIDs(members []interface{}) ([]string, error) {
+	ids, err := scimMemberValues(members)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if _, err := i.scimEntity(id); err != nil {
+			var scimErr *scimError
+			if errors.As(err, &scimErr) {
+				return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "member %q is not a user", id)
+			}
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// scimMemberValues returns the values of the members of a group.
+func scimMemberValues(members []interface{}) ([]string, error) {
+	ids := make([]string, 0, len(members))
+	for _, raw := range members {
+		member, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "invalid member")
+		}
+		id, ok := member["value"].(string)
+		if !ok || id == "" {
+			return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "member is missing value")
+		}
+		ids = append(ids, id)
+	}
+	return strutil.RemoveDuplicates(ids, false), nil
+}
+
+// scimGroup returns the SCIM resource of the group.
+func (i *IdentityStore) scimGroup(group *identity.Group) (map[string]interface{}, error) {
+	members := make([]interface{}, 0, len(group.MemberEntityIDs))
+	for _, entityID := range group.MemberEntityIDs {
+		entity, err := i.MemDBEntityByID(entityID, false)
+		if err != nil {
+			return nil, err
+		}
+		if entity == nil {
+			continue
+		}
+		members = append(members, map[string]interface{}{
+			"value":   entity.ID,
+			"display": entity.Name,
+			"$ref":    "/v1/identity/scim/v2/Users/" + entity.ID,
+		})
+	}
+
+	return map[string]interface{}{
+		"schemas":     []string{scimSchemaGroup},
+		"id":          group.ID,
+		"displayName": group.Name,
+		"members":     members,
+		"meta":        scimMeta("Group", group.ID, group.CreationTime, group.LastUpdateTime),
+	}, nil
+}
+
+func scimMeta(resourceType, id string, created, lastModified *timestamppb.Timestamp) map[string]interface{} {
+	return map[string]interface{}{
+		"resourceType": resourceType,
+		"created":      created.AsTime().Format(scimTimeFormat),
+		"lastModified": lastModified.AsTime().Format(scimTimeFormat),
+		"location":     fmt.Sprintf("/v1/identity/scim/v2/%ss/%s", resourceType, id),
+	}
+}
+
+// parseSCIMFilter returns the value the filter requires attribute to be equal
+// to, and whether a filter was given.
+func parseSCIMFilter(filter, attribute string) (string, bool, error) {
+	if filter == "" {
+		return "", false, nil
+	}
+
+	matches := scimFilterRegex.FindStringSubmatch(filter)
+	if matches == nil || !strings.EqualFold(matches[1], attribute) {
+		return "", false, newSCIMError(http.StatusBadRequest, "invalidFilter", "unsupported filter %q, only equality on %s is supported", filter, attribute)
+	}
+	value, err := strconv.Unquote(matches[2])
+	if err != nil {
+		return "", false, newSCIMError(http.StatusBadRequest, "invalidFilter", "invalid filter %q", filter)
+	}
+	return value, true, nil
+}
+
+// scimPage returns the 1-based index of the first resource of the requested
+// page of total resources, and the 0-based indexes of the resources on it.
+func scimPage(d *framework.FieldData, total int) (int, []int) {
+	startIndex := d.Get("startIndex").(int)
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count := d.Get("count").(int)
+	if count < 0 {
+		count = 0
+	}
+	if count > scimMaxResults {
+		count = scimMaxResults
+	}
+
+	var page []int
+	for index := startIndex - 1; index < total && len(page) < count; index++ {
+		page = append(page, index)
+	}
+	return startIndex, page
+}
+
+func scimListResponse(resources []interface{}, total, startIndex int) map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":      []string{scimSchemaListResponse},
+		"totalResults": total,
+		"startIndex":   startIndex,
+		"itemsPerPage": len(resources),
+		"Resources":    resources,
+	}
+}
+
+// scimPatchOperation is an operation of a SCIM patch request.
+type scimPatchOperation struct {
+	op    string
+	path  string
+	value interface{}
+}
+
+// values returns the values the operation sets, keyed by attribute. The
+// attributes of operations without a path are the keys of their value.
+func (o *scimPatchOperation) values() map[string]interface{} {
+	if o.path != "" {
+		return map[string]interface{}{o.path: o.value}
+	}
+	values, _ := o.value.(map[string]interface{})
+	return values
+}
+
+func parseSCIMPatchOperations(d *framework.FieldData) ([]*scimPatchOperation, error) {
+	raw, ok := d.GetOk("Operations")
+	if !ok {
+		return nil, newSCIMError(http.StatusBadRequest, "invalidSyntax", "missing Operations")
+	}
+
+	var ops []*scimPatchOperation
+	for _, rawOp := range raw.([]interface{}) {
+		opMap, ok := rawOp.(map[string]interface{})
+		if !ok {
+			return nil, newSCIMError(http.StatusBadRequest, "invalidSyntax", "invalid operation")
+		}
+
+		op := &scimPatchOperation{value: opMap["value"]}
+		op.op, _ = opMap["op"].(string)
+		op.op = strings.ToLower(op.op)
+		op.path, _ = opMap["path"].(string)
+		switch op.op {
+		case "add", "replace", "remove":
+		default:
+			return nil, newSCIMError(http.StatusBadRequest, "invalidSyntax", "invalid operation %q", op.op)
+		}
+		if op.op == "remove" && op.path == "" {
+			return nil, newSCIMError(http.StatusBadRequest, "noTarget", "remove operations require a path")
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+var scimHelp = map[string][2]string{
+	"scim-config": {
+		"Configure the SCIM provisioning endpoint.",
+		`
+Users provisioned through the SCIM endpoint are entities of the root
+namespace. If 'mount_accessor' is set, each of them is given an alias named
+after their userName on that auth method, so that their logins are
+attributed to the provisioned entity.
+`,
+	},
+	"scim-service-provider-config": {
+		"Read the SCIM service provider configuration.",
+		"",
+	},
+	"scim-users": {
+		"List or provision SCIM users.",
+		`
+SCIM users are the entities of the root namespace, named after their
+userName. Inactive users are disabled entities. Users can be listed with a
+filter on their userName, e.g. 'userName eq "alice"'.
+`,
+	},
+	"scim-user": {
+		"Read, update or deprovision a SCIM user.",
+		`
+Deactivating or deleting a user revokes the tokens of its entity, along with
+the leases created with them. Deleting a user also deletes its entity.
+`,
+	},
+	"scim-groups": {
+		"List or create SCIM groups.",
+		`
+SCIM groups are the internal groups of the root namespace, named after their
+displayName, whose members are SCIM users. Groups can be listed with a filter
+on their displayName.
+`,
+	},
+	"scim-group": {
+		"Read, update or delete a SCIM group.",
+		"",
+	},
+}
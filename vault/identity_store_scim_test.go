@@ -0,0 +1,241 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// scimRequest sends a SCIM request to the identity store and decodes the
+// status and resource of its response.
+func scimRequest(t *testing.T, c *Core, op logical.Operation, path string, data map[string]interface{}) (int, map[string]interface{}) {
+	t.Helper()
+
+	resp, err := c.identityStore.HandleRequest(namespace.RootContext(nil), &logical.Request{
+		Path:      path,
+		Operation: op,
+		Data:      data,
+	})
+	require.NoError(t, err)
+
+	var resource map[string]interface{}
+	if body, ok := resp.Data[logical.HTTPRawBody]; ok {
+		require.Equal(t, scimContentType, resp.Data[logical.HTTPContentType])
+		require.NoError(t, json.Unmarshal(body.([]byte), &resource))
+	}
+	return resp.Data[logical.HTTPStatusCode].(int), resource
+}
+
+// TestIdentityStore_SCIM_Users verifies the lifecycle of a user provisioned
+// through SCIM: its logins are attributed to its entity through its alias,
+// and deactivating it revokes the tokens of the entity.
+func TestIdentityStore_SCIM_Users(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	c.credentialBackends["directory"] = func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		return &NoopBackend{
+			BackendType: logical.TypeCredential,
+			Login:       []string{"login"},
+			RequestHandler: func(ctx context.Context, req *logical.Request) (*logical.Response, error) {
+				return &logical.Response{
+					Auth: &logical.Auth{
+						Alias:    &logical.Alias{Name: "alice"},
+						Policies: []string{"default"},
+						LeaseOptions: logical.LeaseOptions{
+							TTL:       time.Hour,
+							Renewable: true,
+						},
+					},
+				}, nil
+			},
+		}, nil
+	}
+	resp, err := c.HandleRequest(ctx, &logical.Request{
+		Path:        "sys/auth/directory",
+		Operation:   logical.UpdateOperation,
+		ClientToken: root,
+		Data:        map[string]interface{}{"type": "directory"},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+	accessor := c.router.MatchingMountEntry(ctx, "auth/directory/").Accessor
+
+	resp, err = c.identityStore.HandleRequest(ctx, &logical.Request{
+		Path:      "scim/config",
+		Operation: logical.UpdateOperation,
+		Data:      map[string]interface{}{"mount_accessor": accessor},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	status, user := scimRequest(t, c, logical.UpdateOperation, "scim/v2/Users", map[string]interface{}{
+		"schemas":  []string{scimSchemaUser},
+		"userName": "alice",
+		"active":   true,
+	})
+	require.Equal(t, http.StatusCreated, status)
+	require.Equal(t, "alice", user["userName"])
+	require.Equal(t, true, user["active"])
+	userID := user["id"].(string)
+
+	status, scimErr := scimRequest(t, c, logical.UpdateOperation, "scim/v2/Users", map[string]interface{}{
+		"userName": "alice",
+	})
+	require.Equal(t, http.StatusConflict, status)
+	require.Equal(t, "uniqueness", scimErr["scimType"])
+
+	status, list := scimRequest(t, c, logical.ReadOperation, "scim/v2/Users", map[string]interface{}{
+		"filter": `userName eq "Alice"`,
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, float64(1), list["totalResults"])
+	require.Equal(t, userID, list["Resources"].([]interface{})[0].(map[string]interface{})["id"])
+
+	status, scimErr = scimRequest(t, c, logical.ReadOperation, "scim/v2/Users", map[string]interface{}{
+		"filter": `emails co "example.com"`,
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "invalidFilter", scimErr["scimType"])
+
+	login := func() string {
+		resp, err := c.HandleRequest(ctx, &logical.Request{
+			Path:       "auth/directory/login",
+			Operation:  logical.UpdateOperation,
+			Connection: &logical.Connection{},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, resp.Auth)
+		require.Equal(t, userID, resp.Auth.EntityID)
+		return resp.Auth.ClientToken
+	}
+	token := login()
+
+	// Deactivating the user revokes the tokens of its entity
+	status, user = scimRequest(t, c, logical.PatchOperation, "scim/v2/Users/"+userID, map[string]interface{}{
+		"Operations": []interface{}{
+			map[string]interface{}{"op": "Replace", "path": "active", "value": "False"},
+		},
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, false, user["active"])
+
+	te, err := c.tokenStore.Lookup(ctx, token)
+	require.NoError(t, err)
+	require.Nil(t, te)
+
+	entity, err := c.identityStore.MemDBEntityByID(userID, false)
+	require.NoError(t, err)
+	require.True(t, entity.Disabled)
+
+	// Renaming the user renames its alias
+	status, user = scimRequest(t, c, logical.UpdateOperation, "scim/v2/Users/"+userID, map[string]interface{}{
+		"userName": "alice.smith",
+		"active":   true,
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "alice.smith", user["userName"])
+
+	alias, err := c.identityStore.MemDBAliasByFactors(accessor, "alice.smith", false, false)
+	require.NoError(t, err)
+	require.Equal(t, userID, alias.CanonicalID)
+
+	status, _ = scimRequest(t, c, logical.UpdateOperation, "scim/v2/Users/"+userID, map[string]interface{}{
+		"userName": "alice",
+	})
+	require.Equal(t, http.StatusOK, status)
+	token = login()
+
+	// Deleting the user revokes the tokens of its entity and deletes it
+	status, _ = scimRequest(t, c, logical.DeleteOperation, "scim/v2/Users/"+userID, nil)
+	require.Equal(t, http.StatusNoContent, status)
+
+	te, err = c.tokenStore.Lookup(ctx, token)
+	require.NoError(t, err)
+	require.Nil(t, te)
+
+	status, scimErr = scimRequest(t, c, logical.ReadOperation, "scim/v2/Users/"+userID, nil)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, "404", scimErr["status"])
+}
+
+// TestIdentityStore_SCIM_Groups verifies that SCIM groups manage the members
+// of internal groups.
+func TestIdentityStore_SCIM_Groups(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	var userIDs []string
+	for _, name := range []string{"alice", "bob"} {
+		status, user := scimRequest(t, c, logical.UpdateOperation, "scim/v2/Users", map[string]interface{}{
+			"userName": name,
+		})
+		require.Equal(t, http.StatusCreated, status)
+		userIDs = append(userIDs, user["id"].(string))
+	}
+
+	status, scimErr := scimRequest(t, c, logical.UpdateOperation, "scim/v2/Groups", map[string]interface{}{
+		"displayName": "engineering",
+		"members":     []interface{}{map[string]interface{}{"value": "unknown"}},
+	})
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "invalidValue", scimErr["scimType"])
+
+	status, group := scimRequest(t, c, logical.UpdateOperation, "scim/v2/Groups", map[string]interface{}{
+		"displayName": "engineering",
+		"members":     []interface{}{map[string]interface{}{"value": userIDs[0]}},
+	})
+	require.Equal(t, http.StatusCreated, status)
+	groupID := group["id"].(string)
+
+	status, group = scimRequest(t, c, logical.PatchOperation, "scim/v2/Groups/"+groupID, map[string]interface{}{
+		"Operations": []interface{}{
+			map[string]interface{}{
+				"op":    "add",
+				"path":  "members",
+				"value": []interface{}{map[string]interface{}{"value": userIDs[1]}},
+			},
+			map[string]interface{}{
+				"op":   "remove",
+				"path": `members[value eq "` + userIDs[0] + `"]`,
+			},
+			map[string]interface{}{
+				"op":    "replace",
+				"value": map[string]interface{}{"displayName": "platform"},
+			},
+		},
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "platform", group["displayName"])
+	require.Len(t, group["members"], 1)
+	require.Equal(t, "bob", group["members"].([]interface{})[0].(map[string]interface{})["display"])
+
+	memdbGroup, err := c.identityStore.MemDBGroupByID(groupID, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{userIDs[1]}, memdbGroup.MemberEntityIDs)
+
+	status, user := scimRequest(t, c, logical.ReadOperation, "scim/v2/Users/"+userIDs[1], nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, groupID, user["groups"].([]interface{})[0].(map[string]interface{})["value"])
+
+	status, list := scimRequest(t, c, logical.ReadOperation, "scim/v2/Groups", map[string]interface{}{
+		"filter": `displayName eq "platform"`,
+	})
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, float64(1), list["totalResults"])
+
+	status, _ = scimRequest(t, c, logical.DeleteOperation, "scim/v2/Groups/"+groupID, nil)
+	require.Equal(t, http.StatusNoContent, status)
+
+	memdbGroup, err = c.identityStore.MemDBGroupByID(groupID, false)
+	require.NoError(t, err)
+	require.Nil(t, memdbGroup)
+}
@@ -60,9 +60,25 @@ type IdentityStore struct {
 	lock     sync.RWMutex
 	oidcLock sync.RWMutex
 
+	// oidcRefreshTokenLock serializes the redemption of OIDC refresh tokens,
+	// which are single use
+	oidcRefreshTokenLock sync.Mutex
+
 	// groupLock is used to protect modifications to group entries
 	groupLock sync.RWMutex
 
+	// groupSyncLock serializes updates of the schedule on which external group
+	// memberships are refreshed
+	groupSyncLock sync.Mutex
+
+	// metadataSourcesLock serializes updates of the sources entity metadata
+	// is pulled from
+	metadataSourcesLock sync.Mutex
+
+	// deletedEntitiesLock serializes updates of the deleted entities listed
+	// in the OIDC revocation lists
+	deletedEntitiesLock sync.Mutex
+
 	// oidcCache stores common response data as well as when the periodic func needs
 	// to run. This is conservatively managed, and most writes to the OIDC endpoints
 	// will invalidate the cache.
@@ -103,6 +119,7 @@ type IdentityStore struct {
 	tokenStorer   TokenStorer
 	entityCreator EntityCreator
 	mountLister   MountLister
+	eventSender   LifecycleEventSender
 	mfaBackend    *LoginMFABackend
 }
 
@@ -145,6 +162,7 @@ var _ GroupUpdater = &Core{}
 type TokenStorer interface {
 	LookupToken(context.Context, string) (*logical.TokenEntry, error)
 	CreateToken(context.Context, *logical.TokenEntry) error
+	RevokeEntityTokens(ctx context.Context, entityID string) (int, error)
 }
 
 var _ TokenStorer = &Core{}
@@ -161,3 +179,9 @@ type MountLister interface {
 }
 
 var _ MountLister = &Core{}
+
+type LifecycleEventSender interface {
+	sendLifecycleEvent(ns *namespace.Namespace, eventType logical.EventType, dataPath, operation string, metadataPairs ...string)
+}
+
+var _ LifecycleEventSender = &Core{}
@@ -5,6 +5,8 @@ package vault
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -913,3 +915,55 @@ func TestIdentityStore_DeleteCaseSensitivityKey(t *testing.T) {
 		t.Fatalf("bad: expected no entry for casesensitivity key")
 	}
 }
+
+// TestIdentityStore_LoadBuckets verifies that the identity store is loaded
+// from storage packer buckets spanning more than one page of listing, and
+// that the first error stops the loading.
+func TestIdentityStore_LoadBuckets(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+	is := c.identityStore
+
+	var ids []string
+	entityIDs := make(map[string]struct{})
+	for j := 0; j < 2*identityBucketListPageSize; j++ {
+		resp, err := is.HandleRequest(ctx, &logical.Request{
+			Path:      "entity",
+			Operation: logical.UpdateOperation,
+			Data:      map[string]interface{}{"name": fmt.Sprintf("entity-%d", j)},
+		})
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+		}
+		ids = append(ids, resp.Data["id"].(string))
+		entityIDs[resp.Data["id"].(string)] = struct{}{}
+	}
+
+	buckets := make(map[string]struct{})
+	err := is.loadBuckets(ctx, is.entityPacker, storagepacker.StoragePackerBucketsPrefix, "entities", func(bucket *storagepacker.Bucket) error {
+		buckets[bucket.Key] = struct{}{}
+		for _, item := range bucket.Items {
+			delete(entityIDs, item.ID)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Greater(t, len(buckets), identityBucketListPageSize)
+	require.Empty(t, entityIDs)
+
+	require.NoError(t, is.resetDB(ctx))
+	require.NoError(t, is.loadEntities(ctx))
+	for _, id := range ids {
+		entity, err := is.MemDBEntityByID(id, false)
+		require.NoError(t, err)
+		require.NotNil(t, entity)
+	}
+
+	var calls int
+	err = is.loadBuckets(ctx, is.entityPacker, storagepacker.StoragePackerBucketsPrefix, "entities", func(bucket *storagepacker.Bucket) error {
+		calls++
+		return errors.New("failed to load bucket")
+	})
+	require.EqualError(t, err, "failed to load bucket")
+	require.Equal(t, 1, calls)
+}
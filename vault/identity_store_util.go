@@ -88,24 +88,112 @@ func (i *IdentityStore) sanitizeName(name string) string {
 	return strings.ToLower(name)
 }
 
-func (i *IdentityStore) loadGroups(ctx context.Context) error {
-	i.logger.Debug("identity loading groups")
-	existing, err := i.groupPacker.View().List(ctx, groupBucketsPrefix)
-	if err != nil {
-		return fmt.Errorf("failed to scan for groups: %w", err)
+// identityBucketListPageSize is the number of storage packer buckets listed
+// from storage at a time while loading the identity store
+const identityBucketListPageSize = 100
+
+// loadBuckets fetches the storage packer buckets under the prefix using a pool
+// of workers, and calls cb with each of them. The buckets are listed a page at
+// a time and handed to the workers as they are listed, rather than listing all
+// of them first. cb is not called concurrently, and the first error stops the
+// loading.
+func (i *IdentityStore) loadBuckets(ctx context.Context, packer *storagepacker.StoragePacker, prefix, kind string, cb func(*storagepacker.Bucket) error) error {
+	// Make the channels used for the worker pool
+	broker := make(chan string)
+	result := make(chan *storagepacker.Bucket)
+	quit := make(chan struct{})
+
+	// The first error stops the loading
+	var errOnce sync.Once
+	var loadErr error
+	fail := func(err error) {
+		errOnce.Do(func() {
+			loadErr = err
+			close(quit)
+		})
+	}
+
+	// Use a wait group
+	wg := &sync.WaitGroup{}
+
+	// Create 64 workers to distribute work to
+	for j := 0; j < consts.ExpirationRestoreWorkerCount; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// The broker is closed once all the buckets have been listed
+			for key := range broker {
+				bucket, err := packer.GetBucket(ctx, prefix+key)
+				if err != nil {
+					fail(err)
+					return
+				}
+
+				// Write results out to the result channel
+				select {
+				case result <- bucket:
+				case <-quit:
+					return
+				}
+			}
+		}()
 	}
-	i.logger.Debug("groups collected", "num_existing", len(existing))
 
-	for _, key := range existing {
-		bucket, err := i.groupPacker.GetBucket(ctx, groupBucketsPrefix+key)
+	// Distribute the buckets to the workers as they are listed from storage
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(broker)
+
+		var j int
+		view := logical.NewStorageView(packer.View(), prefix)
+		err := logical.ScanViewPaginated(ctx, view, identityBucketListPageSize, func(key string) bool {
+			if j%500 == 0 {
+				i.logger.Debug(kind+" loading", "progress", j)
+			}
+			j++
+
+			select {
+			case <-quit:
+				return false
+			case broker <- key:
+				return true
+			}
+		})
 		if err != nil {
-			return err
+			fail(fmt.Errorf("failed to scan for %s: %w", kind, err))
 		}
+	}()
+
+	// Close the result channel once all the go routines are done
+	go func() {
+		wg.Wait()
+		close(result)
+	}()
 
+	// Restore each bucket by pulling from the result chan
+	for bucket := range result {
+		// If there is no entry, nothing to restore
 		if bucket == nil {
 			continue
 		}
+		if err := cb(bucket); err != nil {
+			fail(err)
+			break
+		}
+	}
+
+	// Let all go routines finish
+	for range result {
+	}
 
+	return loadErr
+}
+
+func (i *IdentityStore) loadGroups(ctx context.Context) error {
+	i.logger.Debug("identity loading groups")
+	err := i.loadBuckets(ctx, i.groupPacker, groupBucketsPrefix, "groups", func(bucket *storagepacker.Bucket) error {
 		for _, item := range bucket.Items {
 			group, err := i.parseGroupFromBucketItem(item)
 			if err != nil {
@@ -177,6 +265,10 @@ func (i *IdentityStore) loadGroups(ctx context.Context) error {
 
 			txn.Commit()
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	if i.logger.IsInfo() {
@@ -196,282 +288,107 @@ func (i *IdentityStore) loadCachedEntitiesOfLocalAliases(ctx context.Context) er
 	}
 
 	i.logger.Debug("loading cached entities of local aliases")
-	existing, err := i.localAliasPacker.View().List(ctx, localAliasesBucketsPrefix)
-	if err != nil {
-		return fmt.Errorf("failed to scan for cached entities of local alias: %w", err)
-	}
-
-	i.logger.Debug("cached entities of local alias entries", "num_buckets", len(existing))
-
-	// Make the channels used for the worker pool
-	broker := make(chan string)
-	quit := make(chan bool)
-
-	// Buffer these channels to prevent deadlocks
-	errs := make(chan error, len(existing))
-	result := make(chan *storagepacker.Bucket, len(existing))
-
-	// Use a wait group
-	wg := &sync.WaitGroup{}
-
-	// Create 64 workers to distribute work to
-	for j := 0; j < consts.ExpirationRestoreWorkerCount; j++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
-			for {
-				select {
-				case key, ok := <-broker:
-					// broker has been closed, we are done
-					if !ok {
-						return
-					}
-
-					bucket, err := i.localAliasPacker.GetBucket(ctx, localAliasesBucketsPrefix+key)
-					if err != nil {
-						errs <- err
-						continue
-					}
-
-					// Write results out to the result channel
-					result <- bucket
-
-				// quit early
-				case <-quit:
-					return
-				}
-			}
-		}()
-	}
-
-	// Distribute the collected keys to the workers in a go routine
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for j, key := range existing {
-			if j%500 == 0 {
-				i.logger.Debug("cached entities of local aliases loading", "progress", j)
+	err := i.loadBuckets(ctx, i.localAliasPacker, localAliasesBucketsPrefix, "cached entities of local aliases", func(bucket *storagepacker.Bucket) error {
+		for _, item := range bucket.Items {
+			if !strings.HasSuffix(item.ID, tmpSuffix) {
+				continue
 			}
-
-			select {
-			case <-quit:
-				return
-
-			default:
-				broker <- key
+			entity, err := i.parseCachedEntity(item)
+			if err != nil {
+				return err
 			}
-		}
-
-		// Close the broker, causing worker routines to exit
-		close(broker)
-	}()
-
-	defer func() {
-		// Let all go routines finish
-		wg.Wait()
-
-		i.logger.Info("cached entities of local aliases restored")
-	}()
-
-	// Restore each key by pulling from the result chan
-	for j := 0; j < len(existing); j++ {
-		select {
-		case err := <-errs:
-			// Close all go routines
-			close(quit)
-
-			return err
-
-		case bucket := <-result:
-			// If there is no entry, nothing to restore
-			if bucket == nil {
-				continue
+			ns, err := i.namespacer.NamespaceByID(ctx, entity.NamespaceID)
+			if err != nil {
+				return err
 			}
+			nsCtx := namespace.ContextWithNamespace(ctx, ns)
 
-			for _, item := range bucket.Items {
-				if !strings.HasSuffix(item.ID, tmpSuffix) {
-					continue
-				}
-				entity, err := i.parseCachedEntity(item)
-				if err != nil {
-					return err
-				}
-				ns, err := i.namespacer.NamespaceByID(ctx, entity.NamespaceID)
-				if err != nil {
-					return err
-				}
-				nsCtx := namespace.ContextWithNamespace(ctx, ns)
-
-				err = i.upsertEntity(nsCtx, entity, nil, false)
-				if err != nil {
-					return fmt.Errorf("failed to update entity in MemDB: %w", err)
-				}
+			err = i.upsertEntity(nsCtx, entity, nil, false)
+			if err != nil {
+				return fmt.Errorf("failed to update entity in MemDB: %w", err)
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	i.logger.Info("cached entities of local aliases restored")
+
 	return nil
 }
 
 func (i *IdentityStore) loadEntities(ctx context.Context) error {
-	// Accumulate existing entities
 	i.logger.Debug("loading entities")
-	existing, err := i.entityPacker.View().List(ctx, storagepacker.StoragePackerBucketsPrefix)
-	if err != nil {
-		return fmt.Errorf("failed to scan for entities: %w", err)
-	}
-	i.logger.Debug("entities collected", "num_existing", len(existing))
-
 	duplicatedAccessors := make(map[string]struct{})
-	// Make the channels used for the worker pool
-	broker := make(chan string)
-	quit := make(chan bool)
-
-	// Buffer these channels to prevent deadlocks
-	errs := make(chan error, len(existing))
-	result := make(chan *storagepacker.Bucket, len(existing))
-
-	// Use a wait group
-	wg := &sync.WaitGroup{}
-
-	// Create 64 workers to distribute work to
-	for j := 0; j < consts.ExpirationRestoreWorkerCount; j++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
-			for {
-				select {
-				case key, ok := <-broker:
-					// broker has been closed, we are done
-					if !ok {
-						return
-					}
-
-					bucket, err := i.entityPacker.GetBucket(ctx, storagepacker.StoragePackerBucketsPrefix+key)
-					if err != nil {
-						errs <- err
-						continue
-					}
-
-					// Write results out to the result channel
-					result <- bucket
-
-				// quit early
-				case <-quit:
-					return
-				}
-			}
-		}()
-	}
-
-	// Distribute the collected keys to the workers in a go routine
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for j, key := range existing {
-			if j%500 == 0 {
-				i.logger.Debug("entities loading", "progress", j)
-			}
-
-			select {
-			case <-quit:
-				return
-
-			default:
-				broker <- key
+	err := i.loadBuckets(ctx, i.entityPacker, storagepacker.StoragePackerBucketsPrefix, "entities", func(bucket *storagepacker.Bucket) error {
+		for _, item := range bucket.Items {
+			entity, err := i.parseEntityFromBucketItem(ctx, item)
+			if err != nil {
+				return err
 			}
-		}
-
-		// Close the broker, causing worker routines to exit
-		close(broker)
-	}()
-
-	// Restore each key by pulling from the result chan
-LOOP:
-	for j := 0; j < len(existing); j++ {
-		select {
-		case err = <-errs:
-			// Close all go routines
-			close(quit)
-			break LOOP
-
-		case bucket := <-result:
-			// If there is no entry, nothing to restore
-			if bucket == nil {
+			if entity == nil {
 				continue
 			}
 
-			for _, item := range bucket.Items {
-				entity, err := i.parseEntityFromBucketItem(ctx, item)
-				if err != nil {
-					return err
-				}
-				if entity == nil {
-					continue
-				}
-
-				ns, err := i.namespacer.NamespaceByID(ctx, entity.NamespaceID)
-				if err != nil {
-					return err
-				}
-				if ns == nil {
-					// Remove dangling entities
-					if !(i.localNode.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) || i.localNode.HAState() == consts.PerfStandby) {
-						// Entity's namespace doesn't exist anymore but the
-						// entity from the namespace still exists.
-						i.logger.Warn("deleting entity and its any existing aliases", "name", entity.Name, "namespace_id", entity.NamespaceID)
-						err = i.entityPacker.DeleteItem(ctx, entity.ID)
-						if err != nil {
-							return err
-						}
+			ns, err := i.namespacer.NamespaceByID(ctx, entity.NamespaceID)
+			if err != nil {
+				return err
+			}
+			if ns == nil {
+				// Remove dangling entities
+				if !(i.localNode.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) || i.localNode.HAState() == consts.PerfStandby) {
+					// Entity's namespace doesn't exist anymore but the
+					// entity from the namespace still exists.
+					i.logger.Warn("deleting entity and its any existing aliases", "name", entity.Name, "namespace_id", entity.NamespaceID)
+					err = i.entityPacker.DeleteItem(ctx, entity.ID)
+					if err != nil {
+						return err
 					}
-					continue
 				}
-				nsCtx := namespace.ContextWithNamespace(ctx, ns)
+				continue
+			}
+			nsCtx := namespace.ContextWithNamespace(ctx, ns)
 
-				// Ensure that there are no entities with duplicate names
-				entityByName, err := i.MemDBEntityByName(nsCtx, entity.Name, false)
-				if err != nil {
-					return nil
-				}
-				if entityByName != nil {
-					i.logger.Warn(errDuplicateIdentityName.Error(), "entity_name", entity.Name, "conflicting_entity_name", entityByName.Name, "action", "merge the duplicate entities into one")
-					if !i.disableLowerCasedNames {
-						return errDuplicateIdentityName
-					}
+			// Ensure that there are no entities with duplicate names
+			entityByName, err := i.MemDBEntityByName(nsCtx, entity.Name, false)
+			if err != nil {
+				return nil
+			}
+			if entityByName != nil {
+				i.logger.Warn(errDuplicateIdentityName.Error(), "entity_name", entity.Name, "conflicting_entity_name", entityByName.Name, "action", "merge the duplicate entities into one")
+				if !i.disableLowerCasedNames {
+					return errDuplicateIdentityName
 				}
+			}
 
-				mountAccessors := getAccessorsOnDuplicateAliases(entity.Aliases)
+			mountAccessors := getAccessorsOnDuplicateAliases(entity.Aliases)
 
-				for _, accessor := range mountAccessors {
-					if _, ok := duplicatedAccessors[accessor]; !ok {
-						duplicatedAccessors[accessor] = struct{}{}
-					}
+			for _, accessor := range mountAccessors {
+				if _, ok := duplicatedAccessors[accessor]; !ok {
+					duplicatedAccessors[accessor] = struct{}{}
 				}
+			}
 
-				localAliases, err := i.parseLocalAliases(entity.ID)
-				if err != nil {
-					return fmt.Errorf("failed to load local aliases from storage: %v", err)
-				}
-				if localAliases != nil {
-					for _, alias := range localAliases.Aliases {
-						entity.UpsertAlias(alias)
-					}
+			localAliases, err := i.parseLocalAliases(entity.ID)
+			if err != nil {
+				return fmt.Errorf("failed to load local aliases from storage: %v", err)
+			}
+			if localAliases != nil {
+				for _, alias := range localAliases.Aliases {
+					entity.UpsertAlias(alias)
 				}
+			}
 
-				// Only update MemDB and don't hit the storage again
-				err = i.upsertEntity(nsCtx, entity, nil, false)
-				if err != nil {
-					return fmt.Errorf("failed to update entity in MemDB: %w", err)
-				}
+			// Only update MemDB and don't hit the storage again
+			err = i.upsertEntity(nsCtx, entity, nil, false)
+			if err != nil {
+				return fmt.Errorf("failed to update entity in MemDB: %w", err)
 			}
 		}
-	}
-
-	// Let all go routines finish
-	wg.Wait()
+		return nil
+	})
 	if err != nil {
 		return err
 	}
@@ -11,12 +11,14 @@ import (
 	"fmt"
 	"net/url"
 	"sync/atomic"
+	"time"
 
 	"github.com/hashicorp/vault/physical/raft"
 	"github.com/hashicorp/vault/vault/seal"
 
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/helper/x509keys"
 	"github.com/hashicorp/vault/shamir"
 )
 
@@ -37,6 +39,7 @@ type InitResult struct {
 	SecretShares   [][]byte
 	RecoveryShares [][]byte
 	RootToken      string
+	Warnings       []string
 }
 
 var (
@@ -154,6 +157,19 @@ func (c *Core) generateShares(sc *SealConfig) ([]byte, [][]byte, error) {
 		unsealKeys = encryptedShares
 	}
 
+	// If we have X.509 certificates, encrypt to their public keys instead
+	if len(sc.X509Certificates) > 0 {
+		hexEncodedShares := make([][]byte, len(unsealKeys))
+		for i := range unsealKeys {
+			hexEncodedShares[i] = []byte(hex.EncodeToString(unsealKeys[i]))
+		}
+		_, encryptedShares, err := x509keys.EncryptShares(hexEncodedShares, sc.X509Certificates)
+		if err != nil {
+			return nil, nil, err
+		}
+		unsealKeys = encryptedShares
+	}
+
 	return rootKey, unsealKeys, nil
 }
 
@@ -181,6 +197,9 @@ func (c *Core) Initialize(ctx context.Context, initParams *InitParams) (*InitRes
 		if len(barrierConfig.PGPKeys) > 0 {
 			return nil, fmt.Errorf("PGP keys not supported when storing shares")
 		}
+		if len(barrierConfig.X509Certificates) > 0 {
+			return nil, fmt.Errorf("X.509 certificates not supported when storing shares")
+		}
 		barrierConfig.SecretShares = 1
 		barrierConfig.SecretThreshold = 1
 		if barrierConfig.StoredShares != 1 {
@@ -198,10 +217,17 @@ func (c *Core) Initialize(ctx context.Context, initParams *InitParams) (*InitRes
 		return nil, fmt.Errorf("incorrect number of PGP keys")
 	}
 
+	if len(barrierConfig.X509Certificates) > 0 && len(barrierConfig.X509Certificates) != barrierConfig.SecretShares {
+		return nil, fmt.Errorf("incorrect number of X.509 certificates")
+	}
+
 	if c.SealAccess().RecoveryKeySupported() {
 		if len(recoveryConfig.PGPKeys) > 0 && len(recoveryConfig.PGPKeys) != recoveryConfig.SecretShares {
 			return nil, fmt.Errorf("incorrect number of PGP keys for recovery")
 		}
+		if len(recoveryConfig.X509Certificates) > 0 && len(recoveryConfig.X509Certificates) != recoveryConfig.SecretShares {
+			return nil, fmt.Errorf("incorrect number of X.509 certificates for recovery")
+		}
 	}
 
 	if c.seal.RecoveryKeySupported() {
@@ -320,6 +346,15 @@ func (c *Core) Initialize(ctx context.Context, initParams *InitParams) (*InitRes
 		SecretShares: [][]byte{},
 	}
 
+	now := time.Now()
+	results.Warnings = barrierConfig.pgpKeyExpirationWarnings(now)
+	if recoveryConfig != nil && c.seal.RecoveryKeySupported() {
+		results.Warnings = append(results.Warnings, recoveryConfig.pgpKeyExpirationWarnings(now)...)
+	}
+	for _, warning := range results.Warnings {
+		c.logger.Warn("initialization PGP key is expiring", "warning", warning)
+	}
+
 	// If we are storing shares, pop them out of the returned results and push
 	// them through the seal
 	switch c.seal.StoredKeysSupported() {
@@ -473,7 +508,7 @@ func (c *Core) UnsealWithStoredKeys(ctx context.Context) error {
 		return NewNonFatalError(errors.New("expected exactly one stored key"))
 	}
 
-	err = c.unsealInternal(ctx, keys[0])
+	err = c.unsealInternal(ctx, keys[0], sealReasonStoredKeys)
 	if err != nil {
 		return NewNonFatalError(fmt.Errorf("unseal with stored key failed: %w", err))
 	}
@@ -5,10 +5,22 @@ package vault
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
 	"reflect"
 	"testing"
+	"time"
 
 	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/x509keys"
 	"github.com/hashicorp/vault/sdk/helper/logging"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/physical/inmem"
@@ -19,6 +31,79 @@ func TestCore_Init(t *testing.T) {
 	testCore_Init_Common(t, c, conf, &SealConfig{SecretShares: 5, SecretThreshold: 3}, nil)
 }
 
+func TestCore_Init_X509Certificates(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []crypto.Signer{rsaKey, ecKey}
+
+	var certs []string
+	for _, key := range keys {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "piv"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certs = append(certs, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	}
+
+	c, _ := testCore_NewTestCore(t, nil)
+	res, err := c.Initialize(context.Background(), &InitParams{
+		BarrierConfig: &SealConfig{
+			SecretShares:     2,
+			SecretThreshold:  2,
+			X509Certificates: certs,
+		},
+		RecoveryConfig: &SealConfig{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, key := range keys {
+		hexShare, err := x509keys.DecryptShare(key, res.SecretShares[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		share, err := hex.DecodeString(string(hexShare))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := TestCoreUnseal(c, share); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.Sealed() {
+		t.Fatal("should not be sealed")
+	}
+}
+
+func TestSealConfig_Validate_X509Certificates(t *testing.T) {
+	conf := &SealConfig{
+		SecretShares:     1,
+		SecretThreshold:  1,
+		X509Certificates: []string{"not a certificate"},
+	}
+	if err := conf.Validate(); err == nil {
+		t.Fatal("expected error for invalid certificate")
+	}
+
+	conf.PGPKeys = []string{testPGPKey(t, time.Now(), 0)}
+	if err := conf.Validate(); err == nil {
+		t.Fatal("expected error for PGP keys and certificates")
+	}
+}
+
 func testCore_NewTestCore(t *testing.T, seal Seal) (*Core, *CoreConfig) {
 	return testCore_NewTestCoreLicensing(t, seal, nil)
 }
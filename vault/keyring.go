@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/hashicorp/vault/helper/lockedbuffer"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 )
 
@@ -36,9 +37,10 @@ var (
 // allows for decryption of keys written previously. Along with the encryption
 // keys, the keyring also tracks the root key. This is necessary so that
 // when a new key is added to the keyring, we can encrypt with the root key
-// and write out the new keyring.
+// and write out the new keyring. The root key is held in locked memory, which
+// each clone of the keyring owns a copy of and releases on Zeroize.
 type Keyring struct {
-	rootKey        []byte
+	rootKey        *lockedbuffer.Buffer
 	keys           map[uint32]*Key
 	activeTerm     uint32
 	rotationConfig KeyRotationConfig
@@ -93,7 +95,7 @@ func NewKeyring() *Keyring {
 // Clone returns a new copy of the keyring
 func (k *Keyring) Clone() *Keyring {
 	clone := &Keyring{
-		rootKey:        k.rootKey,
+		rootKey:        lockedbuffer.New(k.rootKey.Bytes()),
 		keys:           make(map[uint32]*Key, len(k.keys)),
 		activeTerm:     k.activeTerm,
 		rotationConfig: k.rotationConfig,
@@ -175,23 +177,23 @@ func (k *Keyring) TermKey(term uint32) *Key {
 
 // SetRootKey is used to update the root key
 func (k *Keyring) SetRootKey(val []byte) *Keyring {
-	valCopy := make([]byte, len(val))
-	copy(valCopy, val)
 	clone := k.Clone()
-	clone.rootKey = valCopy
+	clone.rootKey.Destroy()
+	clone.rootKey = lockedbuffer.New(val)
 	return clone
 }
 
-// RootKey returns the root key
+// RootKey returns the root key. The returned slice refers to locked memory
+// and must not be retained beyond the lifetime of the keyring.
 func (k *Keyring) RootKey() []byte {
-	return k.rootKey
+	return k.rootKey.Bytes()
 }
 
 // Serialize is used to create a byte encoded keyring
 func (k *Keyring) Serialize() ([]byte, error) {
 	// Create the encoded entry
 	enc := EncodedKeyring{
-		MasterKey:      k.rootKey,
+		MasterKey:      k.rootKey.Bytes(),
 		RotationConfig: k.rotationConfig,
 	}
 	for _, key := range k.keys {
@@ -213,7 +215,10 @@ func DeserializeKeyring(buf []byte) (*Keyring, error) {
 
 	// Create a new keyring
 	k := NewKeyring()
-	k.rootKey = enc.MasterKey
+	if len(enc.MasterKey) > 0 {
+		k.rootKey = lockedbuffer.New(enc.MasterKey)
+		memzero(enc.MasterKey)
+	}
 	k.rotationConfig = enc.RotationConfig
 	k.rotationConfig.Sanitize()
 	for _, key := range enc.Keys {
@@ -226,15 +231,15 @@ func DeserializeKeyring(buf []byte) (*Keyring, error) {
 }
 
 // N.B.:
-// Since Go 1.5 these are not reliable; see the documentation around the memzero
-// function. These are best-effort.
+// Since Go 1.5 these are not reliable for the keys; see the documentation
+// around the memzero function. These are best-effort. The root key is
+// destroyed along with its locked memory, which clones of the keyring do not
+// share.
 func (k *Keyring) Zeroize(keysToo bool) {
 	if k == nil {
 		return
 	}
-	if k.rootKey != nil {
-		memzero(k.rootKey)
-	}
+	k.rootKey.Destroy()
 	if !keysToo || k.keys == nil {
 		return
 	}
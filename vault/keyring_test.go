@@ -136,6 +136,29 @@ func TestKeyring_MasterKey(t *testing.T) {
 	}
 }
 
+func TestKeyring_Zeroize(t *testing.T) {
+	master := []byte("test")
+	k := NewKeyring().SetRootKey(master)
+	clone := k.Clone()
+
+	k.Zeroize(false)
+	if out := k.RootKey(); out != nil {
+		t.Fatalf("bad: %v", out)
+	}
+	// Clones own their copy of the root key, which outlives the keyring
+	if out := clone.RootKey(); !bytes.Equal(out, master) {
+		t.Fatalf("bad: %v", out)
+	}
+	clone.Zeroize(false)
+	if out := clone.RootKey(); out != nil {
+		t.Fatalf("bad: %v", out)
+	}
+	// The caller's copy of the key is left untouched
+	if !bytes.Equal(master, []byte("test")) {
+		t.Fatalf("bad: %v", master)
+	}
+}
+
 func TestKeyring_Serialize(t *testing.T) {
 	k := NewKeyring()
 	master := []byte("test")
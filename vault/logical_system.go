@@ -13,6 +13,7 @@ import (
 	"errors"
 	"fmt"
 	"hash"
+	"math"
 	"math/rand"
 	"net/http"
 	"path"
@@ -21,6 +22,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unicode"
 
@@ -109,6 +111,7 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 				"remount",
 				"audit",
 				"audit/*",
+				"audit-hash-key/*",
 				"raw",
 				"raw/*",
 				"replication/primary/secondary-token",
@@ -120,6 +123,8 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 				"rotate",
 				"config/cors",
 				"config/auditing/*",
+				"config/bundle/export",
+				"config/bundle/import",
 				"config/ui/headers/*",
 				"plugins/catalog/*",
 				"plugins/runtimes/catalog/*",
@@ -130,6 +135,9 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 				"leases/lookup/*",
 				"storage/raft/snapshot-auto/config/*",
 				"leases",
+				"leases/queue",
+				"leases/recommendations",
+				"leases/revocation-failures",
 				"internal/inspect/*",
 				// sys/seal and sys/step-down actually have their sudo requirement enforced through hardcoding
 				// PolicyCheckOpts.RootPrivsRequired in dedicated calls to Core.performPolicyChecks, but we still need
@@ -206,9 +214,15 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 	b.Backend.Paths = append(b.Backend.Paths, b.pluginsRuntimesCatalogListPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.auditPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.mountPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.mountTrashPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.authPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.lockedUserPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.rotationPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.configBundlePaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.controlGroupPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.leasePaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.operationPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.importPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.policyPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.wrappingPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.toolsPaths()...)
@@ -219,6 +233,7 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 	b.Backend.Paths = append(b.Backend.Paths, b.metricsPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.monitorPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.inFlightRequestPath())
+	b.Backend.Paths = append(b.Backend.Paths, b.discoveryPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.hostInfoPath())
 	b.Backend.Paths = append(b.Backend.Paths, b.quotasPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.rootActivityPaths()...)
@@ -286,6 +301,32 @@ func (b *SystemBackend) handleConfigStateSanitized(ctx context.Context, req *log
 	return resp, nil
 }
 
+// handleConfigStateDrift returns the changes between the configuration files
+// and the configuration the server was started with, as of the last reload.
+func (b *SystemBackend) handleConfigStateDrift(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	drift := b.Core.ConfigDrift()
+
+	changes := make([]map[string]interface{}, 0, len(drift.Changes))
+	for _, change := range drift.Changes {
+		changes = append(changes, map[string]interface{}{
+			"section":    change.Section,
+			"name":       change.Name,
+			"field":      change.Field,
+			"previous":   change.Previous,
+			"configured": change.Configured,
+			"status":     change.Status,
+		})
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"time":             drift.Time.Format(time.RFC3339Nano),
+			"changes":          changes,
+			"restart_required": drift.RestartRequired(),
+		},
+	}, nil
+}
+
 // handleConfigReload handles reloading specific pieces of the configuration.
 func (b *SystemBackend) handleConfigReload(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	subsystem := data.Get("subsystem").(string)
@@ -341,17 +382,31 @@ func (b *SystemBackend) handleTidyLeases(ctx context.Context, req *logical.Reque
 		return nil, err
 	}
 
-	go func() {
-		tidyCtx := namespace.ContextWithNamespace(b.Core.activeContext, ns)
-		err := b.Core.expiration.Tidy(tidyCtx)
+	if atomic.LoadInt32(b.Core.expiration.tidyLock) == 1 {
+		resp := &logical.Response{}
+		resp.AddWarning("Tidy operation already in progress.")
+		return resp, nil
+	}
+
+	op, err := b.Core.asyncOperations.start(b.Core.activeContext, ns, asyncOperationTypeLeaseTidy, func(ctx context.Context, op *AsyncOperation) error {
+		err := b.Core.expiration.tidy(ctx, func(scanned int64) {
+			b.Core.asyncOperations.setProgress(op, scanned, 0)
+		})
 		if err != nil {
 			b.Backend.Logger().Error("failed to tidy leases", "error", err)
-			return
 		}
-	}()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	resp := &logical.Response{}
-	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs.")
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"operation_id": op.ID,
+		},
+	}
+	resp.AddWarning("Tidy operation successfully started. Its progress can be read from the sys/operations endpoint.")
 	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
 }
 
@@ -374,6 +429,63 @@ func (b *SystemBackend) handleLeaseCount(ctx context.Context, req *logical.Reque
 	}, nil
 }
 
+func (b *SystemBackend) handleLeaseQueue(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	includeChildNamespaces := d.Get("include_child_namespaces").(bool)
+
+	resp, err := b.Core.expiration.getPendingLeaseHistogram(ctx, includeChildNamespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: resp,
+	}, nil
+}
+
+func (b *SystemBackend) handleLeaseRecommendations(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	includeChildNamespaces := d.Get("include_child_namespaces").(bool)
+
+	percentile := d.Get("percentile").(float64)
+	if percentile < 50 || percentile > 100 {
+		return logical.ErrorResponse("percentile must be between 50 and 100"), logical.ErrInvalidRequest
+	}
+	minLeases := d.Get("min_leases").(int)
+	if minLeases < 1 {
+		return logical.ErrorResponse("min_leases must be positive"), logical.ErrInvalidRequest
+	}
+
+	resp, err := b.Core.expiration.getLeaseRecommendations(ctx, includeChildNamespaces, percentile, minLeases)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: resp,
+	}, nil
+}
+
+func (b *SystemBackend) handleLeaseRevocationFailures(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	includeChildNamespaces := d.Get("include_child_namespaces").(bool)
+
+	var mountAccessor string
+	if mountPath := d.Get("mount").(string); mountPath != "" {
+		mount := b.Core.router.MatchingMountEntry(ctx, sanitizePath(mountPath))
+		if mount == nil {
+			return logical.ErrorResponse("no mount found at %q", mountPath), logical.ErrInvalidRequest
+		}
+		mountAccessor = mount.Accessor
+	}
+
+	resp, err := b.Core.expiration.getRevokeFailures(ctx, includeChildNamespaces, mountAccessor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: resp,
+	}, nil
+}
+
 func processLimit(d *framework.FieldData) (bool, int, error) {
 	limitStr := ""
 	limitRaw, ok := d.GetOk("limit")
@@ -560,6 +672,11 @@ func (b *SystemBackend) handlePluginCatalogUpdate(ctx context.Context, _ *logica
 				return logical.ErrorResponse("specified plugin runtime %q, but failed to retrieve config: %w", pluginRuntime, err), nil
 			}
 		}
+	} else if pluginRuntime != "" {
+		_, err := b.Core.pluginRuntimeCatalog.Get(ctx, pluginRuntime, consts.PluginRuntimeTypeProcess)
+		if err != nil {
+			return logical.ErrorResponse("specified plugin runtime %q, but failed to retrieve config: %w", pluginRuntime, err), nil
+		}
 	}
 
 	// For backwards compatibility, also accept args as part of command. Don't
@@ -584,6 +701,23 @@ func (b *SystemBackend) handlePluginCatalogUpdate(ctx context.Context, _ *logica
 		return logical.ErrorResponse("Could not decode SHA256 value from Hex %s: %s", sha256, err), err
 	}
 
+	var cosign *pluginutil.CosignVerification
+	if publicKey, bundle := d.Get("cosign_public_key").(string), d.Get("cosign_bundle").(string); publicKey != "" || bundle != "" {
+		if ociImage != "" {
+			return logical.ErrorResponse("cosign signatures are not supported for container plugins"), nil
+		}
+		cosign = &pluginutil.CosignVerification{
+			PublicKey:             publicKey,
+			Signature:             d.Get("cosign_signature").(string),
+			Bundle:                bundle,
+			CertificateIdentity:   d.Get("cosign_certificate_identity").(string),
+			CertificateOIDCIssuer: d.Get("cosign_certificate_oidc_issuer").(string),
+		}
+		if err := cosign.Validate(); err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+	}
+
 	err = b.Core.pluginCatalog.Set(ctx, pluginutil.SetPluginInput{
 		Name:     pluginName,
 		Type:     pluginType,
@@ -594,9 +728,10 @@ func (b *SystemBackend) handlePluginCatalogUpdate(ctx context.Context, _ *logica
 		Args:     args,
 		Env:      env,
 		Sha256:   sha256Bytes,
+		Cosign:   cosign,
 	})
 	if err != nil {
-		if errors.Is(err, plugincatalog.ErrPluginNotFound) || strings.HasPrefix(err.Error(), "plugin version mismatch") {
+		if errors.Is(err, plugincatalog.ErrPluginNotFound) || errors.Is(err, plugincatalog.ErrPluginSignature) || strings.HasPrefix(err.Error(), "plugin version mismatch") {
 			return logical.ErrorResponse(err.Error()), nil
 		}
 		return nil, err
@@ -671,6 +806,17 @@ func (b *SystemBackend) handlePluginCatalogRead(ctx context.Context, _ *logical.
 		data["runtime"] = plugin.Runtime
 	}
 
+	if plugin.Cosign != nil {
+		cosign := map[string]interface{}{}
+		if plugin.Cosign.PublicKey != "" {
+			cosign["public_key"] = plugin.Cosign.PublicKey
+		} else {
+			cosign["certificate_identity"] = plugin.Cosign.CertificateIdentity
+			cosign["certificate_oidc_issuer"] = plugin.Cosign.CertificateOIDCIssuer
+		}
+		data["cosign"] = cosign
+	}
+
 	return &logical.Response{
 		Data: data,
 	}, nil
@@ -984,8 +1130,12 @@ func (b *SystemBackend) handlePluginRuntimeCatalogUpdate(ctx context.Context, _
 	}
 
 	switch runtimeType {
-	case consts.PluginRuntimeTypeContainer:
+	case consts.PluginRuntimeTypeContainer, consts.PluginRuntimeTypeProcess:
 		ociRuntime := d.Get("oci_runtime").(string)
+		rootless := d.Get("rootless").(bool)
+		if runtimeType == consts.PluginRuntimeTypeProcess && (ociRuntime != "" || rootless) {
+			return logical.ErrorResponse("oci_runtime and rootless are only supported by container runtimes"), nil
+		}
 		cgroupParent := d.Get("cgroup_parent").(string)
 		cpu := d.Get("cpu_nanos").(int64)
 		if cpu < 0 {
@@ -995,16 +1145,25 @@ func (b *SystemBackend) handlePluginRuntimeCatalogUpdate(ctx context.Context, _
 		if memory < 0 {
 			return logical.ErrorResponse("runtime memory in bytes cannot be negative"), nil
 		}
-		rootless := d.Get("rootless").(bool)
+		maxRestarts := d.Get("max_restarts").(int64)
+		if maxRestarts < 0 {
+			return logical.ErrorResponse("runtime max restarts cannot be negative"), nil
+		}
+		restartWindow := time.Duration(d.Get("restart_window").(int)) * time.Second
+		if restartWindow < 0 {
+			return logical.ErrorResponse("runtime restart window cannot be negative"), nil
+		}
 		if err = b.Core.pluginRuntimeCatalog.Set(ctx,
 			&pluginruntimeutil.PluginRuntimeConfig{
-				Name:         runtimeName,
-				Type:         runtimeType,
-				OCIRuntime:   ociRuntime,
-				CgroupParent: cgroupParent,
-				CPU:          cpu,
-				Memory:       memory,
-				Rootless:     rootless,
+				Name:          runtimeName,
+				Type:          runtimeType,
+				OCIRuntime:    ociRuntime,
+				CgroupParent:  cgroupParent,
+				CPU:           cpu,
+				Memory:        memory,
+				Rootless:      rootless,
+				MaxRestarts:   maxRestarts,
+				RestartWindow: restartWindow,
 			}); err != nil {
 			return logical.ErrorResponse(err.Error()), nil
 		}
@@ -1071,13 +1230,15 @@ func (b *SystemBackend) handlePluginRuntimeCatalogRead(ctx context.Context, _ *l
 	}
 
 	return &logical.Response{Data: map[string]interface{}{
-		"name":          conf.Name,
-		"type":          conf.Type.String(),
-		"oci_runtime":   conf.OCIRuntime,
-		"cgroup_parent": conf.CgroupParent,
-		"cpu_nanos":     conf.CPU,
-		"memory_bytes":  conf.Memory,
-		"rootless":      conf.Rootless,
+		"name":           conf.Name,
+		"type":           conf.Type.String(),
+		"oci_runtime":    conf.OCIRuntime,
+		"cgroup_parent":  conf.CgroupParent,
+		"cpu_nanos":      conf.CPU,
+		"memory_bytes":   conf.Memory,
+		"rootless":       conf.Rootless,
+		"max_restarts":   conf.MaxRestarts,
+		"restart_window": int64(conf.RestartWindow.Seconds()),
 	}}, nil
 }
 
@@ -1111,13 +1272,15 @@ func (b *SystemBackend) handlePluginRuntimeCatalogList(ctx context.Context, _ *l
 			})
 			for _, conf := range configs {
 				runtimes = append(runtimes, map[string]any{
-					"name":          conf.Name,
-					"type":          conf.Type.String(),
-					"oci_runtime":   conf.OCIRuntime,
-					"cgroup_parent": conf.CgroupParent,
-					"cpu_nanos":     conf.CPU,
-					"memory_bytes":  conf.Memory,
-					"rootless":      conf.Rootless,
+					"name":           conf.Name,
+					"type":           conf.Type.String(),
+					"oci_runtime":    conf.OCIRuntime,
+					"cgroup_parent":  conf.CgroupParent,
+					"cpu_nanos":      conf.CPU,
+					"memory_bytes":   conf.Memory,
+					"rootless":       conf.Rootless,
+					"max_restarts":   conf.MaxRestarts,
+					"restart_window": int64(conf.RestartWindow.Seconds()),
 				})
 			}
 		}
@@ -1410,6 +1573,13 @@ func (b *SystemBackend) mountInfo(ctx context.Context, entry *MountEntry) map[st
 		}
 		entryConfig["user_lockout_config"] = userLockoutConfig
 	}
+	if entry.Config.PluginUpgradeWindow != nil {
+		entryConfig["plugin_upgrade_window"] = pluginUpgradeWindowResponse(entry.Config.PluginUpgradeWindow)
+	}
+
+	if health, ok := b.Core.pluginHealth(entry); ok {
+		info["plugin_health"] = pluginHealthResponse(health)
+	}
 
 	// Add deprecation status only if it exists
 	builtinType := b.Core.builtinTypeFromMountEntry(ctx, entry)
@@ -1618,6 +1788,12 @@ func (b *SystemBackend) handleMount(ctx context.Context, req *logical.Request, d
 	if len(apiConfig.DelegatedAuthAccessors) > 0 {
 		config.DelegatedAuthAccessors = apiConfig.DelegatedAuthAccessors
 	}
+	if apiConfig.PluginUpgradeWindow != nil {
+		config.PluginUpgradeWindow, err = parsePluginUpgradeWindow(apiConfig.PluginUpgradeWindow)
+		if err != nil {
+			return logical.ErrorResponse("invalid plugin_upgrade_window: %s", err), nil
+		}
+	}
 
 	storage := b.Core.router.MatchingStorageByAPIPath(ctx, mountPathIdentity)
 	if storage == nil {
@@ -1745,6 +1921,7 @@ func (b *SystemBackend) handleReadMount(ctx context.Context, req *logical.Reques
 		}
 		resp.AddWarning(warning)
 	}
+	b.addPluginHealthWarning(entry, resp)
 
 	return resp, nil
 }
@@ -1969,7 +2146,7 @@ func (b *SystemBackend) handleRemount(ctx context.Context, req *logical.Request,
 // It is expected to be called asynchronously outside of a request context, hence it creates a context derived from the active one
 // and intermittently checks to see if it is still open.
 func (b *SystemBackend) moveMount(ns *namespace.Namespace, logger log.Logger, migrationID string, entry *MountEntry, fromPathDetails, toPathDetails namespace.MountPathDetails) error {
-	logger.Info("Starting to update the mount table and revoke leases")
+	logger.Info("Starting to update the mount table and migrate leases")
 	revokeCtx := namespace.ContextWithNamespace(b.Core.activeContext, ns)
 
 	var err error
@@ -2159,6 +2336,15 @@ func (b *SystemBackend) handleTuneReadCommon(ctx context.Context, path string) (
 		resp.AddWarning(fmt.Sprintf("plugin_version is configured as %s but a version pin for %s is in effect", mountEntry.Version, pinnedVersion.Version))
 	}
 
+	if mountEntry.Config.PluginUpgradeWindow != nil {
+		resp.Data["plugin_upgrade_window"] = pluginUpgradeWindowResponse(mountEntry.Config.PluginUpgradeWindow)
+	}
+	if b.Core.pluginUpgrades != nil {
+		if version := b.Core.pluginUpgrades.failedVersion(mountEntry.Accessor); version != "" {
+			resp.AddWarning(fmt.Sprintf("upgrade to plugin version %s failed and was rolled back", version))
+		}
+	}
+
 	return resp, nil
 }
 
@@ -2444,6 +2630,40 @@ func (b *SystemBackend) handleTuneWriteCommon(ctx context.Context, path string,
 		}
 	}
 
+	if rawVal, ok := data.GetOk("plugin_upgrade_window"); ok {
+		var window *PluginUpgradeWindow
+		if windowMap := rawVal.(map[string]interface{}); len(windowMap) > 0 {
+			var apiWindow APIPluginUpgradeWindow
+			if err := mapstructure.Decode(windowMap, &apiWindow); err != nil {
+				return logical.ErrorResponse("unable to convert given plugin upgrade window"), logical.ErrInvalidRequest
+			}
+			var err error
+			window, err = parsePluginUpgradeWindow(&apiWindow)
+			if err != nil {
+				return logical.ErrorResponse("invalid plugin_upgrade_window: %s", err), logical.ErrInvalidRequest
+			}
+		}
+
+		oldVal := mountEntry.Config.PluginUpgradeWindow
+		mountEntry.Config.PluginUpgradeWindow = window
+
+		// Update the mount table
+		var err error
+		switch {
+		case strings.HasPrefix(path, "auth/"):
+			err = b.Core.persistAuth(ctx, b.Core.auth, &mountEntry.Local)
+		default:
+			err = b.Core.persistMounts(ctx, b.Core.mounts, &mountEntry.Local)
+		}
+		if err != nil {
+			mountEntry.Config.PluginUpgradeWindow = oldVal
+			return handleError(err)
+		}
+		if b.Core.logger.IsInfo() {
+			b.Core.logger.Info("mount tuning of plugin_upgrade_window successful", "path", path)
+		}
+	}
+
 	if rawVal, ok := data.GetOk("identity_token_key"); ok {
 		identityTokenKey := rawVal.(string)
 
@@ -2997,23 +3217,42 @@ func (b *SystemBackend) handleRevokePrefixCommon(ctx context.Context,
 		return nil, err
 	}
 
+	// Queue the revocation of the leases in the background, as there may be
+	// many of them. Queued revocations are retried until they succeed.
+	if !sync {
+		op, err := b.Core.asyncOperations.start(b.Core.activeContext, ns, asyncOperationTypeLeaseRevokePrefix, func(ctx context.Context, op *AsyncOperation) error {
+			err := b.Core.expiration.revokePrefixCommon(ctx, prefix, false, false, func(queued, total int64) {
+				b.Core.asyncOperations.setProgress(op, queued, total)
+			})
+			if err != nil {
+				b.Backend.Logger().Error("revoke prefix failed", "prefix", prefix, "error", err)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return logical.RespondWithStatusCode(&logical.Response{
+			Data: map[string]interface{}{
+				"operation_id": op.ID,
+			},
+		}, req, http.StatusAccepted)
+	}
+
 	// Invoke the expiration manager directly
 	revokeCtx := namespace.ContextWithNamespace(b.Core.activeContext, ns)
 	if force {
 		err = b.Core.expiration.RevokeForce(revokeCtx, prefix)
 	} else {
-		err = b.Core.expiration.RevokePrefix(revokeCtx, prefix, sync)
+		err = b.Core.expiration.RevokePrefix(revokeCtx, prefix, true)
 	}
 	if err != nil {
 		b.Backend.Logger().Error("revoke prefix failed", "prefix", prefix, "error", err)
 		return handleErrorNoReadOnlyForward(err)
 	}
 
-	if sync {
-		return nil, nil
-	}
-
-	return logical.RespondWithStatusCode(nil, nil, http.StatusAccepted)
+	return nil, nil
 }
 
 // handleAuthTable handles the "auth" endpoint to provide the auth table
@@ -3077,9 +3316,11 @@ func (b *SystemBackend) handleReadAuth(ctx context.Context, req *logical.Request
 			continue
 		}
 
-		return &logical.Response{
+		resp := &logical.Response{
 			Data: b.mountInfo(ctx, entry),
-		}, nil
+		}
+		b.addPluginHealthWarning(entry, resp)
+		return resp, nil
 	}
 
 	return logical.ErrorResponse("No auth engine at %s", path), nil
@@ -3256,6 +3497,12 @@ func (b *SystemBackend) handleEnableAuth(ctx context.Context, req *logical.Reque
 	if len(apiConfig.AllowedManagedKeys) > 0 {
 		config.AllowedManagedKeys = apiConfig.AllowedManagedKeys
 	}
+	if apiConfig.PluginUpgradeWindow != nil {
+		config.PluginUpgradeWindow, err = parsePluginUpgradeWindow(apiConfig.PluginUpgradeWindow)
+		if err != nil {
+			return logical.ErrorResponse("invalid plugin_upgrade_window: %s", err), nil
+		}
+	}
 
 	storage := b.Core.router.MatchingStorageByAPIPath(ctx, mountPathIdentity)
 	if storage == nil {
@@ -3666,18 +3913,54 @@ func (*SystemBackend) handlePoliciesPasswordSet(ctx context.Context, req *logica
 		return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("invalid password policy: %s", err))
 	}
 
-	if policy.Length > maxPasswordLength || policy.Length < minPasswordLength {
-		return nil, logical.CodedError(http.StatusBadRequest,
+	err = validatePasswordPolicy(ctx, &policy)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := passwordPolicyConfig{
+		HCLPolicy: rawPolicy,
+	}
+	entry, err := logical.StorageEntryJSON(getPasswordPolicyKey(policyName), cfg)
+	if err != nil {
+		return nil, logical.CodedError(http.StatusInternalServerError, fmt.Sprintf("unable to save password policy: %s", err))
+	}
+
+	err = req.Storage.Put(ctx, entry)
+	if err != nil {
+		return nil, logical.CodedError(http.StatusInternalServerError,
+			fmt.Sprintf("failed to save policy to storage backend: %s", err))
+	}
+
+	return logical.RespondWithStatusCode(nil, req, http.StatusNoContent)
+}
+
+// validatePasswordPolicy ensures that passwords can be generated from the provided policy
+func validatePasswordPolicy(ctx context.Context, policy *random.StringGenerator) error {
+	// Passphrase lengths are an optional upper bound
+	hasLength := policy.Mode != random.ModePassphrase || policy.Length != 0
+	if hasLength && (policy.Length > maxPasswordLength || policy.Length < minPasswordLength) {
+		return logical.CodedError(http.StatusBadRequest,
 			fmt.Sprintf("passwords must be between %d and %d characters", minPasswordLength, maxPasswordLength))
 	}
 
+	switch policy.Mode {
+	case random.ModePassphrase, random.ModePronounceable:
+		// Generate a test password to ensure that the rules are satisfiable by the words or syllables produced
+		_, err := policy.Generate(ctx, nil)
+		if err != nil {
+			return logical.CodedError(http.StatusBadRequest, fmt.Sprintf("unable to construct test password from provided policy: %s", err))
+		}
+		return nil
+	}
+
 	// Attempt to construct a test password from the rules to ensure that the policy isn't impossible
 	var testPassword []rune
 
 	for _, rule := range policy.Rules {
 		charsetRule, ok := rule.(random.CharsetRule)
 		if !ok {
-			return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("unexpected rule type %T", charsetRule))
+			return logical.CodedError(http.StatusBadRequest, fmt.Sprintf("unexpected rule type %T", charsetRule))
 		}
 
 		for j := 0; j < charsetRule.MinLength(); j++ {
@@ -3693,7 +3976,7 @@ func (*SystemBackend) handlePoliciesPasswordSet(ctx context.Context, req *logica
 			}
 			charsetRule, ok := rule.(random.CharsetRule)
 			if !ok {
-				return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("unexpected rule type %T", charsetRule))
+				return logical.CodedError(http.StatusBadRequest, fmt.Sprintf("unexpected rule type %T", charsetRule))
 			}
 
 			charIndex := rand.Intn(len(charsetRule.Chars()))
@@ -3707,25 +3990,11 @@ func (*SystemBackend) handlePoliciesPasswordSet(ctx context.Context, req *logica
 
 	for _, rule := range policy.Rules {
 		if !rule.Pass(testPassword) {
-			return nil, logical.CodedError(http.StatusBadRequest, "unable to construct test password from provided policy: are the rules impossible?")
+			return logical.CodedError(http.StatusBadRequest, "unable to construct test password from provided policy: are the rules impossible?")
 		}
 	}
 
-	cfg := passwordPolicyConfig{
-		HCLPolicy: rawPolicy,
-	}
-	entry, err := logical.StorageEntryJSON(getPasswordPolicyKey(policyName), cfg)
-	if err != nil {
-		return nil, logical.CodedError(http.StatusInternalServerError, fmt.Sprintf("unable to save password policy: %s", err))
-	}
-
-	err = req.Storage.Put(ctx, entry)
-	if err != nil {
-		return nil, logical.CodedError(http.StatusInternalServerError,
-			fmt.Sprintf("failed to save policy to storage backend: %s", err))
-	}
-
-	return logical.RespondWithStatusCode(nil, req, http.StatusNoContent)
+	return nil
 }
 
 // handlePoliciesPasswordGet retrieves a password policy if it exists
@@ -3787,6 +4056,17 @@ func (*SystemBackend) handlePoliciesPasswordDelete(ctx context.Context, req *log
 	return nil, nil
 }
 
+// handlePoliciesMonitoringTemplate returns the text of the monitoring policy,
+// as generated by this version of Vault
+func (*SystemBackend) handlePoliciesMonitoringTemplate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":   monitoringPolicyName,
+			"policy": monitoringPolicy(),
+		},
+	}, nil
+}
+
 // handlePoliciesPasswordGenerate generates a password from the specified password policy
 func (*SystemBackend) handlePoliciesPasswordGenerate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	policyName := data.Get("name").(string)
@@ -3822,6 +4102,69 @@ func (*SystemBackend) handlePoliciesPasswordGenerate(ctx context.Context, req *l
 	return resp, nil
 }
 
+const maxPasswordPolicyTestSamples = 20
+
+// handlePoliciesPasswordTest generates sample passwords from the specified password policy, or from the policy
+// provided in the request so that changes can be previewed before they are saved
+func (*SystemBackend) handlePoliciesPasswordTest(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	policyName := data.Get("name").(string)
+	if policyName == "" {
+		return nil, logical.CodedError(http.StatusBadRequest, "missing policy name")
+	}
+
+	count := data.Get("count").(int)
+	if count < 1 || count > maxPasswordPolicyTestSamples {
+		return nil, logical.CodedError(http.StatusBadRequest,
+			fmt.Sprintf("count must be between 1 and %d", maxPasswordPolicyTestSamples))
+	}
+
+	rawPolicy := data.Get("policy").(string)
+	if rawPolicy != "" {
+		// Optionally decode base64 string
+		decodedPolicy, err := base64.StdEncoding.DecodeString(rawPolicy)
+		if err == nil {
+			rawPolicy = string(decodedPolicy)
+		}
+	} else {
+		cfg, err := retrievePasswordPolicy(ctx, req.Storage, policyName)
+		if err != nil {
+			return nil, logical.CodedError(http.StatusInternalServerError, "failed to retrieve password policy")
+		}
+		if cfg == nil {
+			return nil, logical.CodedError(http.StatusNotFound, "policy does not exist")
+		}
+		rawPolicy = cfg.HCLPolicy
+	}
+
+	policy, err := random.ParsePolicy(rawPolicy)
+	if err != nil {
+		return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("invalid password policy: %s", err))
+	}
+
+	err = validatePasswordPolicy(ctx, &policy)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		password, err := policy.Generate(ctx, nil)
+		if err != nil {
+			return nil, logical.CodedError(http.StatusInternalServerError,
+				fmt.Sprintf("failed to generate password from policy: %s", err))
+		}
+		samples = append(samples, password)
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"samples":      samples,
+			"entropy_bits": math.Round(policy.Entropy()*100) / 100,
+		},
+	}
+	return resp, nil
+}
+
 // handleAuditTable handles the "audit" endpoint to provide the audit table
 func (b *SystemBackend) handleAuditTable(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	b.Core.auditLock.RLock()
@@ -3866,6 +4209,40 @@ func (b *SystemBackend) handleAuditHash(ctx context.Context, req *logical.Reques
 	}, nil
 }
 
+// handleAuditHashKeyUpdate imports the key with which an audit backend hashes
+// sensitive values.
+func (b *SystemBackend) handleAuditHashKeyUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := sanitizePath(data.Get("path").(string))
+
+	rawKey := data.Get("key").(string)
+	if rawKey == "" {
+		return logical.ErrorResponse("the \"key\" parameter is empty"), nil
+	}
+	key, err := base64.StdEncoding.DecodeString(rawKey)
+	if err != nil {
+		return logical.ErrorResponse("the \"key\" parameter must be base64 encoded"), nil
+	}
+	if len(key) < auditHashKeyMinLength {
+		return logical.ErrorResponse("the key must be at least %d bytes long", auditHashKeyMinLength), nil
+	}
+
+	if err := b.Core.setAuditHashKey(ctx, path, key); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
+// handleAuditHashKeyDelete reverts an audit backend to hashing sensitive
+// values with a new random salt.
+func (b *SystemBackend) handleAuditHashKeyDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := sanitizePath(data.Get("path").(string))
+
+	if err := b.Core.setAuditHashKey(ctx, path, nil); err != nil {
+		return handleError(err)
+	}
+	return nil, nil
+}
+
 // handleEnableAudit is used to enable a new audit backend
 func (b *SystemBackend) handleEnableAudit(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	repState := b.Core.ReplicationState()
@@ -4352,6 +4729,29 @@ func (b *SystemBackend) handleInFlightRequestData(_ context.Context, req *logica
 	return resp, nil
 }
 
+// handleDiscoveryRead returns the discovery document of the cluster. The
+// document is returned as the raw body, so that it has the same shape as when
+// listeners serve it without authentication.
+func (b *SystemBackend) handleDiscoveryRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	doc, err := b.Core.DiscoveryDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling the discovery document: %w", err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			logical.HTTPContentType: "application/json",
+			logical.HTTPRawBody:     content,
+			logical.HTTPStatusCode:  http.StatusOK,
+		},
+	}, nil
+}
+
 func (b *SystemBackend) handleMonitor(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	ll := data.Get("log_level").(string)
 	w := req.ResponseWriter
@@ -4576,6 +4976,13 @@ func (b *SystemBackend) handleWrappingLookup(ctx context.Context, req *logical.R
 	if creationPath != nil {
 		resp.Data["creation_path"] = cubbyResp.Data["creation_path"]
 	}
+	if boundCIDRs := cubbyResp.Data["bound_cidrs"]; boundCIDRs != nil {
+		resp.Data["bound_cidrs"] = boundCIDRs
+	}
+	if notBefore := cubbyResp.Data["not_before"]; notBefore != nil {
+		// This was JSON marshaled so it's already a string in RFC3339 format
+		resp.Data["not_before"] = notBefore
+	}
 
 	return resp, nil
 }
@@ -4653,7 +5060,18 @@ func (b *SystemBackend) handleWrappingRewrap(ctx context.Context, req *logical.R
 	}
 	creationPath := creationPathRaw.(string)
 
-	// Fetch the original response and return it as the data for the new response
+	// The rewrapped token keeps the CIDRs the original was bound to; its
+	// not-before time has passed already
+	boundCIDRs, _, err := wrappingConstraints(cubbyResp.Data)
+	if err != nil {
+		return nil, err
+	}
+	var boundCIDRStrings []string
+	for _, cidr := range boundCIDRs {
+		boundCIDRStrings = append(boundCIDRStrings, cidr.String())
+	}
+
+	// Fetch the original response and return it as the data for the new response
 	cubbyReq = &logical.Request{
 		Operation:   logical.ReadOperation,
 		Path:        "cubbyhole/response",
@@ -4688,6 +5106,7 @@ func (b *SystemBackend) handleWrappingRewrap(ctx context.Context, req *logical.R
 		WrapInfo: &wrapping.ResponseWrapInfo{
 			TTL:          time.Duration(creationTTL),
 			CreationPath: creationPath,
+			BoundCIDRs:   boundCIDRStrings,
 		},
 	}, nil
 }
@@ -5113,6 +5532,36 @@ func (b *SystemBackend) pathInternalCountersEntities(ctx context.Context, req *l
 	return resp, nil
 }
 
+func (b *SystemBackend) pathInternalCountersSecrets(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	counts, err := b.Core.countSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"counters": counts,
+		},
+	}
+
+	return resp, nil
+}
+
+func (b *SystemBackend) pathInternalCountersCredentials(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	counts, err := b.Core.countCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"counters": counts,
+		},
+	}
+
+	return resp, nil
+}
+
 func (b *SystemBackend) pathInternalInspectRouter(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	b.Core.introspectionEnabledLock.Lock()
 	defer b.Core.introspectionEnabledLock.Unlock()
@@ -5670,6 +6119,35 @@ func (b *SystemBackend) handleSealStatus(ctx context.Context, req *logical.Reque
 	return httpResp, nil
 }
 
+// handleSealStatusHistory returns the recorded seal status transitions,
+// oldest first.
+func (b *SystemBackend) handleSealStatusHistory(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	history, err := b.Core.SealHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]map[string]interface{}, 0, len(history))
+	for _, event := range history {
+		e := map[string]interface{}{
+			"time":      event.Time.Format(time.RFC3339),
+			"event":     event.Event,
+			"reason":    event.Reason,
+			"seal_type": event.SealType,
+		}
+		if event.Initiator != "" {
+			e["initiator"] = event.Initiator
+		}
+		events = append(events, e)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"events": events,
+		},
+	}, nil
+}
+
 func (b *SystemBackend) handleLeaderStatus(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	status, err := b.Core.GetLeaderStatusLocked()
 	if err != nil {
@@ -6117,6 +6595,17 @@ This path responds to the following HTTP methods.
         endpoint.
 		`,
 	},
+	"seal-status-history": {
+		"Returns the history of seal status transitions.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Returns the most recent seals and unseals of the cluster, oldest
+        first, with the reason, the initiator where known, and the seal
+        type in effect.
+		`,
+	},
 	"seal": {
 		"Seals the Vault.",
 		`
@@ -6233,6 +6722,10 @@ in the plugin catalog.`,
 		`The user lockout configuration to pass into the backend. Should be a json object with string keys and values.`,
 	},
 
+	"tune_plugin_upgrade_window": {
+		`The daily window during which the mount is automatically upgraded to the newest registered version of its plugin. Should be a json object with "start" (HH:MM in UTC), "duration" and optionally "version_constraint" keys. An empty object removes the window.`,
+	},
+
 	"remount": {
 		"Move the mount point of an already-mounted backend, within or across namespaces",
 		`
@@ -6286,6 +6779,340 @@ This path responds to the following HTTP methods.
 	Report the locked user count metrics, for current namespace and all child namespaces.`,
 	},
 
+	"control-group-authorize": {
+		"Authorize a control group request.",
+		`
+This path responds to the following HTTP methods.
+
+    POST /
+        Approve the control group request of the given control group token
+        accessor with the entity of the calling token. The approval counts
+        towards each factor of the request's control group whose identity
+        groups the entity is a member of. Requesters cannot approve their own
+        requests.
+		`,
+	},
+
+	"control-group-request": {
+		"Check the status of a control group request.",
+		`
+This path responds to the following HTTP methods.
+
+    POST /
+        Return the path and requester of the control group request of the given
+        control group token accessor, the entities which approved it, and
+        whether it is authorized. Once authorized, the request runs when the
+        control group token is unwrapped.
+		`,
+	},
+
+	"control-group-accessor": {
+		"The accessor of the control group token.",
+		"",
+	},
+
+	"control-group-config": {
+		"Configure control groups.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Read the control group configuration.
+
+    POST /
+        Configure the maximum TTL of control group tokens.
+
+    DELETE /
+        Remove the control group configuration.
+		`,
+	},
+
+	"control-group-max-ttl": {
+		"The maximum TTL of control group tokens.",
+		"",
+	},
+
+	"operations": {
+		"List the long-running operations started in the background.",
+		`
+This path responds to the following HTTP methods.
+
+    LIST /
+        List the IDs of the running and recently finished operations of this
+        namespace, such as lease tidies and asynchronous lease revocations,
+        with their type and status.
+		`,
+	},
+
+	"operations-id": {
+		"Read or cancel a long-running operation.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /<id>
+        Read the status and progress of the operation with the given ID.
+
+    DELETE /<id>
+        Cancel the operation with the given ID. Work already done by the
+        operation is not undone.
+		`,
+	},
+
+	"operations-id-field": {
+		"The ID of the operation.",
+		"",
+	},
+
+	"import": {
+		"Import secrets from an external secret manager.",
+		`
+This path responds to the following HTTP methods.
+
+    POST /
+        Import the secrets of an AWS Secrets Manager, Azure Key Vault or GCP
+        Secret Manager into a KV version 2 secrets engine, with their tags or
+        labels as custom metadata. Secrets are written with the permissions
+        of the calling token, and the result of each secret is returned.
+		`,
+	},
+
+	"import-source": {
+		"The type of the secret manager: aws, azure or gcp.",
+		"",
+	},
+
+	"import-config": {
+		`The configuration of the connection to the secret manager, such as its
+region and credentials.`,
+		"",
+	},
+
+	"import-mount": {
+		"The path of the KV version 2 secrets engine to import the secrets into.",
+		"",
+	},
+
+	"import-prefix": {
+		"The path under which the secrets are written in the secrets engine.",
+		"",
+	},
+
+	"import-secrets": {
+		"The names of the secrets to import. If empty, all the secrets are imported.",
+		"",
+	},
+
+	"import-dry-run": {
+		"If set, the secrets to import are listed but not imported.",
+		"",
+	},
+
+	"import-overwrite": {
+		"If set, secrets which already exist in the secrets engine are overwritten rather than skipped.",
+		"",
+	},
+
+	"mounts-trash": {
+		"List the disabled secrets engines retained in the trash.",
+		`
+This path responds to the following HTTP methods.
+
+    LIST /
+        List the accessors of the disabled secrets engines of this namespace
+        whose storage and leases are retained in the trash, with their path
+        and the time at which they are purged.
+		`,
+	},
+
+	"mounts-trash-mount": {
+		"Read or purge a disabled secrets engine retained in the trash.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /<accessor>
+        Read the trashed secrets engine with the given accessor.
+
+    DELETE /<accessor>
+        Purge the storage and leases of the trashed secrets engine with the
+        given accessor before the end of its retention period.
+		`,
+	},
+
+	"mounts-trash-restore": {
+		"Restore a disabled secrets engine retained in the trash.",
+		`
+This path responds to the following HTTP methods.
+
+    POST /<accessor>/restore
+        Enable the trashed secrets engine with the given accessor again at
+        its original path, with its retained storage and leases.
+		`,
+	},
+
+	"mounts-trash-accessor": {
+		"The accessor of the trashed secrets engine.",
+		"",
+	},
+
+	"mounts-trash-config": {
+		"Configure the retention of disabled secrets engines.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /
+        Read the mount trash configuration.
+
+    POST /
+        Configure the retention period of disabled secrets engines.
+
+    DELETE /
+        Remove the mount trash configuration, purging disabled secrets
+        engines immediately.
+		`,
+	},
+
+	"mounts-trash-retention-period": {
+		"The duration for which the storage and leases of disabled secrets engines are retained. Disabled secrets engines are purged immediately if zero.",
+		"",
+	},
+
+	"config-bundle-export": {
+		"Export the configuration of mounts as a signed bundle.",
+		`
+Exports the settings, selected resources such as configuration and roles, and
+rotation schedules of the given mounts, with the policies they refer to, as a
+bundle signed by this cluster. Resources are read with the permissions of the
+requesting token, and resources returning leased secrets are refused.
+		`,
+	},
+
+	"config-bundle-import": {
+		"Apply a signed configuration bundle.",
+		`
+Verifies the signature of a bundle and applies it to the namespace: missing
+mounts and policies are created, and the settings, resources and rotation
+schedules which differ from the bundle are updated. Applying a bundle again
+changes nothing. A dry run reports the changes without making them.
+		`,
+	},
+
+	"config-bundle-public-key": {
+		"Read the public key verifying the bundles exported by this cluster.",
+		"",
+	},
+
+	"config_bundle_mounts": {
+		"The paths of the mounts to export. Auth methods are prefixed with auth/.",
+		"",
+	},
+
+	"config_bundle_resources": {
+		`The paths of the resources to export, relative to their mount and keyed by
+mount path. The resources listed under paths ending with a slash are exported.`,
+		"",
+	},
+
+	"config_bundle_policies": {
+		`The ACL policies to export, in addition to the existing policies referred to
+by the exported resources.`,
+		"",
+	},
+
+	"config_bundle": {
+		"The bundle, as exported.",
+		"",
+	},
+
+	"config_bundle_signature": {
+		"The base64 encoded signature of the bundle.",
+		"",
+	},
+
+	"config_bundle_public_key": {
+		`The base64 encoded public key of the cluster which exported the bundle.
+Defaults to the key of this cluster.`,
+		"",
+	},
+
+	"config_bundle_dry_run": {
+		"Report the changes applying the bundle would make, without making them.",
+		"",
+	},
+
+	"rotation-jobs": {
+		"List the root credentials registered for scheduled rotation.",
+		`
+This path responds to the following HTTP methods.
+
+    LIST /
+        List the root credentials plugins of this namespace registered with the
+        rotation manager, with their next scheduled rotation.
+		`,
+	},
+
+	"rotation-job": {
+		"Read a root credential registered for scheduled rotation.",
+		`
+This path responds to the following HTTP methods.
+
+    GET /<id>
+        Read the schedule of the root credential and the history of its most
+        recent rotations.
+		`,
+	},
+
+	"rotation-job-id": {
+		"The ID of the rotation job, as returned by the plugin registering it.",
+		"",
+	},
+
+	"rotation-calendars": {
+		"List the rotation calendars.",
+		`
+This path responds to the following HTTP methods.
+
+    LIST /
+        List the rotation calendars scheduled rotations can reference.
+		`,
+	},
+
+	"rotation-calendar": {
+		"Manage a calendar of freeze dates for scheduled rotations.",
+		`
+Rotation calendars hold dates, such as public holidays or change freezes,
+on which no scheduled rotation happens. Root credentials and static roles
+referencing a calendar by name postpone their rotations until the freeze
+is over, unless their rotation window passes first.
+
+This path responds to the following HTTP methods.
+
+    GET /<name>
+        Read the freeze dates of the calendar.
+
+    POST /<name>
+        Create or replace the calendar.
+
+    DELETE /<name>
+        Delete the calendar.
+		`,
+	},
+
+	"rotation-calendar-name": {
+		"The name of the rotation calendar.",
+		"",
+	},
+
+	"rotation-calendar-freeze-dates": {
+		`Dates on which scheduled rotations are postponed, as YYYY-MM-DD, or
+inclusive ranges of dates as YYYY-MM-DD/YYYY-MM-DD.`,
+		"",
+	},
+
+	"rotation-calendar-timezone": {
+		"The IANA time zone of the freeze dates. Defaults to UTC.",
+		"",
+	},
+
 	"alias_identifier": {
 		`It is the name of the alias (user). For example, if the alias belongs to userpass backend, 
 	   the name should be a valid username within userpass auth method. If the alias belongs
@@ -6480,6 +7307,22 @@ or delete a policy.
 		"",
 	},
 
+	"audit-hash-key": {
+		"Import the key with which an audit device hashes sensitive values.",
+		`
+By default, each audit device hashes sensitive values with a random salt of
+its own, so the hashes of a value differ across audit devices and clusters.
+Importing a key makes the audit device hash values with it instead, so that
+the hashes of audit devices sharing the key can be correlated. The key cannot
+be read back. Deleting the key reverts the audit device to a new random salt.
+		`,
+	},
+
+	"audit_hash_key": {
+		"The base64 encoded key, of at least 32 bytes.",
+		"",
+	},
+
 	"audit-table": {
 		"List the currently enabled audit backends.",
 		`
@@ -6709,6 +7552,35 @@ Must already be present on the machine.`,
 		`The Vault plugin runtime to use when running the plugin.`,
 		"",
 	},
+	"plugin-catalog_cosign_public_key": {
+		`The PEM encoded public key the plugin binary is signed with. Requires
+cosign_signature.`,
+		"",
+	},
+	"plugin-catalog_cosign_signature": {
+		`The base64 encoded cosign signature of the plugin binary, made with the
+key of cosign_public_key.`,
+		"",
+	},
+	"plugin-catalog_cosign_bundle": {
+		`The cosign bundle of a keyless signature of the plugin binary. Requires
+cosign_certificate_identity and cosign_certificate_oidc_issuer.`,
+		"",
+	},
+	"plugin-catalog_cosign_certificate_identity": {
+		`The email address or URI the signing certificate of cosign_bundle must be
+issued to.`,
+		"",
+	},
+	"plugin-catalog_cosign_certificate_oidc_issuer": {
+		`The OIDC issuer which must have authenticated the identity of the signing
+certificate of cosign_bundle.`,
+		"",
+	},
+	"plugin-catalog_cosign": {
+		`The cosign signature the plugin binary is verified against each time it is run.`,
+		"",
+	},
 	"plugin-catalog-pins": {
 		"Configures pinned plugin versions from the plugin catalog",
 		`
@@ -6765,21 +7637,29 @@ This path responds to the following HTTP methods.
 		"",
 	},
 	"plugin-runtime-catalog_cgroup-parent": {
-		"Parent cgroup to set for each container. This can be used to control the total resource usage for a group of plugins.",
+		"Parent cgroup to set for each container or plugin process. This can be used to control the total resource usage for a group of plugins.",
 		"",
 	},
 	"plugin-runtime-catalog_cpu-nanos": {
-		"CPU limit to set per container in nanos. Defaults to no limit.",
+		"CPU limit to set per container or plugin process in nanos. Defaults to no limit.",
 		"",
 	},
 	"plugin-runtime-catalog_memory-bytes": {
-		"Memory limit to set per container in bytes. Defaults to no limit.",
+		"Memory limit to set per container or plugin process in bytes. Defaults to no limit.",
 		"",
 	},
 	"plugin-runtime-catalog_rootless": {
 		"Whether the container runtime is run as a non-privileged (non-root) user.",
 		"",
 	},
+	"plugin-runtime-catalog_max-restarts": {
+		"Maximum number of times a plugin is restarted after exiting unexpectedly within the restart window. Defaults to no limit.",
+		"",
+	},
+	"plugin-runtime-catalog_restart-window": {
+		"Window max_restarts applies to. Defaults to 1 minute.",
+		"",
+	},
 	"identity_token_key": {
 		"The name of the key used to sign plugin identity tokens. Defaults to the default key.",
 		"",
@@ -6896,6 +7776,16 @@ This path responds to the following HTTP methods.
 		"Export the metrics aggregated for telemetry purpose.",
 		"",
 	},
+	"discovery": {
+		"Returns the cluster name, API versions and features supported by the cluster.",
+		`
+This path responds to the following HTTP methods.
+		GET /
+			Returns the discovery document of the cluster. Listeners configured
+			with unauthenticated_discovery_access serve it without a token,
+			and also at /.well-known/vault.
+		`,
+	},
 	"in-flight-req": {
 		"reports in-flight requests",
 		`
@@ -6916,6 +7806,21 @@ This path responds to the following HTTP methods.
 		"Count of active entities in this Vault cluster.",
 		"Count of active entities in this Vault cluster.",
 	},
+	"internal-counters-secrets": {
+		"Count of secrets, versions of secrets and roles of each secrets engine.",
+		`
+Counts the secrets, versions of secrets and roles of each secrets engine of the
+namespace and its children. They are counted as they are created and deleted,
+since the time reported for each mount, so they are approximate.
+		`,
+	},
+	"internal-counters-credentials": {
+		"Count of credentials of each secrets engine by age.",
+		`
+Counts the leases of the secrets of each secrets engine of the namespace and
+its children, by the time elapsed since they were issued.
+		`,
+	},
 	"internal-inspect-router": {
 		"Information on the entries in each of the trees in the router. Inspectable trees are uuid, accessor, storage, and root.",
 		`
@@ -6950,6 +7855,18 @@ This path responds to the following HTTP methods.
 		"Count of leases associated with this Vault cluster",
 		"Count of leases associated with this Vault cluster",
 	},
+	"lease-queue": {
+		"Show the state of the lease expiration queue",
+		"Requires sudo capability. Show pending leases bucketed by time until expiration, along with the state of the revocation queue.",
+	},
+	"lease-recommendations": {
+		"Recommend tighter TTLs for leases from their usage",
+		"Requires sudo capability. Show how long the leases of each mount and role were used before they were revoked or expired, with the TTL and max TTL recommended for them when tighter than their current ones. Usage is tracked in memory by the active node since it was unsealed.",
+	},
+	"lease-revocation-failures": {
+		"Show the causes of failed lease revocation attempts",
+		"Requires sudo capability. Show the failed revocation attempts of pending and irrevocable leases per mount, by category of their cause: network, permission, not_found or other, with their counts and last error.",
+	},
 	"list-leases": {
 		"List leases associated with this Vault cluster",
 		"Requires sudo capability. List leases associated with this Vault cluster",
@@ -0,0 +1,568 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// configBundleChange is a change made, or which would be made by a dry run,
+// when applying a configuration bundle.
+type configBundleChange struct {
+	Kind   string
+	Path   string
+	Action string
+}
+
+// handleConfigBundlePublicKeyRead returns the public key verifying the
+// configuration bundles exported by this cluster.
+func (b *SystemBackend) handleConfigBundlePublicKeyRead(ctx context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	key, err := b.Core.configBundleSigningKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"public_key": base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		},
+	}, nil
+}
+
+// handleConfigBundleExport exports the configuration of the given mounts of
+// the request's namespace as a signed bundle. The resources of the mounts are
+// read on behalf of the request's token.
+func (b *SystemBackend) handleConfigBundleExport(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mountPaths := d.Get("mounts").([]string)
+	if len(mountPaths) == 0 {
+		return logical.ErrorResponse("at least one mount is required"), logical.ErrInvalidRequest
+	}
+	resourcePaths, err := parseConfigBundleResources(d.Get("resources").(map[string]interface{}))
+	if err != nil {
+		return handleError(err)
+	}
+
+	acl, err := b.configBundleACL(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &ConfigBundle{
+		Version:   configBundleVersion,
+		CreatedAt: time.Now().UTC(),
+		Namespace: ns.Path,
+	}
+	var referenced []string
+	for _, path := range mountPaths {
+		path = sanitizePath(path)
+		entry := b.configBundleMountEntry(ctx, path)
+		if entry == nil {
+			return logical.ErrorResponse("no mount at %q", path), logical.ErrInvalidRequest
+		}
+		if strutil.StrListContains(singletonMounts, entry.Type) {
+			return logical.ErrorResponse("mount %q cannot be exported", path), logical.ErrInvalidRequest
+		}
+
+		mount := &ConfigBundleMount{
+			Path:                  path,
+			Type:                  entry.Type,
+			Local:                 entry.Local,
+			SealWrap:              entry.SealWrap,
+			ExternalEntropyAccess: entry.ExternalEntropyAccess,
+			Config:                configBundleMountConfig(entry),
+		}
+		for _, resourcePath := range resourcePaths[path] {
+			resources, err := b.exportConfigBundleResources(ctx, req, acl, path, resourcePath)
+			if err != nil {
+				return handleError(err)
+			}
+			for _, resource := range resources {
+				referenced = append(referenced, configBundlePolicyNames(resource.Data)...)
+			}
+			mount.Resources = append(mount.Resources, resources...)
+		}
+		delete(resourcePaths, path)
+
+		mount.RotationJobs, err = b.Core.configBundleRotationJobs(ctx, entry)
+		if err != nil {
+			return nil, err
+		}
+		bundle.Mounts = append(bundle.Mounts, mount)
+	}
+	if len(resourcePaths) > 0 {
+		unexported := make([]string, 0, len(resourcePaths))
+		for path := range resourcePaths {
+			unexported = append(unexported, path)
+		}
+		sort.Strings(unexported)
+		return logical.ErrorResponse("resources given for mounts which are not exported: %s", strings.Join(unexported, ", ")), logical.ErrInvalidRequest
+	}
+
+	bundle.Policies, err = b.exportConfigBundlePolicies(ctx, acl, d.Get("policies").([]string), referenced)
+	if err != nil {
+		return handleError(err)
+	}
+
+	encoded, signature, err := b.Core.signConfigBundle(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"bundle":    string(encoded),
+			"signature": base64.StdEncoding.EncodeToString(signature),
+		},
+	}, nil
+}
+
+// handleConfigBundleImport applies a signed configuration bundle to the
+// request's namespace, creating the missing mounts and policies and
+// updating the others. Applying a bundle again changes nothing.
+func (b *SystemBackend) handleConfigBundleImport(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	signature, err := base64.StdEncoding.DecodeString(d.Get("signature").(string))
+	if err != nil || len(signature) == 0 {
+		return logical.ErrorResponse("signature must be base64-encoded"), logical.ErrInvalidRequest
+	}
+	var publicKey ed25519.PublicKey
+	if raw := d.Get("public_key").(string); raw != "" {
+		publicKey, err = base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			return logical.ErrorResponse("public_key must be base64-encoded"), logical.ErrInvalidRequest
+		}
+	}
+	dryRun := d.Get("dry_run").(bool)
+
+	bundle, err := b.Core.verifyConfigBundle(ctx, []byte(d.Get("bundle").(string)), signature, publicKey)
+	if err != nil {
+		return handleError(err)
+	}
+
+	acl, err := b.configBundleACL(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the whole bundle before changing anything
+	for _, mount := range bundle.Mounts {
+		if mount.Path != sanitizePath(mount.Path) || strutil.StrListContains(singletonMounts, mount.Type) {
+			return logical.ErrorResponse("bundle has invalid mount %q", mount.Path), logical.ErrInvalidRequest
+		}
+		if entry := b.configBundleMountEntry(ctx, mount.Path); entry != nil && entry.Type != mount.Type {
+			return logical.ErrorResponse("mount %q is of type %q instead of %q", mount.Path, entry.Type, mount.Type), logical.ErrInvalidRequest
+		}
+	}
+
+	var changes []*configBundleChange
+	for _, policy := range bundle.Policies {
+		change, err := b.importConfigBundlePolicy(ctx, acl, policy, dryRun)
+		if err != nil {
+			return handleError(err)
+		}
+		changes = append(changes, change)
+	}
+	for _, mount := range bundle.Mounts {
+		mountChanges, err := b.importConfigBundleMount(ctx, req, acl, mount, dryRun)
+		if err != nil {
+			return handleError(err)
+		}
+		changes = append(changes, mountChanges...)
+	}
+
+	respChanges := make([]map[string]interface{}, 0, len(changes))
+	for _, change := range changes {
+		respChanges = append(respChanges, map[string]interface{}{
+			"kind":   change.Kind,
+			"path":   change.Path,
+			"action": change.Action,
+		})
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"dry_run": dryRun,
+			"changes": respChanges,
+		},
+	}, nil
+}
+
+// parseConfigBundleResources parses the resource paths to export, keyed by
+// mount path.
+func parseConfigBundleResources(raw map[string]interface{}) (map[string][]string, error) {
+	resources := make(map[string][]string, len(raw))
+	for mountPath, rawPaths := range raw {
+		var paths []string
+		switch v := rawPaths.(type) {
+		case string:
+			paths = strutil.ParseStringSlice(v, ",")
+		case []string:
+			paths = v
+		case []interface{}:
+			for _, p := range v {
+				s, ok := p.(string)
+				if !ok {
+					return nil, fmt.Errorf("resources of mount %q must be paths", mountPath)
+				}
+				paths = append(paths, s)
+			}
+		default:
+			return nil, fmt.Errorf("resources of mount %q must be paths", mountPath)
+		}
+
+		for _, p := range paths {
+			if p == "" || strings.HasPrefix(p, "/") || strings.Contains(p, "..") {
+				return nil, fmt.Errorf("invalid resource path %q of mount %q", p, mountPath)
+			}
+		}
+		resources[sanitizePath(mountPath)] = append(resources[sanitizePath(mountPath)], paths...)
+	}
+	return resources, nil
+}
+
+// configBundleMountEntry returns the mount of the request's namespace at
+// exactly the given path, which starts with "auth/" for auth methods.
+func (b *SystemBackend) configBundleMountEntry(ctx context.Context, path string) *MountEntry {
+	entry := b.Core.router.MatchingMountEntry(ctx, path)
+	if entry == nil || entry.APIPathNoNamespace() != path {
+		return nil
+	}
+
+	var routerPrefix string
+	if entry.Table == credentialTableType {
+		routerPrefix = credentialRoutePrefix
+	}
+	if filtered, err := b.Core.checkReplicatedFiltering(ctx, entry, routerPrefix); err != nil || filtered {
+		return nil
+	}
+	return entry
+}
+
+// configBundleACL returns the ACL of the request's token, which the paths
+// accessed to export or import a bundle are checked against.
+func (b *SystemBackend) configBundleACL(ctx context.Context, req *logical.Request) (*ACL, error) {
+	acl, te, entity, _, err := b.Core.fetchACLTokenEntryAndEntity(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if entity != nil && entity.Disabled {
+		b.logger.Warn("permission denied as the entity on the token is disabled")
+		return nil, logical.ErrPermissionDenied
+	}
+	if te != nil && te.EntityID != "" && entity == nil {
+		b.logger.Warn("permission denied as the entity on the token is invalid")
+		return nil, logical.ErrPermissionDenied
+	}
+	return acl, nil
+}
+
+// configBundleRoute sends a request to a path of the request's namespace on
+// behalf of the request's token, if its policies allow it.
+func (b *SystemBackend) configBundleRoute(ctx context.Context, req *logical.Request, acl *ACL, op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+	routeReq := &logical.Request{
+		Operation:           op,
+		Path:                path,
+		Data:                data,
+		ClientToken:         req.ClientToken,
+		ClientTokenAccessor: req.ClientTokenAccessor,
+		EntityID:            req.EntityID,
+	}
+	if err := configBundleCheckACL(ctx, acl, op, path); err != nil {
+		return nil, err
+	}
+
+	if op == logical.UpdateOperation {
+		_, checkExists, exists, err := b.Core.router.RouteExistenceCheck(ctx, routeReq)
+		if err != nil && err != logical.ErrUnsupportedPath {
+			return nil, err
+		}
+		if checkExists && !exists {
+			routeReq.Operation = logical.CreateOperation
+		}
+	}
+
+	resp, err := b.Core.router.Route(ctx, routeReq)
+	if resp != nil && resp.IsError() {
+		return nil, fmt.Errorf("%s on %q failed: %w", op, path, resp.Error())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s on %q failed: %w", op, path, err)
+	}
+	return resp, nil
+}
+
+// configBundleCheckACL checks that the ACL allows the operation on the path,
+// with sudo if the path requires it.
+func configBundleCheckACL(ctx context.Context, acl *ACL, op logical.Operation, path string) error {
+	results := acl.AllowOperation(ctx, &logical.Request{Operation: op, Path: path}, false)
+	if !results.Allowed || (configBundleSudoPath(path) && !results.RootPrivs) {
+		return logical.CodedError(http.StatusForbidden, fmt.Sprintf("permission denied to %s %q", op, path))
+	}
+	return nil
+}
+
+// configBundleSudoPath returns whether the system path requires sudo.
+func configBundleSudoPath(path string) bool {
+	return strings.HasPrefix(path, "sys/auth/")
+}
+
+// exportConfigBundleResources reads a resource of the mount, or each of the
+// resources listed under it if its path ends with a slash.
+func (b *SystemBackend) exportConfigBundleResources(ctx context.Context, req *logical.Request, acl *ACL, mountPath, resourcePath string) ([]*ConfigBundleResource, error) {
+	paths := []string{resourcePath}
+	if strings.HasSuffix(resourcePath, "/") {
+		resp, err := b.configBundleRoute(ctx, req, acl, logical.ListOperation, mountPath+resourcePath, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		paths = nil
+		if resp != nil {
+			keys, _ := resp.Data["keys"].([]string)
+			sort.Strings(keys)
+			for _, key := range keys {
+				if !strings.HasSuffix(key, "/") {
+					paths = append(paths, resourcePath+key)
+				}
+			}
+		}
+	}
+
+	resources := make([]*ConfigBundleResource, 0, len(paths))
+	for _, path := range paths {
+		resp, err := b.configBundleRoute(ctx, req, acl, logical.ReadOperation, mountPath+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp == nil {
+			return nil, fmt.Errorf("resource %q not found", mountPath+path)
+		}
+		if resp.Secret != nil || resp.Auth != nil || resp.WrapInfo != nil {
+			return nil, fmt.Errorf("resource %q holds secret data and cannot be exported", mountPath+path)
+		}
+		resources = append(resources, &ConfigBundleResource{
+			Path: path,
+			Data: resp.Data,
+		})
+	}
+	return resources, nil
+}
+
+// exportConfigBundlePolicies returns the given policies, which must exist,
+// and the existing policies referenced by the resources of the bundle.
+func (b *SystemBackend) exportConfigBundlePolicies(ctx context.Context, acl *ACL, names, referenced []string) ([]*ConfigBundlePolicy, error) {
+	var policies []*ConfigBundlePolicy
+	seen := make(map[string]struct{})
+	add := func(name string, explicit bool) error {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := seen[name]; ok || name == "" || strutil.StrListContains(immutablePolicies, name) {
+			return nil
+		}
+		seen[name] = struct{}{}
+
+		if err := configBundleCheckACL(ctx, acl, logical.ReadOperation, "sys/policies/acl/"+name); err != nil {
+			if !explicit {
+				return nil
+			}
+			return err
+		}
+		policy, err := b.Core.policyStore.GetPolicy(ctx, name, PolicyTypeACL)
+		if err != nil {
+			return err
+		}
+		if policy == nil {
+			if !explicit {
+				return nil
+			}
+			return fmt.Errorf("policy %q not found", name)
+		}
+		policies = append(policies, &ConfigBundlePolicy{
+			Name:   name,
+			Policy: policy.Raw,
+		})
+		return nil
+	}
+
+	for _, name := range names {
+		if err := add(name, true); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range referenced {
+		if err := add(name, false); err != nil {
+			return nil, err
+		}
+	}
+	return policies, nil
+}
+
+// importConfigBundlePolicy creates or updates a policy of the bundle.
+func (b *SystemBackend) importConfigBundlePolicy(ctx context.Context, acl *ACL, bundled *ConfigBundlePolicy, dryRun bool) (*configBundleChange, error) {
+	change := &configBundleChange{
+		Kind: "policy",
+		Path: bundled.Name,
+	}
+	if strutil.StrListContains(immutablePolicies, bundled.Name) {
+		return nil, fmt.Errorf("cannot import the %q policy", bundled.Name)
+	}
+	if err := configBundleCheckACL(ctx, acl, logical.UpdateOperation, "sys/policies/acl/"+bundled.Name); err != nil {
+		return nil, err
+	}
+
+	existing, err := b.Core.policyStore.GetPolicy(ctx, bundled.Name, PolicyTypeACL)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case existing == nil:
+		change.Action = "create"
+	case existing.Raw == bundled.Policy:
+		change.Action = "none"
+		return change, nil
+	default:
+		change.Action = "update"
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := ParseACLPolicy(ns, bundled.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy %q: %w", bundled.Name, err)
+	}
+	policy.Name = bundled.Name
+	if dryRun {
+		return change, nil
+	}
+	if err := b.Core.policyStore.SetPolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// importConfigBundleMount creates the mount of the bundle if it is missing,
+// then applies its settings, resources and rotation schedules.
+func (b *SystemBackend) importConfigBundleMount(ctx context.Context, req *logical.Request, acl *ACL, mount *ConfigBundleMount, dryRun bool) ([]*configBundleChange, error) {
+	sysPath := "sys/mounts/" + mount.Path
+	if strings.HasPrefix(mount.Path, credentialRoutePrefix) {
+		sysPath = "sys/auth/" + strings.TrimPrefix(mount.Path, credentialRoutePrefix)
+	}
+
+	change := &configBundleChange{
+		Kind: "mount",
+		Path: mount.Path,
+	}
+	entry := b.configBundleMountEntry(ctx, mount.Path)
+	switch {
+	case entry == nil:
+		change.Action = "create"
+		if err := configBundleCheckACL(ctx, acl, logical.UpdateOperation, sysPath); err != nil {
+			return nil, err
+		}
+		if dryRun {
+			break
+		}
+
+		data := map[string]interface{}{
+			"type":                    mount.Type,
+			"local":                   mount.Local,
+			"seal_wrap":               mount.SealWrap,
+			"external_entropy_access": mount.ExternalEntropyAccess,
+		}
+		for _, key := range []string{"description", "options", "plugin_version"} {
+			if v, ok := mount.Config[key]; ok {
+				data[key] = v
+			}
+		}
+		if _, err := b.configBundleRoute(ctx, req, acl, logical.UpdateOperation, sysPath, data); err != nil {
+			return nil, err
+		}
+		entry = b.configBundleMountEntry(ctx, mount.Path)
+		if entry == nil {
+			return nil, fmt.Errorf("failed to create mount %q", mount.Path)
+		}
+	case configBundleEqual(configBundleMountConfig(entry), mount.Config):
+		change.Action = "none"
+	default:
+		change.Action = "update"
+	}
+	changes := []*configBundleChange{change}
+
+	if entry != nil && change.Action != "none" && !dryRun {
+		if _, err := b.configBundleRoute(ctx, req, acl, logical.UpdateOperation, sysPath+"/tune", mount.Config); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, resource := range mount.Resources {
+		change := &configBundleChange{
+			Kind:   "resource",
+			Path:   mount.Path + resource.Path,
+			Action: "create",
+		}
+		data := resource.Data
+		if entry != nil {
+			existing, err := b.configBundleRoute(ctx, req, acl, logical.ReadOperation, change.Path, nil)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				// Only the fields which differ are written, as backends may
+				// refuse to update some fields even to their current value
+				data = configBundleDiff(existing.Data, resource.Data)
+				change.Action = "update"
+				if len(data) == 0 {
+					change.Action = "none"
+				}
+			}
+		}
+		changes = append(changes, change)
+
+		if change.Action == "none" {
+			continue
+		}
+		if err := configBundleCheckACL(ctx, acl, logical.UpdateOperation, change.Path); err != nil {
+			return nil, err
+		}
+		if dryRun {
+			continue
+		}
+		if _, err := b.configBundleRoute(ctx, req, acl, logical.UpdateOperation, change.Path, data); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, job := range mount.RotationJobs {
+		changed, err := b.Core.applyConfigBundleRotationJob(ctx, entry, job, dryRun || entry == nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to schedule rotation of %q: %w", mount.Path+job.ReqPath, err)
+		}
+		change := &configBundleChange{
+			Kind:   "rotation_job",
+			Path:   mount.Path + job.ReqPath,
+			Action: "none",
+		}
+		if changed {
+			change.Action = "update"
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
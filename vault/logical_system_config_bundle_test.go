@@ -0,0 +1,177 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/builtin/credential/approle"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemBackend_ConfigBundle(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	c.credentialBackends["approle"] = approle.Factory
+	ctx := namespace.RootContext(nil)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		resp, err := c.HandleRequest(ctx, req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("%s %s: err: %v\nresp: %#v", op, path, err, resp)
+		}
+		return resp
+	}
+
+	request(logical.UpdateOperation, "sys/auth/approle", map[string]interface{}{
+		"type":        "approle",
+		"description": "applications",
+	})
+	request(logical.UpdateOperation, "sys/auth/approle/tune", map[string]interface{}{
+		"default_lease_ttl": "1h",
+	})
+	request(logical.UpdateOperation, "sys/policies/acl/app", map[string]interface{}{
+		"policy": `path "secret/*" { capabilities = ["read"] }`,
+	})
+	request(logical.UpdateOperation, "auth/approle/role/app", map[string]interface{}{
+		"token_policies": "app",
+		"token_ttl":      "10m",
+	})
+	request(logical.UpdateOperation, "sys/mounts/kv", map[string]interface{}{
+		"type": "kv",
+	})
+
+	resp := request(logical.UpdateOperation, "sys/config/bundle/export", map[string]interface{}{
+		"mounts": []string{"auth/approle", "kv"},
+		"resources": map[string]interface{}{
+			"auth/approle": []string{"role/"},
+		},
+	})
+	bundle := resp.Data["bundle"].(string)
+	signature := resp.Data["signature"].(string)
+
+	var decoded ConfigBundle
+	require.NoError(t, json.Unmarshal([]byte(bundle), &decoded))
+	require.Len(t, decoded.Mounts, 2)
+	require.Equal(t, "auth/approle/", decoded.Mounts[0].Path)
+	require.Equal(t, "approle", decoded.Mounts[0].Type)
+	require.Equal(t, "applications", decoded.Mounts[0].Config["description"])
+	require.Equal(t, "3600s", decoded.Mounts[0].Config["default_lease_ttl"])
+	require.Len(t, decoded.Mounts[0].Resources, 1)
+	require.Equal(t, "role/app", decoded.Mounts[0].Resources[0].Path)
+	require.Equal(t, "kv/", decoded.Mounts[1].Path)
+	require.Equal(t, []*ConfigBundlePolicy{{Name: "app", Policy: `path "secret/*" { capabilities = ["read"] }`}}, decoded.Policies)
+
+	actions := func(resp *logical.Response) map[string]string {
+		out := make(map[string]string)
+		for _, change := range resp.Data["changes"].([]map[string]interface{}) {
+			out[change["kind"].(string)+" "+change["path"].(string)] = change["action"].(string)
+		}
+		return out
+	}
+
+	// Applying the bundle to its own cluster changes nothing
+	resp = request(logical.UpdateOperation, "sys/config/bundle/import", map[string]interface{}{
+		"bundle":    bundle,
+		"signature": signature,
+	})
+	require.Equal(t, map[string]string{
+		"policy app":                     "none",
+		"mount auth/approle/":            "none",
+		"resource auth/approle/role/app": "none",
+		"mount kv/":                      "none",
+	}, actions(resp))
+
+	// Applying it to a cluster missing the configuration restores it
+	request(logical.DeleteOperation, "sys/auth/approle", nil)
+	request(logical.DeleteOperation, "sys/policies/acl/app", nil)
+	request(logical.DeleteOperation, "sys/mounts/kv", nil)
+
+	resp = request(logical.UpdateOperation, "sys/config/bundle/import", map[string]interface{}{
+		"bundle":    bundle,
+		"signature": signature,
+		"dry_run":   true,
+	})
+	expected := map[string]string{
+		"policy app":                     "create",
+		"mount auth/approle/":            "create",
+		"resource auth/approle/role/app": "create",
+		"mount kv/":                      "create",
+	}
+	require.Equal(t, expected, actions(resp))
+	require.Nil(t, c.router.MatchingMountEntry(ctx, "auth/approle/"))
+
+	resp = request(logical.UpdateOperation, "sys/config/bundle/import", map[string]interface{}{
+		"bundle":    bundle,
+		"signature": signature,
+	})
+	require.Equal(t, expected, actions(resp))
+
+	entry := c.router.MatchingMountEntry(ctx, "auth/approle/")
+	require.NotNil(t, entry)
+	require.Equal(t, "applications", entry.Description)
+	require.Equal(t, time.Hour, entry.Config.DefaultLeaseTTL)
+	require.NotNil(t, c.router.MatchingMountEntry(ctx, "kv/"))
+
+	resp = request(logical.ReadOperation, "auth/approle/role/app", nil)
+	require.Equal(t, []string{"app"}, resp.Data["token_policies"])
+	require.EqualValues(t, 600, resp.Data["token_ttl"])
+	resp = request(logical.ReadOperation, "sys/policies/acl/app", nil)
+	require.NotNil(t, resp)
+
+	// Drift from the bundle is reverted
+	request(logical.UpdateOperation, "auth/approle/role/app", map[string]interface{}{
+		"token_ttl": "20m",
+	})
+	resp = request(logical.UpdateOperation, "sys/config/bundle/import", map[string]interface{}{
+		"bundle":    bundle,
+		"signature": signature,
+	})
+	require.Equal(t, map[string]string{
+		"policy app":                     "none",
+		"mount auth/approle/":            "none",
+		"resource auth/approle/role/app": "update",
+		"mount kv/":                      "none",
+	}, actions(resp))
+	resp = request(logical.ReadOperation, "auth/approle/role/app", nil)
+	require.EqualValues(t, 600, resp.Data["token_ttl"])
+
+	// Bundles must be signed by the given key
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/config/bundle/import")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"bundle":    bundle + " ",
+		"signature": signature,
+	}
+	resp, err := c.HandleRequest(ctx, req)
+	require.Error(t, err)
+	require.Contains(t, resp.Error().Error(), "invalid bundle signature")
+
+	otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	req.Data = map[string]interface{}{
+		"bundle":     bundle,
+		"signature":  signature,
+		"public_key": base64.StdEncoding.EncodeToString(otherKey),
+	}
+	resp, err = c.HandleRequest(ctx, req)
+	require.Error(t, err)
+	require.Contains(t, resp.Error().Error(), "invalid bundle signature")
+
+	resp = request(logical.ReadOperation, "sys/config/bundle/public-key", nil)
+	req.Data["public_key"] = resp.Data["public_key"]
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err, "%#v", resp)
+	require.False(t, resp.IsError())
+}
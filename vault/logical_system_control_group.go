@@ -0,0 +1,156 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// handleControlGroupAuthorize records the approval of a control group request
+// by the entity of the calling token.
+func (b *SystemBackend) handleControlGroupAuthorize(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	accessor := d.Get("accessor").(string)
+	if accessor == "" {
+		return logical.ErrorResponse("missing accessor"), logical.ErrInvalidRequest
+	}
+	if req.EntityID == "" {
+		return logical.ErrorResponse("control group requests can only be authorized by tokens with an entity"), logical.ErrPermissionDenied
+	}
+
+	te, err := b.Core.controlGroupToken(ctx, accessor)
+	if err != nil {
+		if errors.Is(err, errControlGroupInvalidAccessor) {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		return nil, err
+	}
+
+	approved, err := b.Core.authorizeControlGroupRequest(ctx, te, req.EntityID)
+	switch {
+	case errors.Is(err, errControlGroupNotAuthorizer), errors.Is(err, errControlGroupSelfAuthorize):
+		return logical.ErrorResponse(err.Error()), logical.ErrPermissionDenied
+	case err != nil:
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"approved": approved,
+		},
+	}, nil
+}
+
+// handleControlGroupRequest returns the status of a control group request.
+func (b *SystemBackend) handleControlGroupRequest(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	accessor := d.Get("accessor").(string)
+	if accessor == "" {
+		return logical.ErrorResponse("missing accessor"), logical.ErrInvalidRequest
+	}
+
+	te, err := b.Core.controlGroupToken(ctx, accessor)
+	if err != nil {
+		if errors.Is(err, errControlGroupInvalidAccessor) {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+		return nil, err
+	}
+
+	b.Core.controlGroupLock.Lock()
+	cgRequest, err := b.Core.controlGroupRequest(ctx, te)
+	b.Core.controlGroupLock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	authorizations := make([]map[string]interface{}, 0, len(cgRequest.Authorizations))
+	for _, authz := range cgRequest.Authorizations {
+		authorizations = append(authorizations, map[string]interface{}{
+			"entity_id":   authz.EntityID,
+			"entity_name": authz.EntityName,
+		})
+	}
+
+	data := map[string]interface{}{
+		"approved":       cgRequest.approved(),
+		"request_path":   cgRequest.Path,
+		"request_time":   cgRequest.RequestTime.Format(time.RFC3339),
+		"authorizations": authorizations,
+	}
+	if cgRequest.EntityID != "" {
+		requestEntity := map[string]interface{}{
+			"id": cgRequest.EntityID,
+		}
+		entity, err := b.Core.identityStore.MemDBEntityByID(cgRequest.EntityID, false)
+		if err != nil {
+			return nil, err
+		}
+		if entity != nil {
+			requestEntity["name"] = entity.Name
+		}
+		data["request_entity"] = requestEntity
+	}
+
+	return &logical.Response{Data: data}, nil
+}
+
+func (b *SystemBackend) handleControlGroupConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	if err := controlGroupCheckNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	config, err := b.Core.controlGroupConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"max_ttl": int64(config.MaxTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleControlGroupConfigUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := controlGroupCheckNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	config := &ControlGroupConfig{
+		MaxTTL: time.Duration(d.Get("max_ttl").(int)) * time.Second,
+	}
+	if config.MaxTTL < 0 {
+		return logical.ErrorResponse("max_ttl must not be negative"), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(coreControlGroupConfigPath, config)
+	if err != nil {
+		return nil, err
+	}
+	return nil, b.Core.barrier.Put(ctx, entry)
+}
+
+func (b *SystemBackend) handleControlGroupConfigDelete(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	if err := controlGroupCheckNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, b.Core.barrier.Delete(ctx, coreControlGroupConfigPath)
+}
+
+func controlGroupCheckNamespace(ctx context.Context) error {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if ns.ID != namespace.RootNamespaceID {
+		return errors.New("control groups can only be configured in the root namespace")
+	}
+	return nil
+}
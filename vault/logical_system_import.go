@@ -0,0 +1,197 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/secretimport"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	importStatusReady    = "ready"
+	importStatusImported = "imported"
+	importStatusSkipped  = "skipped"
+	importStatusFailed   = "failed"
+)
+
+// handleImport imports the secrets of an external secret manager into a KV
+// version 2 secrets engine. The secrets are written through the router, once
+// the calling token is checked to be allowed to write them.
+func (b *SystemBackend) handleImport(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mountPath := sanitizePath(d.Get("mount").(string))
+	entry := b.Core.router.MatchingMountEntry(ctx, mountPath)
+	if entry == nil || entry.Path != mountPath || entry.NamespaceID != ns.ID {
+		return logical.ErrorResponse("no secrets engine is mounted at %q", mountPath), logical.ErrInvalidRequest
+	}
+	if entry.Type != mountTypeKV || entry.Options["version"] != "2" {
+		return logical.ErrorResponse("secrets engine at %q is not a KV version 2 secrets engine", mountPath), logical.ErrInvalidRequest
+	}
+
+	prefix := strings.Trim(d.Get("prefix").(string), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	source, err := secretimport.New(ctx, d.Get("source").(string), d.Get("config").(map[string]string))
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	secrets, err := source.List(ctx)
+	if err != nil {
+		return logical.ErrorResponse("failed to list the secrets of the source: %v", err), logical.ErrInvalidRequest
+	}
+
+	// Selected secrets missing from the source are reported as failed
+	missing := make(map[string]bool)
+	if names := d.Get("secrets").([]string); len(names) > 0 {
+		byName := make(map[string]*secretimport.Secret, len(secrets))
+		for _, secret := range secrets {
+			byName[secret.Name] = secret
+		}
+		secrets = make([]*secretimport.Secret, 0, len(names))
+		for _, name := range strutil.RemoveDuplicatesStable(names, false) {
+			secret, ok := byName[name]
+			if !ok {
+				secret = &secretimport.Secret{Name: name}
+				missing[name] = true
+			}
+			secrets = append(secrets, secret)
+		}
+	}
+
+	dryRun := d.Get("dry_run").(bool)
+	overwrite := d.Get("overwrite").(bool)
+	results := make([]map[string]interface{}, 0, len(secrets))
+	failed := 0
+	for _, secret := range secrets {
+		path := prefix + secret.Name
+		result := map[string]interface{}{
+			"name": secret.Name,
+			"path": mountPath + path,
+		}
+
+		var status string
+		var err error
+		if missing[secret.Name] {
+			err = secretimport.ErrNotFound
+		} else {
+			status, err = b.importSecret(ctx, req, source, mountPath, path, secret, dryRun, overwrite)
+		}
+		if err != nil {
+			failed++
+			status = importStatusFailed
+			result["error"] = err.Error()
+		}
+		result["status"] = status
+		results = append(results, result)
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"dry_run": dryRun,
+			"results": results,
+		},
+	}
+	if failed > 0 {
+		resp.AddWarning(fmt.Sprintf("%d of %d secrets failed to import", failed, len(secrets)))
+	}
+	return resp, nil
+}
+
+// importSecret imports a secret of a source at the given path of a KV version
+// 2 secrets engine, and returns the status of the import.
+func (b *SystemBackend) importSecret(ctx context.Context, req *logical.Request, source secretimport.Source, mountPath, path string, secret *secretimport.Secret, dryRun, overwrite bool) (string, error) {
+	dataPath := mountPath + "data/" + path
+	metadataPath := mountPath + "metadata/" + path
+	if err := b.checkImportAllowed(ctx, req.ClientToken, dataPath); err != nil {
+		return "", err
+	}
+	if len(secret.Labels) > 0 {
+		if err := b.checkImportAllowed(ctx, req.ClientToken, metadataPath); err != nil {
+			return "", err
+		}
+	}
+
+	existing, err := b.Core.router.Route(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      metadataPath,
+	})
+	if err != nil {
+		return "", err
+	}
+	exists := existing != nil && !existing.IsError()
+	switch {
+	case exists && !overwrite:
+		return importStatusSkipped, nil
+	case dryRun:
+		return importStatusReady, nil
+	}
+
+	value, err := source.Value(ctx, secret.Name)
+	if err != nil {
+		return "", err
+	}
+
+	op := logical.CreateOperation
+	if exists {
+		op = logical.UpdateOperation
+	}
+	resp, err := b.Core.router.Route(ctx, &logical.Request{
+		Operation: op,
+		Path:      dataPath,
+		Data: map[string]interface{}{
+			"data": secretimport.Data(value),
+		},
+	})
+	if err == nil && resp.IsError() {
+		err = resp.Error()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write the secret: %w", err)
+	}
+
+	if len(secret.Labels) > 0 {
+		resp, err = b.Core.router.Route(ctx, &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      metadataPath,
+			Data: map[string]interface{}{
+				"custom_metadata": secret.Labels,
+			},
+		})
+		if err == nil && resp.IsError() {
+			err = resp.Error()
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to write the labels of the secret: %w", err)
+		}
+	}
+
+	return importStatusImported, nil
+}
+
+// checkImportAllowed returns an error unless the token is allowed to create
+// and update the given path.
+func (b *SystemBackend) checkImportAllowed(ctx context.Context, token, path string) error {
+	capabilities, err := b.Core.Capabilities(ctx, token, path)
+	if err != nil {
+		return err
+	}
+	if strutil.StrListContains(capabilities, RootCapability) ||
+		(strutil.StrListContains(capabilities, CreateCapability) && strutil.StrListContains(capabilities, UpdateCapability)) {
+		return nil
+	}
+	return fmt.Errorf("%w: create and update capabilities are required on %q", logical.ErrPermissionDenied, path)
+}
@@ -0,0 +1,206 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// testAWSSecretsManager returns a server answering the Secrets Manager API
+// with the given secrets.
+func testAWSSecretsManager(t *testing.T, secrets map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.ListSecrets":
+			var list []interface{}
+			for name := range secrets {
+				list = append(list, map[string]interface{}{
+					"Name": name,
+					"Tags": []interface{}{map[string]interface{}{"Key": "source", "Value": "aws"}},
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"SecretList": list})
+		case "secretsmanager.GetSecretValue":
+			value, ok := secrets[input["SecretId"].(string)]
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]interface{}{"__type": "ResourceNotFoundException"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"SecretString": value})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSystemBackend_Import(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return c.HandleRequest(ctx, &logical.Request{
+			Operation:   op,
+			Path:        path,
+			ClientToken: root,
+			Data:        data,
+		})
+	}
+
+	err := c.mount(ctx, &MountEntry{
+		Table:   mountTableType,
+		Path:    "kv/",
+		Type:    "kv",
+		Options: map[string]string{"version": "2"},
+	})
+	require.NoError(t, err)
+
+	// Wait for the upgrade of the secrets engine to versioned data
+	require.Eventually(t, func() bool {
+		resp, err := request(logical.UpdateOperation, "kv/data/migrated/existing", map[string]interface{}{
+			"data": map[string]interface{}{"password": "old"},
+		})
+		return err == nil && !resp.IsError()
+	}, 10*time.Second, 100*time.Millisecond)
+
+	server := testAWSSecretsManager(t, map[string]string{
+		"db":       `{"password":"hunter2"}`,
+		"existing": "new",
+	})
+	importData := func(data map[string]interface{}) map[string]interface{} {
+		data["source"] = "aws"
+		data["mount"] = "kv"
+		data["prefix"] = "migrated"
+		data["config"] = map[string]interface{}{
+			"region":     "us-east-1",
+			"access_key": "AKIAEXAMPLE",
+			"secret_key": "secret",
+			"endpoint":   server.URL,
+		}
+		return data
+	}
+
+	// Dry runs list the secrets without writing them
+	resp, err := request(logical.UpdateOperation, "sys/import", importData(map[string]interface{}{"dry_run": true}))
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["dry_run"])
+	require.Equal(t, []map[string]interface{}{
+		{"name": "db", "path": "kv/migrated/db", "status": importStatusReady},
+		{"name": "existing", "path": "kv/migrated/existing", "status": importStatusSkipped},
+	}, resp.Data["results"])
+
+	resp, err = request(logical.ReadOperation, "kv/data/migrated/db", nil)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	// Existing secrets are kept unless overwritten
+	resp, err = request(logical.UpdateOperation, "sys/import", importData(map[string]interface{}{}))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"name": "db", "path": "kv/migrated/db", "status": importStatusImported},
+		{"name": "existing", "path": "kv/migrated/existing", "status": importStatusSkipped},
+	}, resp.Data["results"])
+
+	resp, err = request(logical.ReadOperation, "kv/data/migrated/db", nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"password": "hunter2"}, resp.Data["data"])
+
+	resp, err = request(logical.ReadOperation, "kv/metadata/migrated/db", nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"source": "aws"}, resp.Data["custom_metadata"])
+
+	resp, err = request(logical.UpdateOperation, "sys/import", importData(map[string]interface{}{
+		"secrets":   "existing,missing",
+		"overwrite": true,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []map[string]interface{}{
+		{"name": "existing", "path": "kv/migrated/existing", "status": importStatusImported},
+		{"name": "missing", "path": "kv/migrated/missing", "status": importStatusFailed, "error": "secret not found"},
+	}, resp.Data["results"])
+	require.Equal(t, []string{"1 of 2 secrets failed to import"}, resp.Warnings)
+
+	resp, err = request(logical.ReadOperation, "kv/data/migrated/existing", nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"value": "new"}, resp.Data["data"])
+
+	// Only KV version 2 secrets engines are supported
+	data := importData(map[string]interface{}{})
+	data["mount"] = "secret"
+	resp, err = request(logical.UpdateOperation, "sys/import", data)
+	require.ErrorContains(t, err, logical.ErrInvalidRequest.Error())
+	require.Contains(t, resp.Error().Error(), "is not a KV version 2 secrets engine")
+}
+
+func TestSystemBackend_Import_PermissionDenied(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	err := c.mount(ctx, &MountEntry{
+		Table:   mountTableType,
+		Path:    "kv/",
+		Type:    "kv",
+		Options: map[string]string{"version": "2"},
+	})
+	require.NoError(t, err)
+
+	policy, err := ParseACLPolicy(namespace.RootNamespace, `
+path "sys/import" {
+	capabilities = ["update"]
+}
+path "kv/data/allowed/*" {
+	capabilities = ["create", "update", "read"]
+}
+path "kv/metadata/allowed/*" {
+	capabilities = ["create", "update", "read"]
+}
+`)
+	require.NoError(t, err)
+	policy.Name = "import"
+	require.NoError(t, c.policyStore.SetPolicy(ctx, policy))
+	testMakeServiceTokenViaCore(t, c, root, "import-token", "", []string{"import"})
+
+	server := testAWSSecretsManager(t, map[string]string{"db": "hunter2"})
+	resp, err := c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.UpdateOperation,
+		Path:        "sys/import",
+		ClientToken: "import-token",
+		Data: map[string]interface{}{
+			"source":  "aws",
+			"mount":   "kv",
+			"prefix":  "denied",
+			"dry_run": true,
+			"config": map[string]interface{}{
+				"region":     "us-east-1",
+				"access_key": "AKIAEXAMPLE",
+				"secret_key": "secret",
+				"endpoint":   server.URL,
+			},
+		},
+	})
+	require.NoError(t, err)
+	results := resp.Data["results"].([]map[string]interface{})
+	require.Len(t, results, 1)
+	require.Equal(t, importStatusFailed, results[0]["status"])
+	require.Contains(t, results[0]["error"], "permission denied")
+}
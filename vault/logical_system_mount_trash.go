@@ -0,0 +1,174 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func (b *SystemBackend) handleMountTrashConfigRead(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	if err := mountTrashCheckNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	config, err := b.Core.mountTrashConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"retention_period": int64(config.RetentionPeriod.Seconds()),
+		},
+	}, nil
+}
+
+func (b *SystemBackend) handleMountTrashConfigUpdate(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := mountTrashCheckNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	config := &MountTrashConfig{
+		RetentionPeriod: time.Duration(d.Get("retention_period").(int)) * time.Second,
+	}
+	if config.RetentionPeriod < 0 {
+		return logical.ErrorResponse("retention_period must not be negative"), logical.ErrInvalidRequest
+	}
+
+	entry, err := logical.StorageEntryJSON(coreMountTrashConfigPath, config)
+	if err != nil {
+		return nil, err
+	}
+	return nil, b.Core.barrier.Put(ctx, entry)
+}
+
+func (b *SystemBackend) handleMountTrashConfigDelete(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	if err := mountTrashCheckNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, b.Core.barrier.Delete(ctx, coreMountTrashConfigPath)
+}
+
+// handleMountTrashList lists the trashed mounts of the namespace.
+func (b *SystemBackend) handleMountTrashList(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	trashed, err := b.Core.trashedMountsInNamespace(ctx, ns.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(trashed))
+	keyInfo := make(map[string]interface{}, len(trashed))
+	for _, t := range trashed {
+		keys = append(keys, t.Entry.Accessor)
+		keyInfo[t.Entry.Accessor] = map[string]interface{}{
+			"path":       t.Entry.Path,
+			"type":       t.Entry.Type,
+			"purge_time": t.PurgeTime.Format(time.RFC3339),
+		}
+	}
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *SystemBackend) handleMountTrashRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	trashed, err := b.mountTrashEntry(ctx, d.Get("accessor").(string))
+	if err != nil || trashed == nil {
+		return nil, err
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"accessor":       trashed.Entry.Accessor,
+			"path":           trashed.Entry.Path,
+			"type":           trashed.Entry.Type,
+			"description":    trashed.Entry.Description,
+			"local":          trashed.Entry.Local,
+			"plugin_version": trashed.Entry.Version,
+			"deleted_time":   trashed.DeletedTime.Format(time.RFC3339),
+			"purge_time":     trashed.PurgeTime.Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// handleMountTrashPurge purges a trashed mount before the end of its
+// retention period.
+func (b *SystemBackend) handleMountTrashPurge(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	trashed, err := b.mountTrashEntry(ctx, d.Get("accessor").(string))
+	if err != nil || trashed == nil {
+		return nil, err
+	}
+
+	err = b.Core.purgeTrashedMount(ctx, trashed.Entry.Accessor)
+	if err != nil && !errors.Is(err, errMountTrashNotFound) {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// handleMountTrashRestore mounts a trashed mount again at its original path.
+func (b *SystemBackend) handleMountTrashRestore(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	accessor := d.Get("accessor").(string)
+	if accessor == "" {
+		return logical.ErrorResponse("missing accessor"), logical.ErrInvalidRequest
+	}
+
+	entry, err := b.Core.restoreTrashedMount(ctx, accessor)
+	switch {
+	case errors.Is(err, errMountTrashNotFound):
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	case err != nil:
+		return handleError(err)
+	}
+
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"accessor": entry.Accessor,
+			"path":     entry.Path,
+		},
+	}, nil
+}
+
+// mountTrashEntry returns the trashed mount of the namespace with the given
+// accessor, or nil if there is none.
+func (b *SystemBackend) mountTrashEntry(ctx context.Context, accessor string) (*trashedMount, error) {
+	if accessor == "" {
+		return nil, logical.ErrInvalidRequest
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	trashed, err := b.Core.trashedMount(ctx, accessor)
+	if err != nil {
+		return nil, err
+	}
+	if trashed == nil || trashed.Entry.NamespaceID != ns.ID {
+		return nil, nil
+	}
+	return trashed, nil
+}
+
+func mountTrashCheckNamespace(ctx context.Context) error {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if ns.ID != namespace.RootNamespaceID {
+		return errors.New("the mount trash can only be configured in the root namespace")
+	}
+	return nil
+}
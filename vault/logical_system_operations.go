@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// handleOperationsList lists the operations of the namespace.
+func (b *SystemBackend) handleOperationsList(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := b.Core.asyncOperations.list(ns.ID)
+	keys := make([]string, 0, len(ops))
+	keyInfo := make(map[string]interface{}, len(ops))
+	for _, op := range ops {
+		keys = append(keys, op.ID)
+		keyInfo[op.ID] = map[string]interface{}{
+			"type":   op.Type,
+			"status": op.Status,
+		}
+	}
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+func (b *SystemBackend) handleOperationRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	op := b.Core.asyncOperations.get(ns.ID, d.Get("id").(string))
+	if op == nil {
+		return nil, nil
+	}
+	return &logical.Response{Data: op.responseData()}, nil
+}
+
+// handleOperationCancel cancels a running operation. Canceling a finished
+// operation has no effect.
+func (b *SystemBackend) handleOperationCancel(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := d.Get("id").(string)
+	if !b.Core.asyncOperations.cancel(ns.ID, id) {
+		return logical.ErrorResponse("operation %q not found", id), logical.ErrInvalidRequest
+	}
+	return nil, nil
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/hashicorp/vault/helper/secretimport"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 )
@@ -117,6 +118,40 @@ func (b *SystemBackend) configPaths() []*framework.Path {
 			},
 		},
 
+		{
+			Pattern: "config/state/drift$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleConfigStateDrift,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "configuration-drift",
+					},
+					Summary:     "Return the changes between the configuration files and the configuration the server was started with.",
+					Description: "The changes of the listener, seal and telemetry stanzas found by the last reload, with whether they were applied by the reload or require a restart of the server. Seal configuration values are omitted, since they may contain sensitive values.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"time": {
+									Type:     framework.TypeTime,
+									Required: true,
+								},
+								"changes": {
+									Type:     framework.TypeSlice,
+									Required: true,
+								},
+								"restart_required": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+		},
+
 		{
 			Pattern: "config/reload/(?P<subsystem>.+)",
 			Fields: map[string]*framework.FieldSchema{
@@ -1422,6 +1457,36 @@ func (b *SystemBackend) statusPaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysHelp["seal-status"][0]),
 			HelpDescription: strings.TrimSpace(sysHelp["seal-status"][1]),
 		},
+		{
+			Pattern: "seal-status/history$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "seal",
+				OperationVerb:   "read",
+				OperationSuffix: "status-history",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleSealStatusHistory,
+					Summary:  "Read the history of seal status transitions.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"events": {
+									Type:     framework.TypeSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["seal-status-history"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["seal-status-history"][1]),
+		},
 		{
 			Pattern: "ha-status$",
 
@@ -1529,9 +1594,65 @@ func (b *SystemBackend) auditHashPath() *framework.Path {
 	}
 }
 
+func (b *SystemBackend) auditHashKeyPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "audit-hash-key/(?P<path>.+)",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: "auditing",
+			OperationSuffix: "hash-key",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"path": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["audit_path"][0]),
+			},
+
+			"key": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["audit_hash_key"][0]),
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.handleAuditHashKeyUpdate,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "import",
+				},
+				Summary: "Import the key with which the audit device hashes sensitive values.",
+				Responses: map[int][]framework.Response{
+					http.StatusNoContent: {{
+						Description: "OK",
+					}},
+				},
+				ForwardPerformanceStandby: true,
+			},
+			logical.DeleteOperation: &framework.PathOperation{
+				Callback: b.handleAuditHashKeyDelete,
+				DisplayAttrs: &framework.DisplayAttributes{
+					OperationVerb: "reset",
+				},
+				Summary: "Revert the audit device to hashing sensitive values with a new random salt.",
+				Responses: map[int][]framework.Response{
+					http.StatusNoContent: {{
+						Description: "OK",
+					}},
+				},
+				ForwardPerformanceStandby: true,
+			},
+		},
+
+		HelpSynopsis:    strings.TrimSpace(sysHelp["audit-hash-key"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["audit-hash-key"][1]),
+	}
+}
+
 func (b *SystemBackend) auditPaths() []*framework.Path {
 	return []*framework.Path{
 		b.auditHashPath(),
+		b.auditHashKeyPath(),
 
 		{
 			Pattern: "audit$",
@@ -1886,6 +2007,26 @@ func (b *SystemBackend) pluginsCatalogCRUDPath() *framework.Path {
 				Type:        framework.TypeString,
 				Description: strings.TrimSpace(sysHelp["plugin-catalog_version"][0]),
 			},
+			"cosign_public_key": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_cosign_public_key"][0]),
+			},
+			"cosign_signature": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_cosign_signature"][0]),
+			},
+			"cosign_bundle": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_cosign_bundle"][0]),
+			},
+			"cosign_certificate_identity": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_cosign_certificate_identity"][0]),
+			},
+			"cosign_certificate_oidc_issuer": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["plugin-catalog_cosign_certificate_oidc_issuer"][0]),
+			},
 		},
 
 		Operations: map[logical.Operation]framework.OperationHandler{
@@ -1967,6 +2108,10 @@ func (b *SystemBackend) pluginsCatalogCRUDPath() *framework.Path {
 								Type:     framework.TypeString,
 								Required: false,
 							},
+							"cosign": {
+								Type:        framework.TypeMap,
+								Description: strings.TrimSpace(sysHelp["plugin-catalog_cosign"][0]),
+							},
 						},
 					}},
 				},
@@ -2291,7 +2436,7 @@ func (b *SystemBackend) pluginsRootReloadPath() *framework.Path {
 
 func (b *SystemBackend) pluginsRuntimesCatalogCRUDPath() *framework.Path {
 	return &framework.Path{
-		Pattern: "plugins/runtimes/catalog/(?P<type>container)/" + framework.GenericNameRegex("name"),
+		Pattern: "plugins/runtimes/catalog/(?P<type>container|process)/" + framework.GenericNameRegex("name"),
 
 		DisplayAttrs: &framework.DisplayAttributes{
 			OperationPrefix: "plugins-runtimes-catalog",
@@ -2326,6 +2471,14 @@ func (b *SystemBackend) pluginsRuntimesCatalogCRUDPath() *framework.Path {
 				Type:        framework.TypeBool,
 				Description: strings.TrimSpace(sysHelp["plugin-runtime-catalog_rootless"][0]),
 			},
+			"max_restarts": {
+				Type:        framework.TypeInt64,
+				Description: strings.TrimSpace(sysHelp["plugin-runtime-catalog_max-restarts"][0]),
+			},
+			"restart_window": {
+				Type:        framework.TypeDurationSecond,
+				Description: strings.TrimSpace(sysHelp["plugin-runtime-catalog_restart-window"][0]),
+			},
 		},
 
 		Operations: map[logical.Operation]framework.OperationHandler{
@@ -2400,6 +2553,16 @@ func (b *SystemBackend) pluginsRuntimesCatalogCRUDPath() *framework.Path {
 								Description: strings.TrimSpace(sysHelp["plugin-runtime-catalog_rootless"][0]),
 								Required:    true,
 							},
+							"max_restarts": {
+								Type:        framework.TypeInt64,
+								Description: strings.TrimSpace(sysHelp["plugin-runtime-catalog_max-restarts"][0]),
+								Required:    true,
+							},
+							"restart_window": {
+								Type:        framework.TypeDurationSecond,
+								Description: strings.TrimSpace(sysHelp["plugin-runtime-catalog_restart-window"][0]),
+								Required:    true,
+							},
 						},
 					}},
 				},
@@ -2991,6 +3154,60 @@ func (b *SystemBackend) internalPaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysHelp["internal-counters-entities"][0]),
 			HelpDescription: strings.TrimSpace(sysHelp["internal-counters-entities"][1]),
 		},
+		{
+			Pattern: "internal/counters/secrets",
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal",
+				OperationVerb:   "count",
+				OperationSuffix: "secrets",
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathInternalCountersSecrets,
+					Summary:  "Backwards compatibility is not guaranteed for this API",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"counters": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(sysHelp["internal-counters-secrets"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["internal-counters-secrets"][1]),
+		},
+		{
+			Pattern: "internal/counters/credentials",
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "internal",
+				OperationVerb:   "count",
+				OperationSuffix: "credentials",
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.pathInternalCountersCredentials,
+					Summary:  "Backwards compatibility is not guaranteed for this API",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"counters": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(sysHelp["internal-counters-credentials"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["internal-counters-credentials"][1]),
+		},
 	}
 }
 
@@ -3417,6 +3634,15 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 							Description: "OK",
 							Fields:      map[string]*framework.FieldSchema{},
 						}},
+						http.StatusAccepted: {{
+							Description: "Accepted",
+							Fields: map[string]*framework.FieldSchema{
+								"operation_id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
 					},
 				},
 			},
@@ -3475,145 +3701,318 @@ func (b *SystemBackend) leasePaths() []*framework.Path {
 		},
 
 		{
-			Pattern: "leases$",
+			Pattern: "leases/queue$",
 
 			DisplayAttrs: &framework.DisplayAttributes{
 				OperationPrefix: "leases",
-				OperationVerb:   "list",
+				OperationVerb:   "read",
+				OperationSuffix: "queue",
 			},
 
 			Fields: map[string]*framework.FieldSchema{
-				"type": {
-					Type:        framework.TypeString,
-					Required:    true,
-					Description: "Type of leases to retrieve (currently only supporting irrevocable).",
-				},
 				"include_child_namespaces": {
 					Type:        framework.TypeBool,
 					Default:     false,
-					Description: "Set true if you want leases for this namespace and its children.",
-				},
-				"limit": {
-					Type:        framework.TypeString,
-					Default:     "",
-					Description: "Set to a positive integer of the maximum number of entries to return. If you want all results, set to 'none'. If not set, you will get a maximum of 10,000 results returned.",
+					Description: "Set true if you want the queue state for this namespace and its children.",
 				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
-					// currently only works for irrevocable leases with param: type=irrevocable
-					Callback: b.handleLeaseList,
+					Callback: b.handleLeaseQueue,
 					Responses: map[int][]framework.Response{
 						http.StatusOK: {{
 							Description: "OK",
 							Fields: map[string]*framework.FieldSchema{
-								"lease_count": {
+								"pending_count": {
 									Type:        framework.TypeInt,
-									Description: "Number of matching leases",
+									Description: "Number of leases with a pending expiration",
 									Required:    true,
 								},
-								"counts": {
+								"retrying_count": {
 									Type:        framework.TypeInt,
-									Description: "Number of matching leases per mount",
+									Description: "Number of pending leases that have failed at least one revocation attempt",
 									Required:    true,
 								},
+								"non_expiring_count": {
+									Type:        framework.TypeInt,
+									Description: "Number of leases without an expiration, such as root tokens",
+									Required:    true,
+								},
+								"irrevocable_count": {
+									Type:        framework.TypeInt,
+									Description: "Number of leases marked as irrevocable",
+									Required:    true,
+								},
+								"expiring_within": {
+									Type:        framework.TypeMap,
+									Description: "Number of pending leases by time until expiration",
+									Required:    true,
+								},
+								"revocation_queue_length": {
+									Type:        framework.TypeInt,
+									Description: "Number of expired leases waiting for a revocation worker (root namespace only)",
+								},
+								"revocation_queue_lengths": {
+									Type:        framework.TypeMap,
+									Description: "Number of expired leases waiting for a revocation worker per mount accessor (root namespace only)",
+								},
+								"revocation_worker_counts": {
+									Type:        framework.TypeMap,
+									Description: "Number of active revocation workers per mount accessor (root namespace only)",
+								},
 							},
 						}},
 					},
 				},
 			},
 
-			HelpSynopsis:    strings.TrimSpace(sysHelp["list-leases"][0]),
-			HelpDescription: strings.TrimSpace(sysHelp["list-leases"][1]),
+			HelpSynopsis:    strings.TrimSpace(sysHelp["lease-queue"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["lease-queue"][1]),
 		},
-	}
-}
 
-func (b *SystemBackend) remountPaths() []*framework.Path {
-	return []*framework.Path{
 		{
-			Pattern: "remount",
+			Pattern: "leases/recommendations$",
 
 			DisplayAttrs: &framework.DisplayAttributes{
-				OperationVerb: "remount",
+				OperationPrefix: "leases",
+				OperationVerb:   "read",
+				OperationSuffix: "recommendations",
 			},
 
 			Fields: map[string]*framework.FieldSchema{
-				"from": {
-					Type:        framework.TypeString,
-					Description: "The previous mount point.",
+				"include_child_namespaces": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: "Set true if you want recommendations for this namespace and its children.",
 				},
-				"to": {
-					Type:        framework.TypeString,
-					Description: "The new mount point.",
+				"percentile": {
+					Type:        framework.TypeFloat,
+					Default:     95.0,
+					Description: "Percentile of the leases of a role whose lifetime the recommended TTL covers, between 50 and 100.",
+				},
+				"min_leases": {
+					Type:        framework.TypeInt,
+					Default:     20,
+					Description: "Minimum number of leases of a role which ended for recommendations to be made for it.",
 				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
-				logical.UpdateOperation: &framework.PathOperation{
-					Callback: b.handleRemount,
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleLeaseRecommendations,
 					Responses: map[int][]framework.Response{
 						http.StatusOK: {{
 							Description: "OK",
 							Fields: map[string]*framework.FieldSchema{
-								"migration_id": {
-									Type:     framework.TypeString,
-									Required: true,
+								"percentile": {
+									Type:        framework.TypeFloat,
+									Description: "Percentile of the leases of a role whose lifetime the recommended TTL covers",
+									Required:    true,
+								},
+								"recommendations": {
+									Type:        framework.TypeSlice,
+									Description: "Lease usage and recommended TTLs per mount and role",
+									Required:    true,
 								},
 							},
 						}},
 					},
-					Summary: "Initiate a mount migration",
 				},
 			},
-			HelpSynopsis:    strings.TrimSpace(sysHelp["remount"][0]),
-			HelpDescription: strings.TrimSpace(sysHelp["remount"][1]),
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["lease-recommendations"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["lease-recommendations"][1]),
 		},
+
 		{
-			Pattern: "remount/status/(?P<migration_id>.+?)$",
+			Pattern: "leases/revocation-failures$",
 
 			DisplayAttrs: &framework.DisplayAttributes{
-				OperationPrefix: "remount",
-				OperationVerb:   "status",
+				OperationPrefix: "leases",
+				OperationVerb:   "read",
+				OperationSuffix: "revocation-failures",
 			},
 
 			Fields: map[string]*framework.FieldSchema{
-				"migration_id": {
+				"mount": {
 					Type:        framework.TypeString,
-					Description: "The ID of the migration operation",
+					Description: "Path of the mount to report on. Defaults to all mounts.",
+				},
+				"include_child_namespaces": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: "Set true if you want revocation failures for this namespace and its children.",
 				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
-					Callback: b.handleRemountStatusCheck,
+					Callback: b.handleLeaseRevocationFailures,
 					Responses: map[int][]framework.Response{
 						http.StatusOK: {{
 							Description: "OK",
 							Fields: map[string]*framework.FieldSchema{
-								"migration_id": {
-									Type:     framework.TypeString,
-									Required: true,
+								"lease_count": {
+									Type:        framework.TypeInt,
+									Description: "Number of leases with failed revocation attempts",
+									Required:    true,
 								},
-								"migration_info": {
-									Type:     framework.TypeMap,
-									Required: true,
+								"mounts": {
+									Type:        framework.TypeSlice,
+									Description: "Failed revocation attempts per mount and category of their cause",
+									Required:    true,
 								},
 							},
 						}},
 					},
-					Summary: "Check status of a mount migration",
 				},
 			},
-			HelpSynopsis:    strings.TrimSpace(sysHelp["remount-status"][0]),
-			HelpDescription: strings.TrimSpace(sysHelp["remount-status"][1]),
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["lease-revocation-failures"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["lease-revocation-failures"][1]),
 		},
-	}
-}
 
-func (b *SystemBackend) metricsPath() *framework.Path {
-	return &framework.Path{
+		{
+			Pattern: "leases$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "leases",
+				OperationVerb:   "list",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"type": {
+					Type:        framework.TypeString,
+					Required:    true,
+					Description: "Type of leases to retrieve (currently only supporting irrevocable).",
+				},
+				"include_child_namespaces": {
+					Type:        framework.TypeBool,
+					Default:     false,
+					Description: "Set true if you want leases for this namespace and its children.",
+				},
+				"limit": {
+					Type:        framework.TypeString,
+					Default:     "",
+					Description: "Set to a positive integer of the maximum number of entries to return. If you want all results, set to 'none'. If not set, you will get a maximum of 10,000 results returned.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					// currently only works for irrevocable leases with param: type=irrevocable
+					Callback: b.handleLeaseList,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"lease_count": {
+									Type:        framework.TypeInt,
+									Description: "Number of matching leases",
+									Required:    true,
+								},
+								"counts": {
+									Type:        framework.TypeInt,
+									Description: "Number of matching leases per mount",
+									Required:    true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["list-leases"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["list-leases"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) remountPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "remount",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationVerb: "remount",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"from": {
+					Type:        framework.TypeString,
+					Description: "The previous mount point.",
+				},
+				"to": {
+					Type:        framework.TypeString,
+					Description: "The new mount point.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleRemount,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"migration_id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Initiate a mount migration",
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(sysHelp["remount"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["remount"][1]),
+		},
+		{
+			Pattern: "remount/status/(?P<migration_id>.+?)$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "remount",
+				OperationVerb:   "status",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"migration_id": {
+					Type:        framework.TypeString,
+					Description: "The ID of the migration operation",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleRemountStatusCheck,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"migration_id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"migration_info": {
+									Type:     framework.TypeMap,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Check status of a mount migration",
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(sysHelp["remount-status"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["remount-status"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) metricsPath() *framework.Path {
+	return &framework.Path{
 		Pattern: "metrics",
 
 		DisplayAttrs: &framework.DisplayAttributes{
@@ -3703,6 +4102,31 @@ func (b *SystemBackend) inFlightRequestPath() *framework.Path {
 	}
 }
 
+func (b *SystemBackend) discoveryPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "discovery$",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationVerb:   "read",
+			OperationSuffix: "discovery-document",
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.ReadOperation: &framework.PathOperation{
+				Callback:    b.handleDiscoveryRead,
+				Summary:     strings.TrimSpace(sysHelp["discovery"][0]),
+				Description: strings.TrimSpace(sysHelp["discovery"][1]),
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields:      nil, // raw JSON body
+					}},
+				},
+			},
+		},
+	}
+}
+
 func (b *SystemBackend) hostInfoPath() *framework.Path {
 	return &framework.Path{
 		Pattern: "host-info/?",
@@ -3837,6 +4261,10 @@ func (b *SystemBackend) authPaths() []*framework.Path {
 					Type:        framework.TypeMap,
 					Description: strings.TrimSpace(sysHelp["tune_user_lockout_config"][0]),
 				},
+				"plugin_upgrade_window": {
+					Type:        framework.TypeMap,
+					Description: strings.TrimSpace(sysHelp["tune_plugin_upgrade_window"][0]),
+				},
 				"plugin_version": {
 					Type:        framework.TypeString,
 					Description: strings.TrimSpace(sysHelp["plugin-catalog_version"][0]),
@@ -3932,6 +4360,10 @@ func (b *SystemBackend) authPaths() []*framework.Path {
 									Type:     framework.TypeString,
 									Required: false,
 								},
+								"plugin_upgrade_window": {
+									Type:     framework.TypeMap,
+									Required: false,
+								},
 								"identity_token_key": {
 									Type:     framework.TypeString,
 									Required: false,
@@ -4346,6 +4778,41 @@ func (b *SystemBackend) policyPaths() []*framework.Path {
 			HelpDescription: strings.TrimSpace(sysHelp["policy"][1]),
 		},
 
+		{
+			Pattern: "policies/templates/monitoring$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "policies",
+				OperationVerb:   "generate",
+				OperationSuffix: "monitoring-policy",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handlePoliciesMonitoringTemplate,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"policy": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Generate the policy granting read access to the observability endpoints.",
+				},
+			},
+
+			HelpSynopsis:    "Generate the policy granting read access to the observability endpoints.",
+			HelpDescription: "Generate the text of the vault-monitoring policy, which grants read access to the observability endpoints of this version of Vault. The policy is not written; write it to sys/policies/acl to create or update it.",
+		},
+
 		{
 			Pattern: "policies/password/?$",
 
@@ -4400,6 +4867,59 @@ func (b *SystemBackend) policyPaths() []*framework.Path {
 			HelpDescription: "Generate a password from an existing password policy.",
 		},
 
+		{
+			Pattern: "policies/password/(?P<name>.+)/test$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "policies",
+				OperationVerb:   "test",
+				OperationSuffix: "password-policy",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "The name of the password policy.",
+				},
+				"policy": {
+					Type:        framework.TypeString,
+					Description: "An optional password policy to test instead of the stored policy.",
+				},
+				"count": {
+					Type:        framework.TypeInt,
+					Description: "The number of sample passwords to generate.",
+					Default:     5,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handlePoliciesPasswordTest,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"samples": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"entropy_bits": {
+									Type:     framework.TypeFloat,
+									Required: true,
+								},
+							},
+						}},
+					},
+					Summary: "Generate sample passwords from a password policy.",
+				},
+			},
+
+			HelpSynopsis: "Generate sample passwords from a password policy.",
+			HelpDescription: "Validate a password policy and generate sample passwords from it. If a policy " +
+				"is provided it is tested in place of the stored policy, allowing changes to be previewed before " +
+				"they are saved.",
+		},
+
 		{
 			Pattern: "policies/password/(?P<name>.+)$",
 
@@ -4563,6 +5083,14 @@ func (b *SystemBackend) wrappingPaths() []*framework.Path {
 									Type:     framework.TypeString,
 									Required: false,
 								},
+								"bound_cidrs": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: false,
+								},
+								"not_before": {
+									Type:     framework.TypeTime,
+									Required: false,
+								},
 							},
 						}},
 					},
@@ -4590,6 +5118,14 @@ func (b *SystemBackend) wrappingPaths() []*framework.Path {
 									Type:     framework.TypeString,
 									Required: false,
 								},
+								"bound_cidrs": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: false,
+								},
+								"not_before": {
+									Type:     framework.TypeTime,
+									Required: false,
+								},
 							},
 						}},
 					},
@@ -4702,6 +5238,10 @@ func (b *SystemBackend) mountPaths() []*framework.Path {
 					Type:        framework.TypeMap,
 					Description: strings.TrimSpace(sysHelp["tune_user_lockout_config"][0]),
 				},
+				"plugin_upgrade_window": {
+					Type:        framework.TypeMap,
+					Description: strings.TrimSpace(sysHelp["tune_plugin_upgrade_window"][0]),
+				},
 				"identity_token_key": {
 					Type:        framework.TypeString,
 					Description: strings.TrimSpace(sysHelp["identity_token_key"][0]),
@@ -4768,6 +5308,10 @@ func (b *SystemBackend) mountPaths() []*framework.Path {
 									Description: strings.TrimSpace(sysHelp["plugin-catalog_version"][0]),
 									Required:    false,
 								},
+								"plugin_upgrade_window": {
+									Type:     framework.TypeMap,
+									Required: false,
+								},
 								"external_entropy_access": {
 									Type:     framework.TypeBool,
 									Required: false,
@@ -5096,89 +5640,334 @@ func (b *SystemBackend) lockedUserPaths() []*framework.Path {
 	}
 }
 
-func (b *SystemBackend) eventPaths() []*framework.Path {
+func (b *SystemBackend) configBundlePaths() []*framework.Path {
 	return []*framework.Path{
 		{
-			Pattern: "events/subscriptions$",
+			Pattern: "config/bundle/export$",
 
 			DisplayAttrs: &framework.DisplayAttributes{
-				OperationPrefix: "subscriptions",
-				OperationVerb:   "create",
+				OperationPrefix: "config-bundle",
 			},
 
 			Fields: map[string]*framework.FieldSchema{
-				"config": {
-					Type:     framework.TypeMap,
-					Required: true,
-					// Description: strings.TrimSpace(sysHelp["mount_accessor"][0]),
+				"mounts": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["config_bundle_mounts"][0]),
+					Required:    true,
 				},
-				"plugin": {
-					Type:     framework.TypeString,
-					Required: true,
+				"resources": {
+					Type:        framework.TypeMap,
+					Description: strings.TrimSpace(sysHelp["config_bundle_resources"][0]),
+				},
+				"policies": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["config_bundle_policies"][0]),
 				},
-				//"alias_identifier": {
-				//	Type: framework.TypeString,
-				//	// Description: strings.TrimSpace(sysHelp["alias_identifier"][0]),
-				//},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.UpdateOperation: &framework.PathOperation{
-					Callback: b.handleEventsSubscribe,
-					Summary:  "",
+					Callback: b.handleConfigBundleExport,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "export",
+					},
+					Summary: "Export the configuration of mounts as a signed bundle.",
 					Responses: map[int][]framework.Response{
 						http.StatusOK: {{
 							Description: "OK",
 							Fields: map[string]*framework.FieldSchema{
-								"id": {
+								"bundle": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"signature": {
 									Type:     framework.TypeString,
 									Required: true,
 								},
 							},
 						}},
 					},
+					ForwardPerformanceStandby: true,
 				},
 			},
-			// HelpSynopsis:    strings.TrimSpace(sysHelp["unlock_user"][0]),
-			// HelpDescription: strings.TrimSpace(sysHelp["unlock_user"][1]),
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config-bundle-export"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["config-bundle-export"][1]),
 		},
 		{
-			Pattern: "events/subscriptions/(?P<plugin>.+)/(?P<id>.+)",
+			Pattern: "config/bundle/import$",
 
 			DisplayAttrs: &framework.DisplayAttributes{
-				OperationPrefix: "subscriptions",
-				OperationVerb:   "create",
+				OperationPrefix: "config-bundle",
 			},
 
 			Fields: map[string]*framework.FieldSchema{
-				"plugin": {
-					Type: framework.TypeString,
+				"bundle": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["config_bundle"][0]),
+					Required:    true,
+				},
+				"signature": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["config_bundle_signature"][0]),
+					Required:    true,
+				},
+				"public_key": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["config_bundle_public_key"][0]),
+				},
+				"dry_run": {
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["config_bundle_dry_run"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleConfigBundleImport,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "import",
+					},
+					Summary: "Apply a signed configuration bundle.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"dry_run": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"changes": {
+									Type:     framework.TypeSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+					ForwardPerformanceStandby: true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config-bundle-import"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["config-bundle-import"][1]),
+		},
+		{
+			Pattern: "config/bundle/public-key$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "config-bundle",
+				OperationSuffix: "public-key",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleConfigBundlePublicKeyRead,
+					Summary:  "Read the public key verifying the bundles exported by this cluster.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"public_key": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+					ForwardPerformanceStandby: true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["config-bundle-public-key"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["config-bundle-public-key"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) rotationPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "rotation/jobs/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "rotation",
+				OperationSuffix: "jobs",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleRotationJobsList,
+					Summary:  "List the root credentials registered for scheduled rotation in this namespace.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type: framework.TypeStringSlice,
+								},
+								"key_info": {
+									Type: framework.TypeMap,
+								},
+							},
+						}},
+					},
 				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["rotation-jobs"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["rotation-jobs"][1]),
+		},
+		{
+			Pattern: "rotation/jobs/" + framework.GenericNameRegex("id"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "rotation",
+				OperationSuffix: "job",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
 				"id": {
-					Type: framework.TypeString,
-					// Description: strings.TrimSpace(sysHelp["mount_accessor"][0]),
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["rotation-job-id"][0]),
 				},
-				"list": {
-					Type: framework.TypeBool,
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleRotationJobRead,
+					Summary:  "Read the schedule and rotation history of a root credential.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"mount_accessor": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"path": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"rotation_schedule": {
+									Type: framework.TypeString,
+								},
+								"rotation_window": {
+									Type: framework.TypeDurationSecond,
+								},
+								"rotation_period": {
+									Type: framework.TypeDurationSecond,
+								},
+								"rotation_jitter": {
+									Type: framework.TypeDurationSecond,
+								},
+								"rotation_calendar": {
+									Type: framework.TypeString,
+								},
+								"next_rotation": {
+									Type:     framework.TypeTime,
+									Required: true,
+								},
+								"last_rotation": {
+									Type: framework.TypeTime,
+								},
+								"history": {
+									Type: framework.TypeSlice,
+								},
+							},
+						}},
+					},
 				},
-				//"alias_identifier": {
-				//	Type: framework.TypeString,
-				//	// Description: strings.TrimSpace(sysHelp["alias_identifier"][0]),
-				//},
 			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["rotation-job"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["rotation-job"][1]),
+		},
+		{
+			Pattern: "rotation/calendars/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "rotation",
+				OperationSuffix: "calendars",
+			},
+
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ListOperation: &framework.PathOperation{
-					Callback: b.handleEventsListSubscriptions,
-					Summary:  "",
+					Callback: b.handleRotationCalendarsList,
+					Summary:  "List the rotation calendars.",
 					Responses: map[int][]framework.Response{
-						http.StatusNoContent: {{
+						http.StatusOK: {{
 							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type: framework.TypeStringSlice,
+								},
+							},
 						}},
 					},
 				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["rotation-calendars"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["rotation-calendars"][1]),
+		},
+		{
+			Pattern: "rotation/calendars/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "rotation",
+				OperationSuffix: "calendar",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["rotation-calendar-name"][0]),
+				},
+				"freeze_dates": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["rotation-calendar-freeze-dates"][0]),
+				},
+				"timezone": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["rotation-calendar-timezone"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
-					Callback: b.handleEventsListSubscriptions,
-					Summary:  "",
+					Callback: b.handleRotationCalendarRead,
+					Summary:  "Read the freeze dates of a rotation calendar.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"timezone": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"freeze_dates": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleRotationCalendarWrite,
+					Summary:  "Create or replace a rotation calendar.",
 					Responses: map[int][]framework.Response{
 						http.StatusNoContent: {{
 							Description: "OK",
@@ -5186,8 +5975,8 @@ func (b *SystemBackend) eventPaths() []*framework.Path {
 					},
 				},
 				logical.DeleteOperation: &framework.PathOperation{
-					Callback: b.handleEventsUnsubscribe,
-					Summary:  "",
+					Callback: b.handleRotationCalendarDelete,
+					Summary:  "Delete a rotation calendar.",
 					Responses: map[int][]framework.Response{
 						http.StatusNoContent: {{
 							Description: "OK",
@@ -5195,8 +5984,678 @@ func (b *SystemBackend) eventPaths() []*framework.Path {
 					},
 				},
 			},
-			// HelpSynopsis:    strings.TrimSpace(sysHelp["unlock_user"][0]),
-			// HelpDescription: strings.TrimSpace(sysHelp["unlock_user"][1]),
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["rotation-calendar"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["rotation-calendar"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) eventPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "events/subscriptions$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "subscriptions",
+				OperationVerb:   "create",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"config": {
+					Type:     framework.TypeMap,
+					Required: true,
+					// Description: strings.TrimSpace(sysHelp["mount_accessor"][0]),
+				},
+				"plugin": {
+					Type:     framework.TypeString,
+					Required: true,
+				},
+				//"alias_identifier": {
+				//	Type: framework.TypeString,
+				//	// Description: strings.TrimSpace(sysHelp["alias_identifier"][0]),
+				//},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleEventsSubscribe,
+					Summary:  "",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+			// HelpSynopsis:    strings.TrimSpace(sysHelp["unlock_user"][0]),
+			// HelpDescription: strings.TrimSpace(sysHelp["unlock_user"][1]),
+		},
+		{
+			Pattern: "events/subscriptions/(?P<plugin>.+)/(?P<id>.+)",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "subscriptions",
+				OperationVerb:   "create",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"plugin": {
+					Type: framework.TypeString,
+				},
+				"id": {
+					Type: framework.TypeString,
+					// Description: strings.TrimSpace(sysHelp["mount_accessor"][0]),
+				},
+				"list": {
+					Type: framework.TypeBool,
+				},
+				//"alias_identifier": {
+				//	Type: framework.TypeString,
+				//	// Description: strings.TrimSpace(sysHelp["alias_identifier"][0]),
+				//},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleEventsListSubscriptions,
+					Summary:  "",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleEventsListSubscriptions,
+					Summary:  "",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleEventsUnsubscribe,
+					Summary:  "",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+			// HelpSynopsis:    strings.TrimSpace(sysHelp["unlock_user"][0]),
+			// HelpDescription: strings.TrimSpace(sysHelp["unlock_user"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) controlGroupPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "control-group/authorize$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "control-group",
+				OperationVerb:   "authorize",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"accessor": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["control-group-accessor"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleControlGroupAuthorize,
+					Summary:  "Authorize a control group request.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"approved": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["control-group-authorize"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["control-group-authorize"][1]),
+		},
+		{
+			Pattern: "control-group/request$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "control-group",
+				OperationVerb:   "read",
+				OperationSuffix: "request",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"accessor": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["control-group-accessor"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleControlGroupRequest,
+					Summary:  "Check the status of a control group request.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"approved": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"request_path": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"request_time": {
+									Type:     framework.TypeTime,
+									Required: true,
+								},
+								"request_entity": {
+									Type: framework.TypeMap,
+								},
+								"authorizations": {
+									Type:     framework.TypeSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["control-group-request"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["control-group-request"][1]),
+		},
+		{
+			Pattern: "config/control-group$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "control-group",
+				OperationSuffix: "configuration",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"max_ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: strings.TrimSpace(sysHelp["control-group-max-ttl"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleControlGroupConfigRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+					Summary: "Read the control group configuration.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"max_ttl": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleControlGroupConfigUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Summary: "Configure control groups.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleControlGroupConfigDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+					Summary: "Remove the control group configuration.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["control-group-config"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["control-group-config"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) mountTrashPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "mounts-trash/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mount-trash",
+				OperationSuffix: "mounts",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleMountTrashList,
+					Summary:  "List the disabled secrets engines retained in the trash in this namespace.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type: framework.TypeStringSlice,
+								},
+								"key_info": {
+									Type: framework.TypeMap,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mounts-trash"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mounts-trash"][1]),
+		},
+		{
+			Pattern: "mounts-trash/" + framework.GenericNameRegex("accessor") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mount-trash",
+				OperationSuffix: "mount",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"accessor": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mounts-trash-accessor"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleMountTrashRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+					Summary: "Read a disabled secrets engine retained in the trash.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"accessor": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"path": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"type": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"description": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"local": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"plugin_version": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"deleted_time": {
+									Type:     framework.TypeTime,
+									Required: true,
+								},
+								"purge_time": {
+									Type:     framework.TypeTime,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleMountTrashPurge,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "purge",
+					},
+					Summary: "Purge the storage and leases of a disabled secrets engine retained in the trash.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mounts-trash-mount"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mounts-trash-mount"][1]),
+		},
+		{
+			Pattern: "mounts-trash/" + framework.GenericNameRegex("accessor") + "/restore$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mount-trash",
+				OperationVerb:   "restore",
+				OperationSuffix: "mount",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"accessor": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["mounts-trash-accessor"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleMountTrashRestore,
+					Summary:  "Enable a disabled secrets engine retained in the trash again at its original path.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"accessor": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"path": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mounts-trash-restore"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mounts-trash-restore"][1]),
+		},
+		{
+			Pattern: "config/mount-trash$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "mount-trash",
+				OperationSuffix: "configuration",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"retention_period": {
+					Type:        framework.TypeDurationSecond,
+					Description: strings.TrimSpace(sysHelp["mounts-trash-retention-period"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleMountTrashConfigRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+					Summary: "Read the mount trash configuration.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"retention_period": {
+									Type:     framework.TypeDurationSecond,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleMountTrashConfigUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+					Summary: "Configure the retention of disabled secrets engines.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleMountTrashConfigDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+					Summary: "Remove the mount trash configuration.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["mounts-trash-config"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["mounts-trash-config"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) operationPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "operations/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "operations",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleOperationsList,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "list",
+					},
+					Summary: "List the long-running operations of this namespace.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"keys": {
+									Type: framework.TypeStringSlice,
+								},
+								"key_info": {
+									Type: framework.TypeMap,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["operations"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["operations"][1]),
+		},
+		{
+			Pattern: "operations/" + framework.GenericNameRegex("id") + "$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "operations",
+				OperationSuffix: "operation",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"id": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["operations-id-field"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleOperationRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+					Summary: "Read the status and progress of a long-running operation.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"id": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"type": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"status": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"start_time": {
+									Type:     framework.TypeTime,
+									Required: true,
+								},
+								"end_time": {
+									Type: framework.TypeTime,
+								},
+								"completed": {
+									Type:     framework.TypeInt64,
+									Required: true,
+								},
+								"total": {
+									Type:     framework.TypeInt64,
+									Required: true,
+								},
+								"error": {
+									Type: framework.TypeString,
+								},
+							},
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleOperationCancel,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "cancel",
+					},
+					Summary: "Cancel a long-running operation.",
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["operations-id"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["operations-id"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) importPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "import$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "secrets",
+				OperationVerb:   "import",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"source": {
+					Type:          framework.TypeString,
+					Description:   strings.TrimSpace(sysHelp["import-source"][0]),
+					AllowedValues: []interface{}{secretimport.SourceAWS, secretimport.SourceAzure, secretimport.SourceGCP},
+					Required:      true,
+				},
+				"config": {
+					Type:        framework.TypeKVPairs,
+					Description: strings.TrimSpace(sysHelp["import-config"][0]),
+				},
+				"mount": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["import-mount"][0]),
+					Required:    true,
+				},
+				"prefix": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["import-prefix"][0]),
+				},
+				"secrets": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["import-secrets"][0]),
+				},
+				"dry_run": {
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["import-dry-run"][0]),
+				},
+				"overwrite": {
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["import-overwrite"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleImport,
+					Summary:  "Import secrets from an external secret manager into a KV version 2 secrets engine.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"dry_run": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"results": {
+									Type:     framework.TypeSlice,
+									Required: true,
+								},
+							},
+						}},
+					},
+					ForwardPerformanceSecondary: true,
+					ForwardPerformanceStandby:   true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["import"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["import"][1]),
 		},
 	}
 }
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -161,6 +162,41 @@ The 'rate' must be positive.`,
 					Description: `If set, when a client reaches a rate limit threshold, the client will be prohibited
 from any further requests until after the 'block_interval' has elapsed.`,
 				},
+				"group_by": {
+					Type: framework.TypeString,
+					Description: `How requests are grouped into clients, each of which is rate limited separately.
+One of 'ip' (default), 'none', 'entity_then_ip' or 'entity_then_none'. The entity
+options group requests by the identity entity of the client token, falling back
+to the client IP address or a single shared group respectively for requests
+without an entity.`,
+				},
+				"adaptive_target_latency": {
+					Type: framework.TypeString,
+					Description: `If set, enables adaptive rate limiting. The effective rate is decreased when the
+p99 latency of allowed requests exceeds this duration (e.g. '250ms'), or when
+their error rate exceeds 'adaptive_max_error_rate', and is gradually increased
+back towards 'rate' otherwise.`,
+				},
+				"adaptive_max_error_rate": {
+					Type: framework.TypeFloat,
+					Description: `The fraction of allowed requests failing with a server error above which the
+effective rate is decreased (default 0.05). Only used with adaptive rate limiting.`,
+				},
+				"adaptive_min_rate": {
+					Type: framework.TypeFloat,
+					Description: `The lowest effective rate adaptive rate limiting may decrease the rate to (default
+10% of 'rate'). Only used with adaptive rate limiting.`,
+				},
+				"exempt_policies": {
+					Type: framework.TypeCommaStringSlice,
+					Description: `Requests whose client token carries any of these policies, either directly or
+through its identity, are exempt from the quota.`,
+				},
+				"exempt_entity_metadata": {
+					Type: framework.TypeKVPairs,
+					Description: `Requests whose client token belongs to an identity entity having all of these
+metadata key/value pairs are exempt from the quota.`,
+				},
 			},
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.UpdateOperation: &framework.PathOperation{
@@ -211,6 +247,34 @@ from any further requests until after the 'block_interval' has elapsed.`,
 									Type:     framework.TypeInt,
 									Required: true,
 								},
+								"group_by": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"adaptive_target_latency": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"adaptive_max_error_rate": {
+									Type:     framework.TypeFloat,
+									Required: true,
+								},
+								"adaptive_min_rate": {
+									Type:     framework.TypeFloat,
+									Required: true,
+								},
+								"effective_rate": {
+									Type:     framework.TypeFloat,
+									Required: true,
+								},
+								"exempt_policies": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: true,
+								},
+								"exempt_entity_metadata": {
+									Type:     framework.TypeKVPairs,
+									Required: true,
+								},
 								"inheritable": {
 									Type:     framework.TypeBool,
 									Required: true,
@@ -234,6 +298,117 @@ from any further requests until after the 'block_interval' has elapsed.`,
 			HelpSynopsis:    strings.TrimSpace(quotasHelp["rate-limit"][0]),
 			HelpDescription: strings.TrimSpace(quotasHelp["rate-limit"][1]),
 		},
+		{
+			Pattern: "quotas/concurrency/?$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "concurrency-quotas",
+				OperationVerb:   "list",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleConcurrencyQuotasList(),
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(quotasHelp["concurrency-list"][0]),
+			HelpDescription: strings.TrimSpace(quotasHelp["concurrency-list"][1]),
+		},
+		{
+			Pattern: "quotas/concurrency/" + framework.GenericNameRegex("name"),
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "concurrency-quotas",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"type": {
+					Type:        framework.TypeString,
+					Description: "Type of the quota rule.",
+				},
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the quota rule.",
+				},
+				"path": {
+					Type: framework.TypeString,
+					Description: `Path of the mount to apply the quota, optionally followed by a path suffix within
+the mount. For example, transit/ adds a quota to the transit mount and
+namespace1/database/creds/* adds a quota to credential requests on the database
+mount in namespace1.`,
+				},
+				"max_in_flight": {
+					Type: framework.TypeInt,
+					Description: `The maximum number of requests to be processed by the mount or path at the same
+time. The 'max_in_flight' must be positive.`,
+				},
+				"queue_timeout": {
+					Type: framework.TypeDurationSecond,
+					Description: `If set, requests exceeding 'max_in_flight' wait up to 'queue_timeout' for an
+in-flight request to complete before they are rejected. If unset, such requests
+are rejected immediately.`,
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleConcurrencyQuotasUpdate(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "write",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: http.StatusText(http.StatusNoContent),
+						}},
+					},
+				},
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleConcurrencyQuotasRead(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"type": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"name": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"path": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"max_in_flight": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"queue_timeout": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleConcurrencyQuotasDelete(),
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+				},
+			},
+			HelpSynopsis:    strings.TrimSpace(quotasHelp["concurrency"][0]),
+			HelpDescription: strings.TrimSpace(quotasHelp["concurrency"][1]),
+		},
 	}
 }
 
@@ -361,6 +536,36 @@ func (b *SystemBackend) handleRateLimitQuotasUpdate() framework.OperationFunc {
 			return logical.ErrorResponse("'block' is invalid"), nil
 		}
 
+		groupBy := d.Get("group_by").(string)
+		if groupBy == "" {
+			groupBy = quotas.GroupByIP
+		}
+		if !quotas.ValidRateLimitGroupBy(groupBy) {
+			return logical.ErrorResponse("'group_by' is invalid"), nil
+		}
+
+		var adaptiveTargetLatency time.Duration
+		if raw := d.Get("adaptive_target_latency").(string); raw != "" {
+			var err error
+			adaptiveTargetLatency, err = parseutil.ParseDurationSecond(raw)
+			if err != nil || adaptiveTargetLatency < 0 {
+				return logical.ErrorResponse("'adaptive_target_latency' is invalid"), nil
+			}
+		}
+
+		adaptiveMaxErrorRate := d.Get("adaptive_max_error_rate").(float64)
+		if adaptiveMaxErrorRate < 0 || adaptiveMaxErrorRate > 1 {
+			return logical.ErrorResponse("'adaptive_max_error_rate' is invalid"), nil
+		}
+
+		adaptiveMinRate := d.Get("adaptive_min_rate").(float64)
+		if adaptiveMinRate < 0 || adaptiveMinRate > rate {
+			return logical.ErrorResponse("'adaptive_min_rate' is invalid"), nil
+		}
+
+		exemptPolicies := strutil.RemoveDuplicates(d.Get("exempt_policies").([]string), false)
+		exemptEntityMetadata := d.Get("exempt_entity_metadata").(map[string]string)
+
 		rawPath := sanitizePath(d.Get("path").(string))
 		mountPath := rawPath
 
@@ -462,7 +667,14 @@ func (b *SystemBackend) handleRateLimitQuotasUpdate() framework.OperationFunc {
 
 		switch {
 		case quota == nil:
-			quota = quotas.NewRateLimitQuota(name, ns.Path, mountPath, pathSuffix, role, inheritable, interval, blockInterval, rate)
+			rlq := quotas.NewRateLimitQuota(name, ns.Path, mountPath, pathSuffix, role, inheritable, interval, blockInterval, rate)
+			rlq.GroupBy = groupBy
+			rlq.AdaptiveTargetLatency = adaptiveTargetLatency
+			rlq.AdaptiveMaxErrorRate = adaptiveMaxErrorRate
+			rlq.AdaptiveMinRate = adaptiveMinRate
+			rlq.ExemptPolicies = exemptPolicies
+			rlq.ExemptEntityMetadata = exemptEntityMetadata
+			quota = rlq
 		default:
 			// Re-inserting the already indexed object in memdb might cause problems.
 			// So, clone the object. See https://github.com/hashicorp/go-memdb/issues/76.
@@ -475,6 +687,12 @@ func (b *SystemBackend) handleRateLimitQuotasUpdate() framework.OperationFunc {
 			rlq.Inheritable = inheritable
 			rlq.Interval = interval
 			rlq.BlockInterval = blockInterval
+			rlq.GroupBy = groupBy
+			rlq.AdaptiveTargetLatency = adaptiveTargetLatency
+			rlq.AdaptiveMaxErrorRate = adaptiveMaxErrorRate
+			rlq.AdaptiveMinRate = adaptiveMinRate
+			rlq.ExemptPolicies = exemptPolicies
+			rlq.ExemptEntityMetadata = exemptEntityMetadata
 			quota = rlq
 		}
 		if err := b.Core.quotaManager.SetQuota(ctx, qType, quota, false); err != nil {
@@ -514,6 +732,15 @@ func (b *SystemBackend) handleRateLimitQuotasRead() framework.OperationFunc {
 			"inheritable":    rlq.Inheritable,
 			"interval":       int(rlq.Interval.Seconds()),
 			"block_interval": int(rlq.BlockInterval.Seconds()),
+			"group_by":       rlq.GroupBy,
+
+			"adaptive_target_latency": rlq.AdaptiveTargetLatency.String(),
+			"adaptive_max_error_rate": rlq.AdaptiveMaxErrorRate,
+			"adaptive_min_rate":       rlq.AdaptiveMinRate,
+			"effective_rate":          rlq.EffectiveRate(),
+
+			"exempt_policies":        rlq.ExemptPolicies,
+			"exempt_entity_metadata": rlq.ExemptEntityMetadata,
 		}
 
 		return &logical.Response{
@@ -549,6 +776,173 @@ func (b *SystemBackend) handleRateLimitQuotasDelete() framework.OperationFunc {
 	}
 }
 
+func (b *SystemBackend) handleConcurrencyQuotasList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		names, err := b.Core.quotaManager.QuotaNames(quotas.TypeConcurrency)
+		if err != nil {
+			return nil, err
+		}
+
+		return logical.ListResponse(names), nil
+	}
+}
+
+func (b *SystemBackend) handleConcurrencyQuotasUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+
+		qType := quotas.TypeConcurrency.String()
+		maxInFlight := d.Get("max_in_flight").(int)
+		if maxInFlight <= 0 {
+			return logical.ErrorResponse("'max_in_flight' is invalid"), nil
+		}
+
+		queueTimeout := time.Second * time.Duration(d.Get("queue_timeout").(int))
+		if queueTimeout < 0 {
+			return logical.ErrorResponse("'queue_timeout' is invalid"), nil
+		}
+
+		mountPath := sanitizePath(d.Get("path").(string))
+
+		currentNamespace, err := namespace.FromContext(ctx)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), nil
+		}
+		if currentNamespace.ID != namespace.RootNamespaceID && !strings.HasPrefix(mountPath, currentNamespace.Path) {
+			return logical.ErrorResponse(ErrInvalidQuotaOnParentNs), nil
+		}
+
+		// If there is a quota by the same name that was configured on a parent namespace, prohibit updating this quota
+		if currentNamespace.ID != namespace.RootNamespaceID {
+			quota, err := b.Core.quotaManager.QuotaByName(qType, name)
+			if err != nil {
+				return nil, err
+			}
+			if quota != nil && !strings.HasPrefix(quota.GetNamespacePath(), currentNamespace.Path) {
+				return logical.ErrorResponse(ErrInvalidQuotaUpdate), nil
+			}
+		}
+
+		ns := b.Core.namespaceByPath(mountPath)
+		if ns.ID != namespace.RootNamespaceID {
+			mountPath = strings.TrimPrefix(mountPath, ns.Path)
+		}
+
+		// Concurrency quotas protect specific backends, so unlike rate limit
+		// quotas they cannot be configured globally or for a whole namespace.
+		if mountPath == "" {
+			return logical.ErrorResponse("'path' must specify a mount"), nil
+		}
+
+		me := b.Core.router.MatchingMountEntry(namespace.ContextWithNamespace(ctx, ns), mountPath)
+		if me == nil {
+			return logical.ErrorResponse("invalid mount path %q", mountPath), nil
+		}
+
+		mountAPIPath := me.APIPathNoNamespace()
+		pathSuffix := strings.TrimSuffix(strings.TrimPrefix(mountPath, mountAPIPath), "/")
+		mountPath = mountAPIPath
+
+		// Disallow creation of new quota that has properties similar to an
+		// existing quota.
+		quotaByFactors, err := b.Core.quotaManager.QuotaByFactors(ctx, qType, ns.Path, mountPath, pathSuffix, "")
+		if err != nil {
+			return nil, err
+		}
+		if quotaByFactors != nil && quotaByFactors.QuotaName() != name {
+			return logical.ErrorResponse("quota rule with similar properties exists under the name %q", quotaByFactors.QuotaName()), nil
+		}
+
+		// If a quota already exists, fetch and update it.
+		quota, err := b.Core.quotaManager.QuotaByName(qType, name)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case quota == nil:
+			quota = quotas.NewConcurrencyQuota(name, ns.Path, mountPath, pathSuffix, maxInFlight, queueTimeout)
+		default:
+			// Re-inserting the already indexed object in memdb might cause problems.
+			// So, clone the object. See https://github.com/hashicorp/go-memdb/issues/76.
+			clonedQuota := quota.Clone()
+			cq := clonedQuota.(*quotas.ConcurrencyQuota)
+			cq.NamespacePath = ns.Path
+			cq.MountPath = mountPath
+			cq.PathSuffix = pathSuffix
+			cq.MaxInFlight = maxInFlight
+			cq.QueueTimeout = queueTimeout
+			quota = cq
+		}
+		if err := b.Core.quotaManager.SetQuota(ctx, qType, quota, false); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleConcurrencyQuotasRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+		qType := quotas.TypeConcurrency.String()
+
+		quota, err := b.Core.quotaManager.QuotaByName(qType, name)
+		if err != nil {
+			return nil, err
+		}
+		if quota == nil {
+			return nil, nil
+		}
+
+		cq := quota.(*quotas.ConcurrencyQuota)
+
+		nsPath := cq.NamespacePath
+		if cq.NamespacePath == "root" {
+			nsPath = ""
+		}
+
+		data := map[string]interface{}{
+			"type":          qType,
+			"name":          cq.Name,
+			"path":          nsPath + cq.MountPath + cq.PathSuffix,
+			"max_in_flight": cq.MaxInFlight,
+			"queue_timeout": int(cq.QueueTimeout.Seconds()),
+		}
+
+		return &logical.Response{
+			Data: data,
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleConcurrencyQuotasDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		name := d.Get("name").(string)
+		qType := quotas.TypeConcurrency.String()
+
+		ns, err := namespace.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if ns.ID != namespace.RootNamespaceID {
+			quota, err := b.Core.quotaManager.QuotaByName(qType, name)
+			if err != nil {
+				return nil, err
+			}
+			if quota != nil && !strings.HasPrefix(quota.GetNamespacePath(), ns.Path) {
+				return logical.ErrorResponse(ErrInvalidQuotaDeletion), nil
+			}
+		}
+
+		if err := b.Core.quotaManager.DeleteQuota(ctx, qType, name); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
 var quotasHelp = map[string][2]string{
 	"quotas-config": {
 		"Create, update and read the quota configuration.",
@@ -559,11 +953,24 @@ var quotasHelp = map[string][2]string{
 mount.`,
 		`A rate limit quota will enforce API rate limiting in a specified interval. A
 rate limit quota can be created at the root level or defined on a namespace or
-mount by specifying a 'path'. The rate limiter is applied to each unique client
-IP address.`,
+mount by specifying a 'path'. By default, the rate limiter is applied to each
+unique client IP address; 'group_by' can be used to instead apply it to each
+identity entity, or to all clients as a whole.`,
 	},
 	"rate-limit-list": {
 		"Lists the names of all the rate limit quotas.",
 		"This list contains quota definitions from all the namespaces.",
 	},
+	"concurrency": {
+		"Get, create or update a concurrency quota for a mount.",
+		`A concurrency quota limits the number of requests to a mount, or to a path
+within a mount, that are being processed at the same time. Requests exceeding
+the limit are either rejected immediately or, if a 'queue_timeout' is set, wait
+for an in-flight request to complete. This protects slow backends, such as
+HSM-backed or external database secrets engines, from being overwhelmed.`,
+	},
+	"concurrency-list": {
+		"Lists the names of all the concurrency quotas.",
+		"This list contains quota definitions from all the namespaces.",
+	},
 }
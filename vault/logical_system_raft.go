@@ -17,6 +17,7 @@ import (
 	snapshot "github.com/hashicorp/raft-snapshot"
 	"github.com/hashicorp/vault/helper/constants"
 	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/pgpkeys"
 	"github.com/hashicorp/vault/physical/raft"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -92,6 +93,10 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 				"server_id": {
 					Type: framework.TypeString,
 				},
+				"force": {
+					Type:        framework.TypeBool,
+					Description: "Remove the peer even if the remaining healthy voters would not form a quorum.",
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -133,6 +138,14 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 		},
 		{
 			Pattern: "storage/raft/snapshot",
+
+			Fields: map[string]*framework.FieldSchema{
+				"pgp_keys": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Base64-encoded PGP public keys to encrypt the snapshot to, so that it can only be decrypted with one of their private keys.",
+				},
+			},
+
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.ReadOperation: &framework.PathOperation{
 					Callback: b.handleStorageRaftSnapshotRead(makeSealer(nil, "snapshot_read")),
@@ -159,6 +172,32 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-force"][0]),
 			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-force"][1]),
 		},
+		{
+			Pattern: "storage/raft/snapshot-restore-path",
+
+			Fields: map[string]*framework.FieldSchema{
+				"mount": {
+					Type:        framework.TypeString,
+					Description: "Path of the KV secrets engine the secret is restored into, e.g. 'secret'.",
+					Required:    true,
+				},
+				"path": {
+					Type:        framework.TypeString,
+					Description: "Path of the secret within the mount, e.g. 'my-app/db'.",
+					Required:    true,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotRestorePath(makeSealer(b.logger, "snapshot_write")),
+					Summary:  "Restores a single KV secret from the provided snapshot, leaving the rest of the cluster untouched.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-restore-path"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-restore-path"][1]),
+		},
 		{
 			Pattern: "storage/raft/autopilot/state",
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -256,13 +295,31 @@ func (b *SystemBackend) handleRaftRemovePeerUpdate() framework.OperationFunc {
 			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
 		}
 
+		projection, err := raftBackend.ProjectQuorum(ctx, serverID, false)
+		if err != nil {
+			return nil, err
+		}
+		force := d.Get("force").(bool)
+		if !projection.Safe && !force {
+			return logical.ErrorResponse("removing peer %q would leave %d healthy voters, fewer than the quorum of %d; use force to remove it anyway", serverID, projection.HealthyVoters, projection.Quorum), logical.ErrInvalidRequest
+		}
+
 		if err := raftBackend.RemovePeer(ctx, serverID); err != nil {
 			return nil, err
 		}
 
 		b.Core.raftFollowerStates.Delete(serverID)
 
-		return nil, nil
+		resp := &logical.Response{
+			Data: map[string]interface{}{
+				"projected_quorum": projection,
+			},
+		}
+		if !projection.Safe {
+			resp.AddWarning("The peer was forcibly removed and the remaining healthy voters do not form a quorum.")
+		}
+
+		return resp, nil
 	}
 }
 
@@ -367,6 +424,22 @@ func (b *SystemBackend) handleRaftBootstrapAnswerWrite() framework.OperationFunc
 			desiredSuffrage = "voter"
 		}
 
+		// A peer answering the challenge is reachable, so adding it cannot
+		// leave fewer healthy voters than the quorum unless the cluster already
+		// lacks a healthy quorum. Non-voters do not change the quorum.
+		projectedPeer := serverID
+		if nonVoter {
+			projectedPeer = ""
+		}
+		projection, err := raftBackend.ProjectQuorum(ctx, projectedPeer, !nonVoter)
+		if err != nil {
+			return nil, err
+		}
+		if projection.HealthyVoters < projection.Quorum {
+			b.logger.Warn("adding raft peer to a cluster without a healthy quorum", "follower_server_id", serverID,
+				"healthy_voters", projection.HealthyVoters, "quorum", projection.Quorum)
+		}
+
 		added := b.Core.raftFollowerStates.Update(&raft.EchoRequestUpdate{
 			NodeID:          serverID,
 			DesiredSuffrage: desiredSuffrage,
@@ -397,6 +470,7 @@ func (b *SystemBackend) handleRaftBootstrapAnswerWrite() framework.OperationFunc
 				"peers":              peers,
 				"tls_keyring":        &keyring,
 				"autoloaded_license": b.Core.entIsLicenseAutoloaded(),
+				"projected_quorum":   projection,
 			},
 		}, nil
 	}
@@ -412,8 +486,31 @@ func (b *SystemBackend) handleStorageRaftSnapshotRead(makeSealer func() snapshot
 			return nil, errors.New("no writer for request")
 		}
 
-		err := raftStorage.SnapshotHTTP(req.ResponseWriter, makeSealer())
+		pgpKeys := d.Get("pgp_keys").([]string)
+		if len(pgpKeys) == 0 {
+			err := raftStorage.SnapshotHTTP(req.ResponseWriter, makeSealer())
+			if err != nil {
+				return nil, err
+			}
+
+			return nil, nil
+		}
+
+		// The snapshot is additionally encrypted to the PGP keys, so that it
+		// can be escrowed independently of the seal
+		w, fingerprints, err := pgpkeys.EncryptStream(req.ResponseWriter, pgpKeys)
 		if err != nil {
+			return logical.ErrorResponse("invalid pgp_keys: %s", err), logical.ErrInvalidRequest
+		}
+
+		req.ResponseWriter.Header().Add("Content-Disposition", "attachment")
+		req.ResponseWriter.Header().Add("Content-Type", "application/pgp-encrypted")
+		b.logger.Info("taking snapshot encrypted to PGP keys", "fingerprints", fingerprints)
+
+		if err := raftStorage.Snapshot(w, makeSealer()); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
 			return nil, err
 		}
 
@@ -680,6 +777,61 @@ func (b *SystemBackend) handleStorageRaftSnapshotWrite(force bool, makeSealer fu
 	}
 }
 
+func (b *SystemBackend) handleStorageRaftSnapshotRestorePath(makeSealer func() snapshot.Sealer) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		raftStorage, ok := b.Core.underlyingPhysical.(*raft.RaftBackend)
+		if !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+		body, ok := logical.ContextOriginalBodyValue(ctx)
+		if !ok {
+			return nil, errors.New("no reader for request")
+		}
+
+		mount := sanitizePath(d.Get("mount").(string))
+		key := strings.Trim(d.Get("path").(string), "/")
+		if mount == "/" || key == "" {
+			return logical.ErrorResponse("mount and path are required"), logical.ErrInvalidRequest
+		}
+		entry := b.Core.router.MatchingMountEntry(ctx, mount)
+		if entry == nil || entry.Path != mount {
+			return logical.ErrorResponse("no mount found at %q", mount), logical.ErrInvalidRequest
+		}
+
+		snapFile, cleanup, _, err := raftStorage.WriteSnapshotToTemp(body, makeSealer())
+		switch {
+		case err == nil:
+		case strings.Contains(err.Error(), "failed to open the sealed hashes"):
+			return logical.ErrorResponse("could not verify hash file, possibly the snapshot is using a different set of keys"), logical.ErrInvalidRequest
+		default:
+			b.Core.logger.Error("raft snapshot path restore: failed to write snapshot", "error", err)
+			return nil, err
+		}
+		defer cleanup()
+
+		versions, err := b.Core.restoreSnapshotPath(ctx, snapFile, entry, key)
+		switch {
+		case errors.Is(err, ErrSnapshotPathNotFound):
+			return logical.ErrorResponse("%q not found in snapshot", mount+key), logical.ErrInvalidRequest
+		case err != nil:
+			b.Core.logger.Error("raft snapshot path restore failed", "path", mount+key, "error", err)
+			return nil, err
+		}
+
+		b.Core.logger.Info("restored path from raft snapshot", "path", mount+key, "versions", versions)
+		resp := &logical.Response{
+			Data: map[string]interface{}{},
+		}
+		if entry.Options["version"] == "2" {
+			if versions == nil {
+				versions = []uint64{}
+			}
+			resp.Data["restored_versions"] = versions
+		}
+		return resp, nil
+	}
+}
+
 var sysRaftHelp = map[string][2]string{
 	"raft-bootstrap-challenge": {
 		"Creates a challenge for the new peer to be joined to the raft cluster.",
@@ -696,7 +848,9 @@ var sysRaftHelp = map[string][2]string{
 	},
 	"raft-remove-peer": {
 		"Removes a peer from the raft cluster.",
-		"",
+		`Removing a voter is refused if the remaining healthy voters, as reported
+by autopilot, would not form a quorum, unless force is set. The response
+reports the projected quorum of the cluster.`,
 	},
 	"raft-snapshot": {
 		"Restores and saves snapshots from the raft cluster.",
@@ -706,6 +860,15 @@ var sysRaftHelp = map[string][2]string{
 		"Force restore a raft cluster snapshot",
 		"",
 	},
+	"raft-snapshot-restore-path": {
+		"Restores a single KV secret from a raft cluster snapshot.",
+		`The snapshot data is read from the request body and the mount and path of
+the secret from the query string. For KV version 2 mounts, the versions of the
+secret which are missing or destroyed in the live cluster are restored from the
+snapshot, leaving any other versions untouched. For KV version 1 mounts, the
+secret is overwritten with its value in the snapshot. The mount must not have
+been remounted or recreated since the snapshot was taken.`,
+	},
 	"raft-autopilot-state": {
 		"Returns the state of the raft cluster under integrated storage as seen by autopilot.",
 		"",
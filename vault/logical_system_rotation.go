@@ -0,0 +1,210 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// handleRotationJobsList lists the rotation jobs of mounts in the request's
+// namespace.
+func (b *SystemBackend) handleRotationJobsList(ctx context.Context, req *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := b.Core.barrier.List(ctx, coreRotationJobsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	keyInfo := make(map[string]interface{})
+	for _, id := range ids {
+		job, entry, err := b.namespaceRotationJob(ctx, ns, id)
+		if err != nil {
+			return nil, err
+		}
+		if job == nil {
+			continue
+		}
+
+		keys = append(keys, job.ID)
+		keyInfo[job.ID] = map[string]interface{}{
+			"name":          job.Name,
+			"path":          entry.APIPathNoNamespace() + job.ReqPath,
+			"next_rotation": job.NextRotation.Format(time.RFC3339),
+		}
+	}
+
+	return logical.ListResponseWithInfo(keys, keyInfo), nil
+}
+
+// handleRotationJobRead returns the schedule and history of a rotation job of
+// a mount in the request's namespace.
+func (b *SystemBackend) handleRotationJobRead(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	job, entry, err := b.namespaceRotationJob(ctx, ns, d.Get("id").(string))
+	if err != nil || job == nil {
+		return nil, err
+	}
+
+	history := make([]map[string]interface{}, 0, len(job.History))
+	for _, attempt := range job.History {
+		a := map[string]interface{}{
+			"time":    attempt.Time.Format(time.RFC3339),
+			"success": attempt.Success,
+		}
+		if attempt.Error != "" {
+			a["error"] = attempt.Error
+		}
+		if attempt.Missed {
+			a["missed"] = true
+		}
+		history = append(history, a)
+	}
+
+	data := map[string]interface{}{
+		"id":             job.ID,
+		"name":           job.Name,
+		"mount_accessor": job.MountAccessor,
+		"path":           entry.APIPathNoNamespace() + job.ReqPath,
+		"next_rotation":  job.NextRotation.Format(time.RFC3339),
+		"history":        history,
+	}
+	if job.Schedule != "" {
+		data["rotation_schedule"] = job.Schedule
+		data["rotation_window"] = int64(job.Window.Seconds())
+	} else {
+		data["rotation_period"] = int64(job.Period.Seconds())
+	}
+	if job.Jitter > 0 {
+		data["rotation_jitter"] = int64(job.Jitter.Seconds())
+	}
+	if job.Calendar != "" {
+		data["rotation_calendar"] = job.Calendar
+	}
+	if !job.LastRotation.IsZero() {
+		data["last_rotation"] = job.LastRotation.Format(time.RFC3339)
+	}
+
+	return &logical.Response{Data: data}, nil
+}
+
+// namespaceRotationJob returns the rotation job with the given ID and its
+// mount, if the mount exists in the namespace.
+func (b *SystemBackend) namespaceRotationJob(ctx context.Context, ns *namespace.Namespace, id string) (*RotationJob, *MountEntry, error) {
+	job, err := b.Core.rotationJob(ctx, id)
+	if err != nil || job == nil {
+		return nil, nil, err
+	}
+
+	entry := b.Core.router.MatchingMountByAccessor(job.MountAccessor)
+	if entry == nil || entry.NamespaceID != ns.ID {
+		return nil, nil, nil
+	}
+	return job, entry, nil
+}
+
+// errRotationCalendarNamespace is returned when rotation calendars are
+// managed outside of the root namespace. Calendars are shared by all
+// namespaces.
+var errRotationCalendarNamespace = errors.New("rotation calendars can only be managed in the root namespace")
+
+// handleRotationCalendarsList lists the rotation calendars.
+func (b *SystemBackend) handleRotationCalendarsList(ctx context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
+	if err := checkRotationCalendarNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	names, err := b.Core.barrier.List(ctx, coreRotationCalendarsPath)
+	if err != nil {
+		return nil, err
+	}
+	return logical.ListResponse(names), nil
+}
+
+// handleRotationCalendarRead returns the freeze dates of a rotation calendar.
+func (b *SystemBackend) handleRotationCalendarRead(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := checkRotationCalendarNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	calendar, err := b.Core.rotationCalendar(ctx, d.Get("name").(string))
+	if err != nil || calendar == nil {
+		return nil, err
+	}
+
+	timezone := calendar.Timezone
+	if timezone == "" {
+		timezone = time.UTC.String()
+	}
+	return &logical.Response{
+		Data: map[string]interface{}{
+			"name":         calendar.Name,
+			"timezone":     timezone,
+			"freeze_dates": calendar.FreezeDates,
+		},
+	}, nil
+}
+
+// handleRotationCalendarWrite creates or replaces a rotation calendar.
+func (b *SystemBackend) handleRotationCalendarWrite(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := checkRotationCalendarNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	calendar := &RotationCalendar{
+		Name:     d.Get("name").(string),
+		Timezone: strings.TrimSpace(d.Get("timezone").(string)),
+	}
+	for _, date := range d.Get("freeze_dates").([]string) {
+		if date = strings.TrimSpace(date); date != "" {
+			calendar.FreezeDates = append(calendar.FreezeDates, date)
+		}
+	}
+	if _, err := calendar.periods(); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	if err := b.Core.persistRotationCalendar(ctx, calendar); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// handleRotationCalendarDelete deletes a rotation calendar. Rotations still
+// referencing it fail until they are reconfigured.
+func (b *SystemBackend) handleRotationCalendarDelete(ctx context.Context, _ *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	if err := checkRotationCalendarNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	return nil, b.Core.barrier.Delete(ctx, coreRotationCalendarsPath+d.Get("name").(string))
+}
+
+// checkRotationCalendarNamespace returns errRotationCalendarNamespace unless
+// the request is in the root namespace.
+func checkRotationCalendarNamespace(ctx context.Context) error {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if ns.ID != namespace.RootNamespaceID {
+		return errRotationCalendarNamespace
+	}
+	return nil
+}
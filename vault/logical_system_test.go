@@ -5,6 +5,7 @@ package vault
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
@@ -18,12 +19,14 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode"
 
 	"github.com/fatih/structs"
 	"github.com/go-test/deep"
 	"github.com/hashicorp/go-hclog"
 	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
 	aeadwrapper "github.com/hashicorp/go-kms-wrapping/wrappers/aead/v2"
+	"github.com/hashicorp/vault/builtin/audit/file"
 	credUserpass "github.com/hashicorp/vault/builtin/credential/userpass"
 	"github.com/hashicorp/vault/helper/builtinplugins"
 	"github.com/hashicorp/vault/helper/experiments"
@@ -40,6 +43,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/logging"
 	"github.com/hashicorp/vault/sdk/helper/pluginruntimeutil"
 	"github.com/hashicorp/vault/sdk/helper/pluginutil"
+	"github.com/hashicorp/vault/sdk/helper/salt"
 	"github.com/hashicorp/vault/sdk/helper/testhelpers/schema"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault/plugincatalog"
@@ -2782,6 +2786,79 @@ func TestSystemBackend_auditHash(t *testing.T) {
 	}
 }
 
+// TestSystemBackend_auditHashKey tests that audit devices hash values with an
+// imported key, and with a new random salt once the key is deleted
+func TestSystemBackend_auditHashKey(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+	c.auditBackends["file"] = file.Factory
+	ctx := namespace.RootContext(nil)
+
+	for _, path := range []string{"foo", "bar"} {
+		req := logical.TestRequest(t, logical.UpdateOperation, "audit/"+path)
+		req.Data["type"] = "file"
+		req.Data["options"] = map[string]interface{}{
+			"file_path": "discard",
+		}
+		resp, err := b.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		require.Nil(t, resp)
+	}
+
+	hash := func(path string) string {
+		t.Helper()
+		req := logical.TestRequest(t, logical.UpdateOperation, "audit-hash/"+path)
+		req.Data["input"] = "baz"
+		resp, err := b.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		return resp.Data["hash"].(string)
+	}
+	random := hash("foo")
+	require.NotEqual(t, random, hash("bar"))
+
+	// Keys which are not base64 encoded or too short are rejected
+	req := logical.TestRequest(t, logical.UpdateOperation, "audit-hash-key/foo")
+	req.Data["key"] = "not base64"
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+	req.Data["key"] = base64.StdEncoding.EncodeToString(make([]byte, auditHashKeyMinLength-1))
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "audit-hash-key/unknown")
+	req.Data["key"] = base64.StdEncoding.EncodeToString(make([]byte, auditHashKeyMinLength))
+	_, err = b.HandleRequest(ctx, req)
+	require.Error(t, err)
+
+	// Devices sharing a key hash values alike
+	key := []byte("0123456789abcdef0123456789abcdef")
+	for _, path := range []string{"foo", "bar"} {
+		req := logical.TestRequest(t, logical.UpdateOperation, "audit-hash-key/"+path)
+		req.Data["key"] = base64.StdEncoding.EncodeToString(key)
+		resp, err := b.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		require.Nil(t, resp)
+
+		schema.ValidateResponse(
+			t,
+			schema.GetResponseSchema(t, b.(*SystemBackend).Route(req.Path), req.Operation),
+			resp,
+			true,
+		)
+	}
+	require.Equal(t, "hmac-sha256:"+salt.HMACValue(string(key), "baz", sha256.New), hash("foo"))
+	require.Equal(t, hash("foo"), hash("bar"))
+
+	// Deleting the key reverts to a new random salt
+	req = logical.TestRequest(t, logical.DeleteOperation, "audit-hash-key/foo")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+	require.NotEqual(t, hash("foo"), hash("bar"))
+	require.NotEqual(t, random, hash("foo"))
+}
+
 func TestSystemBackend_enableAudit_invalid(t *testing.T) {
 	b := testSystemBackend(t)
 	req := logical.TestRequest(t, logical.UpdateOperation, "audit/foo")
@@ -4861,6 +4938,57 @@ func TestHandlePoliciesPasswordSet(t *testing.T) {
 					"	charset=\"abcdefghij\"\n"+
 					"}")),
 		},
+		"passphrase policy": {
+			inputData: passwordPoliciesFieldData(map[string]interface{}{
+				"name": "testpolicy",
+				"policy": "mode = \"passphrase\"\n" +
+					"words = 5\n" +
+					"capitalize = true\n" +
+					"digits = 2",
+			}),
+
+			storage: new(logical.InmemStorage),
+
+			expectedResp: &logical.Response{
+				Data: map[string]interface{}{
+					logical.HTTPContentType: "application/json",
+					logical.HTTPStatusCode:  http.StatusNoContent,
+				},
+			},
+			expectErr: false,
+			expectedStore: makeStorageMap(storageEntry(t, "testpolicy",
+				"mode = \"passphrase\"\n"+
+					"words = 5\n"+
+					"capitalize = true\n"+
+					"digits = 2")),
+		},
+		"impossible passphrase policy": {
+			inputData: passwordPoliciesFieldData(map[string]interface{}{
+				"name": "testpolicy",
+				"policy": "mode = \"passphrase\"\n" +
+					"length = 10\n" +
+					"words = 8",
+			}),
+
+			storage: new(logical.InmemStorage),
+
+			expectedResp:  nil,
+			expectErr:     true,
+			expectedStore: map[string]*logical.StorageEntry{},
+		},
+		"pronounceable policy too long": {
+			inputData: passwordPoliciesFieldData(map[string]interface{}{
+				"name": "testpolicy",
+				"policy": "mode = \"pronounceable\"\n" +
+					"length = 200",
+			}),
+
+			storage: new(logical.InmemStorage),
+
+			expectedResp:  nil,
+			expectErr:     true,
+			expectedStore: map[string]*logical.StorageEntry{},
+		},
 	}
 
 	for name, test := range tests {
@@ -5416,6 +5544,154 @@ func TestHandlePoliciesPasswordGenerate(t *testing.T) {
 	})
 }
 
+func TestHandlePoliciesPasswordTest(t *testing.T) {
+	t.Run("errors", func(t *testing.T) {
+		type testCase struct {
+			inputData *framework.FieldData
+			storage   *logical.InmemStorage
+		}
+
+		tests := map[string]testCase{
+			"missing policy name": {
+				inputData: passwordPoliciesFieldData(map[string]interface{}{}),
+				storage:   new(logical.InmemStorage),
+			},
+			"policy does not exist": {
+				inputData: passwordPoliciesFieldData(map[string]interface{}{
+					"name": "testpolicy",
+				}),
+				storage: new(logical.InmemStorage),
+			},
+			"too many samples": {
+				inputData: passwordPoliciesFieldData(map[string]interface{}{
+					"name":  "testpolicy",
+					"count": 21,
+				}),
+				storage: makeStorage(t, storageEntry(t, "testpolicy", "length = 20\nrule \"charset\" {\n	charset=\"abcdefghij\"\n}")),
+			},
+			"garbage policy": {
+				inputData: passwordPoliciesFieldData(map[string]interface{}{
+					"name":   "testpolicy",
+					"policy": "hasdukfhiuashdfoiasjdf",
+				}),
+				storage: new(logical.InmemStorage),
+			},
+			"impossible passphrase": {
+				inputData: passwordPoliciesFieldData(map[string]interface{}{
+					"name": "testpolicy",
+					"policy": "mode = \"passphrase\"\n" +
+						"rule \"charset\" {\n" +
+						"	charset = \"!@#\"\n" +
+						"	min-chars = 1\n" +
+						"}",
+				}),
+				storage: new(logical.InmemStorage),
+			},
+		}
+
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+
+				req := &logical.Request{
+					Storage: test.storage,
+				}
+
+				b := &SystemBackend{}
+
+				actualResp, err := b.handlePoliciesPasswordTest(ctx, req, test.inputData)
+				if err == nil {
+					t.Fatalf("err expected, got nil")
+				}
+				if actualResp != nil {
+					t.Fatalf("no response expected, got: %#v", actualResp)
+				}
+			})
+		}
+	})
+
+	t.Run("stored policy", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		storage := makeStorage(t, storageEntry(t, "testpolicy",
+			"mode = \"pronounceable\"\n"+
+				"length = 12\n"+
+				"digits = 2\n"+
+				"capitalize = true"))
+
+		req := &logical.Request{
+			Storage: storage,
+		}
+		inputData := passwordPoliciesFieldData(map[string]interface{}{
+			"name":  "testpolicy",
+			"count": 3,
+		})
+
+		b := &SystemBackend{}
+
+		resp, err := b.handlePoliciesPasswordTest(ctx, req, inputData)
+		if err != nil {
+			t.Fatalf("no error expected, got: %s", err)
+		}
+
+		samples := resp.Data["samples"].([]string)
+		if len(samples) != 3 {
+			t.Fatalf("expected 3 samples, got %d", len(samples))
+		}
+		for _, sample := range samples {
+			if len(sample) != 12 || !unicode.IsUpper(rune(sample[0])) || !unicode.IsDigit(rune(sample[11])) {
+				t.Fatalf("sample %q is not a capitalized pronounceable password ending in digits", sample)
+			}
+		}
+		if entropy := resp.Data["entropy_bits"].(float64); entropy <= 0 {
+			t.Fatalf("expected a positive entropy estimate, got %f", entropy)
+		}
+	})
+
+	t.Run("provided policy", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req := &logical.Request{
+			Storage: new(logical.InmemStorage),
+		}
+		inputData := passwordPoliciesFieldData(map[string]interface{}{
+			"name": "testpolicy",
+			"policy": base64Encode("mode = \"passphrase\"\n" +
+				"words = 4\n" +
+				"separator = \".\""),
+		})
+
+		b := &SystemBackend{}
+
+		resp, err := b.handlePoliciesPasswordTest(ctx, req, inputData)
+		if err != nil {
+			t.Fatalf("no error expected, got: %s", err)
+		}
+
+		samples := resp.Data["samples"].([]string)
+		if len(samples) != 5 {
+			t.Fatalf("expected 5 samples, got %d", len(samples))
+		}
+		for _, sample := range samples {
+			if words := strings.Split(sample, "."); len(words) != 4 {
+				t.Fatalf("sample %q does not have 4 words", sample)
+			}
+		}
+
+		// Testing a policy must not save it
+		entry, err := req.Storage.Get(ctx, getPasswordPolicyKey("testpolicy"))
+		if err != nil {
+			t.Fatalf("no error expected, got: %s", err)
+		}
+		if entry != nil {
+			t.Fatalf("tested policy should not be stored")
+		}
+	})
+}
+
 func assertTrue(t *testing.T, pass bool, f string, vals ...interface{}) {
 	t.Helper()
 	if !pass {
@@ -5451,6 +5727,11 @@ func passwordPoliciesFieldData(raw map[string]interface{}) *framework.FieldData
 				Type:        framework.TypeString,
 				Description: "The password policy",
 			},
+			"count": {
+				Type:        framework.TypeInt,
+				Description: "The number of sample passwords to generate.",
+				Default:     5,
+			},
 		},
 	}
 }
@@ -6407,23 +6688,27 @@ func TestSystemBackend_pluginRuntimeCRUD(t *testing.T) {
 	b := testSystemBackend(t)
 
 	conf := pluginruntimeutil.PluginRuntimeConfig{
-		Name:         "foo",
-		Type:         consts.PluginRuntimeTypeContainer,
-		OCIRuntime:   "some-oci-runtime",
-		CgroupParent: "/cpulimit/",
-		CPU:          1,
-		Memory:       10000,
-		Rootless:     true,
+		Name:          "foo",
+		Type:          consts.PluginRuntimeTypeContainer,
+		OCIRuntime:    "some-oci-runtime",
+		CgroupParent:  "/cpulimit/",
+		CPU:           1,
+		Memory:        10000,
+		Rootless:      true,
+		MaxRestarts:   3,
+		RestartWindow: 30 * time.Second,
 	}
 
 	// Register the plugin runtime
 	req := logical.TestRequest(t, logical.UpdateOperation, fmt.Sprintf("plugins/runtimes/catalog/%s/%s", conf.Type.String(), conf.Name))
 	req.Data = map[string]interface{}{
-		"oci_runtime":   conf.OCIRuntime,
-		"cgroup_parent": conf.CgroupParent,
-		"cpu_nanos":     conf.CPU,
-		"memory_bytes":  conf.Memory,
-		"rootless":      conf.Rootless,
+		"oci_runtime":    conf.OCIRuntime,
+		"cgroup_parent":  conf.CgroupParent,
+		"cpu_nanos":      conf.CPU,
+		"memory_bytes":   conf.Memory,
+		"rootless":       conf.Rootless,
+		"max_restarts":   conf.MaxRestarts,
+		"restart_window": "30s",
 	}
 
 	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
@@ -6458,13 +6743,15 @@ func TestSystemBackend_pluginRuntimeCRUD(t *testing.T) {
 	)
 
 	readExp := map[string]any{
-		"type":          conf.Type.String(),
-		"name":          conf.Name,
-		"oci_runtime":   conf.OCIRuntime,
-		"cgroup_parent": conf.CgroupParent,
-		"cpu_nanos":     conf.CPU,
-		"memory_bytes":  conf.Memory,
-		"rootless":      conf.Rootless,
+		"type":           conf.Type.String(),
+		"name":           conf.Name,
+		"oci_runtime":    conf.OCIRuntime,
+		"cgroup_parent":  conf.CgroupParent,
+		"cpu_nanos":      conf.CPU,
+		"memory_bytes":   conf.Memory,
+		"rootless":       conf.Rootless,
+		"max_restarts":   conf.MaxRestarts,
+		"restart_window": int64(30),
 	}
 	if !reflect.DeepEqual(resp.Data, readExp) {
 		t.Fatalf("got: %#v expect: %#v", resp.Data, readExp)
@@ -6557,6 +6844,44 @@ func TestSystemBackend_pluginRuntimeCRUD(t *testing.T) {
 	}
 }
 
+func TestSystemBackend_pluginRuntimeProcess(t *testing.T) {
+	b := testSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "plugins/runtimes/catalog/process/foo")
+	req.Data = map[string]interface{}{
+		"cgroup_parent": "vault-plugins",
+		"cpu_nanos":     500000000,
+		"memory_bytes":  1 << 28,
+		"max_restarts":  5,
+	}
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "plugins/runtimes/catalog/process/foo")
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["type"] != "process" || resp.Data["memory_bytes"] != int64(1<<28) || resp.Data["max_restarts"] != int64(5) {
+		t.Fatalf("bad: %#v", resp.Data)
+	}
+
+	// Container options are rejected
+	req = logical.TestRequest(t, logical.UpdateOperation, "plugins/runtimes/catalog/process/bar")
+	req.Data = map[string]interface{}{
+		"oci_runtime": "runsc",
+	}
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.IsError() || !strings.Contains(resp.Error().Error(), "only supported by container runtimes") {
+		t.Fatalf("expected error, got: %#v", resp)
+	}
+}
+
 func TestGetSealBackendStatus(t *testing.T) {
 	testCases := []struct {
 		name          string
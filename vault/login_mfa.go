@@ -31,6 +31,7 @@ import (
 	"github.com/hashicorp/vault/helper/identity/mfa"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/cidrutil"
 	"github.com/hashicorp/vault/sdk/helper/identitytpl"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 	"github.com/hashicorp/vault/sdk/helper/parseutil"
@@ -734,7 +735,7 @@ func (b *LoginMFABackend) handleMFALoginValidate(ctx context.Context, req *logic
 	}
 
 	// finding the MFAEnforcement config that matches our ns. ns could be root as well
-	matchedMfaEnforcementList, err := b.Core.buildMFAEnforcementConfigList(ctx, entity, cachedResponseAuth.RequestPath)
+	matchedMfaEnforcementList, mfaEnforcementResults, err := b.Core.buildMFAEnforcementConfigList(ctx, entity, cachedResponseAuth.RequestPath, cachedResponseAuth.RequestConnRemoteAddr, cachedResponseAuth.RequestListenerAddress)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find MFAEnforcement configuration")
 	}
@@ -755,6 +756,9 @@ func (b *LoginMFABackend) handleMFALoginValidate(ctx context.Context, req *logic
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a token. error: %v", err)
 	}
+	if resp != nil && resp.Auth != nil {
+		resp.Auth.MFAEnforcementResults = mfaEnforcementResults
+	}
 
 	return resp, nil
 }
@@ -993,6 +997,17 @@ func (i *IdentityStore) handleMFALoginEnforcementUpdate(ctx context.Context, req
 		oneOfLastFour = true
 	}
 
+	if sourceCIDRs, ok := d.GetOk("source_cidrs"); ok {
+		if _, err := parseutil.ParseAddrs(sourceCIDRs.([]string)); err != nil {
+			return logical.ErrorResponse("invalid source_cidrs: %v", err), nil
+		}
+		eConfig.SourceCIDRs = sourceCIDRs.([]string)
+	}
+
+	if listenerAddresses, ok := d.GetOk("listener_addresses"); ok {
+		eConfig.ListenerAddresses = listenerAddresses.([]string)
+	}
+
 	if !oneOfLastFour {
 		return logical.ErrorResponse("One of auth_method_accessors, auth_method_types, identity_group_ids, identity_entity_ids must be specified"), nil
 	}
@@ -1414,6 +1429,8 @@ func (b *LoginMFABackend) mfaLoginEnforcementConfigToMap(eConfig *mfa.MFAEnforce
 	resp["auth_method_types"] = append([]string{}, eConfig.AuthMethodTypes...)
 	resp["identity_group_ids"] = append([]string{}, eConfig.IdentityGroupIds...)
 	resp["identity_entity_ids"] = append([]string{}, eConfig.IdentityEntityIDs...)
+	resp["source_cidrs"] = append([]string{}, eConfig.SourceCIDRs...)
+	resp["listener_addresses"] = append([]string{}, eConfig.ListenerAddresses...)
 	resp["id"] = eConfig.ID
 	return resp, nil
 }
@@ -1714,23 +1731,28 @@ func (c *Core) validateLoginMFAInternal(ctx context.Context, methodID string, en
 	}
 }
 
-func (c *Core) buildMFAEnforcementConfigList(ctx context.Context, entity *identity.Entity, reqPath string) ([]*mfa.MFAEnforcementConfig, error) {
+// buildMFAEnforcementConfigList returns the MFA enforcements that apply to a
+// login of the given entity on the given path, received from remoteAddr over
+// the listener with the given address. It also returns, for auditing, the
+// result of evaluating the network conditions of every enforcement targeting
+// the login.
+func (c *Core) buildMFAEnforcementConfigList(ctx context.Context, entity *identity.Entity, reqPath, remoteAddr, listenerAddr string) ([]*mfa.MFAEnforcementConfig, []*logical.MFAEnforcementResult, error) {
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get namespace from context. %s, %v", "error", err)
+		return nil, nil, fmt.Errorf("failed to get namespace from context. %s, %v", "error", err)
 	}
 
 	eConfigIter, err := c.loginMFABackend.MemDBMFALoginEnforcementConfigIterator()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	me := c.router.MatchingMountEntry(ctx, reqPath)
 	if me == nil {
-		return nil, fmt.Errorf("failed to find matching mount entry for path %v", reqPath)
+		return nil, nil, fmt.Errorf("failed to find matching mount entry for path %v", reqPath)
 	}
 
-	var matchedMfaEnforcementConfig []*mfa.MFAEnforcementConfig
+	var targetingMfaEnforcementConfig []*mfa.MFAEnforcementConfig
 	// finding the MFAEnforcement config that matches our ns. ns could be root as well
 ECONFIG_LOOP:
 	for eConfigRaw := eConfigIter.Next(); eConfigRaw != nil; eConfigRaw = eConfigIter.Next() {
@@ -1740,7 +1762,7 @@ ECONFIG_LOOP:
 		// i.e. is it the req's ns or an ancestor of req's ns?
 		eConfigNS, err := c.NamespaceByID(ctx, eConfig.NamespaceID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to find the MFAEnforcementConfig namespace")
+			return nil, nil, fmt.Errorf("failed to find the MFAEnforcementConfig namespace")
 		}
 
 		if eConfig == nil || eConfigNS == nil || (eConfigNS.ID != ns.ID && !ns.HasParent(eConfigNS)) {
@@ -1751,29 +1773,29 @@ ECONFIG_LOOP:
 		// having mount type/accessor
 		if entity != nil {
 			if entity.NamespaceID != ns.ID {
-				return nil, fmt.Errorf("entity namespace ID is different than the current ns ID")
+				return nil, nil, fmt.Errorf("entity namespace ID is different than the current ns ID")
 			}
 
 			// Check if entityID is in the MFAEnforcement config
 			if strutil.StrListContains(eConfig.IdentityEntityIDs, entity.ID) {
-				matchedMfaEnforcementConfig = append(matchedMfaEnforcementConfig, eConfig)
+				targetingMfaEnforcementConfig = append(targetingMfaEnforcementConfig, eConfig)
 				continue
 			}
 
 			// Retrieve entity groups
 			directGroups, inheritedGroups, err := c.identityStore.groupsByEntityID(entity.ID)
 			if err != nil {
-				return nil, fmt.Errorf("error on retrieving groups by entityID in MFA")
+				return nil, nil, fmt.Errorf("error on retrieving groups by entityID in MFA")
 			}
 			for _, g := range directGroups {
 				if strutil.StrListContains(eConfig.IdentityGroupIds, g.ID) {
-					matchedMfaEnforcementConfig = append(matchedMfaEnforcementConfig, eConfig)
+					targetingMfaEnforcementConfig = append(targetingMfaEnforcementConfig, eConfig)
 					continue ECONFIG_LOOP
 				}
 			}
 			for _, g := range inheritedGroups {
 				if strutil.StrListContains(eConfig.IdentityGroupIds, g.ID) {
-					matchedMfaEnforcementConfig = append(matchedMfaEnforcementConfig, eConfig)
+					targetingMfaEnforcementConfig = append(targetingMfaEnforcementConfig, eConfig)
 					continue ECONFIG_LOOP
 				}
 			}
@@ -1781,20 +1803,64 @@ ECONFIG_LOOP:
 
 		for _, acc := range eConfig.AuthMethodAccessors {
 			if me != nil && me.Accessor == acc {
-				matchedMfaEnforcementConfig = append(matchedMfaEnforcementConfig, eConfig)
+				targetingMfaEnforcementConfig = append(targetingMfaEnforcementConfig, eConfig)
 				continue ECONFIG_LOOP
 			}
 		}
 
 		for _, authT := range eConfig.AuthMethodTypes {
 			if me != nil && me.Type == authT {
-				matchedMfaEnforcementConfig = append(matchedMfaEnforcementConfig, eConfig)
+				targetingMfaEnforcementConfig = append(targetingMfaEnforcementConfig, eConfig)
 				continue ECONFIG_LOOP
 			}
 		}
 	}
 
-	return matchedMfaEnforcementConfig, nil
+	var matchedMfaEnforcementConfig []*mfa.MFAEnforcementConfig
+	var results []*logical.MFAEnforcementResult
+	for _, eConfig := range targetingMfaEnforcementConfig {
+		result := mfaEnforcementNetworkResult(eConfig, remoteAddr, listenerAddr)
+		results = append(results, result)
+		if result.Applied {
+			matchedMfaEnforcementConfig = append(matchedMfaEnforcementConfig, eConfig)
+		}
+	}
+
+	return matchedMfaEnforcementConfig, results, nil
+}
+
+// mfaEnforcementNetworkResult evaluates the network conditions of an MFA
+// enforcement: it only applies to logins received from one of its source
+// CIDRs, if any, over one of its listeners, if any.
+func mfaEnforcementNetworkResult(eConfig *mfa.MFAEnforcementConfig, remoteAddr, listenerAddr string) *logical.MFAEnforcementResult {
+	result := &logical.MFAEnforcementResult{
+		Name:    eConfig.Name,
+		Applied: true,
+	}
+
+	var reasons []string
+	if len(eConfig.SourceCIDRs) > 0 {
+		// The CIDRs were validated when the enforcement was written
+		sourceCIDRs, _ := parseutil.ParseAddrs(eConfig.SourceCIDRs)
+		if remoteAddr == "" || !cidrutil.RemoteAddrIsOk(remoteAddr, sourceCIDRs) {
+			result.Applied = false
+			result.Reason = fmt.Sprintf("source address %q is not in source_cidrs", remoteAddr)
+			return result
+		}
+		reasons = append(reasons, fmt.Sprintf("source address %q is in source_cidrs", remoteAddr))
+	}
+
+	if len(eConfig.ListenerAddresses) > 0 {
+		if !strutil.StrListContains(eConfig.ListenerAddresses, listenerAddr) {
+			result.Applied = false
+			result.Reason = fmt.Sprintf("listener %q is not in listener_addresses", listenerAddr)
+			return result
+		}
+		reasons = append(reasons, fmt.Sprintf("listener %q is in listener_addresses", listenerAddr))
+	}
+
+	result.Reason = strings.Join(reasons, ", ")
+	return result
 }
 
 func formatUsername(format string, alias *identity.Alias, entity *identity.Entity) string {
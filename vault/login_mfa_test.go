@@ -6,6 +6,8 @@ package vault
 import (
 	"strings"
 	"testing"
+
+	"github.com/hashicorp/vault/helper/identity/mfa"
 )
 
 func TestParseFactors(t *testing.T) {
@@ -62,3 +64,44 @@ func TestParseFactors(t *testing.T) {
 		})
 	}
 }
+
+func TestMFAEnforcementNetworkResult(t *testing.T) {
+	testcases := []struct {
+		name         string
+		sourceCIDRs  []string
+		listeners    []string
+		remoteAddr   string
+		listenerAddr string
+		applied      bool
+	}{
+		{"no conditions", nil, nil, "10.0.0.1", "127.0.0.1:8200", true},
+		{"source in cidrs", []string{"192.168.0.0/16", "10.0.0.0/8"}, nil, "10.0.0.1", "", true},
+		{"source not in cidrs", []string{"192.168.0.0/16"}, nil, "10.0.0.1", "", false},
+		{"single address", []string{"10.0.0.1"}, nil, "10.0.0.1", "", true},
+		{"unknown source", []string{"10.0.0.0/8"}, nil, "", "", false},
+		{"listener matches", nil, []string{"0.0.0.0:8200"}, "10.0.0.1", "0.0.0.0:8200", true},
+		{"listener does not match", nil, []string{"0.0.0.0:8200"}, "10.0.0.1", "127.0.0.1:8201", false},
+		{"both match", []string{"10.0.0.0/8"}, []string{"0.0.0.0:8200"}, "10.0.0.1", "0.0.0.0:8200", true},
+		{"listener only matches", []string{"192.168.0.0/16"}, []string{"0.0.0.0:8200"}, "10.0.0.1", "0.0.0.0:8200", false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			eConfig := &mfa.MFAEnforcementConfig{
+				Name:              "enforcement",
+				SourceCIDRs:       tc.sourceCIDRs,
+				ListenerAddresses: tc.listeners,
+			}
+			result := mfaEnforcementNetworkResult(eConfig, tc.remoteAddr, tc.listenerAddr)
+			if result.Name != "enforcement" {
+				t.Fatalf("unexpected name %q", result.Name)
+			}
+			if result.Applied != tc.applied {
+				t.Fatalf("expected applied %t, got %t: %s", tc.applied, result.Applied, result.Reason)
+			}
+			if !result.Applied && result.Reason == "" {
+				t.Fatal("expected a reason for the enforcement not applying")
+			}
+		})
+	}
+}
@@ -368,6 +368,7 @@ type MountConfig struct {
 	UserLockoutConfig         *UserLockoutConfig    `json:"user_lockout_config,omitempty" mapstructure:"user_lockout_config"`
 	DelegatedAuthAccessors    []string              `json:"delegated_auth_accessors,omitempty" mapstructure:"delegated_auth_accessors"`
 	IdentityTokenKey          string                `json:"identity_token_key,omitempty" mapstructure:"identity_token_key"`
+	PluginUpgradeWindow       *PluginUpgradeWindow  `json:"plugin_upgrade_window,omitempty" mapstructure:"plugin_upgrade_window"`
 
 	// PluginName is the name of the plugin registered in the catalog.
 	//
@@ -395,20 +396,21 @@ type APIUserLockoutConfig struct {
 
 // APIMountConfig is an embedded struct of api.MountConfigInput
 type APIMountConfig struct {
-	DefaultLeaseTTL           string                `json:"default_lease_ttl" structs:"default_lease_ttl" mapstructure:"default_lease_ttl"`
-	MaxLeaseTTL               string                `json:"max_lease_ttl" structs:"max_lease_ttl" mapstructure:"max_lease_ttl"`
-	ForceNoCache              bool                  `json:"force_no_cache" structs:"force_no_cache" mapstructure:"force_no_cache"`
-	AuditNonHMACRequestKeys   []string              `json:"audit_non_hmac_request_keys,omitempty" structs:"audit_non_hmac_request_keys" mapstructure:"audit_non_hmac_request_keys"`
-	AuditNonHMACResponseKeys  []string              `json:"audit_non_hmac_response_keys,omitempty" structs:"audit_non_hmac_response_keys" mapstructure:"audit_non_hmac_response_keys"`
-	ListingVisibility         ListingVisibilityType `json:"listing_visibility,omitempty" structs:"listing_visibility" mapstructure:"listing_visibility"`
-	PassthroughRequestHeaders []string              `json:"passthrough_request_headers,omitempty" structs:"passthrough_request_headers" mapstructure:"passthrough_request_headers"`
-	AllowedResponseHeaders    []string              `json:"allowed_response_headers,omitempty" structs:"allowed_response_headers" mapstructure:"allowed_response_headers"`
-	TokenType                 string                `json:"token_type" structs:"token_type" mapstructure:"token_type"`
-	AllowedManagedKeys        []string              `json:"allowed_managed_keys,omitempty" mapstructure:"allowed_managed_keys"`
-	UserLockoutConfig         *UserLockoutConfig    `json:"user_lockout_config,omitempty" mapstructure:"user_lockout_config"`
-	PluginVersion             string                `json:"plugin_version,omitempty" mapstructure:"plugin_version"`
-	DelegatedAuthAccessors    []string              `json:"delegated_auth_accessors,omitempty" mapstructure:"delegated_auth_accessors"`
-	IdentityTokenKey          string                `json:"identity_token_key,omitempty" mapstructure:"identity_token_key"`
+	DefaultLeaseTTL           string                  `json:"default_lease_ttl" structs:"default_lease_ttl" mapstructure:"default_lease_ttl"`
+	MaxLeaseTTL               string                  `json:"max_lease_ttl" structs:"max_lease_ttl" mapstructure:"max_lease_ttl"`
+	ForceNoCache              bool                    `json:"force_no_cache" structs:"force_no_cache" mapstructure:"force_no_cache"`
+	AuditNonHMACRequestKeys   []string                `json:"audit_non_hmac_request_keys,omitempty" structs:"audit_non_hmac_request_keys" mapstructure:"audit_non_hmac_request_keys"`
+	AuditNonHMACResponseKeys  []string                `json:"audit_non_hmac_response_keys,omitempty" structs:"audit_non_hmac_response_keys" mapstructure:"audit_non_hmac_response_keys"`
+	ListingVisibility         ListingVisibilityType   `json:"listing_visibility,omitempty" structs:"listing_visibility" mapstructure:"listing_visibility"`
+	PassthroughRequestHeaders []string                `json:"passthrough_request_headers,omitempty" structs:"passthrough_request_headers" mapstructure:"passthrough_request_headers"`
+	AllowedResponseHeaders    []string                `json:"allowed_response_headers,omitempty" structs:"allowed_response_headers" mapstructure:"allowed_response_headers"`
+	TokenType                 string                  `json:"token_type" structs:"token_type" mapstructure:"token_type"`
+	AllowedManagedKeys        []string                `json:"allowed_managed_keys,omitempty" mapstructure:"allowed_managed_keys"`
+	UserLockoutConfig         *UserLockoutConfig      `json:"user_lockout_config,omitempty" mapstructure:"user_lockout_config"`
+	PluginVersion             string                  `json:"plugin_version,omitempty" mapstructure:"plugin_version"`
+	DelegatedAuthAccessors    []string                `json:"delegated_auth_accessors,omitempty" mapstructure:"delegated_auth_accessors"`
+	IdentityTokenKey          string                  `json:"identity_token_key,omitempty" mapstructure:"identity_token_key"`
+	PluginUpgradeWindow       *APIPluginUpgradeWindow `json:"plugin_upgrade_window,omitempty" mapstructure:"plugin_upgrade_window"`
 
 	// PluginName is the name of the plugin registered in the catalog.
 	//
@@ -592,6 +594,15 @@ func (c *Core) mount(ctx context.Context, entry *MountEntry) error {
 		}
 	}
 
+	// Paths of trashed mounts are reserved until they are restored or purged
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.trashedMountConflict(ctx, ns.ID, entry.Path); err != nil {
+		return err
+	}
+
 	// Mount internally
 	if err := c.mountInternal(ctx, entry, MountTableUpdateStorage); err != nil {
 		return err
@@ -761,7 +772,7 @@ func (c *Core) mountInternal(ctx context.Context, entry *MountEntry, updateStora
 
 		unlock()
 		// We failed to evaluate filtered paths so we are undoing the mount operation
-		if unmountInternalErr := c.unmountInternal(ctx, entry.Path, MountTableUpdateStorage); unmountInternalErr != nil {
+		if unmountInternalErr := c.unmountInternal(ctx, entry.Path, MountTableUpdateStorage, 0); unmountInternalErr != nil {
 			c.logger.Error("failed to unmount", "error", unmountInternalErr)
 		}
 		return err
@@ -842,8 +853,14 @@ func (c *Core) unmount(ctx context.Context, path string) error {
 		}
 	}
 
+	// Retain the storage of the mount in the trash if configured
+	trashConfig, err := c.mountTrashConfig(ctx)
+	if err != nil {
+		return err
+	}
+
 	// Unmount mount internally
-	if err := c.unmountInternal(ctx, path, MountTableUpdateStorage); err != nil {
+	if err := c.unmountInternal(ctx, path, MountTableUpdateStorage, trashConfig.RetentionPeriod); err != nil {
 		return err
 	}
 
@@ -855,7 +872,10 @@ func (c *Core) unmount(ctx context.Context, path string) error {
 	return nil
 }
 
-func (c *Core) unmountInternal(ctx context.Context, path string, updateStorage bool) error {
+// unmountInternal unmounts the path. If the retention period is positive, the
+// storage and leases of the mount are retained in the trash instead of being
+// removed.
+func (c *Core) unmountInternal(ctx context.Context, path string, updateStorage bool, retention time.Duration) error {
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
 		return err
@@ -875,6 +895,12 @@ func (c *Core) unmountInternal(ctx context.Context, path string, updateStorage b
 	backend := c.router.MatchingBackend(ctx, path)
 	entry := c.router.MatchingMountEntry(ctx, path)
 
+	retain := updateStorage && retention > 0
+	if retain {
+		c.mountTrashLock.Lock()
+		defer c.mountTrashLock.Unlock()
+	}
+
 	// Mark the entry as tainted
 	if err := c.taintMountEntry(ctx, ns.ID, path, updateStorage, true); err != nil {
 		c.logger.Error("failed to taint mount entry for path being unmounted", "error", err, "path", path)
@@ -898,7 +924,7 @@ func (c *Core) unmountInternal(ctx context.Context, path string, updateStorage b
 			err = nil
 		}
 	}
-	if backend != nil && c.expiration != nil && updateStorage {
+	if backend != nil && c.expiration != nil && updateStorage && !retain {
 		// Revoke all the dynamic keys
 		if err := c.expiration.RevokePrefix(rCtx, path, true); err != nil {
 			return err
@@ -914,6 +940,13 @@ func (c *Core) unmountInternal(ctx context.Context, path string, updateStorage b
 	switch {
 	case !updateStorage:
 		// Don't attempt to clear data, replication will handle this
+	case retain:
+		// Keep the data and leases in the trash until the mount is restored
+		// or purged
+		if err := c.trashMountEntry(ctx, entry, retention); err != nil {
+			c.logger.Error("failed to trash mount entry for path being unmounted", "error", err, "path", path)
+			return err
+		}
 	case c.IsDRSecondary():
 		// If we are a dr secondary we want to clear the view, but the provided
 		// view is marked as read only. We use the barrier here to get around
@@ -929,6 +962,10 @@ func (c *Core) unmountInternal(ctx context.Context, path string, updateStorage b
 			c.logger.Error("failed to clear view for path being unmounted", "error", err, "path", path)
 			return err
 		}
+		if err := c.deleteSecretCounters(ctx, entry.Accessor); err != nil {
+			c.logger.Error("failed to delete secret counters for path being unmounted", "error", err, "path", path)
+			return err
+		}
 
 	case !entry.Local && c.IsPerfSecondary():
 		if err := clearIgnoredPaths(ctx, c, backend, viewPath); err != nil {
@@ -1098,7 +1135,7 @@ func (c *Core) remountForceInternal(ctx context.Context, path string, updateStor
 		return err
 	}
 
-	if err := c.unmountInternal(ctx, path, updateStorage); err != nil {
+	if err := c.unmountInternal(ctx, path, updateStorage, 0); err != nil {
 		return err
 	}
 
@@ -1151,6 +1188,9 @@ func (c *Core) remountSecretsEngine(ctx context.Context, src, dst namespace.Moun
 	if match := c.router.MountConflict(ctx, dstRelativePath); match != "" {
 		return fmt.Errorf("path in use at %q", match)
 	}
+	if err := c.trashedMountConflict(ctx, dst.Namespace.ID, dst.MountPath); err != nil {
+		return err
+	}
 
 	// Mark the entry as tainted
 	if err := c.taintMountEntry(ctx, src.Namespace.ID, src.MountPath, updateStorage, false); err != nil {
@@ -1175,9 +1215,7 @@ func (c *Core) remountSecretsEngine(ctx context.Context, src, dst namespace.Moun
 			}
 		}
 
-		revokeCtx := namespace.ContextWithNamespace(ctx, src.Namespace)
-		// Revoke all the dynamic keys
-		if err := c.expiration.RevokePrefix(revokeCtx, src.MountPath, true); err != nil {
+		if err := c.migrateMountLeases(ctx, src, dst); err != nil {
 			return err
 		}
 	}
@@ -1199,6 +1237,9 @@ func (c *Core) remountSecretsEngine(ctx context.Context, src, dst namespace.Moun
 		srcMatch.Path = srcPath
 		srcMatch.Tainted = true
 		c.mountsLock.Unlock()
+		if !c.IsDRSecondary() {
+			c.restoreMountLeases(ctx, src, dst)
+		}
 		if err == logical.ErrReadOnly && c.perfStandby {
 			return err
 		}
@@ -1221,6 +1262,32 @@ func (c *Core) remountSecretsEngine(ctx context.Context, src, dst namespace.Moun
 	return nil
 }
 
+// migrateMountLeases moves the leases of a mount being moved from src to dst.
+// Lease IDs are tied to their namespace, so the leases of a mount moved to
+// another namespace are revoked instead.
+func (c *Core) migrateMountLeases(ctx context.Context, src, dst namespace.MountPathDetails) error {
+	leaseCtx := namespace.ContextWithNamespace(ctx, src.Namespace)
+	if src.Namespace.ID != dst.Namespace.ID {
+		// Revoke all the dynamic keys
+		return c.expiration.RevokePrefix(leaseCtx, src.MountPath, true)
+	}
+
+	return c.expiration.migratePrefix(leaseCtx, src.MountPath, dst.MountPath)
+}
+
+// restoreMountLeases moves the leases of a mount back to src, if the mount
+// could not be moved to dst after its leases were.
+func (c *Core) restoreMountLeases(ctx context.Context, src, dst namespace.MountPathDetails) {
+	if src.Namespace.ID != dst.Namespace.ID {
+		return
+	}
+
+	leaseCtx := namespace.ContextWithNamespace(ctx, src.Namespace)
+	if err := c.expiration.migratePrefix(leaseCtx, dst.MountPath, src.MountPath); err != nil {
+		c.logger.Error("failed to move back leases after failing to move mount", "path", src.Namespace.Path+src.MountPath, "error", err)
+	}
+}
+
 // From an input path that has a relative namespace hierarchy followed by a mount point, return the full
 // namespace of the mount point, along with the mount point without the namespace related prefix.
 // For example, in a hierarchy ns1/ns2/ns3/secret-mount, when currNs is ns1 and path is ns2/ns3/secret-mount,
@@ -1496,7 +1563,9 @@ func (c *Core) setupMounts(ctx context.Context) error {
 	c.mountsLock.Lock()
 	defer c.mountsLock.Unlock()
 
-	for _, entry := range c.mounts.sortEntriesByPathDepth().Entries {
+	entries := c.mounts.sortEntriesByPathDepth().Entries
+	setups := make([]*mountSetup, 0, len(entries))
+	for _, entry := range entries {
 		// Initialize the backend, special casing for system
 		barrierPath := entry.ViewPath()
 
@@ -1533,10 +1602,23 @@ func (c *Core) setupMounts(ctx context.Context) error {
 			defer view.setReadOnlyErr(origReadOnlyErr)
 		}
 
-		var backend logical.Backend
-		// Create the new backend
-		sysView := c.mountEntrySysView(entry)
-		backend, err = c.newLogicalBackend(ctx, entry, sysView, view)
+		setups = append(setups, &mountSetup{
+			entry:           entry,
+			view:            view,
+			nilMount:        nilMount,
+			origReadOnlyErr: origReadOnlyErr,
+		})
+	}
+
+	// Create the new backends
+	c.createMountBackends(ctx, setups, c.newLogicalBackend)
+	defer cleanupUnmountedBackends(ctx, setups)
+
+	for _, setup := range setups {
+		entry, view, nilMount, origReadOnlyErr := setup.entry, setup.view, setup.nilMount, setup.origReadOnlyErr
+		barrierPath := entry.ViewPath()
+
+		backend, err := setup.backend, setup.err
 		if err != nil {
 			c.logger.Error("failed to create mount entry", "path", entry.Path, "error", err)
 
@@ -1597,6 +1679,7 @@ func (c *Core) setupMounts(ctx context.Context) error {
 			c.logger.Error("failed to mount entry", "path", entry.Path, "error", err)
 			return errLoadMountsFailed
 		}
+		setup.mounted = true
 
 		// Initialize
 		if !nilMount {
@@ -1621,7 +1704,7 @@ func (c *Core) setupMounts(ctx context.Context) error {
 		}
 
 		if c.logger.IsInfo() {
-			c.logger.Info("successfully mounted", "type", entry.Type, "version", entry.RunningVersion, "path", entry.Path, "namespace", entry.Namespace())
+			c.logger.Info("successfully mounted", "type", entry.Type, "version", entry.RunningVersion, "path", entry.Path, "namespace", entry.Namespace(), "duration", setup.duration)
 		}
 
 		// Ensure the path is tainted if set in the mount table
@@ -1639,6 +1722,13 @@ func (c *Core) setupMounts(ctx context.Context) error {
 	return nil
 }
 
+// UnavailableMounts returns the number of secrets engine and auth method
+// mounts which have no backend, e.g. because their plugin could not be started
+// during unseal.
+func (c *Core) UnavailableMounts() int {
+	return c.router.unavailableMounts()
+}
+
 // unloadMounts is used before we seal the vault to reset the mounts to
 // their unloaded state, calling Cleanup if defined. This is reversed by load and setup mounts.
 func (c *Core) unloadMounts(ctx context.Context) error {
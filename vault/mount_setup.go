@@ -0,0 +1,120 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// EnvVaultMountSetupConcurrency is the number of mounts whose backends are
+// created concurrently while the mount and auth tables are set up.
+const EnvVaultMountSetupConcurrency = "VAULT_MOUNT_SETUP_CONCURRENCY"
+
+// mountSetup is the state of a mount entry while the mount or auth table is
+// set up.
+type mountSetup struct {
+	entry           *MountEntry
+	view            *BarrierView
+	nilMount        bool
+	origReadOnlyErr error
+
+	// backend and err are the result of creating the backend of the entry,
+	// which took duration
+	backend  logical.Backend
+	err      error
+	duration time.Duration
+
+	// mounted is set once the backend is mounted in the router, which then
+	// owns its cleanup
+	mounted bool
+}
+
+// mountBackendFactory creates the backend of a mount entry, e.g.
+// newLogicalBackend or newCredentialBackend.
+type mountBackendFactory func(context.Context, *MountEntry, logical.SystemView, logical.Storage) (logical.Backend, error)
+
+// mountSetupConcurrency returns the number of mount backends created
+// concurrently during setup.
+func (c *Core) mountSetupConcurrency() int {
+	concurrency := runtime.NumCPU() * 2
+	if v := os.Getenv(EnvVaultMountSetupConcurrency); v != "" {
+		pv, err := strconv.Atoi(v)
+		if err != nil || pv < 1 {
+			c.logger.Warn("invalid value for "+EnvVaultMountSetupConcurrency+", must be a positive integer", "error", err, "value", pv)
+		} else {
+			concurrency = pv
+		}
+	}
+	return concurrency
+}
+
+// createMountBackends creates the backends of the mounts with newBackend.
+// Singleton mounts such as the identity and token stores are created first,
+// one at a time and in order, as other backends may depend on them. The
+// remaining backends are independent of each other and are created
+// concurrently on a bounded pool of workers.
+func (c *Core) createMountBackends(ctx context.Context, setups []*mountSetup, newBackend mountBackendFactory) {
+	create := func(s *mountSetup) {
+		start := time.Now()
+		s.backend, s.err = newBackend(ctx, s.entry, c.mountEntrySysView(s.entry), s.view)
+		s.duration = time.Since(start)
+		metrics.MeasureSinceWithLabels([]string{"core", "mount_setup"}, start, []metrics.Label{
+			{Name: "type", Value: s.entry.Type},
+			{Name: "mount_point", Value: s.entry.APIPathNoNamespace()},
+		})
+	}
+
+	var pending []*mountSetup
+	for _, s := range setups {
+		if strutil.StrListContains(singletonMounts, s.entry.Type) {
+			create(s)
+			continue
+		}
+		pending = append(pending, s)
+	}
+
+	concurrency := c.mountSetupConcurrency()
+	if concurrency <= 1 {
+		for _, s := range pending {
+			create(s)
+		}
+		return
+	}
+
+	jobs := make(chan *mountSetup)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency && i < len(pending); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range jobs {
+				create(s)
+			}
+		}()
+	}
+	for _, s := range pending {
+		jobs <- s
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// cleanupUnmountedBackends cleans up the backends which were created but not
+// mounted, as setup of the table failed before they were reached.
+func cleanupUnmountedBackends(ctx context.Context, setups []*mountSetup) {
+	for _, s := range setups {
+		if !s.mounted && s.backend != nil {
+			s.backend.Cleanup(ctx)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// TestCore_SetupMounts_Concurrent ensures the backends of independent mounts
+// are created concurrently on unseal, bounded by the mount setup concurrency.
+func TestCore_SetupMounts_Concurrent(t *testing.T) {
+	t.Setenv(EnvVaultMountSetupConcurrency, "4")
+
+	var active, maxActive int32
+	factory := func(context.Context, *logical.BackendConfig) (logical.Backend, error) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		return &NoopBackend{BackendType: logical.TypeLogical}, nil
+	}
+
+	c, keys, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{"slow": factory},
+	})
+	for i := 0; i < 8; i++ {
+		err := c.mount(namespace.RootContext(nil), &MountEntry{
+			Table: mountTableType,
+			Path:  fmt.Sprintf("slow%d/", i),
+			Type:  "slow",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := TestCoreSeal(c); err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(&maxActive, 0)
+	for _, key := range keys {
+		if _, err := TestCoreUnseal(c, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.Sealed() {
+		t.Fatal("should be unsealed")
+	}
+
+	if n := atomic.LoadInt32(&maxActive); n < 2 || n > 4 {
+		t.Fatalf("expected between 2 and 4 backends to be created concurrently, got %d", n)
+	}
+	for i := 0; i < 8; i++ {
+		path := fmt.Sprintf("slow%d/", i)
+		if match := c.router.MatchingMount(namespace.RootContext(nil), path+"foo"); match != path {
+			t.Fatalf("expected %q to be mounted, got %q", path, match)
+		}
+	}
+}
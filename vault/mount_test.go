@@ -682,12 +682,32 @@ func TestCore_Remount_Cleanup(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	// Rollback should be invoked
-	if noop.Requests[1].Operation != logical.RollbackOperation {
+	// Rollback should be invoked, but not revoke
+	if len(noop.Requests) != 2 || noop.Requests[1].Operation != logical.RollbackOperation {
 		t.Fatalf("bad: %#v", noop.Requests)
 	}
 
-	// Revoke should be invoked
+	// The lease should have moved with the mount
+	le, err := c.expiration.loadEntry(namespace.RootContext(nil), resp.Secret.LeaseID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if le != nil {
+		t.Fatalf("bad: %#v", le)
+	}
+	leaseID := "new/" + strings.TrimPrefix(resp.Secret.LeaseID, "test/")
+	le, err = c.expiration.loadEntry(namespace.RootContext(nil), leaseID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if le == nil || le.Path != "new/foo" {
+		t.Fatalf("bad: %#v", le)
+	}
+
+	// Revoking the lease should reach the mount at its new path
+	if err := c.expiration.Revoke(namespace.RootContext(nil), leaseID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
 	if noop.Requests[2].Operation != logical.RevokeOperation {
 		t.Fatalf("bad: %#v", noop.Requests)
 	}
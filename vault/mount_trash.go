@@ -0,0 +1,298 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// coreMountTrashConfigPath is the storage path of the mount trash
+	// configuration.
+	coreMountTrashConfigPath = "core/mount-trash/config"
+
+	// coreMountTrashEntriesPath is the storage prefix of the mounts retained
+	// in the trash, keyed by mount accessor.
+	coreMountTrashEntriesPath = "core/mount-trash/entries/"
+
+	// mountTrashCheckInterval is the interval at which the trash is checked
+	// for mounts past their retention period.
+	mountTrashCheckInterval = 10 * time.Minute
+)
+
+var errMountTrashNotFound = errors.New("no trashed mount with this accessor")
+
+// MountTrashConfig is the mount trash configuration.
+type MountTrashConfig struct {
+	// RetentionPeriod is the duration for which the storage of disabled
+	// secrets engines is retained. Disabled secrets engines are purged
+	// immediately if zero.
+	RetentionPeriod time.Duration `json:"retention_period"`
+}
+
+// trashedMount is a disabled secrets engine whose storage and leases are
+// retained until it is restored or purged.
+type trashedMount struct {
+	Entry       *MountEntry `json:"entry"`
+	DeletedTime time.Time   `json:"deleted_time"`
+	PurgeTime   time.Time   `json:"purge_time"`
+}
+
+func (c *Core) mountTrashConfig(ctx context.Context) (*MountTrashConfig, error) {
+	config := new(MountTrashConfig)
+
+	entry, err := c.barrier.Get(ctx, coreMountTrashConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mount trash config: %w", err)
+	}
+	if entry == nil {
+		return config, nil
+	}
+
+	if err := jsonutil.DecodeJSON(entry.Value, config); err != nil {
+		return nil, fmt.Errorf("failed to decode mount trash config: %w", err)
+	}
+	return config, nil
+}
+
+// trashedMount returns the trashed mount with the given accessor, or nil if
+// there is none.
+func (c *Core) trashedMount(ctx context.Context, accessor string) (*trashedMount, error) {
+	entry, err := c.barrier.Get(ctx, coreMountTrashEntriesPath+accessor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trashed mount: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	trashed := new(trashedMount)
+	if err := jsonutil.DecodeJSON(entry.Value, trashed); err != nil {
+		return nil, fmt.Errorf("failed to decode trashed mount: %w", err)
+	}
+	return trashed, nil
+}
+
+// trashedMounts returns the trashed mounts of all namespaces, sorted by
+// accessor.
+func (c *Core) trashedMounts(ctx context.Context) ([]*trashedMount, error) {
+	accessors, err := c.barrier.List(ctx, coreMountTrashEntriesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trashed mounts: %w", err)
+	}
+	sort.Strings(accessors)
+
+	var trashed []*trashedMount
+	for _, accessor := range accessors {
+		t, err := c.trashedMount(ctx, accessor)
+		if err != nil {
+			return nil, err
+		}
+		if t != nil {
+			trashed = append(trashed, t)
+		}
+	}
+	return trashed, nil
+}
+
+// trashedMountsInNamespace returns the trashed mounts of the namespace.
+func (c *Core) trashedMountsInNamespace(ctx context.Context, nsID string) ([]*trashedMount, error) {
+	trashed, err := c.trashedMounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var inNamespace []*trashedMount
+	for _, t := range trashed {
+		if t.Entry.NamespaceID == nsID {
+			inNamespace = append(inNamespace, t)
+		}
+	}
+	return inNamespace, nil
+}
+
+// trashedMountConflict returns an error if the path overlaps the path of a
+// trashed mount of the namespace. The path of a trashed mount is reserved so
+// that its retained leases are never routed to another mount.
+func (c *Core) trashedMountConflict(ctx context.Context, nsID, path string) error {
+	trashed, err := c.trashedMountsInNamespace(ctx, nsID)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range trashed {
+		if strings.HasPrefix(t.Entry.Path, path) || strings.HasPrefix(path, t.Entry.Path) {
+			return logical.CodedError(409, fmt.Sprintf("path is reserved by trashed mount %s at %s; restore or purge it first", t.Entry.Accessor, t.Entry.Path))
+		}
+	}
+	return nil
+}
+
+// trashMountEntry adds the mount entry being unmounted to the trash until
+// the retention period elapses.
+func (c *Core) trashMountEntry(ctx context.Context, entry *MountEntry, retention time.Duration) error {
+	clone, err := entry.Clone()
+	if err != nil {
+		return err
+	}
+	clone.Tainted = false
+	clone.MountState = ""
+
+	now := time.Now().UTC()
+	se, err := logical.StorageEntryJSON(coreMountTrashEntriesPath+entry.Accessor, &trashedMount{
+		Entry:       clone,
+		DeletedTime: now,
+		PurgeTime:   now.Add(retention),
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.barrier.Put(ctx, se); err != nil {
+		return fmt.Errorf("failed to persist trashed mount: %w", err)
+	}
+	return nil
+}
+
+// restoreTrashedMount mounts the trashed mount with the given accessor again
+// at its original path, with its retained storage and leases.
+func (c *Core) restoreTrashedMount(ctx context.Context, accessor string) (*MountEntry, error) {
+	c.mountTrashLock.Lock()
+	defer c.mountTrashLock.Unlock()
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	trashed, err := c.trashedMount(ctx, accessor)
+	if err != nil {
+		return nil, err
+	}
+	if trashed == nil || trashed.Entry.NamespaceID != ns.ID {
+		return nil, errMountTrashNotFound
+	}
+
+	if c.router.MatchingMountByAccessor(accessor) != nil {
+		return nil, logical.CodedError(409, fmt.Sprintf("accessor %s is in use by another mount", accessor))
+	}
+
+	entry := trashed.Entry
+	if err := c.mountInternal(ctx, entry, MountTableUpdateStorage); err != nil {
+		return nil, err
+	}
+
+	if err := c.barrier.Delete(ctx, coreMountTrashEntriesPath+accessor); err != nil {
+		return nil, fmt.Errorf("failed to remove restored mount from the trash: %w", err)
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("restored trashed mount", "path", entry.Path, "namespace", ns.Path, "accessor", accessor)
+	}
+	return entry, nil
+}
+
+// purgeTrashedMount removes the retained storage and leases of the trashed
+// mount with the given accessor. Leases cannot be revoked through the backend
+// of a trashed mount, so they are force-revoked.
+func (c *Core) purgeTrashedMount(ctx context.Context, accessor string) error {
+	c.mountTrashLock.Lock()
+	defer c.mountTrashLock.Unlock()
+
+	trashed, err := c.trashedMount(ctx, accessor)
+	if err != nil {
+		return err
+	}
+	if trashed == nil {
+		return errMountTrashNotFound
+	}
+	entry := trashed.Entry
+
+	ns, err := NamespaceByID(ctx, entry.NamespaceID, c)
+	if err != nil {
+		return err
+	}
+	if ns == nil {
+		return namespace.ErrNoNamespace
+	}
+	nsCtx := namespace.ContextWithNamespace(c.activeContext, ns)
+
+	if c.expiration != nil {
+		if err := c.expiration.RevokeForce(nsCtx, entry.Path); err != nil {
+			return fmt.Errorf("failed to revoke leases of trashed mount: %w", err)
+		}
+	}
+
+	logger := c.logger.Named("secrets.deletion").With("namespace", ns.ID, "path", entry.Path)
+	if err := logical.ClearViewWithLogging(ctx, NewBarrierView(c.barrier, entry.ViewPath()), logger); err != nil {
+		return fmt.Errorf("failed to clear storage of trashed mount: %w", err)
+	}
+	if err := c.deleteSecretCounters(ctx, accessor); err != nil {
+		return err
+	}
+
+	if err := c.barrier.Delete(ctx, coreMountTrashEntriesPath+accessor); err != nil {
+		return fmt.Errorf("failed to remove purged mount from the trash: %w", err)
+	}
+
+	if c.logger.IsInfo() {
+		c.logger.Info("purged trashed mount", "path", entry.Path, "namespace", ns.Path, "accessor", accessor)
+	}
+	return nil
+}
+
+// startMountTrashPurge runs a process which, every mountTrashCheckInterval,
+// purges the trashed mounts past their retention period, until the active
+// context is done.
+func (c *Core) startMountTrashPurge() {
+	if c.mountTrashCancel != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, c.mountTrashCancel = context.WithCancel(namespace.RootContext(c.activeContext))
+
+	go func() {
+		ticker := time.NewTicker(mountTrashCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.runMountTrashPurge(ctx, time.Now()); err != nil {
+					c.logger.Error("failed to purge trashed mounts", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// runMountTrashPurge purges the trashed mounts whose retention period ended
+// at now.
+func (c *Core) runMountTrashPurge(ctx context.Context, now time.Time) error {
+	trashed, err := c.trashedMounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, t := range trashed {
+		if now.Before(t.PurgeTime) {
+			continue
+		}
+		if err := c.purgeTrashedMount(ctx, t.Entry.Accessor); err != nil && !errors.Is(err, errMountTrashNotFound) {
+			errs = errors.Join(errs, fmt.Errorf("failed to purge trashed mount %s: %w", t.Entry.Accessor, err))
+		}
+	}
+	return errs
+}
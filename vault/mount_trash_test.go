@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMountTrash_RestoreAndPurge(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (*logical.Response, error) {
+		return c.HandleRequest(ctx, &logical.Request{
+			Operation:   op,
+			Path:        path,
+			ClientToken: root,
+			Data:        data,
+		})
+	}
+
+	resp, err := request(logical.UpdateOperation, "secret/foo", map[string]interface{}{"foo": "bar"})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	resp, err = request(logical.UpdateOperation, "sys/config/mount-trash", map[string]interface{}{"retention_period": "1h"})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	entry := c.router.MatchingMountEntry(ctx, "secret/")
+	require.NotNil(t, entry)
+	accessor, viewPath := entry.Accessor, entry.ViewPath()
+
+	resp, err = request(logical.DeleteOperation, "sys/mounts/secret", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+	require.Empty(t, c.router.MatchingMount(ctx, "secret/foo"))
+
+	// The storage of the mount is retained
+	keys, err := c.barrier.List(ctx, viewPath)
+	require.NoError(t, err)
+	require.NotEmpty(t, keys)
+
+	resp, err = request(logical.ListOperation, "sys/mounts-trash", nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{accessor}, resp.Data["keys"])
+
+	resp, err = request(logical.ReadOperation, "sys/mounts-trash/"+accessor, nil)
+	require.NoError(t, err)
+	require.Equal(t, "secret/", resp.Data["path"])
+	require.Equal(t, "kv", resp.Data["type"])
+
+	// The path of the trashed mount is reserved
+	err = c.mount(ctx, &MountEntry{
+		Table: mountTableType,
+		Path:  "secret/",
+		Type:  "kv",
+	})
+	require.ErrorContains(t, err, "reserved by trashed mount")
+
+	resp, err = request(logical.UpdateOperation, "sys/mounts-trash/"+accessor+"/restore", nil)
+	require.NoError(t, err)
+	require.Equal(t, "secret/", resp.Data["path"])
+
+	resp, err = request(logical.ReadOperation, "secret/foo", nil)
+	require.NoError(t, err)
+	require.Equal(t, "bar", resp.Data["foo"])
+	require.Equal(t, accessor, c.router.MatchingMountEntry(ctx, "secret/").Accessor)
+
+	trashed, err := c.trashedMounts(ctx)
+	require.NoError(t, err)
+	require.Empty(t, trashed)
+
+	// Trashed mounts are purged once their retention period ends
+	resp, err = request(logical.DeleteOperation, "sys/mounts/secret", nil)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	require.NoError(t, c.runMountTrashPurge(ctx, time.Now()))
+	trashed, err = c.trashedMounts(ctx)
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+
+	require.NoError(t, c.runMountTrashPurge(ctx, time.Now().Add(2*time.Hour)))
+	trashed, err = c.trashedMounts(ctx)
+	require.NoError(t, err)
+	require.Empty(t, trashed)
+
+	keys, err = c.barrier.List(ctx, viewPath)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	// The path can be used again once the trashed mount is purged
+	require.NoError(t, c.mount(ctx, &MountEntry{
+		Table: mountTableType,
+		Path:  "secret/",
+		Type:  "kv",
+	}))
+}
+
+func TestMountTrash_Disabled(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	entry := c.router.MatchingMountEntry(ctx, "secret/")
+	require.NotNil(t, entry)
+
+	resp, err := c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.DeleteOperation,
+		Path:        "sys/mounts/secret",
+		ClientToken: root,
+	})
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("bad: resp: %#v\nerr: %v", resp, err)
+	}
+
+	// Without a retention period, mounts are purged immediately
+	trashed, err := c.trashedMounts(ctx)
+	require.NoError(t, err)
+	require.Empty(t, trashed)
+
+	keys, err := c.barrier.List(ctx, entry.ViewPath())
+	require.NoError(t, err)
+	require.Empty(t, keys)
+}
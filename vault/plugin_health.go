@@ -0,0 +1,50 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// pluginHealth returns the health of the process running the external plugin
+// of the mount, if its backend reports it.
+func (c *Core) pluginHealth(entry *MountEntry) (logical.PluginHealth, bool) {
+	if !entry.IsExternalPlugin() {
+		return logical.PluginHealth{}, false
+	}
+
+	ctx := namespace.ContextWithNamespace(context.Background(), entry.Namespace())
+	reporter, ok := c.router.MatchingBackend(ctx, entry.APIPathNoNamespace()).(logical.PluginHealthReporter)
+	if !ok {
+		return logical.PluginHealth{}, false
+	}
+	return reporter.PluginHealth(), true
+}
+
+func pluginHealthResponse(health logical.PluginHealth) map[string]interface{} {
+	resp := map[string]interface{}{
+		"restarts":               health.Restarts,
+		"restart_limit_exceeded": health.RestartLimitExceeded,
+	}
+	if !health.LastRestart.IsZero() {
+		resp["last_restart"] = health.LastRestart.UTC().Format(time.RFC3339)
+	}
+	if health.LastError != "" {
+		resp["last_error"] = health.LastError
+	}
+	return resp
+}
+
+// addPluginHealthWarning warns if the plugin of the mount is not restarted
+// because it exceeded the restarts allowed by its runtime.
+func (b *SystemBackend) addPluginHealthWarning(entry *MountEntry, resp *logical.Response) {
+	if health, ok := b.Core.pluginHealth(entry); ok && health.RestartLimitExceeded {
+		resp.AddWarning(fmt.Sprintf("Plugin exceeded the restarts allowed by its runtime and will not be restarted until its restart window passes, last error: %s", health.LastError))
+	}
+}
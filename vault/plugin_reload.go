@@ -282,6 +282,11 @@ func (c *Core) reloadBackendCommon(ctx context.Context, entry *MountEntry, isAut
 				return err
 			}
 			re.binaryPaths.Store(binaryPathsEntry)
+			renamedPathsEntry, err := parseRenamedPaths(paths.Renamed)
+			if err != nil {
+				return err
+			}
+			re.renamedPaths.Store(renamedPathsEntry)
 		}
 	}
 
@@ -0,0 +1,329 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	semver "github.com/hashicorp/go-version"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/pluginutil"
+)
+
+// pluginUpgradeCheckInterval is how often mounts with a plugin upgrade window
+// are checked for newer versions of their plugin.
+var pluginUpgradeCheckInterval = time.Minute
+
+// PluginUpgradeWindow is a daily window during which a mount is automatically
+// reloaded onto the newest registered version of its plugin.
+type PluginUpgradeWindow struct {
+	// Start is the time of day the window opens, as HH:MM in UTC.
+	Start string `json:"start" mapstructure:"start"`
+
+	// Duration is how long the window stays open.
+	Duration time.Duration `json:"duration" mapstructure:"duration"`
+
+	// VersionConstraint restricts the versions the mount can be upgraded to,
+	// e.g. "~> 1.2". Any newer version is allowed if empty.
+	VersionConstraint string `json:"version_constraint,omitempty" mapstructure:"version_constraint"`
+}
+
+// APIPluginUpgradeWindow is the API representation of PluginUpgradeWindow.
+type APIPluginUpgradeWindow struct {
+	Start             string `json:"start,omitempty" mapstructure:"start"`
+	Duration          string `json:"duration,omitempty" mapstructure:"duration"`
+	VersionConstraint string `json:"version_constraint,omitempty" mapstructure:"version_constraint"`
+}
+
+// parsePluginUpgradeWindow validates the API representation of an upgrade
+// window and returns the window it describes.
+func parsePluginUpgradeWindow(in *APIPluginUpgradeWindow) (*PluginUpgradeWindow, error) {
+	if _, err := time.Parse("15:04", in.Start); err != nil {
+		return nil, fmt.Errorf("invalid start %q, must be HH:MM in UTC", in.Start)
+	}
+
+	duration, err := parseutil.ParseDurationSecond(in.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration: %w", err)
+	}
+	if duration <= 0 || duration > 24*time.Hour {
+		return nil, errors.New("duration must be greater than zero and at most 24h")
+	}
+
+	if in.VersionConstraint != "" {
+		if _, err := semver.NewConstraint(in.VersionConstraint); err != nil {
+			return nil, fmt.Errorf("invalid version constraint: %w", err)
+		}
+	}
+
+	return &PluginUpgradeWindow{
+		Start:             in.Start,
+		Duration:          duration,
+		VersionConstraint: in.VersionConstraint,
+	}, nil
+}
+
+// pluginUpgradeWindowResponse returns the API representation of the window.
+func pluginUpgradeWindowResponse(w *PluginUpgradeWindow) map[string]interface{} {
+	resp := map[string]interface{}{
+		"start":    w.Start,
+		"duration": w.Duration.String(),
+	}
+	if w.VersionConstraint != "" {
+		resp["version_constraint"] = w.VersionConstraint
+	}
+	return resp
+}
+
+// contains returns whether t falls within the window.
+func (w *PluginUpgradeWindow) contains(t time.Time) bool {
+	start, err := time.Parse("15:04", w.Start)
+	if err != nil {
+		return false
+	}
+
+	t = t.UTC()
+	opens := time.Date(t.Year(), t.Month(), t.Day(), start.Hour(), start.Minute(), 0, 0, time.UTC)
+	// The window may have opened yesterday and not be closed yet
+	if opens.After(t) {
+		opens = opens.AddDate(0, 0, -1)
+	}
+
+	return t.Before(opens.Add(w.Duration))
+}
+
+// pluginUpgrades tracks versions mounts failed to be upgraded to, so that
+// they are not retried every interval for the rest of the window.
+type pluginUpgrades struct {
+	lock sync.Mutex
+
+	// failed maps mount accessors to the version they failed to upgrade to
+	failed map[string]string
+}
+
+func (p *pluginUpgrades) failedVersion(accessor string) string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.failed[accessor]
+}
+
+func (p *pluginUpgrades) setFailedVersion(accessor, version string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if version == "" {
+		delete(p.failed, accessor)
+		return
+	}
+	p.failed[accessor] = version
+}
+
+// startPluginUpgrades runs a process which, every pluginUpgradeCheckInterval,
+// upgrades the mounts whose plugin upgrade window is open, until the active
+// context is done.
+func (c *Core) startPluginUpgrades() {
+	if c.pluginUpgradesCancel != nil {
+		return
+	}
+
+	c.pluginUpgrades = &pluginUpgrades{
+		failed: make(map[string]string),
+	}
+
+	var ctx context.Context
+	ctx, c.pluginUpgradesCancel = context.WithCancel(namespace.RootContext(c.activeContext))
+
+	go func() {
+		ticker := time.NewTicker(pluginUpgradeCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.runPluginUpgrades(ctx, time.Now()); err != nil {
+					c.logger.Error("failed to upgrade plugins", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// runPluginUpgrades upgrades all mounts whose plugin upgrade window contains
+// now. The mounts are reloaded without holding the mounts or auth lock, so
+// that slow plugins do not block requests and mount changes.
+func (c *Core) runPluginUpgrades(ctx context.Context, now time.Time) error {
+	var retErr error
+
+	c.mountsLock.RLock()
+	mounts := pluginUpgradeCandidates(c.mounts, now)
+	c.mountsLock.RUnlock()
+	for _, entry := range mounts {
+		if err := c.upgradeMountPlugin(ctx, entry, false); err != nil {
+			retErr = multierror.Append(retErr, err)
+		}
+	}
+
+	c.authLock.RLock()
+	auths := pluginUpgradeCandidates(c.auth, now)
+	c.authLock.RUnlock()
+	for _, entry := range auths {
+		if err := c.upgradeMountPlugin(ctx, entry, true); err != nil {
+			retErr = multierror.Append(retErr, err)
+		}
+	}
+
+	return retErr
+}
+
+// pluginUpgradeCandidates returns the entries of the table whose upgrade
+// window contains now. The mounts or auth lock must be held.
+func pluginUpgradeCandidates(table *MountTable, now time.Time) []*MountEntry {
+	var entries []*MountEntry
+	for _, entry := range table.Entries {
+		if window := entry.Config.PluginUpgradeWindow; window != nil && window.contains(now) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// upgradeMountPlugin reloads the mount onto the newest registered version of
+// its plugin. If the backend fails to initialize with the new version, the
+// mount is rolled back to the version it was running. The mounts or auth lock
+// must not be held, as it is only taken to update the mount entry.
+func (c *Core) upgradeMountPlugin(ctx context.Context, entry *MountEntry, isAuth bool) error {
+	lock := &c.mountsLock
+	if isAuth {
+		lock = &c.authLock
+	}
+
+	lock.RLock()
+	currentVersion, runningSha256 := entry.Version, entry.RunningSha256
+	constraint := entry.Config.PluginUpgradeWindow.VersionConstraint
+	lock.RUnlock()
+
+	// Only mounts running a specific version of an external plugin are
+	// upgraded, and the replicated mount table is only changed on primaries.
+	if currentVersion == "" || runningSha256 == "" {
+		return nil
+	}
+	if !entry.Local && c.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		return nil
+	}
+
+	pluginType := consts.PluginTypeSecrets
+	if isAuth {
+		pluginType = consts.PluginTypeCredential
+	}
+
+	// A pinned version in the catalog takes precedence over the mount's version
+	pluginName := entry.Type
+	if alias, ok := mountAliases[pluginName]; ok {
+		pluginName = alias
+	}
+	pinnedVersion, err := c.pluginCatalog.GetPinnedVersion(ctx, pluginType, pluginName)
+	if err != nil && !errors.Is(err, pluginutil.ErrPinnedVersionNotFound) {
+		return err
+	}
+	if pinnedVersion != nil {
+		return nil
+	}
+
+	version, err := c.newestPluginVersion(ctx, pluginType, pluginName, currentVersion, constraint)
+	if err != nil || version == "" {
+		return err
+	}
+	if version == c.pluginUpgrades.failedVersion(entry.Accessor) {
+		return nil
+	}
+
+	logger := c.logger.With("plugin", pluginName, "path", entry.Path, "namespace", entry.Namespace().Path)
+	logger.Info("upgrading plugin within upgrade window", "version", currentVersion, "new_version", version)
+
+	// The version is only changed if the mount was not tuned meanwhile
+	lock.Lock()
+	if entry.Version != currentVersion {
+		lock.Unlock()
+		return nil
+	}
+	entry.Version = version
+	lock.Unlock()
+
+	ctx = namespace.ContextWithNamespace(ctx, entry.Namespace())
+	if err := c.reloadBackendCommon(ctx, entry, isAuth); err != nil {
+		c.pluginUpgrades.setFailedVersion(entry.Accessor, version)
+		lock.Lock()
+		entry.Version = currentVersion
+		lock.Unlock()
+		upgradeErr := fmt.Errorf("failed to upgrade plugin on %q to version %s: %w", entry.Path, version, err)
+		if err := c.reloadBackendCommon(ctx, entry, isAuth); err != nil {
+			return multierror.Append(upgradeErr, fmt.Errorf("failed to roll back plugin on %q to version %s: %w", entry.Path, currentVersion, err))
+		}
+		logger.Warn("rolled back plugin after failed upgrade", "version", currentVersion, "new_version", version, "error", err)
+		return upgradeErr
+	}
+
+	lock.Lock()
+	if isAuth {
+		err = c.persistAuth(ctx, c.auth, &entry.Local)
+	} else {
+		err = c.persistMounts(ctx, c.mounts, &entry.Local)
+	}
+	lock.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to persist plugin upgrade of %q to version %s: %w", entry.Path, version, err)
+	}
+	c.pluginUpgrades.setFailedVersion(entry.Accessor, "")
+
+	logger.Info("successfully upgraded plugin", "version", entry.RunningVersion)
+	return nil
+}
+
+// newestPluginVersion returns the newest version of the external plugin
+// registered in the catalog, if it is newer than current and satisfies the
+// constraint. Otherwise an empty string is returned.
+func (c *Core) newestPluginVersion(ctx context.Context, pluginType consts.PluginType, pluginName, current, constraint string) (string, error) {
+	currentVersion, err := semver.NewSemver(current)
+	if err != nil {
+		return "", err
+	}
+
+	var constraints semver.Constraints
+	if constraint != "" {
+		constraints, err = semver.NewConstraint(constraint)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	plugins, err := c.pluginCatalog.ListVersionedPlugins(ctx, pluginType)
+	if err != nil {
+		return "", err
+	}
+
+	var newest *pluginutil.VersionedPlugin
+	for i, p := range plugins {
+		if p.Name != pluginName || p.Builtin || p.SemanticVersion == nil {
+			continue
+		}
+		if !p.SemanticVersion.GreaterThan(currentVersion) || !constraints.Check(p.SemanticVersion) {
+			continue
+		}
+		if newest == nil || p.SemanticVersion.GreaterThan(newest.SemanticVersion) {
+			newest = &plugins[i]
+		}
+	}
+	if newest == nil {
+		return "", nil
+	}
+
+	return newest.Version, nil
+}
@@ -0,0 +1,173 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+func TestPluginUpgradeWindow_Contains(t *testing.T) {
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		start    string
+		duration time.Duration
+		now      time.Time
+		expected bool
+	}{
+		"before window":           {"02:00", time.Hour, day.Add(time.Hour + 59*time.Minute), false},
+		"window opens":            {"02:00", time.Hour, day.Add(2 * time.Hour), true},
+		"within window":           {"02:00", time.Hour, day.Add(2*time.Hour + 30*time.Minute), true},
+		"window closes":           {"02:00", time.Hour, day.Add(3 * time.Hour), false},
+		"across midnight, before": {"23:00", 2 * time.Hour, day.Add(23*time.Hour + 30*time.Minute), true},
+		"across midnight, after":  {"23:00", 2 * time.Hour, day.Add(30 * time.Minute), true},
+		"across midnight, closed": {"23:00", 2 * time.Hour, day.Add(time.Hour), false},
+		"non-UTC time":            {"02:00", time.Hour, day.Add(2*time.Hour + 30*time.Minute).In(time.FixedZone("", -5*3600)), true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := &PluginUpgradeWindow{Start: tc.start, Duration: tc.duration}
+			if actual := w.contains(tc.now); actual != tc.expected {
+				t.Fatalf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParsePluginUpgradeWindow(t *testing.T) {
+	for name, tc := range map[string]struct {
+		in         *APIPluginUpgradeWindow
+		wantErrStr string
+	}{
+		"valid":               {in: &APIPluginUpgradeWindow{Start: "04:30", Duration: "2h", VersionConstraint: "~> 1.2"}},
+		"duration in seconds": {in: &APIPluginUpgradeWindow{Start: "04:30", Duration: "3600"}},
+		"invalid start":       {in: &APIPluginUpgradeWindow{Start: "4pm", Duration: "2h"}, wantErrStr: "invalid start"},
+		"zero duration":       {in: &APIPluginUpgradeWindow{Start: "04:30"}, wantErrStr: "duration must be"},
+		"too long":            {in: &APIPluginUpgradeWindow{Start: "04:30", Duration: "25h"}, wantErrStr: "duration must be"},
+		"invalid constraint":  {in: &APIPluginUpgradeWindow{Start: "04:30", Duration: "2h", VersionConstraint: "latest"}, wantErrStr: "invalid version constraint"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := parsePluginUpgradeWindow(tc.in)
+			if tc.wantErrStr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrStr) {
+				t.Fatalf("expected error containing %q, got: %v", tc.wantErrStr, err)
+			}
+		})
+	}
+}
+
+func tunePluginUpgradeWindow(t *testing.T, sys *SystemBackend, window map[string]interface{}) {
+	t.Helper()
+	req := logical.TestRequest(t, logical.UpdateOperation, "mounts/foo/tune")
+	req.Data = map[string]interface{}{
+		"plugin_upgrade_window": window,
+	}
+	resp, err := sys.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+}
+
+func TestCore_PluginUpgradeWindow_Upgrade(t *testing.T) {
+	c, plugins := testCoreWithPlugins(t, consts.PluginTypeSecrets, "")
+	for _, version := range []string{"v1.0.0", "v1.0.1", "v1.1.0"} {
+		registerPlugin(t, c.systemBackend, plugins[0].Name, consts.PluginTypeSecrets.String(), version, plugins[0].Sha256, plugins[0].FileName)
+	}
+	mountPlugin(t, c.systemBackend, plugins[0].Name, consts.PluginTypeSecrets, "v1.0.0", "")
+
+	now := time.Now().UTC()
+	tunePluginUpgradeWindow(t, c.systemBackend, map[string]interface{}{
+		"start":              now.Add(-time.Hour).Format("15:04"),
+		"duration":           "2h",
+		"version_constraint": "~> 1.0.0",
+	})
+
+	// Nothing happens outside of the window
+	if err := c.runPluginUpgrades(namespace.RootContext(nil), now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	entry := c.router.MatchingMountEntry(namespace.RootContext(nil), "foo/")
+	if entry.Version != "v1.0.0" || entry.RunningVersion != "v1.0.0" {
+		t.Fatalf("expected mount to be on v1.0.0, got version %s, running %s", entry.Version, entry.RunningVersion)
+	}
+
+	// The newest version allowed by the constraint is picked within the window
+	if err := c.runPluginUpgrades(namespace.RootContext(nil), now); err != nil {
+		t.Fatal(err)
+	}
+	entry = c.router.MatchingMountEntry(namespace.RootContext(nil), "foo/")
+	if entry.Version != "v1.0.1" || entry.RunningVersion != "v1.0.1" {
+		t.Fatalf("expected mount to be upgraded to v1.0.1, got version %s, running %s", entry.Version, entry.RunningVersion)
+	}
+
+	req := logical.TestRequest(t, logical.ReadOperation, "mounts/foo/tune")
+	resp, err := c.systemBackend.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	window := resp.Data["plugin_upgrade_window"].(map[string]interface{})
+	if window["duration"] != "2h0m0s" || window["version_constraint"] != "~> 1.0.0" {
+		t.Fatalf("bad plugin_upgrade_window: %#v", window)
+	}
+
+	// The window can be removed
+	tunePluginUpgradeWindow(t, c.systemBackend, map[string]interface{}{})
+	if entry.Config.PluginUpgradeWindow != nil {
+		t.Fatalf("expected plugin upgrade window to be removed, got: %#v", entry.Config.PluginUpgradeWindow)
+	}
+}
+
+func TestCore_PluginUpgradeWindow_Rollback(t *testing.T) {
+	c, plugins := testCoreWithPlugins(t, consts.PluginTypeSecrets, "")
+	registerPlugin(t, c.systemBackend, plugins[0].Name, consts.PluginTypeSecrets.String(), "v1.0.0", plugins[0].Sha256, plugins[0].FileName)
+	// The checksum of this version doesn't match, so its backend fails to start
+	registerPlugin(t, c.systemBackend, plugins[0].Name, consts.PluginTypeSecrets.String(), "v1.0.1", strings.Repeat("0", 64), plugins[0].FileName)
+	mountPlugin(t, c.systemBackend, plugins[0].Name, consts.PluginTypeSecrets, "v1.0.0", "")
+
+	now := time.Now().UTC()
+	tunePluginUpgradeWindow(t, c.systemBackend, map[string]interface{}{
+		"start":    now.Add(-time.Hour).Format("15:04"),
+		"duration": "2h",
+	})
+
+	err := c.runPluginUpgrades(namespace.RootContext(nil), now)
+	if err == nil || !strings.Contains(err.Error(), "failed to upgrade plugin") {
+		t.Fatalf("expected upgrade error, got: %v", err)
+	}
+
+	entry := c.router.MatchingMountEntry(namespace.RootContext(nil), "foo/")
+	if entry.Version != "v1.0.0" || entry.RunningVersion != "v1.0.0" {
+		t.Fatalf("expected mount to be rolled back to v1.0.0, got version %s, running %s", entry.Version, entry.RunningVersion)
+	}
+
+	// The mount still serves requests
+	req := logical.TestRequest(t, logical.ListOperation, "foo/roles/")
+	if _, err := c.router.Route(namespace.RootContext(nil), req); err != nil {
+		t.Fatal(err)
+	}
+
+	// The failed version is not retried for the rest of the window
+	if err := c.runPluginUpgrades(namespace.RootContext(nil), now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "mounts/foo/tune")
+	resp, err := c.systemBackend.HandleRequest(namespace.RootContext(nil), req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "v1.0.1 failed") {
+		t.Fatalf("expected failed upgrade warning, got: %#v", resp.Warnings)
+	}
+}
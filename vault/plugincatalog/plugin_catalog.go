@@ -41,6 +41,7 @@ var (
 	ErrPluginConnectionNotFound = errors.New("plugin connection not found for client")
 	ErrPluginBadType            = errors.New("unable to determine plugin type")
 	ErrPinnedVersion            = errors.New("cannot delete a pinned version")
+	ErrPluginSignature          = errors.New("plugin signature verification failed")
 )
 
 // PluginCatalog keeps a record of plugins known to vault. External plugins need
@@ -67,6 +68,9 @@ type PluginCatalog struct {
 	wrapper pluginutil.RunnerUtil
 
 	runtimeCatalog *PluginRuntimeCatalog
+
+	// cosignTrustedRoot is used to verify keyless cosign signatures of plugins.
+	cosignTrustedRoot *pluginutil.CosignTrustedRoot
 }
 
 // Only plugins running with identical PluginRunner config can be multiplexed,
@@ -149,6 +153,7 @@ type PluginCatalogInput struct {
 	Tmpdir               string
 	EnableMlock          bool
 	PluginRuntimeCatalog *PluginRuntimeCatalog
+	CosignTrustedRoot    *pluginutil.CosignTrustedRoot
 }
 
 func SetupPluginCatalog(ctx context.Context, in *PluginCatalogInput) (*PluginCatalog, error) {
@@ -162,6 +167,8 @@ func SetupPluginCatalog(ctx context.Context, in *PluginCatalogInput) (*PluginCat
 		mlockPlugins:    in.EnableMlock,
 		wrapper:         logical.StaticSystemView{VersionString: version.GetVersion().Version},
 		runtimeCatalog:  in.PluginRuntimeCatalog,
+
+		cosignTrustedRoot: in.CosignTrustedRoot,
 	}
 
 	// Run upgrade if untyped plugins exist
@@ -899,6 +906,13 @@ func (c *PluginCatalog) get(ctx context.Context, name string, pluginType consts.
 			// Only allow returning non-container external plugins if we have a plugin directory.
 			// Make the command path fully rooted.
 			entry.Command = filepath.Join(c.directory, entry.Command)
+			entry.CosignTrustedRoot = c.cosignTrustedRoot
+			if entry.Runtime != "" {
+				entry.RuntimeConfig, err = c.runtimeCatalog.Get(ctx, entry.Runtime, consts.PluginRuntimeTypeProcess)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get configured runtime for plugin %q: %w", name, err)
+				}
+			}
 			return entry, nil
 		}
 	}
@@ -969,6 +983,20 @@ func (c *PluginCatalog) setInternal(ctx context.Context, plugin pluginutil.SetPl
 		}
 	}
 
+	// The signature is verified before the plugin is run to determine its
+	// type and version, and again each time the plugin is run.
+	if plugin.Cosign != nil {
+		if plugin.OCIImage != "" {
+			return nil, errors.New("cosign signatures are not supported for container plugins")
+		}
+		if err := plugin.Cosign.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrPluginSignature, err)
+		}
+		if err := pluginutil.VerifyCosignFile(command, plugin.Sha256, plugin.Cosign, c.cosignTrustedRoot); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrPluginSignature, err)
+		}
+	}
+
 	// entryTmp should only be used for the below type and version checks. It uses the
 	// full command instead of the relative command because get() normally prepends
 	// the plugin directory to the command, but we can't use get() here.
@@ -981,49 +1009,72 @@ func (c *PluginCatalog) setInternal(ctx context.Context, plugin pluginutil.SetPl
 		Env:      plugin.Env,
 		Sha256:   plugin.Sha256,
 		Builtin:  false,
+
+		Cosign:            plugin.Cosign,
+		CosignTrustedRoot: c.cosignTrustedRoot,
 	}
-	if entryTmp.OCIImage != "" && entryTmp.Runtime != "" {
+	if entryTmp.Runtime != "" {
+		runtimeType := consts.PluginRuntimeTypeContainer
+		if entryTmp.OCIImage == "" {
+			runtimeType = consts.PluginRuntimeTypeProcess
+		}
 		var err error
-		entryTmp.RuntimeConfig, err = c.runtimeCatalog.Get(ctx, entryTmp.Runtime, consts.PluginRuntimeTypeContainer)
+		entryTmp.RuntimeConfig, err = c.runtimeCatalog.Get(ctx, entryTmp.Runtime, runtimeType)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get configured runtime for plugin %q: %w", plugin.Name, err)
 		}
 	}
-	// If the plugin type is unknown, we want to attempt to determine the type
-	if plugin.Type == consts.PluginTypeUnknown {
-		var err error
-		plugin.Type, err = c.getPluginTypeFromUnknown(ctx, entryTmp)
-		if err != nil {
-			return nil, err
+
+	// WebAssembly modules are not run to determine their type and version,
+	// since they are only run by the WebAssembly runtime of mounted backends.
+	if entryTmp.IsWASM() {
+		if plugin.Runtime != "" {
+			return nil, errors.New("plugin runtimes are not supported for webassembly plugins")
 		}
+		switch plugin.Type {
+		case consts.PluginTypeSecrets, consts.PluginTypeCredential:
+		case consts.PluginTypeUnknown:
+			return nil, errors.New("plugin type must be specified for webassembly plugins")
+		default:
+			return nil, fmt.Errorf("unsupported webassembly plugin type: %v", plugin.Type)
+		}
+	} else {
+		// If the plugin type is unknown, we want to attempt to determine the type
 		if plugin.Type == consts.PluginTypeUnknown {
-			return nil, ErrPluginBadType
+			var err error
+			plugin.Type, err = c.getPluginTypeFromUnknown(ctx, entryTmp)
+			if err != nil {
+				return nil, err
+			}
+			if plugin.Type == consts.PluginTypeUnknown {
+				return nil, ErrPluginBadType
+			}
 		}
-	}
 
-	// getting the plugin version is best-effort, so errors are not fatal
-	runningVersion := logical.EmptyPluginVersion
-	var versionErr error
-	switch plugin.Type {
-	case consts.PluginTypeSecrets, consts.PluginTypeCredential:
-		runningVersion, versionErr = c.getBackendRunningVersion(ctx, entryTmp)
-	case consts.PluginTypeDatabase:
-		runningVersion, versionErr = c.getDatabaseRunningVersion(ctx, entryTmp)
-	default:
-		return nil, fmt.Errorf("unknown plugin type: %v", plugin.Type)
-	}
-	if versionErr != nil {
-		c.logger.Warn("Error determining plugin version", "error", versionErr)
-	} else if plugin.Version != "" && runningVersion.Version != "" && plugin.Version != runningVersion.Version {
-		c.logger.Warn("Plugin self-reported version did not match requested version", "plugin", plugin.Name, "requestedVersion", plugin.Version, "reportedVersion", runningVersion.Version)
-		return nil, fmt.Errorf("plugin version mismatch: %s reported version (%s) did not match requested version (%s)", plugin.Name, runningVersion.Version, plugin.Version)
-	} else if plugin.Version == "" && runningVersion.Version != "" {
-		plugin.Version = runningVersion.Version
-		_, err := semver.NewVersion(plugin.Version)
-		if err != nil {
-			return nil, fmt.Errorf("plugin self-reported version %q is not a valid semantic version: %w", plugin.Version, err)
+		// getting the plugin version is best-effort, so errors are not fatal
+		runningVersion := logical.EmptyPluginVersion
+		var versionErr error
+		switch plugin.Type {
+		case consts.PluginTypeSecrets, consts.PluginTypeCredential:
+			runningVersion, versionErr = c.getBackendRunningVersion(ctx, entryTmp)
+		case consts.PluginTypeDatabase:
+			runningVersion, versionErr = c.getDatabaseRunningVersion(ctx, entryTmp)
+		default:
+			return nil, fmt.Errorf("unknown plugin type: %v", plugin.Type)
 		}
+		if versionErr != nil {
+			c.logger.Warn("Error determining plugin version", "error", versionErr)
+		} else if plugin.Version != "" && runningVersion.Version != "" && plugin.Version != runningVersion.Version {
+			c.logger.Warn("Plugin self-reported version did not match requested version", "plugin", plugin.Name, "requestedVersion", plugin.Version, "reportedVersion", runningVersion.Version)
+			return nil, fmt.Errorf("plugin version mismatch: %s reported version (%s) did not match requested version (%s)", plugin.Name, runningVersion.Version, plugin.Version)
+		} else if plugin.Version == "" && runningVersion.Version != "" {
+			plugin.Version = runningVersion.Version
+			_, err := semver.NewVersion(plugin.Version)
+			if err != nil {
+				return nil, fmt.Errorf("plugin self-reported version %q is not a valid semantic version: %w", plugin.Version, err)
+			}
 
+		}
 	}
 
 	entry := &pluginutil.PluginRunner{
@@ -1037,6 +1088,7 @@ func (c *PluginCatalog) setInternal(ctx context.Context, plugin pluginutil.SetPl
 		Env:      plugin.Env,
 		Sha256:   plugin.Sha256,
 		Builtin:  false,
+		Cosign:   plugin.Cosign,
 	}
 
 	buf, err := json.Marshal(entry)
@@ -5,9 +5,15 @@ package plugincatalog
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -18,6 +24,7 @@ import (
 	"reflect"
 	"runtime"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/go-hclog"
@@ -1299,3 +1306,126 @@ type pluginCatalogStaticSystemView struct {
 func (p pluginCatalogStaticSystemView) NewPluginClient(ctx context.Context, config pluginutil.PluginClientConfig) (pluginutil.PluginClient, error) {
 	return p.pluginCatalog.NewPluginClient(ctx, config)
 }
+
+// TestPluginCatalog_SetWASM ensures webassembly plugins are registered without
+// being run, and must be registered with a supported plugin type.
+func TestPluginCatalog_SetWASM(t *testing.T) {
+	pluginCatalog := testPluginCatalog(t)
+
+	file, err := os.CreateTemp(pluginCatalog.directory, "temp*.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	command := filepath.Base(file.Name())
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		typ        consts.PluginType
+		wantErrStr string
+	}{
+		"secrets":    {typ: consts.PluginTypeSecrets},
+		"credential": {typ: consts.PluginTypeCredential},
+		"unknown":    {typ: consts.PluginTypeUnknown, wantErrStr: "plugin type must be specified"},
+		"database":   {typ: consts.PluginTypeDatabase, wantErrStr: "unsupported webassembly plugin type"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := pluginCatalog.Set(ctx, pluginutil.SetPluginInput{
+				Name:    "wasm-plugin",
+				Type:    tc.typ,
+				Version: "v1.0.0",
+				Command: command,
+				Sha256:  []byte{'1'},
+			})
+			if tc.wantErrStr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErrStr) {
+					t.Fatalf("expected error containing %q, got: %v", tc.wantErrStr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			p, err := pluginCatalog.Get(ctx, "wasm-plugin", tc.typ, "v1.0.0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p == nil || !p.IsWASM() {
+				t.Fatalf("expected webassembly plugin, got: %#v", p)
+			}
+		})
+	}
+}
+
+// TestPluginCatalog_SetCosign ensures plugins registered with a cosign
+// signature are only registered if the signature is valid, and keep the
+// signature for verification at launch.
+func TestPluginCatalog_SetCosign(t *testing.T) {
+	pluginCatalog := testPluginCatalog(t)
+
+	file, err := os.CreateTemp(pluginCatalog.directory, "temp*.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	content := []byte("webassembly module")
+	if _, err := file.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	command := filepath.Base(file.Name())
+	sum := sha256.Sum256(content)
+	ctx := context.Background()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	sign := func(content []byte) string {
+		digest := sha256.Sum256(content)
+		sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+
+	err = pluginCatalog.Set(ctx, pluginutil.SetPluginInput{
+		Name:    "signed-plugin",
+		Type:    consts.PluginTypeSecrets,
+		Command: command,
+		Sha256:  sum[:],
+		Cosign:  &pluginutil.CosignVerification{PublicKey: publicKey, Signature: sign([]byte("other module"))},
+	})
+	if !errors.Is(err, ErrPluginSignature) {
+		t.Fatalf("expected signature error, got: %v", err)
+	}
+	if p, err := pluginCatalog.Get(ctx, "signed-plugin", consts.PluginTypeSecrets, ""); err != nil || p != nil {
+		t.Fatalf("expected plugin not to be registered, got: %#v, %v", p, err)
+	}
+
+	cosign := &pluginutil.CosignVerification{PublicKey: publicKey, Signature: sign(content)}
+	if err := pluginCatalog.Set(ctx, pluginutil.SetPluginInput{
+		Name:    "signed-plugin",
+		Type:    consts.PluginTypeSecrets,
+		Command: command,
+		Sha256:  sum[:],
+		Cosign:  cosign,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := pluginCatalog.Get(ctx, "signed-plugin", consts.PluginTypeSecrets, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || !reflect.DeepEqual(p.Cosign, cosign) {
+		t.Fatalf("expected cosign signature to be kept, got: %#v", p)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/tokenutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// monitoringPolicyName is the name of the policy granting read access to
+	// the observability endpoints
+	monitoringPolicyName = "vault-monitoring"
+
+	// monitoringRoleName is the name of the built-in token role minting the
+	// tokens of monitoring integrations
+	monitoringRoleName = "vault-monitoring"
+
+	// monitoringRolePeriod is the period of the tokens of the built-in
+	// monitoring role, which integrations renew for as long as they run
+	monitoringRolePeriod = 24 * time.Hour
+)
+
+// monitoringPolicyPaths are the observability endpoints which the monitoring
+// policy grants access to, with the capabilities granted on them. Endpoints
+// requiring sudo are left out, so that the tokens remain read-only. New
+// observability endpoints should be added here, so that the generated policy
+// covers them.
+var monitoringPolicyPaths = []struct {
+	path         string
+	capabilities []string
+}{
+	{"sys/metrics", []string{"read"}},
+	{"sys/health", []string{"read"}},
+	{"sys/seal-status", []string{"read"}},
+	{"sys/seal-status/history", []string{"read"}},
+	{"sys/ha-status", []string{"read"}},
+	{"sys/host-info", []string{"read"}},
+	{"sys/in-flight-req", []string{"read"}},
+	{"sys/key-status", []string{"read"}},
+	{"sys/version-history", []string{"list"}},
+	{"sys/replication/status", []string{"read"}},
+	{"sys/replication/+/status", []string{"read"}},
+	{"sys/storage/raft/autopilot/state", []string{"read"}},
+	{"sys/mounts", []string{"read"}},
+	{"sys/auth", []string{"read"}},
+	{"sys/rotation/jobs", []string{"list"}},
+	{"sys/rotation/jobs/*", []string{"read"}},
+}
+
+// monitoringPolicy generates the text of the monitoring policy.
+func monitoringPolicy() string {
+	var b strings.Builder
+	b.WriteString("# Grants read access to the observability endpoints, for the tokens of\n")
+	b.WriteString("# monitoring integrations. Generated by Vault from sys/policies/templates/monitoring.\n")
+	for _, p := range monitoringPolicyPaths {
+		fmt.Fprintf(&b, "\npath %q {\n    capabilities = [%q]\n}\n", p.path, strings.Join(p.capabilities, `", "`))
+	}
+	return b.String()
+}
+
+// ensureMonitoringPolicy creates the monitoring policy in the namespace of the
+// context from the generated text, unless a policy of that name exists, which
+// is left as is.
+func (c *Core) ensureMonitoringPolicy(ctx context.Context) error {
+	return c.policyStore.loadACLPolicyInternal(ctx, monitoringPolicyName, monitoringPolicy())
+}
+
+// monitoringRole returns the built-in token role minting the tokens of
+// monitoring integrations, which are limited to the monitoring policy. The
+// role is used unless a role of the same name is written, which takes
+// precedence over it.
+func monitoringRole() *tsRoleEntry {
+	return &tsRoleEntry{
+		builtin: true,
+		TokenParams: tokenutil.TokenParams{
+			TokenNoDefaultPolicy: true,
+			TokenPeriod:          monitoringRolePeriod,
+			TokenType:            logical.TokenTypeDefaultService,
+		},
+		Name:            monitoringRoleName,
+		AllowedPolicies: []string{monitoringPolicyName},
+		Orphan:          true,
+		Renewable:       true,
+	}
+}
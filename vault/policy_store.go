@@ -5,8 +5,11 @@ package vault
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +22,7 @@ import (
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/logical"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -32,6 +36,9 @@ const (
 	// policyCacheSize is the number of policies that are kept cached
 	policyCacheSize = 1024
 
+	// aclCacheSize is the number of compiled ACLs that are kept cached
+	aclCacheSize = 1024
+
 	// defaultPolicyName is the name of the default policy
 	defaultPolicyName = "default"
 
@@ -53,13 +60,9 @@ path "sys/wrapping/unwrap" {
     capabilities = ["update"]
 }
 `
-	// controlGroupPolicy is the policy that ensures control group requests can
-	// commit themselves
+	// controlGroupPolicy is the policy of control group tokens, which only
+	// allows running their authorized request by unwrapping them
 	controlGroupPolicy = `
-path "cubbyhole/control-group" {
-    capabilities = ["update", "create", "read"]
-}
-
 path "sys/wrapping/unwrap" {
     capabilities = ["update"]
 }
@@ -192,6 +195,10 @@ type PolicyStore struct {
 	tokenPoliciesLRU *lru.TwoQueueCache
 	egpLRU           *lru.TwoQueueCache
 
+	// aclLRU caches compiled ACLs by the content hash of their policies, so
+	// that tokens with the same set of policies share a single ACL
+	aclLRU *lru.TwoQueueCache
+
 	// This is used to ensure that writes to the store (acl/rgp) or to the egp
 	// path tree don't happen concurrently. We are okay reading stale data so
 	// long as there aren't concurrent writes.
@@ -233,6 +240,8 @@ func NewPolicyStore(ctx context.Context, core *Core, baseView *BarrierView, syst
 		ps.tokenPoliciesLRU = cache
 		cache, _ = lru.New2Q(policyCacheSize)
 		ps.egpLRU = cache
+		cache, _ = lru.New2Q(aclCacheSize)
+		ps.aclLRU = cache
 	}
 
 	aclView := ps.getACLView(namespace.RootNamespace)
@@ -322,6 +331,7 @@ func (ps *PolicyStore) invalidate(ctx context.Context, name string, policyType P
 		if ps.tokenPoliciesLRU != nil {
 			ps.tokenPoliciesLRU.Remove(index)
 		}
+		ps.purgeACLCache()
 
 	case PolicyTypeEGP:
 		if ps.egpLRU != nil {
@@ -417,6 +427,7 @@ func (ps *PolicyStore) setPolicyInternal(ctx context.Context, p *Policy) error {
 		if ps.tokenPoliciesLRU != nil {
 			ps.tokenPoliciesLRU.Add(index, p)
 		}
+		ps.purgeACLCache()
 
 	case PolicyTypeRGP:
 		aclView := ps.getACLView(p.namespace)
@@ -439,6 +450,7 @@ func (ps *PolicyStore) setPolicyInternal(ctx context.Context, p *Policy) error {
 		if ps.tokenPoliciesLRU != nil {
 			ps.tokenPoliciesLRU.Add(index, p)
 		}
+		ps.purgeACLCache()
 
 	case PolicyTypeEGP:
 		if err := ps.handleSentinelPolicy(ctx, p, view, entry); err != nil {
@@ -793,6 +805,7 @@ func (ps *PolicyStore) switchedDeletePolicy(ctx context.Context, name string, po
 			// Clear the cache
 			ps.tokenPoliciesLRU.Remove(index)
 		}
+		ps.purgeACLCache()
 
 		ps.policyTypeMap.Delete(index)
 
@@ -808,6 +821,7 @@ func (ps *PolicyStore) switchedDeletePolicy(ctx context.Context, name string, po
 			// Clear the cache
 			ps.tokenPoliciesLRU.Remove(index)
 		}
+		ps.purgeACLCache()
 
 		ps.policyTypeMap.Delete(index)
 
@@ -839,8 +853,15 @@ func (ps *PolicyStore) switchedDeletePolicy(ctx context.Context, name string, po
 func (ps *PolicyStore) ACL(ctx context.Context, entity *identity.Entity, policyNames map[string][]string, additionalPolicies ...*Policy) (*ACL, error) {
 	var allPolicies []*Policy
 
-	// Fetch the named policies
-	for nsID, nsPolicyNames := range policyNames {
+	// Fetch the named policies in a stable order, so that the same set of
+	// policies always results in the same ACL cache key
+	nsIDs := make([]string, 0, len(policyNames))
+	for nsID := range policyNames {
+		nsIDs = append(nsIDs, nsID)
+	}
+	sort.Strings(nsIDs)
+	for _, nsID := range nsIDs {
+		nsPolicyNames := policyNames[nsID]
 		policyNS, err := NamespaceByID(ctx, nsID, ps.core)
 		if err != nil {
 			return nil, err
@@ -863,20 +884,32 @@ func (ps *PolicyStore) ACL(ctx context.Context, entity *identity.Entity, policyN
 	// Append any pre-fetched policies that were given
 	allPolicies = append(allPolicies, additionalPolicies...)
 
-	var fetchedGroups bool
+	// Templated policies are populated from the entity and its groups
+	var templated bool
+	for _, policy := range allPolicies {
+		if policy.Type == PolicyTypeACL && policy.Templated {
+			templated = true
+			break
+		}
+	}
 	var groups []*identity.Group
+	if templated && entity != nil {
+		directGroups, inheritedGroups, err := ps.core.identityStore.groupsByEntityID(entity.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch group memberships: %w", err)
+		}
+		groups = append(directGroups, inheritedGroups...)
+	}
+
+	cacheKey, cacheable := ps.aclCacheKey(ctx, allPolicies, templated, entity, groups)
+	if cacheable {
+		if raw, ok := ps.aclLRU.Get(cacheKey); ok {
+			return raw.(*ACL), nil
+		}
+	}
+
 	for i, policy := range allPolicies {
 		if policy.Type == PolicyTypeACL && policy.Templated {
-			if !fetchedGroups {
-				fetchedGroups = true
-				if entity != nil {
-					directGroups, inheritedGroups, err := ps.core.identityStore.groupsByEntityID(entity.ID)
-					if err != nil {
-						return nil, fmt.Errorf("failed to fetch group memberships: %w", err)
-					}
-					groups = append(directGroups, inheritedGroups...)
-				}
-			}
 			p, err := parseACLPolicyWithTemplating(policy.namespace, policy.Raw, true, entity, groups)
 			if err != nil {
 				return nil, fmt.Errorf("error parsing templated policy %q: %w", policy.Name, err)
@@ -892,9 +925,80 @@ func (ps *PolicyStore) ACL(ctx context.Context, entity *identity.Entity, policyN
 		return nil, fmt.Errorf("failed to construct ACL: %w", err)
 	}
 
+	if cacheable {
+		ps.aclLRU.Add(cacheKey, acl)
+	}
+
 	return acl, nil
 }
 
+// aclCacheKey returns the key of the compiled ACL of the policies in the
+// ACL cache, which is a hash of the namespace of the ACL and the contents of
+// the policies. If any policy is templated, the ACL also depends on the
+// entity and groups it is populated from, so these are hashed as well. ACLs
+// with policies that have no raw contents to hash are not cached.
+func (ps *PolicyStore) aclCacheKey(ctx context.Context, policies []*Policy, templated bool, entity *identity.Entity, groups []*identity.Group) (string, bool) {
+	if ps.aclLRU == nil {
+		return "", false
+	}
+	ns, err := namespace.FromContext(ctx)
+	if err != nil || ns == nil {
+		return "", false
+	}
+
+	h := sha256.New()
+	h.Write([]byte(ns.ID))
+	for _, policy := range policies {
+		if policy == nil {
+			continue
+		}
+		if policy.Raw == "" && policy.Name != "root" {
+			return "", false
+		}
+		var policyNSID string
+		if policy.namespace != nil {
+			policyNSID = policy.namespace.ID
+		}
+		h.Write([]byte{0})
+		h.Write([]byte(policyNSID))
+		h.Write([]byte{0})
+		h.Write([]byte(policy.Name))
+		h.Write([]byte{0, byte(policy.Type), 0})
+		h.Write([]byte(policy.Raw))
+	}
+
+	if templated && entity != nil {
+		// Deterministic marshaling keeps the encoding of equal entities and
+		// groups stable across requests
+		marshal := proto.MarshalOptions{Deterministic: true}
+		entityBytes, err := marshal.Marshal(entity)
+		if err != nil {
+			return "", false
+		}
+		h.Write([]byte{0})
+		h.Write(entityBytes)
+		for _, group := range groups {
+			groupBytes, err := marshal.Marshal(group)
+			if err != nil {
+				return "", false
+			}
+			h.Write([]byte{0})
+			h.Write(groupBytes)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// purgeACLCache removes all compiled ACLs from the ACL cache. Compiled ACLs
+// are keyed by the contents of their policies and so are never stale, but
+// once a policy changes the ACLs of its previous contents are unlikely to be
+// used again.
+func (ps *PolicyStore) purgeACLCache() {
+	if ps.aclLRU != nil {
+		ps.aclLRU.Purge()
+	}
+}
+
 // loadACLPolicy is used to load default ACL policies. The default policies will
 // be loaded to all namespaces.
 func (ps *PolicyStore) loadACLPolicy(ctx context.Context, policyName, policyText string) error {
@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/hashicorp/vault/helper/identity"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/stretchr/testify/require"
@@ -280,6 +281,71 @@ func testPolicyStoreACL(t *testing.T, ps *PolicyStore, ns *namespace.Namespace)
 	testLayeredACL(t, acl, ns)
 }
 
+// TestPolicyStore_ACLCache ensures compiled ACLs are shared between requests
+// with the same policies, and are rebuilt once a policy changes.
+func TestPolicyStore_ACLCache(t *testing.T) {
+	_, ps := mockPolicyWithCore(t, false)
+	ctx := namespace.RootContext(context.Background())
+
+	policy, err := ParseACLPolicy(namespace.RootNamespace, `
+name = "dev"
+path "secret/*" {
+	capabilities = ["read"]
+}`)
+	require.NoError(t, err)
+	require.NoError(t, ps.SetPolicy(ctx, policy))
+
+	policyNames := map[string][]string{namespace.RootNamespace.ID: {"default", "dev"}}
+	acl, err := ps.ACL(ctx, nil, policyNames)
+	require.NoError(t, err)
+	cached, err := ps.ACL(ctx, nil, policyNames)
+	require.NoError(t, err)
+	require.Same(t, acl, cached)
+
+	// A different set of policies has its own ACL
+	other, err := ps.ACL(ctx, nil, map[string][]string{namespace.RootNamespace.ID: {"dev"}})
+	require.NoError(t, err)
+	require.NotSame(t, acl, other)
+
+	req := &logical.Request{Operation: logical.UpdateOperation, Path: "secret/foo"}
+	require.False(t, acl.AllowOperation(ctx, req, false).Allowed)
+
+	policy, err = ParseACLPolicy(namespace.RootNamespace, `
+name = "dev"
+path "secret/*" {
+	capabilities = ["read", "update"]
+}`)
+	require.NoError(t, err)
+	require.NoError(t, ps.SetPolicy(ctx, policy))
+
+	updated, err := ps.ACL(ctx, nil, policyNames)
+	require.NoError(t, err)
+	require.NotSame(t, acl, updated)
+	require.True(t, updated.AllowOperation(ctx, req, false).Allowed)
+
+	// The ACLs of templated policies are cached per entity
+	policy, err = ParseACLPolicy(namespace.RootNamespace, `
+name = "templated"
+path "secret/{{identity.entity.id}}/*" {
+	capabilities = ["update"]
+}`)
+	require.NoError(t, err)
+	require.NoError(t, ps.SetPolicy(ctx, policy))
+	templatedNames := map[string][]string{namespace.RootNamespace.ID: {"templated"}}
+	entity := &identity.Entity{ID: "foo", NamespaceID: namespace.RootNamespaceID}
+	templated, err := ps.ACL(ctx, entity, templatedNames)
+	require.NoError(t, err)
+	again, err := ps.ACL(ctx, entity, templatedNames)
+	require.NoError(t, err)
+	require.Same(t, templated, again)
+	require.True(t, templated.AllowOperation(ctx, &logical.Request{Operation: logical.UpdateOperation, Path: "secret/foo/bar"}, false).Allowed)
+
+	otherEntity, err := ps.ACL(ctx, &identity.Entity{ID: "bar", NamespaceID: namespace.RootNamespaceID}, templatedNames)
+	require.NoError(t, err)
+	require.NotSame(t, templated, otherEntity)
+	require.False(t, otherEntity.AllowOperation(ctx, &logical.Request{Operation: logical.UpdateOperation, Path: "secret/foo/bar"}, false).Allowed)
+}
+
 func TestDefaultPolicy(t *testing.T) {
 	ctx := namespace.ContextWithNamespace(context.Background(), namespace.RootNamespace)
 
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"time"
 
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-memdb"
@@ -28,6 +29,9 @@ const (
 
 	// TypeLeaseCount represents the lease count limiting quota type
 	TypeLeaseCount Type = "lease-count"
+
+	// TypeConcurrency represents the concurrent request limiting quota type
+	TypeConcurrency Type = "concurrency"
 )
 
 // LeaseAction is the action taken by the expiration manager on the lease. The
@@ -79,6 +83,8 @@ func (q Type) String() string {
 		return "lease-count"
 	case TypeRateLimit:
 		return "rate-limit"
+	case TypeConcurrency:
+		return "concurrency"
 	}
 	return "unknown"
 }
@@ -117,6 +123,10 @@ var (
 	// ErrRateLimitQuotaExceeded is returned when a request is rejected due to a
 	// rate limit quota being exceeded.
 	ErrRateLimitQuotaExceeded = errors.New("rate limit quota exceeded")
+
+	// ErrConcurrencyQuotaExceeded is returned when a request is rejected due to
+	// a concurrency quota being exceeded.
+	ErrConcurrencyQuotaExceeded = errors.New("concurrency quota exceeded")
 )
 
 var defaultExemptPaths = []string{
@@ -127,6 +137,7 @@ var defaultExemptPaths = []string{
 	"sys/health",
 	"sys/seal-status",
 	"sys/unseal",
+	"sys/unseal-delegated",
 }
 
 // Access provides information to reach back to the quota checker.
@@ -282,6 +293,20 @@ type Request struct {
 	// ClientAddress is client unique addressable string (e.g. IP address). It can
 	// be empty if the quota type does not need it.
 	ClientAddress string
+
+	// EntityID is the identity entity of the client token, if any. It is only
+	// populated when the applicable rate limit quota needs the client token to
+	// be resolved, i.e. when it groups by entity or has exemptions.
+	EntityID string
+
+	// Policies are the token and identity policies of the client token. Like
+	// EntityID, it is only populated when the client token was resolved.
+	Policies []string
+
+	// EntityMetadata is the metadata of the identity entity of the client
+	// token. Like EntityID, it is only populated when the client token was
+	// resolved.
+	EntityMetadata map[string]string
 }
 
 // NewManager creates and initializes a new quota manager to hold all the quota
@@ -682,6 +707,23 @@ func (m *Manager) QueryResolveRoleQuotas(req *Request) (bool, error) {
 	return false, nil
 }
 
+// QueryResolveTokenQuotas checks if the rate limit quota applicable to the
+// request groups clients by entity or has policy or entity metadata based
+// exemptions, which requires the client token to be resolved before the quota
+// is applied.
+func (m *Manager) QueryResolveTokenQuotas(req *Request) (bool, error) {
+	quota, err := m.QueryQuota(req)
+	if err != nil {
+		return false, err
+	}
+
+	rlq, ok := quota.(*RateLimitQuota)
+	if !ok {
+		return false, nil
+	}
+	return rlq.requiresToken(), nil
+}
+
 // DeleteQuota removes a quota rule the QuotaManager's storage view and then
 // updates the associated index in memdb.
 func (m *Manager) DeleteQuota(ctx context.Context, qType string, name string) error {
@@ -758,6 +800,24 @@ func (m *Manager) ApplyQuota(ctx context.Context, req *Request) (Response, error
 	return quota.allow(ctx, req)
 }
 
+// ReleaseQuota hands back the resources held by the request that was allowed
+// with the given access. It must be called once the request has completed for
+// responses of concurrency quotas, and is a no-op for any other access.
+func (m *Manager) ReleaseQuota(access Access) {
+	if a, ok := access.(*concurrencyAccess); ok {
+		a.release()
+	}
+}
+
+// ObserveRequest reports the latency and outcome of a completed request that
+// was allowed with the given access, so that adaptive rate limit quotas can
+// adjust their effective rate. It is a no-op for any other access.
+func (m *Manager) ObserveRequest(access Access, latency time.Duration, failed bool) {
+	if a, ok := access.(*rateLimitAccess); ok {
+		a.observe(latency, failed)
+	}
+}
+
 // SetEnableRateLimitAuditLogging updates the operator preference regarding the
 // audit logging behavior.
 func (m *Manager) SetEnableRateLimitAuditLogging(val bool) {
@@ -1132,6 +1192,8 @@ func Load(ctx context.Context, storage logical.Storage, qType, name string) (Quo
 		quota = &RateLimitQuota{}
 	case TypeLeaseCount.String():
 		quota = &LeaseCountQuota{}
+	case TypeConcurrency.String():
+		quota = &ConcurrencyQuota{}
 	default:
 		return nil, fmt.Errorf("unsupported type: %v", qType)
 	}
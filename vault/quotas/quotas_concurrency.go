@@ -0,0 +1,245 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package quotas
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/sdk/helper/cryptoutil"
+	"github.com/sethvargo/go-limiter/httplimit"
+)
+
+// Ensure that ConcurrencyQuota implements the Quota interface
+var _ Quota = (*ConcurrencyQuota)(nil)
+
+// ConcurrencyQuota represents the quota rule properties that is used to limit
+// the number of requests that are in flight at the same time for a mount or a
+// path within a mount.
+type ConcurrencyQuota struct {
+	// ID is the identifier of the quota
+	ID string `json:"id"`
+
+	// Type of quota this represents
+	Type Type `json:"type"`
+
+	// Name of the quota rule
+	Name string `json:"name"`
+
+	// NamespacePath is the path of the namespace to which this quota is
+	// applicable.
+	NamespacePath string `json:"namespace_path"`
+
+	// MountPath is the path of the mount to which this quota is applicable
+	MountPath string `json:"mount_path"`
+
+	// PathSuffix is the path suffix to which this quota is applicable
+	PathSuffix string `json:"path_suffix"`
+
+	// Role is not supported by concurrency quotas and is always empty. It is
+	// present so that concurrency quotas share the indexes of other quota types.
+	Role string `json:"role"`
+
+	// MaxInFlight defines the maximum number of requests that are allowed to
+	// be processed at the same time.
+	MaxInFlight int `json:"max_in_flight"`
+
+	// QueueTimeout defines how long a request waits for an in-flight request to
+	// complete when MaxInFlight is reached. If zero, such requests are rejected
+	// immediately.
+	QueueTimeout time.Duration `json:"queue_timeout"`
+
+	lock       *sync.RWMutex
+	slots      chan struct{}
+	logger     log.Logger
+	metricSink *metricsutil.ClusterMetricSink
+}
+
+// NewConcurrencyQuota creates a quota checker for imposing limits on the number
+// of concurrent requests for a mount or path. An optional queue timeout may be
+// provided, where if set, requests exceeding the limit wait up to that duration
+// for a slot to free up before they are rejected.
+func NewConcurrencyQuota(name, nsPath, mountPath, pathSuffix string, maxInFlight int, queueTimeout time.Duration) *ConcurrencyQuota {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		// Fall back to generating with a hash of the name, later in initialize
+		id = ""
+	}
+	return &ConcurrencyQuota{
+		Name:          name,
+		ID:            id,
+		Type:          TypeConcurrency,
+		NamespacePath: nsPath,
+		MountPath:     mountPath,
+		PathSuffix:    pathSuffix,
+		MaxInFlight:   maxInFlight,
+		QueueTimeout:  queueTimeout,
+	}
+}
+
+func (q *ConcurrencyQuota) Clone() Quota {
+	return &ConcurrencyQuota{
+		ID:            q.ID,
+		Name:          q.Name,
+		Type:          q.Type,
+		NamespacePath: q.NamespacePath,
+		MountPath:     q.MountPath,
+		PathSuffix:    q.PathSuffix,
+		MaxInFlight:   q.MaxInFlight,
+		QueueTimeout:  q.QueueTimeout,
+	}
+}
+
+func (q *ConcurrencyQuota) GetNamespacePath() string {
+	return q.NamespacePath
+}
+
+// IsInheritable always returns false, since concurrency quotas are only
+// applicable to mounts.
+func (q *ConcurrencyQuota) IsInheritable() bool {
+	return false
+}
+
+// initialize ensures the namespace and limits are valid, sets the ID if it's
+// currently empty, and allocates the in-flight request slots. Note, initialize
+// will reset the number of in-flight requests tracked by the quota.
+func (q *ConcurrencyQuota) initialize(logger log.Logger, ms *metricsutil.ClusterMetricSink) error {
+	if q.lock == nil {
+		q.lock = new(sync.RWMutex)
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	// Memdb requires a non-empty value for indexing
+	if q.NamespacePath == "" {
+		q.NamespacePath = "root"
+	}
+
+	if q.MaxInFlight <= 0 {
+		return fmt.Errorf("invalid max in flight: %v", q.MaxInFlight)
+	}
+
+	if q.QueueTimeout < 0 {
+		return fmt.Errorf("invalid queue timeout: %v", q.QueueTimeout)
+	}
+
+	if logger != nil {
+		q.logger = logger
+	}
+
+	if q.metricSink == nil {
+		q.metricSink = ms
+	}
+
+	if q.ID == "" {
+		q.ID = hex.EncodeToString(cryptoutil.Blake2b256Hash(q.Name))
+	}
+
+	q.slots = make(chan struct{}, q.MaxInFlight)
+
+	return nil
+}
+
+// quotaID returns the identifier of the quota rule
+func (q *ConcurrencyQuota) quotaID() string {
+	return q.ID
+}
+
+// QuotaName returns the name of the quota rule
+func (q *ConcurrencyQuota) QuotaName() string {
+	return q.Name
+}
+
+// inFlight returns the number of requests currently holding a slot.
+func (q *ConcurrencyQuota) inFlight() int {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	return len(q.slots)
+}
+
+// allow decides if the request is allowed by the quota. If a slot is free, the
+// request takes it and is allowed. Otherwise, the request either waits for a
+// slot until the queue timeout elapses, or is rejected immediately if there is
+// no queue timeout. The slot is held until the returned Access is passed to
+// Manager.ReleaseQuota.
+func (q *ConcurrencyQuota) allow(ctx context.Context, _ *Request) (Response, error) {
+	resp := Response{
+		Headers: make(map[string]string),
+	}
+
+	q.lock.RLock()
+	slots := q.slots
+	q.lock.RUnlock()
+
+	defer func() {
+		if !resp.Allowed {
+			resp.Headers[httplimit.HeaderRetryAfter] = strconv.Itoa(int(q.QueueTimeout.Seconds()) + 1)
+			q.metricSink.IncrCounterWithLabels([]string{"quota", "concurrency", "violation"}, 1, []metrics.Label{{Name: "name", Value: q.Name}})
+		}
+	}()
+
+	select {
+	case slots <- struct{}{}:
+		resp.Allowed = true
+	default:
+		if q.QueueTimeout == 0 {
+			return resp, nil
+		}
+
+		timer := time.NewTimer(q.QueueTimeout)
+		defer timer.Stop()
+
+		select {
+		case slots <- struct{}{}:
+			resp.Allowed = true
+		case <-timer.C:
+			return resp, nil
+		case <-ctx.Done():
+			return resp, nil
+		}
+	}
+
+	resp.Access = &concurrencyAccess{
+		access: access{quotaID: q.ID},
+		slots:  slots,
+	}
+	return resp, nil
+}
+
+// close is a no-op for concurrency quotas. Requests that hold or wait for a
+// slot of a closed quota finish against the slots they started with.
+func (q *ConcurrencyQuota) close(_ context.Context) error {
+	return nil
+}
+
+func (q *ConcurrencyQuota) handleRemount(mountpath, nspath string) {
+	q.MountPath = mountpath
+	q.NamespacePath = nspath
+}
+
+// concurrencyAccess is the Access returned for requests allowed by a
+// concurrency quota. It holds on to the slot taken by the request.
+type concurrencyAccess struct {
+	access
+
+	once  sync.Once
+	slots chan struct{}
+}
+
+// release frees the slot held by the request. It is safe to call more than
+// once.
+func (a *concurrencyAccess) release() {
+	a.once.Do(func() {
+		<-a.slots
+	})
+}
@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package quotas
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConcurrencyQuota(t *testing.T) {
+	testCases := []struct {
+		name      string
+		cq        *ConcurrencyQuota
+		expectErr bool
+	}{
+		{"valid", NewConcurrencyQuota("test-concurrency", "qa", "transit/", "", 4, time.Second), false},
+		{"invalid max in flight", NewConcurrencyQuota("test-concurrency", "qa", "transit/", "", 0, 0), true},
+		{"invalid queue timeout", NewConcurrencyQuota("test-concurrency", "qa", "transit/", "", 4, -time.Second), true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink())
+			require.Equal(t, tc.expectErr, err != nil, err)
+		})
+	}
+}
+
+func TestConcurrencyQuota_Allow(t *testing.T) {
+	cq := NewConcurrencyQuota("test-concurrency", "", "transit/", "", 2, 0)
+	require.NoError(t, cq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+
+	var accesses []Access
+	for i := 0; i < 2; i++ {
+		resp, err := cq.allow(context.Background(), &Request{})
+		require.NoError(t, err)
+		require.True(t, resp.Allowed)
+		require.NotNil(t, resp.Access)
+		accesses = append(accesses, resp.Access)
+	}
+	require.Equal(t, 2, cq.inFlight())
+
+	// Without a queue timeout, requests over the limit are rejected right away
+	resp, err := cq.allow(context.Background(), &Request{})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+	require.Nil(t, resp.Access)
+
+	// Releasing more than once must only free a single slot
+	accesses[0].(*concurrencyAccess).release()
+	accesses[0].(*concurrencyAccess).release()
+	require.Equal(t, 1, cq.inFlight())
+
+	resp, err = cq.allow(context.Background(), &Request{})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+}
+
+func TestConcurrencyQuota_AllowQueued(t *testing.T) {
+	cq := NewConcurrencyQuota("test-concurrency", "", "transit/", "", 1, 5*time.Second)
+	require.NoError(t, cq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+
+	resp, err := cq.allow(context.Background(), &Request{})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+
+	// A queued request is allowed once the in-flight request completes
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		resp.Access.(*concurrencyAccess).release()
+	}()
+	queued, err := cq.allow(context.Background(), &Request{})
+	require.NoError(t, err)
+	require.True(t, queued.Allowed)
+
+	// A queued request gives up when its context is canceled
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	resp, err = cq.allow(ctx, &Request{})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+}
+
+func TestConcurrencyQuota_Manager(t *testing.T) {
+	qm, err := NewManager(logging.NewVaultLogger(log.Trace), nil, metricsutil.BlackholeSink(), true)
+	require.NoError(t, err)
+	require.NoError(t, qm.Setup(context.Background(), &logical.InmemStorage{}, nil))
+
+	quota := NewConcurrencyQuota("cq", "", "transit/", "", 1, 0)
+	require.NoError(t, qm.SetQuota(context.Background(), TypeConcurrency.String(), quota, false))
+
+	req := &Request{
+		Type:      TypeConcurrency,
+		Path:      "transit/encrypt/foo",
+		MountPath: "transit/",
+	}
+	resp, err := qm.ApplyQuota(context.Background(), req)
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+
+	denied, err := qm.ApplyQuota(context.Background(), req)
+	require.NoError(t, err)
+	require.False(t, denied.Allowed)
+
+	// Other mounts are not affected by the quota
+	other, err := qm.ApplyQuota(context.Background(), &Request{Type: TypeConcurrency, Path: "kv/foo", MountPath: "kv/"})
+	require.NoError(t, err)
+	require.True(t, other.Allowed)
+
+	qm.ReleaseQuota(resp.Access)
+	resp, err = qm.ApplyQuota(context.Background(), req)
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+}
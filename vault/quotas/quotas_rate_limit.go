@@ -7,7 +7,9 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
+	"maps"
 	"math"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
@@ -35,6 +37,29 @@ const (
 	EnvVaultEnableRateLimitAuditLogging = "VAULT_ENABLE_RATE_LIMIT_AUDIT_LOGGING"
 )
 
+// The supported values of GroupBy, which determine how requests are grouped
+// into clients, each of which gets its own rate limiter.
+const (
+	// GroupByIP groups requests by the client IP address. This is the default.
+	GroupByIP = "ip"
+
+	// GroupByNone puts all requests matching the quota into a single group.
+	GroupByNone = "none"
+
+	// GroupByEntityThenIP groups requests by the identity entity of the client
+	// token, falling back to the client IP address for requests without an
+	// entity.
+	GroupByEntityThenIP = "entity_then_ip"
+
+	// GroupByEntityThenNone groups requests by the identity entity of the
+	// client token, placing all requests without an entity into a single group.
+	GroupByEntityThenNone = "entity_then_none"
+)
+
+// rateLimitGroupEntityPrefix prefixes entity IDs used as limiter keys, so they
+// can never collide with client addresses.
+const rateLimitGroupEntityPrefix = "entity:"
+
 // Ensure that RateLimitQuota implements the Quota interface
 var _ Quota = (*RateLimitQuota)(nil)
 
@@ -78,6 +103,34 @@ type RateLimitQuota struct {
 	// reaches the rate limit.
 	BlockInterval time.Duration `json:"block_interval"`
 
+	// GroupBy determines how requests are grouped into clients for rate
+	// limiting purposes. See the GroupBy* constants for valid values.
+	GroupBy string `json:"group_by"`
+
+	// AdaptiveTargetLatency, if non-zero, enables adaptive rate limiting. The
+	// effective rate of the quota is then periodically adjusted based on the
+	// requests it allowed: it is decreased multiplicatively when their p99
+	// latency exceeds AdaptiveTargetLatency or their error rate exceeds
+	// AdaptiveMaxErrorRate, and increased additively back towards Rate
+	// otherwise.
+	AdaptiveTargetLatency time.Duration `json:"adaptive_target_latency"`
+
+	// AdaptiveMaxErrorRate is the fraction of failed requests above which the
+	// effective rate is decreased. Only used with adaptive rate limiting.
+	AdaptiveMaxErrorRate float64 `json:"adaptive_max_error_rate"`
+
+	// AdaptiveMinRate is the lowest effective rate adaptive rate limiting may
+	// decrease the rate to.
+	AdaptiveMinRate float64 `json:"adaptive_min_rate"`
+
+	// ExemptPolicies exempts requests from the quota if their client token
+	// carries any of these policies, either directly or through its identity.
+	ExemptPolicies []string `json:"exempt_policies"`
+
+	// ExemptEntityMetadata exempts requests from the quota if the identity
+	// entity of their client token has all of these metadata key/value pairs.
+	ExemptEntityMetadata map[string]string `json:"exempt_entity_metadata"`
+
 	lock                *sync.RWMutex
 	store               limiter.Store
 	logger              log.Logger
@@ -87,6 +140,9 @@ type RateLimitQuota struct {
 	blockedClients      sync.Map
 	purgeBlocked        bool
 	closePurgeBlockedCh chan struct{}
+	adaptive            *adaptiveController
+	adaptiveWindow      time.Duration
+	closeAdaptiveCh     chan struct{}
 }
 
 func (q *RateLimitQuota) GetNamespacePath() string {
@@ -116,6 +172,7 @@ func NewRateLimitQuota(name, nsPath, mountPath, pathSuffix, role string, inherit
 		Rate:          rate,
 		Interval:      interval,
 		BlockInterval: block,
+		GroupBy:       GroupByIP,
 		purgeInterval: DefaultRateLimitPurgeInterval,
 		staleAge:      DefaultRateLimitStaleAge,
 	}
@@ -134,6 +191,14 @@ func (q *RateLimitQuota) Clone() Quota {
 		BlockInterval: q.BlockInterval,
 		Rate:          q.Rate,
 		Interval:      q.Interval,
+		GroupBy:       q.GroupBy,
+
+		AdaptiveTargetLatency: q.AdaptiveTargetLatency,
+		AdaptiveMaxErrorRate:  q.AdaptiveMaxErrorRate,
+		AdaptiveMinRate:       q.AdaptiveMinRate,
+
+		ExemptPolicies:       slices.Clone(q.ExemptPolicies),
+		ExemptEntityMetadata: maps.Clone(q.ExemptEntityMetadata),
 	}
 	return rlq
 }
@@ -171,6 +236,34 @@ func (rlq *RateLimitQuota) initialize(logger log.Logger, ms *metricsutil.Cluster
 		return fmt.Errorf("invalid block interval: %v", rlq.BlockInterval)
 	}
 
+	// Quotas created before grouping was configurable are grouped by IP.
+	if rlq.GroupBy == "" {
+		rlq.GroupBy = GroupByIP
+	}
+
+	if !ValidRateLimitGroupBy(rlq.GroupBy) {
+		return fmt.Errorf("invalid group_by: %q", rlq.GroupBy)
+	}
+
+	if rlq.AdaptiveTargetLatency < 0 {
+		return fmt.Errorf("invalid adaptive target latency: %v", rlq.AdaptiveTargetLatency)
+	}
+
+	if rlq.adaptiveEnabled() {
+		if rlq.AdaptiveMaxErrorRate == 0 {
+			rlq.AdaptiveMaxErrorRate = DefaultAdaptiveMaxErrorRate
+		}
+		if rlq.AdaptiveMaxErrorRate < 0 || rlq.AdaptiveMaxErrorRate > 1 {
+			return fmt.Errorf("invalid adaptive max error rate: %v", rlq.AdaptiveMaxErrorRate)
+		}
+		if rlq.AdaptiveMinRate == 0 {
+			rlq.AdaptiveMinRate = math.Max(1, rlq.Rate*0.1)
+		}
+		if rlq.AdaptiveMinRate < 0 || rlq.AdaptiveMinRate > rlq.Rate {
+			return fmt.Errorf("invalid adaptive min rate: %v", rlq.AdaptiveMinRate)
+		}
+	}
+
 	if logger != nil {
 		rlq.logger = logger
 	}
@@ -200,12 +293,7 @@ func (rlq *RateLimitQuota) initialize(logger log.Logger, ms *metricsutil.Cluster
 		rlq.staleAge = DefaultRateLimitStaleAge
 	}
 
-	rlStore, err := memorystore.New(&memorystore.Config{
-		Tokens:        uint64(math.Round(rlq.Rate)), // allow 'rlq.Rate' number of requests per 'Interval'
-		Interval:      rlq.Interval,                 // time interval in which to enforce rate limiting
-		SweepInterval: rlq.purgeInterval,            // how often stale clients are removed
-		SweepMinTTL:   rlq.staleAge,                 // how long since the last request a client is considered stale
-	})
+	rlStore, err := rlq.newStore(rlq.Rate)
 	if err != nil {
 		return err
 	}
@@ -219,9 +307,32 @@ func (rlq *RateLimitQuota) initialize(logger log.Logger, ms *metricsutil.Cluster
 		go rlq.purgeBlockedClients()
 	}
 
+	if rlq.adaptiveEnabled() && rlq.adaptive == nil {
+		if rlq.adaptiveWindow == 0 {
+			rlq.adaptiveWindow = DefaultAdaptiveRateLimitWindow
+		}
+		rlq.adaptive = newAdaptiveController(rlq.AdaptiveTargetLatency, rlq.AdaptiveMaxErrorRate, rlq.AdaptiveMinRate, rlq.Rate)
+		rlq.closeAdaptiveCh = make(chan struct{})
+		go rlq.adaptRate(rlq.adaptive, rlq.closeAdaptiveCh)
+	}
+
 	return nil
 }
 
+// newStore creates the store holding the client rate limiters, allowing the
+// given number of requests per interval.
+func (rlq *RateLimitQuota) newStore(rate float64) (limiter.Store, error) {
+	if rlq.adaptiveEnabled() {
+		return newAdaptiveStore(uint64(math.Round(rate)), rlq.Interval, rlq.purgeInterval, rlq.staleAge), nil
+	}
+	return memorystore.New(&memorystore.Config{
+		Tokens:        uint64(math.Round(rate)), // allow 'rate' number of requests per 'Interval'
+		Interval:      rlq.Interval,             // time interval in which to enforce rate limiting
+		SweepInterval: rlq.purgeInterval,        // how often stale clients are removed
+		SweepMinTTL:   rlq.staleAge,             // how long since the last request a client is considered stale
+	})
+}
+
 // purgeBlockedClients performs a blocking process where every purgeInterval
 // duration, we look at all blocked clients to potentially remove from the blocked
 // clients map.
@@ -281,6 +392,74 @@ func (rlq *RateLimitQuota) numBlockedClients() int {
 	return size
 }
 
+// ValidRateLimitGroupBy returns whether the given value is a supported way of
+// grouping requests into clients.
+func ValidRateLimitGroupBy(groupBy string) bool {
+	switch groupBy {
+	case GroupByIP, GroupByNone, GroupByEntityThenIP, GroupByEntityThenNone:
+		return true
+	default:
+		return false
+	}
+}
+
+// groupsByEntity returns whether the quota needs the entity ID of the client
+// token to determine the client of a request.
+func (rlq *RateLimitQuota) groupsByEntity() bool {
+	return rlq.GroupBy == GroupByEntityThenIP || rlq.GroupBy == GroupByEntityThenNone
+}
+
+// hasExemptions returns whether the quota exempts some requests based on the
+// policies or entity metadata of their client token.
+func (rlq *RateLimitQuota) hasExemptions() bool {
+	return len(rlq.ExemptPolicies) > 0 || len(rlq.ExemptEntityMetadata) > 0
+}
+
+// requiresToken returns whether the client token of a request needs to be
+// resolved to apply the quota.
+func (rlq *RateLimitQuota) requiresToken() bool {
+	return rlq.groupsByEntity() || rlq.hasExemptions()
+}
+
+// exempt returns whether the request is exempt from the quota, because its
+// client token carries one of the exempt policies or its entity has all of the
+// exempt metadata.
+func (rlq *RateLimitQuota) exempt(req *Request) bool {
+	for _, policy := range req.Policies {
+		if slices.Contains(rlq.ExemptPolicies, policy) {
+			return true
+		}
+	}
+
+	if len(rlq.ExemptEntityMetadata) == 0 || req.EntityID == "" {
+		return false
+	}
+	for k, v := range rlq.ExemptEntityMetadata {
+		if actual, ok := req.EntityMetadata[k]; !ok || actual != v {
+			return false
+		}
+	}
+	return true
+}
+
+// clientKey returns the key of the client limiter that the request should be
+// counted against, based on the quota's GroupBy setting.
+func (rlq *RateLimitQuota) clientKey(req *Request) (string, error) {
+	if rlq.groupsByEntity() && req.EntityID != "" {
+		return rateLimitGroupEntityPrefix + req.EntityID, nil
+	}
+
+	switch rlq.GroupBy {
+	case GroupByNone, GroupByEntityThenNone:
+		return "", nil
+	}
+
+	if req.ClientAddress == "" {
+		return "", fmt.Errorf("missing request client address in quota request")
+	}
+	return req.ClientAddress, nil
+}
+
 // quotaID returns the identifier of the quota rule
 func (rlq *RateLimitQuota) quotaID() string {
 	return rlq.ID
@@ -292,16 +471,24 @@ func (rlq *RateLimitQuota) QuotaName() string {
 }
 
 // allow decides if the request is allowed by the quota. An error will be
-// returned if the request ID or address is empty. If the path is exempt, the
-// quota will not be evaluated. Otherwise, the client rate limiter is retrieved
-// by address and the rate limit quota is checked against that limiter.
+// returned if the quota groups by address and the address is empty. If the
+// path is exempt, the quota will not be evaluated. Otherwise, the client rate
+// limiter is retrieved by the client key (address, entity, or a single shared
+// key depending on GroupBy) and the rate limit quota is checked against that
+// limiter.
 func (rlq *RateLimitQuota) allow(ctx context.Context, req *Request) (Response, error) {
 	resp := Response{
 		Headers: make(map[string]string),
 	}
 
-	if req.ClientAddress == "" {
-		return resp, fmt.Errorf("missing request client address in quota request")
+	if rlq.exempt(req) {
+		resp.Allowed = true
+		return resp, nil
+	}
+
+	key, err := rlq.clientKey(req)
+	if err != nil {
+		return resp, err
 	}
 
 	var retryAfter string
@@ -318,11 +505,11 @@ func (rlq *RateLimitQuota) allow(ctx context.Context, req *Request) (Response, e
 	// of purging blocked clients may not yield a false negative. In other words,
 	// a client may no longer be considered blocked whereas the purging interval
 	// has yet to run.
-	if v, ok := rlq.blockedClients.Load(req.ClientAddress); ok {
+	if v, ok := rlq.blockedClients.Load(key); ok {
 		blockedAt := v.(time.Time)
 		if time.Since(blockedAt) >= rlq.BlockInterval {
 			// allow the request and remove the blocked client
-			rlq.blockedClients.Delete(req.ClientAddress)
+			rlq.blockedClients.Delete(key)
 		} else {
 			// deny the request and return early
 			resp.Allowed = false
@@ -331,7 +518,11 @@ func (rlq *RateLimitQuota) allow(ctx context.Context, req *Request) (Response, e
 		}
 	}
 
-	limit, remaining, reset, allow, err := rlq.store.Take(ctx, req.ClientAddress)
+	rlq.lock.RLock()
+	store, adaptive := rlq.store, rlq.adaptive
+	rlq.lock.RUnlock()
+
+	limit, remaining, reset, allow, err := store.Take(ctx, key)
 	if err != nil {
 		return resp, err
 	}
@@ -347,7 +538,14 @@ func (rlq *RateLimitQuota) allow(ctx context.Context, req *Request) (Response, e
 	if !resp.Allowed && rlq.purgeBlocked {
 		blockedAt := time.Now()
 		retryAfter = strconv.Itoa(int(time.Until(blockedAt.Add(rlq.BlockInterval)).Seconds()))
-		rlq.blockedClients.Store(req.ClientAddress, blockedAt)
+		rlq.blockedClients.Store(key, blockedAt)
+	}
+
+	if resp.Allowed && adaptive != nil {
+		resp.Access = &rateLimitAccess{
+			access:   access{quotaID: rlq.ID},
+			adaptive: adaptive,
+		}
 	}
 
 	return resp, nil
@@ -360,6 +558,13 @@ func (rlq *RateLimitQuota) close(ctx context.Context) error {
 		close(rlq.closePurgeBlockedCh)
 	}
 
+	rlq.lock.Lock()
+	if rlq.adaptive != nil {
+		close(rlq.closeAdaptiveCh)
+		rlq.adaptive = nil
+	}
+	rlq.lock.Unlock()
+
 	if rlq.store != nil {
 		return rlq.store.Close(ctx)
 	}
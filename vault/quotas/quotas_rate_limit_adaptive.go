@@ -0,0 +1,397 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package quotas
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/sethvargo/go-limiter"
+)
+
+const (
+	// DefaultAdaptiveRateLimitWindow is how often an adaptive rate limit quota
+	// re-evaluates its effective rate.
+	DefaultAdaptiveRateLimitWindow = 10 * time.Second
+
+	// DefaultAdaptiveMaxErrorRate is the fraction of failed requests above
+	// which an adaptive rate limit quota decreases its effective rate, if no
+	// other maximum error rate is configured.
+	DefaultAdaptiveMaxErrorRate = 0.05
+
+	// adaptiveDecreaseFactor is the factor the effective rate is multiplied by
+	// when the backend is considered overloaded.
+	adaptiveDecreaseFactor = 0.7
+
+	// adaptiveIncreaseFraction is the fraction of the configured rate that is
+	// added back to the effective rate when the backend is considered healthy.
+	adaptiveIncreaseFraction = 0.05
+
+	// adaptiveMinSamples is the minimum number of requests observed in a window
+	// before the effective rate is adjusted. Windows with fewer requests carry
+	// too little signal to act on.
+	adaptiveMinSamples = 20
+
+	// adaptiveMaxSamples bounds the number of latencies kept per window. Once
+	// reached, the oldest samples are overwritten.
+	adaptiveMaxSamples = 4096
+)
+
+// adaptiveController implements AIMD (additive increase, multiplicative
+// decrease) control of the effective rate of a rate limit quota, based on the
+// latency and outcome of the requests it allowed during the last window.
+type adaptiveController struct {
+	lock sync.Mutex
+
+	targetLatency time.Duration
+	maxErrorRate  float64
+	minRate       float64
+	maxRate       float64
+
+	rate      float64
+	latencies []time.Duration
+	next      int
+	total     int
+	failed    int
+}
+
+func newAdaptiveController(targetLatency time.Duration, maxErrorRate, minRate, maxRate float64) *adaptiveController {
+	return &adaptiveController{
+		targetLatency: targetLatency,
+		maxErrorRate:  maxErrorRate,
+		minRate:       minRate,
+		maxRate:       maxRate,
+		rate:          maxRate,
+	}
+}
+
+// observe records the latency and outcome of a single request.
+func (a *adaptiveController) observe(latency time.Duration, failed bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.total++
+	if failed {
+		a.failed++
+	}
+
+	if len(a.latencies) < adaptiveMaxSamples {
+		a.latencies = append(a.latencies, latency)
+		return
+	}
+	a.latencies[a.next] = latency
+	a.next = (a.next + 1) % adaptiveMaxSamples
+}
+
+// evaluate closes the current window and returns the new effective rate, and
+// whether it changed since the last window.
+func (a *adaptiveController) evaluate() (float64, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	latencies, total, failed := a.latencies, a.total, a.failed
+	a.latencies, a.next, a.total, a.failed = nil, 0, 0, 0
+
+	if total < adaptiveMinSamples {
+		return a.rate, false
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	p99 := latencies[int(math.Ceil(float64(len(latencies))*0.99))-1]
+	errorRate := float64(failed) / float64(total)
+
+	rate := a.rate
+	if p99 > a.targetLatency || errorRate > a.maxErrorRate {
+		rate = math.Max(a.minRate, rate*adaptiveDecreaseFactor)
+	} else {
+		rate = math.Min(a.maxRate, rate+a.maxRate*adaptiveIncreaseFraction)
+	}
+
+	changed := math.Round(rate) != math.Round(a.rate)
+	a.rate = rate
+	return rate, changed
+}
+
+// effectiveRate returns the rate currently enforced by the controller.
+func (a *adaptiveController) effectiveRate() float64 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.rate
+}
+
+// adaptRate performs a blocking process where every adaptiveWindow duration,
+// the effective rate of the quota is re-evaluated and, if changed, applied to
+// the client limiters in place. The loop runs until a value is sent on the
+// closeAdaptiveCh.
+func (rlq *RateLimitQuota) adaptRate(adaptive *adaptiveController, closeCh chan struct{}) {
+	rlq.lock.RLock()
+	ticker := time.NewTicker(rlq.adaptiveWindow)
+	rlq.lock.RUnlock()
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rate, changed := adaptive.evaluate()
+			rlq.metricSink.SetGaugeWithLabels([]string{"quota", "rate_limit", "effective_rate"}, float32(rate), []metrics.Label{{Name: "name", Value: rlq.Name}})
+			if !changed {
+				continue
+			}
+
+			rlq.lock.RLock()
+			store, ok := rlq.store.(*adaptiveStore)
+			rlq.lock.RUnlock()
+			if !ok {
+				continue
+			}
+
+			store.setTokens(uint64(math.Round(rate)))
+			rlq.logger.Debug("adjusted adaptive rate limit", "name", rlq.Name, "rate", rate)
+
+		case <-closeCh:
+			return
+		}
+	}
+}
+
+// adaptiveEnabled returns whether the effective rate of the quota is adjusted
+// based on backend latency and errors.
+func (rlq *RateLimitQuota) adaptiveEnabled() bool {
+	return rlq.AdaptiveTargetLatency > 0
+}
+
+// EffectiveRate returns the rate currently enforced by the quota. This is the
+// configured rate unless adaptive rate limiting is enabled.
+func (rlq *RateLimitQuota) EffectiveRate() float64 {
+	if rlq.lock == nil {
+		return rlq.Rate
+	}
+
+	rlq.lock.RLock()
+	adaptive := rlq.adaptive
+	rlq.lock.RUnlock()
+
+	if adaptive == nil {
+		return rlq.Rate
+	}
+	return adaptive.effectiveRate()
+}
+
+// rateLimitAccess is the Access returned for requests allowed by an adaptive
+// rate limit quota. It is used to report the outcome of the request back to
+// the quota's controller.
+type rateLimitAccess struct {
+	access
+
+	adaptive *adaptiveController
+}
+
+// observe reports the latency and outcome of the request to the controller.
+func (a *rateLimitAccess) observe(latency time.Duration, failed bool) {
+	a.adaptive.observe(latency, failed)
+}
+
+// adaptiveStore is the limiter.Store holding the client limiters of adaptive
+// rate limit quotas. Unlike memorystore, the number of tokens of its buckets
+// can be changed in place, so that a change of the effective rate neither
+// refills nor empties the buckets of the clients.
+type adaptiveStore struct {
+	lock     sync.RWMutex
+	tokens   uint64
+	interval time.Duration
+	buckets  map[string]*adaptiveBucket
+	stopped  bool
+	stopCh   chan struct{}
+}
+
+// adaptiveBucket holds the tokens available to a client until reset.
+type adaptiveBucket struct {
+	lock      sync.Mutex
+	tokens    uint64
+	available uint64
+	interval  time.Duration
+	reset     time.Time
+	lastUsed  time.Time
+}
+
+var _ limiter.Store = (*adaptiveStore)(nil)
+
+// newAdaptiveStore creates a store allowing the given number of tokens per
+// interval to each client. Every sweepInterval, clients that have not been
+// seen for sweepMinTTL are removed.
+func newAdaptiveStore(tokens uint64, interval, sweepInterval, sweepMinTTL time.Duration) *adaptiveStore {
+	s := &adaptiveStore{
+		tokens:   max(tokens, 1),
+		interval: interval,
+		buckets:  make(map[string]*adaptiveBucket),
+		stopCh:   make(chan struct{}),
+	}
+	go s.sweep(sweepInterval, sweepMinTTL)
+	return s
+}
+
+func newAdaptiveBucket(tokens uint64, interval time.Duration, now time.Time) *adaptiveBucket {
+	return &adaptiveBucket{
+		tokens:    tokens,
+		available: tokens,
+		interval:  interval,
+		reset:     now.Add(interval),
+		lastUsed:  now,
+	}
+}
+
+// bucket returns the bucket of the client, creating it if needed.
+func (s *adaptiveStore) bucket(key string) (*adaptiveBucket, error) {
+	s.lock.RLock()
+	b, ok := s.buckets[key]
+	stopped := s.stopped
+	s.lock.RUnlock()
+	if stopped {
+		return nil, limiter.ErrStopped
+	}
+	if ok {
+		return b, nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stopped {
+		return nil, limiter.ErrStopped
+	}
+	if b, ok := s.buckets[key]; ok {
+		return b, nil
+	}
+	b = newAdaptiveBucket(s.tokens, s.interval, time.Now())
+	s.buckets[key] = b
+	return b, nil
+}
+
+// Take takes a token from the bucket of the client, refilling the bucket
+// first if its interval has passed.
+func (s *adaptiveStore) Take(_ context.Context, key string) (uint64, uint64, uint64, bool, error) {
+	b, err := s.bucket(key)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+
+	now := time.Now()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !now.Before(b.reset) {
+		b.available = b.tokens
+		b.reset = now.Add(b.interval)
+	}
+	b.lastUsed = now
+
+	var ok bool
+	if b.available > 0 {
+		b.available--
+		ok = true
+	}
+	return b.tokens, b.available, uint64(b.reset.UnixNano()), ok, nil
+}
+
+// Get returns the tokens and remaining tokens of the bucket of the client.
+func (s *adaptiveStore) Get(_ context.Context, key string) (uint64, uint64, error) {
+	s.lock.RLock()
+	b, ok := s.buckets[key]
+	stopped := s.stopped
+	s.lock.RUnlock()
+	if stopped {
+		return 0, 0, limiter.ErrStopped
+	}
+	if !ok {
+		return 0, 0, nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.tokens, b.available, nil
+}
+
+// Set replaces the bucket of the client with a full bucket of the given
+// tokens and interval.
+func (s *adaptiveStore) Set(_ context.Context, key string, tokens uint64, interval time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stopped {
+		return limiter.ErrStopped
+	}
+	s.buckets[key] = newAdaptiveBucket(tokens, interval, time.Now())
+	return nil
+}
+
+// Burst adds tokens to the bucket of the client until it is next refilled.
+func (s *adaptiveStore) Burst(_ context.Context, key string, tokens uint64) error {
+	b, err := s.bucket(key)
+	if err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.available += tokens
+	return nil
+}
+
+// Close stops sweeping the store and removes its buckets.
+func (s *adaptiveStore) Close(_ context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stopped {
+		return nil
+	}
+	s.stopped = true
+	s.buckets = nil
+	close(s.stopCh)
+	return nil
+}
+
+// setTokens changes the number of tokens of the store and of the buckets of
+// the clients. Clients keep the tokens they have left, up to the new number.
+func (s *adaptiveStore) setTokens(tokens uint64) {
+	tokens = max(tokens, 1)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.tokens = tokens
+	for _, b := range s.buckets {
+		b.lock.Lock()
+		b.tokens = tokens
+		b.available = min(b.available, tokens)
+		b.lock.Unlock()
+	}
+}
+
+// sweep removes the buckets of clients that have not been seen for minTTL,
+// every interval, until the store is closed.
+func (s *adaptiveStore) sweep(interval, minTTL time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			s.lock.Lock()
+			for key, b := range s.buckets {
+				b.lock.Lock()
+				stale := now.Sub(b.lastUsed) >= minTTL
+				b.lock.Unlock()
+				if stale {
+					delete(s.buckets, key)
+				}
+			}
+			s.lock.Unlock()
+
+		case <-s.stopCh:
+			return
+		}
+	}
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/sdk/helper/logging"
 	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/sethvargo/go-limiter"
+	"github.com/sethvargo/go-limiter/httplimit"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
 	"go.uber.org/goleak"
@@ -231,3 +233,232 @@ func TestRateLimitQuota_Update(t *testing.T) {
 
 	require.Nil(t, quota.close(context.Background()))
 }
+
+// TestRateLimitQuota_GroupBy verifies that requests are counted against the
+// client limiter selected by the quota's GroupBy setting.
+func TestRateLimitQuota_GroupBy(t *testing.T) {
+	testCases := []struct {
+		groupBy string
+		second  *Request
+		allowed bool
+	}{
+		{GroupByIP, &Request{ClientAddress: "127.0.0.1", EntityID: "entity2"}, false},
+		{GroupByIP, &Request{ClientAddress: "127.0.0.2", EntityID: "entity1"}, true},
+		{GroupByNone, &Request{ClientAddress: "127.0.0.2", EntityID: "entity2"}, false},
+		{GroupByEntityThenIP, &Request{ClientAddress: "127.0.0.1", EntityID: "entity2"}, true},
+		{GroupByEntityThenIP, &Request{ClientAddress: "127.0.0.2", EntityID: "entity1"}, false},
+		{GroupByEntityThenIP, &Request{ClientAddress: "127.0.0.1"}, true},
+		{GroupByEntityThenNone, &Request{ClientAddress: "127.0.0.1", EntityID: "entity2"}, true},
+		{GroupByEntityThenNone, &Request{ClientAddress: "127.0.0.2", EntityID: "entity1"}, false},
+		{GroupByEntityThenNone, &Request{ClientAddress: "127.0.0.2"}, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.groupBy, func(t *testing.T) {
+			rlq := NewRateLimitQuota("test-rate-limiter", "", "", "", "", true, time.Minute, 0, 1)
+			rlq.GroupBy = tc.groupBy
+			require.NoError(t, rlq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+			defer rlq.close(context.Background())
+
+			resp, err := rlq.allow(context.Background(), &Request{ClientAddress: "127.0.0.1", EntityID: "entity1"})
+			require.NoError(t, err)
+			require.True(t, resp.Allowed)
+
+			resp, err = rlq.allow(context.Background(), tc.second)
+			require.NoError(t, err)
+			require.Equal(t, tc.allowed, resp.Allowed)
+		})
+	}
+
+	rlq := NewRateLimitQuota("test-rate-limiter", "", "", "", "", true, time.Minute, 0, 1)
+	rlq.GroupBy = "role"
+	require.Error(t, rlq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+}
+
+func TestRateLimitQuota_AdaptiveController(t *testing.T) {
+	a := newAdaptiveController(100*time.Millisecond, 0.1, 10, 100)
+
+	// Too few samples leave the rate unchanged
+	a.observe(time.Second, true)
+	rate, changed := a.evaluate()
+	require.False(t, changed)
+	require.Equal(t, 100.0, rate)
+
+	// High latency decreases the rate multiplicatively
+	for i := 0; i < 100; i++ {
+		a.observe(time.Second, false)
+	}
+	rate, changed = a.evaluate()
+	require.True(t, changed)
+	require.InDelta(t, 70.0, rate, 0.001)
+
+	// A high error rate decreases the rate as well, but never below the minimum
+	for j := 0; j < 10; j++ {
+		for i := 0; i < 100; i++ {
+			a.observe(time.Millisecond, i < 20)
+		}
+		rate, _ = a.evaluate()
+	}
+	require.Equal(t, 10.0, rate)
+
+	// Healthy windows increase the rate additively up to the configured rate
+	for i := 0; i < 100; i++ {
+		a.observe(time.Millisecond, false)
+	}
+	rate, changed = a.evaluate()
+	require.True(t, changed)
+	require.InDelta(t, 15.0, rate, 0.001)
+
+	for j := 0; j < 50; j++ {
+		for i := 0; i < 100; i++ {
+			a.observe(time.Millisecond, false)
+		}
+		rate, _ = a.evaluate()
+	}
+	require.Equal(t, 100.0, rate)
+}
+
+func TestRateLimitQuota_Adaptive(t *testing.T) {
+	rlq := NewRateLimitQuota("test-rate-limiter", "", "", "", "", true, time.Minute, 0, 100)
+	rlq.AdaptiveTargetLatency = 10 * time.Millisecond
+	rlq.adaptiveWindow = 100 * time.Millisecond
+	require.NoError(t, rlq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+	defer rlq.close(context.Background())
+
+	require.Equal(t, DefaultAdaptiveMaxErrorRate, rlq.AdaptiveMaxErrorRate)
+	require.Equal(t, 10.0, rlq.AdaptiveMinRate)
+
+	// Report slow requests until the effective rate has been decreased
+	for i := 0; i < adaptiveMinSamples; i++ {
+		resp, err := rlq.allow(context.Background(), &Request{ClientAddress: "127.0.0.1"})
+		require.NoError(t, err)
+		require.True(t, resp.Allowed)
+		require.NotNil(t, resp.Access)
+		resp.Access.(*rateLimitAccess).observe(time.Second, false)
+	}
+	require.Eventually(t, func() bool {
+		return rlq.EffectiveRate() < 100
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// The client limiters now enforce the decreased rate
+	rate := rlq.EffectiveRate()
+	var allowed int
+	for i := 0; i < 100; i++ {
+		resp, err := rlq.allow(context.Background(), &Request{ClientAddress: "127.0.0.2"})
+		require.NoError(t, err)
+		if resp.Allowed {
+			allowed++
+		}
+	}
+	require.LessOrEqual(t, allowed, int(math.Round(rate)))
+}
+
+// TestRateLimitQuota_Adaptive_KeepsClientState verifies that a change of the
+// effective rate does not refill the buckets of clients which used them up.
+func TestRateLimitQuota_Adaptive_KeepsClientState(t *testing.T) {
+	rlq := NewRateLimitQuota("test-rate-limiter", "", "", "", "", true, time.Minute, 0, 100)
+	rlq.AdaptiveTargetLatency = 10 * time.Millisecond
+	rlq.adaptiveWindow = 100 * time.Millisecond
+	require.NoError(t, rlq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+	defer rlq.close(context.Background())
+
+	// Use up the bucket of the client with slow requests
+	for i := 0; i < 100; i++ {
+		resp, err := rlq.allow(context.Background(), &Request{ClientAddress: "127.0.0.1"})
+		require.NoError(t, err)
+		require.True(t, resp.Allowed)
+		resp.Access.(*rateLimitAccess).observe(time.Second, false)
+	}
+	resp, err := rlq.allow(context.Background(), &Request{ClientAddress: "127.0.0.1"})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+
+	require.Eventually(t, func() bool {
+		return rlq.EffectiveRate() < 100
+	}, 5*time.Second, 50*time.Millisecond)
+
+	// The client is still limited after the rate decrease
+	resp, err = rlq.allow(context.Background(), &Request{ClientAddress: "127.0.0.1"})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+	require.Equal(t, "0", resp.Headers[httplimit.HeaderRateLimitRemaining])
+}
+
+func TestRateLimitQuota_AdaptiveStore(t *testing.T) {
+	s := newAdaptiveStore(10, time.Minute, time.Minute, time.Minute)
+	defer s.Close(context.Background())
+	ctx := context.Background()
+
+	for i := 0; i < 8; i++ {
+		_, _, _, ok, err := s.Take(ctx, "a")
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	// Decreasing the tokens caps the remaining tokens of clients
+	s.setTokens(5)
+	tokens, remaining, err := s.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), tokens)
+	require.Equal(t, uint64(2), remaining)
+
+	// Increasing the tokens does not refill the buckets of clients
+	s.setTokens(20)
+	tokens, remaining, err = s.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), tokens)
+	require.Equal(t, uint64(2), remaining)
+
+	// New clients get the current number of tokens
+	tokens, remaining, _, ok, err := s.Take(ctx, "b")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(20), tokens)
+	require.Equal(t, uint64(19), remaining)
+
+	require.NoError(t, s.Close(ctx))
+	_, _, _, _, err = s.Take(ctx, "a")
+	require.ErrorIs(t, err, limiter.ErrStopped)
+}
+
+func TestRateLimitQuota_Exemptions(t *testing.T) {
+	rlq := NewRateLimitQuota("test-rate-limiter", "", "", "", "", true, time.Minute, 0, 1)
+	rlq.ExemptPolicies = []string{"automation"}
+	rlq.ExemptEntityMetadata = map[string]string{"team": "platform", "tier": "critical"}
+	require.NoError(t, rlq.initialize(logging.NewVaultLogger(log.Trace), metricsutil.BlackholeSink()))
+	defer rlq.close(context.Background())
+	require.True(t, rlq.requiresToken())
+
+	testCases := []struct {
+		name   string
+		req    *Request
+		exempt bool
+	}{
+		{"no token", &Request{}, false},
+		{"exempt policy", &Request{Policies: []string{"default", "automation"}}, true},
+		{"other policies", &Request{Policies: []string{"default"}}, false},
+		{"exempt metadata", &Request{EntityID: "entity1", EntityMetadata: map[string]string{"team": "platform", "tier": "critical", "env": "prod"}}, true},
+		{"partial metadata", &Request{EntityID: "entity1", EntityMetadata: map[string]string{"team": "platform"}}, false},
+		{"mismatched metadata", &Request{EntityID: "entity1", EntityMetadata: map[string]string{"team": "platform", "tier": "best-effort"}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.exempt, rlq.exempt(tc.req))
+		})
+	}
+
+	// Exempt requests are always allowed and don't consume the rate limit
+	for i := 0; i < 5; i++ {
+		resp, err := rlq.allow(context.Background(), &Request{ClientAddress: "127.0.0.1", Policies: []string{"automation"}})
+		require.NoError(t, err)
+		require.True(t, resp.Allowed)
+	}
+	resp, err := rlq.allow(context.Background(), &Request{ClientAddress: "127.0.0.1"})
+	require.NoError(t, err)
+	require.True(t, resp.Allowed)
+	resp, err = rlq.allow(context.Background(), &Request{ClientAddress: "127.0.0.1"})
+	require.NoError(t, err)
+	require.False(t, resp.Allowed)
+}
@@ -17,6 +17,7 @@ import (
 func quotaTypes() []string {
 	return []string{
 		TypeRateLimit.String(),
+		TypeConcurrency.String(),
 	}
 }
 
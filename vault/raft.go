@@ -802,6 +802,9 @@ func (c *Core) raftSnapshotRestoreCallback(grabLock bool, sealNode bool) func(co
 
 		// Purge the cache so we make sure we are operating on fresh data
 		c.physicalCache.Purge(ctx)
+		if c.storageLimits != nil {
+			c.storageLimits.resetEntries()
+		}
 
 		// Reload the keyring in case it changed. If this fails it's likely
 		// we've changed root keys.
@@ -0,0 +1,253 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	kv "github.com/hashicorp/vault-plugin-secrets-kv"
+	"github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/sdk/helper/keysutil"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/plugin/pb"
+)
+
+// ErrSnapshotPathNotFound is returned when the secret to restore does not
+// exist in the snapshot.
+var ErrSnapshotPathNotFound = errors.New("path not found in snapshot")
+
+// raftSnapshotStorage is a read-only view of the storage of a single mount in
+// a raft snapshot. Entries are decrypted with the live keyring, so the mount
+// must not have been remounted or recreated since the snapshot was taken.
+type raftSnapshotStorage struct {
+	barrier SecurityBarrier
+	snap    io.ReadSeeker
+	prefix  string
+}
+
+var _ logical.Storage = (*raftSnapshotStorage)(nil)
+
+// getAll returns the entries with the given keys, relative to the mount, in a
+// single pass over the snapshot. Keys missing from the snapshot are omitted.
+func (s *raftSnapshotStorage) getAll(ctx context.Context, keys []string) (map[string]*logical.StorageEntry, error) {
+	if _, err := s.snap.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]string, len(keys))
+	for _, key := range keys {
+		wanted[s.prefix+key] = key
+	}
+
+	entries := make(map[string]*logical.StorageEntry, len(keys))
+	reader := raft.NewDelimitedReader(bufio.NewReader(s.snap), math.MaxInt32)
+	for len(entries) < len(wanted) {
+		entry := new(pb.StorageEntry)
+		if err := reader.ReadMsg(entry); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("failed to read snapshot: %w", err)
+		}
+
+		key, ok := wanted[entry.Key]
+		if !ok {
+			continue
+		}
+		value, err := s.barrier.Decrypt(ctx, entry.Key, entry.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %q from snapshot: %w", key, err)
+		}
+		entries[key] = &logical.StorageEntry{
+			Key:   key,
+			Value: value,
+		}
+	}
+	return entries, nil
+}
+
+func (s *raftSnapshotStorage) Get(ctx context.Context, key string) (*logical.StorageEntry, error) {
+	entries, err := s.getAll(ctx, []string{key})
+	if err != nil {
+		return nil, err
+	}
+	return entries[key], nil
+}
+
+func (s *raftSnapshotStorage) List(context.Context, string) ([]string, error) {
+	return nil, errors.New("listing snapshot storage is not supported")
+}
+
+func (s *raftSnapshotStorage) Put(context.Context, *logical.StorageEntry) error {
+	return logical.ErrReadOnly
+}
+
+func (s *raftSnapshotStorage) Delete(context.Context, string) error {
+	return logical.ErrReadOnly
+}
+
+// restoreSnapshotPath restores the secret at the given path of a KV mount
+// from the raft snapshot data in snap, as written by WriteSnapshotToTemp. For
+// KV version 2 mounts, versions which are missing or destroyed in the live
+// secret are restored from the snapshot, leaving any other versions, including
+// ones written since the snapshot was taken, untouched. The restored version
+// numbers are returned. For KV version 1 mounts the secret is overwritten with
+// its value in the snapshot.
+func (c *Core) restoreSnapshotPath(ctx context.Context, snap io.ReadSeeker, entry *MountEntry, key string) ([]uint64, error) {
+	if entry.Type != mountTypeKV {
+		return nil, fmt.Errorf("mount %q is not a KV secrets engine", entry.Path)
+	}
+
+	live := c.router.MatchingStorageByAPIPath(ctx, entry.Path)
+	if live == nil {
+		return nil, fmt.Errorf("no storage found for mount %q", entry.Path)
+	}
+	snapStorage := &raftSnapshotStorage{
+		barrier: c.barrier,
+		snap:    snap,
+		prefix:  entry.ViewPath(),
+	}
+
+	if entry.Options["version"] != "2" {
+		secret, err := snapStorage.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if secret == nil {
+			return nil, ErrSnapshotPathNotFound
+		}
+		return nil, live.Put(ctx, secret)
+	}
+
+	storagePrefix := entry.BackendAwareUUID
+	policy, err := keysutil.LoadPolicy(ctx, snapStorage, path.Join(storagePrefix, "policy/metadata"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load metadata key from snapshot: %w", err)
+	}
+	if policy == nil {
+		return nil, ErrSnapshotPathNotFound
+	}
+	wrapper, err := keysutil.NewEncryptedKeyStorageWrapper(keysutil.EncryptedKeyStorageConfig{
+		Policy: policy,
+		Prefix: path.Join(storagePrefix, "metadata/"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snapMeta, err := readKVMetadata(ctx, wrapper.Wrap(snapStorage), key)
+	if err != nil {
+		return nil, err
+	}
+	if snapMeta == nil {
+		return nil, ErrSnapshotPathNotFound
+	}
+
+	liveMetaStorage := wrapper.Wrap(live)
+	liveMeta, err := readKVMetadata(ctx, liveMetaStorage, key)
+	if err != nil {
+		return nil, err
+	}
+	if liveMeta == nil {
+		liveMeta = proto.Clone(snapMeta).(*kv.KeyMetadata)
+		liveMeta.Versions = make(map[uint64]*kv.VersionMetadata)
+	}
+	if liveMeta.Versions == nil {
+		liveMeta.Versions = make(map[uint64]*kv.VersionMetadata)
+	}
+
+	var versions []uint64
+	for version, meta := range snapMeta.Versions {
+		if meta.Destroyed {
+			continue
+		}
+		if current, ok := liveMeta.Versions[version]; ok && !current.Destroyed {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	if len(versions) == 0 {
+		return nil, nil
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	s, err := salt.NewSalt(ctx, snapStorage, &salt.Config{
+		HashFunc: salt.SHA256Hash,
+		Location: path.Join(storagePrefix, salt.DefaultLocation),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load salt from snapshot: %w", err)
+	}
+	versionKeys := make(map[uint64]string, len(versions))
+	keys := make([]string, 0, len(versions))
+	for _, version := range versions {
+		salted := s.SaltID(fmt.Sprintf("%s|%d", key, version))
+		versionKeys[version] = path.Join(storagePrefix, "versions", salted[0:3], salted[3:])
+		keys = append(keys, versionKeys[version])
+	}
+	data, err := snapStorage.getAll(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var restored []uint64
+	for _, version := range versions {
+		versionData, ok := data[versionKeys[version]]
+		if !ok {
+			continue
+		}
+		if err := live.Put(ctx, versionData); err != nil {
+			return nil, fmt.Errorf("failed to restore version %d: %w", version, err)
+		}
+
+		liveMeta.Versions[version] = snapMeta.Versions[version]
+		if version > liveMeta.CurrentVersion {
+			liveMeta.CurrentVersion = version
+		}
+		if liveMeta.OldestVersion == 0 || version < liveMeta.OldestVersion {
+			liveMeta.OldestVersion = version
+		}
+		restored = append(restored, version)
+	}
+	if len(restored) == 0 {
+		return nil, nil
+	}
+
+	raw, err := proto.Marshal(liveMeta)
+	if err != nil {
+		return nil, err
+	}
+	if err := liveMetaStorage.Put(ctx, &logical.StorageEntry{
+		Key:   key,
+		Value: raw,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to restore metadata: %w", err)
+	}
+	return restored, nil
+}
+
+func readKVMetadata(ctx context.Context, s logical.Storage, key string) (*kv.KeyMetadata, error) {
+	entry, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	meta := new(kv.KeyMetadata)
+	if err := proto.Unmarshal(entry.Value, meta); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	return meta, nil
+}
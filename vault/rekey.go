@@ -11,11 +11,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	aeadwrapper "github.com/hashicorp/go-kms-wrapping/wrappers/aead/v2"
 
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/experiments"
+	"github.com/hashicorp/vault/helper/lockedbuffer"
 	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/helper/thresholdunseal"
+	"github.com/hashicorp/vault/helper/x509keys"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 	"github.com/hashicorp/vault/sdk/logical"
@@ -41,10 +46,12 @@ const (
 type RekeyResult struct {
 	SecretShares         [][]byte
 	PGPFingerprints      []string
+	X509Fingerprints     []string
 	Backup               bool
 	RecoveryKey          bool
 	VerificationRequired bool
 	VerificationNonce    string
+	Warnings             []string
 }
 
 type RekeyVerifyResult struct {
@@ -56,6 +63,10 @@ type RekeyVerifyResult struct {
 type RekeyBackup struct {
 	Nonce string
 	Keys  map[string][]string
+
+	// KeyExpirations holds when each of the PGP keys the backup is encrypted
+	// to expires, keyed by fingerprint. Keys that do not expire are omitted.
+	KeyExpirations map[string]time.Time `json:",omitempty"`
 }
 
 // RekeyThreshold returns the secret threshold for the current seal
@@ -122,7 +133,7 @@ func (c *Core) RekeyProgress(recovery, verification bool) (bool, int, logical.HT
 	}
 
 	if verification {
-		return len(conf.VerificationKey) > 0, len(conf.VerificationProgress), nil
+		return conf.VerificationKey.Len() > 0, len(conf.VerificationProgress), nil
 	}
 	return true, len(conf.RekeyProgress), nil
 }
@@ -192,9 +203,27 @@ func (c *Core) BarrierRekeyInit(config *SealConfig) logical.HTTPCodedError {
 		if len(config.PGPKeys) > 0 {
 			return logical.CodedError(http.StatusBadRequest, "PGP key encryption not supported when using stored keys")
 		}
+		if len(config.X509Certificates) > 0 {
+			return logical.CodedError(http.StatusBadRequest, "X.509 certificate encryption not supported when using stored keys")
+		}
 		if config.Backup {
 			return logical.CodedError(http.StatusBadRequest, "key backup not supported when using stored keys")
 		}
+		if config.DelegatedUnseal {
+			return logical.CodedError(http.StatusBadRequest, "delegated unseal not supported when using stored keys")
+		}
+	}
+
+	// The delegated unseal configuration is generated along with the new
+	// shares, never taken from the request.
+	config.DelegatedUnsealConfig = nil
+	if config.DelegatedUnseal {
+		if !c.IsExperimentEnabled(experiments.VaultExperimentCoreUnsealDelegated) {
+			return logical.CodedError(http.StatusBadRequest, fmt.Sprintf("delegated unseal requires the %q experiment", experiments.VaultExperimentCoreUnsealDelegated))
+		}
+		if config.VerificationRequired {
+			return logical.CodedError(http.StatusBadRequest, "requiring verification not supported with delegated unseal")
+		}
 	}
 
 	if c.seal.RecoveryKeySupported() {
@@ -209,6 +238,9 @@ func (c *Core) BarrierRekeyInit(config *SealConfig) logical.HTTPCodedError {
 		c.logger.Error("invalid rekey seal configuration", "error", err)
 		return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("invalid rekey seal configuration: %w", err).Error())
 	}
+	for _, warning := range config.pgpKeyExpirationWarnings(time.Now()) {
+		c.logger.Warn("rekey PGP key is expiring", "warning", warning)
+	}
 
 	c.stateLock.RLock()
 	defer c.stateLock.RUnlock()
@@ -239,7 +271,7 @@ func (c *Core) BarrierRekeyInit(config *SealConfig) logical.HTTPCodedError {
 	c.barrierRekeyConfig.Nonce = nonce
 
 	if c.logger.IsInfo() {
-		c.logger.Info("rekey initialized", "nonce", c.barrierRekeyConfig.Nonce, "shares", c.barrierRekeyConfig.SecretShares, "threshold", c.barrierRekeyConfig.SecretThreshold, "validation_required", c.barrierRekeyConfig.VerificationRequired)
+		c.logger.Info("rekey initialized", "nonce", c.barrierRekeyConfig.Nonce, "shares", c.barrierRekeyConfig.SecretShares, "threshold", c.barrierRekeyConfig.SecretThreshold, "validation_required", c.barrierRekeyConfig.VerificationRequired, "delegated_unseal", c.barrierRekeyConfig.DelegatedUnseal)
 	}
 	return nil
 }
@@ -249,12 +281,18 @@ func (c *Core) RecoveryRekeyInit(config *SealConfig) logical.HTTPCodedError {
 	if config.StoredShares > 0 {
 		return logical.CodedError(http.StatusBadRequest, "stored shares not supported by recovery key")
 	}
+	if config.DelegatedUnseal {
+		return logical.CodedError(http.StatusBadRequest, "delegated unseal not supported by recovery key")
+	}
 
 	// Check if the seal configuration is valid
 	if err := config.Validate(); err != nil {
 		c.logger.Error("invalid recovery configuration", "error", err)
 		return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("invalid recovery configuration: %w", err).Error())
 	}
+	for _, warning := range config.pgpKeyExpirationWarnings(time.Now()) {
+		c.logger.Warn("recovery rekey PGP key is expiring", "warning", warning)
+	}
 
 	if !c.seal.RecoveryKeySupported() {
 		return logical.CodedError(http.StatusBadRequest, "recovery keys not supported")
@@ -354,7 +392,7 @@ func (c *Core) BarrierRekeyUpdate(ctx context.Context, key []byte, nonce string)
 		return nil, logical.CodedError(http.StatusBadRequest, "no barrier rekey in progress")
 	}
 
-	if len(c.barrierRekeyConfig.VerificationKey) > 0 {
+	if c.barrierRekeyConfig.VerificationKey.Len() > 0 {
 		return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("rekey operation already finished; verification must be performed; nonce for the verification operation is %q", c.barrierRekeyConfig.VerificationNonce))
 	}
 
@@ -382,10 +420,19 @@ func (c *Core) BarrierRekeyUpdate(ctx context.Context, key []byte, nonce string)
 
 	// Recover the root key or recovery key
 	var recoveredKey []byte
-	if existingConfig.SecretThreshold == 1 {
+	switch {
+	case existingConfig.DelegatedUnsealConfig != nil:
+		// The shares of a delegated unseal setup are not Shamir shares of the
+		// unseal key, so recover it through the delegated unseal parameters.
+		recoveredKey, err = thresholdunseal.Combine(existingConfig.DelegatedUnsealConfig, c.barrierRekeyConfig.RekeyProgress)
+		c.barrierRekeyConfig.RekeyProgress = nil
+		if err != nil {
+			return nil, logical.CodedError(http.StatusBadRequest, fmt.Errorf("failed to compute unseal key: %w", err).Error())
+		}
+	case existingConfig.SecretThreshold == 1:
 		recoveredKey = c.barrierRekeyConfig.RekeyProgress[0]
 		c.barrierRekeyConfig.RekeyProgress = nil
-	} else {
+	default:
 		recoveredKey, err = shamir.Combine(c.barrierRekeyConfig.RekeyProgress)
 		c.barrierRekeyConfig.RekeyProgress = nil
 		if err != nil {
@@ -443,11 +490,22 @@ func (c *Core) BarrierRekeyUpdate(ctx context.Context, key []byte, nonce string)
 		Backup: c.barrierRekeyConfig.Backup,
 	}
 	if c.seal.StoredKeysSupported() != seal.StoredKeysSupportedGeneric {
-		// Set result.SecretShares to the new key itself if only a single key
-		// part is used -- no Shamir split required.
-		if c.barrierRekeyConfig.SecretShares == 1 {
+		switch {
+		case c.barrierRekeyConfig.DelegatedUnseal:
+			// Hand out signing shares, and keep the new key encrypted so that
+			// it can only be recovered from their signatures.
+			config, shares, err := thresholdunseal.Generate(newKey, c.barrierRekeyConfig.SecretShares, c.barrierRekeyConfig.SecretThreshold)
+			if err != nil {
+				c.logger.Error("failed to generate delegated unseal shares", "error", err)
+				return nil, logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to generate shares: %w", err).Error())
+			}
+			c.barrierRekeyConfig.DelegatedUnsealConfig = config
+			results.SecretShares = shares
+		case c.barrierRekeyConfig.SecretShares == 1:
+			// Set result.SecretShares to the new key itself if only a single key
+			// part is used -- no Shamir split required.
 			results.SecretShares = append(results.SecretShares, newKey)
-		} else {
+		default:
 			// Split the new key using the Shamir algorithm
 			shares, err := shamir.Split(newKey, c.barrierRekeyConfig.SecretShares, c.barrierRekeyConfig.SecretThreshold)
 			if err != nil {
@@ -469,6 +527,13 @@ func (c *Core) BarrierRekeyUpdate(ctx context.Context, key []byte, nonce string)
 			return nil, logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to encrypt shares: %w", err).Error())
 		}
 
+		now := time.Now()
+		expirations, err := c.barrierRekeyConfig.pgpKeyExpirations(now)
+		if err != nil {
+			return nil, logical.CodedError(http.StatusBadRequest, fmt.Errorf("invalid PGP key: %w", err).Error())
+		}
+		results.Warnings = pgpKeyExpirationWarnings(expirations, now)
+
 		// If backup is enabled, store backup info in vault.coreBarrierUnsealKeysBackupPath
 		if c.barrierRekeyConfig.Backup {
 			backupInfo := map[string][]string{}
@@ -482,8 +547,9 @@ func (c *Core) BarrierRekeyUpdate(ctx context.Context, key []byte, nonce string)
 			}
 
 			backupVals := &RekeyBackup{
-				Nonce: c.barrierRekeyConfig.Nonce,
-				Keys:  backupInfo,
+				Nonce:          c.barrierRekeyConfig.Nonce,
+				Keys:           backupInfo,
+				KeyExpirations: expirations,
 			}
 			buf, err := json.Marshal(backupVals)
 			if err != nil {
@@ -501,6 +567,18 @@ func (c *Core) BarrierRekeyUpdate(ctx context.Context, key []byte, nonce string)
 		}
 	}
 
+	// If X.509 certificates are passed in, encrypt shares to their public keys.
+	if len(c.barrierRekeyConfig.X509Certificates) > 0 {
+		hexEncodedShares := make([][]byte, len(results.SecretShares))
+		for i := range results.SecretShares {
+			hexEncodedShares[i] = []byte(hex.EncodeToString(results.SecretShares[i]))
+		}
+		results.X509Fingerprints, results.SecretShares, err = x509keys.EncryptShares(hexEncodedShares, c.barrierRekeyConfig.X509Certificates)
+		if err != nil {
+			return nil, logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to encrypt shares: %w", err).Error())
+		}
+	}
+
 	// If we are requiring validation, return now; otherwise rekey the barrier
 	if c.barrierRekeyConfig.VerificationRequired {
 		nonce, err := uuid.GenerateUUID()
@@ -509,7 +587,7 @@ func (c *Core) BarrierRekeyUpdate(ctx context.Context, key []byte, nonce string)
 			return nil, logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to generate verification nonce: %w", err).Error())
 		}
 		c.barrierRekeyConfig.VerificationNonce = nonce
-		c.barrierRekeyConfig.VerificationKey = newKey
+		c.barrierRekeyConfig.VerificationKey = lockedbuffer.New(newKey)
 
 		results.VerificationRequired = true
 		results.VerificationNonce = nonce
@@ -572,7 +650,7 @@ func (c *Core) performBarrierRekey(ctx context.Context, newSealKey []byte) logic
 		}
 	}
 
-	c.barrierRekeyConfig.VerificationKey = nil
+	c.barrierRekeyConfig.destroyVerificationKey()
 
 	if err := c.seal.SetBarrierConfig(ctx, c.barrierRekeyConfig); err != nil {
 		c.logger.Error("error saving rekey seal configuration", "error", err)
@@ -634,7 +712,7 @@ func (c *Core) RecoveryRekeyUpdate(ctx context.Context, key []byte, nonce string
 		return nil, logical.CodedError(http.StatusBadRequest, "no recovery rekey in progress")
 	}
 
-	if len(c.recoveryRekeyConfig.VerificationKey) > 0 {
+	if c.recoveryRekeyConfig.VerificationKey.Len() > 0 {
 		return nil, logical.CodedError(http.StatusBadRequest, fmt.Sprintf("rekey operation already finished; verification must be performed; nonce for the verification operation is %q", c.recoveryRekeyConfig.VerificationNonce))
 	}
 
@@ -713,6 +791,13 @@ func (c *Core) RecoveryRekeyUpdate(ctx context.Context, key []byte, nonce string
 			return nil, logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to encrypt shares: %w", err).Error())
 		}
 
+		now := time.Now()
+		expirations, err := c.recoveryRekeyConfig.pgpKeyExpirations(now)
+		if err != nil {
+			return nil, logical.CodedError(http.StatusBadRequest, fmt.Errorf("invalid PGP key: %w", err).Error())
+		}
+		results.Warnings = pgpKeyExpirationWarnings(expirations, now)
+
 		if c.recoveryRekeyConfig.Backup {
 			backupInfo := map[string][]string{}
 			for i := 0; i < len(results.PGPFingerprints); i++ {
@@ -725,8 +810,9 @@ func (c *Core) RecoveryRekeyUpdate(ctx context.Context, key []byte, nonce string
 			}
 
 			backupVals := &RekeyBackup{
-				Nonce: c.recoveryRekeyConfig.Nonce,
-				Keys:  backupInfo,
+				Nonce:          c.recoveryRekeyConfig.Nonce,
+				Keys:           backupInfo,
+				KeyExpirations: expirations,
 			}
 			buf, err := json.Marshal(backupVals)
 			if err != nil {
@@ -744,6 +830,18 @@ func (c *Core) RecoveryRekeyUpdate(ctx context.Context, key []byte, nonce string
 		}
 	}
 
+	// If X.509 certificates are passed in, encrypt shares to their public keys.
+	if len(c.recoveryRekeyConfig.X509Certificates) > 0 {
+		hexEncodedShares := make([][]byte, len(results.SecretShares))
+		for i := range results.SecretShares {
+			hexEncodedShares[i] = []byte(hex.EncodeToString(results.SecretShares[i]))
+		}
+		results.X509Fingerprints, results.SecretShares, err = x509keys.EncryptShares(hexEncodedShares, c.recoveryRekeyConfig.X509Certificates)
+		if err != nil {
+			return nil, logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to encrypt shares: %w", err).Error())
+		}
+	}
+
 	// If we are requiring validation, return now; otherwise save the recovery
 	// key
 	if c.recoveryRekeyConfig.VerificationRequired {
@@ -753,7 +851,7 @@ func (c *Core) RecoveryRekeyUpdate(ctx context.Context, key []byte, nonce string
 			return nil, logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to generate verification nonce: %w", err).Error())
 		}
 		c.recoveryRekeyConfig.VerificationNonce = nonce
-		c.recoveryRekeyConfig.VerificationKey = newRecoveryKey
+		c.recoveryRekeyConfig.VerificationKey = lockedbuffer.New(newRecoveryKey)
 
 		results.VerificationRequired = true
 		results.VerificationNonce = nonce
@@ -774,7 +872,7 @@ func (c *Core) performRecoveryRekey(ctx context.Context, newRootKey []byte) logi
 		return logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to set recovery key: %w", err).Error())
 	}
 
-	c.recoveryRekeyConfig.VerificationKey = nil
+	c.recoveryRekeyConfig.destroyVerificationKey()
 
 	if err := c.seal.SetRecoveryConfig(ctx, c.recoveryRekeyConfig); err != nil {
 		c.logger.Error("error saving rekey seal configuration", "error", err)
@@ -830,7 +928,7 @@ func (c *Core) RekeyVerify(ctx context.Context, key []byte, nonce string, recove
 		return nil, logical.CodedError(http.StatusBadRequest, "no rekey in progress")
 	}
 
-	if len(config.VerificationKey) == 0 {
+	if config.VerificationKey.Len() == 0 {
 		return nil, logical.CodedError(http.StatusBadRequest, "no rekey verification in progress")
 	}
 
@@ -884,7 +982,7 @@ func (c *Core) RekeyVerify(ctx context.Context, key []byte, nonce string, recove
 		}
 	}
 
-	if subtle.ConstantTimeCompare(recoveredKey, config.VerificationKey) != 1 {
+	if !config.VerificationKey.Equal(recoveredKey) {
 		c.logger.Error("rekey verification failed")
 		return nil, logical.CodedError(http.StatusBadRequest, "rekey verification failed; incorrect key shares supplied")
 	}
@@ -926,8 +1024,10 @@ func (c *Core) RekeyCancel(recovery bool) logical.HTTPCodedError {
 
 	// Clear any progress or config
 	if recovery {
+		c.recoveryRekeyConfig.destroyVerificationKey()
 		c.recoveryRekeyConfig = nil
 	} else {
+		c.barrierRekeyConfig.destroyVerificationKey()
 		c.barrierRekeyConfig = nil
 	}
 	return nil
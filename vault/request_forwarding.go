@@ -370,6 +370,15 @@ func (c *Core) ForwardRequest(req *http.Request) (int, http.Header, []byte, erro
 		c.logger.Error("got nil forwarding RPC request")
 		return 0, nil, nil, fmt.Errorf("got nil forwarding RPC request")
 	}
+
+	// Let the active node know which of our listeners received the request,
+	// never passing along a value set by the client
+	delete(freq.HeaderEntries, IntForwardedListenerHeaderName)
+	if listenerAddr, ok := logical.ContextListenerAddressValue(req.Context()); ok {
+		freq.HeaderEntries[IntForwardedListenerHeaderName] = &forwarding.HeaderEntry{
+			Values: []string{listenerAddr},
+		}
+	}
 	resp, err := c.rpcForwardingClient.ForwardRequest(req.Context(), freq)
 	if err != nil {
 		metrics.IncrCounter([]string{"ha", "rpc", "client", "forward", "errors"}, 1)
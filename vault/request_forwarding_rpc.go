@@ -15,6 +15,7 @@ import (
 	"github.com/hashicorp/vault/helper/forwarding"
 	"github.com/hashicorp/vault/physical/raft"
 	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/vault/replication"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -36,6 +37,10 @@ func (s *forwardedRequestRPCServer) ForwardRequest(ctx context.Context, freq *fo
 	if err != nil {
 		return nil, err
 	}
+	if listenerAddr := req.Header.Get(IntForwardedListenerHeaderName); listenerAddr != "" {
+		req.Header.Del(IntForwardedListenerHeaderName)
+		req = req.WithContext(logical.CreateContextListenerAddress(req.Context(), listenerAddr))
+	}
 
 	// A very dummy response writer that doesn't follow normal semantics, just
 	// lets you write a status code (last written wins) and a body. But it
@@ -32,6 +32,7 @@ import (
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/accesswindow"
 	"github.com/hashicorp/vault/sdk/helper/consts"
 	"github.com/hashicorp/vault/sdk/helper/errutil"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
@@ -272,6 +273,22 @@ func (c *Core) fetchACLTokenEntryAndEntity(ctx context.Context, req *logical.Req
 		}
 	}
 
+	// Access window checks bind all tokens except non-expiring root tokens
+	if te.TTL != 0 && len(te.AccessWindows) > 0 {
+		windows, err := accesswindow.ParseSet(te.AccessWindows, te.AccessWindowTimezone)
+		if err != nil {
+			c.logger.Error("failed to parse token access windows", "error", err)
+			return nil, nil, nil, nil, ErrInternalError
+		}
+		if !windows.Contains(time.Now()) {
+			timezone := te.AccessWindowTimezone
+			if timezone == "" {
+				timezone = "UTC"
+			}
+			return nil, nil, nil, nil, fmt.Errorf("%w: token can only be used during %s (%s)", logical.ErrPermissionDenied, strings.Join(te.AccessWindows, ", "), timezone)
+		}
+	}
+
 	policyNames := make(map[string][]string)
 	// Add tokens policies
 	policyNames[te.NamespaceID] = append(policyNames[te.NamespaceID], te.Policies...)
@@ -580,6 +597,9 @@ func (c *Core) switchedLockHandleRequest(httpCtx context.Context, req *logical.R
 	if disable_repl_status, ok := logical.ContextDisableReplicationStatusEndpointsValue(httpCtx); ok {
 		ctx = logical.CreateContextDisableReplicationStatusEndpoints(ctx, disable_repl_status)
 	}
+	if listenerAddr, ok := logical.ContextListenerAddressValue(httpCtx); ok {
+		ctx = logical.CreateContextListenerAddress(ctx, listenerAddr)
+	}
 	body, ok := logical.ContextOriginalBodyValue(httpCtx)
 	if ok {
 		ctx = logical.CreateContextOriginalBody(ctx, body)
@@ -1007,6 +1027,15 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 		c.UpdateInFlightReqData(inFlightReqID, req.ClientID)
 	}
 
+	// Reading the response of a wrapping token directly must obey the same
+	// constraints as unwrapping it, and a token that cannot be unwrapped yet
+	// must not be used up
+	if te != nil && req.Path == "cubbyhole/response" && IsWrappingToken(te) {
+		if err := c.checkWrappingConstraints(ctx, req, te); err != nil {
+			return logical.ErrorResponse(fmt.Sprintf("error validating wrapping token: %s", err.Error())), nil, logical.ErrPermissionDenied
+		}
+	}
+
 	// We run this logic first because we want to decrement the use count even
 	// in the case of an error (assuming we can successfully look up; if we
 	// need to forward, we exit before now)
@@ -1024,6 +1053,7 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 			retErr = multierror.Append(retErr, logical.ErrPermissionDenied)
 			return nil, nil, retErr
 		}
+		c.tokenStore.recordUsage(te)
 		if te.NumUses == tokenRevocationPending {
 			// We defer a revocation until after logic has run, since this is a
 			// valid request (this is the token's final use). We pass the ID in
@@ -1150,6 +1180,9 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 
 	// Route the request
 	resp, routeErr := c.doRouting(ctx, req)
+	if routeErr == nil && (resp == nil || !resp.IsError()) {
+		c.recordSecretWrite(entry, req)
+	}
 	if resp != nil {
 		// Add mount type information to the response
 		if entry != nil {
@@ -1160,6 +1193,8 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 		var wrapTTL time.Duration
 		var wrapFormat, creationPath string
 		var sealWrap bool
+		var boundCIDRs []string
+		var notBefore time.Time
 
 		// Ensure no wrap info information is set other than, possibly, the TTL
 		if resp.WrapInfo != nil {
@@ -1169,6 +1204,8 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 			wrapFormat = resp.WrapInfo.Format
 			creationPath = resp.WrapInfo.CreationPath
 			sealWrap = resp.WrapInfo.SealWrap
+			boundCIDRs = resp.WrapInfo.BoundCIDRs
+			notBefore = resp.WrapInfo.NotBefore
 			resp.WrapInfo = nil
 		}
 
@@ -1186,6 +1223,14 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 			if req.WrapInfo.Format != "" && wrapFormat == "" {
 				wrapFormat = req.WrapInfo.Format
 			}
+			// Bound CIDRs set by the response, e.g. when rewrapping, take
+			// precedence, and the later not-before time wins
+			if len(boundCIDRs) == 0 {
+				boundCIDRs = req.WrapInfo.BoundCIDRs
+			}
+			if req.WrapInfo.NotBefore.After(notBefore) {
+				notBefore = req.WrapInfo.NotBefore
+			}
 		}
 
 		if wrapTTL > 0 {
@@ -1194,6 +1239,8 @@ func (c *Core) handleRequest(ctx context.Context, req *logical.Request) (retResp
 				Format:       wrapFormat,
 				CreationPath: creationPath,
 				SealWrap:     sealWrap,
+				BoundCIDRs:   boundCIDRs,
+				NotBefore:    notBefore,
 			}
 		}
 	}
@@ -1530,6 +1577,8 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 		var wrapTTL time.Duration
 		var wrapFormat, creationPath string
 		var sealWrap bool
+		var boundCIDRs []string
+		var notBefore time.Time
 
 		// Ensure no wrap info information is set other than, possibly, the TTL
 		if resp.WrapInfo != nil {
@@ -1539,6 +1588,8 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 			wrapFormat = resp.WrapInfo.Format
 			creationPath = resp.WrapInfo.CreationPath
 			sealWrap = resp.WrapInfo.SealWrap
+			boundCIDRs = resp.WrapInfo.BoundCIDRs
+			notBefore = resp.WrapInfo.NotBefore
 			resp.WrapInfo = nil
 		}
 
@@ -1554,6 +1605,14 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 			if req.WrapInfo.Format != "" && wrapFormat == "" {
 				wrapFormat = req.WrapInfo.Format
 			}
+			// Bound CIDRs set by the response, e.g. when rewrapping, take
+			// precedence, and the later not-before time wins
+			if len(boundCIDRs) == 0 {
+				boundCIDRs = req.WrapInfo.BoundCIDRs
+			}
+			if req.WrapInfo.NotBefore.After(notBefore) {
+				notBefore = req.WrapInfo.NotBefore
+			}
 		}
 
 		if wrapTTL > 0 {
@@ -1562,6 +1621,8 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 				Format:       wrapFormat,
 				CreationPath: creationPath,
 				SealWrap:     sealWrap,
+				BoundCIDRs:   boundCIDRs,
+				NotBefore:    notBefore,
 			}
 		}
 	}
@@ -1708,10 +1769,18 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 		}
 		// finding the MFAEnforcementConfig that matches the ns and either of
 		// entityID, MountAccessor, GroupID, or Auth type.
-		matchedMfaEnforcementList, err := c.buildMFAEnforcementConfigList(ctx, entity, req.Path)
+		var remoteAddr string
+		if req.Connection != nil {
+			remoteAddr = req.Connection.RemoteAddr
+		}
+		listenerAddr, _ := logical.ContextListenerAddressValue(ctx)
+		matchedMfaEnforcementList, mfaEnforcementResults, err := c.buildMFAEnforcementConfigList(ctx, entity, req.Path, remoteAddr, listenerAddr)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to find MFAEnforcement configuration, error: %v", err)
 		}
+		// Record which enforcements applied to the login, and why, so that
+		// the decision ends up in the audit log
+		resp.Auth.MFAEnforcementResults = mfaEnforcementResults
 
 		// (for the context, a response warning above says: "primary cluster
 		// doesn't yet issue entities for local auth mounts; falling back
@@ -1766,13 +1835,14 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 				// response. This flag is indicate to store the auth response for later
 				// and return MFARequirement only
 				respAuth := &MFACachedAuthResponse{
-					CachedAuth:            resp.Auth,
-					RequestPath:           req.Path,
-					RequestNSID:           ns.ID,
-					RequestNSPath:         ns.Path,
-					RequestConnRemoteAddr: req.Connection.RemoteAddr, // this is needed for the DUO method
-					TimeOfStorage:         time.Now(),
-					RequestID:             mfaRequestID,
+					CachedAuth:             resp.Auth,
+					RequestPath:            req.Path,
+					RequestNSID:            ns.ID,
+					RequestNSPath:          ns.Path,
+					RequestConnRemoteAddr:  req.Connection.RemoteAddr, // this is needed for the DUO method
+					RequestListenerAddress: listenerAddr,
+					TimeOfStorage:          time.Now(),
+					RequestID:              mfaRequestID,
 				}
 				err = possiblyForwardSaveCachedAuthResponse(ctx, c, respAuth)
 				if err != nil {
@@ -1780,7 +1850,8 @@ func (c *Core) handleLoginRequest(ctx context.Context, req *logical.Request) (re
 				}
 				auth = nil
 				resp.Auth = &logical.Auth{
-					MFARequirement: mfaRequirement,
+					MFARequirement:        mfaRequirement,
+					MFAEnforcementResults: mfaEnforcementResults,
 				}
 				resp.AddWarning("A login request was issued that is subject to MFA validation. Please make sure to validate the login by sending another request to mfa/validate endpoint.")
 				// going to return early before generating the token
@@ -2367,6 +2438,9 @@ func (c *Core) RegisterAuth(ctx context.Context, tokenTTL time.Duration, path st
 		ExplicitMaxTTL: auth.ExplicitMaxTTL,
 		Period:         auth.Period,
 		Type:           auth.TokenType,
+
+		AccessWindows:        auth.AccessWindows,
+		AccessWindowTimezone: auth.AccessWindowTimezone,
 	}
 
 	if te.TTL == 0 && (len(te.Policies) != 1 || te.Policies[0] != "root") {
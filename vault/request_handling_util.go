@@ -7,6 +7,7 @@ package vault
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -18,12 +19,32 @@ func waitForReplicationState(context.Context, *Core, *logical.Request) (*sync.Wa
 	return nil, nil
 }
 
-func checkNeedsCG(context.Context, *Core, *logical.Request, *logical.Auth, error, []string) (error, *logical.Response, *logical.Auth, error) {
-	return nil, nil, nil, nil
+func checkNeedsCG(ctx context.Context, c *Core, req *logical.Request, auth *logical.Auth, err error, nonHMACReqDataKeys []string) (error, *logical.Response, *logical.Auth, error) {
+	var cgErr *ErrControlGroupRequired
+	if !errors.As(err, &cgErr) {
+		return nil, nil, nil, nil
+	}
+
+	logInput := &logical.LogInput{
+		Auth:               auth,
+		Request:            req,
+		NonHMACReqDataKeys: nonHMACReqDataKeys,
+	}
+	if err := c.auditBroker.LogRequest(ctx, logInput); err != nil {
+		c.logger.Error("failed to audit request", "path", req.Path, "error", err)
+		return ErrInternalError, nil, nil, nil
+	}
+
+	resp, err := c.parkControlGroupRequest(ctx, req, auth, cgErr.ControlGroup)
+	if resp == nil && err != nil {
+		return err, nil, nil, nil
+	}
+	return nil, resp, auth, err
 }
 
 func checkErrControlGroupTokenNeedsCreated(err error) bool {
-	return false
+	var cgErr *ErrControlGroupRequired
+	return errors.As(err, &cgErr)
 }
 
 func shouldForward(c *Core, resp *logical.Response, err error) bool {
@@ -0,0 +1,46 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"github.com/armon/go-radix"
+)
+
+// RequestLimit overrides the maximum request size of the listeners, and limits
+// the number of request parameters, under an API path prefix. A zero value
+// keeps the listener maximum request size, or does not limit the number of
+// parameters.
+type RequestLimit struct {
+	PathPrefix           string
+	MaxRequestSize       int64
+	MaxRequestParameters int
+}
+
+// newRequestLimitTree indexes request limits by path prefix. It returns nil if
+// no request limits are configured.
+func newRequestLimitTree(limits []*RequestLimit) *radix.Tree {
+	if len(limits) == 0 {
+		return nil
+	}
+
+	tree := radix.New()
+	for _, l := range limits {
+		tree.Insert(l.PathPrefix, l)
+	}
+	return tree
+}
+
+// RequestLimit returns the request limit with the longest path prefix matching
+// the given API path, including its namespace path, or nil if there is none.
+func (c *Core) RequestLimit(path string) *RequestLimit {
+	if c.requestLimits == nil {
+		return nil
+	}
+
+	_, raw, ok := c.requestLimits.LongestPrefix(path)
+	if !ok {
+		return nil
+	}
+	return raw.(*RequestLimit)
+}
@@ -0,0 +1,148 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// coreRotationCalendarsPath is the storage prefix of the rotation calendars
+// managed by operators.
+const coreRotationCalendarsPath = "core/rotation/calendars/"
+
+// rotationCalendarDateLayout is the layout of the freeze dates of a rotation
+// calendar.
+const rotationCalendarDateLayout = "2006-01-02"
+
+// RotationCalendar is a named set of freeze periods, e.g. public holidays or
+// change freezes, shared by all scheduled rotations referencing it. Rotations
+// falling in a freeze period are postponed until the period ends.
+type RotationCalendar struct {
+	Name string `json:"name"`
+
+	// Timezone is the IANA time zone the freeze dates are in, UTC if empty.
+	Timezone string `json:"timezone,omitempty"`
+
+	// FreezeDates holds single dates, e.g. "2026-12-25", and inclusive
+	// ranges of dates, e.g. "2026-12-24/2027-01-01".
+	FreezeDates []string `json:"freeze_dates"`
+}
+
+// rotationFreezePeriod is a freeze period of a rotation calendar, starting at
+// start and ending before end.
+type rotationFreezePeriod struct {
+	start, end time.Time
+}
+
+// periods parses the freeze dates of the calendar.
+func (c *RotationCalendar) periods() ([]rotationFreezePeriod, error) {
+	loc := time.UTC
+	if c.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(c.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", c.Timezone, err)
+		}
+	}
+
+	periods := make([]rotationFreezePeriod, 0, len(c.FreezeDates))
+	for _, date := range c.FreezeDates {
+		first, last, isRange := strings.Cut(date, "/")
+		if !isRange {
+			last = first
+		}
+
+		start, err := time.ParseInLocation(rotationCalendarDateLayout, strings.TrimSpace(first), loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid freeze date %q: expected YYYY-MM-DD or YYYY-MM-DD/YYYY-MM-DD", date)
+		}
+		end, err := time.ParseInLocation(rotationCalendarDateLayout, strings.TrimSpace(last), loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid freeze date %q: expected YYYY-MM-DD or YYYY-MM-DD/YYYY-MM-DD", date)
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("invalid freeze date %q: range ends before it starts", date)
+		}
+
+		periods = append(periods, rotationFreezePeriod{start: start, end: end.AddDate(0, 0, 1)})
+	}
+	return periods, nil
+}
+
+// frozenUntil returns when the freeze period containing t ends, following
+// any freeze periods adjoining or overlapping it, or the zero time if t is not
+// in a freeze period.
+func (c *RotationCalendar) frozenUntil(t time.Time) (time.Time, error) {
+	periods, err := c.periods()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	until := t
+	for extended := true; extended; {
+		extended = false
+		for _, p := range periods {
+			if !until.Before(p.start) && until.Before(p.end) {
+				until = p.end
+				extended = true
+			}
+		}
+	}
+
+	if until.Equal(t) {
+		return time.Time{}, nil
+	}
+	return until, nil
+}
+
+// rotationCalendar returns the rotation calendar with the given name, or nil
+// if it does not exist.
+func (c *Core) rotationCalendar(ctx context.Context, name string) (*RotationCalendar, error) {
+	raw, err := c.barrier.Get(ctx, coreRotationCalendarsPath+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rotation calendar: %w", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	calendar := new(RotationCalendar)
+	if err := jsonutil.DecodeJSON(raw.Value, calendar); err != nil {
+		return nil, fmt.Errorf("failed to decode rotation calendar: %w", err)
+	}
+	return calendar, nil
+}
+
+func (c *Core) persistRotationCalendar(ctx context.Context, calendar *RotationCalendar) error {
+	encoded, err := jsonutil.EncodeJSON(calendar)
+	if err != nil {
+		return fmt.Errorf("failed to encode rotation calendar: %w", err)
+	}
+
+	return c.barrier.Put(ctx, &logical.StorageEntry{
+		Key:   coreRotationCalendarsPath + calendar.Name,
+		Value: encoded,
+	})
+}
+
+// rotationFreeze returns when the freeze period of the named rotation
+// calendar containing t ends, or the zero time if t is not in a freeze period.
+// It is an error for the calendar not to exist, so that rotations are not
+// silently unfrozen by a mistyped or deleted calendar.
+func (c *Core) rotationFreeze(ctx context.Context, name string, t time.Time) (time.Time, error) {
+	calendar, err := c.rotationCalendar(ctx, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if calendar == nil {
+		return time.Time{}, fmt.Errorf("rotation calendar %q not found", name)
+	}
+
+	return calendar.frozenUntil(t)
+}
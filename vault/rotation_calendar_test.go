@@ -0,0 +1,235 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/rotation"
+)
+
+func TestRotationCalendar_FrozenUntil(t *testing.T) {
+	calendar := &RotationCalendar{
+		FreezeDates: []string{"2026-12-24/2026-12-26", "2026-12-27", "2027-01-01"},
+	}
+	for name, tc := range map[string]struct {
+		t    time.Time
+		want time.Time
+	}{
+		"before":       {t: time.Date(2026, 12, 23, 23, 59, 0, 0, time.UTC)},
+		"start":        {t: time.Date(2026, 12, 24, 0, 0, 0, 0, time.UTC), want: time.Date(2026, 12, 28, 0, 0, 0, 0, time.UTC)},
+		"adjoining":    {t: time.Date(2026, 12, 26, 18, 0, 0, 0, time.UTC), want: time.Date(2026, 12, 28, 0, 0, 0, 0, time.UTC)},
+		"between":      {t: time.Date(2026, 12, 30, 12, 0, 0, 0, time.UTC)},
+		"single date":  {t: time.Date(2027, 1, 1, 12, 0, 0, 0, time.UTC), want: time.Date(2027, 1, 2, 0, 0, 0, 0, time.UTC)},
+		"end is after": {t: time.Date(2027, 1, 2, 0, 0, 0, 0, time.UTC)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := calendar.frozenUntil(tc.t)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tc.want) {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+
+	// Freeze dates are whole days in the calendar's time zone
+	calendar = &RotationCalendar{Timezone: "America/New_York", FreezeDates: []string{"2026-07-04"}}
+	got, err := calendar.frozenUntil(time.Date(2026, 7, 5, 2, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 7, 5, 4, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestRotationCalendar_Invalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		calendar   *RotationCalendar
+		wantErrStr string
+	}{
+		"bad date":     {calendar: &RotationCalendar{FreezeDates: []string{"12/25/2026"}}, wantErrStr: "invalid freeze date"},
+		"bad range":    {calendar: &RotationCalendar{FreezeDates: []string{"2026-12-24/"}}, wantErrStr: "invalid freeze date"},
+		"reversed":     {calendar: &RotationCalendar{FreezeDates: []string{"2026-12-26/2026-12-24"}}, wantErrStr: "ends before it starts"},
+		"bad timezone": {calendar: &RotationCalendar{Timezone: "Mars/Olympus_Mons"}, wantErrStr: "invalid timezone"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := tc.calendar.periods()
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrStr) {
+				t.Fatalf("expected error containing %q, got: %v", tc.wantErrStr, err)
+			}
+		})
+	}
+}
+
+func TestCore_RotationManager_Calendar(t *testing.T) {
+	var rotated int
+	c, _, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"rotator": func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+				b := &framework.Backend{
+					BackendType: logical.TypeLogical,
+					RotateCredential: func(context.Context, *logical.Request) error {
+						rotated++
+						return nil
+					},
+				}
+				return b, b.Setup(ctx, conf)
+			},
+		},
+	})
+	ctx := namespace.RootContext(nil)
+
+	me := &MountEntry{Table: mountTableType, Path: "rotator/", Type: "rotator"}
+	if err := c.mount(ctx, me); err != nil {
+		t.Fatal(err)
+	}
+
+	req := &rotation.RotationJobConfigureRequest{
+		ReqPath:          "config/root",
+		RotationSchedule: "0 0 * * *",
+		RotationWindow:   36 * time.Hour,
+		RotationCalendar: "holidays",
+	}
+	_, err := c.registerRotationJob(ctx, me, req)
+	if err == nil || !strings.Contains(err.Error(), `rotation calendar "holidays" not found`) {
+		t.Fatalf("expected missing calendar error, got: %v", err)
+	}
+
+	if err := c.persistRotationCalendar(ctx, &RotationCalendar{Name: "holidays"}); err != nil {
+		t.Fatal(err)
+	}
+	id, err := c.registerRotationJob(ctx, me, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := c.rotationJob(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduled := job.NextRotation
+
+	// Freeze the day of the scheduled rotation
+	calendarReq := logical.TestRequest(t, logical.UpdateOperation, "sys/rotation/calendars/holidays")
+	calendarReq.ClientToken = root
+	calendarReq.Data["freeze_dates"] = scheduled.Format(rotationCalendarDateLayout)
+	calendarReq.Data["timezone"] = time.Local.String()
+	resp, err := c.HandleRequest(ctx, calendarReq)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	// The rotation is postponed during the freeze, without being recorded
+	if err := c.runRotations(ctx, scheduled.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if rotated != 0 {
+		t.Fatalf("expected rotation to be postponed, got %d rotations", rotated)
+	}
+	job, err = c.rotationJob(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(job.History) != 0 || !job.NextRotation.Equal(scheduled) {
+		t.Fatalf("bad job: %#v", job)
+	}
+
+	// and happens once the freeze ends, within the rotation window
+	if err := c.runRotations(ctx, scheduled.Add(25*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if rotated != 1 {
+		t.Fatalf("expected rotation after the freeze, got %d rotations", rotated)
+	}
+
+	// Rotations fail while their calendar is missing
+	calendarReq = logical.TestRequest(t, logical.DeleteOperation, "sys/rotation/calendars/holidays")
+	calendarReq.ClientToken = root
+	resp, err = c.HandleRequest(ctx, calendarReq)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	job, err = c.rotationJob(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.runRotations(ctx, job.NextRotation); err != nil {
+		t.Fatal(err)
+	}
+	job, err = c.rotationJob(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated != 1 || len(job.History) != 2 || !strings.Contains(job.History[1].Error, "not found") {
+		t.Fatalf("bad history: %#v", job.History)
+	}
+}
+
+func TestSystemBackend_RotationCalendars(t *testing.T) {
+	c, b, _ := testCoreSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "rotation/calendars/holidays")
+	req.Data["freeze_dates"] = "2026-12-24/2026-12-26,2027-01-01"
+	req.Data["timezone"] = "Europe/Berlin"
+	resp, err := b.HandleRequest(ctx, req)
+	if err != nil || resp != nil {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "rotation/calendars/holidays")
+	resp, err = b.HandleRequest(ctx, req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	expected := map[string]interface{}{
+		"name":         "holidays",
+		"timezone":     "Europe/Berlin",
+		"freeze_dates": []string{"2026-12-24/2026-12-26", "2027-01-01"},
+	}
+	if !reflect.DeepEqual(resp.Data, expected) {
+		t.Fatalf("expected: %#v, got: %#v", expected, resp.Data)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "rotation/calendars/bad")
+	req.Data["freeze_dates"] = "2026-13-01"
+	resp, err = b.HandleRequest(ctx, req)
+	if err != logical.ErrInvalidRequest || !strings.Contains(resp.Error().Error(), "invalid freeze date") {
+		t.Fatalf("expected invalid freeze date, got err:%v resp:%#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.ListOperation, "rotation/calendars")
+	resp, err = b.HandleRequest(ctx, req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if keys := resp.Data["keys"].([]string); !reflect.DeepEqual(keys, []string{"holidays"}) {
+		t.Fatalf("bad keys: %#v", keys)
+	}
+
+	frozenUntil, err := c.rotationFreeze(ctx, "holidays", time.Date(2026, 12, 25, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 12, 26, 23, 0, 0, 0, time.UTC); !frozenUntil.Equal(want) {
+		t.Fatalf("expected freeze until %s, got %s", want, frozenUntil)
+	}
+
+	req = logical.TestRequest(t, logical.DeleteOperation, "rotation/calendars/holidays")
+	if resp, err := b.HandleRequest(ctx, req); err != nil || resp != nil {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if _, err := c.rotationFreeze(ctx, "holidays", time.Now()); err == nil {
+		t.Fatal("expected error for deleted calendar")
+	}
+}
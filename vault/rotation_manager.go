@@ -0,0 +1,388 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/rotation"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// coreRotationJobsPath is the storage prefix of root credentials
+	// registered for scheduled rotation.
+	coreRotationJobsPath = "core/rotation/jobs/"
+
+	// rotationHistoryLimit is the number of rotation attempts kept per job.
+	rotationHistoryLimit = 10
+
+	rotationScheduleParseOptions = cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow
+)
+
+// rotationCheckInterval is how often registered root credentials are checked
+// for whether they are due for rotation.
+var rotationCheckInterval = 10 * time.Second
+
+// RotationJob is a root credential a plugin registered for scheduled
+// rotation.
+type RotationJob struct {
+	ID            string        `json:"id"`
+	Name          string        `json:"name"`
+	MountAccessor string        `json:"mount_accessor"`
+	ReqPath       string        `json:"req_path"`
+	Schedule      string        `json:"rotation_schedule,omitempty"`
+	Window        time.Duration `json:"rotation_window,omitempty"`
+	Period        time.Duration `json:"rotation_period,omitempty"`
+	Jitter        time.Duration `json:"rotation_jitter,omitempty"`
+	Calendar      string        `json:"rotation_calendar,omitempty"`
+
+	NextRotation time.Time `json:"next_rotation"`
+	LastRotation time.Time `json:"last_rotation,omitempty"`
+
+	// History holds the most recent rotation attempts, oldest first.
+	History []*RotationAttempt `json:"history,omitempty"`
+}
+
+// RotationAttempt records a single scheduled rotation of a root credential.
+type RotationAttempt struct {
+	Time    time.Time `json:"time"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+
+	// Missed is set if the rotation window had passed by the time the
+	// rotation could be attempted.
+	Missed bool `json:"missed,omitempty"`
+}
+
+// next returns the first time after from the job is scheduled for rotation,
+// delayed by a random amount of up to the job's jitter.
+func (j *RotationJob) next(from time.Time) (time.Time, error) {
+	next, err := nextScheduled(j.Schedule, j.Period, from)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid rotation schedule: %w", err)
+	}
+	if j.Jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(j.Jitter))))
+	}
+	return next, nil
+}
+
+// inBacklog returns whether the job's root credential is overdue for rotation
+// at now, e.g. during a freeze period, or its last rotation did not succeed.
+func (j *RotationJob) inBacklog(now time.Time) bool {
+	if !now.Before(j.NextRotation) {
+		return true
+	}
+	return len(j.History) > 0 && !j.History[len(j.History)-1].Success
+}
+
+// nextScheduled returns the first time after from of the cron-style schedule,
+// or from plus period if schedule is empty.
+func nextScheduled(schedule string, period time.Duration, from time.Time) (time.Time, error) {
+	if schedule == "" {
+		return from.Add(period), nil
+	}
+
+	parsed, err := cron.NewParser(rotationScheduleParseOptions).Parse(schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parsed.Next(from), nil
+}
+
+// rotationJobID returns the ID of the job of the root credential at path of
+// the mount.
+func rotationJobID(accessor, path string) string {
+	hash := sha256.Sum256([]byte(path))
+	return accessor + "-" + hex.EncodeToString(hash[:8])
+}
+
+// registerRotationJob registers a root credential of the mount for scheduled
+// rotation, and returns the ID of its job. Registering a credential again
+// keeps its history, and its next rotation if its schedule is unchanged.
+func (c *Core) registerRotationJob(ctx context.Context, entry *MountEntry, req *rotation.RotationJobConfigureRequest) (string, error) {
+	if entry == nil {
+		return "", errors.New("rotation jobs can only be registered by mounts")
+	}
+	if err := req.Validate(); err != nil {
+		return "", err
+	}
+
+	job := &RotationJob{
+		ID:            rotationJobID(entry.Accessor, req.ReqPath),
+		Name:          req.Name,
+		MountAccessor: entry.Accessor,
+		ReqPath:       req.ReqPath,
+		Schedule:      req.RotationSchedule,
+		Window:        req.RotationWindow,
+		Period:        req.RotationPeriod,
+		Jitter:        req.RotationJitter,
+		Calendar:      req.RotationCalendar,
+	}
+	if job.Calendar != "" {
+		calendar, err := c.rotationCalendar(ctx, job.Calendar)
+		if err != nil {
+			return "", err
+		}
+		if calendar == nil {
+			return "", fmt.Errorf("rotation calendar %q not found", job.Calendar)
+		}
+	}
+
+	next, err := job.next(time.Now())
+	if err != nil {
+		return "", err
+	}
+	job.NextRotation = next
+
+	c.rotationJobsLock.Lock()
+	defer c.rotationJobsLock.Unlock()
+
+	existing, err := c.rotationJob(ctx, job.ID)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		job.LastRotation = existing.LastRotation
+		job.History = existing.History
+		if existing.Schedule == job.Schedule && existing.Period == job.Period && existing.Jitter == job.Jitter {
+			job.NextRotation = existing.NextRotation
+		}
+	}
+
+	if err := c.persistRotationJob(ctx, job); err != nil {
+		return "", err
+	}
+
+	c.logger.Debug("registered rotation job", "id", job.ID, "name", job.Name, "path", entry.APIPath()+job.ReqPath, "next_rotation", job.NextRotation)
+	return job.ID, nil
+}
+
+// deregisterRotationJob removes a root credential of the mount from scheduled
+// rotation.
+func (c *Core) deregisterRotationJob(ctx context.Context, entry *MountEntry, req *rotation.RotationJobDeregisterRequest) error {
+	if entry == nil {
+		return errors.New("rotation jobs can only be deregistered by mounts")
+	}
+
+	c.rotationJobsLock.Lock()
+	defer c.rotationJobsLock.Unlock()
+
+	return c.barrier.Delete(ctx, coreRotationJobsPath+rotationJobID(entry.Accessor, req.ReqPath))
+}
+
+// rotationJob returns the job with the given ID, or nil if it does not exist.
+func (c *Core) rotationJob(ctx context.Context, id string) (*RotationJob, error) {
+	raw, err := c.barrier.Get(ctx, coreRotationJobsPath+id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rotation job: %w", err)
+	}
+	if raw == nil {
+		return nil, nil
+	}
+
+	job := new(RotationJob)
+	if err := jsonutil.DecodeJSON(raw.Value, job); err != nil {
+		return nil, fmt.Errorf("failed to decode rotation job: %w", err)
+	}
+	return job, nil
+}
+
+func (c *Core) persistRotationJob(ctx context.Context, job *RotationJob) error {
+	encoded, err := jsonutil.EncodeJSON(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode rotation job: %w", err)
+	}
+
+	return c.barrier.Put(ctx, &logical.StorageEntry{
+		Key:   coreRotationJobsPath + job.ID,
+		Value: encoded,
+	})
+}
+
+// startRotationManager runs a process which, every rotationCheckInterval,
+// rotates the root credentials which are due, until the active context is
+// done.
+func (c *Core) startRotationManager() {
+	if c.rotationManagerCancel != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, c.rotationManagerCancel = context.WithCancel(namespace.RootContext(c.activeContext))
+
+	go func() {
+		ticker := time.NewTicker(rotationCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.runRotations(ctx, time.Now()); err != nil {
+					c.logger.Error("failed to rotate root credentials", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// runRotations rotates all root credentials whose next rotation is due at now.
+func (c *Core) runRotations(ctx context.Context, now time.Time) error {
+	ids, err := c.barrier.List(ctx, coreRotationJobsPath)
+	if err != nil {
+		return fmt.Errorf("failed to list rotation jobs: %w", err)
+	}
+
+	var retErr error
+	var backlog int64
+	for _, id := range ids {
+		if err := c.runRotationJob(ctx, id, now); err != nil {
+			retErr = multierror.Append(retErr, err)
+		}
+
+		c.rotationJobsLock.Lock()
+		job, err := c.rotationJob(ctx, id)
+		c.rotationJobsLock.Unlock()
+		if err != nil {
+			retErr = multierror.Append(retErr, err)
+			continue
+		}
+		if job != nil && job.inBacklog(now) {
+			backlog++
+		}
+	}
+	c.rotationBacklog.Store(backlog)
+
+	return retErr
+}
+
+// RotationBacklog returns the number of root credentials which are overdue
+// for rotation or whose last rotation failed, as of the last run of the
+// rotation manager. It is zero on nodes which are not active.
+func (c *Core) RotationBacklog() int64 {
+	return c.rotationBacklog.Load()
+}
+
+// runRotationJob rotates the root credential of the job if it is due at now,
+// and schedules its next rotation.
+func (c *Core) runRotationJob(ctx context.Context, id string, now time.Time) error {
+	c.rotationJobsLock.Lock()
+	job, err := c.rotationJob(ctx, id)
+	c.rotationJobsLock.Unlock()
+	if err != nil || job == nil || now.Before(job.NextRotation) {
+		return err
+	}
+
+	entry := c.router.MatchingMountByAccessor(job.MountAccessor)
+	if entry == nil {
+		// The mount has been disabled
+		c.rotationJobsLock.Lock()
+		defer c.rotationJobsLock.Unlock()
+		return c.barrier.Delete(ctx, coreRotationJobsPath+id)
+	}
+
+	// The replicated mount's credentials are only rotated on the primary
+	if !entry.Local && c.ReplicationState().HasState(consts.ReplicationPerformanceSecondary) {
+		return nil
+	}
+
+	logger := c.logger.With("id", job.ID, "name", job.Name, "path", entry.APIPath()+job.ReqPath)
+	labels := []metrics.Label{
+		metricsutil.NamespaceLabel(entry.Namespace()),
+		{Name: "mount_point", Value: entry.APIPathNoNamespace()},
+	}
+
+	// A rotation falling in a freeze period of the job's calendar is retried
+	// until the period ends, unless its rotation window passes first
+	var frozenUntil time.Time
+	var calendarErr error
+	if job.Calendar != "" {
+		frozenUntil, calendarErr = c.rotationFreeze(ctx, job.Calendar, now)
+	}
+
+	attempt := &RotationAttempt{Time: now}
+	switch {
+	case job.Window > 0 && now.After(job.NextRotation.Add(job.Window)):
+		attempt.Missed = true
+		logger.Warn("rotation window passed before the root credential could be rotated", "scheduled", job.NextRotation)
+		metrics.IncrCounterWithLabels([]string{"rotation", "missed"}, 1, labels)
+	case calendarErr != nil:
+		attempt.Error = calendarErr.Error()
+		logger.Error("failed to check rotation calendar", "calendar", job.Calendar, "error", calendarErr)
+		metrics.IncrCounterWithLabels([]string{"rotation", "failure"}, 1, labels)
+	case !frozenUntil.IsZero():
+		logger.Trace("postponing rotation during freeze period", "calendar", job.Calendar, "until", frozenUntil)
+		return nil
+	default:
+		err := c.rotateCredential(ctx, entry, job.ReqPath)
+		metrics.MeasureSinceWithLabels([]string{"rotation", "attempt"}, now, labels)
+		if err != nil {
+			attempt.Error = err.Error()
+			logger.Error("failed to rotate root credential", "error", err)
+			metrics.IncrCounterWithLabels([]string{"rotation", "failure"}, 1, labels)
+			break
+		}
+		attempt.Success = true
+		logger.Info("rotated root credential")
+		metrics.IncrCounterWithLabels([]string{"rotation", "success"}, 1, labels)
+	}
+
+	// The plugin may have changed or deregistered the job while rotating
+	c.rotationJobsLock.Lock()
+	defer c.rotationJobsLock.Unlock()
+	job, err = c.rotationJob(ctx, id)
+	if err != nil || job == nil {
+		return err
+	}
+
+	if attempt.Success {
+		job.LastRotation = now
+	}
+	job.History = append(job.History, attempt)
+	if len(job.History) > rotationHistoryLimit {
+		job.History = job.History[len(job.History)-rotationHistoryLimit:]
+	}
+	if job.NextRotation, err = job.next(now); err != nil {
+		return err
+	}
+
+	return c.persistRotationJob(ctx, job)
+}
+
+// rotateCredential sends a RotationOperation to path of the mount.
+func (c *Core) rotateCredential(ctx context.Context, entry *MountEntry, path string) error {
+	ctx = namespace.ContextWithNamespace(ctx, entry.Namespace())
+	ctx, cancel := context.WithTimeout(ctx, DefaultMaxRequestDuration)
+	defer cancel()
+
+	resp, err := c.router.Route(ctx, &logical.Request{
+		Operation: logical.RotationOperation,
+		Path:      entry.APIPathNoNamespace() + path,
+	})
+	if err == logical.ErrUnsupportedOperation {
+		return errors.New("plugin does not support root credential rotation")
+	}
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return resp.Error()
+	}
+	return nil
+}
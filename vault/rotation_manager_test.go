@@ -0,0 +1,309 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/rotation"
+)
+
+func TestRotationJobConfigureRequest_Validate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		req        *rotation.RotationJobConfigureRequest
+		wantErrStr string
+	}{
+		"period":             {req: &rotation.RotationJobConfigureRequest{RotationPeriod: time.Hour}},
+		"schedule":           {req: &rotation.RotationJobConfigureRequest{RotationSchedule: "0 0 * * SAT", RotationWindow: time.Hour}},
+		"no schedule":        {req: &rotation.RotationJobConfigureRequest{}, wantErrStr: "is required"},
+		"both":               {req: &rotation.RotationJobConfigureRequest{RotationSchedule: "0 0 * * SAT", RotationPeriod: time.Hour}, wantErrStr: "mutually exclusive"},
+		"window with period": {req: &rotation.RotationJobConfigureRequest{RotationPeriod: time.Hour, RotationWindow: time.Hour}, wantErrStr: "requires a rotation schedule"},
+		"jitter":             {req: &rotation.RotationJobConfigureRequest{RotationPeriod: time.Hour, RotationJitter: time.Minute}},
+		"negative jitter":    {req: &rotation.RotationJobConfigureRequest{RotationPeriod: time.Hour, RotationJitter: -time.Minute}, wantErrStr: "must not be negative"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.req.Validate()
+			if tc.wantErrStr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErrStr) {
+				t.Fatalf("expected error containing %q, got: %v", tc.wantErrStr, err)
+			}
+		})
+	}
+}
+
+func TestCore_RotationManager(t *testing.T) {
+	var rotated []string
+	var rotateErr error
+	c, _, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"rotator": func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+				b := &framework.Backend{
+					BackendType: logical.TypeLogical,
+					RotateCredential: func(_ context.Context, req *logical.Request) error {
+						rotated = append(rotated, req.Path)
+						return rotateErr
+					},
+				}
+				return b, b.Setup(ctx, conf)
+			},
+		},
+	})
+	ctx := namespace.RootContext(nil)
+
+	me := &MountEntry{Table: mountTableType, Path: "rotator/", Type: "rotator"}
+	if err := c.mount(ctx, me); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := c.registerRotationJob(ctx, me, &rotation.RotationJobConfigureRequest{RotationSchedule: "not a schedule"})
+	if err == nil || !strings.Contains(err.Error(), "invalid rotation schedule") {
+		t.Fatalf("expected invalid schedule error, got: %v", err)
+	}
+
+	id, err := c.registerRotationJob(ctx, me, &rotation.RotationJobConfigureRequest{
+		Name:           "root",
+		ReqPath:        "config/root",
+		RotationPeriod: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if err := c.runRotations(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 0 {
+		t.Fatalf("expected no rotation before the job is due, got: %v", rotated)
+	}
+
+	// The rotation is sent to the registered path of the mount
+	now = now.Add(2 * time.Hour)
+	if err := c.runRotations(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 1 || rotated[0] != "config/root" {
+		t.Fatalf("expected config/root to be rotated, got: %v", rotated)
+	}
+	job, err := c.rotationJob(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.NextRotation.Equal(now.Add(time.Hour)) || !job.LastRotation.Equal(now) {
+		t.Fatalf("bad schedule: %#v", job)
+	}
+	if len(job.History) != 1 || !job.History[0].Success {
+		t.Fatalf("bad history: %#v", job.History)
+	}
+	if backlog := c.RotationBacklog(); backlog != 0 {
+		t.Fatalf("expected empty rotation backlog, got: %d", backlog)
+	}
+
+	// Registering the job again, e.g. when the plugin is reloaded, keeps its
+	// schedule and history
+	if _, err := c.registerRotationJob(ctx, me, &rotation.RotationJobConfigureRequest{
+		Name:           "root",
+		ReqPath:        "config/root",
+		RotationPeriod: time.Hour,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	job, err = c.rotationJob(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.NextRotation.Equal(now.Add(time.Hour)) || len(job.History) != 1 {
+		t.Fatalf("expected schedule and history to be kept: %#v", job)
+	}
+
+	// Failed rotations are recorded
+	rotateErr = errors.New("invalid credentials")
+	now = now.Add(2 * time.Hour)
+	if err := c.runRotations(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	job, err = c.rotationJob(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(job.History) != 2 || job.History[1].Success || job.History[1].Error != "invalid credentials" {
+		t.Fatalf("bad history: %#v", job.History)
+	}
+	if backlog := c.RotationBacklog(); backlog != 1 {
+		t.Fatalf("expected failed rotation to be in the backlog, got: %d", backlog)
+	}
+
+	req := logical.TestRequest(t, logical.ReadOperation, "sys/rotation/jobs/"+id)
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Data["path"] != "rotator/config/root" || resp.Data["rotation_period"] != int64(3600) {
+		t.Fatalf("bad response: %#v", resp.Data)
+	}
+	if history := resp.Data["history"].([]map[string]interface{}); len(history) != 2 || history[1]["error"] != "invalid credentials" {
+		t.Fatalf("bad history: %#v", resp.Data["history"])
+	}
+
+	req = logical.TestRequest(t, logical.ListOperation, "sys/rotation/jobs")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if keys := resp.Data["keys"].([]string); len(keys) != 1 || keys[0] != id {
+		t.Fatalf("bad keys: %#v", resp.Data["keys"])
+	}
+
+	// Jobs of disabled mounts are removed
+	if err := c.unmount(ctx, "rotator/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.runRotations(ctx, now.Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if job, err := c.rotationJob(ctx, id); err != nil || job != nil {
+		t.Fatalf("expected job to be removed, got: %#v, %v", job, err)
+	}
+}
+
+func TestCore_RotationManager_Window(t *testing.T) {
+	var rotated int
+	c, _, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"rotator": func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+				b := &framework.Backend{
+					BackendType: logical.TypeLogical,
+					RotateCredential: func(context.Context, *logical.Request) error {
+						rotated++
+						return nil
+					},
+				}
+				return b, b.Setup(ctx, conf)
+			},
+		},
+	})
+	ctx := namespace.RootContext(nil)
+
+	me := &MountEntry{Table: mountTableType, Path: "rotator/", Type: "rotator"}
+	if err := c.mount(ctx, me); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := c.registerRotationJob(ctx, me, &rotation.RotationJobConfigureRequest{
+		ReqPath:          "config/root",
+		RotationSchedule: "0 * * * *",
+		RotationWindow:   10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := c.rotationJob(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The rotation is missed once the window has passed
+	if err := c.runRotations(ctx, job.NextRotation.Add(30*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if rotated != 0 {
+		t.Fatalf("expected rotation to be missed, got %d rotations", rotated)
+	}
+	job, err = c.rotationJob(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(job.History) != 1 || !job.History[0].Missed {
+		t.Fatalf("bad history: %#v", job.History)
+	}
+
+	if err := c.runRotations(ctx, job.NextRotation.Add(5*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if rotated != 1 {
+		t.Fatalf("expected rotation within the window, got %d rotations", rotated)
+	}
+}
+
+// TestCore_RotationManager_Remount verifies that the root credentials
+// registered by a mount keep being rotated after the mount is moved.
+func TestCore_RotationManager_Remount(t *testing.T) {
+	var rotated []string
+	c, _, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		LogicalBackends: map[string]logical.Factory{
+			"rotator": func(ctx context.Context, conf *logical.BackendConfig) (logical.Backend, error) {
+				b := &framework.Backend{
+					BackendType: logical.TypeLogical,
+					RotateCredential: func(_ context.Context, req *logical.Request) error {
+						rotated = append(rotated, req.MountPoint+req.Path)
+						return nil
+					},
+				}
+				return b, b.Setup(ctx, conf)
+			},
+		},
+	})
+	ctx := namespace.RootContext(nil)
+
+	me := &MountEntry{Table: mountTableType, Path: "rotator/", Type: "rotator"}
+	if err := c.mount(ctx, me); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := c.registerRotationJob(ctx, me, &rotation.RotationJobConfigureRequest{
+		ReqPath:        "config/root",
+		RotationPeriod: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.remountSecretsEngineCurrentNamespace(ctx, "rotator/", "moved/", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.runRotations(ctx, time.Now().Add(2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 1 || rotated[0] != "moved/config/root" {
+		t.Fatalf("expected moved/config/root to be rotated, got: %v", rotated)
+	}
+	job, err := c.rotationJob(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job == nil || len(job.History) != 1 || !job.History[0].Success {
+		t.Fatalf("bad job: %#v", job)
+	}
+}
+
+func TestRotationJob_NextJitter(t *testing.T) {
+	from := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	job := &RotationJob{Schedule: "0 0 * * SAT", Jitter: time.Hour}
+	scheduled := time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 100; i++ {
+		next, err := job.next(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next.Before(scheduled) || !next.Before(scheduled.Add(job.Jitter)) {
+			t.Fatalf("expected next rotation within the jitter of %s, got %s", scheduled, next)
+		}
+	}
+}
@@ -69,6 +69,7 @@ type routeEntry struct {
 	loginPaths    atomic.Value
 	binaryPaths   atomic.Value
 	limitedPaths  atomic.Value
+	renamedPaths  atomic.Value
 	l             sync.RWMutex
 }
 
@@ -222,6 +223,12 @@ func (r *Router) Mount(backend logical.Backend, prefix string, mountEntry *Mount
 	}
 	re.limitedPaths.Store(limitedPathsEntry)
 
+	renamedPathsEntry, err := parseRenamedPaths(paths.Renamed)
+	if err != nil {
+		return err
+	}
+	re.renamedPaths.Store(renamedPathsEntry)
+
 	switch {
 	case prefix == "":
 		return fmt.Errorf("missing prefix to be used for router entry; mount_path: %q, mount_type: %q", re.mountEntry.Path, re.mountEntry.Type)
@@ -493,6 +500,25 @@ func (r *Router) MatchingBackend(ctx context.Context, path string) logical.Backe
 	return re.backend
 }
 
+// unavailableMounts returns the number of mounts routed without a backend,
+// e.g. because their plugin could not be started.
+func (r *Router) unavailableMounts() int {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	var unavailable int
+	r.root.Walk(func(_ string, raw interface{}) bool {
+		re := raw.(*routeEntry)
+		re.l.RLock()
+		if re.backend == nil {
+			unavailable++
+		}
+		re.l.RUnlock()
+		return false
+	})
+	return unavailable
+}
+
 // MatchingSystemView returns the SystemView used for a path
 func (r *Router) MatchingSystemView(ctx context.Context, path string) logical.SystemView {
 	ns, err := namespace.FromContext(ctx)
@@ -648,6 +674,12 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 		req.Path = ""
 	}
 
+	// Requests to a renamed path are handled by the backend with its new path
+	renamed, isRenamed := re.renamedPath(req.Path)
+	if isRenamed {
+		req.Path = renamed.newPath
+	}
+
 	// Attach the storage view for the request
 	req.Storage = re.storageView
 
@@ -744,9 +776,11 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 	var wrapInfo *logical.RequestWrapInfo
 	if req.WrapInfo != nil {
 		wrapInfo = &logical.RequestWrapInfo{
-			TTL:      req.WrapInfo.TTL,
-			Format:   req.WrapInfo.Format,
-			SealWrap: req.WrapInfo.SealWrap,
+			TTL:        req.WrapInfo.TTL,
+			Format:     req.WrapInfo.Format,
+			SealWrap:   req.WrapInfo.SealWrap,
+			BoundCIDRs: req.WrapInfo.BoundCIDRs,
+			NotBefore:  req.WrapInfo.NotBefore,
 		}
 	}
 
@@ -801,6 +835,17 @@ func (r *Router) routeCommon(ctx context.Context, req *logical.Request, existenc
 		return nil, ok, exists, err
 	} else {
 		resp, err := re.backend.HandleRequest(ctx, req)
+		if isRenamed {
+			mountPath := strings.TrimPrefix(mount, ns.Path)
+			metrics.IncrCounterWithLabels([]string{"route", "renamed_path"}, 1, []metrics.Label{
+				{Name: "mount_point", Value: mountPath},
+				{Name: "path", Value: renamed.oldPattern},
+			})
+			if resp != nil {
+				resp.AddWarning(fmt.Sprintf("The path %q is deprecated and was renamed to %q. Update clients to use the new path.",
+					mountPath+renamed.oldPath, mountPath+renamed.newPath))
+			}
+		}
 		if resp != nil {
 			if len(allowedResponseHeaders) > 0 {
 				resp.Headers = filteredHeaders(resp.Headers, allowedResponseHeaders, nil)
@@ -1074,6 +1119,76 @@ func pathsToRadix(paths []string) *radix.Tree {
 	return tree
 }
 
+// renamedPathEntry is the new path of a renamed path, or of a prefix of
+// renamed paths
+type renamedPathEntry struct {
+	newPath  string
+	isPrefix bool
+}
+
+// renamedPathMatch is the result of a renamed path lookup
+type renamedPathMatch struct {
+	oldPath    string
+	oldPattern string
+	newPath    string
+}
+
+// parseRenamedPaths validates the Renamed special paths of a backend, and
+// stores them in a radix tree by old path.
+func parseRenamedPaths(paths map[string]string) (*radix.Tree, error) {
+	tree := radix.New()
+	for oldPath, newPath := range paths {
+		isPrefix := strings.HasSuffix(oldPath, "*")
+		switch {
+		case oldPath == "" || oldPath == "*":
+			return nil, fmt.Errorf("renamed path %q must not be empty", oldPath)
+		case isPrefix != strings.HasSuffix(newPath, "*"):
+			return nil, fmt.Errorf("renamed path %q and its new path %q must both end with '*' to rename a prefix", oldPath, newPath)
+		case oldPath == newPath:
+			return nil, fmt.Errorf("renamed path %q must differ from its new path", oldPath)
+		}
+
+		if isPrefix {
+			oldPath = strings.TrimSuffix(oldPath, "*")
+			newPath = strings.TrimSuffix(newPath, "*")
+		}
+		tree.Insert(oldPath, &renamedPathEntry{newPath: newPath, isPrefix: isPrefix})
+	}
+
+	return tree, nil
+}
+
+// renamedPath returns the new path of the given path of the backend, if it
+// was renamed. The longest matching old path takes precedence.
+func (re *routeEntry) renamedPath(path string) (*renamedPathMatch, bool) {
+	renamedPaths, ok := re.renamedPaths.Load().(*radix.Tree)
+	if !ok || renamedPaths.Len() == 0 {
+		return nil, false
+	}
+
+	var match *renamedPathMatch
+	renamedPaths.WalkPath(path, func(oldPath string, raw interface{}) bool {
+		entry := raw.(*renamedPathEntry)
+		switch {
+		case entry.isPrefix:
+			match = &renamedPathMatch{
+				oldPath:    path,
+				oldPattern: oldPath + "*",
+				newPath:    entry.newPath + strings.TrimPrefix(path, oldPath),
+			}
+		case oldPath == path:
+			match = &renamedPathMatch{
+				oldPath:    path,
+				oldPattern: oldPath,
+				newPath:    entry.newPath,
+			}
+		}
+		return false
+	})
+
+	return match, match != nil
+}
+
 // filteredHeaders returns a headers map[string][]string that
 // contains the filtered values contained in candidateHeaders. Filtering of
 // candidateHeaders from the origHeaders is done is a case-insensitive manner.
@@ -27,3 +27,7 @@ func (r *RouterAccess) IsBinaryPath(ctx context.Context, path string) bool {
 func (r *RouterAccess) IsLimitedPath(ctx context.Context, path string) bool {
 	return r.c.router.LimitedPath(ctx, path)
 }
+
+func (r *RouterAccess) IsLoginPath(ctx context.Context, path string) bool {
+	return r.c.router.LoginPath(ctx, path)
+}
@@ -531,6 +531,104 @@ func TestRouter_Untaint(t *testing.T) {
 	}
 }
 
+func TestRouter_RenamedPath(t *testing.T) {
+	r := NewRouter()
+	_, barrier, _ := mockBarrier(t)
+	view := NewBarrierView(barrier, "logical/")
+
+	meUUID, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &NoopBackend{
+		Response: &logical.Response{},
+		Renamed: map[string]string{
+			"rotate-root":    "rotate/root",
+			"creds/*":        "static-creds/*",
+			"creds/legacy/*": "legacy-creds/*",
+		},
+	}
+	err = r.Mount(n, "db/", &MountEntry{UUID: meUUID, Accessor: "dbaccessor", NamespaceID: namespace.RootNamespaceID, namespace: namespace.RootNamespace}, view)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	type tcase struct {
+		path    string
+		newPath string
+	}
+	tcases := []tcase{
+		{"db/rotate-root", "rotate/root"},
+		{"db/rotate-root/", ""},
+		{"db/rotate/root", ""},
+		{"db/creds/app", "static-creds/app"},
+		{"db/creds/legacy/app", "legacy-creds/app"},
+		{"db/static-creds/app", ""},
+	}
+
+	for _, tc := range tcases {
+		req := &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      tc.path,
+		}
+		resp, err := r.Route(namespace.RootContext(nil), req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		expected := tc.newPath
+		if expected == "" {
+			expected = strings.TrimPrefix(tc.path, "db/")
+		}
+		if got := n.Paths[len(n.Paths)-1]; got != expected {
+			t.Fatalf("path %q was handled as %q, expected %q", tc.path, got, expected)
+		}
+		if req.Path != tc.path {
+			t.Fatalf("request path was not restored: %q", req.Path)
+		}
+
+		if tc.newPath == "" {
+			if len(resp.Warnings) != 0 {
+				t.Fatalf("unexpected warnings for path %q: %v", tc.path, resp.Warnings)
+			}
+		} else if len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "db/"+tc.newPath) {
+			t.Fatalf("expected a deprecation warning for path %q, got: %v", tc.path, resp.Warnings)
+		}
+		resp.Warnings = nil
+	}
+}
+
+func TestParseRenamedPaths_Error(t *testing.T) {
+	tcases := []struct {
+		paths map[string]string
+		err   string
+	}{
+		{
+			map[string]string{"": "foo"},
+			`renamed path "" must not be empty`,
+		},
+		{
+			map[string]string{"foo/*": "bar"},
+			`renamed path "foo/*" and its new path "bar" must both end with '*' to rename a prefix`,
+		},
+		{
+			map[string]string{"foo": "bar/*"},
+			`renamed path "foo" and its new path "bar/*" must both end with '*' to rename a prefix`,
+		},
+		{
+			map[string]string{"foo": "foo"},
+			`renamed path "foo" must differ from its new path`,
+		},
+	}
+
+	for _, tc := range tcases {
+		_, err := parseRenamedPaths(tc.paths)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("bad: paths: %v expect: %v got %v", tc.paths, tc.err, err)
+		}
+	}
+}
+
 func TestPathsToRadix(t *testing.T) {
 	// Provide real paths
 	paths := []string{
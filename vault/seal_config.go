@@ -7,10 +7,15 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
+	"time"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/ProtonMail/go-crypto/openpgp/packet"
 	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+	"github.com/hashicorp/vault/helper/lockedbuffer"
+	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/helper/thresholdunseal"
+	"github.com/hashicorp/vault/helper/x509keys"
 )
 
 // SealConfig is used to describe the seal configuration
@@ -31,6 +36,21 @@ type SealConfig struct {
 	// SecretShares. Ordering is important.
 	PGPKeys []string `json:"pgp_keys" mapstructure:"pgp_keys"`
 
+	// X509Certificates is the array of PEM-encoded X.509 certificates whose
+	// RSA or EC public keys are used, if requested, to encrypt the output
+	// unseal tokens, as an alternative to PGPKeys. Ordering is important.
+	X509Certificates []string `json:"x509_certificates,omitempty" mapstructure:"x509_certificates"`
+
+	// DelegatedUnseal indicates that key holders unseal by signing a
+	// challenge with their shares rather than by submitting them. Only
+	// supported for the barrier configuration of Shamir seals.
+	DelegatedUnseal bool `json:"delegated_unseal,omitempty" mapstructure:"delegated_unseal"`
+
+	// DelegatedUnsealConfig holds the public parameters used to recover the
+	// unseal key from the key holders' signatures. It is generated when the
+	// barrier is rekeyed with DelegatedUnseal set.
+	DelegatedUnsealConfig *thresholdunseal.Config `json:"delegated_unseal_config,omitempty" mapstructure:"-"`
+
 	// Nonce is a nonce generated by Vault used to ensure that when unseal keys
 	// are submitted for a rekey operation, the rekey operation itself is the
 	// one intended. This prevents hijacking of the rekey operation, since it
@@ -54,8 +74,9 @@ type SealConfig struct {
 	VerificationRequired bool `json:"-"`
 
 	// VerificationKey is the new key that we will roll to after successful
-	// validation
-	VerificationKey []byte `json:"-"`
+	// validation. It is held in locked memory, which is shared by clones of
+	// the configuration and released by destroyVerificationKey.
+	VerificationKey *lockedbuffer.Buffer `json:"-"`
 
 	// VerificationNonce stores the current operation nonce for verification
 	VerificationNonce string `json:"-"`
@@ -94,43 +115,111 @@ func (s *SealConfig) Validate() error {
 		return fmt.Errorf("count mismatch between number of provided PGP keys and number of shares")
 	}
 	if len(s.PGPKeys) > 0 {
-		for _, keystring := range s.PGPKeys {
-			data, err := base64.StdEncoding.DecodeString(keystring)
-			if err != nil {
-				return fmt.Errorf("error decoding given PGP key: %w", err)
-			}
-			_, err = openpgp.ReadEntity(packet.NewReader(bytes.NewBuffer(data)))
-			if err != nil {
-				return fmt.Errorf("error parsing given PGP key: %w", err)
-			}
+		if _, err := s.pgpKeyExpirations(time.Now()); err != nil {
+			return err
+		}
+	}
+	if len(s.X509Certificates) > 0 && len(s.PGPKeys) > 0 {
+		return fmt.Errorf("PGP keys and X.509 certificates are mutually exclusive")
+	}
+	if len(s.X509Certificates) > 0 && len(s.X509Certificates) != s.SecretShares {
+		return fmt.Errorf("count mismatch between number of provided X.509 certificates and number of shares")
+	}
+	for _, certPEM := range s.X509Certificates {
+		cert, err := x509keys.ParseCertificate(certPEM)
+		if err != nil {
+			return fmt.Errorf("error parsing given X.509 certificate: %w", err)
+		}
+		if err := x509keys.ValidateCertificate(cert, time.Now()); err != nil {
+			return err
+		}
+	}
+	if s.DelegatedUnsealConfig != nil {
+		if !s.DelegatedUnseal {
+			return fmt.Errorf("delegated unseal configuration given without delegated unseal")
+		}
+		if err := s.DelegatedUnsealConfig.Validate(); err != nil {
+			return fmt.Errorf("invalid delegated unseal configuration: %w", err)
+		}
+		if s.DelegatedUnsealConfig.Threshold != s.SecretThreshold || len(s.DelegatedUnsealConfig.Commitments) != s.SecretShares {
+			return fmt.Errorf("delegated unseal configuration does not match shares and threshold")
 		}
 	}
 	return nil
 }
 
+// pgpKeyExpirations returns when each of the PGP keys that expires stops
+// being usable, keyed by fingerprint. It returns an error if any of the keys
+// cannot be parsed, or has already expired or been revoked, as the shares
+// encrypted to it would be unrecoverable.
+func (s *SealConfig) pgpKeyExpirations(now time.Time) (map[string]time.Time, error) {
+	expirations := make(map[string]time.Time)
+	for _, keystring := range s.PGPKeys {
+		data, err := base64.StdEncoding.DecodeString(keystring)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding given PGP key: %w", err)
+		}
+		entity, err := openpgp.ReadEntity(packet.NewReader(bytes.NewBuffer(data)))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing given PGP key: %w", err)
+		}
+		expiration, err := pgpkeys.KeyExpiration(entity, now)
+		if err != nil {
+			return nil, err
+		}
+		if !expiration.IsZero() {
+			expirations[fmt.Sprintf("%x", entity.PrimaryKey.Fingerprint)] = expiration
+		}
+	}
+	return expirations, nil
+}
+
+// pgpKeyExpirationWarnings returns a warning for each of the PGP keys that
+// expires within pgpKeyExpirationWarningPeriod of now.
+func (s *SealConfig) pgpKeyExpirationWarnings(now time.Time) []string {
+	expirations, err := s.pgpKeyExpirations(now)
+	if err != nil {
+		return nil
+	}
+	return pgpKeyExpirationWarnings(expirations, now)
+}
+
 func (s *SealConfig) Clone() *SealConfig {
 	ret := &SealConfig{
-		Type:                 s.Type,
-		SecretShares:         s.SecretShares,
-		SecretThreshold:      s.SecretThreshold,
-		Nonce:                s.Nonce,
-		Backup:               s.Backup,
-		StoredShares:         s.StoredShares,
-		VerificationRequired: s.VerificationRequired,
-		VerificationNonce:    s.VerificationNonce,
-		Name:                 s.Name,
+		Type:                  s.Type,
+		SecretShares:          s.SecretShares,
+		SecretThreshold:       s.SecretThreshold,
+		DelegatedUnseal:       s.DelegatedUnseal,
+		DelegatedUnsealConfig: s.DelegatedUnsealConfig.Clone(),
+		Nonce:                 s.Nonce,
+		Backup:                s.Backup,
+		StoredShares:          s.StoredShares,
+		VerificationRequired:  s.VerificationRequired,
+		VerificationNonce:     s.VerificationNonce,
+		Name:                  s.Name,
 	}
 	if len(s.PGPKeys) > 0 {
 		ret.PGPKeys = make([]string, len(s.PGPKeys))
 		copy(ret.PGPKeys, s.PGPKeys)
 	}
-	if len(s.VerificationKey) > 0 {
-		ret.VerificationKey = make([]byte, len(s.VerificationKey))
-		copy(ret.VerificationKey, s.VerificationKey)
+	if len(s.X509Certificates) > 0 {
+		ret.X509Certificates = make([]string, len(s.X509Certificates))
+		copy(ret.X509Certificates, s.X509Certificates)
 	}
+	ret.VerificationKey = s.VerificationKey
 	return ret
 }
 
+// destroyVerificationKey destroys the verification key, if any, once the
+// verification is complete or abandoned.
+func (s *SealConfig) destroyVerificationKey() {
+	if s == nil {
+		return
+	}
+	s.VerificationKey.Destroy()
+	s.VerificationKey = nil
+}
+
 // SealConfigType specifies the "type" of a seal according to the following rules:
 // - For a defaultSeal, the type is SealConfigTypeShamir, since all defaultSeals use a shamir wrapper.
 //
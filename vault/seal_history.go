@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// coreSealHistoryPath is the storage path of the history of seal status
+	// transitions.
+	coreSealHistoryPath = "core/seal-history"
+
+	// sealHistoryLimit is the number of seal status transitions kept.
+	sealHistoryLimit = 100
+
+	sealHistoryEventSeal   = "seal"
+	sealHistoryEventUnseal = "unseal"
+
+	// Reasons of seal status transitions
+	sealReasonUnsealKeys      = "unseal keys"
+	sealReasonStoredKeys      = "auto-unseal with stored keys"
+	sealReasonDelegatedUnseal = "delegated unseal"
+	sealReasonRequest         = "seal request"
+	sealReasonDrainRequest    = "drain and seal request"
+	sealReasonShutdown        = "shutdown"
+)
+
+// SealHistoryEvent is a transition of the seal status of the cluster.
+type SealHistoryEvent struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+
+	// Initiator is the display name of the token which requested the
+	// transition, if it was requested with a token.
+	Initiator string `json:"initiator,omitempty"`
+	Reason    string `json:"reason"`
+	SealType  string `json:"seal_type"`
+}
+
+// newSealHistoryEvent returns an event for a transition happening now, with
+// the seal type in effect.
+func (c *Core) newSealHistoryEvent(event, reason, initiator string) *SealHistoryEvent {
+	return &SealHistoryEvent{
+		Time:      time.Now().UTC(),
+		Event:     event,
+		Initiator: initiator,
+		Reason:    reason,
+		SealType:  c.seal.BarrierSealConfigType().String(),
+	}
+}
+
+// SealHistory returns the recorded seal status transitions, oldest first.
+func (c *Core) SealHistory(ctx context.Context) ([]*SealHistoryEvent, error) {
+	entry, err := c.barrier.Get(ctx, coreSealHistoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seal history: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var history []*SealHistoryEvent
+	if err := jsonutil.DecodeJSON(entry.Value, &history); err != nil {
+		return nil, fmt.Errorf("failed to decode seal history: %w", err)
+	}
+	return history, nil
+}
+
+// recordSealHistory appends the event to the seal history, dropping the
+// oldest events past sealHistoryLimit. The history can only be written by the
+// active node while the barrier is unsealed, so the transitions of standby
+// nodes are not recorded, and unseals are recorded once the node is active.
+func (c *Core) recordSealHistory(ctx context.Context, event *SealHistoryEvent) error {
+	c.sealHistoryLock.Lock()
+	defer c.sealHistoryLock.Unlock()
+
+	history, err := c.SealHistory(ctx)
+	if err != nil {
+		return err
+	}
+	history = append(history, event)
+	if len(history) > sealHistoryLimit {
+		history = history[len(history)-sealHistoryLimit:]
+	}
+
+	entry, err := logical.StorageEntryJSON(coreSealHistoryPath, history)
+	if err != nil {
+		return fmt.Errorf("failed to encode seal history: %w", err)
+	}
+	if err := c.barrier.Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to persist seal history: %w", err)
+	}
+	return nil
+}
+
+// recordSeal records the seal of the active node. Failing to record it does
+// not prevent sealing. The caller must hold the state lock.
+func (c *Core) recordSeal(ctx context.Context, reason, initiator string) {
+	if c.Sealed() || c.standby {
+		return
+	}
+	if err := c.recordSealHistory(ctx, c.newSealHistoryEvent(sealHistoryEventSeal, reason, initiator)); err != nil {
+		c.logger.Error("failed to record seal", "error", err)
+	}
+}
+
+// recordPendingUnseal records the unseal of the node, once it is active.
+// Failing to record it does not prevent unsealing.
+func (c *Core) recordPendingUnseal(ctx context.Context) error {
+	event := c.pendingUnsealEvent
+	if event == nil {
+		return nil
+	}
+	c.pendingUnsealEvent = nil
+
+	if err := c.recordSealHistory(ctx, event); err != nil {
+		c.logger.Error("failed to record unseal", "error", err)
+	}
+	return nil
+}
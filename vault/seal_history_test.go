@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCore_SealHistory verifies that seals and unseals are recorded, and
+// read from sys/seal-status/history.
+func TestCore_SealHistory(t *testing.T) {
+	c, keys, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	require.NoError(t, c.Seal(root))
+	for _, key := range keys {
+		_, err := TestCoreUnseal(c, TestKeyCopy(key))
+		require.NoError(t, err)
+	}
+	require.False(t, c.Sealed())
+
+	history, err := c.SealHistory(ctx)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+
+	for i, expected := range []SealHistoryEvent{
+		{Event: sealHistoryEventUnseal, Reason: sealReasonUnsealKeys},
+		{Event: sealHistoryEventSeal, Reason: sealReasonRequest, Initiator: "root"},
+		{Event: sealHistoryEventUnseal, Reason: sealReasonUnsealKeys},
+	} {
+		require.Equal(t, expected.Event, history[i].Event, i)
+		require.Equal(t, expected.Reason, history[i].Reason, i)
+		require.Equal(t, expected.Initiator, history[i].Initiator, i)
+		require.Equal(t, SealConfigTypeShamir.String(), history[i].SealType, i)
+		require.False(t, history[i].Time.IsZero(), i)
+	}
+	require.False(t, history[2].Time.Before(history[1].Time))
+
+	resp, err := c.HandleRequest(ctx, &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "sys/seal-status/history",
+		ClientToken: root,
+	})
+	require.NoError(t, err)
+	events := resp.Data["events"].([]map[string]interface{})
+	require.Len(t, events, 3)
+	require.Equal(t, "seal", events[1]["event"])
+	require.Equal(t, "root", events[1]["initiator"])
+	require.NotContains(t, events[0], "initiator")
+}
+
+// TestCore_SealHistoryLimit verifies that only the most recent transitions
+// are kept.
+func TestCore_SealHistoryLimit(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	for i := 0; i < sealHistoryLimit; i++ {
+		require.NoError(t, c.recordSealHistory(ctx, c.newSealHistoryEvent(sealHistoryEventSeal, sealReasonShutdown, "")))
+	}
+
+	history, err := c.SealHistory(ctx)
+	require.NoError(t, err)
+	require.Len(t, history, sealHistoryLimit)
+
+	// The unseal of the test core was dropped
+	for _, event := range history {
+		require.Equal(t, sealHistoryEventSeal, event.Event)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+const (
+	// pgpKeyExpirationWarningPeriod is how long before a PGP key used to
+	// encrypt unseal or recovery key shares expires that we start warning
+	// about it
+	pgpKeyExpirationWarningPeriod = 30 * 24 * time.Hour
+
+	// pgpKeyExpirationCheckInterval is how often the PGP keys of the stored
+	// unseal and recovery key backups are checked for expiration
+	pgpKeyExpirationCheckInterval = 12 * time.Hour
+)
+
+// pgpKeyExpirationWarnings returns a warning, sorted by fingerprint, for each
+// of the PGP key expirations that is within pgpKeyExpirationWarningPeriod of
+// now.
+func pgpKeyExpirationWarnings(expirations map[string]time.Time, now time.Time) []string {
+	fingerprints := make([]string, 0, len(expirations))
+	for fingerprint := range expirations {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Strings(fingerprints)
+
+	var warnings []string
+	for _, fingerprint := range fingerprints {
+		expiration := expirations[fingerprint]
+		switch {
+		case !now.Before(expiration):
+			warnings = append(warnings, fmt.Sprintf("PGP key %s expired at %s; shares encrypted to it may be unrecoverable", fingerprint, expiration.Format(time.RFC3339)))
+		case expiration.Sub(now) <= pgpKeyExpirationWarningPeriod:
+			warnings = append(warnings, fmt.Sprintf("PGP key %s expires at %s; shares encrypted to it will be unrecoverable once it expires", fingerprint, expiration.Format(time.RFC3339)))
+		}
+	}
+	return warnings
+}
+
+// startPGPKeyExpirationChecks periodically warns when the PGP keys that the
+// stored unseal and recovery key backups are encrypted to are about to expire.
+func (c *Core) startPGPKeyExpirationChecks() {
+	if c.pgpKeyExpirationChecksCancel != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, c.pgpKeyExpirationChecksCancel = context.WithCancel(c.activeContext)
+
+	go func() {
+		c.checkPGPKeyExpirations(ctx, time.Now())
+
+		ticker := time.NewTicker(pgpKeyExpirationCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.checkPGPKeyExpirations(ctx, time.Now())
+			}
+		}
+	}()
+}
+
+// checkPGPKeyExpirations logs a warning for each PGP key of the stored unseal
+// and recovery key backups that expires within pgpKeyExpirationWarningPeriod
+// of now, and reports how many there are. It returns the warnings.
+func (c *Core) checkPGPKeyExpirations(ctx context.Context, now time.Time) []string {
+	var warnings []string
+	for _, recovery := range []bool{false, true} {
+		kind := "unseal"
+		if recovery {
+			kind = "recovery"
+		}
+
+		backup, err := c.RekeyRetrieveBackup(ctx, recovery)
+		if err != nil {
+			c.logger.Error("failed to check PGP key expiration of key backup", "type", kind, "error", err)
+			continue
+		}
+
+		var backupWarnings []string
+		if backup != nil {
+			backupWarnings = pgpKeyExpirationWarnings(backup.KeyExpirations, now)
+		}
+		for _, warning := range backupWarnings {
+			c.logger.Warn("PGP key of "+kind+" key backup is expiring", "warning", warning)
+		}
+		metrics.SetGaugeWithLabels([]string{"core", "key_backup", "pgp_keys_expiring"}, float32(len(backupWarnings)),
+			[]metrics.Label{{Name: "type", Value: kind}})
+		warnings = append(warnings, backupWarnings...)
+	}
+	return warnings
+}
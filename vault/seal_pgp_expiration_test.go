@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/require"
+)
+
+// testPGPKey returns a base64-encoded PGP public key created at created that
+// expires after lifetime.
+func testPGPKey(t *testing.T, created time.Time, lifetime time.Duration) string {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", &packet.Config{
+		Algorithm:       packet.PubKeyAlgoEdDSA,
+		KeyLifetimeSecs: uint32(lifetime / time.Second),
+		Time:            func() time.Time { return created },
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, entity.Serialize(&buf))
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestSealConfig_Validate_PGPKeyExpiration(t *testing.T) {
+	now := time.Now()
+
+	conf := &SealConfig{
+		SecretShares:    1,
+		SecretThreshold: 1,
+		PGPKeys:         []string{testPGPKey(t, now.Add(-48*time.Hour), 24*time.Hour)},
+	}
+	require.ErrorContains(t, conf.Validate(), "expired")
+
+	conf.PGPKeys = []string{testPGPKey(t, now, 10*24*time.Hour)}
+	require.NoError(t, conf.Validate())
+	require.Len(t, conf.pgpKeyExpirationWarnings(now), 1)
+
+	conf.PGPKeys = []string{testPGPKey(t, now, 365*24*time.Hour)}
+	require.NoError(t, conf.Validate())
+	require.Empty(t, conf.pgpKeyExpirationWarnings(now))
+}
+
+func TestCore_Rekey_PGPKeyExpiration(t *testing.T) {
+	bc := &SealConfig{
+		SecretShares:    1,
+		SecretThreshold: 1,
+	}
+	c, keys, _, _ := TestCoreUnsealedWithConfigs(t, bc, nil)
+
+	now := time.Now()
+	require.Empty(t, c.checkPGPKeyExpirations(context.Background(), now))
+
+	hErr := c.RekeyInit(&SealConfig{
+		Type:            c.seal.BarrierSealConfigType().String(),
+		SecretShares:    1,
+		SecretThreshold: 1,
+		PGPKeys:         []string{testPGPKey(t, now, 10*24*time.Hour)},
+		Backup:          true,
+	}, false)
+	require.Nil(t, hErr)
+
+	rkconf, hErr := c.RekeyConfig(false)
+	require.Nil(t, hErr)
+
+	result, hErr := c.RekeyUpdate(context.Background(), keys[0], rkconf.Nonce, false)
+	require.Nil(t, hErr)
+	require.NotNil(t, result)
+	require.Len(t, result.Warnings, 1)
+
+	backup, hErr := c.RekeyRetrieveBackup(context.Background(), false)
+	require.Nil(t, hErr)
+	require.Len(t, backup.KeyExpirations, 1)
+
+	require.Len(t, c.checkPGPKeyExpirations(context.Background(), now), 1)
+	require.Empty(t, c.checkPGPKeyExpirations(context.Background(), now.Add(-365*24*time.Hour)))
+}
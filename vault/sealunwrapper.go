@@ -35,8 +35,9 @@ func NewSealUnwrapper(underlying physical.Backend, logger log.Logger) physical.B
 }
 
 var (
-	_ physical.Backend       = (*sealUnwrapper)(nil)
-	_ physical.Transactional = (*transactionalSealUnwrapper)(nil)
+	_ physical.Backend          = (*sealUnwrapper)(nil)
+	_ physical.PaginatedBackend = (*sealUnwrapper)(nil)
+	_ physical.Transactional    = (*transactionalSealUnwrapper)(nil)
 )
 
 type sealUnwrapper struct {
@@ -133,6 +134,10 @@ func (d *sealUnwrapper) List(ctx context.Context, prefix string) ([]string, erro
 	return d.underlying.List(ctx, prefix)
 }
 
+func (d *sealUnwrapper) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	return physical.ListPage(ctx, d.underlying, prefix, after, limit)
+}
+
 func (d *transactionalSealUnwrapper) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
 	// Collect keys that need to be locked
 	var keys []string
@@ -0,0 +1,290 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/vault/seal"
+)
+
+const (
+	// SelfCheckStatusRunning, SelfCheckStatusPassed and SelfCheckStatusFailed
+	// are the statuses of the self-check.
+	SelfCheckStatusRunning = "running"
+	SelfCheckStatusPassed  = "passed"
+	SelfCheckStatusFailed  = "failed"
+
+	// selfCheckStorageSamples is the number of storage entries read by the
+	// self-check.
+	selfCheckStorageSamples = 32
+
+	// selfCheckStorageMaxDepth bounds the depth of the storage prefixes
+	// descended into to sample an entry.
+	selfCheckStorageMaxDepth = 16
+)
+
+// selfCheckStoragePrefixes are the storage prefixes sampled by the self-check,
+// whose entries are all encrypted by the barrier.
+var selfCheckStoragePrefixes = []string{
+	backendBarrierPrefix,
+	credentialBarrierPrefix,
+	systemBarrierPrefix,
+}
+
+// SelfCheckResult is the outcome of the integrity checks run once the node
+// becomes active.
+type SelfCheckResult struct {
+	Status    string
+	StartTime time.Time
+	EndTime   time.Time
+	Findings  []*SelfCheckFinding
+}
+
+// SelfCheckFinding is a problem found by a check. The message does not name
+// paths or keys, which are logged instead.
+type SelfCheckFinding struct {
+	Check   string
+	Message string
+}
+
+// SelfCheckResult returns the result of the self-check, which is nil on nodes
+// which are not active.
+func (c *Core) SelfCheckResult() *SelfCheckResult {
+	return c.selfCheckResult.Load()
+}
+
+// startSelfCheck runs the self-check in the background, so that it does not
+// delay unsealing.
+func (c *Core) startSelfCheck() {
+	if c.selfCheckCancel != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, c.selfCheckCancel = context.WithCancel(namespace.RootContext(c.activeContext))
+
+	c.selfCheckResult.Store(&SelfCheckResult{
+		Status:    SelfCheckStatusRunning,
+		StartTime: time.Now(),
+	})
+
+	go func() {
+		result := c.runSelfCheck(ctx)
+		if ctx.Err() != nil {
+			// The node stepped down or sealed, which may have failed checks
+			return
+		}
+		c.selfCheckResult.Store(result)
+	}()
+}
+
+// runSelfCheck verifies that every seal can decrypt the root key, that a
+// sample of the storage entries can be decrypted, and that the mount tables
+// are consistent with the router.
+func (c *Core) runSelfCheck(ctx context.Context) *SelfCheckResult {
+	result := &SelfCheckResult{
+		Status:    SelfCheckStatusPassed,
+		StartTime: time.Now(),
+	}
+
+	checks := []struct {
+		name string
+		fn   func(context.Context) []string
+	}{
+		{"seal", c.selfCheckSeal},
+		{"storage", c.selfCheckStorage},
+		{"mount_tables", c.selfCheckMountTables},
+	}
+	for _, check := range checks {
+		for _, message := range check.fn(ctx) {
+			result.Findings = append(result.Findings, &SelfCheckFinding{
+				Check:   check.name,
+				Message: message,
+			})
+		}
+	}
+	result.EndTime = time.Now()
+
+	if len(result.Findings) > 0 {
+		result.Status = SelfCheckStatusFailed
+		for _, finding := range result.Findings {
+			c.logger.Error("self-check failed", "check", finding.Check, "finding", finding.Message)
+		}
+	} else {
+		c.logger.Info("self-check passed", "duration", result.EndTime.Sub(result.StartTime))
+	}
+	return result
+}
+
+// selfCheckSeal verifies that each enabled seal can decrypt the stored root
+// key on its own, so that losing any other seal does not prevent unsealing.
+func (c *Core) selfCheckSeal(ctx context.Context) []string {
+	if c.seal.StoredKeysSupported() == seal.StoredKeysNotSupported {
+		return nil
+	}
+
+	pe, err := c.physical.Get(ctx, StoredBarrierKeysPath)
+	if err != nil {
+		c.logger.Warn("self-check failed to read the stored keys", "error", err)
+		return []string{"failed to read the stored root key"}
+	}
+	if pe == nil {
+		return []string{"the stored root key is missing"}
+	}
+	wrapped, err := UnmarshalSealWrappedValue(pe.Value)
+	if err != nil {
+		c.logger.Warn("self-check failed to decode the stored keys", "error", err)
+		return []string{"failed to decode the stored root key"}
+	}
+
+	var findings []string
+	for _, sw := range c.seal.GetAccess().GetEnabledSealWrappersByPriority() {
+		if err := c.selfCheckSealWrapper(ctx, sw, wrapped.GetSlots()); err != nil {
+			c.logger.Warn("self-check failed to decrypt the stored keys", "seal_name", sw.Name, "error", err)
+			findings = append(findings, fmt.Sprintf("seal %q cannot decrypt the root key", sw.Name))
+		}
+	}
+	return findings
+}
+
+func (c *Core) selfCheckSealWrapper(ctx context.Context, sw *seal.SealWrapper, slots []*wrapping.BlobInfo) error {
+	errs := errors.New("no stored key can be decrypted")
+	for _, slot := range slots {
+		pt, err := sw.Wrapper.Decrypt(ctx, slot)
+		if err != nil && !seal.IsOldKeyError(err) {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		var keys [][]byte
+		if err := json.Unmarshal(pt, &keys); err != nil {
+			return fmt.Errorf("failed to decode stored keys: %w", err)
+		}
+		if len(keys) == 0 {
+			return errors.New("no stored keys")
+		}
+		return c.barrier.VerifyRoot(keys[0])
+	}
+	return errs
+}
+
+// selfCheckStorage reads a random sample of the storage entries, which fails
+// for the entries which cannot be decrypted by the barrier.
+func (c *Core) selfCheckStorage(ctx context.Context) []string {
+	var sampled, failed int
+	for i := 0; i < selfCheckStorageSamples && ctx.Err() == nil; i++ {
+		key, err := c.selfCheckSampleKey(ctx, selfCheckStoragePrefixes[rand.Intn(len(selfCheckStoragePrefixes))])
+		if err != nil {
+			c.logger.Warn("self-check failed to list storage", "error", err)
+			failed++
+			continue
+		}
+		if key == "" {
+			continue
+		}
+
+		sampled++
+		if _, err := c.barrier.Get(ctx, key); err != nil {
+			c.logger.Warn("self-check failed to read storage entry", "key", key, "error", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return []string{fmt.Sprintf("%d of %d sampled storage entries could not be read", failed, sampled)}
+	}
+	return nil
+}
+
+// selfCheckSampleKey returns a random entry under prefix, or an empty string
+// if the prefix holds no entries.
+func (c *Core) selfCheckSampleKey(ctx context.Context, prefix string) (string, error) {
+	for depth := 0; depth < selfCheckStorageMaxDepth; depth++ {
+		keys, err := c.barrier.List(ctx, prefix)
+		if err != nil {
+			return "", err
+		}
+		if len(keys) == 0 {
+			return "", nil
+		}
+
+		key := prefix + keys[rand.Intn(len(keys))]
+		if !strings.HasSuffix(key, "/") {
+			return key, nil
+		}
+		prefix = key
+	}
+	return "", nil
+}
+
+// selfCheckMountTables verifies that the mount tables have no duplicate
+// paths, accessors or UUIDs, and that each of their entries is routed.
+func (c *Core) selfCheckMountTables(_ context.Context) []string {
+	var tables []*MountTable
+	c.mountsLock.RLock()
+	if c.mounts != nil {
+		tables = append(tables, c.mounts.shallowClone())
+	}
+	c.mountsLock.RUnlock()
+	c.authLock.RLock()
+	if c.auth != nil {
+		tables = append(tables, c.auth.shallowClone())
+	}
+	c.authLock.RUnlock()
+
+	var findings []string
+	for _, table := range tables {
+		paths := make(map[string]struct{})
+		accessors := make(map[string]struct{})
+		uuids := make(map[string]struct{})
+
+		inconsistent := 0
+		for _, entry := range table.Entries {
+			var problems []string
+			if entry.Table != table.Type {
+				problems = append(problems, fmt.Sprintf("entry of the %s table", entry.Table))
+			}
+			if entry.namespace == nil {
+				problems = append(problems, "unknown namespace")
+			} else {
+				path := entry.namespace.Path + entry.Path
+				if _, ok := paths[path]; ok {
+					problems = append(problems, "duplicate path")
+				}
+				paths[path] = struct{}{}
+			}
+			if _, ok := accessors[entry.Accessor]; ok {
+				problems = append(problems, "duplicate accessor")
+			}
+			accessors[entry.Accessor] = struct{}{}
+			if _, ok := uuids[entry.UUID]; ok {
+				problems = append(problems, "duplicate UUID")
+			}
+			uuids[entry.UUID] = struct{}{}
+			if routed := c.router.MatchingMountByAccessor(entry.Accessor); routed == nil || routed.Accessor != entry.Accessor || routed.UUID != entry.UUID {
+				problems = append(problems, "not routed")
+			}
+
+			if len(problems) > 0 {
+				c.logger.Warn("self-check found inconsistent mount table entry", "table", table.Type, "path", entry.Path,
+					"namespace", entry.NamespaceID, "accessor", entry.Accessor, "problems", strings.Join(problems, ", "))
+				inconsistent++
+			}
+		}
+
+		if inconsistent > 0 {
+			findings = append(findings, fmt.Sprintf("%d of %d entries of the %s table are inconsistent", inconsistent, len(table.Entries), table.Type))
+		}
+	}
+	return findings
+}
@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfCheck(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	// The self-check runs once the node is active
+	require.Eventually(t, func() bool {
+		result := c.SelfCheckResult()
+		return result != nil && result.Status != SelfCheckStatusRunning
+	}, 10*time.Second, 50*time.Millisecond)
+	result := c.SelfCheckResult()
+	require.Equal(t, SelfCheckStatusPassed, result.Status, "%#v", result.Findings)
+	require.Empty(t, result.Findings)
+
+	// A stored root key which the seal cannot decrypt is found
+	pe, err := c.physical.Get(ctx, StoredBarrierKeysPath)
+	require.NoError(t, err)
+	require.NotNil(t, pe)
+	keys, err := c.seal.GetStoredKeys(ctx)
+	require.NoError(t, err)
+	keys[0][0] ^= 0xff
+	require.NoError(t, c.seal.SetStoredKeys(ctx, keys))
+
+	result = c.runSelfCheck(ctx)
+	require.Equal(t, SelfCheckStatusFailed, result.Status)
+	require.Len(t, result.Findings, 1)
+	require.Equal(t, "seal", result.Findings[0].Check)
+
+	require.NoError(t, c.physical.Put(ctx, &physical.Entry{Key: StoredBarrierKeysPath, Value: pe.Value}))
+
+	// So are mount table entries which are not routed
+	c.mountsLock.Lock()
+	unrouted, err := c.mounts.Entries[0].Clone()
+	require.NoError(t, err)
+	unrouted.Path = "unrouted/"
+	unrouted.Accessor = "unrouted"
+	unrouted.UUID = "unrouted"
+	unrouted.namespace = namespace.RootNamespace
+	c.mounts.Entries = append(c.mounts.Entries, unrouted)
+	c.mountsLock.Unlock()
+
+	result = c.runSelfCheck(ctx)
+	require.Equal(t, SelfCheckStatusFailed, result.Status)
+	require.Len(t, result.Findings, 1)
+	require.Equal(t, "mount_tables", result.Findings[0].Check)
+}
@@ -0,0 +1,345 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/sdk/physical"
+)
+
+// storageLimitWarnRatio is the usage of a storage limit above which a warning
+// is logged.
+const storageLimitWarnRatio = 0.9
+
+// StorageLimit restricts the size of individual entries and the number of
+// entries stored under a storage path prefix. A zero value disables the
+// corresponding limit.
+type StorageLimit struct {
+	Prefix       string
+	MaxEntrySize int
+	MaxEntries   int
+}
+
+// storageLimitState tracks the number of entries under the prefix of a limit.
+// The entries are counted on the first write under the prefix after the node
+// becomes active.
+type storageLimitState struct {
+	*StorageLimit
+
+	counted bool
+	entries int
+	warned  bool
+}
+
+// LimitedStorage is a physical backend enforcing storage limits on writes, so
+// that misbehaving plugins cannot write unbounded data.
+type LimitedStorage struct {
+	physical.Backend
+
+	limits []*storageLimitState
+	logger log.Logger
+	sink   *metricsutil.ClusterMetricSink
+
+	// l serializes writes under prefixes with entry count limits, so that
+	// concurrent writes of the same new key are counted once
+	l sync.Mutex
+}
+
+// TransactionalLimitedStorage is the transactional version of LimitedStorage
+type TransactionalLimitedStorage struct {
+	*LimitedStorage
+	physical.Transactional
+}
+
+// Verify LimitedStorage satisfies the correct interfaces
+var (
+	_ physical.Backend          = (*LimitedStorage)(nil)
+	_ physical.PaginatedBackend = (*LimitedStorage)(nil)
+	_ physical.Transactional    = (*TransactionalLimitedStorage)(nil)
+)
+
+// NewLimitedStorage returns a wrapped physical backend enforcing the given
+// storage limits.
+func NewLimitedStorage(b physical.Backend, limits []*StorageLimit, logger log.Logger, sink *metricsutil.ClusterMetricSink) physical.Backend {
+	s := &LimitedStorage{
+		Backend: b,
+		logger:  logger,
+		sink:    sink,
+	}
+	for _, limit := range limits {
+		s.limits = append(s.limits, &storageLimitState{StorageLimit: limit})
+	}
+
+	if bTxn, ok := b.(physical.Transactional); ok {
+		return &TransactionalLimitedStorage{
+			LimitedStorage: s,
+			Transactional:  bTxn,
+		}
+	}
+
+	return s
+}
+
+// matchingLimits returns the limits applying to the key, and whether any of
+// them limits the number of entries.
+func (s *LimitedStorage) matchingLimits(key string) ([]*storageLimitState, bool) {
+	var matching []*storageLimitState
+	var counted bool
+	for _, limit := range s.limits {
+		if strings.HasPrefix(key, limit.Prefix) {
+			matching = append(matching, limit)
+			counted = counted || limit.MaxEntries > 0
+		}
+	}
+	return matching, counted
+}
+
+func (s *LimitedStorage) Put(ctx context.Context, entry *physical.Entry) error {
+	limits, counted := s.matchingLimits(entry.Key)
+	if len(limits) == 0 {
+		return s.Backend.Put(ctx, entry)
+	}
+
+	if err := s.checkEntrySize(entry, limits); err != nil {
+		return err
+	}
+	if !counted {
+		return s.Backend.Put(ctx, entry)
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	delta, err := s.entriesDelta(ctx, entry.Key, limits, physical.PutOperation)
+	if err != nil {
+		return err
+	}
+	if err := s.checkEntries(limits, delta); err != nil {
+		return err
+	}
+	if err := s.Backend.Put(ctx, entry); err != nil {
+		return err
+	}
+	s.addEntries(limits, delta)
+
+	return nil
+}
+
+func (s *LimitedStorage) Delete(ctx context.Context, key string) error {
+	limits, counted := s.matchingLimits(key)
+	if !counted {
+		return s.Backend.Delete(ctx, key)
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	delta, err := s.entriesDelta(ctx, key, limits, physical.DeleteOperation)
+	if err != nil {
+		return err
+	}
+	if err := s.Backend.Delete(ctx, key); err != nil {
+		return err
+	}
+	s.addEntries(limits, delta)
+
+	return nil
+}
+
+// resetEntries discards the number of entries under the limits, so that they
+// are counted again on the next write. The counts only track the writes made
+// through this node, so they must be reset whenever the node becomes active,
+// as the writes of other nodes or restored snapshots bypass it.
+func (s *LimitedStorage) resetEntries() {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	for _, limit := range s.limits {
+		limit.counted, limit.entries = false, 0
+	}
+}
+
+func (s *LimitedStorage) ListPage(ctx context.Context, prefix string, after string, limit int) ([]string, error) {
+	return physical.ListPage(ctx, s.Backend, prefix, after, limit)
+}
+
+func (s *TransactionalLimitedStorage) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	var anyCounted bool
+	for _, txn := range txns {
+		limits, counted := s.matchingLimits(txn.Entry.Key)
+		if txn.Operation == physical.PutOperation {
+			if err := s.checkEntrySize(txn.Entry, limits); err != nil {
+				return err
+			}
+		}
+		anyCounted = anyCounted || counted
+	}
+	if !anyCounted {
+		return s.Transactional.Transaction(ctx, txns)
+	}
+
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	// Entries are counted once per key, with the last operation on the key
+	// determining whether it exists after the transaction
+	lastOps := make(map[string]physical.Operation, len(txns))
+	for _, txn := range txns {
+		if txn.Operation == physical.PutOperation || txn.Operation == physical.DeleteOperation {
+			lastOps[txn.Entry.Key] = txn.Operation
+		}
+	}
+	deltas := make(map[*storageLimitState]int)
+	for key, op := range lastOps {
+		limits, counted := s.matchingLimits(key)
+		if !counted {
+			continue
+		}
+		delta, err := s.entriesDelta(ctx, key, limits, op)
+		if err != nil {
+			return err
+		}
+		for _, limit := range limits {
+			deltas[limit] += delta
+		}
+	}
+	for limit, delta := range deltas {
+		if err := s.checkEntries([]*storageLimitState{limit}, delta); err != nil {
+			return err
+		}
+	}
+	if err := s.Transactional.Transaction(ctx, txns); err != nil {
+		return err
+	}
+	for limit, delta := range deltas {
+		s.addEntries([]*storageLimitState{limit}, delta)
+	}
+
+	return nil
+}
+
+// TransactionLimits implements physical.TransactionalLimits
+func (s *TransactionalLimitedStorage) TransactionLimits() (int, int) {
+	if tl, ok := s.Transactional.(physical.TransactionalLimits); ok {
+		return tl.TransactionLimits()
+	}
+	return 0, 0
+}
+
+func (s *LimitedStorage) checkEntrySize(entry *physical.Entry, limits []*storageLimitState) error {
+	for _, limit := range limits {
+		if limit.MaxEntrySize <= 0 {
+			continue
+		}
+
+		labels := []metricsutil.Label{{Name: "prefix", Value: limit.Prefix}}
+		s.sink.AddSampleWithLabels([]string{"core", "storage_limit", "entry_size_usage"},
+			float32(len(entry.Value))/float32(limit.MaxEntrySize), labels)
+
+		if len(entry.Value) > limit.MaxEntrySize {
+			s.sink.IncrCounterWithLabels([]string{"core", "storage_limit", "rejected"}, 1,
+				append(labels, metricsutil.Label{Name: "limit", Value: "max_entry_size"}))
+			return fmt.Errorf("storage limit exceeded: entry %q of %d bytes is larger than the max_entry_size of %d bytes for prefix %q",
+				entry.Key, len(entry.Value), limit.MaxEntrySize, limit.Prefix)
+		}
+	}
+	return nil
+}
+
+// entriesDelta returns the change in the number of entries caused by the
+// operation on the key, counting the entries under the limits if needed. It
+// must be called with the lock held.
+func (s *LimitedStorage) entriesDelta(ctx context.Context, key string, limits []*storageLimitState, op physical.Operation) (int, error) {
+	for _, limit := range limits {
+		if limit.MaxEntries <= 0 || limit.counted {
+			continue
+		}
+		entries, err := s.countEntries(ctx, limit.Prefix)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count entries under storage limit prefix %q: %w", limit.Prefix, err)
+		}
+		limit.entries, limit.counted = entries, true
+	}
+
+	existing, err := s.Backend.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case op == physical.PutOperation && existing == nil:
+		return 1, nil
+	case op == physical.DeleteOperation && existing != nil:
+		return -1, nil
+	default:
+		return 0, nil
+	}
+}
+
+func (s *LimitedStorage) checkEntries(limits []*storageLimitState, delta int) error {
+	if delta <= 0 {
+		return nil
+	}
+	for _, limit := range limits {
+		if limit.MaxEntries > 0 && limit.entries+delta > limit.MaxEntries {
+			s.sink.IncrCounterWithLabels([]string{"core", "storage_limit", "rejected"}, 1,
+				[]metricsutil.Label{{Name: "prefix", Value: limit.Prefix}, {Name: "limit", Value: "max_entries"}})
+			return fmt.Errorf("storage limit exceeded: prefix %q already holds the max_entries of %d entries",
+				limit.Prefix, limit.MaxEntries)
+		}
+	}
+	return nil
+}
+
+// addEntries updates the number of entries under the limits, reporting the
+// usage of the limits. It must be called with the lock held.
+func (s *LimitedStorage) addEntries(limits []*storageLimitState, delta int) {
+	for _, limit := range limits {
+		if limit.MaxEntries <= 0 {
+			continue
+		}
+		limit.entries += delta
+
+		usage := float32(limit.entries) / float32(limit.MaxEntries)
+		labels := []metricsutil.Label{{Name: "prefix", Value: limit.Prefix}}
+		s.sink.SetGaugeWithLabels([]string{"core", "storage_limit", "entries"}, float32(limit.entries), labels)
+		s.sink.SetGaugeWithLabels([]string{"core", "storage_limit", "entries_usage"}, usage, labels)
+
+		switch {
+		case usage >= storageLimitWarnRatio && !limit.warned:
+			s.logger.Warn("storage prefix is approaching its entry limit", "prefix", limit.Prefix,
+				"entries", limit.entries, "max_entries", limit.MaxEntries)
+			limit.warned = true
+		case usage < storageLimitWarnRatio:
+			limit.warned = false
+		}
+	}
+}
+
+// countEntries returns the number of entries under the prefix.
+func (s *LimitedStorage) countEntries(ctx context.Context, prefix string) (int, error) {
+	keys, err := s.Backend.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	var entries int
+	for _, key := range keys {
+		if !strings.HasSuffix(key, "/") {
+			entries++
+			continue
+		}
+		subEntries, err := s.countEntries(ctx, prefix+key)
+		if err != nil {
+			return 0, err
+		}
+		entries += subEntries
+	}
+	return entries, nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/metricsutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/logging"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/sdk/physical/inmem"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitedStorage(t *testing.T) {
+	ctx := context.Background()
+	logger := hclog.NewNullLogger()
+	inm, err := inmem.NewTransactionalInmem(nil, logger)
+	require.NoError(t, err)
+
+	// Entries existing before the limits are enforced are counted
+	require.NoError(t, inm.Put(ctx, &physical.Entry{Key: "logical/abc/existing", Value: []byte("v")}))
+	require.NoError(t, inm.Put(ctx, &physical.Entry{Key: "logical/abc/dir/nested", Value: []byte("v")}))
+
+	b := NewLimitedStorage(inm, []*StorageLimit{
+		{Prefix: "logical/abc/", MaxEntrySize: 8, MaxEntries: 4},
+		{Prefix: "logical/", MaxEntrySize: 16},
+	}, logger, metricsutil.BlackholeSink())
+	txnBackend, ok := b.(physical.Transactional)
+	require.True(t, ok)
+
+	put := func(key, value string) error {
+		return b.Put(ctx, &physical.Entry{Key: key, Value: []byte(value)})
+	}
+
+	// Entry sizes are limited by all matching prefixes
+	require.ErrorContains(t, put("logical/abc/large", "123456789"), "max_entry_size of 8 bytes")
+	require.ErrorContains(t, put("logical/def/large", "12345678901234567"), "max_entry_size of 16 bytes")
+	require.NoError(t, put("logical/def/ok", "123456789"))
+	require.NoError(t, put("core/unlimited", "12345678901234567"))
+
+	// Entry counts only increase for new keys
+	require.NoError(t, put("logical/abc/new1", "v"))
+	require.NoError(t, put("logical/abc/new1", "v2"))
+	require.NoError(t, put("logical/abc/new2", "v"))
+	require.ErrorContains(t, put("logical/abc/new3", "v"), "max_entries of 4 entries")
+
+	// Updating an existing key is still allowed
+	require.NoError(t, put("logical/abc/existing", "v2"))
+
+	// Deleting an entry frees up room for another
+	require.NoError(t, b.Delete(ctx, "logical/abc/missing"))
+	require.ErrorContains(t, put("logical/abc/new3", "v"), "max_entries")
+	require.NoError(t, b.Delete(ctx, "logical/abc/new1"))
+	require.NoError(t, put("logical/abc/new3", "v"))
+
+	// Transactions are limited as a whole
+	err = txnBackend.Transaction(ctx, []*physical.TxnEntry{
+		{Operation: physical.DeleteOperation, Entry: &physical.Entry{Key: "logical/abc/new2"}},
+		{Operation: physical.PutOperation, Entry: &physical.Entry{Key: "logical/abc/txn1", Value: []byte("v")}},
+	})
+	require.NoError(t, err)
+	err = txnBackend.Transaction(ctx, []*physical.TxnEntry{
+		{Operation: physical.PutOperation, Entry: &physical.Entry{Key: "logical/abc/txn2", Value: []byte("v")}},
+	})
+	require.ErrorContains(t, err, "max_entries")
+	err = txnBackend.Transaction(ctx, []*physical.TxnEntry{
+		{Operation: physical.PutOperation, Entry: &physical.Entry{Key: "logical/def/txn", Value: []byte("12345678901234567")}},
+	})
+	require.ErrorContains(t, err, "max_entry_size")
+
+	entry, err := inm.Get(ctx, "logical/abc/txn2")
+	require.NoError(t, err)
+	require.Nil(t, entry)
+}
+
+// TestLimitedStorage_Failover verifies that the entries under the limits are
+// counted again when a node becomes active, as the writes made by the other
+// nodes meanwhile bypass its storage.
+func TestLimitedStorage_Failover(t *testing.T) {
+	logger := logging.NewVaultLogger(hclog.Trace).Named(t.Name())
+	inm, err := inmem.NewInmemHA(nil, logger)
+	require.NoError(t, err)
+	inmha, err := inmem.NewInmemHA(nil, logger)
+	require.NoError(t, err)
+
+	newCore := func(name string) *Core {
+		core, err := NewCore(&CoreConfig{
+			Physical:      inm,
+			HAPhysical:    inmha.(physical.HABackend),
+			RedirectAddr:  "http://127.0.0.1:8200",
+			DisableMlock:  true,
+			Logger:        logger.Named(name),
+			StorageLimits: []*StorageLimit{{Prefix: "limited/", MaxEntries: 2}},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { core.Shutdown() })
+		return core
+	}
+	core1, core2 := newCore("core1"), newCore("core2")
+
+	keys, root := TestCoreInit(t, core1)
+	unseal := func(core *Core) {
+		for _, key := range keys {
+			_, err := TestCoreUnseal(core, TestKeyCopy(key))
+			require.NoError(t, err)
+		}
+	}
+	unseal(core1)
+	TestWaitActive(t, core1)
+	unseal(core2)
+
+	ctx := namespace.RootContext(nil)
+	put := func(core *Core, key string) error {
+		return core.barrier.Put(ctx, &logical.StorageEntry{Key: key, Value: []byte("v")})
+	}
+	stepDown := func(core *Core) {
+		require.NoError(t, core.StepDown(ctx, &logical.Request{
+			Operation:   logical.UpdateOperation,
+			Path:        "sys/step-down",
+			ClientToken: root,
+		}))
+	}
+
+	require.NoError(t, put(core1, "limited/a"))
+	require.NoError(t, put(core1, "limited/b"))
+	require.ErrorContains(t, put(core1, "limited/c"), "max_entries")
+
+	// Free up room for an entry on the other node
+	stepDown(core1)
+	TestWaitActive(t, core2)
+	require.NoError(t, core2.barrier.Delete(ctx, "limited/a"))
+
+	// The first node counts the entries again once active
+	stepDown(core2)
+	TestWaitActive(t, core1)
+	require.NoError(t, put(core1, "limited/c"))
+	require.ErrorContains(t, put(core1, "limited/d"), "max_entries")
+}
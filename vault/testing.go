@@ -235,6 +235,11 @@ func TestCoreWithSealAndUINoCleanup(t testing.T, opts *CoreConfig) *Core {
 	conf.Experiments = opts.Experiments
 	conf.AdministrativeNamespacePath = opts.AdministrativeNamespacePath
 	conf.ImpreciseLeaseRoleTracking = opts.ImpreciseLeaseRoleTracking
+	conf.LoadShedding = opts.LoadShedding
+	conf.CryptoPolicy = opts.CryptoPolicy
+	conf.GenerateRoot = opts.GenerateRoot
+	conf.StorageLimits = opts.StorageLimits
+	conf.RequestLimits = opts.RequestLimits
 
 	if opts.Logger != nil {
 		conf.Logger = opts.Logger
@@ -649,6 +654,17 @@ func GenerateRandBytes(length int) ([]byte, error) {
 	return buf, nil
 }
 
+// TestCoreRollback triggers an immediate rollback of the mount at path, which
+// runs the periodic function of its backend.
+func TestCoreRollback(t testing.T, core *Core, path string) {
+	t.Helper()
+	core.stateLock.RLock()
+	defer core.stateLock.RUnlock()
+	if err := core.rollback.Rollback(namespace.RootContext(nil), path); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestWaitActive(t testing.T, core *Core) {
 	t.Helper()
 	if err := TestWaitActiveWithError(core); err != nil {
@@ -1501,6 +1517,10 @@ func NewTestCluster(t testing.T, base *CoreConfig, opts *TestClusterOptions) *Te
 		}
 
 		coreConfig.ClusterCipherSuites = base.ClusterCipherSuites
+		coreConfig.CryptoPolicy = base.CryptoPolicy
+		coreConfig.GenerateRoot = base.GenerateRoot
+		coreConfig.StorageLimits = base.StorageLimits
+		coreConfig.RequestLimits = base.RequestLimits
 		coreConfig.DisableCache = base.DisableCache
 		coreConfig.DevToken = base.DevToken
 		coreConfig.RecoveryMode = base.RecoveryMode
@@ -1512,6 +1532,8 @@ func NewTestCluster(t testing.T, base *CoreConfig, opts *TestClusterOptions) *Te
 		coreConfig.ExpirationRevokeRetryBase = base.ExpirationRevokeRetryBase
 		coreConfig.PeriodicLeaderRefreshInterval = base.PeriodicLeaderRefreshInterval
 		coreConfig.ClusterAddrBridge = base.ClusterAddrBridge
+		coreConfig.LoadShedding = base.LoadShedding
+		coreConfig.Experiments = base.Experiments
 
 		if base.LimiterRegistry != nil {
 			coreConfig.LimiterRegistry = base.LimiterRegistry
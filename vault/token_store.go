@@ -11,18 +11,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
 	"regexp"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/armon/go-metrics"
 	"github.com/armon/go-radix"
 	"github.com/golang/protobuf/proto"
 	log "github.com/hashicorp/go-hclog"
-	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/go-secure-stdlib/base62"
 	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/hashicorp/go-secure-stdlib/strutil"
@@ -38,6 +35,7 @@ import (
 	"github.com/hashicorp/vault/sdk/helper/policyutil"
 	"github.com/hashicorp/vault/sdk/helper/salt"
 	"github.com/hashicorp/vault/sdk/helper/tokenutil"
+	"github.com/hashicorp/vault/sdk/helper/wrapping"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/plugin/pb"
 	"github.com/hashicorp/vault/vault/tokens"
@@ -94,6 +92,18 @@ const (
 	// IgnoreForBilling used for HCP Link batch tokens and inserted into the InternalMeta
 	// Tokens created for the purpose of HCP Link should bypass counting for billing purposes
 	IgnoreForBilling = "ignore_for_billing"
+
+	// maxCreateBatchCount is the maximum number of batch tokens that can be
+	// created against a role in a single request
+	maxCreateBatchCount = 1000
+
+	// defaultCreateBatchWrapTTL is the TTL of the wrapping tokens of batch
+	// tokens created against a role if none is given
+	defaultCreateBatchWrapTTL = 5 * time.Minute
+
+	// tokenDelegationChainMeta is the internal metadata key holding the
+	// comma-separated accessors of the tokens an exchanged token derives from
+	tokenDelegationChainMeta = "delegation_chain"
 )
 
 var (
@@ -208,6 +218,25 @@ func (ts *TokenStore) paths() []*framework.Path {
 		fieldsForCreateWithRole[k] = v
 	}
 
+	fieldsForCreateBatchWithRole := map[string]*framework.FieldSchema{
+		"count": {
+			Type:        framework.TypeInt,
+			Description: "Number of batch tokens to create. Defaults to the number of entries in token_meta.",
+		},
+		"token_meta": {
+			Type:        framework.TypeSlice,
+			Description: "List of metadata maps, one per token, merged over the metadata given in meta",
+		},
+		"wrap_ttl": {
+			Type:        framework.TypeDurationSecond,
+			Default:     int(defaultCreateBatchWrapTTL.Seconds()),
+			Description: "TTL of the wrapping token of each created token",
+		},
+	}
+	for k, v := range fieldsForCreateWithRole {
+		fieldsForCreateBatchWithRole[k] = v
+	}
+
 	const operationPrefixToken = "token"
 
 	p := []*framework.Path{
@@ -281,6 +310,64 @@ func (ts *TokenStore) paths() []*framework.Path {
 			HelpDescription: strings.TrimSpace(tokenCreateRoleHelp),
 		},
 
+		{
+			Pattern: "create-batch/" + framework.GenericNameRegex("role_name"),
+
+			Fields: fieldsForCreateBatchWithRole,
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixToken,
+				OperationVerb:   "create",
+				OperationSuffix: "batch-against-role",
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: ts.handleCreateBatchAgainstRole,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(tokenCreateBatchRoleHelp),
+			HelpDescription: strings.TrimSpace(tokenCreateBatchRoleDesc),
+		},
+
+		{
+			Pattern: "exchange$",
+
+			Fields: map[string]*framework.FieldSchema{
+				"policies": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Policies of the new token. Must be a subset of the policies of the calling token. Defaults to all of them.",
+				},
+				"ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "TTL of the new token. Defaults to, and is capped at, the remaining TTL of the calling token.",
+				},
+				"audience": {
+					Type:        framework.TypeString,
+					Description: "Intended audience of the new token, recorded in its metadata",
+				},
+				"meta": {
+					Type:        framework.TypeKVPairs,
+					Description: "Arbitrary key=value metadata to associate with the new token",
+				},
+				"num_uses": {
+					Type:        framework.TypeInt,
+					Description: "Max number of uses for the new token",
+				},
+			},
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixToken,
+				OperationVerb:   "exchange",
+			},
+
+			Callbacks: map[logical.Operation]framework.OperationFunc{
+				logical.UpdateOperation: ts.handleExchange,
+			},
+
+			HelpSynopsis:    strings.TrimSpace(tokenExchangeHelp),
+			HelpDescription: strings.TrimSpace(tokenExchangeDesc),
+		},
+
 		{
 			Pattern: "create$",
 
@@ -664,7 +751,7 @@ func (ts *TokenStore) paths() []*framework.Path {
 		ExistenceCheck: ts.tokenStoreRoleExistenceCheck,
 	}
 
-	tokenutil.AddTokenFieldsWithAllowList(rolesPath.Fields, []string{"token_bound_cidrs", "token_explicit_max_ttl", "token_period", "token_type", "token_no_default_policy", "token_num_uses"})
+	tokenutil.AddTokenFieldsWithAllowList(rolesPath.Fields, []string{"token_bound_cidrs", "token_access_windows", "token_access_window_timezone", "token_explicit_max_ttl", "token_period", "token_type", "token_no_default_policy", "token_num_uses"})
 	p = append(p, rolesPath)
 
 	return p
@@ -702,6 +789,16 @@ func (c *Core) CreateToken(ctx context.Context, entry *logical.TokenEntry) error
 	return c.tokenStore.create(ctx, entry)
 }
 
+// RevokeEntityTokens revokes the tokens issued to the given entity, along with
+// the leases created with them, and returns the number of tokens revoked.
+func (c *Core) RevokeEntityTokens(ctx context.Context, entityID string) (int, error) {
+	if c.expiration == nil {
+		return 0, errors.New("unable to revoke tokens with nil expiration manager")
+	}
+
+	return c.expiration.revokeByEntityID(ctx, entityID)
+}
+
 // TokenStore is used to manage client tokens. Tokens are used for
 // clients to authenticate, and each token is mapped to an applicable
 // set of policy which is used for authorization.
@@ -719,6 +816,7 @@ type TokenStore struct {
 	accessorBarrierView *BarrierView
 	parentBarrierView   *BarrierView
 	rolesBarrierView    *BarrierView
+	usageBarrierView    *BarrierView
 
 	expiration *ExpirationManager
 
@@ -741,6 +839,12 @@ type TokenStore struct {
 
 	tidyLock *uint32
 
+	// tidyConfigLock serializes updates of the tidy schedule
+	tidyConfigLock sync.Mutex
+
+	// usage accumulates the usage of tokens until it is written to storage
+	usage *tokenUsageTracker
+
 	identityPoliciesDeriverFunc func(string) (*identity.Entity, []string, error)
 
 	quitContext context.Context
@@ -768,12 +872,14 @@ func NewTokenStore(ctx context.Context, logger log.Logger, core *Core, config *l
 		accessorBarrierView:   view.SubView(accessorPrefix),
 		parentBarrierView:     view.SubView(parentPrefix),
 		rolesBarrierView:      view.SubView(rolesPrefix),
+		usageBarrierView:      view.SubView(usagePrefix),
 		cubbyholeDestroyer:    destroyCubbyhole,
 		logger:                logger,
 		tokenLocks:            locksutil.CreateLocks(),
 		tokensPendingDeletion: &sync.Map{},
 		saltLock:              sync.RWMutex{},
 		tidyLock:              new(uint32),
+		usage:                 newTokenUsageTracker(),
 		quitContext:           core.activeContext,
 		salts:                 make(map[string]*salt.Salt),
 	}
@@ -794,13 +900,16 @@ func NewTokenStore(ctx context.Context, logger log.Logger, core *Core, config *l
 				idPrefix,
 				accessorPrefix,
 				parentPrefix,
+				usagePrefix,
 				salt.DefaultLocation,
+				tokenTidyStatusKey,
 			},
 		},
 		BackendType: logical.TypeCredential,
 	}
 
 	t.Backend.Paths = append(t.Backend.Paths, t.paths()...)
+	t.Backend.Paths = append(t.Backend.Paths, t.tidyPaths()...)
 
 	t.Backend.Setup(ctx, config)
 
@@ -856,6 +965,9 @@ type tsRoleEntry struct {
 	// The name of the role. Embedded so it can be used for pathing
 	Name string `json:"name" mapstructure:"name" structs:"name"`
 
+	// builtin is set on the built-in roles, which are not stored
+	builtin bool
+
 	// The policies that creation functions using this role can assign to a token,
 	// escaping or further locking down normal subset checking
 	AllowedPolicies []string `json:"allowed_policies" mapstructure:"allowed_policies" structs:"allowed_policies"`
@@ -948,6 +1060,68 @@ func (ts *TokenStore) rootToken(ctx context.Context) (*logical.TokenEntry, error
 	return te, nil
 }
 
+// generatedRootToken creates a root token for a completed root generation,
+// restricted by conf if it is set. The justification of the root generation,
+// if any, is stored in the token metadata so it is recorded in the audit log
+// of every request made with the token.
+func (ts *TokenStore) generatedRootToken(ctx context.Context, conf *GenerateRootTokenConfig, justification string) (*logical.TokenEntry, error) {
+	if conf == nil {
+		conf = new(GenerateRootTokenConfig)
+	}
+	if conf.TokenType == logical.TokenTypeBatch && conf.TTL == 0 {
+		return nil, errors.New("generated batch root tokens require a TTL")
+	}
+
+	// The number of uses is not limited: batch tokens do not support use
+	// limits, and break-glass access takes several requests, the first of
+	// which is usually the lookup made when logging in. The generated tokens
+	// are restricted by their TTL and bound CIDRs instead.
+	ctx = namespace.ContextWithNamespace(ctx, namespace.RootNamespace)
+	te := &logical.TokenEntry{
+		Policies:       []string{"root"},
+		Path:           "auth/token/root",
+		DisplayName:    "root",
+		CreationTime:   time.Now().Unix(),
+		NamespaceID:    namespace.RootNamespaceID,
+		Type:           logical.TokenTypeService,
+		TTL:            conf.TTL,
+		ExplicitMaxTTL: conf.TTL,
+	}
+	if conf.TokenType == logical.TokenTypeBatch {
+		te.Type = logical.TokenTypeBatch
+	}
+	if conf.TTL != 0 {
+		te.BoundCIDRs = conf.BoundCIDRs
+	}
+	if justification != "" {
+		te.Meta = map[string]string{
+			"justification": justification,
+		}
+	}
+	if err := ts.create(ctx, te); err != nil {
+		return nil, err
+	}
+
+	// Service tokens need a lease to expire
+	if te.TTL != 0 && te.Type == logical.TokenTypeService {
+		auth := &logical.Auth{
+			ClientToken: te.ID,
+			Accessor:    te.Accessor,
+			Policies:    te.Policies,
+			LeaseOptions: logical.LeaseOptions{
+				TTL:       te.TTL,
+				Renewable: false,
+				IssueTime: time.Unix(te.CreationTime, 0),
+			},
+		}
+		if err := ts.expiration.RegisterAuth(ctx, te, auth, ""); err != nil {
+			ts.revokeOrphan(ctx, te.ID)
+			return nil, fmt.Errorf("failed to register root token lease: %w", err)
+		}
+	}
+	return te, nil
+}
+
 func (ts *TokenStore) tokenStoreAccessorList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {
@@ -1163,6 +1337,9 @@ func (ts *TokenStore) create(ctx context.Context, entry *logical.TokenEntry) err
 			InternalMeta:       entry.InternalMeta,
 			InlinePolicy:       entry.InlinePolicy,
 			NoIdentityPolicies: entry.NoIdentityPolicies,
+
+			AccessWindows:        entry.AccessWindows,
+			AccessWindowTimezone: entry.AccessWindowTimezone,
 		}
 
 		boundCIDRs := make([]string, len(entry.BoundCIDRs))
@@ -1480,6 +1657,25 @@ func (ts *TokenStore) stripBatchPrefix(id string) string {
 	return ""
 }
 
+// setPath updates the path the token was created at, as done when the leases
+// of its auth mount are moved to a new path.
+func (ts *TokenStore) setPath(ctx context.Context, id, path string) error {
+	lock := locksutil.LockForKey(ts.tokenLocks, id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	te, err := ts.lookupInternal(ctx, id, false, true)
+	if err != nil {
+		return err
+	}
+	if te == nil {
+		return nil
+	}
+
+	te.Path = path
+	return ts.store(ctx, te)
+}
+
 // lookupTainted is used to find a token that may or may not be tainted given
 // its ID. It acquires a read lock, then calls lookupInternal.
 func (ts *TokenStore) lookupTainted(ctx context.Context, id string) (*logical.TokenEntry, error) {
@@ -1826,6 +2022,9 @@ func (ts *TokenStore) revokeInternal(ctx context.Context, saltedID string, skipO
 		if ret == nil {
 			if err := ts.idView(tokenNS).Delete(ctx, saltedID); err != nil {
 				ret = fmt.Errorf("failed to delete entry: %w", err)
+			} else {
+				ts.core.sendLifecycleEvent(tokenNS, tokenEventTypeRevoke, entry.Path, "revoke",
+					lifecycleEventMetadataAccessor, entry.Accessor)
 			}
 		}
 
@@ -1846,6 +2045,13 @@ func (ts *TokenStore) revokeInternal(ctx context.Context, saltedID string, skipO
 		return err
 	}
 
+	// Delete the parked request of a control group token
+	if len(entry.Policies) == 1 && entry.Policies[0] == controlGroupPolicyName {
+		if err := ts.core.deleteControlGroupRequest(ctx, entry.Accessor); err != nil {
+			return err
+		}
+	}
+
 	revokeCtx := namespace.ContextWithNamespace(ts.quitContext, tokenNS)
 	if err := ts.expiration.RevokeByToken(revokeCtx, entry); err != nil {
 		return err
@@ -1899,6 +2105,10 @@ func (ts *TokenStore) revokeInternal(ctx context.Context, saltedID string, skipO
 		if err = ts.accessorView(tokenNS).Delete(ctx, accessorSaltedID); err != nil {
 			return fmt.Errorf("failed to delete entry: %w", err)
 		}
+
+		if err = ts.deleteUsage(ctx, tokenNS, entry.Accessor, accessorSaltedID); err != nil {
+			return err
+		}
 	}
 
 	if !skipOrphan {
@@ -2095,10 +2305,296 @@ func (ts *TokenStore) handleCreateAgainstRole(ctx context.Context, req *logical.
 	if roleEntry == nil {
 		return logical.ErrorResponse(fmt.Sprintf("unknown role %s", name)), nil
 	}
+	if roleEntry.builtin {
+		// The policy of the built-in monitoring role is created when first
+		// needed, and may be modified afterwards
+		if err := ts.core.ensureMonitoringPolicy(ctx); err != nil {
+			return nil, err
+		}
+	}
 
 	return ts.handleCreateCommon(ctx, req, d, false, roleEntry)
 }
 
+// handleCreateBatchAgainstRole handles the auth/token/create-batch path,
+// creating many batch tokens for a role and wrapping each of them
+func (ts *TokenStore) handleCreateBatchAgainstRole(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	name := d.Get("role_name").(string)
+	roleEntry, err := ts.tokenStoreRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if roleEntry == nil {
+		return logical.ErrorResponse(fmt.Sprintf("unknown role %s", name)), nil
+	}
+	if roleEntry.builtin {
+		// The policy of the built-in monitoring role is created when first
+		// needed, and may be modified afterwards
+		if err := ts.core.ensureMonitoringPolicy(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if roleEntry.TokenType == logical.TokenTypeService {
+		return logical.ErrorResponse(fmt.Sprintf("role %s only allows service tokens", name)), logical.ErrInvalidRequest
+	}
+	if tokenType := d.Get("type").(string); tokenType != "" && tokenType != logical.TokenTypeBatch.String() {
+		return logical.ErrorResponse(`"type" must be "batch" if set`), logical.ErrInvalidRequest
+	}
+
+	wrapTTL := time.Duration(d.Get("wrap_ttl").(int)) * time.Second
+	if wrapTTL <= 0 {
+		return logical.ErrorResponse(`"wrap_ttl" must be positive`), logical.ErrInvalidRequest
+	}
+
+	meta := d.Get("meta").(map[string]string)
+	tokenMeta := d.Get("token_meta").([]interface{})
+	count := d.Get("count").(int)
+	switch {
+	case count == 0:
+		count = len(tokenMeta)
+	case len(tokenMeta) > 0 && count != len(tokenMeta):
+		return logical.ErrorResponse(`"count" must match the number of entries in "token_meta"`), logical.ErrInvalidRequest
+	}
+	if count <= 0 || count > maxCreateBatchCount {
+		return logical.ErrorResponse(fmt.Sprintf(`"count" must be between 1 and %d`, maxCreateBatchCount)), logical.ErrInvalidRequest
+	}
+
+	// Build the metadata of every token upfront so that invalid input fails
+	// the request before any token is created
+	metas := make([]map[string]string, count)
+	for i := range metas {
+		metas[i] = make(map[string]string, len(meta))
+		for k, v := range meta {
+			metas[i][k] = v
+		}
+		if len(tokenMeta) == 0 {
+			continue
+		}
+		entry, ok := tokenMeta[i].(map[string]interface{})
+		if !ok {
+			return logical.ErrorResponse(fmt.Sprintf(`"token_meta" entry %d is not a map`, i)), logical.ErrInvalidRequest
+		}
+		for k, v := range entry {
+			value, ok := v.(string)
+			if !ok {
+				return logical.ErrorResponse(fmt.Sprintf(`"token_meta" entry %d: value of %q is not a string`, i, k)), logical.ErrInvalidRequest
+			}
+			metas[i][k] = value
+		}
+	}
+
+	// Wrap the tokens as if the request itself asked for wrapping, which
+	// records the full request path as the creation path
+	wrapReq := &logical.Request{
+		Operation:  req.Operation,
+		Path:       req.MountPoint + req.Path,
+		MountPoint: req.MountPoint,
+		MountType:  req.MountType,
+	}
+
+	resp := &logical.Response{}
+	tokens := make([]map[string]interface{}, 0, count)
+	for i, tokenMeta := range metas {
+		raw := make(map[string]interface{}, len(d.Raw))
+		for k, v := range d.Raw {
+			raw[k] = v
+		}
+		raw["type"] = logical.TokenTypeBatch.String()
+		raw["meta"] = tokenMeta
+
+		tokenResp, err := ts.handleCreateCommon(ctx, req, &framework.FieldData{Raw: raw, Schema: d.Schema}, false, roleEntry)
+		if err != nil || tokenResp.IsError() {
+			return tokenResp, err
+		}
+		if i == 0 {
+			resp.Warnings = tokenResp.Warnings
+		}
+
+		// Wrapping tokens created before a failure are not revoked, they
+		// expire after wrap_ttl
+		tokenResp.Auth.TokenPolicies = policyutil.SanitizePolicies(tokenResp.Auth.Policies, policyutil.DoNotAddDefaultPolicy)
+		tokenResp.WrapInfo = &wrapping.ResponseWrapInfo{
+			TTL: wrapTTL,
+		}
+		if wrapResp, err := ts.core.wrapInCubbyhole(ctx, wrapReq, tokenResp, tokenResp.Auth); err != nil || wrapResp != nil {
+			return wrapResp, err
+		}
+
+		tokens = append(tokens, map[string]interface{}{
+			"token":         tokenResp.WrapInfo.Token,
+			"accessor":      tokenResp.WrapInfo.Accessor,
+			"ttl":           int64(tokenResp.WrapInfo.TTL.Seconds()),
+			"creation_time": tokenResp.WrapInfo.CreationTime.Format(time.RFC3339Nano),
+			"creation_path": tokenResp.WrapInfo.CreationPath,
+			"metadata":      tokenMeta,
+		})
+	}
+
+	resp.Data = map[string]interface{}{
+		"tokens": tokens,
+	}
+	return resp, nil
+}
+
+// handleExchange handles the auth/token/exchange path, exchanging the calling
+// token for a narrower child token that records the delegation chain
+func (ts *TokenStore) handleExchange(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+	parent, err := ts.Lookup(ctx, req.ClientToken)
+	if err != nil {
+		return nil, fmt.Errorf("parent token lookup failed: %w", err)
+	}
+	if parent == nil {
+		return logical.ErrorResponse("parent token lookup failed: no parent found"), logical.ErrInvalidRequest
+	}
+	if parent.Type == logical.TokenTypeBatch {
+		return logical.ErrorResponse("batch tokens cannot be exchanged"), logical.ErrInvalidRequest
+	}
+	// As with token creation, a token with a restricted number of uses could
+	// otherwise escape the restriction count
+	if parent.NumUses > 0 {
+		return logical.ErrorResponse("restricted use tokens cannot be exchanged"), logical.ErrInvalidRequest
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ns.ID != parent.NamespaceID {
+		return logical.ErrorResponse("tokens can only be exchanged in their own namespace"), logical.ErrInvalidRequest
+	}
+
+	numUses := d.Get("num_uses").(int)
+	if numUses < 0 {
+		return logical.ErrorResponse("number of uses cannot be negative"), logical.ErrInvalidRequest
+	}
+
+	// The new token may keep any of the token and identity policies of the
+	// calling token. It does not inherit identity policies itself, so that
+	// it can be narrowed down to a subset of them.
+	availablePolicies := parent.Policies
+	_, identityPolicies, err := ts.core.fetchEntityAndDerivedPolicies(ctx, ns, parent.EntityID, parent.NoIdentityPolicies)
+	if err != nil {
+		return nil, err
+	}
+	availablePolicies = policyutil.SanitizePolicies(append(availablePolicies, identityPolicies[ns.ID]...), policyutil.DoNotAddDefaultPolicy)
+
+	policies := policyutil.SanitizePolicies(d.Get("policies").([]string), policyutil.DoNotAddDefaultPolicy)
+	switch {
+	case len(policies) == 0 && strutil.StrListContains(availablePolicies, "root"):
+		return logical.ErrorResponse("policies must be given when exchanging a root token"), logical.ErrInvalidRequest
+	case len(policies) == 0:
+		policies = availablePolicies
+	case strutil.StrListContains(policies, "root"):
+		return logical.ErrorResponse("root tokens cannot be obtained by exchange"), logical.ErrInvalidRequest
+	case !strutil.StrListSubset(availablePolicies, policies):
+		return logical.ErrorResponse("policies must be a subset of the calling token's policies"), logical.ErrInvalidRequest
+	}
+	for _, policy := range policies {
+		if strutil.StrListContains(nonAssignablePolicies, policy) {
+			return logical.ErrorResponse(fmt.Sprintf("cannot assign policy %q", policy)), logical.ErrInvalidRequest
+		}
+	}
+
+	meta := d.Get("meta").(map[string]string)
+	if audience := d.Get("audience").(string); audience != "" {
+		meta["audience"] = audience
+	}
+
+	chain := parent.Accessor
+	if parentChain := parent.InternalMeta[tokenDelegationChainMeta]; parentChain != "" {
+		chain = parentChain + "," + chain
+	}
+
+	te := logical.TokenEntry{
+		Parent: req.ClientToken,
+		Path:   "auth/token/" + req.Path,
+		Meta:   meta,
+		InternalMeta: map[string]string{
+			tokenDelegationChainMeta: chain,
+		},
+		Policies:             policies,
+		DisplayName:          parent.DisplayName,
+		NumUses:              numUses,
+		CreationTime:         time.Now().Unix(),
+		NamespaceID:          ns.ID,
+		Type:                 logical.TokenTypeService,
+		EntityID:             parent.EntityID,
+		NoIdentityPolicies:   true,
+		BoundCIDRs:           parent.BoundCIDRs,
+		AccessWindows:        parent.AccessWindows,
+		AccessWindowTimezone: parent.AccessWindowTimezone,
+	}
+
+	resp := &logical.Response{}
+
+	// The new token cannot outlive the calling token
+	leaseTimes, err := ts.expiration.FetchLeaseTimesByToken(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	var remaining time.Duration
+	if leaseTimes != nil && !leaseTimes.ExpireTime.IsZero() {
+		remaining = time.Until(leaseTimes.ExpireTime)
+		if remaining <= 0 {
+			return logical.ErrorResponse("calling token has expired"), logical.ErrInvalidRequest
+		}
+	}
+
+	ttl := time.Duration(d.Get("ttl").(int)) * time.Second
+	if ttl < 0 {
+		return logical.ErrorResponse("ttl must be positive"), logical.ErrInvalidRequest
+	}
+	if ttl == 0 {
+		ttl = remaining
+	}
+	ttl, warnings, err := framework.CalculateTTL(ts.System(), 0, ttl, 0, 0, 0, time.Unix(te.CreationTime, 0))
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range warnings {
+		resp.AddWarning(warning)
+	}
+	if remaining > 0 && ttl > remaining {
+		ttl = remaining
+		resp.AddWarning(fmt.Sprintf("TTL is capped at the remaining TTL of the calling token of %d seconds", int64(remaining.Seconds())))
+	}
+	te.TTL = ttl
+
+	if ts.core.perfStandby {
+		forwardedTokenEntry, err := forwardCreateTokenRegisterAuth(ctx, ts.core, &te, "", false, 0, 0)
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), ErrInternalError
+		}
+		te = *forwardedTokenEntry
+	} else {
+		if err := ts.create(ctx, &te); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+	}
+
+	// Exchanged tokens are not renewable, their lifetime only shrinks along
+	// the delegation chain
+	resp.Auth = &logical.Auth{
+		NumUses:     te.NumUses,
+		DisplayName: te.DisplayName,
+		Policies:    te.Policies,
+		Metadata:    te.Meta,
+		LeaseOptions: logical.LeaseOptions{
+			TTL: te.TTL,
+		},
+		ClientToken:  te.ID,
+		Accessor:     te.Accessor,
+		EntityID:     te.EntityID,
+		CreationPath: te.Path,
+		TokenType:    te.Type,
+	}
+	if ts.core.perfStandby && te.ExternalID != "" {
+		resp.Auth.ClientToken = te.ExternalID
+	}
+
+	return resp, nil
+}
+
 func (ts *TokenStore) lookupByAccessor(ctx context.Context, id string, salted, tainted bool) (*accessorEntry, error) {
 	var aEntry accessorEntry
 
@@ -2169,285 +2665,6 @@ func (ts *TokenStore) lookupByAccessor(ctx context.Context, id string, salted, t
 	return &aEntry, nil
 }
 
-// handleTidy handles the cleaning up of leaked accessor storage entries and
-// cleaning up of leases that are associated to tokens that are expired.
-func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
-	if !atomic.CompareAndSwapUint32(ts.tidyLock, 0, 1) {
-		resp := &logical.Response{}
-		resp.AddWarning("Tidy operation already in progress.")
-		return resp, nil
-	}
-
-	ns, err := namespace.FromContext(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get namespace from context: %w", err)
-	}
-
-	go func() {
-		defer atomic.StoreUint32(ts.tidyLock, 0)
-
-		logger := ts.logger.Named("tidy")
-
-		var tidyErrors *multierror.Error
-
-		doTidy := func() error {
-			ts.logger.Info("beginning tidy operation on tokens")
-			defer ts.logger.Info("finished tidy operation on tokens")
-
-			quitCtx := namespace.ContextWithNamespace(ts.quitContext, ns)
-
-			// List out all the accessors
-			saltedAccessorList, err := ts.accessorView(ns).List(quitCtx, "")
-			if err != nil {
-				return fmt.Errorf("failed to fetch accessor index entries: %w", err)
-			}
-
-			// First, clean up secondary index entries that are no longer valid
-			parentList, err := ts.parentView(ns).List(quitCtx, "")
-			if err != nil {
-				return fmt.Errorf("failed to fetch secondary index entries: %w", err)
-			}
-
-			// List all the cubbyhole storage keys
-			view := ts.core.router.MatchingStorageByAPIPath(ctx, mountPathCubbyhole)
-			if view == nil {
-				return fmt.Errorf("no cubby mount entry")
-			}
-			bview := view.(*BarrierView)
-
-			cubbyholeKeys, err := bview.List(quitCtx, "")
-			if err != nil {
-				return fmt.Errorf("failed to fetch cubbyhole storage keys: %w", err)
-			}
-
-			var countParentEntries, deletedCountParentEntries, countParentList, deletedCountParentList int64
-
-			// Scan through the secondary index entries; if there is an entry
-			// with the token's salt ID at the end, remove it
-			for _, parent := range parentList {
-				countParentEntries++
-
-				// Get the children
-				children, err := ts.parentView(ns).List(quitCtx, parent)
-				if err != nil {
-					tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to read secondary index: %w", err))
-					continue
-				}
-
-				// First check if the salt ID of the parent exists, and if not mark this so
-				// that deletion of children later with this loop below applies to all
-				// children
-				originalChildrenCount := int64(len(children))
-				exists, _ := ts.lookupInternal(quitCtx, strings.TrimSuffix(parent, "/"), true, true)
-				if exists == nil {
-					ts.logger.Debug("deleting invalid parent prefix entry", "index", parentPrefix+parent)
-				}
-
-				var deletedChildrenCount int64
-				for index, child := range children {
-					countParentList++
-					if countParentList%500 == 0 {
-						percentComplete := float64(index) / float64(len(children)) * 100
-						ts.logger.Info("checking validity of tokens in secondary index list", "progress", countParentList, "percent_complete", percentComplete)
-					}
-
-					// Look up tainted entries so we can be sure that if this isn't
-					// found, it doesn't exist. Doing the following without locking
-					// since appropriate locks cannot be held with salted token IDs.
-					// Also perform deletion if the parent doesn't exist any more.
-					te, _ := ts.lookupInternal(quitCtx, child, true, true)
-					// If the child entry is not nil, but the parent doesn't exist, then turn
-					// that child token into an orphan token. Theres no deletion in this case.
-					if te != nil && exists == nil {
-						lock := locksutil.LockForKey(ts.tokenLocks, te.ID)
-						lock.Lock()
-
-						te.Parent = ""
-						err = ts.store(quitCtx, te)
-						if err != nil {
-							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to convert child token into an orphan token: %w", err))
-						}
-						lock.Unlock()
-						continue
-					}
-					// Otherwise, if the entry doesn't exist, or if the parent doesn't exist go
-					// on with the delete on the secondary index
-					if te == nil || exists == nil {
-						index := parent + child
-						ts.logger.Debug("deleting invalid secondary index", "index", index)
-						err = ts.parentView(ns).Delete(quitCtx, index)
-						if err != nil {
-							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to delete secondary index: %w", err))
-							continue
-						}
-						deletedChildrenCount++
-					}
-				}
-				// Add current children deleted count to the total count
-				deletedCountParentList += deletedChildrenCount
-				// N.B.: We don't call delete on the parent prefix since physical.Backend.Delete
-				// implementations should be in charge of deleting empty prefixes.
-				// If we deleted all the children, then add that to our deleted parent entries count.
-				if originalChildrenCount == deletedChildrenCount {
-					deletedCountParentEntries++
-				}
-			}
-
-			var countAccessorList,
-				countCubbyholeKeys,
-				deletedCountAccessorEmptyToken,
-				deletedCountAccessorInvalidToken,
-				deletedCountInvalidTokenInAccessor,
-				deletedCountInvalidCubbyholeKey int64
-
-			validCubbyholeKeys := make(map[string]bool)
-
-			// For each of the accessor, see if the token ID associated with it is
-			// a valid one. If not, delete the leases associated with that token
-			// and delete the accessor as well.
-			for index, saltedAccessor := range saltedAccessorList {
-				countAccessorList++
-				if countAccessorList%500 == 0 {
-					percentComplete := float64(index) / float64(len(saltedAccessorList)) * 100
-					ts.logger.Info("checking if accessors contain valid tokens", "progress", countAccessorList, "percent_complete", percentComplete)
-				}
-
-				accessorEntry, err := ts.lookupByAccessor(quitCtx, saltedAccessor, true, true)
-				if err != nil {
-					tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to read the accessor index: %w", err))
-					continue
-				}
-				if accessorEntry == nil {
-					tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to read the accessor index: invalid accessor"))
-					continue
-				}
-
-				// A valid accessor storage entry should always have a token ID
-				// in it. If not, it is an invalid accessor entry and needs to
-				// be deleted.
-				if accessorEntry.TokenID == "" {
-					// If deletion of accessor fails, move on to the next
-					// item since this is just a best-effort operation
-					err = ts.accessorView(ns).Delete(quitCtx, saltedAccessor)
-					if err != nil {
-						tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to delete the accessor index: %w", err))
-						continue
-					}
-					deletedCountAccessorEmptyToken++
-				}
-
-				lock := locksutil.LockForKey(ts.tokenLocks, accessorEntry.TokenID)
-				lock.RLock()
-
-				// Look up tainted variants so we only find entries that truly don't
-				// exist
-				te, err := ts.lookupInternal(quitCtx, accessorEntry.TokenID, false, true)
-				if err != nil {
-					tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to lookup tainted ID: %w", err))
-					lock.RUnlock()
-					continue
-				}
-
-				lock.RUnlock()
-
-				switch {
-				case te == nil:
-					// If token entry is not found assume that the token is not valid any
-					// more and conclude that accessor, leases, and secondary index entries
-					// for this token should not exist as well.
-
-					ts.logger.Info("deleting token with nil entry referenced by accessor", "salted_accessor", saltedAccessor)
-
-					// RevokeByToken expects a '*logical.TokenEntry'. For the
-					// purposes of tidying, it is sufficient if the token
-					// entry only has ID set.
-					tokenEntry := &logical.TokenEntry{
-						ID:          accessorEntry.TokenID,
-						NamespaceID: accessorEntry.NamespaceID,
-					}
-
-					// Attempt to revoke the token. This will also revoke
-					// the leases associated with the token.
-					err = ts.expiration.RevokeByToken(quitCtx, tokenEntry)
-					if err != nil {
-						tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to revoke leases of expired token: %w", err))
-						continue
-					}
-					deletedCountInvalidTokenInAccessor++
-
-					// If deletion of accessor fails, move on to the next item since
-					// this is just a best-effort operation. We do this last so that on
-					// next run if something above failed we still have the accessor
-					// entry to try again.
-					err = ts.accessorView(ns).Delete(quitCtx, saltedAccessor)
-					if err != nil {
-						tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to delete accessor entry: %w", err))
-						continue
-					}
-					deletedCountAccessorInvalidToken++
-				default:
-					// Cache the cubbyhole storage key when the token is valid
-					switch {
-					case te.NamespaceID == namespace.RootNamespaceID && !IsServiceToken(te.ID):
-						saltedID, err := ts.SaltID(quitCtx, te.ID)
-						if err != nil {
-							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to create salted token id: %w", err))
-							continue
-						}
-						validCubbyholeKeys[salt.SaltID(ts.cubbyholeBackend.saltUUID, saltedID, salt.SHA1Hash)] = true
-					default:
-						if te.CubbyholeID == "" {
-							tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("missing cubbyhole ID for a valid token"))
-							continue
-						}
-						validCubbyholeKeys[te.CubbyholeID] = true
-					}
-				}
-			}
-
-			// Revoke invalid cubbyhole storage keys
-			for index, key := range cubbyholeKeys {
-				countCubbyholeKeys++
-				if countCubbyholeKeys%500 == 0 {
-					percentComplete := float64(index) / float64(len(cubbyholeKeys)) * 100
-					ts.logger.Info("checking if there are invalid cubbyholes", "progress", countCubbyholeKeys, "percent_complete", percentComplete)
-				}
-
-				key := strings.TrimSuffix(key, "/")
-				if !validCubbyholeKeys[key] {
-					ts.logger.Info("deleting invalid cubbyhole", "key", key)
-					err = ts.cubbyholeBackend.revoke(quitCtx, bview, key)
-					if err != nil {
-						tidyErrors = multierror.Append(tidyErrors, fmt.Errorf("failed to revoke cubbyhole key %q: %w", key, err))
-					}
-					deletedCountInvalidCubbyholeKey++
-				}
-			}
-
-			ts.logger.Info("number of entries scanned in parent prefix", "count", countParentEntries)
-			ts.logger.Info("number of entries deleted in parent prefix", "count", deletedCountParentEntries)
-			ts.logger.Info("number of tokens scanned in parent index list", "count", countParentList)
-			ts.logger.Info("number of tokens revoked in parent index list", "count", deletedCountParentList)
-			ts.logger.Info("number of accessors scanned", "count", countAccessorList)
-			ts.logger.Info("number of deleted accessors which had empty tokens", "count", deletedCountAccessorEmptyToken)
-			ts.logger.Info("number of revoked tokens which were invalid but present in accessors", "count", deletedCountInvalidTokenInAccessor)
-			ts.logger.Info("number of deleted accessors which had invalid tokens", "count", deletedCountAccessorInvalidToken)
-			ts.logger.Info("number of deleted cubbyhole keys that were invalid", "count", deletedCountInvalidCubbyholeKey)
-
-			return tidyErrors.ErrorOrNil()
-		}
-
-		if err := doTidy(); err != nil {
-			logger.Error("error running tidy", "error", err)
-			return
-		}
-	}()
-
-	resp := &logical.Response{}
-	resp.AddWarning("Tidy operation successfully started. Any information from the operation will be printed to Vault's server logs.")
-	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
-}
-
 // handleUpdateLookupAccessor handles the auth/token/lookup-accessor path for returning
 // the properties of the token associated with the accessor
 func (ts *TokenStore) handleUpdateLookupAccessor(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
@@ -3016,6 +3233,11 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 			te.BoundCIDRs = role.TokenBoundCIDRs
 		}
 
+		if len(role.TokenAccessWindows) > 0 {
+			te.AccessWindows = role.TokenAccessWindows
+			te.AccessWindowTimezone = role.TokenAccessWindowTimezone
+		}
+
 	case d.Get("no_parent").(bool):
 		// Only allow an orphan token if the client has sudo policy
 		if !isSudo {
@@ -3043,12 +3265,14 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 	case te.Parent != "":
 		te.EntityID = parent.EntityID
 
-		// If the parent has bound CIDRs, copy those into the child. We don't
-		// do this if role is not nil because then we always use the role's
-		// bound CIDRs; roles allow escalation of privilege in proper
+		// If the parent has bound CIDRs or access windows, copy those into
+		// the child. We don't do this if role is not nil because then we
+		// always use the role's; roles allow escalation of privilege in proper
 		// circumstances.
 		if role == nil {
 			te.BoundCIDRs = parent.BoundCIDRs
+			te.AccessWindows = parent.AccessWindows
+			te.AccessWindowTimezone = parent.AccessWindowTimezone
 		}
 	}
 
@@ -3156,13 +3380,16 @@ func (ts *TokenStore) handleCreateCommon(ctx context.Context, req *logical.Reque
 	}
 
 	// Don't advertise non-expiring root tokens as renewable, as attempts to
-	// renew them are denied. Don't CIDR-restrict these either.
+	// renew them are denied. Don't CIDR or access window restrict these
+	// either.
 	if te.TTL == 0 {
 		if parent.TTL != 0 {
 			return logical.ErrorResponse("expiring root tokens cannot create non-expiring root tokens"), logical.ErrInvalidRequest
 		}
 		renewable = false
 		te.BoundCIDRs = nil
+		te.AccessWindows = nil
+		te.AccessWindowTimezone = ""
 	}
 
 	if te.ID != "" {
@@ -3390,6 +3617,10 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 		resp.Data["role"] = out.Role
 	}
 
+	if chain := out.InternalMeta[tokenDelegationChainMeta]; chain != "" {
+		resp.Data["delegation_chain"] = strings.Split(chain, ",")
+	}
+
 	if out.Period != 0 {
 		resp.Data["period"] = int64(out.Period.Seconds())
 	}
@@ -3398,6 +3629,11 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 		resp.Data["bound_cidrs"] = out.BoundCIDRs
 	}
 
+	if len(out.AccessWindows) > 0 {
+		resp.Data["access_windows"] = out.AccessWindows
+		resp.Data["access_window_timezone"] = out.AccessWindowTimezone
+	}
+
 	tokenNS, err := NamespaceByID(ctx, out.NamespaceID, ts.core)
 	if err != nil {
 		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
@@ -3429,6 +3665,16 @@ func (ts *TokenStore) handleLookup(ctx context.Context, req *logical.Request, da
 		resp.Data["issue_time"] = leaseTimes.IssueTime
 	}
 
+	// Fetch the approximate usage
+	usage, err := ts.tokenUsage(ctx, tokenNS, out)
+	if err != nil {
+		return nil, err
+	}
+	if usage != nil {
+		resp.Data["last_used_time"] = usage.LastUsed.Unix()
+		resp.Data["request_count"] = usage.RequestCount
+	}
+
 	if out.EntityID != "" {
 		_, identityPolicies, err := ts.core.fetchEntityAndDerivedPolicies(ctx, tokenNS, out.EntityID, out.NoIdentityPolicies)
 		if err != nil {
@@ -3525,6 +3771,10 @@ func (ts *TokenStore) tokenStoreRole(ctx context.Context, name string) (*tsRoleE
 		return nil, err
 	}
 	if entry == nil {
+		// The built-in monitoring role is used unless it was overridden
+		if name == monitoringRoleName && ns.ID == namespace.RootNamespaceID {
+			return monitoringRole(), nil
+		}
 		return nil, nil
 	}
 
@@ -3618,6 +3868,10 @@ func (ts *TokenStore) tokenStoreRoleRead(ctx context.Context, req *logical.Reque
 	if len(role.TokenBoundCIDRs) > 0 {
 		resp.Data["token_bound_cidrs"] = role.TokenBoundCIDRs
 	}
+	if len(role.TokenAccessWindows) > 0 {
+		resp.Data["token_access_windows"] = role.TokenAccessWindows
+		resp.Data["token_access_window_timezone"] = role.TokenAccessWindowTimezone
+	}
 	if len(role.BoundCIDRs) > 0 {
 		resp.Data["bound_cidrs"] = role.BoundCIDRs
 	}
@@ -4196,6 +4450,21 @@ as revocation of tokens. The tokens are renewable if associated with a lease.`
 	tokenCreateHelp          = `The token create path is used to create new tokens.`
 	tokenCreateOrphanHelp    = `The token create path is used to create new orphan tokens.`
 	tokenCreateRoleHelp      = `This token create path is used to create new tokens adhering to the given role.`
+	tokenCreateBatchRoleHelp = `This token create path is used to create many batch tokens adhering to the given role.`
+	tokenCreateBatchRoleDesc = `
+This token create path is used to create many batch tokens adhering to the
+given role in a single request, e.g. to bootstrap a fleet of workers. Each
+token is response-wrapped individually, and can be given its own metadata
+through the token_meta list.
+`
+	tokenExchangeHelp = `This endpoint exchanges the calling token for a narrower token.`
+	tokenExchangeDesc = `
+This endpoint exchanges the calling token for a child token with a subset of
+its policies, a shorter TTL, and its own audience and metadata, so that a
+service can act on behalf of a caller without being handed the caller's token.
+The accessors of the tokens the new token was exchanged from are recorded as
+its delegation chain, returned when looking it up.
+`
 	tokenListRolesHelp       = `This endpoint lists configured roles.`
 	tokenLookupAccessorHelp  = `This endpoint will lookup a token associated with the given accessor and its properties. Response will not contain the token ID.`
 	tokenRenewAccessorHelp   = `This endpoint will renew a token associated with the given accessor and its properties. Response will not contain the token ID.`
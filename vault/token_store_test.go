@@ -30,10 +30,12 @@ import (
 	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
 	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/helper/tokenutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTokenStore_CreateOrphanResponse(t *testing.T) {
@@ -2460,6 +2462,13 @@ func testTokenStoreHandleRequestLookup(t *testing.T, batch, periodic bool) {
 	}
 	delete(resp.Data, "creation_time")
 
+	// The root token was used to set up the core
+	if resp.Data["request_count"].(uint64) == 0 {
+		t.Fatalf("request count was zero")
+	}
+	delete(resp.Data, "request_count")
+	delete(resp.Data, "last_used_time")
+
 	if !reflect.DeepEqual(resp.Data, exp) {
 		t.Fatalf("bad: expected:%#v\nactual:%#v", exp, resp.Data)
 	}
@@ -3981,6 +3990,222 @@ func TestTokenStore_RoleOrphan(t *testing.T) {
 	}
 }
 
+// TestTokenStore_RoleMonitoring verifies that the built-in monitoring role
+// mints read-only tokens for the observability endpoints, creating the
+// monitoring policy if it does not exist, unless a role of the same name
+// overrides it.
+func TestTokenStore_RoleMonitoring(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	// The policy is only generated on request until the role is used
+	req := logical.TestRequest(t, logical.ReadOperation, "sys/policies/templates/monitoring")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	require.Equal(t, monitoringPolicyName, resp.Data["name"])
+	require.Equal(t, monitoringPolicy(), resp.Data["policy"])
+
+	p, err := c.policyStore.GetPolicy(ctx, monitoringPolicyName, PolicyTypeACL)
+	require.NoError(t, err)
+	require.Nil(t, p)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create/"+monitoringRoleName)
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	require.Equal(t, []string{monitoringPolicyName}, resp.Auth.Policies)
+	require.Equal(t, monitoringRolePeriod, resp.Auth.Period)
+
+	out, err := c.tokenStore.Lookup(ctx, resp.Auth.ClientToken)
+	require.NoError(t, err)
+	require.Empty(t, out.Parent)
+
+	for path, expected := range map[string][]string{
+		"sys/metrics":                {"read"},
+		"sys/health":                 {"read"},
+		"sys/replication/dr/status":  {"read"},
+		"sys/rotation/jobs/some-job": {"read"},
+		"sys/mounts/secret":          {"deny"},
+		"sys/audit":                  {"deny"},
+		"secret/foo":                 {"deny"},
+		"auth/token/create/" + monitoringRoleName: {"deny"},
+	} {
+		capabilities, err := c.Capabilities(ctx, resp.Auth.ClientToken, path)
+		require.NoError(t, err)
+		require.Equal(t, expected, capabilities, path)
+	}
+
+	// The created policy may be modified, and is not overwritten when the
+	// role is used again
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/policies/acl/"+monitoringPolicyName)
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"policy": `path "sys/metrics" { capabilities = ["read"] }`,
+	}
+	resp, err = c.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create/"+monitoringRoleName)
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	capabilities, err := c.Capabilities(ctx, resp.Auth.ClientToken, "sys/health")
+	require.NoError(t, err)
+	require.Equal(t, []string{"deny"}, capabilities)
+
+	// A role of the same name overrides the built-in role
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/roles/"+monitoringRoleName)
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"allowed_policies": []string{monitoringPolicyName, "default"},
+	}
+	resp, err = c.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create/"+monitoringRoleName)
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	require.Equal(t, []string{"default", monitoringPolicyName}, resp.Auth.Policies)
+
+	// Deleting it restores the built-in role
+	req = logical.TestRequest(t, logical.DeleteOperation, "auth/token/roles/"+monitoringRoleName)
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "auth/token/roles/"+monitoringRoleName)
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+	require.Equal(t, []string{monitoringPolicyName}, resp.Data["allowed_policies"])
+	require.Equal(t, true, resp.Data["token_no_default_policy"])
+}
+
+// TestTokenStore_RoleAccessWindows verifies that tokens created from a role
+// with access windows, and their children, can only be used during the
+// windows.
+func TestTokenStore_RoleAccessWindows(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/policy/creator")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"policy": `path "auth/token/create" { capabilities = ["update"] }`,
+	}
+	resp, err := c.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err: %v\nresp: %#v", err, resp)
+	}
+
+	// A window two days from now never contains the current time
+	later := time.Now().UTC().AddDate(0, 0, 2).Weekday().String()[:3]
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "roles/test")
+	req.ClientToken = root
+	req.Data = map[string]interface{}{
+		"token_access_windows": "Mon-Fri 09:00-17:00,funday 09:00-17:00",
+	}
+	resp, err = ts.HandleRequest(ctx, req)
+	if err != nil || resp == nil || !resp.IsError() {
+		t.Fatalf("expected an error for an invalid window, got err: %v\nresp: %#v", err, resp)
+	}
+
+	for _, tc := range []struct {
+		windows   string
+		tokenType string
+		allowed   bool
+	}{
+		{"00:00-24:00", "service", true},
+		{later + " 00:00-24:00", "service", false},
+		{later + " 00:00-24:00", "batch", false},
+	} {
+		req := logical.TestRequest(t, logical.UpdateOperation, "roles/test")
+		req.ClientToken = root
+		req.Data = map[string]interface{}{
+			"token_access_windows":         tc.windows,
+			"token_access_window_timezone": "Europe/Paris",
+			"token_type":                   tc.tokenType,
+			"orphan":                       tc.tokenType == "batch",
+			"allowed_policies":             "creator",
+		}
+		resp, err := ts.HandleRequest(ctx, req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v\nresp: %#v", err, resp)
+		}
+
+		req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create/test")
+		req.ClientToken = root
+		resp, err = c.HandleRequest(ctx, req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v\nresp: %#v", err, resp)
+		}
+		token := resp.Auth.ClientToken
+
+		out, err := ts.Lookup(ctx, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out.AccessWindows, []string{tc.windows}) || out.AccessWindowTimezone != "Europe/Paris" {
+			t.Fatalf("bad access windows: %v in %q", out.AccessWindows, out.AccessWindowTimezone)
+		}
+
+		req = logical.TestRequest(t, logical.ReadOperation, "auth/token/lookup-self")
+		req.ClientToken = token
+		resp, err = c.HandleRequest(ctx, req)
+		if tc.allowed {
+			if err != nil || (resp != nil && resp.IsError()) {
+				t.Fatalf("err: %v\nresp: %#v", err, resp)
+			}
+			if !reflect.DeepEqual(resp.Data["access_windows"], []string{tc.windows}) {
+				t.Fatalf("bad: %#v", resp.Data)
+			}
+		} else {
+			if err == nil || !strings.Contains(err.Error(), logical.ErrPermissionDenied.Error()) || !strings.Contains(err.Error(), tc.windows+" (Europe/Paris)") {
+				t.Fatalf("expected permission denied outside the access windows, got err: %v\nresp: %#v", err, resp)
+			}
+		}
+
+		if !tc.allowed {
+			continue
+		}
+
+		// Children of the token inherit its access windows
+		req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create")
+		req.ClientToken = token
+		resp, err = c.HandleRequest(ctx, req)
+		if err != nil || (resp != nil && resp.IsError()) {
+			t.Fatalf("err: %v\nresp: %#v", err, resp)
+		}
+		out, err = ts.Lookup(ctx, resp.Auth.ClientToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out.AccessWindows, []string{tc.windows}) || out.AccessWindowTimezone != "Europe/Paris" {
+			t.Fatalf("bad child access windows: %v in %q", out.AccessWindows, out.AccessWindowTimezone)
+		}
+	}
+}
+
 func TestTokenStore_RolePathSuffix(t *testing.T) {
 	c, _, root := TestCoreUnsealed(t)
 	ts := c.tokenStore
@@ -6180,3 +6405,228 @@ func TestTokenStore_Collectors(t *testing.T) {
 	// Need to set up router for this to work, TODO
 	// ts.gaugeCollectorByMethod( ctx )
 }
+
+func TestTokenStore_HandleRequest_CreateBatchAgainstRole(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/roles/fleet")
+	req.ClientToken = root
+	req.Data["token_type"] = "batch"
+	req.Data["orphan"] = true
+	req.Data["renewable"] = false
+	resp, err := c.HandleRequest(ctx, req)
+	if err != nil || (resp != nil && resp.IsError()) {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create-batch/fleet")
+	req.ClientToken = root
+	req.Data["policies"] = "default"
+	req.Data["ttl"] = "1h"
+	req.Data["meta"] = map[string]interface{}{"fleet": "workers"}
+	req.Data["token_meta"] = []interface{}{
+		map[string]interface{}{"worker": "0"},
+		map[string]interface{}{"worker": "1", "fleet": "canary"},
+	}
+	req.Data["wrap_ttl"] = "10m"
+	resp, err = c.HandleRequest(ctx, req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if resp.Auth != nil {
+		t.Fatalf("expected no auth in response, got %#v", resp.Auth)
+	}
+	tokens := resp.Data["tokens"].([]map[string]interface{})
+	if len(tokens) != 2 {
+		t.Fatalf("expected 2 tokens, got %#v", tokens)
+	}
+
+	expectedMeta := []map[string]string{
+		{"fleet": "workers", "worker": "0"},
+		{"fleet": "canary", "worker": "1"},
+	}
+	for i, token := range tokens {
+		if token["ttl"].(int64) != 600 || token["creation_path"] != "auth/token/create-batch/fleet" {
+			t.Fatalf("bad wrap info: %#v", token)
+		}
+
+		unwrapReq := logical.TestRequest(t, logical.UpdateOperation, "sys/wrapping/unwrap")
+		unwrapReq.ClientToken = token["token"].(string)
+		unwrapResp, err := c.HandleRequest(ctx, unwrapReq)
+		if err != nil || unwrapResp.IsError() {
+			t.Fatalf("err:%v resp:%#v", err, unwrapResp)
+		}
+		var httpResp logical.HTTPResponse
+		if err := jsonutil.DecodeJSON(unwrapResp.Data[logical.HTTPRawBody].([]byte), &httpResp); err != nil {
+			t.Fatal(err)
+		}
+		if httpResp.Auth == nil || !reflect.DeepEqual(httpResp.Auth.Metadata, expectedMeta[i]) {
+			t.Fatalf("bad unwrapped auth: %#v", httpResp.Auth)
+		}
+
+		te, err := c.tokenStore.Lookup(ctx, httpResp.Auth.ClientToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if te == nil || te.Type != logical.TokenTypeBatch || !reflect.DeepEqual(te.Meta, expectedMeta[i]) {
+			t.Fatalf("bad token entry: %#v", te)
+		}
+	}
+
+	// Without token_meta, count sets the number of tokens
+	req = logical.TestRequest(t, logical.UpdateOperation, "auth/token/create-batch/fleet")
+	req.ClientToken = root
+	req.Data["policies"] = "default"
+	req.Data["ttl"] = "1h"
+	req.Data["count"] = 3
+	resp, err = c.HandleRequest(ctx, req)
+	if err != nil || resp.IsError() {
+		t.Fatalf("err:%v resp:%#v", err, resp)
+	}
+	if tokens := resp.Data["tokens"].([]map[string]interface{}); len(tokens) != 3 {
+		t.Fatalf("expected 3 tokens, got %#v", tokens)
+	}
+
+	for name, tc := range map[string]struct {
+		role       string
+		data       map[string]interface{}
+		wantErrStr string
+	}{
+		"no count":        {role: "fleet", data: map[string]interface{}{}, wantErrStr: `"count" must be between`},
+		"too many":        {role: "fleet", data: map[string]interface{}{"count": maxCreateBatchCount + 1}, wantErrStr: `"count" must be between`},
+		"count mismatch":  {role: "fleet", data: map[string]interface{}{"count": 2, "token_meta": []interface{}{map[string]interface{}{}}}, wantErrStr: `must match`},
+		"bad meta value":  {role: "fleet", data: map[string]interface{}{"token_meta": []interface{}{map[string]interface{}{"worker": 1}}}, wantErrStr: `is not a string`},
+		"service type":    {role: "fleet", data: map[string]interface{}{"count": 1, "type": "service"}, wantErrStr: `"type" must be "batch"`},
+		"unknown role":    {role: "missing", data: map[string]interface{}{"count": 1}, wantErrStr: "unknown role"},
+		"service role":    {role: "services", data: map[string]interface{}{"count": 1}, wantErrStr: "only allows service tokens"},
+		"batch with root": {role: "fleet", data: map[string]interface{}{"count": 1, "policies": "root"}, wantErrStr: "root"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if tc.role == "services" {
+				req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/roles/services")
+				req.ClientToken = root
+				req.Data["token_type"] = "service"
+				if resp, err := c.HandleRequest(ctx, req); err != nil || (resp != nil && resp.IsError()) {
+					t.Fatalf("err:%v resp:%#v", err, resp)
+				}
+			}
+
+			req := logical.TestRequest(t, logical.UpdateOperation, "auth/token/create-batch/"+tc.role)
+			req.ClientToken = root
+			req.Data = tc.data
+			resp, err := c.HandleRequest(ctx, req)
+			if err == nil && !resp.IsError() {
+				t.Fatalf("expected error, got %#v", resp)
+			}
+			if resp == nil || !strings.Contains(resp.Error().Error(), tc.wantErrStr) {
+				t.Fatalf("expected error containing %q, got err:%v resp:%#v", tc.wantErrStr, err, resp)
+			}
+		})
+	}
+}
+
+func TestTokenStore_HandleRequest_Exchange(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	request := func(t *testing.T, token, path string, data map[string]interface{}) *logical.Response {
+		t.Helper()
+		req := logical.TestRequest(t, logical.UpdateOperation, path)
+		req.ClientToken = token
+		req.Data = data
+		resp, err := c.HandleRequest(ctx, req)
+		if err != nil && (resp == nil || !resp.IsError()) {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := request(t, root, "sys/policy/exchanger", map[string]interface{}{
+		"policy": `path "auth/token/exchange" { capabilities = ["update"] }`,
+	})
+	if resp != nil && resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	resp = request(t, root, "auth/token/create", map[string]interface{}{
+		"policies": []string{"exchanger", "foo"},
+		"ttl":      "1h",
+	})
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	caller := resp.Auth
+
+	// Exchange for a shorter-lived token with an audience
+	resp = request(t, caller.ClientToken, "auth/token/exchange", map[string]interface{}{
+		"ttl":      "10m",
+		"audience": "service-a",
+		"meta":     map[string]interface{}{"request": "42"},
+	})
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	first := resp.Auth
+	if first.Renewable || first.TTL != 10*time.Minute || first.Orphan {
+		t.Fatalf("bad auth: %#v", first)
+	}
+	if !reflect.DeepEqual(first.TokenPolicies, []string{"default", "exchanger", "foo"}) {
+		t.Fatalf("bad policies: %#v", first.TokenPolicies)
+	}
+	if !reflect.DeepEqual(first.Metadata, map[string]string{"audience": "service-a", "request": "42"}) {
+		t.Fatalf("bad metadata: %#v", first.Metadata)
+	}
+
+	// Exchange again for a narrower token, outliving the exchanged one
+	resp = request(t, first.ClientToken, "auth/token/exchange", map[string]interface{}{
+		"policies": "foo",
+		"ttl":      "2h",
+	})
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	second := resp.Auth
+	if second.TTL > 10*time.Minute || len(resp.Warnings) == 0 {
+		t.Fatalf("expected ttl to be capped, got %#v", resp)
+	}
+	if !reflect.DeepEqual(second.TokenPolicies, []string{"foo"}) {
+		t.Fatalf("bad policies: %#v", second.TokenPolicies)
+	}
+
+	resp = request(t, root, "auth/token/lookup", map[string]interface{}{"token": second.ClientToken})
+	if resp.IsError() {
+		t.Fatalf("bad: %#v", resp)
+	}
+	if chain := resp.Data["delegation_chain"]; !reflect.DeepEqual(chain, []string{caller.Accessor, first.Accessor}) {
+		t.Fatalf("bad delegation chain: %#v", chain)
+	}
+
+	for name, tc := range map[string]struct {
+		token      string
+		data       map[string]interface{}
+		wantErrStr string
+	}{
+		"wider policies": {token: caller.ClientToken, data: map[string]interface{}{"policies": "bar"}, wantErrStr: "must be a subset"},
+		"root policy":    {token: root, data: map[string]interface{}{"policies": "root"}, wantErrStr: "root tokens cannot be obtained"},
+		"root default":   {token: root, data: map[string]interface{}{}, wantErrStr: "policies must be given"},
+		"negative uses":  {token: caller.ClientToken, data: map[string]interface{}{"num_uses": -1}, wantErrStr: "cannot be negative"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := request(t, tc.token, "auth/token/exchange", tc.data)
+			if resp == nil || !resp.IsError() || !strings.Contains(resp.Error().Error(), tc.wantErrStr) {
+				t.Fatalf("expected error containing %q, got %#v", tc.wantErrStr, resp)
+			}
+		})
+	}
+
+	// Revoking the calling token revokes the whole delegation chain
+	request(t, root, "auth/token/revoke", map[string]interface{}{"token": caller.ClientToken})
+	te, err := c.tokenStore.Lookup(ctx, second.ClientToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if te != nil {
+		t.Fatalf("expected exchanged token to be revoked, got %#v", te)
+	}
+}
@@ -0,0 +1,962 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-secure-stdlib/strutil"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+const (
+	// tokenTidyConfigKey is the storage key of the schedule on which the
+	// token store is tidied.
+	tokenTidyConfigKey = "tidy/config"
+
+	// tokenTidyStatusKey is the storage key of the progress of the current
+	// tidy run and the statistics of the last one.
+	tokenTidyStatusKey = "tidy/status"
+
+	tokenTidyPhaseParent    = "parent"
+	tokenTidyPhaseAccessor  = "accessor"
+	tokenTidyPhaseCubbyhole = "cubbyhole"
+
+	defaultTokenTidyBatchSize = 1000
+)
+
+// tokenTidyCheckInterval is how often the token tidy schedule is checked for
+// whether a tidy is due.
+var tokenTidyCheckInterval = 10 * time.Second
+
+var errTokenTidyNamespace = errors.New("token tidy can only be scheduled in the root namespace")
+
+// tokenTidyConfig is the schedule on which the token store is tidied, and how
+// much of it is tidied at once. Scheduled tidies are disabled if neither a
+// schedule nor a period is set.
+type tokenTidyConfig struct {
+	Schedule   string        `json:"tidy_schedule,omitempty"`
+	Period     time.Duration `json:"tidy_period,omitempty"`
+	BatchSize  int           `json:"batch_size,omitempty"`
+	BatchPause time.Duration `json:"batch_pause,omitempty"`
+
+	// RevokeUnusedAfter is how long a service token can go unused before
+	// it is revoked by a tidy. Tokens are not revoked for lack of use if
+	// it is zero.
+	RevokeUnusedAfter time.Duration `json:"revoke_unused_after,omitempty"`
+
+	NextTidy time.Time `json:"next_tidy,omitempty"`
+}
+
+func (c *tokenTidyConfig) enabled() bool {
+	return c.Schedule != "" || c.Period > 0
+}
+
+func (c *tokenTidyConfig) batchSize() int {
+	if c.BatchSize <= 0 {
+		return defaultTokenTidyBatchSize
+	}
+	return c.BatchSize
+}
+
+// tokenTidyStatus is the progress of the current tidy run and the statistics
+// of the last completed one.
+type tokenTidyStatus struct {
+	// Run is the current run. If the run was interrupted, e.g. by the node
+	// stepping down, it is the checkpoint the next run resumes from.
+	Run     *tokenTidyRun `json:"run,omitempty"`
+	LastRun *tokenTidyRun `json:"last_run,omitempty"`
+}
+
+// tokenTidyRun is a single tidy of the token store. The entries of each phase
+// are tidied in batches, in key order, and After is the last key of the phase
+// which was tidied.
+type tokenTidyRun struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time,omitempty"`
+	Phase     string    `json:"phase,omitempty"`
+	After     string    `json:"after,omitempty"`
+	Error     string    `json:"error,omitempty"`
+
+	// Resumed is set if the run resumed from the checkpoint of an
+	// interrupted run.
+	Resumed bool `json:"resumed,omitempty"`
+
+	// CubbyholesSkipped is set if the cubbyholes were not tidied because
+	// the run resumed after it started checking accessors, so the cubbyholes
+	// of valid tokens are not all known.
+	CubbyholesSkipped bool `json:"cubbyholes_skipped,omitempty"`
+
+	Counts tokenTidyCounts `json:"counts"`
+}
+
+type tokenTidyCounts struct {
+	ParentPrefixesScanned        int64 `json:"parent_prefixes_scanned"`
+	ParentPrefixesDeleted        int64 `json:"parent_prefixes_deleted"`
+	ParentIndexesScanned         int64 `json:"parent_indexes_scanned"`
+	ParentIndexesDeleted         int64 `json:"parent_indexes_deleted"`
+	AccessorsScanned             int64 `json:"accessors_scanned"`
+	AccessorsEmptyTokenDeleted   int64 `json:"accessors_empty_token_deleted"`
+	InvalidTokensRevoked         int64 `json:"invalid_tokens_revoked"`
+	AccessorsInvalidTokenDeleted int64 `json:"accessors_invalid_token_deleted"`
+	UnusedTokensRevoked          int64 `json:"unused_tokens_revoked"`
+	CubbyholesScanned            int64 `json:"cubbyholes_scanned"`
+	CubbyholesDeleted            int64 `json:"cubbyholes_deleted"`
+}
+
+func (r *tokenTidyRun) responseData() map[string]interface{} {
+	data := map[string]interface{}{
+		"start_time":                      r.StartTime.Format(time.RFC3339),
+		"resumed":                         r.Resumed,
+		"cubbyholes_skipped":              r.CubbyholesSkipped,
+		"error":                           r.Error,
+		"parent_prefixes_scanned":         r.Counts.ParentPrefixesScanned,
+		"parent_prefixes_deleted":         r.Counts.ParentPrefixesDeleted,
+		"parent_indexes_scanned":          r.Counts.ParentIndexesScanned,
+		"parent_indexes_deleted":          r.Counts.ParentIndexesDeleted,
+		"accessors_scanned":               r.Counts.AccessorsScanned,
+		"accessors_empty_token_deleted":   r.Counts.AccessorsEmptyTokenDeleted,
+		"invalid_tokens_revoked":          r.Counts.InvalidTokensRevoked,
+		"accessors_invalid_token_deleted": r.Counts.AccessorsInvalidTokenDeleted,
+		"unused_tokens_revoked":           r.Counts.UnusedTokensRevoked,
+		"cubbyholes_scanned":              r.Counts.CubbyholesScanned,
+		"cubbyholes_deleted":              r.Counts.CubbyholesDeleted,
+	}
+	if r.EndTime.IsZero() {
+		data["phase"] = r.Phase
+		data["checkpoint"] = r.After
+	} else {
+		data["end_time"] = r.EndTime.Format(time.RFC3339)
+		data["duration"] = r.EndTime.Sub(r.StartTime).String()
+	}
+	return data
+}
+
+func (ts *TokenStore) tidyPaths() []*framework.Path {
+	const operationPrefixToken = "token"
+
+	return []*framework.Path{
+		{
+			Pattern: "tidy/config$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixToken,
+				OperationSuffix: "tidy-configuration",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"tidy_schedule": {
+					Type:        framework.TypeString,
+					Description: "Cron-style schedule, e.g. '0 3 * * *', on which the token store is tidied. Mutually exclusive with 'tidy_period'.",
+				},
+				"tidy_period": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Interval on which the token store is tidied. Mutually exclusive with 'tidy_schedule'.",
+				},
+				"batch_size": {
+					Type:        framework.TypeInt,
+					Description: "Number of entries tidied before the progress of a tidy is saved. Defaults to 1000.",
+				},
+				"batch_pause": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Time to wait between batches, to limit the load a tidy puts on storage. Defaults to no pause.",
+				},
+				"revoke_unused_after": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Time after which service tokens which were not used are revoked by a tidy. Tokens with the root policy are never revoked. Defaults to not revoking unused tokens.",
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: ts.handleTidyConfigRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: ts.handleTidyConfigWrite,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "configure",
+					},
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: ts.handleTidyConfigDelete,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "delete",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(tokenTidyConfigHelp),
+			HelpDescription: strings.TrimSpace(tokenTidyConfigDesc),
+		},
+		{
+			Pattern: "tidy/status$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: operationPrefixToken,
+				OperationSuffix: "tidy-status",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: ts.handleTidyStatus,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "read",
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(tokenTidyStatusHelp),
+			HelpDescription: strings.TrimSpace(tokenTidyStatusDesc),
+		},
+	}
+}
+
+func (ts *TokenStore) handleTidyConfigRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := tokenTidyCheckNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	config, err := ts.tidyConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	respData := map[string]interface{}{
+		"tidy_schedule": config.Schedule,
+		"tidy_period":   int64(config.Period.Seconds()),
+		"batch_size":    config.batchSize(),
+		"batch_pause":   int64(config.BatchPause.Seconds()),
+
+		"revoke_unused_after": int64(config.RevokeUnusedAfter.Seconds()),
+	}
+	if config.enabled() {
+		respData["next_tidy"] = config.NextTidy.Format(time.RFC3339)
+	}
+	return &logical.Response{Data: respData}, nil
+}
+
+func (ts *TokenStore) handleTidyConfigWrite(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := tokenTidyCheckNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	ts.tidyConfigLock.Lock()
+	defer ts.tidyConfigLock.Unlock()
+
+	config, err := ts.tidyConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if raw, ok := data.GetOk("tidy_schedule"); ok {
+		config.Schedule = raw.(string)
+		if _, ok := data.GetOk("tidy_period"); !ok {
+			config.Period = 0
+		}
+	}
+	if raw, ok := data.GetOk("tidy_period"); ok {
+		config.Period = time.Duration(raw.(int)) * time.Second
+		if _, ok := data.GetOk("tidy_schedule"); !ok {
+			config.Schedule = ""
+		}
+	}
+	if raw, ok := data.GetOk("batch_size"); ok {
+		config.BatchSize = raw.(int)
+	}
+	if raw, ok := data.GetOk("batch_pause"); ok {
+		config.BatchPause = time.Duration(raw.(int)) * time.Second
+	}
+	if raw, ok := data.GetOk("revoke_unused_after"); ok {
+		config.RevokeUnusedAfter = time.Duration(raw.(int)) * time.Second
+	}
+
+	switch {
+	case config.Schedule != "" && config.Period != 0:
+		return logical.ErrorResponse("tidy_schedule and tidy_period are mutually exclusive"), nil
+	case config.Period < 0:
+		return logical.ErrorResponse("tidy_period must not be negative"), nil
+	case config.BatchSize < 0:
+		return logical.ErrorResponse("batch_size must not be negative"), nil
+	case config.BatchPause < 0:
+		return logical.ErrorResponse("batch_pause must not be negative"), nil
+	case config.RevokeUnusedAfter < 0:
+		return logical.ErrorResponse("revoke_unused_after must not be negative"), nil
+	}
+
+	if config.enabled() {
+		config.NextTidy, err = nextScheduled(config.Schedule, config.Period, time.Now())
+		if err != nil {
+			return logical.ErrorResponse("invalid tidy_schedule: %s", err), nil
+		}
+	} else {
+		config.NextTidy = time.Time{}
+	}
+
+	return nil, ts.persistTidyConfig(ctx, config)
+}
+
+func (ts *TokenStore) handleTidyConfigDelete(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if err := tokenTidyCheckNamespace(ctx); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+
+	ts.tidyConfigLock.Lock()
+	defer ts.tidyConfigLock.Unlock()
+
+	return nil, ts.baseView(namespace.RootNamespace).Delete(ctx, tokenTidyConfigKey)
+}
+
+func (ts *TokenStore) handleTidyStatus(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := ts.tidyStatus(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+
+	state := "idle"
+	switch {
+	case atomic.LoadUint32(ts.tidyLock) == 1:
+		state = "running"
+	case status.Run != nil:
+		state = "interrupted"
+	}
+
+	respData := map[string]interface{}{
+		"state": state,
+	}
+	if status.Run != nil {
+		respData["current_run"] = status.Run.responseData()
+	}
+	if status.LastRun != nil {
+		respData["last_run"] = status.LastRun.responseData()
+	}
+	if ns.ID == namespace.RootNamespaceID {
+		config, err := ts.tidyConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if config.enabled() {
+			respData["next_tidy"] = config.NextTidy.Format(time.RFC3339)
+		}
+	}
+	return &logical.Response{Data: respData}, nil
+}
+
+// handleTidy handles the cleaning up of leaked accessor storage entries and
+// cleaning up of leases that are associated to tokens that are expired. The
+// tidy runs in the background, resuming from the checkpoint of an interrupted
+// run if there is one.
+func (ts *TokenStore) handleTidy(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	if !atomic.CompareAndSwapUint32(ts.tidyLock, 0, 1) {
+		resp := &logical.Response{}
+		resp.AddWarning("Tidy operation already in progress.")
+		return resp, nil
+	}
+
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		atomic.StoreUint32(ts.tidyLock, 0)
+		return nil, fmt.Errorf("failed to get namespace from context: %w", err)
+	}
+
+	op, err := ts.core.asyncOperations.start(ts.quitContext, ns, asyncOperationTypeTokenTidy, func(ctx context.Context, _ *AsyncOperation) error {
+		defer atomic.StoreUint32(ts.tidyLock, 0)
+
+		err := ts.tidy(ctx, ns)
+		if err != nil {
+			ts.logger.Named("tidy").Error("error running tidy", "error", err)
+		}
+		return err
+	})
+	if err != nil {
+		atomic.StoreUint32(ts.tidyLock, 0)
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"operation_id": op.ID,
+		},
+	}
+	resp.AddWarning("Tidy operation successfully started. Its progress can be read from the tidy/status endpoint.")
+	return logical.RespondWithStatusCode(resp, req, http.StatusAccepted)
+}
+
+func tokenTidyCheckNamespace(ctx context.Context) error {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if ns.ID != namespace.RootNamespaceID {
+		return errTokenTidyNamespace
+	}
+	return nil
+}
+
+// tidyConfig returns the token tidy schedule, which is empty if it was never
+// configured.
+func (ts *TokenStore) tidyConfig(ctx context.Context) (*tokenTidyConfig, error) {
+	config := new(tokenTidyConfig)
+
+	entry, err := ts.baseView(namespace.RootNamespace).Get(ctx, tokenTidyConfigKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token tidy config: %w", err)
+	}
+	if entry == nil {
+		return config, nil
+	}
+
+	if err := jsonutil.DecodeJSON(entry.Value, config); err != nil {
+		return nil, fmt.Errorf("failed to decode token tidy config: %w", err)
+	}
+	return config, nil
+}
+
+func (ts *TokenStore) persistTidyConfig(ctx context.Context, config *tokenTidyConfig) error {
+	entry, err := logical.StorageEntryJSON(tokenTidyConfigKey, config)
+	if err != nil {
+		return fmt.Errorf("failed to encode token tidy config: %w", err)
+	}
+	return ts.baseView(namespace.RootNamespace).Put(ctx, entry)
+}
+
+func (ts *TokenStore) tidyStatus(ctx context.Context, ns *namespace.Namespace) (*tokenTidyStatus, error) {
+	status := new(tokenTidyStatus)
+
+	entry, err := ts.baseView(ns).Get(ctx, tokenTidyStatusKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token tidy status: %w", err)
+	}
+	if entry == nil {
+		return status, nil
+	}
+
+	if err := jsonutil.DecodeJSON(entry.Value, status); err != nil {
+		return nil, fmt.Errorf("failed to decode token tidy status: %w", err)
+	}
+	return status, nil
+}
+
+func (ts *TokenStore) persistTidyStatus(ctx context.Context, ns *namespace.Namespace, status *tokenTidyStatus) error {
+	entry, err := logical.StorageEntryJSON(tokenTidyStatusKey, status)
+	if err != nil {
+		return fmt.Errorf("failed to encode token tidy status: %w", err)
+	}
+	return ts.baseView(ns).Put(ctx, entry)
+}
+
+// tokenTidier tidies the token store of a namespace in batches, saving its
+// progress after each one. Errors tidying individual entries are collected
+// and do not stop the run.
+type tokenTidier struct {
+	ts     *TokenStore
+	ns     *namespace.Namespace
+	config *tokenTidyConfig
+	status *tokenTidyStatus
+	run    *tokenTidyRun
+
+	cubbyholeView *BarrierView
+
+	// validCubbyholeKeys holds the cubbyhole keys of the valid tokens found
+	// while checking accessors. It is only complete if all accessors were
+	// checked by this process.
+	validCubbyholeKeys map[string]bool
+	allAccessorsSeen   bool
+
+	errors *multierror.Error
+}
+
+// tidy runs a tidy of the token store of the namespace to completion. The
+// caller must hold the tidy lock.
+func (ts *TokenStore) tidy(ctx context.Context, ns *namespace.Namespace) error {
+	config, err := ts.tidyConfig(ctx)
+	if err != nil {
+		return err
+	}
+	status, err := ts.tidyStatus(ctx, ns)
+	if err != nil {
+		return err
+	}
+
+	view := ts.core.router.MatchingStorageByAPIPath(ctx, mountPathCubbyhole)
+	if view == nil {
+		return fmt.Errorf("no cubby mount entry")
+	}
+
+	t := &tokenTidier{
+		ts:                 ts,
+		ns:                 ns,
+		config:             config,
+		status:             status,
+		run:                status.Run,
+		cubbyholeView:      view.(*BarrierView),
+		validCubbyholeKeys: make(map[string]bool),
+	}
+	if t.run == nil {
+		t.run = &tokenTidyRun{
+			StartTime: time.Now(),
+			Phase:     tokenTidyPhaseParent,
+		}
+		ts.logger.Info("beginning tidy operation on tokens")
+	} else {
+		t.run.Resumed, t.run.Error = true, ""
+		ts.logger.Info("resuming tidy operation on tokens", "phase", t.run.Phase, "checkpoint", t.run.After)
+	}
+	t.status.Run = t.run
+
+	if err := t.runPhases(ctx); err != nil {
+		t.run.Error = err.Error()
+		if persistErr := ts.persistTidyStatus(ctx, ns, t.status); persistErr != nil {
+			ts.logger.Error("failed to save tidy checkpoint", "error", persistErr)
+		}
+		return err
+	}
+
+	t.run.EndTime = time.Now()
+	t.run.Phase, t.run.After = "", ""
+	if err := t.errors.ErrorOrNil(); err != nil {
+		t.run.Error = err.Error()
+	}
+	t.status.Run, t.status.LastRun = nil, t.run
+	if err := ts.persistTidyStatus(ctx, ns, t.status); err != nil {
+		return err
+	}
+
+	counts := t.run.Counts
+	ts.logger.Info("finished tidy operation on tokens", "duration", t.run.EndTime.Sub(t.run.StartTime))
+	ts.logger.Info("number of entries scanned in parent prefix", "count", counts.ParentPrefixesScanned)
+	ts.logger.Info("number of entries deleted in parent prefix", "count", counts.ParentPrefixesDeleted)
+	ts.logger.Info("number of tokens scanned in parent index list", "count", counts.ParentIndexesScanned)
+	ts.logger.Info("number of tokens revoked in parent index list", "count", counts.ParentIndexesDeleted)
+	ts.logger.Info("number of accessors scanned", "count", counts.AccessorsScanned)
+	ts.logger.Info("number of deleted accessors which had empty tokens", "count", counts.AccessorsEmptyTokenDeleted)
+	ts.logger.Info("number of revoked tokens which were invalid but present in accessors", "count", counts.InvalidTokensRevoked)
+	ts.logger.Info("number of deleted accessors which had invalid tokens", "count", counts.AccessorsInvalidTokenDeleted)
+	ts.logger.Info("number of revoked tokens which were unused", "count", counts.UnusedTokensRevoked)
+	ts.logger.Info("number of deleted cubbyhole keys that were invalid", "count", counts.CubbyholesDeleted)
+
+	return t.errors.ErrorOrNil()
+}
+
+// runPhases tidies the parent index, the accessors and the cubbyholes in
+// turn, starting from the checkpoint of the run.
+func (t *tokenTidier) runPhases(ctx context.Context) error {
+	phases := []struct {
+		name  string
+		view  *BarrierView
+		batch func(context.Context, []string)
+	}{
+		{tokenTidyPhaseParent, t.ts.parentView(t.ns), t.tidyParents},
+		{tokenTidyPhaseAccessor, t.ts.accessorView(t.ns), t.tidyAccessors},
+		{tokenTidyPhaseCubbyhole, t.cubbyholeView, t.tidyCubbyholes},
+	}
+
+	var started bool
+	for _, phase := range phases {
+		if !started && phase.name != t.run.Phase {
+			continue
+		}
+		if started {
+			t.run.Phase, t.run.After = phase.name, ""
+		}
+		started = true
+
+		switch phase.name {
+		case tokenTidyPhaseAccessor:
+			t.allAccessorsSeen = t.run.After == ""
+		case tokenTidyPhaseCubbyhole:
+			if !t.allAccessorsSeen {
+				t.ts.logger.Info("skipping tidy of cubbyholes since the accessors were checked by an interrupted run")
+				t.run.CubbyholesSkipped = true
+				continue
+			}
+		}
+
+		if err := t.runPhase(ctx, phase.view, phase.batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runPhase lists the keys of the view in batches after the checkpoint of the
+// run, tidying each batch and saving the checkpoint after it.
+func (t *tokenTidier) runPhase(ctx context.Context, view *BarrierView, batch func(context.Context, []string)) error {
+	for {
+		keys, err := logical.ListPage(ctx, view, "", t.run.After, t.config.batchSize())
+		if err != nil {
+			return fmt.Errorf("failed to list entries to tidy in phase %q: %w", t.run.Phase, err)
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+
+		batch(ctx, keys)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		t.run.After = keys[len(keys)-1]
+		if err := t.ts.persistTidyStatus(ctx, t.ns, t.status); err != nil {
+			return fmt.Errorf("failed to save tidy checkpoint: %w", err)
+		}
+		t.ts.logger.Info("tidied batch of token store entries", "phase", t.run.Phase, "checkpoint", t.run.After)
+
+		if t.config.BatchPause > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(t.config.BatchPause):
+			}
+		}
+	}
+}
+
+// tidyParents cleans up secondary index entries that are no longer valid.
+// Children whose parent no longer exists are turned into orphan tokens.
+func (t *tokenTidier) tidyParents(ctx context.Context, parentList []string) {
+	ts, counts := t.ts, &t.run.Counts
+
+	// Scan through the secondary index entries; if there is an entry
+	// with the token's salt ID at the end, remove it
+	for _, parent := range parentList {
+		counts.ParentPrefixesScanned++
+
+		// Get the children
+		children, err := ts.parentView(t.ns).List(ctx, parent)
+		if err != nil {
+			t.errors = multierror.Append(t.errors, fmt.Errorf("failed to read secondary index: %w", err))
+			continue
+		}
+
+		// First check if the salt ID of the parent exists, and if not mark this so
+		// that deletion of children later with this loop below applies to all
+		// children
+		originalChildrenCount := int64(len(children))
+		exists, _ := ts.lookupInternal(ctx, strings.TrimSuffix(parent, "/"), true, true)
+		if exists == nil {
+			ts.logger.Debug("deleting invalid parent prefix entry", "index", parentPrefix+parent)
+		}
+
+		var deletedChildrenCount int64
+		for _, child := range children {
+			counts.ParentIndexesScanned++
+
+			// Look up tainted entries so we can be sure that if this isn't
+			// found, it doesn't exist. Doing the following without locking
+			// since appropriate locks cannot be held with salted token IDs.
+			// Also perform deletion if the parent doesn't exist any more.
+			te, _ := ts.lookupInternal(ctx, child, true, true)
+			// If the child entry is not nil, but the parent doesn't exist, then turn
+			// that child token into an orphan token. Theres no deletion in this case.
+			if te != nil && exists == nil {
+				lock := locksutil.LockForKey(ts.tokenLocks, te.ID)
+				lock.Lock()
+
+				te.Parent = ""
+				err = ts.store(ctx, te)
+				if err != nil {
+					t.errors = multierror.Append(t.errors, fmt.Errorf("failed to convert child token into an orphan token: %w", err))
+				}
+				lock.Unlock()
+				continue
+			}
+			// Otherwise, if the entry doesn't exist, or if the parent doesn't exist go
+			// on with the delete on the secondary index
+			if te == nil || exists == nil {
+				index := parent + child
+				ts.logger.Debug("deleting invalid secondary index", "index", index)
+				err = ts.parentView(t.ns).Delete(ctx, index)
+				if err != nil {
+					t.errors = multierror.Append(t.errors, fmt.Errorf("failed to delete secondary index: %w", err))
+					continue
+				}
+				deletedChildrenCount++
+			}
+		}
+		// Add current children deleted count to the total count
+		counts.ParentIndexesDeleted += deletedChildrenCount
+		// N.B.: We don't call delete on the parent prefix since physical.Backend.Delete
+		// implementations should be in charge of deleting empty prefixes.
+		// If we deleted all the children, then add that to our deleted parent entries count.
+		if originalChildrenCount == deletedChildrenCount {
+			counts.ParentPrefixesDeleted++
+		}
+	}
+}
+
+// tidyAccessors checks, for each accessor, if the token ID associated with it
+// is a valid one. If not, the leases associated with that token and the
+// accessor are deleted.
+func (t *tokenTidier) tidyAccessors(ctx context.Context, saltedAccessorList []string) {
+	ts, counts := t.ts, &t.run.Counts
+
+	for _, saltedAccessor := range saltedAccessorList {
+		counts.AccessorsScanned++
+
+		accessorEntry, err := ts.lookupByAccessor(ctx, saltedAccessor, true, true)
+		if err != nil {
+			t.errors = multierror.Append(t.errors, fmt.Errorf("failed to read the accessor index: %w", err))
+			continue
+		}
+		if accessorEntry == nil {
+			t.errors = multierror.Append(t.errors, fmt.Errorf("failed to read the accessor index: invalid accessor"))
+			continue
+		}
+
+		// A valid accessor storage entry should always have a token ID
+		// in it. If not, it is an invalid accessor entry and needs to
+		// be deleted.
+		if accessorEntry.TokenID == "" {
+			// If deletion of accessor fails, move on to the next
+			// item since this is just a best-effort operation
+			err = ts.accessorView(t.ns).Delete(ctx, saltedAccessor)
+			if err != nil {
+				t.errors = multierror.Append(t.errors, fmt.Errorf("failed to delete the accessor index: %w", err))
+				continue
+			}
+			counts.AccessorsEmptyTokenDeleted++
+		}
+
+		lock := locksutil.LockForKey(ts.tokenLocks, accessorEntry.TokenID)
+		lock.RLock()
+
+		// Look up tainted variants so we only find entries that truly don't
+		// exist
+		te, err := ts.lookupInternal(ctx, accessorEntry.TokenID, false, true)
+		if err != nil {
+			t.errors = multierror.Append(t.errors, fmt.Errorf("failed to lookup tainted ID: %w", err))
+			lock.RUnlock()
+			continue
+		}
+
+		lock.RUnlock()
+
+		switch {
+		case te == nil:
+			// If token entry is not found assume that the token is not valid any
+			// more and conclude that accessor, leases, and secondary index entries
+			// for this token should not exist as well.
+
+			ts.logger.Info("deleting token with nil entry referenced by accessor", "salted_accessor", saltedAccessor)
+
+			// RevokeByToken expects a '*logical.TokenEntry'. For the
+			// purposes of tidying, it is sufficient if the token
+			// entry only has ID set.
+			tokenEntry := &logical.TokenEntry{
+				ID:          accessorEntry.TokenID,
+				NamespaceID: accessorEntry.NamespaceID,
+			}
+
+			// Attempt to revoke the token. This will also revoke
+			// the leases associated with the token.
+			err = ts.expiration.RevokeByToken(ctx, tokenEntry)
+			if err != nil {
+				t.errors = multierror.Append(t.errors, fmt.Errorf("failed to revoke leases of expired token: %w", err))
+				continue
+			}
+			counts.InvalidTokensRevoked++
+
+			// If deletion of accessor fails, move on to the next item since
+			// this is just a best-effort operation. We do this last so that on
+			// next run if something above failed we still have the accessor
+			// entry to try again.
+			err = ts.accessorView(t.ns).Delete(ctx, saltedAccessor)
+			if err != nil {
+				t.errors = multierror.Append(t.errors, fmt.Errorf("failed to delete accessor entry: %w", err))
+				continue
+			}
+			counts.AccessorsInvalidTokenDeleted++
+		default:
+			revoked, err := t.revokeIfUnused(ctx, te)
+			if err != nil {
+				t.errors = multierror.Append(t.errors, err)
+				continue
+			}
+			if revoked {
+				counts.UnusedTokensRevoked++
+				continue
+			}
+
+			// Cache the cubbyhole storage key when the token is valid
+			switch {
+			case te.NamespaceID == namespace.RootNamespaceID && !IsServiceToken(te.ID):
+				saltedID, err := ts.SaltID(ctx, te.ID)
+				if err != nil {
+					t.errors = multierror.Append(t.errors, fmt.Errorf("failed to create salted token id: %w", err))
+					continue
+				}
+				t.validCubbyholeKeys[salt.SaltID(ts.cubbyholeBackend.saltUUID, saltedID, salt.SHA1Hash)] = true
+			default:
+				if te.CubbyholeID == "" {
+					t.errors = multierror.Append(t.errors, fmt.Errorf("missing cubbyhole ID for a valid token"))
+					continue
+				}
+				t.validCubbyholeKeys[te.CubbyholeID] = true
+			}
+		}
+	}
+}
+
+// revokeIfUnused revokes the service token if it was not used, or created,
+// for longer than the configured period. The children of the token are
+// orphaned rather than revoked, so that tokens still in use are not revoked
+// along with an unused parent.
+func (t *tokenTidier) revokeIfUnused(ctx context.Context, te *logical.TokenEntry) (bool, error) {
+	ts := t.ts
+
+	if t.config.RevokeUnusedAfter == 0 || te.Type == logical.TokenTypeBatch || strutil.StrListContains(te.Policies, "root") {
+		return false, nil
+	}
+
+	lastUsed := time.Unix(te.CreationTime, 0)
+	usage, err := ts.tokenUsage(ctx, t.ns, te)
+	if err != nil {
+		return false, err
+	}
+	if usage != nil && usage.LastUsed.After(lastUsed) {
+		lastUsed = usage.LastUsed
+	}
+	if time.Since(lastUsed) < t.config.RevokeUnusedAfter {
+		return false, nil
+	}
+
+	ts.logger.Info("revoking unused token", "accessor", te.Accessor, "last_used", lastUsed)
+	if err := ts.revokeOrphan(ctx, te.ID); err != nil {
+		return false, fmt.Errorf("failed to revoke unused token: %w", err)
+	}
+	return true, nil
+}
+
+// tidyCubbyholes revokes the cubbyholes which do not belong to a valid token.
+func (t *tokenTidier) tidyCubbyholes(ctx context.Context, cubbyholeKeys []string) {
+	ts, counts := t.ts, &t.run.Counts
+
+	for _, key := range cubbyholeKeys {
+		counts.CubbyholesScanned++
+
+		key := strings.TrimSuffix(key, "/")
+		if !t.validCubbyholeKeys[key] {
+			ts.logger.Info("deleting invalid cubbyhole", "key", key)
+			err := ts.cubbyholeBackend.revoke(ctx, t.cubbyholeView, key)
+			if err != nil {
+				t.errors = multierror.Append(t.errors, fmt.Errorf("failed to revoke cubbyhole key %q: %w", key, err))
+			}
+			counts.CubbyholesDeleted++
+		}
+	}
+}
+
+// startTokenTidy runs a process which, every tokenTidyCheckInterval, tidies
+// the token store if a tidy is due, until the active context is done.
+func (c *Core) startTokenTidy() {
+	if c.tokenTidyCancel != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, c.tokenTidyCancel = context.WithCancel(namespace.RootContext(c.activeContext))
+
+	go func() {
+		ticker := time.NewTicker(tokenTidyCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.runTokenTidy(ctx, time.Now()); err != nil {
+					c.logger.Error("failed to tidy the token store", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// runTokenTidy tidies the token store if a tidy is due at now, and schedules
+// the next tidy. A tidy already running is left to finish.
+func (c *Core) runTokenTidy(ctx context.Context, now time.Time) error {
+	ts := c.tokenStore
+	if ts == nil || ts.expiration == nil {
+		return nil
+	}
+
+	ts.tidyConfigLock.Lock()
+	config, err := ts.tidyConfig(ctx)
+	ts.tidyConfigLock.Unlock()
+	if err != nil || !config.enabled() || now.Before(config.NextTidy) {
+		return err
+	}
+
+	var tidyErr error
+	if atomic.CompareAndSwapUint32(ts.tidyLock, 0, 1) {
+		tidyErr = ts.tidy(ctx, namespace.RootNamespace)
+		atomic.StoreUint32(ts.tidyLock, 0)
+		if ctx.Err() != nil {
+			return tidyErr
+		}
+	}
+
+	if err := c.scheduleTokenTidy(ctx, now); err != nil {
+		return err
+	}
+	return tidyErr
+}
+
+// scheduleTokenTidy schedules the tidy after the one due at now, unless the
+// schedule was changed while tidying.
+func (c *Core) scheduleTokenTidy(ctx context.Context, now time.Time) error {
+	ts := c.tokenStore
+	ts.tidyConfigLock.Lock()
+	defer ts.tidyConfigLock.Unlock()
+
+	config, err := ts.tidyConfig(ctx)
+	if err != nil || !config.enabled() || config.NextTidy.After(now) {
+		return err
+	}
+
+	if config.NextTidy, err = nextScheduled(config.Schedule, config.Period, time.Now()); err != nil {
+		return err
+	}
+	return ts.persistTidyConfig(ctx, config)
+}
+
+const (
+	tokenTidyConfigHelp = `Configure the schedule on which the token store is tidied.`
+	tokenTidyConfigDesc = `
+On the configured schedule, the active node tidies the token store as the tidy
+endpoint does. Entries are tidied in batches of 'batch_size', optionally
+pausing for 'batch_pause' between batches, and the progress of the tidy is
+saved after each batch. A tidy interrupted, e.g. by the node stepping down,
+resumes from its last batch on the next tidy.
+
+Scheduled tidies are disabled if neither 'tidy_schedule' nor 'tidy_period' is
+set.
+`
+	tokenTidyStatusHelp = `Read the progress of the current tidy and the statistics of the last one.`
+	tokenTidyStatusDesc = `
+Returns whether a tidy is running, the phase and checkpoint of the current or
+interrupted tidy, the number of entries it scanned and deleted so far, and the
+same statistics of the last completed tidy.
+`
+)
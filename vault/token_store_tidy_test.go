@@ -0,0 +1,320 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// testTokenStoreLeakAccessors creates n tokens and deletes their entries
+// while leaking their accessors, returning the sorted salted accessors.
+func testTokenStoreLeakAccessors(t *testing.T, ts *TokenStore, root string, n int) []string {
+	t.Helper()
+	ctx := namespace.RootContext(nil)
+
+	var saltedAccessors []string
+	for i := 0; i < n; i++ {
+		resp := testMakeTokenViaRequest(t, ts, &logical.Request{
+			Operation:   logical.UpdateOperation,
+			Path:        "create",
+			ClientToken: root,
+			Data: map[string]interface{}{
+				"policies": []string{"policy1"},
+			},
+		})
+
+		saltedID, err := ts.SaltID(ctx, resp.Auth.ClientToken)
+		require.NoError(t, err)
+		require.NoError(t, ts.idView(namespace.RootNamespace).Delete(ctx, saltedID))
+
+		saltedAccessor, err := ts.SaltID(ctx, resp.Auth.Accessor)
+		require.NoError(t, err)
+		saltedAccessors = append(saltedAccessors, saltedAccessor)
+	}
+	sort.Strings(saltedAccessors)
+	return saltedAccessors
+}
+
+func testTokenStoreAccessors(t *testing.T, ts *TokenStore) []string {
+	t.Helper()
+	keys, err := ts.accessorView(namespace.RootNamespace).List(namespace.RootContext(nil), "")
+	require.NoError(t, err)
+	return keys
+}
+
+func TestTokenStore_TidyConfig(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	resp, err := ts.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "tidy/config",
+	})
+	require.NoError(t, err)
+	require.Equal(t, defaultTokenTidyBatchSize, resp.Data["batch_size"])
+	require.NotContains(t, resp.Data, "next_tidy")
+
+	resp, err = ts.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "tidy/config",
+		Data: map[string]interface{}{
+			"tidy_schedule": "0 3 * * *",
+			"tidy_period":   "1h",
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	resp, err = ts.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "tidy/config",
+		Data: map[string]interface{}{
+			"tidy_schedule": "not a schedule",
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+
+	resp, err = ts.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "tidy/config",
+		Data: map[string]interface{}{
+			"tidy_schedule": "0 3 * * *",
+			"batch_size":    50,
+			"batch_pause":   "2s",
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	resp, err = ts.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "tidy/config",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "0 3 * * *", resp.Data["tidy_schedule"])
+	require.Equal(t, 50, resp.Data["batch_size"])
+	require.Equal(t, int64(2), resp.Data["batch_pause"])
+	nextTidy, err := time.Parse(time.RFC3339, resp.Data["next_tidy"].(string))
+	require.NoError(t, err)
+	require.Equal(t, 3, nextTidy.Local().Hour())
+
+	// Setting a period replaces the schedule
+	_, err = ts.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "tidy/config",
+		Data: map[string]interface{}{
+			"tidy_period": "1h",
+		},
+	})
+	require.NoError(t, err)
+	config, err := ts.tidyConfig(ctx)
+	require.NoError(t, err)
+	require.Empty(t, config.Schedule)
+	require.Equal(t, time.Hour, config.Period)
+	require.Equal(t, 50, config.BatchSize)
+}
+
+// TestTokenStore_TidyResume verifies that a tidy resumes from the checkpoint
+// of an interrupted tidy, and reports its progress through the status
+// endpoint.
+func TestTokenStore_TidyResume(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	leaked := testTokenStoreLeakAccessors(t, ts, root, 10)
+
+	_, err := ts.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "tidy/config",
+		Data:      map[string]interface{}{"batch_size": 3},
+	})
+	require.NoError(t, err)
+
+	// Simulate a tidy interrupted after checking the first half of the
+	// leaked accessors
+	checkpoint := leaked[4]
+	require.NoError(t, ts.persistTidyStatus(ctx, namespace.RootNamespace, &tokenTidyStatus{
+		Run: &tokenTidyRun{
+			StartTime: time.Now().Add(-time.Hour),
+			Phase:     tokenTidyPhaseAccessor,
+			After:     checkpoint,
+		},
+	}))
+
+	resp, err := ts.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "tidy/status",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "interrupted", resp.Data["state"])
+	require.Equal(t, checkpoint, resp.Data["current_run"].(map[string]interface{})["checkpoint"])
+
+	require.True(t, atomic.CompareAndSwapUint32(ts.tidyLock, 0, 1))
+	require.NoError(t, ts.tidy(ctx, namespace.RootNamespace))
+	atomic.StoreUint32(ts.tidyLock, 0)
+
+	// Only the accessors after the checkpoint were tidied
+	accessors := testTokenStoreAccessors(t, ts)
+	for _, saltedAccessor := range leaked[:5] {
+		require.Contains(t, accessors, saltedAccessor)
+	}
+	for _, saltedAccessor := range leaked[5:] {
+		require.NotContains(t, accessors, saltedAccessor)
+	}
+
+	resp, err = ts.HandleRequest(ctx, &logical.Request{
+		Operation: logical.ReadOperation,
+		Path:      "tidy/status",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "idle", resp.Data["state"])
+	require.NotContains(t, resp.Data, "current_run")
+	lastRun := resp.Data["last_run"].(map[string]interface{})
+	require.Equal(t, true, lastRun["resumed"])
+	require.Equal(t, true, lastRun["cubbyholes_skipped"])
+	require.Equal(t, int64(5), lastRun["accessors_invalid_token_deleted"])
+	require.Contains(t, lastRun, "end_time")
+
+	// A new tidy starts from the beginning and tidies the remaining ones
+	require.True(t, atomic.CompareAndSwapUint32(ts.tidyLock, 0, 1))
+	require.NoError(t, ts.tidy(ctx, namespace.RootNamespace))
+	atomic.StoreUint32(ts.tidyLock, 0)
+
+	require.Len(t, testTokenStoreAccessors(t, ts), 1)
+	status, err := ts.tidyStatus(ctx, namespace.RootNamespace)
+	require.NoError(t, err)
+	require.False(t, status.LastRun.Resumed)
+	require.False(t, status.LastRun.CubbyholesSkipped)
+	require.Equal(t, int64(5), status.LastRun.Counts.AccessorsInvalidTokenDeleted)
+}
+
+// TestTokenStore_TidySchedule verifies that the token store is tidied once a
+// scheduled tidy is due, and that the next tidy is scheduled.
+func TestTokenStore_TidySchedule(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	testTokenStoreLeakAccessors(t, ts, root, 3)
+
+	// Nothing is tidied while scheduled tidies are disabled
+	require.NoError(t, c.runTokenTidy(ctx, time.Now().Add(24*time.Hour)))
+	require.Len(t, testTokenStoreAccessors(t, ts), 4)
+
+	_, err := ts.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "tidy/config",
+		Data:      map[string]interface{}{"tidy_period": "1h"},
+	})
+	require.NoError(t, err)
+	config, err := ts.tidyConfig(ctx)
+	require.NoError(t, err)
+	scheduled := config.NextTidy
+
+	// Nothing is tidied before the tidy is due
+	require.NoError(t, c.runTokenTidy(ctx, time.Now()))
+	require.Len(t, testTokenStoreAccessors(t, ts), 4)
+
+	require.NoError(t, c.runTokenTidy(ctx, scheduled))
+	require.Len(t, testTokenStoreAccessors(t, ts), 1)
+
+	config, err = ts.tidyConfig(ctx)
+	require.NoError(t, err)
+	require.True(t, config.NextTidy.After(scheduled))
+
+	status, err := ts.tidyStatus(ctx, namespace.RootNamespace)
+	require.NoError(t, err)
+	require.Nil(t, status.Run)
+	require.Equal(t, int64(3), status.LastRun.Counts.AccessorsInvalidTokenDeleted)
+}
+
+// TestTokenStore_TidyRevokeUnused verifies that a tidy revokes the service
+// tokens which were not used for longer than revoke_unused_after, orphaning
+// their children.
+func TestTokenStore_TidyRevokeUnused(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	created := time.Now().Add(-48 * time.Hour).Unix()
+	for _, id := range []string{"unused", "used", "root-policy"} {
+		te := &logical.TokenEntry{
+			ID:           id,
+			Path:         "auth/token/create",
+			Policies:     []string{"default"},
+			CreationTime: created,
+			TTL:          72 * time.Hour,
+		}
+		if id == "root-policy" {
+			te.Policies = []string{"root"}
+		}
+		testMakeTokenDirectly(t, ts, te)
+	}
+	testMakeTokenDirectly(t, ts, &logical.TokenEntry{
+		ID:           "child",
+		Parent:       "unused",
+		Path:         "auth/token/create",
+		Policies:     []string{"default"},
+		CreationTime: created,
+		TTL:          72 * time.Hour,
+	})
+	testMakeTokenDirectly(t, ts, &logical.TokenEntry{
+		ID:       "recent",
+		Path:     "auth/token/create",
+		Policies: []string{"default"},
+		TTL:      72 * time.Hour,
+	})
+
+	for _, id := range []string{"used", "child"} {
+		_, err := c.HandleRequest(ctx, &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        "auth/token/lookup-self",
+			ClientToken: id,
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, ts.flushUsage(ctx))
+
+	_, err := ts.HandleRequest(ctx, &logical.Request{
+		Operation: logical.UpdateOperation,
+		Path:      "tidy/config",
+		Data:      map[string]interface{}{"revoke_unused_after": "24h"},
+	})
+	require.NoError(t, err)
+
+	require.True(t, atomic.CompareAndSwapUint32(ts.tidyLock, 0, 1))
+	require.NoError(t, ts.tidy(ctx, namespace.RootNamespace))
+	atomic.StoreUint32(ts.tidyLock, 0)
+
+	for id, valid := range map[string]bool{
+		"unused":      false,
+		"used":        true,
+		"root-policy": true,
+		"child":       true,
+		"recent":      true,
+		root:          true,
+	} {
+		te, err := ts.Lookup(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, valid, te != nil, id)
+	}
+
+	child, err := ts.Lookup(ctx, "child")
+	require.NoError(t, err)
+	require.Empty(t, child.Parent)
+
+	status, err := ts.tidyStatus(ctx, namespace.RootNamespace)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), status.LastRun.Counts.UnusedTokensRevoked)
+}
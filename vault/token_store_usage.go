@@ -0,0 +1,260 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// usagePrefix is the prefix used to store the usage of tokens, indexed by
+// their salted accessor
+const usagePrefix = "usage/"
+
+// tokenUsageFlushInterval is how often the usage of tokens recorded in memory
+// is written to storage.
+var tokenUsageFlushInterval = 30 * time.Second
+
+// tokenUsage is the approximate usage of a token. Usage is recorded in memory
+// and written to storage in batches, so usage recorded since the last flush
+// is lost if the active node stops abruptly.
+type tokenUsage struct {
+	LastUsed     time.Time `json:"last_used"`
+	RequestCount uint64    `json:"request_count"`
+}
+
+func (u *tokenUsage) merge(other *tokenUsage) {
+	if other.LastUsed.After(u.LastUsed) {
+		u.LastUsed = other.LastUsed
+	}
+	u.RequestCount += other.RequestCount
+}
+
+type tokenUsageKey struct {
+	namespaceID string
+	accessor    string
+}
+
+// tokenUsageTracker accumulates the usage of tokens in memory, so that
+// requests do not write to storage.
+type tokenUsageTracker struct {
+	lock    sync.Mutex
+	pending map[tokenUsageKey]*tokenUsage
+}
+
+func newTokenUsageTracker() *tokenUsageTracker {
+	return &tokenUsageTracker{
+		pending: make(map[tokenUsageKey]*tokenUsage),
+	}
+}
+
+func (t *tokenUsageTracker) record(key tokenUsageKey, now time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	usage, ok := t.pending[key]
+	if !ok {
+		usage = new(tokenUsage)
+		t.pending[key] = usage
+	}
+	usage.merge(&tokenUsage{LastUsed: now, RequestCount: 1})
+}
+
+// get returns a copy of the usage recorded since the last flush, if any.
+func (t *tokenUsageTracker) get(key tokenUsageKey) *tokenUsage {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	usage, ok := t.pending[key]
+	if !ok {
+		return nil
+	}
+	copied := *usage
+	return &copied
+}
+
+func (t *tokenUsageTracker) forget(key tokenUsageKey) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	delete(t.pending, key)
+}
+
+// drain returns the usage recorded since the last flush, and resets it.
+func (t *tokenUsageTracker) drain() map[tokenUsageKey]*tokenUsage {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	pending := t.pending
+	t.pending = make(map[tokenUsageKey]*tokenUsage)
+	return pending
+}
+
+// recordUsage records a request made with the token. Batch tokens have no
+// accessor, and their usage is not tracked.
+func (ts *TokenStore) recordUsage(te *logical.TokenEntry) {
+	if te.Accessor == "" || te.Type == logical.TokenTypeBatch {
+		return
+	}
+	ts.usage.record(tokenUsageKey{namespaceID: te.NamespaceID, accessor: te.Accessor}, time.Now())
+}
+
+// tokenUsage returns the usage of the token of the given namespace, combining
+// the usage in storage with the usage recorded since the last flush. It
+// returns nil if no usage was recorded.
+func (ts *TokenStore) tokenUsage(ctx context.Context, tokenNS *namespace.Namespace, te *logical.TokenEntry) (*tokenUsage, error) {
+	if te.Accessor == "" {
+		return nil, nil
+	}
+
+	stored, err := ts.storedTokenUsage(namespace.ContextWithNamespace(ctx, tokenNS), tokenNS, te.Accessor)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := ts.usage.get(tokenUsageKey{namespaceID: tokenNS.ID, accessor: te.Accessor})
+	switch {
+	case stored == nil:
+		return pending, nil
+	case pending != nil:
+		stored.merge(pending)
+	}
+	return stored, nil
+}
+
+func (ts *TokenStore) storedTokenUsage(ctx context.Context, ns *namespace.Namespace, accessor string) (*tokenUsage, error) {
+	saltedAccessor, err := ts.SaltID(ctx, accessor)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := ts.usageView(ns).Get(ctx, saltedAccessor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token usage: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	usage := new(tokenUsage)
+	if err := jsonutil.DecodeJSON(entry.Value, usage); err != nil {
+		return nil, fmt.Errorf("failed to decode token usage: %w", err)
+	}
+	return usage, nil
+}
+
+// flushUsage writes the usage recorded since the last flush to storage. The
+// usage of tokens revoked since it was recorded is dropped.
+func (ts *TokenStore) flushUsage(ctx context.Context) error {
+	var result *multierror.Error
+	for key, pending := range ts.usage.drain() {
+		if err := ts.flushTokenUsage(ctx, key, pending); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+func (ts *TokenStore) flushTokenUsage(ctx context.Context, key tokenUsageKey, pending *tokenUsage) error {
+	tokenNS, err := NamespaceByID(ctx, key.namespaceID, ts.core)
+	if err != nil {
+		return err
+	}
+	if tokenNS == nil {
+		return nil
+	}
+	ctx = namespace.ContextWithNamespace(ctx, tokenNS)
+
+	saltedAccessor, err := ts.SaltID(ctx, key.accessor)
+	if err != nil {
+		return err
+	}
+
+	accessor, err := ts.accessorView(tokenNS).Get(ctx, saltedAccessor)
+	if err != nil {
+		return fmt.Errorf("failed to read accessor index: %w", err)
+	}
+	if accessor == nil {
+		return nil
+	}
+
+	usage, err := ts.storedTokenUsage(ctx, tokenNS, key.accessor)
+	if err != nil {
+		return err
+	}
+	if usage == nil {
+		usage = new(tokenUsage)
+	}
+	usage.merge(pending)
+
+	entry, err := logical.StorageEntryJSON(saltedAccessor, usage)
+	if err != nil {
+		return fmt.Errorf("failed to encode token usage: %w", err)
+	}
+	if err := ts.usageView(tokenNS).Put(ctx, entry); err != nil {
+		return fmt.Errorf("failed to persist token usage: %w", err)
+	}
+	return nil
+}
+
+// deleteUsage deletes the usage of a revoked token.
+func (ts *TokenStore) deleteUsage(ctx context.Context, ns *namespace.Namespace, accessor, saltedAccessor string) error {
+	ts.usage.forget(tokenUsageKey{namespaceID: ns.ID, accessor: accessor})
+	if err := ts.usageView(ns).Delete(ctx, saltedAccessor); err != nil {
+		return fmt.Errorf("failed to delete token usage: %w", err)
+	}
+	return nil
+}
+
+// startTokenUsageFlush runs a process which, every tokenUsageFlushInterval,
+// writes the usage of tokens recorded in memory to storage, until
+// stopTokenUsageFlush is called.
+func (c *Core) startTokenUsageFlush() {
+	if c.tokenUsageFlushCancel != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, c.tokenUsageFlushCancel = context.WithCancel(namespace.RootContext(c.activeContext))
+
+	go func() {
+		ticker := time.NewTicker(tokenUsageFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if ts := c.tokenStore; ts != nil {
+					if err := ts.flushUsage(ctx); err != nil {
+						c.logger.Error("failed to write token usage", "error", err)
+					}
+				}
+			}
+		}
+	}()
+}
+
+// stopTokenUsageFlush stops the periodic writes of token usage, and writes
+// the usage recorded since the last one.
+func (c *Core) stopTokenUsageFlush() {
+	if c.tokenUsageFlushCancel == nil {
+		return
+	}
+	c.tokenUsageFlushCancel()
+	c.tokenUsageFlushCancel = nil
+
+	if ts := c.tokenStore; ts != nil {
+		if err := ts.flushUsage(namespace.RootContext(context.Background())); err != nil {
+			c.logger.Error("failed to write token usage", "error", err)
+		}
+	}
+}
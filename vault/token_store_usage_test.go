@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTokenStore_Usage verifies that the requests made with a token are
+// counted, written to storage in batches, and deleted with the token.
+func TestTokenStore_Usage(t *testing.T) {
+	c, _, root := TestCoreUnsealed(t)
+	ts := c.tokenStore
+	ctx := namespace.RootContext(nil)
+
+	testMakeServiceTokenViaBackend(t, ts, root, "client", "1h", []string{"default"})
+
+	lookup := func() map[string]interface{} {
+		t.Helper()
+		resp, err := ts.HandleRequest(ctx, &logical.Request{
+			Operation:   logical.UpdateOperation,
+			Path:        "lookup",
+			ClientToken: root,
+			Data:        map[string]interface{}{"token": "client"},
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError())
+		return resp.Data
+	}
+
+	// No usage is reported for a token which was never used
+	data := lookup()
+	require.NotContains(t, data, "last_used_time")
+	require.NotContains(t, data, "request_count")
+
+	use := func() {
+		t.Helper()
+		resp, err := c.HandleRequest(ctx, &logical.Request{
+			Operation:   logical.ReadOperation,
+			Path:        "auth/token/lookup-self",
+			ClientToken: "client",
+		})
+		require.NoError(t, err)
+		require.False(t, resp.IsError())
+	}
+
+	use()
+	use()
+	data = lookup()
+	require.Equal(t, uint64(2), data["request_count"])
+	require.NotZero(t, data["last_used_time"])
+	require.NotContains(t, data, "last_used")
+
+	// The usage recorded since the last flush is combined with the stored
+	// usage
+	require.NoError(t, ts.flushUsage(ctx))
+	require.Empty(t, ts.usage.drain())
+	use()
+	require.Equal(t, uint64(3), lookup()["request_count"])
+	require.NoError(t, ts.flushUsage(ctx))
+
+	te, err := ts.Lookup(ctx, "client")
+	require.NoError(t, err)
+	stored, err := ts.storedTokenUsage(ctx, namespace.RootNamespace, te.Accessor)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), stored.RequestCount)
+
+	// Revoking the token deletes its usage, and usage recorded afterwards is
+	// not written
+	require.NoError(t, ts.revokeOrphan(ctx, "client"))
+	stored, err = ts.storedTokenUsage(ctx, namespace.RootNamespace, te.Accessor)
+	require.NoError(t, err)
+	require.Nil(t, stored)
+
+	ts.recordUsage(te)
+	require.NoError(t, ts.flushUsage(ctx))
+	stored, err = ts.storedTokenUsage(ctx, namespace.RootNamespace, te.Accessor)
+	require.NoError(t, err)
+	require.Nil(t, stored)
+}
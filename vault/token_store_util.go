@@ -25,6 +25,10 @@ func (ts *TokenStore) parentView(ns *namespace.Namespace) *BarrierView {
 	return ts.parentBarrierView
 }
 
+func (ts *TokenStore) usageView(ns *namespace.Namespace) *BarrierView {
+	return ts.usageBarrierView
+}
+
 func (ts *TokenStore) rolesView(ns *namespace.Namespace) *BarrierView {
 	return ts.rolesBarrierView
 }
@@ -15,6 +15,8 @@ import (
 	"github.com/armon/go-metrics"
 	"github.com/go-jose/go-jose/v3"
 	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
+	"github.com/hashicorp/go-sockaddr"
 	"github.com/hashicorp/vault/helper/metricsutil"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/sdk/helper/certutil"
@@ -115,6 +117,15 @@ DONELISTHANDLING:
 	var err error
 	sealWrap := resp.WrapInfo.SealWrap
 
+	if notBefore := resp.WrapInfo.NotBefore; !notBefore.IsZero() && !notBefore.Before(time.Now().Add(resp.WrapInfo.TTL)) {
+		return logical.ErrorResponse("wrapping token not-before time must be before the token expires"), logical.ErrInvalidRequest
+	}
+
+	boundCIDRs, err := parseutil.ParseAddrs(resp.WrapInfo.BoundCIDRs)
+	if err != nil {
+		return logical.ErrorResponse(fmt.Sprintf("error parsing wrapping token bound CIDRs: %s", err)), logical.ErrInvalidRequest
+	}
+
 	var ns *namespace.Namespace
 	// If we are creating a JWT wrapping token we always want them to live in
 	// the root namespace. These are only used for replication and plugin setup.
@@ -142,6 +153,7 @@ DONELISTHANDLING:
 		NumUses:        1,
 		ExplicitMaxTTL: resp.WrapInfo.TTL,
 		NamespaceID:    ns.ID,
+		BoundCIDRs:     boundCIDRs,
 	}
 
 	if err := c.CreateToken(ctx, &te); err != nil {
@@ -305,6 +317,12 @@ DONELISTHANDLING:
 	} else {
 		cubbyReq.Data["creation_path"] = resp.WrapInfo.CreationPath
 	}
+	if len(resp.WrapInfo.BoundCIDRs) > 0 {
+		cubbyReq.Data["bound_cidrs"] = resp.WrapInfo.BoundCIDRs
+	}
+	if !resp.WrapInfo.NotBefore.IsZero() {
+		cubbyReq.Data["not_before"] = resp.WrapInfo.NotBefore
+	}
 	cubbyResp, err = c.router.Route(ctx, cubbyReq)
 	if err != nil {
 		// Revoke since it's not yet being tracked for expiration
@@ -453,6 +471,14 @@ func (c *Core) validateWrappingToken(ctx context.Context, req *logical.Request)
 		return false, nil
 	}
 
+	// Lookups are allowed regardless of the unwrapping constraints, so that
+	// the token can be verified before it is handed off
+	if req.Path == "sys/wrapping/unwrap" || req.Path == "sys/wrapping/rewrap" {
+		if err := c.checkWrappingConstraints(ctx, req, te); err != nil {
+			return false, err
+		}
+	}
+
 	if !thirdParty {
 		req.ClientTokenAccessor = te.Accessor
 		req.ClientTokenRemainingUses = te.NumUses
@@ -462,6 +488,81 @@ func (c *Core) validateWrappingToken(ctx context.Context, req *logical.Request)
 	return true, nil
 }
 
+// checkWrappingConstraints returns an error if the bound CIDRs or the
+// not-before time set when the response wrapping token was created do not
+// allow the request to unwrap it. It is checked before the token is used, so
+// that a rejected request does not consume the token.
+func (c *Core) checkWrappingConstraints(ctx context.Context, req *logical.Request, te *logical.TokenEntry) error {
+	if te.Policies[0] != responseWrappingPolicyName {
+		return nil
+	}
+
+	cubbyReq := &logical.Request{
+		Operation:   logical.ReadOperation,
+		Path:        "cubbyhole/wrapinfo",
+		ClientToken: te.ID,
+	}
+	cubbyReq.SetTokenEntry(te)
+	cubbyResp, err := c.router.Route(ctx, cubbyReq)
+	if err != nil {
+		return fmt.Errorf("error looking up wrapping information: %w", err)
+	}
+	if cubbyResp == nil || cubbyResp.IsError() {
+		return nil
+	}
+
+	boundCIDRs, notBefore, err := wrappingConstraints(cubbyResp.Data)
+	if err != nil {
+		return err
+	}
+
+	if !notBefore.IsZero() && time.Now().Before(notBefore) {
+		return fmt.Errorf("wrapping token cannot be unwrapped before %s", notBefore.Format(time.RFC3339))
+	}
+
+	if len(boundCIDRs) > 0 {
+		if req.Connection == nil {
+			return errors.New("wrapping token is bound to CIDRs and the remote address is unknown")
+		}
+		remoteSockAddr, err := sockaddr.NewSockAddr(req.Connection.RemoteAddr)
+		if err != nil {
+			return errors.New("wrapping token is bound to CIDRs and the remote address could not be parsed")
+		}
+		for _, cidr := range boundCIDRs {
+			if cidr.Contains(remoteSockAddr) {
+				return nil
+			}
+		}
+		return errors.New("wrapping token cannot be unwrapped from this address")
+	}
+
+	return nil
+}
+
+// wrappingConstraints returns the bound CIDRs and not-before time stored in
+// the wrapping information of a response wrapping token.
+func wrappingConstraints(wrapInfo map[string]interface{}) ([]*sockaddr.SockAddrMarshaler, time.Time, error) {
+	var notBefore time.Time
+	if raw, ok := wrapInfo["not_before"].(string); ok && raw != "" {
+		var err error
+		notBefore, err = time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("error reading not_before value from wrapping information: %w", err)
+		}
+	}
+
+	var boundCIDRs []*sockaddr.SockAddrMarshaler
+	if raw := wrapInfo["bound_cidrs"]; raw != nil {
+		var err error
+		boundCIDRs, err = parseutil.ParseAddrs(raw)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("error reading bound_cidrs value from wrapping information: %w", err)
+		}
+	}
+
+	return boundCIDRs, notBefore, nil
+}
+
 func IsWrappingToken(te *logical.TokenEntry) bool {
 	if len(te.Policies) != 1 {
 		return false